@@ -89,6 +89,12 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// ManipulationLog request
+	ManipulationLog(ctx context.Context, params *ManipulationLogParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// AuditLog request
+	AuditLog(ctx context.Context, client string, params *AuditLogParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// DisableBlocking request
 	DisableBlocking(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -101,13 +107,74 @@ type ClientInterface interface {
 	// CacheFlush request
 	CacheFlush(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// ConfigDryRunWithBody request with any body
+	ConfigDryRunWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	ConfigDryRun(ctx context.Context, body ConfigDryRunJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// ListRefresh request
-	ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+	ListRefresh(ctx context.Context, params *ListRefreshParams, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// QueryWithBody request with any body
-	QueryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+	QueryWithBody(ctx context.Context, params *QueryParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	Query(ctx context.Context, params *QueryParams, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// QueryLogFlush request
+	QueryLogFlush(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// QueryLogRotate request
+	QueryLogRotate(ctx context.Context, params *QueryLogRotateParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// Quotas request
+	Quotas(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// QuotaReset request
+	QuotaReset(ctx context.Context, params *QuotaResetParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// Reload request
+	Reload(ctx context.Context, subsystem ReloadParamsSubsystem, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ResolverChain request
+	ResolverChain(ctx context.Context, params *ResolverChainParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// StateExport request
+	StateExport(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// StateImportWithBody request with any body
+	StateImportWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	StateImport(ctx context.Context, body StateImportJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ClientStats request
+	ClientStats(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UpstreamStats request
+	UpstreamStats(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) ManipulationLog(ctx context.Context, params *ManipulationLogParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewManipulationLogRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	Query(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+func (c *Client) AuditLog(ctx context.Context, client string, params *AuditLogParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAuditLogRequest(c.Server, client, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
 func (c *Client) DisableBlocking(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -158,8 +225,8 @@ func (c *Client) CacheFlush(ctx context.Context, reqEditors ...RequestEditorFn)
 	return c.Client.Do(req)
 }
 
-func (c *Client) ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewListRefreshRequest(c.Server)
+func (c *Client) ConfigDryRunWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewConfigDryRunRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
@@ -170,8 +237,8 @@ func (c *Client) ListRefresh(ctx context.Context, reqEditors ...RequestEditorFn)
 	return c.Client.Do(req)
 }
 
-func (c *Client) QueryWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewQueryRequestWithBody(c.Server, contentType, body)
+func (c *Client) ConfigDryRun(ctx context.Context, body ConfigDryRunJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewConfigDryRunRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
@@ -182,8 +249,8 @@ func (c *Client) QueryWithBody(ctx context.Context, contentType string, body io.
 	return c.Client.Do(req)
 }
 
-func (c *Client) Query(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewQueryRequest(c.Server, body)
+func (c *Client) ListRefresh(ctx context.Context, params *ListRefreshParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListRefreshRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
@@ -194,154 +261,164 @@ func (c *Client) Query(ctx context.Context, body QueryJSONRequestBody, reqEditor
 	return c.Client.Do(req)
 }
 
-// NewDisableBlockingRequest generates requests for DisableBlocking
-func NewDisableBlockingRequest(server string, params *DisableBlockingParams) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) QueryWithBody(ctx context.Context, params *QueryParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryRequestWithBody(c.Server, params, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/disable")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) Query(ctx context.Context, params *QueryParams, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryRequest(c.Server, params, body)
 	if err != nil {
 		return nil, err
 	}
-
-	if params != nil {
-		queryValues := queryURL.Query()
-
-		if params.Duration != nil {
-
-			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "duration", runtime.ParamLocationQuery, *params.Duration); err != nil {
-				return nil, err
-			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
-				return nil, err
-			} else {
-				for k, v := range parsed {
-					for _, v2 := range v {
-						queryValues.Add(k, v2)
-					}
-				}
-			}
-
-		}
-
-		if params.Groups != nil {
-
-			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "groups", runtime.ParamLocationQuery, *params.Groups); err != nil {
-				return nil, err
-			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
-				return nil, err
-			} else {
-				for k, v := range parsed {
-					for _, v2 := range v {
-						queryValues.Add(k, v2)
-					}
-				}
-			}
-
-		}
-
-		queryURL.RawQuery = queryValues.Encode()
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+func (c *Client) QueryLogFlush(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryLogFlushRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewEnableBlockingRequest generates requests for EnableBlocking
-func NewEnableBlockingRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) QueryLogRotate(ctx context.Context, params *QueryLogRotateParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQueryLogRotateRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/enable")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) Quotas(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQuotasRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+func (c *Client) QuotaReset(ctx context.Context, params *QuotaResetParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewQuotaResetRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewBlockingStatusRequest generates requests for BlockingStatus
-func NewBlockingStatusRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) Reload(ctx context.Context, subsystem ReloadParamsSubsystem, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewReloadRequest(c.Server, subsystem)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/blocking/status")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) ResolverChain(ctx context.Context, params *ResolverChainParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewResolverChainRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+func (c *Client) StateExport(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStateExportRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
-
-	return req, nil
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewCacheFlushRequest generates requests for CacheFlush
-func NewCacheFlushRequest(server string) (*http.Request, error) {
-	var err error
-
-	serverURL, err := url.Parse(server)
+func (c *Client) StateImportWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStateImportRequestWithBody(c.Server, contentType, body)
 	if err != nil {
 		return nil, err
 	}
-
-	operationPath := fmt.Sprintf("/cache/flush")
-	if operationPath[0] == '/' {
-		operationPath = "." + operationPath
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
 	}
+	return c.Client.Do(req)
+}
 
-	queryURL, err := serverURL.Parse(operationPath)
+func (c *Client) StateImport(ctx context.Context, body StateImportJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStateImportRequest(c.Server, body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	req, err := http.NewRequest("POST", queryURL.String(), nil)
+func (c *Client) ClientStats(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewClientStatsRequest(c.Server, params)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
 
-	return req, nil
+func (c *Client) UpstreamStats(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUpstreamStatsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewListRefreshRequest generates requests for ListRefresh
-func NewListRefreshRequest(server string) (*http.Request, error) {
+// NewManipulationLogRequest generates requests for ManipulationLog
+func NewManipulationLogRequest(server string, params *ManipulationLogParams) (*http.Request, error) {
 	var err error
 
 	serverURL, err := url.Parse(server)
@@ -349,7 +426,7 @@ func NewListRefreshRequest(server string) (*http.Request, error) {
 		return nil, err
 	}
 
-	operationPath := fmt.Sprintf("/lists/refresh")
+	operationPath := fmt.Sprintf("/audit/manipulations")
 	if operationPath[0] == '/' {
 		operationPath = "." + operationPath
 	}
@@ -359,7 +436,29 @@ func NewListRefreshRequest(server string) (*http.Request, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Client != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "client", runtime.ParamLocationQuery, *params.Client); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -367,27 +466,23 @@ func NewListRefreshRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
-// NewQueryRequest calls the generic Query builder with application/json body
-func NewQueryRequest(server string, body QueryJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
+// NewAuditLogRequest generates requests for AuditLog
+func NewAuditLogRequest(server string, client string, params *AuditLogParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "client", runtime.ParamLocationPath, client)
 	if err != nil {
 		return nil, err
 	}
-	bodyReader = bytes.NewReader(buf)
-	return NewQueryRequestWithBody(server, "application/json", bodyReader)
-}
-
-// NewQueryRequestWithBody generates requests for Query with any type of body
-func NewQueryRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
-	var err error
 
 	serverURL, err := url.Parse(server)
 	if err != nil {
 		return nil, err
 	}
 
-	operationPath := fmt.Sprintf("/query")
+	operationPath := fmt.Sprintf("/audit/%s", pathParam0)
 	if operationPath[0] == '/' {
 		operationPath = "." + operationPath
 	}
@@ -397,279 +492,1715 @@ func NewQueryRequestWithBody(server string, contentType string, body io.Reader)
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Domain != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "domain", runtime.ParamLocationQuery, *params.Domain); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", contentType)
-
 	return req, nil
 }
 
-func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
-	for _, r := range c.RequestEditors {
-		if err := r(ctx, req); err != nil {
-			return err
+// NewDisableBlockingRequest generates requests for DisableBlocking
+func NewDisableBlockingRequest(server string, params *DisableBlockingParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/disable")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Duration != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "duration", runtime.ParamLocationQuery, *params.Duration); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Groups != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "groups", runtime.ParamLocationQuery, *params.Groups); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewEnableBlockingRequest generates requests for EnableBlocking
+func NewEnableBlockingRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/enable")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewBlockingStatusRequest generates requests for BlockingStatus
+func NewBlockingStatusRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/blocking/status")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCacheFlushRequest generates requests for CacheFlush
+func NewCacheFlushRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/cache/flush")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewConfigDryRunRequest calls the generic ConfigDryRun builder with application/json body
+func NewConfigDryRunRequest(server string, body ConfigDryRunJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewConfigDryRunRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewConfigDryRunRequestWithBody generates requests for ConfigDryRun with any type of body
+func NewConfigDryRunRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/config/dryrun")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListRefreshRequest generates requests for ListRefresh
+func NewListRefreshRequest(server string, params *ListRefreshParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/lists/refresh")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Group != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "group", runtime.ParamLocationQuery, *params.Group); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
 		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewQueryRequest calls the generic Query builder with application/json body
+func NewQueryRequest(server string, params *QueryParams, body QueryJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewQueryRequestWithBody(server, params, "application/json", bodyReader)
+}
+
+// NewQueryRequestWithBody generates requests for Query with any type of body
+func NewQueryRequestWithBody(server string, params *QueryParams, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/query")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Debug != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "debug", runtime.ParamLocationQuery, *params.Debug); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewQueryLogFlushRequest generates requests for QueryLogFlush
+func NewQueryLogFlushRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/querylog/flush")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewQueryLogRotateRequest generates requests for QueryLogRotate
+func NewQueryLogRotateRequest(server string, params *QueryLogRotateParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/querylog/rotate")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Gzip != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "gzip", runtime.ParamLocationQuery, *params.Gzip); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewQuotasRequest generates requests for Quotas
+func NewQuotasRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/quotas")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewQuotaResetRequest generates requests for QuotaReset
+func NewQuotaResetRequest(server string, params *QuotaResetParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/quotas/reset")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Category != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "category", runtime.ParamLocationQuery, *params.Category); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Client != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "client", runtime.ParamLocationQuery, *params.Client); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewReloadRequest generates requests for Reload
+func NewReloadRequest(server string, subsystem ReloadParamsSubsystem) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "subsystem", runtime.ParamLocationPath, subsystem)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/reload/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewResolverChainRequest generates requests for ResolverChain
+func NewResolverChainRequest(server string, params *ResolverChainParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/resolvers/chain")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Format != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "format", runtime.ParamLocationQuery, *params.Format); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewStateExportRequest generates requests for StateExport
+func NewStateExportRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/state/export")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewStateImportRequest calls the generic StateImport builder with application/json body
+func NewStateImportRequest(server string, body StateImportJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewStateImportRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewStateImportRequestWithBody generates requests for StateImport with any type of body
+func NewStateImportRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/state/import")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewClientStatsRequest generates requests for ClientStats
+func NewClientStatsRequest(server string, params *ClientStatsParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/stats/clients")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Days != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "days", runtime.ParamLocationQuery, *params.Days); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUpstreamStatsRequest generates requests for UpstreamStats
+func NewUpstreamStatsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/upstreams/stats")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// ManipulationLogWithResponse request
+	ManipulationLogWithResponse(ctx context.Context, params *ManipulationLogParams, reqEditors ...RequestEditorFn) (*ManipulationLogResponse, error)
+
+	// AuditLogWithResponse request
+	AuditLogWithResponse(ctx context.Context, client string, params *AuditLogParams, reqEditors ...RequestEditorFn) (*AuditLogResponse, error)
+
+	// DisableBlockingWithResponse request
+	DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error)
+
+	// EnableBlockingWithResponse request
+	EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error)
+
+	// BlockingStatusWithResponse request
+	BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error)
+
+	// CacheFlushWithResponse request
+	CacheFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CacheFlushResponse, error)
+
+	// ConfigDryRunWithBodyWithResponse request with any body
+	ConfigDryRunWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*ConfigDryRunResponse, error)
+
+	ConfigDryRunWithResponse(ctx context.Context, body ConfigDryRunJSONRequestBody, reqEditors ...RequestEditorFn) (*ConfigDryRunResponse, error)
+
+	// ListRefreshWithResponse request
+	ListRefreshWithResponse(ctx context.Context, params *ListRefreshParams, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error)
+
+	// QueryWithBodyWithResponse request with any body
+	QueryWithBodyWithResponse(ctx context.Context, params *QueryParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+
+	QueryWithResponse(ctx context.Context, params *QueryParams, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+
+	// QueryLogFlushWithResponse request
+	QueryLogFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*QueryLogFlushResponse, error)
+
+	// QueryLogRotateWithResponse request
+	QueryLogRotateWithResponse(ctx context.Context, params *QueryLogRotateParams, reqEditors ...RequestEditorFn) (*QueryLogRotateResponse, error)
+
+	// QuotasWithResponse request
+	QuotasWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*QuotasResponse, error)
+
+	// QuotaResetWithResponse request
+	QuotaResetWithResponse(ctx context.Context, params *QuotaResetParams, reqEditors ...RequestEditorFn) (*QuotaResetResponse, error)
+
+	// ReloadWithResponse request
+	ReloadWithResponse(ctx context.Context, subsystem ReloadParamsSubsystem, reqEditors ...RequestEditorFn) (*ReloadResponse, error)
+
+	// ResolverChainWithResponse request
+	ResolverChainWithResponse(ctx context.Context, params *ResolverChainParams, reqEditors ...RequestEditorFn) (*ResolverChainResponse, error)
+
+	// StateExportWithResponse request
+	StateExportWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*StateExportResponse, error)
+
+	// StateImportWithBodyWithResponse request with any body
+	StateImportWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*StateImportResponse, error)
+
+	StateImportWithResponse(ctx context.Context, body StateImportJSONRequestBody, reqEditors ...RequestEditorFn) (*StateImportResponse, error)
+
+	// ClientStatsWithResponse request
+	ClientStatsWithResponse(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*ClientStatsResponse, error)
+
+	// UpstreamStatsWithResponse request
+	UpstreamStatsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*UpstreamStatsResponse, error)
+}
+
+type ManipulationLogResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiManipulationEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r ManipulationLogResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ManipulationLogResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type AuditLogResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiAuditEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r AuditLogResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r AuditLogResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DisableBlockingResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DisableBlockingResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DisableBlockingResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type EnableBlockingResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r EnableBlockingResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r EnableBlockingResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type BlockingStatusResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiBlockingStatus
+}
+
+// Status returns HTTPResponse.Status
+func (r BlockingStatusResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r BlockingStatusResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CacheFlushResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r CacheFlushResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CacheFlushResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ConfigDryRunResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiConfigDryRunResult
+}
+
+// Status returns HTTPResponse.Status
+func (r ConfigDryRunResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ConfigDryRunResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListRefreshResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r ListRefreshResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListRefreshResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QueryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiQueryResult
+}
+
+// Status returns HTTPResponse.Status
+func (r QueryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QueryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QueryLogFlushResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r QueryLogFlushResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QueryLogFlushResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QueryLogRotateResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r QueryLogRotateResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QueryLogRotateResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QuotasResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiQuotaUsage
+}
+
+// Status returns HTTPResponse.Status
+func (r QuotasResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QuotasResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type QuotaResetResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r QuotaResetResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r QuotaResetResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ReloadResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r ReloadResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ReloadResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ResolverChainResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiResolverNode
+}
+
+// Status returns HTTPResponse.Status
+func (r ResolverChainResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ResolverChainResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StateExportResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ApiRuntimeState
+}
+
+// Status returns HTTPResponse.Status
+func (r StateExportResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StateExportResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StateImportResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r StateImportResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StateImportResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ClientStatsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiClientStats
+}
+
+// Status returns HTTPResponse.Status
+func (r ClientStatsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ClientStatsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UpstreamStatsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ApiUpstreamStats
+}
+
+// Status returns HTTPResponse.Status
+func (r UpstreamStatsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
 	}
-	for _, r := range additionalEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UpstreamStatsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
 	}
-	return nil
+	return 0
 }
 
-// ClientWithResponses builds on ClientInterface to offer response payloads
-type ClientWithResponses struct {
-	ClientInterface
+// ManipulationLogWithResponse request returning *ManipulationLogResponse
+func (c *ClientWithResponses) ManipulationLogWithResponse(ctx context.Context, params *ManipulationLogParams, reqEditors ...RequestEditorFn) (*ManipulationLogResponse, error) {
+	rsp, err := c.ManipulationLog(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManipulationLogResponse(rsp)
 }
 
-// NewClientWithResponses creates a new ClientWithResponses, which wraps
-// Client with return type handling
-func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
-	client, err := NewClient(server, opts...)
+// AuditLogWithResponse request returning *AuditLogResponse
+func (c *ClientWithResponses) AuditLogWithResponse(ctx context.Context, client string, params *AuditLogParams, reqEditors ...RequestEditorFn) (*AuditLogResponse, error) {
+	rsp, err := c.AuditLog(ctx, client, params, reqEditors...)
 	if err != nil {
 		return nil, err
 	}
-	return &ClientWithResponses{client}, nil
+	return ParseAuditLogResponse(rsp)
 }
 
-// WithBaseURL overrides the baseURL.
-func WithBaseURL(baseURL string) ClientOption {
-	return func(c *Client) error {
-		newBaseURL, err := url.Parse(baseURL)
-		if err != nil {
-			return err
+// DisableBlockingWithResponse request returning *DisableBlockingResponse
+func (c *ClientWithResponses) DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error) {
+	rsp, err := c.DisableBlocking(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDisableBlockingResponse(rsp)
+}
+
+// EnableBlockingWithResponse request returning *EnableBlockingResponse
+func (c *ClientWithResponses) EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error) {
+	rsp, err := c.EnableBlocking(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnableBlockingResponse(rsp)
+}
+
+// BlockingStatusWithResponse request returning *BlockingStatusResponse
+func (c *ClientWithResponses) BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error) {
+	rsp, err := c.BlockingStatus(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlockingStatusResponse(rsp)
+}
+
+// CacheFlushWithResponse request returning *CacheFlushResponse
+func (c *ClientWithResponses) CacheFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CacheFlushResponse, error) {
+	rsp, err := c.CacheFlush(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCacheFlushResponse(rsp)
+}
+
+// ConfigDryRunWithBodyWithResponse request with arbitrary body returning *ConfigDryRunResponse
+func (c *ClientWithResponses) ConfigDryRunWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*ConfigDryRunResponse, error) {
+	rsp, err := c.ConfigDryRunWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigDryRunResponse(rsp)
+}
+
+func (c *ClientWithResponses) ConfigDryRunWithResponse(ctx context.Context, body ConfigDryRunJSONRequestBody, reqEditors ...RequestEditorFn) (*ConfigDryRunResponse, error) {
+	rsp, err := c.ConfigDryRun(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigDryRunResponse(rsp)
+}
+
+// ListRefreshWithResponse request returning *ListRefreshResponse
+func (c *ClientWithResponses) ListRefreshWithResponse(ctx context.Context, params *ListRefreshParams, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error) {
+	rsp, err := c.ListRefresh(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListRefreshResponse(rsp)
+}
+
+// QueryWithBodyWithResponse request with arbitrary body returning *QueryResponse
+func (c *ClientWithResponses) QueryWithBodyWithResponse(ctx context.Context, params *QueryParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
+	rsp, err := c.QueryWithBody(ctx, params, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryResponse(rsp)
+}
+
+func (c *ClientWithResponses) QueryWithResponse(ctx context.Context, params *QueryParams, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
+	rsp, err := c.Query(ctx, params, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryResponse(rsp)
+}
+
+// QueryLogFlushWithResponse request returning *QueryLogFlushResponse
+func (c *ClientWithResponses) QueryLogFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*QueryLogFlushResponse, error) {
+	rsp, err := c.QueryLogFlush(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryLogFlushResponse(rsp)
+}
+
+// QueryLogRotateWithResponse request returning *QueryLogRotateResponse
+func (c *ClientWithResponses) QueryLogRotateWithResponse(ctx context.Context, params *QueryLogRotateParams, reqEditors ...RequestEditorFn) (*QueryLogRotateResponse, error) {
+	rsp, err := c.QueryLogRotate(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQueryLogRotateResponse(rsp)
+}
+
+// QuotasWithResponse request returning *QuotasResponse
+func (c *ClientWithResponses) QuotasWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*QuotasResponse, error) {
+	rsp, err := c.Quotas(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuotasResponse(rsp)
+}
+
+// QuotaResetWithResponse request returning *QuotaResetResponse
+func (c *ClientWithResponses) QuotaResetWithResponse(ctx context.Context, params *QuotaResetParams, reqEditors ...RequestEditorFn) (*QuotaResetResponse, error) {
+	rsp, err := c.QuotaReset(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuotaResetResponse(rsp)
+}
+
+// ReloadWithResponse request returning *ReloadResponse
+func (c *ClientWithResponses) ReloadWithResponse(ctx context.Context, subsystem ReloadParamsSubsystem, reqEditors ...RequestEditorFn) (*ReloadResponse, error) {
+	rsp, err := c.Reload(ctx, subsystem, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReloadResponse(rsp)
+}
+
+// ResolverChainWithResponse request returning *ResolverChainResponse
+func (c *ClientWithResponses) ResolverChainWithResponse(ctx context.Context, params *ResolverChainParams, reqEditors ...RequestEditorFn) (*ResolverChainResponse, error) {
+	rsp, err := c.ResolverChain(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResolverChainResponse(rsp)
+}
+
+// StateExportWithResponse request returning *StateExportResponse
+func (c *ClientWithResponses) StateExportWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*StateExportResponse, error) {
+	rsp, err := c.StateExport(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateExportResponse(rsp)
+}
+
+// StateImportWithBodyWithResponse request with arbitrary body returning *StateImportResponse
+func (c *ClientWithResponses) StateImportWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*StateImportResponse, error) {
+	rsp, err := c.StateImportWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateImportResponse(rsp)
+}
+
+func (c *ClientWithResponses) StateImportWithResponse(ctx context.Context, body StateImportJSONRequestBody, reqEditors ...RequestEditorFn) (*StateImportResponse, error) {
+	rsp, err := c.StateImport(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateImportResponse(rsp)
+}
+
+// ClientStatsWithResponse request returning *ClientStatsResponse
+func (c *ClientWithResponses) ClientStatsWithResponse(ctx context.Context, params *ClientStatsParams, reqEditors ...RequestEditorFn) (*ClientStatsResponse, error) {
+	rsp, err := c.ClientStats(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClientStatsResponse(rsp)
+}
+
+// UpstreamStatsWithResponse request returning *UpstreamStatsResponse
+func (c *ClientWithResponses) UpstreamStatsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*UpstreamStatsResponse, error) {
+	rsp, err := c.UpstreamStats(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUpstreamStatsResponse(rsp)
+}
+
+// ParseManipulationLogResponse parses an HTTP response from a ManipulationLogWithResponse call
+func ParseManipulationLogResponse(rsp *http.Response) (*ManipulationLogResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ManipulationLogResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiManipulationEntry
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
 		}
-		c.Server = newBaseURL.String()
-		return nil
+		response.JSON200 = &dest
+
 	}
+
+	return response, nil
 }
 
-// ClientWithResponsesInterface is the interface specification for the client with responses above.
-type ClientWithResponsesInterface interface {
-	// DisableBlockingWithResponse request
-	DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error)
+// ParseAuditLogResponse parses an HTTP response from a AuditLogWithResponse call
+func ParseAuditLogResponse(rsp *http.Response) (*AuditLogResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
 
-	// EnableBlockingWithResponse request
-	EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error)
+	response := &AuditLogResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
 
-	// BlockingStatusWithResponse request
-	BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error)
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiAuditEntry
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-	// CacheFlushWithResponse request
-	CacheFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CacheFlushResponse, error)
+	}
 
-	// ListRefreshWithResponse request
-	ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error)
+	return response, nil
+}
 
-	// QueryWithBodyWithResponse request with any body
-	QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error)
+// ParseDisableBlockingResponse parses an HTTP response from a DisableBlockingWithResponse call
+func ParseDisableBlockingResponse(rsp *http.Response) (*DisableBlockingResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DisableBlockingResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseEnableBlockingResponse parses an HTTP response from a EnableBlockingWithResponse call
+func ParseEnableBlockingResponse(rsp *http.Response) (*EnableBlockingResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &EnableBlockingResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseBlockingStatusResponse parses an HTTP response from a BlockingStatusWithResponse call
+func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &BlockingStatusResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiBlockingStatus
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-	QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error)
-}
+	}
 
-type DisableBlockingResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r DisableBlockingResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseCacheFlushResponse parses an HTTP response from a CacheFlushWithResponse call
+func ParseCacheFlushResponse(rsp *http.Response) (*CacheFlushResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r DisableBlockingResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &CacheFlushResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type EnableBlockingResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r EnableBlockingResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseConfigDryRunResponse parses an HTTP response from a ConfigDryRunWithResponse call
+func ParseConfigDryRunResponse(rsp *http.Response) (*ConfigDryRunResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r EnableBlockingResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &ConfigDryRunResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
-
-type BlockingStatusResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *ApiBlockingStatus
-}
 
-// Status returns HTTPResponse.Status
-func (r BlockingStatusResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
-	}
-	return http.StatusText(0)
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiConfigDryRunResult
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r BlockingStatusResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
 	}
-	return 0
-}
 
-type CacheFlushResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r CacheFlushResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseListRefreshResponse parses an HTTP response from a ListRefreshWithResponse call
+func ParseListRefreshResponse(rsp *http.Response) (*ListRefreshResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r CacheFlushResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &ListRefreshResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type ListRefreshResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r ListRefreshResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseQueryResponse parses an HTTP response from a QueryWithResponse call
+func ParseQueryResponse(rsp *http.Response) (*QueryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r ListRefreshResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+	response := &QueryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return 0
-}
 
-type QueryResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *ApiQueryResult
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ApiQueryResult
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
 
-// Status returns HTTPResponse.Status
-func (r QueryResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
 	}
-	return http.StatusText(0)
-}
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r QueryResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
-	}
-	return 0
+	return response, nil
 }
 
-// DisableBlockingWithResponse request returning *DisableBlockingResponse
-func (c *ClientWithResponses) DisableBlockingWithResponse(ctx context.Context, params *DisableBlockingParams, reqEditors ...RequestEditorFn) (*DisableBlockingResponse, error) {
-	rsp, err := c.DisableBlocking(ctx, params, reqEditors...)
+// ParseQueryLogFlushResponse parses an HTTP response from a QueryLogFlushWithResponse call
+func ParseQueryLogFlushResponse(rsp *http.Response) (*QueryLogFlushResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseDisableBlockingResponse(rsp)
-}
 
-// EnableBlockingWithResponse request returning *EnableBlockingResponse
-func (c *ClientWithResponses) EnableBlockingWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*EnableBlockingResponse, error) {
-	rsp, err := c.EnableBlocking(ctx, reqEditors...)
-	if err != nil {
-		return nil, err
+	response := &QueryLogFlushResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseEnableBlockingResponse(rsp)
+
+	return response, nil
 }
 
-// BlockingStatusWithResponse request returning *BlockingStatusResponse
-func (c *ClientWithResponses) BlockingStatusWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*BlockingStatusResponse, error) {
-	rsp, err := c.BlockingStatus(ctx, reqEditors...)
+// ParseQueryLogRotateResponse parses an HTTP response from a QueryLogRotateWithResponse call
+func ParseQueryLogRotateResponse(rsp *http.Response) (*QueryLogRotateResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseBlockingStatusResponse(rsp)
-}
 
-// CacheFlushWithResponse request returning *CacheFlushResponse
-func (c *ClientWithResponses) CacheFlushWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*CacheFlushResponse, error) {
-	rsp, err := c.CacheFlush(ctx, reqEditors...)
-	if err != nil {
-		return nil, err
+	response := &QueryLogRotateResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseCacheFlushResponse(rsp)
+
+	return response, nil
 }
 
-// ListRefreshWithResponse request returning *ListRefreshResponse
-func (c *ClientWithResponses) ListRefreshWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListRefreshResponse, error) {
-	rsp, err := c.ListRefresh(ctx, reqEditors...)
+// ParseQuotasResponse parses an HTTP response from a QuotasWithResponse call
+func ParseQuotasResponse(rsp *http.Response) (*QuotasResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseListRefreshResponse(rsp)
-}
 
-// QueryWithBodyWithResponse request with arbitrary body returning *QueryResponse
-func (c *ClientWithResponses) QueryWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
-	rsp, err := c.QueryWithBody(ctx, contentType, body, reqEditors...)
-	if err != nil {
-		return nil, err
+	response := &QuotasResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseQueryResponse(rsp)
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiQuotaUsage
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-func (c *ClientWithResponses) QueryWithResponse(ctx context.Context, body QueryJSONRequestBody, reqEditors ...RequestEditorFn) (*QueryResponse, error) {
-	rsp, err := c.Query(ctx, body, reqEditors...)
+// ParseQuotaResetResponse parses an HTTP response from a QuotaResetWithResponse call
+func ParseQuotaResetResponse(rsp *http.Response) (*QuotaResetResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseQueryResponse(rsp)
+
+	response := &QuotaResetResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// ParseDisableBlockingResponse parses an HTTP response from a DisableBlockingWithResponse call
-func ParseDisableBlockingResponse(rsp *http.Response) (*DisableBlockingResponse, error) {
+// ParseReloadResponse parses an HTTP response from a ReloadWithResponse call
+func ParseReloadResponse(rsp *http.Response) (*ReloadResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &DisableBlockingResponse{
+	response := &ReloadResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -677,38 +2208,51 @@ func ParseDisableBlockingResponse(rsp *http.Response) (*DisableBlockingResponse,
 	return response, nil
 }
 
-// ParseEnableBlockingResponse parses an HTTP response from a EnableBlockingWithResponse call
-func ParseEnableBlockingResponse(rsp *http.Response) (*EnableBlockingResponse, error) {
+// ParseResolverChainResponse parses an HTTP response from a ResolverChainWithResponse call
+func ParseResolverChainResponse(rsp *http.Response) (*ResolverChainResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &EnableBlockingResponse{
+	response := &ResolverChainResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiResolverNode
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case rsp.StatusCode == 200:
+		// Content-type (text/vnd.graphviz) unsupported
+
+	}
+
 	return response, nil
 }
 
-// ParseBlockingStatusResponse parses an HTTP response from a BlockingStatusWithResponse call
-func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, error) {
+// ParseStateExportResponse parses an HTTP response from a StateExportWithResponse call
+func ParseStateExportResponse(rsp *http.Response) (*StateExportResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &BlockingStatusResponse{
+	response := &StateExportResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest ApiBlockingStatus
+		var dest ApiRuntimeState
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -719,15 +2263,15 @@ func ParseBlockingStatusResponse(rsp *http.Response) (*BlockingStatusResponse, e
 	return response, nil
 }
 
-// ParseCacheFlushResponse parses an HTTP response from a CacheFlushWithResponse call
-func ParseCacheFlushResponse(rsp *http.Response) (*CacheFlushResponse, error) {
+// ParseStateImportResponse parses an HTTP response from a StateImportWithResponse call
+func ParseStateImportResponse(rsp *http.Response) (*StateImportResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &CacheFlushResponse{
+	response := &StateImportResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
@@ -735,38 +2279,48 @@ func ParseCacheFlushResponse(rsp *http.Response) (*CacheFlushResponse, error) {
 	return response, nil
 }
 
-// ParseListRefreshResponse parses an HTTP response from a ListRefreshWithResponse call
-func ParseListRefreshResponse(rsp *http.Response) (*ListRefreshResponse, error) {
+// ParseClientStatsResponse parses an HTTP response from a ClientStatsWithResponse call
+func ParseClientStatsResponse(rsp *http.Response) (*ClientStatsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &ListRefreshResponse{
+	response := &ClientStatsResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ApiClientStats
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
 	return response, nil
 }
 
-// ParseQueryResponse parses an HTTP response from a QueryWithResponse call
-func ParseQueryResponse(rsp *http.Response) (*QueryResponse, error) {
+// ParseUpstreamStatsResponse parses an HTTP response from a UpstreamStatsWithResponse call
+func ParseUpstreamStatsResponse(rsp *http.Response) (*UpstreamStatsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &QueryResponse{
+	response := &UpstreamStatsResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest ApiQueryResult
+		var dest []ApiUpstreamStats
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -6,6 +6,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -38,14 +39,28 @@ type BlockingControl interface {
 	BlockingStatus() BlockingStatus
 }
 
+// ErrUnknownListGroup is returned by `ListRefresher`, if a group to refresh doesn't exist
+var ErrUnknownListGroup = errors.New("unknown list group")
+
 // ListRefresher interface to control the list refresh
 type ListRefresher interface {
-	RefreshLists() error
+	// RefreshLists refreshes the lists of the passed groups or all lists, if no group is passed
+	RefreshLists(groups []string) error
+}
+
+// ErrUnknownSubsystem is returned by `Reloader`, if the subsystem to reload doesn't exist
+var ErrUnknownSubsystem = errors.New("unknown subsystem")
+
+// Reloader interface to reload parts of the resolver chain from the configuration file
+type Reloader interface {
+	// Reload rebuilds the subsystem from the current configuration file
+	Reload(ctx context.Context, subsystem string) error
 }
 
 type Querier interface {
+	// Query resolves the question, `debug` enables the trace of the resolver chain
 	Query(
-		ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type,
+		ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type, debug bool,
 	) (*model.Response, error)
 }
 
@@ -53,8 +68,225 @@ type CacheControl interface {
 	FlushCaches(ctx context.Context)
 }
 
-func RegisterOpenAPIEndpoints(router chi.Router, impl StrictServerInterface) {
-	middleware := []StrictMiddlewareFunc{ctxWithHTTPRequestMiddleware}
+// ClientStats represents the aggregated query statistics of a client
+type ClientStats struct {
+	// Client name(s), comma separated
+	Client string
+	// Tenant of the client, empty if the client isn't assigned to a tenant
+	Tenant string
+	// Count of all queries
+	Total int64
+	// Count of blocked queries
+	Blocked int64
+	// Count of queries per response type
+	ResponseTypes map[string]int64
+	// Most queried domains, most queried first
+	TopDomains []DomainCount
+	// Count of queries per category of the queried domains, nil without categorized queries
+	Categories map[string]int64
+}
+
+// DomainCount represents the query count of a domain
+type DomainCount struct {
+	Domain string
+	Count  int64
+}
+
+// ErrClientStatsDisabled is returned by `ClientStatsProvider`, if the client statistics are disabled
+var ErrClientStatsDisabled = errors.New("client statistics are disabled")
+
+// ClientStatsProvider interface to get the query statistics per client
+type ClientStatsProvider interface {
+	// ClientStats returns the statistics of the last days or of all retained days, if days is 0
+	ClientStats(days int) ([]ClientStats, error)
+}
+
+// UpstreamStats represents the statistics of an upstream since start
+type UpstreamStats struct {
+	// Upstream group
+	Group string
+	// Upstream server
+	Upstream string
+	// Current health state: healthy, unreachable, hijacked or disabled
+	State string
+	// Count of all queries sent to the upstream
+	Queries int64
+	// Count of failed queries
+	Errors int64
+	// Count of responses used to answer a query
+	Selected int64
+	// Share of the used responses of the group
+	SelectionShare float64
+	// Response time percentiles of the latest responses
+	LatencyP50, LatencyP90, LatencyP99 time.Duration
+	// Current weight of the upstream, nil if the strategy doesn't use weights
+	Weighting *UpstreamWeighting
+}
+
+// UpstreamWeighting represents the current weight of an upstream for the random selection
+type UpstreamWeighting struct {
+	// Probability to be picked, compared to the other upstreams of the group
+	Weight float64
+	// Decayed average response time
+	Latency time.Duration
+	// Decayed error rate
+	ErrorRate float64
+}
+
+// UpstreamStatsProvider interface to get the statistics per upstream
+type UpstreamStatsProvider interface {
+	// UpstreamStats returns the statistics of all upstreams
+	UpstreamStats() []UpstreamStats
+}
+
+// ResolverNode describes a resolver of the chain
+type ResolverNode struct {
+	Type string
+	Name string
+	// False, if the resolver is disabled and passes all queries
+	Enabled bool
+	// Effective configuration, as logged on start
+	Config []string
+	// Sub-resolvers, which are selected per query
+	Branches []ResolverBranch
+}
+
+// ResolverBranch represents the sub-resolvers of a resolver, which are selected e.g. by upstream group or domain
+type ResolverBranch struct {
+	Name      string
+	Resolvers []ResolverNode
+}
+
+// ResolverChainProvider interface to describe the resolver chain
+type ResolverChainProvider interface {
+	// ResolverChain returns the current resolvers of the chain in the order of processing
+	ResolverChain() []ResolverNode
+}
+
+// DryRunQuery represents a test query of a configuration dry run
+type DryRunQuery struct {
+	Question string
+	Type     dns.Type
+	// Client IP, which determines the client groups
+	ClientIP net.IP
+}
+
+// DryRunResult represents the outcome of a configuration dry run
+type DryRunResult struct {
+	// Error of the configuration or of the creation of the resolver chain, nil if the configuration is valid
+	Err error
+	// Messages logged while parsing the configuration, e.g. warnings about deprecated options
+	Messages []string
+	// Resolver chain of the configuration
+	Chain []ResolverNode
+	// Responses to the test queries in the order of the queries
+	Responses []DryRunResponse
+}
+
+// DryRunResponse represents the outcome of a test query
+type DryRunResponse struct {
+	Response *model.Response
+	Err      error
+}
+
+// ConfigDryRunner interface to test a configuration without changing the running resolver chain
+type ConfigDryRunner interface {
+	// DryRunConfig parses the configuration, creates its resolver chain and resolves the queries with it
+	DryRunConfig(ctx context.Context, data []byte, queries []DryRunQuery) DryRunResult
+}
+
+// AuditEntry represents a response recorded by the audit mode
+type AuditEntry struct {
+	Time time.Time
+	// DNS transaction ID of the query
+	ID uint16
+	// Query type
+	Type string
+	// SHA-256 hashes of the queried domain and of the answer records
+	QuestionHash, AnswerHash string
+	ReturnCode               string
+	ResponseType             string
+}
+
+// ManipulationEntry represents a response, which was altered by blocky
+type ManipulationEntry struct {
+	Time     time.Time
+	Client   string
+	Question string
+	// Query type
+	Type    string
+	Changes []ResponseChange
+}
+
+// ResponseChange describes a single change of a query or its response
+type ResponseChange struct {
+	// Resolver, which made the change
+	Stage string
+	// Kind of the change, e.g. "blocked" or "rewritten"
+	Action string
+	// What was changed and why
+	Detail string
+}
+
+var (
+	// ErrAuditDisabled is returned by `AuditLogProvider`, if the audit mode is disabled
+	ErrAuditDisabled = errors.New("audit is disabled")
+
+	// ErrManipulationAuditDisabled is returned by `AuditLogProvider`, if the altered responses are not recorded
+	ErrManipulationAuditDisabled = errors.New("manipulation audit is disabled")
+)
+
+// AuditLogProvider interface to get the recorded responses
+type AuditLogProvider interface {
+	// AuditLog returns the latest responses to the client, newest first. A domain limits the responses to its questions
+	AuditLog(clientIP net.IP, domain string) ([]AuditEntry, error)
+	// ManipulationLog returns the latest altered responses, newest first. A client IP limits the responses to the client
+	ManipulationLog(clientIP net.IP) ([]ManipulationEntry, error)
+}
+
+// ErrQueryLogNotRotatable is returned by `QueryLogControl`, if the query log target has no files to rotate
+var ErrQueryLogNotRotatable = errors.New("query log target doesn't support rotation")
+
+// QueryLogControl interface to control the query log writer
+type QueryLogControl interface {
+	// FlushQueryLog writes the queued and buffered entries of the query log
+	FlushQueryLog(ctx context.Context) error
+	// RotateQueryLog writes the queued entries and rotates the query log files. If compress is nil, the configured
+	// compression is used
+	RotateQueryLog(ctx context.Context, compress *bool) error
+}
+
+// QuotaUsage represents the usage of a category by a client on the current day
+type QuotaUsage struct {
+	Category string
+	// Client name(s), comma separated
+	Client string
+	// Count of the counted queries and the daily budget, 0 is unlimited
+	Queries, QueryLimit int64
+	// Active time and the daily budget, 0 is unlimited
+	Time, TimeLimit time.Duration
+	// True if the queries of the category are blocked for the client
+	Exhausted bool
+}
+
+var (
+	// ErrQuotasDisabled is returned by `QuotaControl`, if no quota categories are configured
+	ErrQuotasDisabled = errors.New("quotas are disabled")
+
+	// ErrUnknownQuotaCategory is returned by `QuotaControl`, if a category to reset doesn't exist
+	ErrUnknownQuotaCategory = errors.New("unknown quota category")
+)
+
+// QuotaControl interface to view and reset the daily usage of the quota categories
+type QuotaControl interface {
+	// Quotas returns the usage of the current day per category and client
+	Quotas() ([]QuotaUsage, error)
+	// ResetQuotas resets the usage of the category and client. An empty category or client resets all of them
+	ResetQuotas(category, client string) error
+}
+
+func RegisterOpenAPIEndpoints(router chi.Router, impl StrictServerInterface, tokens APITokens) {
+	middleware := []StrictMiddlewareFunc{ctxWithHTTPRequestMiddleware, authMiddleware(tokens)}
 
 	HandlerFromMuxWithBaseURL(NewStrictHandler(impl, middleware), router, "/api")
 }
@@ -68,22 +300,46 @@ func ctxWithHTTPRequestMiddleware(handler StrictHandlerFunc, operationID string)
 }
 
 type OpenAPIInterfaceImpl struct {
-	control      BlockingControl
-	querier      Querier
-	refresher    ListRefresher
-	cacheControl CacheControl
+	control       BlockingControl
+	querier       Querier
+	refresher     ListRefresher
+	cacheControl  CacheControl
+	clientStats   ClientStatsProvider
+	reloader      Reloader
+	upstreamStats UpstreamStatsProvider
+	auditLog      AuditLogProvider
+	queryLog      QueryLogControl
+	resolverChain ResolverChainProvider
+	dryRunner     ConfigDryRunner
+	quotas        QuotaControl
 }
 
 func NewOpenAPIInterfaceImpl(control BlockingControl,
 	querier Querier,
 	refresher ListRefresher,
 	cacheControl CacheControl,
+	clientStats ClientStatsProvider,
+	reloader Reloader,
+	upstreamStats UpstreamStatsProvider,
+	auditLog AuditLogProvider,
+	queryLog QueryLogControl,
+	resolverChain ResolverChainProvider,
+	dryRunner ConfigDryRunner,
+	quotas QuotaControl,
 ) *OpenAPIInterfaceImpl {
 	return &OpenAPIInterfaceImpl{
-		control:      control,
-		querier:      querier,
-		refresher:    refresher,
-		cacheControl: cacheControl,
+		control:       control,
+		querier:       querier,
+		refresher:     refresher,
+		cacheControl:  cacheControl,
+		clientStats:   clientStats,
+		reloader:      reloader,
+		upstreamStats: upstreamStats,
+		auditLog:      auditLog,
+		queryLog:      queryLog,
+		resolverChain: resolverChain,
+		dryRunner:     dryRunner,
+		quotas:        quotas,
 	}
 }
 
@@ -142,9 +398,19 @@ func (i *OpenAPIInterfaceImpl) BlockingStatus(_ context.Context, _ BlockingStatu
 }
 
 func (i *OpenAPIInterfaceImpl) ListRefresh(_ context.Context,
-	_ ListRefreshRequestObject,
+	request ListRefreshRequestObject,
 ) (ListRefreshResponseObject, error) {
-	err := i.refresher.RefreshLists()
+	var groups []string
+
+	if request.Params.Group != nil && len(*request.Params.Group) > 0 {
+		groups = strings.Split(*request.Params.Group, ",")
+	}
+
+	err := i.refresher.RefreshLists(groups)
+	if errors.Is(err, ErrUnknownListGroup) {
+		return ListRefresh400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
 	if err != nil {
 		return ListRefresh500TextResponse(log.EscapeInput(err.Error())), nil
 	}
@@ -152,6 +418,19 @@ func (i *OpenAPIInterfaceImpl) ListRefresh(_ context.Context,
 	return ListRefresh200Response{}, nil
 }
 
+func (i *OpenAPIInterfaceImpl) Reload(ctx context.Context, request ReloadRequestObject) (ReloadResponseObject, error) {
+	err := i.reloader.Reload(ctx, string(request.Subsystem))
+	if errors.Is(err, ErrUnknownSubsystem) {
+		return Reload400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	if err != nil {
+		return Reload500TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return Reload200Response{}, nil
+}
+
 func (i *OpenAPIInterfaceImpl) Query(ctx context.Context, request QueryRequestObject) (QueryResponseObject, error) {
 	qType := dns.Type(dns.StringToType[request.Body.Type])
 	if qType == dns.Type(dns.TypeNone) {
@@ -169,17 +448,60 @@ func (i *OpenAPIInterfaceImpl) Query(ctx context.Context, request QueryRequestOb
 		clientIP = util.HTTPClientIP(httpReq)
 	}
 
-	resp, err := i.querier.Query(ctx, serverHost, clientIP, dns.Fqdn(request.Body.Query), qType)
+	debug := request.Params.Debug != nil && *request.Params.Debug
+
+	resp, err := i.querier.Query(ctx, serverHost, clientIP, dns.Fqdn(request.Body.Query), qType, debug)
 	if err != nil {
 		return nil, err
 	}
 
-	return Query200JSONResponse(ApiQueryResult{
-		Reason:       resp.Reason,
+	result := Query200JSONResponse{Body: toAPIQueryResult(resp)}
+
+	if upstream := resp.Upstream; upstream != nil {
+		result.Headers = Query200ResponseHeaders{
+			XBlockyUpstream:         upstream.Name,
+			XBlockyUpstreamGroup:    upstream.Group,
+			XBlockyUpstreamProtocol: upstream.Protocol,
+			XBlockyUpstreamRtt:      int(upstream.RTT.Milliseconds()),
+			XBlockyUpstreamRetries:  int(upstream.Retries),
+		}
+	}
+
+	return result, nil
+}
+
+func toAPIQueryResult(resp *model.Response) ApiQueryResult {
+	result := ApiQueryResult{
+		Reason:       resp.Reason.String(),
+		ReasonCode:   resp.Reason.Code.String(),
 		ResponseType: resp.RType.String(),
 		Response:     util.AnswerToString(resp.Res.Answer),
 		ReturnCode:   dns.RcodeToString[resp.Res.Rcode],
-	}), nil
+	}
+
+	if resp.Reason.Subject != "" {
+		result.ReasonSubject = &resp.Reason.Subject
+	}
+
+	if len(resp.Reason.Params) > 0 {
+		result.ReasonParams = &resp.Reason.Params
+	}
+
+	if len(resp.Trace) > 0 {
+		result.Trace = &resp.Trace
+	}
+
+	if upstream := resp.Upstream; upstream != nil {
+		result.Upstream = &ApiQueryUpstream{
+			Name:     upstream.Name,
+			Group:    upstream.Group,
+			Protocol: upstream.Protocol,
+			RttMs:    upstream.RTT.Milliseconds(),
+			Retries:  int(upstream.Retries),
+		}
+	}
+
+	return result
 }
 
 func (i *OpenAPIInterfaceImpl) CacheFlush(ctx context.Context,
@@ -189,3 +511,450 @@ func (i *OpenAPIInterfaceImpl) CacheFlush(ctx context.Context,
 
 	return CacheFlush200Response{}, nil
 }
+
+func (i *OpenAPIInterfaceImpl) QueryLogFlush(ctx context.Context,
+	_ QueryLogFlushRequestObject,
+) (QueryLogFlushResponseObject, error) {
+	if err := i.queryLog.FlushQueryLog(ctx); err != nil {
+		return QueryLogFlush500TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return QueryLogFlush200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) QueryLogRotate(ctx context.Context,
+	request QueryLogRotateRequestObject,
+) (QueryLogRotateResponseObject, error) {
+	err := i.queryLog.RotateQueryLog(ctx, request.Params.Gzip)
+	if errors.Is(err, ErrQueryLogNotRotatable) {
+		return QueryLogRotate400TextResponse(err.Error()), nil
+	}
+
+	if err != nil {
+		return QueryLogRotate500TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return QueryLogRotate200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) ClientStats(ctx context.Context,
+	request ClientStatsRequestObject,
+) (ClientStatsResponseObject, error) {
+	days := 0
+	if request.Params.Days != nil {
+		days = *request.Params.Days
+	}
+
+	stats, err := i.clientStats.ClientStats(days)
+	if errors.Is(err, ErrClientStatsDisabled) {
+		return ClientStats404TextResponse(err.Error()), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(ClientStats200JSONResponse, 0, len(stats))
+
+	tenant, scoped := tenantFromCtx(ctx)
+
+	for _, client := range stats {
+		if scoped && client.Tenant != tenant {
+			continue
+		}
+
+		topDomains := make([]ApiDomainCount, 0, len(client.TopDomains))
+
+		for _, domain := range client.TopDomains {
+			topDomains = append(topDomains, ApiDomainCount{Domain: domain.Domain, Count: domain.Count})
+		}
+
+		apiStats := ApiClientStats{
+			Client:        client.Client,
+			Total:         client.Total,
+			Blocked:       client.Blocked,
+			ResponseTypes: client.ResponseTypes,
+			TopDomains:    topDomains,
+		}
+
+		if client.Tenant != "" {
+			apiStats.Tenant = &client.Tenant
+		}
+
+		if len(client.Categories) != 0 {
+			apiStats.Categories = &client.Categories
+		}
+
+		result = append(result, apiStats)
+	}
+
+	return result, nil
+}
+
+func (i *OpenAPIInterfaceImpl) UpstreamStats(_ context.Context,
+	_ UpstreamStatsRequestObject,
+) (UpstreamStatsResponseObject, error) {
+	stats := i.upstreamStats.UpstreamStats()
+
+	result := make(UpstreamStats200JSONResponse, 0, len(stats))
+
+	for _, upstream := range stats {
+		var weighting *ApiUpstreamWeighting
+
+		if w := upstream.Weighting; w != nil {
+			weighting = &ApiUpstreamWeighting{
+				Weight:    float32(w.Weight),
+				LatencyMs: toMilliseconds(w.Latency),
+				ErrorRate: float32(w.ErrorRate),
+			}
+		}
+
+		result = append(result, ApiUpstreamStats{
+			Group:          upstream.Group,
+			Upstream:       upstream.Upstream,
+			State:          ApiUpstreamStatsState(upstream.State),
+			Queries:        upstream.Queries,
+			Errors:         upstream.Errors,
+			Selected:       upstream.Selected,
+			SelectionShare: float32(upstream.SelectionShare),
+			LatencyP50Ms:   toMilliseconds(upstream.LatencyP50),
+			LatencyP90Ms:   toMilliseconds(upstream.LatencyP90),
+			LatencyP99Ms:   toMilliseconds(upstream.LatencyP99),
+			Weighting:      weighting,
+		})
+	}
+
+	return result, nil
+}
+
+func (i *OpenAPIInterfaceImpl) AuditLog(_ context.Context,
+	request AuditLogRequestObject,
+) (AuditLogResponseObject, error) {
+	clientIP := net.ParseIP(request.Client)
+	if clientIP == nil {
+		return AuditLog400TextResponse(fmt.Sprintf("invalid client IP address '%s'", log.EscapeInput(request.Client))), nil
+	}
+
+	domain := ""
+	if request.Params.Domain != nil {
+		domain = *request.Params.Domain
+	}
+
+	entries, err := i.auditLog.AuditLog(clientIP, domain)
+	if errors.Is(err, ErrAuditDisabled) {
+		return AuditLog404TextResponse(err.Error()), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(AuditLog200JSONResponse, 0, len(entries))
+
+	for _, entry := range entries {
+		result = append(result, ApiAuditEntry{
+			Time:         entry.Time,
+			Id:           int(entry.ID),
+			Type:         entry.Type,
+			QuestionHash: entry.QuestionHash,
+			AnswerHash:   entry.AnswerHash,
+			ReturnCode:   entry.ReturnCode,
+			ResponseType: entry.ResponseType,
+		})
+	}
+
+	return result, nil
+}
+
+func (i *OpenAPIInterfaceImpl) ResolverChain(_ context.Context,
+	request ResolverChainRequestObject,
+) (ResolverChainResponseObject, error) {
+	nodes := i.resolverChain.ResolverChain()
+
+	format := "json"
+	if request.Params.Format != nil {
+		format = strings.ToLower(*request.Params.Format)
+	}
+
+	switch format {
+	case "json":
+		return ResolverChain200JSONResponse(toAPIResolverNodes(nodes)), nil
+	case "dot":
+		dot := resolverChainDOT(nodes)
+
+		return ResolverChain200TextvndGraphvizResponse{
+			Body:          strings.NewReader(dot),
+			ContentLength: int64(len(dot)),
+		}, nil
+	default:
+		return ResolverChain400TextResponse(fmt.Sprintf("unknown format '%s'", log.EscapeInput(format))), nil
+	}
+}
+
+func (i *OpenAPIInterfaceImpl) ConfigDryRun(ctx context.Context,
+	request ConfigDryRunRequestObject,
+) (ConfigDryRunResponseObject, error) {
+	var callerIP net.IP
+
+	if httpReq, ok := ctx.Value(httpReqCtxKey{}).(*http.Request); ok {
+		callerIP = util.HTTPClientIP(httpReq)
+	}
+
+	var queries []DryRunQuery
+
+	if request.Body.Queries != nil {
+		for _, q := range *request.Body.Queries {
+			qType := dns.Type(dns.StringToType[q.Type])
+			if qType == dns.Type(dns.TypeNone) {
+				return ConfigDryRun400TextResponse(fmt.Sprintf("unknown query type '%s'", log.EscapeInput(q.Type))), nil
+			}
+
+			clientIP := callerIP
+
+			if q.ClientIP != nil {
+				clientIP = net.ParseIP(*q.ClientIP)
+				if clientIP == nil {
+					return ConfigDryRun400TextResponse(
+						fmt.Sprintf("invalid client IP address '%s'", log.EscapeInput(*q.ClientIP))), nil
+				}
+			}
+
+			queries = append(queries, DryRunQuery{Question: dns.Fqdn(q.Query), Type: qType, ClientIP: clientIP})
+		}
+	}
+
+	dryRun := i.dryRunner.DryRunConfig(ctx, []byte(request.Body.Config), queries)
+
+	result := ApiConfigDryRunResult{
+		Valid:    dryRun.Err == nil,
+		Messages: dryRun.Messages,
+		Chain:    toAPIResolverNodes(dryRun.Chain),
+		Queries:  make([]ApiDryRunQueryResult, 0, len(dryRun.Responses)),
+	}
+
+	if result.Messages == nil {
+		result.Messages = []string{}
+	}
+
+	if dryRun.Err != nil {
+		errMsg := dryRun.Err.Error()
+		result.Error = &errMsg
+	}
+
+	for idx, resp := range dryRun.Responses {
+		queryResult := ApiDryRunQueryResult{Query: queries[idx].Question, Type: queries[idx].Type.String()}
+
+		if resp.Err != nil {
+			errMsg := resp.Err.Error()
+			queryResult.Error = &errMsg
+		} else {
+			apiResult := toAPIQueryResult(resp.Response)
+			queryResult.Result = &apiResult
+		}
+
+		result.Queries = append(result.Queries, queryResult)
+	}
+
+	return ConfigDryRun200JSONResponse(result), nil
+}
+
+func toAPIResolverNodes(nodes []ResolverNode) []ApiResolverNode {
+	result := make([]ApiResolverNode, 0, len(nodes))
+
+	for _, node := range nodes {
+		branches := make([]ApiResolverBranch, 0, len(node.Branches))
+
+		for _, branch := range node.Branches {
+			branches = append(branches, ApiResolverBranch{
+				Name:      branch.Name,
+				Resolvers: toAPIResolverNodes(branch.Resolvers),
+			})
+		}
+
+		config := node.Config
+		if config == nil {
+			config = []string{}
+		}
+
+		result = append(result, ApiResolverNode{
+			Type:     node.Type,
+			Name:     node.Name,
+			Enabled:  node.Enabled,
+			Config:   config,
+			Branches: branches,
+		})
+	}
+
+	return result
+}
+
+func (i *OpenAPIInterfaceImpl) ManipulationLog(_ context.Context,
+	request ManipulationLogRequestObject,
+) (ManipulationLogResponseObject, error) {
+	var clientIP net.IP
+
+	if request.Params.Client != nil {
+		clientIP = net.ParseIP(*request.Params.Client)
+		if clientIP == nil {
+			return ManipulationLog400TextResponse(
+				fmt.Sprintf("invalid client IP address '%s'", log.EscapeInput(*request.Params.Client))), nil
+		}
+	}
+
+	entries, err := i.auditLog.ManipulationLog(clientIP)
+	if errors.Is(err, ErrManipulationAuditDisabled) {
+		return ManipulationLog404TextResponse(err.Error()), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(ManipulationLog200JSONResponse, 0, len(entries))
+
+	for _, entry := range entries {
+		changes := make([]ApiResponseChange, 0, len(entry.Changes))
+
+		for _, change := range entry.Changes {
+			changes = append(changes, ApiResponseChange{
+				Stage:  change.Stage,
+				Action: change.Action,
+				Detail: change.Detail,
+			})
+		}
+
+		result = append(result, ApiManipulationEntry{
+			Time:     entry.Time,
+			Client:   entry.Client,
+			Question: entry.Question,
+			Type:     entry.Type,
+			Changes:  changes,
+		})
+	}
+
+	return result, nil
+}
+
+// runtimeStateVersion is the version of the runtime state bundle, an import rejects other versions
+const runtimeStateVersion = 1
+
+func (i *OpenAPIInterfaceImpl) StateExport(_ context.Context,
+	_ StateExportRequestObject,
+) (StateExportResponseObject, error) {
+	blStatus := i.control.BlockingStatus()
+
+	blocking := ApiBlockingState{
+		Enabled: blStatus.Enabled,
+	}
+
+	if !blStatus.Enabled {
+		blocking.DisabledGroups = &blStatus.DisabledGroups
+	}
+
+	if blStatus.AutoEnableInSec > 0 {
+		autoEnableAt := time.Now().Add(time.Duration(blStatus.AutoEnableInSec) * time.Second).UTC()
+		blocking.AutoEnableAt = &autoEnableAt
+	}
+
+	return StateExport200JSONResponse(ApiRuntimeState{
+		Version:  runtimeStateVersion,
+		Blocking: blocking,
+	}), nil
+}
+
+func (i *OpenAPIInterfaceImpl) StateImport(ctx context.Context,
+	request StateImportRequestObject,
+) (StateImportResponseObject, error) {
+	state := request.Body
+
+	if state.Version != runtimeStateVersion {
+		return StateImport400TextResponse(fmt.Sprintf("unsupported state version %d", state.Version)), nil
+	}
+
+	blocking := state.Blocking
+
+	var duration time.Duration
+
+	if blocking.AutoEnableAt != nil {
+		duration = time.Until(*blocking.AutoEnableAt)
+	}
+
+	// a timed disabling, which elapsed in the meantime, enables the blocking again
+	if blocking.Enabled || (blocking.AutoEnableAt != nil && duration <= 0) {
+		i.control.EnableBlocking(ctx)
+
+		return StateImport200Response{}, nil
+	}
+
+	var groups []string
+
+	if blocking.DisabledGroups != nil {
+		groups = *blocking.DisabledGroups
+	}
+
+	if err := i.control.DisableBlocking(ctx, duration, groups); err != nil {
+		return StateImport400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	return StateImport200Response{}, nil
+}
+
+func (i *OpenAPIInterfaceImpl) Quotas(_ context.Context, _ QuotasRequestObject) (QuotasResponseObject, error) {
+	usages, err := i.quotas.Quotas()
+	if errors.Is(err, ErrQuotasDisabled) {
+		return Quotas404TextResponse(err.Error()), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(Quotas200JSONResponse, 0, len(usages))
+
+	for _, usage := range usages {
+		result = append(result, ApiQuotaUsage{
+			Category:         usage.Category,
+			Client:           usage.Client,
+			Queries:          usage.Queries,
+			QueryLimit:       usage.QueryLimit,
+			TimeSeconds:      int64(usage.Time.Seconds()),
+			TimeLimitSeconds: int64(usage.TimeLimit.Seconds()),
+			Exhausted:        usage.Exhausted,
+		})
+	}
+
+	return result, nil
+}
+
+func (i *OpenAPIInterfaceImpl) QuotaReset(_ context.Context,
+	request QuotaResetRequestObject,
+) (QuotaResetResponseObject, error) {
+	var category, client string
+
+	if request.Params.Category != nil {
+		category = *request.Params.Category
+	}
+
+	if request.Params.Client != nil {
+		client = *request.Params.Client
+	}
+
+	err := i.quotas.ResetQuotas(category, client)
+	if errors.Is(err, ErrQuotasDisabled) {
+		return QuotaReset404TextResponse(err.Error()), nil
+	}
+
+	if errors.Is(err, ErrUnknownQuotaCategory) {
+		return QuotaReset400TextResponse(log.EscapeInput(err.Error())), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return QuotaReset200Response{}, nil
+}
+
+func toMilliseconds(d time.Duration) float32 {
+	return float32(d) / float32(time.Millisecond)
+}
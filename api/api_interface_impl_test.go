@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"time"
@@ -34,9 +36,116 @@ type CacheControlMock struct {
 	mock.Mock
 }
 
-func (m *ListRefreshMock) RefreshLists() error {
+type ClientStatsMock struct {
+	mock.Mock
+}
+
+type ReloaderMock struct {
+	mock.Mock
+}
+
+type UpstreamStatsMock struct {
+	mock.Mock
+}
+
+type AuditLogMock struct {
+	mock.Mock
+}
+
+type ResolverChainMock struct {
+	mock.Mock
+}
+
+type QueryLogControlMock struct {
+	mock.Mock
+}
+
+type ConfigDryRunnerMock struct {
+	mock.Mock
+}
+
+type QuotaControlMock struct {
+	mock.Mock
+}
+
+func (m *QuotaControlMock) Quotas() ([]QuotaUsage, error) {
 	args := m.Called()
 
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]QuotaUsage), nil
+}
+
+func (m *QuotaControlMock) ResetQuotas(category, client string) error {
+	args := m.Called(category, client)
+
+	return args.Error(0)
+}
+
+func (m *ConfigDryRunnerMock) DryRunConfig(_ context.Context, data []byte, queries []DryRunQuery) DryRunResult {
+	args := m.Called(string(data), queries)
+
+	return args.Get(0).(DryRunResult)
+}
+
+func (m *QueryLogControlMock) FlushQueryLog(_ context.Context) error {
+	args := m.Called()
+
+	return args.Error(0)
+}
+
+func (m *QueryLogControlMock) RotateQueryLog(_ context.Context, compress *bool) error {
+	args := m.Called(compress)
+
+	return args.Error(0)
+}
+
+func (m *AuditLogMock) AuditLog(clientIP net.IP, domain string) ([]AuditEntry, error) {
+	args := m.Called(clientIP, domain)
+
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]AuditEntry), nil
+}
+
+func (m *AuditLogMock) ManipulationLog(clientIP net.IP) ([]ManipulationEntry, error) {
+	args := m.Called(clientIP)
+
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]ManipulationEntry), nil
+}
+
+func (m *UpstreamStatsMock) UpstreamStats() []UpstreamStats {
+	args := m.Called()
+
+	return args.Get(0).([]UpstreamStats)
+}
+
+func (m *ResolverChainMock) ResolverChain() []ResolverNode {
+	args := m.Called()
+
+	return args.Get(0).([]ResolverNode)
+}
+
+func (m *ReloaderMock) Reload(_ context.Context, subsystem string) error {
+	args := m.Called(subsystem)
+
+	return args.Error(0)
+}
+
+func (m *ListRefreshMock) RefreshLists(groups []string) error {
+	args := m.Called(groups)
+
 	return args.Error(0)
 }
 
@@ -57,9 +166,9 @@ func (m *BlockingControlMock) BlockingStatus() BlockingStatus {
 }
 
 func (m *QuerierMock) Query(
-	ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type,
+	ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type, debug bool,
 ) (*model.Response, error) {
-	args := m.Called(ctx, serverHost, clientIP, question, qType)
+	args := m.Called(ctx, serverHost, clientIP, question, qType, debug)
 
 	err := args.Error(1)
 	if err != nil {
@@ -73,12 +182,31 @@ func (m *CacheControlMock) FlushCaches(ctx context.Context) {
 	_ = m.Called(ctx)
 }
 
+func (m *ClientStatsMock) ClientStats(days int) ([]ClientStats, error) {
+	args := m.Called(days)
+
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]ClientStats), nil
+}
+
 var _ = Describe("API implementation tests", func() {
 	var (
 		blockingControlMock *BlockingControlMock
 		querierMock         *QuerierMock
 		listRefreshMock     *ListRefreshMock
 		cacheControlMock    *CacheControlMock
+		clientStatsMock     *ClientStatsMock
+		reloaderMock        *ReloaderMock
+		upstreamStatsMock   *UpstreamStatsMock
+		auditLogMock        *AuditLogMock
+		queryLogMock        *QueryLogControlMock
+		resolverChainMock   *ResolverChainMock
+		dryRunnerMock       *ConfigDryRunnerMock
+		quotaMock           *QuotaControlMock
 		sut                 *OpenAPIInterfaceImpl
 
 		ctx      context.Context
@@ -93,19 +221,36 @@ var _ = Describe("API implementation tests", func() {
 		querierMock = &QuerierMock{}
 		listRefreshMock = &ListRefreshMock{}
 		cacheControlMock = &CacheControlMock{}
-		sut = NewOpenAPIInterfaceImpl(blockingControlMock, querierMock, listRefreshMock, cacheControlMock)
+		clientStatsMock = &ClientStatsMock{}
+		reloaderMock = &ReloaderMock{}
+		upstreamStatsMock = &UpstreamStatsMock{}
+		auditLogMock = &AuditLogMock{}
+		queryLogMock = &QueryLogControlMock{}
+		resolverChainMock = &ResolverChainMock{}
+		dryRunnerMock = &ConfigDryRunnerMock{}
+		quotaMock = &QuotaControlMock{}
+		sut = NewOpenAPIInterfaceImpl(blockingControlMock, querierMock, listRefreshMock, cacheControlMock, clientStatsMock,
+			reloaderMock, upstreamStatsMock, auditLogMock, queryLogMock, resolverChainMock, dryRunnerMock, quotaMock)
 	})
 
 	AfterEach(func() {
 		blockingControlMock.AssertExpectations(GinkgoT())
 		querierMock.AssertExpectations(GinkgoT())
 		listRefreshMock.AssertExpectations(GinkgoT())
+		clientStatsMock.AssertExpectations(GinkgoT())
+		reloaderMock.AssertExpectations(GinkgoT())
+		upstreamStatsMock.AssertExpectations(GinkgoT())
+		auditLogMock.AssertExpectations(GinkgoT())
+		queryLogMock.AssertExpectations(GinkgoT())
+		resolverChainMock.AssertExpectations(GinkgoT())
+		dryRunnerMock.AssertExpectations(GinkgoT())
+		quotaMock.AssertExpectations(GinkgoT())
 	})
 
 	Describe("RegisterOpenAPIEndpoints", func() {
 		It("adds routes", func() {
 			rtr := chi.NewRouter()
-			RegisterOpenAPIEndpoints(rtr, sut)
+			RegisterOpenAPIEndpoints(rtr, sut, APITokens{})
 
 			Expect(rtr.Routes()).ShouldNot(BeEmpty())
 		})
@@ -138,9 +283,9 @@ var _ = Describe("API implementation tests", func() {
 				)
 				Expect(err).Should(Succeed())
 
-				querierMock.On("Query", ctx, "", net.IP(nil), "google.com.", A).Return(&model.Response{
+				querierMock.On("Query", ctx, "", net.IP(nil), "google.com.", A, false).Return(&model.Response{
 					Res:    queryResponse,
-					Reason: "reason",
+					Reason: model.NewReason(model.ReasonCodeBLOCKED, "ads"),
 				}, nil)
 
 				resp, err := sut.Query(ctx, QueryRequestObject{
@@ -152,10 +297,85 @@ var _ = Describe("API implementation tests", func() {
 				var resp200 Query200JSONResponse
 				Expect(resp).Should(BeAssignableToTypeOf(resp200))
 				resp200 = resp.(Query200JSONResponse)
-				Expect(resp200.Reason).Should(Equal("reason"))
-				Expect(resp200.Response).Should(Equal("A (0.0.0.0)"))
-				Expect(resp200.ResponseType).Should(Equal("RESOLVED"))
-				Expect(resp200.ReturnCode).Should(Equal("NOERROR"))
+				Expect(resp200.Body.Reason).Should(Equal("BLOCKED (ads)"))
+				Expect(resp200.Body.ReasonCode).Should(Equal("BLOCKED"))
+				Expect(resp200.Body.ReasonSubject).Should(BeNil())
+				Expect(resp200.Body.ReasonParams).Should(Equal(&[]string{"ads"}))
+				Expect(resp200.Body.Response).Should(Equal("A (0.0.0.0)"))
+				Expect(resp200.Body.ResponseType).Should(Equal("RESOLVED"))
+				Expect(resp200.Body.ReturnCode).Should(Equal("NOERROR"))
+				Expect(resp200.Body.Upstream).Should(BeNil())
+				Expect(resp200.Body.Trace).Should(BeNil())
+			})
+
+			It("should return the upstream, which answered the query", func() {
+				queryResponse, err := util.NewMsgWithAnswer(
+					"domain.", 123, A, "0.0.0.0",
+				)
+				Expect(err).Should(Succeed())
+
+				querierMock.On("Query", ctx, "", net.IP(nil), "google.com.", A, false).Return(&model.Response{
+					Res:    queryResponse,
+					Reason: model.NewReason(model.ReasonCodeRESOLVED),
+					Upstream: &model.UpstreamInfo{
+						Name:     "tcp+udp:1.1.1.1",
+						Group:    "default",
+						Protocol: "tcp+udp",
+						RTT:      12 * time.Millisecond,
+						Retries:  1,
+					},
+				}, nil)
+
+				resp, err := sut.Query(ctx, QueryRequestObject{
+					Body: &ApiQueryRequest{
+						Query: "google.com", Type: "A",
+					},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Query200JSONResponse{}))
+
+				resp200 := resp.(Query200JSONResponse)
+				Expect(resp200.Body.Upstream).Should(Equal(&ApiQueryUpstream{
+					Name:     "tcp+udp:1.1.1.1",
+					Group:    "default",
+					Protocol: "tcp+udp",
+					RttMs:    12,
+					Retries:  1,
+				}))
+				Expect(resp200.Headers).Should(Equal(Query200ResponseHeaders{
+					XBlockyUpstream:         "tcp+udp:1.1.1.1",
+					XBlockyUpstreamGroup:    "default",
+					XBlockyUpstreamProtocol: "tcp+udp",
+					XBlockyUpstreamRtt:      12,
+					XBlockyUpstreamRetries:  1,
+				}))
+			})
+
+			It("should return the trace of debug queries", func() {
+				queryResponse, err := util.NewMsgWithAnswer(
+					"domain.", 123, A, "0.0.0.0",
+				)
+				Expect(err).Should(Succeed())
+
+				querierMock.On("Query", ctx, "", net.IP(nil), "google.com.", A, true).Return(&model.Response{
+					Res:    queryResponse,
+					Reason: model.NewReason(model.ReasonCodeRESOLVED),
+					Trace:  []string{"+1ms fqdn_only: start"},
+				}, nil)
+
+				debug := true
+
+				resp, err := sut.Query(ctx, QueryRequestObject{
+					Params: QueryParams{Debug: &debug},
+					Body: &ApiQueryRequest{
+						Query: "google.com", Type: "A",
+					},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Query200JSONResponse{}))
+
+				resp200 := resp.(Query200JSONResponse)
+				Expect(resp200.Body.Trace).Should(HaveValue(Equal([]string{"+1ms fqdn_only: start"})))
 			})
 
 			It("extracts metadata from the HTTP request", func() {
@@ -168,7 +388,7 @@ var _ = Describe("API implementation tests", func() {
 				ctx = context.WithValue(ctx, httpReqCtxKey{}, r)
 
 				expectedErr := errors.New("test")
-				querierMock.On("Query", ctx, "blocky.localhost", clientIP, "example.com.", A).Return(nil, expectedErr)
+				querierMock.On("Query", ctx, "blocky.localhost", clientIP, "example.com.", A, false).Return(nil, expectedErr)
 
 				_, err = sut.Query(ctx, QueryRequestObject{
 					Body: &ApiQueryRequest{
@@ -196,7 +416,7 @@ var _ = Describe("API implementation tests", func() {
 	Describe("Lists API", func() {
 		When("List refresh is called", func() {
 			It("should return 200 on success", func() {
-				listRefreshMock.On("RefreshLists").Return(nil)
+				listRefreshMock.On("RefreshLists", []string(nil)).Return(nil)
 
 				resp, err := sut.ListRefresh(ctx, ListRefreshRequestObject{})
 				Expect(err).Should(Succeed())
@@ -205,7 +425,7 @@ var _ = Describe("API implementation tests", func() {
 			})
 
 			It("should return 500 on failure", func() {
-				listRefreshMock.On("RefreshLists").Return(errors.New("failed"))
+				listRefreshMock.On("RefreshLists", []string(nil)).Return(errors.New("failed"))
 
 				resp, err := sut.ListRefresh(ctx, ListRefreshRequestObject{})
 				Expect(err).Should(Succeed())
@@ -213,6 +433,58 @@ var _ = Describe("API implementation tests", func() {
 				Expect(resp).Should(BeAssignableToTypeOf(resp500))
 				Expect(resp).Should(Equal(ListRefresh500TextResponse("failed")))
 			})
+
+			It("should refresh the passed groups", func() {
+				listRefreshMock.On("RefreshLists", []string{"ads", "kids"}).Return(nil)
+
+				groups := "ads,kids"
+				resp, err := sut.ListRefresh(ctx, ListRefreshRequestObject{
+					Params: ListRefreshParams{Group: &groups},
+				})
+				Expect(err).Should(Succeed())
+				var resp200 ListRefresh200Response
+				Expect(resp).Should(BeAssignableToTypeOf(resp200))
+			})
+
+			It("should return 400 on unknown group", func() {
+				listRefreshMock.On("RefreshLists", []string{"unknown"}).
+					Return(fmt.Errorf("%w 'unknown'", ErrUnknownListGroup))
+
+				groups := "unknown"
+				resp, err := sut.ListRefresh(ctx, ListRefreshRequestObject{
+					Params: ListRefreshParams{Group: &groups},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ListRefresh400TextResponse("unknown list group 'unknown'")))
+			})
+		})
+	})
+
+	Describe("Reload API", func() {
+		When("Reload is called", func() {
+			It("should return 200 on success", func() {
+				reloaderMock.On("Reload", "lists").Return(nil)
+
+				resp, err := sut.Reload(ctx, ReloadRequestObject{Subsystem: Lists})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(Reload200Response{}))
+			})
+
+			It("should return 400 on unknown subsystem", func() {
+				reloaderMock.On("Reload", "cache").Return(fmt.Errorf("%w 'cache'", ErrUnknownSubsystem))
+
+				resp, err := sut.Reload(ctx, ReloadRequestObject{Subsystem: "cache"})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(Reload400TextResponse("unknown subsystem 'cache'")))
+			})
+
+			It("should return 500 on failure", func() {
+				reloaderMock.On("Reload", "upstreams").Return(errors.New("failed"))
+
+				resp, err := sut.Reload(ctx, ReloadRequestObject{Subsystem: Upstreams})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(Reload500TextResponse("failed")))
+			})
 		})
 	})
 
@@ -304,6 +576,91 @@ var _ = Describe("API implementation tests", func() {
 		})
 	})
 
+	Describe("Runtime state API", func() {
+		When("State export is called", func() {
+			It("should return the blocking status", func() {
+				blockingControlMock.On("BlockingStatus").Return(BlockingStatus{
+					Enabled:         false,
+					DisabledGroups:  []string{"gr1"},
+					AutoEnableInSec: 60,
+				})
+
+				resp, err := sut.StateExport(ctx, StateExportRequestObject{})
+				Expect(err).Should(Succeed())
+				var resp200 StateExport200JSONResponse
+				Expect(resp).Should(BeAssignableToTypeOf(resp200))
+				resp200 = resp.(StateExport200JSONResponse)
+				Expect(resp200.Version).Should(Equal(1))
+				Expect(resp200.Blocking.Enabled).Should(BeFalse())
+				Expect(resp200.Blocking.DisabledGroups).Should(HaveValue(Equal([]string{"gr1"})))
+				Expect(resp200.Blocking.AutoEnableAt).Should(HaveValue(BeTemporally("~", time.Now().Add(time.Minute), time.Second)))
+			})
+		})
+
+		When("State import is called", func() {
+			It("should enable blocking", func() {
+				blockingControlMock.On("EnableBlocking").Return()
+
+				resp, err := sut.StateImport(ctx, StateImportRequestObject{
+					Body: &ApiRuntimeState{Version: 1, Blocking: ApiBlockingState{Enabled: true}},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(StateImport200Response{}))
+				blockingControlMock.AssertExpectations(GinkgoT())
+			})
+
+			It("should disable blocking for the remaining duration", func() {
+				blockingControlMock.On("DisableBlocking",
+					mock.MatchedBy(func(d time.Duration) bool { return d > 50*time.Second && d <= time.Minute }),
+					[]string{"gr1"}).Return(nil)
+
+				autoEnableAt := time.Now().Add(time.Minute)
+
+				resp, err := sut.StateImport(ctx, StateImportRequestObject{
+					Body: &ApiRuntimeState{Version: 1, Blocking: ApiBlockingState{
+						DisabledGroups: &[]string{"gr1"},
+						AutoEnableAt:   &autoEnableAt,
+					}},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(StateImport200Response{}))
+				blockingControlMock.AssertExpectations(GinkgoT())
+			})
+
+			It("should enable blocking, if the disabling elapsed", func() {
+				blockingControlMock.On("EnableBlocking").Return()
+
+				autoEnableAt := time.Now().Add(-time.Minute)
+
+				resp, err := sut.StateImport(ctx, StateImportRequestObject{
+					Body: &ApiRuntimeState{Version: 1, Blocking: ApiBlockingState{AutoEnableAt: &autoEnableAt}},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(StateImport200Response{}))
+				blockingControlMock.AssertExpectations(GinkgoT())
+			})
+
+			It("should return 400 on unknown group", func() {
+				blockingControlMock.On("DisableBlocking", time.Duration(0), []string{"unknown"}).
+					Return(errors.New("group 'unknown' is unknown"))
+
+				resp, err := sut.StateImport(ctx, StateImportRequestObject{
+					Body: &ApiRuntimeState{Version: 1, Blocking: ApiBlockingState{DisabledGroups: &[]string{"unknown"}}},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(StateImport400TextResponse("group 'unknown' is unknown")))
+			})
+
+			It("should return 400 on unsupported version", func() {
+				resp, err := sut.StateImport(ctx, StateImportRequestObject{
+					Body: &ApiRuntimeState{Version: 2},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(StateImport400TextResponse("unsupported state version 2")))
+			})
+		})
+	})
+
 	Describe("Cache API", func() {
 		When("Cache flush is called", func() {
 			It("should return 200 on success", func() {
@@ -315,4 +672,524 @@ var _ = Describe("API implementation tests", func() {
 			})
 		})
 	})
+
+	Describe("Query log API", func() {
+		When("Query log flush is called", func() {
+			It("should return 200 on success", func() {
+				queryLogMock.On("FlushQueryLog").Return(nil)
+
+				resp, err := sut.QueryLogFlush(ctx, QueryLogFlushRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(QueryLogFlush200Response{}))
+			})
+
+			It("should return 500 on error", func() {
+				queryLogMock.On("FlushQueryLog").Return(errors.New("db is gone"))
+
+				resp, err := sut.QueryLogFlush(ctx, QueryLogFlushRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QueryLogFlush500TextResponse("db is gone")))
+			})
+		})
+
+		When("Query log rotate is called", func() {
+			It("should pass the compression", func() {
+				compress := true
+				queryLogMock.On("RotateQueryLog", &compress).Return(nil)
+
+				resp, err := sut.QueryLogRotate(ctx, QueryLogRotateRequestObject{
+					Params: QueryLogRotateParams{Gzip: &compress},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeAssignableToTypeOf(QueryLogRotate200Response{}))
+			})
+
+			It("should return 400 if the target has no files", func() {
+				queryLogMock.On("RotateQueryLog", (*bool)(nil)).Return(ErrQueryLogNotRotatable)
+
+				resp, err := sut.QueryLogRotate(ctx, QueryLogRotateRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QueryLogRotate400TextResponse(ErrQueryLogNotRotatable.Error())))
+			})
+
+			It("should return 500 on error", func() {
+				queryLogMock.On("RotateQueryLog", (*bool)(nil)).Return(errors.New("disk full"))
+
+				resp, err := sut.QueryLogRotate(ctx, QueryLogRotateRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QueryLogRotate500TextResponse("disk full")))
+			})
+		})
+	})
+
+	Describe("Stats API", func() {
+		When("Client stats are called", func() {
+			It("should return 200 with the statistics", func() {
+				clientStatsMock.On("ClientStats", 0).Return([]ClientStats{
+					{
+						Client:        "laptop",
+						Total:         3,
+						Blocked:       1,
+						ResponseTypes: map[string]int64{"RESOLVED": 2, "BLOCKED": 1},
+						TopDomains:    []DomainCount{{Domain: "example.com", Count: 2}},
+					},
+				}, nil)
+
+				resp, err := sut.ClientStats(ctx, ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats200JSONResponse{
+					{
+						Client:        "laptop",
+						Total:         3,
+						Blocked:       1,
+						ResponseTypes: map[string]int64{"RESOLVED": 2, "BLOCKED": 1},
+						TopDomains:    []ApiDomainCount{{Domain: "example.com", Count: 2}},
+					},
+				}))
+			})
+
+			It("should return only the clients of the tenant of the API token", func() {
+				clientStatsMock.On("ClientStats", 0).Return([]ClientStats{
+					{Client: "laptop", Tenant: "miller", Total: 1},
+					{Client: "laptop", Tenant: "smith", Total: 2},
+					{Client: "phone", Total: 3},
+				}, nil)
+
+				resp, err := sut.ClientStats(context.WithValue(ctx, tenantCtxKey{}, "smith"), ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+
+				tenant := "smith"
+				Expect(resp).Should(Equal(ClientStats200JSONResponse{
+					{Client: "laptop", Tenant: &tenant, Total: 2, TopDomains: []ApiDomainCount{}},
+				}))
+			})
+
+			It("should return the categories", func() {
+				categories := map[string]int64{"social": 2}
+				clientStatsMock.On("ClientStats", 0).Return([]ClientStats{
+					{Client: "laptop", Total: 2, Categories: categories},
+				}, nil)
+
+				resp, err := sut.ClientStats(ctx, ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats200JSONResponse{
+					{Client: "laptop", Total: 2, TopDomains: []ApiDomainCount{}, Categories: &categories},
+				}))
+			})
+
+			It("should pass the days", func() {
+				days := 7
+				clientStatsMock.On("ClientStats", 7).Return([]ClientStats{}, nil)
+
+				resp, err := sut.ClientStats(ctx, ClientStatsRequestObject{Params: ClientStatsParams{Days: &days}})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats200JSONResponse{}))
+			})
+
+			It("should return 404 if disabled", func() {
+				clientStatsMock.On("ClientStats", 0).Return(nil, ErrClientStatsDisabled)
+
+				resp, err := sut.ClientStats(ctx, ClientStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ClientStats404TextResponse("client statistics are disabled")))
+			})
+
+			It("should return other errors", func() {
+				clientStatsMock.On("ClientStats", 0).Return(nil, errors.New("failed"))
+
+				_, err := sut.ClientStats(ctx, ClientStatsRequestObject{})
+				Expect(err).Should(MatchError("failed"))
+			})
+		})
+
+		When("Upstream stats are called", func() {
+			It("should return 200 with the statistics", func() {
+				upstreamStatsMock.On("UpstreamStats").Return([]UpstreamStats{
+					{
+						Group:          "default",
+						Upstream:       "https://dns.example.com/dns-query",
+						State:          "healthy",
+						Queries:        10,
+						Errors:         1,
+						Selected:       4,
+						SelectionShare: 0.5,
+						LatencyP50:     1500 * time.Microsecond,
+						LatencyP90:     20 * time.Millisecond,
+						LatencyP99:     time.Second,
+						Weighting: &UpstreamWeighting{
+							Weight:    0.25,
+							Latency:   2 * time.Millisecond,
+							ErrorRate: 0.5,
+						},
+					},
+				})
+
+				resp, err := sut.UpstreamStats(ctx, UpstreamStatsRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(UpstreamStats200JSONResponse{
+					{
+						Group:          "default",
+						Upstream:       "https://dns.example.com/dns-query",
+						State:          Healthy,
+						Queries:        10,
+						Errors:         1,
+						Selected:       4,
+						SelectionShare: 0.5,
+						LatencyP50Ms:   1.5,
+						LatencyP90Ms:   20,
+						LatencyP99Ms:   1000,
+						Weighting: &ApiUpstreamWeighting{
+							Weight:    0.25,
+							LatencyMs: 2,
+							ErrorRate: 0.5,
+						},
+					},
+				}))
+			})
+		})
+	})
+
+	Describe("Quotas API", func() {
+		When("Quotas are called", func() {
+			It("should return 200 with the usage", func() {
+				quotaMock.On("Quotas").Return([]QuotaUsage{
+					{
+						Category:  "gaming",
+						Client:    "laptop",
+						Queries:   42,
+						Time:      30 * time.Minute,
+						TimeLimit: time.Hour,
+					},
+				}, nil)
+
+				resp, err := sut.Quotas(ctx, QuotasRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(Quotas200JSONResponse{
+					{
+						Category:         "gaming",
+						Client:           "laptop",
+						Queries:          42,
+						TimeSeconds:      1800,
+						TimeLimitSeconds: 3600,
+					},
+				}))
+			})
+
+			It("should return 404 if disabled", func() {
+				quotaMock.On("Quotas").Return(nil, ErrQuotasDisabled)
+
+				resp, err := sut.Quotas(ctx, QuotasRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(Quotas404TextResponse("quotas are disabled")))
+			})
+		})
+
+		When("Quota reset is called", func() {
+			It("should reset all usage without parameters", func() {
+				quotaMock.On("ResetQuotas", "", "").Return(nil)
+
+				resp, err := sut.QuotaReset(ctx, QuotaResetRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QuotaReset200Response{}))
+			})
+
+			It("should pass category and client", func() {
+				category := "gaming"
+				client := "laptop"
+				quotaMock.On("ResetQuotas", "gaming", "laptop").Return(nil)
+
+				resp, err := sut.QuotaReset(ctx, QuotaResetRequestObject{
+					Params: QuotaResetParams{Category: &category, Client: &client},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QuotaReset200Response{}))
+			})
+
+			It("should return 400 for an unknown category", func() {
+				category := "social"
+				quotaMock.On("ResetQuotas", "social", "").Return(fmt.Errorf("%w: social", ErrUnknownQuotaCategory))
+
+				resp, err := sut.QuotaReset(ctx, QuotaResetRequestObject{Params: QuotaResetParams{Category: &category}})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QuotaReset400TextResponse("unknown quota category: social")))
+			})
+
+			It("should return 404 if disabled", func() {
+				quotaMock.On("ResetQuotas", "", "").Return(ErrQuotasDisabled)
+
+				resp, err := sut.QuotaReset(ctx, QuotaResetRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(QuotaReset404TextResponse("quotas are disabled")))
+			})
+		})
+	})
+
+	Describe("Resolver chain API", func() {
+		BeforeEach(func() {
+			resolverChainMock.On("ResolverChain").Return([]ResolverNode{
+				{Type: "filtering", Name: "filtering"},
+				{
+					Type:    "upstream_tree",
+					Name:    "upstream_tree",
+					Enabled: true,
+					Config:  []string{"upstream \"dns\""},
+					Branches: []ResolverBranch{
+						{Name: "default", Resolvers: []ResolverNode{{Type: "strict", Name: "strict", Enabled: true}}},
+					},
+				},
+			})
+		})
+
+		It("should return the chain as JSON", func() {
+			resp, err := sut.ResolverChain(ctx, ResolverChainRequestObject{})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(Equal(ResolverChain200JSONResponse{
+				{Type: "filtering", Name: "filtering", Config: []string{}, Branches: []ApiResolverBranch{}},
+				{
+					Type:    "upstream_tree",
+					Name:    "upstream_tree",
+					Enabled: true,
+					Config:  []string{"upstream \"dns\""},
+					Branches: []ApiResolverBranch{
+						{Name: "default", Resolvers: []ApiResolverNode{
+							{Type: "strict", Name: "strict", Enabled: true, Config: []string{}, Branches: []ApiResolverBranch{}},
+						}},
+					},
+				},
+			}))
+		})
+
+		It("should return the chain as DOT", func() {
+			format := "dot"
+
+			resp, err := sut.ResolverChain(ctx, ResolverChainRequestObject{Params: ResolverChainParams{Format: &format}})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeAssignableToTypeOf(ResolverChain200TextvndGraphvizResponse{}))
+
+			body, err := io.ReadAll(resp.(ResolverChain200TextvndGraphvizResponse).Body)
+			Expect(err).Should(Succeed())
+			Expect(string(body)).Should(Equal(`digraph resolvers {
+  node [shape=box, fontname=monospace];
+  n0 [label="filtering\n", style=dashed, fontcolor=gray];
+  n1 [label="upstream_tree\nupstream \"dns\"\l"];
+  n0 -> n1;
+  n2 [label="strict\n"];
+  n1 -> n2 [label="default", style=dashed];
+}
+`))
+		})
+
+		It("should return 400 for an unknown format", func() {
+			format := "xml"
+
+			resp, err := sut.ResolverChain(ctx, ResolverChainRequestObject{Params: ResolverChainParams{Format: &format}})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(Equal(ResolverChain400TextResponse("unknown format 'xml'")))
+		})
+	})
+
+	Describe("Config dry run API", func() {
+		It("should return the chain and the results of the queries", func() {
+			answer, err := util.NewMsgWithAnswer("example.com.", 123, A, "192.0.2.1")
+			Expect(err).Should(Succeed())
+
+			dryRunnerMock.On("DryRunConfig", "upstreams: ...", []DryRunQuery{
+				{Question: "example.com.", Type: A, ClientIP: net.ParseIP("192.168.178.2")},
+				{Question: "failing.com.", Type: AAAA},
+			}).Return(DryRunResult{
+				Chain: []ResolverNode{{Type: "filtering", Name: "filtering"}},
+				Responses: []DryRunResponse{
+					{Response: &model.Response{Res: answer, Reason: model.NewReason(model.ReasonCodeRESOLVED)}},
+					{Err: errors.New("timeout")},
+				},
+			})
+
+			clientIP := "192.168.178.2"
+
+			resp, err := sut.ConfigDryRun(ctx, ConfigDryRunRequestObject{Body: &ApiConfigDryRunRequest{
+				Config: "upstreams: ...",
+				Queries: &[]ApiDryRunQuery{
+					{Query: "example.com", Type: "A", ClientIP: &clientIP},
+					{Query: "failing.com", Type: "AAAA"},
+				},
+			}})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeAssignableToTypeOf(ConfigDryRun200JSONResponse{}))
+
+			result := resp.(ConfigDryRun200JSONResponse)
+			Expect(result.Valid).Should(BeTrue())
+			Expect(result.Error).Should(BeNil())
+			Expect(result.Messages).Should(BeEmpty())
+			Expect(result.Chain).Should(HaveExactElements(HaveField("Type", "filtering")))
+			Expect(result.Queries).Should(HaveExactElements(
+				SatisfyAll(
+					HaveField("Query", "example.com."),
+					HaveField("Type", "A"),
+					HaveField("Error", BeNil()),
+					HaveField("Result.Response", "A (192.0.2.1)"),
+					HaveField("Result.ReasonCode", "RESOLVED"),
+				),
+				SatisfyAll(
+					HaveField("Query", "failing.com."),
+					HaveField("Type", "AAAA"),
+					HaveField("Error", HaveValue(Equal("timeout"))),
+					HaveField("Result", BeNil()),
+				),
+			))
+		})
+
+		It("should return the error of an invalid configuration", func() {
+			dryRunnerMock.On("DryRunConfig", "invalid", []DryRunQuery(nil)).Return(DryRunResult{
+				Err:      errors.New("wrong file structure"),
+				Messages: []string{"deprecated option"},
+			})
+
+			resp, err := sut.ConfigDryRun(ctx, ConfigDryRunRequestObject{Body: &ApiConfigDryRunRequest{Config: "invalid"}})
+			Expect(err).Should(Succeed())
+
+			errMsg := "wrong file structure"
+			Expect(resp).Should(Equal(ConfigDryRun200JSONResponse{
+				Valid:    false,
+				Error:    &errMsg,
+				Messages: []string{"deprecated option"},
+				Chain:    []ApiResolverNode{},
+				Queries:  []ApiDryRunQueryResult{},
+			}))
+		})
+
+		It("should return 400 for an unknown query type", func() {
+			resp, err := sut.ConfigDryRun(ctx, ConfigDryRunRequestObject{Body: &ApiConfigDryRunRequest{
+				Queries: &[]ApiDryRunQuery{{Query: "example.com", Type: "XYZ"}},
+			}})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(Equal(ConfigDryRun400TextResponse("unknown query type 'XYZ'")))
+		})
+
+		It("should return 400 for an invalid client IP", func() {
+			clientIP := "invalid"
+
+			resp, err := sut.ConfigDryRun(ctx, ConfigDryRunRequestObject{Body: &ApiConfigDryRunRequest{
+				Queries: &[]ApiDryRunQuery{{Query: "example.com", Type: "A", ClientIP: &clientIP}},
+			}})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(Equal(ConfigDryRun400TextResponse("invalid client IP address 'invalid'")))
+		})
+	})
+
+	Describe("Audit API", func() {
+		When("the audit log of a client is called", func() {
+			It("should return 200 with the entries", func() {
+				ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+				auditLogMock.On("AuditLog", net.ParseIP("192.168.178.2"), "").Return([]AuditEntry{
+					{
+						Time:         ts,
+						ID:           4711,
+						Type:         "A",
+						QuestionHash: "qhash",
+						AnswerHash:   "ahash",
+						ReturnCode:   "NOERROR",
+						ResponseType: "RESOLVED",
+					},
+				}, nil)
+
+				resp, err := sut.AuditLog(ctx, AuditLogRequestObject{Client: "192.168.178.2"})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(AuditLog200JSONResponse{
+					{
+						Time:         ts,
+						Id:           4711,
+						Type:         "A",
+						QuestionHash: "qhash",
+						AnswerHash:   "ahash",
+						ReturnCode:   "NOERROR",
+						ResponseType: "RESOLVED",
+					},
+				}))
+			})
+
+			It("should pass the domain", func() {
+				domain := "example.com"
+				auditLogMock.On("AuditLog", net.ParseIP("192.168.178.2"), domain).Return([]AuditEntry{}, nil)
+
+				resp, err := sut.AuditLog(ctx, AuditLogRequestObject{
+					Client: "192.168.178.2",
+					Params: AuditLogParams{Domain: &domain},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(AuditLog200JSONResponse{}))
+			})
+
+			It("should return 400 for an invalid client IP", func() {
+				resp, err := sut.AuditLog(ctx, AuditLogRequestObject{Client: "laptop"})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(AuditLog400TextResponse("invalid client IP address 'laptop'")))
+			})
+
+			It("should return 404 if disabled", func() {
+				auditLogMock.On("AuditLog", net.ParseIP("192.168.178.2"), "").Return(nil, ErrAuditDisabled)
+
+				resp, err := sut.AuditLog(ctx, AuditLogRequestObject{Client: "192.168.178.2"})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(AuditLog404TextResponse("audit is disabled")))
+			})
+		})
+
+		When("the manipulation log is called", func() {
+			It("should return 200 with the entries", func() {
+				ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+				auditLogMock.On("ManipulationLog", net.IP(nil)).Return([]ManipulationEntry{
+					{
+						Time:     ts,
+						Client:   "192.168.178.2",
+						Question: "example.com",
+						Type:     "A",
+						Changes:  []ResponseChange{{Stage: "blocking", Action: "blocked", Detail: "BLOCKED (ads)"}},
+					},
+				}, nil)
+
+				resp, err := sut.ManipulationLog(ctx, ManipulationLogRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ManipulationLog200JSONResponse{
+					{
+						Time:     ts,
+						Client:   "192.168.178.2",
+						Question: "example.com",
+						Type:     "A",
+						Changes:  []ApiResponseChange{{Stage: "blocking", Action: "blocked", Detail: "BLOCKED (ads)"}},
+					},
+				}))
+			})
+
+			It("should pass the client", func() {
+				client := "192.168.178.2"
+				auditLogMock.On("ManipulationLog", net.ParseIP(client)).Return([]ManipulationEntry{}, nil)
+
+				resp, err := sut.ManipulationLog(ctx, ManipulationLogRequestObject{
+					Params: ManipulationLogParams{Client: &client},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ManipulationLog200JSONResponse{}))
+			})
+
+			It("should return 400 for an invalid client IP", func() {
+				client := "laptop"
+
+				resp, err := sut.ManipulationLog(ctx, ManipulationLogRequestObject{
+					Params: ManipulationLogParams{Client: &client},
+				})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ManipulationLog400TextResponse("invalid client IP address 'laptop'")))
+			})
+
+			It("should return 404 if disabled", func() {
+				auditLogMock.On("ManipulationLog", net.IP(nil)).Return(nil, ErrManipulationAuditDisabled)
+
+				resp, err := sut.ManipulationLog(ctx, ManipulationLogRequestObject{})
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(Equal(ManipulationLog404TextResponse(ErrManipulationAuditDisabled.Error())))
+			})
+		})
+	})
 })
@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -16,6 +17,12 @@ import (
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Altered responses
+	// (GET /audit/manipulations)
+	ManipulationLog(w http.ResponseWriter, r *http.Request, params ManipulationLogParams)
+	// Audit log of a client
+	// (GET /audit/{client})
+	AuditLog(w http.ResponseWriter, r *http.Request, client string, params AuditLogParams)
 	// Disable blocking
 	// (GET /blocking/disable)
 	DisableBlocking(w http.ResponseWriter, r *http.Request, params DisableBlockingParams)
@@ -28,18 +35,63 @@ type ServerInterface interface {
 	// Clears the DNS response cache
 	// (POST /cache/flush)
 	CacheFlush(w http.ResponseWriter, r *http.Request)
+	// Test a configuration
+	// (POST /config/dryrun)
+	ConfigDryRun(w http.ResponseWriter, r *http.Request)
 	// List refresh
 	// (POST /lists/refresh)
-	ListRefresh(w http.ResponseWriter, r *http.Request)
+	ListRefresh(w http.ResponseWriter, r *http.Request, params ListRefreshParams)
 	// Performs DNS query
 	// (POST /query)
-	Query(w http.ResponseWriter, r *http.Request)
+	Query(w http.ResponseWriter, r *http.Request, params QueryParams)
+	// Flushes the query log
+	// (POST /querylog/flush)
+	QueryLogFlush(w http.ResponseWriter, r *http.Request)
+	// Rotates the query log files
+	// (POST /querylog/rotate)
+	QueryLogRotate(w http.ResponseWriter, r *http.Request, params QueryLogRotateParams)
+	// Usage of the quota categories
+	// (GET /quotas)
+	Quotas(w http.ResponseWriter, r *http.Request)
+	// Resets the usage of the quota categories
+	// (POST /quotas/reset)
+	QuotaReset(w http.ResponseWriter, r *http.Request, params QuotaResetParams)
+	// Reload a subsystem
+	// (POST /reload/{subsystem})
+	Reload(w http.ResponseWriter, r *http.Request, subsystem ReloadParamsSubsystem)
+	// Resolver chain
+	// (GET /resolvers/chain)
+	ResolverChain(w http.ResponseWriter, r *http.Request, params ResolverChainParams)
+	// Export the runtime state
+	// (GET /state/export)
+	StateExport(w http.ResponseWriter, r *http.Request)
+	// Import the runtime state
+	// (POST /state/import)
+	StateImport(w http.ResponseWriter, r *http.Request)
+	// Statistics per client
+	// (GET /stats/clients)
+	ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams)
+	// Statistics per upstream
+	// (GET /upstreams/stats)
+	UpstreamStats(w http.ResponseWriter, r *http.Request)
 }
 
 // Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
 
 type Unimplemented struct{}
 
+// Altered responses
+// (GET /audit/manipulations)
+func (_ Unimplemented) ManipulationLog(w http.ResponseWriter, r *http.Request, params ManipulationLogParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Audit log of a client
+// (GET /audit/{client})
+func (_ Unimplemented) AuditLog(w http.ResponseWriter, r *http.Request, client string, params AuditLogParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Disable blocking
 // (GET /blocking/disable)
 func (_ Unimplemented) DisableBlocking(w http.ResponseWriter, r *http.Request, params DisableBlockingParams) {
@@ -64,15 +116,81 @@ func (_ Unimplemented) CacheFlush(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Test a configuration
+// (POST /config/dryrun)
+func (_ Unimplemented) ConfigDryRun(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // List refresh
 // (POST /lists/refresh)
-func (_ Unimplemented) ListRefresh(w http.ResponseWriter, r *http.Request) {
+func (_ Unimplemented) ListRefresh(w http.ResponseWriter, r *http.Request, params ListRefreshParams) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
 // Performs DNS query
 // (POST /query)
-func (_ Unimplemented) Query(w http.ResponseWriter, r *http.Request) {
+func (_ Unimplemented) Query(w http.ResponseWriter, r *http.Request, params QueryParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Flushes the query log
+// (POST /querylog/flush)
+func (_ Unimplemented) QueryLogFlush(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Rotates the query log files
+// (POST /querylog/rotate)
+func (_ Unimplemented) QueryLogRotate(w http.ResponseWriter, r *http.Request, params QueryLogRotateParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Usage of the quota categories
+// (GET /quotas)
+func (_ Unimplemented) Quotas(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Resets the usage of the quota categories
+// (POST /quotas/reset)
+func (_ Unimplemented) QuotaReset(w http.ResponseWriter, r *http.Request, params QuotaResetParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Reload a subsystem
+// (POST /reload/{subsystem})
+func (_ Unimplemented) Reload(w http.ResponseWriter, r *http.Request, subsystem ReloadParamsSubsystem) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Resolver chain
+// (GET /resolvers/chain)
+func (_ Unimplemented) ResolverChain(w http.ResponseWriter, r *http.Request, params ResolverChainParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Export the runtime state
+// (GET /state/export)
+func (_ Unimplemented) StateExport(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Import the runtime state
+// (POST /state/import)
+func (_ Unimplemented) StateImport(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Statistics per client
+// (GET /stats/clients)
+func (_ Unimplemented) ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Statistics per upstream
+// (GET /upstreams/stats)
+func (_ Unimplemented) UpstreamStats(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
@@ -85,6 +203,69 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// ManipulationLog operation middleware
+func (siw *ServerInterfaceWrapper) ManipulationLog(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ManipulationLogParams
+
+	// ------------- Optional query parameter "client" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client", r.URL.Query(), &params.Client)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ManipulationLog(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AuditLog operation middleware
+func (siw *ServerInterfaceWrapper) AuditLog(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "client" -------------
+	var client string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "client", chi.URLParam(r, "client"), &client, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params AuditLogParams
+
+	// ------------- Optional query parameter "domain" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "domain", r.URL.Query(), &params.Domain)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "domain", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AuditLog(w, r, client, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // DisableBlocking operation middleware
 func (siw *ServerInterfaceWrapper) DisableBlocking(w http.ResponseWriter, r *http.Request) {
 
@@ -162,11 +343,11 @@ func (siw *ServerInterfaceWrapper) CacheFlush(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
-// ListRefresh operation middleware
-func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Request) {
+// ConfigDryRun operation middleware
+func (siw *ServerInterfaceWrapper) ConfigDryRun(w http.ResponseWriter, r *http.Request) {
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.ListRefresh(w, r)
+		siw.Handler.ConfigDryRun(w, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -176,11 +357,24 @@ func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r)
 }
 
-// Query operation middleware
-func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request) {
+// ListRefresh operation middleware
+func (siw *ServerInterfaceWrapper) ListRefresh(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListRefreshParams
+
+	// ------------- Optional query parameter "group" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "group", r.URL.Query(), &params.Group)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "group", Err: err})
+		return
+	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.Query(w, r)
+		siw.Handler.ListRefresh(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -190,156 +384,508 @@ func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request)
 	handler.ServeHTTP(w, r)
 }
 
-type UnescapedCookieParamError struct {
-	ParamName string
-	Err       error
-}
+// Query operation middleware
+func (siw *ServerInterfaceWrapper) Query(w http.ResponseWriter, r *http.Request) {
 
-func (e *UnescapedCookieParamError) Error() string {
-	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
-}
+	var err error
 
-func (e *UnescapedCookieParamError) Unwrap() error {
-	return e.Err
-}
+	// Parameter object where we will unmarshal all parameters from the context
+	var params QueryParams
 
-type UnmarshalingParamError struct {
-	ParamName string
-	Err       error
-}
+	// ------------- Optional query parameter "debug" -------------
 
-func (e *UnmarshalingParamError) Error() string {
-	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
-}
+	err = runtime.BindQueryParameter("form", true, false, "debug", r.URL.Query(), &params.Debug)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "debug", Err: err})
+		return
+	}
 
-func (e *UnmarshalingParamError) Unwrap() error {
-	return e.Err
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Query(w, r, params)
+	}))
 
-type RequiredParamError struct {
-	ParamName string
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-func (e *RequiredParamError) Error() string {
-	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+	handler.ServeHTTP(w, r)
 }
 
-type RequiredHeaderError struct {
-	ParamName string
-	Err       error
-}
+// QueryLogFlush operation middleware
+func (siw *ServerInterfaceWrapper) QueryLogFlush(w http.ResponseWriter, r *http.Request) {
 
-func (e *RequiredHeaderError) Error() string {
-	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.QueryLogFlush(w, r)
+	}))
 
-func (e *RequiredHeaderError) Unwrap() error {
-	return e.Err
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-type InvalidParamFormatError struct {
-	ParamName string
-	Err       error
+	handler.ServeHTTP(w, r)
 }
 
-func (e *InvalidParamFormatError) Error() string {
-	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
-}
+// QueryLogRotate operation middleware
+func (siw *ServerInterfaceWrapper) QueryLogRotate(w http.ResponseWriter, r *http.Request) {
 
-func (e *InvalidParamFormatError) Unwrap() error {
-	return e.Err
-}
+	var err error
 
-type TooManyValuesForParamError struct {
-	ParamName string
-	Count     int
-}
+	// Parameter object where we will unmarshal all parameters from the context
+	var params QueryLogRotateParams
 
-func (e *TooManyValuesForParamError) Error() string {
-	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
-}
+	// ------------- Optional query parameter "gzip" -------------
 
-// Handler creates http.Handler with routing matching OpenAPI spec.
-func Handler(si ServerInterface) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{})
-}
+	err = runtime.BindQueryParameter("form", true, false, "gzip", r.URL.Query(), &params.Gzip)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "gzip", Err: err})
+		return
+	}
 
-type ChiServerOptions struct {
-	BaseURL          string
-	BaseRouter       chi.Router
-	Middlewares      []MiddlewareFunc
-	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.QueryLogRotate(w, r, params)
+	}))
 
-// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
-func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{
-		BaseRouter: r,
-	})
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
-	return HandlerWithOptions(si, ChiServerOptions{
-		BaseURL:    baseURL,
-		BaseRouter: r,
-	})
+	handler.ServeHTTP(w, r)
 }
 
-// HandlerWithOptions creates http.Handler with additional options
-func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
-	r := options.BaseRouter
+// Quotas operation middleware
+func (siw *ServerInterfaceWrapper) Quotas(w http.ResponseWriter, r *http.Request) {
 
-	if r == nil {
-		r = chi.NewRouter()
-	}
-	if options.ErrorHandlerFunc == nil {
-		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
-	}
-	wrapper := ServerInterfaceWrapper{
-		Handler:            si,
-		HandlerMiddlewares: options.Middlewares,
-		ErrorHandlerFunc:   options.ErrorHandlerFunc,
-	}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Quotas(w, r)
+	}))
 
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/disable", wrapper.DisableBlocking)
-	})
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/enable", wrapper.EnableBlocking)
-	})
-	r.Group(func(r chi.Router) {
-		r.Get(options.BaseURL+"/blocking/status", wrapper.BlockingStatus)
-	})
-	r.Group(func(r chi.Router) {
-		r.Post(options.BaseURL+"/cache/flush", wrapper.CacheFlush)
-	})
-	r.Group(func(r chi.Router) {
-		r.Post(options.BaseURL+"/lists/refresh", wrapper.ListRefresh)
-	})
-	r.Group(func(r chi.Router) {
-		r.Post(options.BaseURL+"/query", wrapper.Query)
-	})
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-	return r
+	handler.ServeHTTP(w, r)
 }
 
-type DisableBlockingRequestObject struct {
-	Params DisableBlockingParams
-}
+// QuotaReset operation middleware
+func (siw *ServerInterfaceWrapper) QuotaReset(w http.ResponseWriter, r *http.Request) {
 
-type DisableBlockingResponseObject interface {
-	VisitDisableBlockingResponse(w http.ResponseWriter) error
-}
+	var err error
 
-type DisableBlocking200Response struct {
-}
+	// Parameter object where we will unmarshal all parameters from the context
+	var params QuotaResetParams
 
-func (response DisableBlocking200Response) VisitDisableBlockingResponse(w http.ResponseWriter) error {
-	w.WriteHeader(200)
-	return nil
-}
+	// ------------- Optional query parameter "category" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "category", r.URL.Query(), &params.Category)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "category", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "client" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "client", r.URL.Query(), &params.Client)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "client", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.QuotaReset(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Reload operation middleware
+func (siw *ServerInterfaceWrapper) Reload(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "subsystem" -------------
+	var subsystem ReloadParamsSubsystem
+
+	err = runtime.BindStyledParameterWithOptions("simple", "subsystem", chi.URLParam(r, "subsystem"), &subsystem, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "subsystem", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Reload(w, r, subsystem)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ResolverChain operation middleware
+func (siw *ServerInterfaceWrapper) ResolverChain(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ResolverChainParams
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "format", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ResolverChain(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// StateExport operation middleware
+func (siw *ServerInterfaceWrapper) StateExport(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StateExport(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// StateImport operation middleware
+func (siw *ServerInterfaceWrapper) StateImport(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StateImport(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ClientStats operation middleware
+func (siw *ServerInterfaceWrapper) ClientStats(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ClientStatsParams
+
+	// ------------- Optional query parameter "days" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "days", r.URL.Query(), &params.Days)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "days", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ClientStats(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpstreamStats operation middleware
+func (siw *ServerInterfaceWrapper) UpstreamStats(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpstreamStats(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/audit/manipulations", wrapper.ManipulationLog)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/audit/{client}", wrapper.AuditLog)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/disable", wrapper.DisableBlocking)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/enable", wrapper.EnableBlocking)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/blocking/status", wrapper.BlockingStatus)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/cache/flush", wrapper.CacheFlush)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/config/dryrun", wrapper.ConfigDryRun)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/lists/refresh", wrapper.ListRefresh)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/query", wrapper.Query)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/querylog/flush", wrapper.QueryLogFlush)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/querylog/rotate", wrapper.QueryLogRotate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/quotas", wrapper.Quotas)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/quotas/reset", wrapper.QuotaReset)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/reload/{subsystem}", wrapper.Reload)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/resolvers/chain", wrapper.ResolverChain)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/state/export", wrapper.StateExport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/state/import", wrapper.StateImport)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/stats/clients", wrapper.ClientStats)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/upstreams/stats", wrapper.UpstreamStats)
+	})
+
+	return r
+}
+
+type ManipulationLogRequestObject struct {
+	Params ManipulationLogParams
+}
+
+type ManipulationLogResponseObject interface {
+	VisitManipulationLogResponse(w http.ResponseWriter) error
+}
+
+type ManipulationLog200JSONResponse []ApiManipulationEntry
+
+func (response ManipulationLog200JSONResponse) VisitManipulationLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ManipulationLog400TextResponse string
+
+func (response ManipulationLog400TextResponse) VisitManipulationLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type ManipulationLog404TextResponse string
+
+func (response ManipulationLog404TextResponse) VisitManipulationLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(404)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type AuditLogRequestObject struct {
+	Client string `json:"client"`
+	Params AuditLogParams
+}
+
+type AuditLogResponseObject interface {
+	VisitAuditLogResponse(w http.ResponseWriter) error
+}
+
+type AuditLog200JSONResponse []ApiAuditEntry
+
+func (response AuditLog200JSONResponse) VisitAuditLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AuditLog400TextResponse string
+
+func (response AuditLog400TextResponse) VisitAuditLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type AuditLog404TextResponse string
+
+func (response AuditLog404TextResponse) VisitAuditLogResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(404)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type DisableBlockingRequestObject struct {
+	Params DisableBlockingParams
+}
+
+type DisableBlockingResponseObject interface {
+	VisitDisableBlockingResponse(w http.ResponseWriter) error
+}
+
+type DisableBlocking200Response struct {
+}
+
+func (response DisableBlocking200Response) VisitDisableBlockingResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
 
 type DisableBlocking400TextResponse string
 
@@ -397,7 +943,35 @@ func (response CacheFlush200Response) VisitCacheFlushResponse(w http.ResponseWri
 	return nil
 }
 
+type ConfigDryRunRequestObject struct {
+	Body *ConfigDryRunJSONRequestBody
+}
+
+type ConfigDryRunResponseObject interface {
+	VisitConfigDryRunResponse(w http.ResponseWriter) error
+}
+
+type ConfigDryRun200JSONResponse ApiConfigDryRunResult
+
+func (response ConfigDryRun200JSONResponse) VisitConfigDryRunResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ConfigDryRun400TextResponse string
+
+func (response ConfigDryRun400TextResponse) VisitConfigDryRunResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
 type ListRefreshRequestObject struct {
+	Params ListRefreshParams
 }
 
 type ListRefreshResponseObject interface {
@@ -412,6 +986,16 @@ func (response ListRefresh200Response) VisitListRefreshResponse(w http.ResponseW
 	return nil
 }
 
+type ListRefresh400TextResponse string
+
+func (response ListRefresh400TextResponse) VisitListRefreshResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
 type ListRefresh500TextResponse string
 
 func (response ListRefresh500TextResponse) VisitListRefreshResponse(w http.ResponseWriter) error {
@@ -423,20 +1007,37 @@ func (response ListRefresh500TextResponse) VisitListRefreshResponse(w http.Respo
 }
 
 type QueryRequestObject struct {
-	Body *QueryJSONRequestBody
+	Params QueryParams
+	Body   *QueryJSONRequestBody
 }
 
 type QueryResponseObject interface {
 	VisitQueryResponse(w http.ResponseWriter) error
 }
 
-type Query200JSONResponse ApiQueryResult
+type Query200ResponseHeaders struct {
+	XBlockyUpstream         string
+	XBlockyUpstreamGroup    string
+	XBlockyUpstreamProtocol string
+	XBlockyUpstreamRetries  int
+	XBlockyUpstreamRtt      int
+}
+
+type Query200JSONResponse struct {
+	Body    ApiQueryResult
+	Headers Query200ResponseHeaders
+}
 
 func (response Query200JSONResponse) VisitQueryResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Blocky-Upstream", fmt.Sprint(response.Headers.XBlockyUpstream))
+	w.Header().Set("X-Blocky-Upstream-Group", fmt.Sprint(response.Headers.XBlockyUpstreamGroup))
+	w.Header().Set("X-Blocky-Upstream-Protocol", fmt.Sprint(response.Headers.XBlockyUpstreamProtocol))
+	w.Header().Set("X-Blocky-Upstream-Retries", fmt.Sprint(response.Headers.XBlockyUpstreamRetries))
+	w.Header().Set("X-Blocky-Upstream-Rtt", fmt.Sprint(response.Headers.XBlockyUpstreamRtt))
 	w.WriteHeader(200)
 
-	return json.NewEncoder(w).Encode(response)
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
 type Query400TextResponse string
@@ -445,12 +1046,308 @@ func (response Query400TextResponse) VisitQueryResponse(w http.ResponseWriter) e
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(400)
 
-	_, err := w.Write([]byte(response))
-	return err
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QueryLogFlushRequestObject struct {
+}
+
+type QueryLogFlushResponseObject interface {
+	VisitQueryLogFlushResponse(w http.ResponseWriter) error
+}
+
+type QueryLogFlush200Response struct {
+}
+
+func (response QueryLogFlush200Response) VisitQueryLogFlushResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type QueryLogFlush500TextResponse string
+
+func (response QueryLogFlush500TextResponse) VisitQueryLogFlushResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(500)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QueryLogRotateRequestObject struct {
+	Params QueryLogRotateParams
+}
+
+type QueryLogRotateResponseObject interface {
+	VisitQueryLogRotateResponse(w http.ResponseWriter) error
+}
+
+type QueryLogRotate200Response struct {
+}
+
+func (response QueryLogRotate200Response) VisitQueryLogRotateResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type QueryLogRotate400TextResponse string
+
+func (response QueryLogRotate400TextResponse) VisitQueryLogRotateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QueryLogRotate500TextResponse string
+
+func (response QueryLogRotate500TextResponse) VisitQueryLogRotateResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(500)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QuotasRequestObject struct {
+}
+
+type QuotasResponseObject interface {
+	VisitQuotasResponse(w http.ResponseWriter) error
+}
+
+type Quotas200JSONResponse []ApiQuotaUsage
+
+func (response Quotas200JSONResponse) VisitQuotasResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type Quotas404TextResponse string
+
+func (response Quotas404TextResponse) VisitQuotasResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(404)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QuotaResetRequestObject struct {
+	Params QuotaResetParams
+}
+
+type QuotaResetResponseObject interface {
+	VisitQuotaResetResponse(w http.ResponseWriter) error
+}
+
+type QuotaReset200Response struct {
+}
+
+func (response QuotaReset200Response) VisitQuotaResetResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type QuotaReset400TextResponse string
+
+func (response QuotaReset400TextResponse) VisitQuotaResetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type QuotaReset404TextResponse string
+
+func (response QuotaReset404TextResponse) VisitQuotaResetResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(404)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type ReloadRequestObject struct {
+	Subsystem ReloadParamsSubsystem `json:"subsystem"`
+}
+
+type ReloadResponseObject interface {
+	VisitReloadResponse(w http.ResponseWriter) error
+}
+
+type Reload200Response struct {
+}
+
+func (response Reload200Response) VisitReloadResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type Reload400TextResponse string
+
+func (response Reload400TextResponse) VisitReloadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type Reload500TextResponse string
+
+func (response Reload500TextResponse) VisitReloadResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(500)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type ResolverChainRequestObject struct {
+	Params ResolverChainParams
+}
+
+type ResolverChainResponseObject interface {
+	VisitResolverChainResponse(w http.ResponseWriter) error
+}
+
+type ResolverChain200JSONResponse []ApiResolverNode
+
+func (response ResolverChain200JSONResponse) VisitResolverChainResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResolverChain200TextvndGraphvizResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response ResolverChain200TextvndGraphvizResponse) VisitResolverChainResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/vnd.graphviz")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type ResolverChain400TextResponse string
+
+func (response ResolverChain400TextResponse) VisitResolverChainResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type StateExportRequestObject struct {
+}
+
+type StateExportResponseObject interface {
+	VisitStateExportResponse(w http.ResponseWriter) error
+}
+
+type StateExport200JSONResponse ApiRuntimeState
+
+func (response StateExport200JSONResponse) VisitStateExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type StateImportRequestObject struct {
+	Body *StateImportJSONRequestBody
+}
+
+type StateImportResponseObject interface {
+	VisitStateImportResponse(w http.ResponseWriter) error
+}
+
+type StateImport200Response struct {
+}
+
+func (response StateImport200Response) VisitStateImportResponse(w http.ResponseWriter) error {
+	w.WriteHeader(200)
+	return nil
+}
+
+type StateImport400TextResponse string
+
+func (response StateImport400TextResponse) VisitStateImportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(400)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type ClientStatsRequestObject struct {
+	Params ClientStatsParams
+}
+
+type ClientStatsResponseObject interface {
+	VisitClientStatsResponse(w http.ResponseWriter) error
+}
+
+type ClientStats200JSONResponse []ApiClientStats
+
+func (response ClientStats200JSONResponse) VisitClientStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ClientStats404TextResponse string
+
+func (response ClientStats404TextResponse) VisitClientStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(404)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type UpstreamStatsRequestObject struct {
+}
+
+type UpstreamStatsResponseObject interface {
+	VisitUpstreamStatsResponse(w http.ResponseWriter) error
+}
+
+type UpstreamStats200JSONResponse []ApiUpstreamStats
+
+func (response UpstreamStats200JSONResponse) VisitUpstreamStatsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
 }
 
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
+	// Altered responses
+	// (GET /audit/manipulations)
+	ManipulationLog(ctx context.Context, request ManipulationLogRequestObject) (ManipulationLogResponseObject, error)
+	// Audit log of a client
+	// (GET /audit/{client})
+	AuditLog(ctx context.Context, request AuditLogRequestObject) (AuditLogResponseObject, error)
 	// Disable blocking
 	// (GET /blocking/disable)
 	DisableBlocking(ctx context.Context, request DisableBlockingRequestObject) (DisableBlockingResponseObject, error)
@@ -463,12 +1360,45 @@ type StrictServerInterface interface {
 	// Clears the DNS response cache
 	// (POST /cache/flush)
 	CacheFlush(ctx context.Context, request CacheFlushRequestObject) (CacheFlushResponseObject, error)
+	// Test a configuration
+	// (POST /config/dryrun)
+	ConfigDryRun(ctx context.Context, request ConfigDryRunRequestObject) (ConfigDryRunResponseObject, error)
 	// List refresh
 	// (POST /lists/refresh)
 	ListRefresh(ctx context.Context, request ListRefreshRequestObject) (ListRefreshResponseObject, error)
 	// Performs DNS query
 	// (POST /query)
 	Query(ctx context.Context, request QueryRequestObject) (QueryResponseObject, error)
+	// Flushes the query log
+	// (POST /querylog/flush)
+	QueryLogFlush(ctx context.Context, request QueryLogFlushRequestObject) (QueryLogFlushResponseObject, error)
+	// Rotates the query log files
+	// (POST /querylog/rotate)
+	QueryLogRotate(ctx context.Context, request QueryLogRotateRequestObject) (QueryLogRotateResponseObject, error)
+	// Usage of the quota categories
+	// (GET /quotas)
+	Quotas(ctx context.Context, request QuotasRequestObject) (QuotasResponseObject, error)
+	// Resets the usage of the quota categories
+	// (POST /quotas/reset)
+	QuotaReset(ctx context.Context, request QuotaResetRequestObject) (QuotaResetResponseObject, error)
+	// Reload a subsystem
+	// (POST /reload/{subsystem})
+	Reload(ctx context.Context, request ReloadRequestObject) (ReloadResponseObject, error)
+	// Resolver chain
+	// (GET /resolvers/chain)
+	ResolverChain(ctx context.Context, request ResolverChainRequestObject) (ResolverChainResponseObject, error)
+	// Export the runtime state
+	// (GET /state/export)
+	StateExport(ctx context.Context, request StateExportRequestObject) (StateExportResponseObject, error)
+	// Import the runtime state
+	// (POST /state/import)
+	StateImport(ctx context.Context, request StateImportRequestObject) (StateImportResponseObject, error)
+	// Statistics per client
+	// (GET /stats/clients)
+	ClientStats(ctx context.Context, request ClientStatsRequestObject) (ClientStatsResponseObject, error)
+	// Statistics per upstream
+	// (GET /upstreams/stats)
+	UpstreamStats(ctx context.Context, request UpstreamStatsRequestObject) (UpstreamStatsResponseObject, error)
 }
 
 type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
@@ -500,6 +1430,59 @@ type strictHandler struct {
 	options     StrictHTTPServerOptions
 }
 
+// ManipulationLog operation middleware
+func (sh *strictHandler) ManipulationLog(w http.ResponseWriter, r *http.Request, params ManipulationLogParams) {
+	var request ManipulationLogRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ManipulationLog(ctx, request.(ManipulationLogRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ManipulationLog")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ManipulationLogResponseObject); ok {
+		if err := validResponse.VisitManipulationLogResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// AuditLog operation middleware
+func (sh *strictHandler) AuditLog(w http.ResponseWriter, r *http.Request, client string, params AuditLogParams) {
+	var request AuditLogRequestObject
+
+	request.Client = client
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AuditLog(ctx, request.(AuditLogRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AuditLog")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AuditLogResponseObject); ok {
+		if err := validResponse.VisitAuditLogResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // DisableBlocking operation middleware
 func (sh *strictHandler) DisableBlocking(w http.ResponseWriter, r *http.Request, params DisableBlockingParams) {
 	var request DisableBlockingRequestObject
@@ -598,10 +1581,43 @@ func (sh *strictHandler) CacheFlush(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ConfigDryRun operation middleware
+func (sh *strictHandler) ConfigDryRun(w http.ResponseWriter, r *http.Request) {
+	var request ConfigDryRunRequestObject
+
+	var body ConfigDryRunJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ConfigDryRun(ctx, request.(ConfigDryRunRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ConfigDryRun")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ConfigDryRunResponseObject); ok {
+		if err := validResponse.VisitConfigDryRunResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
 // ListRefresh operation middleware
-func (sh *strictHandler) ListRefresh(w http.ResponseWriter, r *http.Request) {
+func (sh *strictHandler) ListRefresh(w http.ResponseWriter, r *http.Request, params ListRefreshParams) {
 	var request ListRefreshRequestObject
 
+	request.Params = params
+
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
 		return sh.ssi.ListRefresh(ctx, request.(ListRefreshRequestObject))
 	}
@@ -623,9 +1639,11 @@ func (sh *strictHandler) ListRefresh(w http.ResponseWriter, r *http.Request) {
 }
 
 // Query operation middleware
-func (sh *strictHandler) Query(w http.ResponseWriter, r *http.Request) {
+func (sh *strictHandler) Query(w http.ResponseWriter, r *http.Request, params QueryParams) {
 	var request QueryRequestObject
 
+	request.Params = params
+
 	var body QueryJSONRequestBody
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
@@ -652,3 +1670,260 @@ func (sh *strictHandler) Query(w http.ResponseWriter, r *http.Request) {
 		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
 	}
 }
+
+// QueryLogFlush operation middleware
+func (sh *strictHandler) QueryLogFlush(w http.ResponseWriter, r *http.Request) {
+	var request QueryLogFlushRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.QueryLogFlush(ctx, request.(QueryLogFlushRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "QueryLogFlush")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(QueryLogFlushResponseObject); ok {
+		if err := validResponse.VisitQueryLogFlushResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// QueryLogRotate operation middleware
+func (sh *strictHandler) QueryLogRotate(w http.ResponseWriter, r *http.Request, params QueryLogRotateParams) {
+	var request QueryLogRotateRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.QueryLogRotate(ctx, request.(QueryLogRotateRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "QueryLogRotate")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(QueryLogRotateResponseObject); ok {
+		if err := validResponse.VisitQueryLogRotateResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Quotas operation middleware
+func (sh *strictHandler) Quotas(w http.ResponseWriter, r *http.Request) {
+	var request QuotasRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.Quotas(ctx, request.(QuotasRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "Quotas")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(QuotasResponseObject); ok {
+		if err := validResponse.VisitQuotasResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// QuotaReset operation middleware
+func (sh *strictHandler) QuotaReset(w http.ResponseWriter, r *http.Request, params QuotaResetParams) {
+	var request QuotaResetRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.QuotaReset(ctx, request.(QuotaResetRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "QuotaReset")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(QuotaResetResponseObject); ok {
+		if err := validResponse.VisitQuotaResetResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Reload operation middleware
+func (sh *strictHandler) Reload(w http.ResponseWriter, r *http.Request, subsystem ReloadParamsSubsystem) {
+	var request ReloadRequestObject
+
+	request.Subsystem = subsystem
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.Reload(ctx, request.(ReloadRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "Reload")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ReloadResponseObject); ok {
+		if err := validResponse.VisitReloadResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ResolverChain operation middleware
+func (sh *strictHandler) ResolverChain(w http.ResponseWriter, r *http.Request, params ResolverChainParams) {
+	var request ResolverChainRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ResolverChain(ctx, request.(ResolverChainRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ResolverChain")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ResolverChainResponseObject); ok {
+		if err := validResponse.VisitResolverChainResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// StateExport operation middleware
+func (sh *strictHandler) StateExport(w http.ResponseWriter, r *http.Request) {
+	var request StateExportRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.StateExport(ctx, request.(StateExportRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "StateExport")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(StateExportResponseObject); ok {
+		if err := validResponse.VisitStateExportResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// StateImport operation middleware
+func (sh *strictHandler) StateImport(w http.ResponseWriter, r *http.Request) {
+	var request StateImportRequestObject
+
+	var body StateImportJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.StateImport(ctx, request.(StateImportRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "StateImport")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(StateImportResponseObject); ok {
+		if err := validResponse.VisitStateImportResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ClientStats operation middleware
+func (sh *strictHandler) ClientStats(w http.ResponseWriter, r *http.Request, params ClientStatsParams) {
+	var request ClientStatsRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ClientStats(ctx, request.(ClientStatsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ClientStats")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ClientStatsResponseObject); ok {
+		if err := validResponse.VisitClientStatsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpstreamStats operation middleware
+func (sh *strictHandler) UpstreamStats(w http.ResponseWriter, r *http.Request) {
+	var request UpstreamStatsRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpstreamStats(ctx, request.(UpstreamStatsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpstreamStats")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpstreamStatsResponseObject); ok {
+		if err := validResponse.VisitUpstreamStatsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
@@ -3,6 +3,62 @@
 // Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
 package api
 
+import (
+	"time"
+)
+
+// Defines values for ApiUpstreamStatsState.
+const (
+	Disabled    ApiUpstreamStatsState = "disabled"
+	Healthy     ApiUpstreamStatsState = "healthy"
+	Hijacked    ApiUpstreamStatsState = "hijacked"
+	Unreachable ApiUpstreamStatsState = "unreachable"
+)
+
+// Defines values for ReloadParamsSubsystem.
+const (
+	Customdns ReloadParamsSubsystem = "customdns"
+	Lists     ReloadParamsSubsystem = "lists"
+	Querylog  ReloadParamsSubsystem = "querylog"
+	Upstreams ReloadParamsSubsystem = "upstreams"
+)
+
+// ApiAuditEntry defines model for api.AuditEntry.
+type ApiAuditEntry struct {
+	// AnswerHash SHA-256 hash of the answer records, empty if the answer is empty
+	AnswerHash string `json:"answerHash"`
+
+	// Id DNS transaction ID of the query
+	Id int `json:"id"`
+
+	// QuestionHash SHA-256 hash of the queried domain
+	QuestionHash string `json:"questionHash"`
+
+	// ResponseType response type (RESOLVED, CACHED, BLOCKED, ...)
+	ResponseType string `json:"responseType"`
+
+	// ReturnCode DNS return code (NOERROR, NXDOMAIN, ...)
+	ReturnCode string `json:"returnCode"`
+
+	// Time time of the response
+	Time time.Time `json:"time"`
+
+	// Type query type (A, AAAA, ...)
+	Type string `json:"type"`
+}
+
+// ApiBlockingState defines model for api.BlockingState.
+type ApiBlockingState struct {
+	// AutoEnableAt If blocking is temporary disabled, time when blocking will be enabled
+	AutoEnableAt *time.Time `json:"autoEnableAt,omitempty"`
+
+	// DisabledGroups Disabled group names
+	DisabledGroups *[]string `json:"disabledGroups,omitempty"`
+
+	// Enabled True if blocking is enabled
+	Enabled bool `json:"enabled"`
+}
+
 // ApiBlockingStatus defines model for api.BlockingStatus.
 type ApiBlockingStatus struct {
 	// AutoEnableInSec If blocking is temporary disabled: amount of seconds until blocking will be enabled
@@ -15,6 +71,108 @@ type ApiBlockingStatus struct {
 	Enabled bool `json:"enabled"`
 }
 
+// ApiClientStats defines model for api.ClientStats.
+type ApiClientStats struct {
+	// Blocked count of blocked queries
+	Blocked int64 `json:"blocked"`
+
+	// Categories count of queries per category of the queried domains
+	Categories *map[string]int64 `json:"categories,omitempty"`
+
+	// Client client name(s), comma separated
+	Client string `json:"client"`
+
+	// ResponseTypes count of queries per response type (RESOLVED, CACHED, BLOCKED, ...)
+	ResponseTypes map[string]int64 `json:"responseTypes"`
+
+	// Tenant tenant of the client, empty if the client isn't assigned to a tenant
+	Tenant *string `json:"tenant,omitempty"`
+
+	// TopDomains most queried domains, most queried first
+	TopDomains []ApiDomainCount `json:"topDomains"`
+
+	// Total count of all queries
+	Total int64 `json:"total"`
+}
+
+// ApiConfigDryRunRequest defines model for api.ConfigDryRunRequest.
+type ApiConfigDryRunRequest struct {
+	// Config candidate configuration (YAML)
+	Config string `json:"config"`
+
+	// Queries test queries, which are resolved with the candidate configuration
+	Queries *[]ApiDryRunQuery `json:"queries,omitempty"`
+}
+
+// ApiConfigDryRunResult defines model for api.ConfigDryRunResult.
+type ApiConfigDryRunResult struct {
+	// Chain resolver chain of the candidate configuration
+	Chain []ApiResolverNode `json:"chain"`
+
+	// Error error of the configuration or of the creation of the resolver chain
+	Error *string `json:"error,omitempty"`
+
+	// Messages messages of the validation, e.g. warnings about invalid values or deprecated options
+	Messages []string `json:"messages"`
+
+	// Queries results of the test queries in the order of the request
+	Queries []ApiDryRunQueryResult `json:"queries"`
+
+	// Valid true, if the configuration is valid and the resolver chain was created
+	Valid bool `json:"valid"`
+}
+
+// ApiDomainCount defines model for api.DomainCount.
+type ApiDomainCount struct {
+	// Count count of queries
+	Count int64 `json:"count"`
+
+	// Domain queried domain
+	Domain string `json:"domain"`
+}
+
+// ApiDryRunQuery defines model for api.DryRunQuery.
+type ApiDryRunQuery struct {
+	// ClientIP IP of the client, which determines the client groups. The caller of the API if empty
+	ClientIP *string `json:"clientIP,omitempty"`
+
+	// Query query for DNS request
+	Query string `json:"query"`
+
+	// Type request type (A, AAAA, ...)
+	Type string `json:"type"`
+}
+
+// ApiDryRunQueryResult defines model for api.DryRunQueryResult.
+type ApiDryRunQueryResult struct {
+	// Error error of the query
+	Error *string `json:"error,omitempty"`
+
+	// Query query for DNS request
+	Query  string          `json:"query"`
+	Result *ApiQueryResult `json:"result,omitempty"`
+
+	// Type request type (A, AAAA, ...)
+	Type string `json:"type"`
+}
+
+// ApiManipulationEntry defines model for api.ManipulationEntry.
+type ApiManipulationEntry struct {
+	Changes []ApiResponseChange `json:"changes"`
+
+	// Client IP address of the client
+	Client string `json:"client"`
+
+	// Question queried domain
+	Question string `json:"question"`
+
+	// Time time of the response
+	Time time.Time `json:"time"`
+
+	// Type query type (A, AAAA, ...)
+	Type string `json:"type"`
+}
+
 // ApiQueryRequest defines model for api.QueryRequest.
 type ApiQueryRequest struct {
 	// Query query for DNS request
@@ -29,6 +187,15 @@ type ApiQueryResult struct {
 	// Reason blocky reason for resolution
 	Reason string `json:"reason"`
 
+	// ReasonCode code of the reason (RESOLVED, CACHED, BLOCKED, ...)
+	ReasonCode string `json:"reasonCode"`
+
+	// ReasonParams parameters of the reason, e.g. the list groups of a blocked query
+	ReasonParams *[]string `json:"reasonParams,omitempty"`
+
+	// ReasonSubject subject of the reason, e.g. the type of the blocked answer record (CNAME, IP, ...)
+	ReasonSubject *string `json:"reasonSubject,omitempty"`
+
 	// Response actual DNS response
 	Response string `json:"response"`
 
@@ -37,6 +204,164 @@ type ApiQueryResult struct {
 
 	// ReturnCode DNS return code (NOERROR, NXDOMAIN, ...)
 	ReturnCode string `json:"returnCode"`
+
+	// Trace trace of the resolver chain (only for debug queries)
+	Trace *[]string `json:"trace,omitempty"`
+
+	// Upstream upstream, which answered the query (only if resolved by an upstream)
+	Upstream *ApiQueryUpstream `json:"upstream,omitempty"`
+}
+
+// ApiQueryUpstream upstream, which answered the query (only if resolved by an upstream)
+type ApiQueryUpstream struct {
+	// Group upstream group of the upstream
+	Group string `json:"group"`
+
+	// Name upstream
+	Name string `json:"name"`
+
+	// Protocol protocol of the upstream (tcp+udp, tcp-tls, https)
+	Protocol string `json:"protocol"`
+
+	// Retries number of retries of the upstream query
+	Retries int `json:"retries"`
+
+	// RttMs round trip time of the upstream query in milliseconds
+	RttMs int64 `json:"rttMs"`
+}
+
+// ApiQuotaUsage defines model for api.QuotaUsage.
+type ApiQuotaUsage struct {
+	// Category quota category
+	Category string `json:"category"`
+
+	// Client client name(s), comma separated
+	Client string `json:"client"`
+
+	// Exhausted true if the queries of the category are blocked for the client
+	Exhausted bool `json:"exhausted"`
+
+	// Queries count of the queries of the current day
+	Queries int64 `json:"queries"`
+
+	// QueryLimit daily query budget, 0 is unlimited
+	QueryLimit int64 `json:"queryLimit"`
+
+	// TimeLimitSeconds daily time budget in seconds, 0 is unlimited
+	TimeLimitSeconds int64 `json:"timeLimitSeconds"`
+
+	// TimeSeconds active time of the current day in seconds
+	TimeSeconds int64 `json:"timeSeconds"`
+}
+
+// ApiResolverBranch defines model for api.ResolverBranch.
+type ApiResolverBranch struct {
+	// Name selector of the branch, e.g. the upstream group or the conditional domain
+	Name string `json:"name"`
+
+	// Resolvers resolvers of the branch
+	Resolvers []ApiResolverNode `json:"resolvers"`
+}
+
+// ApiResolverNode defines model for api.ResolverNode.
+type ApiResolverNode struct {
+	// Branches sub-resolvers, which are selected per query
+	Branches []ApiResolverBranch `json:"branches"`
+
+	// Config effective configuration of the enabled resolver, as logged on start
+	Config []string `json:"config"`
+
+	// Enabled false, if the resolver is disabled by the configuration and passes all queries
+	Enabled bool `json:"enabled"`
+
+	// Name name of the resolver, e.g. with the upstream or the group
+	Name string `json:"name"`
+
+	// Type type of the resolver, e.g. `blocking`
+	Type string `json:"type"`
+}
+
+// ApiResponseChange defines model for api.ResponseChange.
+type ApiResponseChange struct {
+	// Action kind of the change (blocked, rewritten, ttlClamped, aaaaFiltered, ecsRemoved)
+	Action string `json:"action"`
+
+	// Detail what was changed and why
+	Detail string `json:"detail"`
+
+	// Stage resolver, which changed the query or the response
+	Stage string `json:"stage"`
+}
+
+// ApiRuntimeState defines model for api.RuntimeState.
+type ApiRuntimeState struct {
+	Blocking ApiBlockingState `json:"blocking"`
+
+	// Version version of the bundle format
+	Version int `json:"version"`
+}
+
+// ApiUpstreamStats defines model for api.UpstreamStats.
+type ApiUpstreamStats struct {
+	// Errors count of failed queries
+	Errors int64 `json:"errors"`
+
+	// Group upstream group
+	Group string `json:"group"`
+
+	// LatencyP50Ms median response time of the latest responses in milliseconds
+	LatencyP50Ms float32 `json:"latencyP50Ms"`
+
+	// LatencyP90Ms 90th percentile response time of the latest responses in milliseconds
+	LatencyP90Ms float32 `json:"latencyP90Ms"`
+
+	// LatencyP99Ms 99th percentile response time of the latest responses in milliseconds
+	LatencyP99Ms float32 `json:"latencyP99Ms"`
+
+	// Queries count of all queries sent to the upstream
+	Queries int64 `json:"queries"`
+
+	// Selected count of responses used to answer a query
+	Selected int64 `json:"selected"`
+
+	// SelectionShare share of the used responses of the group (0-1), e.g. the winner of parallel_best
+	SelectionShare float32 `json:"selectionShare"`
+
+	// State current health state
+	State ApiUpstreamStatsState `json:"state"`
+
+	// Upstream upstream server
+	Upstream string `json:"upstream"`
+
+	// Weighting current weight for the random selection, only for the strategies parallel_best and random
+	Weighting *ApiUpstreamWeighting `json:"weighting,omitempty"`
+}
+
+// ApiUpstreamStatsState current health state
+type ApiUpstreamStatsState string
+
+// ApiUpstreamWeighting current weight for the random selection, only for the strategies parallel_best and random
+type ApiUpstreamWeighting struct {
+	// ErrorRate exponentially decayed error rate (0-1)
+	ErrorRate float32 `json:"errorRate"`
+
+	// LatencyMs exponentially decayed average response time in milliseconds
+	LatencyMs float32 `json:"latencyMs"`
+
+	// Weight probability to be picked, compared to the other upstreams of the group (0-1)
+	Weight float32 `json:"weight"`
+}
+
+// ManipulationLogParams defines parameters for ManipulationLog.
+type ManipulationLogParams struct {
+	// Client returns only the responses to this client IP address
+	Client *string `form:"client,omitempty" json:"client,omitempty"`
+}
+
+// AuditLogParams defines parameters for AuditLog.
+type AuditLogParams struct {
+	// Domain returns only the responses to questions for this domain
+	Domain *string `form:"domain,omitempty" json:"domain,omitempty"`
 }
 
 // DisableBlockingParams defines parameters for DisableBlocking.
@@ -48,5 +373,53 @@ type DisableBlockingParams struct {
 	Groups *string `form:"groups,omitempty" json:"groups,omitempty"`
 }
 
+// ListRefreshParams defines parameters for ListRefresh.
+type ListRefreshParams struct {
+	// Group groups to refresh (comma separated). If empty, refresh all groups
+	Group *string `form:"group,omitempty" json:"group,omitempty"`
+}
+
+// QueryParams defines parameters for Query.
+type QueryParams struct {
+	// Debug return the trace of the resolver chain (visited resolvers, timings and log messages) for this query
+	Debug *bool `form:"debug,omitempty" json:"debug,omitempty"`
+}
+
+// QueryLogRotateParams defines parameters for QueryLogRotate.
+type QueryLogRotateParams struct {
+	// Gzip compress the rotated files with gzip. If empty, use the configured `queryLog.compress`
+	Gzip *bool `form:"gzip,omitempty" json:"gzip,omitempty"`
+}
+
+// QuotaResetParams defines parameters for QuotaReset.
+type QuotaResetParams struct {
+	// Category category to reset. If empty, reset all categories
+	Category *string `form:"category,omitempty" json:"category,omitempty"`
+
+	// Client client name(s) to reset, comma separated as returned by the usage. If empty, reset all clients
+	Client *string `form:"client,omitempty" json:"client,omitempty"`
+}
+
+// ReloadParamsSubsystem defines parameters for Reload.
+type ReloadParamsSubsystem string
+
+// ResolverChainParams defines parameters for ResolverChain.
+type ResolverChainParams struct {
+	// Format format of the response, `json` (default) or `dot` for Graphviz
+	Format *string `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// ClientStatsParams defines parameters for ClientStats.
+type ClientStatsParams struct {
+	// Days count of the last days to aggregate. If empty, aggregate all retained days
+	Days *int `form:"days,omitempty" json:"days,omitempty"`
+}
+
+// ConfigDryRunJSONRequestBody defines body for ConfigDryRun for application/json ContentType.
+type ConfigDryRunJSONRequestBody = ApiConfigDryRunRequest
+
 // QueryJSONRequestBody defines body for Query for application/json ContentType.
 type QueryJSONRequestBody = ApiQueryRequest
+
+// StateImportJSONRequestBody defines body for StateImport for application/json ContentType.
+type StateImportJSONRequestBody = ApiRuntimeState
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// APITokens are the bearer tokens, which authorize the API requests
+type APITokens struct {
+	// Admin authorizes all requests. If it's empty, the API is not restricted.
+	Admin string
+	// Tenants are the tokens of the tenants by tenant name, they authorize only the tenant scoped operations
+	Tenants map[string]string
+}
+
+type tenantCtxKey struct{}
+
+// tenantScopedOperations can be called with the token of a tenant, their results are limited to the tenant
+var tenantScopedOperations = map[string]bool{ //nolint:gochecknoglobals
+	"ClientStats": true,
+}
+
+// authMiddleware rejects the requests without a valid token and passes the tenant of a tenant token in the context
+func authMiddleware(tokens APITokens) StrictMiddlewareFunc {
+	return func(handler StrictHandlerFunc, operationID string) StrictHandlerFunc {
+		if tokens.Admin == "" {
+			return handler
+		}
+
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request any) (any, error) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				http.Error(w, "missing API token", http.StatusUnauthorized)
+
+				return nil, nil
+			}
+
+			if tokenEquals(token, tokens.Admin) {
+				return handler(ctx, w, r, request)
+			}
+
+			for tenant, tenantToken := range tokens.Tenants {
+				if !tokenEquals(token, tenantToken) {
+					continue
+				}
+
+				if !tenantScopedOperations[operationID] {
+					http.Error(w, "operation is not permitted for tenant", http.StatusForbidden)
+
+					return nil, nil
+				}
+
+				return handler(context.WithValue(ctx, tenantCtxKey{}, tenant), w, r, request)
+			}
+
+			http.Error(w, "invalid API token", http.StatusUnauthorized)
+
+			return nil, nil
+		}
+	}
+}
+
+func tokenEquals(token, expected string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// tenantFromCtx returns the tenant of the API token, false if the request is not limited to a tenant
+func tenantFromCtx(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantCtxKey{}).(string)
+
+	return tenant, ok
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("authMiddleware", func() {
+	var (
+		tokens APITokens
+
+		called     bool
+		ctxTenant  string
+		ctxScoped  bool
+		innerValue = "response"
+	)
+
+	BeforeEach(func() {
+		tokens = APITokens{
+			Admin:   "admin-secret",
+			Tenants: map[string]string{"smith": "smith-secret", "miller": ""},
+		}
+		called = false
+	})
+
+	call := func(operationID, authorization string) (*httptest.ResponseRecorder, any) {
+		handler := func(ctx context.Context, _ http.ResponseWriter, _ *http.Request, _ any) (any, error) {
+			called = true
+			ctxTenant, ctxScoped = tenantFromCtx(ctx)
+
+			return innerValue, nil
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/stats/clients", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		rec := httptest.NewRecorder()
+
+		resp, err := authMiddleware(tokens)(handler, operationID)(context.Background(), rec, req, nil)
+		Expect(err).Should(Succeed())
+
+		return rec, resp
+	}
+
+	When("no admin token is configured", func() {
+		BeforeEach(func() {
+			tokens = APITokens{}
+		})
+
+		It("should pass all requests", func() {
+			_, resp := call("EnableBlocking", "")
+
+			Expect(called).Should(BeTrue())
+			Expect(resp).Should(Equal(innerValue))
+			Expect(ctxScoped).Should(BeFalse())
+		})
+	})
+
+	It("should pass all requests with the admin token", func() {
+		_, resp := call("EnableBlocking", "Bearer admin-secret")
+
+		Expect(called).Should(BeTrue())
+		Expect(resp).Should(Equal(innerValue))
+		Expect(ctxScoped).Should(BeFalse())
+	})
+
+	It("should reject requests without token", func() {
+		rec, resp := call("ClientStats", "")
+
+		Expect(called).Should(BeFalse())
+		Expect(resp).Should(BeNil())
+		Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject requests with an invalid token", func() {
+		rec, _ := call("ClientStats", "Bearer wrong")
+
+		Expect(called).Should(BeFalse())
+		Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("should not accept an empty tenant token", func() {
+		rec, _ := call("ClientStats", "Bearer ")
+
+		Expect(called).Should(BeFalse())
+		Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("should pass tenant scoped operations with the tenant in the context", func() {
+		_, resp := call("ClientStats", "Bearer smith-secret")
+
+		Expect(called).Should(BeTrue())
+		Expect(resp).Should(Equal(innerValue))
+		Expect(ctxScoped).Should(BeTrue())
+		Expect(ctxTenant).Should(Equal("smith"))
+	})
+
+	It("should forbid other operations with a tenant token", func() {
+		rec, _ := call("DisableBlocking", "Bearer smith-secret")
+
+		Expect(called).Should(BeFalse())
+		Expect(rec.Code).Should(Equal(http.StatusForbidden))
+	})
+})
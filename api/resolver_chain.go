@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolverChainDOT renders the resolver chain as Graphviz graph. The resolvers of the chain and of each branch are
+// linked in the order of processing, the branches are linked to their resolver with the name of the branch.
+func resolverChainDOT(nodes []ResolverNode) string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph resolvers {\n")
+	sb.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	dot := dotWriter{sb: &sb}
+	dot.writeChain(nodes, "", "")
+
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+type dotWriter struct {
+	sb    *strings.Builder
+	count int
+}
+
+// writeChain writes the nodes and links the first one to the parent, if there is one
+func (w *dotWriter) writeChain(nodes []ResolverNode, parent, label string) {
+	previous := parent
+
+	for i, node := range nodes {
+		id := w.writeNode(node)
+
+		switch {
+		case i == 0 && parent != "":
+			fmt.Fprintf(w.sb, "  %s -> %s [label=\"%s\", style=dashed];\n", parent, id, dotEscape(label))
+		case i > 0:
+			fmt.Fprintf(w.sb, "  %s -> %s;\n", previous, id)
+		}
+
+		previous = id
+
+		for _, branch := range node.Branches {
+			w.writeChain(branch.Resolvers, id, branch.Name)
+		}
+	}
+}
+
+func (w *dotWriter) writeNode(node ResolverNode) string {
+	id := fmt.Sprintf("n%d", w.count)
+	w.count++
+
+	label := dotEscape(node.Name) + `\n`
+	for _, line := range node.Config {
+		label += dotEscape(strings.TrimSpace(line)) + `\l`
+	}
+
+	style := ""
+	if !node.Enabled {
+		style = ", style=dashed, fontcolor=gray"
+	}
+
+	fmt.Fprintf(w.sb, "  %s [label=\"%s\"%s];\n", id, label, style)
+
+	return id
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -15,3 +15,11 @@ type ExpiringCache[T any] interface {
 	// Clear removes all cache entries
 	Clear()
 }
+
+// DeletableCache is an ExpiringCache, which can remove single entries
+type DeletableCache[T any] interface {
+	ExpiringCache[T]
+
+	// DeleteFunc removes the entries with matching keys and returns their count
+	DeleteFunc(match func(key string) bool) int
+}
@@ -2,6 +2,7 @@ package prefetching
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -12,12 +13,28 @@ import (
 
 type PrefetchingExpiringLRUCache[T any] struct {
 	cache                   cache.ExpiringCache[cacheValue[T]]
-	prefetchingNameCache    cache.ExpiringCache[atomic.Uint32]
+	prefetchingNameCache    cache.ExpiringCache[queryCount]
 	reloadFn                ReloadEntryFn[T]
 	prefetchThreshold       int
 	prefetchExpires         time.Duration
 	onPrefetchEntryReloaded OnEntryReloadedCallback
 	onPrefetchCacheHit      expirationcache.OnCacheHitCallback
+
+	// keys of the prefetching name cache, nil if the candidates are not tracked
+	candidateKeys     map[string]struct{}
+	candidateKeysLock sync.Mutex
+}
+
+type queryCount struct {
+	hits     atomic.Uint32
+	lastSeen atomic.Int64 // unix nano
+}
+
+// PrefetchCandidate is a queried key with its query count within the prefetch expiration
+type PrefetchCandidate struct {
+	Key      string    `json:"key"`
+	Hits     uint32    `json:"hits"`
+	LastSeen time.Time `json:"lastSeen"`
 }
 
 type cacheValue[T any] struct {
@@ -41,13 +58,20 @@ type PrefetchingOptions[T any] struct {
 	OnPrefetchAfterPut      expirationcache.OnAfterPutCallback
 	OnPrefetchEntryReloaded OnEntryReloadedCallback
 	OnPrefetchCacheHit      expirationcache.OnCacheHitCallback
+	// TrackCandidates enables `Candidates` and `RestoreCandidates`, e.g. to persist them across restarts
+	TrackCandidates bool
+	// MaxBytes enables a memory budget for the cached values, measured with `SizeFn`
+	MaxBytes        uint64
+	SizeFn          cache.SizeFn[T]
+	OnEvictedFn     cache.OnEvictedCallback
+	OnSizeChangedFn cache.OnSizeChangedCallback
 }
 
 type PrefetchingCacheOption[T any] func(c *PrefetchingExpiringLRUCache[cacheValue[T]])
 
 func NewPrefetchingCache[T any](ctx context.Context, options PrefetchingOptions[T]) *PrefetchingExpiringLRUCache[T] {
 	pc := &PrefetchingExpiringLRUCache[T]{
-		prefetchingNameCache: expirationcache.NewCache[atomic.Uint32](ctx, expirationcache.Options{
+		prefetchingNameCache: expirationcache.NewCache[queryCount](ctx, expirationcache.Options{
 			CleanupInterval: time.Minute,
 			MaxSize:         uint(options.PrefetchMaxItemsCount),
 			OnAfterPutFn:    options.OnPrefetchAfterPut,
@@ -59,7 +83,24 @@ func NewPrefetchingCache[T any](ctx context.Context, options PrefetchingOptions[
 		onPrefetchCacheHit:      options.OnPrefetchCacheHit,
 	}
 
-	pc.cache = expirationcache.NewCacheWithOnExpired[cacheValue[T]](ctx, options.Options, pc.onExpired)
+	if options.TrackCandidates {
+		pc.candidateKeys = make(map[string]struct{})
+	}
+
+	if options.MaxBytes > 0 {
+		pc.cache = cache.NewSizeLimitedCache(ctx, cache.SizeLimitedOptions[cacheValue[T]]{
+			Options:  options.Options,
+			MaxBytes: options.MaxBytes,
+			SizeFn: func(val *cacheValue[T]) uint64 {
+				return options.SizeFn(val.element)
+			},
+			OnExpiredFn:     pc.onExpired,
+			OnEvictedFn:     options.OnEvictedFn,
+			OnSizeChangedFn: options.OnSizeChangedFn,
+		})
+	} else {
+		pc.cache = expirationcache.NewCacheWithOnExpired[cacheValue[T]](ctx, options.Options, pc.onExpired)
+	}
 
 	return pc
 }
@@ -72,7 +113,7 @@ func (e *PrefetchingExpiringLRUCache[T]) shouldPrefetch(cacheKey string) bool {
 
 	cnt, _ := e.prefetchingNameCache.Get(cacheKey)
 
-	return cnt != nil && int64(cnt.Load()) > int64(e.prefetchThreshold)
+	return cnt != nil && int64(cnt.hits.Load()) > int64(e.prefetchThreshold)
 }
 
 func (e *PrefetchingExpiringLRUCache[T]) onExpired(
@@ -93,15 +134,79 @@ func (e *PrefetchingExpiringLRUCache[T]) onExpired(
 }
 
 func (e *PrefetchingExpiringLRUCache[T]) trackCacheKeyQueryCount(cacheKey string) {
-	var x *atomic.Uint32
+	var x *queryCount
 	if x, _ = e.prefetchingNameCache.Get(cacheKey); x == nil {
-		x = &atomic.Uint32{}
+		x = &queryCount{}
+
+		e.addCandidateKey(cacheKey)
 	}
 
-	x.Add(1)
+	x.hits.Add(1)
+	x.lastSeen.Store(time.Now().UnixNano())
 	e.prefetchingNameCache.Put(cacheKey, x, e.prefetchExpires)
 }
 
+func (e *PrefetchingExpiringLRUCache[T]) addCandidateKey(cacheKey string) {
+	if e.candidateKeys == nil {
+		return
+	}
+
+	e.candidateKeysLock.Lock()
+	defer e.candidateKeysLock.Unlock()
+
+	e.candidateKeys[cacheKey] = struct{}{}
+}
+
+// Candidates returns the queried keys, which are not expired. It requires `TrackCandidates`.
+func (e *PrefetchingExpiringLRUCache[T]) Candidates() []PrefetchCandidate {
+	e.candidateKeysLock.Lock()
+	defer e.candidateKeysLock.Unlock()
+
+	result := make([]PrefetchCandidate, 0, len(e.candidateKeys))
+
+	for key := range e.candidateKeys {
+		cnt, _ := e.prefetchingNameCache.Get(key)
+		if cnt == nil {
+			// expired or evicted
+			delete(e.candidateKeys, key)
+
+			continue
+		}
+
+		result = append(result, PrefetchCandidate{
+			Key:      key,
+			Hits:     cnt.hits.Load(),
+			LastSeen: time.Unix(0, cnt.lastSeen.Load()),
+		})
+	}
+
+	return result
+}
+
+// RestoreCandidates adds the query counts of the candidates, e.g. from before a restart.
+// Candidates, which are expired meanwhile, are ignored.
+func (e *PrefetchingExpiringLRUCache[T]) RestoreCandidates(candidates []PrefetchCandidate) {
+	for _, candidate := range candidates {
+		if candidate.Hits == 0 || time.Since(candidate.LastSeen) >= e.prefetchExpires {
+			continue
+		}
+
+		x, _ := e.prefetchingNameCache.Get(candidate.Key)
+		if x == nil {
+			x = &queryCount{}
+			x.lastSeen.Store(candidate.LastSeen.UnixNano())
+
+			e.addCandidateKey(candidate.Key)
+		}
+
+		x.hits.Add(candidate.Hits)
+
+		// the entry expires after the last query
+		remaining := e.prefetchExpires - time.Since(time.Unix(0, x.lastSeen.Load()))
+		e.prefetchingNameCache.Put(candidate.Key, x, remaining)
+	}
+}
+
 func (e *PrefetchingExpiringLRUCache[T]) Put(key string, val *T, expiration time.Duration) {
 	e.cache.Put(key, &cacheValue[T]{element: val, prefetch: false}, expiration)
 }
@@ -129,8 +234,27 @@ func (e *PrefetchingExpiringLRUCache[T]) TotalCount() int {
 	return e.cache.TotalCount()
 }
 
+// DeleteFunc removes the entries with matching keys and returns their count. Without memory budget, the entries
+// can't be removed selectively and all entries are removed.
+func (e *PrefetchingExpiringLRUCache[T]) DeleteFunc(match func(key string) bool) int {
+	if deletable, ok := e.cache.(cache.DeletableCache[cacheValue[T]]); ok {
+		return deletable.DeleteFunc(match)
+	}
+
+	count := e.cache.TotalCount()
+	e.cache.Clear()
+
+	return count
+}
+
 // Clear removes all cache entries
 func (e *PrefetchingExpiringLRUCache[T]) Clear() {
 	e.cache.Clear()
 	e.prefetchingNameCache.Clear()
+
+	if e.candidateKeys != nil {
+		e.candidateKeysLock.Lock()
+		clear(e.candidateKeys)
+		e.candidateKeysLock.Unlock()
+	}
 }
@@ -46,6 +46,29 @@ var _ = Describe("Prefetching expiration cache", func() {
 
 				Expect(cache.TotalCount()).Should(Equal(0))
 			})
+
+			It("Should remove the matching entries with memory budget", func() {
+				cache := NewPrefetchingCache[string](ctx, PrefetchingOptions[string]{
+					MaxBytes: 100,
+					SizeFn:   func(val *string) uint64 { return uint64(len(*val)) },
+				})
+				v := "v1"
+				cache.Put("key1", &v, time.Minute)
+				cache.Put("key2", &v, time.Minute)
+
+				Expect(cache.DeleteFunc(func(key string) bool { return key == "key1" })).Should(Equal(1))
+				Expect(cache.TotalCount()).Should(Equal(1))
+			})
+
+			It("Should remove all entries without memory budget", func() {
+				cache := NewPrefetchingCache[string](ctx, PrefetchingOptions[string]{})
+				v := "v1"
+				cache.Put("key1", &v, time.Minute)
+				cache.Put("key2", &v, time.Minute)
+
+				Expect(cache.DeleteFunc(func(key string) bool { return key == "key1" })).Should(Equal(2))
+				Expect(cache.TotalCount()).Should(Equal(0))
+			})
 		})
 		Context("Prefetching", func() {
 			It("Should prefetch element", func() {
@@ -189,5 +212,69 @@ var _ = Describe("Prefetching expiration cache", func() {
 				})
 			})
 		})
+		Context("Prefetch candidates", func() {
+			var sut *PrefetchingExpiringLRUCache[string]
+
+			BeforeEach(func() {
+				sut = NewPrefetchingCache[string](ctx, PrefetchingOptions[string]{
+					PrefetchThreshold: 2,
+					PrefetchExpires:   time.Hour,
+					TrackCandidates:   true,
+				})
+			})
+
+			It("Should return the queried keys with their hits", func() {
+				sut.Get("key1")
+				sut.Get("key1")
+				sut.Get("key2")
+
+				Expect(sut.Candidates()).Should(ConsistOf(
+					SatisfyAll(
+						HaveField("Key", "key1"),
+						HaveField("Hits", uint32(2)),
+						HaveField("LastSeen", BeTemporally("~", time.Now(), time.Second)),
+					),
+					SatisfyAll(HaveField("Key", "key2"), HaveField("Hits", uint32(1))),
+				))
+			})
+
+			It("Should not return cleared keys", func() {
+				sut.Get("key1")
+				sut.Clear()
+
+				Expect(sut.Candidates()).Should(BeEmpty())
+			})
+
+			It("Should restore the hits of the candidates", func() {
+				sut.Get("key1")
+
+				sut.RestoreCandidates([]PrefetchCandidate{
+					{Key: "key1", Hits: 2, LastSeen: time.Now().Add(-time.Minute)},
+					{Key: "key2", Hits: 3, LastSeen: time.Now().Add(-time.Minute)},
+					{Key: "expired", Hits: 3, LastSeen: time.Now().Add(-2 * time.Hour)},
+				})
+
+				Expect(sut.shouldPrefetch("key1")).Should(BeTrue())
+				Expect(sut.shouldPrefetch("key2")).Should(BeTrue())
+				Expect(sut.shouldPrefetch("expired")).Should(BeFalse())
+
+				Expect(sut.Candidates()).Should(ConsistOf(
+					SatisfyAll(HaveField("Key", "key1"), HaveField("Hits", uint32(3))),
+					SatisfyAll(
+						HaveField("Key", "key2"),
+						HaveField("Hits", uint32(3)),
+						HaveField("LastSeen", BeTemporally("~", time.Now().Add(-time.Minute), time.Second)),
+					),
+				))
+			})
+
+			It("Should not track keys, if disabled", func() {
+				sut = NewPrefetchingCache[string](ctx, PrefetchingOptions[string]{PrefetchExpires: time.Hour})
+
+				sut.Get("key1")
+
+				Expect(sut.Candidates()).Should(BeEmpty())
+			})
+		})
 	})
 })
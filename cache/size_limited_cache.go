@@ -0,0 +1,289 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	expirationcache "github.com/0xERR0R/expiration-cache"
+)
+
+const defaultSizeLimitedCleanupInterval = 10 * time.Second
+
+// SizeFn returns the memory size of a value in bytes
+type SizeFn[T any] func(val *T) uint64
+
+// OnEvictedCallback will be called after the least recently used entries were evicted to stay within the limits,
+// receives the count of evicted entries
+type OnEvictedCallback func(count int)
+
+// OnSizeChangedCallback will be called after entries were added or removed, receives the new total size in bytes
+type OnSizeChangedCallback func(newSize uint64)
+
+// SizeLimitedOptions configures a `SizeLimitedCache`
+type SizeLimitedOptions[T any] struct {
+	// callbacks, cleanup interval and `MaxSize` as maximum count of entries (0: unlimited)
+	expirationcache.Options
+
+	// MaxBytes is the maximum total size of the values in bytes
+	MaxBytes uint64
+	SizeFn   SizeFn[T]
+	// OnExpiredFn is called before an expired entry is removed, it can return a new value to keep it
+	OnExpiredFn     expirationcache.OnExpirationCallback[T]
+	OnEvictedFn     OnEvictedCallback
+	OnSizeChangedFn OnSizeChangedCallback
+}
+
+// SizeLimitedCache is an LRU cache with per-entry expiration, which evicts the least recently used entries
+// if the total size of the values exceeds the memory budget
+type SizeLimitedCache[T any] struct {
+	options SizeLimitedOptions[T]
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	// most recently used entry first
+	lru  *list.List
+	size uint64
+}
+
+type sizedEntry[T any] struct {
+	key     string
+	val     *T
+	size    uint64
+	expires time.Time
+}
+
+// NewSizeLimitedCache creates a new cache, the expired entries are removed until the context is done
+func NewSizeLimitedCache[T any](ctx context.Context, options SizeLimitedOptions[T]) *SizeLimitedCache[T] {
+	c := &SizeLimitedCache[T]{
+		options: options,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	interval := options.CleanupInterval
+	if interval <= 0 {
+		interval = defaultSizeLimitedCleanupInterval
+	}
+
+	go c.periodicCleanup(ctx, interval)
+
+	return c
+}
+
+// Put adds the value to the cache under the passed key with expiration. If expiration <=0, entry will NOT be cached.
+// Values larger than the memory budget are not cached.
+func (c *SizeLimitedCache[T]) Put(key string, val *T, expiration time.Duration) {
+	if expiration <= 0 {
+		return
+	}
+
+	size := c.options.SizeFn(val)
+	if c.options.MaxBytes > 0 && size > c.options.MaxBytes {
+		return
+	}
+
+	c.lock.Lock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+
+	c.entries[key] = c.lru.PushFront(&sizedEntry[T]{
+		key:     key,
+		val:     val,
+		size:    size,
+		expires: time.Now().Add(expiration),
+	})
+	c.size += size
+
+	evicted := c.evict()
+	count, newSize := len(c.entries), c.size
+
+	c.lock.Unlock()
+
+	if evicted > 0 && c.options.OnEvictedFn != nil {
+		c.options.OnEvictedFn(evicted)
+	}
+
+	if c.options.OnAfterPutFn != nil {
+		c.options.OnAfterPutFn(count)
+	}
+
+	c.sizeChanged(newSize)
+}
+
+func (c *SizeLimitedCache[T]) sizeChanged(newSize uint64) {
+	if c.options.OnSizeChangedFn != nil {
+		c.options.OnSizeChangedFn(newSize)
+	}
+}
+
+// evict removes the least recently used entries until the cache is within its limits, returns the evicted count
+func (c *SizeLimitedCache[T]) evict() int {
+	evicted := 0
+
+	for c.exceedsLimits() {
+		c.removeElement(c.lru.Back())
+
+		evicted++
+	}
+
+	return evicted
+}
+
+func (c *SizeLimitedCache[T]) exceedsLimits() bool {
+	return (c.options.MaxSize > 0 && uint(len(c.entries)) > c.options.MaxSize) ||
+		(c.options.MaxBytes > 0 && c.size > c.options.MaxBytes)
+}
+
+func (c *SizeLimitedCache[T]) removeElement(el *list.Element) {
+	entry := c.lru.Remove(el).(*sizedEntry[T])
+
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// Get returns the value of cached entry with remained TTL. If entry is not cached, returns nil.
+// An expired entry is returned with TTL 0 until it is removed.
+func (c *SizeLimitedCache[T]) Get(key string) (val *T, expiration time.Duration) {
+	c.lock.Lock()
+
+	el, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(el)
+
+		entry := el.Value.(*sizedEntry[T])
+		val, expiration = entry.val, max(time.Until(entry.expires), 0)
+	}
+
+	c.lock.Unlock()
+
+	if !ok {
+		if c.options.OnCacheMissFn != nil {
+			c.options.OnCacheMissFn(key)
+		}
+
+		return nil, 0
+	}
+
+	if c.options.OnCacheHitFn != nil {
+		c.options.OnCacheHitFn(key)
+	}
+
+	return val, expiration
+}
+
+// TotalCount returns the total count of valid (not expired) elements
+func (c *SizeLimitedCache[T]) TotalCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.entries)
+}
+
+// TotalSize returns the total size of the values in bytes
+func (c *SizeLimitedCache[T]) TotalSize() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.size
+}
+
+// Clear removes all cache entries
+func (c *SizeLimitedCache[T]) Clear() {
+	c.lock.Lock()
+
+	clear(c.entries)
+	c.lru.Init()
+	c.size = 0
+
+	c.lock.Unlock()
+
+	c.sizeChanged(0)
+}
+
+// DeleteFunc removes the entries with matching keys and returns their count
+func (c *SizeLimitedCache[T]) DeleteFunc(match func(key string) bool) int {
+	c.lock.Lock()
+
+	deleted := 0
+
+	for key, el := range c.entries {
+		if match(key) {
+			c.removeElement(el)
+
+			deleted++
+		}
+	}
+
+	newSize := c.size
+
+	c.lock.Unlock()
+
+	if deleted > 0 {
+		c.sizeChanged(newSize)
+	}
+
+	return deleted
+}
+
+func (c *SizeLimitedCache[T]) periodicCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanUp(ctx)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// cleanUp removes the expired entries, unless `OnExpiredFn` returns a new value
+func (c *SizeLimitedCache[T]) cleanUp(ctx context.Context) {
+	now := time.Now()
+
+	var expired []string
+
+	c.lock.Lock()
+
+	for key, el := range c.entries {
+		if now.After(el.Value.(*sizedEntry[T]).expires) {
+			expired = append(expired, key)
+		}
+	}
+
+	c.lock.Unlock()
+
+	removed := false
+
+	for _, key := range expired {
+		if c.options.OnExpiredFn != nil {
+			if val, ttl := c.options.OnExpiredFn(ctx, key); val != nil {
+				c.Put(key, val, ttl)
+
+				continue
+			}
+		}
+
+		c.lock.Lock()
+
+		// the entry could be replaced meanwhile
+		if el, ok := c.entries[key]; ok && now.After(el.Value.(*sizedEntry[T]).expires) {
+			c.removeElement(el)
+
+			removed = true
+		}
+
+		c.lock.Unlock()
+	}
+
+	if removed {
+		c.sizeChanged(c.TotalSize())
+	}
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	expirationcache "github.com/0xERR0R/expiration-cache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Size limited cache", func() {
+	var (
+		ctx     context.Context
+		options SizeLimitedOptions[string]
+		sut     *SizeLimitedCache[string]
+	)
+
+	BeforeEach(func() {
+		var cancelFn context.CancelFunc
+
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		options = SizeLimitedOptions[string]{
+			MaxBytes: 10,
+			SizeFn: func(val *string) uint64 {
+				return uint64(len(*val))
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewSizeLimitedCache(ctx, options)
+	})
+
+	put := func(key, val string) {
+		sut.Put(key, &val, time.Minute)
+	}
+
+	get := func(key string) *string {
+		val, _ := sut.Get(key)
+
+		return val
+	}
+
+	Describe("Basic operations", func() {
+		It("should be empty initially", func() {
+			val, ttl := sut.Get("key1")
+
+			Expect(val).Should(BeNil())
+			Expect(ttl).Should(BeZero())
+			Expect(sut.TotalCount()).Should(Equal(0))
+			Expect(sut.TotalSize()).Should(BeZero())
+		})
+
+		It("should return the values with the remaining TTL", func() {
+			put("key1", "abc")
+
+			val, ttl := sut.Get("key1")
+
+			Expect(val).Should(HaveValue(Equal("abc")))
+			Expect(ttl).Should(BeNumerically("~", time.Minute, time.Second))
+			Expect(sut.TotalCount()).Should(Equal(1))
+			Expect(sut.TotalSize()).Should(BeEquivalentTo(3))
+		})
+
+		It("should replace the value of an existing key", func() {
+			put("key1", "abc")
+			put("key1", "abcdef")
+
+			Expect(get("key1")).Should(HaveValue(Equal("abcdef")))
+			Expect(sut.TotalCount()).Should(Equal(1))
+			Expect(sut.TotalSize()).Should(BeEquivalentTo(6))
+		})
+
+		It("should not cache values without TTL", func() {
+			val := "abc"
+			sut.Put("key1", &val, 0)
+
+			Expect(sut.TotalCount()).Should(Equal(0))
+		})
+
+		It("should remove all entries on clear", func() {
+			put("key1", "abc")
+
+			sut.Clear()
+
+			Expect(sut.TotalCount()).Should(Equal(0))
+			Expect(sut.TotalSize()).Should(BeZero())
+		})
+
+		It("should remove the matching entries", func() {
+			put("key1", "abc")
+			put("key2", "de")
+			put("other", "f")
+
+			Expect(sut.DeleteFunc(func(key string) bool { return strings.HasPrefix(key, "key") })).Should(Equal(2))
+
+			Expect(sut.TotalCount()).Should(Equal(1))
+			Expect(sut.TotalSize()).Should(BeEquivalentTo(1))
+			Expect(sut.Get("key1")).Should(BeNil())
+		})
+	})
+
+	Describe("Eviction", func() {
+		var evicted atomic.Int32
+
+		BeforeEach(func() {
+			evicted.Store(0)
+
+			options.OnEvictedFn = func(count int) {
+				evicted.Add(int32(count))
+			}
+		})
+
+		It("should evict the least recently used entries if the memory budget is exceeded", func() {
+			put("key1", "aaaa")
+			put("key2", "bbbb")
+
+			// key1 is now more recently used than key2
+			get("key1")
+
+			put("key3", "cccc")
+
+			Expect(get("key2")).Should(BeNil())
+			Expect(get("key1")).ShouldNot(BeNil())
+			Expect(get("key3")).ShouldNot(BeNil())
+			Expect(sut.TotalSize()).Should(BeEquivalentTo(8))
+			Expect(evicted.Load()).Should(BeEquivalentTo(1))
+		})
+
+		It("should not cache values larger than the memory budget", func() {
+			put("key1", "aaaa")
+			put("key2", "01234567890")
+
+			Expect(get("key2")).Should(BeNil())
+			Expect(get("key1")).ShouldNot(BeNil())
+			Expect(evicted.Load()).Should(BeZero())
+		})
+
+		When("the count of entries is limited", func() {
+			BeforeEach(func() {
+				options.MaxSize = 2
+			})
+
+			It("should evict the least recently used entries if the count is exceeded", func() {
+				put("key1", "a")
+				put("key2", "b")
+				put("key3", "c")
+
+				Expect(get("key1")).Should(BeNil())
+				Expect(sut.TotalCount()).Should(Equal(2))
+				Expect(evicted.Load()).Should(BeEquivalentTo(1))
+			})
+		})
+	})
+
+	Describe("Callbacks", func() {
+		var (
+			hits, misses atomic.Int32
+			size         atomic.Uint64
+			count        atomic.Int32
+		)
+
+		BeforeEach(func() {
+			hits.Store(0)
+			misses.Store(0)
+
+			options.Options = expirationcache.Options{
+				OnCacheHitFn:  func(string) { hits.Add(1) },
+				OnCacheMissFn: func(string) { misses.Add(1) },
+				OnAfterPutFn:  func(newSize int) { count.Store(int32(newSize)) },
+			}
+			options.OnSizeChangedFn = func(newSize uint64) {
+				size.Store(newSize)
+			}
+		})
+
+		It("should report hits, misses and changes", func() {
+			put("key1", "abc")
+			put("key2", "de")
+
+			sut.Get("key1")
+			sut.Get("key3")
+
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+			Expect(misses.Load()).Should(BeEquivalentTo(1))
+			Expect(count.Load()).Should(BeEquivalentTo(2))
+			Expect(size.Load()).Should(BeEquivalentTo(5))
+
+			sut.Clear()
+
+			Expect(size.Load()).Should(BeZero())
+		})
+	})
+
+	Describe("Expiration", func() {
+		BeforeEach(func() {
+			options.CleanupInterval = 10 * time.Millisecond
+		})
+
+		It("should remove expired entries", func() {
+			val := "abc"
+			sut.Put("key1", &val, 20*time.Millisecond)
+
+			Eventually(sut.TotalCount).Should(Equal(0))
+			Expect(sut.TotalSize()).Should(BeZero())
+		})
+
+		When("the expired entries are reloaded", func() {
+			BeforeEach(func() {
+				options.OnExpiredFn = func(ctx context.Context, key string) (*string, time.Duration) {
+					val := "reloaded"
+
+					return &val, time.Minute
+				}
+			})
+
+			It("should keep the reloaded value", func() {
+				val := "abc"
+				sut.Put("key1", &val, 20*time.Millisecond)
+
+				Eventually(get).WithArguments("key1").Should(HaveValue(Equal("reloaded")))
+				Expect(sut.TotalSize()).Should(BeEquivalentTo(8))
+			})
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package stringcache
+
+import (
+	"encoding/gob"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// bloomFilter is a probabilistic set: it has no false negatives, but false positives with the configured rate
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+func newBloomFilter(keys []uint64, falsePositiveRate float64) *bloomFilter {
+	n := math.Max(float64(len(keys)), 1)
+
+	// optimal size and number of hash functions for n keys
+	size := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(math.Round(float64(size)/n*math.Ln2), 1))
+
+	f := &bloomFilter{
+		bits:   make([]uint64, (size+63)/64), //nolint:mnd
+		size:   size,
+		hashes: hashes,
+	}
+
+	for _, key := range keys {
+		f.forEachBit(key, func(word int, mask uint64) bool {
+			f.bits[word] |= mask
+
+			return true
+		})
+	}
+
+	return f
+}
+
+func (f *bloomFilter) mayContain(key uint64) bool {
+	return f.forEachBit(key, func(word int, mask uint64) bool {
+		return f.bits[word]&mask != 0
+	})
+}
+
+// forEachBit calls fn for each bit of the key (double hashing) until fn returns false
+func (f *bloomFilter) forEachBit(key uint64, fn func(word int, mask uint64) bool) bool {
+	h1, h2 := key&math.MaxUint32, key>>32|1 //nolint:mnd
+
+	for i := range f.hashes {
+		bit := (h1 + i*h2) % f.size
+
+		if !fn(int(bit/64), 1<<(bit%64)) { //nolint:mnd
+			return false
+		}
+	}
+
+	return true
+}
+
+// bloomKey returns the filter key of the domain: its base domain consisting of the last two labels.
+// A domain and all its subdomains have the same key, so wildcard entries can be filtered too.
+// The hash doesn't change between restarts, so encoded factories keep their keys.
+func bloomKey(domain string) uint64 {
+	domain = strings.TrimSuffix(normalizeEntry(domain), ".")
+
+	if idx := strings.LastIndexByte(domain, '.'); idx > 0 {
+		if idx2 := strings.LastIndexByte(domain[:idx], '.'); idx2 >= 0 {
+			domain = domain[idx2+1:]
+		}
+	}
+
+	return xxhash.Sum64String(domain)
+}
+
+// BloomFilteredGroupedCache checks a bloom filter of each group before searching the group in the wrapped cache.
+// Regexes can't be filtered and must not be added to this cache.
+type BloomFilteredGroupedCache struct {
+	cache             GroupedStringCache
+	falsePositiveRate float64
+
+	lock sync.RWMutex
+	// filter of each group, nil if the group can't be filtered
+	filters map[string]*bloomFilter
+}
+
+func NewBloomFilteredGroupedCache(cache GroupedStringCache, falsePositiveRate float64) *BloomFilteredGroupedCache {
+	return &BloomFilteredGroupedCache{
+		cache:             cache,
+		falsePositiveRate: falsePositiveRate,
+		filters:           make(map[string]*bloomFilter),
+	}
+}
+
+func (c *BloomFilteredGroupedCache) ElementCount(group string) int {
+	return c.cache.ElementCount(group)
+}
+
+func (c *BloomFilteredGroupedCache) Contains(searchString string, groups []string) []string {
+	key := bloomKey(searchString)
+
+	candidates := make([]string, 0, len(groups))
+
+	c.lock.RLock()
+
+	for _, group := range groups {
+		filter, found := c.filters[group]
+		if found && (filter == nil || filter.mayContain(key)) {
+			candidates = append(candidates, group)
+		}
+	}
+
+	c.lock.RUnlock()
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return c.cache.Contains(searchString, candidates)
+}
+
+func (c *BloomFilteredGroupedCache) Refresh(group string) GroupFactory {
+	return &bloomFilteredGroupFactory{
+		factory: c.cache.Refresh(group),
+		finishFn: func(keys []uint64, filterable bool, factory GroupFactory) {
+			var filter *bloomFilter
+			if filterable {
+				filter = newBloomFilter(keys, c.falsePositiveRate)
+			}
+
+			// update the filter first: the new entries must never be filtered by the old filter
+			c.lock.Lock()
+
+			if factory.Count() > 0 {
+				c.filters[group] = filter
+			} else {
+				delete(c.filters, group)
+			}
+
+			c.lock.Unlock()
+
+			factory.Finish()
+		},
+	}
+}
+
+type bloomFilteredGroupFactory struct {
+	factory    GroupFactory
+	keys       []uint64
+	unfiltered bool
+	finishFn   func(keys []uint64, filterable bool, factory GroupFactory)
+}
+
+func (c *bloomFilteredGroupFactory) AddEntry(entry string) bool {
+	if !c.factory.AddEntry(entry) {
+		return false
+	}
+
+	if strings.HasPrefix(entry, "*.") && !strings.Contains(normalizeWildcard(entry), ".") {
+		// wildcards of TLDs match domains with any key
+		c.unfiltered = true
+	}
+
+	c.keys = append(c.keys, bloomKey(strings.TrimLeft(entry, "*.")))
+
+	return true
+}
+
+func (c *bloomFilteredGroupFactory) Count() int {
+	return c.factory.Count()
+}
+
+func (c *bloomFilteredGroupFactory) encode(enc *gob.Encoder) error {
+	f, ok := c.factory.(persistentFactory)
+	if !ok {
+		return errNotPersistent
+	}
+
+	if err := encodeAll(enc, c.keys, c.unfiltered); err != nil {
+		return err
+	}
+
+	return f.encode(enc)
+}
+
+func (c *bloomFilteredGroupFactory) decode(dec *gob.Decoder) error {
+	f, ok := c.factory.(persistentFactory)
+	if !ok {
+		return errNotPersistent
+	}
+
+	var (
+		keys       []uint64
+		unfiltered bool
+	)
+
+	if err := decodeAll(dec, &keys, &unfiltered); err != nil {
+		return err
+	}
+
+	c.keys = keys
+	c.unfiltered = unfiltered
+
+	return f.decode(dec)
+}
+
+func (c *bloomFilteredGroupFactory) Finish() {
+	c.finishFn(c.keys, !c.unfiltered, c.factory)
+}
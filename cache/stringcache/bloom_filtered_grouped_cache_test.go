@@ -0,0 +1,109 @@
+package stringcache
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bloom filtered grouped cache", func() {
+	var (
+		cache *BloomFilteredGroupedCache
+		inner *ChainedGroupedCache
+	)
+
+	BeforeEach(func() {
+		inner = NewChainedGroupedCache(NewInMemoryGroupedWildcardCache(), NewInMemoryGroupedStringCache())
+		cache = NewBloomFilteredGroupedCache(inner, 0.01)
+	})
+
+	fill := func(group string, entries ...string) {
+		factory := cache.Refresh(group)
+
+		for _, entry := range entries {
+			Expect(factory.AddEntry(entry)).Should(BeTrue())
+		}
+
+		factory.Finish()
+	}
+
+	When("the cache is empty", func() {
+		It("should not find any string", func() {
+			Expect(cache.Contains("example.com", []string{"group1"})).Should(BeEmpty())
+			Expect(cache.ElementCount("group1")).Should(BeZero())
+		})
+	})
+
+	When("the cache has entries", func() {
+		BeforeEach(func() {
+			fill("group1", "example.com", "*.example.org", "Sub.Example.NET")
+			fill("group2", "example.com")
+		})
+
+		It("should find the entries in the groups", func() {
+			Expect(cache.Contains("example.com", []string{"group1", "group2"})).Should(ConsistOf("group1", "group2"))
+			Expect(cache.Contains("sub.example.net", []string{"group1", "group2"})).Should(ConsistOf("group1"))
+			Expect(cache.ElementCount("group1")).Should(Equal(3))
+		})
+
+		It("should find subdomains of wildcards", func() {
+			Expect(cache.Contains("a.b.example.org", []string{"group1"})).Should(ConsistOf("group1"))
+		})
+
+		It("should not find other domains", func() {
+			Expect(cache.Contains("other.com", []string{"group1", "group2"})).Should(BeEmpty())
+			Expect(cache.Contains("www.example.com", []string{"group1"})).Should(BeEmpty())
+		})
+
+		It("should remove the filter of emptied groups", func() {
+			fill("group2")
+
+			Expect(cache.Contains("example.com", []string{"group2"})).Should(BeEmpty())
+			Expect(cache.filters).ShouldNot(HaveKey("group2"))
+		})
+	})
+
+	When("a group contains wildcards of TLDs", func() {
+		BeforeEach(func() {
+			fill("group1", "*.com")
+		})
+
+		It("should not filter the group", func() {
+			Expect(cache.filters).Should(HaveKeyWithValue("group1", BeNil()))
+			Expect(cache.Contains("example.com", []string{"group1"})).Should(ConsistOf("group1"))
+		})
+	})
+
+	Describe("bloomFilter", func() {
+		It("should have no false negatives and roughly the configured false positive rate", func() {
+			const count = 10000
+
+			keys := make([]uint64, 0, count)
+			for i := range count {
+				keys = append(keys, bloomKey(fmt.Sprintf("domain%d.com", i)))
+			}
+
+			filter := newBloomFilter(keys, 0.01)
+
+			for _, key := range keys {
+				Expect(filter.mayContain(key)).Should(BeTrue())
+			}
+
+			falsePositives := 0
+
+			for i := range count {
+				if filter.mayContain(bloomKey(fmt.Sprintf("other%d.com", i))) {
+					falsePositives++
+				}
+			}
+
+			Expect(falsePositives).Should(BeNumerically("<", count*0.02))
+		})
+
+		It("should use the base domain as key", func() {
+			Expect(bloomKey("a.b.example.com")).Should(Equal(bloomKey("Example.com.")))
+			Expect(bloomKey("example.com")).ShouldNot(Equal(bloomKey("example.org")))
+		})
+	})
+})
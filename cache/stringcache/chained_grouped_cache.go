@@ -1,6 +1,8 @@
 package stringcache
 
 import (
+	"encoding/gob"
+	"fmt"
 	"sort"
 
 	"golang.org/x/exp/maps"
@@ -75,6 +77,51 @@ func (c *chainedGroupFactory) Count() int {
 	return cnt
 }
 
+// encode writes the content of each factory preceded by the count of factories
+func (c *chainedGroupFactory) encode(enc *gob.Encoder) error {
+	if err := enc.Encode(len(c.cacheFactories)); err != nil {
+		return err
+	}
+
+	for _, factory := range c.cacheFactories {
+		f, ok := factory.(persistentFactory)
+		if !ok {
+			return errNotPersistent
+		}
+
+		if err := f.encode(enc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *chainedGroupFactory) decode(dec *gob.Decoder) error {
+	var count int
+
+	if err := dec.Decode(&count); err != nil {
+		return err
+	}
+
+	if count != len(c.cacheFactories) {
+		return fmt.Errorf("%w: %d chained factories instead of %d", errInvalidEncoding, count, len(c.cacheFactories))
+	}
+
+	for _, factory := range c.cacheFactories {
+		f, ok := factory.(persistentFactory)
+		if !ok {
+			return errNotPersistent
+		}
+
+		if err := f.decode(dec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *chainedGroupFactory) Finish() {
 	for _, factory := range c.cacheFactories {
 		factory.Finish()
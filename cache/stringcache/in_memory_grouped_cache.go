@@ -1,6 +1,9 @@
 package stringcache
 
-import "sync"
+import (
+	"encoding/gob"
+	"sync"
+)
 
 type stringCacheFactoryFn func() cacheFactory
 
@@ -88,6 +91,14 @@ func (c *inMemoryGroupFactory) Count() int {
 	return c.factory.count()
 }
 
+func (c *inMemoryGroupFactory) encode(enc *gob.Encoder) error {
+	return c.factory.encode(enc)
+}
+
+func (c *inMemoryGroupFactory) decode(dec *gob.Decoder) error {
+	return c.factory.decode(dec)
+}
+
 func (c *inMemoryGroupFactory) Finish() {
 	sc := c.factory.create()
 	c.finishFn(sc)
@@ -0,0 +1,40 @@
+package stringcache
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+)
+
+var (
+	errNotPersistent   = errors.New("factory can't be encoded")
+	errInvalidEncoding = errors.New("invalid encoded factory")
+)
+
+// persistentFactory is a factory, which can encode its compiled content and restore it
+type persistentFactory interface {
+	encode(enc *gob.Encoder) error
+	decode(dec *gob.Decoder) error
+}
+
+// EncodeFactory writes the compiled content of a factory before `Finish` is called: sorted strings, tries, regexes
+// and bloom filter keys. `DecodeFactory` restores it without adding and compiling all entries again.
+func EncodeFactory(w io.Writer, factory GroupFactory) error {
+	f, ok := factory.(persistentFactory)
+	if !ok {
+		return errNotPersistent
+	}
+
+	return f.encode(gob.NewEncoder(w))
+}
+
+// DecodeFactory replaces the content of an unused factory with the content written by `EncodeFactory`.
+// Both factories must be created by caches with the same structure. The factory must not be used on error.
+func DecodeFactory(r io.Reader, factory GroupFactory) error {
+	f, ok := factory.(persistentFactory)
+	if !ok {
+		return errNotPersistent
+	}
+
+	return f.decode(gob.NewDecoder(r))
+}
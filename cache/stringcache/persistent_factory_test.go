@@ -0,0 +1,105 @@
+package stringcache
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Persistent factories", func() {
+	newCache := func() GroupedStringCache {
+		return NewChainedGroupedCache(
+			NewInMemoryGroupedRegexCache(),
+			NewBloomFilteredGroupedCache(
+				NewChainedGroupedCache(NewInMemoryGroupedWildcardCache(), NewSharedGroupedStringCache()),
+				0.01,
+			),
+		)
+	}
+
+	encode := func(cache GroupedStringCache, entries ...string) []byte {
+		factory := cache.Refresh("group1")
+
+		for _, entry := range entries {
+			Expect(factory.AddEntry(entry)).Should(BeTrue())
+		}
+
+		var buf bytes.Buffer
+
+		Expect(EncodeFactory(&buf, factory)).Should(Succeed())
+
+		return buf.Bytes()
+	}
+
+	It("should restore the content of the factory", func() {
+		data := encode(newCache(), "Example.com", "other.com", "*.example.org", "/^regex[0-9]+$/", "1.2.3.4")
+
+		cache := newCache()
+		factory := cache.Refresh("group1")
+
+		Expect(DecodeFactory(bytes.NewReader(data), factory)).Should(Succeed())
+		Expect(factory.Count()).Should(Equal(5))
+
+		factory.Finish()
+
+		groups := []string{"group1"}
+
+		Expect(cache.ElementCount("group1")).Should(Equal(5))
+
+		for _, domain := range []string{"example.com", "other.com", "example.org", "a.b.example.org", "regex42", "1.2.3.4"} {
+			Expect(cache.Contains(domain, groups)).Should(ConsistOf("group1"), domain)
+		}
+
+		for _, domain := range []string{"www.example.com", "regex", "example.net"} {
+			Expect(cache.Contains(domain, groups)).Should(BeEmpty(), domain)
+		}
+	})
+
+	It("should restore an empty factory", func() {
+		data := encode(newCache())
+
+		cache := newCache()
+		factory := cache.Refresh("group1")
+
+		Expect(DecodeFactory(bytes.NewReader(data), factory)).Should(Succeed())
+		Expect(factory.Count()).Should(BeZero())
+	})
+
+	It("should fail on truncated data", func() {
+		data := encode(newCache(), "example.com", "*.example.org", "/regex/")
+
+		for i := range data {
+			Expect(DecodeFactory(bytes.NewReader(data[:i]), newCache().Refresh("group1"))).ShouldNot(Succeed())
+		}
+	})
+
+	It("should fail on factories of other caches", func() {
+		data := encode(NewChainedGroupedCache(NewInMemoryGroupedWildcardCache()), "*.example.org")
+
+		Expect(DecodeFactory(bytes.NewReader(data), newCache().Refresh("group1"))).
+			Should(MatchError(errInvalidEncoding))
+	})
+
+	It("should fail on unsorted strings", func() {
+		factory := &stringCacheFactory{tmp: map[int][]string{3: {"def", "abc"}}}
+
+		var buf bytes.Buffer
+
+		Expect(EncodeFactory(&buf, &sharedGroupFactory{factory: factory})).Should(Succeed())
+
+		Expect(DecodeFactory(&buf, NewSharedGroupedStringCache().Refresh("group1"))).
+			Should(MatchError(errInvalidEncoding))
+	})
+
+	It("should fail on factories of other packages", func() {
+		Expect(EncodeFactory(&bytes.Buffer{}, otherFactory{})).Should(MatchError(errNotPersistent))
+		Expect(DecodeFactory(&bytes.Buffer{}, otherFactory{})).Should(MatchError(errNotPersistent))
+	})
+})
+
+type otherFactory struct{}
+
+func (otherFactory) AddEntry(string) bool { return true }
+func (otherFactory) Count() int           { return 0 }
+func (otherFactory) Finish()              {}
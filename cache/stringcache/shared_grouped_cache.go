@@ -0,0 +1,245 @@
+package stringcache
+
+import (
+	"encoding/gob"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const bitsPerByte = 8
+
+// SharedGroupedStringCache stores each string only once for all groups: the strings are stored like in `stringMap`
+// with a bitset of the groups containing the string. So the memory usage depends on the count of unique strings
+// and not on the count of strings in all groups.
+type SharedGroupedStringCache struct {
+	lock sync.RWMutex
+	// serializes the group refreshes, which build the new buckets without blocking the searches
+	refreshLock sync.Mutex
+
+	// bit of each group in the bitsets
+	groupBits map[string]int
+	// bytes of each bitset
+	bitsetLen int
+	// count of strings in each group
+	counts  map[string]int
+	buckets map[int]sharedBucket
+}
+
+// sharedBucket contains the sorted strings of the same length with their group bitsets
+type sharedBucket struct {
+	entries string
+	groups  []byte
+}
+
+func NewSharedGroupedStringCache() *SharedGroupedStringCache {
+	return &SharedGroupedStringCache{
+		groupBits: make(map[string]int),
+		counts:    make(map[string]int),
+		buckets:   make(map[int]sharedBucket),
+	}
+}
+
+func (c *SharedGroupedStringCache) ElementCount(group string) int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.counts[group]
+}
+
+// UniqueElementCount returns the amount of unique strings in all groups
+func (c *SharedGroupedStringCache) UniqueElementCount() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	count := 0
+
+	for length, bucket := range c.buckets {
+		count += len(bucket.entries) / length
+	}
+
+	return count
+}
+
+func (c *SharedGroupedStringCache) Contains(searchString string, groups []string) []string {
+	normalized := normalizeEntry(searchString)
+	searchLen := len(normalized)
+
+	if searchLen == 0 {
+		return nil
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	bucket := c.buckets[searchLen]
+	bucketLen := len(bucket.entries) / searchLen
+
+	idx := sort.Search(bucketLen, func(i int) bool {
+		return bucket.entries[i*searchLen:i*searchLen+searchLen] >= normalized
+	})
+
+	if idx >= bucketLen || bucket.entries[idx*searchLen:idx*searchLen+searchLen] != normalized {
+		return nil
+	}
+
+	bitset := bucket.groups[idx*c.bitsetLen : (idx+1)*c.bitsetLen]
+
+	var result []string
+
+	for _, group := range groups {
+		if bit, ok := c.groupBits[group]; ok && bitset[bit/bitsPerByte]&(1<<(bit%bitsPerByte)) != 0 {
+			result = append(result, group)
+		}
+	}
+
+	return result
+}
+
+func (c *SharedGroupedStringCache) Refresh(group string) GroupFactory {
+	return &sharedGroupFactory{
+		factory: &stringCacheFactory{tmp: make(map[int][]string)},
+		finishFn: func(entries map[int][]string) {
+			c.replaceGroup(group, entries)
+		},
+	}
+}
+
+// replaceGroup replaces the strings of the group with the passed sorted strings grouped by length
+func (c *SharedGroupedStringCache) replaceGroup(group string, entries map[int][]string) {
+	c.refreshLock.Lock()
+	defer c.refreshLock.Unlock()
+
+	// only refreshes modify the fields, so they can be read without lock here
+	bit, ok := c.groupBits[group]
+	if !ok {
+		bit = len(c.groupBits)
+	}
+
+	newBitsetLen := (max(bit+1, len(c.groupBits)) + bitsPerByte - 1) / bitsPerByte
+
+	buckets := make(map[int]sharedBucket, len(c.buckets))
+	count := 0
+
+	for length := range c.buckets {
+		if _, ok := entries[length]; !ok {
+			entries[length] = nil
+		}
+	}
+
+	for length, groupEntries := range entries {
+		bucket, bucketCount := c.mergeBucket(c.buckets[length], length, bit, newBitsetLen, groupEntries)
+		if len(bucket.entries) > 0 {
+			buckets[length] = bucket
+		}
+
+		count += bucketCount
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.groupBits[group] = bit
+	c.buckets = buckets
+	c.bitsetLen = newBitsetLen
+
+	if count > 0 {
+		c.counts[group] = count
+	} else {
+		delete(c.counts, group)
+	}
+}
+
+// mergeBucket merges the existing bucket with the new strings of the group identified by bit
+func (c *SharedGroupedStringCache) mergeBucket(
+	old sharedBucket, length, bit, bitsetLen int, groupEntries []string,
+) (sharedBucket, int) {
+	oldLen := len(old.entries) / length
+
+	var (
+		entries strings.Builder
+		count   int
+	)
+
+	entries.Grow(len(old.entries) + len(groupEntries)*length)
+
+	groups := make([]byte, 0, (oldLen+len(groupEntries))*bitsetLen)
+	byteIdx, mask := bit/bitsPerByte, byte(1<<(bit%bitsPerByte))
+
+	add := func(entry string, bitset []byte, inGroup bool) {
+		start := len(groups)
+		groups = append(groups, make([]byte, bitsetLen)...)
+
+		newBitset := groups[start:]
+		copy(newBitset, bitset)
+
+		if inGroup {
+			newBitset[byteIdx] |= mask
+			count++
+		} else {
+			newBitset[byteIdx] &^= mask
+		}
+
+		for _, b := range newBitset {
+			if b != 0 {
+				entries.WriteString(entry)
+
+				return
+			}
+		}
+
+		// the entry is in no group anymore
+		groups = groups[:start]
+	}
+
+	i, j := 0, 0
+
+	for i < oldLen || j < len(groupEntries) {
+		var oldEntry string
+		if i < oldLen {
+			oldEntry = old.entries[i*length : (i+1)*length]
+		}
+
+		switch {
+		case i < oldLen && (j >= len(groupEntries) || oldEntry < groupEntries[j]):
+			add(oldEntry, old.groups[i*c.bitsetLen:(i+1)*c.bitsetLen], false)
+			i++
+		case i < oldLen && oldEntry == groupEntries[j]:
+			add(oldEntry, old.groups[i*c.bitsetLen:(i+1)*c.bitsetLen], true)
+			i++
+			j++
+		default:
+			add(groupEntries[j], nil, true)
+			j++
+		}
+	}
+
+	// the buffers were sized for the worst case: copy the results to not keep the unused capacity
+	return sharedBucket{entries: strings.Clone(entries.String()), groups: slices.Clone(groups)}, count
+}
+
+type sharedGroupFactory struct {
+	factory  *stringCacheFactory
+	finishFn func(map[int][]string)
+}
+
+func (c *sharedGroupFactory) AddEntry(entry string) bool {
+	return c.factory.addEntry(entry)
+}
+
+func (c *sharedGroupFactory) Count() int {
+	return c.factory.count()
+}
+
+func (c *sharedGroupFactory) encode(enc *gob.Encoder) error {
+	return c.factory.encode(enc)
+}
+
+func (c *sharedGroupFactory) decode(dec *gob.Decoder) error {
+	return c.factory.decode(dec)
+}
+
+func (c *sharedGroupFactory) Finish() {
+	c.finishFn(c.factory.tmp)
+}
@@ -0,0 +1,97 @@
+package stringcache_test
+
+import (
+	"github.com/0xERR0R/blocky/cache/stringcache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shared grouped cache", func() {
+	var cache *stringcache.SharedGroupedStringCache
+
+	BeforeEach(func() {
+		cache = stringcache.NewSharedGroupedStringCache()
+	})
+
+	fill := func(group string, entries ...string) {
+		factory := cache.Refresh(group)
+
+		for _, entry := range entries {
+			Expect(factory.AddEntry(entry)).Should(BeTrue())
+		}
+
+		factory.Finish()
+	}
+
+	When("the cache is empty", func() {
+		It("should have element count of 0", func() {
+			Expect(cache.ElementCount("group1")).Should(BeZero())
+			Expect(cache.UniqueElementCount()).Should(BeZero())
+		})
+
+		It("should not find any string", func() {
+			Expect(cache.Contains("searchString", []string{"group1"})).Should(BeEmpty())
+			Expect(cache.Contains("", []string{"group1"})).Should(BeEmpty())
+		})
+	})
+
+	When("groups share strings", func() {
+		BeforeEach(func() {
+			fill("group1", "shared.com", "one.com", "Other.com")
+			fill("group2", "shared.com", "two.com", "two.com")
+		})
+
+		It("should store shared strings once", func() {
+			Expect(cache.ElementCount("group1")).Should(Equal(3))
+			Expect(cache.ElementCount("group2")).Should(Equal(2))
+			Expect(cache.UniqueElementCount()).Should(Equal(4))
+		})
+
+		It("should find the strings in their groups", func() {
+			Expect(cache.Contains("shared.com", []string{"group1", "group2"})).Should(Equal([]string{"group1", "group2"}))
+			Expect(cache.Contains("ONE.com", []string{"group1", "group2"})).Should(Equal([]string{"group1"}))
+			Expect(cache.Contains("other.com", []string{"group1"})).Should(Equal([]string{"group1"}))
+			Expect(cache.Contains("two.com", []string{"group1", "group2"})).Should(Equal([]string{"group2"}))
+			Expect(cache.Contains("two.com", []string{"group1"})).Should(BeEmpty())
+			Expect(cache.Contains("two.com", []string{"unknown"})).Should(BeEmpty())
+		})
+
+		It("should replace the strings of a refreshed group only", func() {
+			fill("group1", "new.com")
+
+			Expect(cache.Contains("new.com", []string{"group1", "group2"})).Should(Equal([]string{"group1"}))
+			Expect(cache.Contains("shared.com", []string{"group1", "group2"})).Should(Equal([]string{"group2"}))
+			Expect(cache.Contains("one.com", []string{"group1", "group2"})).Should(BeEmpty())
+			Expect(cache.ElementCount("group1")).Should(Equal(1))
+			Expect(cache.UniqueElementCount()).Should(Equal(3))
+		})
+
+		It("should remove the strings of an emptied group", func() {
+			fill("group2")
+
+			Expect(cache.ElementCount("group2")).Should(BeZero())
+			Expect(cache.Contains("two.com", []string{"group2"})).Should(BeEmpty())
+			Expect(cache.UniqueElementCount()).Should(Equal(3))
+		})
+	})
+
+	When("the cache has many groups", func() {
+		groups := []string{"g0", "g1", "g2", "g3", "g4", "g5", "g6", "g7", "g8", "g9"}
+
+		BeforeEach(func() {
+			for i, group := range groups {
+				fill(group, "shared.com", group+".com")
+
+				// refresh an earlier group after the bitsets grew
+				fill(groups[i/2], "shared.com", groups[i/2]+".com")
+			}
+		})
+
+		It("should keep the groups of all strings", func() {
+			Expect(cache.Contains("shared.com", groups)).Should(Equal(groups))
+			Expect(cache.Contains("g9.com", groups)).Should(Equal([]string{"g9"}))
+			Expect(cache.Contains("g0.com", groups)).Should(Equal([]string{"g0"}))
+			Expect(cache.UniqueElementCount()).Should(Equal(len(groups) + 1))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package stringcache
 
 import (
+	"encoding/gob"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -15,6 +17,8 @@ type stringCache interface {
 }
 
 type cacheFactory interface {
+	persistentFactory
+
 	addEntry(entry string) bool
 	create() stringCache
 	count() int
@@ -115,6 +119,55 @@ func (s *stringCacheFactory) addEntry(entry string) bool {
 	return true
 }
 
+// encode writes the sorted strings of each length concatenated like in `stringMap`
+func (s *stringCacheFactory) encode(enc *gob.Encoder) error {
+	buckets := make(map[int]string, len(s.tmp))
+	for k, v := range s.tmp {
+		buckets[k] = strings.Join(v, "")
+	}
+
+	return encodeAll(enc, buckets, s.cnt)
+}
+
+func (s *stringCacheFactory) decode(dec *gob.Decoder) error {
+	var (
+		buckets map[int]string
+		cnt     int
+	)
+
+	if err := decodeAll(dec, &buckets, &cnt); err != nil {
+		return err
+	}
+
+	tmp := make(map[int][]string, len(buckets))
+
+	for length, entries := range buckets {
+		if length <= 0 || len(entries)%length != 0 {
+			return errInvalidEncoding
+		}
+
+		bucket := make([]string, 0, len(entries)/length)
+
+		for i := 0; i < len(entries); i += length {
+			entry := entries[i : i+length]
+
+			// the buckets must stay sorted without duplicates
+			if len(bucket) > 0 && bucket[len(bucket)-1] >= entry {
+				return errInvalidEncoding
+			}
+
+			bucket = append(bucket, entry)
+		}
+
+		tmp[length] = bucket
+	}
+
+	s.tmp = tmp
+	s.cnt = cnt
+
+	return nil
+}
+
 func (s *stringCacheFactory) create() stringCache {
 	if len(s.tmp) == 0 {
 		return nil
@@ -174,6 +227,39 @@ func (r *regexCacheFactory) count() int {
 	return len(r.cache)
 }
 
+// encode writes the regex sources: compiled regexes can't be encoded
+func (r *regexCacheFactory) encode(enc *gob.Encoder) error {
+	regexes := make([]string, len(r.cache))
+	for i, regex := range r.cache {
+		regexes[i] = regex.String()
+	}
+
+	return enc.Encode(regexes)
+}
+
+func (r *regexCacheFactory) decode(dec *gob.Decoder) error {
+	var regexes []string
+
+	if err := dec.Decode(&regexes); err != nil {
+		return err
+	}
+
+	cache := make(regexCache, len(regexes))
+
+	for i, regex := range regexes {
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidEncoding, err)
+		}
+
+		cache[i] = compiled
+	}
+
+	r.cache = cache
+
+	return nil
+}
+
 func (r *regexCacheFactory) create() stringCache {
 	if len(r.cache) == 0 {
 		return nil
@@ -237,6 +323,25 @@ func (r *wildcardCacheFactory) count() int {
 	return r.cnt
 }
 
+func (r *wildcardCacheFactory) encode(enc *gob.Encoder) error {
+	return encodeAll(enc, r.trie, r.cnt)
+}
+
+func (r *wildcardCacheFactory) decode(dec *gob.Decoder) error {
+	t := trie.NewTrie(trie.SplitTLD)
+
+	var cnt int
+
+	if err := decodeAll(dec, t, &cnt); err != nil {
+		return err
+	}
+
+	r.trie = t
+	r.cnt = cnt
+
+	return nil
+}
+
 func (r *wildcardCacheFactory) create() stringCache {
 	if r.cnt == 0 {
 		return nil
@@ -253,3 +358,23 @@ func normalizeWildcard(domain string) string {
 
 	return domain
 }
+
+func encodeAll(enc *gob.Encoder, values ...any) error {
+	for _, value := range values {
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeAll(dec *gob.Decoder, values ...any) error {
+	for _, value := range values {
+		if err := dec.Decode(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
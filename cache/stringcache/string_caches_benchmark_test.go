@@ -190,6 +190,111 @@ func benchmarkCache(b *testing.B, data []string, newFactory func() cacheFactory)
 	reportMemUsage(b, "cache", cache)
 }
 
+// --- Grouped Cache Building with the same list in several groups ---
+//
+// The shared cache stores the strings only once for all groups.
+//
+//nolint:lll
+// BenchmarkInMemoryGroupedStringCache3Groups      3     826 791 694 ns/op    45.33 cache_heap_MB    200 520 312 B/op    3 780 allocs/op
+// BenchmarkSharedGroupedStringCache3Groups        3   1 133 268 165 ns/op    15.82 cache_heap_MB    285 254 904 B/op    4 662 allocs/op
+
+func BenchmarkInMemoryGroupedStringCache3Groups(b *testing.B) {
+	benchmarkGroupedCacheGroups(b, func() GroupedStringCache {
+		return NewInMemoryGroupedStringCache()
+	})
+}
+
+func BenchmarkSharedGroupedStringCache3Groups(b *testing.B) {
+	benchmarkGroupedCacheGroups(b, func() GroupedStringCache {
+		return NewSharedGroupedStringCache()
+	})
+}
+
+func benchmarkGroupedCacheGroups(b *testing.B, newCache func() GroupedStringCache) {
+	baseMemStats = readMemStats()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var cache GroupedStringCache
+
+	for range b.N {
+		cache = newCache()
+
+		for _, group := range []string{"group1", "group2", "group3"} {
+			factory := cache.Refresh(group)
+
+			for _, s := range stringTestData {
+				factory.AddEntry(s)
+			}
+
+			factory.Finish()
+		}
+	}
+
+	b.StopTimer()
+	reportMemUsage(b, "cache", cache)
+}
+
+// --- Grouped Cache Querying of non-listed domains ---
+//
+// The bloom filter avoids searching the caches for most domains which aren't listed.
+//
+//nolint:lll
+// BenchmarkGroupedCacheMiss                   3     343 860 920 ns/op    28.94 cache_heap_MB    21 813 920 B/op    1 363 420 allocs/op
+// BenchmarkBloomFilteredGroupedCacheMiss      3      83 052 037 ns/op    30.00 cache_heap_MB    10 907 360 B/op      681 710 allocs/op
+
+func BenchmarkGroupedCacheMiss(b *testing.B) {
+	benchmarkGroupedCacheMiss(b, func(cache GroupedStringCache) GroupedStringCache {
+		return cache
+	})
+}
+
+func BenchmarkBloomFilteredGroupedCacheMiss(b *testing.B) {
+	benchmarkGroupedCacheMiss(b, func(cache GroupedStringCache) GroupedStringCache {
+		return NewBloomFilteredGroupedCache(cache, 0.01)
+	})
+}
+
+func benchmarkGroupedCacheMiss(b *testing.B, wrap func(GroupedStringCache) GroupedStringCache) {
+	baseMemStats = readMemStats()
+
+	cache := wrap(NewChainedGroupedCache(NewInMemoryGroupedWildcardCache(), NewInMemoryGroupedStringCache()))
+
+	factory := cache.Refresh("group")
+
+	for _, s := range wildcardTestData {
+		factory.AddEntry(s)
+	}
+
+	for _, s := range stringTestData {
+		factory.AddEntry(s)
+	}
+
+	factory.Finish()
+
+	queries := make([]string, 0, len(stringTestData))
+	for i := range stringTestData {
+		queries = append(queries, fmt.Sprintf("www.notlisted%d.net", i))
+	}
+
+	groups := []string{"group"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		for _, s := range queries {
+			if len(cache.Contains(s, groups)) != 0 {
+				b.Fatalf("cache contains unlisted value: %s", s)
+			}
+		}
+	}
+
+	b.StopTimer()
+	reportMemUsage(b, "cache", cache)
+}
+
 // ---
 
 func readMemStats() (res runtime.MemStats) {
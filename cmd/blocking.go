@@ -48,7 +48,7 @@ func newBlockingCommand() *cobra.Command {
 }
 
 func enableBlocking(_ *cobra.Command, _ []string) error {
-	client, err := api.NewClientWithResponses(apiURL())
+	client, err := newAPIClient()
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
@@ -68,7 +68,7 @@ func disableBlocking(cmd *cobra.Command, _ []string) error {
 	durationString := duration.String()
 	groupsString := strings.Join(groups, ",")
 
-	client, err := api.NewClientWithResponses(apiURL())
+	client, err := newAPIClient()
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
@@ -85,7 +85,7 @@ func disableBlocking(cmd *cobra.Command, _ []string) error {
 }
 
 func statusBlocking(_ *cobra.Command, _ []string) error {
-	client, err := api.NewClientWithResponses(apiURL())
+	client, err := newAPIClient()
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/0xERR0R/blocky/config"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand creates new command instance
+func NewConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "configuration operations",
+	}
+
+	c.AddCommand(newConfigMigrateCommand(), newConfigImportPiholeCommand(), newConfigExportCommand())
+
+	return c
+}
+
+func newConfigMigrateCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "migrate",
+		Args:  cobra.NoArgs,
+		Short: "Replaces deprecated options of the configuration file and prints the result",
+		RunE:  migrateConfiguration,
+	}
+
+	c.Flags().BoolP("write", "w", false, "write the result to the configuration file instead of printing it")
+
+	return c
+}
+
+func migrateConfiguration(cmd *cobra.Command, _ []string) error {
+	write, _ := cmd.Flags().GetBool("write")
+
+	stat, err := os.Stat(configPath)
+	if err != nil {
+		return fmt.Errorf("can't read configuration file: %w", err)
+	}
+
+	if stat.IsDir() {
+		return errors.New("configuration path is a directory, please migrate each file separately")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("can't read configuration file: %w", err)
+	}
+
+	migrated, changes, err := config.MigrateFile(data)
+	if err != nil {
+		return fmt.Errorf("can't migrate configuration file '%s': %w", configPath, err)
+	}
+
+	// the changes are reported on stderr, so the output can be redirected to a file
+	for _, change := range changes {
+		fmt.Fprintln(cmd.ErrOrStderr(), change)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "configuration uses no deprecated options")
+	}
+
+	if !write {
+		_, err = cmd.OutOrStdout().Write(migrated)
+
+		return err
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return os.WriteFile(configPath, migrated, stat.Mode().Perm())
+}
+
+func newConfigImportPiholeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-pihole <teleporter archive>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Converts a Pi-hole Teleporter archive into a blocky configuration and prints it",
+		RunE:  importPihole,
+	}
+}
+
+func importPihole(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("can't open Teleporter archive: %w", err)
+	}
+
+	defer f.Close()
+
+	converted, notes, err := config.ImportPiholeTeleporter(f)
+	if err != nil {
+		return fmt.Errorf("can't import Teleporter archive '%s': %w", args[0], err)
+	}
+
+	// the notes are reported on stderr, so the output can be redirected to a file
+	for _, note := range notes {
+		fmt.Fprintln(cmd.ErrOrStderr(), note)
+	}
+
+	_, err = cmd.OutOrStdout().Write(converted)
+
+	return err
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatUnbound = "unbound"
+	exportFormatBind    = "bind"
+
+	defaultExportBindIP = "127.0.0.1"
+)
+
+// forwardZone is a zone, which the resolver in front of blocky forwards to the upstreams
+type forwardZone struct {
+	name      string
+	upstreams []config.Upstream
+	// source of the zone in the blocky configuration
+	source string
+}
+
+func newConfigExportCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "export",
+		Args:  cobra.NoArgs,
+		Short: "Prints forward zones for unbound or bind, which are equivalent to conditional forwarding and custom DNS",
+		Long: `Prints forward zones for a resolver in front of blocky: the conditional domains are forwarded to
+their upstreams, the custom DNS domains to blocky.`,
+		RunE: exportConfiguration,
+	}
+
+	c.Flags().StringP("format", "f", exportFormatUnbound, "output format (unbound, bind)")
+	c.Flags().StringP("address", "a", "", "address of blocky, default is the first DNS listener")
+
+	return c
+}
+
+func exportConfiguration(cmd *cobra.Command, _ []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	address, _ := cmd.Flags().GetString("address")
+
+	if format != exportFormatUnbound && format != exportFormatBind {
+		return fmt.Errorf("unknown export format '%s'", format)
+	}
+
+	cfg, err := config.LoadConfig(configPath, true)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration file '%s': %w", configPath, err)
+	}
+
+	if address == "" {
+		if len(cfg.Ports.DNS) == 0 {
+			return errors.New("no DNS listener configured, please pass the address of blocky")
+		}
+
+		address = listenerAddress(cfg.Ports.DNS[0], defaultExportBindIP)
+	}
+
+	blocky, err := config.ParseUpstream(address)
+	if err != nil {
+		return fmt.Errorf("invalid address of blocky '%s': %w", address, err)
+	}
+
+	zones := createForwardZones(cfg, blocky)
+
+	if format == exportFormatBind {
+		return writeBindZones(cmd.OutOrStdout(), zones)
+	}
+
+	return writeUnboundZones(cmd.OutOrStdout(), zones)
+}
+
+// createForwardZones returns the zones sorted by name. The custom DNS domains take precedence, blocky
+// resolves the conditional domains too.
+func createForwardZones(cfg *config.Config, blocky config.Upstream) []forwardZone {
+	zones := make(map[string]forwardZone)
+
+	add := func(name string, upstreams []config.Upstream, source string) {
+		name = strings.ToLower(strings.Trim(name, "."))
+
+		if _, ok := zones[name]; !ok {
+			zones[name] = forwardZone{name: name, upstreams: upstreams, source: source}
+		}
+	}
+
+	toBlocky := []config.Upstream{blocky}
+
+	for name := range cfg.CustomDNS.Mapping {
+		add(name, toBlocky, "customDNS.mapping")
+	}
+
+	for name := range cfg.CustomDNS.Zone.RRs {
+		add(name, toBlocky, "customDNS.zone")
+	}
+
+	for name := range cfg.CustomDNS.Rewrite {
+		add(name, toBlocky, "customDNS.rewrite")
+	}
+
+	for _, entry := range cfg.CustomDNS.ReverseSynthesis {
+		for _, zone := range util.ReverseZones(entry.Subnet) {
+			add(zone, toBlocky, "customDNS.reverseSynthesis "+entry.Subnet.String())
+		}
+	}
+
+	for name := range cfg.Conditional.Rewrite {
+		add(name, toBlocky, "conditional.rewrite")
+	}
+
+	for name, upstreams := range cfg.Conditional.Mapping.Upstreams {
+		add(name, upstreams, "conditional.mapping")
+	}
+
+	for zone, domain := range cfg.Conditional.ReverseZoneDomains() {
+		add(zone, cfg.Conditional.Mapping.Upstreams[domain], "conditional.reverseZones "+domain)
+	}
+
+	result := make([]forwardZone, 0, len(zones))
+
+	for _, zone := range zones {
+		result = append(result, zone)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	return result
+}
+
+// splitUpstreams returns the plain DNS and the DNS-over-TLS upstreams, DoH isn't supported by unbound and bind
+func splitUpstreams(upstreams []config.Upstream) (plain, tls, unsupported []config.Upstream) {
+	for _, u := range upstreams {
+		switch {
+		case u.IsRegistry():
+			// the registered instances are only known at runtime
+			unsupported = append(unsupported, u)
+		case u.Net == config.NetProtocolTcpUdp:
+			plain = append(plain, u)
+		case u.Net == config.NetProtocolTcpTls:
+			tls = append(tls, u)
+		default:
+			unsupported = append(unsupported, u)
+		}
+	}
+
+	return plain, tls, unsupported
+}
+
+func writeUnboundZones(w io.Writer, zones []forwardZone) error {
+	var sb strings.Builder
+
+	for _, zone := range zones {
+		if zone.name == "" {
+			fmt.Fprintf(&sb, "# %s: unqualified names can't be forwarded by unbound\n\n", zone.source)
+
+			continue
+		}
+
+		plain, tls, unsupported := splitUpstreams(zone.upstreams)
+
+		fmt.Fprintf(&sb, "# %s\n", zone.source)
+
+		for _, u := range unsupported {
+			fmt.Fprintf(&sb, "# upstream %s is not supported by unbound\n", u)
+		}
+
+		// TLS is configured per zone, the plain upstreams are used if both are configured
+		if len(plain) > 0 {
+			for _, u := range tls {
+				fmt.Fprintf(&sb, "# upstream %s is skipped, unbound can't mix TLS and plain DNS in a zone\n", u)
+			}
+
+			tls = nil
+		}
+
+		if len(plain) == 0 && len(tls) == 0 {
+			sb.WriteString("\n")
+
+			continue
+		}
+
+		fmt.Fprintf(&sb, "forward-zone:\n    name: \"%s.\"\n", zone.name)
+
+		if len(tls) > 0 {
+			sb.WriteString("    forward-tls-upstream: yes\n")
+		}
+
+		for _, u := range append(plain, tls...) {
+			key := "forward-addr"
+			if net.ParseIP(u.Host) == nil {
+				key = "forward-host"
+			}
+
+			fmt.Fprintf(&sb, "    %s: %s@%d", key, u.Host, u.Port)
+
+			if u.Net == config.NetProtocolTcpTls {
+				sb.WriteString("#" + tlsServerName(u))
+			}
+
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func writeBindZones(w io.Writer, zones []forwardZone) error {
+	var sb strings.Builder
+
+	for _, zone := range zones {
+		if zone.name == "" {
+			fmt.Fprintf(&sb, "// %s: unqualified names can't be forwarded by bind\n\n", zone.source)
+
+			continue
+		}
+
+		plain, tls, unsupported := splitUpstreams(zone.upstreams)
+
+		fmt.Fprintf(&sb, "// %s\n", zone.source)
+
+		var forwarders []string
+
+		for _, u := range append(unsupported, tls...) {
+			fmt.Fprintf(&sb, "// upstream %s is not supported, bind forwards only plain DNS to IP addresses\n", u)
+		}
+
+		for _, u := range plain {
+			if net.ParseIP(u.Host) == nil {
+				fmt.Fprintf(&sb, "// upstream %s is not supported, bind forwards only plain DNS to IP addresses\n", u)
+
+				continue
+			}
+
+			forwarders = append(forwarders, fmt.Sprintf("%s port %d;", u.Host, u.Port))
+		}
+
+		if len(forwarders) > 0 {
+			fmt.Fprintf(&sb, "zone \"%s\" {\n    type forward;\n    forward only;\n    forwarders { %s };\n};\n",
+				zone.name, strings.Join(forwarders, " "))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func tlsServerName(u config.Upstream) string {
+	if u.CommonName != "" {
+		return u.CommonName
+	}
+
+	return u.Host
+}
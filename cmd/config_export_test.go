@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/0xERR0R/blocky/helpertest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config export command", func() {
+	var (
+		tmpDir *helpertest.TmpFolder
+		out    *bytes.Buffer
+		cfg    *helpertest.TmpFile
+	)
+
+	BeforeEach(func() {
+		tmpDir = helpertest.NewTmpFolder("config-export")
+		DeferCleanup(tmpDir.Clean)
+
+		out = new(bytes.Buffer)
+
+		cfg = tmpDir.CreateStringFile("config.yml",
+			"upstreams:",
+			"  groups:",
+			"    default:",
+			"      - 1.1.1.1",
+			"ports:",
+			"  dns: 5353",
+			"customDNS:",
+			"  mapping:",
+			"    printer.lan: 192.168.178.3",
+			"conditional:",
+			"  mapping:",
+			"    fritz.box: 192.168.178.1",
+			"    corp.example: tcp-tls:dns.corp.example:853,https://dns.corp.example/dns-query",
+			"    .: 192.168.178.1",
+			"  reverseZones:",
+			"    fritz.box: 192.168.178.0/24",
+		)
+	})
+
+	execute := func(args ...string) error {
+		c := NewRootCommand()
+		c.SetOut(out)
+		c.SetArgs(append([]string{"config", "export", "--config", cfg.Path}, args...))
+
+		return c.Execute()
+	}
+
+	When("the format is unbound", func() {
+		It("should print forward zones", func() {
+			Expect(execute("--format", "unbound")).Should(Succeed())
+
+			Expect(out.String()).Should(Equal(`# conditional.mapping: unqualified names can't be forwarded by unbound
+
+# conditional.reverseZones fritz.box
+forward-zone:
+    name: "178.168.192.in-addr.arpa."
+    forward-addr: 192.168.178.1@53
+
+# conditional.mapping
+# upstream https://dns.corp.example/dns-query is not supported by unbound
+forward-zone:
+    name: "corp.example."
+    forward-tls-upstream: yes
+    forward-host: dns.corp.example@853#dns.corp.example
+
+# conditional.mapping
+forward-zone:
+    name: "fritz.box."
+    forward-addr: 192.168.178.1@53
+
+# customDNS.mapping
+forward-zone:
+    name: "printer.lan."
+    forward-addr: 127.0.0.1@5353
+
+`))
+		})
+	})
+
+	When("the format is bind", func() {
+		It("should print forward zones", func() {
+			Expect(execute("--format", "bind", "--address", "192.168.178.2:53")).Should(Succeed())
+
+			Expect(out.String()).Should(ContainSubstring(`// conditional.mapping
+zone "fritz.box" {
+    type forward;
+    forward only;
+    forwarders { 192.168.178.1 port 53; };
+};
+`))
+			Expect(out.String()).Should(ContainSubstring(`// customDNS.mapping
+zone "printer.lan" {
+    type forward;
+    forward only;
+    forwarders { 192.168.178.2 port 53; };
+};
+`))
+			Expect(out.String()).Should(ContainSubstring(
+				"// upstream tcp-tls:dns.corp.example is not supported, bind forwards only plain DNS to IP addresses\n"))
+		})
+	})
+
+	When("the format is unknown", func() {
+		It("should terminate with error", func() {
+			Expect(execute("--format", "dnsmasq")).Should(MatchError("unknown export format 'dnsmasq'"))
+		})
+	})
+})
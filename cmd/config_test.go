@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+
+	"github.com/0xERR0R/blocky/helpertest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config command", func() {
+	var (
+		tmpDir *helpertest.TmpFolder
+		out    *bytes.Buffer
+		errOut *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		tmpDir = helpertest.NewTmpFolder("config")
+		DeferCleanup(tmpDir.Clean)
+
+		out = new(bytes.Buffer)
+		errOut = new(bytes.Buffer)
+	})
+
+	execute := func(args ...string) error {
+		c := NewRootCommand()
+		c.SetOut(out)
+		c.SetErr(errOut)
+		c.SetArgs(append([]string{"config", "migrate"}, args...))
+
+		return c.Execute()
+	}
+
+	When("migrate is called with a deprecated configuration", func() {
+		var cfgFile *helpertest.TmpFile
+
+		BeforeEach(func() {
+			cfgFile = tmpDir.CreateStringFile("config.yaml",
+				"upstream:",
+				"  default:",
+				"    - 1.1.1.1")
+		})
+
+		It("should print the migrated configuration", func() {
+			Expect(execute("--config", cfgFile.Path)).Should(Succeed())
+
+			Expect(out.String()).Should(Equal("upstreams:\n  groups:\n    default:\n      - 1.1.1.1\n"))
+			Expect(errOut.String()).Should(Equal(`moved "upstream" to "upstreams.groups"` + "\n"))
+			Expect(os.ReadFile(cfgFile.Path)).Should(ContainSubstring("upstream:\n"))
+		})
+
+		It("should write the migrated configuration", func() {
+			Expect(execute("--config", cfgFile.Path, "--write")).Should(Succeed())
+
+			Expect(out.String()).Should(BeEmpty())
+			Expect(os.ReadFile(cfgFile.Path)).Should(ContainSubstring("upstreams:\n  groups:\n"))
+		})
+	})
+
+	When("migrate is called with a current configuration", func() {
+		It("should print the unchanged configuration", func() {
+			cfgFile := tmpDir.CreateStringFile("config.yaml", "ports:", "  dns: 53")
+
+			Expect(execute("--config", cfgFile.Path)).Should(Succeed())
+
+			Expect(out.String()).Should(Equal("ports:\n  dns: 53"))
+		})
+	})
+
+	When("migrate is called with an invalid configuration", func() {
+		It("should terminate with error", func() {
+			cfgFile := tmpDir.CreateStringFile("config.yaml", "a: b: c")
+
+			Expect(execute("--config", cfgFile.Path)).Should(MatchError(ContainSubstring("can't migrate")))
+		})
+	})
+
+	When("migrate is called with a directory", func() {
+		It("should terminate with error", func() {
+			Expect(execute("--config", tmpDir.Path)).Should(MatchError(ContainSubstring("is a directory")))
+		})
+	})
+
+	When("migrate is called with not existing configuration file", func() {
+		It("should terminate with error", func() {
+			Expect(execute("--config", "/notexisting/path.yaml")).Should(HaveOccurred())
+		})
+	})
+
+	When("import-pihole is called with a Teleporter archive", func() {
+		It("should print the converted configuration", func() {
+			archive := new(bytes.Buffer)
+			gz := gzip.NewWriter(archive)
+			tw := tar.NewWriter(gz)
+			content := "192.168.178.2 nas.lan\n"
+
+			Expect(tw.WriteHeader(&tar.Header{
+				Name: "custom.list", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content)),
+			})).Should(Succeed())
+			_, err := tw.Write([]byte(content))
+			Expect(err).Should(Succeed())
+			Expect(tw.Close()).Should(Succeed())
+			Expect(gz.Close()).Should(Succeed())
+
+			archiveFile := tmpDir.JoinPath("teleporter.tar.gz")
+			Expect(os.WriteFile(archiveFile, archive.Bytes(), 0o600)).Should(Succeed())
+
+			c := NewRootCommand()
+			c.SetOut(out)
+			c.SetErr(errOut)
+			c.SetArgs([]string{"config", "import-pihole", archiveFile})
+
+			Expect(c.Execute()).Should(Succeed())
+			Expect(out.String()).Should(ContainSubstring("customDNS:\n  mapping:\n    nas.lan: 192.168.178.2\n"))
+			Expect(errOut.String()).Should(BeEmpty())
+		})
+	})
+
+	When("import-pihole is called with not existing archive", func() {
+		It("should terminate with error", func() {
+			c := NewRootCommand()
+			c.SetOut(out)
+			c.SetErr(errOut)
+			c.SetArgs([]string{"config", "import-pihole", "/notexisting/teleporter.tar.gz"})
+
+			Expect(c.Execute()).Should(MatchError(ContainSubstring("can't open Teleporter archive")))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/0xERR0R/blocky/api"
 	"github.com/spf13/cobra"
@@ -16,26 +17,35 @@ func NewListsCommand() *cobra.Command {
 		PersistentPreRunE: initConfigPreRun,
 	}
 
-	c.AddCommand(newRefreshCommand())
+	c.AddCommand(newRefreshCommand(), newEvaluateCommand())
 
 	return c
 }
 
 func newRefreshCommand() *cobra.Command {
-	return &cobra.Command{
+	c := &cobra.Command{
 		Use:   "refresh",
-		Short: "refreshes all lists",
+		Short: "refreshes all lists or the lists of the passed groups",
 		RunE:  refreshList,
 	}
+	c.Flags().StringArrayP("groups", "g", []string{}, "list groups to refresh")
+
+	return c
 }
 
-func refreshList(_ *cobra.Command, _ []string) error {
-	client, err := api.NewClientWithResponses(apiURL())
+func refreshList(cmd *cobra.Command, _ []string) error {
+	groups, _ := cmd.Flags().GetStringArray("groups")
+
+	groupsString := strings.Join(groups, ",")
+
+	client, err := newAPIClient()
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
 
-	resp, err := client.ListRefreshWithResponse(context.Background())
+	resp, err := client.ListRefreshWithResponse(context.Background(), &api.ListRefreshParams{
+		Group: &groupsString,
+	})
 	if err != nil {
 		return fmt.Errorf("can't execute %w", err)
 	}
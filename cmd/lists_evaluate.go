@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/resolver"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+const (
+	evaluateOutputText = "text"
+	evaluateOutputJSON = "json"
+)
+
+// columns of the CSV query log, see querylog.FileWriter
+const (
+	queryLogColClientIP = 1
+	queryLogColClients  = 2
+	queryLogColQuestion = 5
+	queryLogColQType    = 9
+)
+
+// evaluationDiff is a domain with different blocking decisions of the baseline and the candidate configuration
+type evaluationDiff struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+	Reason string `json:"reason"`
+}
+
+// evaluationReport is the result of the replay, printed with `--output json`
+type evaluationReport struct {
+	Queries          int              `json:"queries"`
+	BlockedBaseline  int              `json:"blockedBaseline"`
+	BlockedCandidate int              `json:"blockedCandidate"`
+	NewlyBlocked     []evaluationDiff `json:"newlyBlocked"`
+	NoLongerBlocked  []evaluationDiff `json:"noLongerBlocked"`
+}
+
+// emptyAnswerResolver answers all queries with an empty answer, so only the question is evaluated
+type emptyAnswerResolver struct {
+	resolver.NoOpResolver
+}
+
+func (emptyAnswerResolver) Resolve(_ context.Context, request *model.Request) (*model.Response, error) {
+	response := new(dns.Msg)
+	response.SetReply(request.Req)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeRESOLVED}, nil
+}
+
+func newEvaluateCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "evaluate",
+		Args:  cobra.NoArgs,
+		Short: "replays a query log against the lists of the baseline and of this configuration",
+		Long: `Replays the queries of a CSV query log against the allow/denylists of two configurations and
+reports the domains with different blocking decisions. The configuration passed with --config is the candidate.`,
+		RunE: evaluateLists,
+	}
+
+	c.Flags().StringP("baseline", "b", "", "path to the baseline config file or folder")
+	c.Flags().StringSliceP("replay", "r", []string{}, "CSV query log file(s) to replay")
+	c.Flags().StringP("output", "o", evaluateOutputText, "output format (text, json)")
+
+	_ = c.MarkFlagRequired("baseline")
+	_ = c.MarkFlagRequired("replay")
+
+	return c
+}
+
+func evaluateLists(cmd *cobra.Command, _ []string) error {
+	baselinePath, _ := cmd.Flags().GetString("baseline")
+	replay, _ := cmd.Flags().GetStringSlice("replay")
+	output, _ := cmd.Flags().GetString("output")
+
+	if output != evaluateOutputText && output != evaluateOutputJSON {
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // stops the list refresh of the resolvers
+
+	baseline, err := newListsEvaluator(ctx, baselinePath)
+	if err != nil {
+		return fmt.Errorf("can't load baseline: %w", err)
+	}
+
+	candidate, err := newListsEvaluator(ctx, configPath)
+	if err != nil {
+		return fmt.Errorf("can't load candidate: %w", err)
+	}
+
+	requests, err := readQueryLogs(replay)
+	if err != nil {
+		return err
+	}
+
+	report, err := evaluate(ctx, baseline, candidate, requests)
+	if err != nil {
+		return err
+	}
+
+	if output == evaluateOutputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("can't write report: %w", err)
+		}
+
+		return nil
+	}
+
+	logEvaluationReport(report)
+
+	return nil
+}
+
+// newListsEvaluator creates a blocking resolver with the lists of the configuration, the lists are loaded on return
+func newListsEvaluator(ctx context.Context, path string) (*resolver.BlockingResolver, error) {
+	cfg, err := config.LoadConfig(path, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load configuration file '%s': %w", path, err)
+	}
+
+	blockingCfg := cfg.Blocking
+
+	// the decisions must not depend on the persisted state of a running instance
+	blockingCfg.StateFile = ""
+
+	if blockingCfg.Loading.Strategy == config.InitStrategyFast {
+		blockingCfg.Loading.Strategy = config.InitStrategyBlocking
+	}
+
+	bootstrap, err := resolver.NewBootstrap(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := resolver.NewBlockingResolver(ctx, blockingCfg, nil, bootstrap)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Next(emptyAnswerResolver{})
+
+	return r, nil
+}
+
+// readQueryLogs reads the queries of the CSV query log files
+func readQueryLogs(paths []string) ([]*model.Request, error) {
+	var requests []*model.Request
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't open query log: %w", err)
+		}
+
+		fileRequests, err := parseQueryLog(f)
+
+		f.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("can't read query log '%s': %w", path, err)
+		}
+
+		requests = append(requests, fileRequests...)
+	}
+
+	return requests, nil
+}
+
+func parseQueryLog(r io.Reader) ([]*model.Request, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var requests []*model.Request
+
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return requests, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(row) <= queryLogColQType {
+			return nil, fmt.Errorf("line %d: expected at least %d columns, got %d", line, queryLogColQType+1, len(row))
+		}
+
+		question := strings.TrimSpace(row[queryLogColQuestion])
+		if question == "" {
+			continue
+		}
+
+		qType, found := dns.StringToType[row[queryLogColQType]]
+		if !found {
+			qType = dns.TypeA
+		}
+
+		var clientNames []string
+
+		for _, name := range strings.Split(row[queryLogColClients], "; ") {
+			if name != "" {
+				clientNames = append(clientNames, name)
+			}
+		}
+
+		requests = append(requests, &model.Request{
+			ClientIP:    net.ParseIP(row[queryLogColClientIP]),
+			ClientNames: clientNames,
+			Protocol:    model.RequestProtocolUDP,
+			Req:         util.NewMsgWithQuestion(dns.Fqdn(question), dns.Type(qType)),
+			RequestTS:   time.Now(),
+		})
+	}
+}
+
+// evaluate resolves each request with both configurations and collects the domains with different decisions
+func evaluate(ctx context.Context, baseline, candidate resolver.Resolver, requests []*model.Request,
+) (*evaluationReport, error) {
+	report := evaluationReport{Queries: len(requests)}
+
+	newlyBlocked := make(map[string]*evaluationDiff)
+	noLongerBlocked := make(map[string]*evaluationDiff)
+
+	for _, request := range requests {
+		baselineResp, err := baseline.Resolve(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("can't evaluate baseline: %w", err)
+		}
+
+		candidateResp, err := candidate.Resolve(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("can't evaluate candidate: %w", err)
+		}
+
+		baselineBlocked := baselineResp.RType == model.ResponseTypeBLOCKED
+		candidateBlocked := candidateResp.RType == model.ResponseTypeBLOCKED
+
+		if baselineBlocked {
+			report.BlockedBaseline++
+		}
+
+		if candidateBlocked {
+			report.BlockedCandidate++
+		}
+
+		domain := util.ExtractDomain(request.Req.Question[0])
+
+		switch {
+		case candidateBlocked && !baselineBlocked:
+			countDiff(newlyBlocked, domain, candidateResp.Reason.String())
+		case baselineBlocked && !candidateBlocked:
+			countDiff(noLongerBlocked, domain, baselineResp.Reason.String())
+		}
+	}
+
+	report.NewlyBlocked = sortedDiffs(newlyBlocked)
+	report.NoLongerBlocked = sortedDiffs(noLongerBlocked)
+
+	return &report, nil
+}
+
+func countDiff(diffs map[string]*evaluationDiff, domain, reason string) {
+	if diff, found := diffs[domain]; found {
+		diff.Count++
+
+		return
+	}
+
+	diffs[domain] = &evaluationDiff{Domain: domain, Count: 1, Reason: reason}
+}
+
+// sortedDiffs returns the diffs, the most frequent domains first
+func sortedDiffs(diffs map[string]*evaluationDiff) []evaluationDiff {
+	result := make([]evaluationDiff, 0, len(diffs))
+
+	for _, diff := range diffs {
+		result = append(result, *diff)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+
+		return result[i].Domain < result[j].Domain
+	})
+
+	return result
+}
+
+func logEvaluationReport(report *evaluationReport) {
+	logger := log.Log()
+
+	logger.Infof("replayed %d queries: %d blocked by baseline, %d blocked by candidate",
+		report.Queries, report.BlockedBaseline, report.BlockedCandidate)
+
+	logger.Infof("newly blocked domains: %d", len(report.NewlyBlocked))
+
+	for _, diff := range report.NewlyBlocked {
+		logger.Infof("+ %s (%d queries): %s", diff.Domain, diff.Count, diff.Reason)
+	}
+
+	logger.Infof("no longer blocked domains: %d", len(report.NoLongerBlocked))
+
+	for _, diff := range report.NoLongerBlocked {
+		logger.Infof("- %s (%d queries): %s", diff.Domain, diff.Count, diff.Reason)
+	}
+}
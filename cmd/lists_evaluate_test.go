@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lists evaluate command", func() {
+	var (
+		tmpDir     *TmpFolder
+		loggerHook *test.Hook
+		args       []string
+	)
+
+	createConfig := func(name string, domains ...string) string {
+		listFile := tmpDir.CreateStringFile(name+".txt", domains...)
+
+		return tmpDir.CreateStringFile(name+".yml",
+			"upstreams:",
+			"  groups:",
+			"    default:",
+			"      - 1.1.1.1",
+			"blocking:",
+			"  denylists:",
+			"    ads:",
+			"      - "+listFile.Path,
+			"  clientGroupsBlock:",
+			"    default:",
+			"      - ads",
+		).Path
+	}
+
+	BeforeEach(func() {
+		tmpDir = NewTmpFolder("evaluate")
+		DeferCleanup(tmpDir.Clean)
+
+		loggerHook = test.NewGlobal()
+		log.Log().AddHook(loggerHook)
+		DeferCleanup(loggerHook.Reset)
+
+		oldConfigPath := configPath
+		configPath = createConfig("candidate", "ads.com", "tracker.com")
+		DeferCleanup(func() { configPath = oldConfigPath })
+
+		queryLog := tmpDir.CreateStringFile("querylog.csv",
+			"2024-01-01 10:00:00\t192.168.178.2\tlaptop\t1\tRESOLVED (udp)\tads.com\tA (1.2.3.4)\tNOERROR\tRESOLVED\tA",
+			"2024-01-01 10:00:01\t192.168.178.2\tlaptop\t1\tRESOLVED (udp)\ttracker.com\tA (1.2.3.4)\tNOERROR\tRESOLVED\tA",
+			"2024-01-01 10:00:02\t192.168.178.3\tphone\t1\tRESOLVED (udp)\ttracker.com\t\tNOERROR\tRESOLVED\tAAAA",
+			"2024-01-01 10:00:03\t192.168.178.3\tphone\t0\tBLOCKED (ads)\told.com\tA (0.0.0.0)\tNOERROR\tBLOCKED\tA",
+			"2024-01-01 10:00:04\t192.168.178.3\tphone\t1\tRESOLVED (udp)\texample.com\tA (1.2.3.4)\tNOERROR\tRESOLVED\tA",
+		)
+
+		args = []string{
+			"evaluate",
+			"--baseline", createConfig("baseline", "ads.com", "old.com"),
+			"--replay", queryLog.Path,
+		}
+	})
+
+	When("the report is printed as JSON", func() {
+		It("should report the differences of the blocking decisions", func() {
+			out := new(bytes.Buffer)
+
+			c := NewListsCommand()
+			c.SetOut(out)
+			c.SetArgs(append(args, "--output", "json"))
+
+			Expect(c.Execute()).Should(Succeed())
+
+			var report evaluationReport
+
+			Expect(json.Unmarshal(out.Bytes(), &report)).Should(Succeed())
+			Expect(report).Should(Equal(evaluationReport{
+				Queries:          5,
+				BlockedBaseline:  2,
+				BlockedCandidate: 3,
+				NewlyBlocked:     []evaluationDiff{{Domain: "tracker.com", Count: 2, Reason: "BLOCKED (ads)"}},
+				NoLongerBlocked:  []evaluationDiff{{Domain: "old.com", Count: 1, Reason: "BLOCKED (ads)"}},
+			}))
+		})
+	})
+
+	When("the report is printed as text", func() {
+		It("should log the differences", func() {
+			c := NewListsCommand()
+			c.SetArgs(args)
+
+			Expect(c.Execute()).Should(Succeed())
+
+			var messages []string
+			for _, entry := range loggerHook.AllEntries() {
+				messages = append(messages, entry.Message)
+			}
+
+			Expect(messages).Should(ContainElements(
+				"replayed 5 queries: 2 blocked by baseline, 3 blocked by candidate",
+				"+ tracker.com (2 queries): BLOCKED (ads)",
+				"- old.com (1 queries): BLOCKED (ads)",
+			))
+		})
+	})
+
+	When("the query log has too few columns", func() {
+		It("should fail", func() {
+			c := NewListsCommand()
+			c.SetArgs([]string{
+				"evaluate",
+				"--baseline", configPath,
+				"--replay", tmpDir.CreateStringFile("invalid.csv", "2024-01-01 10:00:00\t192.168.178.2").Path,
+			})
+
+			Expect(c.Execute()).Should(MatchError(ContainSubstring("line 1: expected at least 10 columns, got 2")))
+		})
+	})
+
+	When("the output format is unknown", func() {
+		It("should fail", func() {
+			c := NewListsCommand()
+			c.SetArgs(append(args, "--output", "xml"))
+
+			Expect(c.Execute()).Should(MatchError("unknown output format 'xml'"))
+		})
+	})
+})
@@ -47,6 +47,21 @@ var _ = Describe("Lists command", func() {
 				Expect(loggerHook.LastEntry().Message).Should(ContainSubstring("OK"))
 			})
 		})
+		When("list refresh is executed for groups", func() {
+			BeforeEach(func() {
+				c = NewListsCommand()
+				c.SetArgs([]string{"refresh", "--groups", "ads", "-g", "kids"})
+				mockFn = func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.URL.Query().Get("group")).Should(Equal("ads,kids"))
+				}
+			})
+			It("should pass the groups", func() {
+				err = c.Execute()
+				Expect(err).Should(Succeed())
+
+				Expect(loggerHook.LastEntry().Message).Should(ContainSubstring("OK"))
+			})
+		})
 		When("Server returns 500", func() {
 			BeforeEach(func() {
 				c = newRefreshCommand()
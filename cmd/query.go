@@ -22,19 +22,21 @@ func NewQueryCommand() *cobra.Command {
 	}
 
 	c.Flags().StringP("type", "t", "A", "query type (A, AAAA, ...)")
+	c.Flags().BoolP("debug", "d", false, "print the trace of the resolver chain")
 
 	return c
 }
 
 func query(cmd *cobra.Command, args []string) error {
 	typeFlag, _ := cmd.Flags().GetString("type")
+	debug, _ := cmd.Flags().GetBool("debug")
 	qType := dns.StringToType[typeFlag]
 
 	if qType == dns.TypeNone {
 		return fmt.Errorf("unknown query type '%s'", typeFlag)
 	}
 
-	client, err := api.NewClientWithResponses(apiURL())
+	client, err := newAPIClient()
 	if err != nil {
 		return fmt.Errorf("can't create client: %w", err)
 	}
@@ -44,7 +46,7 @@ func query(cmd *cobra.Command, args []string) error {
 		Type:  typeFlag,
 	}
 
-	resp, err := client.QueryWithResponse(context.Background(), req)
+	resp, err := client.QueryWithResponse(context.Background(), &api.QueryParams{Debug: &debug}, req)
 	if err != nil {
 		return fmt.Errorf("can't execute %w", err)
 	}
@@ -59,5 +61,13 @@ func query(cmd *cobra.Command, args []string) error {
 	log.Log().Infof("\tresponse:      %20s", resp.JSON200.Response)
 	log.Log().Infof("\treturn code:   %20s", resp.JSON200.ReturnCode)
 
+	if resp.JSON200.Trace != nil {
+		log.Log().Info("\ttrace:")
+
+		for _, line := range *resp.JSON200.Trace {
+			log.Log().Infof("\t\t%s", line)
+		}
+	}
+
 	return nil
 }
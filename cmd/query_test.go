@@ -70,6 +70,33 @@ var _ = Describe("Blocking command", func() {
 				Expect(loggerHook.LastEntry().Message).Should(ContainSubstring("NOERROR"))
 			})
 		})
+		When("query command is called with debug flag", func() {
+			BeforeEach(func() {
+				mockFn = func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.URL.Query().Get("debug")).Should(Equal("true"))
+
+					w.Header().Add("Content-Type", "application/json")
+					response, err := json.Marshal(api.ApiQueryResult{
+						Reason:       "Reason",
+						ResponseType: "Type",
+						Response:     "Response",
+						ReturnCode:   "NOERROR",
+						Trace:        &[]string{"+1ms fqdn_only: start"},
+					})
+					Expect(err).Should(Succeed())
+
+					_, err = w.Write(response)
+					Expect(err).Should(Succeed())
+				}
+			})
+			It("should print the trace", func() {
+				command := NewQueryCommand()
+				Expect(command.Flags().Set("debug", "true")).Should(Succeed())
+
+				Expect(query(command, []string{"google.de"})).Should(Succeed())
+				Expect(loggerHook.LastEntry().Message).Should(ContainSubstring("+1ms fqdn_only: start"))
+			})
+		})
 		When("Server returns 500", func() {
 			BeforeEach(func() {
 				mockFn = func(w http.ResponseWriter, _ *http.Request) {
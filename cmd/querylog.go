@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/spf13/cobra"
+)
+
+func newQueryLogCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:               "querylog",
+		Short:             "Performs query log operations",
+		PersistentPreRunE: initConfigPreRun,
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "flush",
+		Args:  cobra.NoArgs,
+		Short: "Writes the queued and buffered query log entries",
+		RunE:  flushQueryLog,
+	})
+	c.AddCommand(newQueryLogRotateCommand())
+
+	return c
+}
+
+func newQueryLogRotateCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "rotate",
+		Args:  cobra.NoArgs,
+		Short: "Rotates the CSV query log files",
+		RunE:  rotateQueryLog,
+	}
+	c.Flags().Bool("gzip", false, "compress the rotated files. Default: the configured compression")
+
+	return c
+}
+
+func flushQueryLog(_ *cobra.Command, _ []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return fmt.Errorf("can't create client: %w", err)
+	}
+
+	resp, err := client.QueryLogFlushWithResponse(context.Background())
+	if err != nil {
+		return fmt.Errorf("can't execute %w", err)
+	}
+
+	return printOkOrError(resp, string(resp.Body))
+}
+
+func rotateQueryLog(cmd *cobra.Command, _ []string) error {
+	params := &api.QueryLogRotateParams{}
+
+	if cmd.Flags().Changed("gzip") {
+		compress, _ := cmd.Flags().GetBool("gzip")
+		params.Gzip = &compress
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return fmt.Errorf("can't create client: %w", err)
+	}
+
+	resp, err := client.QueryLogRotateWithResponse(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("can't execute %w", err)
+	}
+
+	return printOkOrError(resp, string(resp.Body))
+}
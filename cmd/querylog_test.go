@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/0xERR0R/blocky/log"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query log command", func() {
+	var (
+		ts         *httptest.Server
+		mockFn     func(w http.ResponseWriter, _ *http.Request)
+		request    *http.Request
+		loggerHook *test.Hook
+	)
+	JustBeforeEach(func() {
+		ts = testHTTPAPIServer(mockFn)
+	})
+	JustAfterEach(func() {
+		ts.Close()
+	})
+	BeforeEach(func() {
+		mockFn = func(w http.ResponseWriter, r *http.Request) {
+			request = r
+		}
+		loggerHook = test.NewGlobal()
+		log.Log().AddHook(loggerHook)
+	})
+	AfterEach(func() {
+		loggerHook.Reset()
+	})
+	Describe("flush query log", func() {
+		It("should flush the query log", func() {
+			Expect(flushQueryLog(newQueryLogCommand(), []string{})).Should(Succeed())
+			Expect(loggerHook.LastEntry().Message).Should(Equal("OK"))
+			Expect(request.URL.Path).Should(Equal("/api/querylog/flush"))
+		})
+	})
+	Describe("rotate query log", func() {
+		It("should rotate with the configured compression", func() {
+			Expect(rotateQueryLog(newQueryLogRotateCommand(), []string{})).Should(Succeed())
+			Expect(loggerHook.LastEntry().Message).Should(Equal("OK"))
+			Expect(request.URL.Path).Should(Equal("/api/querylog/rotate"))
+			Expect(request.URL.RawQuery).Should(BeEmpty())
+		})
+
+		It("should pass the compression flag", func() {
+			c := newQueryLogRotateCommand()
+			Expect(c.Flags().Set("gzip", "true")).Should(Succeed())
+
+			Expect(rotateQueryLog(c, []string{})).Should(Succeed())
+			Expect(request.URL.RawQuery).Should(Equal("gzip=true"))
+		})
+
+		When("the target has no files", func() {
+			BeforeEach(func() {
+				mockFn = func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			})
+
+			It("should end with error", func() {
+				Expect(rotateQueryLog(newQueryLogRotateCommand(), []string{})).Should(HaveOccurred())
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/spf13/cobra"
@@ -18,6 +20,7 @@ var (
 	configPath string
 	apiHost    string
 	apiPort    uint16
+	apiToken   string
 )
 
 const (
@@ -56,7 +59,11 @@ Complete documentation is available at https://github.com/0xERR0R/blocky`,
 		NewListsCommand(),
 		NewHealthcheckCommand(),
 		newCacheCommand(),
-		NewValidateCommand())
+		newQueryLogCommand(),
+		NewValidateCommand(),
+		NewConfigCommand(),
+		NewSelfCheckCommand(),
+		newServiceCommand())
 
 	return c
 }
@@ -65,6 +72,17 @@ func apiURL() string {
 	return fmt.Sprintf("http://%s%s", net.JoinHostPort(apiHost, strconv.Itoa(int(apiPort))), "/api")
 }
 
+// newAPIClient creates a client of the API, which passes the API token of the configuration
+func newAPIClient() (*api.ClientWithResponses, error) {
+	return api.NewClientWithResponses(apiURL(), api.WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+		if apiToken != "" {
+			req.Header.Set("Authorization", "Bearer "+apiToken)
+		}
+
+		return nil
+	}))
+}
+
 func initConfigPreRun(cmd *cobra.Command, args []string) error {
 	return initConfig()
 }
@@ -89,6 +107,8 @@ func initConfig() error {
 
 	log.Configure(&cfg.Log)
 
+	apiToken = cfg.Tenancy.APIToken
+
 	if len(cfg.Ports.HTTP) != 0 {
 		split := strings.Split(cfg.Ports.HTTP[0], ":")
 
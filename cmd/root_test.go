@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"os"
@@ -138,6 +139,46 @@ var _ = Describe("root command", func() {
 		})
 	})
 
+	Describe("newAPIClient function", func() {
+		AfterEach(func() {
+			apiToken = ""
+		})
+
+		It("should pass the API token", func() {
+			var authorization string
+
+			ts := testHTTPAPIServer(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+			})
+			defer ts.Close()
+
+			apiToken = "admin-secret"
+
+			client, err := newAPIClient()
+			Expect(err).Should(Succeed())
+
+			_, err = client.BlockingStatusWithResponse(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(authorization).Should(Equal("Bearer admin-secret"))
+		})
+
+		It("should not pass an empty API token", func() {
+			authorization := "unset"
+
+			ts := testHTTPAPIServer(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+			})
+			defer ts.Close()
+
+			client, err := newAPIClient()
+			Expect(err).Should(Succeed())
+
+			_, err = client.BlockingStatusWithResponse(context.Background())
+			Expect(err).Should(Succeed())
+			Expect(authorization).Should(BeEmpty())
+		})
+	})
+
 	Describe("printOkOrError function", func() {
 		It("should return nil for OK status", func() {
 			resp := mockResponse{
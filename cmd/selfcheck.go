@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultSelfCheckTimeout = 5 * time.Second
+
+	selfCheckOutputText = "text"
+	selfCheckOutputJSON = "json"
+)
+
+// selfCheck is a single named step of the self-check
+type selfCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// selfCheckResult is the result of a single check in the JSON report
+type selfCheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// selfCheckReport is the machine-readable report, printed with `--output json`
+type selfCheckReport struct {
+	Checks []selfCheckResult `json:"checks"`
+	Total  int               `json:"total"`
+	Failed int               `json:"failed"`
+}
+
+// NewSelfCheckCommand creates new command instance
+func NewSelfCheckCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:               "test",
+		Args:              cobra.NoArgs,
+		Short:             "performs a self-check against a running blocky instance",
+		Long:              "Resolves canary domains via all configured listeners and upstreams, verifies blocking and Redis",
+		RunE:              runSelfCheck,
+		PersistentPreRunE: initConfigPreRun,
+	}
+
+	c.Flags().StringSliceP("domain", "d", []string{"example.com"}, "canary domain(s), which must be resolved")
+	c.Flags().StringSlice("blocked", []string{}, "domain(s), which must be blocked")
+	c.Flags().StringP("bindip", "b", defaultIPAddress, "blocky host binding ip address")
+	c.Flags().Duration("timeout", defaultSelfCheckTimeout, "timeout for each check")
+	c.Flags().StringP("output", "o", selfCheckOutputText, "output format (text, json)")
+
+	return c
+}
+
+func runSelfCheck(cmd *cobra.Command, _ []string) error {
+	domains, _ := cmd.Flags().GetStringSlice("domain")
+	blocked, _ := cmd.Flags().GetStringSlice("blocked")
+	bindIP, _ := cmd.Flags().GetString("bindip")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	output, _ := cmd.Flags().GetString("output")
+
+	if output != selfCheckOutputText && output != selfCheckOutputJSON {
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	cfg, err := config.LoadConfig(configPath, true)
+	if err != nil {
+		return fmt.Errorf("unable to load configuration file '%s': %w", configPath, err)
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return fmt.Errorf("can't create client: %w", err)
+	}
+
+	checks := createSelfChecks(cfg, client, bindIP, domains, blocked)
+
+	report := selfCheckReport{Total: len(checks)}
+
+	for _, check := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := check.run(ctx)
+
+		cancel()
+
+		result := selfCheckResult{Name: check.name, OK: err == nil}
+
+		if err != nil {
+			report.Failed++
+			result.Error = err.Error()
+		}
+
+		report.Checks = append(report.Checks, result)
+
+		if output == selfCheckOutputText {
+			if err != nil {
+				log.Log().Errorf("[FAIL] %s: %s", check.name, err)
+			} else {
+				log.Log().Infof("[ OK ] %s", check.name)
+			}
+		}
+	}
+
+	if output == selfCheckOutputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("can't write report: %w", err)
+		}
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", report.Failed, report.Total)
+	}
+
+	if output == selfCheckOutputText {
+		log.Log().Infof("all %d checks passed", report.Total)
+	}
+
+	return nil
+}
+
+func createSelfChecks(
+	cfg *config.Config, client api.ClientWithResponsesInterface, bindIP string, domains, blocked []string,
+) []selfCheck {
+	var checks []selfCheck
+
+	for _, domain := range domains {
+		checks = append(checks, createListenerChecks(cfg, bindIP, domain)...)
+		checks = append(checks, createUpstreamChecks(cfg, domain)...)
+	}
+
+	checks = append(checks, selfCheck{"blocking is enabled", func(ctx context.Context) error {
+		return checkBlockingEnabled(ctx, client)
+	}})
+
+	for _, domain := range blocked {
+		checks = append(checks, selfCheck{fmt.Sprintf("blocking of '%s'", domain), func(ctx context.Context) error {
+			return checkBlocked(ctx, client, domain)
+		}})
+	}
+
+	if cfg.Redis.IsEnabled() {
+		address := cfg.Redis.Address
+		if len(cfg.Redis.ClusterAddresses) > 0 {
+			address = strings.Join(cfg.Redis.ClusterAddresses, ", ")
+		}
+
+		checks = append(checks, selfCheck{"redis connection " + address, func(ctx context.Context) error {
+			return checkRedis(ctx, &cfg.Redis)
+		}})
+	}
+
+	return checks
+}
+
+// createListenerChecks creates a check for each DNS, DoT and DoH listener of the running instance
+func createListenerChecks(cfg *config.Config, bindIP, domain string) []selfCheck {
+	// #nosec G402 // the listener is often using a self-signed certificate
+	insecureTLS := &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+
+	var checks []selfCheck
+
+	for _, address := range cfg.Ports.DNS {
+		addr := listenerAddress(address, bindIP)
+
+		checks = append(checks,
+			selfCheck{fmt.Sprintf("resolve '%s' via UDP %s", domain, addr), func(ctx context.Context) error {
+				return checkDNSExchange(ctx, &dns.Client{Net: "udp"}, addr, domain)
+			}},
+			selfCheck{fmt.Sprintf("resolve '%s' via TCP %s", domain, addr), func(ctx context.Context) error {
+				return checkDNSExchange(ctx, &dns.Client{Net: "tcp"}, addr, domain)
+			}},
+		)
+	}
+
+	for _, address := range cfg.Ports.TLS {
+		addr := listenerAddress(address, bindIP)
+
+		checks = append(checks,
+			selfCheck{fmt.Sprintf("resolve '%s' via DoT %s", domain, addr), func(ctx context.Context) error {
+				return checkDNSExchange(ctx, &dns.Client{Net: "tcp-tls", TLSConfig: insecureTLS}, addr, domain)
+			}})
+	}
+
+	for _, address := range cfg.Ports.HTTPS {
+		url := fmt.Sprintf("https://%s%s", listenerAddress(address, bindIP), cfg.Ports.DOHPath)
+
+		checks = append(checks,
+			selfCheck{fmt.Sprintf("resolve '%s' via DoH %s", domain, url), func(ctx context.Context) error {
+				return checkDoH(ctx, newDoHClient(insecureTLS), url, domain)
+			}})
+	}
+
+	return checks
+}
+
+// createUpstreamChecks creates a check for each configured upstream resolver. The upstreams are queried directly,
+// the blocky cache can't hide an unreachable upstream.
+func createUpstreamChecks(cfg *config.Config, domain string) []selfCheck {
+	groups := make([]string, 0, len(cfg.Upstreams.Groups))
+	for group := range cfg.Upstreams.Groups {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	var checks []selfCheck
+
+	for _, group := range groups {
+		for _, upstream := range cfg.Upstreams.Groups[group] {
+			if upstream.IsRegistry() {
+				// the registered instances are only known at runtime
+				continue
+			}
+
+			checks = append(checks, selfCheck{
+				fmt.Sprintf("resolve '%s' via upstream %s (group '%s')", domain, upstream, group),
+				func(ctx context.Context) error {
+					return checkUpstream(ctx, upstream, domain)
+				},
+			})
+		}
+	}
+
+	return checks
+}
+
+// listenerAddress converts a listen address (e.g. ":53") into an address which can be dialed
+func listenerAddress(address, bindIP string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = bindIP
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+func checkDNSExchange(ctx context.Context, client *dns.Client, addr, domain string) error {
+	resp, _, err := client.ExchangeContext(ctx, util.NewMsgWithQuestion(domain, dns.Type(dns.TypeA)), addr)
+	if err != nil {
+		return err
+	}
+
+	return checkResolved(resp)
+}
+
+func newDoHClient(tlsCfg *tls.Config) *http.Client {
+	transport := util.DefaultHTTPTransport()
+	transport.TLSClientConfig = tlsCfg
+
+	return &http.Client{Transport: transport}
+}
+
+func checkDoH(ctx context.Context, client *http.Client, url, domain string) error {
+	rawMsg, err := util.NewMsgWithQuestion(domain, dns.Type(dns.TypeA)).Pack()
+	if err != nil {
+		return fmt.Errorf("can't pack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(rawMsg))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/dns-message")
+
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http return code should be %d, but received %d", http.StatusOK, httpResp.StatusCode)
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("can't read response body: %w", err)
+	}
+
+	resp := new(dns.Msg)
+	if err := resp.Unpack(body); err != nil {
+		return fmt.Errorf("can't unpack message: %w", err)
+	}
+
+	return checkResolved(resp)
+}
+
+func checkResolved(resp *dns.Msg) error {
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("unexpected return code %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	if len(resp.Answer) == 0 {
+		return errors.New("empty answer")
+	}
+
+	return nil
+}
+
+func apiQuery(ctx context.Context, client api.ClientWithResponsesInterface, domain string) (*api.ApiQueryResult, error) {
+	resp, err := client.QueryWithResponse(ctx, nil, api.ApiQueryRequest{Query: domain, Type: "A"})
+	if err != nil {
+		return nil, fmt.Errorf("can't execute %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+		return nil, fmt.Errorf("response NOK, %s %s", resp.Status(), string(resp.Body))
+	}
+
+	return resp.JSON200, nil
+}
+
+func checkUpstream(ctx context.Context, upstream config.Upstream, domain string) error {
+	addr := net.JoinHostPort(upstream.Host, strconv.FormatUint(uint64(upstream.Port), 10))
+
+	serverName := upstream.CommonName
+	if serverName == "" {
+		serverName = upstream.Host
+	}
+
+	tlsCfg := &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+
+	switch upstream.Net {
+	case config.NetProtocolTcpTls:
+		return checkDNSExchange(ctx, &dns.Client{Net: "tcp-tls", TLSConfig: tlsCfg}, addr, domain)
+
+	case config.NetProtocolHttps:
+		return checkDoH(ctx, newDoHClient(tlsCfg), fmt.Sprintf("https://%s%s", addr, upstream.Path), domain)
+
+	default:
+		return checkDNSExchange(ctx, &dns.Client{Net: "udp"}, addr, domain)
+	}
+}
+
+func checkBlockingEnabled(ctx context.Context, client api.ClientWithResponsesInterface) error {
+	resp, err := client.BlockingStatusWithResponse(ctx)
+	if err != nil {
+		return fmt.Errorf("can't execute %w", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+		return fmt.Errorf("response NOK, %s %s", resp.Status(), string(resp.Body))
+	}
+
+	if !resp.JSON200.Enabled {
+		return errors.New("blocking is disabled")
+	}
+
+	return nil
+}
+
+func checkBlocked(ctx context.Context, client api.ClientWithResponsesInterface, domain string) error {
+	result, err := apiQuery(ctx, client, domain)
+	if err != nil {
+		return err
+	}
+
+	if result.ResponseType != model.ResponseTypeBLOCKED.String() {
+		return fmt.Errorf("domain is not blocked, response type %s (%s)", result.ResponseType, result.Reason)
+	}
+
+	return nil
+}
+
+func checkRedis(ctx context.Context, cfg *config.Redis) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the goroutines of the redis client
+
+	_, err := redis.New(ctx, cfg)
+
+	return err
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/api"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-check command", func() {
+	var (
+		loggerHook *test.Hook
+		tmpDir     *TmpFolder
+		cfgLines   []string
+		dnsAddr    string
+		// read by the handlers of the DNS servers
+		dnsRcode atomic.Int32
+
+		queryResponseType string
+		blockingEnabled   bool
+	)
+
+	answer := func(request *dns.Msg) *dns.Msg {
+		resp := new(dns.Msg)
+		rcode := int(dnsRcode.Load())
+		resp.SetRcode(request, rcode)
+
+		if rcode == dns.RcodeSuccess {
+			rr, err := dns.NewRR(request.Question[0].Name + " 300 IN A 1.2.3.4")
+			Expect(err).Should(Succeed())
+
+			resp.Answer = []dns.RR{rr}
+		}
+
+		return resp
+	}
+
+	startDNSServer := func(netw, addr string, tlsCfg *tls.Config) {
+		res := &dns.Server{
+			Addr:      addr,
+			Net:       netw,
+			TLSConfig: tlsCfg,
+			Handler: dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+				Expect(w.WriteMsg(answer(request))).Should(Succeed())
+			}),
+		}
+
+		started := make(chan struct{})
+		res.NotifyStartedFunc = func() { close(started) }
+
+		go func() {
+			defer GinkgoRecover()
+
+			_ = res.ListenAndServe()
+		}()
+
+		Eventually(started).Should(BeClosed())
+		DeferCleanup(res.Shutdown)
+	}
+
+	newCommand := func(args ...string) *cobra.Command {
+		c := NewSelfCheckCommand()
+		Expect(c.ParseFlags(args)).Should(Succeed())
+
+		return c
+	}
+
+	BeforeEach(func() {
+		loggerHook = test.NewGlobal()
+		log.Log().AddHook(loggerHook)
+		DeferCleanup(loggerHook.Reset)
+
+		dnsRcode.Store(dns.RcodeSuccess)
+		queryResponseType = "RESOLVED"
+		blockingEnabled = true
+
+		dnsAddr = GetHostPort("127.0.0.1", 4000)
+		startDNSServer("udp", dnsAddr, nil)
+		startDNSServer("tcp", dnsAddr, nil)
+
+		_, port, err := net.SplitHostPort(dnsAddr)
+		Expect(err).Should(Succeed())
+
+		// the mock server is used as blocky listener and upstream
+		cfgLines = []string{
+			"upstreams:",
+			"  groups:",
+			"    default:",
+			"      - " + dnsAddr,
+			"ports:",
+			"  dns: :" + port,
+		}
+
+		tmpDir = NewTmpFolder("SelfCheck")
+
+		ts := testHTTPAPIServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+
+			var response any
+
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/blocking/status"):
+				response = api.ApiBlockingStatus{Enabled: blockingEnabled}
+			case strings.HasSuffix(r.URL.Path, "/query"):
+				response = api.ApiQueryResult{
+					Reason:       "reason",
+					ResponseType: queryResponseType,
+					Response:     "A (1.2.3.4)",
+					ReturnCode:   "NOERROR",
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			Expect(json.NewEncoder(w).Encode(response)).Should(Succeed())
+		})
+		DeferCleanup(ts.Close)
+	})
+
+	JustBeforeEach(func() {
+		oldConfigPath := configPath
+		configPath = tmpDir.CreateStringFile("config.yml", cfgLines...).Path
+		DeferCleanup(func() { configPath = oldConfigPath })
+	})
+
+	When("all checks succeed", func() {
+		It("should report success", func() {
+			Expect(runSelfCheck(newCommand(), []string{})).Should(Succeed())
+			Expect(loggerHook.LastEntry().Message).Should(Equal("all 4 checks passed"))
+		})
+	})
+
+	When("DNS listener and upstream return an error", func() {
+		BeforeEach(func() {
+			dnsRcode.Store(dns.RcodeServerFailure)
+		})
+
+		It("should fail", func() {
+			err := runSelfCheck(newCommand(), []string{})
+			Expect(err).Should(MatchError("3 of 4 checks failed"))
+			Expect(loggerHook.AllEntries()[0].Message).Should(ContainSubstring("unexpected return code SERVFAIL"))
+		})
+	})
+
+	When("blocking is disabled", func() {
+		BeforeEach(func() {
+			blockingEnabled = false
+		})
+
+		It("should fail without passing blocked domains", func() {
+			Expect(runSelfCheck(newCommand(), []string{})).Should(MatchError("1 of 4 checks failed"))
+			Expect(loggerHook.AllEntries()).Should(ContainElement(
+				HaveField("Message", "[FAIL] blocking is enabled: blocking is disabled")))
+		})
+	})
+
+	When("blocked domain is checked", func() {
+		It("should succeed if domain is blocked", func() {
+			queryResponseType = "BLOCKED"
+
+			Expect(runSelfCheck(newCommand("--domain", "", "--blocked", "ads.com"), []string{})).Should(Succeed())
+		})
+
+		It("should fail if domain is not blocked", func() {
+			Expect(runSelfCheck(newCommand("--blocked", "ads.com"), []string{})).
+				Should(MatchError("1 of 5 checks failed"))
+		})
+	})
+
+	When("upstream is not reachable", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"  tls: 127.0.0.1:"+GetStringPort(4010),
+			)
+			cfgLines[3] = "      - tcp-tls:127.0.0.1:" + GetStringPort(4011)
+		})
+
+		It("should fail, although the blocky API returns a cached answer", func() {
+			queryResponseType = "CACHED"
+
+			err := runSelfCheck(newCommand("--timeout", "200ms"), []string{})
+			// DoT listener and DoT upstream are not running
+			Expect(err).Should(MatchError("2 of 5 checks failed"))
+			Expect(loggerHook.AllEntries()).Should(ContainElement(
+				HaveField("Message", ContainSubstring("[FAIL] resolve 'example.com' via upstream tcp-tls:127.0.0.1"))))
+		})
+	})
+
+	When("DoT listener is configured", func() {
+		BeforeEach(func() {
+			cert, err := util.TLSGenerateSelfSignedCert([]string{"blocky.local"})
+			Expect(err).Should(Succeed())
+
+			tlsAddr := GetHostPort("127.0.0.1", 4020)
+			startDNSServer("tcp-tls", tlsAddr, &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				MinVersion:   tls.VersionTLS12,
+			})
+
+			cfgLines = append(cfgLines, "  tls: "+tlsAddr)
+		})
+
+		It("should resolve via DoT", func() {
+			Expect(runSelfCheck(newCommand(), []string{})).Should(Succeed())
+			Expect(loggerHook.AllEntries()).Should(ContainElement(
+				HaveField("Message", ContainSubstring("[ OK ] resolve 'example.com' via DoT 127.0.0.1:"))))
+		})
+	})
+
+	When("DoH listener is configured", func() {
+		var dohStatus int
+
+		BeforeEach(func() {
+			dohStatus = http.StatusOK
+
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).Should(Equal("/dns-query"))
+				Expect(r.Header.Get("Content-Type")).Should(Equal("application/dns-message"))
+
+				if dohStatus != http.StatusOK {
+					w.WriteHeader(dohStatus)
+
+					return
+				}
+
+				body, err := io.ReadAll(r.Body)
+				Expect(err).Should(Succeed())
+
+				req := new(dns.Msg)
+				Expect(req.Unpack(body)).Should(Succeed())
+
+				raw, err := answer(req).Pack()
+				Expect(err).Should(Succeed())
+
+				_, err = w.Write(raw)
+				Expect(err).Should(Succeed())
+			}))
+			DeferCleanup(ts.Close)
+
+			cfgLines = append(cfgLines, "  https: "+ts.Listener.Addr().String())
+		})
+
+		It("should resolve via DoH", func() {
+			Expect(runSelfCheck(newCommand(), []string{})).Should(Succeed())
+			Expect(loggerHook.AllEntries()).Should(ContainElement(
+				HaveField("Message", ContainSubstring("[ OK ] resolve 'example.com' via DoH https://127.0.0.1:"))))
+		})
+
+		It("should fail on HTTP error", func() {
+			dohStatus = http.StatusInternalServerError
+
+			Expect(runSelfCheck(newCommand(), []string{})).Should(MatchError("1 of 5 checks failed"))
+			Expect(loggerHook.AllEntries()).Should(ContainElement(
+				HaveField("Message", ContainSubstring("but received 500"))))
+		})
+	})
+
+	When("Redis is configured", func() {
+		When("Redis is reachable", func() {
+			BeforeEach(func() {
+				redisServer, err := miniredis.Run()
+				Expect(err).Should(Succeed())
+				DeferCleanup(redisServer.Close)
+
+				cfgLines = append(cfgLines, "redis:", "  address: "+redisServer.Addr())
+			})
+
+			It("should check the connection", func() {
+				Expect(runSelfCheck(newCommand(), []string{})).Should(Succeed())
+				Expect(loggerHook.AllEntries()).Should(ContainElement(
+					HaveField("Message", ContainSubstring("[ OK ] redis connection 127.0.0.1:"))))
+			})
+		})
+
+		When("Redis is not reachable", func() {
+			BeforeEach(func() {
+				cfgLines = append(cfgLines,
+					"redis:",
+					"  address: 127.0.0.1:"+GetStringPort(4030),
+					"  connectionAttempts: 1",
+					"  connectionCooldown: 10ms",
+				)
+			})
+
+			It("should fail", func() {
+				Expect(runSelfCheck(newCommand(), []string{})).Should(MatchError("1 of 5 checks failed"))
+				Expect(loggerHook.AllEntries()).Should(ContainElement(
+					HaveField("Message", ContainSubstring("[FAIL] redis connection 127.0.0.1:"))))
+			})
+		})
+	})
+
+	When("JSON output is requested", func() {
+		BeforeEach(func() {
+			blockingEnabled = false
+		})
+
+		It("should print a machine-readable report", func() {
+			c := newCommand("--output", "json")
+
+			out := new(bytes.Buffer)
+			c.SetOut(out)
+
+			Expect(runSelfCheck(c, []string{})).Should(MatchError("1 of 4 checks failed"))
+
+			var report selfCheckReport
+			Expect(json.Unmarshal(out.Bytes(), &report)).Should(Succeed())
+
+			Expect(report.Total).Should(Equal(4))
+			Expect(report.Failed).Should(Equal(1))
+			Expect(report.Checks).Should(HaveLen(4))
+			Expect(report.Checks[0].OK).Should(BeTrue())
+			Expect(report.Checks[3]).Should(Equal(selfCheckResult{
+				Name:  "blocking is enabled",
+				OK:    false,
+				Error: "blocking is disabled",
+			}))
+		})
+
+		It("should reject unknown formats", func() {
+			Expect(runSelfCheck(newCommand("--output", "xml"), []string{})).
+				Should(MatchError("unknown output format 'xml'"))
+		})
+	})
+
+	When("config file is invalid", func() {
+		JustBeforeEach(func() {
+			configPath = tmpDir.JoinPath("missing.yml")
+		})
+
+		It("should fail", func() {
+			Expect(runSelfCheck(newCommand(), []string{})).
+				Should(MatchError(ContainSubstring("unable to load configuration file")))
+		})
+	})
+
+	Describe("listenerAddress", func() {
+		It("should replace unspecified host", func() {
+			Expect(listenerAddress(":53", "127.0.0.1")).Should(Equal("127.0.0.1:53"))
+			Expect(listenerAddress("0.0.0.0:53", "127.0.0.1")).Should(Equal("127.0.0.1:53"))
+			Expect(listenerAddress("[::]:53", "::1")).Should(Equal("[::1]:53"))
+			Expect(listenerAddress("192.168.1.1:53", "127.0.0.1")).Should(Equal("192.168.1.1:53"))
+		})
+	})
+})
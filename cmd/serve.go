@@ -11,6 +11,7 @@ import (
 	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/server"
+	"github.com/0xERR0R/blocky/service"
 	"github.com/0xERR0R/blocky/util"
 
 	"github.com/spf13/cobra"
@@ -35,6 +36,11 @@ func newServeCommand() *cobra.Command {
 }
 
 func startServer(_ *cobra.Command, _ []string) error {
+	return runServer(func() {})
+}
+
+// runServer starts the server and blocks until it is terminated, onReady is called as soon as all listeners are up
+func runServer(onReady func()) error {
 	printBanner()
 
 	cfg, err := config.LoadConfig(configPath, isConfigMandatory)
@@ -54,17 +60,52 @@ func startServer(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("can't start server: %w", err)
 	}
 
+	if cfg.RunAs.IsEnabled() {
+		// all listeners are bound, the privileges are dropped before the first query is served
+		if err := service.DropPrivileges(cfg.RunAs.User, cfg.RunAs.Group); err != nil {
+			return fmt.Errorf("can't drop privileges: %w", err)
+		}
+
+		log.Log().Infof("dropped privileges, running as user %d, group %d", os.Getuid(), os.Getgid())
+	}
+
+	srv.SetConfigLoader(func() (*config.Config, error) {
+		return config.LoadConfig(configPath, isConfigMandatory)
+	})
+
+	if cfg.ConfigWatch.IsEnabled() {
+		srv.WatchConfig(ctx, cfg.ConfigWatch, func() (string, error) {
+			return config.Fingerprint(configPath)
+		})
+	}
+
 	const errChanSize = 10
 	errChan := make(chan error, errChanSize)
 
 	srv.Start(ctx, errChan)
 
+	go func() {
+		select {
+		case <-srv.Ready():
+			onReady()
+			notifyServiceManager(ctx, "READY=1")
+
+			err := service.RunWatchdog(ctx, srv.CheckHealth, func(err error) {
+				log.Log().Warn("watchdog: ", err)
+			})
+			util.LogOnError(ctx, "can't start watchdog: ", err)
+
+		case <-ctx.Done():
+		}
+	}()
+
 	var terminationErr error
 
 	go func() {
 		select {
 		case <-signals:
 			log.Log().Infof("Terminating...")
+			notifyServiceManager(ctx, "STOPPING=1")
 			util.LogOnError(ctx, "can't stop server: ", srv.Stop(ctx))
 			done <- true
 
@@ -81,6 +122,15 @@ func startServer(_ *cobra.Command, _ []string) error {
 	return terminationErr
 }
 
+// notifyServiceManager sends the state to systemd, if blocky was started as notify service
+func notifyServiceManager(ctx context.Context, state string) {
+	if ok, err := service.Notify(state); ok {
+		log.Log().Debugf("service manager notified: %s", state)
+	} else {
+		util.LogOnError(ctx, "can't notify service manager: ", err)
+	}
+}
+
 func printBanner() {
 	log.Log().Info("_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/_/")
 	log.Log().Info("_/                                                              _/")
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -73,9 +75,13 @@ var _ = Describe("Serve command", func() {
 	When("Serve command is called with valid config", func() {
 		It("should fail if server start fails", func() {
 			By("start http server on port "+port, func() {
-				go func(p string) {
-					Expect(http.ListenAndServe(":"+p, nil)).Should(Succeed())
-				}(port)
+				listener, err := net.Listen("tcp", ":"+port)
+				Expect(err).Should(Succeed())
+				DeferCleanup(listener.Close)
+
+				go func() {
+					_ = http.Serve(listener, nil)
+				}()
 			})
 			By("initialize config with blocked port "+port, func() {
 				cfgFile := tmpDir.CreateStringFile("config.yaml",
@@ -108,6 +114,52 @@ var _ = Describe("Serve command", func() {
 		})
 	})
 
+	When("the privileges can't be dropped", func() {
+		It("should terminate with error", func() {
+			// the listeners of a failed start are not closed
+			port := helpertest.GetStringPort(basePort + 10)
+
+			By("initialize config with unknown user", func() {
+				cfgFile := tmpDir.CreateStringFile("config.yaml",
+					"upstreams:",
+					"  groups:",
+					"    default:",
+					"      - 1.1.1.1",
+					"ports:",
+					"  dns: "+port,
+					"runAs:",
+					"  user: blocky-unknown-user")
+
+				os.Setenv(configFileEnvVar, cfgFile.Path)
+				DeferCleanup(func() { os.Unsetenv(configFileEnvVar) })
+
+				Expect(initConfig()).Should(Succeed())
+			})
+
+			errChan := make(chan error)
+			By("start server", func() {
+				go func() {
+					// it is a blocking function, call async
+					errChan <- startServer(newServeCommand(), []string{})
+				}()
+			})
+
+			By("server should terminate with error", func() {
+				var startError error
+				Eventually(errChan, "10s").Should(Receive(&startError))
+				Expect(startError).Should(MatchError(ContainSubstring("can't drop privileges")))
+			})
+
+			By("no query should be answered with the privileges", func() {
+				client := &dns.Client{Timeout: 200 * time.Millisecond}
+
+				_, _, err := client.Exchange(util.NewMsgWithQuestion("example.com.", dns.Type(dns.TypeA)),
+					"127.0.0.1:"+port)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+
 	When("Serve command is called without config", func() {
 		It("should fail to start and report error", func() {
 			errChan := make(chan error)
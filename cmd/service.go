@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/service"
+
+	"github.com/spf13/cobra"
+)
+
+func newServiceCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:               "service",
+		Args:              cobra.NoArgs,
+		Short:             "Windows service management",
+		Hidden:            runtime.GOOS != "windows",
+		PersistentPreRunE: initConfigPreRun,
+	}
+
+	c.AddCommand(&cobra.Command{
+		Use:   "install",
+		Args:  cobra.NoArgs,
+		Short: "registers blocky as Windows service with the current config file",
+		RunE:  installService,
+	}, &cobra.Command{
+		Use:   "uninstall",
+		Args:  cobra.NoArgs,
+		Short: "removes the blocky Windows service",
+		RunE:  uninstallService,
+	}, &cobra.Command{
+		Use:          "run",
+		Args:         cobra.NoArgs,
+		Short:        "starts blocky DNS server, used by the Windows service manager",
+		RunE:         runService,
+		SilenceUsage: true,
+	})
+
+	return c
+}
+
+func installService(_ *cobra.Command, _ []string) error {
+	path, err := filepath.Abs(configPath)
+	if err != nil {
+		return fmt.Errorf("can't determine config path: %w", err)
+	}
+
+	if err := service.Install([]string{"--config", path}); err != nil {
+		return fmt.Errorf("can't install service: %w", err)
+	}
+
+	log.Log().Infof("service '%s' installed, config: %s", service.Name, path)
+
+	return nil
+}
+
+func uninstallService(_ *cobra.Command, _ []string) error {
+	if err := service.Uninstall(); err != nil {
+		return fmt.Errorf("can't uninstall service: %w", err)
+	}
+
+	log.Log().Infof("service '%s' uninstalled", service.Name)
+
+	return nil
+}
+
+func runService(_ *cobra.Command, _ []string) error {
+	return service.Run(runServer, func() {
+		signals <- syscall.SIGTERM
+	})
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"github.com/0xERR0R/blocky/service"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service command", func() {
+	It("should be hidden on non Windows systems", func() {
+		Expect(newServiceCommand().Hidden).Should(BeTrue())
+	})
+
+	It("should fail on non Windows systems", func() {
+		Expect(installService(nil, nil)).Should(MatchError(service.ErrNotSupported))
+		Expect(uninstallService(nil, nil)).Should(MatchError(service.ErrNotSupported))
+		Expect(runService(nil, nil)).Should(MatchError(service.ErrNotSupported))
+	})
+})
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// AnomalyDetection flags clients with query patterns typical for DNS tunneling or domain generation algorithms (DGA).
+// A threshold of 0 disables the check.
+type AnomalyDetection struct {
+	Enable           bool     `yaml:"enable"`
+	Window           Duration `default:"1m"   yaml:"window"`
+	MinQueries       uint     `default:"20"   yaml:"minQueries"`
+	MaxQueries       uint     `default:"1000" yaml:"maxQueries"`
+	MaxNXDomainRatio float64  `default:"0.5"  yaml:"maxNxdomainRatio"`
+	MaxEntropy       float64  `default:"4"    yaml:"maxEntropy"`
+	MaxLabelLength   uint     `default:"40"   yaml:"maxLabelLength"`
+	AlertInterval    Duration `default:"1h"   yaml:"alertInterval"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *AnomalyDetection) IsEnabled() bool {
+	return c.Enable && c.Window.IsAboveZero()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *AnomalyDetection) LogConfig(logger *logrus.Entry) {
+	logger.Infof("window: %s", c.Window)
+	logger.Infof("min queries: %d", c.MinQueries)
+	logger.Infof("max queries: %d", c.MaxQueries)
+	logger.Infof("max NXDOMAIN ratio: %g", c.MaxNXDomainRatio)
+	logger.Infof("max entropy: %g", c.MaxEntropy)
+	logger.Infof("max label length: %d", c.MaxLabelLength)
+	logger.Infof("alert interval: %s", c.AlertInterval)
+}
+
+func (c *AnomalyDetection) validate(logger *logrus.Entry) {
+	if c.MaxNXDomainRatio < 0 || c.MaxNXDomainRatio > 1 {
+		def := mustDefault[AnomalyDetection]().MaxNXDomainRatio
+
+		logger.Warnf("anomaly detection max NXDOMAIN ratio %g must be between 0 and 1, using %g instead",
+			c.MaxNXDomainRatio, def)
+		c.MaxNXDomainRatio = def
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AnomalyDetection", func() {
+	var c AnomalyDetection
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = AnomalyDetection{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled", func() {
+			c.Enable = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be disabled without window", func() {
+			c.Enable = true
+			c.Window = 0
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"window: 1 minute",
+				"min queries: 20",
+				"max queries: 1000",
+				"max NXDOMAIN ratio: 0.5",
+				"max entropy: 4",
+				"max label length: 40",
+				"alert interval: 1 hour",
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should keep valid ratios", func() {
+			c.MaxNXDomainRatio = 0.8
+
+			c.validate(logger)
+
+			Expect(c.MaxNXDomainRatio).Should(Equal(0.8))
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		It("should use the default for invalid ratios", func() {
+			c.MaxNXDomainRatio = 2
+
+			c.validate(logger)
+
+			Expect(c.MaxNXDomainRatio).Should(Equal(0.5))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("must be between 0 and 1")))
+		})
+	})
+})
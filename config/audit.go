@@ -0,0 +1,47 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Audit records hashes of the questions and answers per client to investigate reports of wrong answers
+type Audit struct {
+	Enable     bool `yaml:"enable"`
+	Entries    uint `default:"100"  yaml:"entries"`
+	MaxClients uint `default:"1000" yaml:"maxClients"`
+	// changes of the responses by blocky, independent of the hashes per client
+	Manipulations AuditManipulations `yaml:"manipulations"`
+}
+
+// AuditManipulations records the responses, which were altered by blocky (e.g. blocked or filtered), and the changes
+type AuditManipulations struct {
+	Enable  bool `yaml:"enable"`
+	Entries uint `default:"1000" yaml:"entries"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Audit) IsEnabled() bool {
+	return c.RecordsResponses() || c.Manipulations.IsEnabled()
+}
+
+// RecordsResponses returns true, if the hashes of the responses are recorded per client
+func (c *Audit) RecordsResponses() bool {
+	return c.Enable && c.Entries > 0 && c.MaxClients > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Audit) LogConfig(logger *logrus.Entry) {
+	if c.RecordsResponses() {
+		logger.Infof("entries per client: %d", c.Entries)
+		logger.Infof("max clients: %d", c.MaxClients)
+	}
+
+	if c.Manipulations.IsEnabled() {
+		logger.Infof("manipulations: %d entries", c.Manipulations.Entries)
+	}
+}
+
+// IsEnabled returns true, if the altered responses are recorded
+func (c *AuditManipulations) IsEnabled() bool {
+	return c.Enable && c.Entries > 0
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var c Audit
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Audit{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+
+		c.Enable = true
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true if enabled", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false by default", func() {
+			c.Enable = false
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false without entries", func() {
+			c.Entries = 0
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if only the manipulations are recorded", func() {
+			c.Enable = false
+			c.Manipulations.Enable = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+			Expect(c.RecordsResponses()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"entries per client: 100",
+				"max clients: 1000",
+			))
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("manipulations")))
+		})
+
+		It("should log the manipulations", func() {
+			c.Enable = false
+			c.Manipulations.Enable = true
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(Equal([]string{"manipulations: 1000 entries"}))
+		})
+	})
+})
@@ -11,9 +11,13 @@ type Blocking struct {
 	Denylists         map[string][]BytesSource `yaml:"denylists"`
 	Allowlists        map[string][]BytesSource `yaml:"allowlists"`
 	ClientGroupsBlock map[string][]string      `yaml:"clientGroupsBlock"`
+	Categories        map[string][]string      `yaml:"categories"`
+	GroupModes        map[string]BlockingMode  `yaml:"groupModes"`
 	BlockType         string                   `default:"ZEROIP"         yaml:"blockType"`
 	BlockTTL          Duration                 `default:"6h"             yaml:"blockTTL"`
+	ResponseCacheSize uint                     `default:"1000"           yaml:"responseCacheSize"`
 	Loading           SourceLoading            `yaml:"loading"`
+	StateFile         string                   `yaml:"stateFile"`
 
 	// Deprecated options
 	Deprecated struct {
@@ -62,10 +66,32 @@ func (c *Blocking) LogConfig(logger *logrus.Entry) {
 		logger.Infof("  %s = %v", key, val)
 	}
 
+	if len(c.Categories) != 0 {
+		logger.Info("categories:")
+
+		for group, categories := range c.Categories {
+			logger.Infof("  %s = %v", group, categories)
+		}
+	}
+
+	if len(c.GroupModes) != 0 {
+		logger.Info("groupModes:")
+
+		for group, mode := range c.GroupModes {
+			logger.Infof("  %s = %s", group, mode)
+		}
+	}
+
 	logger.Infof("blockType = %s", c.BlockType)
 
-	if c.BlockType != "NXDOMAIN" {
-		logger.Infof("blockTTL = %s", c.BlockTTL)
+	logger.Infof("blockTTL = %s", c.BlockTTL)
+
+	if c.ResponseCacheSize > 0 {
+		logger.Infof("responseCacheSize = %d", c.ResponseCacheSize)
+	}
+
+	if c.StateFile != "" {
+		logger.Infof("stateFile = %s", c.StateFile)
 	}
 
 	logger.Info("loading:")
@@ -82,6 +108,11 @@ func (c *Blocking) LogConfig(logger *logrus.Entry) {
 	})
 }
 
+// IsLogOnly returns true, if the blocking decisions of the list group are only recorded
+func (c *Blocking) IsLogOnly(group string) bool {
+	return c.GroupModes[group] == BlockingModeLogOnly
+}
+
 func (c *Blocking) logListGroups(logger *logrus.Entry, listGroups map[string][]BytesSource) {
 	for group, sources := range listGroups {
 		logger.Infof("%s:", group)
@@ -6,6 +6,7 @@ import (
 	"github.com/creasty/defaults"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("BlockingConfig", func() {
@@ -58,6 +59,47 @@ var _ = Describe("BlockingConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages[0]).Should(Equal("clientGroupsBlock:"))
 			Expect(hook.Messages).Should(ContainElement(Equal("blockType = ZEROIP")))
+			Expect(hook.Messages).ShouldNot(ContainElement(HavePrefix("stateFile")))
+		})
+
+		When("a state file is configured", func() {
+			It("should log the state file", func() {
+				cfg.StateFile = "/var/lib/blocky/state.json"
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(Equal("stateFile = /var/lib/blocky/state.json")))
+			})
+		})
+
+		When("categories are configured", func() {
+			It("should log the categories", func() {
+				cfg.Categories = map[string][]string{"kids": {"gambling", "social"}}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements("categories:", "  kids = [gambling social]"))
+			})
+		})
+
+		When("group modes are configured", func() {
+			It("should log the modes", func() {
+				cfg.GroupModes = map[string]BlockingMode{"gr1": BlockingModeLogOnly}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements("groupModes:", "  gr1 = log-only"))
+			})
+		})
+	})
+
+	Describe("IsLogOnly", func() {
+		It("should be true for groups in log-only mode", func() {
+			Expect(yaml.Unmarshal([]byte("groupModes:\n  gr1: log-only\n  gr2: block"), &cfg)).Should(Succeed())
+
+			Expect(cfg.IsLogOnly("gr1")).Should(BeTrue())
+			Expect(cfg.IsLogOnly("gr2")).Should(BeFalse())
+			Expect(cfg.IsLogOnly("gr3")).Should(BeFalse())
 		})
 	})
 
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// BloomFilter configuration of the bloom filters, which are checked before searching the entries of the list groups
+type BloomFilter struct {
+	Enable            bool    `default:"false" yaml:"enable"`
+	FalsePositiveRate float64 `default:"0.01"  yaml:"falsePositiveRate"`
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *BloomFilter) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`
+func (c *BloomFilter) LogConfig(logger *logrus.Entry) {
+	logger.Infof("false positive rate: %g", c.FalsePositiveRate)
+}
+
+func (c *BloomFilter) validate(logger *logrus.Entry) {
+	if c.FalsePositiveRate <= 0 || c.FalsePositiveRate >= 1 {
+		def := mustDefault[BloomFilter]().FalsePositiveRate
+
+		logger.Warnf("bloom filter false positive rate %g must be between 0 and 1, using %g instead", c.FalsePositiveRate, def)
+		c.FalsePositiveRate = def
+	}
+}
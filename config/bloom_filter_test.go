@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BloomFilter", func() {
+	var c BloomFilter
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = BloomFilter{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled", func() {
+			c.Enable = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("false positive rate: 0.01"))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should keep valid rates", func() {
+			c.FalsePositiveRate = 0.001
+
+			c.validate(logger)
+
+			Expect(c.FalsePositiveRate).Should(Equal(0.001))
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		It("should use the default for invalid rates", func() {
+			c.FalsePositiveRate = 1.5
+
+			c.validate(logger)
+
+			Expect(c.FalsePositiveRate).Should(Equal(0.01))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("must be between 0 and 1")))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes, which supports units like "256MB" or "1GiB" in yaml
+type ByteSize uint64
+
+//nolint:gochecknoglobals
+var byteSizeUnits = map[string]ByteSize{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+}
+
+// String implements `fmt.Stringer`
+func (s ByteSize) String() string {
+	for _, unit := range []string{"GiB", "MiB", "KiB"} {
+		factor := byteSizeUnits[strings.ToUpper(unit)]
+		if s >= factor && s%factor == 0 {
+			return fmt.Sprintf("%d %s", s/factor, unit)
+		}
+	}
+
+	return fmt.Sprintf("%d B", uint64(s))
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (s *ByteSize) UnmarshalText(data []byte) error {
+	input := strings.TrimSpace(string(data))
+
+	split := strings.IndexFunc(input, func(r rune) bool { return !unicode.IsDigit(r) })
+	if split < 0 {
+		split = len(input)
+	}
+
+	value, err := strconv.ParseUint(input[:split], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size '%s': %w", input, err)
+	}
+
+	factor, ok := byteSizeUnits[strings.ToUpper(strings.TrimSpace(input[split:]))]
+	if !ok {
+		return fmt.Errorf("invalid size '%s': unknown unit, use B, KB, MB, GB, KiB, MiB or GiB", input)
+	}
+
+	*s = ByteSize(value) * factor
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ByteSize", func() {
+	var s ByteSize
+
+	BeforeEach(func() {
+		s = 0
+	})
+
+	Describe("UnmarshalText", func() {
+		DescribeTable("should parse sizes with units",
+			func(input string, expected ByteSize) {
+				Expect(s.UnmarshalText([]byte(input))).Should(Succeed())
+				Expect(s).Should(Equal(expected))
+			},
+			Entry("without unit", "1024", ByteSize(1024)),
+			Entry("bytes", "512B", ByteSize(512)),
+			Entry("decimal unit", "256MB", ByteSize(256_000_000)),
+			Entry("binary unit", "1GiB", ByteSize(1<<30)),
+			Entry("lower case unit with space", "64 kib", ByteSize(64<<10)),
+		)
+
+		It("should fail for an unknown unit", func() {
+			Expect(s.UnmarshalText([]byte("10TB"))).Should(MatchError(ContainSubstring("unknown unit")))
+		})
+
+		It("should fail without number", func() {
+			Expect(s.UnmarshalText([]byte("MB"))).Should(HaveOccurred())
+		})
+	})
+
+	Describe("String", func() {
+		It("should use the largest binary unit", func() {
+			Expect(ByteSize(256 << 20).String()).Should(Equal("256 MiB"))
+			Expect(ByteSize(3 << 10).String()).Should(Equal("3 KiB"))
+		})
+
+		It("should fall back to bytes", func() {
+			Expect(ByteSize(1000).String()).Should(Equal("1000 B"))
+		})
+	})
+})
@@ -2,11 +2,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
-const maxTextSourceDisplayLen = 12
+const (
+	maxTextSourceDisplayLen = 12
+
+	inlineSourcePrefix = "inline:"
+)
 
 // var BytesSourceNone = BytesSource{}
 
@@ -62,6 +67,10 @@ func (s *BytesSource) UnmarshalText(data []byte) error {
 	source := string(data)
 
 	switch {
+	// Inline definition with explicit prefix
+	case strings.HasPrefix(source, inlineSourcePrefix):
+		*s = TextBytesSource(strings.TrimSpace(strings.TrimPrefix(source, inlineSourcePrefix)))
+
 	// Inline definition in YAML (with literal style Block Scalar)
 	case strings.ContainsAny(source, "\n"):
 		*s = BytesSource{Type: BytesSourceTypeText, From: source}
@@ -78,6 +87,31 @@ func (s *BytesSource) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+// Besides the string forms, a source can be a list of entries: `entries: [example.com, "*.tracker.net"]`
+func (s *BytesSource) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var source string
+	if err := unmarshal(&source); err == nil {
+		return s.UnmarshalText([]byte(source))
+	}
+
+	var input struct {
+		Entries []string `yaml:"entries"`
+	}
+
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	if len(input.Entries) == 0 {
+		return errors.New("source without entries")
+	}
+
+	*s = TextBytesSource(input.Entries...)
+
+	return nil
+}
+
 func newBytesSource(source string) BytesSource {
 	var res BytesSource
 
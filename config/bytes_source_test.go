@@ -0,0 +1,71 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("BytesSource", func() {
+	DescribeTable("UnmarshalText",
+		func(input string, expected BytesSource) {
+			var sut BytesSource
+
+			Expect(sut.UnmarshalText([]byte(input))).Should(Succeed())
+			Expect(sut).Should(Equal(expected))
+		},
+		Entry("http",
+			"https://example.com/list.txt",
+			BytesSource{Type: BytesSourceTypeHttp, From: "https://example.com/list.txt"}),
+		Entry("file",
+			"file:///etc/list.txt",
+			BytesSource{Type: BytesSourceTypeFile, From: "/etc/list.txt"}),
+		Entry("multi-line inline",
+			"example.com\n*.tracker.net\n",
+			BytesSource{Type: BytesSourceTypeText, From: "example.com\n*.tracker.net\n"}),
+		Entry("inline with prefix",
+			"inline:\n  example.com\n  *.tracker.net",
+			BytesSource{Type: BytesSourceTypeText, From: "example.com\n  *.tracker.net\n"}),
+		Entry("single line inline with prefix",
+			"inline:example.com",
+			BytesSource{Type: BytesSourceTypeText, From: "example.com\n"}),
+	)
+
+	Describe("UnmarshalYAML", func() {
+		var sources []BytesSource
+
+		It("should parse string sources", func() {
+			Expect(yaml.Unmarshal([]byte(`[https://example.com/list.txt, "inline:example.com"]`), &sources)).
+				Should(Succeed())
+
+			Expect(sources).Should(Equal([]BytesSource{
+				{Type: BytesSourceTypeHttp, From: "https://example.com/list.txt"},
+				TextBytesSource("example.com"),
+			}))
+		})
+
+		It("should parse entries", func() {
+			Expect(yaml.Unmarshal([]byte(`[{entries: [example.com, "*.tracker.net"]}]`), &sources)).Should(Succeed())
+
+			Expect(sources).Should(Equal([]BytesSource{TextBytesSource("example.com", "*.tracker.net")}))
+		})
+
+		It("should fail without entries", func() {
+			Expect(yaml.Unmarshal([]byte(`[{entries: []}]`), &sources)).
+				Should(MatchError("source without entries"))
+		})
+
+		It("should fail on invalid source", func() {
+			Expect(yaml.Unmarshal([]byte(`[[example.com]]`), &sources)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("String", func() {
+		It("should show the first line of inline sources", func() {
+			var sut BytesSource
+
+			Expect(sut.UnmarshalText([]byte("inline:\n  example.com\n  *.tracker.net"))).Should(Succeed())
+			Expect(sut.String()).Should(Equal("example.com"))
+		})
+	})
+})
@@ -8,15 +8,49 @@ import (
 
 // Caching configuration for domain caching
 type Caching struct {
-	MinCachingTime        Duration `yaml:"minTime"`
-	MaxCachingTime        Duration `yaml:"maxTime"`
-	CacheTimeNegative     Duration `default:"30m"                yaml:"cacheTimeNegative"`
-	MaxItemsCount         int      `yaml:"maxItemsCount"`
-	Prefetching           bool     `yaml:"prefetching"`
-	PrefetchExpires       Duration `default:"2h"                 yaml:"prefetchExpires"`
-	PrefetchThreshold     int      `default:"5"                  yaml:"prefetchThreshold"`
-	PrefetchMaxItemsCount int      `yaml:"prefetchMaxItemsCount"`
-	Exclude               []string `yaml:"exclude"`
+	MinCachingTime        Duration      `yaml:"minTime"`
+	MaxCachingTime        Duration      `yaml:"maxTime"`
+	CacheTimeNegative     Duration      `default:"30m"                yaml:"cacheTimeNegative"`
+	MaxItemsCount         int           `yaml:"maxItemsCount"`
+	MaxSize               ByteSize      `yaml:"maxSize"`
+	Prefetching           bool          `yaml:"prefetching"`
+	PrefetchExpires       Duration      `default:"2h"                 yaml:"prefetchExpires"`
+	PrefetchThreshold     int           `default:"5"                  yaml:"prefetchThreshold"`
+	PrefetchMaxItemsCount int           `yaml:"prefetchMaxItemsCount"`
+	PrefetchStateFile     string        `yaml:"prefetchStateFile"`
+	Exclude               []string      `yaml:"exclude"`
+	WarmupDomains         []BytesSource `yaml:"warmupDomains"`
+	Warmup                CacheWarmup   `yaml:"warmup"`
+	Refresh               CacheRefresh  `yaml:"refresh"`
+}
+
+// CacheWarmup configuration for resolving the warm-up domains after startup
+type CacheWarmup struct {
+	Concurrency uint     `default:"4"  yaml:"concurrency"`
+	Attempts    uint     `default:"3"  yaml:"attempts"`
+	Cooldown    Duration `default:"1s" yaml:"cooldown"`
+}
+
+// CacheRefresh configuration for serving stale entries while they are refreshed in the background
+type CacheRefresh struct {
+	// elapsed share of the TTL in percent, after which a cache hit starts a refresh, 0 disables the refresh
+	Threshold uint `yaml:"threshold"`
+	// maximum count of refreshes per minute
+	Budget uint `default:"100" yaml:"budget"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *CacheRefresh) IsEnabled() bool {
+	return c.Threshold > 0
+}
+
+func (c *CacheRefresh) validate(logger *logrus.Entry) {
+	const maxThreshold = 100
+
+	if c.Threshold >= maxThreshold {
+		logger.Warnf("caching.refresh.threshold %d must be below 100, disabling the refresh", c.Threshold)
+		c.Threshold = 0
+	}
 }
 
 // IsEnabled implements `config.Configurable`.
@@ -29,6 +63,11 @@ func (c *Caching) LogConfig(logger *logrus.Entry) {
 	logger.Infof("minTime = %s", c.MinCachingTime)
 	logger.Infof("maxTime = %s", c.MaxCachingTime)
 	logger.Infof("cacheTimeNegative = %s", c.CacheTimeNegative)
+
+	if c.MaxSize > 0 {
+		logger.Infof("maxSize = %s", c.MaxSize)
+	}
+
 	logger.Infof("exclude:")
 	for _, val := range c.Exclude {
 		logger.Infof("- %v", val)
@@ -39,9 +78,31 @@ func (c *Caching) LogConfig(logger *logrus.Entry) {
 		logger.Infof("  expires   = %s", c.PrefetchExpires)
 		logger.Infof("  threshold = %d", c.PrefetchThreshold)
 		logger.Infof("  maxItems  = %d", c.PrefetchMaxItemsCount)
+
+		if c.PrefetchStateFile != "" {
+			logger.Infof("  stateFile = %s", c.PrefetchStateFile)
+		}
 	} else {
 		logger.Debug("prefetching: disabled")
 	}
+
+	if c.Refresh.IsEnabled() {
+		logger.Info("refresh:")
+		logger.Infof("  threshold = %d%%", c.Refresh.Threshold)
+		logger.Infof("  budget    = %d/min", c.Refresh.Budget)
+	}
+
+	if len(c.WarmupDomains) != 0 {
+		logger.Info("warm-up:")
+		logger.Infof("  concurrency = %d", c.Warmup.Concurrency)
+		logger.Infof("  attempts    = %d", c.Warmup.Attempts)
+		logger.Infof("  cooldown    = %s", c.Warmup.Cooldown)
+		logger.Info("  domains:")
+
+		for _, source := range c.WarmupDomains {
+			logger.Infof("    - %s", source)
+		}
+	}
 }
 
 func (c *Caching) EnablePrefetch() {
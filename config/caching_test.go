@@ -70,6 +70,49 @@ var _ = Describe("CachingConfig", func() {
 				Expect(hook.Messages).Should(ContainElement(ContainSubstring("prefetching:")))
 			})
 		})
+		When("warm-up domains are configured", func() {
+			BeforeEach(func() {
+				cfg = Caching{
+					WarmupDomains: []BytesSource{TextBytesSource("example.com")},
+					Warmup:        CacheWarmup{Concurrency: 2, Attempts: 3},
+				}
+			})
+
+			It("should log the warm-up configuration", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					ContainSubstring("warm-up:"),
+					ContainSubstring("concurrency = 2"),
+					ContainSubstring("example.com"),
+				))
+			})
+		})
+		When("prefetch state file is configured", func() {
+			BeforeEach(func() {
+				cfg = Caching{
+					Prefetching:       true,
+					PrefetchStateFile: "/tmp/prefetch.json",
+				}
+			})
+
+			It("should log the state file", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("stateFile = /tmp/prefetch.json")))
+			})
+		})
+		When("a memory budget is configured", func() {
+			BeforeEach(func() {
+				cfg = Caching{MaxSize: 256 * 1024 * 1024}
+			})
+
+			It("should log the memory budget", func() {
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("maxSize = 256 MiB")))
+			})
+		})
 		When("has any settings", func() {
 			BeforeEach(func() {
 				cfg = Caching{}
@@ -95,6 +138,37 @@ var _ = Describe("CachingConfig", func() {
 		})
 	})
 
+	Describe("Refresh", func() {
+		It("should be disabled by default", func() {
+			cfg := Caching{}
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			Expect(cfg.Refresh.IsEnabled()).Should(BeFalse())
+			Expect(cfg.Refresh.Budget).Should(BeEquivalentTo(100))
+		})
+
+		It("should log the refresh configuration", func() {
+			cfg.Refresh = CacheRefresh{Threshold: 80, Budget: 50}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"refresh:",
+				"  threshold = 80%",
+				"  budget    = 50/min",
+			))
+		})
+
+		It("should disable a threshold of 100% or more", func() {
+			cfg.Refresh = CacheRefresh{Threshold: 100}
+
+			cfg.Refresh.validate(logger)
+
+			Expect(cfg.Refresh.IsEnabled()).Should(BeFalse())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("caching.refresh.threshold")))
+		})
+	})
+
 	Describe("EnablePrefetch", func() {
 		When("prefetching is enabled", func() {
 			BeforeEach(func() {
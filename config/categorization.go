@@ -0,0 +1,94 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/sirupsen/logrus"
+)
+
+// CategorizationDomainPlaceholder is replaced with the queried domain in the URL of the categorization API
+const CategorizationDomainPlaceholder = "{domain}"
+
+// Categorization assigns categories like "social" or "gambling" to the queried domains
+type Categorization struct {
+	// lists with the domains of each category, e.g. a category database with a list per category
+	Sources map[string][]BytesSource `yaml:"sources"`
+	Loading SourceLoading            `yaml:"loading"`
+	API     CategorizationAPI        `yaml:"api"`
+}
+
+// CategorizationAPI is a remote service, which returns the categories of a domain
+type CategorizationAPI struct {
+	// URL with the placeholder `{domain}`, the response is a JSON array of category names
+	URL string `yaml:"url"`
+	// sent as bearer token
+	Token     string   `yaml:"token"`
+	Timeout   Duration `default:"2s"    yaml:"timeout"`
+	CacheTTL  Duration `default:"24h"   yaml:"cacheTTL"`
+	CacheSize uint     `default:"10000" yaml:"cacheSize"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Categorization) IsEnabled() bool {
+	return len(c.Sources) != 0 || c.API.IsEnabled()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Categorization) LogConfig(logger *logrus.Entry) {
+	if len(c.Sources) != 0 {
+		logger.Info("loading:")
+		log.WithIndent(logger, "  ", c.Loading.LogConfig)
+
+		logger.Info("sources:")
+
+		for _, category := range slices.Sorted(maps.Keys(c.Sources)) {
+			logger.Infof("  %s:", category)
+
+			for _, source := range c.Sources[category] {
+				logger.Infof("    - %s", source)
+			}
+		}
+	}
+
+	if c.API.IsEnabled() {
+		logger.Info("api:")
+		log.WithIndent(logger, "  ", c.API.LogConfig)
+	}
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *CategorizationAPI) IsEnabled() bool {
+	return c.URL != ""
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *CategorizationAPI) LogConfig(logger *logrus.Entry) {
+	logger.Infof("url: %s", c.URL)
+
+	if c.Token != "" {
+		logger.Info("token: ", secretObfuscator)
+	}
+
+	logger.Infof("timeout: %s", c.Timeout)
+	logger.Infof("cache TTL: %s", c.CacheTTL)
+	logger.Infof("cache size: %d", c.CacheSize)
+}
+
+func (c *Categorization) validate(logger *logrus.Entry) {
+	for category := range c.Sources {
+		if category != strings.ToLower(category) {
+			logger.Warnf("categorization.sources.%s: category names must be lowercase, it's ignored", category)
+
+			delete(c.Sources, category)
+		}
+	}
+
+	if c.API.IsEnabled() && !strings.Contains(c.API.URL, CategorizationDomainPlaceholder) {
+		logger.Warnf("categorization.api.url has no placeholder %s, the API is disabled", CategorizationDomainPlaceholder)
+
+		c.API.URL = ""
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Categorization", func() {
+	var c Categorization
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = mustDefault[Categorization]()
+		c.Sources = map[string][]BytesSource{
+			"social":   NewBytesSources("/etc/blocky/categories/social"),
+			"gambling": NewBytesSources("https://example.com/gambling.txt"),
+		}
+		c.API.URL = "https://categories.example.com/v1/{domain}"
+		c.API.Token = "secret"
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			c := mustDefault[Categorization]()
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with sources", func() {
+			c.API = CategorizationAPI{}
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with the API", func() {
+			c.Sources = nil
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("defaults", func() {
+		It("should cache the API answers", func() {
+			Expect(c.API.Timeout).Should(Equal(Duration(2 * time.Second)))
+			Expect(c.API.CacheTTL).Should(Equal(Duration(24 * time.Hour)))
+			Expect(c.API.CacheSize).Should(BeEquivalentTo(10000))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the categories in alphabetical order", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"sources:",
+				"  gambling:",
+				"    - https://example.com/gambling.txt",
+				"  social:",
+				"    - file:///etc/blocky/categories/social",
+				"api:",
+			))
+		})
+
+		It("should obfuscate the token of the API", func() {
+			c.API.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"url: https://categories.example.com/v1/{domain}",
+				"token: ********",
+			))
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("secret")))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept a valid configuration", func() {
+			c.validate(logger)
+
+			Expect(c.Sources).Should(HaveLen(2))
+			Expect(c.API.IsEnabled()).Should(BeTrue())
+			Expect(hook.Messages).Should(BeEmpty())
+		})
+
+		It("should ignore categories with uppercase names", func() {
+			c.Sources["Video"] = NewBytesSources("/etc/blocky/categories/video")
+
+			c.validate(logger)
+
+			Expect(c.Sources).ShouldNot(HaveKey("Video"))
+			Expect(hook.Messages).Should(ContainElement(
+				"categorization.sources.Video: category names must be lowercase, it's ignored"))
+		})
+
+		It("should disable the API without placeholder", func() {
+			c.API.URL = "https://categories.example.com/v1"
+
+			c.validate(logger)
+
+			Expect(c.API.IsEnabled()).Should(BeFalse())
+			Expect(hook.Messages).Should(ContainElement(
+				"categorization.api.url has no placeholder {domain}, the API is disabled"))
+		})
+	})
+})
@@ -2,7 +2,9 @@ package config
 
 import (
 	"net"
+	"strings"
 
+	"github.com/0xERR0R/blocky/log"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,11 +13,17 @@ type ClientLookup struct {
 	ClientnameIPMapping map[string][]net.IP `yaml:"clients"`
 	Upstream            Upstream            `yaml:"upstream"`
 	SingleNameOrder     []uint              `yaml:"singleNameOrder"`
+	Tags                map[string][]string `yaml:"tags"`
+	Tailscale           TailscaleLookup     `yaml:"tailscale"`
+	WireGuard           WireGuardLookup     `yaml:"wireGuard"`
+	// interval between two reads of the prefixes of the interfaces in the tags (`if:<interface>`)
+	PrefixRefreshPeriod Duration `default:"1m" yaml:"prefixRefreshPeriod"`
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *ClientLookup) IsEnabled() bool {
-	return !c.Upstream.IsDefault() || len(c.ClientnameIPMapping) != 0
+	return !c.Upstream.IsDefault() || len(c.ClientnameIPMapping) != 0 || len(c.Tags) != 0 ||
+		c.Tailscale.IsEnabled() || c.WireGuard.IsEnabled()
 }
 
 // LogConfig implements `config.Configurable`.
@@ -33,4 +41,64 @@ func (c *ClientLookup) LogConfig(logger *logrus.Entry) {
 			logger.Infof("  %s = %s", k, v)
 		}
 	}
+
+	if c.Tailscale.IsEnabled() {
+		logger.Info("tailscale:")
+		log.WithIndent(logger, "  ", c.Tailscale.LogConfig)
+	}
+
+	if c.WireGuard.IsEnabled() {
+		logger.Info("wireGuard:")
+		log.WithIndent(logger, "  ", c.WireGuard.LogConfig)
+	}
+
+	if len(c.Tags) > 0 {
+		logger.Infof("client tags:")
+
+		for tag, clients := range c.Tags {
+			logger.Infof("  %s = %s", tag, strings.Join(clients, ", "))
+		}
+	}
+
+	if len(c.InterfacePrefixes()) != 0 {
+		logger.Infof("prefix refresh period = %s", c.PrefixRefreshPeriod)
+	}
+}
+
+func (c *ClientLookup) validate(logger *logrus.Entry) {
+	for tag, clients := range c.Tags {
+		for _, client := range clients {
+			if _, _, err := ParseInterfacePrefix(client); err != nil {
+				logger.Warnf("clientLookup.tags.%s: %v, the client is ignored", tag, err)
+			}
+		}
+	}
+
+	if len(c.InterfacePrefixes()) != 0 && !c.PrefixRefreshPeriod.IsAboveZero() {
+		defaults := mustDefault[ClientLookup]()
+
+		logger.Warnf("clientLookup.prefixRefreshPeriod <= 0, setting to %s", defaults.PrefixRefreshPeriod)
+		c.PrefixRefreshPeriod = defaults.PrefixRefreshPeriod
+	}
+
+	if c.Tailscale.IsEnabled() {
+		defaults := mustDefault[TailscaleLookup]()
+
+		if c.Tailscale.Socket == "" {
+			logger.Warnf("clientLookup.tailscale.socket is empty, setting to %s", defaults.Socket)
+			c.Tailscale.Socket = defaults.Socket
+		}
+
+		if !c.Tailscale.RefreshPeriod.IsAboveZero() {
+			logger.Warnf("clientLookup.tailscale.refreshPeriod <= 0, setting to %s", defaults.RefreshPeriod)
+			c.Tailscale.RefreshPeriod = defaults.RefreshPeriod
+		}
+	}
+
+	if c.WireGuard.IsEnabled() && !c.WireGuard.RefreshPeriod.IsAboveZero() {
+		defaults := mustDefault[WireGuardLookup]()
+
+		logger.Warnf("clientLookup.wireGuard.refreshPeriod <= 0, setting to %s", defaults.RefreshPeriod)
+		c.WireGuard.RefreshPeriod = defaults.RefreshPeriod
+	}
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"net"
+	"time"
 
 	"github.com/creasty/defaults"
 	. "github.com/onsi/ginkgo/v2"
@@ -20,6 +21,9 @@ var _ = Describe("ClientLookupConfig", func() {
 			ClientnameIPMapping: map[string][]net.IP{
 				"client8": {net.ParseIP("1.2.3.5")},
 			},
+			Tags: map[string][]string{
+				"iot": {"cam-*", "192.168.20.0/24"},
+			},
 		}
 	})
 
@@ -42,6 +46,22 @@ var _ = Describe("ClientLookupConfig", func() {
 					Expect(cfg.IsEnabled()).Should(BeTrue())
 				})
 
+				By("tags", func() {
+					cfg := ClientLookup{
+						Tags: map[string][]string{"iot": {"192.168.20.0/24"}},
+					}
+
+					Expect(cfg.IsEnabled()).Should(BeTrue())
+				})
+
+				By("tunnel", func() {
+					cfg := ClientLookup{Tailscale: TailscaleLookup{Enable: true}}
+					Expect(cfg.IsEnabled()).Should(BeTrue())
+
+					cfg = ClientLookup{WireGuard: WireGuardLookup{Configs: []string{"/etc/wireguard/wg0.conf"}}}
+					Expect(cfg.IsEnabled()).Should(BeTrue())
+				})
+
 				By("mapping", func() {
 					cfg := ClientLookup{
 						ClientnameIPMapping: map[string][]net.IP{
@@ -61,6 +81,47 @@ var _ = Describe("ClientLookupConfig", func() {
 
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("client IP mapping:")))
+			Expect(hook.Messages).Should(ContainElements("client tags:", "  iot = cam-*, 192.168.20.0/24"))
+		})
+
+		It("should log the tunnel lookups", func() {
+			cfg.Tailscale = mustDefault[TailscaleLookup]()
+			cfg.Tailscale.Enable = true
+			cfg.WireGuard = WireGuardLookup{Configs: []string{"/etc/wireguard/wg0.conf"}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"tailscale:",
+				"socket: /var/run/tailscale/tailscaled.sock",
+				"wireGuard:",
+				"configs: /etc/wireguard/wg0.conf",
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should fix invalid tunnel settings", func() {
+			cfg.Tailscale = TailscaleLookup{Enable: true}
+			cfg.WireGuard = WireGuardLookup{Configs: []string{"/etc/wireguard/wg0.conf"}}
+
+			cfg.validate(logger)
+
+			Expect(cfg.Tailscale).Should(Equal(TailscaleLookup{
+				Enable: true, Socket: "/var/run/tailscale/tailscaled.sock", RefreshPeriod: Duration(time.Minute),
+			}))
+			Expect(cfg.WireGuard.RefreshPeriod).Should(Equal(Duration(time.Minute)))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("clientLookup.tailscale.socket is empty"),
+				ContainSubstring("clientLookup.tailscale.refreshPeriod <= 0"),
+				ContainSubstring("clientLookup.wireGuard.refreshPeriod <= 0"),
+			))
+		})
+
+		It("should ignore disabled tunnel lookups", func() {
+			cfg.validate(logger)
+
+			Expect(hook.Messages).Should(BeEmpty())
 		})
 	})
 })
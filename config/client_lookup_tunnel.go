@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TailscaleLookup configures the lookup of the device names and ACL tags via the local API of tailscaled
+type TailscaleLookup struct {
+	Enable        bool     `default:"false"                             yaml:"enable"`
+	Socket        string   `default:"/var/run/tailscale/tailscaled.sock" yaml:"socket"`
+	RefreshPeriod Duration `default:"1m"                                yaml:"refreshPeriod"`
+}
+
+// WireGuardLookup configures the lookup of the peer names in WireGuard configurations. The name of a peer is the
+// comment `# Name = <name>` in its section or the comment line above the section.
+type WireGuardLookup struct {
+	Configs       []string `yaml:"configs"`
+	RefreshPeriod Duration `default:"1m" yaml:"refreshPeriod"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *TailscaleLookup) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *TailscaleLookup) LogConfig(logger *logrus.Entry) {
+	logger.Infof("socket: %s", c.Socket)
+	logger.Infof("refresh period: %s", c.RefreshPeriod)
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *WireGuardLookup) IsEnabled() bool {
+	return len(c.Configs) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *WireGuardLookup) LogConfig(logger *logrus.Entry) {
+	logger.Infof("configs: %s", strings.Join(c.Configs, ", "))
+	logger.Infof("refresh period: %s", c.RefreshPeriod)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// ClientStats aggregates query statistics per client independent of the query log
+type ClientStats struct {
+	Enable        bool   `yaml:"enable"`
+	RetentionDays uint   `default:"30" yaml:"retentionDays"`
+	TopDomains    uint   `default:"10" yaml:"topDomains"`
+	StoreFile     string `yaml:"storeFile"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ClientStats) IsEnabled() bool {
+	return c.Enable && c.RetentionDays > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ClientStats) LogConfig(logger *logrus.Entry) {
+	logger.Infof("retention days: %d", c.RetentionDays)
+	logger.Infof("top domains: %d", c.TopDomains)
+
+	if c.StoreFile != "" {
+		logger.Infof("store file: %s", c.StoreFile)
+	} else {
+		logger.Info("store file: none, statistics are lost on restart")
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientStats", func() {
+	var c ClientStats
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = ClientStats{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+
+		c.Enable = true
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true if enabled", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false by default", func() {
+			c.Enable = false
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false without retention", func() {
+			c.RetentionDays = 0
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.StoreFile = "/var/lib/blocky/client-stats.json"
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"retention days: 30",
+				"top domains: 10",
+				"store file: /var/lib/blocky/client-stats.json",
+			))
+		})
+
+		It("should log missing store file", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("store file: none, statistics are lost on restart"))
+		})
+	})
+})
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
+	"github.com/0xERR0R/blocky/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,7 +13,31 @@ import (
 type ConditionalUpstream struct {
 	RewriterConfig `yaml:",inline"`
 
-	Mapping ConditionalUpstreamMapping `yaml:"mapping"`
+	Mapping      ConditionalUpstreamMapping         `yaml:"mapping"`
+	ClientSubnet map[string]ConditionalClientSubnet `yaml:"clientSubnet"`
+	ReverseZones ConditionalReverseZones            `yaml:"reverseZones"`
+	Transfer     ConditionalTransfer                `yaml:"transfer"`
+	// Notify accepts NOTIFY messages of the upstreams to refresh a transferred zone or to flush the cached answers of
+	// a forwarded zone
+	Notify bool `yaml:"notify" default:"true"`
+}
+
+// ConditionalTransfer zones of the mapping, which are transferred from their conditional upstreams via AXFR/IXFR
+// and answered locally
+type ConditionalTransfer struct {
+	Zones []string `yaml:"zones"`
+	// Refresh interval of the zones, 0 uses the refresh interval of the SOA record
+	Refresh Duration `yaml:"refresh"`
+}
+
+// ConditionalReverseZones internal subnets per conditional domain, the reverse zones of the subnets are
+// forwarded to the upstreams of the domain
+type ConditionalReverseZones map[string][]*net.IPNet
+
+// ConditionalClientSubnet subnet masks of the client IP, which is sent as EDNS Client Subnet to the conditional upstream
+type ConditionalClientSubnet struct {
+	IPv4Mask ECSv4Mask `yaml:"ipv4Mask"`
+	IPv6Mask ECSv6Mask `yaml:"ipv6Mask"`
 }
 
 // ConditionalUpstreamMapping mapping for conditional configuration
@@ -29,6 +55,100 @@ func (c *ConditionalUpstream) LogConfig(logger *logrus.Entry) {
 	for key, val := range c.Mapping.Upstreams {
 		logger.Infof("%s = %v", key, val)
 	}
+
+	if len(c.ReverseZones) != 0 {
+		logger.Info("reverse zones:")
+
+		for key, val := range c.ReverseZones {
+			logger.Infof("  %s = %v", key, val)
+		}
+	}
+
+	if len(c.Transfer.Zones) != 0 {
+		logger.Info("transfer:")
+		logger.Infof("  zones = %v", c.Transfer.Zones)
+
+		if c.Transfer.Refresh.IsAboveZero() {
+			logger.Infof("  refresh = %s", c.Transfer.Refresh)
+		} else {
+			logger.Info("  refresh = SOA refresh")
+		}
+	}
+
+	logger.Infof("notify = %t", c.Notify)
+
+	if len(c.ClientSubnet) == 0 {
+		return
+	}
+
+	logger.Info("client subnet:")
+
+	for key, val := range c.ClientSubnet {
+		logger.Infof("  %s = IPv4 netmask %d, IPv6 netmask %d", key, val.IPv4Mask, val.IPv6Mask)
+	}
+}
+
+// ReverseZoneDomains returns the conditional domain for each reverse zone derived from `reverseZones`.
+// Reverse zones which are explicitly part of the mapping are skipped.
+func (c *ConditionalUpstream) ReverseZoneDomains() map[string]string {
+	result := make(map[string]string)
+
+	for domain, subnets := range c.ReverseZones {
+		for _, subnet := range subnets {
+			for _, zone := range util.ReverseZones(subnet) {
+				if _, ok := c.Mapping.Upstreams[zone]; ok {
+					continue
+				}
+
+				result[zone] = domain
+			}
+		}
+	}
+
+	return result
+}
+
+func (c *ConditionalUpstream) validate(logger *logrus.Entry) {
+	for domain := range c.ReverseZones {
+		if _, ok := c.Mapping.Upstreams[domain]; !ok {
+			logger.Warnf("conditional.reverseZones: domain '%s' is not in the mapping, ignoring it", domain)
+			delete(c.ReverseZones, domain)
+		}
+	}
+
+	zones := make([]string, 0, len(c.Transfer.Zones))
+
+	for _, zone := range c.Transfer.Zones {
+		if _, ok := c.Mapping.Upstreams[zone]; !ok {
+			logger.Warnf("conditional.transfer: zone '%s' is not in the mapping, ignoring it", zone)
+
+			continue
+		}
+
+		if len(c.TransferPrimaries(zone)) == 0 {
+			logger.Warnf("conditional.transfer: zone '%s' has no plain DNS upstream, ignoring it", zone)
+
+			continue
+		}
+
+		zones = append(zones, zone)
+	}
+
+	c.Transfer.Zones = zones
+}
+
+// TransferPrimaries returns the upstreams of the conditional domain, which can transfer the zone: the plain DNS
+// upstreams with a static address
+func (c *ConditionalUpstream) TransferPrimaries(zone string) []Upstream {
+	var result []Upstream
+
+	for _, upstream := range c.Mapping.Upstreams[zone] {
+		if upstream.Net == NetProtocolTcpUdp && upstream.Registry == "" && upstream.Provider == "" {
+			result = append(result, upstream)
+		}
+	}
+
+	return result
 }
 
 // UnmarshalYAML implements `yaml.Unmarshaler`.
@@ -59,3 +179,32 @@ func (c *ConditionalUpstreamMapping) UnmarshalYAML(unmarshal func(interface{}) e
 
 	return nil
 }
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+func (c *ConditionalReverseZones) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var input map[string]string
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	result := make(ConditionalReverseZones, len(input))
+
+	for k, v := range input {
+		var subnets []*net.IPNet
+
+		for _, part := range strings.Split(v, ",") {
+			_, subnet, err := net.ParseCIDR(strings.TrimSpace(part))
+			if err != nil {
+				return fmt.Errorf("can't convert subnet '%s': %w", strings.TrimSpace(part), err)
+			}
+
+			subnets = append(subnets, subnet)
+		}
+
+		result[k] = subnets
+	}
+
+	*c = result
+
+	return nil
+}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"errors"
+	"net"
 
 	"github.com/creasty/defaults"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("ConditionalUpstreamConfig", func() {
@@ -57,6 +59,30 @@ var _ = Describe("ConditionalUpstreamConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("fritz.box = ")))
 		})
+
+		It("should log client subnet", func() {
+			cfg.ClientSubnet = map[string]ConditionalClientSubnet{"fritz.box": {IPv4Mask: 32, IPv6Mask: 56}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"client subnet:",
+				"  fritz.box = IPv4 netmask 32, IPv6 netmask 56",
+			))
+		})
+
+		It("should log transferred zones", func() {
+			cfg.Transfer = ConditionalTransfer{Zones: []string{"fritz.box"}}
+			cfg.Notify = true
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"  zones = [fritz.box]",
+				"  refresh = SOA refresh",
+				"notify = true",
+			))
+		})
 	})
 
 	Describe("UnmarshalYAML", func() {
@@ -84,4 +110,68 @@ var _ = Describe("ConditionalUpstreamConfig", func() {
 			Expect(err).Should(MatchError("some err"))
 		})
 	})
+
+	Describe("ConditionalReverseZones UnmarshalYAML", func() {
+		It("should parse the subnets", func() {
+			var c ConditionalReverseZones
+
+			Expect(yaml.Unmarshal([]byte(`fritz.box: "192.168.178.0/24, fd00::/8"`), &c)).Should(Succeed())
+
+			Expect(c).Should(HaveKey("fritz.box"))
+			Expect(c["fritz.box"]).Should(HaveLen(2))
+			Expect(c["fritz.box"][0].String()).Should(Equal("192.168.178.0/24"))
+			Expect(c["fritz.box"][1].String()).Should(Equal("fd00::/8"))
+		})
+
+		It("should fail on invalid subnets", func() {
+			var c ConditionalReverseZones
+
+			Expect(yaml.Unmarshal([]byte(`fritz.box: 192.168.178.1`), &c)).
+				Should(MatchError(ContainSubstring("can't convert subnet '192.168.178.1'")))
+		})
+	})
+
+	Describe("ReverseZoneDomains", func() {
+		BeforeEach(func() {
+			_, subnet, err := net.ParseCIDR("192.168.178.0/23")
+			Expect(err).Should(Succeed())
+
+			cfg.Mapping.Upstreams["179.168.192.in-addr.arpa"] = []Upstream{{Net: NetProtocolTcpUdp, Host: "ptrTest"}}
+			cfg.ReverseZones = ConditionalReverseZones{"fritz.box": {subnet}}
+		})
+
+		It("should derive the reverse zones not in the mapping", func() {
+			Expect(cfg.ReverseZoneDomains()).Should(Equal(map[string]string{
+				"178.168.192.in-addr.arpa": "fritz.box",
+			}))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should drop domains not in the mapping", func() {
+			_, subnet, err := net.ParseCIDR("10.0.0.0/8")
+			Expect(err).Should(Succeed())
+
+			cfg.ReverseZones = ConditionalReverseZones{"fritz.box": {subnet}, "unknown.box": {subnet}}
+
+			cfg.validate(logger)
+
+			Expect(cfg.ReverseZones).Should(HaveKey("fritz.box"))
+			Expect(cfg.ReverseZones).ShouldNot(HaveKey("unknown.box"))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("'unknown.box' is not in the mapping")))
+		})
+
+		It("should drop transferred zones without plain DNS upstream", func() {
+			cfg.Mapping.Upstreams["dot.box"] = []Upstream{{Net: NetProtocolTcpTls, Host: "dotTest"}}
+			cfg.Transfer.Zones = []string{"fritz.box", "unknown.box", "dot.box"}
+
+			cfg.validate(logger)
+
+			Expect(cfg.Transfer.Zones).Should(Equal([]string{"fritz.box"}))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("zone 'unknown.box' is not in the mapping"),
+				ContainSubstring("zone 'dot.box' has no plain DNS upstream"),
+			))
+		})
+	})
 })
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net"
 	"os"
 	"path/filepath"
@@ -48,6 +49,20 @@ type Configurable interface {
 // )
 type NetProtocol uint16
 
+// DoHMethod HTTP method of the requests to a DoH upstream ENUM(
+// auto // GET for paths with the URI template `{?dns}`, POST otherwise
+// get // cache-friendly GET with the base64url encoded query as parameter `dns`
+// post // POST with the query as body
+// )
+type DoHMethod uint8
+
+// ClientIdentifierValue identifier of the querying client, which is sent to an upstream ENUM(
+// name // the first client name
+// ip // the client IP address
+// mac // the MAC address of the client from the neighbor table of the host, only on Linux
+// )
+type ClientIdentifierValue uint8
+
 // IPVersion represents IP protocol version(s). ENUM(
 // dual // IPv4 and IPv6
 // v4   // IPv4 only
@@ -119,6 +134,38 @@ type QueryLogType int16
 // )
 type InitStrategy uint16
 
+// EDEBlockedCode EDE info code of blocked responses ENUM(
+// blocked = 15 // the domain is on a blocklist of the operator
+// filtered = 17 // the domain is filtered as requested by the client
+// )
+type EDEBlockedCode uint16
+
+// TyposquattingAction what happens with queries for domains similar to the protected domains ENUM(
+// flag // log the query but resolve it
+// block // answer the query with NXDOMAIN
+// )
+type TyposquattingAction uint8
+
+// NewDomainAction what happens with queries for newly observed domains ENUM(
+// flag // log the query but resolve it
+// block // answer the query with NXDOMAIN
+// )
+type NewDomainAction uint8
+
+// ListFailureMode behavior of a list group, which can't be loaded ENUM(
+// keep // keep the previously loaded entries, if any
+// allowAll // the group blocks no queries
+// denyAll // the group blocks all queries
+// )
+type ListFailureMode uint8
+
+// FilterAAAAMode for which queries the AAAA answers are removed ENUM(
+// off // the AAAA answers are kept
+// onV4 // only for queries received over IPv4, like filter-aaaa-on-v4 of BIND
+// always // for all queries
+// )
+type FilterAAAAMode uint8
+
 func (s InitStrategy) Do(ctx context.Context, init func(context.Context) error, logErr func(error)) error {
 	init = recoverToError(init, func(panicVal any) error {
 		return fmt.Errorf("panic during initialization: %v", panicVal)
@@ -148,13 +195,68 @@ func (s InitStrategy) Do(ctx context.Context, init func(context.Context) error,
 }
 
 // QueryLogField data field to be logged
-// ENUM(clientIP,clientName,responseReason,responseAnswer,question,duration)
+// ENUM(
+// clientIP,clientName,responseReason,responseAnswer,question,duration,upstream,ecs,durationBucket,tenant,categories
+// )
 type QueryLogField string
 
 // UpstreamStrategy data field to be logged
 // ENUM(parallel_best,strict,random)
 type UpstreamStrategy uint8
 
+// NotificationType format of a notification ENUM(
+// webhook // generic JSON webhook
+// slack // Slack incoming webhook
+// telegram // Telegram bot API
+// gotify // Gotify server
+// )
+type NotificationType uint8
+
+// BlockingMode how the blocking decisions of a list group are applied ENUM(
+// block // matching queries are blocked
+// log-only // matching queries are recorded as WOULD_BLOCK, but resolved
+// )
+type BlockingMode uint8
+
+// SelfCheckExpectation expected result of a self-check probe ENUM(
+// resolved // the domain resolves to an answer
+// blocked // the domain is blocked
+// )
+type SelfCheckExpectation uint8
+
+// MetricsClientLabel value of the client label in the metrics ENUM(
+// name // the client names
+// hash // a hash of the client names
+// )
+type MetricsClientLabel uint8
+
+// EDNSOption option of the EDNS OPT record ENUM(
+// ecs // client subnet
+// cookie // DNS cookie
+// padding // padding
+// nsid // name server identifier
+// ede // extended DNS error
+// )
+type EDNSOption uint16
+
+// Code returns the EDNS option code
+func (o EDNSOption) Code() uint16 {
+	switch o {
+	case EDNSOptionEcs:
+		return dns.EDNS0SUBNET
+	case EDNSOptionCookie:
+		return dns.EDNS0COOKIE
+	case EDNSOptionPadding:
+		return dns.EDNS0PADDING
+	case EDNSOptionNsid:
+		return dns.EDNS0NSID
+	case EDNSOptionEde:
+		return dns.EDNS0EDE
+	}
+
+	return 0
+}
+
 //nolint:gochecknoglobals
 var netDefaultPort = map[NetProtocol]uint16{
 	NetProtocolTcpUdp: udpPort,
@@ -255,6 +357,9 @@ type Config struct {
 	QueryLog         QueryLog            `yaml:"queryLog"`
 	Prometheus       Metrics             `yaml:"prometheus"`
 	Redis            Redis               `yaml:"redis"`
+	PeerSync         PeerSync            `yaml:"peerSync"`
+	Sync             Sync                `yaml:"sync"`
+	XDP              XDP                 `yaml:"xdp"`
 	Log              log.Config          `yaml:"log"`
 	Ports            Ports               `yaml:"ports"`
 	MinTLSServeVer   TLSVersion          `default:"1.2"            yaml:"minTlsServeVersion"`
@@ -267,6 +372,34 @@ type Config struct {
 	EDE              EDE                 `yaml:"ede"`
 	ECS              ECS                 `yaml:"ecs"`
 	SUDN             SUDN                `yaml:"specialUseDomains"`
+	Plugins          []Plugin            `yaml:"plugins"`
+	Scripting        Scripting           `yaml:"scripting"`
+	Notifications    Notifications       `yaml:"notifications"`
+	ZoneVisibility   ZoneVisibility      `yaml:"zoneVisibility"`
+	LeakPrevention   LeakPrevention      `yaml:"leakPrevention"`
+	IPv6Only         IPv6Only            `yaml:"ipv6Only"`
+	Coalescing       Coalescing          `yaml:"coalescing"`
+	QueryProcessing  QueryProcessing     `yaml:"queryProcessing"`
+	Responses        Responses           `yaml:"responses"`
+	Cookies          Cookies             `yaml:"cookies"`
+	RunAs            RunAs               `yaml:"runAs"`
+	ResponseMangling ResponseMangling    `yaml:"responseMangling"`
+	IPRewrite        IPRewrite           `yaml:"ipRewrite"`
+	Typosquatting    Typosquatting       `yaml:"typosquatting"`
+	NewDomains       NewDomains          `yaml:"newDomains"`
+	ClientStats      ClientStats         `yaml:"clientStats"`
+	Audit            Audit               `yaml:"audit"`
+	AnomalyDetection AnomalyDetection    `yaml:"anomalyDetection"`
+	Bailiwick        Bailiwick           `yaml:"bailiwick"`
+	Profiling        Profiling           `yaml:"profiling"`
+	SelfCheck        SelfCheck           `yaml:"selfCheck"`
+	Statistics       Statistics          `yaml:"statistics"`
+	Tenancy          Tenancy             `yaml:"tenancy"`
+	Quotas           Quotas              `yaml:"quotas"`
+	Categorization   Categorization      `yaml:"categorization"`
+	Mirroring        Mirroring           `yaml:"mirroring"`
+	ConfigWatch      ConfigWatch         `yaml:"configWatch"`
+	ReverseProxy     ReverseProxy        `yaml:"reverseProxy"`
 
 	// Deprecated options
 	Deprecated struct {
@@ -292,6 +425,8 @@ type Ports struct {
 	HTTPS   ListenConfig `yaml:"https"`
 	TLS     ListenConfig `yaml:"tls"`
 	DOHPath string       `default:"/dns-query" yaml:"dohPath"`
+	// additional DoH endpoints, which tag their queries
+	DoHEndpoints []DoHEndpoint `yaml:"dohEndpoints"`
 }
 
 func (c *Ports) LogConfig(logger *logrus.Entry) {
@@ -299,6 +434,10 @@ func (c *Ports) LogConfig(logger *logrus.Entry) {
 	logger.Infof("TLS   = %s", c.TLS)
 	logger.Infof("HTTP  = %s", c.HTTP)
 	logger.Infof("HTTPS = %s", c.HTTPS)
+
+	for _, endpoint := range c.DoHEndpoints {
+		logger.Infof("DoH endpoint = %s", endpoint)
+	}
 }
 
 // split in two types to avoid infinite recursion. See `BootstrapDNS.UnmarshalYAML`.
@@ -327,8 +466,8 @@ type (
 )
 
 type (
-	FQDNOnly = toEnable
-	EDE      = toEnable
+	Coalescing = toEnable
+	Bailiwick  = toEnable
 )
 
 type toEnable struct {
@@ -356,10 +495,49 @@ func (c *Init) LogConfig(logger *logrus.Entry) {
 type SourceLoading struct {
 	Init `yaml:",inline"`
 
-	Concurrency        uint       `default:"4"      yaml:"concurrency"`
-	MaxErrorsPerSource int        `default:"5"      yaml:"maxErrorsPerSource"`
-	RefreshPeriod      Duration   `default:"4h"     yaml:"refreshPeriod"`
-	Downloads          Downloader `yaml:"downloads"`
+	Concurrency           uint                       `default:"4"      yaml:"concurrency"`
+	MaxErrorsPerSource    int                        `default:"5"      yaml:"maxErrorsPerSource"`
+	MaxSourceSize         ByteSize                   `default:"100MiB" yaml:"maxSourceSize"`
+	MaxEntriesPerSource   uint                       `yaml:"maxEntriesPerSource"`
+	RefreshPeriod         Duration                   `default:"4h"     yaml:"refreshPeriod"`
+	RefreshSchedule       CronSchedule               `yaml:"refreshSchedule"`
+	GroupRefreshSchedules map[string]CronSchedule    `yaml:"groupRefreshSchedules"`
+	RefreshJitter         Duration                   `yaml:"refreshJitter"`
+	GroupPolicies         map[string]ListGroupPolicy `yaml:"groupPolicies"`
+	CacheDir              string                     `yaml:"cacheDir"`
+	BloomFilter           BloomFilter                `yaml:"bloomFilter"`
+	Downloads             Downloader                 `yaml:"downloads"`
+}
+
+// ListGroupPolicy defines when the loading of a list group fails and what happens then
+type ListGroupPolicy struct {
+	// minimum share of the group's sources, which must be loaded successfully
+	RequireAtLeast Percent `yaml:"requireAtLeast"`
+	// maximum age of the previously loaded entries, which are kept if the group can't be loaded
+	MaxStaleAge Duration        `yaml:"maxStaleAge"`
+	OnFailure   ListFailureMode `yaml:"onFailure"`
+}
+
+// Percent is a percentage between 0 and 100
+type Percent uint8
+
+// String implements `fmt.Stringer`
+func (p Percent) String() string {
+	return fmt.Sprintf("%d%%", p)
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (p *Percent) UnmarshalText(data []byte) error {
+	input := string(data)
+
+	value, err := strconv.ParseUint(strings.TrimSuffix(input, "%"), 10, 8)
+	if err != nil || value > 100 { //nolint:mnd
+		return fmt.Errorf("invalid percentage '%s'", input)
+	}
+
+	*p = Percent(value)
+
+	return nil
 }
 
 func (c *SourceLoading) LogConfig(logger *logrus.Entry) {
@@ -367,12 +545,45 @@ func (c *SourceLoading) LogConfig(logger *logrus.Entry) {
 	logger.Infof("concurrency = %d", c.Concurrency)
 	logger.Debugf("maxErrorsPerSource = %d", c.MaxErrorsPerSource)
 
-	if c.RefreshPeriod.IsAboveZero() {
+	if c.MaxSourceSize > 0 {
+		logger.Debugf("maxSourceSize = %s", c.MaxSourceSize)
+	}
+
+	if c.MaxEntriesPerSource > 0 {
+		logger.Debugf("maxEntriesPerSource = %d", c.MaxEntriesPerSource)
+	}
+
+	switch {
+	case c.RefreshSchedule.IsEnabled():
+		logger.Infof("refresh = '%s'", c.RefreshSchedule)
+	case c.RefreshPeriod.IsAboveZero():
 		logger.Infof("refresh = every %s", c.RefreshPeriod)
-	} else {
+	default:
 		logger.Debug("refresh = disabled")
 	}
 
+	for group, schedule := range c.GroupRefreshSchedules {
+		logger.Infof("refresh of %s = '%s'", group, schedule)
+	}
+
+	if c.RefreshJitter.IsAboveZero() {
+		logger.Infof("refresh jitter = %s", c.RefreshJitter)
+	}
+
+	for group, policy := range c.GroupPolicies {
+		logger.Infof("policy of %s = require at least %s, max stale age %s, on failure %s",
+			group, policy.RequireAtLeast, policy.MaxStaleAge, policy.OnFailure)
+	}
+
+	if c.CacheDir != "" {
+		logger.Infof("cache dir = %s", c.CacheDir)
+	}
+
+	if c.BloomFilter.IsEnabled() {
+		logger.Info("bloom filter:")
+		log.WithIndent(logger, "  ", c.BloomFilter.LogConfig)
+	}
+
 	logger.Info("downloads:")
 	log.WithIndent(logger, "  ", c.Downloads.LogConfig)
 }
@@ -385,37 +596,70 @@ func (c *SourceLoading) StartPeriodicRefresh(
 		return err
 	}
 
-	if c.RefreshPeriod > 0 {
-		go c.periodically(ctx, refresh, logErr)
-	}
+	c.RefreshPeriodically(ctx, c.RefreshSchedule, refresh, logErr)
 
 	return nil
 }
 
+// RefreshPeriodically calls refresh according to the schedule or, if the schedule is empty, each refresh period.
+// Each refresh is delayed by a random duration up to the refresh jitter.
+func (c *SourceLoading) RefreshPeriodically(
+	ctx context.Context, schedule CronSchedule, refresh func(context.Context) error, logErr func(error),
+) {
+	var next func(time.Time) time.Time
+
+	switch {
+	case schedule.IsEnabled():
+		next = schedule.Next
+	case c.RefreshPeriod > 0:
+		next = func(t time.Time) time.Time {
+			return t.Add(c.RefreshPeriod.ToDuration())
+		}
+	default:
+		return
+	}
+
+	go c.periodically(ctx, next, refresh, logErr)
+}
+
 func (c *SourceLoading) periodically(
-	ctx context.Context, refresh func(context.Context) error, logErr func(error),
+	ctx context.Context, next func(time.Time) time.Time, refresh func(context.Context) error, logErr func(error),
 ) {
 	refresh = recoverToError(refresh, func(panicVal any) error {
 		return fmt.Errorf("panic during refresh: %v", panicVal)
 	})
 
-	ticker := time.NewTicker(c.RefreshPeriod.ToDuration())
-	defer ticker.Stop()
-
 	for {
+		nextRefresh := next(time.Now())
+		if nextRefresh.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(nextRefresh) + c.jitter())
+
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			err := refresh(ctx)
 			if err != nil {
 				logErr(err)
 			}
 
 		case <-ctx.Done():
+			timer.Stop()
+
 			return
 		}
 	}
 }
 
+func (c *SourceLoading) jitter() time.Duration {
+	if !c.RefreshJitter.IsAboveZero() {
+		return 0
+	}
+
+	return rand.N(c.RefreshJitter.ToDuration()) //nolint:gosec // no crypto
+}
+
 func recoverToError(do func(context.Context) error, onPanic func(any) error) func(context.Context) error {
 	return func(ctx context.Context) (rerr error) {
 		defer func() {
@@ -435,12 +679,27 @@ type Downloader struct {
 	WriteTimeout      Duration `default:"20s"   yaml:"writeTimeout"`
 	Attempts          uint     `default:"3"     yaml:"attempts"`
 	Cooldown          Duration `default:"500ms" yaml:"cooldown"`
+	MaxConcurrent     uint     `yaml:"maxConcurrent"`
+	HostInterval      Duration `yaml:"hostInterval"`
+	BandwidthLimit    uint     `yaml:"bandwidthLimit"`
 }
 
 func (c *Downloader) LogConfig(logger *logrus.Entry) {
 	logger.Infof("timeout = %s", c.Timeout)
 	logger.Infof("attempts = %d", c.Attempts)
 	logger.Debugf("cooldown = %s", c.Cooldown)
+
+	if c.MaxConcurrent > 0 {
+		logger.Infof("maxConcurrent = %d", c.MaxConcurrent)
+	}
+
+	if c.HostInterval.IsAboveZero() {
+		logger.Infof("hostInterval = %s", c.HostInterval)
+	}
+
+	if c.BandwidthLimit > 0 {
+		logger.Infof("bandwidthLimit = %d KiB/s", c.BandwidthLimit)
+	}
 }
 
 func WithDefaults[T any]() (T, error) {
@@ -492,25 +751,14 @@ func loadConfig(logger *logrus.Entry, path string, mandatory bool) (rCfg *Config
 		return nil, fmt.Errorf("can't read config file(s): %w", err)
 	}
 
-	var (
-		data       []byte
-		prettyPath string
-	)
-
+	prettyPath := path
 	if fs.IsDir() {
 		prettyPath = filepath.Join(path, "*")
+	}
 
-		data, err = readFromDir(path, data)
-		if err != nil {
-			return nil, fmt.Errorf("can't read config files: %w", err)
-		}
-	} else {
-		prettyPath = path
-
-		data, err = os.ReadFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("can't read config file: %w", err)
-		}
+	data, err := readConfigData(path)
+	if err != nil {
+		return nil, err
 	}
 
 	cfg.CustomDNS.Zone.configPath = prettyPath
@@ -523,6 +771,21 @@ func loadConfig(logger *logrus.Entry, path string, mandatory bool) (rCfg *Config
 	return &cfg, nil
 }
 
+// ParseConfig creates new config from YAML data, e.g. to test a configuration without applying it. The deprecated
+// options are migrated and the validation warnings are logged to the logger.
+func ParseConfig(logger *logrus.Entry, data []byte) (*Config, error) {
+	cfg, err := WithDefaults[Config]()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalConfig(logger, data, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
 func readFromDir(path string, data []byte) ([]byte, error) {
 	err := filepath.WalkDir(path, func(filePath string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -626,6 +889,26 @@ func (cfg *Config) migrate(logger *logrus.Entry) bool {
 func (cfg *Config) validate(logger *logrus.Entry) {
 	cfg.MinTLSServeVer.validate(logger)
 	cfg.Upstreams.validate(logger)
+	cfg.PeerSync.validate(logger)
+	validatePlugins(logger, cfg.Plugins)
+	cfg.Notifications.validate(logger)
+	cfg.Blocking.Loading.BloomFilter.validate(logger)
+	cfg.AnomalyDetection.validate(logger)
+	cfg.Redis.validate(logger)
+	cfg.Sync.validate(logger, cfg)
+	cfg.XDP.validate(logger, cfg)
+	cfg.RunAs.validate(logger, cfg)
+	cfg.Responses.validate(logger)
+	cfg.Conditional.validate(logger)
+	cfg.Caching.Refresh.validate(logger)
+	cfg.IPv6Only.validate(logger, cfg)
+	cfg.ConfigWatch.validate(logger)
+	cfg.ClientLookup.validate(logger)
+	cfg.Ports.validate(logger)
+	cfg.ReverseProxy.validate(logger)
+	cfg.Tenancy.validate(logger)
+	cfg.Quotas.validate(logger)
+	cfg.Categorization.validate(logger)
 }
 
 // ConvertPort converts string representation into a valid port (0 - 65535)
@@ -11,6 +11,540 @@ import (
 	"strings"
 )
 
+const (
+	// BlockingModeBlock is a BlockingMode of type Block.
+	// matching queries are blocked
+	BlockingModeBlock BlockingMode = iota
+	// BlockingModeLogOnly is a BlockingMode of type Log-Only.
+	// matching queries are recorded as WOULD_BLOCK, but resolved
+	BlockingModeLogOnly
+)
+
+var ErrInvalidBlockingMode = fmt.Errorf("not a valid BlockingMode, try [%s]", strings.Join(_BlockingModeNames, ", "))
+
+const _BlockingModeName = "blocklog-only"
+
+var _BlockingModeNames = []string{
+	_BlockingModeName[0:5],
+	_BlockingModeName[5:13],
+}
+
+// BlockingModeNames returns a list of possible string values of BlockingMode.
+func BlockingModeNames() []string {
+	tmp := make([]string, len(_BlockingModeNames))
+	copy(tmp, _BlockingModeNames)
+	return tmp
+}
+
+// BlockingModeValues returns a list of the values for BlockingMode
+func BlockingModeValues() []BlockingMode {
+	return []BlockingMode{
+		BlockingModeBlock,
+		BlockingModeLogOnly,
+	}
+}
+
+var _BlockingModeMap = map[BlockingMode]string{
+	BlockingModeBlock:   _BlockingModeName[0:5],
+	BlockingModeLogOnly: _BlockingModeName[5:13],
+}
+
+// String implements the Stringer interface.
+func (x BlockingMode) String() string {
+	if str, ok := _BlockingModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("BlockingMode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x BlockingMode) IsValid() bool {
+	_, ok := _BlockingModeMap[x]
+	return ok
+}
+
+var _BlockingModeValue = map[string]BlockingMode{
+	_BlockingModeName[0:5]:  BlockingModeBlock,
+	_BlockingModeName[5:13]: BlockingModeLogOnly,
+}
+
+// ParseBlockingMode attempts to convert a string to a BlockingMode.
+func ParseBlockingMode(name string) (BlockingMode, error) {
+	if x, ok := _BlockingModeValue[name]; ok {
+		return x, nil
+	}
+	return BlockingMode(0), fmt.Errorf("%s is %w", name, ErrInvalidBlockingMode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x BlockingMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *BlockingMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseBlockingMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// ClientIdentifierValueName is a ClientIdentifierValue of type Name.
+	// the first client name
+	ClientIdentifierValueName ClientIdentifierValue = iota
+	// ClientIdentifierValueIp is a ClientIdentifierValue of type Ip.
+	// the client IP address
+	ClientIdentifierValueIp
+	// ClientIdentifierValueMac is a ClientIdentifierValue of type Mac.
+	// the MAC address of the client from the neighbor table of the host, only on Linux
+	ClientIdentifierValueMac
+)
+
+var ErrInvalidClientIdentifierValue = fmt.Errorf("not a valid ClientIdentifierValue, try [%s]", strings.Join(_ClientIdentifierValueNames, ", "))
+
+const _ClientIdentifierValueName = "nameipmac"
+
+var _ClientIdentifierValueNames = []string{
+	_ClientIdentifierValueName[0:4],
+	_ClientIdentifierValueName[4:6],
+	_ClientIdentifierValueName[6:9],
+}
+
+// ClientIdentifierValueNames returns a list of possible string values of ClientIdentifierValue.
+func ClientIdentifierValueNames() []string {
+	tmp := make([]string, len(_ClientIdentifierValueNames))
+	copy(tmp, _ClientIdentifierValueNames)
+	return tmp
+}
+
+// ClientIdentifierValueValues returns a list of the values for ClientIdentifierValue
+func ClientIdentifierValueValues() []ClientIdentifierValue {
+	return []ClientIdentifierValue{
+		ClientIdentifierValueName,
+		ClientIdentifierValueIp,
+		ClientIdentifierValueMac,
+	}
+}
+
+var _ClientIdentifierValueMap = map[ClientIdentifierValue]string{
+	ClientIdentifierValueName: _ClientIdentifierValueName[0:4],
+	ClientIdentifierValueIp:   _ClientIdentifierValueName[4:6],
+	ClientIdentifierValueMac:  _ClientIdentifierValueName[6:9],
+}
+
+// String implements the Stringer interface.
+func (x ClientIdentifierValue) String() string {
+	if str, ok := _ClientIdentifierValueMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ClientIdentifierValue(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ClientIdentifierValue) IsValid() bool {
+	_, ok := _ClientIdentifierValueMap[x]
+	return ok
+}
+
+var _ClientIdentifierValueValue = map[string]ClientIdentifierValue{
+	_ClientIdentifierValueName[0:4]: ClientIdentifierValueName,
+	_ClientIdentifierValueName[4:6]: ClientIdentifierValueIp,
+	_ClientIdentifierValueName[6:9]: ClientIdentifierValueMac,
+}
+
+// ParseClientIdentifierValue attempts to convert a string to a ClientIdentifierValue.
+func ParseClientIdentifierValue(name string) (ClientIdentifierValue, error) {
+	if x, ok := _ClientIdentifierValueValue[name]; ok {
+		return x, nil
+	}
+	return ClientIdentifierValue(0), fmt.Errorf("%s is %w", name, ErrInvalidClientIdentifierValue)
+}
+
+// MarshalText implements the text marshaller method.
+func (x ClientIdentifierValue) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *ClientIdentifierValue) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseClientIdentifierValue(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// DoHMethodAuto is a DoHMethod of type Auto.
+	// GET for paths with the URI template `{?dns}`, POST otherwise
+	DoHMethodAuto DoHMethod = iota
+	// DoHMethodGet is a DoHMethod of type Get.
+	// cache-friendly GET with the base64url encoded query as parameter `dns`
+	DoHMethodGet
+	// DoHMethodPost is a DoHMethod of type Post.
+	// POST with the query as body
+	DoHMethodPost
+)
+
+var ErrInvalidDoHMethod = fmt.Errorf("not a valid DoHMethod, try [%s]", strings.Join(_DoHMethodNames, ", "))
+
+const _DoHMethodName = "autogetpost"
+
+var _DoHMethodNames = []string{
+	_DoHMethodName[0:4],
+	_DoHMethodName[4:7],
+	_DoHMethodName[7:11],
+}
+
+// DoHMethodNames returns a list of possible string values of DoHMethod.
+func DoHMethodNames() []string {
+	tmp := make([]string, len(_DoHMethodNames))
+	copy(tmp, _DoHMethodNames)
+	return tmp
+}
+
+// DoHMethodValues returns a list of the values for DoHMethod
+func DoHMethodValues() []DoHMethod {
+	return []DoHMethod{
+		DoHMethodAuto,
+		DoHMethodGet,
+		DoHMethodPost,
+	}
+}
+
+var _DoHMethodMap = map[DoHMethod]string{
+	DoHMethodAuto: _DoHMethodName[0:4],
+	DoHMethodGet:  _DoHMethodName[4:7],
+	DoHMethodPost: _DoHMethodName[7:11],
+}
+
+// String implements the Stringer interface.
+func (x DoHMethod) String() string {
+	if str, ok := _DoHMethodMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("DoHMethod(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x DoHMethod) IsValid() bool {
+	_, ok := _DoHMethodMap[x]
+	return ok
+}
+
+var _DoHMethodValue = map[string]DoHMethod{
+	_DoHMethodName[0:4]:  DoHMethodAuto,
+	_DoHMethodName[4:7]:  DoHMethodGet,
+	_DoHMethodName[7:11]: DoHMethodPost,
+}
+
+// ParseDoHMethod attempts to convert a string to a DoHMethod.
+func ParseDoHMethod(name string) (DoHMethod, error) {
+	if x, ok := _DoHMethodValue[name]; ok {
+		return x, nil
+	}
+	return DoHMethod(0), fmt.Errorf("%s is %w", name, ErrInvalidDoHMethod)
+}
+
+// MarshalText implements the text marshaller method.
+func (x DoHMethod) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *DoHMethod) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseDoHMethod(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// EDEBlockedCodeBlocked is a EDEBlockedCode of type Blocked.
+	// the domain is on a blocklist of the operator
+	EDEBlockedCodeBlocked EDEBlockedCode = iota + 15
+	// EDEBlockedCodeFiltered is a EDEBlockedCode of type Filtered.
+	// the domain is filtered as requested by the client
+	EDEBlockedCodeFiltered EDEBlockedCode = iota + 16
+)
+
+var ErrInvalidEDEBlockedCode = fmt.Errorf("not a valid EDEBlockedCode, try [%s]", strings.Join(_EDEBlockedCodeNames, ", "))
+
+const _EDEBlockedCodeName = "blockedfiltered"
+
+var _EDEBlockedCodeNames = []string{
+	_EDEBlockedCodeName[0:7],
+	_EDEBlockedCodeName[7:15],
+}
+
+// EDEBlockedCodeNames returns a list of possible string values of EDEBlockedCode.
+func EDEBlockedCodeNames() []string {
+	tmp := make([]string, len(_EDEBlockedCodeNames))
+	copy(tmp, _EDEBlockedCodeNames)
+	return tmp
+}
+
+// EDEBlockedCodeValues returns a list of the values for EDEBlockedCode
+func EDEBlockedCodeValues() []EDEBlockedCode {
+	return []EDEBlockedCode{
+		EDEBlockedCodeBlocked,
+		EDEBlockedCodeFiltered,
+	}
+}
+
+var _EDEBlockedCodeMap = map[EDEBlockedCode]string{
+	EDEBlockedCodeBlocked:  _EDEBlockedCodeName[0:7],
+	EDEBlockedCodeFiltered: _EDEBlockedCodeName[7:15],
+}
+
+// String implements the Stringer interface.
+func (x EDEBlockedCode) String() string {
+	if str, ok := _EDEBlockedCodeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("EDEBlockedCode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x EDEBlockedCode) IsValid() bool {
+	_, ok := _EDEBlockedCodeMap[x]
+	return ok
+}
+
+var _EDEBlockedCodeValue = map[string]EDEBlockedCode{
+	_EDEBlockedCodeName[0:7]:  EDEBlockedCodeBlocked,
+	_EDEBlockedCodeName[7:15]: EDEBlockedCodeFiltered,
+}
+
+// ParseEDEBlockedCode attempts to convert a string to a EDEBlockedCode.
+func ParseEDEBlockedCode(name string) (EDEBlockedCode, error) {
+	if x, ok := _EDEBlockedCodeValue[name]; ok {
+		return x, nil
+	}
+	return EDEBlockedCode(0), fmt.Errorf("%s is %w", name, ErrInvalidEDEBlockedCode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x EDEBlockedCode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *EDEBlockedCode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseEDEBlockedCode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// EDNSOptionEcs is a EDNSOption of type Ecs.
+	// client subnet
+	EDNSOptionEcs EDNSOption = iota
+	// EDNSOptionCookie is a EDNSOption of type Cookie.
+	// DNS cookie
+	EDNSOptionCookie
+	// EDNSOptionPadding is a EDNSOption of type Padding.
+	// padding
+	EDNSOptionPadding
+	// EDNSOptionNsid is a EDNSOption of type Nsid.
+	// name server identifier
+	EDNSOptionNsid
+	// EDNSOptionEde is a EDNSOption of type Ede.
+	// extended DNS error
+	EDNSOptionEde
+)
+
+var ErrInvalidEDNSOption = fmt.Errorf("not a valid EDNSOption, try [%s]", strings.Join(_EDNSOptionNames, ", "))
+
+const _EDNSOptionName = "ecscookiepaddingnsidede"
+
+var _EDNSOptionNames = []string{
+	_EDNSOptionName[0:3],
+	_EDNSOptionName[3:9],
+	_EDNSOptionName[9:16],
+	_EDNSOptionName[16:20],
+	_EDNSOptionName[20:23],
+}
+
+// EDNSOptionNames returns a list of possible string values of EDNSOption.
+func EDNSOptionNames() []string {
+	tmp := make([]string, len(_EDNSOptionNames))
+	copy(tmp, _EDNSOptionNames)
+	return tmp
+}
+
+// EDNSOptionValues returns a list of the values for EDNSOption
+func EDNSOptionValues() []EDNSOption {
+	return []EDNSOption{
+		EDNSOptionEcs,
+		EDNSOptionCookie,
+		EDNSOptionPadding,
+		EDNSOptionNsid,
+		EDNSOptionEde,
+	}
+}
+
+var _EDNSOptionMap = map[EDNSOption]string{
+	EDNSOptionEcs:     _EDNSOptionName[0:3],
+	EDNSOptionCookie:  _EDNSOptionName[3:9],
+	EDNSOptionPadding: _EDNSOptionName[9:16],
+	EDNSOptionNsid:    _EDNSOptionName[16:20],
+	EDNSOptionEde:     _EDNSOptionName[20:23],
+}
+
+// String implements the Stringer interface.
+func (x EDNSOption) String() string {
+	if str, ok := _EDNSOptionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("EDNSOption(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x EDNSOption) IsValid() bool {
+	_, ok := _EDNSOptionMap[x]
+	return ok
+}
+
+var _EDNSOptionValue = map[string]EDNSOption{
+	_EDNSOptionName[0:3]:   EDNSOptionEcs,
+	_EDNSOptionName[3:9]:   EDNSOptionCookie,
+	_EDNSOptionName[9:16]:  EDNSOptionPadding,
+	_EDNSOptionName[16:20]: EDNSOptionNsid,
+	_EDNSOptionName[20:23]: EDNSOptionEde,
+}
+
+// ParseEDNSOption attempts to convert a string to a EDNSOption.
+func ParseEDNSOption(name string) (EDNSOption, error) {
+	if x, ok := _EDNSOptionValue[name]; ok {
+		return x, nil
+	}
+	return EDNSOption(0), fmt.Errorf("%s is %w", name, ErrInvalidEDNSOption)
+}
+
+// MarshalText implements the text marshaller method.
+func (x EDNSOption) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *EDNSOption) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseEDNSOption(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// FilterAAAAModeOff is a FilterAAAAMode of type Off.
+	// the AAAA answers are kept
+	FilterAAAAModeOff FilterAAAAMode = iota
+	// FilterAAAAModeOnV4 is a FilterAAAAMode of type OnV4.
+	// only for queries received over IPv4, like filter-aaaa-on-v4 of BIND
+	FilterAAAAModeOnV4
+	// FilterAAAAModeAlways is a FilterAAAAMode of type Always.
+	// for all queries
+	FilterAAAAModeAlways
+)
+
+var ErrInvalidFilterAAAAMode = fmt.Errorf("not a valid FilterAAAAMode, try [%s]", strings.Join(_FilterAAAAModeNames, ", "))
+
+const _FilterAAAAModeName = "offonV4always"
+
+var _FilterAAAAModeNames = []string{
+	_FilterAAAAModeName[0:3],
+	_FilterAAAAModeName[3:7],
+	_FilterAAAAModeName[7:13],
+}
+
+// FilterAAAAModeNames returns a list of possible string values of FilterAAAAMode.
+func FilterAAAAModeNames() []string {
+	tmp := make([]string, len(_FilterAAAAModeNames))
+	copy(tmp, _FilterAAAAModeNames)
+	return tmp
+}
+
+// FilterAAAAModeValues returns a list of the values for FilterAAAAMode
+func FilterAAAAModeValues() []FilterAAAAMode {
+	return []FilterAAAAMode{
+		FilterAAAAModeOff,
+		FilterAAAAModeOnV4,
+		FilterAAAAModeAlways,
+	}
+}
+
+var _FilterAAAAModeMap = map[FilterAAAAMode]string{
+	FilterAAAAModeOff:    _FilterAAAAModeName[0:3],
+	FilterAAAAModeOnV4:   _FilterAAAAModeName[3:7],
+	FilterAAAAModeAlways: _FilterAAAAModeName[7:13],
+}
+
+// String implements the Stringer interface.
+func (x FilterAAAAMode) String() string {
+	if str, ok := _FilterAAAAModeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("FilterAAAAMode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x FilterAAAAMode) IsValid() bool {
+	_, ok := _FilterAAAAModeMap[x]
+	return ok
+}
+
+var _FilterAAAAModeValue = map[string]FilterAAAAMode{
+	_FilterAAAAModeName[0:3]:  FilterAAAAModeOff,
+	_FilterAAAAModeName[3:7]:  FilterAAAAModeOnV4,
+	_FilterAAAAModeName[7:13]: FilterAAAAModeAlways,
+}
+
+// ParseFilterAAAAMode attempts to convert a string to a FilterAAAAMode.
+func ParseFilterAAAAMode(name string) (FilterAAAAMode, error) {
+	if x, ok := _FilterAAAAModeValue[name]; ok {
+		return x, nil
+	}
+	return FilterAAAAMode(0), fmt.Errorf("%s is %w", name, ErrInvalidFilterAAAAMode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x FilterAAAAMode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *FilterAAAAMode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseFilterAAAAMode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// IPVersionDual is a IPVersion of type Dual.
 	// IPv4 and IPv6
@@ -25,74 +559,252 @@ const (
 
 var ErrInvalidIPVersion = fmt.Errorf("not a valid IPVersion, try [%s]", strings.Join(_IPVersionNames, ", "))
 
-const _IPVersionName = "dualv4v6"
+const _IPVersionName = "dualv4v6"
+
+var _IPVersionNames = []string{
+	_IPVersionName[0:4],
+	_IPVersionName[4:6],
+	_IPVersionName[6:8],
+}
+
+// IPVersionNames returns a list of possible string values of IPVersion.
+func IPVersionNames() []string {
+	tmp := make([]string, len(_IPVersionNames))
+	copy(tmp, _IPVersionNames)
+	return tmp
+}
+
+// IPVersionValues returns a list of the values for IPVersion
+func IPVersionValues() []IPVersion {
+	return []IPVersion{
+		IPVersionDual,
+		IPVersionV4,
+		IPVersionV6,
+	}
+}
+
+var _IPVersionMap = map[IPVersion]string{
+	IPVersionDual: _IPVersionName[0:4],
+	IPVersionV4:   _IPVersionName[4:6],
+	IPVersionV6:   _IPVersionName[6:8],
+}
+
+// String implements the Stringer interface.
+func (x IPVersion) String() string {
+	if str, ok := _IPVersionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("IPVersion(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x IPVersion) IsValid() bool {
+	_, ok := _IPVersionMap[x]
+	return ok
+}
+
+var _IPVersionValue = map[string]IPVersion{
+	_IPVersionName[0:4]: IPVersionDual,
+	_IPVersionName[4:6]: IPVersionV4,
+	_IPVersionName[6:8]: IPVersionV6,
+}
+
+// ParseIPVersion attempts to convert a string to a IPVersion.
+func ParseIPVersion(name string) (IPVersion, error) {
+	if x, ok := _IPVersionValue[name]; ok {
+		return x, nil
+	}
+	return IPVersion(0), fmt.Errorf("%s is %w", name, ErrInvalidIPVersion)
+}
+
+// MarshalText implements the text marshaller method.
+func (x IPVersion) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *IPVersion) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseIPVersion(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// InitStrategyBlocking is a InitStrategy of type Blocking.
+	// synchronously download blocking lists on startup
+	InitStrategyBlocking InitStrategy = iota
+	// InitStrategyFailOnError is a InitStrategy of type FailOnError.
+	// synchronously download blocking lists on startup and shutdown on error
+	InitStrategyFailOnError
+	// InitStrategyFast is a InitStrategy of type Fast.
+	// asyncronously download blocking lists on startup
+	InitStrategyFast
+)
+
+var ErrInvalidInitStrategy = fmt.Errorf("not a valid InitStrategy, try [%s]", strings.Join(_InitStrategyNames, ", "))
+
+const _InitStrategyName = "blockingfailOnErrorfast"
+
+var _InitStrategyNames = []string{
+	_InitStrategyName[0:8],
+	_InitStrategyName[8:19],
+	_InitStrategyName[19:23],
+}
+
+// InitStrategyNames returns a list of possible string values of InitStrategy.
+func InitStrategyNames() []string {
+	tmp := make([]string, len(_InitStrategyNames))
+	copy(tmp, _InitStrategyNames)
+	return tmp
+}
+
+// InitStrategyValues returns a list of the values for InitStrategy
+func InitStrategyValues() []InitStrategy {
+	return []InitStrategy{
+		InitStrategyBlocking,
+		InitStrategyFailOnError,
+		InitStrategyFast,
+	}
+}
+
+var _InitStrategyMap = map[InitStrategy]string{
+	InitStrategyBlocking:    _InitStrategyName[0:8],
+	InitStrategyFailOnError: _InitStrategyName[8:19],
+	InitStrategyFast:        _InitStrategyName[19:23],
+}
+
+// String implements the Stringer interface.
+func (x InitStrategy) String() string {
+	if str, ok := _InitStrategyMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("InitStrategy(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x InitStrategy) IsValid() bool {
+	_, ok := _InitStrategyMap[x]
+	return ok
+}
+
+var _InitStrategyValue = map[string]InitStrategy{
+	_InitStrategyName[0:8]:   InitStrategyBlocking,
+	_InitStrategyName[8:19]:  InitStrategyFailOnError,
+	_InitStrategyName[19:23]: InitStrategyFast,
+}
+
+// ParseInitStrategy attempts to convert a string to a InitStrategy.
+func ParseInitStrategy(name string) (InitStrategy, error) {
+	if x, ok := _InitStrategyValue[name]; ok {
+		return x, nil
+	}
+	return InitStrategy(0), fmt.Errorf("%s is %w", name, ErrInvalidInitStrategy)
+}
+
+// MarshalText implements the text marshaller method.
+func (x InitStrategy) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *InitStrategy) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseInitStrategy(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// ListFailureModeKeep is a ListFailureMode of type Keep.
+	// keep the previously loaded entries, if any
+	ListFailureModeKeep ListFailureMode = iota
+	// ListFailureModeAllowAll is a ListFailureMode of type AllowAll.
+	// the group blocks no queries
+	ListFailureModeAllowAll
+	// ListFailureModeDenyAll is a ListFailureMode of type DenyAll.
+	// the group blocks all queries
+	ListFailureModeDenyAll
+)
+
+var ErrInvalidListFailureMode = fmt.Errorf("not a valid ListFailureMode, try [%s]", strings.Join(_ListFailureModeNames, ", "))
+
+const _ListFailureModeName = "keepallowAlldenyAll"
 
-var _IPVersionNames = []string{
-	_IPVersionName[0:4],
-	_IPVersionName[4:6],
-	_IPVersionName[6:8],
+var _ListFailureModeNames = []string{
+	_ListFailureModeName[0:4],
+	_ListFailureModeName[4:12],
+	_ListFailureModeName[12:19],
 }
 
-// IPVersionNames returns a list of possible string values of IPVersion.
-func IPVersionNames() []string {
-	tmp := make([]string, len(_IPVersionNames))
-	copy(tmp, _IPVersionNames)
+// ListFailureModeNames returns a list of possible string values of ListFailureMode.
+func ListFailureModeNames() []string {
+	tmp := make([]string, len(_ListFailureModeNames))
+	copy(tmp, _ListFailureModeNames)
 	return tmp
 }
 
-// IPVersionValues returns a list of the values for IPVersion
-func IPVersionValues() []IPVersion {
-	return []IPVersion{
-		IPVersionDual,
-		IPVersionV4,
-		IPVersionV6,
+// ListFailureModeValues returns a list of the values for ListFailureMode
+func ListFailureModeValues() []ListFailureMode {
+	return []ListFailureMode{
+		ListFailureModeKeep,
+		ListFailureModeAllowAll,
+		ListFailureModeDenyAll,
 	}
 }
 
-var _IPVersionMap = map[IPVersion]string{
-	IPVersionDual: _IPVersionName[0:4],
-	IPVersionV4:   _IPVersionName[4:6],
-	IPVersionV6:   _IPVersionName[6:8],
+var _ListFailureModeMap = map[ListFailureMode]string{
+	ListFailureModeKeep:     _ListFailureModeName[0:4],
+	ListFailureModeAllowAll: _ListFailureModeName[4:12],
+	ListFailureModeDenyAll:  _ListFailureModeName[12:19],
 }
 
 // String implements the Stringer interface.
-func (x IPVersion) String() string {
-	if str, ok := _IPVersionMap[x]; ok {
+func (x ListFailureMode) String() string {
+	if str, ok := _ListFailureModeMap[x]; ok {
 		return str
 	}
-	return fmt.Sprintf("IPVersion(%d)", x)
+	return fmt.Sprintf("ListFailureMode(%d)", x)
 }
 
 // IsValid provides a quick way to determine if the typed value is
 // part of the allowed enumerated values
-func (x IPVersion) IsValid() bool {
-	_, ok := _IPVersionMap[x]
+func (x ListFailureMode) IsValid() bool {
+	_, ok := _ListFailureModeMap[x]
 	return ok
 }
 
-var _IPVersionValue = map[string]IPVersion{
-	_IPVersionName[0:4]: IPVersionDual,
-	_IPVersionName[4:6]: IPVersionV4,
-	_IPVersionName[6:8]: IPVersionV6,
+var _ListFailureModeValue = map[string]ListFailureMode{
+	_ListFailureModeName[0:4]:   ListFailureModeKeep,
+	_ListFailureModeName[4:12]:  ListFailureModeAllowAll,
+	_ListFailureModeName[12:19]: ListFailureModeDenyAll,
 }
 
-// ParseIPVersion attempts to convert a string to a IPVersion.
-func ParseIPVersion(name string) (IPVersion, error) {
-	if x, ok := _IPVersionValue[name]; ok {
+// ParseListFailureMode attempts to convert a string to a ListFailureMode.
+func ParseListFailureMode(name string) (ListFailureMode, error) {
+	if x, ok := _ListFailureModeValue[name]; ok {
 		return x, nil
 	}
-	return IPVersion(0), fmt.Errorf("%s is %w", name, ErrInvalidIPVersion)
+	return ListFailureMode(0), fmt.Errorf("%s is %w", name, ErrInvalidListFailureMode)
 }
 
 // MarshalText implements the text marshaller method.
-func (x IPVersion) MarshalText() ([]byte, error) {
+func (x ListFailureMode) MarshalText() ([]byte, error) {
 	return []byte(x.String()), nil
 }
 
 // UnmarshalText implements the text unmarshaller method.
-func (x *IPVersion) UnmarshalText(text []byte) error {
+func (x *ListFailureMode) UnmarshalText(text []byte) error {
 	name := string(text)
-	tmp, err := ParseIPVersion(name)
+	tmp, err := ParseListFailureMode(name)
 	if err != nil {
 		return err
 	}
@@ -101,87 +813,80 @@ func (x *IPVersion) UnmarshalText(text []byte) error {
 }
 
 const (
-	// InitStrategyBlocking is a InitStrategy of type Blocking.
-	// synchronously download blocking lists on startup
-	InitStrategyBlocking InitStrategy = iota
-	// InitStrategyFailOnError is a InitStrategy of type FailOnError.
-	// synchronously download blocking lists on startup and shutdown on error
-	InitStrategyFailOnError
-	// InitStrategyFast is a InitStrategy of type Fast.
-	// asyncronously download blocking lists on startup
-	InitStrategyFast
+	// MetricsClientLabelName is a MetricsClientLabel of type Name.
+	// the client names
+	MetricsClientLabelName MetricsClientLabel = iota
+	// MetricsClientLabelHash is a MetricsClientLabel of type Hash.
+	// a hash of the client names
+	MetricsClientLabelHash
 )
 
-var ErrInvalidInitStrategy = fmt.Errorf("not a valid InitStrategy, try [%s]", strings.Join(_InitStrategyNames, ", "))
+var ErrInvalidMetricsClientLabel = fmt.Errorf("not a valid MetricsClientLabel, try [%s]", strings.Join(_MetricsClientLabelNames, ", "))
 
-const _InitStrategyName = "blockingfailOnErrorfast"
+const _MetricsClientLabelName = "namehash"
 
-var _InitStrategyNames = []string{
-	_InitStrategyName[0:8],
-	_InitStrategyName[8:19],
-	_InitStrategyName[19:23],
+var _MetricsClientLabelNames = []string{
+	_MetricsClientLabelName[0:4],
+	_MetricsClientLabelName[4:8],
 }
 
-// InitStrategyNames returns a list of possible string values of InitStrategy.
-func InitStrategyNames() []string {
-	tmp := make([]string, len(_InitStrategyNames))
-	copy(tmp, _InitStrategyNames)
+// MetricsClientLabelNames returns a list of possible string values of MetricsClientLabel.
+func MetricsClientLabelNames() []string {
+	tmp := make([]string, len(_MetricsClientLabelNames))
+	copy(tmp, _MetricsClientLabelNames)
 	return tmp
 }
 
-// InitStrategyValues returns a list of the values for InitStrategy
-func InitStrategyValues() []InitStrategy {
-	return []InitStrategy{
-		InitStrategyBlocking,
-		InitStrategyFailOnError,
-		InitStrategyFast,
+// MetricsClientLabelValues returns a list of the values for MetricsClientLabel
+func MetricsClientLabelValues() []MetricsClientLabel {
+	return []MetricsClientLabel{
+		MetricsClientLabelName,
+		MetricsClientLabelHash,
 	}
 }
 
-var _InitStrategyMap = map[InitStrategy]string{
-	InitStrategyBlocking:    _InitStrategyName[0:8],
-	InitStrategyFailOnError: _InitStrategyName[8:19],
-	InitStrategyFast:        _InitStrategyName[19:23],
+var _MetricsClientLabelMap = map[MetricsClientLabel]string{
+	MetricsClientLabelName: _MetricsClientLabelName[0:4],
+	MetricsClientLabelHash: _MetricsClientLabelName[4:8],
 }
 
 // String implements the Stringer interface.
-func (x InitStrategy) String() string {
-	if str, ok := _InitStrategyMap[x]; ok {
+func (x MetricsClientLabel) String() string {
+	if str, ok := _MetricsClientLabelMap[x]; ok {
 		return str
 	}
-	return fmt.Sprintf("InitStrategy(%d)", x)
+	return fmt.Sprintf("MetricsClientLabel(%d)", x)
 }
 
 // IsValid provides a quick way to determine if the typed value is
 // part of the allowed enumerated values
-func (x InitStrategy) IsValid() bool {
-	_, ok := _InitStrategyMap[x]
+func (x MetricsClientLabel) IsValid() bool {
+	_, ok := _MetricsClientLabelMap[x]
 	return ok
 }
 
-var _InitStrategyValue = map[string]InitStrategy{
-	_InitStrategyName[0:8]:   InitStrategyBlocking,
-	_InitStrategyName[8:19]:  InitStrategyFailOnError,
-	_InitStrategyName[19:23]: InitStrategyFast,
+var _MetricsClientLabelValue = map[string]MetricsClientLabel{
+	_MetricsClientLabelName[0:4]: MetricsClientLabelName,
+	_MetricsClientLabelName[4:8]: MetricsClientLabelHash,
 }
 
-// ParseInitStrategy attempts to convert a string to a InitStrategy.
-func ParseInitStrategy(name string) (InitStrategy, error) {
-	if x, ok := _InitStrategyValue[name]; ok {
+// ParseMetricsClientLabel attempts to convert a string to a MetricsClientLabel.
+func ParseMetricsClientLabel(name string) (MetricsClientLabel, error) {
+	if x, ok := _MetricsClientLabelValue[name]; ok {
 		return x, nil
 	}
-	return InitStrategy(0), fmt.Errorf("%s is %w", name, ErrInvalidInitStrategy)
+	return MetricsClientLabel(0), fmt.Errorf("%s is %w", name, ErrInvalidMetricsClientLabel)
 }
 
 // MarshalText implements the text marshaller method.
-func (x InitStrategy) MarshalText() ([]byte, error) {
+func (x MetricsClientLabel) MarshalText() ([]byte, error) {
 	return []byte(x.String()), nil
 }
 
 // UnmarshalText implements the text unmarshaller method.
-func (x *InitStrategy) UnmarshalText(text []byte) error {
+func (x *MetricsClientLabel) UnmarshalText(text []byte) error {
 	name := string(text)
-	tmp, err := ParseInitStrategy(name)
+	tmp, err := ParseMetricsClientLabel(name)
 	if err != nil {
 		return err
 	}
@@ -278,6 +983,184 @@ func (x *NetProtocol) UnmarshalText(text []byte) error {
 	return nil
 }
 
+const (
+	// NewDomainActionFlag is a NewDomainAction of type Flag.
+	// log the query but resolve it
+	NewDomainActionFlag NewDomainAction = iota
+	// NewDomainActionBlock is a NewDomainAction of type Block.
+	// answer the query with NXDOMAIN
+	NewDomainActionBlock
+)
+
+var ErrInvalidNewDomainAction = fmt.Errorf("not a valid NewDomainAction, try [%s]", strings.Join(_NewDomainActionNames, ", "))
+
+const _NewDomainActionName = "flagblock"
+
+var _NewDomainActionNames = []string{
+	_NewDomainActionName[0:4],
+	_NewDomainActionName[4:9],
+}
+
+// NewDomainActionNames returns a list of possible string values of NewDomainAction.
+func NewDomainActionNames() []string {
+	tmp := make([]string, len(_NewDomainActionNames))
+	copy(tmp, _NewDomainActionNames)
+	return tmp
+}
+
+// NewDomainActionValues returns a list of the values for NewDomainAction
+func NewDomainActionValues() []NewDomainAction {
+	return []NewDomainAction{
+		NewDomainActionFlag,
+		NewDomainActionBlock,
+	}
+}
+
+var _NewDomainActionMap = map[NewDomainAction]string{
+	NewDomainActionFlag:  _NewDomainActionName[0:4],
+	NewDomainActionBlock: _NewDomainActionName[4:9],
+}
+
+// String implements the Stringer interface.
+func (x NewDomainAction) String() string {
+	if str, ok := _NewDomainActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("NewDomainAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x NewDomainAction) IsValid() bool {
+	_, ok := _NewDomainActionMap[x]
+	return ok
+}
+
+var _NewDomainActionValue = map[string]NewDomainAction{
+	_NewDomainActionName[0:4]: NewDomainActionFlag,
+	_NewDomainActionName[4:9]: NewDomainActionBlock,
+}
+
+// ParseNewDomainAction attempts to convert a string to a NewDomainAction.
+func ParseNewDomainAction(name string) (NewDomainAction, error) {
+	if x, ok := _NewDomainActionValue[name]; ok {
+		return x, nil
+	}
+	return NewDomainAction(0), fmt.Errorf("%s is %w", name, ErrInvalidNewDomainAction)
+}
+
+// MarshalText implements the text marshaller method.
+func (x NewDomainAction) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *NewDomainAction) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseNewDomainAction(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// NotificationTypeWebhook is a NotificationType of type Webhook.
+	// generic JSON webhook
+	NotificationTypeWebhook NotificationType = iota
+	// NotificationTypeSlack is a NotificationType of type Slack.
+	// Slack incoming webhook
+	NotificationTypeSlack
+	// NotificationTypeTelegram is a NotificationType of type Telegram.
+	// Telegram bot API
+	NotificationTypeTelegram
+	// NotificationTypeGotify is a NotificationType of type Gotify.
+	// Gotify server
+	NotificationTypeGotify
+)
+
+var ErrInvalidNotificationType = fmt.Errorf("not a valid NotificationType, try [%s]", strings.Join(_NotificationTypeNames, ", "))
+
+const _NotificationTypeName = "webhookslacktelegramgotify"
+
+var _NotificationTypeNames = []string{
+	_NotificationTypeName[0:7],
+	_NotificationTypeName[7:12],
+	_NotificationTypeName[12:20],
+	_NotificationTypeName[20:26],
+}
+
+// NotificationTypeNames returns a list of possible string values of NotificationType.
+func NotificationTypeNames() []string {
+	tmp := make([]string, len(_NotificationTypeNames))
+	copy(tmp, _NotificationTypeNames)
+	return tmp
+}
+
+// NotificationTypeValues returns a list of the values for NotificationType
+func NotificationTypeValues() []NotificationType {
+	return []NotificationType{
+		NotificationTypeWebhook,
+		NotificationTypeSlack,
+		NotificationTypeTelegram,
+		NotificationTypeGotify,
+	}
+}
+
+var _NotificationTypeMap = map[NotificationType]string{
+	NotificationTypeWebhook:  _NotificationTypeName[0:7],
+	NotificationTypeSlack:    _NotificationTypeName[7:12],
+	NotificationTypeTelegram: _NotificationTypeName[12:20],
+	NotificationTypeGotify:   _NotificationTypeName[20:26],
+}
+
+// String implements the Stringer interface.
+func (x NotificationType) String() string {
+	if str, ok := _NotificationTypeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("NotificationType(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x NotificationType) IsValid() bool {
+	_, ok := _NotificationTypeMap[x]
+	return ok
+}
+
+var _NotificationTypeValue = map[string]NotificationType{
+	_NotificationTypeName[0:7]:   NotificationTypeWebhook,
+	_NotificationTypeName[7:12]:  NotificationTypeSlack,
+	_NotificationTypeName[12:20]: NotificationTypeTelegram,
+	_NotificationTypeName[20:26]: NotificationTypeGotify,
+}
+
+// ParseNotificationType attempts to convert a string to a NotificationType.
+func ParseNotificationType(name string) (NotificationType, error) {
+	if x, ok := _NotificationTypeValue[name]; ok {
+		return x, nil
+	}
+	return NotificationType(0), fmt.Errorf("%s is %w", name, ErrInvalidNotificationType)
+}
+
+// MarshalText implements the text marshaller method.
+func (x NotificationType) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *NotificationType) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseNotificationType(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// QueryLogFieldClientIP is a QueryLogField of type clientIP.
 	QueryLogFieldClientIP QueryLogField = "clientIP"
@@ -291,6 +1174,16 @@ const (
 	QueryLogFieldQuestion QueryLogField = "question"
 	// QueryLogFieldDuration is a QueryLogField of type duration.
 	QueryLogFieldDuration QueryLogField = "duration"
+	// QueryLogFieldUpstream is a QueryLogField of type upstream.
+	QueryLogFieldUpstream QueryLogField = "upstream"
+	// QueryLogFieldEcs is a QueryLogField of type ecs.
+	QueryLogFieldEcs QueryLogField = "ecs"
+	// QueryLogFieldDurationBucket is a QueryLogField of type durationBucket.
+	QueryLogFieldDurationBucket QueryLogField = "durationBucket"
+	// QueryLogFieldTenant is a QueryLogField of type tenant.
+	QueryLogFieldTenant QueryLogField = "tenant"
+	// QueryLogFieldCategories is a QueryLogField of type categories.
+	QueryLogFieldCategories QueryLogField = "categories"
 )
 
 var ErrInvalidQueryLogField = fmt.Errorf("not a valid QueryLogField, try [%s]", strings.Join(_QueryLogFieldNames, ", "))
@@ -302,6 +1195,11 @@ var _QueryLogFieldNames = []string{
 	string(QueryLogFieldResponseAnswer),
 	string(QueryLogFieldQuestion),
 	string(QueryLogFieldDuration),
+	string(QueryLogFieldUpstream),
+	string(QueryLogFieldEcs),
+	string(QueryLogFieldDurationBucket),
+	string(QueryLogFieldTenant),
+	string(QueryLogFieldCategories),
 }
 
 // QueryLogFieldNames returns a list of possible string values of QueryLogField.
@@ -320,6 +1218,11 @@ func QueryLogFieldValues() []QueryLogField {
 		QueryLogFieldResponseAnswer,
 		QueryLogFieldQuestion,
 		QueryLogFieldDuration,
+		QueryLogFieldUpstream,
+		QueryLogFieldEcs,
+		QueryLogFieldDurationBucket,
+		QueryLogFieldTenant,
+		QueryLogFieldCategories,
 	}
 }
 
@@ -342,6 +1245,11 @@ var _QueryLogFieldValue = map[string]QueryLogField{
 	"responseAnswer": QueryLogFieldResponseAnswer,
 	"question":       QueryLogFieldQuestion,
 	"duration":       QueryLogFieldDuration,
+	"upstream":       QueryLogFieldUpstream,
+	"ecs":            QueryLogFieldEcs,
+	"durationBucket": QueryLogFieldDurationBucket,
+	"tenant":         QueryLogFieldTenant,
+	"categories":     QueryLogFieldCategories,
 }
 
 // ParseQueryLogField attempts to convert a string to a QueryLogField.
@@ -484,6 +1392,88 @@ func (x *QueryLogType) UnmarshalText(text []byte) error {
 	return nil
 }
 
+const (
+	// SelfCheckExpectationResolved is a SelfCheckExpectation of type Resolved.
+	// the domain resolves to an answer
+	SelfCheckExpectationResolved SelfCheckExpectation = iota
+	// SelfCheckExpectationBlocked is a SelfCheckExpectation of type Blocked.
+	// the domain is blocked
+	SelfCheckExpectationBlocked
+)
+
+var ErrInvalidSelfCheckExpectation = fmt.Errorf("not a valid SelfCheckExpectation, try [%s]", strings.Join(_SelfCheckExpectationNames, ", "))
+
+const _SelfCheckExpectationName = "resolvedblocked"
+
+var _SelfCheckExpectationNames = []string{
+	_SelfCheckExpectationName[0:8],
+	_SelfCheckExpectationName[8:15],
+}
+
+// SelfCheckExpectationNames returns a list of possible string values of SelfCheckExpectation.
+func SelfCheckExpectationNames() []string {
+	tmp := make([]string, len(_SelfCheckExpectationNames))
+	copy(tmp, _SelfCheckExpectationNames)
+	return tmp
+}
+
+// SelfCheckExpectationValues returns a list of the values for SelfCheckExpectation
+func SelfCheckExpectationValues() []SelfCheckExpectation {
+	return []SelfCheckExpectation{
+		SelfCheckExpectationResolved,
+		SelfCheckExpectationBlocked,
+	}
+}
+
+var _SelfCheckExpectationMap = map[SelfCheckExpectation]string{
+	SelfCheckExpectationResolved: _SelfCheckExpectationName[0:8],
+	SelfCheckExpectationBlocked:  _SelfCheckExpectationName[8:15],
+}
+
+// String implements the Stringer interface.
+func (x SelfCheckExpectation) String() string {
+	if str, ok := _SelfCheckExpectationMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("SelfCheckExpectation(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x SelfCheckExpectation) IsValid() bool {
+	_, ok := _SelfCheckExpectationMap[x]
+	return ok
+}
+
+var _SelfCheckExpectationValue = map[string]SelfCheckExpectation{
+	_SelfCheckExpectationName[0:8]:  SelfCheckExpectationResolved,
+	_SelfCheckExpectationName[8:15]: SelfCheckExpectationBlocked,
+}
+
+// ParseSelfCheckExpectation attempts to convert a string to a SelfCheckExpectation.
+func ParseSelfCheckExpectation(name string) (SelfCheckExpectation, error) {
+	if x, ok := _SelfCheckExpectationValue[name]; ok {
+		return x, nil
+	}
+	return SelfCheckExpectation(0), fmt.Errorf("%s is %w", name, ErrInvalidSelfCheckExpectation)
+}
+
+// MarshalText implements the text marshaller method.
+func (x SelfCheckExpectation) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *SelfCheckExpectation) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseSelfCheckExpectation(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// TLSVersion10 is a TLSVersion of type 1.0.
 	TLSVersion10 TLSVersion = iota + 769
@@ -576,6 +1566,88 @@ func (x *TLSVersion) UnmarshalText(text []byte) error {
 	return nil
 }
 
+const (
+	// TyposquattingActionFlag is a TyposquattingAction of type Flag.
+	// log the query but resolve it
+	TyposquattingActionFlag TyposquattingAction = iota
+	// TyposquattingActionBlock is a TyposquattingAction of type Block.
+	// answer the query with NXDOMAIN
+	TyposquattingActionBlock
+)
+
+var ErrInvalidTyposquattingAction = fmt.Errorf("not a valid TyposquattingAction, try [%s]", strings.Join(_TyposquattingActionNames, ", "))
+
+const _TyposquattingActionName = "flagblock"
+
+var _TyposquattingActionNames = []string{
+	_TyposquattingActionName[0:4],
+	_TyposquattingActionName[4:9],
+}
+
+// TyposquattingActionNames returns a list of possible string values of TyposquattingAction.
+func TyposquattingActionNames() []string {
+	tmp := make([]string, len(_TyposquattingActionNames))
+	copy(tmp, _TyposquattingActionNames)
+	return tmp
+}
+
+// TyposquattingActionValues returns a list of the values for TyposquattingAction
+func TyposquattingActionValues() []TyposquattingAction {
+	return []TyposquattingAction{
+		TyposquattingActionFlag,
+		TyposquattingActionBlock,
+	}
+}
+
+var _TyposquattingActionMap = map[TyposquattingAction]string{
+	TyposquattingActionFlag:  _TyposquattingActionName[0:4],
+	TyposquattingActionBlock: _TyposquattingActionName[4:9],
+}
+
+// String implements the Stringer interface.
+func (x TyposquattingAction) String() string {
+	if str, ok := _TyposquattingActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("TyposquattingAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x TyposquattingAction) IsValid() bool {
+	_, ok := _TyposquattingActionMap[x]
+	return ok
+}
+
+var _TyposquattingActionValue = map[string]TyposquattingAction{
+	_TyposquattingActionName[0:4]: TyposquattingActionFlag,
+	_TyposquattingActionName[4:9]: TyposquattingActionBlock,
+}
+
+// ParseTyposquattingAction attempts to convert a string to a TyposquattingAction.
+func ParseTyposquattingAction(name string) (TyposquattingAction, error) {
+	if x, ok := _TyposquattingActionValue[name]; ok {
+		return x, nil
+	}
+	return TyposquattingAction(0), fmt.Errorf("%s is %w", name, ErrInvalidTyposquattingAction)
+}
+
+// MarshalText implements the text marshaller method.
+func (x TyposquattingAction) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *TyposquattingAction) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseTyposquattingAction(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// UpstreamStrategyParallelBest is a UpstreamStrategy of type Parallel_best.
 	UpstreamStrategyParallelBest UpstreamStrategy = iota
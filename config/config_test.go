@@ -5,12 +5,14 @@ import (
 	"crypto/tls"
 	"errors"
 	"net"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/creasty/defaults"
 	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 
 	"github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
@@ -164,6 +166,60 @@ var _ = Describe("Config", func() {
 				defaultTestFileConfig(c)
 			})
 		})
+		When("Config data is parsed", func() {
+			It("should migrate and validate the config", func() {
+				c, err = ParseConfig(logger, []byte(strings.Join([]string{
+					"upstream:",
+					"  default:",
+					"    - 1.1.1.1",
+					"filtering:",
+					"  queryTypes:",
+					"    - AAAA",
+				}, "\n")))
+				Expect(err).Should(Succeed())
+
+				Expect(c.Upstreams.Groups[UpstreamDefaultCfgName]).Should(HaveLen(1))
+				Expect(c.Filtering.QueryTypes.Contains(dns.Type(dns.TypeAAAA))).Should(BeTrue())
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("deprecated")))
+			})
+
+			It("should return an error for unknown options", func() {
+				_, err = ParseConfig(logger, []byte("unknownOption: 1"))
+				Expect(err).Should(MatchError(ContainSubstring("wrong file structure")))
+			})
+		})
+		When("Test config file contains module log levels and sampling", func() {
+			It("should parse them", func() {
+				cfgFile := tmpDir.CreateStringFile("config.yml",
+					"upstreams:",
+					"  groups:",
+					"    default:",
+					"      - 1.1.1.1",
+					"log:",
+					"  format: slog",
+					"  levels:",
+					"    blocking: debug",
+					"    server: warn",
+					"  sampling:",
+					"    initial: 10",
+					"    thereafter: 100",
+				)
+
+				c, err = LoadConfig(cfgFile.Path, true)
+				Expect(err).Should(Succeed())
+
+				Expect(c.Log.Format).Should(Equal(log.FormatTypeSlog))
+				Expect(c.Log.Levels).Should(Equal(map[string]logrus.Level{
+					"blocking": logrus.DebugLevel,
+					"server":   logrus.WarnLevel,
+				}))
+				Expect(c.Log.Sampling).Should(Equal(log.SamplingConfig{
+					Initial:    10,
+					Thereafter: 100,
+					Period:     time.Second,
+				}))
+			})
+		})
 		When("Test config file contains a zone file with $INCLUDE", func() {
 			When("The config path is set to the config file", func() {
 				It("Should support the $INCLUDE directive with a bare filename", func() {
@@ -521,6 +577,14 @@ bootstrapDns:
 			"https://dns.google:888/dns-query",
 			Upstream{Net: NetProtocolHttps, Host: "dns.google", Port: 888, Path: "/dns-query"},
 			false),
+		Entry("DoH with URI template",
+			"https://dns.nextdns.io/abc123{?dns}",
+			Upstream{Net: NetProtocolHttps, Host: "dns.nextdns.io", Port: 443, Path: "/abc123{?dns}"},
+			false),
+		Entry("DoH with unsupported URI template",
+			"https://dns.example.com/{profile}/dns-query",
+			Upstream{},
+			true),
 		Entry("empty",
 			"",
 			Upstream{Net: 0},
@@ -583,6 +647,25 @@ bootstrapDns:
 			false),
 	)
 
+	Describe("Upstream URI template", func() {
+		It("should detect the template variable of the query", func() {
+			for _, path := range []string{"/dns-query{?dns}", "/dns-query?profile=a{&dns}"} {
+				u := Upstream{Net: NetProtocolHttps, Host: "localhost", Port: 443, Path: path}
+
+				Expect(u.HasURITemplate()).Should(BeTrue(), path)
+			}
+
+			u := Upstream{Net: NetProtocolHttps, Host: "localhost", Port: 443, Path: "/dns-query"}
+			Expect(u.HasURITemplate()).Should(BeFalse())
+		})
+
+		It("should remove the template variable from the path", func() {
+			u := Upstream{Net: NetProtocolHttps, Host: "localhost", Port: 443, Path: "/dns-query?profile=a{&dns}"}
+
+			Expect(u.PathWithoutTemplate()).Should(Equal("/dns-query?profile=a"))
+		})
+	})
+
 	DescribeTable("Upstream string representation",
 		func(upstream Upstream, canonical string) {
 			Expect(upstream.String()).To(Equal(canonical))
@@ -665,6 +748,43 @@ bootstrapDns:
 					ContainSubstring("refresh = every 1 hour"),
 				))
 			})
+			It("should log refresh schedules", func() {
+				cfg.RefreshSchedule, _ = NewCronSchedule("0 3 * * *")
+				cfg.GroupRefreshSchedules = map[string]CronSchedule{}
+				cfg.GroupRefreshSchedules["ads"], _ = NewCronSchedule("0 4 * * 0")
+				cfg.RefreshJitter = Duration(10 * time.Minute)
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					"refresh = '0 3 * * *'",
+					"refresh of ads = '0 4 * * 0'",
+					"refresh jitter = 10 minutes",
+				))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("refresh = every")))
+			})
+			It("should log the cache dir", func() {
+				cfg.CacheDir = "/var/cache/blocky"
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement("cache dir = /var/cache/blocky"))
+			})
+			It("should log group policies", func() {
+				cfg.GroupPolicies = map[string]ListGroupPolicy{
+					"ads": {
+						RequireAtLeast: 80,
+						MaxStaleAge:    Duration(48 * time.Hour),
+						OnFailure:      ListFailureModeAllowAll,
+					},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement(
+					"policy of ads = require at least 80%, max stale age 2 days, on failure allowAll",
+				))
+			})
 			When("refresh is disabled", func() {
 				BeforeEach(func() {
 					cfg.RefreshPeriod = Duration(-1)
@@ -689,6 +809,33 @@ bootstrapDns:
 		})
 	})
 
+	Describe("DownloaderConfig", func() {
+		It("should log download limits", func() {
+			cfg := Downloader{
+				MaxConcurrent:  2,
+				HostInterval:   Duration(time.Second),
+				BandwidthLimit: 512,
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"maxConcurrent = 2",
+				"hostInterval = 1 second",
+				"bandwidthLimit = 512 KiB/s",
+			))
+		})
+
+		It("should not log disabled limits", func() {
+			cfg := Downloader{}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("maxConcurrent")))
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("bandwidthLimit")))
+		})
+	})
+
 	Describe("InitStrategy", func() {
 		Describe("InitStrategyBlocking", func() {
 			It("runs in the current goroutine", func() {
@@ -923,6 +1070,67 @@ bootstrapDns:
 			Eventually(calls, "50ms").Should(Receive(Equal(int32(2))))
 			Eventually(calls, "50ms").Should(Receive(Equal(int32(3))))
 		})
+
+		It("should not refresh if neither schedule nor period is defined", func() {
+			sut := SourceLoading{}
+
+			calls := make(chan struct{}, 1)
+
+			sut.RefreshPeriodically(ctx, CronSchedule{}, func(context.Context) error {
+				calls <- struct{}{}
+
+				return nil
+			}, func(error) {})
+
+			Consistently(calls, "20ms").ShouldNot(Receive())
+		})
+
+		It("should delay the refresh by at most the jitter", func() {
+			sut := SourceLoading{RefreshJitter: Duration(time.Millisecond)}
+
+			for range 100 {
+				Expect(sut.jitter()).Should(BeNumerically("<", time.Millisecond))
+			}
+
+			sut.RefreshJitter = 0
+			Expect(sut.jitter()).Should(BeZero())
+		})
+
+		It("should parse group policies", func() {
+			data := "groupPolicies:\n  ads:\n    requireAtLeast: 80%\n    maxStaleAge: 48h\n    onFailure: denyAll\n"
+
+			var sut SourceLoading
+			Expect(yaml.Unmarshal([]byte(data), &sut)).Should(Succeed())
+
+			Expect(sut.GroupPolicies).Should(Equal(map[string]ListGroupPolicy{
+				"ads": {
+					RequireAtLeast: 80,
+					MaxStaleAge:    Duration(48 * time.Hour),
+					OnFailure:      ListFailureModeDenyAll,
+				},
+			}))
+		})
+	})
+
+	Describe("Percent", func() {
+		It("should parse values with and without percent sign", func() {
+			var p Percent
+
+			Expect(p.UnmarshalText([]byte("80%"))).Should(Succeed())
+			Expect(p).Should(Equal(Percent(80)))
+
+			Expect(p.UnmarshalText([]byte("100"))).Should(Succeed())
+			Expect(p).Should(Equal(Percent(100)))
+			Expect(p.String()).Should(Equal("100%"))
+		})
+
+		It("should fail on invalid values", func() {
+			var p Percent
+
+			Expect(p.UnmarshalText([]byte("101%"))).Should(MatchError("invalid percentage '101%'"))
+			Expect(p.UnmarshalText([]byte("-1"))).ShouldNot(Succeed())
+			Expect(p.UnmarshalText([]byte("abc"))).ShouldNot(Succeed())
+		})
 	})
 
 	Describe("WithDefaults", func() {
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigWatch configuration of the detection of changes of the configuration file(s), e.g. a mounted Kubernetes
+// ConfigMap, which triggers a reload of all subsystems
+type ConfigWatch struct {
+	Enable   bool     `default:"false" yaml:"enable"`
+	Interval Duration `default:"10s"   yaml:"interval"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ConfigWatch) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ConfigWatch) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interval: %s", c.Interval)
+}
+
+func (c *ConfigWatch) validate(logger *logrus.Entry) {
+	if c.IsEnabled() && !c.Interval.IsAboveZero() {
+		defaults := mustDefault[ConfigWatch]()
+
+		logger.Warnf("configWatch.interval <= 0, setting to %s", defaults.Interval)
+		c.Interval = defaults.Interval
+	}
+}
+
+// Fingerprint returns the hash of the content of the configuration file or of the YAML files of the directory.
+// The content is compared instead of the modification time: Kubernetes updates a mounted ConfigMap by swapping
+// a symlink, which keeps the modification time of the link.
+func Fingerprint(path string) (string, error) {
+	data, err := readConfigData(path)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// readConfigData reads the configuration file or the YAML files of the directory
+func readConfigData(path string) ([]byte, error) {
+	fs, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read config file(s): %w", err)
+	}
+
+	if fs.IsDir() {
+		data, err := readFromDir(path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("can't read config files: %w", err)
+		}
+
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read config file: %w", err)
+	}
+
+	return data, nil
+}
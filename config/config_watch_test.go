@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigWatch", func() {
+	var c ConfigWatch
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = mustDefault[ConfigWatch]()
+		c.Enable = true
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			c := mustDefault[ConfigWatch]()
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if enabled", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("interval: 10 seconds"))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should use the default for an invalid interval", func() {
+			c.Interval = 0
+
+			c.validate(logger)
+
+			Expect(c.Interval).Should(Equal(Duration(10 * time.Second)))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("configWatch.interval")))
+		})
+	})
+
+	Describe("Fingerprint", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+
+			Expect(os.WriteFile(filepath.Join(dir, "config.yml"), []byte("upstreams: {}"), 0o600)).Should(Succeed())
+		})
+
+		It("should change with the content of the file", func() {
+			path := filepath.Join(dir, "config.yml")
+
+			before, err := Fingerprint(path)
+			Expect(err).Should(Succeed())
+
+			Expect(Fingerprint(path)).Should(Equal(before))
+
+			Expect(os.WriteFile(path, []byte("log: {level: debug}"), 0o600)).Should(Succeed())
+
+			Expect(Fingerprint(path)).ShouldNot(Equal(before))
+		})
+
+		It("should change with the YAML files of a directory", func() {
+			before, err := Fingerprint(dir)
+			Expect(err).Should(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0o600)).Should(Succeed())
+			Expect(Fingerprint(dir)).Should(Equal(before))
+
+			Expect(os.WriteFile(filepath.Join(dir, "log.yaml"), []byte("log: {level: debug}"), 0o600)).Should(Succeed())
+			Expect(Fingerprint(dir)).ShouldNot(Equal(before))
+		})
+
+		It("should fail for a missing file", func() {
+			_, err := Fingerprint(filepath.Join(dir, "missing.yml"))
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CookieSecretLen is the length of the secret of the server cookies in bytes
+const CookieSecretLen = 16
+
+// Cookies configures the DNS cookies (RFC 7873) of the server
+type Cookies struct {
+	Enable bool `yaml:"enable"`
+	// secret of the server cookies, blocky instances sharing an address need the same secret. Random if empty.
+	Secret CookieSecret `yaml:"secret"`
+	// UDP queries of clients sending a cookie without a valid server cookie are answered with BADCOOKIE
+	EnforceUDP bool `yaml:"enforceUDP"`
+}
+
+// CookieSecret hex encoded secret of the server cookies
+type CookieSecret []byte
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (s *CookieSecret) UnmarshalText(data []byte) error {
+	secret, err := hex.DecodeString(string(data))
+	if err != nil {
+		return fmt.Errorf("invalid cookie secret: %w", err)
+	}
+
+	if len(secret) != CookieSecretLen {
+		return fmt.Errorf("invalid cookie secret: expected %d bytes, got %d", CookieSecretLen, len(secret))
+	}
+
+	*s = secret
+
+	return nil
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Cookies) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Cookies) LogConfig(logger *logrus.Entry) {
+	if len(c.Secret) != 0 {
+		logger.Info("secret: configured")
+	} else {
+		logger.Info("secret: random")
+	}
+
+	logger.Infof("enforce UDP: %t", c.EnforceUDP)
+}
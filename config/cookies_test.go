@@ -0,0 +1,74 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Cookies", func() {
+	var cfg Cookies
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[Cookies]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if enabled", func() {
+			cfg.Enable = true
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.EnforceUDP = true
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"secret: random",
+				"enforce UDP: true",
+			))
+		})
+
+		It("should not log the secret", func() {
+			cfg.Secret = CookieSecret("0123456789abcdef")
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("secret: configured"))
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("0123456789abcdef")))
+		})
+	})
+
+	Describe("CookieSecret", func() {
+		It("should parse a hex encoded secret", func() {
+			Expect(yaml.Unmarshal([]byte("secret: 000102030405060708090a0b0c0d0e0f"), &cfg)).Should(Succeed())
+
+			Expect(cfg.Secret).Should(BeEquivalentTo([]byte{
+				0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			}))
+		})
+
+		It("should fail on invalid hex", func() {
+			Expect(yaml.Unmarshal([]byte("secret: xyz"), &cfg)).
+				Should(MatchError(ContainSubstring("invalid cookie secret")))
+		})
+
+		It("should fail on a wrong length", func() {
+			Expect(yaml.Unmarshal([]byte("secret: 0001"), &cfg)).
+				Should(MatchError(ContainSubstring("expected 16 bytes, got 2")))
+		})
+	})
+})
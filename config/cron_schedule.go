@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximum time span to search for the next activation of a schedule
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule is a cron expression with the five fields minute, hour, day of month, month and day of week.
+// Each field supports `*`, values, ranges (`1-5`), steps (`*/15`, `0-30/10`) and lists of them (`1,15`).
+type CronSchedule struct {
+	expr string
+
+	minutes, hours, days, months, weekdays uint64
+	// day of month and day of week are combined with OR, if both are restricted
+	anyDay, anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [...]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 and 7 are sunday
+}
+
+// NewCronSchedule parses the cron expression
+func NewCronSchedule(expr string) (CronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return CronSchedule{}, fmt.Errorf("invalid cron expression '%s': expected %d fields", expr, len(cronFields))
+	}
+
+	var bits [len(cronFields)]uint64
+
+	for i, part := range parts {
+		var err error
+
+		bits[i], err = parseCronField(part, cronFields[i])
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("invalid cron expression '%s': %w", expr, err)
+		}
+	}
+
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return CronSchedule{
+		expr:       strings.Join(parts, " "),
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, def cronField) (uint64, error) {
+	var result uint64
+
+	for _, item := range strings.Split(field, ",") {
+		valueRange, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s' for %s", stepStr, def.name)
+			}
+		}
+
+		start, end := def.min, def.max
+
+		if valueRange != "*" {
+			startStr, endStr, isRange := strings.Cut(valueRange, "-")
+
+			var err error
+
+			start, err = parseCronValue(startStr, def)
+			if err != nil {
+				return 0, err
+			}
+
+			end = start
+
+			switch {
+			case isRange:
+				end, err = parseCronValue(endStr, def)
+				if err != nil {
+					return 0, err
+				}
+			case hasStep:
+				end = def.max
+			}
+
+			if end < start {
+				return 0, fmt.Errorf("invalid range '%s' for %s", valueRange, def.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			result |= 1 << v
+		}
+	}
+
+	return result, nil
+}
+
+func parseCronValue(value string, def cronField) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < def.min || v > def.max {
+		return 0, fmt.Errorf("invalid value '%s' for %s (%d-%d)", value, def.name, def.min, def.max)
+	}
+
+	return v, nil
+}
+
+// IsEnabled returns true if a schedule is defined
+func (c CronSchedule) IsEnabled() bool {
+	return c.expr != ""
+}
+
+// String implements `fmt.Stringer`
+func (c CronSchedule) String() string {
+	return c.expr
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`.
+func (c *CronSchedule) UnmarshalText(data []byte) error {
+	schedule, err := NewCronSchedule(string(data))
+	if err != nil {
+		return err
+	}
+
+	*c = schedule
+
+	return nil
+}
+
+// Next returns the first activation of the schedule after t or the zero time, if there is no activation
+func (c CronSchedule) Next(t time.Time) time.Time {
+	if !c.IsEnabled() {
+		return time.Time{}
+	}
+
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c CronSchedule) matchesDay(t time.Time) bool {
+	day := c.days&(1<<t.Day()) != 0
+	weekday := c.weekdays&(1<<int(t.Weekday())) != 0
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
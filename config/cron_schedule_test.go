@@ -0,0 +1,88 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("CronSchedule", func() {
+	// Wednesday
+	start := time.Date(2024, time.May, 15, 10, 30, 20, 0, time.UTC)
+
+	DescribeTable("Next",
+		func(expr string, expected time.Time) {
+			schedule, err := NewCronSchedule(expr)
+			Expect(err).Should(Succeed())
+
+			Expect(schedule.Next(start)).Should(Equal(expected))
+		},
+		Entry("each minute", "* * * * *",
+			time.Date(2024, time.May, 15, 10, 31, 0, 0, time.UTC)),
+		Entry("each 15 minutes", "*/15 * * * *",
+			time.Date(2024, time.May, 15, 10, 45, 0, 0, time.UTC)),
+		Entry("daily", "0 3 * * *",
+			time.Date(2024, time.May, 16, 3, 0, 0, 0, time.UTC)),
+		Entry("hour range with step", "5 0-12/6 * * *",
+			time.Date(2024, time.May, 15, 12, 5, 0, 0, time.UTC)),
+		Entry("list", "0 9,11 * * *",
+			time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)),
+		Entry("weekly on sunday", "30 2 * * 0",
+			time.Date(2024, time.May, 19, 2, 30, 0, 0, time.UTC)),
+		Entry("sunday as 7", "30 2 * * 7",
+			time.Date(2024, time.May, 19, 2, 30, 0, 0, time.UTC)),
+		Entry("monthly", "0 0 1 * *",
+			time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("yearly", "0 0 1 1 *",
+			time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("day of month or day of week", "0 0 20 * 4",
+			time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC)),
+		Entry("leap day", "0 0 29 2 *",
+			time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("never", "0 0 31 2 *",
+			time.Time{}),
+	)
+
+	It("should return the zero time if not defined", func() {
+		var schedule CronSchedule
+
+		Expect(schedule.IsEnabled()).Should(BeFalse())
+		Expect(schedule.Next(start)).Should(BeZero())
+	})
+
+	DescribeTable("invalid expression",
+		func(expr, errMsg string) {
+			_, err := NewCronSchedule(expr)
+
+			Expect(err).Should(MatchError(ContainSubstring(errMsg)))
+		},
+		Entry("too few fields", "* * * *", "expected 5 fields"),
+		Entry("value out of range", "60 * * * *", "invalid value '60' for minute"),
+		Entry("no number", "* x * * *", "invalid value 'x' for hour"),
+		Entry("invalid range", "* * 5-1 * *", "invalid range '5-1' for day of month"),
+		Entry("invalid step", "*/0 * * * *", "invalid step '0' for minute"),
+	)
+
+	Describe("YAML", func() {
+		It("should parse the expression", func() {
+			var cfg struct {
+				Schedule CronSchedule `yaml:"schedule"`
+			}
+
+			Expect(yaml.Unmarshal([]byte(`schedule: "0  3 * * 1-5"`), &cfg)).Should(Succeed())
+
+			Expect(cfg.Schedule.String()).Should(Equal("0 3 * * 1-5"))
+			Expect(cfg.Schedule.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should fail on invalid expression", func() {
+			var cfg struct {
+				Schedule CronSchedule `yaml:"schedule"`
+			}
+
+			Expect(yaml.Unmarshal([]byte(`schedule: "0 25 * * *"`), &cfg)).ShouldNot(Succeed())
+		})
+	})
+})
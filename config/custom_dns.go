@@ -1,9 +1,17 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
@@ -16,9 +24,14 @@ type CustomDNS struct {
 	CustomTTL           Duration         `default:"1h"   yaml:"customTTL"`
 	Mapping             CustomDNSMapping `yaml:"mapping"`
 	Zone                ZoneFileDNS      `default:""     yaml:"zone"`
+	ZoneLimits          ZoneLimits       `yaml:"zoneLimits"`
 	FilterUnmappedTypes bool             `default:"true" yaml:"filterUnmappedTypes"`
+	ReverseSynthesis    ReverseSynthesis `yaml:"reverseSynthesis"`
 }
 
+// ReverseSynthesisPlaceholder is replaced with the dashed IP in a reverse synthesis template
+const ReverseSynthesisPlaceholder = "{ip}"
+
 type (
 	CustomDNSMapping map[string]CustomDNSEntries
 	CustomDNSEntries []dns.RR
@@ -26,32 +39,182 @@ type (
 	ZoneFileDNS struct {
 		RRs        CustomDNSMapping
 		configPath string
+		limits     ZoneLimits
+	}
+
+	// ZoneLimits limits the parsing of the zone and its included files, so that a malformed zone can't block the
+	// start. 0 disables a limit.
+	ZoneLimits struct {
+		MaxSize    ByteSize `default:"10MiB"  yaml:"maxSize"`
+		MaxRecords uint     `default:"100000" yaml:"maxRecords"`
+		Timeout    Duration `default:"10s"    yaml:"timeout"`
+	}
+
+	// ReverseSynthesis maps subnets to the template used to synthesize PTR answers for IPs without mapping,
+	// ordered from the most to the least specific subnet
+	ReverseSynthesis []ReverseSynthesisEntry
+
+	ReverseSynthesisEntry struct {
+		Subnet   *net.IPNet
+		Template string
 	}
 )
 
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+func (r *ReverseSynthesis) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var input map[string]string
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	result := make(ReverseSynthesis, 0, len(input))
+
+	for cidr, template := range input {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid subnet '%s': %w", cidr, err)
+		}
+
+		if !strings.Contains(template, ReverseSynthesisPlaceholder) {
+			return fmt.Errorf("template '%s' for subnet '%s' must contain %s", template, cidr, ReverseSynthesisPlaceholder)
+		}
+
+		result = append(result, ReverseSynthesisEntry{Subnet: subnet, Template: strings.TrimSuffix(template, ".")})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		iOnes, _ := result[i].Subnet.Mask.Size()
+		jOnes, _ := result[j].Subnet.Mask.Size()
+
+		if iOnes != jOnes {
+			return iOnes > jOnes
+		}
+
+		return bytes.Compare(result[i].Subnet.IP, result[j].Subnet.IP) < 0
+	})
+
+	*r = result
+
+	return nil
+}
+
+// HostName returns the synthesized host name for the IP or false if no subnet contains it
+func (r ReverseSynthesis) HostName(ip net.IP) (string, bool) {
+	for _, entry := range r {
+		if !entry.Subnet.Contains(ip) {
+			continue
+		}
+
+		dashed := strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())
+
+		return strings.ReplaceAll(entry.Template, ReverseSynthesisPlaceholder, dashed), true
+	}
+
+	return "", false
+}
+
+// UnmarshalYAML decodes the zone limits first, since the zone is parsed while it's decoded
+func (c *CustomDNS) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	limits := struct {
+		ZoneLimits ZoneLimits             `yaml:"zoneLimits"`
+		Other      map[string]interface{} `yaml:",inline"`
+	}{ZoneLimits: c.ZoneLimits}
+
+	if err := unmarshal(&limits); err != nil {
+		return err
+	}
+
+	c.Zone.limits = limits.ZoneLimits
+
+	// customDNS is used to avoid infinite recursion
+	type customDNS CustomDNS
+
+	return unmarshal((*customDNS)(c))
+}
+
+// errZoneTimeout stops the reading of the included files after the timeout
+var errZoneTimeout = errors.New("zone parsing timed out")
+
 func (z *ZoneFileDNS) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var input string
 	if err := unmarshal(&input); err != nil {
 		return err
 	}
 
+	budget := &zoneBudget{maxSize: z.limits.MaxSize}
+	if err := budget.consume(len(input)); err != nil {
+		return err
+	}
+
+	if !z.limits.Timeout.IsAboveZero() {
+		rrs, err := z.parse(input, budget)
+		if err != nil {
+			return err
+		}
+
+		z.RRs = rrs
+
+		return nil
+	}
+
+	type parseResult struct {
+		rrs CustomDNSMapping
+		err error
+	}
+
+	done := make(chan parseResult, 1)
+
+	// a blocking $INCLUDE (e.g. a FIFO) can't be interrupted, so the parsing is abandoned after the timeout
+	go func() {
+		rrs, err := z.parse(input, budget)
+		done <- parseResult{rrs, err}
+	}()
+
+	timer := time.NewTimer(z.limits.Timeout.ToDuration())
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return res.err
+		}
+
+		z.RRs = res.rrs
+
+		return nil
+	case <-timer.C:
+		budget.timedOut.Store(true)
+
+		return fmt.Errorf("%w after %s, check the files of $INCLUDE", errZoneTimeout, z.limits.Timeout)
+	}
+}
+
+func (z *ZoneFileDNS) parse(input string, budget *zoneBudget) (CustomDNSMapping, error) {
 	result := make(CustomDNSMapping)
 
-	zoneParser := dns.NewZoneParser(strings.NewReader(input), "", z.configPath)
+	zoneParser := dns.NewZoneParser(strings.NewReader(input), "", zoneFilePath(z.configPath))
 	zoneParser.SetIncludeAllowed(true)
+	zoneParser.SetIncludeFS(zoneIncludeFS{budget: budget})
+
+	var count uint
 
 	for {
 		zoneRR, ok := zoneParser.Next()
 
 		if !ok {
 			if zoneParser.Err() != nil {
-				return zoneParser.Err()
+				return nil, zoneParser.Err()
 			}
 
 			// Done
 			break
 		}
 
+		count++
+		if z.limits.MaxRecords > 0 && count > z.limits.MaxRecords {
+			return nil, fmt.Errorf("zone has more than %d records", z.limits.MaxRecords)
+		}
+
 		domain := zoneRR.Header().Name
 
 		if _, ok := result[domain]; !ok {
@@ -61,11 +224,77 @@ func (z *ZoneFileDNS) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		result[domain] = append(result[domain], zoneRR)
 	}
 
-	z.RRs = result
+	return result, nil
+}
+
+// zoneFilePath returns the slash separated absolute path, which the paths of $INCLUDE are relative to.
+// Without configuration file, they are relative to the working directory.
+func zoneFilePath(configPath string) string {
+	abs, err := filepath.Abs(configPath)
+	if err != nil {
+		return configPath
+	}
+
+	if configPath == "" {
+		abs += string(filepath.Separator)
+	}
+
+	return filepath.ToSlash(abs)
+}
+
+// zoneBudget counts the bytes of the zone and its included files
+type zoneBudget struct {
+	maxSize  ByteSize
+	used     atomic.Uint64
+	timedOut atomic.Bool
+}
+
+func (b *zoneBudget) consume(n int) error {
+	if b.timedOut.Load() {
+		return errZoneTimeout
+	}
+
+	if used := b.used.Add(uint64(n)); b.maxSize > 0 && ByteSize(used) > b.maxSize {
+		return fmt.Errorf("zone and included files exceed the maximum size of %s", b.maxSize)
+	}
 
 	return nil
 }
 
+// zoneIncludeFS opens the files of $INCLUDE, their content counts towards the maximum size of the zone
+type zoneIncludeFS struct {
+	budget *zoneBudget
+}
+
+// Open implements `fs.FS`. The zone parser passes absolute paths without leading slash.
+func (f zoneIncludeFS) Open(name string) (fs.File, error) {
+	if filepath.VolumeName(name) == "" {
+		name = "/" + name
+	}
+
+	file, err := os.Open(filepath.FromSlash(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zoneIncludeFile{File: file, budget: f.budget}, nil
+}
+
+type zoneIncludeFile struct {
+	*os.File
+
+	budget *zoneBudget
+}
+
+func (f *zoneIncludeFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	if budgetErr := f.budget.consume(n); budgetErr != nil {
+		return n, budgetErr
+	}
+
+	return n, err
+}
+
 func (c *CustomDNSEntries) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var input string
 	if err := unmarshal(&input); err != nil {
@@ -91,7 +320,7 @@ func (c *CustomDNSEntries) UnmarshalYAML(unmarshal func(interface{}) error) erro
 
 // IsEnabled implements `config.Configurable`.
 func (c *CustomDNS) IsEnabled() bool {
-	return len(c.Mapping) != 0
+	return len(c.Mapping) != 0 || len(c.ReverseSynthesis) != 0
 }
 
 // LogConfig implements `config.Configurable`.
@@ -104,6 +333,14 @@ func (c *CustomDNS) LogConfig(logger *logrus.Entry) {
 	for key, val := range c.Mapping {
 		logger.Infof("  %s = %s", key, val)
 	}
+
+	if len(c.ReverseSynthesis) != 0 {
+		logger.Info("reverseSynthesis:")
+
+		for _, entry := range c.ReverseSynthesis {
+			logger.Infof("  %s = %s", entry.Subnet, entry.Template)
+		}
+	}
 }
 
 func configToRR(ipStr string) (dns.RR, error) {
@@ -2,14 +2,20 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"os"
+	"runtime"
 	"strings"
+	"testing"
+	"time"
 
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/creasty/defaults"
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("CustomDNSConfig", func() {
@@ -232,4 +238,169 @@ www A 1.2.3.4
 			Expect(err).Should(MatchError("Failed to unmarshal"))
 		})
 	})
+
+	Describe("Zone limits", func() {
+		unmarshalZone := func(z *ZoneFileDNS, zone string) error {
+			return z.UnmarshalYAML(func(i interface{}) error {
+				*i.(*string) = zone
+
+				return nil
+			})
+		}
+
+		It("should reject a zone bigger than the maximum size", func() {
+			z := ZoneFileDNS{limits: ZoneLimits{MaxSize: 20}}
+
+			err := unmarshalZone(&z, "$ORIGIN example.com.\nwww 3600 A 1.2.3.4")
+			Expect(err).Should(MatchError(ContainSubstring("maximum size of 20 B")))
+		})
+
+		It("should count the included files towards the maximum size", func() {
+			folder := NewTmpFolder("zones")
+			file := folder.CreateStringFile("other.zone", strings.Repeat("www 3600 A 1.2.3.4\n", 10))
+
+			z := ZoneFileDNS{limits: ZoneLimits{MaxSize: 100}}
+
+			err := unmarshalZone(&z, "$ORIGIN example.com.\n$INCLUDE "+file.Path)
+			Expect(err).Should(MatchError(ContainSubstring("maximum size of 100 B")))
+		})
+
+		It("should reject a zone with more records than allowed", func() {
+			z := ZoneFileDNS{limits: ZoneLimits{MaxRecords: 10}}
+
+			err := unmarshalZone(&z, "$ORIGIN example.com.\n$GENERATE 1-100 host$ 3600 A 192.0.2.$")
+			Expect(err).Should(MatchError("zone has more than 10 records"))
+		})
+
+		It("should abandon a blocking $INCLUDE after the timeout", func() {
+			if runtime.GOOS == "windows" {
+				Skip("no file path for pipes")
+			}
+
+			r, w, err := os.Pipe()
+			Expect(err).Should(Succeed())
+			DeferCleanup(r.Close)
+			DeferCleanup(w.Close)
+
+			z := ZoneFileDNS{limits: ZoneLimits{Timeout: Duration(50 * time.Millisecond)}}
+
+			err = unmarshalZone(&z, fmt.Sprintf("$ORIGIN example.com.\n$INCLUDE /dev/fd/%d", r.Fd()))
+			Expect(err).Should(MatchError(errZoneTimeout))
+			Expect(z.RRs).Should(BeNil())
+		})
+
+		It("should apply the configured limits independent of their position", func() {
+			var c CustomDNS
+
+			Expect(defaults.Set(&c)).Should(Succeed())
+
+			err := yaml.UnmarshalStrict([]byte(`
+zone: |
+  $ORIGIN example.com.
+  www 3600 A 1.2.3.4
+  www 3600 A 1.2.3.5
+zoneLimits:
+  maxRecords: 1
+`), &c)
+			Expect(err).Should(MatchError(ContainSubstring("zone has more than 1 records")))
+		})
+
+		It("should apply the default limits", func() {
+			var c CustomDNS
+
+			Expect(defaults.Set(&c)).Should(Succeed())
+			Expect(yaml.UnmarshalStrict([]byte("zone: \"$ORIGIN example.com.\\nwww 3600 A 1.2.3.4\""), &c)).
+				Should(Succeed())
+			Expect(c.Zone.RRs).Should(HaveLen(1))
+			Expect(c.ZoneLimits).Should(Equal(ZoneLimits{
+				MaxSize:    10 << 20,
+				MaxRecords: 100000,
+				Timeout:    Duration(10 * time.Second),
+			}))
+		})
+	})
+
+	Describe("ReverseSynthesis UnmarshalYAML", func() {
+		It("should parse subnets ordered by specificity", func() {
+			var r ReverseSynthesis
+
+			Expect(yaml.Unmarshal([]byte(`
+192.168.0.0/16: "{ip}.lan"
+192.168.1.0/24: "{ip}.dhcp.lan."
+"fd00::/8": "{ip}.v6.lan"
+`), &r)).Should(Succeed())
+
+			Expect(r).Should(HaveLen(3))
+			Expect(r[0].Subnet.String()).Should(Equal("192.168.1.0/24"))
+			Expect(r[0].Template).Should(Equal("{ip}.dhcp.lan"))
+			Expect(r[1].Subnet.String()).Should(Equal("192.168.0.0/16"))
+			Expect(r[2].Subnet.String()).Should(Equal("fd00::/8"))
+		})
+
+		It("should fail on invalid subnets", func() {
+			var r ReverseSynthesis
+
+			Expect(yaml.Unmarshal([]byte(`192.168.1.1: "{ip}.lan"`), &r)).
+				Should(MatchError(ContainSubstring("invalid subnet '192.168.1.1'")))
+		})
+
+		It("should fail on templates without placeholder", func() {
+			var r ReverseSynthesis
+
+			Expect(yaml.Unmarshal([]byte(`192.168.1.0/24: host.lan`), &r)).
+				Should(MatchError(ContainSubstring("must contain {ip}")))
+		})
+	})
+
+	Describe("ReverseSynthesis HostName", func() {
+		var r ReverseSynthesis
+
+		BeforeEach(func() {
+			Expect(yaml.Unmarshal([]byte(`
+192.168.0.0/16: "{ip}.lan"
+192.168.1.0/24: "{ip}.dhcp.lan"
+"fd00::/8": "{ip}.v6.lan"
+`), &r)).Should(Succeed())
+		})
+
+		It("should use the most specific subnet", func() {
+			name, found := r.HostName(net.ParseIP("192.168.1.77"))
+			Expect(found).Should(BeTrue())
+			Expect(name).Should(Equal("192-168-1-77.dhcp.lan"))
+
+			name, found = r.HostName(net.ParseIP("192.168.2.77"))
+			Expect(found).Should(BeTrue())
+			Expect(name).Should(Equal("192-168-2-77.lan"))
+		})
+
+		It("should dash IPv6 addresses", func() {
+			name, found := r.HostName(net.ParseIP("fd00::1"))
+			Expect(found).Should(BeTrue())
+			Expect(name).Should(Equal("fd00--1.v6.lan"))
+		})
+
+		It("should not match IPs outside the subnets", func() {
+			_, found := r.HostName(net.ParseIP("10.0.0.1"))
+			Expect(found).Should(BeFalse())
+		})
+	})
 })
+
+// FuzzZoneFileDNS ensures that malformed zones neither panic nor block the start
+func FuzzZoneFileDNS(f *testing.F) {
+	f.Add("$ORIGIN example.com.\nwww 3600 A 1.2.3.4\ncname 3600 CNAME www")
+	f.Add("$TTL 3600\n$ORIGIN example.com.\n@ IN SOA ns hostmaster 1 2 3 4 5\n@ HTTPS 1 . alpn=h2")
+	f.Add("$ORIGIN example.com.\n$GENERATE 1-65535 host$ 3600 A 192.0.2.1")
+	f.Add("$INCLUDE /dev/zero")
+	f.Add("www A 1.2.3.4\n(\n\"unterminated")
+
+	f.Fuzz(func(t *testing.T, zone string) {
+		z := ZoneFileDNS{limits: ZoneLimits{MaxSize: 1 << 16, MaxRecords: 1000, Timeout: Duration(time.Second)}}
+
+		_ = z.UnmarshalYAML(func(i interface{}) error {
+			*i.(*string) = zone
+
+			return nil
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DoHEndpoint is an additional DoH endpoint on the HTTP(S) listeners, which is selected by its path and/or by the
+// host name of the request (Host header or SNI). The queries of the endpoint get the tag as client tag.
+type DoHEndpoint struct {
+	Path string `yaml:"path"`
+	Host string `yaml:"host"`
+	Tag  string `yaml:"tag"`
+}
+
+func (e DoHEndpoint) String() string {
+	var parts []string
+
+	if e.Host != "" {
+		parts = append(parts, "host "+e.Host)
+	}
+
+	if e.Path != "" {
+		parts = append(parts, "path "+e.Path)
+	}
+
+	return fmt.Sprintf("%s -> tag:%s", strings.Join(parts, ", "), e.Tag)
+}
+
+// validate removes the invalid endpoints and normalizes the host names
+func (c *Ports) validate(logger *logrus.Entry) {
+	endpoints := make([]DoHEndpoint, 0, len(c.DoHEndpoints))
+
+	for i, endpoint := range c.DoHEndpoints {
+		endpoint.Host = strings.ToLower(strings.TrimSuffix(endpoint.Host, "."))
+
+		switch {
+		case endpoint.Tag == "":
+			logger.Warnf("ports.dohEndpoints[%d]: tag is empty, the endpoint is ignored", i)
+
+			continue
+		case endpoint.Path == "" && endpoint.Host == "":
+			logger.Warnf("ports.dohEndpoints[%d]: path and host are empty, the endpoint is ignored", i)
+
+			continue
+		case endpoint.Path != "" && !strings.HasPrefix(endpoint.Path, "/"):
+			logger.Warnf("ports.dohEndpoints[%d]: path '%s' doesn't start with '/', the endpoint is ignored",
+				i, endpoint.Path)
+
+			continue
+		case endpoint.Path == c.DOHPath && endpoint.Host == "":
+			logger.Warnf("ports.dohEndpoints[%d]: path '%s' is the default DoH path, the endpoint needs a host",
+				i, endpoint.Path)
+
+			continue
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+
+	c.DoHEndpoints = endpoints
+}
+
+// DoHPaths returns the default DoH path and the paths of the additional endpoints
+func (c *Ports) DoHPaths() []string {
+	paths := []string{c.DOHPath}
+
+	for _, endpoint := range c.DoHEndpoints {
+		if endpoint.Path != "" && !slices.Contains(paths, endpoint.Path) {
+			paths = append(paths, endpoint.Path)
+		}
+	}
+
+	return paths
+}
+
+// DoHEndpointTag returns the tag of the endpoint for the path and the host of a request. An endpoint with host and
+// path takes precedence over an endpoint with host or path only. Returns false, if no endpoint matches.
+func (c *Ports) DoHEndpointTag(path, host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	var (
+		tag   string
+		score int
+	)
+
+	for _, endpoint := range c.DoHEndpoints {
+		endpointPath := endpoint.Path
+		if endpointPath == "" {
+			endpointPath = c.DOHPath
+		}
+
+		if endpointPath != path || (endpoint.Host != "" && endpoint.Host != host) {
+			continue
+		}
+
+		current := 1
+		if endpoint.Host != "" {
+			current++
+		}
+
+		if endpoint.Path != "" {
+			current++
+		}
+
+		if current > score {
+			tag, score = endpoint.Tag, current
+		}
+	}
+
+	return tag, score != 0
+}
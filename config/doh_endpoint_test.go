@@ -0,0 +1,71 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DoHEndpoint", func() {
+	var cfg Ports
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = Ports{
+			DOHPath: "/dns-query",
+			DoHEndpoints: []DoHEndpoint{
+				{Path: "/dns-query-kids", Tag: "kids"},
+				{Host: "Kids.DNS.example.com.", Tag: "kids"},
+				{Path: "/dns-query-kids", Host: "guest.dns.example.com", Tag: "guests"},
+			},
+		}
+	})
+
+	Describe("validate", func() {
+		It("should remove invalid endpoints", func() {
+			cfg.DoHEndpoints = append(cfg.DoHEndpoints,
+				DoHEndpoint{Path: "/dns-query-iot"},
+				DoHEndpoint{Tag: "iot"},
+				DoHEndpoint{Path: "dns-query-iot", Tag: "iot"},
+				DoHEndpoint{Path: "/dns-query", Tag: "iot"},
+			)
+
+			cfg.validate(logger)
+
+			Expect(cfg.DoHEndpoints).Should(HaveLen(3))
+			Expect(cfg.DoHEndpoints[1].Host).Should(Equal("kids.dns.example.com"))
+			Expect(hook.Messages).Should(ContainElements(
+				"ports.dohEndpoints[3]: tag is empty, the endpoint is ignored",
+				"ports.dohEndpoints[4]: path and host are empty, the endpoint is ignored",
+				"ports.dohEndpoints[5]: path 'dns-query-iot' doesn't start with '/', the endpoint is ignored",
+				"ports.dohEndpoints[6]: path '/dns-query' is the default DoH path, the endpoint needs a host",
+			))
+		})
+	})
+
+	Describe("DoHPaths", func() {
+		It("should return the default and the additional paths", func() {
+			Expect(cfg.DoHPaths()).Should(Equal([]string{"/dns-query", "/dns-query-kids"}))
+		})
+	})
+
+	DescribeTable("DoHEndpointTag",
+		func(path, host, want string) {
+			cfg.validate(logger)
+
+			tag, found := cfg.DoHEndpointTag(path, host)
+			Expect(found).Should(Equal(want != ""))
+			Expect(tag).Should(Equal(want))
+		},
+		Entry("path", "/dns-query-kids", "blocky.example.com", "kids"),
+		Entry("host on the default path", "/dns-query", "KIDS.dns.example.com", "kids"),
+		Entry("host and path take precedence", "/dns-query-kids", "guest.dns.example.com", "guests"),
+		Entry("host on another path", "/dns-query-other", "kids.dns.example.com", ""),
+		Entry("default endpoint", "/dns-query", "blocky.example.com", ""),
+	)
+
+	It("should describe the endpoint", func() {
+		Expect(cfg.DoHEndpoints[2].String()).
+			Should(Equal("host guest.dns.example.com, path /dns-query-kids -> tag:guests"))
+	})
+})
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// EDE configuration of the extended DNS errors (RFC 8914) in the responses
+type EDE struct {
+	Enable      bool           `default:"false"   yaml:"enable"`
+	BlockedCode EDEBlockedCode `default:"blocked" yaml:"blockedCode"`
+	HideReason  bool           `default:"false"   yaml:"hideReason"`
+	BlockedOnly bool           `default:"false"   yaml:"blockedOnly"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *EDE) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *EDE) LogConfig(logger *logrus.Entry) {
+	logger.Infof("blocked code = %s (%d)", c.BlockedCode, c.BlockedCode)
+	logger.Infof("hide reason = %t", c.HideReason)
+	logger.Infof("blocked only = %t", c.BlockedOnly)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EDE", func() {
+	var c EDE
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = EDE{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled", func() {
+			c.Enable = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.BlockedCode = EDEBlockedCodeFiltered
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"blocked code = filtered (17)",
+				"hide reason = false",
+				"blocked only = false",
+			))
+		})
+	})
+
+	It("should use the blocked code by default", func() {
+		Expect(c.BlockedCode).Should(Equal(EDEBlockedCodeBlocked))
+		Expect(uint16(c.BlockedCode)).Should(BeNumerically("==", 15))
+	})
+})
@@ -1,16 +1,73 @@
 package config
 
 import (
+	"strings"
+
+	"github.com/0xERR0R/blocky/log"
 	"github.com/sirupsen/logrus"
 )
 
 type Filtering struct {
-	QueryTypes QTypeSet `yaml:"queryTypes"`
+	QueryTypes   QTypeSet            `yaml:"queryTypes"`
+	ClientGroups map[string]QTypeSet `yaml:"clientGroups"`
+	StripECH     bool                `yaml:"stripECH"`
+	FilterAAAA   FilterAAAA          `yaml:"filterAAAA"`
+}
+
+// FilterAAAA removes the AAAA answers for clients without working IPv6 connectivity
+type FilterAAAA struct {
+	Mode FilterAAAAMode `default:"off" yaml:"mode"`
+	// mode per client group, overrides the mode for the clients of the group
+	ClientGroups map[string]FilterAAAAMode `yaml:"clientGroups"`
+	// if set, only the answers of these domains and their subdomains are removed
+	Domains []string `yaml:"domains"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *FilterAAAA) IsEnabled() bool {
+	if c.Mode != FilterAAAAModeOff {
+		return true
+	}
+
+	for _, mode := range c.ClientGroups {
+		if mode != FilterAAAAModeOff {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *FilterAAAA) LogConfig(logger *logrus.Entry) {
+	logger.Infof("mode = %s", c.Mode)
+
+	if len(c.ClientGroups) != 0 {
+		logger.Info("client groups:")
+
+		for group, mode := range c.ClientGroups {
+			logger.Infof("  %s = %s", group, mode)
+		}
+	}
+
+	if len(c.Domains) != 0 {
+		logger.Infof("domains: %s", strings.Join(c.Domains, ", "))
+	}
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *Filtering) IsEnabled() bool {
-	return len(c.QueryTypes) != 0
+	if len(c.QueryTypes) != 0 || c.StripECH || c.FilterAAAA.IsEnabled() {
+		return true
+	}
+
+	for _, qTypes := range c.ClientGroups {
+		if len(qTypes) != 0 {
+			return true
+		}
+	}
+
+	return false
 }
 
 // LogConfig implements `config.Configurable`.
@@ -20,4 +77,25 @@ func (c *Filtering) LogConfig(logger *logrus.Entry) {
 	for qType := range c.QueryTypes {
 		logger.Infof("  - %s", qType)
 	}
+
+	logger.Infof("strip ECH = %t", c.StripECH)
+
+	if c.FilterAAAA.IsEnabled() {
+		logger.Info("filter AAAA:")
+		log.WithIndent(logger, "  ", c.FilterAAAA.LogConfig)
+	}
+
+	if len(c.ClientGroups) == 0 {
+		return
+	}
+
+	logger.Info("client groups:")
+
+	for group, qTypes := range c.ClientGroups {
+		logger.Infof("  %s:", group)
+
+		for qType := range qTypes {
+			logger.Infof("    - %s", qType)
+		}
+	}
 }
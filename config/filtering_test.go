@@ -33,6 +33,36 @@ var _ = Describe("FilteringConfig", func() {
 			})
 		})
 
+		When("only client groups are defined", func() {
+			It("should be true", func() {
+				cfg := Filtering{ClientGroups: map[string]QTypeSet{"iot": NewQTypeSet(AAAA)}}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+
+		When("only ECH stripping is enabled", func() {
+			It("should be true", func() {
+				cfg := Filtering{StripECH: true}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+
+		When("only AAAA filtering is enabled", func() {
+			It("should be true", func() {
+				cfg := Filtering{FilterAAAA: FilterAAAA{Mode: FilterAAAAModeOnV4}}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+
+				cfg = Filtering{FilterAAAA: FilterAAAA{
+					ClientGroups: map[string]FilterAAAAMode{"tv*": FilterAAAAModeAlways},
+				}}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+
 		When("disabled", func() {
 			It("should be false", func() {
 				cfg := Filtering{}
@@ -46,11 +76,42 @@ var _ = Describe("FilteringConfig", func() {
 		It("should log configuration", func() {
 			cfg.LogConfig(logger)
 
-			Expect(hook.Calls).Should(HaveLen(3))
+			Expect(hook.Calls).Should(HaveLen(4))
 			Expect(hook.Messages).Should(ContainElements(
 				ContainSubstring("query types:"),
 				ContainSubstring("  - AAAA"),
 				ContainSubstring("  - MX"),
+				ContainSubstring("strip ECH = false"),
+			))
+		})
+
+		It("should log client groups", func() {
+			cfg.ClientGroups = map[string]QTypeSet{"192.168.20.0/24": NewQTypeSet(AAAA)}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("client groups:"),
+				ContainSubstring("  192.168.20.0/24:"),
+				ContainSubstring("    - AAAA"),
+			))
+		})
+
+		It("should log the AAAA filtering", func() {
+			cfg.FilterAAAA = FilterAAAA{
+				Mode:         FilterAAAAModeOnV4,
+				ClientGroups: map[string]FilterAAAAMode{"tv*": FilterAAAAModeAlways},
+				Domains:      []string{"netflix.com", "youtube.com"},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"filter AAAA:",
+				"mode = onV4",
+				"client groups:",
+				"  tv* = always",
+				"domains: netflix.com, youtube.com",
 			))
 		})
 	})
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// FQDNOnly configuration to reject queries for single-label names
+type FQDNOnly struct {
+	Enable bool `default:"false" yaml:"enable"`
+	// overrides `Enable` for the clients of the groups
+	ClientGroups map[string]bool `yaml:"clientGroups"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *FQDNOnly) IsEnabled() bool {
+	if c.Enable {
+		return true
+	}
+
+	for _, enable := range c.ClientGroups {
+		if enable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *FQDNOnly) LogConfig(logger *logrus.Entry) {
+	logger.Infof("enable = %t", c.Enable)
+
+	if len(c.ClientGroups) == 0 {
+		return
+	}
+
+	logger.Info("client groups:")
+
+	for group, enable := range c.ClientGroups {
+		logger.Infof("  %s = %t", group, enable)
+	}
+}
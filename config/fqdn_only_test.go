@@ -0,0 +1,56 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FQDNOnlyConfig", func() {
+	var cfg FQDNOnly
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = FQDNOnly{
+			Enable:       false,
+			ClientGroups: map[string]bool{"guest": true, "lab": false},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg, err := WithDefaults[FQDNOnly]()
+			Expect(err).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		When("enabled for a client group", func() {
+			It("should be true", func() {
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
+
+		When("only disabled for client groups", func() {
+			It("should be false", func() {
+				cfg.ClientGroups = map[string]bool{"lab": false}
+
+				Expect(cfg.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+			Expect(hook.Messages).Should(ContainElements(
+				"enable = false",
+				"client groups:",
+				"  guest = true",
+				"  lab = false",
+			))
+		})
+	})
+})
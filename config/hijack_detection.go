@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// HijackDetection configuration of the NXDOMAIN hijack detection: random, nonexistent domains are queried
+// periodically via each upstream, an upstream answering them with records is considered as hijacking
+type HijackDetection struct {
+	Enable   bool     `default:"false" yaml:"enable"`
+	Interval Duration `default:"1h"    yaml:"interval"`
+	Canaries uint     `default:"2"     yaml:"canaries"`
+	Disable  bool     `default:"false" yaml:"disable"`
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *HijackDetection) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`
+func (c *HijackDetection) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interval: %s", c.Interval)
+	logger.Infof("canaries: %d", c.Canaries)
+	logger.Infof("disable hijacking upstreams: %t", c.Disable)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HijackDetection", func() {
+	var c HijackDetection
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = HijackDetection{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled", func() {
+			c.Enable = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+		})
+
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(Equal("interval: 1 hour")),
+				ContainElement(Equal("canaries: 2")),
+				ContainElement(Equal("disable hijacking upstreams: false")),
+			))
+		})
+	})
+})
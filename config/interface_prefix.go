@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// interfacePrefixTag is the prefix of client identifiers relative to the IPv6 prefixes of a network interface
+const interfacePrefixTag = "if:"
+
+// InterfacePrefix is a client identifier `if:<interface>[/<suffix>/<length>]`, which follows the IPv6 prefixes of a
+// network interface: without suffix it contains all addresses of the prefixes, with suffix the suffix replaces the
+// bits behind the prefix, e.g. `if:eth0/::10/124` contains the addresses `<prefix>::10` to `<prefix>::1f`.
+type InterfacePrefix struct {
+	Interface string
+	Suffix    *net.IPNet
+}
+
+// ParseInterfacePrefix parses a client identifier with the prefix `if:`.
+// Returns false, if the identifier doesn't refer to an interface.
+func ParseInterfacePrefix(identifier string) (InterfacePrefix, bool, error) {
+	rest, found := strings.CutPrefix(identifier, interfacePrefixTag)
+	if !found {
+		return InterfacePrefix{}, false, nil
+	}
+
+	name, suffix, hasSuffix := strings.Cut(rest, "/")
+	if name == "" {
+		return InterfacePrefix{}, true, fmt.Errorf("missing interface in '%s'", identifier)
+	}
+
+	result := InterfacePrefix{Interface: name}
+
+	if hasSuffix {
+		ip, ipNet, err := net.ParseCIDR(suffix)
+		if err != nil || ip.To4() != nil {
+			return InterfacePrefix{}, true, fmt.Errorf("invalid IPv6 suffix '%s' in '%s'", suffix, identifier)
+		}
+
+		result.Suffix = ipNet
+	}
+
+	return result, true, nil
+}
+
+// Network returns the network of the identifier within the prefix of the interface.
+// Returns false, if the suffix is shorter than the prefix.
+func (p InterfacePrefix) Network(prefix net.IPNet) (net.IPNet, bool) {
+	if p.Suffix == nil {
+		return prefix, true
+	}
+
+	prefixLen, _ := prefix.Mask.Size()
+	suffixLen, _ := p.Suffix.Mask.Size()
+
+	if suffixLen < prefixLen {
+		return net.IPNet{}, false
+	}
+
+	base := prefix.IP.To16()
+	ip := make(net.IP, net.IPv6len)
+
+	for i := range ip {
+		ip[i] = (base[i] & prefix.Mask[i]) | (p.Suffix.IP[i] &^ prefix.Mask[i])
+	}
+
+	return net.IPNet{IP: ip, Mask: p.Suffix.Mask}, true
+}
+
+// InterfacePrefixes returns the identifiers of all client tags, which refer to the prefixes of an interface
+func (c *ClientLookup) InterfacePrefixes() []InterfacePrefix {
+	var result []InterfacePrefix
+
+	for _, clients := range c.Tags {
+		for _, client := range clients {
+			if prefix, ok, err := ParseInterfacePrefix(client); ok && err == nil {
+				result = append(result, prefix)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InterfacePrefix", func() {
+	suiteBeforeEach()
+
+	DescribeTable("ParseInterfacePrefix",
+		func(identifier, wantInterface, wantSuffix string) {
+			prefix, ok, err := ParseInterfacePrefix(identifier)
+			Expect(err).Should(Succeed())
+			Expect(ok).Should(BeTrue())
+			Expect(prefix.Interface).Should(Equal(wantInterface))
+
+			if wantSuffix == "" {
+				Expect(prefix.Suffix).Should(BeNil())
+			} else {
+				Expect(prefix.Suffix.String()).Should(Equal(wantSuffix))
+			}
+		},
+		Entry("whole prefix", "if:eth0", "eth0", ""),
+		Entry("host suffix", "if:br-lan/::10/128", "br-lan", "::10/128"),
+		Entry("suffix range", "if:eth0/::1:0/112", "eth0", "::1:0/112"),
+	)
+
+	DescribeTable("invalid identifiers",
+		func(identifier, wantErr string) {
+			_, ok, err := ParseInterfacePrefix(identifier)
+			Expect(ok).Should(BeTrue())
+			Expect(err).Should(MatchError(ContainSubstring(wantErr)))
+		},
+		Entry("missing interface", "if:/::1/128", "missing interface"),
+		Entry("missing length", "if:eth0/::1", "invalid IPv6 suffix '::1'"),
+		Entry("IPv4 suffix", "if:eth0/0.0.0.1/32", "invalid IPv6 suffix"),
+	)
+
+	It("should ignore other identifiers", func() {
+		_, ok, err := ParseInterfacePrefix("192.168.178.0/24")
+		Expect(ok).Should(BeFalse())
+		Expect(err).Should(Succeed())
+	})
+
+	DescribeTable("Network",
+		func(identifier, prefix, want string) {
+			p, _, err := ParseInterfacePrefix(identifier)
+			Expect(err).Should(Succeed())
+
+			_, prefixNet, err := net.ParseCIDR(prefix)
+			Expect(err).Should(Succeed())
+
+			network, ok := p.Network(*prefixNet)
+			if want == "" {
+				Expect(ok).Should(BeFalse())
+			} else {
+				Expect(ok).Should(BeTrue())
+				Expect(network.String()).Should(Equal(want))
+			}
+		},
+		Entry("whole prefix", "if:eth0", "2001:db8:1:2::/64", "2001:db8:1:2::/64"),
+		Entry("host", "if:eth0/::10/128", "2001:db8:1:2::/64", "2001:db8:1:2::10/128"),
+		Entry("suffix bits of the prefix are ignored", "if:eth0/ffff::1:0/112", "2001:db8:1:2::/64",
+			"2001:db8:1:2::1:0/112"),
+		Entry("suffix shorter than the prefix", "if:eth0/::/48", "2001:db8:1:2::/64", ""),
+	)
+
+	Describe("ClientLookup", func() {
+		It("should validate the interface identifiers of the tags", func() {
+			c := ClientLookup{Tags: map[string][]string{"lan": {"if:eth0", "if:eth0/::1"}}}
+
+			c.validate(logger)
+
+			Expect(c.InterfacePrefixes()).Should(HaveLen(1))
+			Expect(c.PrefixRefreshPeriod).Should(Equal(mustDefault[ClientLookup]().PrefixRefreshPeriod))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("clientLookup.tags.lan: invalid IPv6 suffix '::1'"),
+				ContainSubstring("clientLookup.prefixRefreshPeriod <= 0"),
+			))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IPRewrite maps resolved IPs to other IPs in the responses (DNS doctoring)
+type IPRewrite struct {
+	// Mapping of the resolved IP (normalized string) to the IP in the response
+	Mapping map[string]net.IP
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *IPRewrite) IsEnabled() bool {
+	return len(c.Mapping) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *IPRewrite) LogConfig(logger *logrus.Entry) {
+	for from, to := range c.Mapping {
+		logger.Infof("%s = %s", from, to)
+	}
+}
+
+// UnmarshalYAML implements `yaml.Unmarshaler`.
+func (c *IPRewrite) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var input map[string]string
+	if err := unmarshal(&input); err != nil {
+		return err
+	}
+
+	result := make(map[string]net.IP, len(input))
+
+	for k, v := range input {
+		from := net.ParseIP(k)
+		if from == nil {
+			return fmt.Errorf("invalid IP address '%s'", k)
+		}
+
+		to := net.ParseIP(v)
+		if to == nil {
+			return fmt.Errorf("invalid IP address '%s'", v)
+		}
+
+		if (from.To4() == nil) != (to.To4() == nil) {
+			return fmt.Errorf("can't rewrite '%s' to '%s': IP versions differ", k, v)
+		}
+
+		result[from.String()] = to
+	}
+
+	c.Mapping = result
+
+	return nil
+}
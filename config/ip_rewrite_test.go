@@ -0,0 +1,78 @@
+package config
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("IPRewrite", func() {
+	var cfg IPRewrite
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = IPRewrite{
+			Mapping: map[string]net.IP{"203.0.113.10": net.ParseIP("192.168.1.10")},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := IPRewrite{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with mapping", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("203.0.113.10 = 192.168.1.10"))
+		})
+	})
+
+	Describe("UnmarshalYAML", func() {
+		It("should parse the mapping", func() {
+			var cfg IPRewrite
+
+			Expect(yaml.Unmarshal([]byte(`
+203.0.113.10: 192.168.1.10
+"2001:0db8::10": "fd00::10"
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.Mapping).Should(HaveLen(2))
+			Expect(cfg.Mapping).Should(HaveKeyWithValue("203.0.113.10", net.ParseIP("192.168.1.10")))
+			Expect(cfg.Mapping).Should(HaveKeyWithValue("2001:db8::10", net.ParseIP("fd00::10")))
+		})
+
+		It("should fail on invalid IPs", func() {
+			var cfg IPRewrite
+
+			Expect(yaml.Unmarshal([]byte("203.0.113.10: internal"), &cfg)).
+				Should(MatchError("invalid IP address 'internal'"))
+			Expect(yaml.Unmarshal([]byte("example.com: 192.168.1.10"), &cfg)).
+				Should(MatchError("invalid IP address 'example.com'"))
+		})
+
+		It("should fail on different IP versions", func() {
+			var cfg IPRewrite
+
+			Expect(yaml.Unmarshal([]byte(`203.0.113.10: "fd00::10"`), &cfg)).
+				Should(MatchError(ContainSubstring("IP versions differ")))
+		})
+
+		It("should fail on wrong type", func() {
+			var cfg IPRewrite
+
+			Expect(yaml.Unmarshal([]byte("[203.0.113.10]"), &cfg)).ShouldNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"net"
+	"strings"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// IPv6Only configuration of the mode for networks without IPv4 connectivity
+type IPv6Only struct {
+	Enable bool `default:"false" yaml:"enable"`
+	// clients (names, IPs, CIDRs or tags), which get no answers for A queries
+	FilterA        []string       `yaml:"filterA"`
+	NAT64Discovery NAT64Discovery `yaml:"nat64Discovery"`
+}
+
+// NAT64Discovery configuration of the discovery of the NAT64 prefix via `ipv4only.arpa` (RFC 7050)
+type NAT64Discovery struct {
+	Enable   bool     `default:"false" yaml:"enable"`
+	Interval Duration `default:"1h"    yaml:"interval"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *IPv6Only) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *IPv6Only) LogConfig(logger *logrus.Entry) {
+	if len(c.FilterA) != 0 {
+		logger.Infof("filter A: %s", strings.Join(c.FilterA, ", "))
+	}
+
+	if c.NAT64Discovery.IsEnabled() {
+		logger.Info("NAT64 discovery:")
+		log.WithIndent(logger, "  ", c.NAT64Discovery.LogConfig)
+	}
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *NAT64Discovery) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *NAT64Discovery) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interval: %s", c.Interval)
+}
+
+// validate warns about settings of the configuration, which assume IPv4 connectivity
+func (c *IPv6Only) validate(logger *logrus.Entry, cfg *Config) {
+	if !c.IsEnabled() {
+		return
+	}
+
+	if c.NAT64Discovery.IsEnabled() && !c.NAT64Discovery.Interval.IsAboveZero() {
+		defaults := mustDefault[NAT64Discovery]()
+
+		logger.Warnf("ipv6Only.nat64Discovery.interval <= 0, setting to %s", defaults.Interval)
+		c.NAT64Discovery.Interval = defaults.Interval
+	}
+
+	if cfg.ConnectIPVersion == IPVersionV4 {
+		logger.Warn("ipv6Only: connectIPVersion is v4, the upstreams can't be reached")
+	}
+
+	for group, upstreams := range cfg.Upstreams.Groups {
+		warnIPv4Upstreams(logger, "upstreams.groups."+group, upstreams)
+	}
+
+	for domain, upstreams := range cfg.Conditional.Mapping.Upstreams {
+		warnIPv4Upstreams(logger, "conditional.mapping."+domain, upstreams)
+	}
+
+	for _, bootstrap := range cfg.BootstrapDNS {
+		warnIPv4Upstreams(logger, "bootstrapDns", []Upstream{bootstrap.Upstream})
+
+		for _, ip := range bootstrap.IPs {
+			if ip.To4() != nil {
+				logger.Warnf("ipv6Only: bootstrapDns uses the IPv4 address %s", ip)
+			}
+		}
+	}
+
+	for group, binding := range cfg.Upstreams.Bind {
+		if binding.SourceIP.To4() != nil {
+			logger.Warnf("ipv6Only: upstreams.bind.%s uses the IPv4 source address %s", group, binding.SourceIP)
+		}
+	}
+
+	for name, addresses := range map[string]ListenConfig{
+		"dns": cfg.Ports.DNS, "tls": cfg.Ports.TLS, "http": cfg.Ports.HTTP, "https": cfg.Ports.HTTPS,
+	} {
+		for _, address := range addresses {
+			if host, _, err := net.SplitHostPort(address); err == nil && isIPv4(host) {
+				logger.Warnf("ipv6Only: ports.%s listens on the IPv4 address %s", name, address)
+			}
+		}
+	}
+
+	if cfg.Filtering.QueryTypes.Contains(dns.Type(dns.TypeAAAA)) {
+		logger.Warn("ipv6Only: filtering.queryTypes contains AAAA, the clients can't resolve any address")
+	}
+
+	if cfg.Filtering.FilterAAAA.IsEnabled() {
+		logger.Warn("ipv6Only: filtering.filterAAAA removes the only usable answers")
+	}
+}
+
+func warnIPv4Upstreams(logger *logrus.Entry, name string, upstreams []Upstream) {
+	for _, upstream := range upstreams {
+		if isIPv4(upstream.Host) {
+			logger.Warnf("ipv6Only: %s uses the IPv4 upstream %s", name, upstream)
+		}
+	}
+}
+
+func isIPv4(host string) bool {
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.To4() != nil
+}
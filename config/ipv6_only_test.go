@@ -0,0 +1,105 @@
+package config
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPv6Only", func() {
+	var c IPv6Only
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = mustDefault[IPv6Only]()
+		c.Enable = true
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			c := mustDefault[IPv6Only]()
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if enabled", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.FilterA = []string{"laptop", "tag:iot"}
+			c.NAT64Discovery.Enable = true
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"filter A: laptop, tag:iot",
+				"NAT64 discovery:",
+				"interval: 1 hour",
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		var cfg Config
+
+		BeforeEach(func() {
+			cfg = mustDefault[Config]()
+		})
+
+		It("should not warn for an IPv6 only configuration", func() {
+			cfg.Upstreams.Groups = map[string][]Upstream{"default": {{Net: NetProtocolTcpUdp, Host: "2620:fe::fe"}}}
+			cfg.Ports.DNS = ListenConfig{"[::]:53"}
+
+			c.validate(logger, &cfg)
+
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		It("should not warn if disabled", func() {
+			c.Enable = false
+			cfg.ConnectIPVersion = IPVersionV4
+
+			c.validate(logger, &cfg)
+
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		It("should warn about IPv4 assumptions", func() {
+			cfg.ConnectIPVersion = IPVersionV4
+			cfg.Upstreams.Groups = map[string][]Upstream{"default": {{Net: NetProtocolTcpUdp, Host: "9.9.9.9", Port: 53}}}
+			cfg.Upstreams.Bind = map[string]UpstreamBinding{"default": {SourceIP: net.ParseIP("192.168.178.2")}}
+			cfg.BootstrapDNS = BootstrapDNS{{
+				Upstream: Upstream{Net: NetProtocolHttps, Host: "dns.quad9.net", Port: 443},
+				IPs:      []net.IP{net.ParseIP("9.9.9.9")},
+			}}
+			cfg.Ports.DNS = ListenConfig{"0.0.0.0:53"}
+			cfg.Filtering.QueryTypes = NewQTypeSet(dns.Type(dns.TypeAAAA))
+
+			c.validate(logger, &cfg)
+
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("connectIPVersion is v4"),
+				"ipv6Only: upstreams.groups.default uses the IPv4 upstream tcp+udp:9.9.9.9",
+				"ipv6Only: upstreams.bind.default uses the IPv4 source address 192.168.178.2",
+				"ipv6Only: bootstrapDns uses the IPv4 address 9.9.9.9",
+				"ipv6Only: ports.dns listens on the IPv4 address 0.0.0.0:53",
+				ContainSubstring("filtering.queryTypes contains AAAA"),
+			))
+		})
+
+		It("should use the default for an invalid discovery interval", func() {
+			c.NAT64Discovery = NAT64Discovery{Enable: true, Interval: -1}
+
+			c.validate(logger, &cfg)
+
+			Expect(c.NAT64Discovery.Interval).Should(Equal(mustDefault[NAT64Discovery]().Interval))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("interval <= 0")))
+		})
+	})
+})
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LeakPrevention answers queries for the internal zones (custom DNS and conditional domains) with NXDOMAIN
+// instead of forwarding them to the public upstreams
+type LeakPrevention struct {
+	Enable bool     `yaml:"enable"`
+	Zones  []string `yaml:"zones"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *LeakPrevention) IsEnabled() bool {
+	return c.Enable
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *LeakPrevention) LogConfig(logger *logrus.Entry) {
+	logger.Info("enabled")
+
+	if len(c.Zones) != 0 {
+		logger.Infof("additional zones: %s", strings.Join(c.Zones, ", "))
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeakPrevention", func() {
+	var cfg LeakPrevention
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = LeakPrevention{
+			Enable: true,
+			Zones:  []string{"corp.example", "home.arpa"},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := LeakPrevention{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if enabled", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"enabled",
+				"additional zones: corp.example, home.arpa",
+			))
+		})
+	})
+})
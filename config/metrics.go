@@ -1,11 +1,26 @@
 package config
 
-import "github.com/sirupsen/logrus"
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
 
 // Metrics contains the config values for prometheus
 type Metrics struct {
-	Enable bool   `default:"false"    yaml:"enable"`
-	Path   string `default:"/metrics" yaml:"path"`
+	Enable bool          `default:"false"    yaml:"enable"`
+	Path   string        `default:"/metrics" yaml:"path"`
+	Labels MetricsLabels `yaml:"labels"`
+}
+
+// MetricsLabels limits the cardinality of the metric labels
+type MetricsLabels struct {
+	// value of the client labels
+	Client MetricsClientLabel `default:"name" yaml:"client"`
+	// only the N most queried domains get their own domain label, 0 means unlimited
+	TopDomains uint `default:"0" yaml:"topDomains"`
+	// labels, which are removed from all metrics
+	Disable []string `yaml:"disable"`
 }
 
 // IsEnabled implements `config.Configurable`.
@@ -16,4 +31,16 @@ func (c *Metrics) IsEnabled() bool {
 // LogConfig implements `config.Configurable`.
 func (c *Metrics) LogConfig(logger *logrus.Entry) {
 	logger.Infof("url path: %s", c.Path)
+
+	if c.Labels.Client != MetricsClientLabelName {
+		logger.Infof("client label: %s", c.Labels.Client)
+	}
+
+	if c.Labels.TopDomains > 0 {
+		logger.Infof("domain label: top %d domains", c.Labels.TopDomains)
+	}
+
+	if len(c.Labels.Disable) > 0 {
+		logger.Infof("disabled labels: %s", strings.Join(c.Labels.Disable, ", "))
+	}
 }
@@ -48,5 +48,23 @@ var _ = Describe("MetricsConfig", func() {
 			Expect(hook.Calls).Should(HaveLen(1))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("url path: /custom/path")))
 		})
+
+		When("label policy is configured", func() {
+			It("should log the policy", func() {
+				cfg.Labels = MetricsLabels{
+					Client:     MetricsClientLabelHash,
+					TopDomains: 10,
+					Disable:    []string{"reason", "upstream"},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					ContainSubstring("client label: hash"),
+					ContainSubstring("domain label: top 10 domains"),
+					ContainSubstring("disabled labels: reason, upstream"),
+				))
+			})
+		})
 	})
 })
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileMigration rewrites a deprecated option of a configuration file to its replacement
+type fileMigration struct {
+	old string
+	new string
+	// optional, converts the value of the deprecated option. A nil result removes the option without replacement.
+	convert func(value *yaml.Node) (*yaml.Node, error)
+}
+
+// fileMigrations mirror the migrations of the deprecated options in `Config.migrate`
+//
+//nolint:gochecknoglobals
+var fileMigrations = []fileMigration{
+	{old: "upstream", new: "upstreams.groups"},
+	{old: "upstreamTimeout", new: "upstreams.timeout"},
+	{old: "disableIPv6", new: "filtering.queryTypes", convert: ifTrueSequence("AAAA")},
+	{old: "port", new: "ports.dns"},
+	{old: "httpPort", new: "ports.http"},
+	{old: "httpsPort", new: "ports.https"},
+	{old: "tlsPort", new: "ports.tls"},
+	{old: "logLevel", new: "log.level"},
+	{old: "logFormat", new: "log.format"},
+	{old: "logPrivacy", new: "log.privacy"},
+	{old: "logTimestamp", new: "log.timestamp"},
+	{old: "dohUserAgent", new: "upstreams.userAgent"},
+	{old: "startVerifyUpstream", new: "upstreams.init.strategy", convert: boolToStrategy(InitStrategyFast.String())},
+
+	{old: "blocking.blackLists", new: "blocking.denylists"},
+	{old: "blocking.whiteLists", new: "blocking.allowlists"},
+	{old: "blocking.downloadTimeout", new: "blocking.loading.downloads.timeout"},
+	{old: "blocking.downloadAttempts", new: "blocking.loading.downloads.attempts"},
+	{old: "blocking.downloadCooldown", new: "blocking.loading.downloads.cooldown"},
+	{old: "blocking.refreshPeriod", new: "blocking.loading.refreshPeriod"},
+	{old: "blocking.failStartOnListError", new: "blocking.loading.strategy", convert: boolToStrategy("")},
+	{old: "blocking.processingConcurrency", new: "blocking.loading.concurrency"},
+	{old: "blocking.startStrategy", new: "blocking.loading.strategy"},
+	{old: "blocking.maxErrorsPerFile", new: "blocking.loading.maxErrorsPerSource"},
+
+	{old: "hostsFile.refreshPeriod", new: "hostsFile.loading.refreshPeriod"},
+	{old: "hostsFile.filePath", new: "hostsFile.sources", convert: func(value *yaml.Node) (*yaml.Node, error) {
+		return &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{value}}, nil
+	}},
+}
+
+// MigrateFile replaces the deprecated options in the content of a configuration file with their replacements.
+// Comments are kept where possible.
+// It returns the migrated content and a description of each change.
+func MigrateFile(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("wrong file structure: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, errors.New("wrong file structure: configuration must be a mapping")
+	}
+
+	var changes []string
+
+	for _, m := range fileMigrations {
+		change, err := m.apply(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't migrate %q: %w", m.old, err)
+		}
+
+		if change != "" {
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) //nolint:mnd
+
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), changes, nil
+}
+
+// apply moves the deprecated option to the new one and returns a description of the change, if the option is used
+func (m *fileMigration) apply(root *yaml.Node) (string, error) {
+	oldPath := strings.Split(m.old, ".")
+
+	parent := lookupMapping(root, oldPath[:len(oldPath)-1], false)
+	if parent == nil {
+		return "", nil
+	}
+
+	key, value := removeKey(parent, oldPath[len(oldPath)-1])
+	if key == nil {
+		return "", nil
+	}
+
+	if m.convert != nil {
+		var err error
+
+		value, err = m.convert(value)
+		if err != nil {
+			return "", err
+		}
+
+		if value == nil {
+			return fmt.Sprintf("removed %q, it has no effect", m.old), nil
+		}
+	}
+
+	newPath := strings.Split(m.new, ".")
+	name := newPath[len(newPath)-1]
+
+	dest := lookupMapping(root, newPath[:len(newPath)-1], true)
+	if dest == nil {
+		return "", fmt.Errorf("%q is not a mapping", strings.Join(newPath[:len(newPath)-1], "."))
+	}
+
+	if existing, _ := findKey(dest, name); existing != nil {
+		return fmt.Sprintf("removed %q, %q is already set", m.old, m.new), nil
+	}
+
+	// the key node keeps the comments of the deprecated option
+	key.Value = name
+	dest.Content = append(dest.Content, key, value)
+
+	return fmt.Sprintf("moved %q to %q", m.old, m.new), nil
+}
+
+// lookupMapping returns the mapping at the path, missing mappings are created if `create` is set
+func lookupMapping(root *yaml.Node, path []string, create bool) *yaml.Node {
+	current := root
+
+	for _, name := range path {
+		_, value := findKey(current, name)
+
+		if value == nil {
+			if !create {
+				return nil
+			}
+
+			value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			current.Content = append(current.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+		}
+
+		if value.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		current = value
+	}
+
+	return current
+}
+
+// findKey returns the key and value nodes of the option in the mapping
+func findKey(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// removeKey removes the option from the mapping and returns its key and value nodes
+func removeKey(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			key, value := mapping.Content[i], mapping.Content[i+1]
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+
+			return key, value
+		}
+	}
+
+	return nil, nil
+}
+
+// ifTrueSequence replaces a true value with a sequence containing `item` and removes a false one
+func ifTrueSequence(item string) func(*yaml.Node) (*yaml.Node, error) {
+	return func(value *yaml.Node) (*yaml.Node, error) {
+		var enabled bool
+
+		if err := value.Decode(&enabled); err != nil {
+			return nil, err
+		}
+
+		if !enabled {
+			return nil, nil
+		}
+
+		return &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: item}}}, nil
+	}
+}
+
+// boolToStrategy replaces true with the strategy `failOnError` and false with `ifFalse`, an empty one removes it
+func boolToStrategy(ifFalse string) func(*yaml.Node) (*yaml.Node, error) {
+	return func(value *yaml.Node) (*yaml.Node, error) {
+		var enabled bool
+
+		if err := value.Decode(&enabled); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case enabled:
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: InitStrategyFailOnError.String()}, nil
+		case ifFalse != "":
+			return &yaml.Node{Kind: yaml.ScalarNode, Value: ifFalse}, nil
+		default:
+			return nil, nil
+		}
+	}
+}
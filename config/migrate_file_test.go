@@ -0,0 +1,166 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("MigrateFile", func() {
+	migrate := func(lines ...string) (string, []string) {
+		migrated, changes, err := MigrateFile([]byte(strings.Join(lines, "\n")))
+		Expect(err).Should(Succeed())
+
+		return string(migrated), changes
+	}
+
+	It("should have a migration for each deprecated option", func() {
+		migrated := make(map[string]bool, len(fileMigrations))
+		for _, m := range fileMigrations {
+			migrated[m.old] = true
+		}
+
+		for prefix, deprecated := range map[string]any{
+			"":           Config{}.Deprecated,
+			"blocking.":  Blocking{}.Deprecated,
+			"hostsFile.": HostsFile{}.Deprecated,
+		} {
+			typ := reflect.TypeOf(deprecated)
+			for i := range typ.NumField() {
+				Expect(migrated).Should(HaveKey(prefix + typ.Field(i).Tag.Get("yaml")))
+			}
+		}
+	})
+
+	It("should move deprecated options and keep comments", func() {
+		migrated, changes := migrate(
+			"# my blocky config",
+			"",
+			"upstream:",
+			"  default:",
+			"    - 1.1.1.1 # cloudflare",
+			"port: 5353",
+			"blocking:",
+			"  # ads and more",
+			"  blackLists:",
+			"    ads:",
+			"      - https://example.com/ads.txt",
+			"  refreshPeriod: 1h",
+		)
+
+		Expect(changes).Should(Equal([]string{
+			`moved "upstream" to "upstreams.groups"`,
+			`moved "port" to "ports.dns"`,
+			`moved "blocking.blackLists" to "blocking.denylists"`,
+			`moved "blocking.refreshPeriod" to "blocking.loading.refreshPeriod"`,
+		}))
+
+		Expect(migrated).Should(Equal(strings.Join([]string{
+			"# my blocky config",
+			"",
+			"blocking:",
+			"  # ads and more",
+			"  denylists:",
+			"    ads:",
+			"      - https://example.com/ads.txt",
+			"  loading:",
+			"    refreshPeriod: 1h",
+			"upstreams:",
+			"  groups:",
+			"    default:",
+			"      - 1.1.1.1 # cloudflare",
+			"ports:",
+			"  dns: 5353",
+			"",
+		}, "\n")))
+	})
+
+	It("should convert values", func() {
+		migrated, _ := migrate(
+			"disableIPv6: true",
+			"startVerifyUpstream: false",
+			"hostsFile:",
+			"  filePath: /etc/hosts",
+			"blocking:",
+			"  failStartOnListError: false",
+		)
+
+		Expect(migrated).Should(Equal(strings.Join([]string{
+			"hostsFile:",
+			"  sources:",
+			"    - /etc/hosts",
+			"blocking: {}",
+			"filtering:",
+			"  queryTypes:",
+			"    - AAAA",
+			"upstreams:",
+			"  init:",
+			"    strategy: fast",
+			"",
+		}, "\n")))
+	})
+
+	It("should produce a configuration without deprecated options", func() {
+		migrated, _ := migrate(
+			"upstream:",
+			"  default:",
+			"    - 1.1.1.1",
+			"logLevel: debug",
+			"blocking:",
+			"  whiteLists:",
+			"    ads:",
+			"      - allowed.com",
+			"  failStartOnListError: true",
+		)
+
+		logger, hook := log.NewMockEntry()
+
+		var cfg Config
+		Expect(unmarshalConfig(logger, []byte(migrated), &cfg)).Should(Succeed())
+
+		Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("deprecated")))
+		Expect(cfg.Log.Level).Should(Equal(logrus.DebugLevel))
+		Expect(cfg.Blocking.Allowlists).Should(HaveKey("ads"))
+		Expect(cfg.Blocking.Loading.Strategy).Should(Equal(InitStrategyFailOnError))
+	})
+
+	It("should ignore deprecated options, if the new option is set", func() {
+		migrated, changes := migrate(
+			"port: 5353",
+			"ports:",
+			"  dns: 53",
+		)
+
+		Expect(changes).Should(Equal([]string{`removed "port", "ports.dns" is already set`}))
+		Expect(migrated).Should(Equal("ports:\n  dns: 53\n"))
+	})
+
+	It("should not change configurations without deprecated options", func() {
+		content := "ports:\n    dns:   53 # default\n"
+
+		migrated, changes := migrate(content)
+
+		Expect(changes).Should(BeEmpty())
+		Expect(migrated).Should(Equal(content))
+	})
+
+	It("should fail on invalid files", func() {
+		_, _, err := MigrateFile([]byte("a: b: c"))
+		Expect(err).Should(MatchError(ContainSubstring("wrong file structure")))
+
+		_, _, err = MigrateFile([]byte("- a"))
+		Expect(err).Should(MatchError(ContainSubstring("must be a mapping")))
+
+		_, _, err = MigrateFile([]byte("disableIPv6: maybe"))
+		Expect(err).Should(MatchError(ContainSubstring(`can't migrate "disableIPv6"`)))
+	})
+
+	It("should fail, if the new option can't be created", func() {
+		_, _, err := MigrateFile([]byte("port: 53\nports: 53"))
+		Expect(err).Should(MatchError(ContainSubstring(`"ports" is not a mapping`)))
+	})
+})
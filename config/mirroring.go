@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Mirroring duplicates a sample of the queries asynchronously to a shadow upstream and logs the differences
+// of the answers, the responses to the clients are not affected
+type Mirroring struct {
+	Upstream   Upstream `yaml:"upstream"`
+	SampleRate Percent  `default:"100" yaml:"sampleRate"`
+	Timeout    Duration `default:"2s"  yaml:"timeout"`
+	// queries are not mirrored, if this number of mirrored queries is in flight
+	MaxInFlight uint `default:"100" yaml:"maxInFlight"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Mirroring) IsEnabled() bool {
+	return !c.Upstream.IsDefault() && c.SampleRate > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Mirroring) LogConfig(logger *logrus.Entry) {
+	logger.Infof("upstream: %s", c.Upstream)
+	logger.Infof("sample rate: %s", c.SampleRate)
+	logger.Infof("timeout: %s", c.Timeout)
+	logger.Infof("max in flight: %d", c.MaxInFlight)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Mirroring", func() {
+	var cfg Mirroring
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[Mirroring]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with an upstream", func() {
+			Expect(yaml.Unmarshal([]byte("upstream: 192.168.178.3"), &cfg)).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false with a sample rate of 0", func() {
+			Expect(yaml.Unmarshal([]byte("upstream: 192.168.178.3\nsampleRate: 0%"), &cfg)).Should(Succeed())
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			Expect(yaml.Unmarshal([]byte("upstream: 192.168.178.3\nsampleRate: 10%"), &cfg)).Should(Succeed())
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"upstream: tcp+udp:192.168.178.3",
+				"sample rate: 10%",
+				"timeout: 2 seconds",
+				"max in flight: 100",
+			))
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// NewDomains flags or blocks queries for registrable domains, which were first observed recently
+type NewDomains struct {
+	Enable         bool            `yaml:"enable"`
+	MinAge         Duration        `default:"24h"  yaml:"minAge"`
+	LearningPeriod Duration        `default:"168h" yaml:"learningPeriod"`
+	Action         NewDomainAction `default:"flag" yaml:"action"`
+	StoreFile      string          `yaml:"storeFile"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *NewDomains) IsEnabled() bool {
+	return c.Enable && c.MinAge.IsAboveZero()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *NewDomains) LogConfig(logger *logrus.Entry) {
+	logger.Infof("min age: %s", c.MinAge)
+	logger.Infof("learning period: %s", c.LearningPeriod)
+	logger.Infof("action: %s", c.Action)
+
+	if c.StoreFile != "" {
+		logger.Infof("store file: %s", c.StoreFile)
+	} else {
+		logger.Info("store file: none, first-seen timestamps are lost on restart")
+	}
+}
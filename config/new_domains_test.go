@@ -0,0 +1,59 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewDomains", func() {
+	var c NewDomains
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = NewDomains{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+
+		c.Enable = true
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true if enabled", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false by default", func() {
+			c.Enable = false
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false without min age", func() {
+			c.MinAge = 0
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.StoreFile = "/var/lib/blocky/first-seen"
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"min age: 1 day",
+				"learning period: 1 week",
+				"action: flag",
+				"store file: /var/lib/blocky/first-seen",
+			))
+		})
+
+		It("should log missing store file", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("first-seen timestamps are lost on restart")))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifications configuration of the notifications, which are sent on events
+type Notifications struct {
+	Targets []NotificationTarget `yaml:"targets"`
+}
+
+// NotificationTarget is the configuration of a single notification receiver
+type NotificationTarget notificationTarget
+
+// notificationTarget is used to avoid infinite recursion in `NotificationTarget.UnmarshalYAML`
+type notificationTarget struct {
+	Name     string             `yaml:"name"`
+	Type     NotificationType   `default:"webhook" yaml:"type"`
+	URL      string             `yaml:"url"`
+	Token    string             `yaml:"token"`
+	ChatID   string             `yaml:"chatId"`
+	Headers  map[string]string  `yaml:"headers"`
+	Template string             `yaml:"template"`
+	Events   NotificationEvents `yaml:"events"`
+	Retries  uint               `default:"3"       yaml:"retries"`
+	Timeout  Duration           `default:"5s"      yaml:"timeout"`
+}
+
+// NotificationEvents enables the notification per event
+type NotificationEvents struct {
+	BlockingDisabled  bool `default:"true" yaml:"blockingDisabled"`
+	ListRefreshFailed bool `default:"true" yaml:"listRefreshFailed"`
+	UpstreamDown      bool `default:"true" yaml:"upstreamDown"`
+	UpstreamHijacked  bool `default:"true" yaml:"upstreamHijacked"`
+	ConfigReloaded    bool `default:"true" yaml:"configReloaded"`
+	QueryAnomaly      bool `default:"true" yaml:"queryAnomaly"`
+	SelfCheckFailed   bool `default:"true" yaml:"selfCheckFailed"`
+}
+
+// UnmarshalYAML sets the default values, which are not applied to list elements otherwise
+func (c *NotificationTarget) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var t notificationTarget
+	if err := defaults.Set(&t); err != nil {
+		return err
+	}
+
+	if err := unmarshal(&t); err != nil {
+		return err
+	}
+
+	*c = NotificationTarget(t)
+
+	return nil
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *Notifications) IsEnabled() bool {
+	return len(c.Targets) > 0
+}
+
+// LogConfig implements `config.Configurable`
+func (c *Notifications) LogConfig(logger *logrus.Entry) {
+	for _, target := range c.Targets {
+		logger.Infof("- %s (%s):", target.Name, target.Type)
+		logger.Infof("    retries: %d", target.Retries)
+		logger.Infof("    timeout: %s", target.Timeout)
+		logger.Infof("    blockingDisabled: %t", target.Events.BlockingDisabled)
+		logger.Infof("    listRefreshFailed: %t", target.Events.ListRefreshFailed)
+		logger.Infof("    upstreamDown: %t", target.Events.UpstreamDown)
+		logger.Infof("    upstreamHijacked: %t", target.Events.UpstreamHijacked)
+		logger.Infof("    configReloaded: %t", target.Events.ConfigReloaded)
+		logger.Infof("    queryAnomaly: %t", target.Events.QueryAnomaly)
+		logger.Infof("    selfCheckFailed: %t", target.Events.SelfCheckFailed)
+	}
+}
+
+func (c *Notifications) validate(logger *logrus.Entry) {
+	for _, target := range c.Targets {
+		switch {
+		case target.URL == "":
+			logger.Warnf("notification target '%s' has no url", target.Name)
+		case target.Type == NotificationTypeTelegram && target.ChatID == "":
+			logger.Warnf("notification target '%s' of type telegram has no chatId", target.Name)
+		case target.Type == NotificationTypeGotify && target.Token == "":
+			logger.Warnf("notification target '%s' of type gotify has no token", target.Name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Notifications", func() {
+	var c Notifications
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Notifications{}
+	})
+
+	Describe("UnmarshalYAML", func() {
+		It("should apply default values to targets", func() {
+			Expect(yaml.Unmarshal([]byte(`
+targets:
+  - name: chat
+    type: slack
+    url: https://hooks.slack.com/services/x
+    events:
+      upstreamDown: false
+`), &c)).Should(Succeed())
+
+			Expect(c.Targets).Should(HaveLen(1))
+
+			target := c.Targets[0]
+			Expect(target.Type).Should(Equal(NotificationTypeSlack))
+			Expect(target.Retries).Should(BeEquivalentTo(3))
+			Expect(target.Timeout).Should(Equal(Duration(5 * time.Second)))
+			Expect(target.Events).Should(Equal(NotificationEvents{
+				BlockingDisabled:  true,
+				ListRefreshFailed: true,
+				UpstreamDown:      false,
+				UpstreamHijacked:  true,
+				ConfigReloaded:    true,
+				QueryAnomaly:      true,
+				SelfCheckFailed:   true,
+			}))
+		})
+
+		It("should use webhook as default type", func() {
+			Expect(yaml.Unmarshal([]byte("targets:\n  - url: http://localhost"), &c)).Should(Succeed())
+
+			Expect(c.Targets[0].Type).Should(Equal(NotificationTypeWebhook))
+		})
+
+		It("should fail on unknown type", func() {
+			Expect(yaml.Unmarshal([]byte("targets:\n  - type: foo"), &c)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled without targets", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled with targets", func() {
+			c.Targets = []NotificationTarget{{URL: "http://localhost"}}
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log targets without secrets", func() {
+			logger, hook = log.NewMockEntry()
+
+			c.Targets = []NotificationTarget{{Name: "push", Type: NotificationTypeGotify, Token: "secret"}}
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement("- push (gotify):"),
+				Not(ContainElement(ContainSubstring("secret"))),
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+		})
+
+		It("should warn about missing fields", func() {
+			c.Targets = []NotificationTarget{
+				{Name: "empty"},
+				{Name: "bot", Type: NotificationTypeTelegram, URL: "https://api.telegram.org/botX/sendMessage"},
+				{Name: "push", Type: NotificationTypeGotify, URL: "https://gotify.local"},
+			}
+
+			c.validate(logger)
+
+			Expect(hook.Messages).Should(ConsistOf(
+				"notification target 'empty' has no url",
+				"notification target 'bot' of type telegram has no chatId",
+				"notification target 'push' of type gotify has no token",
+			))
+		})
+	})
+})
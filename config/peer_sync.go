@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// PeerSync configuration for the runtime state synchronization between multiple blocky instances
+type PeerSync struct {
+	Peers   []string `yaml:"peers"`
+	Secret  string   `yaml:"secret"`
+	Timeout Duration `default:"2s" yaml:"timeout"`
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *PeerSync) IsEnabled() bool {
+	return len(c.Peers) > 0 && c.Secret != ""
+}
+
+// LogConfig implements `config.Configurable`
+func (c *PeerSync) LogConfig(logger *logrus.Entry) {
+	logger.Info("secret: ", secretObfuscator)
+	logger.Info("timeout: ", c.Timeout)
+	logger.Info("peers:")
+
+	for _, peer := range c.Peers {
+		logger.Info("  - ", peer)
+	}
+}
+
+func (c *PeerSync) validate(logger *logrus.Entry) {
+	if len(c.Peers) > 0 && c.Secret == "" {
+		logger.Warn("peerSync.secret is not set, peer sync is disabled")
+	}
+}
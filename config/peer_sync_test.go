@@ -0,0 +1,74 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PeerSync", func() {
+	var c PeerSync
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		When("all fields are default", func() {
+			It("should be disabled", func() {
+				Expect(c.IsEnabled()).Should(BeFalse())
+			})
+		})
+
+		When("peers are set without secret", func() {
+			BeforeEach(func() {
+				c.Peers = []string{"http://10.0.0.2:4000"}
+			})
+
+			It("should be disabled", func() {
+				Expect(c.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should warn on validation", func() {
+				logger, hook = log.NewMockEntry()
+
+				c.validate(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("peerSync.secret is not set")))
+			})
+		})
+
+		When("peers and secret are set", func() {
+			BeforeEach(func() {
+				c.Peers = []string{"http://10.0.0.2:4000"}
+				c.Secret = "secret"
+			})
+
+			It("should be enabled", func() {
+				Expect(c.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+
+			c.Peers = []string{"http://10.0.0.2:4000"}
+			c.Secret = "secret"
+		})
+
+		It("should log configuration without secret", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(ContainSubstring("peers:")),
+				ContainElement(ContainSubstring("- http://10.0.0.2:4000")),
+				Not(ContainElement(ContainSubstring("secret: secret"))),
+			))
+		})
+	})
+})
@@ -0,0 +1,550 @@
+package config
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maximum size of a single file in the Teleporter archive
+	piholeMaxFileSize = 64 * 1024 * 1024
+
+	// ID of the Pi-hole group, which is used by all clients without an explicit assignment
+	piholeDefaultGroupID = 0
+
+	piholeCNAMEFile  = "05-pihole-custom-cname.conf"
+	piholeCustomList = "custom.list"
+
+	// TTL of the converted CNAME records, the default of customDNS.customTTL
+	piholeCNAMETTL = 3600
+)
+
+var piholeInvalidGroupChars = regexp.MustCompile("[^a-z0-9_-]+")
+
+// piholeBool is a boolean of the Pi-hole database, exported as 0/1
+type piholeBool bool
+
+func (b *piholeBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "1", "true":
+		*b = true
+	case "0", "false", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean '%s'", data)
+	}
+
+	return nil
+}
+
+type piholeGroup struct {
+	ID      int        `json:"id"`
+	Enabled piholeBool `json:"enabled"`
+	Name    string     `json:"name"`
+}
+
+type piholeAdlist struct {
+	ID      int        `json:"id"`
+	Address string     `json:"address"`
+	Enabled piholeBool `json:"enabled"`
+}
+
+type piholeDomain struct {
+	ID      int        `json:"id"`
+	Domain  string     `json:"domain"`
+	Enabled piholeBool `json:"enabled"`
+}
+
+type piholeClient struct {
+	ID int    `json:"id"`
+	IP string `json:"ip"`
+}
+
+// piholeGroupRelation assigns an adlist, a domain or a client to a group
+type piholeGroupRelation struct {
+	AdlistID     int `json:"adlist_id"`
+	DomainlistID int `json:"domainlist_id"`
+	ClientID     int `json:"client_id"`
+	GroupID      int `json:"group_id"`
+}
+
+// piholeDomainList is a file of the Teleporter archive with domain entries
+type piholeDomainList struct {
+	file  string
+	allow bool
+	regex bool
+}
+
+//nolint:gochecknoglobals
+var piholeDomainLists = []piholeDomainList{
+	{file: "blacklist.exact.json"},
+	{file: "blacklist.regex.json", regex: true},
+	{file: "whitelist.exact.json", allow: true},
+	{file: "whitelist.regex.json", allow: true, regex: true},
+}
+
+type piholeConfig struct {
+	CustomDNS *piholeCustomDNS `yaml:"customDNS,omitempty"`
+	Blocking  *piholeBlocking  `yaml:"blocking,omitempty"`
+}
+
+type piholeCustomDNS struct {
+	Mapping map[string]string `yaml:"mapping,omitempty"`
+	Zone    string            `yaml:"zone,omitempty"`
+}
+
+type piholeBlocking struct {
+	Denylists         map[string][]string `yaml:"denylists,omitempty"`
+	Allowlists        map[string][]string `yaml:"allowlists,omitempty"`
+	ClientGroupsBlock map[string][]string `yaml:"clientGroupsBlock,omitempty"`
+}
+
+// piholeImport converts the content of a Teleporter archive
+type piholeImport struct {
+	files map[string][]byte
+	notes []string
+
+	// enabled groups by ID
+	groups map[int]string
+}
+
+// ImportPiholeTeleporter converts a Teleporter archive of Pi-hole v5 (adlists, allow/denylist entries, local DNS
+// records and client groups) into a blocky configuration.
+// It returns the configuration and a description of each part, which can't be converted.
+func ImportPiholeTeleporter(r io.Reader) ([]byte, []string, error) {
+	files, err := readTeleporterArchive(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imp := piholeImport{files: files}
+
+	cfg, err := imp.convert()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("# converted from a Pi-hole Teleporter archive\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2) //nolint:mnd
+
+	if err := enc.Encode(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), imp.notes, nil
+}
+
+// readTeleporterArchive returns the files of the archive by their base name
+func readTeleporterArchive(r io.Reader) (map[string][]byte, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2) //nolint:mnd
+	if err != nil {
+		return nil, fmt.Errorf("can't read Teleporter archive: %w", err)
+	}
+
+	if string(magic) == "PK" {
+		return nil, errors.New("archives of Pi-hole v6 (zip) are not supported, " +
+			"please use a Teleporter archive of Pi-hole v5 (tar.gz)")
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("can't read Teleporter archive: %w", err)
+	}
+
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("can't read Teleporter archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if hdr.Size > piholeMaxFileSize {
+			return nil, fmt.Errorf("file '%s' of Teleporter archive is too large", hdr.Name)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(tr, piholeMaxFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("can't read file '%s' of Teleporter archive: %w", hdr.Name, err)
+		}
+
+		files[path.Base(hdr.Name)] = content
+	}
+}
+
+func (imp *piholeImport) notef(format string, args ...any) {
+	imp.notes = append(imp.notes, fmt.Sprintf(format, args...))
+}
+
+// readJSON decodes a JSON file of the archive, a missing file is empty
+func (imp *piholeImport) readJSON(file string, target any) error {
+	content, ok := imp.files[file]
+	if !ok {
+		return nil
+	}
+
+	if err := json.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("invalid file '%s' in Teleporter archive: %w", file, err)
+	}
+
+	return nil
+}
+
+func (imp *piholeImport) convert() (*piholeConfig, error) {
+	if err := imp.readGroups(); err != nil {
+		return nil, err
+	}
+
+	blocking, err := imp.convertLists()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := imp.convertClients(blocking); err != nil {
+		return nil, err
+	}
+
+	var cfg piholeConfig
+
+	if len(blocking.Denylists) > 0 || len(blocking.Allowlists) > 0 {
+		cfg.Blocking = blocking
+	}
+
+	customDNS := imp.convertLocalDNS()
+	if len(customDNS.Mapping) > 0 || customDNS.Zone != "" {
+		cfg.CustomDNS = customDNS
+	}
+
+	return &cfg, nil
+}
+
+func (imp *piholeImport) readGroups() error {
+	var groups []piholeGroup
+
+	if err := imp.readJSON("group.json", &groups); err != nil {
+		return err
+	}
+
+	imp.groups = map[int]string{piholeDefaultGroupID: "default"}
+
+	for _, g := range groups {
+		switch {
+		case !bool(g.Enabled):
+			imp.notef("group '%s' is disabled and not converted", g.Name)
+
+			delete(imp.groups, g.ID)
+		case g.ID != piholeDefaultGroupID:
+			imp.groups[g.ID] = piholeGroupName(g.Name)
+		}
+	}
+
+	return nil
+}
+
+// piholeGroupName converts the name of a Pi-hole group into a blocky group name
+func piholeGroupName(name string) string {
+	return strings.Trim(piholeInvalidGroupChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// readRelations returns the names of the enabled groups by the ID of the assigned entity
+func (imp *piholeImport) readRelations(file string, id func(piholeGroupRelation) int) (map[int][]string, error) {
+	var relations []piholeGroupRelation
+
+	if err := imp.readJSON(file, &relations); err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]string, len(relations))
+
+	for _, rel := range relations {
+		if name, ok := imp.groups[rel.GroupID]; ok {
+			result[id(rel)] = append(result[id(rel)], name)
+		}
+	}
+
+	return result, nil
+}
+
+func (imp *piholeImport) convertLists() (*piholeBlocking, error) {
+	blocking := piholeBlocking{
+		Denylists:  make(map[string][]string),
+		Allowlists: make(map[string][]string),
+	}
+
+	if err := imp.convertAdlists(blocking.Denylists); err != nil {
+		return nil, err
+	}
+
+	domainGroups, err := imp.readRelations("domainlist_by_group.json",
+		func(rel piholeGroupRelation) int { return rel.DomainlistID })
+	if err != nil {
+		return nil, err
+	}
+
+	denyEntries := make(map[string][]string)
+	allowEntries := make(map[string][]string)
+
+	for _, list := range piholeDomainLists {
+		var domains []piholeDomain
+
+		if err := imp.readJSON(list.file, &domains); err != nil {
+			return nil, err
+		}
+
+		entries := denyEntries
+		if list.allow {
+			entries = allowEntries
+		}
+
+		for _, d := range domains {
+			entry, ok := imp.domainEntry(d, list.regex)
+			if !ok {
+				continue
+			}
+
+			for _, group := range domainGroups[d.ID] {
+				entries[group] = append(entries[group], entry)
+			}
+		}
+	}
+
+	// the entries of a group are converted into one inline list
+	for group, entries := range denyEntries {
+		blocking.Denylists[group] = append(blocking.Denylists[group], strings.Join(entries, "\n")+"\n")
+	}
+
+	for group, entries := range allowEntries {
+		if _, ok := blocking.Denylists[group]; !ok {
+			imp.notef("group '%s' has only allowlist entries, which would block all other domains in blocky: "+
+				"the allowlist entries are not converted", group)
+
+			continue
+		}
+
+		blocking.Allowlists[group] = []string{strings.Join(entries, "\n") + "\n"}
+	}
+
+	return &blocking, nil
+}
+
+func (imp *piholeImport) convertAdlists(denylists map[string][]string) error {
+	var adlists []piholeAdlist
+
+	if err := imp.readJSON("adlist.json", &adlists); err != nil {
+		return err
+	}
+
+	adlistGroups, err := imp.readRelations("adlist_by_group.json",
+		func(rel piholeGroupRelation) int { return rel.AdlistID })
+	if err != nil {
+		return err
+	}
+
+	for _, list := range adlists {
+		if !list.Enabled {
+			imp.notef("adlist '%s' is disabled and not converted", list.Address)
+
+			continue
+		}
+
+		source := strings.TrimPrefix(list.Address, "file://")
+
+		for _, group := range adlistGroups[list.ID] {
+			denylists[group] = append(denylists[group], source)
+		}
+	}
+
+	return nil
+}
+
+// domainEntry converts a domain of a Pi-hole domain list into a list entry
+func (imp *piholeImport) domainEntry(d piholeDomain, regex bool) (string, bool) {
+	if !d.Enabled {
+		imp.notef("domain entry '%s' is disabled and not converted", d.Domain)
+
+		return "", false
+	}
+
+	if !regex {
+		return d.Domain, true
+	}
+
+	// Pi-hole extensions like ;querytype=AAAA or ;invert
+	if strings.Contains(d.Domain, ";") {
+		imp.notef("regex '%s' uses Pi-hole extensions and is not converted", d.Domain)
+
+		return "", false
+	}
+
+	return "/" + d.Domain + "/", true
+}
+
+func (imp *piholeImport) convertClients(blocking *piholeBlocking) error {
+	var clients []piholeClient
+
+	if err := imp.readJSON("client.json", &clients); err != nil {
+		return err
+	}
+
+	clientGroups, err := imp.readRelations("client_by_group.json",
+		func(rel piholeGroupRelation) int { return rel.ClientID })
+	if err != nil {
+		return err
+	}
+
+	blocking.ClientGroupsBlock = make(map[string][]string)
+
+	if groups := blocking.listGroups([]string{imp.groups[piholeDefaultGroupID]}); len(groups) > 0 {
+		blocking.ClientGroupsBlock["default"] = groups
+	}
+
+	for _, client := range clients {
+		if !isBlockyClientIdentifier(client.IP) {
+			imp.notef("client '%s' is identified by MAC address or interface and is not converted", client.IP)
+
+			continue
+		}
+
+		groups := blocking.listGroups(clientGroups[client.ID])
+		if len(groups) == 0 {
+			imp.notef("client '%s' has no groups with lists, it uses the default group in blocky", client.IP)
+
+			continue
+		}
+
+		blocking.ClientGroupsBlock[strings.ToLower(client.IP)] = groups
+	}
+
+	return nil
+}
+
+// listGroups returns the groups, which have allow/denylists
+func (b *piholeBlocking) listGroups(groups []string) []string {
+	var result []string
+
+	for _, group := range groups {
+		_, deny := b.Denylists[group]
+		_, allow := b.Allowlists[group]
+
+		if (deny || allow) && !slices.Contains(result, group) {
+			result = append(result, group)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// isBlockyClientIdentifier returns true for IPs, subnets and client names
+func isBlockyClientIdentifier(id string) bool {
+	if net.ParseIP(id) != nil {
+		return true
+	}
+
+	if _, _, err := net.ParseCIDR(id); err == nil {
+		return true
+	}
+
+	if _, err := net.ParseMAC(id); err == nil {
+		return false
+	}
+
+	// interfaces start with ':'
+	return id != "" && !strings.HasPrefix(id, ":")
+}
+
+// convertLocalDNS converts the local DNS records (custom.list) and CNAME records
+func (imp *piholeImport) convertLocalDNS() *piholeCustomDNS {
+	addresses := make(map[string][]string)
+
+	for _, line := range strings.Split(string(imp.files[piholeCustomList]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") { //nolint:mnd
+			continue
+		}
+
+		if net.ParseIP(fields[0]) == nil {
+			imp.notef("local DNS record '%s' has no valid IP address and is not converted", line)
+
+			continue
+		}
+
+		for _, host := range fields[1:] {
+			host = strings.ToLower(host)
+
+			addresses[host] = append(addresses[host], fields[0])
+		}
+	}
+
+	result := piholeCustomDNS{Mapping: make(map[string]string, len(addresses))}
+
+	for host, ips := range addresses {
+		result.Mapping[host] = strings.Join(ips, ",")
+	}
+
+	var zone strings.Builder
+
+	for _, line := range strings.Split(string(imp.files[piholeCNAMEFile]), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "cname=")
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(value, ",")
+
+		// optional TTL
+		if _, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			parts = parts[:len(parts)-1]
+		}
+
+		if len(parts) < 2 { //nolint:mnd
+			imp.notef("CNAME record '%s' is invalid and not converted", line)
+
+			continue
+		}
+
+		target := parts[len(parts)-1]
+
+		for _, alias := range parts[:len(parts)-1] {
+			fmt.Fprintf(&zone, "%s %d IN CNAME %s\n",
+				dns.Fqdn(strings.ToLower(alias)), piholeCNAMETTL, dns.Fqdn(strings.ToLower(target)))
+		}
+	}
+
+	result.Zone = zone.String()
+
+	return &result
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("ImportPiholeTeleporter", func() {
+	var files map[string]string
+
+	archive := func() *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+
+		for name, content := range files {
+			Expect(tw.WriteHeader(&tar.Header{
+				Name:     name,
+				Typeflag: tar.TypeReg,
+				Mode:     0o644,
+				Size:     int64(len(content)),
+			})).Should(Succeed())
+
+			_, err := tw.Write([]byte(content))
+			Expect(err).Should(Succeed())
+		}
+
+		Expect(tw.Close()).Should(Succeed())
+		Expect(gz.Close()).Should(Succeed())
+
+		return buf
+	}
+
+	BeforeEach(func() {
+		files = map[string]string{
+			"group.json": `[{"id":0,"enabled":1,"name":"Default"},{"id":1,"enabled":1,"name":"Kids Devices"},` +
+				`{"id":2,"enabled":0,"name":"old"}]`,
+			"adlist.json": `[{"id":1,"address":"https://example.com/ads.txt","enabled":1},` +
+				`{"id":2,"address":"file:///etc/pihole/local.txt","enabled":1},` +
+				`{"id":3,"address":"https://example.com/disabled.txt","enabled":0}]`,
+			"adlist_by_group.json": `[{"adlist_id":1,"group_id":0},{"adlist_id":1,"group_id":1},` +
+				`{"adlist_id":2,"group_id":1},{"adlist_id":2,"group_id":2}]`,
+			"blacklist.exact.json": `[{"id":1,"type":1,"domain":"tracker.com","enabled":1}]`,
+			"blacklist.regex.json": `[{"id":2,"type":3,"domain":"^ads\\.","enabled":1},` +
+				`{"id":3,"type":3,"domain":"^ad;querytype=AAAA","enabled":1}]`,
+			"whitelist.exact.json": `[{"id":4,"type":0,"domain":"allowed.com","enabled":1}]`,
+			"whitelist.regex.json": `[]`,
+			"domainlist_by_group.json": `[{"domainlist_id":1,"group_id":1},{"domainlist_id":2,"group_id":0},` +
+				`{"domainlist_id":3,"group_id":0},{"domainlist_id":4,"group_id":0}]`,
+			"client.json": `[{"id":1,"ip":"192.168.178.10"},{"id":2,"ip":"00:11:22:33:44:55"},` +
+				`{"id":3,"ip":"10.0.0.0/24"}]`,
+			"client_by_group.json": `[{"client_id":1,"group_id":1},{"client_id":2,"group_id":1},` +
+				`{"client_id":3,"group_id":0}]`,
+			"custom.list":                           "192.168.178.2 nas.lan\n# comment\n192.168.178.3 printer.lan Printer2.lan\n",
+			"dnsmasq.d/05-pihole-custom-cname.conf": "cname=files.lan,nas.lan\n",
+		}
+	})
+
+	It("should convert the archive", func() {
+		converted, notes, err := ImportPiholeTeleporter(archive())
+		Expect(err).Should(Succeed())
+
+		var cfg Config
+
+		Expect(yaml.Unmarshal(converted, &cfg)).Should(Succeed())
+
+		Expect(cfg.Blocking.Denylists).Should(HaveLen(2))
+		Expect(cfg.Blocking.Denylists["default"]).Should(ConsistOf(
+			BytesSource{Type: BytesSourceTypeHttp, From: "https://example.com/ads.txt"},
+			TextBytesSource("/^ads\\./"),
+		))
+
+		Expect(cfg.Blocking.Denylists["kids_devices"]).Should(ConsistOf(
+			BytesSource{Type: BytesSourceTypeHttp, From: "https://example.com/ads.txt"},
+			BytesSource{Type: BytesSourceTypeFile, From: "/etc/pihole/local.txt"},
+			TextBytesSource("tracker.com"),
+		))
+
+		Expect(cfg.Blocking.Allowlists).Should(HaveKeyWithValue("default",
+			ConsistOf(TextBytesSource("allowed.com"))))
+
+		Expect(cfg.Blocking.ClientGroupsBlock).Should(Equal(map[string][]string{
+			"default":        {"default"},
+			"192.168.178.10": {"kids_devices"},
+			"10.0.0.0/24":    {"default"},
+		}))
+
+		Expect(cfg.CustomDNS.Mapping).Should(HaveLen(3))
+		Expect(cfg.CustomDNS.Mapping).Should(HaveKey("printer2.lan"))
+		Expect(cfg.CustomDNS.Zone.RRs).Should(HaveKey("files.lan."))
+
+		Expect(notes).Should(ConsistOf(
+			"group 'old' is disabled and not converted",
+			"adlist 'https://example.com/disabled.txt' is disabled and not converted",
+			"regex '^ad;querytype=AAAA' uses Pi-hole extensions and is not converted",
+			"client '00:11:22:33:44:55' is identified by MAC address or interface and is not converted",
+		))
+	})
+
+	When("a group has only allowlist entries", func() {
+		BeforeEach(func() {
+			files["domainlist_by_group.json"] = `[{"domainlist_id":4,"group_id":1}]`
+			files["adlist_by_group.json"] = `[{"adlist_id":1,"group_id":0}]`
+		})
+
+		It("should not convert the allowlist", func() {
+			converted, notes, err := ImportPiholeTeleporter(archive())
+			Expect(err).Should(Succeed())
+			Expect(string(converted)).ShouldNot(ContainSubstring("allowlists"))
+			Expect(notes).Should(ContainElement(ContainSubstring("group 'kids_devices' has only allowlist entries")))
+		})
+	})
+
+	When("a file is invalid", func() {
+		BeforeEach(func() {
+			files["adlist.json"] = "{"
+		})
+
+		It("should fail", func() {
+			_, _, err := ImportPiholeTeleporter(archive())
+			Expect(err).Should(MatchError(ContainSubstring("invalid file 'adlist.json'")))
+		})
+	})
+
+	When("the archive is a zip file", func() {
+		It("should fail", func() {
+			_, _, err := ImportPiholeTeleporter(bytes.NewBufferString("PK\x03\x04"))
+			Expect(err).Should(MatchError(ContainSubstring("archives of Pi-hole v6 (zip) are not supported")))
+		})
+	})
+})
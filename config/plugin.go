@@ -0,0 +1,74 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// Plugin is the configuration of a custom resolver, which is inserted into the resolver chain
+type Plugin plugin
+
+// plugin is used to avoid infinite recursion in `Plugin.UnmarshalYAML`
+type plugin struct {
+	Name    string            `yaml:"name"`
+	Path    string            `yaml:"path"`
+	URL     string            `yaml:"url"`
+	Before  string            `yaml:"before"`
+	After   string            `yaml:"after"`
+	Timeout Duration          `default:"2s" yaml:"timeout"`
+	Args    map[string]string `yaml:"args"`
+}
+
+// UnmarshalYAML sets the default values, which are not applied to list elements otherwise
+func (c *Plugin) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var p plugin
+	if err := defaults.Set(&p); err != nil {
+		return err
+	}
+
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+
+	*c = Plugin(p)
+
+	return nil
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *Plugin) IsEnabled() bool {
+	return c.Path != "" || c.URL != ""
+}
+
+// LogConfig implements `config.Configurable`
+func (c *Plugin) LogConfig(logger *logrus.Entry) {
+	logger.Info("name: ", c.Name)
+
+	if c.Path != "" {
+		logger.Info("path: ", c.Path)
+	}
+
+	if c.URL != "" {
+		logger.Info("url: ", c.URL)
+		logger.Info("timeout: ", c.Timeout)
+	}
+
+	if len(c.Args) > 0 {
+		logger.Info("args:")
+
+		for key := range c.Args {
+			logger.Infof("  %s = %s", key, secretObfuscator)
+		}
+	}
+}
+
+func validatePlugins(logger *logrus.Entry, plugins []Plugin) {
+	for _, p := range plugins {
+		switch {
+		case p.Path != "" && p.URL != "":
+			logger.Warnf("plugin '%s' has path and url, only the path is used", p.Name)
+		case !p.IsEnabled():
+			logger.Warnf("plugin '%s' has neither path nor url and is ignored", p.Name)
+		}
+	}
+}
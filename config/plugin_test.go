@@ -0,0 +1,100 @@
+package config
+
+import (
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Plugin", func() {
+	var c Plugin
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Plugin{Name: "policy"}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("UnmarshalYAML", func() {
+		It("should apply default values to list elements", func() {
+			var plugins []Plugin
+
+			Expect(yaml.Unmarshal([]byte("- name: policy\n  url: http://localhost:8080\n  before: blocking"), &plugins)).
+				Should(Succeed())
+
+			Expect(plugins).Should(HaveLen(1))
+			Expect(plugins[0].Before).Should(Equal("blocking"))
+			Expect(plugins[0].Timeout).Should(Equal(Duration(2 * time.Second)))
+		})
+
+		It("should fail on invalid values", func() {
+			var plugins []Plugin
+
+			Expect(yaml.Unmarshal([]byte("- name: policy\n  timeout: invalid"), &plugins)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled without path and url", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled with path", func() {
+			c.Path = "/plugins/policy.so"
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be enabled with url", func() {
+			c.URL = "http://localhost:8080/resolve"
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+
+			c.URL = "http://localhost:8080/resolve"
+			c.Args = map[string]string{"token": "secret"}
+		})
+
+		It("should log configuration without argument values", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(ContainSubstring("url: http://localhost:8080/resolve")),
+				ContainElement(ContainSubstring("timeout: 2 seconds")),
+				ContainElement(ContainSubstring("token = ")),
+				Not(ContainElement(ContainSubstring("secret"))),
+			))
+		})
+	})
+
+	Describe("validatePlugins", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+		})
+
+		It("should warn about plugins without path and url", func() {
+			validatePlugins(logger, []Plugin{c})
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("plugin 'policy' has neither path nor url")))
+		})
+
+		It("should warn about plugins with path and url", func() {
+			c.Path = "/plugins/policy.so"
+			c.URL = "http://localhost:8080/resolve"
+
+			validatePlugins(logger, []Plugin{c})
+
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("only the path is used")))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Profiling configures the pprof endpoints and the automatic profile capture
+type Profiling struct {
+	// pprof handlers on the HTTP listeners (/debug/pprof)
+	Endpoints bool           `default:"true" yaml:"endpoints"`
+	Capture   ProfileCapture `yaml:"capture"`
+}
+
+// ProfileCapture writes CPU and heap profiles to a directory, if the query latency or the heap size exceed a threshold
+type ProfileCapture struct {
+	Directory string `yaml:"directory"`
+	// queries taking longer trigger a capture, 0 disables the latency trigger
+	LatencyThreshold Duration `yaml:"latencyThreshold"`
+	// heap size in MB, which triggers a capture, 0 disables the memory trigger
+	MemoryThreshold uint64   `yaml:"memoryThreshold"`
+	CPUDuration     Duration `default:"10s" yaml:"cpuDuration"`
+	// minimum time between two captures
+	Cooldown Duration `default:"10m" yaml:"cooldown"`
+	// oldest profiles are deleted, if the directory contains more files
+	MaxFiles uint `default:"10" yaml:"maxFiles"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Profiling) IsEnabled() bool {
+	return c.Endpoints || c.Capture.IsEnabled()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Profiling) LogConfig(logger *logrus.Entry) {
+	logger.Infof("endpoints: %t", c.Endpoints)
+
+	if c.Capture.IsEnabled() {
+		logger.Info("capture:")
+		c.Capture.LogConfig(logger)
+	}
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ProfileCapture) IsEnabled() bool {
+	return c.Directory != "" && (c.LatencyThreshold.IsAboveZero() || c.MemoryThreshold > 0)
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ProfileCapture) LogConfig(logger *logrus.Entry) {
+	logger.Infof("  directory = %s", c.Directory)
+
+	if c.LatencyThreshold.IsAboveZero() {
+		logger.Infof("  latency threshold = %s", c.LatencyThreshold)
+	}
+
+	if c.MemoryThreshold > 0 {
+		logger.Infof("  memory threshold = %d MB", c.MemoryThreshold)
+	}
+
+	logger.Infof("  CPU duration = %s", c.CPUDuration)
+	logger.Infof("  cooldown = %s", c.Cooldown)
+	logger.Infof("  max files = %d", c.MaxFiles)
+}
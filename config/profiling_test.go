@@ -0,0 +1,65 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Profiling", func() {
+	var cfg Profiling
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[Profiling]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should enable the endpoints by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+			Expect(cfg.Capture.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false without endpoints and capture", func() {
+			cfg.Endpoints = false
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should require a directory and a threshold for the capture", func() {
+			cfg.Endpoints = false
+			cfg.Capture.Directory = "/tmp/profiles"
+
+			Expect(cfg.Capture.IsEnabled()).Should(BeFalse())
+
+			cfg.Capture.MemoryThreshold = 512
+
+			Expect(cfg.Capture.IsEnabled()).Should(BeTrue())
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.Capture.Directory = "/tmp/profiles"
+			cfg.Capture.LatencyThreshold = Duration(2 * time.Second)
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"endpoints: true",
+				"capture:",
+				"  directory = /tmp/profiles",
+				"  latency threshold = 2 seconds",
+				"  CPU duration = 10 seconds",
+				"  cooldown = 10 minutes",
+				"  max files = 10",
+			))
+		})
+	})
+})
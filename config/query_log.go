@@ -17,13 +17,34 @@ type QueryLog struct {
 	CreationCooldown Duration        `default:"2s"            yaml:"creationCooldown"`
 	Fields           []QueryLogField `yaml:"fields"`
 	FlushInterval    Duration        `default:"30s"           yaml:"flushInterval"`
+	Aggregate        bool            `yaml:"aggregate"`
+	MaxFileSize      ByteSize        `yaml:"maxFileSize"`
+	Compress         bool            `yaml:"compress"`
 	Ignore           QueryLogIgnore  `yaml:"ignore"`
+	Redis            QueryLogRedis   `yaml:"redis"`
 }
 
 type QueryLogIgnore struct {
 	SUDN bool `default:"false" yaml:"sudn"`
 }
 
+// QueryLogRedis publishes the entries to Redis for external consumers, in addition to the writer of the type
+type QueryLogRedis struct {
+	// stream, which the entries are added to
+	Stream string `yaml:"stream"`
+	// channel, which the entries are published to
+	Channel string `yaml:"channel"`
+	// approximate maximum length of the stream, 0 means unlimited
+	MaxLen uint `default:"10000" yaml:"maxLen"`
+	// entries waiting to be sent, further entries are dropped
+	BufferSize uint `default:"1000" yaml:"bufferSize"`
+}
+
+// IsEnabled returns true, if the entries are sent to a stream or channel
+func (c *QueryLogRedis) IsEnabled() bool {
+	return c.Stream != "" || c.Channel != ""
+}
+
 // SetDefaults implements `defaults.Setter`.
 func (c *QueryLog) SetDefaults() {
 	// Since the default depends on the enum values, set it dynamically
@@ -33,7 +54,7 @@ func (c *QueryLog) SetDefaults() {
 
 // IsEnabled implements `config.Configurable`.
 func (c *QueryLog) IsEnabled() bool {
-	return c.Type != QueryLogTypeNone
+	return c.Type != QueryLogTypeNone || c.Redis.IsEnabled()
 }
 
 // LogConfig implements `config.Configurable`.
@@ -48,12 +69,34 @@ func (c *QueryLog) LogConfig(logger *logrus.Entry) {
 	logger.Debugf("creationAttempts: %d", c.CreationAttempts)
 	logger.Debugf("creationCooldown: %s", c.CreationCooldown)
 	logger.Infof("flushInterval: %s", c.FlushInterval)
+	logger.Infof("aggregate: %t", c.Aggregate)
+
+	if c.MaxFileSize > 0 {
+		logger.Infof("maxFileSize: %s", c.MaxFileSize)
+	}
+
+	logger.Infof("compress: %t", c.Compress)
 	logger.Infof("fields: %s", c.Fields)
 
 	logger.Infof("ignore:")
 	log.WithIndent(logger, "  ", func(e *logrus.Entry) {
 		logger.Infof("sudn: %t", c.Ignore.SUDN)
 	})
+
+	if c.Redis.IsEnabled() {
+		logger.Infof("redis:")
+		log.WithIndent(logger, "  ", func(e *logrus.Entry) {
+			if c.Redis.Stream != "" {
+				e.Infof("stream: %s (maxLen: %d)", c.Redis.Stream, c.Redis.MaxLen)
+			}
+
+			if c.Redis.Channel != "" {
+				e.Infof("channel: %s", c.Redis.Channel)
+			}
+
+			e.Infof("bufferSize: %d", c.Redis.BufferSize)
+		})
+	}
 }
 
 func (c *QueryLog) censoredTarget() string {
@@ -45,6 +45,15 @@ var _ = Describe("QueryLogConfig", func() {
 
 				Expect(cfg.IsEnabled()).Should(BeFalse())
 			})
+
+			It("should be true, if the entries are published to Redis", func() {
+				cfg := QueryLog{
+					Type:  QueryLogTypeNone,
+					Redis: QueryLogRedis{Stream: "blocky:querylog"},
+				}
+
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
 		})
 	})
 
@@ -55,6 +64,20 @@ var _ = Describe("QueryLogConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("logRetentionDays:")))
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("sudn:")))
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("aggregate: false")))
+		})
+
+		It("should log the Redis configuration", func() {
+			cfg.Redis = QueryLogRedis{Stream: "blocky:querylog", Channel: "blocky_querylog", MaxLen: 100, BufferSize: 10}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"redis:",
+				"stream: blocky:querylog (maxLen: 100)",
+				"channel: blocky_querylog",
+				"bufferSize: 10",
+			))
 		})
 
 		DescribeTable("secret censoring", func(target string) {
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/sirupsen/logrus"
+)
+
+// QueryProcessing limits of the query processing
+type QueryProcessing struct {
+	MaxConcurrent uint `yaml:"maxConcurrent"`
+	// maximum wait for a free processing slot, before the query is rejected
+	QueueTimeout Duration `default:"100ms" yaml:"queueTimeout"`
+	Timeout      Duration `yaml:"timeout"`
+	// code of the EDNS option, which enables the trace of a query, 0 disables it
+	DebugOption uint16      `yaml:"debugOption"`
+	Limits      QueryLimits `yaml:"limits"`
+}
+
+// QueryLimits protects against loops and crafted configurations, 0 disables a limit.
+// A query exceeding a limit is answered with SERVFAIL.
+type QueryLimits struct {
+	// CNAME records in an upstream response
+	CNAMEChain uint `default:"16" yaml:"cnameChain"`
+	// depth of the recursive resolution of custom DNS CNAME targets
+	CustomDNSDepth uint `default:"8" yaml:"customDNSDepth"`
+	// rewrites applied to a query
+	Rewrites uint `default:"4" yaml:"rewrites"`
+	// total of upstream responses, custom DNS CNAME resolutions and rewrites
+	Work uint `default:"32" yaml:"work"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *QueryProcessing) IsEnabled() bool {
+	return c.MaxConcurrent > 0 || c.Timeout.IsAboveZero() || c.DebugOption > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *QueryProcessing) LogConfig(logger *logrus.Entry) {
+	if c.MaxConcurrent > 0 {
+		logger.Infof("max concurrent queries: %d", c.MaxConcurrent)
+		logger.Infof("queue timeout: %s", c.QueueTimeout)
+	} else {
+		logger.Info("max concurrent queries: unlimited")
+	}
+
+	if c.Timeout.IsAboveZero() {
+		logger.Infof("timeout: %s", c.Timeout)
+	}
+
+	if c.DebugOption > 0 {
+		logger.Infof("debug EDNS option: %d", c.DebugOption)
+	}
+
+	logger.Info("limits:")
+	log.WithIndent(logger, "  ", c.Limits.LogConfig)
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *QueryLimits) LogConfig(logger *logrus.Entry) {
+	logger.Infof("CNAME chain: %d", c.CNAMEChain)
+	logger.Infof("custom DNS depth: %d", c.CustomDNSDepth)
+	logger.Infof("rewrites: %d", c.Rewrites)
+	logger.Infof("work: %d", c.Work)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QueryProcessing", func() {
+	var cfg QueryProcessing
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = QueryProcessing{
+			MaxConcurrent: 100,
+			QueueTimeout:  Duration(100 * time.Millisecond),
+			Timeout:       Duration(5 * time.Second),
+			DebugOption:   65001,
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := QueryProcessing{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with max concurrent queries", func() {
+			cfg.Timeout = 0
+			cfg.DebugOption = 0
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with timeout", func() {
+			cfg.MaxConcurrent = 0
+			cfg.DebugOption = 0
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with debug option", func() {
+			cfg = QueryProcessing{DebugOption: 65001}
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"max concurrent queries: 100",
+				"queue timeout: 100 milliseconds",
+				"timeout: 5 seconds",
+				"debug EDNS option: 65001",
+			))
+		})
+
+		It("should log the limits", func() {
+			cfg.Limits = mustDefault[QueryLimits]()
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"limits:",
+				"CNAME chain: 16",
+				"custom DNS depth: 8",
+				"rewrites: 4",
+				"work: 32",
+			))
+		})
+
+		It("should log unlimited concurrent queries", func() {
+			cfg.MaxConcurrent = 0
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("max concurrent queries: unlimited"))
+			Expect(hook.Messages).ShouldNot(ContainElement(HavePrefix("queue timeout")))
+		})
+	})
+})
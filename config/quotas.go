@@ -0,0 +1,102 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Quotas limits the daily usage of categories of domains per client, e.g. the time for games of the kids
+type Quotas struct {
+	Categories map[string]QuotaCategory `yaml:"categories"`
+	// a query marks the client as active in the category for the period, the used time is the sum of the active periods
+	ActivityPeriod Duration `default:"1m" yaml:"activityPeriod"`
+	StoreFile      string   `yaml:"storeFile"`
+}
+
+// QuotaCategory is a category of domains with the daily budget of each client
+type QuotaCategory struct {
+	// exact domains, wildcards or regexes
+	Domains []string `yaml:"domains"`
+	// client IPs, CIDRs, client names (with wildcards), `tag:` or `tenant:` of a client. All clients, if empty.
+	Clients []string `yaml:"clients"`
+	// allowed time per day, 0 is unlimited
+	Time Duration `yaml:"time"`
+	// allowed queries per day, 0 is unlimited
+	Queries uint `yaml:"queries"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Quotas) IsEnabled() bool {
+	return len(c.Categories) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Quotas) LogConfig(logger *logrus.Entry) {
+	for _, name := range c.Names() {
+		category := c.Categories[name]
+
+		logger.Infof("%s:", name)
+		logger.Infof("  domains: %s", strings.Join(category.Domains, ", "))
+
+		if len(category.Clients) != 0 {
+			logger.Infof("  clients: %s", strings.Join(category.Clients, ", "))
+		} else {
+			logger.Info("  clients: all")
+		}
+
+		if category.Time.IsAboveZero() {
+			logger.Infof("  time: %s", category.Time)
+		}
+
+		if category.Queries > 0 {
+			logger.Infof("  queries: %d", category.Queries)
+		}
+	}
+
+	logger.Infof("activity period: %s", c.ActivityPeriod)
+
+	if c.StoreFile != "" {
+		logger.Infof("store file: %s", c.StoreFile)
+	} else {
+		logger.Info("store file: none, the usage is reset on restart")
+	}
+}
+
+// Names returns the names of the categories in alphabetical order
+func (c *Quotas) Names() []string {
+	return slices.Sorted(maps.Keys(c.Categories))
+}
+
+func (c *Quotas) validate(logger *logrus.Entry) {
+	if !c.IsEnabled() {
+		return
+	}
+
+	if !c.ActivityPeriod.IsAboveZero() {
+		defaults := mustDefault[Quotas]()
+
+		logger.Warnf("quotas.activityPeriod <= 0, setting to %s", defaults.ActivityPeriod)
+		c.ActivityPeriod = defaults.ActivityPeriod
+	}
+
+	for _, name := range c.Names() {
+		category := c.Categories[name]
+
+		if !category.Time.IsAboveZero() && category.Queries == 0 {
+			logger.Warnf("quotas.categories.%s has no time and no query budget, it's ignored", name)
+
+			delete(c.Categories, name)
+
+			continue
+		}
+
+		if len(category.Domains) == 0 {
+			logger.Warnf("quotas.categories.%s has no domains, it's ignored", name)
+
+			delete(c.Categories, name)
+		}
+	}
+}
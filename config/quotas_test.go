@@ -0,0 +1,97 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quotas", func() {
+	var c Quotas
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = mustDefault[Quotas]()
+		c.Categories = map[string]QuotaCategory{
+			"gaming": {
+				Domains: []string{"*.roblox.com", "fortnite.com"},
+				Clients: []string{"tag:kids"},
+				Time:    Duration(time.Hour),
+			},
+			"video": {
+				Domains: []string{"*.youtube.com"},
+				Queries: 500,
+			},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			c := mustDefault[Quotas]()
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with categories", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(Equal([]string{
+				"gaming:",
+				"  domains: *.roblox.com, fortnite.com",
+				"  clients: tag:kids",
+				"  time: 1 hour",
+				"video:",
+				"  domains: *.youtube.com",
+				"  clients: all",
+				"  queries: 500",
+				"activity period: 1 minute",
+				"store file: none, the usage is reset on restart",
+			}))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept a valid configuration", func() {
+			c.validate(logger)
+
+			Expect(c.Categories).Should(HaveLen(2))
+			Expect(hook.Messages).Should(BeEmpty())
+		})
+
+		It("should ignore categories without budget", func() {
+			c.Categories["social"] = QuotaCategory{Domains: []string{"*.tiktok.com"}}
+
+			c.validate(logger)
+
+			Expect(c.Categories).ShouldNot(HaveKey("social"))
+			Expect(hook.Messages).Should(ContainElement(
+				"quotas.categories.social has no time and no query budget, it's ignored"))
+		})
+
+		It("should ignore categories without domains", func() {
+			c.Categories["social"] = QuotaCategory{Queries: 10}
+
+			c.validate(logger)
+
+			Expect(c.Categories).ShouldNot(HaveKey("social"))
+			Expect(hook.Messages).Should(ContainElement("quotas.categories.social has no domains, it's ignored"))
+		})
+
+		It("should reset an invalid activity period", func() {
+			c.ActivityPeriod = 0
+
+			c.validate(logger)
+
+			Expect(c.ActivityPeriod).Should(Equal(Duration(time.Minute)))
+			Expect(hook.Messages).Should(ContainElement("quotas.activityPeriod <= 0, setting to 1 minute"))
+		})
+	})
+})
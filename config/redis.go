@@ -16,16 +16,31 @@ type Redis struct {
 	SentinelUsername   string   `default:""               yaml:"sentinelUsername"`
 	SentinelPassword   string   `default:""               yaml:"sentinelPassword"`
 	SentinelAddresses  []string `yaml:"sentinelAddresses"`
+	ClusterAddresses   []string `yaml:"clusterAddresses"`
+	PoolSize           int      `default:"0"              yaml:"poolSize"`
+	MinIdleConnections int      `default:"0"              yaml:"minIdleConnections"`
+	PoolTimeout        Duration `default:"0s"             yaml:"poolTimeout"`
+	TLS                RedisTLS `yaml:"tls"`
+}
+
+// RedisTLS configuration for TLS connections to redis
+type RedisTLS struct {
+	Enable             bool   `default:"false" yaml:"enable"`
+	ServerName         string `yaml:"serverName"`
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	InsecureSkipVerify bool   `default:"false" yaml:"insecureSkipVerify"`
 }
 
 // IsEnabled implements `config.Configurable`
 func (c *Redis) IsEnabled() bool {
-	return c.Address != ""
+	return c.Address != "" || len(c.ClusterAddresses) > 0
 }
 
 // LogConfig implements `config.Configurable`
 func (c *Redis) LogConfig(logger *logrus.Entry) {
-	if len(c.SentinelAddresses) == 0 {
+	if len(c.SentinelAddresses) == 0 && len(c.ClusterAddresses) == 0 {
 		logger.Info("address: ", c.Address)
 	}
 
@@ -36,6 +51,18 @@ func (c *Redis) LogConfig(logger *logrus.Entry) {
 	logger.Info("connectionAttempts: ", c.ConnectionAttempts)
 	logger.Info("connectionCooldown: ", c.ConnectionCooldown)
 
+	if c.PoolSize > 0 {
+		logger.Info("poolSize: ", c.PoolSize)
+	}
+
+	if c.MinIdleConnections > 0 {
+		logger.Info("minIdleConnections: ", c.MinIdleConnections)
+	}
+
+	if c.PoolTimeout > 0 {
+		logger.Info("poolTimeout: ", c.PoolTimeout)
+	}
+
 	if len(c.SentinelAddresses) > 0 {
 		logger.Info("sentinel:")
 		logger.Info("  master: ", c.Address)
@@ -47,4 +74,40 @@ func (c *Redis) LogConfig(logger *logrus.Entry) {
 			logger.Info("    - ", addr)
 		}
 	}
+
+	if len(c.ClusterAddresses) > 0 {
+		logger.Info("cluster:")
+		logger.Info("  addresses:")
+
+		for _, addr := range c.ClusterAddresses {
+			logger.Info("    - ", addr)
+		}
+	}
+
+	if c.TLS.Enable {
+		logger.Info("tls:")
+		logger.Info("  serverName: ", c.TLS.ServerName)
+		logger.Info("  caFile: ", c.TLS.CAFile)
+		logger.Info("  certFile: ", c.TLS.CertFile)
+		logger.Info("  insecureSkipVerify: ", c.TLS.InsecureSkipVerify)
+	}
+}
+
+func (c *Redis) validate(logger *logrus.Entry) {
+	if len(c.ClusterAddresses) > 0 && len(c.SentinelAddresses) > 0 {
+		logger.Warn("redis.clusterAddresses and redis.sentinelAddresses are both set, using the cluster")
+	}
+
+	if len(c.ClusterAddresses) > 0 && c.Database != 0 {
+		logger.Warn("redis.database is not supported by a redis cluster, using database 0")
+
+		c.Database = 0
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		logger.Warn("redis.tls.certFile and redis.tls.keyFile must be set together, no client certificate is used")
+
+		c.TLS.CertFile = ""
+		c.TLS.KeyFile = ""
+	}
 }
@@ -16,6 +16,7 @@ var _ = Describe("Redis", func() {
 	suiteBeforeEach()
 
 	BeforeEach(func() {
+		c = Redis{}
 		err = defaults.Set(&c)
 		Expect(err).Should(Succeed())
 	})
@@ -36,6 +37,16 @@ var _ = Describe("Redis", func() {
 				Expect(c.IsEnabled()).Should(BeTrue())
 			})
 		})
+
+		When("ClusterAddresses is set", func() {
+			BeforeEach(func() {
+				c.ClusterAddresses = []string{"localhost:7000"}
+			})
+
+			It("should be enabled", func() {
+				Expect(c.IsEnabled()).Should(BeTrue())
+			})
+		})
 	})
 
 	Describe("LogConfig", func() {
@@ -87,6 +98,40 @@ var _ = Describe("Redis", func() {
 			})
 		})
 
+		When("ClusterAddresses is set", func() {
+			BeforeEach(func() {
+				c.ClusterAddresses = []string{"localhost:7000", "localhost:7001"}
+			})
+
+			It("should log cluster addresses", func() {
+				c.LogConfig(logger)
+
+				Expect(hook.Messages).Should(
+					SatisfyAll(
+						ContainElement(ContainSubstring("cluster:")),
+						ContainElement(ContainSubstring("  - localhost:7000")),
+						ContainElement(ContainSubstring("  - localhost:7001")),
+						Not(ContainElement(HavePrefix("address: ")))))
+			})
+		})
+
+		When("pool and TLS options are set", func() {
+			BeforeEach(func() {
+				c.PoolSize = 20
+				c.TLS = RedisTLS{Enable: true, ServerName: "redis.lan"}
+			})
+
+			It("should log them", func() {
+				c.LogConfig(logger)
+
+				Expect(hook.Messages).Should(
+					SatisfyAll(
+						ContainElement(Equal("poolSize: 20")),
+						ContainElement(Equal("tls:")),
+						ContainElement(Equal("  serverName: redis.lan"))))
+			})
+		})
+
 		const secretValue = "secret-value"
 
 		It("should not log the password", func() {
@@ -105,4 +150,35 @@ var _ = Describe("Redis", func() {
 			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring(secretValue)))
 		})
 	})
+
+	Describe("validate", func() {
+		It("should use database 0 for a cluster", func() {
+			c.ClusterAddresses = []string{"localhost:7000"}
+			c.Database = 2
+
+			c.validate(logger)
+
+			Expect(c.Database).Should(BeZero())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("redis.database is not supported")))
+		})
+
+		It("should ignore a client certificate without key", func() {
+			c.TLS.CertFile = "/etc/blocky/redis.crt"
+
+			c.validate(logger)
+
+			Expect(c.TLS.CertFile).Should(BeEmpty())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("must be set together")))
+		})
+
+		It("should accept valid options", func() {
+			c.Address = "localhost:6379"
+			c.Database = 2
+
+			c.validate(logger)
+
+			Expect(c.Database).Should(Equal(2))
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+	})
 })
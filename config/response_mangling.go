@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ResponseMangling modifies the responses to the clients per client group
+type ResponseMangling struct {
+	MinTTL       Duration                         `yaml:"minTTL"`
+	MaxTTL       Duration                         `yaml:"maxTTL"`
+	DomainTTL    map[string]Duration              `yaml:"domainTTL"`
+	ClientGroups map[string]ResponseManglingRules `yaml:"clientGroups"`
+	// sorts the records of each RRset in canonical order (RFC 4034)
+	SortAnswers bool `yaml:"sortAnswers"`
+	// rotates the records of each RRset by a random offset (round robin, RFC 1794)
+	RotateAnswers bool `yaml:"rotateAnswers"`
+}
+
+// ResponseManglingRules modifications of the responses to the clients of a group
+type ResponseManglingRules struct {
+	RemoveEDNSOptions []EDNSOption `yaml:"removeEdnsOptions"`
+	MinTTL            Duration     `yaml:"minTTL"`
+	MaxTTL            Duration     `yaml:"maxTTL"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ResponseMangling) IsEnabled() bool {
+	if c.MinTTL.IsAboveZero() || c.MaxTTL.IsAboveZero() || len(c.DomainTTL) != 0 || c.SortAnswers || c.RotateAnswers {
+		return true
+	}
+
+	for _, rules := range c.ClientGroups {
+		if rules.isEnabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c ResponseManglingRules) isEnabled() bool {
+	return len(c.RemoveEDNSOptions) != 0 || c.MinTTL.IsAboveZero() || c.MaxTTL.IsAboveZero()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ResponseMangling) LogConfig(logger *logrus.Entry) {
+	if c.MinTTL.IsAboveZero() {
+		logger.Infof("min TTL: %s", c.MinTTL)
+	}
+
+	if c.MaxTTL.IsAboveZero() {
+		logger.Infof("max TTL: %s", c.MaxTTL)
+	}
+
+	if len(c.DomainTTL) != 0 {
+		logger.Info("domain TTL:")
+
+		for domain, ttl := range c.DomainTTL {
+			logger.Infof("  %s = %s", domain, ttl)
+		}
+	}
+
+	if c.SortAnswers {
+		logger.Info("sort answers: enabled")
+	}
+
+	if c.RotateAnswers {
+		logger.Info("rotate answers: enabled")
+	}
+
+	if len(c.ClientGroups) == 0 {
+		return
+	}
+
+	logger.Info("client groups:")
+
+	for group, rules := range c.ClientGroups {
+		logger.Infof("  %s:", group)
+
+		if len(rules.RemoveEDNSOptions) != 0 {
+			options := make([]string, len(rules.RemoveEDNSOptions))
+			for i, option := range rules.RemoveEDNSOptions {
+				options[i] = option.String()
+			}
+
+			logger.Infof("    remove EDNS options: %s", strings.Join(options, ", "))
+		}
+
+		if rules.MinTTL.IsAboveZero() {
+			logger.Infof("    min TTL: %s", rules.MinTTL)
+		}
+
+		if rules.MaxTTL.IsAboveZero() {
+			logger.Infof("    max TTL: %s", rules.MaxTTL)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("ResponseMangling", func() {
+	var cfg ResponseMangling
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = ResponseMangling{
+			ClientGroups: map[string]ResponseManglingRules{
+				"192.168.20.0/24": {
+					RemoveEDNSOptions: []EDNSOption{EDNSOptionEcs, EDNSOptionPadding},
+					MinTTL:            Duration(time.Minute),
+					MaxTTL:            Duration(time.Hour),
+				},
+			},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := ResponseMangling{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false with empty groups", func() {
+			cfg := ResponseMangling{ClientGroups: map[string]ResponseManglingRules{"default": {}}}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with global TTL rules", func() {
+			cfg := ResponseMangling{DomainTTL: map[string]Duration{"example.com": Duration(time.Minute)}}
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with rules", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with answer order", func() {
+			Expect((&ResponseMangling{SortAnswers: true}).IsEnabled()).Should(BeTrue())
+			Expect((&ResponseMangling{RotateAnswers: true}).IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"client groups:",
+				"  192.168.20.0/24:",
+				"    remove EDNS options: ecs, padding",
+				"    min TTL: 1 minute",
+				"    max TTL: 1 hour",
+			))
+		})
+
+		It("should log global TTL rules", func() {
+			cfg := ResponseMangling{
+				MinTTL:    Duration(time.Minute),
+				MaxTTL:    Duration(time.Hour),
+				DomainTTL: map[string]Duration{"example.com": Duration(5 * time.Second)},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"min TTL: 1 minute",
+				"max TTL: 1 hour",
+				"domain TTL:",
+				"  example.com = 5 seconds",
+			))
+			Expect(hook.Messages).ShouldNot(ContainElement("client groups:"))
+		})
+
+		It("should log the answer order", func() {
+			cfg := ResponseMangling{SortAnswers: true, RotateAnswers: true}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("sort answers: enabled", "rotate answers: enabled"))
+		})
+	})
+
+	Describe("YAML", func() {
+		It("should parse EDNS options", func() {
+			var rules ResponseManglingRules
+
+			Expect(yaml.Unmarshal([]byte("removeEdnsOptions: [cookie, nsid, ede]"), &rules)).Should(Succeed())
+
+			Expect(rules.RemoveEDNSOptions).Should(Equal([]EDNSOption{
+				EDNSOptionCookie, EDNSOptionNsid, EDNSOptionEde,
+			}))
+		})
+
+		It("should fail on unknown EDNS option", func() {
+			var rules ResponseManglingRules
+
+			Expect(yaml.Unmarshal([]byte("removeEdnsOptions: [keepalive]"), &rules)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("EDNSOption", func() {
+		It("should return the option code", func() {
+			Expect(EDNSOptionEcs.Code()).Should(Equal(uint16(dns.EDNS0SUBNET)))
+			Expect(EDNSOptionCookie.Code()).Should(Equal(uint16(dns.EDNS0COOKIE)))
+			Expect(EDNSOptionPadding.Code()).Should(Equal(uint16(dns.EDNS0PADDING)))
+			Expect(EDNSOptionNsid.Code()).Should(Equal(uint16(dns.EDNS0NSID)))
+			Expect(EDNSOptionEde.Code()).Should(Equal(uint16(dns.EDNS0EDE)))
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// Responses configures the size and the name compression of the responses to the clients
+type Responses struct {
+	// EDNS UDP payload size advertised to the clients, 0 keeps the size of the resolved response
+	UDPPayloadSize uint16 `default:"1232" yaml:"udpPayloadSize"`
+	// UDP responses exceeding the size of the client or this size are truncated, 0 only uses the size of the client
+	MaxUDPSize uint16 `default:"1232" yaml:"maxUDPSize"`
+	// responses of these listeners are only compressed, if they don't fit into the size otherwise
+	UncompressedListeners []model.RequestListener `yaml:"uncompressedListeners"`
+	// unpacks each compressed response and compares it with the original
+	VerifyCompression bool `yaml:"verifyCompression"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Responses) IsEnabled() bool {
+	return c.UDPPayloadSize > 0 || c.MaxUDPSize > 0 || len(c.UncompressedListeners) != 0 || c.VerifyCompression
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Responses) LogConfig(logger *logrus.Entry) {
+	if c.UDPPayloadSize > 0 {
+		logger.Infof("advertised UDP payload size: %d", c.UDPPayloadSize)
+	} else {
+		logger.Info("advertised UDP payload size: from response")
+	}
+
+	if c.MaxUDPSize > 0 {
+		logger.Infof("max UDP size: %d", c.MaxUDPSize)
+	} else {
+		logger.Info("max UDP size: from client")
+	}
+
+	if len(c.UncompressedListeners) != 0 {
+		listeners := make([]string, len(c.UncompressedListeners))
+		for i, listener := range c.UncompressedListeners {
+			listeners[i] = listener.String()
+		}
+
+		logger.Infof("uncompressed listeners: %s", strings.Join(listeners, ", "))
+	}
+
+	if c.VerifyCompression {
+		logger.Info("verify compression: enabled")
+	}
+}
+
+func (c *Responses) validate(logger *logrus.Entry) {
+	// RFC 6891: sizes below 512 are treated as 512
+	if c.UDPPayloadSize > 0 && c.UDPPayloadSize < dns.MinMsgSize {
+		logger.Warnf("responses.udpPayloadSize %d is below %d, using %d", c.UDPPayloadSize, dns.MinMsgSize, dns.MinMsgSize)
+		c.UDPPayloadSize = dns.MinMsgSize
+	}
+
+	if c.MaxUDPSize > 0 && c.MaxUDPSize < dns.MinMsgSize {
+		logger.Warnf("responses.maxUDPSize %d is below %d, using %d", c.MaxUDPSize, dns.MinMsgSize, dns.MinMsgSize)
+		c.MaxUDPSize = dns.MinMsgSize
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Responses", func() {
+	var cfg Responses
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[Responses]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true by default", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false without limits", func() {
+			cfg := Responses{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.UncompressedListeners = []model.RequestListener{model.RequestListenerDns, model.RequestListenerHttps}
+			cfg.VerifyCompression = true
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"advertised UDP payload size: 1232",
+				"max UDP size: 1232",
+				"uncompressed listeners: dns, https",
+				"verify compression: enabled",
+			))
+		})
+
+		It("should log the sizes of the client and the response", func() {
+			cfg = Responses{}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"advertised UDP payload size: from response",
+				"max UDP size: from client",
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should raise sizes below 512", func() {
+			cfg.UDPPayloadSize = 100
+			cfg.MaxUDPSize = 200
+
+			cfg.validate(logger)
+
+			Expect(cfg.UDPPayloadSize).Should(BeEquivalentTo(512))
+			Expect(cfg.MaxUDPSize).Should(BeEquivalentTo(512))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("responses.udpPayloadSize"),
+				ContainSubstring("responses.maxUDPSize"),
+			))
+		})
+
+		It("should keep 0 and valid sizes", func() {
+			cfg.UDPPayloadSize = 0
+
+			cfg.validate(logger)
+
+			Expect(cfg.UDPPayloadSize).Should(BeZero())
+			Expect(cfg.MaxUDPSize).Should(BeEquivalentTo(1232))
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReverseProxy configures the trusted reverse proxies and load balancers in front of blocky, which pass the IP of the
+// client via the PROXY protocol or the headers `Forwarded` and `X-Forwarded-For`
+type ReverseProxy struct {
+	// IPs and CIDRs of the proxies
+	TrustedProxies []string `yaml:"trustedProxies"`
+	// accept the PROXY protocol (v1 and v2) on the TCP and DoT listeners
+	ProxyProtocol bool `default:"false" yaml:"proxyProtocol"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ReverseProxy) IsEnabled() bool {
+	return len(c.TrustedProxies) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ReverseProxy) LogConfig(logger *logrus.Entry) {
+	logger.Infof("trusted proxies: %s", strings.Join(c.TrustedProxies, ", "))
+	logger.Infof("PROXY protocol: %t", c.ProxyProtocol)
+}
+
+// Trusts returns true, if the IP is one of the trusted proxies
+func (c *ReverseProxy) Trusts(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(c.TrustedProxies, func(proxy string) bool {
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			return ipNet.Contains(ip)
+		}
+
+		return ip.Equal(net.ParseIP(proxy))
+	})
+}
+
+func (c *ReverseProxy) validate(logger *logrus.Entry) {
+	c.TrustedProxies = slices.DeleteFunc(c.TrustedProxies, func(proxy string) bool {
+		if _, _, err := net.ParseCIDR(proxy); err == nil || net.ParseIP(proxy) != nil {
+			return false
+		}
+
+		logger.Warnf("reverseProxy.trustedProxies: '%s' is no IP or CIDR, it's ignored", proxy)
+
+		return true
+	})
+
+	if c.ProxyProtocol && !c.IsEnabled() {
+		logger.Warn("reverseProxy.proxyProtocol is enabled without trusted proxies, the PROXY protocol is not accepted")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReverseProxy", func() {
+	var cfg ReverseProxy
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = ReverseProxy{TrustedProxies: []string{"192.0.2.10", "10.0.0.0/8", "2001:db8::/64"}, ProxyProtocol: true}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := ReverseProxy{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with trusted proxies", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"trusted proxies: 192.0.2.10, 10.0.0.0/8, 2001:db8::/64",
+				"PROXY protocol: true",
+			))
+		})
+	})
+
+	DescribeTable("Trusts",
+		func(ip string, want bool) {
+			Expect(cfg.Trusts(net.ParseIP(ip))).Should(Equal(want))
+		},
+		Entry("IP", "192.0.2.10", true),
+		Entry("IPv4 CIDR", "10.1.2.3", true),
+		Entry("IPv6 CIDR", "2001:db8::1", true),
+		Entry("other IP", "192.0.2.11", false),
+		Entry("other IPv6", "2001:db8:1::1", false),
+		Entry("no IP", "", false),
+	)
+
+	Describe("validate", func() {
+		It("should remove invalid proxies", func() {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, "proxy.example.com", "10.0.0.0/33")
+
+			cfg.validate(logger)
+
+			Expect(cfg.TrustedProxies).Should(Equal([]string{"192.0.2.10", "10.0.0.0/8", "2001:db8::/64"}))
+			Expect(hook.Messages).Should(ContainElements(
+				"reverseProxy.trustedProxies: 'proxy.example.com' is no IP or CIDR, it's ignored",
+				"reverseProxy.trustedProxies: '10.0.0.0/33' is no IP or CIDR, it's ignored",
+			))
+		})
+
+		It("should warn about the PROXY protocol without trusted proxies", func() {
+			cfg.TrustedProxies = nil
+
+			cfg.validate(logger)
+
+			Expect(hook.Messages).Should(ContainElement(
+				"reverseProxy.proxyProtocol is enabled without trusted proxies, the PROXY protocol is not accepted"))
+		})
+	})
+})
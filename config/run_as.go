@@ -0,0 +1,48 @@
+package config
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunAs is the user and group, which blocky switches to after binding the listeners
+type RunAs struct {
+	User  string `yaml:"user"`
+	Group string `yaml:"group"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *RunAs) IsEnabled() bool {
+	return c.User != "" || c.Group != ""
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *RunAs) LogConfig(logger *logrus.Entry) {
+	if c.User != "" {
+		logger.Infof("user: %s", c.User)
+	} else {
+		logger.Info("user: current user")
+	}
+
+	if c.Group != "" {
+		logger.Infof("group: %s", c.Group)
+	} else {
+		logger.Info("group: primary group of the user")
+	}
+}
+
+// validate warns about settings, which need privileges after they are dropped
+func (c *RunAs) validate(logger *logrus.Entry, cfg *Config) {
+	if !c.IsEnabled() {
+		return
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(cfg.Upstreams.Bind)) {
+		if cfg.Upstreams.Bind[group].Interface != "" {
+			logger.Warnf("runAs: upstreams.bind.%s.interface requires CAP_NET_RAW before Linux 5.7, "+
+				"the binding fails after the privileges are dropped", group)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunAs", func() {
+	var cfg RunAs
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = RunAs{User: "blocky", Group: "dns"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := RunAs{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with user", func() {
+			cfg.Group = ""
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with group", func() {
+			cfg.User = ""
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("user: blocky", "group: dns"))
+		})
+
+		It("should log the defaults", func() {
+			cfg = RunAs{}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("user: current user", "group: primary group of the user"))
+		})
+	})
+
+	Describe("validate", func() {
+		var fullCfg *Config
+
+		BeforeEach(func() {
+			c, err := WithDefaults[Config]()
+			Expect(err).Should(Succeed())
+
+			fullCfg = &c
+			fullCfg.Upstreams.Bind = map[string]UpstreamBinding{
+				"default": {SourceIP: net.ParseIP("192.168.178.2")},
+				"vpn":     {Interface: "wg0"},
+			}
+		})
+
+		It("should warn about the binding to an interface", func() {
+			cfg.validate(logger, fullCfg)
+
+			Expect(hook.Messages).Should(ConsistOf(
+				"runAs: upstreams.bind.vpn.interface requires CAP_NET_RAW before Linux 5.7, " +
+					"the binding fails after the privileges are dropped",
+			))
+		})
+
+		It("should not warn without runAs", func() {
+			cfg = RunAs{}
+
+			cfg.validate(logger, fullCfg)
+
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Scripting configuration of the Lua hooks, which are evaluated for each query and response
+type Scripting struct {
+	Script  BytesSource `yaml:"script"`
+	Timeout Duration    `default:"50ms" yaml:"timeout"`
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *Scripting) IsEnabled() bool {
+	return c.Script.From != ""
+}
+
+// LogConfig implements `config.Configurable`
+func (c *Scripting) LogConfig(logger *logrus.Entry) {
+	logger.Infof("script: %s", c.Script)
+	logger.Infof("timeout: %s", c.Timeout)
+}
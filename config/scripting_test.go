@@ -0,0 +1,48 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scripting", func() {
+	var c Scripting
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Scripting{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled with script", func() {
+			Expect(c.Script.UnmarshalText([]byte("/etc/blocky/policy.lua"))).Should(Succeed())
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+
+			Expect(c.Script.UnmarshalText([]byte("/etc/blocky/policy.lua"))).Should(Succeed())
+		})
+
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(Equal("script: file:///etc/blocky/policy.lua")),
+				ContainElement(ContainSubstring("timeout: 50 milliseconds")),
+			))
+		})
+	})
+})
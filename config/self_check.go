@@ -0,0 +1,52 @@
+package config
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// SelfCheck periodically resolves probe domains with the own resolver chain and reports the results
+type SelfCheck struct {
+	Interval Duration `default:"1m" yaml:"interval"`
+	// client of the probe queries, determines the client groups of the blocking
+	ClientIP net.IP           `yaml:"clientIP"`
+	Probes   []SelfCheckProbe `yaml:"probes"`
+}
+
+// SelfCheckProbe is a domain, which is resolved in each self-check, and its expected result
+type SelfCheckProbe struct {
+	Domain string               `yaml:"domain"`
+	Type   QType                `yaml:"type"`
+	Expect SelfCheckExpectation `yaml:"expect"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *SelfCheck) IsEnabled() bool {
+	return len(c.Probes) > 0 && c.Interval.IsAboveZero()
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *SelfCheck) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interval: %s", c.Interval)
+
+	if c.ClientIP != nil {
+		logger.Infof("client IP: %s", c.ClientIP)
+	}
+
+	logger.Info("probes:")
+
+	for _, probe := range c.Probes {
+		logger.Infof("  %s %s: %s", probe.Domain, probe.QType(), probe.Expect)
+	}
+}
+
+// QType returns the query type of the probe, A if not configured
+func (c *SelfCheckProbe) QType() QType {
+	if c.Type == 0 {
+		return QType(dns.TypeA)
+	}
+
+	return c.Type
+}
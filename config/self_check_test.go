@@ -0,0 +1,77 @@
+package config
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("SelfCheck", func() {
+	var cfg SelfCheck
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = WithDefaults[SelfCheck]()
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false without probes", func() {
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with probes", func() {
+			cfg.Probes = []SelfCheckProbe{{Domain: "example.com"}}
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("Probes", func() {
+		It("should parse the probes", func() {
+			Expect(yaml.Unmarshal([]byte(`
+probes:
+  - domain: example.com
+  - domain: ads.example.com
+    type: AAAA
+    expect: blocked
+`), &cfg)).Should(Succeed())
+
+			Expect(cfg.Probes).Should(HaveLen(2))
+			Expect(cfg.Probes[0].QType()).Should(Equal(QType(dns.TypeA)))
+			Expect(cfg.Probes[0].Expect).Should(Equal(SelfCheckExpectationResolved))
+			Expect(cfg.Probes[1].QType()).Should(Equal(QType(dns.TypeAAAA)))
+			Expect(cfg.Probes[1].Expect).Should(Equal(SelfCheckExpectationBlocked))
+		})
+
+		It("should fail on unknown expectation", func() {
+			Expect(yaml.Unmarshal([]byte("probes:\n  - domain: example.com\n    expect: foo"), &cfg)).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.ClientIP = net.ParseIP("192.168.178.2")
+			cfg.Probes = []SelfCheckProbe{
+				{Domain: "example.com"},
+				{Domain: "ads.example.com", Type: QType(dns.TypeAAAA), Expect: SelfCheckExpectationBlocked},
+			}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"interval: 1 minute",
+				"client IP: 192.168.178.2",
+				"probes:",
+				"  example.com A: resolved",
+				"  ads.example.com AAAA: blocked",
+			))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Statistics configures the queries, which are recorded in the metrics and the query log
+type Statistics struct {
+	Exclude StatisticsExclude `yaml:"exclude"`
+}
+
+// StatisticsExclude are queries, which don't reflect the traffic of the users, e.g. health checks of load balancers
+type StatisticsExclude struct {
+	// client IPs, CIDRs, client names (with wildcards) or `tag:` of a client
+	Clients []string `yaml:"clients"`
+	// exact domains, wildcards or regexes
+	Domains []string `yaml:"domains"`
+	// the probes of the self-check
+	SelfCheck bool `default:"false" yaml:"selfCheck"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Statistics) IsEnabled() bool {
+	return len(c.Exclude.Clients) != 0 || len(c.Exclude.Domains) != 0 || c.Exclude.SelfCheck
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Statistics) LogConfig(logger *logrus.Entry) {
+	logger.Info("exclude:")
+
+	if len(c.Exclude.Clients) != 0 {
+		logger.Infof("  clients: %s", strings.Join(c.Exclude.Clients, ", "))
+	}
+
+	if len(c.Exclude.Domains) != 0 {
+		logger.Infof("  domains: %s", strings.Join(c.Exclude.Domains, ", "))
+	}
+
+	logger.Infof("  self-check: %t", c.Exclude.SelfCheck)
+}
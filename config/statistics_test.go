@@ -0,0 +1,50 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Statistics", func() {
+	var c Statistics
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = mustDefault[Statistics]()
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true if clients are excluded", func() {
+			c.Exclude.Clients = []string{"10.0.0.5"}
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true if the self-check is excluded", func() {
+			c.Exclude.SelfCheck = true
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.Exclude.Clients = []string{"10.0.0.5", "tag:lb"}
+			c.Exclude.Domains = []string{"health.example.com", "*.canary.example.com"}
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"exclude:",
+				"  clients: 10.0.0.5, tag:lb",
+				"  domains: health.example.com, *.canary.example.com",
+				"  self-check: false",
+			))
+		})
+	})
+})
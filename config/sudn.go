@@ -12,14 +12,50 @@ type SUDN struct {
 	// Thus defaulting to `true` and returning NXDOMAIN here should not conflict.
 	RFC6762AppendixG bool `default:"true" yaml:"rfc6762-appendixG"`
 	Enable           bool `default:"true" yaml:"enable"`
+	// overrides the options for the clients of the groups
+	ClientGroups map[string]SUDNRules `yaml:"clientGroups"`
+}
+
+// SUDNRules options of a client group, unset options are taken from the global configuration
+type SUDNRules struct {
+	RFC6762AppendixG *bool `yaml:"rfc6762-appendixG"`
+	Enable           *bool `yaml:"enable"`
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *SUDN) IsEnabled() bool {
-	return c.Enable
+	if c.Enable {
+		return true
+	}
+
+	for _, rules := range c.ClientGroups {
+		if rules.Enable != nil && *rules.Enable {
+			return true
+		}
+	}
+
+	return false
 }
 
 // LogConfig implements `config.Configurable`.
 func (c *SUDN) LogConfig(logger *logrus.Entry) {
 	logger.Debugf("rfc6762-appendixG = %v", c.RFC6762AppendixG)
+
+	if len(c.ClientGroups) == 0 {
+		return
+	}
+
+	logger.Info("client groups:")
+
+	for group, rules := range c.ClientGroups {
+		logger.Infof("  %s:", group)
+
+		if rules.Enable != nil {
+			logger.Infof("    enable = %t", *rules.Enable)
+		}
+
+		if rules.RFC6762AppendixG != nil {
+			logger.Infof("    rfc6762-appendixG = %t", *rules.RFC6762AppendixG)
+		}
+	}
 }
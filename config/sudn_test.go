@@ -39,6 +39,16 @@ var _ = Describe("SUDNConfig", func() {
 				Expect(cfg.IsEnabled()).Should(BeFalse())
 			})
 		})
+
+		When("enabled for a client group", func() {
+			It("should be true", func() {
+				cfg := SUDN{
+					Enable:       false,
+					ClientGroups: map[string]SUDNRules{"guest": {Enable: ptrOf(true)}},
+				}
+				Expect(cfg.IsEnabled()).Should(BeTrue())
+			})
+		})
 	})
 
 	Describe("LogConfig", func() {
@@ -48,5 +58,17 @@ var _ = Describe("SUDNConfig", func() {
 			Expect(hook.Calls).ShouldNot(BeEmpty())
 			Expect(hook.Messages).Should(ContainElement(ContainSubstring("rfc6762-appendixG = true")))
 		})
+
+		It("should log the client groups", func() {
+			cfg.ClientGroups = map[string]SUDNRules{"lab": {RFC6762AppendixG: ptrOf(false)}}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"client groups:",
+				"  lab:",
+				"    rfc6762-appendixG = false",
+			))
+		})
 	})
 })
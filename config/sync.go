@@ -0,0 +1,89 @@
+package config
+
+import (
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sync configuration for the synchronization of the cache and the blocking status between multiple blocky instances
+type Sync struct {
+	NATS SyncNATS `yaml:"nats"`
+}
+
+// SyncNATS configuration for the synchronization via a NATS server
+type SyncNATS struct {
+	URL                string   `yaml:"url"`
+	Subject            string   `default:"blocky.sync" yaml:"subject"`
+	Token              string   `yaml:"token"`
+	Username           string   `yaml:"username"`
+	Password           string   `yaml:"password"`
+	Required           bool     `default:"false"       yaml:"required"`
+	ConnectionCooldown Duration `default:"1s"          yaml:"connectionCooldown"`
+	CAFile             string   `yaml:"caFile"`
+	InsecureSkipVerify bool     `default:"false"       yaml:"insecureSkipVerify"`
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *Sync) IsEnabled() bool {
+	return c.NATS.IsEnabled()
+}
+
+// LogConfig implements `config.Configurable`
+func (c *Sync) LogConfig(logger *logrus.Entry) {
+	logger.Info("nats:")
+	logger.Info("  url: ", c.NATS.URL)
+	logger.Info("  subject: ", c.NATS.Subject)
+
+	if c.NATS.Token != "" {
+		logger.Info("  token: ", secretObfuscator)
+	}
+
+	if c.NATS.Username != "" {
+		logger.Info("  username: ", c.NATS.Username)
+		logger.Info("  password: ", secretObfuscator)
+	}
+
+	logger.Info("  required: ", c.NATS.Required)
+	logger.Info("  connectionCooldown: ", c.NATS.ConnectionCooldown)
+
+	if c.NATS.CAFile != "" || c.NATS.InsecureSkipVerify {
+		logger.Info("  caFile: ", c.NATS.CAFile)
+		logger.Info("  insecureSkipVerify: ", c.NATS.InsecureSkipVerify)
+	}
+}
+
+// IsEnabled implements `config.Configurable`
+func (c *SyncNATS) IsEnabled() bool {
+	return c.URL != ""
+}
+
+func (c *Sync) validate(logger *logrus.Entry, cfg *Config) {
+	if !c.NATS.IsEnabled() {
+		return
+	}
+
+	u, err := url.Parse(c.NATS.URL)
+	if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+		logger.Warnf("sync.nats.url '%s' is invalid (expected nats://host:port or tls://host:port), "+
+			"synchronization via NATS is disabled", c.NATS.URL)
+
+		c.NATS.URL = ""
+
+		return
+	}
+
+	if c.NATS.Subject == "" {
+		logger.Warn("sync.nats.subject is empty, synchronization via NATS is disabled")
+
+		c.NATS.URL = ""
+
+		return
+	}
+
+	if cfg.Redis.IsEnabled() {
+		logger.Warn("redis and sync.nats are both configured, the instances are synchronized via redis")
+
+		c.NATS.URL = ""
+	}
+}
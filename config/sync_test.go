@@ -0,0 +1,105 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sync", func() {
+	var (
+		c   Sync
+		cfg Config
+	)
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Sync{}
+		cfg = Config{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		When("all fields are default", func() {
+			It("should be disabled", func() {
+				Expect(c.IsEnabled()).Should(BeFalse())
+			})
+		})
+
+		When("the NATS URL is set", func() {
+			BeforeEach(func() {
+				c.NATS.URL = "nats://nats:4222"
+			})
+
+			It("should be enabled", func() {
+				Expect(c.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+
+			c.NATS.URL = "nats://nats:4222"
+			c.NATS.Token = "token"
+		})
+
+		It("should log configuration without token", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(ContainSubstring("url: nats://nats:4222")),
+				ContainElement(ContainSubstring("subject: blocky.sync")),
+				Not(ContainElement(ContainSubstring("token: token"))),
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should keep a valid configuration", func() {
+			c.NATS.URL = "tls://nats:4222"
+
+			c.validate(logger, &cfg)
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		DescribeTable("should disable NATS with an invalid URL",
+			func(url string) {
+				c.NATS.URL = url
+
+				c.validate(logger, &cfg)
+
+				Expect(c.IsEnabled()).Should(BeFalse())
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("sync.nats.url")))
+			},
+			Entry("unknown scheme", "http://nats:4222"),
+			Entry("without scheme", "nats:4222"),
+			Entry("without host", "nats://"),
+		)
+
+		It("should disable NATS without subject", func() {
+			c.NATS.URL = "nats://nats:4222"
+			c.NATS.Subject = ""
+
+			c.validate(logger, &cfg)
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("sync.nats.subject is empty")))
+		})
+
+		It("should prefer redis", func() {
+			c.NATS.URL = "nats://nats:4222"
+			cfg.Redis.Address = "redis:6379"
+
+			c.validate(logger, &cfg)
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+			Expect(hook.Messages).Should(ContainElement(ContainSubstring("synchronized via redis")))
+		})
+	})
+})
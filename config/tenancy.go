@@ -0,0 +1,110 @@
+package config
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/sirupsen/logrus"
+)
+
+// tenant names are used in client group identifiers, which are case-insensitive, and file names
+var validTenantName = regexp.MustCompile(`^[a-z0-9-_]+$`)
+
+// Tenancy configures the tenants, e.g. the households or customers of a provider, which share the instance, but have
+// their own client groups, query log files, client statistics and API token
+type Tenancy struct {
+	// the token of the administrator, which authorizes all API requests. Without token, the API is not restricted.
+	APIToken string            `yaml:"apiToken"`
+	Tenants  map[string]Tenant `yaml:"tenants"`
+}
+
+// Tenant assigns the queries of listeners and clients to a tenant
+type Tenant struct {
+	Listeners []model.RequestListener `yaml:"listeners"`
+	// client IPs, CIDRs, client names (with wildcards) or `tag:` of a client
+	Clients []string `yaml:"clients"`
+	// the token, which authorizes the tenant scoped API requests
+	APIToken string `yaml:"apiToken"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Tenancy) IsEnabled() bool {
+	return len(c.Tenants) != 0 || c.APIToken != ""
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Tenancy) LogConfig(logger *logrus.Entry) {
+	if c.APIToken != "" {
+		logger.Info("API token: ", secretObfuscator)
+	}
+
+	for _, name := range c.Names() {
+		tenant := c.Tenants[name]
+
+		listeners := make([]string, len(tenant.Listeners))
+		for i, listener := range tenant.Listeners {
+			listeners[i] = listener.String()
+		}
+
+		logger.Infof("%s:", name)
+		logger.Infof("  listeners: %s", strings.Join(listeners, ", "))
+		logger.Infof("  clients: %s", strings.Join(tenant.Clients, ", "))
+
+		if tenant.APIToken != "" {
+			logger.Info("  API token: ", secretObfuscator)
+		}
+	}
+}
+
+// Names returns the names of the tenants in the order of matching
+func (c *Tenancy) Names() []string {
+	return slices.Sorted(maps.Keys(c.Tenants))
+}
+
+func (c *Tenancy) validate(logger *logrus.Entry) {
+	tokens := make(map[string]bool)
+
+	if c.APIToken != "" {
+		tokens[c.APIToken] = true
+	}
+
+	for _, name := range c.Names() {
+		tenant := c.Tenants[name]
+
+		if !validTenantName.MatchString(name) {
+			logger.Warnf("tenancy.tenants: '%s' is no valid name (lowercase letters, digits, '-' and '_'), it's ignored",
+				name)
+
+			delete(c.Tenants, name)
+
+			continue
+		}
+
+		if len(tenant.Listeners) == 0 && len(tenant.Clients) == 0 {
+			logger.Warnf("tenancy.tenants.%s has no listeners and clients, no queries are assigned", name)
+		}
+
+		if tenant.APIToken == "" {
+			continue
+		}
+
+		if tokens[tenant.APIToken] {
+			logger.Warnf("tenancy.tenants.%s.apiToken is already used, it's ignored", name)
+
+			tenant.APIToken = ""
+			c.Tenants[name] = tenant
+
+			continue
+		}
+
+		tokens[tenant.APIToken] = true
+
+		if c.APIToken == "" {
+			logger.Warnf("tenancy.tenants.%s.apiToken has no effect without tenancy.apiToken, the API is not restricted",
+				name)
+		}
+	}
+}
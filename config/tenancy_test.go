@@ -0,0 +1,124 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/model"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tenancy", func() {
+	var c Tenancy
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Tenancy{
+			APIToken: "admin-secret",
+			Tenants: map[string]Tenant{
+				"smith": {
+					Clients:  []string{"10.1.0.0/16", "tag:smith"},
+					APIToken: "smith-secret",
+				},
+				"miller": {
+					Listeners: []model.RequestListener{model.RequestListenerHttps},
+				},
+			},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			c := mustDefault[Tenancy]()
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with tenants", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with an API token only", func() {
+			c := Tenancy{APIToken: "admin-secret"}
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration without tokens", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(Equal([]string{
+				"API token: ********",
+				"miller:",
+				"  listeners: https",
+				"  clients: ",
+				"smith:",
+				"  listeners: ",
+				"  clients: 10.1.0.0/16, tag:smith",
+				"  API token: ********",
+			}))
+		})
+	})
+
+	Describe("Names", func() {
+		It("should return the sorted names", func() {
+			Expect(c.Names()).Should(Equal([]string{"miller", "smith"}))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should accept a valid configuration", func() {
+			c.validate(logger)
+
+			Expect(hook.Messages).Should(BeEmpty())
+		})
+
+		It("should remove tenants with invalid names", func() {
+			c.Tenants["smith family"] = Tenant{Clients: []string{"10.2.0.0/16"}}
+			c.Tenants["Wilson"] = Tenant{Clients: []string{"10.3.0.0/16"}}
+
+			c.validate(logger)
+
+			Expect(c.Tenants).Should(HaveLen(2))
+			Expect(hook.Messages).Should(ContainElements(
+				"tenancy.tenants: 'smith family' is no valid name (lowercase letters, digits, '-' and '_'), it's ignored",
+				"tenancy.tenants: 'Wilson' is no valid name (lowercase letters, digits, '-' and '_'), it's ignored",
+			))
+		})
+
+		It("should warn about tenants without listeners and clients", func() {
+			c.Tenants["empty"] = Tenant{}
+
+			c.validate(logger)
+
+			Expect(hook.Messages).Should(ContainElement(
+				"tenancy.tenants.empty has no listeners and clients, no queries are assigned"))
+		})
+
+		It("should ignore tokens, which are already used", func() {
+			c.Tenants["miller"] = Tenant{Clients: []string{"10.2.0.0/16"}, APIToken: "smith-secret"}
+			c.Tenants["wilson"] = Tenant{Clients: []string{"10.3.0.0/16"}, APIToken: "admin-secret"}
+
+			c.validate(logger)
+
+			Expect(c.Tenants["miller"].APIToken).Should(Equal("smith-secret"))
+			Expect(c.Tenants["smith"].APIToken).Should(BeEmpty())
+			Expect(c.Tenants["wilson"].APIToken).Should(BeEmpty())
+			Expect(hook.Messages).Should(ContainElements(
+				"tenancy.tenants.smith.apiToken is already used, it's ignored",
+				"tenancy.tenants.wilson.apiToken is already used, it's ignored",
+			))
+		})
+
+		It("should warn about tenant tokens without admin token", func() {
+			c.APIToken = ""
+
+			c.validate(logger)
+
+			Expect(hook.Messages).Should(ContainElement(
+				"tenancy.tenants.smith.apiToken has no effect without tenancy.apiToken, the API is not restricted"))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Typosquatting flags or blocks queries for domains within a small edit distance of the protected domains
+type Typosquatting struct {
+	Domains     []string            `yaml:"domains"`
+	MaxDistance uint                `default:"1"     yaml:"maxDistance"`
+	Action      TyposquattingAction `default:"block" yaml:"action"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *Typosquatting) IsEnabled() bool {
+	return len(c.Domains) != 0 && c.MaxDistance > 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *Typosquatting) LogConfig(logger *logrus.Entry) {
+	logger.Infof("domains: %s", strings.Join(c.Domains, ", "))
+	logger.Infof("max distance: %d", c.MaxDistance)
+	logger.Infof("action: %s", c.Action)
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"github.com/creasty/defaults"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Typosquatting", func() {
+	var c Typosquatting
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = Typosquatting{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+
+		c.Domains = []string{"mybank.com", "employer.de"}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be true with domains", func() {
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be false without domains", func() {
+			c.Domains = nil
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be false with max distance 0", func() {
+			c.MaxDistance = 0
+
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"domains: mybank.com, employer.de",
+				"max distance: 1",
+				"action: block",
+			))
+		})
+	})
+})
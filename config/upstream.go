@@ -11,6 +11,9 @@ import (
 var validDomain = regexp.MustCompile(
 	`^(([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])\.)*([A-Za-z0-9]|[A-Za-z0-9][A-Za-z0-9\-]*[A-Za-z0-9])$`)
 
+// dohURITemplate is the RFC 8484 URI template variable of the query at the end of a DoH path, e.g. `/dns-query{?dns}`
+var dohURITemplate = regexp.MustCompile(`\{[?&]dns\}$`)
+
 // Upstream is the definition of external DNS server
 type Upstream struct {
 	Net        NetProtocol
@@ -18,6 +21,8 @@ type Upstream struct {
 	Port       uint16
 	Path       string
 	CommonName string // Common Name to use for certificate verification; optional. "" uses .Host
+	Provider   string // profile provider like `nextdns`; optional. The path contains the profile and device
+	Registry   string // service registry like `consul`; optional. The path contains the service name or key prefix
 }
 
 // IsDefault returns true if u is the default value
@@ -25,12 +30,30 @@ func (u *Upstream) IsDefault() bool {
 	return *u == Upstream{}
 }
 
+// HasURITemplate returns true, if the path ends with the URI template variable `dns`
+func (u *Upstream) HasURITemplate() bool {
+	return dohURITemplate.MatchString(u.Path)
+}
+
+// PathWithoutTemplate returns the path without the URI template variable
+func (u *Upstream) PathWithoutTemplate() string {
+	return dohURITemplate.ReplaceAllString(u.Path, "")
+}
+
 // String returns the string representation of u
 func (u Upstream) String() string {
 	if u.IsDefault() {
 		return "no upstream"
 	}
 
+	if u.Provider != "" {
+		return u.profileString()
+	}
+
+	if u.IsRegistry() {
+		return u.registryString()
+	}
+
 	var sb strings.Builder
 
 	sb.WriteString(u.Net.String())
@@ -76,7 +99,17 @@ func (u *Upstream) UnmarshalText(data []byte) error {
 }
 
 // ParseUpstream creates new Upstream from passed string in format [net]:host[:port][/path][#commonname]
+// or provider[-tls]://profile[/device] for the profiles of NextDNS and ControlD
+// or consul://service and etcd://prefix for the upstreams of a service registry
 func ParseUpstream(upstream string) (Upstream, error) {
+	if u, ok, err := parseProfileUpstream(upstream); ok {
+		return u, err
+	}
+
+	if u, ok, err := parseRegistryUpstream(upstream); ok {
+		return u, err
+	}
+
 	var path string
 
 	var port uint16
@@ -87,6 +120,10 @@ func ParseUpstream(upstream string) (Upstream, error) {
 
 	path, upstream = extractPath(upstream)
 
+	if strings.ContainsAny(dohURITemplate.ReplaceAllString(path, ""), "{}") {
+		return Upstream{}, fmt.Errorf("unsupported URI template in path '%s', only {?dns} and {&dns} are supported", path)
+	}
+
 	host, portString, err := net.SplitHostPort(upstream)
 
 	// string contains host:port
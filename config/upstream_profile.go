@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	ProfileProviderNextDNS  = "nextdns"
+	ProfileProviderControlD = "controld"
+
+	// suffix of the scheme for DoT, e.g. `nextdns-tls://abc123`
+	profileTLSSuffix = "-tls"
+)
+
+// profileProviderHosts are the hosts of the DNS services with profiles, the DoT endpoint of a profile is a subdomain
+var profileProviderHosts = map[string]string{
+	ProfileProviderNextDNS:  "dns.nextdns.io",
+	ProfileProviderControlD: "dns.controld.com",
+}
+
+var (
+	validProfileID = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	// device names use "--" for spaces, as expected by the providers
+	validDeviceName = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]*[a-zA-Z0-9])?$`)
+)
+
+// Profile returns the profile ID and the device name of an upstream of a profile provider
+func (u *Upstream) Profile() (id, device string) {
+	id, device, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+
+	return id, device
+}
+
+// profileString returns the representation of an upstream of a profile provider, e.g. `nextdns://abc123/laptop`
+func (u *Upstream) profileString() string {
+	scheme := u.Provider
+
+	if u.Net == NetProtocolTcpTls {
+		scheme += profileTLSSuffix
+	}
+
+	return scheme + ":/" + u.Path
+}
+
+// parseProfileUpstream creates the upstream of a profile in format provider[-tls]://profile[/device].
+// Returns false, if the upstream is not a profile of a known provider.
+func parseProfileUpstream(upstream string) (Upstream, bool, error) {
+	scheme, rest, found := strings.Cut(upstream, "://")
+	if !found {
+		return Upstream{}, false, nil
+	}
+
+	provider, isTLS := strings.CutSuffix(scheme, profileTLSSuffix)
+
+	host, ok := profileProviderHosts[provider]
+	if !ok {
+		return Upstream{}, false, nil
+	}
+
+	id, device, _ := strings.Cut(rest, "/")
+
+	if !validProfileID.MatchString(id) {
+		return Upstream{}, true, fmt.Errorf("invalid %s profile ID '%s'", provider, id)
+	}
+
+	path := "/" + id
+
+	if device != "" {
+		if !validDeviceName.MatchString(device) {
+			return Upstream{}, true, fmt.Errorf(
+				"invalid device name '%s', only letters, digits and hyphens (-- for spaces) are allowed", device)
+		}
+
+		path += "/" + device
+	}
+
+	if !isTLS {
+		return Upstream{
+			Net:      NetProtocolHttps,
+			Host:     host,
+			Port:     netDefaultPort[NetProtocolHttps],
+			Path:     path,
+			Provider: provider,
+		}, true, nil
+	}
+
+	// DoT identifies the profile and the device by the subdomain
+	subdomain := id
+	if device != "" {
+		subdomain = device + "-" + id
+	}
+
+	return Upstream{
+		Net:      NetProtocolTcpTls,
+		Host:     subdomain + "." + host,
+		Port:     netDefaultPort[NetProtocolTcpTls],
+		Path:     path,
+		Provider: provider,
+	}, true, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upstream profiles", func() {
+	DescribeTable("parsing",
+		func(in string, want Upstream) {
+			u, err := ParseUpstream(in)
+			Expect(err).Should(Succeed())
+			Expect(u).Should(Equal(want))
+			Expect(u.String()).Should(Equal(in))
+		},
+		Entry("NextDNS DoH",
+			"nextdns://abc123",
+			Upstream{
+				Net: NetProtocolHttps, Host: "dns.nextdns.io", Port: 443, Path: "/abc123", Provider: ProfileProviderNextDNS,
+			}),
+		Entry("NextDNS DoH with device",
+			"nextdns://abc123/My--Laptop",
+			Upstream{
+				Net: NetProtocolHttps, Host: "dns.nextdns.io", Port: 443, Path: "/abc123/My--Laptop",
+				Provider: ProfileProviderNextDNS,
+			}),
+		Entry("NextDNS DoT",
+			"nextdns-tls://abc123",
+			Upstream{
+				Net: NetProtocolTcpTls, Host: "abc123.dns.nextdns.io", Port: 853, Path: "/abc123",
+				Provider: ProfileProviderNextDNS,
+			}),
+		Entry("ControlD DoT with device",
+			"controld-tls://abc123/router",
+			Upstream{
+				Net: NetProtocolTcpTls, Host: "router-abc123.dns.controld.com", Port: 853, Path: "/abc123/router",
+				Provider: ProfileProviderControlD,
+			}),
+	)
+
+	DescribeTable("invalid profiles",
+		func(in, wantErr string) {
+			_, err := ParseUpstream(in)
+			Expect(err).Should(MatchError(ContainSubstring(wantErr)))
+		},
+		Entry("missing profile ID", "nextdns://", "invalid nextdns profile ID ''"),
+		Entry("invalid profile ID", "controld://abc.123", "invalid controld profile ID 'abc.123'"),
+		Entry("invalid device name", "nextdns://abc123/my laptop", "invalid device name 'my laptop'"),
+	)
+
+	It("should not parse other schemes as profile", func() {
+		u, err := ParseUpstream("https://dns.nextdns.io/abc123")
+		Expect(err).Should(Succeed())
+		Expect(u.Provider).Should(BeEmpty())
+	})
+
+	Describe("Profile", func() {
+		It("should return the profile ID and the device", func() {
+			u, err := ParseUpstream("nextdns-tls://abc123/laptop")
+			Expect(err).Should(Succeed())
+
+			id, device := u.Profile()
+			Expect(id).Should(Equal("abc123"))
+			Expect(device).Should(Equal("laptop"))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RegistryConsul = "consul"
+	RegistryEtcd   = "etcd"
+
+	defaultEtcdEndpoint = "http://127.0.0.1:2379"
+)
+
+// UpstreamRegistry configures the connections to the service registries of the upstreams like `consul://dns`
+type UpstreamRegistry struct {
+	Consul ConsulRegistry `yaml:"consul"`
+	Etcd   EtcdRegistry   `yaml:"etcd"`
+}
+
+// ConsulRegistry configures the Consul agent, which provides the healthy instances of a service
+type ConsulRegistry struct {
+	Address    string `default:"http://127.0.0.1:8500" yaml:"address"`
+	Token      string `yaml:"token"`
+	Datacenter string `yaml:"datacenter"`
+	// maximum duration of a blocking query, the instances are updated as soon as they change
+	Wait Duration `default:"5m" yaml:"wait"`
+}
+
+// EtcdRegistry configures the etcd cluster, which contains the upstreams as values of the keys with a prefix
+type EtcdRegistry struct {
+	Endpoints []string `yaml:"endpoints"`
+	Username  string   `yaml:"username"`
+	Password  string   `yaml:"password"`
+}
+
+// IsRegistry returns true, if the upstream is resolved from a service registry
+func (u *Upstream) IsRegistry() bool {
+	return u.Registry != ""
+}
+
+// RegistryKey returns the name of the Consul service or the key prefix in etcd
+func (u *Upstream) RegistryKey() string {
+	return u.Path
+}
+
+// registryString returns the representation of an upstream of a service registry, e.g. `consul://dns`
+func (u *Upstream) registryString() string {
+	return u.Registry + "://" + u.Path
+}
+
+// parseRegistryUpstream creates the upstream of a service registry in format consul://service or etcd://prefix.
+// Returns false, if the upstream is not in the format of a known registry.
+func parseRegistryUpstream(upstream string) (Upstream, bool, error) {
+	scheme, key, found := strings.Cut(upstream, "://")
+	if !found || (scheme != RegistryConsul && scheme != RegistryEtcd) {
+		return Upstream{}, false, nil
+	}
+
+	if key == "" {
+		return Upstream{}, true, fmt.Errorf("missing %s key", scheme)
+	}
+
+	if scheme == RegistryConsul && !validDomain.MatchString(key) {
+		return Upstream{}, true, fmt.Errorf("invalid consul service name '%s'", key)
+	}
+
+	return Upstream{Registry: scheme, Path: key}, true, nil
+}
+
+// HasRegistryUpstreams returns true, if one of the upstreams is resolved from a service registry
+func HasRegistryUpstreams(upstreams []Upstream) bool {
+	return slices.ContainsFunc(upstreams, func(u Upstream) bool { return u.IsRegistry() })
+}
+
+// EndpointList returns the configured endpoints or the local default endpoint
+func (c *EtcdRegistry) EndpointList() []string {
+	if len(c.Endpoints) == 0 {
+		return []string{defaultEtcdEndpoint}
+	}
+
+	return c.Endpoints
+}
+
+// LogConfig logs the addresses, the credentials are not logged
+func (c *UpstreamRegistry) LogConfig(logger *logrus.Entry) {
+	logger.Infof("consul: %s", c.Consul.Address)
+
+	if c.Consul.Datacenter != "" {
+		logger.Infof("  datacenter: %s", c.Consul.Datacenter)
+	}
+
+	logger.Infof("etcd: %s", strings.Join(c.Etcd.EndpointList(), ", "))
+}
+
+func (c *UpstreamRegistry) validate(logger *logrus.Entry) {
+	defaults := mustDefault[ConsulRegistry]()
+
+	if c.Consul.Address == "" {
+		logger.Warnf("upstreams.registry.consul.address is empty, setting to %s", defaults.Address)
+		c.Consul.Address = defaults.Address
+	}
+
+	if !c.Consul.Wait.IsAboveZero() {
+		logger.Warnf("upstreams.registry.consul.wait <= 0, setting to %s", defaults.Wait)
+		c.Consul.Wait = defaults.Wait
+	}
+}
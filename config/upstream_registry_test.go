@@ -0,0 +1,84 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upstream registries", func() {
+	suiteBeforeEach()
+
+	DescribeTable("parsing",
+		func(in string, want Upstream) {
+			u, err := ParseUpstream(in)
+			Expect(err).Should(Succeed())
+			Expect(u).Should(Equal(want))
+			Expect(u.IsRegistry()).Should(BeTrue())
+			Expect(u.String()).Should(Equal(in))
+		},
+		Entry("Consul service", "consul://dns-resolver", Upstream{Registry: RegistryConsul, Path: "dns-resolver"}),
+		Entry("etcd prefix", "etcd:///blocky/upstreams/", Upstream{Registry: RegistryEtcd, Path: "/blocky/upstreams/"}),
+	)
+
+	DescribeTable("invalid registry upstreams",
+		func(in, wantErr string) {
+			_, err := ParseUpstream(in)
+			Expect(err).Should(MatchError(ContainSubstring(wantErr)))
+		},
+		Entry("missing service", "consul://", "missing consul key"),
+		Entry("missing prefix", "etcd://", "missing etcd key"),
+		Entry("invalid service name", "consul://dns_resolver", "invalid consul service name 'dns_resolver'"),
+	)
+
+	It("should not parse other schemes as registry", func() {
+		u, err := ParseUpstream("https://consul.example.com/dns-query")
+		Expect(err).Should(Succeed())
+		Expect(u.IsRegistry()).Should(BeFalse())
+	})
+
+	Describe("HasRegistryUpstreams", func() {
+		It("should find a registry upstream", func() {
+			Expect(HasRegistryUpstreams([]Upstream{{Net: NetProtocolTcpUdp, Host: "9.9.9.9", Port: 53}})).Should(BeFalse())
+			Expect(HasRegistryUpstreams([]Upstream{{Registry: RegistryEtcd, Path: "/dns/"}})).Should(BeTrue())
+		})
+	})
+
+	Describe("EndpointList", func() {
+		It("should use the local endpoint by default", func() {
+			Expect((&EtcdRegistry{}).EndpointList()).Should(Equal([]string{"http://127.0.0.1:2379"}))
+			Expect((&EtcdRegistry{Endpoints: []string{"https://etcd:2379"}}).EndpointList()).
+				Should(Equal([]string{"https://etcd:2379"}))
+		})
+	})
+
+	Describe("validate", func() {
+		It("should fix invalid settings", func() {
+			c := UpstreamRegistry{Consul: ConsulRegistry{Wait: -1}}
+
+			c.validate(logger)
+
+			Expect(c.Consul.Address).Should(Equal("http://127.0.0.1:8500"))
+			Expect(c.Consul.Wait).Should(Equal(mustDefault[ConsulRegistry]().Wait))
+			Expect(hook.Messages).Should(ContainElements(
+				ContainSubstring("consul.address is empty"),
+				ContainSubstring("consul.wait <= 0"),
+			))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log the registries of the upstream groups", func() {
+			c := mustDefault[Upstreams]()
+			c.Groups = UpstreamGroups{"default": {{Registry: RegistryConsul, Path: "dns"}}}
+
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"    - consul://dns",
+				"registry:",
+				"consul: http://127.0.0.1:8500",
+				"etcd: http://127.0.0.1:2379",
+			))
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// UpstreamTruncation configures the handling of truncated UDP answers (TC bit) of `tcp+udp` upstreams, which are
+// queried over UDP and TCP in parallel
+type UpstreamTruncation struct {
+	// retry the query over TCP, if the UDP answer is truncated and the parallel TCP query failed
+	RetryTCP bool `default:"false" yaml:"retryTCP"`
+	// truncated answers of these query types are returned without waiting for the TCP answer
+	TrustTypes QTypeSet `yaml:"trustTypes"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *UpstreamTruncation) IsEnabled() bool {
+	return c.RetryTCP || len(c.TrustTypes) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *UpstreamTruncation) LogConfig(logger *logrus.Entry) {
+	logger.Infof("retry over TCP: %t", c.RetryTCP)
+
+	if len(c.TrustTypes) != 0 {
+		logger.Info("trusted query types:")
+
+		for qType := range c.TrustTypes {
+			logger.Infof("  - %s", qType)
+		}
+	}
+}
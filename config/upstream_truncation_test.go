@@ -0,0 +1,39 @@
+package config
+
+import (
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpstreamTruncation", func() {
+	var cfg UpstreamTruncation
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = UpstreamTruncation{RetryTCP: true, TrustTypes: NewQTypeSet(dns.Type(dns.TypeA))}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := UpstreamTruncation{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with trusted types only", func() {
+			cfg.RetryTCP = false
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements("retry over TCP: true", "trusted query types:", "  - A"))
+		})
+	})
+})
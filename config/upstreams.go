@@ -1,7 +1,14 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
 	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
 )
 
@@ -9,15 +16,193 @@ const UpstreamDefaultCfgName = "default"
 
 // Upstreams upstream servers configuration
 type Upstreams struct {
-	Init      Init             `yaml:"init"`
-	Timeout   Duration         `default:"2s"            yaml:"timeout"` // always > 0
-	Groups    UpstreamGroups   `yaml:"groups"`
-	Strategy  UpstreamStrategy `default:"parallel_best" yaml:"strategy"`
-	UserAgent string           `yaml:"userAgent"`
+	Init            Init                       `yaml:"init"`
+	Timeout         Duration                   `default:"2s"            yaml:"timeout"` // always > 0
+	Groups          UpstreamGroups             `yaml:"groups"`
+	Strategy        UpstreamStrategy           `default:"parallel_best" yaml:"strategy"`
+	UserAgent       string                     `yaml:"userAgent"`
+	HijackDetection HijackDetection            `yaml:"hijackDetection"`
+	RandomizeCase   bool                       `yaml:"randomizeCase"`
+	Truncation      UpstreamTruncation         `yaml:"truncation"`
+	Cookies         bool                       `yaml:"cookies"`
+	WeightHalfLife  Duration                   `default:"5m"            yaml:"weightHalfLife"`
+	Bind            map[string]UpstreamBinding `yaml:"bind"`
+	DoH             map[string]DoHUpstream     `yaml:"doh"`
+	// identifier of the querying client per upstream, it is sent only to these upstreams
+	ClientIdentifier map[string]UpstreamClientIdentifier `yaml:"clientIdentifier"`
+	// upstream group per client identifier, like `blocking.clientGroupsBlock`
+	ClientGroupsUpstream map[string]string `yaml:"clientGroupsUpstream"`
+	// discovery of the upstreams per group, e.g. from a Kubernetes headless Service
+	Discovery map[string]UpstreamDiscovery `yaml:"discovery"`
+	// connections to the service registries of upstreams like `consul://dns`
+	Registry UpstreamRegistry `yaml:"registry"`
 }
 
 type UpstreamGroups map[string][]Upstream
 
+// UpstreamBinding configures the source of the sockets to the upstreams of a group
+type UpstreamBinding struct {
+	SourceIP  net.IP `yaml:"sourceIP"`
+	Interface string `yaml:"interface"`
+}
+
+// IsEnabled returns true, if the sockets are bound to a source IP or an interface
+func (b UpstreamBinding) IsEnabled() bool {
+	return b.SourceIP != nil || b.Interface != ""
+}
+
+func (b UpstreamBinding) String() string {
+	parts := make([]string, 0, 2) //nolint:mnd
+
+	if b.SourceIP != nil {
+		parts = append(parts, "source IP "+b.SourceIP.String())
+	}
+
+	if b.Interface != "" {
+		parts = append(parts, "interface "+b.Interface)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// DoHUpstream configures the HTTP requests to a DoH upstream
+type DoHUpstream struct {
+	Method  DoHMethod         `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// UpstreamClientIdentifier configures the EDNS0 local option with the identifier of the querying client
+type UpstreamClientIdentifier upstreamClientIdentifier
+
+// upstreamClientIdentifier is used to avoid infinite recursion in `UpstreamClientIdentifier.UnmarshalYAML`
+type upstreamClientIdentifier struct {
+	Code  uint16                `default:"65001" yaml:"code"`
+	Value ClientIdentifierValue `default:"name"  yaml:"value"`
+}
+
+// UnmarshalYAML sets the default values, which are not applied to map values otherwise
+func (c *UpstreamClientIdentifier) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var i upstreamClientIdentifier
+	if err := defaults.Set(&i); err != nil {
+		return err
+	}
+
+	if err := unmarshal(&i); err != nil {
+		return err
+	}
+
+	*c = UpstreamClientIdentifier(i)
+
+	return nil
+}
+
+// UpstreamDiscovery resolves the upstreams of a group periodically from DNS: the A/AAAA records of a name
+// (e.g. a Kubernetes headless Service), its SRV records or the designated resolvers of a resolver (DDR)
+type UpstreamDiscovery upstreamDiscovery
+
+// upstreamDiscovery is used to avoid infinite recursion in `UpstreamDiscovery.UnmarshalYAML`
+type upstreamDiscovery struct {
+	Name     string   `yaml:"name"`
+	SRV      bool     `default:"false" yaml:"srv"`
+	Port     uint16   `default:"53"    yaml:"port"`
+	Interval Duration `default:"30s"   yaml:"interval"`
+	// unencrypted resolver of the network, which is asked for its encrypted endpoints (RFC 9462)
+	DDR Upstream `yaml:"ddr"`
+}
+
+// UnmarshalYAML sets the default values, which are not applied to map values otherwise
+func (c *UpstreamDiscovery) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var d upstreamDiscovery
+	if err := defaults.Set(&d); err != nil {
+		return err
+	}
+
+	if err := unmarshal(&d); err != nil {
+		return err
+	}
+
+	*c = UpstreamDiscovery(d)
+
+	return nil
+}
+
+func (c UpstreamDiscovery) String() string {
+	if c.IsDDR() {
+		return fmt.Sprintf("designated resolvers of %s every %s", c.DDR, c.Interval)
+	}
+
+	if c.SRV {
+		return fmt.Sprintf("SRV %s every %s", c.Name, c.Interval)
+	}
+
+	return fmt.Sprintf("%s:%d every %s", c.Name, c.Port, c.Interval)
+}
+
+// IsDDR returns true, if the designated resolvers of an unencrypted resolver are discovered
+func (c UpstreamDiscovery) IsDDR() bool {
+	return !c.DDR.IsDefault()
+}
+
+// HasGroup returns true, if the group has static upstreams or a discovery
+func (c *Upstreams) HasGroup(name string) bool {
+	_, static := c.Groups[name]
+	_, discovered := c.Discovery[name]
+
+	return static || discovered
+}
+
+// GroupNames returns the names of the groups with static upstreams or a discovery
+func (c *Upstreams) GroupNames() []string {
+	names := make([]string, 0, len(c.Groups)+len(c.Discovery))
+
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+
+	for name := range c.Discovery {
+		if _, ok := c.Groups[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// usesRegistry returns true, if a group contains an upstream of a service registry
+func (c *Upstreams) usesRegistry() bool {
+	for _, upstreams := range c.Groups {
+		if HasRegistryUpstreams(upstreams) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DoHOptions returns the configuration of the requests to the DoH upstream
+func (c *Upstreams) DoHOptions(upstream Upstream) DoHUpstream {
+	for key, options := range c.DoH {
+		if u, err := ParseUpstream(key); err == nil && u == upstream {
+			return options
+		}
+	}
+
+	return DoHUpstream{}
+}
+
+// ClientIdentifierOf returns the client identifier, which is sent to the upstream, or nil
+func (c *Upstreams) ClientIdentifierOf(upstream Upstream) *UpstreamClientIdentifier {
+	for key, identifier := range c.ClientIdentifier {
+		if u, err := ParseUpstream(key); err == nil && u == upstream {
+			return &identifier
+		}
+	}
+
+	return nil
+}
+
 func (c *Upstreams) validate(logger *logrus.Entry) {
 	defaults := mustDefault[Upstreams]()
 
@@ -25,11 +210,89 @@ func (c *Upstreams) validate(logger *logrus.Entry) {
 		logger.Warnf("upstreams.timeout <= 0, setting to %s", defaults.Timeout)
 		c.Timeout = defaults.Timeout
 	}
+
+	if c.HijackDetection.IsEnabled() && !c.HijackDetection.Interval.IsAboveZero() {
+		logger.Warnf("upstreams.hijackDetection.interval <= 0, setting to %s", defaults.HijackDetection.Interval)
+		c.HijackDetection.Interval = defaults.HijackDetection.Interval
+	}
+
+	for group := range c.Bind {
+		if !c.HasGroup(group) {
+			logger.Warnf("upstreams.bind.%s: no upstream group with this name", group)
+		}
+	}
+
+	for key := range c.DoH {
+		if u, err := ParseUpstream(key); err != nil || u.Net != NetProtocolHttps {
+			logger.Warnf("upstreams.doh.%s: not a DoH upstream", key)
+		}
+	}
+
+	for key, identifier := range c.ClientIdentifier {
+		if _, err := ParseUpstream(key); err != nil {
+			logger.Warnf("upstreams.clientIdentifier.%s: invalid upstream", key)
+		}
+
+		if identifier.Code < dns.EDNS0LOCALSTART || identifier.Code > dns.EDNS0LOCALEND {
+			logger.Warnf("upstreams.clientIdentifier.%s.code: %d is not a local option code (%d - %d)",
+				key, identifier.Code, dns.EDNS0LOCALSTART, dns.EDNS0LOCALEND)
+		}
+	}
+
+	for identifier, group := range c.ClientGroupsUpstream {
+		if !c.HasGroup(group) {
+			logger.Warnf("upstreams.clientGroupsUpstream.%s: no upstream group with the name %s", identifier, group)
+		}
+	}
+
+	if !c.WeightHalfLife.IsAboveZero() {
+		logger.Warnf("upstreams.weightHalfLife <= 0, setting to %s", defaults.WeightHalfLife)
+		c.WeightHalfLife = defaults.WeightHalfLife
+	}
+
+	c.validateDiscovery(logger)
+	c.Registry.validate(logger)
+}
+
+func (c *Upstreams) validateDiscovery(logger *logrus.Entry) {
+	defaults := mustDefault[UpstreamDiscovery]()
+
+	for group, discovery := range c.Discovery {
+		if discovery.IsDDR() {
+			// the certificates of the designated resolvers must contain the IP of the queried resolver
+			if discovery.DDR.Net != NetProtocolTcpUdp || net.ParseIP(discovery.DDR.Host) == nil {
+				logger.Warnf("upstreams.discovery.%s.ddr must be the IP of a plain DNS resolver, the discovery is disabled",
+					group)
+				delete(c.Discovery, group)
+
+				continue
+			}
+
+			if discovery.Name != "" {
+				logger.Warnf("upstreams.discovery.%s: ddr is used, the name is ignored", group)
+			}
+		} else if discovery.Name == "" {
+			logger.Warnf("upstreams.discovery.%s.name is empty, the discovery is disabled", group)
+			delete(c.Discovery, group)
+
+			continue
+		}
+
+		if HasRegistryUpstreams(c.Groups[group]) {
+			logger.Warnf("upstreams.discovery.%s: the service registry upstreams of the group are ignored", group)
+		}
+
+		if !discovery.Interval.IsAboveZero() {
+			logger.Warnf("upstreams.discovery.%s.interval <= 0, setting to %s", group, defaults.Interval)
+			discovery.Interval = defaults.Interval
+			c.Discovery[group] = discovery
+		}
+	}
 }
 
 // IsEnabled implements `config.Configurable`.
 func (c *Upstreams) IsEnabled() bool {
-	return len(c.Groups) != 0
+	return len(c.Groups) != 0 || len(c.Discovery) != 0
 }
 
 // LogConfig implements `config.Configurable`.
@@ -39,15 +302,80 @@ func (c *Upstreams) LogConfig(logger *logrus.Entry) {
 
 	logger.Info("timeout: ", c.Timeout)
 	logger.Info("strategy: ", c.Strategy)
+
+	if c.Strategy != UpstreamStrategyStrict {
+		logger.Info("weight half-life: ", c.WeightHalfLife)
+	}
+
+	if c.RandomizeCase {
+		logger.Info("randomize case: enabled")
+	}
+
+	if c.Cookies {
+		logger.Info("cookies: enabled")
+	}
+
+	if c.Truncation.IsEnabled() {
+		logger.Info("truncation:")
+		log.WithIndent(logger, "  ", c.Truncation.LogConfig)
+	}
+
+	if c.HijackDetection.IsEnabled() {
+		logger.Info("hijack detection:")
+		log.WithIndent(logger, "  ", c.HijackDetection.LogConfig)
+	}
+
 	logger.Info("groups:")
 
 	for name, upstreams := range c.Groups {
 		logger.Infof("  %s:", name)
 
+		if binding := c.Bind[name]; binding.IsEnabled() {
+			logger.Infof("    bind: %s", binding)
+		}
+
 		for _, upstream := range upstreams {
 			logger.Infof("    - %s", upstream)
 		}
 	}
+
+	if len(c.Discovery) != 0 {
+		logger.Info("discovery:")
+
+		for group, discovery := range c.Discovery {
+			logger.Infof("  %s = %s", group, discovery)
+		}
+	}
+
+	if c.usesRegistry() {
+		logger.Info("registry:")
+		log.WithIndent(logger, "  ", c.Registry.LogConfig)
+	}
+
+	if len(c.ClientGroupsUpstream) != 0 {
+		logger.Info("clientGroupsUpstream:")
+
+		for identifier, group := range c.ClientGroupsUpstream {
+			logger.Infof("  %s = %s", identifier, group)
+		}
+	}
+
+	if len(c.ClientIdentifier) != 0 {
+		logger.Info("client identifier:")
+
+		for key, identifier := range c.ClientIdentifier {
+			logger.Infof("  %s: %s as option %d", key, identifier.Value, identifier.Code)
+		}
+	}
+
+	if len(c.DoH) != 0 {
+		logger.Info("doh:")
+
+		for key, options := range c.DoH {
+			logger.Infof("  %s:", key)
+			log.WithIndent(logger, "    ", options.LogConfig)
+		}
+	}
 }
 
 // UpstreamGroup represents the config for one group (upstream branch)
@@ -75,6 +403,11 @@ func (c *UpstreamGroup) GroupUpstreams() []Upstream {
 	return c.Groups[c.Name]
 }
 
+// Binding returns the source binding of the sockets to the upstreams of the group
+func (c *UpstreamGroup) Binding() UpstreamBinding {
+	return c.Bind[c.Name]
+}
+
 // IsEnabled implements `config.Configurable`.
 func (c *UpstreamGroup) IsEnabled() bool {
 	return len(c.GroupUpstreams()) != 0
@@ -83,9 +416,31 @@ func (c *UpstreamGroup) IsEnabled() bool {
 // LogConfig implements `config.Configurable`.
 func (c *UpstreamGroup) LogConfig(logger *logrus.Entry) {
 	logger.Info("group: ", c.Name)
+
+	if binding := c.Binding(); binding.IsEnabled() {
+		logger.Info("bind: ", binding)
+	}
+
 	logger.Info("upstreams:")
 
 	for _, upstream := range c.GroupUpstreams() {
 		logger.Infof("  - %s", upstream)
 	}
 }
+
+// LogConfig logs the method and the names of the headers, the values can contain credentials
+func (c DoHUpstream) LogConfig(logger *logrus.Entry) {
+	logger.Info("method: ", c.Method)
+
+	if len(c.Headers) != 0 {
+		names := make([]string, 0, len(c.Headers))
+
+		for name := range c.Headers {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		logger.Info("headers: ", strings.Join(names, ", "))
+	}
+}
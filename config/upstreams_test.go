@@ -1,11 +1,13 @@
 package config
 
 import (
+	"net"
 	"time"
 
 	"github.com/creasty/defaults"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("ParallelBestConfig", func() {
@@ -60,6 +62,79 @@ var _ = Describe("ParallelBestConfig", func() {
 					ContainSubstring(":host2:"),
 				))
 			})
+
+			It("should log the case randomization", func() {
+				cfg.RandomizeCase = true
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement("randomize case: enabled"))
+			})
+
+			It("should log the binding of a group", func() {
+				cfg.Bind = map[string]UpstreamBinding{
+					UpstreamDefaultCfgName: {SourceIP: net.ParseIP("192.0.2.1"), Interface: "eth0"},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement("    bind: source IP 192.0.2.1, interface eth0"))
+			})
+
+			It("should log the DoH options without header values", func() {
+				cfg.DoH = map[string]DoHUpstream{
+					"https://dns.nextdns.io/abc123": {
+						Method:  DoHMethodGet,
+						Headers: map[string]string{"X-Token": "secret", "Authorization": "Bearer secret"},
+					},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					"  https://dns.nextdns.io/abc123:",
+					"method: get",
+					"headers: Authorization, X-Token",
+				))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("secret")))
+			})
+
+			It("should log the client identifiers", func() {
+				cfg.ClientIdentifier = map[string]UpstreamClientIdentifier{
+					"192.168.178.1": {Code: 65001, Value: ClientIdentifierValueMac},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					"client identifier:",
+					"  192.168.178.1: mac as option 65001",
+				))
+			})
+
+			It("should log the client groups mapping", func() {
+				cfg.ClientGroupsUpstream = map[string]string{"kid-*": UpstreamDefaultCfgName}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					"clientGroupsUpstream:",
+					"  kid-* = default",
+				))
+			})
+
+			It("should log the discovery", func() {
+				cfg.Discovery = map[string]UpstreamDiscovery{
+					"cluster": {Name: "dns.infra.svc.cluster.local", Port: 5353, Interval: Duration(time.Minute)},
+				}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					"discovery:",
+					"  cluster = dns.infra.svc.cluster.local:5353 every 1 minute",
+				))
+			})
 		})
 
 		Describe("validate", func() {
@@ -74,12 +149,216 @@ var _ = Describe("ParallelBestConfig", func() {
 				Expect(hook.Messages).Should(ContainElement(ContainSubstring("timeout")))
 			})
 
+			It("should compute hijack detection interval", func() {
+				cfg.HijackDetection.Enable = true
+				cfg.HijackDetection.Interval = 0
+
+				cfg.validate(logger)
+
+				Expect(cfg.HijackDetection.Interval).Should(BeNumerically(">", 0))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("hijackDetection.interval")))
+			})
+
+			It("should compute weight half-life", func() {
+				cfg.WeightHalfLife = 0
+
+				cfg.validate(logger)
+
+				Expect(cfg.WeightHalfLife).Should(BeNumerically(">", 0))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("weightHalfLife")))
+			})
+
+			It("should warn about a binding of an unknown group", func() {
+				cfg.Bind = map[string]UpstreamBinding{
+					UpstreamDefaultCfgName: {Interface: "eth0"},
+					"unknown":              {Interface: "tun0"},
+				}
+
+				cfg.validate(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("upstreams.bind.unknown")))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("upstreams.bind.default")))
+			})
+
+			It("should warn about DoH options of other upstreams", func() {
+				cfg.DoH = map[string]DoHUpstream{
+					"https://dns.nextdns.io/abc123": {Method: DoHMethodGet},
+					"tcp-tls:1.1.1.1":               {Method: DoHMethodGet},
+				}
+
+				cfg.validate(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("upstreams.doh.tcp-tls:1.1.1.1")))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("upstreams.doh.https")))
+			})
+
+			It("should warn about client groups mapped to unknown upstream groups", func() {
+				cfg.ClientGroupsUpstream = map[string]string{
+					"kid-*":  UpstreamDefaultCfgName,
+					"laptop": "unknown",
+				}
+
+				cfg.validate(logger)
+
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("upstreams.clientGroupsUpstream.laptop")))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("upstreams.clientGroupsUpstream.kid-*")))
+			})
+
+			It("should accept client groups mapped to discovered upstream groups", func() {
+				cfg.ClientGroupsUpstream = map[string]string{"laptop": "cluster"}
+				cfg.Discovery = map[string]UpstreamDiscovery{
+					"cluster": {Name: "dns.infra.svc.cluster.local", Interval: Duration(time.Minute)},
+				}
+
+				cfg.validate(logger)
+
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("upstreams.clientGroupsUpstream")))
+			})
+
+			It("should disable a discovery without name", func() {
+				cfg.Discovery = map[string]UpstreamDiscovery{"cluster": {Interval: Duration(time.Minute)}}
+
+				cfg.validate(logger)
+
+				Expect(cfg.Discovery).Should(BeEmpty())
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("upstreams.discovery.cluster.name")))
+			})
+
+			It("should disable a DDR discovery without the IP of a plain DNS resolver", func() {
+				cfg.Discovery = map[string]UpstreamDiscovery{
+					"home": {DDR: Upstream{Net: NetProtocolTcpTls, Host: "dns.quad9.net"}, Interval: Duration(time.Minute)},
+					"lab": {
+						Name:     "ignored",
+						DDR:      Upstream{Net: NetProtocolTcpUdp, Host: "192.168.178.1", Port: 53},
+						Interval: Duration(time.Minute),
+					},
+				}
+
+				cfg.validate(logger)
+
+				Expect(cfg.Discovery).Should(HaveKey("lab"))
+				Expect(cfg.Discovery).ShouldNot(HaveKey("home"))
+				Expect(hook.Messages).Should(ContainElements(
+					ContainSubstring("upstreams.discovery.home.ddr must be the IP"),
+					ContainSubstring("upstreams.discovery.lab: ddr is used"),
+				))
+			})
+
+			It("should compute the discovery interval", func() {
+				cfg.Discovery = map[string]UpstreamDiscovery{"cluster": {Name: "dns.infra.svc.cluster.local"}}
+
+				cfg.validate(logger)
+
+				Expect(cfg.Discovery["cluster"].Interval).Should(Equal(Duration(30 * time.Second)))
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring("upstreams.discovery.cluster.interval")))
+			})
+
+			It("should warn about invalid client identifiers", func() {
+				cfg.ClientIdentifier = map[string]UpstreamClientIdentifier{
+					"192.168.178.1": {Code: 65001},
+					"tcp+udp:":      {Code: 65001},
+					"192.168.178.2": {Code: 10},
+				}
+
+				cfg.validate(logger)
+
+				Expect(hook.Messages).Should(ContainElements(
+					ContainSubstring("upstreams.clientIdentifier.tcp+udp:"),
+					ContainSubstring("upstreams.clientIdentifier.192.168.178.2"),
+				))
+				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("upstreams.clientIdentifier.192.168.178.1")))
+			})
+
 			It("should not override valid user values", func() {
 				cfg.validate(logger)
 
 				Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("timeout")))
 			})
 		})
+
+		Describe("DoHOptions", func() {
+			It("should return the options of the upstream", func() {
+				options := DoHUpstream{Method: DoHMethodPost, Headers: map[string]string{"X-Token": "secret"}}
+				cfg.DoH = map[string]DoHUpstream{"https://dns.nextdns.io:443/abc123": options}
+
+				Expect(cfg.DoHOptions(Upstream{
+					Net: NetProtocolHttps, Host: "dns.nextdns.io", Port: 443, Path: "/abc123",
+				})).Should(Equal(options))
+			})
+
+			It("should return the defaults for other upstreams", func() {
+				cfg.DoH = map[string]DoHUpstream{"https://dns.nextdns.io/abc123": {Method: DoHMethodPost}}
+
+				Expect(cfg.DoHOptions(Upstream{
+					Net: NetProtocolHttps, Host: "dns.nextdns.io", Port: 443, Path: "/other",
+				})).Should(Equal(DoHUpstream{}))
+			})
+		})
+
+		Describe("ClientIdentifierOf", func() {
+			It("should return the identifier of the upstream", func() {
+				cfg.ClientIdentifier = map[string]UpstreamClientIdentifier{
+					"192.168.178.1": {Code: 65073, Value: ClientIdentifierValueIp},
+				}
+
+				Expect(cfg.ClientIdentifierOf(Upstream{Net: NetProtocolTcpUdp, Host: "192.168.178.1", Port: 53})).
+					Should(Equal(&UpstreamClientIdentifier{Code: 65073, Value: ClientIdentifierValueIp}))
+				Expect(cfg.ClientIdentifierOf(Upstream{Net: NetProtocolTcpUdp, Host: "192.168.178.2", Port: 53})).
+					Should(BeNil())
+			})
+
+			It("should apply the defaults", func() {
+				Expect(yaml.Unmarshal([]byte("192.168.178.1: {}\n192.168.178.2: {value: mac}"), &cfg.ClientIdentifier)).
+					Should(Succeed())
+
+				Expect(cfg.ClientIdentifier).Should(Equal(map[string]UpstreamClientIdentifier{
+					"192.168.178.1": {Code: 65001, Value: ClientIdentifierValueName},
+					"192.168.178.2": {Code: 65001, Value: ClientIdentifierValueMac},
+				}))
+			})
+		})
+	})
+
+	Context("UpstreamDiscovery", func() {
+		It("should apply the defaults", func() {
+			var discovery map[string]UpstreamDiscovery
+
+			Expect(yaml.Unmarshal([]byte("cluster: {name: _dns._udp.dns.infra.svc.cluster.local, srv: true}"), &discovery)).
+				Should(Succeed())
+
+			Expect(discovery).Should(Equal(map[string]UpstreamDiscovery{
+				"cluster": {
+					Name:     "_dns._udp.dns.infra.svc.cluster.local",
+					SRV:      true,
+					Port:     53,
+					Interval: Duration(30 * time.Second),
+				},
+			}))
+		})
+
+		It("should list the discovered groups", func() {
+			cfg := Upstreams{
+				Groups:    UpstreamGroups{UpstreamDefaultCfgName: {{Host: "host1"}}, "lab": {{Host: "host2"}}},
+				Discovery: map[string]UpstreamDiscovery{"cluster": {}, "lab": {}},
+			}
+
+			Expect(cfg.GroupNames()).Should(Equal([]string{"cluster", UpstreamDefaultCfgName, "lab"}))
+			Expect(cfg.HasGroup("cluster")).Should(BeTrue())
+			Expect(cfg.HasGroup("unknown")).Should(BeFalse())
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should describe the discovery", func() {
+			Expect(UpstreamDiscovery{Name: "_dns._udp.dns", SRV: true, Interval: Duration(time.Minute)}.String()).
+				Should(Equal("SRV _dns._udp.dns every 1 minute"))
+
+			ddr := UpstreamDiscovery{
+				DDR:      Upstream{Net: NetProtocolTcpUdp, Host: "192.168.178.1", Port: 53},
+				Interval: Duration(time.Minute),
+			}
+			Expect(ddr.IsDDR()).Should(BeTrue())
+			Expect(ddr.String()).Should(Equal("designated resolvers of tcp+udp:192.168.178.1 every 1 minute"))
+		})
 	})
 
 	Context("UpstreamGroupConfig", func() {
@@ -130,6 +409,30 @@ var _ = Describe("ParallelBestConfig", func() {
 					ContainSubstring(":host2:"),
 				))
 			})
+
+			It("should log the binding", func() {
+				cfg.Bind = map[string]UpstreamBinding{"test": {Interface: "tun0"}}
+
+				cfg.LogConfig(logger)
+
+				Expect(hook.Messages).Should(ContainElement("bind: interface tun0"))
+			})
+		})
+
+		Describe("Binding", func() {
+			It("should return the binding of the group", func() {
+				cfg.Bind = map[string]UpstreamBinding{
+					"test":  {SourceIP: net.ParseIP("192.0.2.1")},
+					"other": {Interface: "tun0"},
+				}
+
+				Expect(cfg.Binding()).Should(Equal(UpstreamBinding{SourceIP: net.ParseIP("192.0.2.1")}))
+				Expect(cfg.Binding().IsEnabled()).Should(BeTrue())
+			})
+
+			It("should be disabled without binding", func() {
+				Expect(cfg.Binding().IsEnabled()).Should(BeFalse())
+			})
 		})
 	})
 })
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// XDP configuration of the fast path, which answers cached UDP queries with an XDP program (Linux only)
+type XDP struct {
+	// network interfaces, the XDP program is attached to
+	Interfaces []string `yaml:"interfaces"`
+	// maximum count of cached answers in the kernel
+	MaxEntries uint32 `default:"10000" yaml:"maxEntries"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *XDP) IsEnabled() bool {
+	return len(c.Interfaces) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *XDP) LogConfig(logger *logrus.Entry) {
+	logger.Infof("interfaces = %s", strings.Join(c.Interfaces, ", "))
+	logger.Infof("maxEntries = %d", c.MaxEntries)
+}
+
+// validate disables the fast path, if its answers would differ from the answers of the resolvers
+func (c *XDP) validate(logger *logrus.Entry, cfg *Config) {
+	if !c.IsEnabled() {
+		return
+	}
+
+	disable := func(reason string) {
+		logger.Warnf("xdp: %s, the fast path is disabled", reason)
+
+		c.Interfaces = nil
+	}
+
+	if c.MaxEntries == 0 {
+		disable("maxEntries must be above 0")
+
+		return
+	}
+
+	if !cfg.Caching.IsEnabled() {
+		disable("caching is disabled")
+
+		return
+	}
+
+	for group := range cfg.Blocking.ClientGroupsBlock {
+		if group != "default" {
+			disable("blocking is configured per client group")
+
+			return
+		}
+	}
+
+	// the program answers all clients alike and the resolvers don't see the answered queries
+	checks := []struct {
+		active bool
+		reason string
+	}{
+		{cfg.ZoneVisibility.IsEnabled(), "zoneVisibility is configured"},
+		{filteringPerClientGroup(&cfg.Filtering), "filtering is configured per client group"},
+		{cfg.Filtering.FilterAAAA.IsEnabled(), "filtering.filterAAAA is enabled"},
+		{len(cfg.FQDNOnly.ClientGroups) != 0, "fqdnOnly is configured per client group"},
+		{len(cfg.SUDN.ClientGroups) != 0, "specialUseDomains is configured per client group"},
+		{cfg.Quotas.IsEnabled(), "quotas are configured"},
+		{len(cfg.Tenancy.Tenants) != 0, "tenants are configured"},
+		{
+			len(cfg.Upstreams.ClientGroupsUpstream) != 0 || len(cfg.Upstreams.Groups) > 1,
+			"upstreams are configured per client group",
+		},
+		{cfg.Cookies.Enable && cfg.Cookies.EnforceUDP, "cookies.enforceUDP is enabled"},
+		{cfg.QueryLog.IsEnabled(), "the query log is enabled"},
+		{cfg.Prometheus.IsEnabled(), "the prometheus metrics are enabled"},
+		{cfg.ClientStats.IsEnabled(), "clientStats is enabled"},
+		{cfg.Statistics.IsEnabled(), "statistics exclusions are configured"},
+	}
+
+	for _, check := range checks {
+		if check.active {
+			disable(check.reason)
+
+			return
+		}
+	}
+
+	ports := make(map[string]struct{})
+
+	for _, address := range cfg.Ports.DNS {
+		if _, port, err := net.SplitHostPort(address); err == nil {
+			ports[port] = struct{}{}
+		}
+	}
+
+	if len(ports) != 1 {
+		disable("ports.dns must listen on exactly one port")
+	}
+}
+
+func filteringPerClientGroup(c *Filtering) bool {
+	for _, qTypes := range c.ClientGroups {
+		if len(qTypes) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
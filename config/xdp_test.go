@@ -0,0 +1,135 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/log"
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("XDP", func() {
+	var (
+		c   XDP
+		cfg Config
+	)
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		c = XDP{}
+		cfg = Config{}
+		Expect(defaults.Set(&c)).Should(Succeed())
+		Expect(defaults.Set(&cfg)).Should(Succeed())
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be disabled by default", func() {
+			Expect(c.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be enabled with interfaces", func() {
+			c.Interfaces = []string{"eth0"}
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		BeforeEach(func() {
+			logger, hook = log.NewMockEntry()
+
+			c.Interfaces = []string{"eth0", "eth1"}
+		})
+
+		It("should log configuration", func() {
+			c.LogConfig(logger)
+
+			Expect(hook.Messages).Should(SatisfyAll(
+				ContainElement(ContainSubstring("interfaces = eth0, eth1")),
+				ContainElement(ContainSubstring("maxEntries = 10000")),
+			))
+		})
+	})
+
+	Describe("validate", func() {
+		BeforeEach(func() {
+			c.Interfaces = []string{"eth0"}
+
+			cfg.QueryLog.Type = QueryLogTypeNone
+		})
+
+		It("should keep a valid configuration", func() {
+			c.validate(logger, &cfg)
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+			Expect(hook.Calls).Should(BeEmpty())
+		})
+
+		It("should keep blocking for all clients", func() {
+			cfg.Blocking.ClientGroupsBlock = map[string][]string{"default": {"ads"}}
+
+			c.validate(logger, &cfg)
+
+			Expect(c.IsEnabled()).Should(BeTrue())
+		})
+
+		DescribeTable("should disable the fast path",
+			func(change func(), message string) {
+				change()
+
+				c.validate(logger, &cfg)
+
+				Expect(c.IsEnabled()).Should(BeFalse())
+				Expect(hook.Messages).Should(ContainElement(ContainSubstring(message)))
+			},
+			Entry("without entries", func() { c.MaxEntries = 0 }, "maxEntries must be above 0"),
+			Entry("without caching", func() { cfg.Caching.MaxCachingTime = Duration(-1) }, "caching is disabled"),
+			Entry("with blocking per client group",
+				func() { cfg.Blocking.ClientGroupsBlock = map[string][]string{"kids": {"ads"}} },
+				"blocking is configured per client group"),
+			Entry("with different DNS ports",
+				func() { cfg.Ports.DNS = ListenConfig{":53", "127.0.0.1:5353"} },
+				"ports.dns must listen on exactly one port"),
+			Entry("without DNS port", func() { cfg.Ports.DNS = nil }, "ports.dns must listen on exactly one port"),
+			Entry("with zone visibility",
+				func() { cfg.ZoneVisibility.Clients = []string{"192.168.178.0/24"} },
+				"zoneVisibility is configured"),
+			Entry("with filtering per client group",
+				func() { cfg.Filtering.ClientGroups = map[string]QTypeSet{"kids": NewQTypeSet(dns.Type(dns.TypeAAAA))} },
+				"filtering is configured per client group"),
+			Entry("with AAAA filtering",
+				func() { cfg.Filtering.FilterAAAA.Mode = FilterAAAAModeOnV4 },
+				"filtering.filterAAAA is enabled"),
+			Entry("with FQDN only per client group",
+				func() { cfg.FQDNOnly.ClientGroups = map[string]bool{"kids": true} },
+				"fqdnOnly is configured per client group"),
+			Entry("with special use domains per client group",
+				func() { cfg.SUDN.ClientGroups = map[string]SUDNRules{"kids": {}} },
+				"specialUseDomains is configured per client group"),
+			Entry("with quotas",
+				func() { cfg.Quotas.Categories = map[string]QuotaCategory{"social": {Domains: []string{"social.com"}}} },
+				"quotas are configured"),
+			Entry("with tenants",
+				func() { cfg.Tenancy.Tenants = map[string]Tenant{"home": {Clients: []string{"192.168.178.0/24"}}} },
+				"tenants are configured"),
+			Entry("with upstreams per client group",
+				func() { cfg.Upstreams.ClientGroupsUpstream = map[string]string{"tag:kids": "family"} },
+				"upstreams are configured per client group"),
+			Entry("with upstream groups",
+				func() { cfg.Upstreams.Groups = UpstreamGroups{"default": nil, "kids": nil} },
+				"upstreams are configured per client group"),
+			Entry("with enforced cookies",
+				func() { cfg.Cookies = Cookies{Enable: true, EnforceUDP: true} },
+				"cookies.enforceUDP is enabled"),
+			Entry("with query log", func() { cfg.QueryLog.Type = QueryLogTypeConsole }, "the query log is enabled"),
+			Entry("with metrics", func() { cfg.Prometheus.Enable = true }, "the prometheus metrics are enabled"),
+			Entry("with client statistics",
+				func() { cfg.ClientStats = ClientStats{Enable: true, RetentionDays: 7} },
+				"clientStats is enabled"),
+			Entry("with statistics exclusions",
+				func() { cfg.Statistics.Exclude.SelfCheck = true },
+				"statistics exclusions are configured"),
+		)
+	})
+})
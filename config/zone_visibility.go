@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ZoneVisibility restricts the internal zones (custom DNS and conditional domains) to listeners and clients
+type ZoneVisibility struct {
+	Zones     []string                `yaml:"zones"`
+	Listeners []model.RequestListener `yaml:"listeners"`
+	Clients   []string                `yaml:"clients"`
+}
+
+// IsEnabled implements `config.Configurable`.
+func (c *ZoneVisibility) IsEnabled() bool {
+	return len(c.Listeners) != 0 || len(c.Clients) != 0
+}
+
+// LogConfig implements `config.Configurable`.
+func (c *ZoneVisibility) LogConfig(logger *logrus.Entry) {
+	listeners := make([]string, len(c.Listeners))
+	for i, listener := range c.Listeners {
+		listeners[i] = listener.String()
+	}
+
+	logger.Infof("listeners: %s", strings.Join(listeners, ", "))
+	logger.Infof("clients: %s", strings.Join(c.Clients, ", "))
+
+	if len(c.Zones) != 0 {
+		logger.Infof("additional zones: %s", strings.Join(c.Zones, ", "))
+	}
+}
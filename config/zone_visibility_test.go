@@ -0,0 +1,71 @@
+package config
+
+import (
+	"github.com/0xERR0R/blocky/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("ZoneVisibility", func() {
+	var cfg ZoneVisibility
+
+	suiteBeforeEach()
+
+	BeforeEach(func() {
+		cfg = ZoneVisibility{
+			Listeners: []model.RequestListener{model.RequestListenerDns, model.RequestListenerTls},
+			Clients:   []string{"192.168.178.0/24"},
+		}
+	})
+
+	Describe("IsEnabled", func() {
+		It("should be false by default", func() {
+			cfg := ZoneVisibility{}
+
+			Expect(cfg.IsEnabled()).Should(BeFalse())
+		})
+
+		It("should be true with listeners", func() {
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+
+		It("should be true with clients only", func() {
+			cfg.Listeners = nil
+
+			Expect(cfg.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			cfg.Zones = []string{"corp.example"}
+
+			cfg.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"listeners: dns, tls",
+				"clients: 192.168.178.0/24",
+				"additional zones: corp.example",
+			))
+		})
+	})
+
+	Describe("YAML", func() {
+		It("should parse listeners", func() {
+			var cfg ZoneVisibility
+
+			Expect(yaml.Unmarshal([]byte("listeners: [dns, https]"), &cfg)).Should(Succeed())
+
+			Expect(cfg.Listeners).Should(Equal([]model.RequestListener{
+				model.RequestListenerDns, model.RequestListenerHttps,
+			}))
+		})
+
+		It("should fail on unknown listener", func() {
+			var cfg ZoneVisibility
+
+			Expect(yaml.Unmarshal([]byte("listeners: [wan]"), &cfg)).ShouldNot(Succeed())
+		})
+	})
+})
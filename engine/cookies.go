@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+)
+
+// Cookie lengths in bytes (RFC 7873) and the server cookie layout (RFC 9018):
+// version (1), reserved (3), timestamp (4), hash (8)
+const (
+	clientCookieLen    = 8
+	minServerCookieLen = 8
+	maxServerCookieLen = 32
+	serverCookieLen    = 16
+	serverCookieHash   = 8
+	// offset of the timestamp in the server cookie
+	serverCookieTime = 4
+
+	serverCookieVersion = 1
+
+	// RFC 9018: server cookies are valid for one hour and may be up to five minutes in the future
+	serverCookieLifetime   = time.Hour
+	serverCookieClockSkew  = 5 * time.Minute
+	serverCookieRenewAfter = 30 * time.Minute
+)
+
+var errMalformedCookie = errors.New("malformed cookie")
+
+// requestCookie is the cookie of a request
+type requestCookie struct {
+	client []byte
+	// the server cookie was issued by this server for the client within its lifetime
+	valid bool
+	// the timestamp of the valid server cookie
+	issued time.Time
+}
+
+// newCookieSecret returns the configured secret of the server cookies or a random one
+func newCookieSecret(cfg config.Cookies) []byte {
+	if len(cfg.Secret) != 0 {
+		return cfg.Secret
+	}
+
+	secret := make([]byte, config.CookieSecretLen)
+	_, _ = rand.Read(secret)
+
+	return secret
+}
+
+// takeCookie removes the cookie from the request, so it isn't forwarded to the upstreams.
+// Returns nil, if cookies are disabled or the request has no cookie.
+func (e *Engine) takeCookie(request *model.Request) (*requestCookie, error) {
+	opt := request.Req.IsEdns0()
+	if !e.cfg.Cookies.IsEnabled() || opt == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	var cookieOpt *dns.EDNS0_COOKIE
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		if c, ok := o.(*dns.EDNS0_COOKIE); ok {
+			cookieOpt = c
+
+			return true
+		}
+
+		return false
+	})
+
+	if cookieOpt == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	cookie, err := hex.DecodeString(cookieOpt.Cookie)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedCookie, err)
+	}
+
+	serverLen := len(cookie) - clientCookieLen
+	if serverLen != 0 && (serverLen < minServerCookieLen || serverLen > maxServerCookieLen) {
+		return nil, fmt.Errorf("%w: invalid length %d", errMalformedCookie, len(cookie))
+	}
+
+	result := &requestCookie{client: cookie[:clientCookieLen]}
+
+	server := cookie[clientCookieLen:]
+	if len(server) != serverCookieLen || server[0] != serverCookieVersion {
+		return result, nil
+	}
+
+	issued := time.Unix(int64(binary.BigEndian.Uint32(server[serverCookieTime:])), 0)
+	now := time.Now()
+
+	if issued.Before(now.Add(-serverCookieLifetime)) || issued.After(now.Add(serverCookieClockSkew)) {
+		return result, nil
+	}
+
+	expected := e.serverCookie(result.client, request.ClientIP, issued)
+
+	result.valid = hmac.Equal(server, expected)
+	if result.valid {
+		result.issued = issued
+	}
+
+	return result, nil
+}
+
+// setCookie adds the client cookie and a server cookie to the response. The server cookie of the request is reused,
+// if it was issued recently.
+func (e *Engine) setCookie(request *model.Request, response *dns.Msg, cookie *requestCookie) {
+	issued := time.Now()
+	if cookie.valid && issued.Sub(cookie.issued) < serverCookieRenewAfter {
+		issued = cookie.issued
+	}
+
+	value := slices.Concat(cookie.client, e.serverCookie(cookie.client, request.ClientIP, issued))
+
+	opt := response.IsEdns0()
+	if opt == nil {
+		reqOpt := request.Req.IsEdns0()
+
+		response.SetEdns0(reqOpt.UDPSize(), reqOpt.Do())
+		opt = response.IsEdns0()
+	}
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		return o.Option() == dns.EDNS0COOKIE
+	})
+
+	opt.Option = append(opt.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: hex.EncodeToString(value)})
+}
+
+// serverCookie returns the server cookie for the client cookie and IP issued at the time. The hash is a truncated
+// HMAC-SHA256 instead of the SipHash of RFC 9018, so only blocky instances sharing the secret accept the cookies.
+func (e *Engine) serverCookie(client []byte, clientIP net.IP, issued time.Time) []byte {
+	header := make([]byte, serverCookieLen-serverCookieHash)
+	header[0] = serverCookieVersion
+	binary.BigEndian.PutUint32(header[serverCookieTime:], uint32(issued.Unix())) //nolint:gosec
+
+	ip := clientIP.To4()
+	if ip == nil {
+		ip = clientIP.To16()
+	}
+
+	mac := hmac.New(sha256.New, e.cookieSecret)
+	mac.Write(client)
+	mac.Write(header)
+	mac.Write(ip)
+
+	return append(header, mac.Sum(nil)[:serverCookieHash]...)
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cookies", func() {
+	const clientCookie = "0102030405060708"
+
+	var (
+		sut     *Engine
+		request *model.Request
+	)
+
+	withCookie := func(cookie string) *model.Request {
+		request.Req.SetEdns0(1232, false)
+		util.SetEdns0Option(request.Req, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+
+		return request
+	}
+
+	responseCookie := func(msg *dns.Msg) string {
+		opt := util.GetEdns0Option[*dns.EDNS0_COOKIE](msg)
+		Expect(opt).ShouldNot(BeNil())
+
+		return opt.Cookie
+	}
+
+	BeforeEach(func() {
+		request = &model.Request{
+			ClientIP: net.ParseIP("192.168.178.2"),
+			Req:      util.NewMsgWithQuestion("example.com.", A),
+			Protocol: model.RequestProtocolUDP,
+		}
+
+		sut = &Engine{
+			cfg:          &config.Config{Cookies: config.Cookies{Enable: true}},
+			cookieSecret: newCookieSecret(config.Cookies{}),
+		}
+	})
+
+	Describe("newCookieSecret", func() {
+		It("should use the configured secret", func() {
+			secret := config.CookieSecret(strings.Repeat("s", config.CookieSecretLen))
+
+			Expect(newCookieSecret(config.Cookies{Secret: secret})).Should(BeEquivalentTo(secret))
+		})
+
+		It("should create a random secret", func() {
+			Expect(newCookieSecret(config.Cookies{})).Should(HaveLen(config.CookieSecretLen))
+			Expect(newCookieSecret(config.Cookies{})).ShouldNot(Equal(newCookieSecret(config.Cookies{})))
+		})
+	})
+
+	Describe("takeCookie", func() {
+		It("should ignore requests without cookie", func() {
+			Expect(sut.takeCookie(request)).Should(BeNil())
+		})
+
+		It("should ignore cookies, if disabled", func() {
+			sut.cfg.Cookies.Enable = false
+
+			Expect(sut.takeCookie(withCookie(clientCookie))).Should(BeNil())
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](request.Req)).ShouldNot(BeNil())
+		})
+
+		It("should remove the cookie from the request", func() {
+			cookie, err := sut.takeCookie(withCookie(clientCookie))
+			Expect(err).Should(Succeed())
+			Expect(hex.EncodeToString(cookie.client)).Should(Equal(clientCookie))
+			Expect(cookie.valid).Should(BeFalse())
+
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](request.Req)).Should(BeNil())
+		})
+
+		It("should fail on malformed cookies", func() {
+			_, err := sut.takeCookie(withCookie("010203"))
+			Expect(err).Should(MatchError(errMalformedCookie))
+
+			_, err = sut.takeCookie(withCookie(clientCookie + "0102"))
+			Expect(err).Should(MatchError(errMalformedCookie))
+		})
+
+		It("should accept server cookies issued by this server", func() {
+			client, _ := hex.DecodeString(clientCookie)
+			server := hex.EncodeToString(sut.serverCookie(client, request.ClientIP, time.Now().Add(-time.Minute)))
+
+			cookie, err := sut.takeCookie(withCookie(clientCookie + server))
+			Expect(err).Should(Succeed())
+			Expect(cookie.valid).Should(BeTrue())
+		})
+
+		It("should reject server cookies of other clients", func() {
+			client, _ := hex.DecodeString(clientCookie)
+			server := hex.EncodeToString(sut.serverCookie(client, net.ParseIP("192.168.178.3"), time.Now()))
+
+			cookie, err := sut.takeCookie(withCookie(clientCookie + server))
+			Expect(err).Should(Succeed())
+			Expect(cookie.valid).Should(BeFalse())
+		})
+
+		It("should reject expired server cookies", func() {
+			client, _ := hex.DecodeString(clientCookie)
+			server := hex.EncodeToString(sut.serverCookie(client, request.ClientIP, time.Now().Add(-2*time.Hour)))
+
+			cookie, err := sut.takeCookie(withCookie(clientCookie + server))
+			Expect(err).Should(Succeed())
+			Expect(cookie.valid).Should(BeFalse())
+		})
+	})
+
+	Describe("setCookie", func() {
+		It("should add the client and a new server cookie", func() {
+			cookie, err := sut.takeCookie(withCookie(clientCookie))
+			Expect(err).Should(Succeed())
+
+			response := new(dns.Msg)
+			response.SetReply(request.Req)
+
+			sut.setCookie(request, response, cookie)
+
+			value := responseCookie(response)
+			Expect(value).Should(HavePrefix(clientCookie))
+			Expect(value).Should(HaveLen(2 * (clientCookieLen + serverCookieLen)))
+
+			// the new server cookie is accepted
+			cookie, err = sut.takeCookie(withCookie(value))
+			Expect(err).Should(Succeed())
+			Expect(cookie.valid).Should(BeTrue())
+		})
+
+		It("should replace the cookie of the upstream", func() {
+			cookie, err := sut.takeCookie(withCookie(clientCookie))
+			Expect(err).Should(Succeed())
+
+			response := new(dns.Msg)
+			response.SetReply(request.Req)
+			response.SetEdns0(1232, false)
+			util.SetEdns0Option(response, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "1112131415161718"})
+
+			sut.setCookie(request, response, cookie)
+
+			Expect(response.IsEdns0().Option).Should(HaveLen(1))
+			Expect(responseCookie(response)).Should(HavePrefix(clientCookie))
+		})
+	})
+})
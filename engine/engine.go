@@ -0,0 +1,562 @@
+// Package engine exposes the query processing of blocky as a library: the complete resolver chain is created from
+// a configuration and queries can be resolved directly, without starting any listeners.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/nats"
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/0xERR0R/blocky/resolver"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/0xERR0R/blocky/xdp"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/miekg/dns"
+)
+
+var (
+	// ErrOverloaded is returned, if the maximum number of concurrent queries is processed and no slot becomes free
+	// within the queue timeout
+	ErrOverloaded = errors.New("too many concurrent queries")
+
+	// ErrTimeout is returned, if the query was not answered within the query timeout
+	ErrTimeout = errors.New("query timeout exceeded")
+)
+
+// Engine processes DNS queries with the resolver chain of blocky
+type Engine struct {
+	cfg   *config.Config
+	chain resolver.ChainedResolver
+	// the chain, including the first resolver in query traces
+	traced resolver.Resolver
+
+	// limits the number of concurrently processed queries, nil if unlimited
+	slots chan struct{}
+
+	reloadables map[Subsystem]*reloadable
+	reloadLock  sync.Mutex
+
+	// secret of the server cookies
+	cookieSecret []byte
+}
+
+// New creates the resolver chain from the configuration. Lists are loaded and the upstreams are initialized
+// according to the configured init strategies.
+func New(ctx context.Context, cfg *config.Config) (*Engine, error) {
+	bootstrap, err := resolver.NewBootstrap(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var redisClient *redis.Client
+	if cfg.Redis.IsEnabled() {
+		redisClient, err = redis.New(ctx, &cfg.Redis)
+		if err != nil && cfg.Redis.Required {
+			return nil, err
+		}
+	}
+
+	bus, err := newSyncBus(ctx, cfg, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, reloadables, err := createQueryResolver(ctx, cfg, bootstrap, redisClient, bus, newFastPath(ctx, cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		cfg:          cfg,
+		chain:        chain,
+		traced:       resolver.TraceStages(chain),
+		reloadables:  reloadables,
+		cookieSecret: newCookieSecret(cfg.Cookies),
+	}
+
+	if cfg.QueryProcessing.MaxConcurrent > 0 {
+		e.slots = make(chan struct{}, cfg.QueryProcessing.MaxConcurrent)
+	}
+
+	if cfg.Caching.IsEnabled() && len(cfg.Caching.WarmupDomains) != 0 {
+		go e.warmUpCache(ctx, lists.NewDownloader(cfg.Blocking.Loading.Downloads, bootstrap.NewHTTPTransport()))
+	}
+
+	if cfg.SelfCheck.IsEnabled() {
+		go e.runSelfCheck(ctx)
+	}
+
+	return e, nil
+}
+
+// newSyncBus returns the bus to share the cache and the blocking status with other instances or nil, if the
+// instances aren't synchronized. Redis is used if configured, otherwise NATS.
+func newSyncBus(ctx context.Context, cfg *config.Config, redisClient *redis.Client) (resolver.SyncBus, error) {
+	if redisClient != nil {
+		return redisClient, nil
+	}
+
+	natsClient, err := nats.New(ctx, &cfg.Sync.NATS)
+	if err != nil || natsClient == nil {
+		return nil, err
+	}
+
+	return natsClient, nil
+}
+
+// newFastPath returns the XDP fast path or nil, if it is disabled. If it can't be started, e.g. without the
+// privileges to load BPF programs, all queries are answered by the resolvers.
+func newFastPath(ctx context.Context, cfg *config.Config) resolver.FastPath {
+	fastPath, err := xdp.New(ctx, &cfg.XDP, cfg.Ports.DNS)
+	if err != nil {
+		log.PrefixedLog("xdp").Warnf("can't start the fast path, queries are answered without it: %v", err)
+
+		return nil
+	}
+
+	if fastPath == nil {
+		return nil
+	}
+
+	return fastPath
+}
+
+// NewSandbox creates the resolver chain like `New`, but without effects outside of the engine, e.g. to test a
+// configuration: the query log, Redis, NATS, the XDP fast path, the state and store files, the list cache directories,
+// the metrics, the mirroring, the cache warm-up and the self-check are disabled. The resolvers are stopped with the
+// context.
+func NewSandbox(ctx context.Context, cfg *config.Config) (*Engine, error) {
+	sandbox := *cfg
+
+	sandbox.QueryLog.Type = config.QueryLogTypeNone
+	sandbox.QueryLog.Redis = config.QueryLogRedis{}
+	sandbox.Redis = config.Redis{}
+	sandbox.Sync = config.Sync{}
+	sandbox.XDP = config.XDP{}
+	sandbox.Blocking.StateFile = ""
+	sandbox.Blocking.Loading.CacheDir = ""
+	sandbox.HostsFile.Loading.CacheDir = ""
+	sandbox.Caching.PrefetchStateFile = ""
+	sandbox.Caching.WarmupDomains = nil
+	sandbox.ClientStats.StoreFile = ""
+	sandbox.NewDomains.StoreFile = ""
+	sandbox.Quotas.StoreFile = ""
+	sandbox.Categorization.Loading.CacheDir = ""
+	sandbox.Prometheus.Enable = false
+	sandbox.Mirroring = config.Mirroring{}
+	sandbox.SelfCheck.Probes = nil
+
+	return New(ctx, &sandbox)
+}
+
+// Chain returns the resolver chain, e.g. to access the blocking or cache control with `resolver.GetFromChainWithType`
+func (e *Engine) Chain() resolver.ChainedResolver {
+	return e.chain
+}
+
+// UpstreamStats returns the statistics of all upstreams, they are reset if the upstreams are reloaded
+func (e *Engine) UpstreamStats() []api.UpstreamStats {
+	return resolver.CollectUpstreamStats(e.reloadables[SubsystemUpstreams].link)
+}
+
+// ResolverChain describes the current resolvers of the chain, e.g. after reloads or the discovery of upstreams
+func (e *Engine) ResolverChain() []api.ResolverNode {
+	return resolver.DescribeChain(e.chain)
+}
+
+// Resolve resolves the DNS message. The request has no client IP, so only the default client groups apply.
+func (e *Engine) Resolve(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	return e.ResolveFor(ctx, nil, msg)
+}
+
+// ResolveFor resolves the DNS message on behalf of the client with the passed IP address
+func (e *Engine) ResolveFor(ctx context.Context, clientIP net.IP, msg *dns.Msg) (*dns.Msg, error) {
+	response, err := e.ResolveRequest(ctx, &model.Request{
+		ClientIP:  clientIP,
+		Protocol:  model.RequestProtocolTCP,
+		Req:       msg,
+		RequestTS: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Res, nil
+}
+
+// ResolveRequest resolves the request with the resolver chain. Panics in the chain are returned as error,
+// the response is truncated according to the protocol and EDNS buffer size of the request.
+// The query timeout is the deadline for the complete processing, including the wait for a free slot.
+func (e *Engine) ResolveRequest(
+	ctx context.Context, request *model.Request,
+) (response *model.Response, rerr error) {
+	defer func() {
+		if val := recover(); val != nil {
+			rerr = fmt.Errorf("panic occurred: %v", val)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, e.queryTimeout())
+
+	defer cancel()
+
+	ctx = resolver.WithQueryLimits(ctx, e.cfg.QueryProcessing.Limits)
+
+	if e.cfg.Audit.Manipulations.IsEnabled() {
+		// some resolvers are in front of the audit resolver
+		ctx = resolver.WithResponseManipulations(ctx)
+	}
+
+	if e.slots != nil {
+		if err := e.acquireSlot(ctx); err != nil {
+			return nil, err
+		}
+
+		defer func() { <-e.slots }()
+	}
+
+	if e.takeDebugOption(request) || request.Debug {
+		logger := log.FromCtx(ctx)
+
+		var trace *resolver.QueryTrace
+
+		ctx, trace = resolver.NewQueryTrace(ctx)
+
+		defer func() {
+			lines := trace.Lines()
+
+			for _, line := range lines {
+				logger.Info("query trace: ", line)
+			}
+
+			if response != nil {
+				response.Trace = lines
+			}
+		}()
+	}
+
+	cookie, cookieErr := e.takeCookie(request)
+
+	switch {
+	case cookieErr != nil:
+		log.FromCtx(ctx).Debug("query has a malformed cookie: ", cookieErr)
+
+		response = newRcodeResponse(request, dns.RcodeFormatError, model.ReasonCodeBADCOOKIE)
+	case cookie != nil && !cookie.valid && e.cfg.Cookies.EnforceUDP && request.Protocol == model.RequestProtocolUDP:
+		// RFC 7873: the client retries with the server cookie of the response
+		response = newRcodeResponse(request, dns.RcodeBadCookie, model.ReasonCodeBADCOOKIE)
+	case request.Req.Opcode == dns.OpcodeNotify:
+		response = e.handleNotify(ctx, request)
+	case len(request.Req.Question) == 0 && cookie != nil:
+		// RFC 7873: clients may query the server cookie without question
+		response = newRcodeResponse(request, dns.RcodeSuccess, model.ReasonCodeCOOKIE)
+	case len(request.Req.Question) == 0:
+		log.FromCtx(ctx).Error("query has no questions")
+
+		response = newRcodeResponse(request, dns.RcodeFormatError, model.ReasonCodeMALFORMED)
+	default:
+		var err error
+
+		response, err = e.traced.Resolve(ctx, request)
+		if err != nil {
+			var (
+				upstreamErr *resolver.UpstreamServerError
+				limitErr    *resolver.QueryLimitError
+			)
+
+			switch {
+			case errors.As(err, &limitErr):
+				log.FromCtx(ctx).Warn(limitErr)
+
+				response = newQueryLimitResponse(request, limitErr)
+			case errors.As(err, &upstreamErr):
+				response = &model.Response{
+					Res:    upstreamErr.Msg,
+					RType:  model.ResponseTypeRESOLVED,
+					Reason: model.NewReason(model.ReasonCodeUPSTREAMFAILURE),
+				}
+			case isTimeout(ctx, err):
+				return nil, fmt.Errorf("%w: %w", ErrTimeout, err)
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	response.Res.RecursionAvailable = request.Req.RecursionDesired
+
+	if cookie != nil {
+		e.setCookie(request, response.Res, cookie)
+	}
+
+	e.prepareResponse(ctx, request, response.Res)
+
+	return response, nil
+}
+
+// isTimeout returns true, if the error was caused by the query timeout. The deadline of the network I/O can expire
+// before the context is canceled.
+func isTimeout(ctx context.Context, err error) bool {
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// newRcodeResponse returns an empty response with the RCODE, which isn't resolved by the resolver chain
+func newRcodeResponse(request *model.Request, rcode int, reason model.ReasonCode) *model.Response {
+	m := new(dns.Msg)
+	m.SetRcode(request.Req, rcode)
+
+	return &model.Response{Res: m, RType: model.ResponseTypeCUSTOMDNS, Reason: model.NewReason(reason)}
+}
+
+// handleNotify passes a NOTIFY message (RFC 1996) of a conditional upstream to the conditional upstream resolver
+func (e *Engine) handleNotify(ctx context.Context, request *model.Request) *model.Response {
+	if len(request.Req.Question) == 0 {
+		return newRcodeResponse(request, dns.RcodeFormatError, model.ReasonCodeMALFORMED)
+	}
+
+	zone := request.Req.Question[0].Name
+
+	if !resolver.NotifyZone(ctx, e.chain, zone, request.ClientIP) {
+		log.FromCtx(ctx).Debugf("refused NOTIFY for zone '%s' from %s", util.Obfuscate(zone), request.ClientIP)
+
+		return newRcodeResponse(request, dns.RcodeRefused, model.ReasonCodeNOTIFY)
+	}
+
+	response := newRcodeResponse(request, dns.RcodeSuccess, model.ReasonCodeNOTIFY)
+	response.Res.Authoritative = true
+
+	return response
+}
+
+// newQueryLimitResponse returns a SERVFAIL response with the exceeded limit as extended DNS error
+func newQueryLimitResponse(request *model.Request, limitErr *resolver.QueryLimitError) *model.Response {
+	response := newRcodeResponse(request, dns.RcodeServerFailure, model.ReasonCodeQUERYLIMIT)
+
+	util.SetEdns0Option(response.Res, &dns.EDNS0_EDE{
+		InfoCode:  dns.ExtendedErrorCodeOther,
+		ExtraText: limitErr.Error(),
+	})
+
+	return response
+}
+
+// removes the debug EDNS option from the request, so it isn't forwarded to upstreams. Returns true, if it was present
+func (e *Engine) takeDebugOption(request *model.Request) bool {
+	code := e.cfg.QueryProcessing.DebugOption
+
+	opt := request.Req.IsEdns0()
+	if code == 0 || opt == nil {
+		return false
+	}
+
+	found := false
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		if o.Option() == code {
+			found = true
+
+			return true
+		}
+
+		return false
+	})
+
+	return found
+}
+
+// acquireSlot waits for a free processing slot, but not longer than the queue timeout, so that queries are rejected
+// early under overload instead of queueing up
+func (e *Engine) acquireSlot(ctx context.Context) error {
+	select {
+	case e.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(e.cfg.QueryProcessing.QueueTimeout.ToDuration())
+	defer timer.Stop()
+
+	select {
+	case e.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrOverloaded
+	case <-ctx.Done():
+		return ErrOverloaded
+	}
+}
+
+// returns the configured query timeout or a multiple of the upstream timeout
+func (e *Engine) queryTimeout() time.Duration {
+	if e.cfg.QueryProcessing.Timeout.IsAboveZero() {
+		return e.cfg.QueryProcessing.Timeout.ToDuration()
+	}
+
+	contextUpstreamTimeoutMultiplier := 100
+
+	return time.Duration(contextUpstreamTimeoutMultiplier) * e.cfg.Upstreams.Timeout.ToDuration()
+}
+
+//nolint:funlen
+func createQueryResolver(
+	ctx context.Context,
+	cfg *config.Config,
+	bootstrap *resolver.Bootstrap,
+	redisClient *redis.Client,
+	bus resolver.SyncBus,
+	fastPath resolver.FastPath,
+) (resolver.ChainedResolver, map[Subsystem]*reloadable, error) {
+	upstreamTree, utErr := newReloadable(ctx, cfg,
+		func(ctx context.Context, cfg *config.Config, _ resolver.Resolver) (resolver.Resolver, error) {
+			upstreamTree, err := resolver.NewUpstreamTreeResolver(ctx, cfg.Upstreams, bootstrap)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg.Upstreams.HijackDetection.IsEnabled() {
+				resolver.NewHijackDetector(cfg.Upstreams.HijackDetection, upstreamTree).Start(ctx)
+			}
+
+			if cfg.IPv6Only.IsEnabled() && cfg.IPv6Only.NAT64Discovery.IsEnabled() {
+				resolver.NewNAT64Discovery(cfg.IPv6Only.NAT64Discovery, upstreamTree).Start(ctx)
+			}
+
+			return upstreamTree, nil
+		})
+	blocking, blErr := newReloadable(ctx, cfg,
+		func(ctx context.Context, cfg *config.Config, prev resolver.Resolver) (resolver.Resolver, error) {
+			blocking, err := resolver.NewBlockingResolver(ctx, cfg.Blocking, bus, bootstrap)
+			if err != nil {
+				return nil, err
+			}
+
+			if prev, ok := prev.(*resolver.BlockingResolver); ok {
+				blocking.TakeOver(ctx, prev)
+			}
+
+			return blocking, nil
+		})
+	clientNames, cnErr := resolver.NewClientNamesResolver(ctx, cfg.ClientLookup, cfg.Upstreams, bootstrap)
+	queryLogging, qlErr := newReloadable(ctx, cfg,
+		func(ctx context.Context, cfg *config.Config, _ resolver.Resolver) (resolver.Resolver, error) {
+			return resolver.NewQueryLoggingResolver(ctx, cfg.QueryLog, redisClient)
+		})
+	customDNS, cdErr := newReloadable(ctx, cfg,
+		func(_ context.Context, cfg *config.Config, _ resolver.Resolver) (resolver.Resolver, error) {
+			return resolver.NewRewriterResolver(cfg.CustomDNS.RewriterConfig, resolver.NewCustomDNSResolver(cfg.CustomDNS)), nil
+		})
+	condUpstream, cuErr := resolver.NewConditionalUpstreamResolver(ctx, cfg.Conditional, cfg.Upstreams, bootstrap)
+	hostsFile, hfErr := resolver.NewHostsFileResolver(ctx, cfg.HostsFile, bootstrap)
+	// the upstream tree is reloadable: the group is selected by the current one
+	upstreamGroup := func(request *model.Request) string {
+		if tree, ok := upstreamTree.link.Current().(*resolver.UpstreamTreeResolver); ok {
+			return tree.UpstreamGroup(request)
+		}
+
+		return ""
+	}
+	cachingResolver, crErr := resolver.NewCachingResolver(ctx, cfg.Caching, bus, fastPath, upstreamGroup)
+	scripting, scErr := resolver.NewScriptingResolver(cfg.Scripting)
+	newDomains, ndErr := resolver.NewNewDomainsResolver(ctx, cfg.NewDomains)
+	clientStats, csErr := resolver.NewClientStatsResolver(ctx, cfg.ClientStats)
+	quotas, qtErr := resolver.NewQuotaResolver(ctx, cfg.Quotas)
+	categorization, ctErr := resolver.NewCategorizationResolver(ctx, cfg.Categorization, bootstrap)
+	statistics, stErr := resolver.NewStatisticsResolver(cfg.Statistics)
+
+	err := multierror.Append(
+		multierror.Prefix(utErr, "upstream tree resolver: "),
+		multierror.Prefix(blErr, "blocking resolver: "),
+		multierror.Prefix(qlErr, "query logging resolver: "),
+		multierror.Prefix(cnErr, "client names resolver: "),
+		multierror.Prefix(cuErr, "conditional upstream resolver: "),
+		multierror.Prefix(cdErr, "custom DNS resolver: "),
+		multierror.Prefix(hfErr, "hosts file resolver: "),
+		multierror.Prefix(crErr, "caching resolver: "),
+		multierror.Prefix(scErr, "scripting resolver: "),
+		multierror.Prefix(ndErr, "new domains resolver: "),
+		multierror.Prefix(csErr, "client stats resolver: "),
+		multierror.Prefix(qtErr, "quota resolver: "),
+		multierror.Prefix(ctErr, "categorization resolver: "),
+		multierror.Prefix(stErr, "statistics resolver: "),
+	).ErrorOrNil()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvers, err := insertPlugins([]resolver.Resolver{
+		resolver.NewECSResolver(cfg.ECS),
+		clientNames,
+		// after client names and before all resolvers with client groups: clients can be matched by name
+		resolver.NewTenantResolver(cfg.Tenancy),
+		// before query logging, metrics, client stats and blocking: they use the categories of the domain
+		categorization,
+		// after client names and before query logging and metrics: excluded clients can be matched by name
+		statistics,
+		// after client names and before all resolvers answering queries: records the final responses per client
+		resolver.NewAuditResolver(cfg.Audit),
+		// after client names: FQDN only and filtering can be configured per client group
+		resolver.NewFQDNOnlyResolver(cfg.FQDNOnly),
+		resolver.NewFilteringResolver(cfg.Filtering),
+		resolver.NewIPv6OnlyResolver(cfg.IPv6Only),
+		// before all resolvers adding EDNS options: the options can be removed
+		resolver.NewResponseManglingResolver(cfg.ResponseMangling),
+		resolver.NewEDEResolver(cfg.EDE),
+		queryLogging.link,
+		resolver.NewMetricsResolver(cfg.Prometheus),
+		// compares the final responses, the responses of filtered queries are not compared
+		resolver.NewMirroringResolver(cfg.Mirroring, cfg.Upstreams, bootstrap),
+		clientStats,
+		resolver.NewAnomalyDetectionResolver(ctx, cfg.AnomalyDetection),
+		scripting,
+		resolver.NewZoneVisibilityResolver(cfg.ZoneVisibility, cfg.CustomDNS, cfg.Conditional),
+		resolver.NewTyposquattingResolver(cfg.Typosquatting),
+		// after client names and before all resolvers answering queries: cached and local answers use the budget
+		quotas,
+		// before blocking: the blocking lists are checked against the original IPs
+		resolver.NewIPRewriteResolver(cfg.IPRewrite),
+		customDNS.link,
+		hostsFile,
+		blocking.link,
+		// after all local answers and before caching: only domains resolved by upstreams are tracked
+		newDomains,
+		cachingResolver,
+		resolver.NewCoalescingResolver(cfg.Coalescing, cfg.Upstreams.ClientIdentifier, upstreamGroup),
+		// below caching and coalescing: only sanitized responses are cached and shared
+		resolver.NewBailiwickResolver(cfg.Bailiwick),
+		resolver.NewRewriterResolver(cfg.Conditional.RewriterConfig, condUpstream),
+		resolver.NewSpecialUseDomainNamesResolver(cfg.SUDN),
+		// directly before the upstreams: internal zones, which were not resolved locally, don't leak
+		resolver.NewLeakPreventionResolver(cfg.LeakPrevention, cfg.CustomDNS, cfg.Conditional),
+		upstreamTree.link,
+	}, cfg.Plugins)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reloadables := map[Subsystem]*reloadable{
+		SubsystemLists:     blocking,
+		SubsystemCustomdns: customDNS,
+		SubsystemUpstreams: upstreamTree,
+		SubsystemQuerylog:  queryLogging,
+	}
+
+	return resolver.Chain(resolvers...), reloadables, nil
+}
@@ -0,0 +1,793 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/extension"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/resolver"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Engine", func() {
+	var (
+		ctx      context.Context
+		cfgLines []string
+		cfg      *config.Config
+		sut      *Engine
+		err      error
+	)
+
+	startUpstream := func() string {
+		addr := GetHostPort("127.0.0.1", 5000)
+
+		handler := dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+			if strings.HasPrefix(request.Question[0].Name, "slow.") {
+				time.Sleep(300 * time.Millisecond)
+			}
+
+			resp := new(dns.Msg)
+			resp.SetReply(request)
+
+			rr, err := dns.NewRR(request.Question[0].Name + " 300 IN A 123.124.122.122")
+			Expect(err).Should(Succeed())
+
+			resp.Answer = []dns.RR{rr}
+
+			Expect(w.WriteMsg(resp)).Should(Succeed())
+		})
+
+		// tcp+udp upstreams fall back to TCP, e.g. if the UDP exchange timed out
+		for _, network := range []string{"udp", "tcp"} {
+			srv := &dns.Server{Addr: addr, Net: network, Handler: handler}
+
+			started := make(chan struct{})
+			srv.NotifyStartedFunc = func() { close(started) }
+
+			go func() {
+				defer GinkgoRecover()
+
+				_ = srv.ListenAndServe()
+			}()
+
+			Eventually(started).Should(BeClosed())
+			DeferCleanup(srv.Shutdown)
+		}
+
+		return addr
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		cfgLines = []string{
+			"upstreams:",
+			"  groups:",
+			"    default:",
+			"      - " + startUpstream(),
+			"customDNS:",
+			"  mapping:",
+			"    custom.lan: 192.168.178.55",
+			"blocking:",
+			"  denylists:",
+			"    ads:",
+			"      - |",
+			"        blocked.com",
+			"  clientGroupsBlock:",
+			"    default:",
+			"      - ads",
+		}
+	})
+
+	JustBeforeEach(func() {
+		tmpDir := NewTmpFolder("engine")
+		cfgFile := tmpDir.CreateStringFile("config.yml", cfgLines...)
+
+		cfg, err = config.LoadConfig(cfgFile.Path, true)
+		Expect(err).Should(Succeed())
+
+		sut, err = New(ctx, cfg)
+	})
+
+	Describe("Resolve", func() {
+		It("should resolve via upstream", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("example.com.", A, "123.124.122.122")))
+		})
+
+		It("should resolve custom DNS entries", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("custom.lan.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.55")))
+		})
+
+		It("should block denylisted domains", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveFor(ctx, net.ParseIP("192.168.178.2"), util.NewMsgWithQuestion("blocked.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("blocked.com.", A, "0.0.0.0")))
+		})
+	})
+
+	Describe("ResolveRequest", func() {
+		It("should return the response type", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{
+				Req:      util.NewMsgWithQuestion("blocked.com.", A),
+				Protocol: model.RequestProtocolUDP,
+			})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				HaveResponseType(model.ResponseTypeBLOCKED),
+				HaveReason("BLOCKED (ads)"),
+			))
+		})
+
+		It("should not trace queries by default", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{
+				Req:      util.NewMsgWithQuestion("custom.lan.", A),
+				Protocol: model.RequestProtocolUDP,
+			})
+			Expect(err).Should(Succeed())
+			Expect(resp.Trace).Should(BeEmpty())
+		})
+
+		It("should return the trace of debug queries", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{
+				Req:      util.NewMsgWithQuestion("blocked.com.", A),
+				Protocol: model.RequestProtocolUDP,
+				Debug:    true,
+			})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(HaveResponseType(model.ResponseTypeBLOCKED))
+
+			Expect(resp.Trace[0]).Should(HaveSuffix("extended_client_subnet: start"))
+			Expect(resp.Trace).Should(ContainElements(
+				ContainSubstring("blocking: start"),
+				MatchRegexp(`blocking: done after .+: BLOCKED \(BLOCKED \(ads\)\) NOERROR`),
+			))
+			Expect(resp.Trace).ShouldNot(ContainElement(ContainSubstring("upstream_tree")))
+		})
+
+		It("should return FORMERR for queries without question", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: new(dns.Msg)})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeFormatError))
+			Expect(resp.Reason.String()).Should(Equal("MALFORMED"))
+		})
+
+		Describe("NOTIFY", func() {
+			notify := func(clientIP string) *model.Response {
+				msg := util.NewMsgWithQuestion("lan.", dns.Type(dns.TypeSOA))
+				msg.Opcode = dns.OpcodeNotify
+
+				resp, err := sut.ResolveRequest(ctx, &model.Request{
+					Req:      msg,
+					ClientIP: net.ParseIP(clientIP),
+					Protocol: model.RequestProtocolUDP,
+				})
+				Expect(err).Should(Succeed())
+
+				return resp
+			}
+
+			It("should refuse NOTIFY messages for zones, which aren't conditional", func() {
+				Expect(err).Should(Succeed())
+
+				resp := notify("127.0.0.1")
+				Expect(resp.Res.Rcode).Should(Equal(dns.RcodeRefused))
+				Expect(resp.Res.Opcode).Should(Equal(dns.OpcodeNotify))
+				Expect(resp.Reason.Code).Should(Equal(model.ReasonCodeNOTIFY))
+			})
+
+			When("the zone is transferred", func() {
+				BeforeEach(func() {
+					cfgLines = append(cfgLines,
+						"conditional:",
+						"  mapping:",
+						"    lan: "+GetHostPort("127.0.0.1", 5000),
+						"  transfer:",
+						"    zones:",
+						"      - lan",
+					)
+				})
+
+				It("should accept NOTIFY messages of the primaries", func() {
+					Expect(err).Should(Succeed())
+
+					resp := notify("127.0.0.1")
+					Expect(resp.Res.Rcode).Should(Equal(dns.RcodeSuccess))
+					Expect(resp.Res.Authoritative).Should(BeTrue())
+
+					Expect(notify("192.168.178.2").Res.Rcode).Should(Equal(dns.RcodeRefused))
+				})
+			})
+		})
+	})
+
+	Describe("Chain", func() {
+		It("should give access to the blocking control", func() {
+			Expect(err).Should(Succeed())
+
+			control, err := resolver.GetFromChainWithType[api.BlockingControl](sut.Chain())
+			Expect(err).Should(Succeed())
+
+			Expect(control.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("blocked.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("blocked.com.", A, "123.124.122.122")))
+		})
+	})
+
+	Describe("NewSandbox", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"queryLog:",
+				"  type: console",
+				"prometheus:",
+				"  enable: true",
+			)
+		})
+
+		It("should create the chain without query log and metrics", func() {
+			sandbox, err := NewSandbox(ctx, cfg)
+			Expect(err).Should(Succeed())
+
+			resp, err := sandbox.ResolveFor(ctx, net.ParseIP("192.168.178.2"), util.NewMsgWithQuestion("blocked.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("blocked.com.", A, "0.0.0.0")))
+
+			Expect(sandbox.ResolverChain()).Should(ContainElements(
+				SatisfyAll(HaveField("Type", "query_logging"), HaveField("Enabled", false)),
+				SatisfyAll(HaveField("Type", "metrics"), HaveField("Enabled", false)),
+			))
+
+			By("the configuration is not changed", func() {
+				Expect(cfg.QueryLog.Type).Should(Equal(config.QueryLogTypeConsole))
+				Expect(cfg.Prometheus.Enable).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("XDP fast path", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"queryLog:",
+				"  type: none",
+				"xdp:",
+				"  interfaces:",
+				"    - unknown0",
+			)
+		})
+
+		It("should resolve without the fast path, if it can't be started", func() {
+			Expect(err).Should(Succeed())
+			Expect(cfg.XDP.IsEnabled()).Should(BeTrue())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("example.com.", A, "123.124.122.122")))
+		})
+	})
+
+	Describe("UpstreamStats", func() {
+		It("should return the statistics of the upstreams", func() {
+			Expect(err).Should(Succeed())
+
+			_, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			stats := sut.UpstreamStats()
+			Expect(stats).Should(HaveLen(1))
+			Expect(stats[0].Group).Should(Equal("default"))
+			Expect(stats[0].State).Should(Equal("healthy"))
+			Expect(stats[0].Selected).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("Cache warm-up", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"caching:",
+				"  warmupDomains:",
+				"    - |",
+				"      warm.com",
+				"      blocked.com",
+			)
+		})
+
+		It("should answer the warm-up domains from the cache", func() {
+			Expect(err).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				resp, err := sut.ResolveRequest(ctx, &model.Request{
+					Req:      util.NewMsgWithQuestion("warm.com.", A),
+					Protocol: model.RequestProtocolUDP,
+				})
+				g.Expect(err).Should(Succeed())
+				g.Expect(resp).Should(HaveResponseType(model.ResponseTypeCACHED))
+			}, "2s").Should(Succeed())
+		})
+	})
+
+	Describe("Reload", func() {
+		It("should answer with the new custom DNS mapping", func() {
+			Expect(err).Should(Succeed())
+
+			cfg.CustomDNS.Mapping = config.CustomDNSMapping{
+				"custom.lan": {&dns.A{Hdr: dns.RR_Header{Rrtype: dns.TypeA}, A: net.ParseIP("192.168.178.66")}},
+			}
+
+			Expect(sut.Reload(ctx, cfg, SubsystemCustomdns)).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("custom.lan.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.66")))
+		})
+
+		It("should keep the blocking status", func() {
+			Expect(err).Should(Succeed())
+
+			control, err := resolver.GetFromChainWithType[api.BlockingControl](sut.Chain())
+			Expect(err).Should(Succeed())
+			Expect(control.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+			Expect(sut.Reload(ctx, cfg, SubsystemLists)).Should(Succeed())
+
+			control, err = resolver.GetFromChainWithType[api.BlockingControl](sut.Chain())
+			Expect(err).Should(Succeed())
+			Expect(control.BlockingStatus().Enabled).Should(BeFalse())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("blocked.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("blocked.com.", A, "123.124.122.122")))
+		})
+
+		It("should keep the current resolver on error", func() {
+			Expect(err).Should(Succeed())
+
+			cfg.Blocking.BlockType = "wrong"
+
+			Expect(sut.Reload(ctx, cfg, SubsystemLists)).Should(MatchError(ContainSubstring("can't reload lists")))
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("blocked.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("blocked.com.", A, "0.0.0.0")))
+		})
+
+		It("should resolve via the new upstreams", func() {
+			Expect(err).Should(Succeed())
+
+			Expect(sut.Reload(ctx, cfg, SubsystemUpstreams)).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("example.com.", A, "123.124.122.122")))
+		})
+
+		It("should fail for an unknown subsystem", func() {
+			Expect(err).Should(Succeed())
+
+			Expect(sut.Reload(ctx, cfg, Subsystem(42))).Should(MatchError(ErrInvalidSubsystem))
+		})
+	})
+
+	When("plugin is configured", func() {
+		BeforeEach(func() {
+			ts := httptest.NewServer(extension.Handler(extension.ResolverFunc(
+				func(_ context.Context, query *extension.Query) (*dns.Msg, error) {
+					if query.Msg.Question[0].Name != "blocked.com." {
+						return nil, nil
+					}
+
+					resp := new(dns.Msg)
+					resp.SetRcode(query.Msg, dns.RcodeRefused)
+
+					return resp, nil
+				})))
+			DeferCleanup(ts.Close)
+
+			cfgLines = append(cfgLines,
+				"plugins:",
+				"  - name: policy",
+				"    url: "+ts.URL,
+				"    before: blocking",
+			)
+		})
+
+		It("should resolve via plugin before blocking", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: util.NewMsgWithQuestion("blocked.com.", A)})
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(SatisfyAll(
+				HaveResponseType(model.ResponseTypePLUGIN),
+				HaveReturnCode(dns.RcodeRefused),
+			))
+		})
+
+		It("should pass other queries to the next resolver", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("example.com.", A, "123.124.122.122")))
+		})
+	})
+
+	When("query processing limits are configured", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"queryProcessing:",
+				"  maxConcurrent: 1",
+				"  timeout: 100ms",
+			)
+		})
+
+		It("should resolve fast queries", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("example.com.", A, "123.124.122.122")))
+		})
+
+		It("should fail if the timeout is exceeded", func() {
+			Expect(err).Should(Succeed())
+
+			_, err := sut.Resolve(ctx, util.NewMsgWithQuestion("slow.example.com.", A))
+			Expect(err).Should(MatchError(ErrTimeout))
+		})
+
+		It("should fail if no slot is free within the timeout", func() {
+			Expect(err).Should(Succeed())
+
+			done := make(chan struct{})
+
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				_, _ = sut.Resolve(ctx, util.NewMsgWithQuestion("slow.example.com.", A))
+			}()
+
+			// wait until the slow query is processed
+			Eventually(func() int { return len(sut.slots) }).Should(Equal(1))
+
+			// deadline of the caller is shorter than the remaining time of the slow query
+			shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			DeferCleanup(cancel)
+
+			_, err := sut.Resolve(shortCtx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(MatchError(ErrOverloaded))
+
+			Eventually(done).Should(BeClosed())
+
+			// the slot is released
+			_, err = sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(Succeed())
+		})
+	})
+
+	When("the queue timeout is shorter than the query timeout", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"queryProcessing:",
+				"  maxConcurrent: 1",
+				"  queueTimeout: 10ms",
+			)
+		})
+
+		It("should fail fast if no slot is free", func() {
+			Expect(err).Should(Succeed())
+
+			done := make(chan struct{})
+
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+
+				_, err := sut.Resolve(ctx, util.NewMsgWithQuestion("slow.example.com.", A))
+				Expect(err).Should(Succeed())
+			}()
+
+			Eventually(func() int { return len(sut.slots) }).Should(Equal(1))
+
+			start := time.Now()
+
+			_, err := sut.Resolve(ctx, util.NewMsgWithQuestion("example.com.", A))
+			Expect(err).Should(MatchError(ErrOverloaded))
+			Expect(time.Since(start)).Should(BeNumerically("<", 200*time.Millisecond))
+
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	When("the depth of the custom DNS CNAME resolution is limited", func() {
+		BeforeEach(func() {
+			mapping := slices.Index(cfgLines, "    custom.lan: 192.168.178.55")
+
+			cfgLines = slices.Insert(cfgLines, mapping+1,
+				"  zone: |",
+				"    $ORIGIN lan.",
+				"    a 3600 CNAME b",
+				"    b 3600 CNAME c",
+				"    c 3600 CNAME custom",
+			)
+
+			cfgLines = append(cfgLines,
+				"queryProcessing:",
+				"  limits:",
+				"    customDNSDepth: 2",
+			)
+		})
+
+		It("should resolve chains within the limit", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("b.lan.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(resp.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.55")))
+		})
+
+		It("should answer longer chains with SERVFAIL and an extended error", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.Resolve(ctx, util.NewMsgWithQuestion("a.lan.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp.Rcode).Should(Equal(dns.RcodeServerFailure))
+			Expect(resp.Answer).Should(BeEmpty())
+
+			Expect(resp.IsEdns0()).ShouldNot(BeNil())
+			Expect(resp.IsEdns0().Option).Should(ContainElement(
+				BeAssignableToTypeOf(&dns.EDNS0_EDE{}),
+			))
+			Expect(resp.IsEdns0().Option[0].String()).Should(ContainSubstring("customDNSDepth"))
+		})
+	})
+
+	When("a debug EDNS option is configured", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"queryProcessing:",
+				"  debugOption: 65001",
+			)
+		})
+
+		It("should trace queries with the option and remove it", func() {
+			Expect(err).Should(Succeed())
+
+			msg := util.NewMsgWithQuestion("custom.lan.", A)
+			msg.SetEdns0(4096, false)
+			util.SetEdns0Option(msg, &dns.EDNS0_LOCAL{Code: 65001})
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolUDP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Trace).Should(ContainElement(ContainSubstring("custom_dns: start")))
+
+			Expect(msg.IsEdns0()).ShouldNot(BeNil())
+			Expect(msg.IsEdns0().Option).Should(BeEmpty())
+		})
+
+		It("should not trace queries without the option", func() {
+			Expect(err).Should(Succeed())
+
+			msg := util.NewMsgWithQuestion("custom.lan.", A)
+			util.SetEdns0Option(msg, &dns.EDNS0_LOCAL{Code: 65002})
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolUDP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Trace).Should(BeEmpty())
+		})
+	})
+
+	When("cookies are enforced", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"cookies:",
+				"  enable: true",
+				"  enforceUDP: true",
+			)
+		})
+
+		withCookie := func(msg *dns.Msg, cookie string) *dns.Msg {
+			msg.SetEdns0(1232, false)
+			util.SetEdns0Option(msg, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+
+			return msg
+		}
+
+		It("should answer UDP queries without server cookie with BADCOOKIE", func() {
+			Expect(err).Should(Succeed())
+
+			msg := withCookie(util.NewMsgWithQuestion("custom.lan.", A), "0102030405060708")
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolUDP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeBadCookie))
+			Expect(resp.Res.Answer).Should(BeEmpty())
+
+			cookie := util.GetEdns0Option[*dns.EDNS0_COOKIE](resp.Res)
+			Expect(cookie).ShouldNot(BeNil())
+
+			By("retrying with the server cookie", func() {
+				msg := withCookie(util.NewMsgWithQuestion("custom.lan.", A), cookie.Cookie)
+
+				resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolUDP})
+				Expect(err).Should(Succeed())
+				Expect(resp.Res.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.55")))
+			})
+		})
+
+		It("should answer TCP queries without server cookie", func() {
+			Expect(err).Should(Succeed())
+
+			msg := withCookie(util.NewMsgWithQuestion("custom.lan.", A), "0102030405060708")
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolTCP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.55")))
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](resp.Res)).ShouldNot(BeNil())
+		})
+
+		It("should answer UDP queries without cookie", func() {
+			Expect(err).Should(Succeed())
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{
+				Req:      util.NewMsgWithQuestion("custom.lan.", A),
+				Protocol: model.RequestProtocolUDP,
+			})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Answer).Should(ContainElement(BeDNSRecord("custom.lan.", A, "192.168.178.55")))
+		})
+
+		It("should return the server cookie for queries without question", func() {
+			Expect(err).Should(Succeed())
+
+			msg := withCookie(new(dns.Msg), "0102030405060708")
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolTCP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](resp.Res)).ShouldNot(BeNil())
+		})
+
+		It("should return FORMERR for malformed cookies", func() {
+			Expect(err).Should(Succeed())
+
+			msg := withCookie(util.NewMsgWithQuestion("custom.lan.", A), "0102")
+
+			resp, err := sut.ResolveRequest(ctx, &model.Request{Req: msg, Protocol: model.RequestProtocolUDP})
+			Expect(err).Should(Succeed())
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeFormatError))
+		})
+	})
+
+	Describe("Self-check", func() {
+		var (
+			results  chan string
+			failures chan string
+		)
+
+		BeforeEach(func() {
+			cfgLines = append(cfgLines,
+				"selfCheck:",
+				"  interval: 1h",
+				"  probes:",
+				"    - domain: blocked.com",
+				"      expect: blocked",
+				"    - domain: custom.lan",
+				"      expect: resolved",
+				"    - domain: example.com",
+				"      type: AAAA",
+				"      expect: blocked",
+			)
+
+			results = make(chan string, 10)
+			failures = make(chan string, 10)
+
+			onCompleted := func(domain, qType string, passed bool) {
+				results <- fmt.Sprintf("%s %s %t", domain, qType, passed)
+			}
+			onFailed := func(domain, qType, description string) {
+				failures <- fmt.Sprintf("%s %s: %s", domain, qType, description)
+			}
+
+			Expect(evt.Bus().Subscribe(evt.SelfCheckProbeCompleted, onCompleted)).Should(Succeed())
+			DeferCleanup(evt.Bus().Unsubscribe, evt.SelfCheckProbeCompleted, onCompleted)
+			Expect(evt.Bus().Subscribe(evt.SelfCheckFailed, onFailed)).Should(Succeed())
+			DeferCleanup(evt.Bus().Unsubscribe, evt.SelfCheckFailed, onFailed)
+		})
+
+		It("should publish the result of each probe", func() {
+			Expect(err).Should(Succeed())
+
+			sut.selfCheck(ctx, make(map[int]bool))
+
+			Expect(results).Should(HaveLen(3))
+			Expect([]string{<-results, <-results, <-results}).Should(Equal([]string{
+				"blocked.com A true",
+				"custom.lan A true",
+				"example.com AAAA false",
+			}))
+			Expect(failures).Should(Receive(Equal("example.com AAAA: expected blocked, got RESOLVED (NOERROR)")))
+		})
+
+		It("should report a failing probe only once", func() {
+			Expect(err).Should(Succeed())
+
+			passed := make(map[int]bool)
+
+			sut.selfCheck(ctx, passed)
+			sut.selfCheck(ctx, passed)
+
+			Expect(failures).Should(HaveLen(1))
+			Expect(passed).Should(Equal(map[int]bool{0: true, 1: true, 2: false}))
+		})
+	})
+
+	When("configuration is invalid", func() {
+		BeforeEach(func() {
+			cfgLines = append(cfgLines, "  blockType: invalid")
+		})
+
+		It("should return error", func() {
+			Expect(err).Should(MatchError(ContainSubstring("blocking resolver")))
+		})
+	})
+})
+
+var _ = Describe("isTimeout", func() {
+	It("should be true after the deadline", func() {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		Expect(isTimeout(ctx, errors.New("connection refused"))).Should(BeTrue())
+	})
+
+	It("should be true for network timeouts before the context is canceled", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		Expect(isTimeout(ctx, fmt.Errorf("upstream: %w", &net.DNSError{IsTimeout: true}))).Should(BeTrue())
+	})
+
+	It("should be false for other errors", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		Expect(isTimeout(ctx, errors.New("connection refused"))).Should(BeFalse())
+	})
+})
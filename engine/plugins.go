@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/resolver"
+)
+
+// insertPlugins inserts the configured plugin resolvers before or after the resolver with the configured type
+func insertPlugins(resolvers []resolver.Resolver, plugins []config.Plugin) ([]resolver.Resolver, error) {
+	for _, cfg := range plugins {
+		if !cfg.IsEnabled() {
+			continue
+		}
+
+		target, offset, err := pluginPosition(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		idx := slices.IndexFunc(resolvers, func(res resolver.Resolver) bool {
+			return hasType(res, target)
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("plugin '%s': resolver '%s' is not in the chain", cfg.Name, target)
+		}
+
+		plugin, err := resolver.NewPluginResolver(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("plugin '%s': %w", cfg.Name, err)
+		}
+
+		resolvers = slices.Insert(resolvers, idx+offset, resolver.Resolver(plugin))
+	}
+
+	return resolvers, nil
+}
+
+func pluginPosition(cfg config.Plugin) (target string, offset int, err error) {
+	switch {
+	case cfg.Before != "" && cfg.After != "":
+		return "", 0, fmt.Errorf("plugin '%s': only one of before and after can be set", cfg.Name)
+	case cfg.Before != "":
+		return cfg.Before, 0, nil
+	case cfg.After != "":
+		return cfg.After, 1, nil
+	default:
+		return "", 0, fmt.Errorf("plugin '%s': before or after must be set", cfg.Name)
+	}
+}
+
+func hasType(res resolver.Resolver, typeName string) bool {
+	res = resolver.Unwrap(res)
+
+	if res.Type() == typeName {
+		return true
+	}
+
+	if rewriter, ok := res.(*resolver.RewriterResolver); ok {
+		return rewriter.Inner().Type() == typeName
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/resolver"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("insertPlugins", func() {
+	var resolvers []resolver.Resolver
+
+	types := func(resolvers []resolver.Resolver) []string {
+		res := make([]string, 0, len(resolvers))
+		for _, r := range resolvers {
+			res = append(res, resolver.Name(r))
+		}
+
+		return res
+	}
+
+	BeforeEach(func() {
+		resolvers = []resolver.Resolver{
+			resolver.NewFilteringResolver(config.Filtering{}),
+			resolver.NewRewriterResolver(
+				config.RewriterConfig{Rewrite: map[string]string{"lan": "home"}},
+				resolver.NewCustomDNSResolver(config.CustomDNS{}),
+			),
+			resolver.NewFQDNOnlyResolver(config.FQDNOnly{}),
+		}
+	})
+
+	It("should insert plugins before and after the configured resolver", func() {
+		res, err := insertPlugins(resolvers, []config.Plugin{
+			{Name: "first", URL: "http://localhost", Before: "filtering"},
+			{Name: "last", URL: "http://localhost", After: "fqdn_only"},
+			{Name: "custom", URL: "http://localhost", After: "custom_dns"},
+		})
+		Expect(err).Should(Succeed())
+
+		Expect(types(res)).Should(Equal([]string{
+			"plugin (first)", "filtering", "custom_dns w/ rewrite", "plugin (custom)", "fqdn_only", "plugin (last)",
+		}))
+	})
+
+	It("should ignore disabled plugins", func() {
+		res, err := insertPlugins(resolvers, []config.Plugin{{Name: "disabled", Before: "filtering"}})
+		Expect(err).Should(Succeed())
+		Expect(res).Should(HaveLen(3))
+	})
+
+	It("should fail on unknown resolver", func() {
+		_, err := insertPlugins(resolvers, []config.Plugin{{Name: "p", URL: "http://localhost", Before: "foo"}})
+		Expect(err).Should(MatchError("plugin 'p': resolver 'foo' is not in the chain"))
+	})
+
+	It("should fail without position", func() {
+		_, err := insertPlugins(resolvers, []config.Plugin{{Name: "p", URL: "http://localhost"}})
+		Expect(err).Should(MatchError("plugin 'p': before or after must be set"))
+	})
+
+	It("should fail with before and after", func() {
+		_, err := insertPlugins(resolvers, []config.Plugin{
+			{Name: "p", URL: "http://localhost", Before: "filtering", After: "fqdn_only"},
+		})
+		Expect(err).Should(MatchError("plugin 'p': only one of before and after can be set"))
+	})
+})
@@ -0,0 +1,97 @@
+package engine
+
+//go:generate go tool go-enum -f=$GOFILE --marshal --names
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/resolver"
+)
+
+// Subsystem is a part of the resolver chain, which can be reloaded without a restart ENUM(
+// lists // allow and denylists of the blocking
+// customdns // custom DNS
+// upstreams // upstream groups of the upstream tree
+// querylog // query log
+// )
+type Subsystem int
+
+// buildFn creates the resolver of a subsystem. `prev` is the resolver to replace, nil on start.
+// Background tasks of the resolver must stop, if ctx is done.
+type buildFn func(ctx context.Context, cfg *config.Config, prev resolver.Resolver) (resolver.Resolver, error)
+
+// reloadable is a resolver of the chain, which can be rebuilt from a new configuration
+type reloadable struct {
+	link *resolver.SwappableResolver
+
+	// builds the next generation of the resolver and returns it with the function to stop it
+	build func(cfg *config.Config, prev resolver.Resolver) (resolver.Resolver, context.CancelFunc, error)
+	// stops the current generation
+	cancel context.CancelFunc
+}
+
+func newReloadable(ctx context.Context, cfg *config.Config, build buildFn) (*reloadable, error) {
+	r := &reloadable{
+		build: func(cfg *config.Config, prev resolver.Resolver) (resolver.Resolver, context.CancelFunc, error) {
+			genCtx, cancel := context.WithCancel(ctx)
+
+			res, err := build(genCtx, cfg, prev)
+			if err != nil {
+				cancel()
+
+				return nil, nil, err
+			}
+
+			return res, cancel, nil
+		},
+	}
+
+	res, cancel, err := r.build(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.link = resolver.NewSwappableResolver(res)
+	r.cancel = cancel
+
+	return r, nil
+}
+
+func (r *reloadable) reload(cfg *config.Config) error {
+	res, cancel, err := r.build(cfg, r.link.Current())
+	if err != nil {
+		return err
+	}
+
+	r.link.Swap(res)
+
+	r.cancel()
+	r.cancel = cancel
+
+	return nil
+}
+
+// Reload rebuilds the resolver of the subsystem with the passed configuration, all other resolvers keep their
+// configuration. The current resolver keeps answering queries until the new one is ready.
+func (e *Engine) Reload(ctx context.Context, cfg *config.Config, subsystem Subsystem) error {
+	r, ok := e.reloadables[subsystem]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidSubsystem, subsystem)
+	}
+
+	e.reloadLock.Lock()
+	defer e.reloadLock.Unlock()
+
+	if err := r.reload(cfg); err != nil {
+		return fmt.Errorf("can't reload %s: %w", subsystem, err)
+	}
+
+	logger := log.FromCtx(ctx)
+	logger.Infof("reloaded %s", subsystem)
+	log.WithIndent(logger, "  ", r.link.LogConfig)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SubsystemLists is a Subsystem of type Lists.
+	// allow and denylists of the blocking
+	SubsystemLists Subsystem = iota
+	// SubsystemCustomdns is a Subsystem of type Customdns.
+	// custom DNS
+	SubsystemCustomdns
+	// SubsystemUpstreams is a Subsystem of type Upstreams.
+	// upstream groups of the upstream tree
+	SubsystemUpstreams
+	// SubsystemQuerylog is a Subsystem of type Querylog.
+	// query log
+	SubsystemQuerylog
+)
+
+var ErrInvalidSubsystem = fmt.Errorf("not a valid Subsystem, try [%s]", strings.Join(_SubsystemNames, ", "))
+
+const _SubsystemName = "listscustomdnsupstreamsquerylog"
+
+var _SubsystemNames = []string{
+	_SubsystemName[0:5],
+	_SubsystemName[5:14],
+	_SubsystemName[14:23],
+	_SubsystemName[23:31],
+}
+
+// SubsystemNames returns a list of possible string values of Subsystem.
+func SubsystemNames() []string {
+	tmp := make([]string, len(_SubsystemNames))
+	copy(tmp, _SubsystemNames)
+	return tmp
+}
+
+var _SubsystemMap = map[Subsystem]string{
+	SubsystemLists:     _SubsystemName[0:5],
+	SubsystemCustomdns: _SubsystemName[5:14],
+	SubsystemUpstreams: _SubsystemName[14:23],
+	SubsystemQuerylog:  _SubsystemName[23:31],
+}
+
+// String implements the Stringer interface.
+func (x Subsystem) String() string {
+	if str, ok := _SubsystemMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Subsystem(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Subsystem) IsValid() bool {
+	_, ok := _SubsystemMap[x]
+	return ok
+}
+
+var _SubsystemValue = map[string]Subsystem{
+	_SubsystemName[0:5]:   SubsystemLists,
+	_SubsystemName[5:14]:  SubsystemCustomdns,
+	_SubsystemName[14:23]: SubsystemUpstreams,
+	_SubsystemName[23:31]: SubsystemQuerylog,
+}
+
+// ParseSubsystem attempts to convert a string to a Subsystem.
+func ParseSubsystem(name string) (Subsystem, error) {
+	if x, ok := _SubsystemValue[name]; ok {
+		return x, nil
+	}
+	return Subsystem(0), fmt.Errorf("%s is %w", name, ErrInvalidSubsystem)
+}
+
+// MarshalText implements the text marshaller method.
+func (x Subsystem) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *Subsystem) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseSubsystem(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
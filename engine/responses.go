@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+)
+
+var errCompressionMismatch = errors.New("unpacked response differs from the original")
+
+// prepareResponse sets the advertised EDNS UDP payload size, truncates the response to the size limit of the
+// request and enables the name compression
+func (e *Engine) prepareResponse(ctx context.Context, request *model.Request, msg *dns.Msg) {
+	cfg := e.cfg.Responses
+
+	setUDPPayloadSize(request.Req, msg, cfg.UDPPayloadSize)
+
+	size := maxResponseSize(request, cfg.MaxUDPSize)
+
+	// only compresses, if the response doesn't fit otherwise
+	msg.Truncate(size)
+
+	if !slices.Contains(cfg.UncompressedListeners, request.Listener) {
+		msg.Compress = true
+	}
+
+	if !msg.Compress || !cfg.VerifyCompression {
+		return
+	}
+
+	if err := verifyCompression(msg); err != nil {
+		log.FromCtx(ctx).Warnf("name compression of the response failed, sending it uncompressed: %s", err)
+
+		msg.Compress = false
+
+		// the client has to retry via TCP, if the uncompressed response doesn't fit
+		if msg.Len() > size {
+			truncateAll(msg)
+		}
+	}
+}
+
+// maxResponseSize returns the size limit of the response: 64K for TCP, for UDP the EDNS UDP size of the request
+// (512 without EDNS) limited by maxUDPSize
+func maxResponseSize(request *model.Request, maxUDPSize uint16) int {
+	if request.Protocol == model.RequestProtocolTCP {
+		return dns.MaxMsgSize
+	}
+
+	size := dns.MinMsgSize
+
+	if edns := request.Req.IsEdns0(); edns != nil && edns.UDPSize() > dns.MinMsgSize {
+		size = int(edns.UDPSize())
+	}
+
+	if maxUDPSize > 0 {
+		size = min(size, max(int(maxUDPSize), dns.MinMsgSize))
+	}
+
+	return size
+}
+
+// setUDPPayloadSize advertises the EDNS UDP payload size in the response, if the request uses EDNS
+func setUDPPayloadSize(request, response *dns.Msg, size uint16) {
+	reqOpt := request.IsEdns0()
+	if size == 0 || reqOpt == nil {
+		return
+	}
+
+	if opt := response.IsEdns0(); opt != nil {
+		opt.SetUDPSize(size)
+
+		return
+	}
+
+	// RFC 6891: a response to a request with OPT record should contain one
+	response.SetEdns0(size, reqOpt.Do())
+}
+
+// verifyCompression returns an error, if the compressed response is changed by packing and unpacking it
+func verifyCompression(msg *dns.Msg) error {
+	packed, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+
+	unpacked := new(dns.Msg)
+
+	if err := unpacked.Unpack(packed); err != nil {
+		return err
+	}
+
+	if unpacked.String() != msg.String() {
+		return fmt.Errorf("%w (%d bytes)", errCompressionMismatch, len(packed))
+	}
+
+	return nil
+}
+
+// truncateAll removes all records except the OPT record and sets the TC bit
+func truncateAll(msg *dns.Msg) {
+	opt := msg.IsEdns0()
+
+	msg.Answer = nil
+	msg.Ns = nil
+	msg.Extra = nil
+
+	if opt != nil {
+		msg.Extra = []dns.RR{opt}
+	}
+
+	msg.Truncated = true
+}
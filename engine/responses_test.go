@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Responses", func() {
+	var (
+		ctx     context.Context
+		cfg     config.Responses
+		sut     *Engine
+		request *model.Request
+	)
+
+	// response with the given number of A records (16 bytes each, if compressed)
+	newResponse := func(records int) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetReply(request.Req)
+
+		for i := range records {
+			msg.Answer = append(msg.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+				A:   net.ParseIP(fmt.Sprintf("10.0.%d.%d", i/256, i%256)),
+			})
+		}
+
+		return msg
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+
+		cfg, err = config.WithDefaults[config.Responses]()
+		Expect(err).Should(Succeed())
+
+		request = &model.Request{
+			Req:      util.NewMsgWithQuestion("example.com.", A),
+			Protocol: model.RequestProtocolUDP,
+			Listener: model.RequestListenerDns,
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = &Engine{cfg: &config.Config{Responses: cfg}}
+	})
+
+	Describe("maxResponseSize", func() {
+		It("should use 512 for UDP without EDNS", func() {
+			Expect(maxResponseSize(request, 1232)).Should(Equal(dns.MinMsgSize))
+		})
+
+		It("should limit the EDNS UDP size of the client", func() {
+			request.Req.SetEdns0(4096, false)
+
+			Expect(maxResponseSize(request, 1232)).Should(Equal(1232))
+			Expect(maxResponseSize(request, 0)).Should(Equal(4096))
+		})
+
+		It("should use a smaller EDNS UDP size of the client", func() {
+			request.Req.SetEdns0(1000, false)
+
+			Expect(maxResponseSize(request, 1232)).Should(Equal(1000))
+		})
+
+		It("should not truncate TCP responses to the EDNS UDP size", func() {
+			request.Protocol = model.RequestProtocolTCP
+			request.Req.SetEdns0(1232, false)
+
+			Expect(maxResponseSize(request, 1232)).Should(Equal(dns.MaxMsgSize))
+		})
+	})
+
+	Describe("prepareResponse", func() {
+		It("should advertise the UDP payload size, if the request uses EDNS", func() {
+			request.Req.SetEdns0(4096, true)
+
+			resp := newResponse(1)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.IsEdns0()).ShouldNot(BeNil())
+			Expect(resp.IsEdns0().UDPSize()).Should(BeEquivalentTo(1232))
+			Expect(resp.IsEdns0().Do()).Should(BeTrue())
+		})
+
+		It("should replace the UDP payload size of the resolved response", func() {
+			request.Req.SetEdns0(4096, false)
+
+			resp := newResponse(1)
+			resp.SetEdns0(4096, false)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.IsEdns0().UDPSize()).Should(BeEquivalentTo(1232))
+			Expect(resp.Extra).Should(HaveLen(1))
+		})
+
+		It("should not add an OPT record, if the request doesn't use EDNS", func() {
+			resp := newResponse(1)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.IsEdns0()).Should(BeNil())
+		})
+
+		When("the UDP payload size is 0", func() {
+			BeforeEach(func() {
+				cfg.UDPPayloadSize = 0
+			})
+
+			It("should keep the size of the resolved response", func() {
+				request.Req.SetEdns0(4096, false)
+
+				resp := newResponse(1)
+				resp.SetEdns0(4096, false)
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.IsEdns0().UDPSize()).Should(BeEquivalentTo(4096))
+			})
+		})
+
+		It("should truncate UDP responses exceeding the max UDP size", func() {
+			request.Req.SetEdns0(4096, false)
+
+			resp := newResponse(100)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.Truncated).Should(BeTrue())
+			Expect(resp.Len()).Should(BeNumerically("<=", 1232))
+		})
+
+		It("should not truncate TCP responses", func() {
+			request.Protocol = model.RequestProtocolTCP
+			request.Req.SetEdns0(1232, false)
+
+			resp := newResponse(100)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.Truncated).Should(BeFalse())
+			Expect(resp.Answer).Should(HaveLen(100))
+		})
+
+		It("should compress all responses by default", func() {
+			resp := newResponse(1)
+
+			sut.prepareResponse(ctx, request, resp)
+
+			Expect(resp.Compress).Should(BeTrue())
+		})
+
+		When("the listener is uncompressed", func() {
+			BeforeEach(func() {
+				cfg.UncompressedListeners = []model.RequestListener{model.RequestListenerDns}
+			})
+
+			It("should not compress small responses", func() {
+				resp := newResponse(1)
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeFalse())
+			})
+
+			It("should compress responses, which don't fit otherwise", func() {
+				resp := newResponse(25)
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeTrue())
+				Expect(resp.Truncated).Should(BeFalse())
+				Expect(resp.Answer).Should(HaveLen(25))
+			})
+
+			It("should compress responses of other listeners", func() {
+				request.Listener = model.RequestListenerTls
+
+				resp := newResponse(1)
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeTrue())
+			})
+		})
+
+		When("the compression is verified", func() {
+			BeforeEach(func() {
+				cfg.VerifyCompression = true
+			})
+
+			It("should keep valid compressed responses", func() {
+				resp := newResponse(25)
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeTrue())
+				Expect(resp.Answer).Should(HaveLen(25))
+			})
+
+			It("should send responses, which change by packing, uncompressed", func() {
+				request.Protocol = model.RequestProtocolTCP
+
+				resp := newResponse(1)
+				resp.Answer[0].(*dns.A).A = net.ParseIP("::1") // packed as 0.0.0.0
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeFalse())
+				Expect(resp.Answer).Should(HaveLen(1))
+			})
+
+			It("should truncate all records, if the uncompressed response doesn't fit", func() {
+				resp := newResponse(25)
+				resp.Answer[0].(*dns.A).A = net.ParseIP("::1")
+
+				sut.prepareResponse(ctx, request, resp)
+
+				Expect(resp.Compress).Should(BeFalse())
+				Expect(resp.Truncated).Should(BeTrue())
+				Expect(resp.Answer).Should(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+const selfCheckLogPrefix = "self_check"
+
+// runSelfCheck resolves the probes with the complete resolver chain in each interval, until the context is done.
+// The first check runs after one interval, so the lists and upstreams are initialized.
+func (e *Engine) runSelfCheck(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.SelfCheck.Interval.ToDuration())
+	defer ticker.Stop()
+
+	// result of the last check per probe
+	passed := make(map[int]bool, len(e.cfg.SelfCheck.Probes))
+
+	for {
+		select {
+		case <-ticker.C:
+			e.selfCheck(ctx, passed)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// selfCheck resolves all probes and publishes the results. A failure is reported only, if the probe passed
+// in the last check or on its first check.
+func (e *Engine) selfCheck(ctx context.Context, passed map[int]bool) {
+	cfg := e.cfg.SelfCheck
+	logger := log.PrefixedLog(selfCheckLogPrefix)
+
+	for i, probe := range cfg.Probes {
+		qType := probe.QType().String()
+
+		err := e.probe(ctx, cfg, probe)
+
+		evt.Bus().Publish(evt.SelfCheckProbeCompleted, probe.Domain, qType, err == nil)
+
+		last, checked := passed[i]
+
+		switch {
+		case err != nil && (!checked || last):
+			logger.Warnf("probe %s (%s) failed: %s", probe.Domain, qType, err)
+
+			evt.Bus().Publish(evt.SelfCheckFailed, probe.Domain, qType, err.Error())
+		case err != nil:
+			logger.Debugf("probe %s (%s) still fails: %s", probe.Domain, qType, err)
+		case checked && !last:
+			logger.Infof("probe %s (%s) passes again", probe.Domain, qType)
+		}
+
+		passed[i] = err == nil
+	}
+}
+
+// probe resolves the domain of the probe and returns an error, if the result is not the expected one
+func (e *Engine) probe(ctx context.Context, cfg config.SelfCheck, probe config.SelfCheckProbe) error {
+	response, err := e.ResolveRequest(ctx, &model.Request{
+		ClientIP:  cfg.ClientIP,
+		Protocol:  model.RequestProtocolTCP,
+		Req:       util.NewMsgWithQuestion(dns.Fqdn(probe.Domain), dns.Type(probe.QType())),
+		RequestTS: time.Now(),
+		// the probes are no user traffic
+		Unrecorded: e.cfg.Statistics.Exclude.SelfCheck,
+	})
+	if err != nil {
+		return err
+	}
+
+	blocked := response.RType == model.ResponseTypeBLOCKED
+
+	switch probe.Expect {
+	case config.SelfCheckExpectationBlocked:
+		if blocked {
+			return nil
+		}
+	case config.SelfCheckExpectationResolved:
+		if !blocked && response.Res.Rcode == dns.RcodeSuccess && len(response.Res.Answer) > 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected %s, got %s (%s)", probe.Expect, response.RType, dns.RcodeToString[response.Res.Rcode])
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/lists/parsers"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/ThinkChaos/parcour/jobgroup"
+	"github.com/avast/retry-go/v4"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+const warmupLogPrefix = "cache_warmup"
+
+//nolint:gochecknoglobals
+var warmupQueryTypes = []uint16{dns.TypeA, dns.TypeAAAA}
+
+// warmUpCache resolves the warm-up domains with the complete resolver chain, so the answers are cached
+// before the first client asks for them
+func (e *Engine) warmUpCache(ctx context.Context, downloader lists.FileDownloader) {
+	cfg := e.cfg.Caching.Warmup
+	logger := log.PrefixedLog(warmupLogPrefix)
+
+	domains := loadWarmupDomains(ctx, e.cfg.Caching.WarmupDomains, downloader, logger)
+
+	logger.Infof("resolving %d domains", len(domains))
+
+	grp, ctx := jobgroup.WithContext(ctx)
+	defer grp.Close()
+
+	limited := jobgroup.WithMaxConcurrency(grp, cfg.Concurrency)
+	defer limited.Close()
+
+	var resolved atomic.Uint32
+
+	for _, domain := range domains {
+		limited.Go(func(ctx context.Context) error {
+			if err := e.warmUpDomain(ctx, cfg, domain); err != nil {
+				logger.Warnf("can't resolve %s: %s", domain, err)
+
+				return nil
+			}
+
+			resolved.Add(1)
+
+			return nil
+		})
+	}
+
+	_ = limited.Wait() // jobs only fail, if ctx is done
+
+	logger.Infof("resolved %d of %d domains", resolved.Load(), len(domains))
+}
+
+// warmUpDomain resolves all query types of the domain, each is retried on error or server failure
+func (e *Engine) warmUpDomain(ctx context.Context, cfg config.CacheWarmup, domain string) error {
+	for _, qType := range warmupQueryTypes {
+		err := retry.Do(
+			func() error {
+				resp, err := e.Resolve(ctx, util.NewMsgWithQuestion(dns.Fqdn(domain), dns.Type(qType)))
+				if err != nil {
+					return err
+				}
+
+				if resp.Rcode == dns.RcodeServerFailure {
+					return fmt.Errorf("%s query failed with %s", dns.TypeToString[qType], dns.RcodeToString[resp.Rcode])
+				}
+
+				return nil
+			},
+			retry.Attempts(cfg.Attempts),
+			retry.DelayType(retry.FixedDelay),
+			retry.Delay(cfg.Cooldown.ToDuration()),
+			retry.LastErrorOnly(true),
+			retry.Context(ctx),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadWarmupDomains reads the domains of all sources, invalid sources and entries are skipped
+func loadWarmupDomains(
+	ctx context.Context, sources []config.BytesSource, downloader lists.FileDownloader, logger *logrus.Entry,
+) []string {
+	var domains []string
+
+	for i, source := range sources {
+		opener, err := lists.NewSourceOpener(fmt.Sprintf("item #%d", i), source, downloader)
+		if err == nil {
+			err = parseWarmupDomains(ctx, opener, logger, func(domain string) {
+				domains = append(domains, domain)
+			})
+		}
+
+		if err != nil {
+			logger.Warnf("can't load warm-up domains of %s: %s", source, err)
+		}
+	}
+
+	return domains
+}
+
+func parseWarmupDomains(
+	ctx context.Context, opener lists.SourceOpener, logger *logrus.Entry, add func(domain string),
+) error {
+	reader, err := opener.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	p := parsers.AllowErrors(parsers.HostList(reader), parsers.NoErrorLimit)
+	p.OnErr(func(err error) {
+		logger.Warnf("error parsing %s: %s, trying to continue", opener, err)
+	})
+
+	return parsers.ForEach[*parsers.HostListEntry](ctx, p, func(entry *parsers.HostListEntry) error {
+		host := entry.String()
+
+		// regexes and IPs can't be resolved
+		if strings.HasPrefix(host, "/") || net.ParseIP(host) != nil {
+			return nil
+		}
+
+		add(host)
+
+		return nil
+	})
+}
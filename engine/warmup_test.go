@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/log"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache warm-up", func() {
+	Describe("loadWarmupDomains", func() {
+		var downloader lists.FileDownloader
+
+		BeforeEach(func() {
+			downloader = lists.NewDownloader(config.Downloader{}, nil)
+		})
+
+		It("should return the domains of all sources", func() {
+			domains := loadWarmupDomains(context.Background(), []config.BytesSource{
+				config.TextBytesSource("example.com", "# comment", "www.example.com"),
+				config.TextBytesSource("other.com"),
+			}, downloader, log.PrefixedLog(warmupLogPrefix))
+
+			Expect(domains).Should(Equal([]string{"example.com", "www.example.com", "other.com"}))
+		})
+
+		It("should skip regexes, IPs and invalid entries", func() {
+			domains := loadWarmupDomains(context.Background(), []config.BytesSource{
+				config.TextBytesSource("/ads\\..*/", "1.2.3.4", "invalid domain", "example.com"),
+			}, downloader, log.PrefixedLog(warmupLogPrefix))
+
+			Expect(domains).Should(Equal([]string{"example.com"}))
+		})
+
+		It("should skip sources, which can't be read", func() {
+			domains := loadWarmupDomains(context.Background(), []config.BytesSource{
+				{Type: config.BytesSourceTypeFile, From: "/does/not/exist"},
+				config.TextBytesSource("example.com"),
+			}, downloader, log.PrefixedLog(warmupLogPrefix))
+
+			Expect(domains).Should(Equal([]string{"example.com"}))
+		})
+	})
+})
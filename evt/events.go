@@ -11,6 +11,10 @@ const (
 	// BlockingCacheGroupChanged fires, if a list group is changed. Parameter: list type, group name, element count
 	BlockingCacheGroupChanged = "blocking:cachingGroupChanged"
 
+	// BlockingListGroupPolicyTriggered fires, if a failure policy of a list group is applied.
+	// Parameter: list type, group name, policy
+	BlockingListGroupPolicyTriggered = "blocking:listGroupPolicyTriggered"
+
 	// CachingDomainPrefetched fires if a domain will be prefetched, Parameter: domain name
 	CachingDomainPrefetched = "caching:prefetched"
 
@@ -26,6 +30,36 @@ const (
 	// CachingFailedDownloadChanged fires, if a download of a blocking list or hosts file fails
 	CachingFailedDownloadChanged = "caching:failedDownload"
 
+	// UpstreamStatusChanged fires if an upstream becomes unreachable or reachable again.
+	// Parameter: upstream name, reachable (bool), error
+	UpstreamStatusChanged = "upstream:statusChanged"
+
+	// UpstreamHijackDetected fires if an upstream answers queries for nonexistent domains.
+	// Parameter: upstream name, answer
+	UpstreamHijackDetected = "upstream:hijackDetected"
+
+	// UpstreamResponseRejected fires if a response of an upstream is discarded, since it doesn't match the query.
+	// Parameter: upstream name, reason (id, question or case)
+	UpstreamResponseRejected = "upstream:responseRejected"
+
+	// ConditionalZoneChanged fires if a conditional zone changed, e.g. on NOTIFY of its DNS server or a new serial of
+	// a transferred zone. Parameter: zone
+	ConditionalZoneChanged = "conditional:zoneChanged"
+
+	// QueryAnomalyDetected fires if the queries of a client look like DNS tunneling or a DGA.
+	// Parameter: client name, anomaly, description
+	QueryAnomalyDetected = "query:anomalyDetected"
+
+	// SelfCheckProbeCompleted fires after each self-check probe. Parameter: domain, query type, passed (bool)
+	SelfCheckProbeCompleted = "selfCheck:probeCompleted"
+
+	// SelfCheckFailed fires if a self-check probe fails, after it passed before or on its first failure.
+	// Parameter: domain, query type, description
+	SelfCheckFailed = "selfCheck:failed"
+
+	// ConfigReloaded fires after the configuration was reloaded
+	ConfigReloaded = "config:reloaded"
+
 	// ApplicationStarted fires on start of the application. Parameter: version number, build time
 	ApplicationStarted = "application:started"
 )
@@ -0,0 +1,84 @@
+// Package extension defines the stable interface for custom resolvers (plugins), which can be inserted into the
+// resolver chain of blocky without forking it.
+//
+// A plugin is either a Go plugin (built with `go build -buildmode=plugin`), which exports a `Factory` with the name
+// `NewResolver`, or an external HTTP service, which implements the remote resolver protocol (see `Handler`).
+package extension
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"plugin"
+
+	"github.com/miekg/dns"
+)
+
+// FactorySymbol is the name of the symbol, which a Go plugin must export
+const FactorySymbol = "NewResolver"
+
+// Query is a DNS query passed to a plugin resolver
+type Query struct {
+	// ClientIP is the IP address of the client
+	ClientIP net.IP
+	// ClientNames contains the resolved names of the client
+	ClientNames []string
+	// Protocol is the protocol of the query ("udp" or "tcp")
+	Protocol string
+	// Msg is the DNS request
+	Msg *dns.Msg
+}
+
+// Resolver is the interface, which must be implemented by a plugin.
+// If Resolve returns a nil message and no error, the query is passed to the next resolver in the chain.
+type Resolver interface {
+	Resolve(ctx context.Context, query *Query) (*dns.Msg, error)
+}
+
+// ResolverFunc is an adapter to use ordinary functions as Resolver
+type ResolverFunc func(ctx context.Context, query *Query) (*dns.Msg, error)
+
+// Resolve implements `Resolver`
+func (f ResolverFunc) Resolve(ctx context.Context, query *Query) (*dns.Msg, error) {
+	return f(ctx, query)
+}
+
+// Factory creates the resolver of a Go plugin with the configured arguments
+type Factory func(args map[string]string) (Resolver, error)
+
+// Load opens the Go plugin with the passed path and creates its resolver
+func Load(path string, args map[string]string) (Resolver, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open plugin '%s': %w", path, err)
+	}
+
+	sym, err := p.Lookup(FactorySymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' has no symbol '%s': %w", path, FactorySymbol, err)
+	}
+
+	return newFromSymbol(sym, args)
+}
+
+func newFromSymbol(sym plugin.Symbol, args map[string]string) (Resolver, error) {
+	var factory Factory
+
+	switch f := sym.(type) {
+	case func(map[string]string) (Resolver, error):
+		factory = f
+	case *Factory:
+		factory = *f
+	case *func(map[string]string) (Resolver, error):
+		factory = *f
+	default:
+		return nil, fmt.Errorf("symbol '%s' has unsupported type %T", FactorySymbol, sym)
+	}
+
+	res, err := factory(args)
+	if err != nil {
+		return nil, fmt.Errorf("can't create plugin resolver: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package extension
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extension", func() {
+	answer := new(dns.Msg)
+
+	factory := func(args map[string]string) (Resolver, error) {
+		if args["fail"] == "true" {
+			return nil, errors.New("boom")
+		}
+
+		return ResolverFunc(func(context.Context, *Query) (*dns.Msg, error) {
+			return answer, nil
+		}), nil
+	}
+
+	Describe("Load", func() {
+		It("should fail if plugin does not exist", func() {
+			_, err := Load("/does/not/exist.so", nil)
+			Expect(err).Should(MatchError(ContainSubstring("can't open plugin '/does/not/exist.so'")))
+		})
+	})
+
+	Describe("newFromSymbol", func() {
+		It("should create resolver from function", func() {
+			res, err := newFromSymbol(factory, nil)
+			Expect(err).Should(Succeed())
+
+			Expect(res.Resolve(context.Background(), &Query{Msg: util.NewMsgWithQuestion("example.com", A)})).
+				Should(BeIdenticalTo(answer))
+		})
+
+		It("should create resolver from factory variable", func() {
+			f := Factory(factory)
+
+			_, err := newFromSymbol(&f, nil)
+			Expect(err).Should(Succeed())
+		})
+
+		It("should pass arguments and factory errors", func() {
+			_, err := newFromSymbol(factory, map[string]string{"fail": "true"})
+			Expect(err).Should(MatchError("can't create plugin resolver: boom"))
+		})
+
+		It("should fail on unsupported symbol type", func() {
+			_, err := newFromSymbol("foo", nil)
+			Expect(err).Should(MatchError("symbol 'NewResolver' has unsupported type string"))
+		})
+	})
+})
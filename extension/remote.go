@@ -0,0 +1,145 @@
+package extension
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Remote resolver protocol: the query is sent as DNS wire format message in the body of a POST request,
+// client information is passed in the headers. The remote resolver answers with status 200 and a DNS message
+// in the body or with status 204 if the query should be passed to the next resolver.
+const (
+	ContentType       = "application/dns-message"
+	HeaderClientIP    = "X-Blocky-Client-IP"
+	HeaderClientNames = "X-Blocky-Client-Names"
+	HeaderProtocol    = "X-Blocky-Protocol"
+)
+
+const maxMsgSize = dns.MaxMsgSize
+
+type remote struct {
+	url    string
+	client *http.Client
+}
+
+// NewRemote creates a resolver, which forwards the queries via the remote resolver protocol to the passed URL
+func NewRemote(url string, client *http.Client) Resolver {
+	return &remote{url: url, client: client}
+}
+
+// Resolve implements `Resolver`
+func (r *remote) Resolve(ctx context.Context, query *Query) (*dns.Msg, error) {
+	raw, err := query.Msg.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("can't pack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("can't create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set(HeaderProtocol, query.Protocol)
+
+	if query.ClientIP != nil {
+		req.Header.Set(HeaderClientIP, query.ClientIP.String())
+	}
+
+	if len(query.ClientNames) > 0 {
+		req.Header.Set(HeaderClientNames, strings.Join(query.ClientNames, ","))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't perform request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil //nolint:nilnil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("remote resolver returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMsgSize))
+	if err != nil {
+		return nil, fmt.Errorf("can't read response: %w", err)
+	}
+
+	msg := new(dns.Msg)
+	if err := msg.Unpack(body); err != nil {
+		return nil, fmt.Errorf("can't unpack response: %w", err)
+	}
+
+	return msg, nil
+}
+
+// Handler serves the passed resolver via the remote resolver protocol
+func Handler(res Resolver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != ContentType {
+			http.Error(w, "unsupported request", http.StatusBadRequest)
+
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxMsgSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		query := &Query{
+			ClientIP: net.ParseIP(req.Header.Get(HeaderClientIP)),
+			Protocol: req.Header.Get(HeaderProtocol),
+			Msg:      new(dns.Msg),
+		}
+
+		if names := req.Header.Get(HeaderClientNames); names != "" {
+			query.ClientNames = strings.Split(names, ",")
+		}
+
+		if err := query.Msg.Unpack(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		msg, err := res.Resolve(req.Context(), query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		if msg == nil {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		raw, err := msg.Pack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+
+		// the client has gone away, if the write fails
+		_, _ = w.Write(raw)
+	})
+}
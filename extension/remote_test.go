@@ -0,0 +1,102 @@
+package extension
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Remote resolver protocol", func() {
+	var (
+		ctx      context.Context
+		received *Query
+		answer   *dns.Msg
+		resErr   error
+		sut      Resolver
+		server   *httptest.Server
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		received = nil
+		resErr = nil
+
+		answer = new(dns.Msg)
+		answer.SetRcode(util.NewMsgWithQuestion("example.com", A), dns.RcodeRefused)
+
+		server = httptest.NewServer(Handler(ResolverFunc(func(_ context.Context, query *Query) (*dns.Msg, error) {
+			received = query
+
+			return answer, resErr
+		})))
+		DeferCleanup(server.Close)
+
+		sut = NewRemote(server.URL, server.Client())
+	})
+
+	It("should pass query and client information", func() {
+		msg, err := sut.Resolve(ctx, &Query{
+			ClientIP:    net.ParseIP("192.168.178.2"),
+			ClientNames: []string{"laptop", "laptop.lan"},
+			Protocol:    "udp",
+			Msg:         util.NewMsgWithQuestion("example.com", A),
+		})
+		Expect(err).Should(Succeed())
+		Expect(msg.Rcode).Should(Equal(dns.RcodeRefused))
+
+		Expect(received.ClientIP.String()).Should(Equal("192.168.178.2"))
+		Expect(received.ClientNames).Should(Equal([]string{"laptop", "laptop.lan"}))
+		Expect(received.Protocol).Should(Equal("udp"))
+		Expect(received.Msg.Question[0].Name).Should(Equal("example.com."))
+	})
+
+	It("should return no message if remote resolver has no answer", func() {
+		answer = nil
+
+		msg, err := sut.Resolve(ctx, &Query{Msg: util.NewMsgWithQuestion("example.com", A)})
+		Expect(err).Should(Succeed())
+		Expect(msg).Should(BeNil())
+		Expect(received.ClientIP).Should(BeNil())
+		Expect(received.ClientNames).Should(BeEmpty())
+	})
+
+	It("should return error if remote resolver fails", func() {
+		resErr = errors.New("boom")
+
+		_, err := sut.Resolve(ctx, &Query{Msg: util.NewMsgWithQuestion("example.com", A)})
+		Expect(err).Should(MatchError("remote resolver returned status 500"))
+	})
+
+	It("should return error if remote resolver is not reachable", func() {
+		server.Close()
+
+		_, err := sut.Resolve(ctx, &Query{Msg: util.NewMsgWithQuestion("example.com", A)})
+		Expect(err).Should(MatchError(ContainSubstring("can't perform request")))
+	})
+
+	It("should reject requests with wrong content type", func() {
+		resp, err := server.Client().Post(server.URL, "text/plain", bytes.NewReader([]byte("foo")))
+		Expect(err).Should(Succeed())
+		DeferCleanup(resp.Body.Close)
+
+		Expect(resp.StatusCode).Should(Equal(http.StatusBadRequest))
+	})
+
+	It("should reject invalid DNS messages", func() {
+		resp, err := server.Client().Post(server.URL, ContentType, bytes.NewReader([]byte("foo")))
+		Expect(err).Should(Succeed())
+		DeferCleanup(resp.Body.Close)
+
+		Expect(resp.StatusCode).Should(Equal(http.StatusBadRequest))
+	})
+})
@@ -112,7 +112,7 @@ func HaveNoAnswer() types.GomegaMatcher {
 
 func HaveReason(reason string) types.GomegaMatcher {
 	return gcustom.MakeMatcher(func(m *model.Response) (bool, error) {
-		return m.Reason == reason, nil
+		return m.Reason.String() == reason, nil
 	}).WithTemplate(
 		"Expected:\n{{.Actual}}\n{{.To}} have reason:\n{{format .Data 1}}",
 		reason,
@@ -229,6 +229,8 @@ func (matcher *dnsRecordMatcher) matchSingle(rr dns.RR) bool {
 		return strings.Join(v.Txt, " ") == matcher.answer
 	case *dns.MX:
 		return v.Mx == matcher.answer
+	case *dns.SVCB, *dns.HTTPS:
+		return strings.TrimPrefix(v.String(), v.Header().String()) == matcher.answer
 	}
 
 	return false
@@ -7,12 +7,16 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sync"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/evt"
 	"github.com/avast/retry-go/v4"
+	"golang.org/x/time/rate"
 )
 
+const bytesPerKiB = 1024
+
 // TransientError represents a temporary error like timeout, network errors...
 type TransientError struct {
 	inner error
@@ -36,6 +40,14 @@ type httpDownloader struct {
 	cfg config.Downloader
 
 	client http.Client
+
+	// limits the number of concurrent downloads, nil if unlimited
+	slots chan struct{}
+	// limits the bandwidth of all downloads in bytes per second, nil if unlimited
+	bandwidth *rate.Limiter
+
+	hostLimitersLock sync.Mutex
+	hostLimiters     map[string]*rate.Limiter
 }
 
 func NewDownloader(cfg config.Downloader, transport http.RoundTripper) FileDownloader {
@@ -43,17 +55,79 @@ func NewDownloader(cfg config.Downloader, transport http.RoundTripper) FileDownl
 }
 
 func newDownloader(cfg config.Downloader, transport http.RoundTripper) *httpDownloader {
-	return &httpDownloader{
+	d := &httpDownloader{
 		cfg: cfg,
 
 		client: http.Client{
 			Transport: transport,
 			Timeout:   cfg.Timeout.ToDuration(),
 		},
+
+		hostLimiters: make(map[string]*rate.Limiter),
+	}
+
+	if cfg.MaxConcurrent > 0 {
+		d.slots = make(chan struct{}, cfg.MaxConcurrent)
 	}
+
+	if cfg.BandwidthLimit > 0 {
+		bytesPerSec := int(cfg.BandwidthLimit) * bytesPerKiB
+		d.bandwidth = rate.NewLimiter(rate.Limit(bytesPerSec), bytesPerSec)
+	}
+
+	return d
 }
 
 func (d *httpDownloader) DownloadFile(ctx context.Context, link string) (io.ReadCloser, error) {
+	release, err := d.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := d.download(ctx, link)
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return &limitedBody{ReadCloser: body, ctx: ctx, limiter: d.bandwidth, release: sync.OnceFunc(release)}, nil
+}
+
+// waits until a download slot is free, the returned function releases the slot
+func (d *httpDownloader) acquireSlot(ctx context.Context) (func(), error) {
+	if d.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+		return func() { <-d.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waits until the minimum interval between two requests to the host has passed
+func (d *httpDownloader) waitForHost(ctx context.Context, host string) error {
+	if !d.cfg.HostInterval.IsAboveZero() {
+		return nil
+	}
+
+	d.hostLimitersLock.Lock()
+
+	limiter, ok := d.hostLimiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(d.cfg.HostInterval.ToDuration()), 1)
+		d.hostLimiters[host] = limiter
+	}
+
+	d.hostLimitersLock.Unlock()
+
+	return limiter.Wait(ctx)
+}
+
+func (d *httpDownloader) download(ctx context.Context, link string) (io.ReadCloser, error) {
 	var body io.ReadCloser
 
 	err := retry.Do(
@@ -63,6 +137,10 @@ func (d *httpDownloader) DownloadFile(ctx context.Context, link string) (io.Read
 				return err
 			}
 
+			if err := d.waitForHost(ctx, req.URL.Host); err != nil {
+				return retry.Unrecoverable(err)
+			}
+
 			resp, httpErr := d.client.Do(req)
 			if httpErr == nil {
 				if resp.StatusCode == http.StatusOK {
@@ -111,6 +189,40 @@ func (d *httpDownloader) DownloadFile(ctx context.Context, link string) (io.Read
 	return body, err
 }
 
+// limitedBody limits the read bandwidth and releases the download slot on close
+type limitedBody struct {
+	io.ReadCloser
+
+	ctx     context.Context //nolint:containedctx // the body is read after the download call
+	limiter *rate.Limiter
+	release func()
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.limiter == nil {
+		return b.ReadCloser.Read(p)
+	}
+
+	if len(p) > b.limiter.Burst() {
+		p = p[:b.limiter.Burst()]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.WaitN(b.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	defer b.release()
+
+	return b.ReadCloser.Close()
+}
+
 func onDownloadError(link string) {
 	evt.Bus().Publish(evt.CachingFailedDownloadChanged, link)
 }
@@ -238,4 +238,94 @@ var _ = Describe("Downloader", func() {
 			})
 		})
 	})
+
+	Describe("Download limits", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = TestServer(strings.Repeat("a", 2048))
+		})
+
+		When("max concurrent downloads are limited", func() {
+			BeforeEach(func() {
+				sutConfig.MaxConcurrent = 1
+			})
+
+			It("should wait until the previous download is closed", func(ctx context.Context) {
+				first, err := sut.DownloadFile(ctx, server.URL)
+				Expect(err).Should(Succeed())
+
+				second := make(chan io.ReadCloser, 1)
+
+				go func() {
+					defer GinkgoRecover()
+
+					reader, err := sut.DownloadFile(ctx, server.URL)
+					Expect(err).Should(Succeed())
+
+					second <- reader
+				}()
+
+				Consistently(second, "50ms").ShouldNot(Receive())
+
+				Expect(first.Close()).Should(Succeed())
+
+				var reader io.ReadCloser
+
+				Eventually(second, "1s").Should(Receive(&reader))
+				Expect(reader.Close()).Should(Succeed())
+			})
+
+			It("should stop waiting when the context is canceled", func(ctx context.Context) {
+				first, err := sut.DownloadFile(ctx, server.URL)
+				Expect(err).Should(Succeed())
+				DeferCleanup(first.Close)
+
+				ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+				defer cancel()
+
+				_, err = sut.DownloadFile(ctx, server.URL)
+				Expect(err).Should(MatchError(context.DeadlineExceeded))
+			})
+		})
+
+		When("requests to the same host are rate limited", func() {
+			BeforeEach(func() {
+				sutConfig.HostInterval = config.Duration(100 * time.Millisecond)
+			})
+
+			It("should wait between the requests", func(ctx context.Context) {
+				start := time.Now()
+
+				for range 3 {
+					reader, err := sut.DownloadFile(ctx, server.URL)
+					Expect(err).Should(Succeed())
+					Expect(reader.Close()).Should(Succeed())
+				}
+
+				Expect(time.Since(start)).Should(BeNumerically(">=", 200*time.Millisecond))
+			})
+		})
+
+		When("bandwidth is limited", func() {
+			BeforeEach(func() {
+				sutConfig.BandwidthLimit = 1
+			})
+
+			It("should limit the read rate", func(ctx context.Context) {
+				reader, err := sut.DownloadFile(ctx, server.URL)
+				Expect(err).Should(Succeed())
+				DeferCleanup(reader.Close)
+
+				start := time.Now()
+
+				data, err := io.ReadAll(reader)
+				Expect(err).Should(Succeed())
+				Expect(data).Should(HaveLen(2048))
+
+				// the first KiB is the burst, the second one is limited
+				Expect(time.Since(start)).Should(BeNumerically(">=", 900*time.Millisecond))
+			})
+		})
+	})
 })
@@ -5,7 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -16,6 +23,7 @@ import (
 	"github.com/0xERR0R/blocky/log"
 	"github.com/ThinkChaos/parcour"
 	"github.com/ThinkChaos/parcour/jobgroup"
+	"golang.org/x/exp/maps"
 )
 
 const (
@@ -26,6 +34,7 @@ const (
 // ListCacheType represents the type of cached list ENUM(
 // denylist // is a list with blocked domains
 // allowlist // is a list with allowlisted domains / IPs
+// category // is a list with the domains of a category
 // )
 type ListCacheType int
 
@@ -39,23 +48,32 @@ type Matcher interface {
 type ListCache struct {
 	groupedCache stringcache.GroupedStringCache
 	regexCache   stringcache.GroupedStringCache
+	stringCache  *stringcache.SharedGroupedStringCache
 
 	cfg          config.SourceLoading
 	listType     ListCacheType
 	groupSources map[string][]config.BytesSource
 	downloader   FileDownloader
+	sourceCache  *sourceCache
+
+	// state of the groups for the failure policies
+	groupStateLock sync.RWMutex
+	lastLoaded     map[string]time.Time
+	matchAll       map[string]bool
 }
 
 // LogConfig implements `config.Configurable`.
 func (b *ListCache) LogConfig(logger *logrus.Entry) {
 	total := 0
 	regexes := 0
+	strs := 0
 
 	for group := range b.groupSources {
 		count := b.groupedCache.ElementCount(group)
 		logger.Infof("%s: %d entries", group, count)
 		total += count
 		regexes += b.regexCache.ElementCount(group)
+		strs += b.stringCache.ElementCount(group)
 	}
 
 	if regexes > regexWarningThreshold {
@@ -66,6 +84,10 @@ func (b *ListCache) LogConfig(logger *logrus.Entry) {
 	}
 
 	logger.Infof("TOTAL: %d entries", total)
+
+	if unique := b.stringCache.UniqueElementCount(); unique < strs {
+		logger.Infof("UNIQUE: %d domains, %d duplicates in several groups are stored once", unique, strs-unique)
+	}
 }
 
 // NewListCache creates new list instance
@@ -74,38 +96,102 @@ func NewListCache(ctx context.Context,
 	groupSources map[string][]config.BytesSource, downloader FileDownloader,
 ) (*ListCache, error) {
 	regexCache := stringcache.NewInMemoryGroupedRegexCache()
+	stringCache := stringcache.NewSharedGroupedStringCache()
+
+	var domainCache stringcache.GroupedStringCache = stringcache.NewChainedGroupedCache(
+		stringcache.NewInMemoryGroupedWildcardCache(), // must be after regex which can contain '*'
+		stringCache, // accepts all values, must be last
+	)
+
+	if cfg.BloomFilter.IsEnabled() {
+		domainCache = stringcache.NewBloomFilteredGroupedCache(domainCache, cfg.BloomFilter.FalsePositiveRate)
+	}
 
 	c := &ListCache{
-		groupedCache: stringcache.NewChainedGroupedCache(
-			regexCache,
-			stringcache.NewInMemoryGroupedWildcardCache(), // must be after regex which can contain '*'
-			stringcache.NewInMemoryGroupedStringCache(),   // accepts all values, must be last
-		),
-		regexCache: regexCache,
+		groupedCache: stringcache.NewChainedGroupedCache(regexCache, domainCache),
+		regexCache:   regexCache,
+		stringCache:  stringCache,
 
 		cfg:          cfg,
 		listType:     t,
 		groupSources: groupSources,
 		downloader:   downloader,
+
+		lastLoaded: make(map[string]time.Time, len(groupSources)),
+		matchAll:   make(map[string]bool),
+	}
+
+	if cfg.CacheDir != "" {
+		var err error
+
+		// the compiled entries depend on the parser settings and the structure of the caches
+		settings := fmt.Sprintf("maxErrorsPerSource=%d maxSourceSize=%d maxEntriesPerSource=%d bloomFilter=%t",
+			cfg.MaxErrorsPerSource, cfg.MaxSourceSize, cfg.MaxEntriesPerSource, cfg.BloomFilter.IsEnabled())
+
+		c.sourceCache, err = newSourceCache(filepath.Join(cfg.CacheDir, t.String()), settings)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	err := cfg.StartPeriodicRefresh(ctx, c.refresh, func(err error) {
+	err := cfg.Strategy.Do(ctx, c.refresh, func(err error) {
 		logger().WithError(err).Errorf("could not init %s", t)
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	c.startRefreshSchedules(ctx)
+
 	return c, nil
 }
 
+// refreshes the groups with own schedule according to it and all other groups according to the global schedule
+func (b *ListCache) startRefreshSchedules(ctx context.Context) {
+	logErr := func(err error) {
+		logger().WithError(err).Errorf("could not refresh %s", b.listType)
+	}
+
+	var unscheduled []string
+
+	for group := range b.groupSources {
+		schedule, ok := b.cfg.GroupRefreshSchedules[group]
+		if !ok {
+			unscheduled = append(unscheduled, group)
+
+			continue
+		}
+
+		b.cfg.RefreshPeriodically(ctx, schedule, func(ctx context.Context) error {
+			return b.refreshGroups(ctx, []string{group})
+		}, logErr)
+	}
+
+	if len(unscheduled) > 0 {
+		b.cfg.RefreshPeriodically(ctx, b.cfg.RefreshSchedule, func(ctx context.Context) error {
+			return b.refreshGroups(ctx, unscheduled)
+		}, logErr)
+	}
+}
+
 func logger() *logrus.Entry {
 	return log.PrefixedLog("list_cache")
 }
 
 // Match matches passed domain name against cached list entries
 func (b *ListCache) Match(domain string, groupsToCheck []string) (groups []string) {
-	return b.groupedCache.Contains(domain, groupsToCheck)
+	groups = b.groupedCache.Contains(domain, groupsToCheck)
+
+	b.groupStateLock.RLock()
+	defer b.groupStateLock.RUnlock()
+
+	for _, group := range groupsToCheck {
+		if b.matchAll[group] && !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
 }
 
 // Refresh triggers the refresh of a list
@@ -113,17 +199,44 @@ func (b *ListCache) Refresh() error {
 	return b.refresh(context.Background())
 }
 
+// RefreshGroups triggers the refresh of the passed groups of a list, unknown groups are ignored
+func (b *ListCache) RefreshGroups(groups []string) error {
+	return b.refreshGroups(context.Background(), groups)
+}
+
+// HasGroup returns true if the list contains the group
+func (b *ListCache) HasGroup(group string) bool {
+	_, ok := b.groupSources[group]
+
+	return ok
+}
+
 func (b *ListCache) refresh(ctx context.Context) error {
+	return b.refreshGroups(ctx, maps.Keys(b.groupSources))
+}
+
+func (b *ListCache) refreshGroups(ctx context.Context, groups []string) error {
 	unlimitedGrp, _ := jobgroup.WithContext(ctx)
 	defer unlimitedGrp.Close()
 
 	producersGrp := jobgroup.WithMaxConcurrency(unlimitedGrp, b.cfg.Concurrency)
 	defer producersGrp.Close()
 
-	for group, sources := range b.groupSources {
+	for _, group := range groups {
+		sources, ok := b.groupSources[group]
+		if !ok {
+			continue
+		}
+
 		unlimitedGrp.Go(func(ctx context.Context) error {
-			err := b.createCacheForGroup(producersGrp, unlimitedGrp, group, sources)
+			err := b.createCacheForGroup(ctx, producersGrp, unlimitedGrp, group, sources)
+
+			if b.sourceCache != nil {
+				b.sourceCache.finishGroup(group, err == nil)
+			}
+
 			if err != nil {
+				mode := b.onGroupFailed(group)
 				count := b.groupedCache.ElementCount(group)
 
 				logger := logger().WithFields(logrus.Fields{
@@ -131,15 +244,20 @@ func (b *ListCache) refresh(ctx context.Context) error {
 					"total_count": count,
 				})
 
-				if count == 0 {
+				switch {
+				case mode != config.ListFailureModeKeep:
+					logger.Warnf("Populating of group cache failed, group uses failure mode '%s' until refresh succeeds", mode)
+				case count == 0:
 					logger.Warn("Populating of group cache failed, cache will be empty until refresh succeeds")
-				} else {
+				default:
 					logger.Warn("Populating of group cache failed, using existing cache, if any")
 				}
 
 				return err
 			}
 
+			b.onGroupLoaded(group)
+
 			count := b.groupedCache.ElementCount(group)
 
 			evt.Bus().Publish(evt.BlockingCacheGroupChanged, b.listType, group, count)
@@ -153,27 +271,64 @@ func (b *ListCache) refresh(ctx context.Context) error {
 		})
 	}
 
-	return unlimitedGrp.Wait()
+	err := unlimitedGrp.Wait()
+
+	if b.sourceCache != nil {
+		if pruneErr := b.sourceCache.prune(); pruneErr != nil {
+			logger().Warn("cannot prune source cache: ", pruneErr)
+		}
+	}
+
+	return err
 }
 
 func (b *ListCache) createCacheForGroup(
-	producersGrp, consumersGrp jobgroup.JobGroup, group string, sources []config.BytesSource,
+	ctx context.Context, producersGrp, consumersGrp jobgroup.JobGroup, group string, sources []config.BytesSource,
 ) error {
 	groupFactory := b.groupedCache.Refresh(group)
 
+	openers := make([]SourceOpener, len(sources))
+	openErrs := make([]error, len(sources))
+
+	for i, source := range sources {
+		openers[i], openErrs[i] = NewSourceOpener(fmt.Sprintf("item #%d of group %s", i, group), source, b.downloader)
+	}
+
+	var cacheKey string
+
+	if b.sourceCache != nil {
+		var cleanup func()
+
+		cacheKey, cleanup = b.spoolSources(producersGrp, openers, openErrs)
+		defer cleanup()
+
+		if cacheKey != "" && b.loadFromSourceCache(group, cacheKey, groupFactory) {
+			groupFactory.Finish()
+
+			return nil
+		}
+
+		// a failed load can leave the factory with partial entries
+		groupFactory = b.groupedCache.Refresh(group)
+	}
+
 	producers := parcour.NewProducersWithBuffer[string](producersGrp, consumersGrp, groupProducersBufferCap)
 	defer producers.Close()
 
-	for i, source := range sources {
+	var failedSources atomic.Int32
+
+	for i, opener := range openers {
 		producers.GoProduce(func(ctx context.Context, hostsChan chan<- string) error {
-			locInfo := fmt.Sprintf("item #%d of group %s", i, group)
+			err := openErrs[i]
+			if err == nil {
+				err = b.parseFile(ctx, opener, hostsChan)
+			}
 
-			opener, err := NewSourceOpener(locInfo, source, b.downloader)
 			if err != nil {
-				return err
+				failedSources.Add(1)
 			}
 
-			return b.parseFile(ctx, opener, hostsChan)
+			return err
 		})
 	}
 
@@ -192,6 +347,20 @@ func (b *ListCache) createCacheForGroup(
 	})
 
 	err := producers.Wait()
+
+	if required := b.cfg.GroupPolicies[group].RequireAtLeast; required > 0 {
+		loaded := len(sources) - int(failedSources.Load())
+
+		if loaded*100 < int(required)*len(sources) {
+			b.publishPolicyTriggered(group, "requireAtLeast")
+
+			return errors.Join(
+				fmt.Errorf("only %d of %d sources of group %s loaded, at least %s required", loaded, len(sources), group, required),
+				err,
+			)
+		}
+	}
+
 	if err != nil {
 		if !hasEntries {
 			// Always fail the group if no entries were parsed
@@ -206,11 +375,139 @@ func (b *ListCache) createCacheForGroup(
 		}
 	}
 
+	// partially parsed sources must not be cached
+	if cacheKey != "" && err == nil && ctx.Err() == nil {
+		if err := b.sourceCache.store(cacheKey, groupFactory); err != nil {
+			logger().WithField("group", group).Warn("cannot store group in source cache: ", err)
+		}
+	}
+
 	groupFactory.Finish()
 
 	return nil
 }
 
+// spoolSources copies the sources into the source cache directory and replaces the openers with the copies.
+// Returns the cache key of the group, which is empty if a source failed, and a function removing the copies.
+func (b *ListCache) spoolSources(
+	producersGrp jobgroup.JobGroup, openers []SourceOpener, errs []error,
+) (string, func()) {
+	spoolGrp := jobgroup.WithParent(producersGrp)
+	defer spoolGrp.Close()
+
+	checksums := make([][]byte, len(openers))
+	spooled := make([]*spooledSource, len(openers))
+
+	for i, opener := range openers {
+		if errs[i] != nil {
+			continue
+		}
+
+		spoolGrp.Go(func(ctx context.Context) error {
+			r, err := opener.Open(ctx)
+			if err == nil {
+				spooled[i], checksums[i], err = b.sourceCache.spool(opener, parsers.LimitSize(r, uint64(b.cfg.MaxSourceSize)))
+				r.Close()
+			}
+
+			if err != nil {
+				logger().WithField("source", opener.String()).Error("cannot read source: ", err)
+
+				errs[i] = err
+
+				return nil
+			}
+
+			openers[i] = spooled[i]
+
+			return nil
+		})
+	}
+
+	// the jobs record their errors, so a failed source doesn't cancel the others: only not started jobs fail here
+	err := spoolGrp.Wait()
+
+	cleanup := func() {
+		for _, source := range spooled {
+			if source != nil {
+				os.Remove(source.path)
+			}
+		}
+	}
+
+	if err != nil || slices.ContainsFunc(errs, func(err error) bool { return err != nil }) {
+		return "", cleanup
+	}
+
+	return b.sourceCache.key(checksums), cleanup
+}
+
+// loadFromSourceCache loads the compiled entries of the group into the factory and returns true on success
+func (b *ListCache) loadFromSourceCache(group, key string, factory stringcache.GroupFactory) bool {
+	b.sourceCache.use(group, key)
+
+	logger := logger().WithField("group", group)
+
+	err := b.sourceCache.load(key, factory)
+
+	switch {
+	case err == nil:
+		logger.Info("import from source cache succeeded")
+
+		return true
+	case !errors.Is(err, fs.ErrNotExist):
+		logger.Warn("cannot load group from source cache, parsing it: ", err)
+	}
+
+	return false
+}
+
+// applies the failure policy of the group and returns the used failure mode
+func (b *ListCache) onGroupFailed(group string) config.ListFailureMode {
+	policy := b.cfg.GroupPolicies[group]
+
+	b.groupStateLock.Lock()
+	defer b.groupStateLock.Unlock()
+
+	lastLoaded, loaded := b.lastLoaded[group]
+
+	if loaded && policy.MaxStaleAge.IsAboveZero() && time.Since(lastLoaded) > policy.MaxStaleAge.ToDuration() {
+		// the existing entries are too old to be used
+		b.groupedCache.Refresh(group).Finish()
+		delete(b.lastLoaded, group)
+		b.publishPolicyTriggered(group, "maxStaleAge")
+
+		loaded = false
+	}
+
+	if loaded || policy.OnFailure == config.ListFailureModeKeep {
+		return config.ListFailureModeKeep
+	}
+
+	// allowing all queries means matching all domains for allowlists and none for denylists and categories
+	if (policy.OnFailure == config.ListFailureModeDenyAll) == (b.listType != ListCacheTypeAllowlist) {
+		b.matchAll[group] = true
+	} else {
+		b.groupedCache.Refresh(group).Finish()
+	}
+
+	b.publishPolicyTriggered(group, policy.OnFailure.String())
+
+	return policy.OnFailure
+}
+
+func (b *ListCache) onGroupLoaded(group string) {
+	b.groupStateLock.Lock()
+	defer b.groupStateLock.Unlock()
+
+	b.lastLoaded[group] = time.Now()
+	delete(b.matchAll, group)
+}
+
+func (b *ListCache) publishPolicyTriggered(group, policy string) {
+	evt.Bus().Publish(evt.BlockingListGroupPolicyTriggered, b.listType, group, policy)
+}
+
 // downloads file (or reads local file) and writes each line in the file to the result channel
 func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh chan<- string) error {
 	count := 0
@@ -232,13 +529,20 @@ func (b *ListCache) parseFile(ctx context.Context, opener SourceOpener, resultCh
 	}
 	defer r.Close()
 
-	p := parsers.AllowErrors(parsers.Hosts(r), b.cfg.MaxErrorsPerSource)
+	reader := parsers.LimitSize(r, uint64(b.cfg.MaxSourceSize))
+
+	p := parsers.AllowErrors(parsers.Hosts(reader), b.cfg.MaxErrorsPerSource)
 	p.OnErr(func(err error) {
 		logger().Warnf("parse error: %s, trying to continue", err)
 	})
 
 	err = parsers.ForEach[*parsers.HostsIterator](ctx, p, func(hosts *parsers.HostsIterator) error {
 		return hosts.ForEach(func(host string) error {
+			if maxEntries := b.cfg.MaxEntriesPerSource; maxEntries > 0 && uint(count) >= maxEntries {
+				return parsers.NewNonResumableError(
+					fmt.Errorf("%w: more than %d entries", parsers.ErrTooManyEntries, maxEntries))
+			}
+
 			count++
 
 			// For IPs, we want to ensure the string is the Go representation so that when
@@ -18,15 +18,19 @@ const (
 	// ListCacheTypeAllowlist is a ListCacheType of type Allowlist.
 	// is a list with allowlisted domains / IPs
 	ListCacheTypeAllowlist
+	// ListCacheTypeCategory is a ListCacheType of type Category.
+	// is a list with the domains of a category
+	ListCacheTypeCategory
 )
 
 var ErrInvalidListCacheType = fmt.Errorf("not a valid ListCacheType, try [%s]", strings.Join(_ListCacheTypeNames, ", "))
 
-const _ListCacheTypeName = "denylistallowlist"
+const _ListCacheTypeName = "denylistallowlistcategory"
 
 var _ListCacheTypeNames = []string{
 	_ListCacheTypeName[0:8],
 	_ListCacheTypeName[8:17],
+	_ListCacheTypeName[17:25],
 }
 
 // ListCacheTypeNames returns a list of possible string values of ListCacheType.
@@ -39,6 +43,7 @@ func ListCacheTypeNames() []string {
 var _ListCacheTypeMap = map[ListCacheType]string{
 	ListCacheTypeDenylist:  _ListCacheTypeName[0:8],
 	ListCacheTypeAllowlist: _ListCacheTypeName[8:17],
+	ListCacheTypeCategory:  _ListCacheTypeName[17:25],
 }
 
 // String implements the Stringer interface.
@@ -57,8 +62,9 @@ func (x ListCacheType) IsValid() bool {
 }
 
 var _ListCacheTypeValue = map[string]ListCacheType{
-	_ListCacheTypeName[0:8]:  ListCacheTypeDenylist,
-	_ListCacheTypeName[8:17]: ListCacheTypeAllowlist,
+	_ListCacheTypeName[0:8]:   ListCacheTypeDenylist,
+	_ListCacheTypeName[8:17]:  ListCacheTypeAllowlist,
+	_ListCacheTypeName[17:25]: ListCacheTypeCategory,
 }
 
 // ParseListCacheType attempts to convert a string to a ListCacheType.
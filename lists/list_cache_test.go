@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/0xERR0R/blocky/config"
 	. "github.com/0xERR0R/blocky/evt"
@@ -332,6 +334,19 @@ var _ = Describe("ListCache", func() {
 				Expect(group).Should(ContainElement("gr1"))
 			})
 		})
+		When("inline list content is defined with prefix", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources("inline:\n  inlinedomain1.com\n  *.tracker.net"),
+				}
+			})
+
+			It("should match", func() {
+				Expect(sut.groupedCache.ElementCount("gr1")).Should(Equal(2))
+				Expect(sut.Match("inlinedomain1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				Expect(sut.Match("sub.tracker.net", []string{"gr1"})).Should(ContainElement("gr1"))
+			})
+		})
 		When("Text file can't be parsed", func() {
 			BeforeEach(func() {
 				lists = map[string][]config.BytesSource{
@@ -364,6 +379,33 @@ var _ = Describe("ListCache", func() {
 				Expect(err).Should(MatchError(parsers.ErrTooManyErrors))
 			})
 		})
+		When("a source has more entries than allowed", func() {
+			BeforeEach(func() {
+				sutConfig.MaxEntriesPerSource = 2
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("first.com", "second.com", "third.com")},
+				}
+			})
+
+			It("should use the entries up to the limit", func() {
+				Expect(sut.Match("second.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				Expect(sut.Match("third.com", []string{"gr1"})).Should(BeEmpty())
+			})
+		})
+		When("a source is bigger than allowed", func() {
+			BeforeEach(func() {
+				sutConfig.MaxSourceSize = 15
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("first.com", "second.com")},
+				}
+			})
+
+			It("should use the complete lines up to the limit", func() {
+				Expect(sut.Match("first.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				Expect(sut.Match("sec", []string{"gr1"})).Should(BeEmpty())
+				Expect(sut.Match("second.com", []string{"gr1"})).Should(BeEmpty())
+			})
+		})
 		When("file has end of line comment", func() {
 			BeforeEach(func() {
 				lists = map[string][]config.BytesSource{
@@ -418,6 +460,19 @@ var _ = Describe("ListCache", func() {
 				ContainSubstring("gr2:"),
 				ContainSubstring("TOTAL:"),
 			))
+			Expect(hook.Messages).ShouldNot(ContainElement(ContainSubstring("UNIQUE:")))
+		})
+
+		It("should print the duplicates in several groups", func() {
+			lists["gr2"] = config.NewBytesSources(file1.Path)
+
+			sut, err = NewListCache(ctx, ListCacheTypeDenylist, sutConfig, lists, downloader)
+			Expect(err).Should(Succeed())
+
+			sut.LogConfig(logger)
+			Expect(hook.Messages).Should(ContainElement(
+				"UNIQUE: 4 domains, 2 duplicates in several groups are stored once",
+			))
 		})
 	})
 
@@ -437,6 +492,307 @@ var _ = Describe("ListCache", func() {
 			})
 		})
 	})
+
+	Describe("group refresh", func() {
+		BeforeEach(func() {
+			lists = map[string][]config.BytesSource{
+				"gr1": config.NewBytesSources(file1.Path),
+				"gr2": config.NewBytesSources(file2.Path),
+			}
+		})
+
+		It("should only refresh the passed groups", func() {
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			Expect(sut.Match("blocked2.com", []string{"gr2"})).Should(ContainElement("gr2"))
+
+			tmpDir.CreateStringFile("file1", "changed1.com")
+			tmpDir.CreateStringFile("file2", "changed2.com")
+
+			Expect(sut.RefreshGroups([]string{"gr1", "unknown"})).Should(Succeed())
+
+			Expect(sut.Match("changed1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+			Expect(sut.Match("blocked2.com", []string{"gr2"})).Should(ContainElement("gr2"))
+		})
+
+		It("should know its groups", func() {
+			Expect(sut.HasGroup("gr1")).Should(BeTrue())
+			Expect(sut.HasGroup("unknown")).Should(BeFalse())
+		})
+
+		When("a group has an own refresh schedule", func() {
+			BeforeEach(func() {
+				sutConfig.RefreshPeriod = config.Duration(10 * time.Millisecond)
+				sutConfig.GroupRefreshSchedules = map[string]config.CronSchedule{}
+				// once a year
+				sutConfig.GroupRefreshSchedules["gr2"], err = config.NewCronSchedule("0 0 1 1 *")
+				Expect(err).Should(Succeed())
+			})
+
+			It("should refresh the group only according to its schedule", func() {
+				tmpDir.CreateStringFile("file1", "changed1.com")
+				tmpDir.CreateStringFile("file2", "changed2.com")
+
+				Eventually(sut.Match, "1s").
+					WithArguments("changed1.com", []string{"gr1"}).
+					Should(ContainElement("gr1"))
+
+				Consistently(sut.Match, "50ms").
+					WithArguments("blocked2.com", []string{"gr2"}).
+					Should(ContainElement("gr2"))
+			})
+		})
+	})
+
+	Describe("source cache", func() {
+		BeforeEach(func() {
+			sutConfig.CacheDir = tmpDir.JoinPath("cache")
+
+			lists = map[string][]config.BytesSource{
+				"gr1": config.NewBytesSources(file1.Path),
+			}
+		})
+
+		cachedFiles := func() []string {
+			files, err := filepath.Glob(filepath.Join(sutConfig.CacheDir, "denylist", "*"))
+			Expect(err).Should(Succeed())
+
+			return files
+		}
+
+		It("should load unchanged sources from the cache", func() {
+			files := cachedFiles()
+			Expect(files).Should(ConsistOf(HaveSuffix(sourceCacheFileExt)))
+
+			// replace the compiled entries to detect their usage
+			factory := sut.groupedCache.Refresh("gr1")
+			factory.AddEntry("cached.com")
+
+			key := strings.TrimSuffix(filepath.Base(files[0]), sourceCacheFileExt)
+			Expect(sut.sourceCache.store(key, factory)).Should(Succeed())
+
+			sut, err = NewListCache(ctx, listCacheType, sutConfig, lists, downloader)
+			Expect(err).Should(Succeed())
+
+			Expect(sut.Match("cached.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+		})
+
+		It("should parse changed sources and prune the old entries", func() {
+			oldFiles := cachedFiles()
+
+			tmpDir.CreateStringFile("file1", "changed1.com")
+
+			Expect(sut.Refresh()).Should(Succeed())
+
+			Expect(sut.Match("changed1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+
+			files := cachedFiles()
+			Expect(files).Should(HaveLen(1))
+			Expect(files).ShouldNot(Equal(oldFiles))
+		})
+
+		It("should parse the sources again, if the parser settings change", func() {
+			oldFiles := cachedFiles()
+
+			sutConfig.MaxEntriesPerSource = 1
+
+			sut, err = NewListCache(ctx, listCacheType, sutConfig, lists, downloader)
+			Expect(err).Should(Succeed())
+
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			Expect(sut.Match("blocked1a.com", []string{"gr1"})).Should(BeEmpty())
+
+			Expect(cachedFiles()).Should(SatisfyAll(HaveLen(1), Not(Equal(oldFiles))))
+		})
+
+		It("should parse invalid cached groups", func() {
+			files := cachedFiles()
+			Expect(os.WriteFile(files[0], []byte(sourceCacheHeader+"invalid"), 0o600)).Should(Succeed())
+
+			sut, err = NewListCache(ctx, listCacheType, sutConfig, lists, downloader)
+			Expect(err).Should(Succeed())
+
+			Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			Expect(sut.Match("blocked1a.com", []string{"gr1"})).Should(ContainElement("gr1"))
+		})
+
+		When("a source can't be read", func() {
+			BeforeEach(func() {
+				lists = map[string][]config.BytesSource{
+					"gr1": config.NewBytesSources(file1.Path, tmpDir.JoinPath("missing")),
+				}
+			})
+
+			It("should use the other sources without caching the group", func() {
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+
+				Expect(cachedFiles()).Should(BeEmpty())
+			})
+		})
+
+		When("a source is bigger than allowed", func() {
+			BeforeEach(func() {
+				sutConfig.MaxSourceSize = 15
+				lists = map[string][]config.BytesSource{
+					"gr1": {config.TextBytesSource("first.com", "second.com")},
+				}
+			})
+
+			It("should use and cache the complete lines up to the limit", func() {
+				Expect(sut.Match("first.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				Expect(sut.Match("sec", []string{"gr1"})).Should(BeEmpty())
+
+				Expect(cachedFiles()).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("failure policies", func() {
+		var policy config.ListGroupPolicy
+
+		BeforeEach(func() {
+			policy = config.ListGroupPolicy{}
+
+			lists = map[string][]config.BytesSource{
+				"gr1": config.NewBytesSources(file1.Path),
+			}
+		})
+
+		JustBeforeEach(func() {
+			sutConfig.GroupPolicies = map[string]config.ListGroupPolicy{"gr1": policy}
+
+			sut, err = NewListCache(ctx, listCacheType, sutConfig, lists, downloader)
+			Expect(err).Should(Succeed())
+		})
+
+		When("less sources than required can be loaded", func() {
+			BeforeEach(func() {
+				policy.RequireAtLeast = 100
+
+				lists["gr1"] = config.NewBytesSources(file1.Path, "doesnotexist")
+			})
+
+			It("should fail the group", func() {
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should fire event", func() {
+				policies := make(chan string, 1)
+				handler := func(_ ListCacheType, group, policy string) {
+					policies <- group + ":" + policy
+				}
+
+				Expect(Bus().Subscribe(BlockingListGroupPolicyTriggered, handler)).Should(Succeed())
+				DeferCleanup(Bus().Unsubscribe, BlockingListGroupPolicyTriggered, handler)
+
+				Expect(sut.Refresh()).ShouldNot(Succeed())
+
+				Eventually(policies).Should(Receive(Equal("gr1:requireAtLeast")))
+			})
+		})
+
+		When("enough sources can be loaded", func() {
+			BeforeEach(func() {
+				policy.RequireAtLeast = 50
+
+				lists["gr1"] = config.NewBytesSources(file1.Path, "doesnotexist")
+			})
+
+			It("should use the loaded sources", func() {
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+			})
+		})
+
+		When("the group fails with failure mode denyAll", func() {
+			BeforeEach(func() {
+				policy.OnFailure = config.ListFailureModeDenyAll
+			})
+
+			It("should keep the existing entries", func() {
+				Expect(os.Remove(file1.Path)).Should(Succeed())
+				Expect(sut.Refresh()).ShouldNot(Succeed())
+
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				Expect(sut.Match("other.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			It("should match all domains if the group was never loaded", func() {
+				sut, err = NewListCache(ctx, listCacheType, sutConfig,
+					map[string][]config.BytesSource{"gr1": config.NewBytesSources("doesnotexist")}, downloader)
+				Expect(err).Should(Succeed())
+
+				Expect(sut.Match("other.com", []string{"gr1", "gr2"})).Should(Equal([]string{"gr1"}))
+			})
+
+			When("the existing entries are older than the max stale age", func() {
+				BeforeEach(func() {
+					policy.MaxStaleAge = config.Duration(time.Millisecond)
+				})
+
+				It("should match all domains until the group is loaded again", func() {
+					time.Sleep(5 * time.Millisecond)
+
+					Expect(os.Remove(file1.Path)).Should(Succeed())
+					Expect(sut.Refresh()).ShouldNot(Succeed())
+
+					Expect(sut.Match("other.com", []string{"gr1"})).Should(ContainElement("gr1"))
+
+					tmpDir.CreateStringFile("file1", "blocked1.com")
+					Expect(sut.Refresh()).Should(Succeed())
+
+					Expect(sut.Match("other.com", []string{"gr1"})).Should(BeEmpty())
+					Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				})
+			})
+		})
+
+		When("the group fails with failure mode allowAll", func() {
+			BeforeEach(func() {
+				policy.OnFailure = config.ListFailureModeAllowAll
+				policy.MaxStaleAge = config.Duration(time.Millisecond)
+			})
+
+			It("should remove the stale entries of a denylist", func() {
+				time.Sleep(5 * time.Millisecond)
+
+				Expect(os.Remove(file1.Path)).Should(Succeed())
+				Expect(sut.Refresh()).ShouldNot(Succeed())
+
+				Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+			})
+
+			When("the list is an allowlist", func() {
+				BeforeEach(func() {
+					listCacheType = ListCacheTypeAllowlist
+				})
+
+				It("should match all domains", func() {
+					time.Sleep(5 * time.Millisecond)
+
+					Expect(os.Remove(file1.Path)).Should(Succeed())
+					Expect(sut.Refresh()).ShouldNot(Succeed())
+
+					Expect(sut.Match("other.com", []string{"gr1"})).Should(ContainElement("gr1"))
+				})
+			})
+
+			When("the list is a category", func() {
+				BeforeEach(func() {
+					listCacheType = ListCacheTypeCategory
+				})
+
+				It("should match no domains", func() {
+					time.Sleep(5 * time.Millisecond)
+
+					Expect(os.Remove(file1.Path)).Should(Succeed())
+					Expect(sut.Refresh()).ShouldNot(Succeed())
+
+					Expect(sut.Match("blocked1.com", []string{"gr1"})).Should(BeEmpty())
+				})
+			})
+		})
+	})
 })
 
 type MockDownloader struct {
@@ -0,0 +1,55 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrInputTooLarge is returned by the reader of `LimitSize`, if the input exceeds the maximum size
+	ErrInputTooLarge = errors.New("input too large")
+
+	// ErrTooManyEntries can be returned by consumers, if a source exceeds the maximum number of entries
+	ErrTooManyEntries = errors.New("too many entries")
+)
+
+// LimitSize returns a reader that fails with `ErrInputTooLarge` after `maxSize` bytes of `r`.
+//
+// A `maxSize` of 0 returns `r` unchanged.
+func LimitSize(r io.Reader, maxSize uint64) io.Reader {
+	if maxSize == 0 {
+		return r
+	}
+
+	return &sizeLimiter{inner: r, maxSize: maxSize, remaining: maxSize}
+}
+
+type sizeLimiter struct {
+	inner     io.Reader
+	maxSize   uint64
+	remaining uint64
+}
+
+func (l *sizeLimiter) Read(p []byte) (int, error) {
+	if l.remaining == 0 {
+		// the input ends exactly at the limit, if there is nothing more to read
+		var probe [1]byte
+
+		n, err := l.inner.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, l.maxSize)
+		}
+
+		return 0, err
+	}
+
+	if uint64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+
+	n, err := l.inner.Read(p)
+	l.remaining -= uint64(n)
+
+	return n, err
+}
@@ -0,0 +1,59 @@
+package parsers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LimitSize", func() {
+	It("reads inputs up to the maximum size", func() {
+		data, err := io.ReadAll(LimitSize(strings.NewReader("domain.tld"), 10))
+		Expect(err).Should(Succeed())
+		Expect(string(data)).Should(Equal("domain.tld"))
+	})
+
+	It("fails for bigger inputs", func() {
+		data, err := io.ReadAll(LimitSize(strings.NewReader("domain.tld"), 6))
+		Expect(err).Should(MatchError(ErrInputTooLarge))
+		Expect(string(data)).Should(Equal("domain"))
+	})
+
+	It("doesn't limit the size, if the maximum size is 0", func() {
+		r := strings.NewReader("domain.tld")
+
+		Expect(LimitSize(r, 0)).Should(BeIdenticalTo(r))
+	})
+
+	It("stops the parsing of a list", func() {
+		p := Hosts(LimitSize(linesReader("first.tld", "second.tld"), 12))
+
+		_, err := p.Next(context.Background())
+		Expect(err).Should(Succeed())
+
+		_, err = p.Next(context.Background())
+		Expect(err).Should(MatchError(ErrInputTooLarge))
+		Expect(IsNonResumableErr(err)).Should(BeTrue())
+	})
+})
+
+// FuzzHosts ensures that malformed lists neither panic nor block the parsing
+func FuzzHosts(f *testing.F) {
+	f.Add("127.0.0.1 domain.tld alias # comment\n::1 localhost")
+	f.Add(`/^(.*\.)?2023\.xn--aptslabs-6fd\.net$/`)
+	f.Add("||0-c1j0.lat^\n*.example.com\nmüller.com")
+	f.Add("/unclosed\n# comment\n\x00\xff")
+	f.Add(strings.Repeat("a", 70000))
+
+	f.Fuzz(func(t *testing.T, data string) {
+		p := AllowErrors(Hosts(LimitSize(strings.NewReader(data), 1<<16)), NoErrorLimit)
+
+		_ = ForEach[*HostsIterator](context.Background(), p, func(hosts *HostsIterator) error {
+			return hosts.ForEach(func(string) error { return nil })
+		})
+	})
+}
@@ -2,8 +2,10 @@ package parsers
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -42,12 +44,36 @@ type lines struct {
 }
 
 func newLines(r io.Reader) SeriesParser[string] {
-	scanner := bufio.NewScanner(r)
-	scanner.Split(bufio.ScanLines)
+	reader := &errRecorder{inner: r}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		// the incomplete last line of a failed input (e.g. a truncated download) is dropped
+		if atEOF && reader.err != nil && bytes.IndexByte(data, '\n') < 0 {
+			return 0, nil, reader.err
+		}
+
+		return bufio.ScanLines(data, atEOF)
+	})
 
 	return &lines{scanner: scanner}
 }
 
+// errRecorder records the error of the inner reader, except `io.EOF`
+type errRecorder struct {
+	inner io.Reader
+	err   error
+}
+
+func (r *errRecorder) Read(p []byte) (int, error) {
+	n, err := r.inner.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+
+	return n, err
+}
+
 func (l *lines) Position() string {
 	return fmt.Sprintf("line %d", l.lineNo)
 }
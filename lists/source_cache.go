@@ -0,0 +1,248 @@
+package lists
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/0xERR0R/blocky/cache/stringcache"
+	"github.com/0xERR0R/blocky/lists/parsers"
+)
+
+const (
+	sourceCacheHeader  = "blocky compiled group v2\n"
+	sourceCacheFileExt = ".compiled"
+)
+
+var errInvalidSourceCacheFile = errors.New("invalid source cache file")
+
+// sourceCache stores the compiled entries of list groups on disk: the sorted strings, wildcard tries, regexes and bloom
+// filter keys of the cache factories. The key is the checksum of the parser settings and the content of the sources.
+// Groups with unchanged sources are loaded from the cache without parsing them again, e.g. after a restart.
+type sourceCache struct {
+	dir string
+	// parser settings, which change the entries parsed from a source
+	settings string
+
+	lock sync.Mutex
+	// key of the last successful import of each group
+	groupKeys map[string]string
+	// keys of the running imports
+	pendingKeys map[string]string
+}
+
+func newSourceCache(dir, settings string) (*sourceCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("can't create source cache directory: %w", err)
+	}
+
+	return &sourceCache{
+		dir:         dir,
+		settings:    settings,
+		groupKeys:   make(map[string]string),
+		pendingKeys: make(map[string]string),
+	}, nil
+}
+
+// spool copies the source to a temporary file in the cache directory, so it can be parsed if the group isn't cached.
+// The content is hashed while it is copied. The caller must remove the file of the returned source.
+func (c *sourceCache) spool(source SourceOpener, r io.Reader) (*spooledSource, []byte, error) {
+	f, err := os.CreateTemp(c.dir, "spool-*.tmp")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spooled := &spooledSource{SourceOpener: source, path: f.Name()}
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(f, hash), r)
+	if errors.Is(err, parsers.ErrInputTooLarge) {
+		// the content up to the limit is parsed like without the cache, it differs from a source ending at the limit
+		spooled.tailErr = err
+		hash.Write([]byte(err.Error()))
+
+		err = nil
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+
+		return nil, nil, err
+	}
+
+	return spooled, hash.Sum(nil), nil
+}
+
+// key returns the cache key of a group with the checksums of its sources
+func (c *sourceCache) key(checksums [][]byte) string {
+	hash := sha256.New()
+
+	// the lengths are fixed, so the hashed values can't be ambiguous
+	fmt.Fprintf(hash, "%s\n%d\n", c.settings, len(checksums))
+
+	for _, checksum := range checksums {
+		hash.Write(checksum)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *sourceCache) path(key string) string {
+	return filepath.Join(c.dir, key+sourceCacheFileExt)
+}
+
+// use marks the key as used by the running import of the group
+func (c *sourceCache) use(group, key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pendingKeys[group] = key
+}
+
+// finishGroup updates the used key of the group after an import
+func (c *sourceCache) finishGroup(group string, success bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if key, ok := c.pendingKeys[group]; ok && success {
+		c.groupKeys[group] = key
+	}
+
+	delete(c.pendingKeys, group)
+}
+
+// load restores the compiled entries of the key into the unused factory. Returns `os.ErrNotExist` if the key is not
+// cached. The factory must not be used on other errors.
+func (c *sourceCache) load(key string, factory stringcache.GroupFactory) error {
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	header := make([]byte, len(sourceCacheHeader))
+	if _, err := io.ReadFull(r, header); err != nil || string(header) != sourceCacheHeader {
+		return errInvalidSourceCacheFile
+	}
+
+	if err := stringcache.DecodeFactory(r, factory); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidSourceCacheFile, err)
+	}
+
+	return nil
+}
+
+// store writes the compiled entries of the factory before `Finish` is called
+func (c *sourceCache) store(key string, factory stringcache.GroupFactory) error {
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+
+	_, err = w.WriteString(sourceCacheHeader)
+
+	if err == nil {
+		err = stringcache.EncodeFactory(w, factory)
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// prune removes all cached groups, which are neither used by a group nor by a running import
+func (c *sourceCache) prune() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var errs []error
+
+	for _, file := range files {
+		key, ok := strings.CutSuffix(file.Name(), sourceCacheFileExt)
+		if !ok || c.isUsed(key) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(c.dir, file.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *sourceCache) isUsed(key string) bool {
+	for _, keys := range [...]map[string]string{c.groupKeys, c.pendingKeys} {
+		for _, groupKey := range keys {
+			if groupKey == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// spooledSource is a source copied into the source cache directory
+type spooledSource struct {
+	SourceOpener // the original source
+
+	path string
+	// error returned after the content, if the source was truncated
+	tailErr error
+}
+
+func (s *spooledSource) Open(context.Context) (io.ReadCloser, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.tailErr == nil {
+		return f, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(f, errReader{s.tailErr}), f}, nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
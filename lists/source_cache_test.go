@@ -0,0 +1,156 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing/iotest"
+
+	"github.com/0xERR0R/blocky/cache/stringcache"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/lists/parsers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SourceCache", func() {
+	var (
+		sut    *sourceCache
+		tmpDir *TmpFolder
+	)
+
+	BeforeEach(func() {
+		tmpDir = NewTmpFolder("SourceCache")
+		DeferCleanup(tmpDir.Clean)
+
+		var err error
+
+		sut, err = newSourceCache(tmpDir.JoinPath("cache"), "settings")
+		Expect(err).Should(Succeed())
+	})
+
+	newCache := func() stringcache.GroupedStringCache {
+		return stringcache.NewChainedGroupedCache(
+			stringcache.NewInMemoryGroupedWildcardCache(),
+			stringcache.NewSharedGroupedStringCache(),
+		)
+	}
+
+	store := func(key string, entries ...string) {
+		factory := newCache().Refresh("gr1")
+
+		for _, entry := range entries {
+			factory.AddEntry(entry)
+		}
+
+		Expect(sut.store(key, factory)).Should(Succeed())
+	}
+
+	spool := func(r io.Reader) (*spooledSource, []byte) {
+		source, checksum, err := sut.spool(nil, r)
+		Expect(err).Should(Succeed())
+		DeferCleanup(os.Remove, source.path)
+
+		return source, checksum
+	}
+
+	checksum := func(content string) []byte {
+		_, checksum := spool(strings.NewReader(content))
+
+		return checksum
+	}
+
+	read := func(source *spooledSource) (string, error) {
+		r, err := source.Open(context.Background())
+		Expect(err).Should(Succeed())
+
+		defer r.Close()
+
+		content, err := io.ReadAll(r)
+
+		return string(content), err
+	}
+
+	It("should spool the content", func() {
+		source, _ := spool(strings.NewReader("example.com"))
+
+		Expect(read(source)).Should(Equal("example.com"))
+	})
+
+	It("should spool the content up to the size limit", func() {
+		source, truncated := spool(parsers.LimitSize(strings.NewReader("example.com"), 7))
+
+		content, err := read(source)
+		Expect(content).Should(Equal("example"))
+		Expect(err).Should(MatchError(parsers.ErrInputTooLarge))
+
+		Expect(truncated).ShouldNot(Equal(checksum("example")))
+	})
+
+	It("should fail on read errors", func() {
+		_, _, err := sut.spool(nil, iotest.ErrReader(errors.New("read failed")))
+		Expect(err).Should(MatchError("read failed"))
+
+		Expect(os.ReadDir(sut.dir)).Should(BeEmpty())
+	})
+
+	It("should use the checksums of the settings and the content as key", func() {
+		key := sut.key([][]byte{checksum("example.com")})
+
+		Expect(sut.key([][]byte{checksum("example.com")})).Should(Equal(key))
+		Expect(sut.key([][]byte{checksum("example.org")})).ShouldNot(Equal(key))
+		Expect(sut.key([][]byte{checksum("example.com"), checksum("")})).ShouldNot(Equal(key))
+
+		other, err := newSourceCache(tmpDir.JoinPath("cache"), "other settings")
+		Expect(err).Should(Succeed())
+		Expect(other.key([][]byte{checksum("example.com")})).ShouldNot(Equal(key))
+	})
+
+	It("should load stored compiled entries", func() {
+		cache := newCache()
+
+		Expect(sut.load("key", cache.Refresh("gr1"))).Should(MatchError(os.ErrNotExist))
+
+		store("key", "example.com", "*.example.org")
+
+		factory := cache.Refresh("gr1")
+		Expect(sut.load("key", factory)).Should(Succeed())
+		factory.Finish()
+
+		Expect(cache.ElementCount("gr1")).Should(Equal(2))
+		Expect(cache.Contains("example.com", []string{"gr1"})).Should(ConsistOf("gr1"))
+		Expect(cache.Contains("www.example.org", []string{"gr1"})).Should(ConsistOf("gr1"))
+	})
+
+	It("should fail on invalid files", func() {
+		Expect(os.WriteFile(sut.path("text"), []byte("example.com\n"), 0o600)).Should(Succeed())
+		Expect(sut.load("text", newCache().Refresh("gr1"))).Should(MatchError(errInvalidSourceCacheFile))
+
+		Expect(os.WriteFile(sut.path("truncated"), []byte(sourceCacheHeader+"x"), 0o600)).Should(Succeed())
+		Expect(sut.load("truncated", newCache().Refresh("gr1"))).Should(MatchError(errInvalidSourceCacheFile))
+	})
+
+	It("should prune unused entries", func() {
+		store("used")
+		store("running")
+		store("unused")
+		store("failed")
+
+		sut.use("gr1", "used")
+		sut.finishGroup("gr1", true)
+
+		sut.use("gr2", "failed")
+		sut.finishGroup("gr2", false)
+
+		sut.use("gr3", "running")
+
+		Expect(sut.prune()).Should(Succeed())
+
+		Expect(sut.path("used")).Should(BeAnExistingFile())
+		Expect(sut.path("running")).Should(BeAnExistingFile())
+		Expect(sut.path("unused")).ShouldNot(BeAnExistingFile())
+		Expect(sut.path("failed")).ShouldNot(BeAnExistingFile())
+	})
+})
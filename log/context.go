@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"io"
 
 	"github.com/sirupsen/logrus"
 )
@@ -44,3 +45,38 @@ func CtxWithFields(ctx context.Context, fields logrus.Fields) (context.Context,
 		return e.WithFields(fields)
 	})
 }
+
+// CaptureCtx returns a context, whose logger passes all entries to `capture`, independent of the log level.
+// Entries enabled by the level of the original logger are logged by it as well.
+func CaptureCtx(ctx context.Context, capture func(*logrus.Entry)) (context.Context, *logrus.Entry) {
+	original := FromCtx(ctx)
+
+	captureLogger := logrus.New()
+	captureLogger.SetLevel(logrus.TraceLevel)
+	captureLogger.SetOutput(io.Discard)
+	captureLogger.SetFormatter(nopFormatter{})
+	captureLogger.AddHook(&captureHook{capture: capture, original: original.Logger})
+
+	return NewCtx(ctx, captureLogger.WithFields(original.Data))
+}
+
+type captureHook struct {
+	capture  func(*logrus.Entry)
+	original *logrus.Logger
+}
+
+// Levels implements `logrus.Hook`.
+func (h *captureHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements `logrus.Hook`.
+func (h *captureHook) Fire(entry *logrus.Entry) error {
+	h.capture(entry)
+
+	if h.original.IsLevelEnabled(entry.Level) {
+		h.original.WithTime(entry.Time).WithFields(entry.Data).Log(entry.Level, entry.Message)
+	}
+
+	return nil
+}
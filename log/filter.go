@@ -0,0 +1,208 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingConfig limits the number of equal messages (same level, prefix and message) per period.
+// Warnings and errors are never dropped.
+type SamplingConfig struct {
+	// number of equal messages logged per period, 0 disables the sampling
+	Initial uint `yaml:"initial"`
+	// after the initial messages only every n-th message is logged, 0 drops all of them
+	Thereafter uint          `yaml:"thereafter"`
+	Period     time.Duration `default:"1s" yaml:"period"`
+}
+
+// IsEnabled returns true, if messages are sampled
+func (c *SamplingConfig) IsEnabled() bool {
+	return c.Initial > 0
+}
+
+// minLevel returns the most verbose level of the configuration, it is the level of the logger.
+// The formatter drops the messages, which are not enabled for their module.
+func (c *Config) minLevel() logrus.Level {
+	level := c.Level
+
+	for _, moduleLevel := range c.Levels {
+		level = max(level, moduleLevel)
+	}
+
+	return level
+}
+
+// filterFormatter drops messages, which are not enabled for their module or dropped by the sampling
+type filterFormatter struct {
+	logrus.Formatter
+
+	level  logrus.Level
+	levels map[string]logrus.Level
+
+	sampler *sampler
+}
+
+func newFilterFormatter(formatter logrus.Formatter, cfg *Config) logrus.Formatter {
+	if len(cfg.Levels) == 0 && !cfg.Sampling.IsEnabled() {
+		return formatter
+	}
+
+	f := &filterFormatter{
+		Formatter: formatter,
+		level:     cfg.Level,
+		levels:    maps.Clone(cfg.Levels),
+	}
+
+	if cfg.Sampling.IsEnabled() {
+		f.sampler = &sampler{cfg: cfg.Sampling, counts: make(map[sampleKey]uint)}
+	}
+
+	return f
+}
+
+// Format implements `logrus.Formatter`.
+func (f *filterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level > f.moduleLevel(Prefix(entry)) {
+		return nil, nil
+	}
+
+	if f.sampler != nil && !f.sampler.sample(entry) {
+		return nil, nil
+	}
+
+	return f.Formatter.Format(entry)
+}
+
+// moduleLevel returns the level of the prefix: the level of the complete prefix or else of its innermost
+// configured module, e.g. `custom_dns` for `query_logging.custom_dns`
+func (f *filterFormatter) moduleLevel(prefix string) logrus.Level {
+	if len(f.levels) == 0 || prefix == "" {
+		return f.level
+	}
+
+	if level, ok := f.levels[prefix]; ok {
+		return level
+	}
+
+	segments := strings.Split(prefix, ".")
+
+	for _, segment := range slices.Backward(segments) {
+		if level, ok := f.levels[segment]; ok {
+			return level
+		}
+	}
+
+	return f.level
+}
+
+type sampleKey struct {
+	level   logrus.Level
+	prefix  string
+	message string
+}
+
+type sampler struct {
+	cfg SamplingConfig
+
+	lock        sync.Mutex
+	periodStart time.Time
+	counts      map[sampleKey]uint
+}
+
+// sample returns true, if the entry should be logged
+func (s *sampler) sample(entry *logrus.Entry) bool {
+	if entry.Level <= logrus.WarnLevel {
+		return true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if entry.Time.Sub(s.periodStart) >= s.cfg.Period {
+		s.periodStart = entry.Time
+		clear(s.counts)
+	}
+
+	key := sampleKey{level: entry.Level, prefix: Prefix(entry), message: entry.Message}
+
+	s.counts[key]++
+	count := s.counts[key]
+
+	if count <= s.cfg.Initial {
+		return true
+	}
+
+	return s.cfg.Thereafter > 0 && (count-s.cfg.Initial)%s.cfg.Thereafter == 0
+}
+
+// slogFormatter formats the entries with the JSON handler of `log/slog`
+type slogFormatter struct {
+	timestamp bool
+}
+
+// Format implements `logrus.Formatter`.
+func (f slogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slogLevel(logrus.TraceLevel),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) > 0:
+				return a
+			case a.Key == slog.TimeKey && !f.timestamp:
+				return slog.Attr{}
+			case a.Key == slog.LevelKey:
+				return slog.String(slog.LevelKey, strings.ToUpper(entry.Level.String()))
+			default:
+				return a
+			}
+		},
+	})
+
+	record := slog.NewRecord(entry.Time, slogLevel(entry.Level), entry.Message, 0)
+
+	for _, key := range slices.Sorted(maps.Keys(entry.Data)) {
+		value := entry.Data[key]
+
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		record.AddAttrs(slog.Any(key, value))
+	}
+
+	if err := handler.Handle(context.Background(), record); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// slogLevel maps the logrus level to the slog level, trace is below debug and fatal/panic above error
+func slogLevel(level logrus.Level) slog.Level {
+	const step = 4 // distance of the slog levels
+
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return slog.LevelError + step
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	default:
+		return slog.LevelDebug - step
+	}
+}
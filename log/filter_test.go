@@ -0,0 +1,152 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Log filter", func() {
+	var (
+		cfg    *Config
+		logger *logrus.Logger
+		out    *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		cfg = DefaultConfig()
+		cfg.Format = FormatTypeJson
+	})
+
+	JustBeforeEach(func() {
+		logger = logrus.New()
+		ConfigureLogger(logger, cfg)
+
+		out = new(bytes.Buffer)
+		logger.SetOutput(out)
+	})
+
+	lines := func() []string {
+		return strings.FieldsFunc(out.String(), func(r rune) bool { return r == '\n' })
+	}
+
+	Describe("module levels", func() {
+		BeforeEach(func() {
+			cfg.Levels = map[string]logrus.Level{
+				"blocking": logrus.DebugLevel,
+				"server":   logrus.WarnLevel,
+			}
+		})
+
+		It("should use the most verbose level for the logger", func() {
+			Expect(logger.GetLevel()).Should(Equal(logrus.DebugLevel))
+		})
+
+		It("should apply the level of the module", func() {
+			logger.WithField(prefixField, "blocking").Debug("blocking debug")
+			logger.WithField(prefixField, "query_logging.blocking").Debug("nested debug")
+			logger.WithField(prefixField, "server").Info("server info")
+			logger.WithField(prefixField, "server").Warn("server warn")
+			logger.WithField(prefixField, "caching").Debug("caching debug")
+			logger.WithField(prefixField, "caching").Info("caching info")
+			logger.Debug("debug")
+
+			Expect(lines()).Should(HaveExactElements(
+				ContainSubstring("blocking debug"),
+				ContainSubstring("nested debug"),
+				ContainSubstring("server warn"),
+				ContainSubstring("caching info"),
+			))
+		})
+
+		It("should prefer the complete prefix", func() {
+			cfg.Levels["query_logging.blocking"] = logrus.InfoLevel
+
+			logger = logrus.New()
+			ConfigureLogger(logger, cfg)
+			logger.SetOutput(out)
+
+			logger.WithField(prefixField, "query_logging.blocking").Debug("nested debug")
+
+			Expect(lines()).Should(BeEmpty())
+		})
+	})
+
+	Describe("sampling", func() {
+		BeforeEach(func() {
+			cfg.Sampling = SamplingConfig{Initial: 2, Thereafter: 3, Period: time.Hour}
+		})
+
+		It("should drop equal messages", func() {
+			for range 8 {
+				logger.Info("equal")
+			}
+
+			logger.Info("other")
+
+			// 1st, 2nd, 5th and 8th message
+			Expect(lines()).Should(HaveLen(5))
+		})
+
+		It("should not drop warnings", func() {
+			for range 5 {
+				logger.Warn("equal")
+			}
+
+			Expect(lines()).Should(HaveLen(5))
+		})
+
+		It("should drop all messages after the initial ones without thereafter", func() {
+			cfg.Sampling.Thereafter = 0
+
+			logger = logrus.New()
+			ConfigureLogger(logger, cfg)
+			logger.SetOutput(out)
+
+			for range 5 {
+				logger.WithField(prefixField, "caching").Info("equal")
+			}
+
+			Expect(lines()).Should(HaveLen(2))
+		})
+	})
+
+	Describe("slog format", func() {
+		BeforeEach(func() {
+			cfg.Format = FormatTypeSlog
+			cfg.Level = logrus.TraceLevel
+			cfg.Timestamp = false
+		})
+
+		It("should log JSON", func() {
+			logger.WithField(prefixField, "blocking").WithField("count", 3).Trace("message")
+
+			var entry map[string]any
+			Expect(json.Unmarshal(out.Bytes(), &entry)).Should(Succeed())
+
+			Expect(entry).Should(Equal(map[string]any{
+				"level":  "TRACE",
+				"msg":    "message",
+				"prefix": "blocking",
+				"count":  float64(3),
+			}))
+		})
+
+		It("should log the timestamp", func() {
+			cfg.Timestamp = true
+
+			logger = logrus.New()
+			ConfigureLogger(logger, cfg)
+			logger.SetOutput(out)
+
+			logger.Info("message")
+
+			Expect(out.String()).Should(ContainSubstring(`"time":`))
+		})
+	})
+})
@@ -29,6 +29,7 @@ var (
 // FormatType format for logging ENUM(
 // text // logging as text
 // json // JSON format
+// slog // JSON format of the structured logging package of Go (log/slog)
 // )
 type FormatType int
 
@@ -38,6 +39,9 @@ type Config struct {
 	Format    FormatType   `default:"text"  yaml:"format"`
 	Privacy   bool         `default:"false" yaml:"privacy"`
 	Timestamp bool         `default:"true"  yaml:"timestamp"`
+	// levels of single modules (log prefixes), overriding `Level`
+	Levels   map[string]logrus.Level `yaml:"levels"`
+	Sampling SamplingConfig          `yaml:"sampling"`
 }
 
 // DefaultConfig returns a new Config initialized with default values.
@@ -81,6 +85,13 @@ func WithPrefix(logger *logrus.Entry, prefix string) *logrus.Entry {
 	return logger.WithField(prefixField, prefix)
 }
 
+// Prefix returns the prefix of the entry
+func Prefix(entry *logrus.Entry) string {
+	prefix, _ := entry.Data[prefixField].(string)
+
+	return prefix
+}
+
 // EscapeInput removes line breaks from input
 func EscapeInput(input string) string {
 	result := strings.ReplaceAll(input, "\n", "")
@@ -96,7 +107,7 @@ func Configure(cfg *Config) {
 
 // Configure applies configuration to the given logger.
 func ConfigureLogger(logger *logrus.Logger, cfg *Config) {
-	logger.SetLevel(cfg.Level)
+	logger.SetLevel(cfg.minLevel())
 
 	switch cfg.Format {
 	case FormatTypeText:
@@ -121,7 +132,12 @@ func ConfigureLogger(logger *logrus.Logger, cfg *Config) {
 
 	case FormatTypeJson:
 		logger.SetFormatter(&logrus.JSONFormatter{})
+
+	case FormatTypeSlog:
+		logger.SetFormatter(slogFormatter{timestamp: cfg.Timestamp})
 	}
+
+	logger.SetFormatter(newFilterFormatter(logger.Formatter, cfg))
 }
 
 // Silence disables the logger output
@@ -142,6 +158,37 @@ func (f nopFormatter) Format(*logrus.Entry) ([]byte, error) {
 	return nil, nil
 }
 
+// Messages returns the messages logged by the callback, independent of the log level. They are not logged.
+func Messages(callback func(*logrus.Entry)) []string {
+	hook := &messagesHook{}
+
+	captureLogger := logrus.New()
+	captureLogger.SetLevel(logrus.TraceLevel)
+	captureLogger.SetOutput(io.Discard)
+	captureLogger.SetFormatter(nopFormatter{})
+	captureLogger.AddHook(hook)
+
+	callback(logrus.NewEntry(captureLogger))
+
+	return hook.messages
+}
+
+type messagesHook struct {
+	messages []string
+}
+
+// Levels implements `logrus.Hook`.
+func (h *messagesHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements `logrus.Hook`.
+func (h *messagesHook) Fire(entry *logrus.Entry) error {
+	h.messages = append(h.messages, entry.Message)
+
+	return nil
+}
+
 func WithIndent(log *logrus.Entry, prefix string, callback func(*logrus.Entry)) {
 	undo := indentMessages(prefix, log.Logger)
 	defer undo()
@@ -153,7 +200,12 @@ func WithIndent(log *logrus.Entry, prefix string, callback func(*logrus.Entry))
 //
 // The returned function must be called to remove the prefix.
 func indentMessages(prefix string, logger *logrus.Logger) func() {
-	if _, ok := logger.Formatter.(*prefixed.TextFormatter); !ok {
+	formatter := logger.Formatter
+	if filter, ok := formatter.(*filterFormatter); ok {
+		formatter = filter.Formatter
+	}
+
+	if _, ok := formatter.(*prefixed.TextFormatter); !ok {
 		// log is not plaintext, do nothing
 		return func() {}
 	}
@@ -18,15 +18,19 @@ const (
 	// FormatTypeJson is a FormatType of type Json.
 	// JSON format
 	FormatTypeJson
+	// FormatTypeSlog is a FormatType of type Slog.
+	// JSON format of the structured logging package of Go (log/slog)
+	FormatTypeSlog
 )
 
 var ErrInvalidFormatType = fmt.Errorf("not a valid FormatType, try [%s]", strings.Join(_FormatTypeNames, ", "))
 
-const _FormatTypeName = "textjson"
+const _FormatTypeName = "textjsonslog"
 
 var _FormatTypeNames = []string{
 	_FormatTypeName[0:4],
 	_FormatTypeName[4:8],
+	_FormatTypeName[8:12],
 }
 
 // FormatTypeNames returns a list of possible string values of FormatType.
@@ -39,6 +43,7 @@ func FormatTypeNames() []string {
 var _FormatTypeMap = map[FormatType]string{
 	FormatTypeText: _FormatTypeName[0:4],
 	FormatTypeJson: _FormatTypeName[4:8],
+	FormatTypeSlog: _FormatTypeName[8:12],
 }
 
 // String implements the Stringer interface.
@@ -57,8 +62,9 @@ func (x FormatType) IsValid() bool {
 }
 
 var _FormatTypeValue = map[string]FormatType{
-	_FormatTypeName[0:4]: FormatTypeText,
-	_FormatTypeName[4:8]: FormatTypeJson,
+	_FormatTypeName[0:4]:  FormatTypeText,
+	_FormatTypeName[4:8]:  FormatTypeJson,
+	_FormatTypeName[8:12]: FormatTypeSlog,
 }
 
 // ParseFormatType attempts to convert a string to a FormatType.
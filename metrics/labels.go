@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ClientLabel name of the labels containing the client names
+	ClientLabel = "client"
+	// DomainLabel name of the labels containing a domain
+	DomainLabel = "domain"
+
+	// otherDomains label value of all domains, which are not one of the top domains
+	otherDomains = "other"
+
+	// number of tracked domains per top domain, more tracked domains improve the accuracy of the top domains
+	topDomainsTrackingFactor = 10
+
+	// length of the client hash in bytes
+	clientHashLen = 8
+)
+
+//nolint:gochecknoglobals
+var (
+	policy atomic.Pointer[labelPolicy]
+
+	domainVecsLock sync.Mutex
+	// vectors with a domain label, the series of domains dropping out of the top domains are deleted
+	domainVecs []*prometheus.MetricVec
+)
+
+// labelPolicy limits the cardinality of the metric labels
+type labelPolicy struct {
+	client   config.MetricsClientLabel
+	disabled []string
+	// nil, if the domain labels are unlimited
+	domains *topDomains
+}
+
+// ConfigureLabels sets the policy, which is applied to the label values of the metrics
+func ConfigureLabels(cfg config.MetricsLabels) {
+	p := labelPolicy{
+		client:   cfg.Client,
+		disabled: cfg.Disable,
+	}
+
+	if cfg.TopDomains > 0 {
+		p.domains = newTopDomains(int(cfg.TopDomains))
+	}
+
+	policy.Store(&p)
+}
+
+// labelValue returns the value of the label according to the policy
+func (p *labelPolicy) labelValue(name, value string) string {
+	if slices.Contains(p.disabled, name) {
+		return ""
+	}
+
+	switch name {
+	case ClientLabel:
+		if p.client == config.MetricsClientLabelHash && value != "" {
+			hash := sha256.Sum256([]byte(value))
+
+			return hex.EncodeToString(hash[:clientHashLen])
+		}
+	case DomainLabel:
+		if p.domains != nil {
+			label, evicted := p.domains.label(value)
+			if evicted != "" {
+				deleteDomainSeries(evicted)
+			}
+
+			return label
+		}
+	}
+
+	return value
+}
+
+func applyPolicy(names, values []string) []string {
+	p := policy.Load()
+	if p == nil {
+		return values
+	}
+
+	result := make([]string, len(values))
+
+	for i, value := range values {
+		if i < len(names) {
+			value = p.labelValue(names[i], value)
+		}
+
+		result[i] = value
+	}
+
+	return result
+}
+
+func applyPolicyToLabels(labels prometheus.Labels) prometheus.Labels {
+	p := policy.Load()
+	if p == nil {
+		return labels
+	}
+
+	result := make(prometheus.Labels, len(labels))
+
+	for name, value := range labels {
+		result[name] = p.labelValue(name, value)
+	}
+
+	return result
+}
+
+func trackDomainLabel(vec *prometheus.MetricVec, labelNames []string) {
+	if !slices.Contains(labelNames, DomainLabel) {
+		return
+	}
+
+	domainVecsLock.Lock()
+	defer domainVecsLock.Unlock()
+
+	domainVecs = append(domainVecs, vec)
+}
+
+func deleteDomainSeries(domain string) {
+	domainVecsLock.Lock()
+	defer domainVecsLock.Unlock()
+
+	for _, vec := range domainVecs {
+		vec.DeletePartialMatch(prometheus.Labels{DomainLabel: domain})
+	}
+}
+
+// topDomains approximates the most frequent domains with the space-saving algorithm:
+// a fixed number of domains is counted, a new domain replaces the domain with the lowest count
+type topDomains struct {
+	lock sync.Mutex
+
+	limit  int
+	counts map[string]uint64
+	top    map[string]struct{}
+}
+
+func newTopDomains(limit int) *topDomains {
+	return &topDomains{
+		limit:  limit,
+		counts: make(map[string]uint64, limit*topDomainsTrackingFactor),
+		top:    make(map[string]struct{}, limit),
+	}
+}
+
+// label counts the domain and returns the domain, if it is one of the top domains, "other" otherwise.
+// evicted is the domain, which dropped out of the top domains, if any.
+func (t *topDomains) label(domain string) (label, evicted string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	cnt, ok := t.counts[domain]
+	if !ok && len(t.counts) >= t.limit*topDomainsTrackingFactor {
+		minDomain, minCnt := lowest(t, t.counts)
+
+		delete(t.counts, minDomain)
+
+		if _, ok := t.top[minDomain]; ok {
+			delete(t.top, minDomain)
+
+			evicted = minDomain
+		}
+
+		cnt = minCnt
+	}
+
+	cnt++
+	t.counts[domain] = cnt
+
+	if _, ok := t.top[domain]; ok {
+		return domain, evicted
+	}
+
+	if len(t.top) < t.limit {
+		t.top[domain] = struct{}{}
+
+		return domain, evicted
+	}
+
+	minDomain, minCnt := lowest(t, t.top)
+	if cnt > minCnt {
+		delete(t.top, minDomain)
+		t.top[domain] = struct{}{}
+
+		return domain, minDomain
+	}
+
+	return otherDomains, evicted
+}
+
+// lowest returns the domain of the set with the lowest count
+func lowest[V any](t *topDomains, domains map[string]V) (string, uint64) {
+	var (
+		result   string
+		minCount uint64
+	)
+
+	for domain := range domains {
+		if cnt := t.counts[domain]; result == "" || cnt < minCount {
+			result = domain
+			minCount = cnt
+		}
+	}
+
+	return result, minCount
+}
+
+// CounterVec is a prometheus.CounterVec, which applies the label policy to the label values
+type CounterVec struct {
+	*prometheus.CounterVec
+
+	labelNames []string
+}
+
+// NewCounterVec creates a new CounterVec, which applies the label policy
+func NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *CounterVec {
+	v := &CounterVec{prometheus.NewCounterVec(opts, labelNames), labelNames}
+
+	trackDomainLabel(v.MetricVec, labelNames)
+
+	return v
+}
+
+// WithLabelValues see prometheus.CounterVec
+func (v *CounterVec) WithLabelValues(lvs ...string) prometheus.Counter {
+	return v.CounterVec.WithLabelValues(applyPolicy(v.labelNames, lvs)...)
+}
+
+// With see prometheus.CounterVec
+func (v *CounterVec) With(labels prometheus.Labels) prometheus.Counter {
+	return v.CounterVec.With(applyPolicyToLabels(labels))
+}
+
+// GaugeVec is a prometheus.GaugeVec, which applies the label policy to the label values
+type GaugeVec struct {
+	*prometheus.GaugeVec
+
+	labelNames []string
+}
+
+// NewGaugeVec creates a new GaugeVec, which applies the label policy
+func NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *GaugeVec {
+	v := &GaugeVec{prometheus.NewGaugeVec(opts, labelNames), labelNames}
+
+	trackDomainLabel(v.MetricVec, labelNames)
+
+	return v
+}
+
+// WithLabelValues see prometheus.GaugeVec
+func (v *GaugeVec) WithLabelValues(lvs ...string) prometheus.Gauge {
+	return v.GaugeVec.WithLabelValues(applyPolicy(v.labelNames, lvs)...)
+}
+
+// With see prometheus.GaugeVec
+func (v *GaugeVec) With(labels prometheus.Labels) prometheus.Gauge {
+	return v.GaugeVec.With(applyPolicyToLabels(labels))
+}
+
+// HistogramVec is a prometheus.HistogramVec, which applies the label policy to the label values
+type HistogramVec struct {
+	*prometheus.HistogramVec
+
+	labelNames []string
+}
+
+// NewHistogramVec creates a new HistogramVec, which applies the label policy
+func NewHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *HistogramVec {
+	v := &HistogramVec{prometheus.NewHistogramVec(opts, labelNames), labelNames}
+
+	trackDomainLabel(v.MetricVec, labelNames)
+
+	return v
+}
+
+// WithLabelValues see prometheus.HistogramVec
+func (v *HistogramVec) WithLabelValues(lvs ...string) prometheus.Observer {
+	return v.HistogramVec.WithLabelValues(applyPolicy(v.labelNames, lvs)...)
+}
+
+// With see prometheus.HistogramVec
+func (v *HistogramVec) With(labels prometheus.Labels) prometheus.Observer {
+	return v.HistogramVec.With(applyPolicyToLabels(labels))
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"github.com/0xERR0R/blocky/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Label policy", func() {
+	var sut *CounterVec
+
+	BeforeEach(func() {
+		sut = NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{ClientLabel, DomainLabel, "reason"})
+
+		DeferCleanup(func() {
+			policy.Store(nil)
+		})
+	})
+
+	When("no policy is configured", func() {
+		It("should keep the label values", func() {
+			sut.WithLabelValues("client1", "example.com", "BLOCKED").Inc()
+
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client1", "example.com", "BLOCKED"))).
+				Should(Equal(1.0))
+		})
+	})
+
+	When("clients are hashed", func() {
+		BeforeEach(func() {
+			ConfigureLabels(config.MetricsLabels{Client: config.MetricsClientLabelHash})
+		})
+
+		It("should replace the client names with a hash", func() {
+			sut.With(prometheus.Labels{ClientLabel: "client1", DomainLabel: "example.com", "reason": "BLOCKED"}).Inc()
+			sut.WithLabelValues("client1", "example.com", "BLOCKED").Inc()
+
+			Expect(testutil.CollectAndCount(sut)).Should(Equal(1))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("1917e33407c28366", "example.com", "BLOCKED"))).
+				Should(Equal(2.0))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client1", "example.com", "BLOCKED"))).
+				Should(Equal(0.0))
+		})
+	})
+
+	When("labels are disabled", func() {
+		BeforeEach(func() {
+			ConfigureLabels(config.MetricsLabels{Disable: []string{"reason", ClientLabel}})
+		})
+
+		It("should remove the label values", func() {
+			sut.WithLabelValues("client1", "example.com", "BLOCKED").Inc()
+			sut.WithLabelValues("client2", "example.com", "CACHED").Inc()
+
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("", "example.com", ""))).Should(Equal(2.0))
+		})
+	})
+
+	When("domains are limited", func() {
+		BeforeEach(func() {
+			ConfigureLabels(config.MetricsLabels{TopDomains: 2})
+		})
+
+		It("should aggregate all other domains", func() {
+			for range 3 {
+				sut.WithLabelValues("client", "a.com", "").Inc()
+				sut.WithLabelValues("client", "b.com", "").Inc()
+			}
+
+			sut.WithLabelValues("client", "c.com", "").Inc()
+			sut.WithLabelValues("client", "d.com", "").Inc()
+
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client", "a.com", ""))).Should(Equal(3.0))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client", "b.com", ""))).Should(Equal(3.0))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client", "other", ""))).Should(Equal(2.0))
+		})
+
+		It("should delete the series of domains dropping out of the top domains", func() {
+			sut.WithLabelValues("client", "a.com", "").Inc()
+			sut.WithLabelValues("client", "b.com", "").Inc()
+
+			for range 2 {
+				sut.WithLabelValues("client", "c.com", "").Inc()
+			}
+
+			// one of a.com and b.com, c.com and other
+			Expect(testutil.CollectAndCount(sut)).Should(Equal(3))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client", "c.com", ""))).Should(Equal(1.0))
+			Expect(testutil.ToFloat64(sut.CounterVec.WithLabelValues("client", "other", ""))).Should(Equal(1.0))
+		})
+	})
+})
+
+var _ = Describe("topDomains", func() {
+	var sut *topDomains
+
+	BeforeEach(func() {
+		sut = newTopDomains(1)
+	})
+
+	It("should replace the top domain, if another domain is more frequent", func() {
+		Expect(sut.label("a.com")).Should(Equal("a.com"))
+		Expect(sut.label("b.com")).Should(Equal("other"))
+
+		label, evicted := sut.label("b.com")
+		Expect(label).Should(Equal("b.com"))
+		Expect(evicted).Should(Equal("a.com"))
+	})
+
+	It("should track a limited number of domains", func() {
+		for i := range 2 * topDomainsTrackingFactor {
+			sut.label(string(rune('a'+i)) + ".com")
+		}
+
+		Expect(sut.counts).Should(HaveLen(topDomainsTrackingFactor))
+	})
+})
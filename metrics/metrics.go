@@ -17,8 +17,17 @@ func RegisterMetric(c prometheus.Collector) {
 	_ = Reg.Register(c)
 }
 
+// Registered registers prometheus collector and returns it
+func Registered[T prometheus.Collector](c T) T {
+	RegisterMetric(c)
+
+	return c
+}
+
 // Start starts prometheus endpoint
 func Start(router *chi.Mux, cfg config.Metrics) {
+	ConfigureLabels(cfg.Labels)
+
 	if cfg.Enable {
 		_ = Reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 		_ = Reg.Register(collectors.NewGoCollector())
@@ -15,7 +15,9 @@ import (
 func RegisterEventListeners() {
 	registerBlockingEventListeners()
 	registerCachingEventListeners()
+	registerUpstreamEventListeners()
 	registerApplicationEventListeners()
+	registerSelfCheckEventListeners()
 }
 
 func registerApplicationEventListeners() {
@@ -55,10 +57,13 @@ func registerBlockingEventListeners() {
 
 	allowlistCnt := allowlistGauge()
 
+	categoryCnt := categoryGauge()
+
 	lastListGroupRefresh := lastListGroupRefresh()
 
 	RegisterMetric(denylistCnt)
 	RegisterMetric(allowlistCnt)
+	RegisterMetric(categoryCnt)
 	RegisterMetric(lastListGroupRefresh)
 
 	subscribe(evt.BlockingCacheGroupChanged, func(listType lists.ListCacheType, groupName string, cnt int) {
@@ -69,8 +74,18 @@ func registerBlockingEventListeners() {
 			denylistCnt.WithLabelValues(groupName).Set(float64(cnt))
 		case lists.ListCacheTypeAllowlist:
 			allowlistCnt.WithLabelValues(groupName).Set(float64(cnt))
+		case lists.ListCacheTypeCategory:
+			categoryCnt.WithLabelValues(groupName).Set(float64(cnt))
 		}
 	})
+
+	listGroupPolicyCnt := listGroupPolicyTriggeredCount()
+
+	RegisterMetric(listGroupPolicyCnt)
+
+	subscribe(evt.BlockingListGroupPolicyTriggered, func(listType lists.ListCacheType, groupName, policy string) {
+		listGroupPolicyCnt.WithLabelValues(listType.String(), groupName, policy).Inc()
+	})
 }
 
 func enabledGauge() prometheus.Gauge {
@@ -83,8 +98,8 @@ func enabledGauge() prometheus.Gauge {
 	return enabledGauge
 }
 
-func denylistGauge() *prometheus.GaugeVec {
-	denylistCnt := prometheus.NewGaugeVec(
+func denylistGauge() *GaugeVec {
+	denylistCnt := NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "blocky_denylist_cache_entries",
 			Help: "Number of entries in the denylist cache",
@@ -94,8 +109,8 @@ func denylistGauge() *prometheus.GaugeVec {
 	return denylistCnt
 }
 
-func allowlistGauge() *prometheus.GaugeVec {
-	allowlistCnt := prometheus.NewGaugeVec(
+func allowlistGauge() *GaugeVec {
+	allowlistCnt := NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "blocky_allowlist_cache_entries",
 			Help: "Number of entries in the allowlist cache",
@@ -105,6 +120,26 @@ func allowlistGauge() *prometheus.GaugeVec {
 	return allowlistCnt
 }
 
+func categoryGauge() *GaugeVec {
+	categoryCnt := NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_category_cache_entries",
+			Help: "Number of entries in the category lists",
+		}, []string{"category"},
+	)
+
+	return categoryCnt
+}
+
+func listGroupPolicyTriggeredCount() *CounterVec {
+	return NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_list_group_policy_triggered_total",
+			Help: "Number of times a failure policy of a list group was applied",
+		}, []string{"type", "group", "policy"},
+	)
+}
+
 func lastListGroupRefresh() prometheus.Gauge {
 	return prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -191,6 +226,60 @@ func prefetchDomainCacheCount() prometheus.Gauge {
 	)
 }
 
+func registerUpstreamEventListeners() {
+	rejectedCount := rejectedResponseCount()
+
+	RegisterMetric(rejectedCount)
+
+	subscribe(evt.UpstreamResponseRejected, func(upstream, reason string) {
+		rejectedCount.WithLabelValues(upstream, reason).Inc()
+	})
+}
+
+func rejectedResponseCount() *CounterVec {
+	return NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_upstream_rejected_responses_total",
+			Help: "Number of upstream responses discarded, since they don't match the query",
+		}, []string{"upstream", "reason"},
+	)
+}
+
+func registerSelfCheckEventListeners() {
+	passed := selfCheckPassedGauge()
+	failures := selfCheckFailureCount()
+
+	RegisterMetric(passed)
+	RegisterMetric(failures)
+
+	subscribe(evt.SelfCheckProbeCompleted, func(domain, qType string, ok bool) {
+		if ok {
+			passed.WithLabelValues(domain, qType).Set(1)
+		} else {
+			passed.WithLabelValues(domain, qType).Set(0)
+			failures.WithLabelValues(domain, qType).Inc()
+		}
+	})
+}
+
+func selfCheckPassedGauge() *GaugeVec {
+	return NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_self_check_passed",
+			Help: "Result of the last self-check of the probe (1 = passed, 0 = failed)",
+		}, []string{"probe", "type"},
+	)
+}
+
+func selfCheckFailureCount() *CounterVec {
+	return NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_self_check_failures_total",
+			Help: "Number of failed self-checks of the probe",
+		}, []string{"probe", "type"},
+	)
+}
+
 func subscribe(topic string, fn interface{}) {
 	util.FatalOnError(fmt.Sprintf("can't subscribe topic '%s'", topic), evt.Bus().Subscribe(topic, fn))
 }
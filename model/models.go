@@ -3,6 +3,7 @@ package model
 //go:generate go tool go-enum -f=$GOFILE --marshal --names
 import (
 	"net"
+	"strings"
 	"time"
 
 	"github.com/miekg/dns"
@@ -18,6 +19,10 @@ import (
 // FILTERED // the query was filtered by query type
 // NOTFQDN // the query was filtered as it is not fqdn conform
 // SPECIAL // the query was resolved by the special use domain name resolver
+// PLUGIN // the query was resolved by a plugin resolver
+// SCRIPT // the query was answered by a script hook
+// REFUSED // the query for an internal zone was refused on this listener
+// ERROR // the query failed with an error, the client received SERVFAIL
 // )
 type ResponseType int
 
@@ -41,16 +46,115 @@ func (t ResponseType) ToExtendedErrorCode() uint16 {
 		return dns.ExtendedErrorCodeFiltered
 	case ResponseTypeSPECIAL:
 		return dns.ExtendedErrorCodeFiltered
+	case ResponseTypePLUGIN:
+		return dns.ExtendedErrorCodeForgedAnswer
+	case ResponseTypeSCRIPT:
+		return dns.ExtendedErrorCodeProhibited
+	case ResponseTypeREFUSED:
+		return dns.ExtendedErrorCodeProhibited
 	default:
 		return dns.ExtendedErrorCodeOther
 	}
 }
 
+// ReasonCode identifies the decision, which produced a response ENUM(
+// RESOLVED // resolved by an upstream, parameter: upstream
+// CONDITIONAL // resolved by a conditional upstream
+// CACHED // answered from the cache
+// CACHED_NEGATIVE // negative answer from the cache
+// EXTERNAL_CACHE // answer of another instance received via Redis
+// BLOCKED // blocked by a denylist, subject: type of the blocked answer record, parameters: list groups
+// BLOCKED_ALLOWLIST_ONLY // blocked, since the domain isn't on the allowlist of an allowlist only group
+// WOULD_BLOCK // would be blocked by a log-only group, subject: type of the answer record, parameters: list groups
+// WOULD_BLOCK_ALLOWLIST_ONLY // would be blocked by a log-only allowlist only group
+// CUSTOM_DNS // answered by a custom DNS mapping
+// CUSTOM_DNS_SYNTHESIZED // reverse answer synthesized from a custom DNS mapping
+// HOSTS_FILE // answered from a hosts file
+// FILTERED // filtered, parameter: filter
+// NOTFQDN // the query name is not fully qualified
+// SPECIAL // answered by the special use domain name resolver
+// PLUGIN // answered by a plugin, parameter: plugin
+// SCRIPT // answered by a script hook, parameter: hook
+// INTERNAL_ZONE // the query for an internal zone was refused on the listener
+// LEAK_PREVENTION // the query for an internal zone wasn't forwarded to the upstreams
+// TYPOSQUATTING // typosquatting domain, parameter: protected domain
+// NEW_DOMAIN // newly observed domain
+// ERROR // the query failed, parameter: error class
+// UPSTREAM_FAILURE // the upstream answered with SERVFAIL
+// COOKIE // answer to a query for the server cookie
+// BAD_COOKIE // the query has an invalid cookie
+// MALFORMED // the query has no question
+// QUERY_LIMIT // the query exceeded a processing limit
+// SECONDARY_ZONE // answered from a zone transferred from a conditional upstream, parameter: zone
+// NOTIFY // answer to a NOTIFY message of a primary
+// QUOTA // the daily budget of the client for the category is exhausted, parameter: category
+// )
+type ReasonCode uint8
+
+// Reason describes the decision, which produced a response
+type Reason struct {
+	Code ReasonCode
+	// Subject of the decision, e.g. the type of the blocked answer record
+	Subject string
+	// Params of the decision, e.g. the list groups of a blocked query
+	Params []string
+}
+
+// NewReason creates a reason with parameters
+func NewReason(code ReasonCode, params ...string) Reason {
+	return Reason{Code: code, Params: params}
+}
+
+// reasonTexts are the texts of the reasons, which differ from the name of their code
+//
+//nolint:gochecknoglobals
+var reasonTexts = map[ReasonCode]string{
+	ReasonCodeEXTERNALCACHE:           "EXTERNAL_CACHE",
+	ReasonCodeBLOCKEDALLOWLISTONLY:    "BLOCKED (ALLOWLIST ONLY)",
+	ReasonCodeWOULDBLOCK:              "WOULD_BLOCK",
+	ReasonCodeWOULDBLOCKALLOWLISTONLY: "WOULD_BLOCK (ALLOWLIST ONLY)",
+	ReasonCodeCUSTOMDNSSYNTHESIZED:    "CUSTOM DNS (SYNTHESIZED)",
+	ReasonCodeSPECIAL:                 "Special-Use Domain Name",
+	ReasonCodeUPSTREAMFAILURE:         "upstream server failed",
+}
+
+// String returns the text of the reason, e.g. `BLOCKED CNAME (ads,tracking)`, which is used in the query log,
+// the metrics and the extended DNS errors
+func (r Reason) String() string {
+	text, found := reasonTexts[r.Code]
+	if !found {
+		text = strings.ReplaceAll(r.Code.String(), "_", " ")
+	}
+
+	if r.Subject != "" {
+		text += " " + r.Subject
+	}
+
+	if len(r.Params) != 0 {
+		text += " (" + strings.Join(r.Params, ",") + ")"
+	}
+
+	return text
+}
+
 // Response represents the response of a DNS query
 type Response struct {
 	Res    *dns.Msg
-	Reason string
+	Reason Reason
 	RType  ResponseType
+	// Upstream which produced the answer, nil if the answer was not resolved by an upstream
+	Upstream *UpstreamInfo
+	// Trace of the resolver chain, only set for debug queries
+	Trace []string
+}
+
+// UpstreamInfo describes the upstream, which produced the answer
+type UpstreamInfo struct {
+	Name     string
+	Group    string
+	Protocol string
+	RTT      time.Duration
+	Retries  uint
 }
 
 // RequestProtocol represents the server protocol ENUM(
@@ -59,12 +163,34 @@ type Response struct {
 // )
 type RequestProtocol uint8
 
+// RequestListener represents the listener, which received the request ENUM(
+// dns // plain DNS over UDP or TCP
+// tls // DNS over TLS
+// http // DNS over HTTP
+// https // DNS over HTTPS
+// )
+type RequestListener uint8
+
 // Request represents client's DNS request
 type Request struct {
 	ClientIP        net.IP
 	RequestClientID string
 	Protocol        RequestProtocol
+	Listener        RequestListener
 	ClientNames     []string
-	Req             *dns.Msg
-	RequestTS       time.Time
+	ClientTags      []string
+	// EndpointTag is the client tag of the DoH endpoint, which received the request
+	EndpointTag string
+	Req         *dns.Msg
+	RequestTS   time.Time
+	// Debug enables the trace of the resolver chain for this query
+	Debug bool
+	// Unrecorded excludes the query from the metrics and the query log, e.g. health checks
+	Unrecorded bool
+	// Tenant is the name of the tenant, which the listener or the client is assigned to
+	Tenant string
+	// Categories of the queried domain in alphabetical order, e.g. "social"
+	Categories []string
+	// UpstreamGroup selects the upstream group instead of the client, e.g. for the refresh of a cache entry
+	UpstreamGroup string
 }
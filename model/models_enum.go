@@ -11,6 +11,334 @@ import (
 	"strings"
 )
 
+const (
+	// ReasonCodeRESOLVED is a ReasonCode of type RESOLVED.
+	// resolved by an upstream, parameter: upstream
+	ReasonCodeRESOLVED ReasonCode = iota
+	// ReasonCodeCONDITIONAL is a ReasonCode of type CONDITIONAL.
+	// resolved by a conditional upstream
+	ReasonCodeCONDITIONAL
+	// ReasonCodeCACHED is a ReasonCode of type CACHED.
+	// answered from the cache
+	ReasonCodeCACHED
+	// ReasonCodeCACHEDNEGATIVE is a ReasonCode of type CACHED_NEGATIVE.
+	// negative answer from the cache
+	ReasonCodeCACHEDNEGATIVE
+	// ReasonCodeEXTERNALCACHE is a ReasonCode of type EXTERNAL_CACHE.
+	// answer of another instance received via Redis
+	ReasonCodeEXTERNALCACHE
+	// ReasonCodeBLOCKED is a ReasonCode of type BLOCKED.
+	// blocked by a denylist, subject: type of the blocked answer record, parameters: list groups
+	ReasonCodeBLOCKED
+	// ReasonCodeBLOCKEDALLOWLISTONLY is a ReasonCode of type BLOCKED_ALLOWLIST_ONLY.
+	// blocked, since the domain isn't on the allowlist of an allowlist only group
+	ReasonCodeBLOCKEDALLOWLISTONLY
+	// ReasonCodeWOULDBLOCK is a ReasonCode of type WOULD_BLOCK.
+	// would be blocked by a log-only group, subject: type of the answer record, parameters: list groups
+	ReasonCodeWOULDBLOCK
+	// ReasonCodeWOULDBLOCKALLOWLISTONLY is a ReasonCode of type WOULD_BLOCK_ALLOWLIST_ONLY.
+	// would be blocked by a log-only allowlist only group
+	ReasonCodeWOULDBLOCKALLOWLISTONLY
+	// ReasonCodeCUSTOMDNS is a ReasonCode of type CUSTOM_DNS.
+	// answered by a custom DNS mapping
+	ReasonCodeCUSTOMDNS
+	// ReasonCodeCUSTOMDNSSYNTHESIZED is a ReasonCode of type CUSTOM_DNS_SYNTHESIZED.
+	// reverse answer synthesized from a custom DNS mapping
+	ReasonCodeCUSTOMDNSSYNTHESIZED
+	// ReasonCodeHOSTSFILE is a ReasonCode of type HOSTS_FILE.
+	// answered from a hosts file
+	ReasonCodeHOSTSFILE
+	// ReasonCodeFILTERED is a ReasonCode of type FILTERED.
+	// filtered, parameter: filter
+	ReasonCodeFILTERED
+	// ReasonCodeNOTFQDN is a ReasonCode of type NOTFQDN.
+	// the query name is not fully qualified
+	ReasonCodeNOTFQDN
+	// ReasonCodeSPECIAL is a ReasonCode of type SPECIAL.
+	// answered by the special use domain name resolver
+	ReasonCodeSPECIAL
+	// ReasonCodePLUGIN is a ReasonCode of type PLUGIN.
+	// answered by a plugin, parameter: plugin
+	ReasonCodePLUGIN
+	// ReasonCodeSCRIPT is a ReasonCode of type SCRIPT.
+	// answered by a script hook, parameter: hook
+	ReasonCodeSCRIPT
+	// ReasonCodeINTERNALZONE is a ReasonCode of type INTERNAL_ZONE.
+	// the query for an internal zone was refused on the listener
+	ReasonCodeINTERNALZONE
+	// ReasonCodeLEAKPREVENTION is a ReasonCode of type LEAK_PREVENTION.
+	// the query for an internal zone wasn't forwarded to the upstreams
+	ReasonCodeLEAKPREVENTION
+	// ReasonCodeTYPOSQUATTING is a ReasonCode of type TYPOSQUATTING.
+	// typosquatting domain, parameter: protected domain
+	ReasonCodeTYPOSQUATTING
+	// ReasonCodeNEWDOMAIN is a ReasonCode of type NEW_DOMAIN.
+	// newly observed domain
+	ReasonCodeNEWDOMAIN
+	// ReasonCodeERROR is a ReasonCode of type ERROR.
+	// the query failed, parameter: error class
+	ReasonCodeERROR
+	// ReasonCodeUPSTREAMFAILURE is a ReasonCode of type UPSTREAM_FAILURE.
+	// the upstream answered with SERVFAIL
+	ReasonCodeUPSTREAMFAILURE
+	// ReasonCodeCOOKIE is a ReasonCode of type COOKIE.
+	// answer to a query for the server cookie
+	ReasonCodeCOOKIE
+	// ReasonCodeBADCOOKIE is a ReasonCode of type BAD_COOKIE.
+	// the query has an invalid cookie
+	ReasonCodeBADCOOKIE
+	// ReasonCodeMALFORMED is a ReasonCode of type MALFORMED.
+	// the query has no question
+	ReasonCodeMALFORMED
+	// ReasonCodeQUERYLIMIT is a ReasonCode of type QUERY_LIMIT.
+	// the query exceeded a processing limit
+	ReasonCodeQUERYLIMIT
+	// ReasonCodeSECONDARYZONE is a ReasonCode of type SECONDARY_ZONE.
+	// answered from a zone transferred from a conditional upstream, parameter: zone
+	ReasonCodeSECONDARYZONE
+	// ReasonCodeNOTIFY is a ReasonCode of type NOTIFY.
+	// answer to a NOTIFY message of a primary
+	ReasonCodeNOTIFY
+	// ReasonCodeQUOTA is a ReasonCode of type QUOTA.
+	// the daily budget of the client for the category is exhausted, parameter: category
+	ReasonCodeQUOTA
+)
+
+var ErrInvalidReasonCode = fmt.Errorf("not a valid ReasonCode, try [%s]", strings.Join(_ReasonCodeNames, ", "))
+
+const _ReasonCodeName = "RESOLVEDCONDITIONALCACHEDCACHED_NEGATIVEEXTERNAL_CACHEBLOCKEDBLOCKED_ALLOWLIST_ONLYWOULD_BLOCKWOULD_BLOCK_ALLOWLIST_ONLYCUSTOM_DNSCUSTOM_DNS_SYNTHESIZEDHOSTS_FILEFILTEREDNOTFQDNSPECIALPLUGINSCRIPTINTERNAL_ZONELEAK_PREVENTIONTYPOSQUATTINGNEW_DOMAINERRORUPSTREAM_FAILURECOOKIEBAD_COOKIEMALFORMEDQUERY_LIMITSECONDARY_ZONENOTIFYQUOTA"
+
+var _ReasonCodeNames = []string{
+	_ReasonCodeName[0:8],
+	_ReasonCodeName[8:19],
+	_ReasonCodeName[19:25],
+	_ReasonCodeName[25:40],
+	_ReasonCodeName[40:54],
+	_ReasonCodeName[54:61],
+	_ReasonCodeName[61:83],
+	_ReasonCodeName[83:94],
+	_ReasonCodeName[94:120],
+	_ReasonCodeName[120:130],
+	_ReasonCodeName[130:152],
+	_ReasonCodeName[152:162],
+	_ReasonCodeName[162:170],
+	_ReasonCodeName[170:177],
+	_ReasonCodeName[177:184],
+	_ReasonCodeName[184:190],
+	_ReasonCodeName[190:196],
+	_ReasonCodeName[196:209],
+	_ReasonCodeName[209:224],
+	_ReasonCodeName[224:237],
+	_ReasonCodeName[237:247],
+	_ReasonCodeName[247:252],
+	_ReasonCodeName[252:268],
+	_ReasonCodeName[268:274],
+	_ReasonCodeName[274:284],
+	_ReasonCodeName[284:293],
+	_ReasonCodeName[293:304],
+	_ReasonCodeName[304:318],
+	_ReasonCodeName[318:324],
+	_ReasonCodeName[324:329],
+}
+
+// ReasonCodeNames returns a list of possible string values of ReasonCode.
+func ReasonCodeNames() []string {
+	tmp := make([]string, len(_ReasonCodeNames))
+	copy(tmp, _ReasonCodeNames)
+	return tmp
+}
+
+var _ReasonCodeMap = map[ReasonCode]string{
+	ReasonCodeRESOLVED:                _ReasonCodeName[0:8],
+	ReasonCodeCONDITIONAL:             _ReasonCodeName[8:19],
+	ReasonCodeCACHED:                  _ReasonCodeName[19:25],
+	ReasonCodeCACHEDNEGATIVE:          _ReasonCodeName[25:40],
+	ReasonCodeEXTERNALCACHE:           _ReasonCodeName[40:54],
+	ReasonCodeBLOCKED:                 _ReasonCodeName[54:61],
+	ReasonCodeBLOCKEDALLOWLISTONLY:    _ReasonCodeName[61:83],
+	ReasonCodeWOULDBLOCK:              _ReasonCodeName[83:94],
+	ReasonCodeWOULDBLOCKALLOWLISTONLY: _ReasonCodeName[94:120],
+	ReasonCodeCUSTOMDNS:               _ReasonCodeName[120:130],
+	ReasonCodeCUSTOMDNSSYNTHESIZED:    _ReasonCodeName[130:152],
+	ReasonCodeHOSTSFILE:               _ReasonCodeName[152:162],
+	ReasonCodeFILTERED:                _ReasonCodeName[162:170],
+	ReasonCodeNOTFQDN:                 _ReasonCodeName[170:177],
+	ReasonCodeSPECIAL:                 _ReasonCodeName[177:184],
+	ReasonCodePLUGIN:                  _ReasonCodeName[184:190],
+	ReasonCodeSCRIPT:                  _ReasonCodeName[190:196],
+	ReasonCodeINTERNALZONE:            _ReasonCodeName[196:209],
+	ReasonCodeLEAKPREVENTION:          _ReasonCodeName[209:224],
+	ReasonCodeTYPOSQUATTING:           _ReasonCodeName[224:237],
+	ReasonCodeNEWDOMAIN:               _ReasonCodeName[237:247],
+	ReasonCodeERROR:                   _ReasonCodeName[247:252],
+	ReasonCodeUPSTREAMFAILURE:         _ReasonCodeName[252:268],
+	ReasonCodeCOOKIE:                  _ReasonCodeName[268:274],
+	ReasonCodeBADCOOKIE:               _ReasonCodeName[274:284],
+	ReasonCodeMALFORMED:               _ReasonCodeName[284:293],
+	ReasonCodeQUERYLIMIT:              _ReasonCodeName[293:304],
+	ReasonCodeSECONDARYZONE:           _ReasonCodeName[304:318],
+	ReasonCodeNOTIFY:                  _ReasonCodeName[318:324],
+	ReasonCodeQUOTA:                   _ReasonCodeName[324:329],
+}
+
+// String implements the Stringer interface.
+func (x ReasonCode) String() string {
+	if str, ok := _ReasonCodeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ReasonCode(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ReasonCode) IsValid() bool {
+	_, ok := _ReasonCodeMap[x]
+	return ok
+}
+
+var _ReasonCodeValue = map[string]ReasonCode{
+	_ReasonCodeName[0:8]:     ReasonCodeRESOLVED,
+	_ReasonCodeName[8:19]:    ReasonCodeCONDITIONAL,
+	_ReasonCodeName[19:25]:   ReasonCodeCACHED,
+	_ReasonCodeName[25:40]:   ReasonCodeCACHEDNEGATIVE,
+	_ReasonCodeName[40:54]:   ReasonCodeEXTERNALCACHE,
+	_ReasonCodeName[54:61]:   ReasonCodeBLOCKED,
+	_ReasonCodeName[61:83]:   ReasonCodeBLOCKEDALLOWLISTONLY,
+	_ReasonCodeName[83:94]:   ReasonCodeWOULDBLOCK,
+	_ReasonCodeName[94:120]:  ReasonCodeWOULDBLOCKALLOWLISTONLY,
+	_ReasonCodeName[120:130]: ReasonCodeCUSTOMDNS,
+	_ReasonCodeName[130:152]: ReasonCodeCUSTOMDNSSYNTHESIZED,
+	_ReasonCodeName[152:162]: ReasonCodeHOSTSFILE,
+	_ReasonCodeName[162:170]: ReasonCodeFILTERED,
+	_ReasonCodeName[170:177]: ReasonCodeNOTFQDN,
+	_ReasonCodeName[177:184]: ReasonCodeSPECIAL,
+	_ReasonCodeName[184:190]: ReasonCodePLUGIN,
+	_ReasonCodeName[190:196]: ReasonCodeSCRIPT,
+	_ReasonCodeName[196:209]: ReasonCodeINTERNALZONE,
+	_ReasonCodeName[209:224]: ReasonCodeLEAKPREVENTION,
+	_ReasonCodeName[224:237]: ReasonCodeTYPOSQUATTING,
+	_ReasonCodeName[237:247]: ReasonCodeNEWDOMAIN,
+	_ReasonCodeName[247:252]: ReasonCodeERROR,
+	_ReasonCodeName[252:268]: ReasonCodeUPSTREAMFAILURE,
+	_ReasonCodeName[268:274]: ReasonCodeCOOKIE,
+	_ReasonCodeName[274:284]: ReasonCodeBADCOOKIE,
+	_ReasonCodeName[284:293]: ReasonCodeMALFORMED,
+	_ReasonCodeName[293:304]: ReasonCodeQUERYLIMIT,
+	_ReasonCodeName[304:318]: ReasonCodeSECONDARYZONE,
+	_ReasonCodeName[318:324]: ReasonCodeNOTIFY,
+	_ReasonCodeName[324:329]: ReasonCodeQUOTA,
+}
+
+// ParseReasonCode attempts to convert a string to a ReasonCode.
+func ParseReasonCode(name string) (ReasonCode, error) {
+	if x, ok := _ReasonCodeValue[name]; ok {
+		return x, nil
+	}
+	return ReasonCode(0), fmt.Errorf("%s is %w", name, ErrInvalidReasonCode)
+}
+
+// MarshalText implements the text marshaller method.
+func (x ReasonCode) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *ReasonCode) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseReasonCode(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
+const (
+	// RequestListenerDns is a RequestListener of type Dns.
+	// plain DNS over UDP or TCP
+	RequestListenerDns RequestListener = iota
+	// RequestListenerTls is a RequestListener of type Tls.
+	// DNS over TLS
+	RequestListenerTls
+	// RequestListenerHttp is a RequestListener of type Http.
+	// DNS over HTTP
+	RequestListenerHttp
+	// RequestListenerHttps is a RequestListener of type Https.
+	// DNS over HTTPS
+	RequestListenerHttps
+)
+
+var ErrInvalidRequestListener = fmt.Errorf("not a valid RequestListener, try [%s]", strings.Join(_RequestListenerNames, ", "))
+
+const _RequestListenerName = "dnstlshttphttps"
+
+var _RequestListenerNames = []string{
+	_RequestListenerName[0:3],
+	_RequestListenerName[3:6],
+	_RequestListenerName[6:10],
+	_RequestListenerName[10:15],
+}
+
+// RequestListenerNames returns a list of possible string values of RequestListener.
+func RequestListenerNames() []string {
+	tmp := make([]string, len(_RequestListenerNames))
+	copy(tmp, _RequestListenerNames)
+	return tmp
+}
+
+var _RequestListenerMap = map[RequestListener]string{
+	RequestListenerDns:   _RequestListenerName[0:3],
+	RequestListenerTls:   _RequestListenerName[3:6],
+	RequestListenerHttp:  _RequestListenerName[6:10],
+	RequestListenerHttps: _RequestListenerName[10:15],
+}
+
+// String implements the Stringer interface.
+func (x RequestListener) String() string {
+	if str, ok := _RequestListenerMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RequestListener(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RequestListener) IsValid() bool {
+	_, ok := _RequestListenerMap[x]
+	return ok
+}
+
+var _RequestListenerValue = map[string]RequestListener{
+	_RequestListenerName[0:3]:   RequestListenerDns,
+	_RequestListenerName[3:6]:   RequestListenerTls,
+	_RequestListenerName[6:10]:  RequestListenerHttp,
+	_RequestListenerName[10:15]: RequestListenerHttps,
+}
+
+// ParseRequestListener attempts to convert a string to a RequestListener.
+func ParseRequestListener(name string) (RequestListener, error) {
+	if x, ok := _RequestListenerValue[name]; ok {
+		return x, nil
+	}
+	return RequestListener(0), fmt.Errorf("%s is %w", name, ErrInvalidRequestListener)
+}
+
+// MarshalText implements the text marshaller method.
+func (x RequestListener) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *RequestListener) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseRequestListener(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// RequestProtocolTCP is a RequestProtocol of type TCP.
 	// is the TCP protocol
@@ -113,11 +441,23 @@ const (
 	// ResponseTypeSPECIAL is a ResponseType of type SPECIAL.
 	// the query was resolved by the special use domain name resolver
 	ResponseTypeSPECIAL
+	// ResponseTypePLUGIN is a ResponseType of type PLUGIN.
+	// the query was resolved by a plugin resolver
+	ResponseTypePLUGIN
+	// ResponseTypeSCRIPT is a ResponseType of type SCRIPT.
+	// the query was answered by a script hook
+	ResponseTypeSCRIPT
+	// ResponseTypeREFUSED is a ResponseType of type REFUSED.
+	// the query for an internal zone was refused on this listener
+	ResponseTypeREFUSED
+	// ResponseTypeERROR is a ResponseType of type ERROR.
+	// the query failed with an error, the client received SERVFAIL
+	ResponseTypeERROR
 )
 
 var ErrInvalidResponseType = fmt.Errorf("not a valid ResponseType, try [%s]", strings.Join(_ResponseTypeNames, ", "))
 
-const _ResponseTypeName = "RESOLVEDCACHEDBLOCKEDCONDITIONALCUSTOMDNSHOSTSFILEFILTEREDNOTFQDNSPECIAL"
+const _ResponseTypeName = "RESOLVEDCACHEDBLOCKEDCONDITIONALCUSTOMDNSHOSTSFILEFILTEREDNOTFQDNSPECIALPLUGINSCRIPTREFUSEDERROR"
 
 var _ResponseTypeNames = []string{
 	_ResponseTypeName[0:8],
@@ -129,6 +469,10 @@ var _ResponseTypeNames = []string{
 	_ResponseTypeName[50:58],
 	_ResponseTypeName[58:65],
 	_ResponseTypeName[65:72],
+	_ResponseTypeName[72:78],
+	_ResponseTypeName[78:84],
+	_ResponseTypeName[84:91],
+	_ResponseTypeName[91:96],
 }
 
 // ResponseTypeNames returns a list of possible string values of ResponseType.
@@ -148,6 +492,10 @@ var _ResponseTypeMap = map[ResponseType]string{
 	ResponseTypeFILTERED:    _ResponseTypeName[50:58],
 	ResponseTypeNOTFQDN:     _ResponseTypeName[58:65],
 	ResponseTypeSPECIAL:     _ResponseTypeName[65:72],
+	ResponseTypePLUGIN:      _ResponseTypeName[72:78],
+	ResponseTypeSCRIPT:      _ResponseTypeName[78:84],
+	ResponseTypeREFUSED:     _ResponseTypeName[84:91],
+	ResponseTypeERROR:       _ResponseTypeName[91:96],
 }
 
 // String implements the Stringer interface.
@@ -175,6 +523,10 @@ var _ResponseTypeValue = map[string]ResponseType{
 	_ResponseTypeName[50:58]: ResponseTypeFILTERED,
 	_ResponseTypeName[58:65]: ResponseTypeNOTFQDN,
 	_ResponseTypeName[65:72]: ResponseTypeSPECIAL,
+	_ResponseTypeName[72:78]: ResponseTypePLUGIN,
+	_ResponseTypeName[78:84]: ResponseTypeSCRIPT,
+	_ResponseTypeName[84:91]: ResponseTypeREFUSED,
+	_ResponseTypeName[91:96]: ResponseTypeERROR,
 }
 
 // ParseResponseType attempts to convert a string to a ResponseType.
@@ -0,0 +1,64 @@
+package model
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reason", func() {
+	DescribeTable("String",
+		func(code ReasonCode, text string) {
+			Expect(NewReason(code).String()).Should(Equal(text))
+		},
+		Entry("RESOLVED", ReasonCodeRESOLVED, "RESOLVED"),
+		Entry("CONDITIONAL", ReasonCodeCONDITIONAL, "CONDITIONAL"),
+		Entry("CACHED", ReasonCodeCACHED, "CACHED"),
+		Entry("CACHED_NEGATIVE", ReasonCodeCACHEDNEGATIVE, "CACHED NEGATIVE"),
+		Entry("EXTERNAL_CACHE", ReasonCodeEXTERNALCACHE, "EXTERNAL_CACHE"),
+		Entry("BLOCKED", ReasonCodeBLOCKED, "BLOCKED"),
+		Entry("BLOCKED_ALLOWLIST_ONLY", ReasonCodeBLOCKEDALLOWLISTONLY, "BLOCKED (ALLOWLIST ONLY)"),
+		Entry("WOULD_BLOCK", ReasonCodeWOULDBLOCK, "WOULD_BLOCK"),
+		Entry("WOULD_BLOCK_ALLOWLIST_ONLY", ReasonCodeWOULDBLOCKALLOWLISTONLY, "WOULD_BLOCK (ALLOWLIST ONLY)"),
+		Entry("CUSTOM_DNS", ReasonCodeCUSTOMDNS, "CUSTOM DNS"),
+		Entry("CUSTOM_DNS_SYNTHESIZED", ReasonCodeCUSTOMDNSSYNTHESIZED, "CUSTOM DNS (SYNTHESIZED)"),
+		Entry("HOSTS_FILE", ReasonCodeHOSTSFILE, "HOSTS FILE"),
+		Entry("FILTERED", ReasonCodeFILTERED, "FILTERED"),
+		Entry("NOTFQDN", ReasonCodeNOTFQDN, "NOTFQDN"),
+		Entry("SPECIAL", ReasonCodeSPECIAL, "Special-Use Domain Name"),
+		Entry("PLUGIN", ReasonCodePLUGIN, "PLUGIN"),
+		Entry("SCRIPT", ReasonCodeSCRIPT, "SCRIPT"),
+		Entry("INTERNAL_ZONE", ReasonCodeINTERNALZONE, "INTERNAL ZONE"),
+		Entry("LEAK_PREVENTION", ReasonCodeLEAKPREVENTION, "LEAK PREVENTION"),
+		Entry("TYPOSQUATTING", ReasonCodeTYPOSQUATTING, "TYPOSQUATTING"),
+		Entry("NEW_DOMAIN", ReasonCodeNEWDOMAIN, "NEW DOMAIN"),
+		Entry("ERROR", ReasonCodeERROR, "ERROR"),
+		Entry("UPSTREAM_FAILURE", ReasonCodeUPSTREAMFAILURE, "upstream server failed"),
+		Entry("COOKIE", ReasonCodeCOOKIE, "COOKIE"),
+		Entry("BAD_COOKIE", ReasonCodeBADCOOKIE, "BAD COOKIE"),
+		Entry("MALFORMED", ReasonCodeMALFORMED, "MALFORMED"),
+		Entry("QUERY_LIMIT", ReasonCodeQUERYLIMIT, "QUERY LIMIT"),
+		Entry("SECONDARY_ZONE", ReasonCodeSECONDARYZONE, "SECONDARY ZONE"),
+		Entry("NOTIFY", ReasonCodeNOTIFY, "NOTIFY"),
+		Entry("QUOTA", ReasonCodeQUOTA, "QUOTA"),
+	)
+
+	It("should have a distinct text for each code", func() {
+		texts := make(map[string]string)
+
+		for _, name := range ReasonCodeNames() {
+			code, err := ParseReasonCode(name)
+			Expect(err).Should(Succeed())
+
+			text := NewReason(code).String()
+			Expect(texts).ShouldNot(HaveKey(text), "code %s has the text of %s", name, texts[text])
+
+			texts[text] = name
+		}
+	})
+
+	It("should append the subject and the parameters", func() {
+		reason := Reason{Code: ReasonCodeBLOCKED, Subject: "CNAME", Params: []string{"ads", "tracking"}}
+
+		Expect(reason.String()).Should(Equal("BLOCKED CNAME (ads,tracking)"))
+	})
+})
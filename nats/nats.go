@@ -0,0 +1,555 @@
+// Package nats synchronizes the cache and the blocking status between blocky instances via a NATS server.
+//
+// The client implements the part of the NATS client protocol, which is needed to publish and subscribe to a single
+// subject (core NATS, without JetStream). The messages have the same format as the messages of the redis sync.
+package nats
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/google/uuid"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPort       = "4222"
+	chanCap           = 1000
+	connectTimeout    = 5 * time.Second
+	pingInterval      = 30 * time.Second
+	defaultMaxPayload = 1024 * 1024
+	messageTypeCache  = 0
+	messageTypeEnable = 1
+	subscriptionID    = "1"
+)
+
+// syncMessage is published on the subject, it has the same format as the message of the redis sync
+type syncMessage struct {
+	Key     string `json:"k,omitempty"`
+	Type    int    `json:"t"`
+	Message []byte `json:"m"`
+	Client  []byte `json:"c"`
+}
+
+// serverInfo contains the fields of the INFO message of the server, which are used by the client
+type serverInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+// connectOptions are sent with the CONNECT message
+type connectOptions struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	AuthToken   string `json:"auth_token,omitempty"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Version     string `json:"version"`
+	Protocol    int    `json:"protocol"`
+	// the server doesn't send the own messages back to the client
+	Echo bool `json:"echo"`
+}
+
+// Client for the synchronization via NATS
+type Client struct {
+	cfg       *config.SyncNATS
+	address   string
+	useTLS    bool
+	tlsConfig *tls.Config
+	l         *logrus.Entry
+	id        []byte
+
+	sendBuffer     chan []byte
+	cacheChannel   chan *redis.CacheMessage
+	enabledChannel chan *redis.EnabledMessage
+}
+
+// New creates a new NATS client and subscribes to the subject. If the server is not reachable, New fails if the
+// connection is required, otherwise the client connects in the background.
+func New(ctx context.Context, cfg *config.SyncNATS) (*Client, error) {
+	if cfg == nil || !cfg.IsEnabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg, u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.New().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	c := &Client{
+		cfg:            cfg,
+		address:        net.JoinHostPort(u.Hostname(), port),
+		useTLS:         u.Scheme == "tls",
+		tlsConfig:      tlsConfig,
+		l:              log.PrefixedLog("nats"),
+		id:             id,
+		sendBuffer:     make(chan []byte, chanCap),
+		cacheChannel:   make(chan *redis.CacheMessage, chanCap),
+		enabledChannel: make(chan *redis.EnabledMessage, chanCap),
+	}
+
+	conn, err := c.connect(ctx)
+	if err != nil {
+		if cfg.Required {
+			return nil, err
+		}
+
+		c.l.Warnf("can't connect to %s, retrying in the background: %v", c.address, err)
+	}
+
+	go c.run(ctx, conn)
+
+	return c, nil
+}
+
+// newTLSConfig returns the TLS configuration for the connection, which is used if the URL has the scheme `tls`
+// or the server requires TLS
+func newTLSConfig(cfg *config.SyncNATS, serverName string) (*tls.Config, error) {
+	res := &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read NATS CA file: %w", err)
+		}
+
+		res.RootCAs = x509.NewCertPool()
+		if !res.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("NATS CA file '%s' contains no certificate", cfg.CAFile)
+		}
+	}
+
+	return res, nil
+}
+
+// CacheMessages returns the cache entries received from other instances
+func (c *Client) CacheMessages() <-chan *redis.CacheMessage {
+	return c.cacheChannel
+}
+
+// EnabledMessages returns the blocking status changes received from other instances
+func (c *Client) EnabledMessages() <-chan *redis.EnabledMessage {
+	return c.enabledChannel
+}
+
+// PublishCache publishes the cache entry async, it is dropped if the client can't keep up
+func (c *Client) PublishCache(key string, message *dns.Msg) {
+	if len(key) == 0 || message == nil {
+		return
+	}
+
+	message.Compress = true
+
+	binRes, err := message.Pack()
+	if err != nil {
+		c.l.Error("can't pack cache entry: ", err)
+
+		return
+	}
+
+	c.publish(&syncMessage{Key: key, Type: messageTypeCache, Message: binRes, Client: c.id})
+}
+
+// PublishEnabled publishes the blocking status async
+func (c *Client) PublishEnabled(_ context.Context, state *redis.EnabledMessage) {
+	binState, err := json.Marshal(state)
+	if err != nil {
+		c.l.Error("can't marshal blocking status: ", err)
+
+		return
+	}
+
+	c.publish(&syncMessage{Type: messageTypeEnable, Message: binState, Client: c.id})
+}
+
+func (c *Client) publish(msg *syncMessage) {
+	binMsg, err := json.Marshal(msg)
+	if err != nil {
+		c.l.Error("can't marshal message: ", err)
+
+		return
+	}
+
+	select {
+	case c.sendBuffer <- binMsg:
+	default:
+		c.l.Warn("send buffer is full, message is dropped")
+	}
+}
+
+// run serves the connection and reconnects after the cooldown, until the context is done
+func (c *Client) run(ctx context.Context, conn *conn) {
+	for {
+		if conn != nil {
+			err := c.serve(ctx, conn)
+
+			conn.Close()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.l.Warn("connection lost: ", err)
+		}
+
+		if !c.waitCooldown(ctx) {
+			return
+		}
+
+		var err error
+
+		conn, err = c.connect(ctx)
+		if err != nil {
+			c.l.Debugf("can't connect to %s: %v", c.address, err)
+		}
+	}
+}
+
+// waitCooldown waits before the next connection attempt, the messages published in the meantime are dropped.
+// It returns false, if the context is done.
+func (c *Client) waitCooldown(ctx context.Context) bool {
+	timer := time.NewTimer(c.cfg.ConnectionCooldown.ToDuration())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.sendBuffer:
+		case <-timer.C:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// serve publishes the buffered messages and processes the received ones, until the connection fails
+func (c *Client) serve(ctx context.Context, conn *conn) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- c.receive(ctx, conn)
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.sendBuffer:
+			if err := conn.publish(c.cfg.Subject, msg); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			// the server answers with PONG, which extends the read deadline
+			if err := conn.write([]byte("PING\r\n")); err != nil {
+				return err
+			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// receive reads the messages of the server, until the connection fails
+func (c *Client) receive(ctx context.Context, conn *conn) error {
+	for {
+		// a connection without PONG to the periodic PING is dead
+		if err := conn.SetReadDeadline(time.Now().Add(2 * pingInterval)); err != nil {
+			return err
+		}
+
+		line, err := conn.readLine()
+		if err != nil {
+			return err
+		}
+
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "MSG":
+			payload, err := conn.readPayload(args)
+			if err != nil {
+				return err
+			}
+
+			c.processReceivedMessage(ctx, payload)
+		case "PING":
+			if err := conn.write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case "-ERR":
+			return fmt.Errorf("server error: %s", args)
+		}
+	}
+}
+
+func (c *Client) processReceivedMessage(ctx context.Context, payload []byte) {
+	var msg syncMessage
+
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		c.l.Error("Processing error: ", err)
+
+		return
+	}
+
+	// the server doesn't echo the own messages, but the subject can be shared with other clients
+	if bytes.Equal(msg.Client, c.id) {
+		return
+	}
+
+	switch msg.Type {
+	case messageTypeCache:
+		cm, err := convertMessage(&msg)
+		if err != nil {
+			c.l.Error("Processing CacheMessage error: ", err)
+
+			return
+		}
+
+		util.CtxSend(ctx, c.cacheChannel, cm)
+	case messageTypeEnable:
+		var em redis.EnabledMessage
+
+		if err := json.Unmarshal(msg.Message, &em); err != nil {
+			c.l.Error("Processing EnabledMessage error: ", err)
+
+			return
+		}
+
+		util.CtxSend(ctx, c.enabledChannel, &em)
+	default:
+		c.l.Warn("Unknown message type: ", msg.Type)
+	}
+}
+
+// convertMessage converts the message to a cache entry
+func convertMessage(message *syncMessage) (*redis.CacheMessage, error) {
+	msg := new(dns.Msg)
+
+	if err := msg.Unpack(message.Message); err != nil {
+		return nil, err
+	}
+
+	return &redis.CacheMessage{
+		Key: message.Key,
+		Response: &model.Response{
+			RType:  model.ResponseTypeCACHED,
+			Reason: model.NewReason(model.ReasonCodeEXTERNALCACHE),
+			Res:    msg,
+		},
+	}, nil
+}
+
+// conn is a connection to the NATS server
+type conn struct {
+	net.Conn
+
+	reader     *bufio.Reader
+	writeLock  sync.Mutex
+	maxPayload int
+}
+
+// connect opens a connection to the server, authenticates and subscribes to the subject
+func (c *Client) connect(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: connectTimeout}
+
+	netConn, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if err := c.handshake(ctx, res); err != nil {
+		res.Close()
+
+		return nil, err
+	}
+
+	c.l.Infof("connected to %s", c.address)
+
+	return res, nil
+}
+
+func (c *Client) handshake(ctx context.Context, conn *conn) error {
+	if err := conn.SetDeadline(time.Now().Add(connectTimeout)); err != nil {
+		return err
+	}
+
+	line, err := conn.readLine()
+	if err != nil {
+		return fmt.Errorf("can't read server info: %w", err)
+	}
+
+	op, args, _ := strings.Cut(line, " ")
+	if op != "INFO" {
+		return fmt.Errorf("unexpected message from server: %s", line)
+	}
+
+	var info serverInfo
+	if err := json.Unmarshal([]byte(args), &info); err != nil {
+		return fmt.Errorf("invalid server info: %w", err)
+	}
+
+	conn.maxPayload = info.MaxPayload
+	if conn.maxPayload <= 0 {
+		conn.maxPayload = defaultMaxPayload
+	}
+
+	useTLS := c.useTLS || info.TLSRequired
+	if useTLS {
+		tlsConn := tls.Client(conn.Conn, c.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+
+		conn.Conn = tlsConn
+		conn.reader = bufio.NewReader(tlsConn)
+	}
+
+	options, err := json.Marshal(connectOptions{
+		TLSRequired: useTLS,
+		AuthToken:   c.cfg.Token,
+		User:        c.cfg.Username,
+		Pass:        c.cfg.Password,
+		Name:        "blocky",
+		Lang:        "go",
+		Version:     util.Version,
+		Protocol:    1,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the server answers the PING after the CONNECT was processed: with PONG or an error, e.g. on invalid credentials
+	if err := conn.write(fmt.Appendf(nil, "CONNECT %s\r\nPING\r\n", options)); err != nil {
+		return err
+	}
+
+	if err := conn.waitForPong(); err != nil {
+		return err
+	}
+
+	if err := conn.write(fmt.Appendf(nil, "SUB %s %s\r\n", c.cfg.Subject, subscriptionID)); err != nil {
+		return err
+	}
+
+	return conn.SetDeadline(time.Time{})
+}
+
+func (c *conn) waitForPong() error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "PONG":
+			return nil
+		case "-ERR":
+			return fmt.Errorf("server rejected the connection: %s", args)
+		}
+	}
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPayload reads the payload of the MSG with the arguments `<subject> <sid> [reply-to] <#bytes>`
+func (c *conn) readPayload(args string) ([]byte, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 { //nolint:mnd
+		return nil, fmt.Errorf("invalid message: MSG %s", args)
+	}
+
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 || size > c.maxPayload {
+		return nil, fmt.Errorf("invalid message size: MSG %s", args)
+	}
+
+	// the payload is followed by CRLF
+	payload := make([]byte, size+2) //nolint:mnd
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return nil, err
+	}
+
+	return payload[:size], nil
+}
+
+func (c *conn) publish(subject string, payload []byte) error {
+	if len(payload) > c.maxPayload {
+		// the server would close the connection
+		return nil
+	}
+
+	msg := fmt.Appendf(nil, "PUB %s %d\r\n", subject, len(payload))
+	msg = append(msg, payload...)
+	msg = append(msg, "\r\n"...)
+
+	return c.write(msg)
+}
+
+func (c *conn) write(msg []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	if err := c.SetWriteDeadline(time.Now().Add(connectTimeout)); err != nil {
+		return err
+	}
+
+	_, err := c.Conn.Write(msg)
+
+	return err
+}
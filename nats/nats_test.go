@@ -0,0 +1,301 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeServer is a minimal NATS server, which forwards the published messages to all connections.
+// The sender gets its own messages, too: the client must ignore them.
+type fakeServer struct {
+	listener  net.Listener
+	authToken string
+
+	connects chan connectOptions
+	pongs    chan struct{}
+
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeServer() *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).Should(Succeed())
+
+	s := &fakeServer{
+		listener: listener,
+		connects: make(chan connectOptions, 10),
+		pongs:    make(chan struct{}, 10),
+	}
+
+	go s.accept()
+
+	DeferCleanup(s.close)
+
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.serve(conn)
+	}
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte(`INFO {"server_id":"fake","max_payload":1048576}` + "\r\n"))
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		op, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		switch op {
+		case "CONNECT":
+			var options connectOptions
+			_ = json.Unmarshal([]byte(args), &options)
+			s.connects <- options
+
+			if s.authToken != "" && options.AuthToken != s.authToken {
+				_, _ = conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+
+				return
+			}
+		case "PING":
+			_, _ = conn.Write([]byte("PONG\r\n"))
+		case "PONG":
+			s.pongs <- struct{}{}
+		case "SUB":
+			s.lock.Lock()
+			s.conns = append(s.conns, conn)
+			s.lock.Unlock()
+		case "PUB":
+			subject, size, _ := strings.Cut(args, " ")
+			n, _ := strconv.Atoi(size)
+
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			s.broadcast(fmt.Appendf(nil, "MSG %s %s %d\r\n%s", subject, subscriptionID, n, payload))
+		}
+	}
+}
+
+func (s *fakeServer) broadcast(msg []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, conn := range s.conns {
+		_, _ = conn.Write(msg)
+	}
+}
+
+func (s *fakeServer) subscriptions() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.conns)
+}
+
+// dropConnections closes all connections, the server keeps accepting new ones
+func (s *fakeServer) dropConnections() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+
+	s.conns = nil
+}
+
+func (s *fakeServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+}
+
+var _ = Describe("NATS client", func() {
+	var (
+		server *fakeServer
+		cfg    *config.SyncNATS
+	)
+
+	BeforeEach(func() {
+		server = newFakeServer()
+
+		// the config of the clients of the previous spec is not changed
+		cfg = new(config.SyncNATS)
+		Expect(defaults.Set(cfg)).Should(Succeed())
+		cfg.URL = server.url()
+		cfg.ConnectionCooldown = config.Duration(10 * time.Millisecond)
+	})
+
+	newClient := func(ctx context.Context) *Client {
+		client, err := New(ctx, cfg)
+		Expect(err).Should(Succeed())
+		Expect(client).ShouldNot(BeNil())
+
+		Eventually(server.connects).Should(Receive())
+
+		return client
+	}
+
+	Describe("Client creation", func() {
+		It("should return nil without URL", func(ctx context.Context) {
+			Expect(New(ctx, &config.SyncNATS{})).Should(BeNil())
+		})
+
+		When("the server is not reachable", func() {
+			BeforeEach(func() {
+				server.close()
+			})
+
+			It("should fail, if the connection is required", func(ctx context.Context) {
+				cfg.Required = true
+
+				_, err := New(ctx, cfg)
+				Expect(err).Should(HaveOccurred())
+			})
+
+			It("should connect in the background, if the connection isn't required", func(ctx context.Context) {
+				Expect(New(ctx, cfg)).ShouldNot(BeNil())
+			})
+		})
+
+		It("should connect without echo of the own messages", func(ctx context.Context) {
+			cfg.Username = "blocky"
+			cfg.Password = "secret"
+
+			_, err := New(ctx, cfg)
+			Expect(err).Should(Succeed())
+
+			var options connectOptions
+
+			Eventually(server.connects).Should(Receive(&options))
+			Expect(options.Echo).Should(BeFalse())
+			Expect(options.User).Should(Equal("blocky"))
+			Expect(options.Pass).Should(Equal("secret"))
+		})
+
+		When("the server requires a token", func() {
+			BeforeEach(func() {
+				server.authToken = "token"
+				cfg.Required = true
+			})
+
+			It("should fail with an invalid token", func(ctx context.Context) {
+				cfg.Token = "invalid"
+
+				_, err := New(ctx, cfg)
+				Expect(err).Should(MatchError(ContainSubstring("Authorization Violation")))
+			})
+
+			It("should connect with the token", func(ctx context.Context) {
+				cfg.Token = "token"
+
+				Expect(New(ctx, cfg)).ShouldNot(BeNil())
+			})
+		})
+
+		It("should fail with an invalid CA file", func(ctx context.Context) {
+			cfg.CAFile = "missing.pem"
+
+			_, err := New(ctx, cfg)
+			Expect(err).Should(MatchError(ContainSubstring("can't read NATS CA file")))
+		})
+	})
+
+	Describe("Synchronization", func() {
+		var sender, receiver *Client
+
+		BeforeEach(func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			sender = newClient(ctx)
+			receiver = newClient(ctx)
+
+			Eventually(server.subscriptions).Should(Equal(2))
+		})
+
+		It("should share the cache entries", func(ctx context.Context) {
+			res, err := util.NewMsgWithAnswer("example.com.", 123, dns.Type(dns.TypeA), "1.2.3.4")
+			Expect(err).Should(Succeed())
+
+			sender.PublishCache("example.com", res)
+
+			var msg *redis.CacheMessage
+
+			Eventually(receiver.CacheMessages()).Should(Receive(&msg))
+			Expect(msg.Key).Should(Equal("example.com"))
+			Expect(msg.Response.RType).Should(Equal(model.ResponseTypeCACHED))
+			Expect(msg.Response.Res.Answer).Should(HaveLen(1))
+
+			// the own message is ignored
+			Consistently(sender.CacheMessages(), "100ms").ShouldNot(Receive())
+		})
+
+		It("should share the blocking status", func(ctx context.Context) {
+			sender.PublishEnabled(ctx, &redis.EnabledMessage{State: false, Duration: time.Minute, Groups: []string{"ads"}})
+
+			Eventually(receiver.EnabledMessages()).Should(Receive(Equal(&redis.EnabledMessage{
+				State:    false,
+				Duration: time.Minute,
+				Groups:   []string{"ads"},
+			})))
+		})
+
+		It("should reconnect after the connection is lost", func(ctx context.Context) {
+			server.dropConnections()
+
+			Eventually(server.connects).Should(Receive())
+			Eventually(server.connects).Should(Receive())
+
+			Eventually(func(g Gomega) {
+				sender.PublishEnabled(ctx, &redis.EnabledMessage{State: true})
+
+				g.Eventually(receiver.EnabledMessages(), "50ms").Should(Receive())
+			}).Should(Succeed())
+		})
+
+		It("should answer the PING of the server", func() {
+			server.broadcast([]byte("PING\r\n"))
+
+			Eventually(server.pongs).Should(Receive())
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+)
+
+// gotifyPriority is the default priority of a Gotify message
+const gotifyPriority = 5
+
+type request struct {
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+// newRequest creates the request in the format of the target type
+func newRequest(cfg config.NotificationTarget, notification *Notification, message string) (*request, error) {
+	var payload any
+
+	r := &request{url: cfg.URL}
+
+	switch cfg.Type {
+	case config.NotificationTypeWebhook:
+		n := *notification
+		n.Message = message
+		payload = n
+
+	case config.NotificationTypeSlack:
+		payload = map[string]string{"text": message}
+
+	case config.NotificationTypeTelegram:
+		payload = map[string]string{"chat_id": cfg.ChatID, "text": message}
+
+	case config.NotificationTypeGotify:
+		r.url = strings.TrimSuffix(cfg.URL, "/") + "/message"
+		r.headers = map[string]string{"X-Gotify-Key": cfg.Token}
+		payload = map[string]any{"title": "blocky: " + notification.Event, "message": message, "priority": gotifyPriority}
+
+	default:
+		return nil, fmt.Errorf("unsupported notification type %s", cfg.Type)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal notification: %w", err)
+	}
+
+	r.body = body
+
+	return r, nil
+}
@@ -0,0 +1,237 @@
+// Package notification sends notifications about events (e.g. blocking disabled or upstream down)
+// to webhooks and chat services.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// Event names, which are used in the notifications and in the configuration
+const (
+	EventBlockingDisabled  = "blockingDisabled"
+	EventListRefreshFailed = "listRefreshFailed"
+	EventUpstreamDown      = "upstreamDown"
+	EventUpstreamHijacked  = "upstreamHijacked"
+	EventConfigReloaded    = "configReloaded"
+	EventQueryAnomaly      = "queryAnomaly"
+	EventSelfCheckFailed   = "selfCheckFailed"
+)
+
+const (
+	defaultTemplate   = "{{ .Message }}"
+	defaultRetryDelay = time.Second
+)
+
+// Notification contains the data of an event, it is passed to the message templates
+type Notification struct {
+	Event    string            `json:"event"`
+	Message  string            `json:"message"`
+	Time     time.Time         `json:"time"`
+	Hostname string            `json:"hostname"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+type target struct {
+	cfg      config.NotificationTarget
+	template *template.Template
+	client   *http.Client
+}
+
+func (t *target) isEnabled(event string) bool {
+	switch event {
+	case EventBlockingDisabled:
+		return t.cfg.Events.BlockingDisabled
+	case EventListRefreshFailed:
+		return t.cfg.Events.ListRefreshFailed
+	case EventUpstreamDown:
+		return t.cfg.Events.UpstreamDown
+	case EventUpstreamHijacked:
+		return t.cfg.Events.UpstreamHijacked
+	case EventConfigReloaded:
+		return t.cfg.Events.ConfigReloaded
+	case EventQueryAnomaly:
+		return t.cfg.Events.QueryAnomaly
+	case EventSelfCheckFailed:
+		return t.cfg.Events.SelfCheckFailed
+	default:
+		return false
+	}
+}
+
+// Notifier sends notifications to all configured targets
+type Notifier struct {
+	targets    []*target
+	hostname   string
+	retryDelay time.Duration
+}
+
+func logger() *logrus.Entry {
+	return log.PrefixedLog("notification")
+}
+
+// New creates a notifier for the configured targets, the message templates are parsed
+func New(cfg config.Notifications) (*Notifier, error) {
+	hostname, _ := os.Hostname()
+
+	n := &Notifier{hostname: hostname, retryDelay: defaultRetryDelay}
+
+	for _, targetCfg := range cfg.Targets {
+		text := targetCfg.Template
+		if text == "" {
+			text = defaultTemplate
+		}
+
+		tmpl, err := template.New(targetCfg.Name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of notification target '%s': %w", targetCfg.Name, err)
+		}
+
+		n.targets = append(n.targets, &target{
+			cfg:      targetCfg,
+			template: tmpl,
+			client:   &http.Client{Timeout: targetCfg.Timeout.ToDuration()},
+		})
+	}
+
+	return n, nil
+}
+
+// Subscribe registers the notifier for the events, the subscriptions are removed when the context is done
+func (n *Notifier) Subscribe(ctx context.Context) {
+	handlers := map[string]any{
+		evt.BlockingEnabledEvent: func(enabled bool) {
+			if !enabled {
+				n.publish(ctx, EventBlockingDisabled, "blocking disabled", nil)
+			}
+		},
+		evt.CachingFailedDownloadChanged: func(link string) {
+			n.publish(ctx, EventListRefreshFailed, fmt.Sprintf("download of list %s failed", link),
+				map[string]string{"url": link})
+		},
+		evt.UpstreamStatusChanged: func(upstream string, reachable bool, err error) {
+			if !reachable {
+				n.publish(ctx, EventUpstreamDown, fmt.Sprintf("upstream %s is not reachable: %v", upstream, err),
+					map[string]string{"upstream": upstream})
+			}
+		},
+		evt.UpstreamHijackDetected: func(upstream, answer string) {
+			n.publish(ctx, EventUpstreamHijacked,
+				fmt.Sprintf("upstream %s answers queries for nonexistent domains: %s", upstream, answer),
+				map[string]string{"upstream": upstream, "answer": answer})
+		},
+		evt.QueryAnomalyDetected: func(client, anomaly, description string) {
+			n.publish(ctx, EventQueryAnomaly, fmt.Sprintf("client %s: %s", client, description),
+				map[string]string{"client": client, "anomaly": anomaly})
+		},
+		evt.SelfCheckFailed: func(domain, qType, description string) {
+			n.publish(ctx, EventSelfCheckFailed, fmt.Sprintf("self-check of %s (%s) failed: %s", domain, qType, description),
+				map[string]string{"domain": domain, "type": qType})
+		},
+		evt.ConfigReloaded: func() {
+			n.publish(ctx, EventConfigReloaded, "configuration reloaded", nil)
+		},
+	}
+
+	for topic, handler := range handlers {
+		if err := evt.Bus().Subscribe(topic, handler); err != nil {
+			logger().Errorf("can't subscribe to %s: %v", topic, err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		for topic, handler := range handlers {
+			_ = evt.Bus().Unsubscribe(topic, handler)
+		}
+	}()
+}
+
+// publish sends the notification asynchronously to not block the publisher of the event
+func (n *Notifier) publish(ctx context.Context, event, message string, data map[string]string) {
+	go n.Notify(ctx, &Notification{
+		Event:    event,
+		Message:  message,
+		Time:     time.Now(),
+		Hostname: n.hostname,
+		Data:     data,
+	})
+}
+
+// Notify sends the notification to all targets, which have the event enabled
+func (n *Notifier) Notify(ctx context.Context, notification *Notification) {
+	for _, t := range n.targets {
+		if !t.isEnabled(notification.Event) {
+			continue
+		}
+
+		if err := n.send(ctx, t, notification); err != nil {
+			logger().Warnf("can't send notification '%s' to '%s': %v", notification.Event, t.cfg.Name, err)
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, t *target, notification *Notification) error {
+	var message bytes.Buffer
+	if err := t.template.Execute(&message, notification); err != nil {
+		return fmt.Errorf("can't render template: %w", err)
+	}
+
+	req, err := newRequest(t.cfg, notification, message.String())
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(
+		func() error {
+			return t.post(ctx, req)
+		},
+		retry.Context(ctx),
+		retry.Attempts(t.cfg.Retries+1),
+		retry.Delay(n.retryDelay),
+		retry.LastErrorOnly(true),
+	)
+}
+
+func (t *target) post(ctx context.Context, r *request) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(r.body))
+	if err != nil {
+		return retry.Unrecoverable(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	for key, value := range r.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("received status %d", resp.StatusCode)
+	}
+
+	return nil
+}
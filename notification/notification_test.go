@@ -0,0 +1,239 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type receivedRequest struct {
+	path    string
+	headers http.Header
+	body    map[string]any
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		mu       sync.Mutex
+		received []receivedRequest
+		failures atomic.Int32
+		targets  []config.NotificationTarget
+		sut      *Notifier
+	)
+
+	requests := func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]receivedRequest(nil), received...)
+	}
+
+	newTarget := func(name string, typ config.NotificationType) config.NotificationTarget {
+		return config.NotificationTarget{
+			Name:    name,
+			Type:    typ,
+			URL:     server.URL + "/" + name,
+			Retries: 2,
+			Timeout: config.Duration(time.Second),
+			Events: config.NotificationEvents{
+				BlockingDisabled:  true,
+				ListRefreshFailed: true,
+				UpstreamDown:      true,
+				UpstreamHijacked:  true,
+				ConfigReloaded:    true,
+				QueryAnomaly:      true,
+				SelfCheckFailed:   true,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		received = nil
+		failures.Store(0)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			if failures.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			raw, err := io.ReadAll(r.Body)
+			Expect(err).Should(Succeed())
+
+			var body map[string]any
+			Expect(json.Unmarshal(raw, &body)).Should(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			received = append(received, receivedRequest{path: r.URL.Path, headers: r.Header, body: body})
+		}))
+		DeferCleanup(server.Close)
+
+		targets = []config.NotificationTarget{newTarget("hook", config.NotificationTypeWebhook)}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = New(config.Notifications{Targets: targets})
+		Expect(err).Should(Succeed())
+
+		sut.hostname = "blocky-host"
+		sut.retryDelay = time.Millisecond
+	})
+
+	Describe("New", func() {
+		It("should fail on invalid templates", func() {
+			_, err := New(config.Notifications{Targets: []config.NotificationTarget{{Name: "t", Template: "{{ .Foo"}}})
+			Expect(err).Should(MatchError(ContainSubstring("invalid template of notification target 't'")))
+		})
+	})
+
+	Describe("Notify", func() {
+		It("should send a JSON webhook", func() {
+			sut.Notify(ctx, &Notification{
+				Event:    EventUpstreamDown,
+				Message:  "upstream 1.1.1.1 is not reachable",
+				Hostname: sut.hostname,
+				Data:     map[string]string{"upstream": "1.1.1.1"},
+			})
+
+			Expect(requests()).Should(HaveLen(1))
+
+			req := requests()[0]
+			Expect(req.path).Should(Equal("/hook"))
+			Expect(req.headers.Get("Content-Type")).Should(Equal("application/json"))
+			Expect(req.body).Should(SatisfyAll(
+				HaveKeyWithValue("event", "upstreamDown"),
+				HaveKeyWithValue("message", "upstream 1.1.1.1 is not reachable"),
+				HaveKeyWithValue("hostname", "blocky-host"),
+				HaveKeyWithValue("data", HaveKeyWithValue("upstream", "1.1.1.1")),
+			))
+		})
+
+		When("target types and templates are configured", func() {
+			BeforeEach(func() {
+				slack := newTarget("slack", config.NotificationTypeSlack)
+				slack.Template = "{{ .Hostname }}: {{ .Message }}"
+
+				telegram := newTarget("telegram", config.NotificationTypeTelegram)
+				telegram.ChatID = "42"
+
+				gotify := newTarget("gotify", config.NotificationTypeGotify)
+				gotify.Token = "app-token"
+				gotify.Headers = map[string]string{"X-Custom": "value"}
+
+				targets = []config.NotificationTarget{slack, telegram, gotify}
+			})
+
+			It("should use the format of the target type", func() {
+				sut.Notify(ctx, &Notification{Event: EventConfigReloaded, Message: "configuration reloaded", Hostname: sut.hostname})
+
+				Expect(requests()).Should(HaveLen(3))
+
+				Expect(requests()[0].path).Should(Equal("/slack"))
+				Expect(requests()[0].body).Should(Equal(map[string]any{"text": "blocky-host: configuration reloaded"}))
+
+				Expect(requests()[1].path).Should(Equal("/telegram"))
+				Expect(requests()[1].body).Should(Equal(map[string]any{"chat_id": "42", "text": "configuration reloaded"}))
+
+				Expect(requests()[2].path).Should(Equal("/gotify/message"))
+				Expect(requests()[2].headers.Get("X-Gotify-Key")).Should(Equal("app-token"))
+				Expect(requests()[2].headers.Get("X-Custom")).Should(Equal("value"))
+				Expect(requests()[2].body).Should(SatisfyAll(
+					HaveKeyWithValue("title", "blocky: configReloaded"),
+					HaveKeyWithValue("message", "configuration reloaded"),
+				))
+			})
+		})
+
+		When("event is disabled for the target", func() {
+			BeforeEach(func() {
+				targets[0].Events.BlockingDisabled = false
+			})
+
+			It("should not send the notification", func() {
+				sut.Notify(ctx, &Notification{Event: EventBlockingDisabled})
+
+				Expect(requests()).Should(BeEmpty())
+			})
+		})
+
+		When("target fails temporarily", func() {
+			It("should retry", func() {
+				failures.Store(2)
+
+				sut.Notify(ctx, &Notification{Event: EventBlockingDisabled})
+
+				Expect(requests()).Should(HaveLen(1))
+			})
+
+			It("should give up after the configured retries", func() {
+				failures.Store(3)
+
+				sut.Notify(ctx, &Notification{Event: EventBlockingDisabled})
+
+				Expect(requests()).Should(BeEmpty())
+				Expect(failures.Load()).Should(BeEquivalentTo(0))
+			})
+		})
+	})
+
+	Describe("Subscribe", func() {
+		JustBeforeEach(func() {
+			sut.Subscribe(ctx)
+		})
+
+		It("should notify on events", func() {
+			evt.Bus().Publish(evt.BlockingEnabledEvent, true)
+			evt.Bus().Publish(evt.BlockingEnabledEvent, false)
+			evt.Bus().Publish(evt.CachingFailedDownloadChanged, "http://lists.local/ads.txt")
+			evt.Bus().Publish(evt.UpstreamStatusChanged, "1.1.1.1", false, errors.New("timeout"))
+			evt.Bus().Publish(evt.UpstreamStatusChanged, "1.1.1.1", true, nil)
+			evt.Bus().Publish(evt.UpstreamHijackDetected, "1.1.1.1", "A (10.0.0.1)")
+			evt.Bus().Publish(evt.QueryAnomalyDetected, "laptop", "highNxdomainRatio", "80% NXDOMAIN responses")
+			evt.Bus().Publish(evt.SelfCheckFailed, "ads.example.com", "A", "expected blocked, got RESOLVED (NOERROR)")
+			evt.Bus().Publish(evt.ConfigReloaded)
+
+			Eventually(func() []any {
+				res := make([]any, 0)
+				for _, r := range requests() {
+					res = append(res, r.body["message"])
+				}
+
+				return res
+			}).Should(ConsistOf(
+				"blocking disabled",
+				"download of list http://lists.local/ads.txt failed",
+				"upstream 1.1.1.1 is not reachable: timeout",
+				"upstream 1.1.1.1 answers queries for nonexistent domains: A (10.0.0.1)",
+				"client laptop: 80% NXDOMAIN responses",
+				"self-check of ads.example.com (A) failed: expected blocked, got RESOLVED (NOERROR)",
+				"configuration reloaded",
+			))
+		})
+	})
+})
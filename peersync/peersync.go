@@ -0,0 +1,322 @@
+// Package peersync synchronizes the runtime state (blocking status) between multiple blocky instances
+// without a shared Redis. Each state change is pushed to all configured peers over HTTP, the requests are
+// authenticated with a HMAC signature over the payload using a shared secret.
+package peersync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BlockingPath is the endpoint, which receives blocking state changes from peers
+	BlockingPath = "/peersync/blocking"
+	// StatePath is the endpoint, which returns the current state to peers
+	StatePath = "/peersync/state"
+
+	timestampHeader = "X-Blocky-Timestamp"
+	signatureHeader = "X-Blocky-Signature"
+
+	maxClockSkew   = 30 * time.Second
+	maxMessageSize = 64 * 1024
+)
+
+var errUnauthorized = errors.New("invalid signature")
+
+// State is the synchronized runtime state
+type State struct {
+	Enabled  bool          `json:"enabled"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Groups   []string      `json:"groups,omitempty"`
+}
+
+// Peers sends local state changes to the peers and applies changes received from peers.
+// It implements `api.BlockingControl` and forwards the calls to the wrapped control.
+type Peers struct {
+	cfg     config.PeerSync
+	control api.BlockingControl
+	client  *http.Client
+}
+
+func logger() *logrus.Entry {
+	return log.PrefixedLog("peersync")
+}
+
+// New creates a new instance, which wraps the blocking control. The current state is requested from the peers
+// as soon as the application is started.
+func New(ctx context.Context, cfg config.PeerSync, control api.BlockingControl) (*Peers, error) {
+	p := &Peers{
+		cfg:     cfg,
+		control: control,
+		client: &http.Client{
+			Timeout:   cfg.Timeout.ToDuration(),
+			Transport: util.DefaultHTTPTransport(),
+		},
+	}
+
+	err := evt.Bus().SubscribeOnce(evt.ApplicationStarted, func(_ ...string) {
+		go p.Pull(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// EnableBlocking implements `api.BlockingControl`
+func (p *Peers) EnableBlocking(ctx context.Context) {
+	p.control.EnableBlocking(ctx)
+
+	p.publish(ctx, State{Enabled: true})
+}
+
+// DisableBlocking implements `api.BlockingControl`
+func (p *Peers) DisableBlocking(ctx context.Context, duration time.Duration, disableGroups []string) error {
+	if err := p.control.DisableBlocking(ctx, duration, disableGroups); err != nil {
+		return err
+	}
+
+	p.publish(ctx, State{Enabled: false, Duration: duration, Groups: disableGroups})
+
+	return nil
+}
+
+// BlockingStatus implements `api.BlockingControl`
+func (p *Peers) BlockingStatus() api.BlockingStatus {
+	return p.control.BlockingStatus()
+}
+
+// RegisterEndpoints registers the HTTP endpoints, which are called by the peers
+func (p *Peers) RegisterEndpoints(router chi.Router) {
+	router.Post(BlockingPath, p.handleBlocking)
+	router.Get(StatePath, p.handleState)
+}
+
+// Pull requests the current state from the peers and applies the first received one
+func (p *Peers) Pull(ctx context.Context) {
+	for _, peer := range p.cfg.Peers {
+		state, err := p.fetchState(ctx, peer)
+		if err != nil {
+			logger().Warnf("can't get state from peer %s: %s", peer, err)
+
+			continue
+		}
+
+		logger().Infof("received state from peer %s: %+v", peer, *state)
+
+		if err := p.apply(ctx, state); err != nil {
+			logger().Warn("can't apply state: ", err)
+		}
+
+		return
+	}
+}
+
+func (p *Peers) currentState() State {
+	status := p.control.BlockingStatus()
+
+	return State{
+		Enabled:  status.Enabled,
+		Duration: time.Duration(status.AutoEnableInSec) * time.Second,
+		Groups:   status.DisabledGroups,
+	}
+}
+
+// apply changes the local state without publishing it again
+func (p *Peers) apply(ctx context.Context, state *State) error {
+	if state.Enabled {
+		p.control.EnableBlocking(ctx)
+
+		return nil
+	}
+
+	return p.control.DisableBlocking(ctx, state.Duration, state.Groups)
+}
+
+func (p *Peers) publish(ctx context.Context, state State) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		logger().Error("can't serialize state: ", err)
+
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	for _, peer := range p.cfg.Peers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := p.send(ctx, peer, body); err != nil {
+				logger().Warnf("can't send state to peer %s: %s", peer, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (p *Peers) send(ctx context.Context, peer string, body []byte) error {
+	// the request must not be canceled with the API request, which triggered the state change
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.Timeout.ToDuration())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peerURL(peer, BlockingPath), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	p.sign(req.Header, http.MethodPost, BlockingPath, body)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returned status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (p *Peers) fetchState(ctx context.Context, peer string) (*State, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peerURL(peer, StatePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p.sign(req.Header, http.MethodGet, StatePath, nil)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned status %s", resp.Status)
+	}
+
+	var state State
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxMessageSize)).Decode(&state); err != nil {
+		return nil, fmt.Errorf("can't parse state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (p *Peers) handleBlocking(rw http.ResponseWriter, req *http.Request) {
+	body, err := p.readVerified(req, BlockingPath)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	var state State
+	if err := json.Unmarshal(body, &state); err != nil {
+		http.Error(rw, "invalid state", http.StatusBadRequest)
+
+		return
+	}
+
+	logger().Debugf("received state from %s: %+v", req.RemoteAddr, state)
+
+	if err := p.apply(req.Context(), &state); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (p *Peers) handleState(rw http.ResponseWriter, req *http.Request) {
+	if _, err := p.readVerified(req, StatePath); err != nil {
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(rw).Encode(p.currentState()); err != nil {
+		logger().Warn("can't write state: ", err)
+	}
+}
+
+// readVerified reads the request body and verifies its signature
+func (p *Peers) readVerified(req *http.Request, path string) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxMessageSize))
+	if err != nil {
+		return nil, fmt.Errorf("can't read body: %w", err)
+	}
+
+	ts, err := strconv.ParseInt(req.Header.Get(timestampHeader), 10, 64)
+	if err != nil {
+		return nil, errUnauthorized
+	}
+
+	if skew := time.Since(time.Unix(ts, 0)); math.Abs(float64(skew)) > float64(maxClockSkew) {
+		return nil, errUnauthorized
+	}
+
+	expected := signature(p.cfg.Secret, req.Header.Get(timestampHeader), req.Method, path, body)
+
+	if !hmac.Equal([]byte(expected), []byte(req.Header.Get(signatureHeader))) {
+		return nil, errUnauthorized
+	}
+
+	return body, nil
+}
+
+// sign adds the signature headers, method and path are signed as well, so a request can't be replayed
+// on another endpoint
+func (p *Peers) sign(header http.Header, method, path string, body []byte) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	header.Set(timestampHeader, ts)
+	header.Set(signatureHeader, signature(p.cfg.Secret, ts, method, path, body))
+}
+
+func signature(secret, ts, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func peerURL(peer, path string) string {
+	return strings.TrimSuffix(peer, "/") + path
+}
@@ -0,0 +1,19 @@
+package peersync
+
+import (
+	"testing"
+
+	"github.com/0xERR0R/blocky/log"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func init() {
+	log.Silence()
+}
+
+func TestPeerSync(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Peer Sync Suite")
+}
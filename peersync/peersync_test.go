@@ -0,0 +1,223 @@
+package peersync
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/go-chi/chi/v5"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type mockControl struct {
+	lock   sync.Mutex
+	status api.BlockingStatus
+}
+
+func (m *mockControl) EnableBlocking(context.Context) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.status = api.BlockingStatus{Enabled: true}
+}
+
+func (m *mockControl) DisableBlocking(_ context.Context, duration time.Duration, groups []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.status = api.BlockingStatus{
+		Enabled:         false,
+		DisabledGroups:  groups,
+		AutoEnableInSec: int(duration.Seconds()),
+	}
+
+	return nil
+}
+
+func (m *mockControl) BlockingStatus() api.BlockingStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.status
+}
+
+var _ = Describe("Peers", func() {
+	var (
+		ctx context.Context
+
+		localControl, remoteControl *mockControl
+		local, remote               *Peers
+		remoteServer                *httptest.Server
+		remoteSecret                string
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		remoteSecret = "secret"
+		localControl = &mockControl{status: api.BlockingStatus{Enabled: true}}
+		remoteControl = &mockControl{status: api.BlockingStatus{Enabled: true}}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		remote, err = New(ctx, config.PeerSync{
+			Secret:  remoteSecret,
+			Timeout: config.Duration(time.Second),
+		}, remoteControl)
+		Expect(err).Should(Succeed())
+
+		router := chi.NewRouter()
+		remote.RegisterEndpoints(router)
+
+		remoteServer = httptest.NewServer(router)
+		DeferCleanup(remoteServer.Close)
+
+		local, err = New(ctx, config.PeerSync{
+			Peers:   []string{remoteServer.URL + "/"},
+			Secret:  "secret",
+			Timeout: config.Duration(time.Second),
+		}, localControl)
+		Expect(err).Should(Succeed())
+	})
+
+	Describe("State changes", func() {
+		It("should disable blocking locally and on the peer", func() {
+			Expect(local.DisableBlocking(ctx, time.Minute, []string{"ads"})).Should(Succeed())
+
+			Expect(localControl.BlockingStatus().Enabled).Should(BeFalse())
+			Expect(remoteControl.BlockingStatus()).Should(Equal(api.BlockingStatus{
+				Enabled:         false,
+				DisabledGroups:  []string{"ads"},
+				AutoEnableInSec: 60,
+			}))
+		})
+
+		It("should enable blocking locally and on the peer", func() {
+			Expect(remoteControl.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+			local.EnableBlocking(ctx)
+
+			Expect(localControl.BlockingStatus().Enabled).Should(BeTrue())
+			Expect(remoteControl.BlockingStatus().Enabled).Should(BeTrue())
+		})
+
+		It("should return the status of the wrapped control", func() {
+			Expect(local.BlockingStatus()).Should(Equal(localControl.BlockingStatus()))
+		})
+
+		When("the peer uses another secret", func() {
+			BeforeEach(func() {
+				remoteSecret = "other"
+			})
+
+			It("should reject the change on the peer", func() {
+				Expect(local.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+				Expect(localControl.BlockingStatus().Enabled).Should(BeFalse())
+				Expect(remoteControl.BlockingStatus().Enabled).Should(BeTrue())
+			})
+		})
+
+		When("the peer is not reachable", func() {
+			It("should change the local state anyway", func() {
+				remoteServer.Close()
+
+				Expect(local.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+				Expect(localControl.BlockingStatus().Enabled).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("Pull", func() {
+		It("should apply the state of the peer", func() {
+			Expect(remoteControl.DisableBlocking(ctx, 0, []string{"ads"})).Should(Succeed())
+
+			local.Pull(ctx)
+
+			Expect(localControl.BlockingStatus()).Should(Equal(api.BlockingStatus{
+				Enabled:        false,
+				DisabledGroups: []string{"ads"},
+			}))
+		})
+
+		When("the peer uses another secret", func() {
+			BeforeEach(func() {
+				remoteSecret = "other"
+			})
+
+			It("should keep the local state", func() {
+				Expect(remoteControl.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+				local.Pull(ctx)
+
+				Expect(localControl.BlockingStatus().Enabled).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("Authentication", func() {
+		post := func(path string, ts time.Time, sign func(ts string, body []byte) string) int {
+			body := []byte(`{"enabled":false}`)
+			tsStr := strconv.FormatInt(ts.Unix(), 10)
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, remoteServer.URL+path, bytes.NewReader(body))
+			Expect(err).Should(Succeed())
+
+			req.Header.Set(timestampHeader, tsStr)
+			req.Header.Set(signatureHeader, sign(tsStr, body))
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).Should(Succeed())
+
+			defer resp.Body.Close()
+
+			return resp.StatusCode
+		}
+
+		It("should accept a valid signature", func() {
+			code := post(BlockingPath, time.Now(), func(ts string, body []byte) string {
+				return signature("secret", ts, http.MethodPost, BlockingPath, body)
+			})
+
+			Expect(code).Should(Equal(http.StatusOK))
+			Expect(remoteControl.BlockingStatus().Enabled).Should(BeFalse())
+		})
+
+		It("should reject an outdated timestamp", func() {
+			ts := time.Now().Add(-time.Hour)
+
+			code := post(BlockingPath, ts, func(ts string, body []byte) string {
+				return signature("secret", ts, http.MethodPost, BlockingPath, body)
+			})
+
+			Expect(code).Should(Equal(http.StatusUnauthorized))
+			Expect(remoteControl.BlockingStatus().Enabled).Should(BeTrue())
+		})
+
+		It("should reject a signature for another endpoint", func() {
+			code := post(BlockingPath, time.Now(), func(ts string, body []byte) string {
+				return signature("secret", ts, http.MethodGet, StatePath, body)
+			})
+
+			Expect(code).Should(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject a missing signature", func() {
+			code := post(BlockingPath, time.Now(), func(string, []byte) string { return "" })
+
+			Expect(code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+})
@@ -0,0 +1,217 @@
+// Package profiling captures CPU and heap profiles automatically, if the query latency or the memory usage
+// exceed the configured thresholds.
+package profiling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	memoryCheckInterval = 10 * time.Second
+	profileFileSuffix   = ".pprof"
+	timestampFormat     = "20060102-150405"
+	bytesInMB           = 1024 * 1024
+	directoryPerm       = 0o750
+)
+
+func logger() *logrus.Entry {
+	return log.PrefixedLog("profiling")
+}
+
+// Capture writes CPU and heap profiles to a bounded directory, if a query takes longer than the latency threshold
+// or the heap grows above the memory threshold
+type Capture struct {
+	cfg config.ProfileCapture
+
+	checkInterval time.Duration
+
+	lock        sync.Mutex
+	running     bool
+	lastCapture time.Time
+}
+
+// NewCapture creates the profile directory and returns a new Capture
+func NewCapture(cfg config.ProfileCapture) (*Capture, error) {
+	if err := os.MkdirAll(cfg.Directory, directoryPerm); err != nil {
+		return nil, fmt.Errorf("can't create profile directory: %w", err)
+	}
+
+	return &Capture{
+		cfg:           cfg,
+		checkInterval: memoryCheckInterval,
+	}, nil
+}
+
+// Start checks the heap size periodically until the context is done
+func (c *Capture) Start(ctx context.Context) {
+	if c.cfg.MemoryThreshold == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.checkMemory(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ObserveLatency triggers a capture, if the duration of a query exceeds the latency threshold
+func (c *Capture) ObserveLatency(ctx context.Context, d time.Duration) {
+	threshold := c.cfg.LatencyThreshold.ToDuration()
+
+	if threshold > 0 && d > threshold {
+		c.trigger(ctx, fmt.Sprintf("query latency %s exceeded threshold %s", d, c.cfg.LatencyThreshold))
+	}
+}
+
+func (c *Capture) checkMemory(ctx context.Context) {
+	var m runtime.MemStats
+
+	runtime.ReadMemStats(&m)
+
+	if heapMB := m.HeapAlloc / bytesInMB; heapMB > c.cfg.MemoryThreshold {
+		c.trigger(ctx, fmt.Sprintf("heap size %d MB exceeded threshold %d MB", heapMB, c.cfg.MemoryThreshold))
+	}
+}
+
+// trigger starts a capture in the background, unless a capture is running or the last one is within the cooldown
+func (c *Capture) trigger(ctx context.Context, reason string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.running || (!c.lastCapture.IsZero() && time.Since(c.lastCapture) < c.cfg.Cooldown.ToDuration()) {
+		return
+	}
+
+	c.running = true
+	c.lastCapture = time.Now()
+
+	go func() {
+		defer func() {
+			c.lock.Lock()
+			c.running = false
+			c.lock.Unlock()
+		}()
+
+		c.capture(ctx, reason)
+	}()
+}
+
+func (c *Capture) capture(ctx context.Context, reason string) {
+	logger().Warnf("capturing profiles: %s", reason)
+
+	timestamp := time.Now().Format(timestampFormat)
+
+	if err := c.captureCPU(ctx, c.profilePath("cpu", timestamp)); err != nil {
+		logger().Errorf("can't capture CPU profile: %s", err)
+	}
+
+	if err := c.captureHeap(c.profilePath("heap", timestamp)); err != nil {
+		logger().Errorf("can't capture heap profile: %s", err)
+	}
+
+	if err := c.prune(); err != nil {
+		logger().Errorf("can't delete old profiles: %s", err)
+	}
+}
+
+func (c *Capture) profilePath(kind, timestamp string) string {
+	return filepath.Join(c.cfg.Directory, fmt.Sprintf("%s-%s%s", kind, timestamp, profileFileSuffix))
+}
+
+func (c *Capture) captureCPU(ctx context.Context, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	// fails, if a CPU profile is already running, e.g. by the pprof endpoint
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = os.Remove(path)
+
+		return err
+	}
+
+	select {
+	case <-time.After(c.cfg.CPUDuration.ToDuration()):
+	case <-ctx.Done():
+	}
+
+	pprof.StopCPUProfile()
+
+	return nil
+}
+
+func (c *Capture) captureHeap(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return pprof.Lookup("heap").WriteTo(f, 0)
+}
+
+// prune deletes the oldest profiles, if the directory contains more than the maximum number of profiles
+func (c *Capture) prune() error {
+	entries, err := os.ReadDir(c.cfg.Directory)
+	if err != nil {
+		return err
+	}
+
+	var profiles []string
+
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), profileFileSuffix) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+
+	if len(profiles) <= int(c.cfg.MaxFiles) {
+		return nil
+	}
+
+	// the timestamp follows the kind of the profile, newest profiles first
+	slices.SortFunc(profiles, func(a, b string) int {
+		return strings.Compare(timestampOf(b), timestampOf(a))
+	})
+
+	for _, name := range profiles[c.cfg.MaxFiles:] {
+		if err := os.Remove(filepath.Join(c.cfg.Directory, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func timestampOf(name string) string {
+	_, timestamp, _ := strings.Cut(strings.TrimSuffix(name, profileFileSuffix), "-")
+
+	return timestamp
+}
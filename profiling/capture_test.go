@@ -0,0 +1,140 @@
+package profiling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capture", func() {
+	var (
+		sut *Capture
+		cfg config.ProfileCapture
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		cfg, err = config.WithDefaults[config.ProfileCapture]()
+		Expect(err).Should(Succeed())
+
+		cfg.Directory = filepath.Join(GinkgoT().TempDir(), "profiles")
+		cfg.LatencyThreshold = config.Duration(time.Second)
+		cfg.CPUDuration = config.Duration(10 * time.Millisecond)
+
+		var cancelFn context.CancelFunc
+
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewCapture(cfg)
+		Expect(err).Should(Succeed())
+	})
+
+	profiles := func() []string {
+		entries, err := os.ReadDir(cfg.Directory)
+		Expect(err).Should(Succeed())
+
+		result := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			result = append(result, entry.Name())
+		}
+
+		return result
+	}
+
+	isRunning := func() bool {
+		sut.lock.Lock()
+		defer sut.lock.Unlock()
+
+		return sut.running
+	}
+
+	It("should create the directory", func() {
+		Expect(cfg.Directory).Should(BeADirectory())
+	})
+
+	When("the latency is below the threshold", func() {
+		It("should not capture profiles", func() {
+			sut.ObserveLatency(ctx, 500*time.Millisecond)
+
+			Consistently(profiles, "50ms").Should(BeEmpty())
+		})
+	})
+
+	When("the latency exceeds the threshold", func() {
+		It("should capture CPU and heap profiles", func() {
+			sut.ObserveLatency(ctx, 2*time.Second)
+
+			Eventually(isRunning).Should(BeFalse())
+			Expect(profiles()).Should(ConsistOf(
+				MatchRegexp(`^cpu-\d{8}-\d{6}\.pprof$`),
+				MatchRegexp(`^heap-\d{8}-\d{6}\.pprof$`),
+			))
+		})
+
+		It("should not capture again within the cooldown", func() {
+			sut.ObserveLatency(ctx, 2*time.Second)
+			Eventually(isRunning).Should(BeFalse())
+
+			captured := sut.lastCapture
+
+			sut.ObserveLatency(ctx, 2*time.Second)
+
+			Expect(isRunning()).Should(BeFalse())
+			Expect(sut.lastCapture).Should(Equal(captured))
+		})
+	})
+
+	When("the heap exceeds the memory threshold", func() {
+		BeforeEach(func() {
+			cfg.LatencyThreshold = 0
+			cfg.MemoryThreshold = 1
+		})
+
+		It("should capture profiles", func() {
+			// keep the heap above 1 MB
+			buf := make([]byte, 2*bytesInMB)
+
+			sut.checkInterval = 10 * time.Millisecond
+			sut.Start(ctx)
+
+			Eventually(profiles).Should(HaveLen(2))
+			Eventually(isRunning).Should(BeFalse())
+			Expect(buf).Should(HaveLen(2 * bytesInMB))
+		})
+	})
+
+	Describe("prune", func() {
+		BeforeEach(func() {
+			cfg.MaxFiles = 2
+		})
+
+		It("should delete the oldest profiles", func() {
+			for _, name := range []string{
+				"cpu-20260101-100000.pprof", "heap-20260101-100000.pprof",
+				"cpu-20260102-100000.pprof", "heap-20260102-100000.pprof",
+				"notes.txt",
+			} {
+				Expect(os.WriteFile(filepath.Join(cfg.Directory, name), nil, 0o600)).Should(Succeed())
+			}
+
+			Expect(sut.prune()).Should(Succeed())
+
+			Expect(profiles()).Should(ConsistOf(
+				"cpu-20260102-100000.pprof", "heap-20260102-100000.pprof", "notes.txt",
+			))
+		})
+	})
+})
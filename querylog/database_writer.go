@@ -20,6 +20,7 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type logEntry struct {
@@ -35,6 +36,33 @@ type logEntry struct {
 	Answer        string
 	ResponseCode  string
 	Hostname      string
+
+	Upstream         string
+	UpstreamGroup    string
+	UpstreamProtocol string
+	UpstreamRTTMs    int64
+	UpstreamRetries  uint
+
+	ECS            string
+	DurationBucket string
+	ReasonCode     string
+	Tenant         string `gorm:"index"`
+	Categories     string
+}
+
+// logHourlyStat is the pre-aggregated count of queries per hour, client and response type
+type logHourlyStat struct {
+	Hour         time.Time `gorm:"primaryKey"`
+	ClientName   string    `gorm:"primaryKey;size:255"`
+	ResponseType string    `gorm:"primaryKey;size:64"`
+	Queries      int64
+}
+
+// logDailyDomainStat is the pre-aggregated count of queries per day and domain
+type logDailyDomainStat struct {
+	Day          time.Time `gorm:"primaryKey"`
+	QuestionName string    `gorm:"primaryKey;size:255"`
+	Queries      int64
 }
 
 type DatabaseWriter struct {
@@ -43,23 +71,25 @@ type DatabaseWriter struct {
 	pendingEntries   []*logEntry
 	lock             sync.RWMutex
 	dbFlushPeriod    time.Duration
+	// maintain the aggregate tables with each flush
+	aggregate bool
 }
 
 func NewDatabaseWriter(ctx context.Context, dbType, target string, logRetentionDays uint64,
-	dbFlushPeriod time.Duration,
+	dbFlushPeriod time.Duration, aggregate bool,
 ) (*DatabaseWriter, error) {
 	switch dbType {
 	case "mysql":
-		return newDatabaseWriter(ctx, mysql.Open(target), logRetentionDays, dbFlushPeriod, dbType)
+		return newDatabaseWriter(ctx, mysql.Open(target), logRetentionDays, dbFlushPeriod, dbType, aggregate)
 	case "postgresql", "timescale":
-		return newDatabaseWriter(ctx, postgres.Open(target), logRetentionDays, dbFlushPeriod, dbType)
+		return newDatabaseWriter(ctx, postgres.Open(target), logRetentionDays, dbFlushPeriod, dbType, aggregate)
 	}
 
 	return nil, fmt.Errorf("incorrect database type provided: %s", dbType)
 }
 
 func newDatabaseWriter(ctx context.Context, target gorm.Dialector, logRetentionDays uint64,
-	dbFlushPeriod time.Duration, dbType string,
+	dbFlushPeriod time.Duration, dbType string, aggregate bool,
 ) (*DatabaseWriter, error) {
 	db, err := gorm.Open(target, &gorm.Config{
 		Logger: logger.New(
@@ -80,10 +110,17 @@ func newDatabaseWriter(ctx context.Context, target gorm.Dialector, logRetentionD
 		return nil, fmt.Errorf("can't perform auto migration: %w", err)
 	}
 
+	if aggregate {
+		if err := db.AutoMigrate(&logHourlyStat{}, &logDailyDomainStat{}); err != nil {
+			return nil, fmt.Errorf("can't perform auto migration of the aggregate tables: %w", err)
+		}
+	}
+
 	w := &DatabaseWriter{
 		db:               db,
 		logRetentionDays: logRetentionDays,
 		dbFlushPeriod:    dbFlushPeriod,
+		aggregate:        aggregate,
 	}
 
 	go w.periodicFlush(ctx)
@@ -177,6 +214,18 @@ func (d *DatabaseWriter) Write(entry *LogEntry) {
 		Answer:        entry.Answer,
 		ResponseCode:  entry.ResponseCode,
 		Hostname:      entry.BlockyInstance,
+
+		Upstream:         entry.Upstream,
+		UpstreamGroup:    entry.UpstreamGroup,
+		UpstreamProtocol: entry.UpstreamProtocol,
+		UpstreamRTTMs:    entry.UpstreamRTTMs,
+		UpstreamRetries:  entry.UpstreamRetries,
+
+		ECS:            entry.ECS,
+		DurationBucket: entry.DurationBucket,
+		ReasonCode:     entry.ResponseReasonCode,
+		Tenant:         entry.Tenant,
+		Categories:     entry.Categories,
 	}
 
 	d.lock.Lock()
@@ -190,6 +239,16 @@ func (d *DatabaseWriter) CleanUp() {
 
 	log.PrefixedLog("database_writer").Debugf("deleting log entries with request_ts < %s", deletionDate)
 	d.db.Where("request_ts < ?", deletionDate).Delete(&logEntry{})
+
+	if d.aggregate {
+		d.db.Where("hour < ?", deletionDate).Delete(&logHourlyStat{})
+		d.db.Where("day < ?", deletionDate).Delete(&logDailyDomainStat{})
+	}
+}
+
+// Flush implements `Flusher`.
+func (d *DatabaseWriter) Flush() error {
+	return d.doDBWrite()
 }
 
 func (d *DatabaseWriter) doDBWrite() error {
@@ -213,6 +272,10 @@ func (d *DatabaseWriter) doDBWrite() error {
 			err = multierror.Append(err, tx.Error)
 		}
 
+		if d.aggregate {
+			err = multierror.Append(err, d.writeAggregates(d.pendingEntries))
+		}
+
 		// clear the slice with pending entries
 		d.pendingEntries = nil
 
@@ -221,3 +284,59 @@ func (d *DatabaseWriter) doDBWrite() error {
 
 	return nil
 }
+
+// writeAggregates adds the counts of the entries to the aggregate tables
+func (d *DatabaseWriter) writeAggregates(entries []*logEntry) error {
+	hourly := make(map[logHourlyStat]int64)
+	daily := make(map[logDailyDomainStat]int64)
+
+	for _, e := range entries {
+		ts := *e.RequestTS
+
+		hourly[logHourlyStat{
+			Hour:         ts.Truncate(time.Hour),
+			ClientName:   e.ClientName,
+			ResponseType: e.ResponseType,
+		}]++
+
+		if e.QuestionName != "" {
+			daily[logDailyDomainStat{
+				Day:          time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, ts.Location()),
+				QuestionName: e.QuestionName,
+			}]++
+		}
+	}
+
+	var err *multierror.Error
+
+	for stat, count := range hourly {
+		stat.Queries = count
+
+		err = multierror.Append(err, d.addQueries(&stat, count, "hour", "client_name", "response_type"))
+	}
+
+	for stat, count := range daily {
+		stat.Queries = count
+
+		err = multierror.Append(err, d.addQueries(&stat, count, "day", "question_name"))
+	}
+
+	return err.ErrorOrNil()
+}
+
+// addQueries inserts the aggregate row or adds the count to the existing row with the same key
+func (d *DatabaseWriter) addQueries(stat any, count int64, keyColumns ...string) error {
+	tableName := d.db.NamingStrategy.TableName(reflect.TypeOf(stat).Elem().Name())
+
+	columns := make([]clause.Column, 0, len(keyColumns))
+	for _, name := range keyColumns {
+		columns = append(columns, clause.Column{Name: name})
+	}
+
+	return d.db.Clauses(clause.OnConflict{
+		Columns: columns,
+		DoUpdates: clause.Assignments(map[string]any{
+			"queries": gorm.Expr(tableName+".queries + ?", count),
+		}),
+	}).Create(stat).Error
+}
@@ -40,7 +40,7 @@ var _ = Describe("DatabaseWriter", func() {
 
 		When("New log entry was created", func() {
 			BeforeEach(func() {
-				writer, err = newDatabaseWriter(ctx, sqliteDB, 7, time.Millisecond, "sqlite")
+				writer, err = newDatabaseWriter(ctx, sqliteDB, 7, time.Millisecond, "sqlite", false)
 				Expect(err).Should(Succeed())
 
 				db, err := writer.db.DB()
@@ -91,7 +91,7 @@ var _ = Describe("DatabaseWriter", func() {
 
 		When("> 10000 Entries were created", func() {
 			BeforeEach(func() {
-				writer, err = newDatabaseWriter(ctx, sqliteDB, 7, time.Millisecond, "sqlite")
+				writer, err = newDatabaseWriter(ctx, sqliteDB, 7, time.Millisecond, "sqlite", false)
 				Expect(err).Should(Succeed())
 			})
 
@@ -120,9 +120,69 @@ var _ = Describe("DatabaseWriter", func() {
 			})
 		})
 
+		When("aggregation is enabled", func() {
+			BeforeEach(func() {
+				writer, err = newDatabaseWriter(ctx, sqliteDB, 1, time.Hour, "sqlite", true)
+				Expect(err).Should(Succeed())
+			})
+
+			It("should add the entries to the aggregate tables with each write", func() {
+				now := time.Now()
+				hour := now.Truncate(time.Hour)
+
+				write := func(client, responseType, question string) {
+					writer.Write(&LogEntry{
+						Start:        now,
+						ClientNames:  []string{client},
+						ResponseType: responseType,
+						QuestionName: question,
+					})
+				}
+
+				write("client1", "RESOLVED", "example.com")
+				write("client1", "RESOLVED", "example.com")
+				write("client2", "BLOCKED", "ads.com")
+				Expect(writer.doDBWrite()).Should(Succeed())
+
+				write("client1", "RESOLVED", "example.com")
+				Expect(writer.doDBWrite()).Should(Succeed())
+
+				var hourly []logHourlyStat
+				Expect(writer.db.Order("client_name").Find(&hourly).Error).Should(Succeed())
+				Expect(hourly).Should(HaveLen(2))
+				Expect(hourly[0].Hour).Should(BeTemporally("==", hour))
+				Expect(hourly[0].ClientName).Should(Equal("client1"))
+				Expect(hourly[0].ResponseType).Should(Equal("RESOLVED"))
+				Expect(hourly[0].Queries).Should(BeEquivalentTo(3))
+				Expect(hourly[1].ClientName).Should(Equal("client2"))
+				Expect(hourly[1].Queries).Should(BeEquivalentTo(1))
+
+				var daily []logDailyDomainStat
+				Expect(writer.db.Order("queries DESC").Find(&daily).Error).Should(Succeed())
+				Expect(daily).Should(HaveLen(2))
+				Expect(daily[0].QuestionName).Should(Equal("example.com"))
+				Expect(daily[0].Queries).Should(BeEquivalentTo(3))
+				Expect(daily[1].QuestionName).Should(Equal("ads.com"))
+			})
+
+			It("should delete aggregates exceeding the retention period", func() {
+				writer.Write(&LogEntry{Start: time.Now(), QuestionName: "example.com"})
+				writer.Write(&LogEntry{Start: time.Now().AddDate(0, 0, -3), QuestionName: "example.com"})
+				Expect(writer.doDBWrite()).Should(Succeed())
+
+				writer.CleanUp()
+
+				var hourly, daily int64
+				Expect(writer.db.Model(&logHourlyStat{}).Count(&hourly).Error).Should(Succeed())
+				Expect(writer.db.Model(&logDailyDomainStat{}).Count(&daily).Error).Should(Succeed())
+				Expect(hourly).Should(BeEquivalentTo(1))
+				Expect(daily).Should(BeEquivalentTo(1))
+			})
+		})
+
 		When("There are log entries with timestamp exceeding the retention period", func() {
 			BeforeEach(func() {
-				writer, err = newDatabaseWriter(ctx, sqliteDB, 1, time.Millisecond, "sqlite")
+				writer, err = newDatabaseWriter(ctx, sqliteDB, 1, time.Millisecond, "sqlite", false)
 				Expect(err).Should(Succeed())
 			})
 
@@ -170,7 +230,7 @@ var _ = Describe("DatabaseWriter", func() {
 	Describe("Database query log fails", func() {
 		When("mysql connection parameters wrong", func() {
 			It("should be log with fatal", func() {
-				_, err := NewDatabaseWriter(ctx, "mysql", "wrong param", 7, 1)
+				_, err := NewDatabaseWriter(ctx, "mysql", "wrong param", 7, 1, false)
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).Should(HavePrefix("can't create database connection"))
 			})
@@ -178,7 +238,7 @@ var _ = Describe("DatabaseWriter", func() {
 
 		When("postgresql connection parameters wrong", func() {
 			It("should be log with fatal", func() {
-				_, err := NewDatabaseWriter(ctx, "postgresql", "wrong param", 7, 1)
+				_, err := NewDatabaseWriter(ctx, "postgresql", "wrong param", 7, 1, false)
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).Should(HavePrefix("can't create database connection"))
 			})
@@ -186,7 +246,7 @@ var _ = Describe("DatabaseWriter", func() {
 
 		When("invalid database type is specified", func() {
 			It("should be log with fatal", func() {
-				_, err := NewDatabaseWriter(ctx, "invalidsql", "", 7, 1)
+				_, err := NewDatabaseWriter(ctx, "invalidsql", "", 7, 1, false)
 				Expect(err).Should(HaveOccurred())
 				Expect(err.Error()).Should(HavePrefix("incorrect database type provided"))
 			})
@@ -227,13 +287,14 @@ var _ = Describe("DatabaseWriter", func() {
 					mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "idx_log_entries_response_type"`).WillReturnResult(sqlmock.NewResult(0, 0))
 					mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "idx_log_entries_client_name"`).WillReturnResult(sqlmock.NewResult(0, 0))
 					mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "idx_log_entries_request_ts"`).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "idx_log_entries_tenant"`).WillReturnResult(sqlmock.NewResult(0, 0))
 				})
 
 				By("create postgres specific manually defined primary key", func() {
 					mock.ExpectExec(`ALTER TABLE log_entries ADD column if not exists id bigserial primary key`).WillReturnResult(sqlmock.NewResult(0, 0))
 				})
 
-				_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "postgres")
+				_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "postgres", false)
 				Expect(err).Should(Succeed())
 			})
 		})
@@ -265,7 +326,7 @@ var _ = Describe("DatabaseWriter", func() {
 						mock.ExpectExec("ALTER TABLE `log_entries` ADD `id` INT PRIMARY KEY AUTO_INCREMENT").WillReturnResult(sqlmock.NewResult(0, 0))
 					})
 
-					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql")
+					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql", false)
 					Expect(err).Should(Succeed())
 				})
 			})
@@ -281,7 +342,7 @@ var _ = Describe("DatabaseWriter", func() {
 						mock.ExpectExec("ALTER TABLE `log_entries` ADD `id` INT PRIMARY KEY AUTO_INCREMENT").WillReturnError(errors.New("error 1060: duplicate column name"))
 					})
 
-					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql")
+					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql", false)
 					Expect(err).Should(Succeed())
 				})
 
@@ -294,7 +355,7 @@ var _ = Describe("DatabaseWriter", func() {
 						mock.ExpectExec("ALTER TABLE `log_entries` ADD `id` INT PRIMARY KEY AUTO_INCREMENT").WillReturnError(errors.New("error XXX: some index error"))
 					})
 
-					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql")
+					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql", false)
 					Expect(err).Should(HaveOccurred())
 					Expect(err.Error()).Should(ContainSubstring("can't perform auto migration: error XXX: some index error"))
 				})
@@ -306,7 +367,7 @@ var _ = Describe("DatabaseWriter", func() {
 						mock.ExpectExec("CREATE TABLE `log_entries`").WillReturnError(errors.New("error XXX: some db error"))
 					})
 
-					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql")
+					_, err = newDatabaseWriter(ctx, dlc, 1, time.Millisecond, "mysql", false)
 					Expect(err).Should(HaveOccurred())
 					Expect(err.Error()).Should(ContainSubstring("can't perform auto migration: error XXX: some db error"))
 				})
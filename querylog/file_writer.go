@@ -1,7 +1,9 @@
 package querylog
 
 import (
+	"compress/gzip"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -21,15 +23,24 @@ const (
 	filePermission         = 0o666
 )
 
-var validFilePattern = regexp.MustCompile("[^a-zA-Z0-9-_]+")
+var (
+	validFilePattern = regexp.MustCompile("[^a-zA-Z0-9-_]+")
+	// files, which are currently written: date and escaped client, without rotation index
+	currentFilePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}_[a-zA-Z0-9-_]+\.log$`)
+)
 
 type FileWriter struct {
 	target           string
 	perClient        bool
 	logRetentionDays uint64
+	// a file is rotated, if it reaches this size in bytes, 0 disables the size based rotation
+	maxFileSize uint64
+	// compress the files rotated because of their size
+	compress bool
 }
 
-func NewCSVWriter(target string, perClient bool, logRetentionDays uint64) (*FileWriter, error) {
+func NewCSVWriter(target string, perClient bool, logRetentionDays, maxFileSize uint64, compress bool,
+) (*FileWriter, error) {
 	if _, err := os.Stat(target); target != "" && err != nil && os.IsNotExist(err) {
 		return nil, fmt.Errorf("query log directory '%s' does not exist or is not writable", target)
 	}
@@ -38,6 +49,8 @@ func NewCSVWriter(target string, perClient bool, logRetentionDays uint64) (*File
 		target:           target,
 		perClient:        perClient,
 		logRetentionDays: logRetentionDays,
+		maxFileSize:      maxFileSize,
+		compress:         compress,
 	}, nil
 }
 
@@ -52,23 +65,128 @@ func (d *FileWriter) Write(entry *LogEntry) {
 		clientPrefix = "ALL"
 	}
 
+	// separate files per tenant, e.g. to pass them to the tenant
+	if entry.Tenant != "" {
+		clientPrefix = entry.Tenant + "_" + clientPrefix
+	}
+
 	fileName := fmt.Sprintf("%s_%s.log", dateString, escape(clientPrefix))
 	writePath := filepath.Join(d.target, fileName)
 
-	file, err := os.OpenFile(writePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, filePermission)
+	logger := log.PrefixedLog(loggerPrefixFileWriter).WithField("file_name", writePath)
+
+	size, err := writeRow(writePath, createQueryLogRow(entry))
+	util.LogOnErrorWithEntry(logger, "can't write to file", err)
+
+	if err == nil && d.maxFileSize > 0 && uint64(size) >= d.maxFileSize {
+		util.LogOnErrorWithEntry(logger, "can't rotate file", rotateFile(writePath, d.compress))
+	}
+}
+
+// writeRow appends the row to the file, returns the new file size
+func writeRow(path string, row []string) (int64, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, filePermission)
+	if err != nil {
+		return 0, fmt.Errorf("can't create/open file: %w", err)
+	}
+
+	defer file.Close()
+
+	writer := createCsvWriter(file)
+
+	if err := writer.Write(row); err != nil {
+		return 0, err
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return 0, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Size(), nil
+}
+
+// Rotate implements `Rotator`.
+func (d *FileWriter) Rotate(compress bool) error {
+	files, err := os.ReadDir(d.target)
+	if err != nil {
+		return fmt.Errorf("can't list log directory: %w", err)
+	}
+
+	var errs error
+
+	for _, f := range files {
+		if !f.IsDir() && currentFilePattern.MatchString(f.Name()) {
+			errs = errors.Join(errs, rotateFile(filepath.Join(d.target, f.Name()), compress))
+		}
+	}
+
+	return errs
+}
+
+// rotateFile renames the file with the next free index, e.g. "2006-01-02_ALL.1.log", and compresses it optionally
+func rotateFile(path string, compress bool) error {
+	base := strings.TrimSuffix(path, ".log")
+
+	var rotated string
+
+	for i := 1; ; i++ {
+		rotated = fmt.Sprintf("%s.%d.log", base, i)
+
+		if !fileExists(rotated) && !fileExists(rotated+".gz") {
+			break
+		}
+	}
+
+	if err := os.Rename(path, rotated); err != nil {
+		return fmt.Errorf("can't rotate file: %w", err)
+	}
+
+	if compress {
+		return gzipFile(rotated)
+	}
+
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
 
-	util.LogOnErrorWithEntry(log.PrefixedLog(loggerPrefixFileWriter).WithField("file_name", writePath),
-		"can't create/open file", err)
+	return err == nil
+}
+
+// gzipFile replaces the file with its compressed version with suffix ".gz"
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("can't open file to compress: %w", err)
+	}
 
-	if err == nil {
-		defer file.Close()
-		writer := createCsvWriter(file)
+	defer src.Close()
 
-		err := writer.Write(createQueryLogRow(entry))
-		util.LogOnErrorWithEntry(log.PrefixedLog(loggerPrefixFileWriter).WithField("file_name", writePath),
-			"can't write to file", err)
-		writer.Flush()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, filePermission)
+	if err != nil {
+		return fmt.Errorf("can't create compressed file: %w", err)
 	}
+
+	zw := gzip.NewWriter(dst)
+
+	_, err = io.Copy(zw, src)
+	err = errors.Join(err, zw.Close(), dst.Close())
+
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+
+		return fmt.Errorf("can't compress file: %w", err)
+	}
+
+	return os.Remove(path)
 }
 
 // CleanUp deletes old log files
@@ -85,7 +203,7 @@ func (d *FileWriter) CleanUp() {
 
 	// search for log files, which names starts with date
 	for _, f := range files {
-		if strings.HasSuffix(f.Name(), ".log") && len(f.Name()) > 10 {
+		if (strings.HasSuffix(f.Name(), ".log") || strings.HasSuffix(f.Name(), ".log.gz")) && len(f.Name()) > 10 {
 			t, err := time.ParseInLocation("2006-01-02", f.Name()[:10], time.Local)
 			if err == nil {
 				differenceDays := uint64(time.Since(t).Hours() / hoursPerDay)
@@ -117,6 +235,16 @@ func createQueryLogRow(logEntry *LogEntry) []string {
 		logEntry.ResponseType,
 		logEntry.QuestionType,
 		logEntry.BlockyInstance,
+		logEntry.Upstream,
+		logEntry.UpstreamGroup,
+		logEntry.UpstreamProtocol,
+		strconv.FormatInt(logEntry.UpstreamRTTMs, 10),
+		strconv.FormatUint(uint64(logEntry.UpstreamRetries), 10),
+		logEntry.ECS,
+		logEntry.DurationBucket,
+		logEntry.ResponseReasonCode,
+		logEntry.Tenant,
+		logEntry.Categories,
 	}
 }
 
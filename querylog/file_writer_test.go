@@ -2,6 +2,7 @@ package querylog
 
 import (
 	"bufio"
+	"compress/gzip"
 	"encoding/csv"
 	"errors"
 	"io"
@@ -30,13 +31,13 @@ var _ = Describe("FileWriter", func() {
 	Describe("CSV writer", func() {
 		When("target dir does not exist", func() {
 			It("should return error", func() {
-				_, err = NewCSVWriter("wrongdir", false, 0)
+				_, err = NewCSVWriter("wrongdir", false, 0, 0, false)
 				Expect(err).Should(HaveOccurred())
 			})
 		})
 		When("New log entry was created", func() {
 			It("should be logged in one file", func() {
-				writer, err = NewCSVWriter(tmpDir.Path, false, 0)
+				writer, err = NewCSVWriter(tmpDir.Path, false, 0, 0, false)
 
 				Expect(err).Should(Succeed())
 
@@ -63,7 +64,7 @@ var _ = Describe("FileWriter", func() {
 			})
 
 			It("should be logged in separate files per client", func() {
-				writer, err = NewCSVWriter(tmpDir.Path, true, 0)
+				writer, err = NewCSVWriter(tmpDir.Path, true, 0, 0, false)
 
 				Expect(err).Should(Succeed())
 
@@ -94,9 +95,35 @@ var _ = Describe("FileWriter", func() {
 				}).Should(Equal(1))
 			})
 		})
+		When("entries have a tenant", func() {
+			It("should be logged in separate files per tenant", func() {
+				writer, err = NewCSVWriter(tmpDir.Path, false, 0, 0, false)
+
+				Expect(err).Should(Succeed())
+
+				writer.Write(&LogEntry{ClientNames: []string{"client1"}, Start: time.Now(), Tenant: "smith"})
+				writer.Write(&LogEntry{ClientNames: []string{"client2"}, Start: time.Now()})
+
+				rows := readCsv(tmpDir.JoinPath(time.Now().Format("2006-01-02") + "_smith_ALL.log"))
+				Expect(rows).Should(HaveLen(1))
+				Expect(rows[0][19]).Should(Equal("smith"))
+
+				Expect(readCsv(tmpDir.JoinPath(time.Now().Format("2006-01-02") + "_ALL.log"))).Should(HaveLen(1))
+			})
+
+			It("should prefix the files per client with the tenant", func() {
+				writer, err = NewCSVWriter(tmpDir.Path, true, 0, 0, false)
+
+				Expect(err).Should(Succeed())
+
+				writer.Write(&LogEntry{ClientNames: []string{"client1"}, Start: time.Now(), Tenant: "smith"})
+
+				Expect(readCsv(tmpDir.JoinPath(time.Now().Format("2006-01-02") + "_smith_client1.log"))).Should(HaveLen(1))
+			})
+		})
 		When("Cleanup is called", func() {
 			It("should delete old files", func() {
-				writer, err = NewCSVWriter(tmpDir.Path, false, 1)
+				writer, err = NewCSVWriter(tmpDir.Path, false, 1, 0, false)
 
 				Expect(err).Should(Succeed())
 
@@ -125,6 +152,81 @@ var _ = Describe("FileWriter", func() {
 					return os.ReadDir(tmpDir.Path)
 				}, "20s", "1s").Should(HaveLen(1))
 			})
+
+			It("should delete old compressed files", func() {
+				writer, err = NewCSVWriter(tmpDir.Path, false, 1, 0, false)
+				Expect(err).Should(Succeed())
+
+				tmpDir.CreateStringFile(time.Now().AddDate(0, 0, -3).Format("2006-01-02")+"_ALL.1.log.gz", "")
+
+				writer.CleanUp()
+
+				Expect(os.ReadDir(tmpDir.Path)).Should(BeEmpty())
+			})
+		})
+		When("Rotate is called", func() {
+			var fileName string
+
+			JustBeforeEach(func() {
+				fileName = time.Now().Format("2006-01-02") + "_ALL"
+
+				writer, err = NewCSVWriter(tmpDir.Path, false, 0, 0, false)
+				Expect(err).Should(Succeed())
+
+				writer.Write(&LogEntry{
+					ClientNames: []string{"client1"},
+					Start:       time.Now(),
+					DurationMs:  20,
+				})
+			})
+
+			It("should rename the current files with the next index", func() {
+				Expect(writer.Rotate(false)).Should(Succeed())
+				Expect(tmpDir.JoinPath(fileName + ".1.log")).Should(BeARegularFile())
+
+				writer.Write(&LogEntry{Start: time.Now()})
+				Expect(writer.Rotate(false)).Should(Succeed())
+
+				Expect(tmpDir.JoinPath(fileName + ".2.log")).Should(BeARegularFile())
+				Expect(tmpDir.JoinPath(fileName + ".log")).ShouldNot(BeAnExistingFile())
+				Expect(readCsv(tmpDir.JoinPath(fileName + ".1.log"))).Should(HaveLen(1))
+			})
+
+			It("should compress the rotated files", func() {
+				Expect(writer.Rotate(true)).Should(Succeed())
+
+				Expect(tmpDir.JoinPath(fileName + ".1.log")).ShouldNot(BeAnExistingFile())
+
+				f, err := os.Open(tmpDir.JoinPath(fileName + ".1.log.gz"))
+				Expect(err).Should(Succeed())
+				DeferCleanup(f.Close)
+
+				zr, err := gzip.NewReader(f)
+				Expect(err).Should(Succeed())
+
+				content, err := io.ReadAll(zr)
+				Expect(err).Should(Succeed())
+				Expect(string(content)).Should(ContainSubstring("client1"))
+			})
+		})
+		When("a max file size is configured", func() {
+			It("should rotate the file, if it reaches the size", func() {
+				writer, err = NewCSVWriter(tmpDir.Path, false, 0, 100, true)
+				Expect(err).Should(Succeed())
+
+				for range 3 {
+					writer.Write(&LogEntry{
+						ClientNames:  []string{"client1"},
+						Start:        time.Now(),
+						QuestionName: "example.com",
+					})
+				}
+
+				fileName := time.Now().Format("2006-01-02") + "_ALL"
+
+				Expect(tmpDir.JoinPath(fileName + ".1.log.gz")).Should(BeARegularFile())
+				Expect(readCsv(tmpDir.JoinPath(fileName + ".log"))).Should(HaveLen(1))
+			})
 		})
 	})
 })
@@ -40,6 +40,18 @@ func LogEntryFields(entry *LogEntry) logrus.Fields {
 		"answer":          entry.Answer,
 		"duration_ms":     entry.DurationMs,
 		"instance":        entry.BlockyInstance,
+
+		"upstream":          entry.Upstream,
+		"upstream_group":    entry.UpstreamGroup,
+		"upstream_protocol": entry.UpstreamProtocol,
+		"upstream_rtt_ms":   entry.UpstreamRTTMs,
+		"upstream_retries":  entry.UpstreamRetries,
+
+		"ecs":                  entry.ECS,
+		"duration_bucket":      entry.DurationBucket,
+		"response_reason_code": entry.ResponseReasonCode,
+		"tenant":               entry.Tenant,
+		"categories":           entry.Categories,
 	})
 }
 
@@ -55,6 +55,64 @@ var _ = Describe("LoggerWriter", func() {
 			Expect(fields).ShouldNot(HaveKey("client_names"))
 			Expect(fields).ShouldNot(HaveKey("question_name"))
 		})
+
+		It("should return upstream fields", func() {
+			entry := LogEntry{
+				Upstream:         "tcp+udp:1.1.1.1",
+				UpstreamGroup:    "default",
+				UpstreamProtocol: "tcp+udp",
+				UpstreamRTTMs:    42,
+				UpstreamRetries:  1,
+			}
+
+			fields := LogEntryFields(&entry)
+
+			Expect(fields).Should(HaveKeyWithValue("upstream", entry.Upstream))
+			Expect(fields).Should(HaveKeyWithValue("upstream_group", entry.UpstreamGroup))
+			Expect(fields).Should(HaveKeyWithValue("upstream_protocol", entry.UpstreamProtocol))
+			Expect(fields).Should(HaveKeyWithValue("upstream_rtt_ms", entry.UpstreamRTTMs))
+			Expect(fields).Should(HaveKeyWithValue("upstream_retries", entry.UpstreamRetries))
+		})
+
+		It("should return ECS and duration bucket fields", func() {
+			entry := LogEntry{
+				ECS:            "192.168.178.0/24",
+				DurationBucket: "10-50ms",
+			}
+
+			fields := LogEntryFields(&entry)
+
+			Expect(fields).Should(HaveKeyWithValue("ecs", entry.ECS))
+			Expect(fields).Should(HaveKeyWithValue("duration_bucket", entry.DurationBucket))
+		})
+
+		It("should return the reason code", func() {
+			entry := LogEntry{
+				ResponseReason:     "BLOCKED (ads)",
+				ResponseReasonCode: "BLOCKED",
+			}
+
+			fields := LogEntryFields(&entry)
+
+			Expect(fields).Should(HaveKeyWithValue("response_reason", entry.ResponseReason))
+			Expect(fields).Should(HaveKeyWithValue("response_reason_code", entry.ResponseReasonCode))
+		})
+
+		It("should return the tenant", func() {
+			entry := LogEntry{Tenant: "smith"}
+
+			fields := LogEntryFields(&entry)
+
+			Expect(fields).Should(HaveKeyWithValue("tenant", "smith"))
+		})
+
+		It("should return the categories", func() {
+			entry := LogEntry{Categories: "social,video"}
+
+			fields := LogEntryFields(&entry)
+
+			Expect(fields).Should(HaveKeyWithValue("categories", "social,video"))
+		})
 	})
 
 	DescribeTable("withoutZeroes",
@@ -0,0 +1,133 @@
+package querylog
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+const loggerPrefixRedisWriter = "queryLog_redis"
+
+// RedisPublisher sends the entries to Redis
+type RedisPublisher interface {
+	// AddToStream appends an entry with the values to the stream, which is trimmed to about maxLen entries
+	AddToStream(ctx context.Context, stream string, maxLen int64, values map[string]any) error
+	// Publish sends the message to the subscribers of the channel
+	Publish(ctx context.Context, channel string, message []byte) error
+}
+
+// RedisWriter adds the entries to a Redis stream and/or publishes them to a Redis channel for external consumers.
+// The entries are sent in the background, if Redis is too slow or unavailable and the buffer is full, further
+// entries are dropped instead of slowing down the query log.
+type RedisWriter struct {
+	publisher RedisPublisher
+	stream    string
+	channel   string
+	maxLen    int64
+	buffer    chan *LogEntry
+	// entries dropped since the last sent entry
+	dropped atomic.Uint64
+	// true, if the last entry couldn't be sent
+	failing bool
+	logger  *logrus.Entry
+}
+
+// NewRedisWriter creates a writer, which sends the entries until the context is done. An empty stream or channel
+// is not used.
+func NewRedisWriter(ctx context.Context, publisher RedisPublisher, stream, channel string,
+	maxLen, bufferSize uint,
+) *RedisWriter {
+	w := &RedisWriter{
+		publisher: publisher,
+		stream:    stream,
+		channel:   channel,
+		maxLen:    int64(maxLen),
+		buffer:    make(chan *LogEntry, bufferSize),
+		logger:    log.PrefixedLog(loggerPrefixRedisWriter),
+	}
+
+	go w.run(ctx)
+
+	return w
+}
+
+// Write queues the entry, it is dropped if the buffer is full
+func (w *RedisWriter) Write(entry *LogEntry) {
+	select {
+	case w.buffer <- entry:
+	default:
+		if w.dropped.Add(1) == 1 {
+			w.logger.Warn("redis is too slow, query log entries are dropped")
+		}
+	}
+}
+
+func (w *RedisWriter) CleanUp() {
+	// Nothing to do: the stream is trimmed on each entry
+}
+
+func (w *RedisWriter) run(ctx context.Context) {
+	for {
+		select {
+		case entry := <-w.buffer:
+			w.send(ctx, entry)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *RedisWriter) send(ctx context.Context, entry *LogEntry) {
+	err := w.publish(ctx, redisEntryFields(entry))
+	if err != nil {
+		if !w.failing {
+			w.logger.Error("can't send query log entry to redis: ", err)
+		}
+
+		w.failing = true
+
+		return
+	}
+
+	if w.failing {
+		w.logger.Info("query log entries are sent to redis again")
+
+		w.failing = false
+	}
+
+	if dropped := w.dropped.Swap(0); dropped > 0 {
+		w.logger.Warnf("%d query log entries were dropped", dropped)
+	}
+}
+
+func (w *RedisWriter) publish(ctx context.Context, fields map[string]any) error {
+	if w.stream != "" {
+		if err := w.publisher.AddToStream(ctx, w.stream, w.maxLen, fields); err != nil {
+			return err
+		}
+	}
+
+	if w.channel != "" {
+		message, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+
+		return w.publisher.Publish(ctx, w.channel, message)
+	}
+
+	return nil
+}
+
+// redisEntryFields returns the fields of the entry like the console writer with its time
+func redisEntryFields(entry *LogEntry) map[string]any {
+	fields := LogEntryFields(entry)
+	fields["time"] = entry.Start.UTC().Format(time.RFC3339Nano)
+
+	return fields
+}
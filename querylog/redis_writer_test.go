@@ -0,0 +1,155 @@
+package querylog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type streamEntry struct {
+	stream string
+	maxLen int64
+	values map[string]any
+}
+
+type channelMessage struct {
+	channel string
+	message []byte
+}
+
+// fakeRedisPublisher records the entries, until it is blocked or fails
+type fakeRedisPublisher struct {
+	entries  chan streamEntry
+	messages chan channelMessage
+	blocked  chan struct{}
+	err      error
+}
+
+func newFakeRedisPublisher() *fakeRedisPublisher {
+	return &fakeRedisPublisher{
+		entries:  make(chan streamEntry, 10),
+		messages: make(chan channelMessage, 10),
+	}
+}
+
+func (p *fakeRedisPublisher) AddToStream(_ context.Context, stream string, maxLen int64, values map[string]any) error {
+	if p.blocked != nil {
+		<-p.blocked
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.entries <- streamEntry{stream, maxLen, values}
+
+	return nil
+}
+
+func (p *fakeRedisPublisher) Publish(_ context.Context, channel string, message []byte) error {
+	p.messages <- channelMessage{channel, message}
+
+	return nil
+}
+
+var _ = Describe("RedisWriter", func() {
+	var (
+		publisher *fakeRedisPublisher
+		ctx       context.Context
+		entry     *LogEntry
+	)
+
+	BeforeEach(func() {
+		var cancelFn context.CancelFunc
+
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		publisher = newFakeRedisPublisher()
+		entry = &LogEntry{
+			Start:        time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			ClientIP:     "192.168.178.25",
+			QuestionName: "example.com",
+			ResponseType: "RESOLVED",
+			DurationMs:   20,
+		}
+	})
+
+	When("a stream is configured", func() {
+		It("should add the entry to the stream", func() {
+			writer := NewRedisWriter(ctx, publisher, "blocky:querylog", "", 100, 10)
+
+			writer.Write(entry)
+
+			var added streamEntry
+			Eventually(publisher.entries).Should(Receive(&added))
+			Expect(added.stream).Should(Equal("blocky:querylog"))
+			Expect(added.maxLen).Should(BeEquivalentTo(100))
+			Expect(added.values).Should(SatisfyAll(
+				HaveKeyWithValue("time", "2024-05-01T10:00:00Z"),
+				HaveKeyWithValue("client_ip", "192.168.178.25"),
+				HaveKeyWithValue("question_name", "example.com"),
+				HaveKeyWithValue("duration_ms", int64(20)),
+			))
+			Expect(publisher.messages).Should(BeEmpty())
+		})
+	})
+
+	When("a channel is configured", func() {
+		It("should publish the entry as JSON", func() {
+			writer := NewRedisWriter(ctx, publisher, "", "blocky_querylog", 100, 10)
+
+			writer.Write(entry)
+
+			var published channelMessage
+			Eventually(publisher.messages).Should(Receive(&published))
+			Expect(published.channel).Should(Equal("blocky_querylog"))
+
+			var fields map[string]any
+			Expect(json.Unmarshal(published.message, &fields)).Should(Succeed())
+			Expect(fields).Should(HaveKeyWithValue("response_type", "RESOLVED"))
+			Expect(publisher.entries).Should(BeEmpty())
+		})
+	})
+
+	When("redis is too slow", func() {
+		It("should drop the entries, which don't fit into the buffer", func() {
+			publisher.blocked = make(chan struct{})
+
+			writer := NewRedisWriter(ctx, publisher, "blocky:querylog", "", 100, 2)
+
+			// the first entry is taken from the buffer and blocks the writer
+			writer.Write(entry)
+			Eventually(writer.buffer).Should(BeEmpty())
+
+			for range 5 {
+				writer.Write(entry)
+			}
+
+			Expect(writer.dropped.Load()).Should(BeEquivalentTo(3))
+
+			close(publisher.blocked)
+
+			Eventually(publisher.entries).Should(HaveLen(3))
+			Eventually(writer.dropped.Load).Should(BeZero())
+		})
+	})
+
+	When("redis fails", func() {
+		It("should discard the entry", func() {
+			publisher.err = errors.New("connection refused")
+
+			writer := NewRedisWriter(ctx, publisher, "blocky:querylog", "", 100, 10)
+
+			writer.Write(entry)
+			Eventually(writer.buffer).Should(BeEmpty())
+			Consistently(publisher.entries).Should(BeEmpty())
+
+			writer.CleanUp()
+		})
+	})
+})
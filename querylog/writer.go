@@ -16,9 +16,38 @@ type LogEntry struct {
 	QuestionName   string
 	Answer         string
 	BlockyInstance string
+
+	Upstream         string
+	UpstreamGroup    string
+	UpstreamProtocol string
+	UpstreamRTTMs    int64
+	UpstreamRetries  uint
+
+	// EDNS client subnet of the query, e.g. "192.168.178.0/24"
+	ECS string
+	// coarse range of `DurationMs`, e.g. "10-50ms"
+	DurationBucket string
+	// code of the response reason, e.g. "BLOCKED"
+	ResponseReasonCode string
+	// tenant of the listener or client
+	Tenant string
+	// comma separated categories of the queried domain, e.g. "social,video"
+	Categories string
 }
 
 type Writer interface {
 	Write(entry *LogEntry)
 	CleanUp()
 }
+
+// Flusher is implemented by writers, which buffer the entries
+type Flusher interface {
+	// Flush writes the buffered entries
+	Flush() error
+}
+
+// Rotator is implemented by writers with files
+type Rotator interface {
+	// Rotate renames the current files, so new entries are written to new files
+	Rotate(compress bool) error
+}
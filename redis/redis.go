@@ -3,9 +3,13 @@ package redis
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"os"
 	"strings"
 	"time"
 
@@ -22,8 +26,8 @@ import (
 const (
 	SyncChannelName   = "blocky_sync"
 	CacheStorePrefix  = "blocky:cache:"
+	StateStorePrefix  = "blocky:state:"
 	chanCap           = 1000
-	cacheReason       = "EXTERNAL_CACHE"
 	defaultCacheTime  = 1 * time.Second
 	messageTypeCache  = 0
 	messageTypeEnable = 1
@@ -58,7 +62,7 @@ type EnabledMessage struct {
 // Client for redis communication
 type Client struct {
 	config         *config.Redis
-	client         *redis.Client
+	client         redis.UniversalClient
 	l              *logrus.Entry
 	id             []byte
 	sendBuffer     chan *bufferMessage
@@ -69,37 +73,16 @@ type Client struct {
 // New creates a new redis client
 func New(ctx context.Context, cfg *config.Redis) (*Client, error) {
 	// disable redis if no address is provided
-	if cfg == nil || len(cfg.Address) == 0 {
+	if cfg == nil || !cfg.IsEnabled() {
 		return nil, nil //nolint:nilnil
 	}
 
-	var baseClient *redis.Client
-	if len(cfg.SentinelAddresses) > 0 {
-		baseClient = redis.NewFailoverClient(&redis.FailoverOptions{
-			MasterName:       cfg.Address,
-			SentinelUsername: cfg.Username,
-			SentinelPassword: cfg.SentinelPassword,
-			SentinelAddrs:    cfg.SentinelAddresses,
-			Username:         cfg.Username,
-			Password:         cfg.Password,
-			DB:               cfg.Database,
-			MaxRetries:       cfg.ConnectionAttempts,
-			MaxRetryBackoff:  cfg.ConnectionCooldown.ToDuration(),
-		})
-	} else {
-		baseClient = redis.NewClient(&redis.Options{
-			Addr:            cfg.Address,
-			Username:        cfg.Username,
-			Password:        cfg.Password,
-			DB:              cfg.Database,
-			MaxRetries:      cfg.ConnectionAttempts,
-			MaxRetryBackoff: cfg.ConnectionCooldown.ToDuration(),
-		})
+	baseClient, err := newBaseClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	rdb := baseClient.WithContext(ctx)
-
-	_, err := rdb.Ping(ctx).Result()
+	_, err = baseClient.Ping(ctx).Result()
 	if err == nil {
 		var id []byte
 
@@ -108,7 +91,7 @@ func New(ctx context.Context, cfg *config.Redis) (*Client, error) {
 			// construct client
 			res := &Client{
 				config:         cfg,
-				client:         rdb,
+				client:         baseClient,
 				l:              log.PrefixedLog("redis"),
 				id:             id,
 				sendBuffer:     make(chan *bufferMessage, chanCap),
@@ -123,9 +106,109 @@ func New(ctx context.Context, cfg *config.Redis) (*Client, error) {
 		}
 	}
 
+	baseClient.Close()
+
 	return nil, err
 }
 
+// newBaseClient creates a cluster, sentinel (failover) or single server client
+func newBaseClient(cfg *config.Redis) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(cfg.ClusterAddresses) > 0:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           cfg.ClusterAddresses,
+			Username:        cfg.Username,
+			Password:        cfg.Password,
+			MaxRetries:      cfg.ConnectionAttempts,
+			MaxRetryBackoff: cfg.ConnectionCooldown.ToDuration(),
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConnections,
+			PoolTimeout:     cfg.PoolTimeout.ToDuration(),
+			TLSConfig:       tlsConfig,
+		}), nil
+	case len(cfg.SentinelAddresses) > 0:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.Address,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			SentinelAddrs:    cfg.SentinelAddresses,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.Database,
+			MaxRetries:       cfg.ConnectionAttempts,
+			MaxRetryBackoff:  cfg.ConnectionCooldown.ToDuration(),
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConnections,
+			PoolTimeout:      cfg.PoolTimeout.ToDuration(),
+			TLSConfig:        tlsConfig,
+		}), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:            cfg.Address,
+			Username:        cfg.Username,
+			Password:        cfg.Password,
+			DB:              cfg.Database,
+			MaxRetries:      cfg.ConnectionAttempts,
+			MaxRetryBackoff: cfg.ConnectionCooldown.ToDuration(),
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConnections,
+			PoolTimeout:     cfg.PoolTimeout.ToDuration(),
+			TLSConfig:       tlsConfig,
+		}), nil
+	}
+}
+
+// newTLSConfig returns the TLS configuration for the connections or nil, if TLS is disabled
+func newTLSConfig(cfg *config.RedisTLS) (*tls.Config, error) {
+	if !cfg.Enable {
+		return nil, nil //nolint:nilnil
+	}
+
+	res := &tls.Config{
+		ServerName:         cfg.ServerName,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read redis CA file: %w", err)
+		}
+
+		res.RootCAs = x509.NewCertPool()
+		if !res.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis CA file '%s' contains no certificate", cfg.CAFile)
+		}
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load redis client certificate: %w", err)
+		}
+
+		res.Certificates = []tls.Certificate{cert}
+	}
+
+	return res, nil
+}
+
+// CacheMessages returns the cache entries received from other instances
+func (c *Client) CacheMessages() <-chan *CacheMessage {
+	return c.CacheChannel
+}
+
+// EnabledMessages returns the blocking status changes received from other instances
+func (c *Client) EnabledMessages() <-chan *EnabledMessage {
+	return c.EnabledChannel
+}
+
 // PublishCache publish cache to redis async
 func (c *Client) PublishCache(key string, message *dns.Msg) {
 	if len(key) > 0 && message != nil {
@@ -151,31 +234,77 @@ func (c *Client) PublishEnabled(ctx context.Context, state *EnabledMessage) {
 	}
 }
 
+// AddToStream appends an entry with the values to the stream, which is trimmed to about maxLen entries.
+// A maxLen of 0 doesn't trim the stream.
+func (c *Client) AddToStream(ctx context.Context, stream string, maxLen int64, values map[string]any) error {
+	return c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Err()
+}
+
+// Publish sends the message to the subscribers of the channel
+func (c *Client) Publish(ctx context.Context, channel string, message []byte) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
+// StoreState persists the runtime state with the name, so it is available after a restart
+func (c *Client) StoreState(ctx context.Context, name string, state []byte) error {
+	return c.client.Set(ctx, StateStorePrefix+name, state, 0).Err()
+}
+
+// LoadState returns the persisted runtime state with the name or nil, if there is none
+func (c *Client) LoadState(ctx context.Context, name string) ([]byte, error) {
+	state, err := c.client.Get(ctx, StateStorePrefix+name).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+
+	return state, err
+}
+
 // GetRedisCache reads the redis cache and publish it to the channel
 func (c *Client) GetRedisCache(ctx context.Context) {
 	c.l.Debug("GetRedisCache")
 
 	go func() {
-		iter := c.client.Scan(ctx, 0, prefixKey("*"), 0).Iterator()
-		if err := iter.Err(); err != nil {
-			c.l.Error("GetRedisCache ", err)
+		var err error
+
+		// the keys of a cluster are distributed over the masters
+		if cluster, ok := c.client.(*redis.ClusterClient); ok {
+			err = cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+				return c.publishCachedResponses(ctx, master)
+			})
+		} else {
+			err = c.publishCachedResponses(ctx, c.client)
+		}
 
-			return
+		if err != nil {
+			c.l.Error("GetRedisCache ", err)
 		}
+	}()
+}
 
-		for iter.Next(ctx) {
-			response, err := c.getResponse(ctx, iter.Val())
-			if err == nil {
-				if response != nil {
-					if !util.CtxSend(ctx, c.CacheChannel, response) {
-						return
-					}
+// publishCachedResponses publishes the cache entries of the server to the channel
+func (c *Client) publishCachedResponses(ctx context.Context, server redis.UniversalClient) error {
+	iter := server.Scan(ctx, 0, prefixKey("*"), 0).Iterator()
+
+	for iter.Next(ctx) {
+		response, err := c.getResponse(ctx, iter.Val())
+		if err == nil {
+			if response != nil {
+				if !util.CtxSend(ctx, c.CacheChannel, response) {
+					return nil
 				}
-			} else {
-				c.l.Error("GetRedisCache ", err)
 			}
+		} else {
+			c.l.Error("GetRedisCache ", err)
 		}
-	}()
+	}
+
+	return iter.Err()
 }
 
 // startup starts a new goroutine for subscription and translation
@@ -315,7 +444,7 @@ func convertMessage(message *redisMessage, ttl time.Duration) (*CacheMessage, er
 			Key: message.Key,
 			Response: &model.Response{
 				RType:  model.ResponseTypeCACHED,
-				Reason: cacheReason,
+				Reason: model.NewReason(model.ReasonCodeEXTERNALCACHE),
 				Res:    &msg,
 			},
 		}
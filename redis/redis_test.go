@@ -2,7 +2,11 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
@@ -64,6 +68,54 @@ var _ = Describe("Redis client", func() {
 			})
 		})
 
+		When("TLS is enabled", func() {
+			var caFile string
+
+			BeforeEach(func() {
+				cert, err := util.TLSGenerateSelfSignedCert([]string{"localhost"})
+				Expect(err).Should(Succeed())
+
+				redisServer, err := miniredis.RunTLS(&tls.Config{
+					Certificates: []tls.Certificate{cert},
+					MinVersion:   tls.VersionTLS12,
+				})
+				Expect(err).Should(Succeed())
+				DeferCleanup(redisServer.Close)
+
+				caFile = filepath.Join(GinkgoT().TempDir(), "ca.pem")
+				Expect(os.WriteFile(caFile,
+					pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600),
+				).Should(Succeed())
+
+				redisConfig.Address = redisServer.Addr()
+				redisConfig.TLS = config.RedisTLS{Enable: true, ServerName: "localhost"}
+			})
+
+			It("should connect with the configured CA", func(ctx context.Context) {
+				redisConfig.TLS.CAFile = caFile
+
+				Expect(New(ctx, redisConfig)).ShouldNot(BeNil())
+			})
+
+			It("should fail with an untrusted certificate", func(ctx context.Context) {
+				_, err = New(ctx, redisConfig)
+				Expect(err).Should(HaveOccurred())
+			})
+
+			It("should connect without verification if configured", func(ctx context.Context) {
+				redisConfig.TLS.InsecureSkipVerify = true
+
+				Expect(New(ctx, redisConfig)).ShouldNot(BeNil())
+			})
+
+			It("should fail with an invalid CA file", func(ctx context.Context) {
+				redisConfig.TLS.CAFile = filepath.Join(GinkgoT().TempDir(), "missing.pem")
+
+				_, err = New(ctx, redisConfig)
+				Expect(err).Should(MatchError(ContainSubstring("can't read redis CA file")))
+			})
+		})
+
 		When("redis configuration has invalid password", func() {
 			BeforeEach(func() {
 				setupRedisServer(redisConfig)
@@ -295,6 +347,74 @@ var _ = Describe("Redis client", func() {
 		})
 	})
 
+	Describe("Runtime state", func() {
+		var redisServer *miniredis.Miniredis
+		BeforeEach(func() {
+			redisServer = setupRedisServer(redisConfig)
+		})
+		When("a state is stored", func() {
+			It("should be persisted without TTL and loaded again", func(ctx context.Context) {
+				redisClient, err = New(ctx, redisConfig)
+				Expect(err).Should(Succeed())
+
+				Expect(redisClient.StoreState(ctx, "test", []byte("state"))).Should(Succeed())
+
+				Expect(redisServer.DB(redisConfig.Database).Get(StateStorePrefix + "test")).Should(Equal("state"))
+				Expect(redisServer.DB(redisConfig.Database).TTL(StateStorePrefix + "test")).Should(BeZero())
+
+				Expect(redisClient.LoadState(ctx, "test")).Should(Equal([]byte("state")))
+			})
+		})
+		When("no state is stored", func() {
+			It("should return nil", func(ctx context.Context) {
+				redisClient, err = New(ctx, redisConfig)
+				Expect(err).Should(Succeed())
+
+				Expect(redisClient.LoadState(ctx, "test")).Should(BeNil())
+			})
+		})
+	})
+
+	Describe("Streams and channels", func() {
+		var redisServer *miniredis.Miniredis
+		BeforeEach(func() {
+			redisServer = setupRedisServer(redisConfig)
+		})
+		When("an entry is added to a stream", func() {
+			It("should be appended with its values", func(ctx context.Context) {
+				redisClient, err = New(ctx, redisConfig)
+				Expect(err).Should(Succeed())
+
+				Expect(redisClient.AddToStream(ctx, "stream", 100, map[string]any{"name": "example.com"})).
+					Should(Succeed())
+
+				entries, err := redisServer.DB(redisConfig.Database).Stream("stream")
+				Expect(err).Should(Succeed())
+				Expect(entries).Should(HaveLen(1))
+				Expect(entries[0].Values).Should(Equal([]string{"name", "example.com"}))
+			})
+		})
+		When("a message is published", func() {
+			It("should be received by the subscribers", func(ctx context.Context) {
+				redisClient, err = New(ctx, redisConfig)
+				Expect(err).Should(Succeed())
+
+				subscriber := redisServer.NewSubscriber()
+				DeferCleanup(subscriber.Close)
+				subscriber.Subscribe("channel")
+
+				// the subscriber of miniredis receives the message synchronously
+				published := make(chan error, 1)
+				go func() {
+					published <- redisClient.Publish(ctx, "channel", []byte("message"))
+				}()
+
+				Eventually(subscriber.Messages()).Should(Receive(HaveField("Message", "message")))
+				Eventually(published).Should(Receive(BeNil()))
+			})
+		})
+	})
+
 	Describe("Read the redis cache and publish it to the channel", func() {
 		var redisServer *miniredis.Miniredis
 		BeforeEach(func() {
@@ -334,6 +454,30 @@ var _ = Describe("Redis client", func() {
 				})
 			}, SpecTimeout(time.Second*4))
 		})
+		When("a cluster is configured", func() {
+			BeforeEach(func() {
+				redisConfig.ClusterAddresses = []string{redisConfig.Address}
+				redisConfig.Address = ""
+			})
+
+			It("Should read data from all masters and propagate it via cache channel", func(ctx context.Context) {
+				redisClient, err = New(ctx, redisConfig)
+				Expect(err).Should(Succeed())
+
+				res, err := util.NewMsgWithAnswer("example.com.", 123, dns.Type(dns.TypeA), "123.124.122.123")
+				Expect(err).Should(Succeed())
+
+				redisClient.PublishCache("example.com", res)
+
+				Eventually(func() bool {
+					return redisServer.Exists(exampleComKey)
+				}).Should(BeTrue())
+
+				redisClient.GetRedisCache(ctx)
+
+				Eventually(redisClient.CacheChannel).Should(HaveLen(1))
+			}, SpecTimeout(time.Second*4))
+		})
 		When("GetRedisCache is called and database contains not valid entry", func() {
 			It("Should do nothing (only log error)", func(ctx context.Context) {
 				redisClient, err = New(ctx, redisConfig)
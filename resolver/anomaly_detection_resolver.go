@@ -0,0 +1,241 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const anomalyDetectionResolverType = "anomaly_detection"
+
+// detected anomalies, used in the metrics, logs and notifications
+const (
+	anomalyQueryRate     = "highQueryRate"
+	anomalyNXDomainRatio = "highNxdomainRatio"
+	anomalyEntropy       = "highEntropy"
+	anomalyLabelLength   = "longLabels"
+)
+
+//nolint:gochecknoglobals
+var queryAnomalies = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_query_anomalies_total",
+		Help: "Number of windows, in which the queries of a client exceeded an anomaly threshold",
+	}, []string{"client", "anomaly"},
+))
+
+// AnomalyDetectionResolver computes statistics of the queries of each client per time window and reports clients,
+// which exceed the thresholds: a high query rate or long, random looking labels are typical for DNS tunneling,
+// many NXDOMAIN responses for domain generation algorithms (DGA)
+type AnomalyDetectionResolver struct {
+	configurable[*config.AnomalyDetection]
+	NextResolver
+	typed
+
+	lock sync.Mutex
+	// statistics of the running window per client
+	windows map[string]*queryWindow
+	// time of the last alert per client and anomaly
+	lastAlerts map[anomalyKey]time.Time
+}
+
+type queryWindow struct {
+	queries  int
+	nxDomain int
+	// sums of the entropy and length of the longest label of each query
+	entropy     float64
+	labelLength int
+}
+
+type anomalyKey struct {
+	client  string
+	anomaly string
+}
+
+// NewAnomalyDetectionResolver creates new resolver instance, the windows are evaluated until the context is done
+func NewAnomalyDetectionResolver(ctx context.Context, cfg config.AnomalyDetection) *AnomalyDetectionResolver {
+	r := &AnomalyDetectionResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(anomalyDetectionResolverType),
+
+		windows:    make(map[string]*queryWindow),
+		lastAlerts: make(map[anomalyKey]time.Time),
+	}
+
+	if cfg.IsEnabled() {
+		go r.periodicEvaluation(ctx)
+	}
+
+	return r
+}
+
+// Resolve records the query and its response in the window of the client
+func (r *AnomalyDetectionResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	response, err := r.next.Resolve(ctx, request)
+
+	if err == nil && r.IsEnabled() {
+		r.record(request, response)
+	}
+
+	return response, err
+}
+
+func (r *AnomalyDetectionResolver) record(request *model.Request, response *model.Response) {
+	client := strings.Join(request.ClientNames, ",")
+	label := longestLabel(util.ExtractDomain(request.Req.Question[0]))
+	// blocked queries are often answered with NXDOMAIN too, but say nothing about the client
+	nxDomain := response.Res.Rcode == dns.RcodeNameError && response.RType != model.ResponseTypeBLOCKED
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	window, ok := r.windows[client]
+	if !ok {
+		window = new(queryWindow)
+		r.windows[client] = window
+	}
+
+	window.queries++
+	window.entropy += entropy(label)
+	window.labelLength += len(label)
+
+	if nxDomain {
+		window.nxDomain++
+	}
+}
+
+func (r *AnomalyDetectionResolver) periodicEvaluation(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Window.ToDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			r.evaluate(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// evaluate checks the windows of all clients against the thresholds and starts new windows
+func (r *AnomalyDetectionResolver) evaluate(now time.Time) {
+	r.lock.Lock()
+	windows := r.windows
+	r.windows = make(map[string]*queryWindow, len(windows))
+	r.lock.Unlock()
+
+	for client, window := range windows {
+		for anomaly, description := range r.anomalies(window) {
+			r.report(client, anomaly, description, now)
+		}
+	}
+
+	for key, lastAlert := range r.lastAlerts {
+		if now.Sub(lastAlert) >= r.cfg.AlertInterval.ToDuration() {
+			delete(r.lastAlerts, key)
+		}
+	}
+}
+
+// anomalies returns the description of each exceeded threshold
+func (r *AnomalyDetectionResolver) anomalies(window *queryWindow) map[string]string {
+	result := make(map[string]string)
+
+	if r.cfg.MaxQueries > 0 && window.queries > int(r.cfg.MaxQueries) {
+		result[anomalyQueryRate] = fmt.Sprintf("%d queries in %s", window.queries, r.cfg.Window)
+	}
+
+	// the averages are meaningless for a few queries
+	if window.queries < int(r.cfg.MinQueries) {
+		return result
+	}
+
+	queries := float64(window.queries)
+
+	if ratio := float64(window.nxDomain) / queries; r.cfg.MaxNXDomainRatio > 0 && ratio > r.cfg.MaxNXDomainRatio {
+		result[anomalyNXDomainRatio] = fmt.Sprintf("%.0f%% of %d queries answered with NXDOMAIN",
+			ratio*100, window.queries) //nolint:mnd
+	}
+
+	if avg := window.entropy / queries; r.cfg.MaxEntropy > 0 && avg > r.cfg.MaxEntropy {
+		result[anomalyEntropy] = fmt.Sprintf("average label entropy of %.2f bits per character", avg)
+	}
+
+	if avg := float64(window.labelLength) / queries; r.cfg.MaxLabelLength > 0 && avg > float64(r.cfg.MaxLabelLength) {
+		result[anomalyLabelLength] = fmt.Sprintf("average label length of %.0f characters", avg)
+	}
+
+	return result
+}
+
+// report counts the anomaly and alerts, if there was no alert for the client and anomaly in the alert interval
+func (r *AnomalyDetectionResolver) report(client, anomaly, description string, now time.Time) {
+	queryAnomalies.WithLabelValues(client, anomaly).Inc()
+
+	key := anomalyKey{client: client, anomaly: anomaly}
+
+	if _, ok := r.lastAlerts[key]; ok {
+		return
+	}
+
+	r.lastAlerts[key] = now
+
+	log.PrefixedLog(anomalyDetectionResolverType).WithFields(logrus.Fields{
+		"client":  client,
+		"anomaly": anomaly,
+	}).Warnf("suspicious queries: %s", description)
+
+	evt.Bus().Publish(evt.QueryAnomalyDetected, client, anomaly, description)
+}
+
+// longestLabel returns the longest label of the domain without the TLD
+func longestLabel(domain string) string {
+	labels := strings.Split(domain, ".")
+	if len(labels) > 1 {
+		labels = labels[:len(labels)-1]
+	}
+
+	longest := ""
+
+	for _, label := range labels {
+		if len(label) > len(longest) {
+			longest = label
+		}
+	}
+
+	return longest
+}
+
+// entropy returns the Shannon entropy of the characters of s in bits per character
+func entropy(s string) float64 {
+	var counts [256]int
+
+	for i := range len(s) {
+		counts[s[i]]++
+	}
+
+	result := 0.0
+
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(len(s))
+			result -= p * math.Log2(p)
+		}
+	}
+
+	return result
+}
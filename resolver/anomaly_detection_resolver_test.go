@@ -0,0 +1,243 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("AnomalyDetectionResolver", func() {
+	var (
+		sut       *AnomalyDetectionResolver
+		sutConfig config.AnomalyDetection
+		m         *mockResolver
+		alerts    chan []string
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.AnomalyDetection{
+			Enable:           true,
+			Window:           config.Duration(time.Hour),
+			MinQueries:       10,
+			MaxQueries:       100,
+			MaxNXDomainRatio: 0.5,
+			MaxEntropy:       4,
+			MaxLabelLength:   40,
+			AlertInterval:    config.Duration(time.Hour),
+		}
+
+		alerts = make(chan []string, 10)
+		handler := func(client, anomaly, description string) {
+			alerts <- []string{client, anomaly, description}
+		}
+
+		Expect(evt.Bus().Subscribe(evt.QueryAnomalyDetected, handler)).Should(Succeed())
+		DeferCleanup(evt.Bus().Unsubscribe, evt.QueryAnomalyDetected, handler)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewAnomalyDetectionResolver(ctx, sutConfig)
+
+		nxDomain := new(dns.Msg)
+		nxDomain.Rcode = dns.RcodeNameError
+
+		mockAnswer, _ := util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.MatchedBy(func(req *Request) bool {
+			return req.Req.Question[0].Name == "blocked.com."
+		})).Return(&Response{Res: nxDomain, RType: ResponseTypeBLOCKED}, nil)
+		m.On("Resolve", mock.MatchedBy(func(req *Request) bool {
+			return req.Req.Question[0].Name[0] == 'x'
+		})).Return(&Response{Res: nxDomain, RType: ResponseTypeRESOLVED}, nil)
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	query := func(count int, domain func(i int) string) {
+		for i := range count {
+			_, err := sut.Resolve(ctx, newRequestWithClient(domain(i), A, "192.168.178.2", "laptop"))
+			Expect(err).Should(Succeed())
+		}
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not record queries", func() {
+				query(1, func(int) string { return "example.com." })
+
+				Expect(sut.windows).Should(BeEmpty())
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("should not alert for normal queries", func() {
+		query(50, func(i int) string { return fmt.Sprintf("www%d.example.com.", i) })
+		query(5, func(i int) string { return fmt.Sprintf("x%d.example.com.", i) })
+		query(5, func(int) string { return "blocked.com." })
+
+		sut.evaluate(time.Now())
+
+		Consistently(alerts).ShouldNot(Receive())
+	})
+
+	It("should alert on a high query rate", func() {
+		query(101, func(int) string { return "example.com." })
+
+		sut.evaluate(time.Now())
+
+		Eventually(alerts).Should(Receive(Equal([]string{"laptop", anomalyQueryRate, "101 queries in 1 hour"})))
+	})
+
+	It("should alert on many NXDOMAIN responses", func() {
+		query(6, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+		query(4, func(int) string { return "example.com." })
+
+		sut.evaluate(time.Now())
+
+		Eventually(alerts).Should(Receive(Equal([]string{
+			"laptop", anomalyNXDomainRatio, "60% of 10 queries answered with NXDOMAIN",
+		})))
+	})
+
+	It("should alert on long labels with high entropy", func() {
+		query(10, func(i int) string {
+			return fmt.Sprintf("%d-dGhpcyBpcyBhIHNlY3JldCBtZXNzYWdlIGZvciB0aGUgYzIK.t.example.com.", i)
+		})
+
+		sut.evaluate(time.Now())
+
+		var anomalies []string
+
+		for range 2 {
+			var alert []string
+
+			Eventually(alerts).Should(Receive(&alert))
+
+			anomalies = append(anomalies, alert[1])
+		}
+
+		Expect(anomalies).Should(ConsistOf(anomalyEntropy, anomalyLabelLength))
+	})
+
+	It("should ignore averages of a few queries", func() {
+		query(9, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+
+		sut.evaluate(time.Now())
+
+		Consistently(alerts).ShouldNot(Receive())
+	})
+
+	It("should start a new window after the evaluation", func() {
+		query(60, func(int) string { return "example.com." })
+		sut.evaluate(time.Now())
+
+		query(60, func(int) string { return "example.com." })
+		sut.evaluate(time.Now())
+
+		Consistently(alerts).ShouldNot(Receive())
+	})
+
+	It("should alert only once in the alert interval", func() {
+		now := time.Now()
+
+		query(6, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+		query(4, func(int) string { return "example.com." })
+		sut.evaluate(now)
+
+		Eventually(alerts).Should(Receive())
+
+		query(10, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+		sut.evaluate(now.Add(time.Minute))
+
+		Consistently(alerts).ShouldNot(Receive())
+
+		// the alert interval passed
+		sut.evaluate(now.Add(time.Hour))
+
+		query(10, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+		sut.evaluate(now.Add(time.Hour + time.Minute))
+
+		Eventually(alerts).Should(Receive())
+	})
+
+	When("a threshold is 0", func() {
+		BeforeEach(func() {
+			sutConfig.MaxNXDomainRatio = 0
+		})
+
+		It("should disable the check", func() {
+			query(10, func(i int) string { return fmt.Sprintf("x%dqk.com.", i) })
+
+			sut.evaluate(time.Now())
+
+			Consistently(alerts).ShouldNot(Receive())
+		})
+	})
+
+	Describe("longestLabel", func() {
+		It("should ignore the TLD", func() {
+			Expect(longestLabel("www.example.com")).Should(Equal("example"))
+			Expect(longestLabel("a.b.verylongtld")).Should(Equal("a"))
+			Expect(longestLabel("localhost")).Should(Equal("localhost"))
+		})
+	})
+
+	Describe("entropy", func() {
+		It("should compute the Shannon entropy", func() {
+			Expect(entropy("")).Should(BeZero())
+			Expect(entropy("aaaa")).Should(BeZero())
+			Expect(entropy("abab")).Should(BeNumerically("~", 1))
+			Expect(entropy("abcdefgh")).Should(BeNumerically("~", 3))
+			Expect(entropy("google")).Should(BeNumerically("~", 1.918, 0.001))
+			Expect(entropy("abcdefghijklmnop")).Should(BeNumerically("~", math.Log2(16)))
+		})
+	})
+})
@@ -0,0 +1,248 @@
+package resolver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+)
+
+const auditResolverType = "audit"
+
+// AuditResolver records the hashes of the questions and answers of the latest responses per client
+// and the latest responses, which were altered by the resolvers
+type AuditResolver struct {
+	configurable[*config.Audit]
+	NextResolver
+	typed
+
+	lock sync.Mutex
+	// ring buffers per client IP
+	clients map[string]*auditRing[api.AuditEntry]
+	// ring buffer of the altered responses of all clients
+	manipulations *auditRing[api.ManipulationEntry]
+}
+
+// auditRing keeps the latest entries, the oldest entry is overwritten if it is full
+type auditRing[T any] struct {
+	entries  []T
+	next     int
+	lastSeen time.Time
+}
+
+// NewAuditResolver creates new resolver instance
+func NewAuditResolver(cfg config.Audit) *AuditResolver {
+	return &AuditResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(auditResolverType),
+
+		clients:       make(map[string]*auditRing[api.AuditEntry]),
+		manipulations: &auditRing[api.ManipulationEntry]{},
+	}
+}
+
+// Resolve records the response for the client
+func (r *AuditResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if r.cfg.Manipulations.IsEnabled() {
+		ctx = WithResponseManipulations(ctx)
+	}
+
+	response, err := r.next.Resolve(ctx, request)
+
+	if err == nil && r.cfg.RecordsResponses() && request.ClientIP != nil {
+		r.record(request, response, time.Now())
+	}
+
+	if err == nil && r.cfg.Manipulations.IsEnabled() {
+		r.recordManipulations(ctx, request, time.Now())
+	}
+
+	return response, err
+}
+
+// recordManipulations records the changes of the query and its response, if there are any
+func (r *AuditResolver) recordManipulations(ctx context.Context, request *model.Request, now time.Time) {
+	changes := responseManipulationsFromCtx(ctx).list()
+	if len(changes) == 0 {
+		return
+	}
+
+	question := request.Req.Question[0]
+
+	entry := api.ManipulationEntry{
+		Time:     now,
+		Question: strings.TrimSuffix(question.Name, "."),
+		Type:     dns.Type(question.Qtype).String(),
+		Changes:  changes,
+	}
+
+	if request.ClientIP != nil {
+		entry.Client = request.ClientIP.String()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.manipulations.add(entry, now, int(r.cfg.Manipulations.Entries))
+}
+
+func (r *AuditResolver) record(request *model.Request, response *model.Response, now time.Time) {
+	question := request.Req.Question[0]
+
+	entry := api.AuditEntry{
+		Time:         now,
+		ID:           request.Req.Id,
+		Type:         dns.Type(question.Qtype).String(),
+		QuestionHash: auditQuestionHash(question.Name),
+		AnswerHash:   auditAnswerHash(response.Res.Answer),
+		ReturnCode:   dns.RcodeToString[response.Res.Rcode],
+		ResponseType: response.RType.String(),
+	}
+
+	client := request.ClientIP.String()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ring, ok := r.clients[client]
+	if !ok {
+		if len(r.clients) >= int(r.cfg.MaxClients) {
+			r.evictLeastRecentClient()
+		}
+
+		ring = &auditRing[api.AuditEntry]{entries: make([]api.AuditEntry, 0, r.cfg.Entries)}
+		r.clients[client] = ring
+	}
+
+	ring.add(entry, now, int(r.cfg.Entries))
+}
+
+// evictLeastRecentClient removes the client with the oldest response to make room for a new client
+func (r *AuditResolver) evictLeastRecentClient() {
+	var (
+		oldest   string
+		lastSeen time.Time
+	)
+
+	for client, ring := range r.clients {
+		if oldest == "" || ring.lastSeen.Before(lastSeen) {
+			oldest = client
+			lastSeen = ring.lastSeen
+		}
+	}
+
+	delete(r.clients, oldest)
+}
+
+func (a *auditRing[T]) add(entry T, now time.Time, size int) {
+	a.lastSeen = now
+
+	if len(a.entries) < size {
+		a.entries = append(a.entries, entry)
+
+		return
+	}
+
+	a.entries[a.next] = entry
+	a.next = (a.next + 1) % size
+}
+
+// newestFirst returns a copy of the entries, the newest entry first
+func (a *auditRing[T]) newestFirst() []T {
+	result := make([]T, 0, len(a.entries))
+	result = append(result, a.entries[a.next:]...)
+	result = append(result, a.entries[:a.next]...)
+
+	slices.Reverse(result)
+
+	return result
+}
+
+// AuditLog implements `api.AuditLogProvider`
+func (r *AuditResolver) AuditLog(clientIP net.IP, domain string) ([]api.AuditEntry, error) {
+	if !r.cfg.RecordsResponses() {
+		return nil, api.ErrAuditDisabled
+	}
+
+	r.lock.Lock()
+
+	var entries []api.AuditEntry
+
+	if ring, ok := r.clients[clientIP.String()]; ok {
+		entries = ring.newestFirst()
+	}
+
+	r.lock.Unlock()
+
+	if domain == "" {
+		return entries, nil
+	}
+
+	questionHash := auditQuestionHash(domain)
+
+	return slices.DeleteFunc(entries, func(entry api.AuditEntry) bool {
+		return entry.QuestionHash != questionHash
+	}), nil
+}
+
+// ManipulationLog implements `api.AuditLogProvider`
+func (r *AuditResolver) ManipulationLog(clientIP net.IP) ([]api.ManipulationEntry, error) {
+	if !r.cfg.Manipulations.IsEnabled() {
+		return nil, api.ErrManipulationAuditDisabled
+	}
+
+	r.lock.Lock()
+	entries := r.manipulations.newestFirst()
+	r.lock.Unlock()
+
+	if clientIP == nil {
+		return entries, nil
+	}
+
+	client := clientIP.String()
+
+	return slices.DeleteFunc(entries, func(entry api.ManipulationEntry) bool {
+		return entry.Client != client
+	}), nil
+}
+
+// auditQuestionHash returns the SHA-256 hash of the domain in lower case without trailing dot
+func auditQuestionHash(domain string) string {
+	return auditHash(strings.ToLower(strings.TrimSuffix(domain, ".")))
+}
+
+// auditAnswerHash returns the SHA-256 hash of the sorted answer records, formatted as "<type> <data>" per line.
+// Names and TTLs are not part of the hash, so an answer can be compared with the records seen by the client.
+func auditAnswerHash(answer []dns.RR) string {
+	if len(answer) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(answer))
+
+	for _, rr := range answer {
+		hdr := rr.Header()
+		data := strings.TrimPrefix(rr.String(), hdr.String())
+
+		lines = append(lines, dns.Type(hdr.Rrtype).String()+" "+data)
+	}
+
+	slices.Sort(lines)
+
+	return auditHash(strings.Join(lines, "\n"))
+}
+
+func auditHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,281 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("AuditResolver", func() {
+	const (
+		// printf 'example.com' | sha256sum
+		exampleQuestionHash = "a379a6f6eeafb9a55e378c118034e2751e682fab9f2d30ab13d2125586ce1947"
+		// printf 'A 192.0.2.1' | sha256sum
+		exampleAnswerHash = "df6a599275d93e05dc0c1463789f5613ce089a656874c6605823bdc1c469168b"
+	)
+
+	var (
+		sut       *AuditResolver
+		sutConfig config.Audit
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Audit{
+			Enable:     true,
+			Entries:    2,
+			MaxClients: 2,
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewAuditResolver(sutConfig)
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(ctx context.Context, req *Request) (*Response, error) {
+			if req.Req.Question[0].Name == "blocked.com." {
+				recordManipulation(ctx, "blocking", manipulationBlocked, "BLOCKED (ads)")
+
+				return &Response{Res: new(dns.Msg), RType: ResponseTypeBLOCKED}, nil
+			}
+
+			answer, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "192.0.2.1")
+
+			return &Response{Res: answer, RType: ResponseTypeRESOLVED}, err
+		}
+		sut.Next(m)
+	})
+
+	query := func(domain, clientIP string) *Request {
+		request := newRequestWithClient(domain, A, clientIP)
+
+		_, err := sut.Resolve(ctx, request)
+		Expect(err).Should(Succeed())
+
+		return request
+	}
+
+	auditLog := func(clientIP, domain string) []api.AuditEntry {
+		entries, err := sut.AuditLog(net.ParseIP(clientIP), domain)
+		Expect(err).Should(Succeed())
+
+		return entries
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not record responses", func() {
+				query("example.com.", "192.168.178.2")
+
+				Expect(sut.clients).Should(BeEmpty())
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+
+			It("should return an error for the audit log", func() {
+				_, err := sut.AuditLog(net.ParseIP("192.168.178.2"), "")
+				Expect(err).Should(MatchError(api.ErrAuditDisabled))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("AuditLog", func() {
+		It("should record the hashes of question and answer", func() {
+			request := query("Example.com.", "192.168.178.2")
+
+			entries := auditLog("192.168.178.2", "")
+			Expect(entries).Should(HaveLen(1))
+			Expect(entries[0].Time).ShouldNot(BeZero())
+			Expect(entries[0]).Should(SatisfyAll(
+				HaveField("ID", request.Req.Id),
+				HaveField("Type", "A"),
+				HaveField("QuestionHash", exampleQuestionHash),
+				HaveField("AnswerHash", exampleAnswerHash),
+				HaveField("ReturnCode", "NOERROR"),
+				HaveField("ResponseType", "RESOLVED"),
+			))
+		})
+
+		It("should record an empty answer without hash", func() {
+			query("blocked.com.", "192.168.178.2")
+
+			Expect(auditLog("192.168.178.2", "")).Should(ConsistOf(SatisfyAll(
+				HaveField("AnswerHash", ""),
+				HaveField("ResponseType", "BLOCKED"),
+			)))
+		})
+
+		It("should keep the latest entries per client, newest first", func() {
+			query("first.com.", "192.168.178.2")
+			query("second.com.", "192.168.178.2")
+			query("third.com.", "192.168.178.2")
+			query("other.com.", "192.168.178.3")
+
+			Expect(auditLog("192.168.178.2", "")).Should(HaveExactElements(
+				HaveField("QuestionHash", auditQuestionHash("third.com")),
+				HaveField("QuestionHash", auditQuestionHash("second.com")),
+			))
+		})
+
+		It("should filter the entries by domain", func() {
+			query("example.com.", "192.168.178.2")
+			query("other.com.", "192.168.178.2")
+
+			Expect(auditLog("192.168.178.2", "example.com")).Should(ConsistOf(
+				HaveField("QuestionHash", exampleQuestionHash),
+			))
+		})
+
+		It("should return no entries for an unknown client", func() {
+			Expect(auditLog("192.168.178.9", "")).Should(BeEmpty())
+		})
+
+		It("should evict the least recent client", func() {
+			query("example.com.", "192.168.178.2")
+			query("example.com.", "192.168.178.3")
+			query("example.com.", "192.168.178.2")
+			query("example.com.", "192.168.178.4")
+
+			Expect(sut.clients).Should(HaveLen(2))
+			Expect(sut.clients).Should(HaveKey("192.168.178.2"))
+			Expect(sut.clients).Should(HaveKey("192.168.178.4"))
+		})
+	})
+
+	Describe("ManipulationLog", func() {
+		BeforeEach(func() {
+			sutConfig.Enable = false
+			sutConfig.Manipulations = config.AuditManipulations{Enable: true, Entries: 2}
+		})
+
+		manipulationLog := func(clientIP string) []api.ManipulationEntry {
+			entries, err := sut.ManipulationLog(net.ParseIP(clientIP))
+			Expect(err).Should(Succeed())
+
+			return entries
+		}
+
+		It("should record the changes of a response", func() {
+			query("blocked.com.", "192.168.178.2")
+
+			entries := manipulationLog("")
+			Expect(entries).Should(HaveLen(1))
+			Expect(entries[0].Time).ShouldNot(BeZero())
+			Expect(entries[0]).Should(SatisfyAll(
+				HaveField("Client", "192.168.178.2"),
+				HaveField("Question", "blocked.com"),
+				HaveField("Type", "A"),
+				HaveField("Changes", ConsistOf(api.ResponseChange{
+					Stage:  "blocking",
+					Action: manipulationBlocked,
+					Detail: "BLOCKED (ads)",
+				})),
+			))
+		})
+
+		It("should not record unchanged responses", func() {
+			query("example.com.", "192.168.178.2")
+
+			Expect(manipulationLog("")).Should(BeEmpty())
+		})
+
+		It("should not record the responses of the audit log", func() {
+			query("blocked.com.", "192.168.178.2")
+
+			Expect(sut.clients).Should(BeEmpty())
+
+			_, err := sut.AuditLog(net.ParseIP("192.168.178.2"), "")
+			Expect(err).Should(MatchError(api.ErrAuditDisabled))
+		})
+
+		It("should keep the latest entries, newest first", func() {
+			query("blocked.com.", "192.168.178.2")
+			query("blocked.com.", "192.168.178.3")
+			query("blocked.com.", "192.168.178.4")
+
+			Expect(manipulationLog("")).Should(HaveExactElements(
+				HaveField("Client", "192.168.178.4"),
+				HaveField("Client", "192.168.178.3"),
+			))
+		})
+
+		It("should filter the entries by client", func() {
+			query("blocked.com.", "192.168.178.2")
+			query("blocked.com.", "192.168.178.3")
+
+			Expect(manipulationLog("192.168.178.2")).Should(ConsistOf(
+				HaveField("Client", "192.168.178.2"),
+			))
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = true
+				sutConfig.Manipulations.Enable = false
+			})
+
+			It("should return an error", func() {
+				query("blocked.com.", "192.168.178.2")
+
+				_, err := sut.ManipulationLog(nil)
+				Expect(err).Should(MatchError(api.ErrManipulationAuditDisabled))
+			})
+		})
+	})
+
+	Describe("auditAnswerHash", func() {
+		It("should not depend on order, names and TTLs", func() {
+			a1, _ := util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+			a2, _ := util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.2")
+			b1, _ := util.NewMsgWithAnswer("other.com.", 10, A, "192.0.2.1")
+			b2, _ := util.NewMsgWithAnswer("other.com.", 10, A, "192.0.2.2")
+
+			Expect(auditAnswerHash([]dns.RR{a1.Answer[0], a2.Answer[0]})).
+				Should(Equal(auditAnswerHash([]dns.RR{b2.Answer[0], b1.Answer[0]})))
+		})
+	})
+})
@@ -0,0 +1,246 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+//nolint:gochecknoglobals
+var bailiwickDroppedRecords = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_bailiwick_dropped_records_total",
+		Help: "Number of upstream records outside of the bailiwick of the question",
+	}, []string{"section"},
+))
+
+// BailiwickResolver removes records, which don't belong to the question, from the upstream responses before they are
+// cached. So a misbehaving upstream can't poison the cache with records for other domains.
+type BailiwickResolver struct {
+	configurable[*config.Bailiwick]
+	NextResolver
+	typed
+}
+
+func NewBailiwickResolver(cfg config.Bailiwick) *BailiwickResolver {
+	return &BailiwickResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("bailiwick"),
+	}
+}
+
+// Resolve removes the out-of-bailiwick records from the response of the next resolver
+func (r *BailiwickResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	response, err := r.next.Resolve(ctx, request)
+	if err != nil || !r.IsEnabled() || response.RType != model.ResponseTypeRESOLVED || len(request.Req.Question) == 0 {
+		return response, err
+	}
+
+	question := request.Req.Question[0]
+	res := response.Res
+
+	var answerDropped, nsDropped, extraDropped int
+
+	names := answerChain(question, res.Answer)
+	res.Answer, answerDropped = filterRecords(res.Answer, func(rr dns.RR) bool {
+		return names.contains(rr)
+	})
+
+	zones := names.authorityZones(res.Ns)
+	res.Ns, nsDropped = filterRecords(res.Ns, func(rr dns.RR) bool {
+		return names.inAuthority(rr, zones)
+	})
+
+	targets := additionalTargets(res.Answer, res.Ns)
+	res.Extra, extraDropped = filterRecords(res.Extra, func(rr dns.RR) bool {
+		owner := strings.ToLower(rr.Header().Name)
+		_, isTarget := targets[owner]
+		_, inChain := names[owner]
+
+		return isTarget || inChain || rr.Header().Rrtype == dns.TypeOPT
+	})
+
+	if dropped := answerDropped + nsDropped + extraDropped; dropped > 0 {
+		_, logger := r.logWithFields(ctx, logrus.Fields{
+			"question": util.QuestionToString(request.Req.Question),
+			"answer":   answerDropped,
+			"ns":       nsDropped,
+			"extra":    extraDropped,
+		})
+
+		logger.Debug("removed out-of-bailiwick records")
+
+		bailiwickDroppedRecords.WithLabelValues("answer").Add(float64(answerDropped))
+		bailiwickDroppedRecords.WithLabelValues("authority").Add(float64(nsDropped))
+		bailiwickDroppedRecords.WithLabelValues("additional").Add(float64(extraDropped))
+	}
+
+	return response, err
+}
+
+// chainNames contains the lower case question name and the targets of the CNAME and DNAME records answering it
+type chainNames map[string]struct{}
+
+// answerChain returns the names of the CNAME/DNAME chain starting at the question name.
+// The answer records can be in any order.
+func answerChain(question dns.Question, answer []dns.RR) chainNames {
+	names := chainNames{strings.ToLower(question.Name): {}}
+
+	for changed := true; changed; {
+		changed = false
+
+		var targets []string
+
+		for _, rr := range answer {
+			switch v := rr.(type) {
+			case *dns.CNAME:
+				if _, ok := names[strings.ToLower(v.Hdr.Name)]; ok {
+					targets = append(targets, strings.ToLower(v.Target))
+				}
+			case *dns.DNAME:
+				for name := range names {
+					if target, ok := dnameTarget(name, v); ok {
+						targets = append(targets, target)
+					}
+				}
+			}
+		}
+
+		for _, target := range targets {
+			if _, ok := names[target]; !ok {
+				names[target] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	return names
+}
+
+// dnameTarget returns the name, which the DNAME record substitutes for the name
+func dnameTarget(name string, dname *dns.DNAME) (string, bool) {
+	owner := strings.ToLower(dname.Hdr.Name)
+
+	if name == owner || !dns.IsSubDomain(owner, name) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(name, owner) + strings.ToLower(dname.Target), true
+}
+
+// contains returns true, if the answer record belongs to a name of the chain
+func (n chainNames) contains(rr dns.RR) bool {
+	owner := strings.ToLower(rr.Header().Name)
+
+	if _, ok := n[owner]; ok {
+		return true
+	}
+
+	// a DNAME record (and its signature) belongs to an ancestor of the name
+	if rr.Header().Rrtype == dns.TypeDNAME || rr.Header().Rrtype == dns.TypeRRSIG {
+		return n.hasSubDomainOf(owner)
+	}
+
+	return false
+}
+
+// authorityZones returns the lower case owners of the SOA and NS records of zones containing a name of the chain
+func (n chainNames) authorityZones(authority []dns.RR) []string {
+	var zones []string
+
+	for _, rr := range authority {
+		owner := strings.ToLower(rr.Header().Name)
+
+		if rrType := rr.Header().Rrtype; (rrType == dns.TypeSOA || rrType == dns.TypeNS) && n.hasSubDomainOf(owner) {
+			zones = append(zones, owner)
+		}
+	}
+
+	return zones
+}
+
+// inAuthority returns true, if the authority record belongs to a name of the chain, one of its parents or a zone
+// containing a name of the chain
+func (n chainNames) inAuthority(rr dns.RR, zones []string) bool {
+	owner := strings.ToLower(rr.Header().Name)
+
+	if n.hasSubDomainOf(owner) {
+		return true
+	}
+
+	// e.g. NSEC records for names near the question name in the same zone
+	for _, zone := range zones {
+		if dns.IsSubDomain(zone, owner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasSubDomainOf returns true, if a name of the chain is the parent or a subdomain of the parent
+func (n chainNames) hasSubDomainOf(parent string) bool {
+	for name := range n {
+		if dns.IsSubDomain(parent, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// additionalTargets returns the lower case names, which are referenced by the records, e.g. the name servers
+func additionalTargets(sections ...[]dns.RR) map[string]struct{} {
+	result := make(map[string]struct{})
+
+	for _, section := range sections {
+		for _, rr := range section {
+			var target string
+
+			switch v := rr.(type) {
+			case *dns.NS:
+				target = v.Ns
+			case *dns.MX:
+				target = v.Mx
+			case *dns.SRV:
+				target = v.Target
+			case *dns.CNAME:
+				target = v.Target
+			case *dns.SVCB:
+				target = v.Target
+			case *dns.HTTPS:
+				target = v.Target
+			default:
+				continue
+			}
+
+			result[strings.ToLower(target)] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+// filterRecords returns the records, which are accepted, and the count of removed records
+func filterRecords(records []dns.RR, accept func(dns.RR) bool) ([]dns.RR, int) {
+	if len(records) == 0 {
+		return records, 0
+	}
+
+	result := records[:0]
+
+	for _, rr := range records {
+		if accept(rr) {
+			result = append(result, rr)
+		}
+	}
+
+	return result, len(records) - len(result)
+}
@@ -0,0 +1,189 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("BailiwickResolver", func() {
+	var (
+		sut       *BailiwickResolver
+		sutConfig config.Bailiwick
+		m         *mockResolver
+		upstream  *dns.Msg
+		rType     ResponseType
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	rrs := func(records ...string) []dns.RR {
+		result := make([]dns.RR, 0, len(records))
+
+		for _, record := range records {
+			rr, err := dns.NewRR(record)
+			Expect(err).Should(Succeed())
+
+			result = append(result, rr)
+		}
+
+		return result
+	}
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Bailiwick{Enable: true}
+		rType = ResponseTypeRESOLVED
+
+		upstream = new(dns.Msg)
+		upstream.Answer = rrs(
+			"www.example.com. 300 IN CNAME cdn.example.net.",
+			"cdn.example.net. 300 IN A 192.0.2.1",
+			"mybank.com. 300 IN A 203.0.113.66",
+		)
+		upstream.Ns = rrs(
+			"example.net. 300 IN NS ns1.example.net.",
+			"mybank.com. 300 IN NS ns.evil.org.",
+		)
+		upstream.Extra = rrs(
+			"ns1.example.net. 300 IN A 192.0.2.53",
+			"ns.evil.org. 300 IN A 203.0.113.53",
+			"www.mybank.com. 300 IN A 203.0.113.66",
+		)
+		upstream.SetEdns0(4096, false)
+	})
+
+	JustBeforeEach(func() {
+		sut = NewBailiwickResolver(sutConfig)
+		m = &mockResolver{ResolveFn: func(context.Context, *Request) (*Response, error) {
+			return &Response{Res: upstream, RType: rType}, nil
+		}}
+		m.On("Resolve", mock.Anything)
+		sut.Next(m)
+	})
+
+	resolve := func(question string, qType dns.Type) *dns.Msg {
+		resp, err := sut.Resolve(ctx, newRequest(question, qType))
+		Expect(err).Should(Succeed())
+
+		return resp.Res
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should keep all records", func() {
+				res := resolve("www.example.com.", A)
+
+				Expect(res.Answer).Should(HaveLen(3))
+				Expect(res.Ns).Should(HaveLen(2))
+				Expect(res.Extra).Should(HaveLen(4))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("should remove records for other domains", func() {
+		res := resolve("www.example.com.", A)
+
+		Expect(res.Answer).Should(Equal(rrs(
+			"www.example.com. 300 IN CNAME cdn.example.net.",
+			"cdn.example.net. 300 IN A 192.0.2.1",
+		)))
+		Expect(res.Ns).Should(Equal(rrs("example.net. 300 IN NS ns1.example.net.")))
+		Expect(res.Extra).Should(HaveLen(2))
+		Expect(res.Extra[0]).Should(Equal(rrs("ns1.example.net. 300 IN A 192.0.2.53")[0]))
+		Expect(res.Extra[1].Header().Rrtype).Should(Equal(dns.TypeOPT))
+	})
+
+	It("should follow CNAME chains in any order", func() {
+		upstream.Answer = rrs(
+			"b.example.org. 300 IN A 192.0.2.2",
+			"a.example.org. 300 IN CNAME b.example.org.",
+			"WWW.example.com. 300 IN CNAME A.example.org.",
+		)
+
+		Expect(resolve("www.example.com.", A).Answer).Should(HaveLen(3))
+	})
+
+	It("should follow DNAME records", func() {
+		upstream.Answer = rrs(
+			"example.com. 300 IN DNAME example.org.",
+			"www.example.com. 300 IN CNAME www.example.org.",
+			"www.example.org. 300 IN A 192.0.2.3",
+			"mail.example.org. 300 IN A 192.0.2.4",
+		)
+
+		Expect(resolve("www.example.com.", A).Answer).Should(Equal(rrs(
+			"example.com. 300 IN DNAME example.org.",
+			"www.example.com. 300 IN CNAME www.example.org.",
+			"www.example.org. 300 IN A 192.0.2.3",
+		)))
+	})
+
+	It("should keep the authority of negative responses", func() {
+		upstream.Answer = nil
+		upstream.Ns = rrs(
+			"example.com. 300 IN SOA ns.example.com. hostmaster.example.com. 1 7200 3600 1209600 300",
+			"a.example.com. 300 IN NSEC z.example.com. A RRSIG NSEC",
+			"org. 300 IN SOA a0.org.afilias-nst.info. noc.afilias-nst.info. 1 1800 900 604800 86400",
+		)
+		upstream.Extra = nil
+
+		Expect(resolve("nothing.example.com.", A).Ns).Should(HaveLen(2))
+	})
+
+	It("should not change responses without foreign records", func() {
+		upstream.Answer = rrs("www.example.com. 300 IN A 192.0.2.1")
+		upstream.Ns = nil
+		upstream.Extra = nil
+
+		Expect(resolve("www.example.com.", A).Answer).Should(Equal(rrs("www.example.com. 300 IN A 192.0.2.1")))
+	})
+
+	When("the response is not from an upstream", func() {
+		BeforeEach(func() {
+			rType = ResponseTypeSPECIAL
+		})
+
+		It("should keep all records", func() {
+			Expect(resolve("www.example.com.", A).Answer).Should(HaveLen(3))
+		})
+	})
+})
@@ -2,8 +2,11 @@ package resolver
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"slices"
 	"sort"
 	"strings"
@@ -23,25 +26,38 @@ import (
 	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/lists"
 	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/redis"
 	"github.com/0xERR0R/blocky/util"
 
 	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
-const defaultBlockingCleanUpInterval = 5 * time.Second
+//nolint:gochecknoglobals
+var wouldBlockCount = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_would_block_total",
+		Help: "Number of queries, which would be blocked by list groups in log-only mode",
+	}, []string{"group"},
+))
+
+const (
+	defaultBlockingCleanUpInterval = 5 * time.Second
+	blockingStateName              = "blocking"
+)
 
 func createBlockHandler(cfg config.Blocking) (blockHandler, error) {
 	cfgBlockType := cfg.BlockType
 
+	blockTime := cfg.BlockTTL.SecondsU32()
+
 	if strings.EqualFold(cfgBlockType, "NXDOMAIN") {
-		return nxDomainBlockHandler{}, nil
+		return nxDomainBlockHandler{BlockTimeSec: blockTime}, nil
 	}
 
-	blockTime := cfg.BlockTTL.SecondsU32()
-
 	if strings.EqualFold(cfgBlockType, "ZEROIP") {
 		return zeroIPBlockHandler{
 			BlockTimeSec: blockTime,
@@ -71,6 +87,15 @@ func createBlockHandler(cfg config.Blocking) (blockHandler, error) {
 			cfgBlockType)
 }
 
+// blockingState is the persisted runtime state, so a restart neither enables nor permanently disables the blocking
+type blockingState struct {
+	Enabled bool `json:"enabled"`
+	// groups passed to DisableBlocking, empty for all groups
+	DisabledGroups []string `json:"disabledGroups,omitempty"`
+	// zero if the blocking is disabled until it is enabled again
+	AutoEnableAt time.Time `json:"autoEnableAt,omitzero"`
+}
+
 type status struct {
 	// true: blocking of all groups is enabled
 	// false: blocking is disabled. Either all groups or only particular
@@ -93,8 +118,18 @@ type BlockingResolver struct {
 	allowlistOnlyGroups map[string]bool
 	status              *status
 	clientGroupsBlock   map[string][]string
+	bus                 SyncBus
 	redisClient         *redis.Client
 	fqdnIPCache         cache.ExpiringCache[[]net.IP]
+	responseCache       cache.ExpiringCache[blockedResponse]
+}
+
+// blockedResponse is a cached response to a blocked question
+type blockedResponse struct {
+	rcode  int
+	answer []dns.RR
+	ns     []dns.RR
+	reason model.Reason
 }
 
 func clientGroupsBlock(cfg config.Blocking) map[string][]string {
@@ -114,10 +149,10 @@ func clientGroupsBlock(cfg config.Blocking) map[string][]string {
 	return cgb
 }
 
-// NewBlockingResolver returns a new configured instance of the resolver
+// NewBlockingResolver returns a new configured instance of the resolver, the bus is optional
 func NewBlockingResolver(ctx context.Context,
 	cfg config.Blocking,
-	redis *redis.Client,
+	bus SyncBus,
 	bootstrap *Bootstrap,
 ) (r *BlockingResolver, err error) {
 	blockHandler, err := createBlockHandler(cfg)
@@ -138,6 +173,9 @@ func NewBlockingResolver(ctx context.Context,
 		return nil, err
 	}
 
+	// the state is stored in redis, if it is the bus
+	redisClient, _ := bus.(*redis.Client)
+
 	res := &BlockingResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("blocking"),
@@ -151,7 +189,8 @@ func NewBlockingResolver(ctx context.Context,
 			enableTimer: time.NewTimer(0),
 		},
 		clientGroupsBlock: clientGroupsBlock(cfg),
-		redisClient:       redis,
+		bus:               bus,
+		redisClient:       redisClient,
 	}
 
 	res.fqdnIPCache = expirationcache.NewCacheWithOnExpired[[]net.IP](ctx, expirationcache.Options{
@@ -160,8 +199,16 @@ func NewBlockingResolver(ctx context.Context,
 		return res.queryForFQIdentifierIPs(ctx, key)
 	})
 
-	if res.redisClient != nil {
-		go res.redisSubscriber(ctx)
+	if cfg.ResponseCacheSize > 0 {
+		res.initResponseCache(ctx, cfg.ResponseCacheSize)
+	}
+
+	if err := res.restoreState(ctx); err != nil {
+		return nil, err
+	}
+
+	if res.bus != nil {
+		go res.syncSubscriber(ctx)
 	}
 
 	err = evt.Bus().SubscribeOnce(evt.ApplicationStarted, func(_ ...string) {
@@ -174,14 +221,14 @@ func NewBlockingResolver(ctx context.Context,
 	return res, nil
 }
 
-func (r *BlockingResolver) redisSubscriber(ctx context.Context) {
+func (r *BlockingResolver) syncSubscriber(ctx context.Context) {
 	ctx, logger := r.log(ctx)
 
 	for {
 		select {
-		case em := <-r.redisClient.EnabledChannel:
+		case em := <-r.bus.EnabledMessages():
 			if em != nil {
-				logger.Debug("Received state from redis: ", em)
+				logger.Debug("Received state from other instance: ", em)
 
 				if em.State {
 					r.internalEnableBlocking()
@@ -199,12 +246,25 @@ func (r *BlockingResolver) redisSubscriber(ctx context.Context) {
 	}
 }
 
-// RefreshLists triggers the refresh of all allow/denylists in the cache
-func (r *BlockingResolver) RefreshLists() error {
+// RefreshLists triggers the refresh of the allow/denylists of the passed groups or of all groups, if empty
+func (r *BlockingResolver) RefreshLists(groups []string) error {
 	var err *multierror.Error
 
-	err = multierror.Append(err, r.denylistMatcher.Refresh())
-	err = multierror.Append(err, r.allowlistMatcher.Refresh())
+	if len(groups) == 0 {
+		err = multierror.Append(err, r.denylistMatcher.Refresh())
+		err = multierror.Append(err, r.allowlistMatcher.Refresh())
+
+		return err.ErrorOrNil()
+	}
+
+	for _, g := range groups {
+		if !r.denylistMatcher.HasGroup(g) && !r.allowlistMatcher.HasGroup(g) {
+			return fmt.Errorf("%w '%s'", api.ErrUnknownListGroup, g)
+		}
+	}
+
+	err = multierror.Append(err, r.denylistMatcher.RefreshGroups(groups))
+	err = multierror.Append(err, r.allowlistMatcher.RefreshGroups(groups))
 
 	return err.ErrorOrNil()
 }
@@ -212,18 +272,20 @@ func (r *BlockingResolver) RefreshLists() error {
 func (r *BlockingResolver) retrieveAllBlockingGroups() []string {
 	result := maps.Keys(r.cfg.Denylists)
 
+	result = append(result, maps.Keys(r.cfg.Categories)...)
 	result = append(result, "default")
 	slices.Sort(result)
 
-	return result
+	return slices.Compact(result)
 }
 
 // EnableBlocking enables the blocking against the denylists
 func (r *BlockingResolver) EnableBlocking(ctx context.Context) {
 	r.internalEnableBlocking()
+	r.saveState(ctx, blockingState{Enabled: true})
 
-	if r.redisClient != nil {
-		r.redisClient.PublishEnabled(ctx, &redis.EnabledMessage{State: true})
+	if r.bus != nil {
+		r.bus.PublishEnabled(ctx, &redis.EnabledMessage{State: true})
 	}
 }
 
@@ -241,15 +303,26 @@ func (r *BlockingResolver) internalEnableBlocking() {
 // DisableBlocking deactivates the blocking for a particular duration (or forever if 0).
 func (r *BlockingResolver) DisableBlocking(ctx context.Context, duration time.Duration, disableGroups []string) error {
 	err := r.internalDisableBlocking(ctx, duration, disableGroups)
-	if err == nil && r.redisClient != nil {
-		r.redisClient.PublishEnabled(ctx, &redis.EnabledMessage{
+	if err != nil {
+		return err
+	}
+
+	state := blockingState{DisabledGroups: disableGroups}
+	if duration > 0 {
+		state.AutoEnableAt = time.Now().Add(duration)
+	}
+
+	r.saveState(ctx, state)
+
+	if r.bus != nil {
+		r.bus.PublishEnabled(ctx, &redis.EnabledMessage{
 			State:    false,
 			Duration: duration,
 			Groups:   disableGroups,
 		})
 	}
 
-	return err
+	return nil
 }
 
 func (r *BlockingResolver) internalDisableBlocking(ctx context.Context, duration time.Duration,
@@ -295,6 +368,91 @@ func (r *BlockingResolver) internalDisableBlocking(ctx context.Context, duration
 	return nil
 }
 
+// saveState persists the state in redis or in the state file. Errors are only logged, the state change itself succeeded.
+func (r *BlockingResolver) saveState(ctx context.Context, state blockingState) {
+	ctx, logger := r.log(ctx)
+
+	content, err := json.Marshal(state)
+	if err == nil {
+		switch {
+		case r.redisClient != nil:
+			err = r.redisClient.StoreState(ctx, blockingStateName, content)
+		case r.cfg.StateFile != "":
+			err = writeFileAtomic(r.cfg.StateFile, content)
+		default:
+			return
+		}
+	}
+
+	if err != nil {
+		logger.Warn("can't save blocking state: ", err)
+	}
+}
+
+// loadState returns the persisted state or nil, if there is none
+func (r *BlockingResolver) loadState(ctx context.Context) (*blockingState, error) {
+	var (
+		content []byte
+		err     error
+	)
+
+	switch {
+	case r.redisClient != nil:
+		content, err = r.redisClient.LoadState(ctx, blockingStateName)
+	case r.cfg.StateFile != "":
+		content, err = os.ReadFile(r.cfg.StateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil //nolint:nilnil
+		}
+	}
+
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	var state blockingState
+
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("invalid blocking state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// restoreState disables the blocking again, if it was disabled before the restart and the auto enable time didn't pass
+func (r *BlockingResolver) restoreState(ctx context.Context) error {
+	state, err := r.loadState(ctx)
+	if err != nil {
+		return fmt.Errorf("can't restore blocking state: %w", err)
+	}
+
+	if state == nil || state.Enabled {
+		return nil
+	}
+
+	ctx, logger := r.log(ctx)
+
+	var duration time.Duration
+
+	if !state.AutoEnableAt.IsZero() {
+		duration = time.Until(state.AutoEnableAt)
+
+		if duration <= 0 {
+			logger.Infof("blocking was disabled until %s, keeping it enabled", state.AutoEnableAt.Format(time.RFC3339))
+			r.saveState(ctx, blockingState{Enabled: true})
+
+			return nil
+		}
+	}
+
+	// e.g. a disabled group was removed from the configuration
+	if err := r.internalDisableBlocking(ctx, duration, state.DisabledGroups); err != nil {
+		logger.Warn("can't restore disabled blocking: ", err)
+	}
+
+	return nil
+}
+
 // BlockingStatus returns the current blocking status
 func (r *BlockingResolver) BlockingStatus() api.BlockingStatus {
 	var autoEnableDuration time.Duration
@@ -313,13 +471,78 @@ func (r *BlockingResolver) BlockingStatus() api.BlockingStatus {
 	}
 }
 
+// TakeOver continues with the runtime state of the resolver, which is replaced by this one after a configuration
+// reload: the blocking status is kept and the timer of the previous resolver is stopped.
+func (r *BlockingResolver) TakeOver(ctx context.Context, prev *BlockingResolver) {
+	ctx, logger := r.log(ctx)
+
+	prev.status.lock.Lock()
+	timed := prev.status.enableTimer.Stop()
+	enabled := prev.status.enabled
+	disabledGroups := slices.Clone(prev.status.disabledGroups)
+	disableEnd := prev.status.disableEnd
+	allGroups := slices.Equal(disabledGroups, prev.retrieveAllBlockingGroups())
+	prev.status.lock.Unlock()
+
+	go r.initFQDNIPCache(ctx)
+
+	if enabled {
+		if !r.BlockingStatus().Enabled {
+			r.internalEnableBlocking()
+		}
+
+		return
+	}
+
+	if allGroups {
+		disabledGroups = nil
+	} else {
+		knownGroups := r.retrieveAllBlockingGroups()
+
+		disabledGroups = slices.DeleteFunc(disabledGroups, func(group string) bool {
+			if _, found := slices.BinarySearch(knownGroups, group); !found {
+				logger.Warnf("disabled group '%s' was removed from the configuration", group)
+
+				return true
+			}
+
+			return false
+		})
+
+		if len(disabledGroups) == 0 {
+			r.EnableBlocking(ctx)
+
+			return
+		}
+	}
+
+	var duration time.Duration
+
+	if timed {
+		duration = time.Until(disableEnd)
+
+		if duration <= 0 {
+			r.EnableBlocking(ctx)
+
+			return
+		}
+	}
+
+	if err := r.internalDisableBlocking(ctx, duration, disabledGroups); err != nil {
+		logger.Warn("can't keep the blocking status: ", err)
+	}
+}
+
 // returns groups, which have only allowlist entries
 func determineAllowlistOnlyGroups(cfg *config.Blocking) (result map[string]bool) {
 	result = make(map[string]bool, len(cfg.Allowlists))
 
 	for g, links := range cfg.Allowlists {
 		if len(links) > 0 {
-			if _, found := cfg.Denylists[g]; !found {
+			_, hasDenylists := cfg.Denylists[g]
+			_, hasCategories := cfg.Categories[g]
+
+			if !hasDenylists && !hasCategories {
 				result[g] = true
 			}
 		}
@@ -329,8 +552,8 @@ func determineAllowlistOnlyGroups(cfg *config.Blocking) (result map[string]bool)
 }
 
 // sets answer and/or return code for DNS response, if request should be blocked
-func (r *BlockingResolver) handleBlocked(logger *logrus.Entry,
-	request *model.Request, question dns.Question, reason string,
+func (r *BlockingResolver) handleBlocked(ctx context.Context, logger *logrus.Entry,
+	request *model.Request, question dns.Question, reason model.Reason,
 ) (*model.Response, error) {
 	response := new(dns.Msg)
 	response.SetReply(request.Req)
@@ -339,9 +562,101 @@ func (r *BlockingResolver) handleBlocked(logger *logrus.Entry,
 
 	logger.Debugf("blocking request '%s'", reason)
 
+	recordManipulation(ctx, r.Type(), manipulationBlocked, reason.String())
+
 	return &model.Response{Res: response, RType: model.ResponseTypeBLOCKED, Reason: reason}, nil
 }
 
+// initResponseCache creates the cache of the blocked responses, which is cleared if a list group changes
+func (r *BlockingResolver) initResponseCache(ctx context.Context, size uint) {
+	r.responseCache = expirationcache.NewCache[blockedResponse](ctx, expirationcache.Options{
+		CleanupInterval: defaultBlockingCleanUpInterval,
+		MaxSize:         size,
+	})
+
+	clearCache := func(_ ...any) {
+		r.responseCache.Clear()
+	}
+
+	if err := evt.Bus().Subscribe(evt.BlockingCacheGroupChanged, clearCache); err != nil {
+		_, logger := r.log(ctx)
+		logger.Errorf("can't subscribe to %s: %v", evt.BlockingCacheGroupChanged, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		_ = evt.Bus().Unsubscribe(evt.BlockingCacheGroupChanged, clearCache)
+	}()
+}
+
+// responseCacheKey returns the key of the blocked response of a request with one question for the groups, or an
+// empty key if the response can't be cached
+func (r *BlockingResolver) responseCacheKey(groupsToCheck []string, request *model.Request) string {
+	if r.responseCache == nil || len(request.Req.Question) != 1 {
+		return ""
+	}
+
+	question := request.Req.Question[0]
+
+	return fmt.Sprintf("%s|%s|%d|%s",
+		strings.Join(groupsToCheck, ","), dns.Type(question.Qtype), question.Qclass, question.Name)
+}
+
+// cacheBlocked caches the blocked response for the block TTL
+func (r *BlockingResolver) cacheBlocked(key string, response *model.Response) {
+	if key == "" {
+		return
+	}
+
+	r.responseCache.Put(key, &blockedResponse{
+		rcode:  response.Res.Rcode,
+		answer: copyRRs(response.Res.Answer),
+		ns:     copyRRs(response.Res.Ns),
+		reason: response.Reason,
+	}, r.cfg.BlockTTL.ToDuration())
+}
+
+// handleCachedBlocked returns the cached blocked response or nil, if the question isn't cached
+func (r *BlockingResolver) handleCachedBlocked(ctx context.Context, logger *logrus.Entry,
+	request *model.Request, key string,
+) *model.Response {
+	if key == "" {
+		return nil
+	}
+
+	cached, _ := r.responseCache.Get(key)
+	if cached == nil {
+		return nil
+	}
+
+	response := new(dns.Msg)
+	response.SetReply(request.Req)
+	response.Rcode = cached.rcode
+	response.Answer = copyRRs(cached.answer)
+	response.Ns = copyRRs(cached.ns)
+
+	logger.Debugf("blocking request '%s' (cached)", cached.reason)
+
+	recordManipulation(ctx, r.Type(), manipulationBlocked, cached.reason.String())
+
+	return &model.Response{Res: response, RType: model.ResponseTypeBLOCKED, Reason: cached.reason}
+}
+
+func copyRRs(rrs []dns.RR) []dns.RR {
+	if len(rrs) == 0 {
+		return nil
+	}
+
+	result := make([]dns.RR, 0, len(rrs))
+
+	for _, rr := range rrs {
+		result = append(result, dns.Copy(rr))
+	}
+
+	return result
+}
+
 // LogConfig implements `config.Configurable`.
 func (r *BlockingResolver) LogConfig(logger *logrus.Entry) {
 	r.cfg.LogConfig(logger)
@@ -363,11 +678,42 @@ func (r *BlockingResolver) hasAllowlistOnlyAllowed(groupsToCheck []string) bool
 	return false
 }
 
+// splitLogOnly splits the groups into the enforced groups and the groups in log-only mode
+func (r *BlockingResolver) splitLogOnly(groups []string) (enforced, logOnly []string) {
+	for _, group := range groups {
+		if r.cfg.IsLogOnly(group) {
+			logOnly = append(logOnly, group)
+		} else {
+			enforced = append(enforced, group)
+		}
+	}
+
+	return enforced, logOnly
+}
+
+// wouldBlock is a blocking decision of groups in log-only mode
+type wouldBlock struct {
+	reason model.Reason
+	groups []string
+}
+
 func (r *BlockingResolver) handleDenylist(ctx context.Context, groupsToCheck []string,
 	request *model.Request, logger *logrus.Entry,
-) (bool, *model.Response, error) {
+) (bool, *model.Response, *wouldBlock, error) {
 	logger.WithField("groupsToCheck", strings.Join(groupsToCheck, "; ")).Debug("checking groups for request")
-	allowlistOnlyAllowed := r.hasAllowlistOnlyAllowed(groupsToCheck)
+
+	cacheKey := r.responseCacheKey(groupsToCheck, request)
+	if resp := r.handleCachedBlocked(ctx, logger, request, cacheKey); resp != nil {
+		return true, resp, nil, nil
+	}
+
+	enforcedGroups, logOnlyGroups := r.splitLogOnly(groupsToCheck)
+	allowlistOnlyAllowed := r.hasAllowlistOnlyAllowed(enforcedGroups)
+	allowlistOnlyLogged := r.hasAllowlistOnlyAllowed(logOnlyGroups)
+
+	var wb *wouldBlock
+
+	categoryGroups := r.categoryGroups(groupsToCheck, request.Categories)
 
 	for _, question := range request.Req.Question {
 		domain := util.ExtractDomain(question)
@@ -378,23 +724,71 @@ func (r *BlockingResolver) handleDenylist(ctx context.Context, groupsToCheck []s
 
 			resp, err := r.next.Resolve(ctx, request)
 
-			return true, resp, err
+			return true, resp, nil, err
 		}
 
 		if allowlistOnlyAllowed {
-			resp, err := r.handleBlocked(logger, request, question, "BLOCKED (ALLOWLIST ONLY)")
+			resp, err := r.handleBlocked(ctx, logger, request, question,
+				model.NewReason(model.ReasonCodeBLOCKEDALLOWLISTONLY))
+			r.cacheBlocked(cacheKey, resp)
 
-			return true, resp, err
+			return true, resp, nil, err
 		}
 
-		if groups := r.matches(groupsToCheck, r.denylistMatcher, domain); len(groups) > 0 {
-			resp, err := r.handleBlocked(logger, request, question, fmt.Sprintf("BLOCKED (%s)", strings.Join(groups, ",")))
+		groups := r.matches(groupsToCheck, r.denylistMatcher, domain)
+		groups = append(groups, categoryGroups...)
+		slices.Sort(groups)
+
+		enforced, logOnly := r.splitLogOnly(slices.Compact(groups))
+
+		if len(enforced) > 0 {
+			resp, err := r.handleBlocked(ctx, logger, request, question,
+				model.NewReason(model.ReasonCodeBLOCKED, enforced...))
+			r.cacheBlocked(cacheKey, resp)
+
+			return true, resp, nil, err
+		}
 
-			return true, resp, err
+		switch {
+		case wb != nil:
+		case allowlistOnlyLogged:
+			wb = &wouldBlock{
+				reason: model.NewReason(model.ReasonCodeWOULDBLOCKALLOWLISTONLY),
+				groups: r.allowlistOnlyOf(logOnlyGroups),
+			}
+		case len(logOnly) > 0:
+			wb = &wouldBlock{reason: model.NewReason(model.ReasonCodeWOULDBLOCK, logOnly...), groups: logOnly}
 		}
 	}
 
-	return false, nil, nil
+	return false, nil, wb, nil
+}
+
+// categoryGroups returns the groups, which block a category of the queried domain
+func (r *BlockingResolver) categoryGroups(groupsToCheck, categories []string) (groups []string) {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	for _, group := range groupsToCheck {
+		if slices.ContainsFunc(r.cfg.Categories[group], func(category string) bool {
+			return slices.Contains(categories, strings.ToLower(category))
+		}) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+func (r *BlockingResolver) allowlistOnlyOf(groups []string) (result []string) {
+	for _, group := range groups {
+		if _, found := r.allowlistOnlyGroups[group]; found {
+			result = append(result, group)
+		}
+	}
+
+	return result
 }
 
 // Resolve checks the query against the denylist and delegates to next resolver if domain is not blocked
@@ -402,48 +796,108 @@ func (r *BlockingResolver) Resolve(ctx context.Context, request *model.Request)
 	ctx, logger := r.log(ctx)
 	groupsToCheck := r.groupsToCheckForClient(request)
 
+	var wb *wouldBlock
+
 	if len(groupsToCheck) > 0 {
-		handled, resp, err := r.handleDenylist(ctx, groupsToCheck, request, logger)
+		handled, resp, denylistWB, err := r.handleDenylist(ctx, groupsToCheck, request, logger)
 		if handled {
 			return resp, err
 		}
+
+		wb = denylistWB
 	}
 
 	respFromNext, err := r.next.Resolve(ctx, request)
 
 	if err == nil && len(groupsToCheck) > 0 && respFromNext.Res != nil {
 		for _, rr := range respFromNext.Res.Answer {
-			entryToCheck, tName := extractEntryToCheckFromResponse(rr)
-			if len(entryToCheck) > 0 {
+			entriesToCheck, tName := extractEntriesToCheckFromResponse(rr)
+			for _, entryToCheck := range entriesToCheck {
 				logger := logger.WithField("response_entry", entryToCheck)
 
 				if groups := r.matches(groupsToCheck, r.allowlistMatcher, entryToCheck); len(groups) > 0 {
 					logger.WithField("groups", groups).Debugf("%s is allowlisted", tName)
-				} else if groups := r.matches(groupsToCheck, r.denylistMatcher, entryToCheck); len(groups) > 0 {
-					return r.handleBlocked(logger, request, request.Req.Question[0], fmt.Sprintf("BLOCKED %s (%s)", tName,
-						strings.Join(groups, ",")))
+
+					continue
+				}
+
+				enforced, logOnly := r.splitLogOnly(r.matches(groupsToCheck, r.denylistMatcher, entryToCheck))
+
+				if len(enforced) > 0 {
+					return r.handleBlocked(ctx, logger, request, request.Req.Question[0],
+						model.Reason{Code: model.ReasonCodeBLOCKED, Subject: tName, Params: enforced})
+				}
+
+				if len(logOnly) > 0 && wb == nil {
+					wb = &wouldBlock{
+						reason: model.Reason{Code: model.ReasonCodeWOULDBLOCK, Subject: tName, Params: logOnly},
+						groups: logOnly,
+					}
 				}
 			}
 		}
 	}
 
-	return respFromNext, err
+	if err != nil || wb == nil {
+		return respFromNext, err
+	}
+
+	return r.handleWouldBlock(logger, request, respFromNext, wb), nil
+}
+
+// handleWouldBlock records the blocking decision of log-only groups and returns the real answer
+func (r *BlockingResolver) handleWouldBlock(logger *logrus.Entry,
+	request *model.Request, response *model.Response, wb *wouldBlock,
+) *model.Response {
+	logger.WithFields(logrus.Fields{
+		"question": util.QuestionToString(request.Req.Question),
+		"groups":   wb.groups,
+	}).Infof("would block request '%s'", wb.reason)
+
+	for _, group := range wb.groups {
+		wouldBlockCount.WithLabelValues(group).Inc()
+	}
+
+	return &model.Response{Res: response.Res, RType: response.RType, Reason: wb.reason}
 }
 
-func extractEntryToCheckFromResponse(rr dns.RR) (entryToCheck, tName string) {
+func extractEntriesToCheckFromResponse(rr dns.RR) (entriesToCheck []string, tName string) {
 	switch v := rr.(type) {
 	case *dns.A:
-		entryToCheck = v.A.String()
-		tName = "IP"
+		return []string{v.A.String()}, "IP"
 	case *dns.AAAA:
-		entryToCheck = strings.ToLower(v.AAAA.String())
-		tName = "IP"
+		return []string{strings.ToLower(v.AAAA.String())}, "IP"
 	case *dns.CNAME:
-		entryToCheck = util.ExtractDomainOnly(v.Target)
-		tName = "CNAME"
+		return []string{util.ExtractDomainOnly(v.Target)}, "CNAME"
+	case *dns.SVCB:
+		return extractSVCBEntries(v), "SVCB"
+	case *dns.HTTPS:
+		return extractSVCBEntries(&v.SVCB), "HTTPS"
 	}
 
-	return
+	return nil, ""
+}
+
+// returns the target name (if it is not the owner name) and the IP hints
+func extractSVCBEntries(rr *dns.SVCB) (entries []string) {
+	if rr.Target != "." {
+		entries = append(entries, util.ExtractDomainOnly(rr.Target))
+	}
+
+	for _, kv := range rr.Value {
+		switch hint := kv.(type) {
+		case *dns.SVCBIPv4Hint:
+			for _, ip := range hint.Hint {
+				entries = append(entries, ip.String())
+			}
+		case *dns.SVCBIPv6Hint:
+			for _, ip := range hint.Hint {
+				entries = append(entries, strings.ToLower(ip.String()))
+			}
+		}
+	}
+
+	return entries
 }
 
 func (r *BlockingResolver) isGroupDisabled(group string) bool {
@@ -465,36 +919,9 @@ func (r *BlockingResolver) groupsToCheckForClient(request *model.Request) []stri
 	defer r.status.lock.RUnlock()
 
 	var groups []string
-	// try client names
-	for _, cName := range request.ClientNames {
-		for blockGroup, groupsByName := range r.clientGroupsBlock {
-			if util.ClientNameMatchesGroupName(blockGroup, cName) {
-				groups = append(groups, groupsByName...)
-			}
-		}
-	}
 
-	// try IP
-	groupsByIP, found := r.clientGroupsBlock[request.ClientIP.String()]
-
-	if found {
-		groups = append(groups, groupsByIP...)
-	}
-
-	for clientIdentifier, groupsByCidr := range r.clientGroupsBlock {
-		// try CIDR
-		if util.CidrContainsIP(clientIdentifier, request.ClientIP) {
-			groups = append(groups, groupsByCidr...)
-		} else if isFQDN(clientIdentifier) && r.fqdnIPCache != nil {
-			ips, _ := r.fqdnIPCache.Get(clientIdentifier)
-			if ips != nil {
-				for _, ip := range *ips {
-					if ip.Equal(request.ClientIP) {
-						groups = append(groups, groupsByCidr...)
-					}
-				}
-			}
-		}
+	for _, groupsByIdentifier := range matchingClientGroups(request, r.clientGroupsBlock, r.fqdnIPs) {
+		groups = append(groups, groupsByIdentifier...)
 	}
 
 	if len(groups) == 0 {
@@ -515,6 +942,19 @@ func (r *BlockingResolver) groupsToCheckForClient(request *model.Request) []stri
 	return result
 }
 
+// fqdnIPs returns the cached IPs of a client identifier, which is a FQDN
+func (r *BlockingResolver) fqdnIPs(identifier string) []net.IP {
+	if r.fqdnIPCache == nil {
+		return nil
+	}
+
+	if ips, _ := r.fqdnIPCache.Get(identifier); ips != nil {
+		return *ips
+	}
+
+	return nil
+}
+
 func (r *BlockingResolver) matches(groupsToCheck []string, m lists.Matcher,
 	domain string,
 ) (group []string) {
@@ -533,7 +973,9 @@ type zeroIPBlockHandler struct {
 	BlockTimeSec uint32
 }
 
-type nxDomainBlockHandler struct{}
+type nxDomainBlockHandler struct {
+	BlockTimeSec uint32
+}
 
 type ipBlockHandler struct {
 	destinations    []net.IP
@@ -550,7 +992,7 @@ func (b zeroIPBlockHandler) handleBlock(question dns.Question, response *dns.Msg
 	case dns.TypeA:
 		zeroIP = net.IPv4zero
 	default:
-		response.Rcode = dns.RcodeNameError
+		nxDomainBlockHandler(b).handleBlock(question, response)
 
 		return
 	}
@@ -560,8 +1002,25 @@ func (b zeroIPBlockHandler) handleBlock(question dns.Question, response *dns.Msg
 	response.Answer = append(response.Answer, rr)
 }
 
-func (b nxDomainBlockHandler) handleBlock(_ dns.Question, response *dns.Msg) {
+// handleBlock answers with NXDOMAIN and a SOA record, so the clients cache the negative answer for the block TTL
+// (RFC 2308) instead of their own default
+func (b nxDomainBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
 	response.Rcode = dns.RcodeNameError
+	response.Ns = append(response.Ns, &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   question.Name,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    b.BlockTimeSec,
+		},
+		Ns:      "blocky.",
+		Mbox:    "blocky.",
+		Serial:  1,
+		Refresh: b.BlockTimeSec,
+		Retry:   b.BlockTimeSec,
+		Expire:  b.BlockTimeSec,
+		Minttl:  b.BlockTimeSec,
+	})
 }
 
 func (b ipBlockHandler) handleBlock(question dns.Question, response *dns.Msg) {
@@ -2,8 +2,10 @@ package resolver
 
 import (
 	"context"
+	"os"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	. "github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
@@ -209,6 +211,8 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 					"altName":         {"gr2"},
 					"10.43.8.67/28":   {"gr1"},
 					"wildcard[0-9]*":  {"gr1"},
+					"tag:IoT":         {"gr2"},
+					"tenant:smith":    {"gr2"},
 					"default":         {"defaultGroup"},
 				},
 				BlockType: "ZeroIP",
@@ -384,6 +388,88 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 
+		When("Client tag is defined in client groups block", func() {
+			It("should block query if domain is in the group of the tag", func() {
+				request := newRequestWithClient("blocked2.com.", A, "1.2.1.2", "cam")
+				request.ClientTags = []string{"iot"}
+
+				Expect(sut.Resolve(ctx, request)).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked2.com.", A, "0.0.0.0"),
+							HaveTTL(BeNumerically("==", 21600)),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (gr2)"),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+			})
+		})
+
+		When("Tenant is defined in client groups block", func() {
+			It("should block query if domain is in the group of the tenant", func() {
+				request := newRequestWithClient("blocked2.com.", A, "1.2.1.2", "laptop")
+				request.Tenant = "smith"
+
+				Expect(sut.Resolve(ctx, request)).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked2.com.", A, "0.0.0.0"),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (gr2)"),
+						))
+			})
+
+			It("should not use the default group for the tenant", func() {
+				request := newRequestWithClient("blocked3.com.", A, "1.2.1.2", "laptop")
+				request.Tenant = "smith"
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+
+		When("a group blocks categories", func() {
+			BeforeEach(func() {
+				sutConfig.ClientGroupsBlock["kids"] = []string{"gr1", "social"}
+				sutConfig.Categories = map[string][]string{"social": {"social", "Video"}}
+			})
+
+			It("should block query if the domain has a blocked category", func() {
+				request := newRequestWithClient("example.com.", A, "1.2.1.2", "kids")
+				request.Categories = []string{"gaming", "video"}
+
+				Expect(sut.Resolve(ctx, request)).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("example.com.", A, "0.0.0.0"),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (social)"),
+						))
+			})
+
+			It("should not block other categories", func() {
+				request := newRequestWithClient("example.com.", A, "1.2.1.2", "kids")
+				request.Categories = []string{"gaming"}
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+
+			It("should only block the category for the clients of the group", func() {
+				request := newRequestWithClient("example.com.", A, "1.2.1.2", "client1")
+				request.Categories = []string{"social"}
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+
+			It("should list the group as blocking group", func() {
+				Expect(sut.DisableBlocking(ctx, 0, []string{"social"})).Should(Succeed())
+
+				request := newRequestWithClient("example.com.", A, "1.2.1.2", "kids")
+				request.Categories = []string{"social"}
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+
 		When("Default group is defined", func() {
 			It("should block domains from default group for each client", func() {
 				Expect(sut.Resolve(ctx, newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))).
@@ -422,6 +508,69 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 							HaveReason("BLOCKED (defaultGroup)"),
 						))
 			})
+
+			It("should return a SOA with the block TTL as negative TTL", func() {
+				resp, err := sut.Resolve(ctx, newRequestWithClient("blocked3.com.", AAAA, "1.2.1.2", "unknown"))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.Ns).Should(HaveLen(1))
+				Expect(resp.Res.Ns[0]).Should(BeAssignableToTypeOf(&dns.SOA{}))
+
+				soa := resp.Res.Ns[0].(*dns.SOA)
+				Expect(soa.Hdr.Name).Should(Equal("blocked3.com."))
+				Expect(soa.Hdr.Ttl).Should(BeNumerically("==", 60))
+				Expect(soa.Minttl).Should(BeNumerically("==", 60))
+			})
+		})
+
+		When("response cache is enabled", func() {
+			BeforeEach(func() {
+				sutConfig.ResponseCacheSize = 10
+			})
+
+			It("should return the cached response for the same question and groups", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))
+				Expect(err).Should(Succeed())
+				Expect(sut.responseCache.TotalCount()).Should(Equal(1))
+
+				request := newRequestWithClient("blocked3.com.", A, "1.2.1.3", "unknown")
+
+				cached, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+				Expect(cached).Should(
+					SatisfyAll(
+						BeDNSRecord("blocked3.com.", A, "0.0.0.0"),
+						HaveTTL(BeNumerically("==", 6*60*60)),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("BLOCKED (defaultGroup)"),
+					))
+				Expect(cached.Res.Id).Should(Equal(request.Req.Id))
+
+				By("changing a returned response", func() {
+					cached.Res.Answer[0].Header().Ttl = 0
+
+					Expect(sut.Resolve(ctx, request)).Should(HaveTTL(BeNumerically("==", 6*60*60)))
+				})
+			})
+
+			It("should not reuse the response for other groups", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("domain1.com.", A, "1.2.1.2", "client1"))).
+					Should(HaveResponseType(ResponseTypeBLOCKED))
+
+				Expect(sut.Resolve(ctx, newRequestWithClient("domain1.com.", A, "1.2.1.2", "unknown"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+				m.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+			})
+
+			It("should be cleared if a list group changes", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("blocked3.com.", A, "1.2.1.2", "unknown"))
+				Expect(err).Should(Succeed())
+				Expect(sut.responseCache.TotalCount()).Should(Equal(1))
+
+				Bus().Publish(BlockingCacheGroupChanged, lists.ListCacheTypeDenylist, "defaultGroup", 4)
+
+				Expect(sut.responseCache.TotalCount()).Should(Equal(0))
+			})
 		})
 
 		When("BlockTTL is set", func() {
@@ -575,6 +724,50 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 
+		When("denylist contains target or IP hint of a HTTPS record in response", func() {
+			When("the target is on a denylist", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, HTTPS, "1 badcnamedomain.com. alpn=h2")
+				})
+				It("should block the query", func() {
+					Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", HTTPS, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								HaveNoAnswer(),
+								HaveResponseType(ResponseTypeBLOCKED),
+								HaveReturnCode(dns.RcodeNameError),
+								HaveReason("BLOCKED HTTPS (defaultGroup)"),
+							))
+				})
+			})
+			When("an IP hint is on a denylist", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, HTTPS, "1 . ipv4hint=192.0.2.1,123.145.123.145")
+				})
+				It("should block the query", func() {
+					Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", HTTPS, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								HaveResponseType(ResponseTypeBLOCKED),
+								HaveReason("BLOCKED HTTPS (defaultGroup)"),
+							))
+				})
+			})
+			When("target and IP hints are not on a denylist", func() {
+				BeforeEach(func() {
+					mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, HTTPS, "1 . ipv4hint=192.0.2.1")
+				})
+				It("should not block the query", func() {
+					Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", HTTPS, "1.2.1.2", "unknown"))).
+						Should(
+							SatisfyAll(
+								BeDNSRecord("example.com.", HTTPS, `1 . ipv4hint="192.0.2.1"`),
+								HaveResponseType(ResponseTypeRESOLVED),
+							))
+				})
+			})
+		})
+
 		When("denylist contains domain which is CNAME in response", func() {
 			BeforeEach(func() {
 				// reconfigure mock, to return CNAMEs
@@ -598,6 +791,75 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		})
 	})
 
+	Describe("Log-only mode", func() {
+		BeforeEach(func() {
+			sutConfig = config.Blocking{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+				Denylists: map[string][]config.BytesSource{
+					"gr1":          config.NewBytesSources(group1File.Path),
+					"gr2":          config.NewBytesSources(group2File.Path),
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				},
+				ClientGroupsBlock: map[string][]string{
+					"client1": {"gr1", "gr2"},
+					"default": {"defaultGroup"},
+				},
+				GroupModes: map[string]config.BlockingMode{
+					"gr2":          config.BlockingModeLogOnly,
+					"defaultGroup": config.BlockingModeLogOnly,
+				},
+			}
+
+			mockAnswer, _ = util.NewMsgWithAnswer("blocked2.com.", 300, A, "1.2.3.4")
+		})
+
+		When("domain is on the denylist of a log-only group", func() {
+			It("should return the real answer with reason WOULD_BLOCK", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("blocked2.com.", A, "1.2.1.2", "client1"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("blocked2.com.", A, "1.2.3.4"),
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReason("WOULD_BLOCK (gr2)"),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+
+				m.AssertExpectations(GinkgoT())
+			})
+		})
+
+		When("domain is on the denylist of an enforced group", func() {
+			It("should block the query", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("domain1.com.", A, "1.2.1.2", "client1"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("domain1.com.", A, "0.0.0.0"),
+							HaveResponseType(ResponseTypeBLOCKED),
+							HaveReason("BLOCKED (gr1)"),
+						))
+
+				m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+			})
+		})
+
+		When("lookup result contains an IP on the denylist of a log-only group", func() {
+			BeforeEach(func() {
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.145.123.145")
+			})
+
+			It("should return the real answer with reason WOULD_BLOCK", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "1.2.1.2", "unknown"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("example.com.", A, "123.145.123.145"),
+							HaveResponseType(ResponseTypeRESOLVED),
+							HaveReason("WOULD_BLOCK IP (defaultGroup)"),
+						))
+			})
+		})
+	})
+
 	Describe("Allowlisting", func() {
 		When("Requested domain is on black and allowlist", func() {
 			BeforeEach(func() {
@@ -1108,6 +1370,80 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				})
 			})
 		})
+
+		When("the resolver is replaced after a reload", func() {
+			var next *BlockingResolver
+
+			newSut := func(cfg config.Blocking) *BlockingResolver {
+				res, err := NewBlockingResolver(ctx, cfg, nil, systemResolverBootstrap)
+				Expect(err).Should(Succeed())
+
+				return res
+			}
+
+			It("should keep the enabled status", func() {
+				next = newSut(sutConfig)
+				next.TakeOver(ctx, sut)
+
+				Expect(next.BlockingStatus().Enabled).Should(BeTrue())
+			})
+
+			It("should keep blocking disabled for the remaining time", func() {
+				Expect(sut.DisableBlocking(ctx, time.Hour, []string{})).Should(Succeed())
+
+				next = newSut(sutConfig)
+				next.TakeOver(ctx, sut)
+
+				status := next.BlockingStatus()
+				Expect(status.Enabled).Should(BeFalse())
+				Expect(status.DisabledGroups).Should(ContainElements("defaultGroup", "group1"))
+				Expect(status.AutoEnableInSec).Should(BeNumerically("~", time.Hour.Seconds(), 1))
+			})
+
+			It("should keep blocking disabled forever", func() {
+				Expect(sut.DisableBlocking(ctx, 0, []string{"group1"})).Should(Succeed())
+
+				next = newSut(sutConfig)
+				next.TakeOver(ctx, sut)
+
+				status := next.BlockingStatus()
+				Expect(status.Enabled).Should(BeFalse())
+				Expect(status.DisabledGroups).Should(ConsistOf("group1"))
+				Expect(status.AutoEnableInSec).Should(BeZero())
+			})
+
+			It("should enable blocking, if the disabled groups were removed", func() {
+				Expect(sut.DisableBlocking(ctx, 0, []string{"group1"})).Should(Succeed())
+
+				cfg := sutConfig
+				cfg.Denylists = map[string][]config.BytesSource{
+					"defaultGroup": config.NewBytesSources(defaultGroupFile.Path),
+				}
+				cfg.ClientGroupsBlock = map[string][]string{
+					"default": {"defaultGroup"},
+				}
+
+				next = newSut(cfg)
+				next.TakeOver(ctx, sut)
+
+				Expect(next.BlockingStatus().Enabled).Should(BeTrue())
+			})
+		})
+
+		When("List refresh is called", func() {
+			It("should refresh all lists", func() {
+				Expect(sut.RefreshLists(nil)).Should(Succeed())
+			})
+
+			It("should refresh the lists of the passed groups", func() {
+				Expect(sut.RefreshLists([]string{"group1"})).Should(Succeed())
+			})
+
+			It("should fail on unknown group", func() {
+				Expect(sut.RefreshLists([]string{"group1", "unknown"})).
+					Should(MatchError(api.ErrUnknownListGroup))
+			})
+		})
 	})
 
 	Describe("Create resolver with wrong parameter", func() {
@@ -1136,6 +1472,106 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 		})
 	})
 
+	Describe("State persistence", func() {
+		var stateDir *TmpFolder
+
+		BeforeEach(func() {
+			stateDir = NewTmpFolder("BlockingState")
+			DeferCleanup(stateDir.Clean)
+
+			sutConfig.Denylists = map[string][]config.BytesSource{
+				"gr1": config.NewBytesSources(group1File.Path),
+				"gr2": config.NewBytesSources(group2File.Path),
+			}
+			sutConfig.StateFile = stateDir.JoinPath("state.json")
+		})
+
+		restart := func() *BlockingResolver {
+			res, err := NewBlockingResolver(ctx, sutConfig, nil, systemResolverBootstrap)
+			Expect(err).Should(Succeed())
+
+			return res
+		}
+
+		When("blocking is disabled", func() {
+			It("should stay disabled after a restart", func() {
+				Expect(sut.DisableBlocking(ctx, 0, []string{"gr1"})).Should(Succeed())
+
+				status := restart().BlockingStatus()
+				Expect(status.Enabled).Should(BeFalse())
+				Expect(status.DisabledGroups).Should(Equal([]string{"gr1"}))
+				Expect(status.AutoEnableInSec).Should(BeZero())
+			})
+
+			It("should keep the remaining duration", func() {
+				Expect(sut.DisableBlocking(ctx, time.Hour, nil)).Should(Succeed())
+
+				status := restart().BlockingStatus()
+				Expect(status.Enabled).Should(BeFalse())
+				Expect(status.DisabledGroups).Should(Equal([]string{"default", "gr1", "gr2"}))
+				Expect(status.AutoEnableInSec).Should(BeNumerically("~", time.Hour.Seconds(), 5))
+			})
+
+			It("should be enabled after a restart, if it was enabled again", func() {
+				Expect(sut.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+				sut.EnableBlocking(ctx)
+
+				Expect(restart().BlockingStatus().Enabled).Should(BeTrue())
+			})
+
+			It("should be enabled after the duration elapsed", func() {
+				Expect(sut.DisableBlocking(ctx, 50*time.Millisecond, nil)).Should(Succeed())
+
+				Eventually(func() bool {
+					return restart().BlockingStatus().Enabled
+				}, "1s").Should(BeTrue())
+			})
+		})
+
+		When("the auto enable time passed during the restart", func() {
+			BeforeEach(func() {
+				stateDir.CreateStringFile("state.json",
+					`{"enabled":false,"disabledGroups":["gr1"],"autoEnableAt":"2020-01-01T00:00:00Z"}`)
+			})
+
+			It("should be enabled", func() {
+				Expect(sut.BlockingStatus().Enabled).Should(BeTrue())
+				Expect(os.ReadFile(sutConfig.StateFile)).Should(MatchJSON(`{"enabled":true}`))
+			})
+		})
+
+		When("a disabled group doesn't exist anymore", func() {
+			BeforeEach(func() {
+				stateDir.CreateStringFile("state.json", `{"enabled":false,"disabledGroups":["removed"]}`)
+			})
+
+			It("should be enabled", func() {
+				Expect(sut.BlockingStatus().Enabled).Should(BeTrue())
+			})
+		})
+
+		When("the state file is invalid", func() {
+			It("should fail", func() {
+				stateDir.CreateStringFile("state.json", "invalid")
+
+				_, err := NewBlockingResolver(ctx, sutConfig, nil, systemResolverBootstrap)
+				Expect(err).Should(MatchError(ContainSubstring("invalid blocking state")))
+			})
+		})
+
+		When("no state file is configured", func() {
+			BeforeEach(func() {
+				sutConfig.StateFile = ""
+			})
+
+			It("should be enabled after a restart", func() {
+				Expect(sut.DisableBlocking(ctx, 0, nil)).Should(Succeed())
+
+				Expect(restart().BlockingStatus().Enabled).Should(BeTrue())
+			})
+		})
+	})
+
 	Describe("Redis is configured", func() {
 		var redisServer *miniredis.Miniredis
 		var redisClient *redis.Client
@@ -1193,6 +1629,18 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 				}, "5s").Should(BeTrue())
 			})
 		})
+		When("blocking is disabled", func() {
+			It("should restore the state from redis", func() {
+				Expect(sut.DisableBlocking(ctx, time.Hour, nil)).Should(Succeed())
+				Expect(redisServer.Exists(redis.StateStorePrefix + blockingStateName)).Should(BeTrue())
+
+				restarted, err := NewBlockingResolver(ctx, sutConfig, redisClient, systemResolverBootstrap)
+				Expect(err).Should(Succeed())
+
+				Expect(restarted.BlockingStatus().Enabled).Should(BeFalse())
+				Expect(restarted.BlockingStatus().AutoEnableInSec).Should(BeNumerically(">", 0))
+			})
+		})
 		When("enable", func() {
 			It("should return enable", func() {
 				err = sut.DisableBlocking(context.TODO(), time.Hour, []string{})
@@ -1209,4 +1657,38 @@ var _ = Describe("BlockingResolver", Label("blockingResolver"), func() {
 			})
 		})
 	})
+
+	Describe("a sync bus without redis is configured", func() {
+		var bus *mockSyncBus
+
+		JustBeforeEach(func() {
+			bus = newMockSyncBus()
+			sutConfig = config.Blocking{
+				BlockType: "ZEROIP",
+				BlockTTL:  config.Duration(time.Minute),
+			}
+
+			var err error
+
+			sut, err = NewBlockingResolver(ctx, sutConfig, bus, systemResolverBootstrap)
+			Expect(err).Should(Succeed())
+		})
+
+		It("should publish the blocking status", func() {
+			Expect(sut.DisableBlocking(ctx, time.Hour, nil)).Should(Succeed())
+
+			Eventually(bus.publishedEnabled).Should(Receive(Equal(&redis.EnabledMessage{
+				State:    false,
+				Duration: time.Hour,
+			})))
+		})
+
+		It("should apply the received blocking status", func() {
+			bus.enabledMessages <- &redis.EnabledMessage{State: false}
+
+			Eventually(func() bool {
+				return sut.BlockingStatus().Enabled
+			}, "5s").Should(BeFalse())
+		})
+	})
 })
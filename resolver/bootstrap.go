@@ -98,7 +98,7 @@ func NewBootstrap(ctx context.Context, cfg *config.Config) (b *Bootstrap, err er
 	}
 
 	b.bootstraped = bootstraped
-	cachingResolver, _ := newCachingResolver(ctx, cachingCfg, nil, false)
+	cachingResolver, _ := newCachingResolver(ctx, cachingCfg, nil, nil, nil, false)
 
 	b.resolver = Chain(
 		NewFilteringResolver(cfg.Filtering),
@@ -144,6 +144,11 @@ func (b *Bootstrap) resolveUpstream(ctx context.Context, r Resolver, host string
 		return ips, nil
 	}
 
+	return b.lookupIPs(ctx, host)
+}
+
+// lookupIPs resolves the IPs of the host with the bootstrap DNS or the system resolver
+func (b *Bootstrap) lookupIPs(ctx context.Context, host string) ([]net.IP, error) {
 	ctx, cancel := context.WithTimeout(ctx, b.cfg.timeout.ToDuration())
 	defer cancel()
 
@@ -155,6 +160,39 @@ func (b *Bootstrap) resolveUpstream(ctx context.Context, r Resolver, host string
 	return b.resolve(ctx, host, b.cfg.connectIPVersion.QTypes())
 }
 
+// lookupSRV resolves the SRV records of the name with the bootstrap DNS or the system resolver
+func (b *Bootstrap) lookupSRV(ctx context.Context, name string) ([]*net.SRV, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.timeout.ToDuration())
+	defer cancel()
+
+	if b.resolver == nil {
+		_, srvs, err := b.systemResolver.LookupSRV(ctx, "", "", name)
+
+		return srvs, err
+	}
+
+	ctx, _ = b.log(ctx)
+
+	rsp, err := b.resolver.Resolve(ctx, &model.Request{Req: util.NewMsgWithQuestion(name, dns.Type(dns.TypeSRV))})
+	if err != nil {
+		return nil, err
+	}
+
+	srvs := make([]*net.SRV, 0, len(rsp.Res.Answer))
+
+	for _, rr := range rsp.Res.Answer {
+		if srv, ok := rr.(*dns.SRV); ok {
+			srvs = append(srvs, &net.SRV{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight})
+		}
+	}
+
+	if len(srvs) == 0 {
+		return nil, fmt.Errorf("no SRV records for %s", name)
+	}
+
+	return srvs, nil
+}
+
 // NewHTTPTransport returns a new http.Transport that uses b to resolve hostnames
 func (b *Bootstrap) NewHTTPTransport() *http.Transport {
 	transport := util.DefaultHTTPTransport()
@@ -287,6 +325,15 @@ func newBootstrapedResolvers(
 			continue
 		}
 
+		if upstream.IsRegistry() {
+			multiErr = multierror.Append(
+				multiErr,
+				fmt.Errorf("item %d: '%s': upstreams of a service registry can't be used for bootstrap", i, upstream),
+			)
+
+			continue
+		}
+
 		ips := make([]net.IP, 0, len(upstreamCfg.IPs)+1)
 
 		if ip := net.ParseIP(upstream.Host); ip != nil {
@@ -2,10 +2,13 @@ package resolver
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
-	"regexp"
+	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,9 +26,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
-const defaultCachingCleanUpInterval = 5 * time.Second
+const (
+	defaultCachingCleanUpInterval = 5 * time.Second
+	prefetchStateSaveInterval     = 5 * time.Minute
+	prefetchStateName             = "prefetch"
+	// estimated memory of a cache entry without the packed response: key, LRU list element and map bucket
+	cacheEntryOverhead = 128
+	// separates the upstream group from the query type and the domain in the cache key, a domain name contains
+	// no NUL byte
+	cacheKeyGroupSeparator = "\x00"
+	// length of the query type at the start of the cache key
+	cacheKeyQTypeLength = 2
+)
 
 //nolint:gochecknoglobals
 var (
@@ -41,6 +56,32 @@ var (
 			Help: "Cache miss counter",
 		},
 	)
+	cacheRefreshes = promauto.With(metrics.Reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_refreshes_total",
+			Help: "Count of the background refreshes of stale cache entries by result (refreshed, failed, skipped)",
+		},
+		[]string{"result"},
+	)
+	cacheEvictions = promauto.With(metrics.Reg).NewCounter(
+		prometheus.CounterOpts{
+			Name: "blocky_cache_evictions_total",
+			Help: "Count of the cache entries evicted to stay within the memory budget",
+		},
+	)
+	cacheSizeBytes = promauto.With(metrics.Reg).NewGauge(
+		prometheus.GaugeOpts{
+			Name: "blocky_cache_size_bytes",
+			Help: "Estimated memory usage of the cache entries with memory budget in bytes",
+		},
+	)
+)
+
+const (
+	cacheRefreshed     = "refreshed"
+	cacheRefreshFailed = "failed"
+	// the refresh budget is exhausted
+	cacheRefreshSkipped = "skipped"
 )
 
 // CachingResolver caches answers from dns queries with their TTL time,
@@ -53,44 +94,104 @@ type CachingResolver struct {
 	emitMetricEvents bool // disabled by Bootstrap
 
 	resultCache cache.ExpiringCache[[]byte]
+	// only set, if the prefetch candidates are persisted
+	prefetchCache *prefetching.PrefetchingExpiringLRUCache[[]byte]
 
+	// shares the cache entries with other instances
+	bus SyncBus
+	// only set, if the bus is redis: the cache entries and the prefetch state are stored in redis
 	redisClient *redis.Client
-
-	compiledExclusions []*regexp.Regexp
+	// answers the cached queries without the resolver chain
+	fastPath FastPath
+	// returns the upstream group of the request: the answers of different upstream groups are cached separately
+	upstreamGroup func(request *model.Request) string
+
+	// domains, which are never cached
+	exclusions *domainPatterns
+
+	// only set, if stale entries are refreshed
+	refreshBudget *rate.Limiter
+	// keys of the running refreshes
+	refreshing sync.Map
 }
 
-// NewCachingResolver creates a new resolver instance
+// NewCachingResolver creates a new resolver instance, the bus and the fast path are optional.
+// upstreamGroup can be nil, if all clients use the same upstreams.
 func NewCachingResolver(ctx context.Context,
 	cfg config.Caching,
-	redis *redis.Client,
+	bus SyncBus,
+	fastPath FastPath,
+	upstreamGroup func(request *model.Request) string,
 ) (*CachingResolver, error) {
-	return newCachingResolver(ctx, cfg, redis, true)
+	return newCachingResolver(ctx, cfg, bus, fastPath, upstreamGroup, true)
 }
 
 func newCachingResolver(ctx context.Context,
 	cfg config.Caching,
-	redis *redis.Client,
+	bus SyncBus,
+	fastPath FastPath,
+	upstreamGroup func(request *model.Request) string,
 	emitMetricEvents bool,
 ) (*CachingResolver, error) {
+	redisClient, _ := bus.(*redis.Client)
+
 	c := &CachingResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("caching"),
 
-		redisClient:      redis,
+		bus:              bus,
+		redisClient:      redisClient,
+		fastPath:         fastPath,
+		upstreamGroup:    upstreamGroup,
 		emitMetricEvents: emitMetricEvents,
 	}
 
 	configureCaches(ctx, c, &cfg)
 	err := configureExclusions(c, &cfg)
 
+	if cfg.Refresh.IsEnabled() {
+		c.refreshBudget = rate.NewLimiter(rate.Limit(float64(cfg.Refresh.Budget)/time.Minute.Seconds()),
+			int(cfg.Refresh.Budget))
+	}
+
+	if c.prefetchCache != nil {
+		c.restorePrefetchState(ctx)
+
+		go c.savePrefetchStatePeriodically(ctx)
+	}
+
+	if c.bus != nil {
+		go c.syncSubscriber(ctx)
+	}
+
 	if c.redisClient != nil {
-		go c.redisSubscriber(ctx)
+		// the stored entries are received like the entries of other instances
 		c.redisClient.GetRedisCache(ctx)
 	}
 
+	c.subscribeZoneChanges(ctx)
+
 	return c, err
 }
 
+// subscribeZoneChanges flushes the cached answers of changed conditional zones until the context is done
+func (r *CachingResolver) subscribeZoneChanges(ctx context.Context) {
+	flushZone := func(zone string) {
+		r.FlushZone(ctx, zone)
+	}
+
+	if err := evt.Bus().Subscribe(evt.ConditionalZoneChanged, flushZone); err != nil {
+		_, logger := r.log(ctx)
+		logger.Errorf("can't subscribe to %s: %v", evt.ConditionalZoneChanged, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		_ = evt.Bus().Unsubscribe(evt.ConditionalZoneChanged, flushZone)
+	}()
+}
+
 func configureCaches(ctx context.Context, c *CachingResolver, cfg *config.Caching) {
 	options := expirationcache.Options{
 		CleanupInterval: defaultCachingCleanUpInterval,
@@ -106,6 +207,18 @@ func configureCaches(ctx context.Context, c *CachingResolver, cfg *config.Cachin
 		},
 	}
 
+	onEvicted := func(count int) {
+		if c.emitMetricEvents {
+			cacheEvictions.Add(float64(count))
+		}
+	}
+
+	onSizeChanged := func(newSize uint64) {
+		if c.emitMetricEvents {
+			cacheSizeBytes.Set(float64(newSize))
+		}
+	}
+
 	if cfg.Prefetching {
 		prefetchingOptions := prefetching.PrefetchingOptions[[]byte]{
 			Options:               options,
@@ -122,38 +235,89 @@ func configureCaches(ctx context.Context, c *CachingResolver, cfg *config.Cachin
 			OnPrefetchCacheHit: func(key string) {
 				c.publishMetricsIfEnabled(evt.CachingPrefetchCacheHit, key)
 			},
+			TrackCandidates: c.redisClient != nil || cfg.PrefetchStateFile != "",
+			MaxBytes:        uint64(cfg.MaxSize),
+			SizeFn:          cacheEntrySize,
+			OnEvictedFn:     onEvicted,
+			OnSizeChangedFn: onSizeChanged,
+		}
+
+		prefetchCache := prefetching.NewPrefetchingCache(ctx, prefetchingOptions)
+		if prefetchingOptions.TrackCandidates {
+			c.prefetchCache = prefetchCache
 		}
 
-		c.resultCache = prefetching.NewPrefetchingCache(ctx, prefetchingOptions)
+		c.resultCache = prefetchCache
+	} else if cfg.MaxSize > 0 {
+		c.resultCache = cache.NewSizeLimitedCache(ctx, cache.SizeLimitedOptions[[]byte]{
+			Options:         options,
+			MaxBytes:        uint64(cfg.MaxSize),
+			SizeFn:          cacheEntrySize,
+			OnEvictedFn:     onEvicted,
+			OnSizeChangedFn: onSizeChanged,
+		})
 	} else {
 		c.resultCache = expirationcache.NewCache[[]byte](ctx, options)
 	}
 }
 
+// cacheEntrySize estimates the memory of a cache entry with the packed response
+func cacheEntrySize(packed *[]byte) uint64 {
+	return uint64(len(*packed)) + cacheEntryOverhead
+}
+
 func configureExclusions(c *CachingResolver, cfg *config.Caching) error {
-	compiled := []*regexp.Regexp{}
-	for _, expStr := range cfg.Exclude {
-		if !strings.HasPrefix(expStr, "/") || !strings.HasSuffix(expStr, "/") {
-			return fmt.Errorf("cache exclusion configuration '%s' fail because of missing slashes", expStr)
-		}
-		re, err := regexp.Compile(strings.TrimSpace(expStr[1 : len(expStr)-1]))
-		if err != nil {
-			return fmt.Errorf("cache exclusion configuration '%s' fail because '%s'", expStr, err.Error())
-		}
-		compiled = append(compiled, re)
+	exclusions, err := newDomainPatterns(cfg.Exclude)
+	if err != nil {
+		return fmt.Errorf("cache exclusion configuration %w", err)
 	}
-	c.compiledExclusions = compiled
+
+	c.exclusions = exclusions
 
 	return nil
 }
 
+// cacheKey returns the key of the question, which contains the upstream group of the request
+func (r *CachingResolver) cacheKey(request *model.Request, question dns.Question) string {
+	key := util.GenerateCacheKey(dns.Type(question.Qtype), util.ExtractDomain(question))
+
+	if r.upstreamGroup != nil {
+		if group := r.upstreamGroup(request); group != "" {
+			return key + cacheKeyGroupSeparator + group
+		}
+	}
+
+	return key
+}
+
+// extractCacheKey returns the query type, the domain and the upstream group of the cache key
+func extractCacheKey(cacheKey string) (qType dns.Type, domain, group string) {
+	if i := strings.Index(cacheKey[cacheKeyQTypeLength:], cacheKeyGroupSeparator); i >= 0 {
+		group = cacheKey[cacheKeyQTypeLength+i+len(cacheKeyGroupSeparator):]
+		cacheKey = cacheKey[:cacheKeyQTypeLength+i]
+	}
+
+	qType, domain = util.ExtractCacheKey(cacheKey)
+
+	return qType, domain, group
+}
+
+// cacheKeyRequest returns a request for the cache key, which is resolved by the same upstream group
+func cacheKeyRequest(cacheKey string) (*model.Request, string) {
+	qType, domain, group := extractCacheKey(cacheKey)
+
+	request := newRequest(dns.Fqdn(domain), qType)
+	request.UpstreamGroup = group
+
+	return request, domain
+}
+
 func (r *CachingResolver) reloadCacheEntry(ctx context.Context, cacheKey string) (*[]byte, time.Duration) {
-	qType, domainName := util.ExtractCacheKey(cacheKey)
+	req, domainName := cacheKeyRequest(cacheKey)
 	ctx, logger := r.log(ctx)
 
-	logger.Debugf("prefetching '%s' (%s)", util.Obfuscate(domainName), qType)
+	logger.Debugf("prefetching '%s' (%s)", util.Obfuscate(domainName), dns.Type(req.Req.Question[0].Qtype))
 
-	req := newRequest(dns.Fqdn(domainName), qType)
 	response, err := r.next.Resolve(ctx, req)
 
 	if err == nil {
@@ -174,14 +338,88 @@ func (r *CachingResolver) reloadCacheEntry(ctx context.Context, cacheKey string)
 	return nil, 0
 }
 
-func (r *CachingResolver) redisSubscriber(ctx context.Context) {
+// restorePrefetchState restores the prefetch candidates from before the restart,
+// so the hot domains are prefetched without reaching the threshold again
+func (r *CachingResolver) restorePrefetchState(ctx context.Context) {
 	ctx, logger := r.log(ctx)
 
+	var (
+		content []byte
+		err     error
+	)
+
+	if r.redisClient != nil {
+		content, err = r.redisClient.LoadState(ctx, prefetchStateName)
+	} else {
+		content, err = os.ReadFile(r.cfg.PrefetchStateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		}
+	}
+
+	var candidates []prefetching.PrefetchCandidate
+
+	if err == nil && content != nil {
+		err = json.Unmarshal(content, &candidates)
+	}
+
+	if err != nil {
+		logger.Warn("can't restore prefetch state: ", err)
+
+		return
+	}
+
+	r.prefetchCache.RestoreCandidates(candidates)
+
+	logger.Debugf("restored %d prefetch candidates", len(candidates))
+}
+
+// SavePrefetchState persists the prefetch candidates in redis or in the state file, if configured.
+// They are saved periodically and should be saved on shutdown.
+func (r *CachingResolver) SavePrefetchState(ctx context.Context) {
+	if r.prefetchCache == nil {
+		return
+	}
+
+	ctx, logger := r.log(ctx)
+
+	content, err := json.Marshal(r.prefetchCache.Candidates())
+	if err == nil {
+		if r.redisClient != nil {
+			err = r.redisClient.StoreState(ctx, prefetchStateName, content)
+		} else {
+			err = writeFileAtomic(r.cfg.PrefetchStateFile, content)
+		}
+	}
+
+	if err != nil {
+		logger.Warn("can't save prefetch state: ", err)
+	}
+}
+
+func (r *CachingResolver) savePrefetchStatePeriodically(ctx context.Context) {
+	ticker := time.NewTicker(prefetchStateSaveInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
-		case rc := <-r.redisClient.CacheChannel:
+		case <-ticker.C:
+			r.SavePrefetchState(ctx)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *CachingResolver) syncSubscriber(ctx context.Context) {
+	ctx, logger := r.log(ctx)
+
+	for {
+		select {
+		case rc := <-r.bus.CacheMessages():
 			if rc != nil {
-				logger.Debug("Received key from redis: ", rc.Key)
+				logger.Debug("Received key from other instance: ", rc.Key)
 				ttl := r.adjustTTLs(rc.Response.Res.Answer)
 				r.putInCache(ctx, rc.Key, rc.Response, ttl, false)
 			}
@@ -212,7 +450,7 @@ func (r *CachingResolver) Resolve(ctx context.Context, request *model.Request) (
 
 	for _, question := range request.Req.Question {
 		domain := util.ExtractDomain(question)
-		cacheKey := util.GenerateCacheKey(dns.Type(question.Qtype), domain)
+		cacheKey := r.cacheKey(request, question)
 		logger := logger.WithField("domain", util.Obfuscate(domain))
 
 		val, ttl := r.getFromCache(logger, cacheKey)
@@ -220,22 +458,26 @@ func (r *CachingResolver) Resolve(ctx context.Context, request *model.Request) (
 		if val != nil {
 			logger.Debug("domain is cached")
 
+			r.refreshIfStale(ctx, cacheKey, val, ttl)
+
 			val.SetRcode(request.Req, val.Rcode)
 
 			// Adjust TTL
 			setTTLInCachedResponse(val, ttl)
 
 			if val.Rcode == dns.RcodeSuccess {
-				return &model.Response{Res: val, RType: model.ResponseTypeCACHED, Reason: "CACHED"}, nil
+				return &model.Response{Res: val, RType: model.ResponseTypeCACHED, Reason: model.NewReason(model.ReasonCodeCACHED)}, nil
 			}
 
-			return &model.Response{Res: val, RType: model.ResponseTypeCACHED, Reason: "CACHED NEGATIVE"}, nil
+			return &model.Response{Res: val, RType: model.ResponseTypeCACHED, Reason: model.NewReason(model.ReasonCodeCACHEDNEGATIVE)}, nil
 		}
 
 		logger.WithField("next_resolver", Name(r.next)).Trace("not in cache: go to next resolver")
 		response, err = r.next.Resolve(ctx, request)
 
 		if err == nil {
+			r.recordTTLClamp(ctx, response.Res.Answer)
+
 			cacheTTL := r.adjustTTLs(response.Res.Answer)
 			r.putInCache(ctx, cacheKey, response, cacheTTL, true)
 		}
@@ -244,6 +486,63 @@ func (r *CachingResolver) Resolve(ctx context.Context, request *model.Request) (
 	return response, err
 }
 
+// refreshIfStale resolves the query of a cache entry in the background, if the elapsed share of its TTL
+// reaches the refresh threshold. The stale entry is served until the refresh is done.
+func (r *CachingResolver) refreshIfStale(ctx context.Context, cacheKey string, cached *dns.Msg, ttl time.Duration) {
+	if r.refreshBudget == nil || cached.Rcode != dns.RcodeSuccess || len(cached.Answer) == 0 {
+		return
+	}
+
+	// the cached answer has the TTL of the time it was cached
+	originalTTL := uint32(math.MaxUint32)
+	for _, rr := range cached.Answer {
+		originalTTL = min(originalTTL, rr.Header().Ttl)
+	}
+
+	const percent = 100
+
+	elapsed := 1 - ttl.Seconds()/float64(originalTTL)
+	if originalTTL == 0 || elapsed*percent < float64(r.cfg.Refresh.Threshold) {
+		return
+	}
+
+	if _, running := r.refreshing.LoadOrStore(cacheKey, struct{}{}); running {
+		return
+	}
+
+	if !r.refreshBudget.Allow() {
+		r.refreshing.Delete(cacheKey)
+		cacheRefreshes.WithLabelValues(cacheRefreshSkipped).Inc()
+
+		return
+	}
+
+	go func() {
+		defer r.refreshing.Delete(cacheKey)
+
+		r.refreshCacheEntry(context.WithoutCancel(ctx), cacheKey)
+	}()
+}
+
+func (r *CachingResolver) refreshCacheEntry(ctx context.Context, cacheKey string) {
+	request, domainName := cacheKeyRequest(cacheKey)
+	ctx, logger := r.log(ctx)
+
+	logger.Debugf("refreshing '%s' (%s)", util.Obfuscate(domainName), dns.Type(request.Req.Question[0].Qtype))
+
+	response, err := r.next.Resolve(ctx, request)
+	if err != nil {
+		cacheRefreshes.WithLabelValues(cacheRefreshFailed).Inc()
+		util.LogOnError(ctx, fmt.Sprintf("can't refresh '%s' ", domainName), err)
+
+		return
+	}
+
+	cacheRefreshes.WithLabelValues(cacheRefreshed).Inc()
+
+	r.putInCache(ctx, cacheKey, response, r.adjustTTLs(response.Res.Answer), true)
+}
+
 func (r *CachingResolver) getFromCache(logger *logrus.Entry, key string) (*dns.Msg, time.Duration) {
 	val, ttl := r.resultCache.Get(key)
 	if val == nil {
@@ -276,8 +575,8 @@ func setTTLInCachedResponse(resp *dns.Msg, ttl time.Duration) {
 
 // isRequestCacheable returns true if the request should be cached
 func (r *CachingResolver) isRequestCacheable(request *model.Request) bool {
-	// don't cache response if name ends with any exclution
-	if questionsMatchAnyExcludedElement(request.Req.Question, r.compiledExclusions) {
+	// don't cache responses of excluded domains
+	if r.exclusions.matchesAny(request.Req.Question) {
 		return false
 	}
 	// don't cache responses with EDNS Client Subnet option with masks that include more than one client
@@ -291,27 +590,6 @@ func (r *CachingResolver) isRequestCacheable(request *model.Request) bool {
 	return true
 }
 
-func questionsMatchAnyExcludedElement(questions []dns.Question, exclutions []*regexp.Regexp) bool {
-	for _, q := range questions {
-		if matchAnyElementOfArray(q.Name[:len(q.Name)-1], exclutions) {
-			return true
-		}
-	}
-
-	return false
-}
-
-func matchAnyElementOfArray(givingText string, arr []*regexp.Regexp) bool {
-	loweredGivingText := strings.ToLower(givingText)
-	for _, s := range arr {
-		if s.MatchString(loweredGivingText) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // isResponseCacheable returns true if the response is not truncated and its CD flag isn't set.
 func isResponseCacheable(msg *dns.Msg) bool {
 	// we don't cache truncated responses and responses with CD flag
@@ -333,17 +611,45 @@ func (r *CachingResolver) putInCache(
 		if response.Res.Rcode == dns.RcodeSuccess && isResponseCacheable(response.Res) {
 			// put value into cache
 			r.resultCache.Put(cacheKey, &packed, ttl)
+			r.putInFastPath(respCopy, ttl)
 		} else if response.Res.Rcode == dns.RcodeNameError {
 			if r.cfg.CacheTimeNegative.IsAboveZero() {
 				// put negative cache if result code is NXDOMAIN
 				r.resultCache.Put(cacheKey, &packed, r.cfg.CacheTimeNegative.ToDuration())
+				r.putInFastPath(respCopy, r.cfg.CacheTimeNegative.ToDuration())
 			}
 		}
 	}
 
-	if publish && r.redisClient != nil {
+	if publish && r.bus != nil {
 		res := *respCopy
-		r.redisClient.PublishCache(cacheKey, &res)
+		r.bus.PublishCache(cacheKey, &res)
+	}
+}
+
+func (r *CachingResolver) putInFastPath(response *dns.Msg, ttl time.Duration) {
+	if r.fastPath != nil {
+		r.fastPath.Put(response, ttl)
+	}
+}
+
+// recordTTLClamp records a manipulation, if the TTL of an answer is outside of the min and max cache time
+func (r *CachingResolver) recordTTLClamp(ctx context.Context, answer []dns.RR) {
+	if responseManipulationsFromCtx(ctx) == nil {
+		return
+	}
+
+	minTTL, maxTTL := r.cfg.MinCachingTime.SecondsU32(), r.cfg.MaxCachingTime.SecondsU32()
+
+	for _, a := range answer {
+		ttl := atomic.LoadUint32(&a.Header().Ttl)
+
+		if (r.cfg.MinCachingTime.IsAboveZero() && ttl < minTTL) || (r.cfg.MaxCachingTime.IsAboveZero() && ttl > maxTTL) {
+			recordManipulation(ctx, r.Type(), manipulationTTLClamped,
+				fmt.Sprintf("TTL %d clamped to the range of the cache time", ttl))
+
+			return
+		}
 	}
 }
 
@@ -386,9 +692,41 @@ func (r *CachingResolver) publishMetricsIfEnabled(event string, val interface{})
 	}
 }
 
+// FlushZone removes the cached answers of the zone and its sub domains. Without memory budget (`maxSize`), the cache
+// can't remove single entries and is flushed completely.
+func (r *CachingResolver) FlushZone(ctx context.Context, zone string) {
+	_, logger := r.log(ctx)
+
+	if r.fastPath != nil {
+		r.fastPath.FlushZone(zone)
+	}
+
+	deletable, ok := r.resultCache.(cache.DeletableCache[[]byte])
+	if !ok {
+		logger.Debugf("flush caches for zone '%s'", util.Obfuscate(zone))
+		r.resultCache.Clear()
+
+		return
+	}
+
+	zone = dns.Fqdn(zone)
+
+	count := deletable.DeleteFunc(func(key string) bool {
+		_, domain, _ := extractCacheKey(key)
+
+		return dns.IsSubDomain(zone, dns.Fqdn(domain))
+	})
+
+	logger.Debugf("flushed %d cache entries of zone '%s'", count, util.Obfuscate(zone))
+}
+
 func (r *CachingResolver) FlushCaches(ctx context.Context) {
 	_, logger := r.log(ctx)
 
 	logger.Debug("flush caches")
 	r.resultCache.Clear()
+
+	if r.fastPath != nil {
+		r.fastPath.Flush()
+	}
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
@@ -51,7 +53,7 @@ var _ = Describe("CachingResolver", func() {
 		ctx, cancelFn = context.WithCancel(context.Background())
 		DeferCleanup(cancelFn)
 
-		sut, _ = NewCachingResolver(ctx, sutConfig, nil)
+		sut, _ = NewCachingResolver(ctx, sutConfig, nil, nil, nil)
 		m = &mockResolver{}
 		cacheMock = &mockExpiringCache{}
 		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
@@ -251,6 +253,19 @@ var _ = Describe("CachingResolver", func() {
 						mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 123, A, "123.122.121.120")
 					})
 
+					It("should record the clamped TTL as manipulation", func() {
+						ctx := WithResponseManipulations(ctx)
+
+						_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+						Expect(err).Should(Succeed())
+
+						Expect(responseManipulationsFromCtx(ctx).list()).Should(ConsistOf(SatisfyAll(
+							HaveField("Stage", "caching"),
+							HaveField("Action", manipulationTTLClamped),
+							HaveField("Detail", ContainSubstring("123")),
+						)))
+					})
+
 					It("should cache response and use min caching time as TTL", func() {
 						By("first request", func() {
 							Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
@@ -447,6 +462,185 @@ var _ = Describe("CachingResolver", func() {
 		})
 	})
 
+	Describe("Memory budget", func() {
+		BeforeEach(func() {
+			// enough for two entries
+			sutConfig.MaxSize = 2*cacheEntryOverhead + 150
+		})
+
+		JustBeforeEach(func() {
+			m.ResolveFn = func(_ context.Context, req *Request) (*Response, error) {
+				msg, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "1.1.1.1")
+
+				return &Response{Res: msg, RType: ResponseTypeRESOLVED}, err
+			}
+		})
+
+		resolve := func(domain string) ResponseType {
+			resp, err := sut.Resolve(ctx, newRequest(domain, A))
+			Expect(err).Should(Succeed())
+
+			return resp.RType
+		}
+
+		It("should evict the least recently used entries", func() {
+			Expect(resolve("a.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+			Expect(resolve("b.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+			Expect(resolve("a.example.com.")).Should(Equal(ResponseTypeCACHED))
+
+			Expect(resolve("c.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+
+			Expect(resolve("b.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+			Expect(resolve("c.example.com.")).Should(Equal(ResponseTypeCACHED))
+			Expect(sut.resultCache.TotalCount()).Should(Equal(2))
+		})
+
+		When("prefetching is enabled", func() {
+			BeforeEach(func() {
+				sutConfig.Prefetching = true
+			})
+
+			It("should evict the least recently used entries", func() {
+				Expect(resolve("a.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+				Expect(resolve("b.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+				Expect(resolve("c.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+
+				Expect(resolve("a.example.com.")).Should(Equal(ResponseTypeRESOLVED))
+				Expect(sut.resultCache.TotalCount()).Should(Equal(2))
+			})
+		})
+	})
+
+	Describe("Flush of changed conditional zones", func() {
+		JustBeforeEach(func() {
+			m.ResolveFn = func(_ context.Context, req *Request) (*Response, error) {
+				msg, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "192.168.178.2")
+
+				return &Response{Res: msg, RType: ResponseTypeRESOLVED}, err
+			}
+
+			for _, domain := range []string{"nas.lan.", "lan.", "example.com."} {
+				_, err := sut.Resolve(ctx, newRequest(domain, A))
+				Expect(err).Should(Succeed())
+			}
+		})
+
+		resolve := func(domain string) ResponseType {
+			resp, err := sut.Resolve(ctx, newRequest(domain, A))
+			Expect(err).Should(Succeed())
+
+			return resp.RType
+		}
+
+		When("the cache has a memory budget", func() {
+			BeforeEach(func() {
+				sutConfig.MaxSize = 1024 * 1024
+			})
+
+			It("should remove the cached answers of the zone", func() {
+				Bus().Publish(ConditionalZoneChanged, "lan")
+
+				Expect(resolve("nas.lan.")).Should(Equal(ResponseTypeRESOLVED))
+				Expect(resolve("lan.")).Should(Equal(ResponseTypeRESOLVED))
+				Expect(resolve("example.com.")).Should(Equal(ResponseTypeCACHED))
+			})
+		})
+
+		It("should flush the cache without memory budget", func() {
+			sut.FlushZone(ctx, "lan")
+
+			Expect(resolve("example.com.")).Should(Equal(ResponseTypeRESOLVED))
+		})
+	})
+
+	Describe("Refresh of stale entries", func() {
+		// the refreshes run in the background, the calls of the mock can't be read without race
+		var resolved atomic.Int32
+
+		BeforeEach(func() {
+			sutConfig.Refresh = config.CacheRefresh{Threshold: 10, Budget: 100}
+
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 5, A, "1.1.1.1")
+			resolved.Store(0)
+		})
+
+		JustBeforeEach(func() {
+			answer := mockAnswer
+
+			m.ResolveFn = func(context.Context, *Request) (*Response, error) {
+				resolved.Add(1)
+
+				return &Response{Res: answer.Copy(), RType: ResponseTypeRESOLVED}, nil
+			}
+		})
+
+		It("should serve the cached entry and refresh it in the background", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Eventually(func(g Gomega) {
+				g.Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeCACHED))
+				g.Expect(resolved.Load()).Should(BeEquivalentTo(2))
+			}, "2s", "100ms").Should(Succeed())
+		})
+
+		Describe("refreshIfStale", func() {
+			var cacheKey string
+
+			BeforeEach(func() {
+				cacheKey = util.GenerateCacheKey(A, "example.com")
+			})
+
+			cached := func(ttl uint) *dns.Msg {
+				msg, err := util.NewMsgWithAnswer("example.com.", ttl, A, "1.1.1.1")
+				Expect(err).Should(Succeed())
+
+				return msg
+			}
+
+			It("should not refresh entries below the threshold", func() {
+				sut.refreshIfStale(ctx, cacheKey, cached(100), 95*time.Second)
+
+				Consistently(resolved.Load, "200ms").Should(BeZero())
+			})
+
+			It("should refresh entries past the threshold", func() {
+				sut.refreshIfStale(ctx, cacheKey, cached(100), 85*time.Second)
+
+				Eventually(func() *[]byte {
+					val, _ := sut.resultCache.Get(cacheKey)
+
+					return val
+				}).ShouldNot(BeNil())
+				Expect(resolved.Load()).Should(BeEquivalentTo(1))
+			})
+
+			It("should not refresh negative answers", func() {
+				msg := new(dns.Msg)
+				msg.Rcode = dns.RcodeNameError
+
+				sut.refreshIfStale(ctx, cacheKey, msg, 0)
+
+				Consistently(resolved.Load, "200ms").Should(BeZero())
+			})
+
+			When("the budget is exhausted", func() {
+				BeforeEach(func() {
+					sutConfig.Refresh.Budget = 1
+				})
+
+				It("should skip the refresh", func() {
+					sut.refreshIfStale(ctx, cacheKey, cached(100), 0)
+					sut.refreshIfStale(ctx, util.GenerateCacheKey(A, "other.com"), cached(100), 0)
+
+					Eventually(resolved.Load).Should(BeEquivalentTo(1))
+					Consistently(resolved.Load, "200ms").Should(BeEquivalentTo(1))
+				})
+			})
+		})
+	})
+
 	Describe("Negative cache (caching if upstream resolver returns NXDOMAIN)", func() {
 		Context("Caching if upstream resolver returns NXDOMAIN", func() {
 			When("Upstream resolver returns NXDOMAIN with caching", func() {
@@ -512,7 +706,7 @@ var _ = Describe("CachingResolver", func() {
 							WithArguments(newRequest("example.com.", AAAA)).
 							Should(SatisfyAll(
 								HaveResponseType(ResponseTypeRESOLVED),
-								HaveReason(""),
+								HaveReason("RESOLVED"),
 								HaveReturnCode(dns.RcodeNameError),
 								HaveNoAnswer(),
 							))
@@ -718,6 +912,114 @@ var _ = Describe("CachingResolver", func() {
 		})
 	})
 
+	Describe("Prefetch state persistence", func() {
+		var stateDir *TmpFolder
+
+		BeforeEach(func() {
+			stateDir = NewTmpFolder("PrefetchState")
+			DeferCleanup(stateDir.Clean)
+
+			sutConfig.Prefetching = true
+			sutConfig.PrefetchThreshold = 5
+			sutConfig.PrefetchStateFile = stateDir.JoinPath("prefetch.json")
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 600, A, "123.122.121.120")
+		})
+
+		restart := func() *CachingResolver {
+			res, err := NewCachingResolver(ctx, sutConfig, nil, nil, nil)
+			Expect(err).Should(Succeed())
+
+			return res
+		}
+
+		candidateKeys := func(res *CachingResolver) []string {
+			keys := []string{}
+			for _, c := range res.prefetchCache.Candidates() {
+				keys = append(keys, c.Key)
+			}
+
+			return keys
+		}
+
+		It("should restore the prefetch candidates after a restart", func() {
+			for range 3 {
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+			}
+
+			sut.SavePrefetchState(ctx)
+
+			Expect(stateDir.JoinPath("prefetch.json")).Should(BeARegularFile())
+
+			candidates := restart().prefetchCache.Candidates()
+			Expect(candidates).Should(HaveLen(1))
+			Expect(candidates[0].Key).Should(Equal(util.GenerateCacheKey(A, "example.com")))
+			Expect(candidates[0].Hits).Should(BeEquivalentTo(3))
+		})
+
+		When("the state file doesn't exist", func() {
+			It("should start without candidates", func() {
+				Expect(candidateKeys(sut)).Should(BeEmpty())
+			})
+		})
+
+		When("the state file is invalid", func() {
+			BeforeEach(func() {
+				stateDir.CreateStringFile("prefetch.json", "invalid")
+			})
+
+			It("should start without candidates", func() {
+				Expect(candidateKeys(sut)).Should(BeEmpty())
+			})
+		})
+
+		When("no state file is configured", func() {
+			BeforeEach(func() {
+				sutConfig.PrefetchStateFile = ""
+			})
+
+			It("should not track the candidates", func() {
+				Expect(sut.prefetchCache).Should(BeNil())
+
+				sut.SavePrefetchState(ctx)
+			})
+		})
+
+		When("redis is configured", func() {
+			var redisClient *redis.Client
+
+			BeforeEach(func() {
+				redisServer, err := miniredis.Run()
+				Expect(err).Should(Succeed())
+				DeferCleanup(redisServer.Close)
+
+				var rcfg config.Redis
+				Expect(defaults.Set(&rcfg)).Should(Succeed())
+				rcfg.Address = redisServer.Addr()
+
+				redisClient, err = redis.New(context.TODO(), &rcfg)
+				Expect(err).Should(Succeed())
+
+				sutConfig.PrefetchStateFile = ""
+			})
+
+			It("should restore the prefetch candidates from redis", func() {
+				res, err := NewCachingResolver(ctx, sutConfig, redisClient, nil, nil)
+				Expect(err).Should(Succeed())
+				res.Next(m)
+
+				_, err = res.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				res.SavePrefetchState(ctx)
+
+				restarted, err := NewCachingResolver(ctx, sutConfig, redisClient, nil, nil)
+				Expect(err).Should(Succeed())
+				Expect(candidateKeys(restarted)).Should(ConsistOf(util.GenerateCacheKey(A, "example.com")))
+			})
+		})
+	})
+
 	Describe("Redis is configured", func() {
 		var (
 			redisServer *miniredis.Miniredis
@@ -752,7 +1054,7 @@ var _ = Describe("CachingResolver", func() {
 				}
 				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 1000, A, "1.1.1.1")
 
-				sut, _ = NewCachingResolver(ctx, sutConfig, redisClient)
+				sut, _ = NewCachingResolver(ctx, sutConfig, redisClient, nil, nil)
 				m = &mockResolver{}
 				m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
 				sut.Next(m)
@@ -775,7 +1077,7 @@ var _ = Describe("CachingResolver", func() {
 					Key: cacheKey,
 					Response: &Response{
 						RType:  ResponseTypeCACHED,
-						Reason: "MOCK_REDIS",
+						Reason: NewReason(ReasonCodeEXTERNALCACHE),
 						Res:    mockAnswer,
 					},
 				}
@@ -792,6 +1094,225 @@ var _ = Describe("CachingResolver", func() {
 			})
 		})
 	})
+	Describe("a sync bus without redis is configured", func() {
+		var bus *mockSyncBus
+
+		JustBeforeEach(func() {
+			bus = newMockSyncBus()
+			sutConfig = config.Caching{
+				MaxCachingTime: config.Duration(time.Second * 10),
+			}
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 1000, A, "1.1.1.1")
+
+			sut, _ = NewCachingResolver(ctx, sutConfig, bus, nil, nil)
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
+			sut.Next(m)
+		})
+
+		It("should publish the resolved entries", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Eventually(bus.publishedCache).Should(Receive(Equal(util.GenerateCacheKey(A, "example.com"))))
+		})
+
+		It("should cache the received entries", func() {
+			request := newRequest("example2.com.", A)
+
+			bus.cacheMessages <- &redis.CacheMessage{
+				Key: util.GenerateCacheKey(A, "example2.com"),
+				Response: &Response{
+					RType:  ResponseTypeCACHED,
+					Reason: NewReason(ReasonCodeEXTERNALCACHE),
+					Res:    mockAnswer,
+				},
+			}
+
+			Eventually(sut.Resolve).
+				WithContext(ctx).
+				WithArguments(request).
+				Should(HaveResponseType(ResponseTypeCACHED))
+		})
+	})
+	Describe("Upstream groups", func() {
+		// the answers of the upstream groups, the requests of kids are resolved by the family group
+		answers := map[string]string{"family": "0.0.0.0", "default": "1.1.1.1"}
+
+		upstreamGroup := func(request *Request) string {
+			if request.UpstreamGroup != "" {
+				return request.UpstreamGroup
+			}
+
+			if slices.Contains(request.ClientNames, "kid") {
+				return "family"
+			}
+
+			return "default"
+		}
+
+		var resolvedGroups chan string
+
+		JustBeforeEach(func() {
+			sutConfig.MaxSize = 1024 * 1024
+
+			sut, _ = NewCachingResolver(ctx, sutConfig, nil, nil, upstreamGroup)
+			resolvedGroups = make(chan string, 10)
+
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything)
+			m.ResolveFn = func(_ context.Context, req *Request) (*Response, error) {
+				group := upstreamGroup(req)
+				resolvedGroups <- group
+
+				msg, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, answers[group])
+
+				return &Response{Res: msg, RType: ResponseTypeRESOLVED}, err
+			}
+			sut.Next(m)
+		})
+
+		resolve := func(clientName string) *Response {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", clientName))
+			Expect(err).Should(Succeed())
+
+			return resp
+		}
+
+		It("should cache the answers of each upstream group separately", func() {
+			Expect(resolve("kid")).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeRESOLVED), BeDNSRecord("example.com.", A, "0.0.0.0")))
+			Expect(resolve("adult")).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeRESOLVED), BeDNSRecord("example.com.", A, "1.1.1.1")))
+
+			Expect(resolve("kid")).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeCACHED), BeDNSRecord("example.com.", A, "0.0.0.0")))
+			Expect(resolve("adult")).Should(SatisfyAll(
+				HaveResponseType(ResponseTypeCACHED), BeDNSRecord("example.com.", A, "1.1.1.1")))
+		})
+
+		It("should refresh the entries with their upstream group", func() {
+			resolve("kid")
+			Expect(resolvedGroups).Should(Receive(Equal("family")))
+
+			cacheKey := sut.cacheKey(newRequestWithClient("example.com.", A, "192.168.178.2", "kid"),
+				dns.Question{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET})
+			sut.refreshCacheEntry(ctx, cacheKey)
+
+			Expect(resolvedGroups).Should(Receive(Equal("family")))
+			Expect(resolve("kid")).Should(BeDNSRecord("example.com.", A, "0.0.0.0"))
+		})
+
+		It("should prefetch the entries with their upstream group", func() {
+			cacheKey := sut.cacheKey(newRequestWithClient("example.com.", A, "192.168.178.2", "kid"),
+				dns.Question{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET})
+
+			packed, _ := sut.reloadCacheEntry(ctx, cacheKey)
+			Expect(packed).ShouldNot(BeNil())
+
+			msg := new(dns.Msg)
+			Expect(msg.Unpack(*packed)).Should(Succeed())
+			Expect(msg.Answer).Should(ConsistOf(BeDNSRecord("example.com.", A, "0.0.0.0")))
+			Expect(resolvedGroups).Should(Receive(Equal("family")))
+		})
+
+		It("should flush the entries of all upstream groups of a zone", func() {
+			resolve("kid")
+			resolve("adult")
+
+			sut.FlushZone(ctx, "example.com")
+
+			Expect(resolve("kid")).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(resolve("adult")).Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should extract the upstream group of the cache key", func() {
+			cacheKey := sut.cacheKey(newRequestWithClient("example.com.", A, "192.168.178.2", "kid"),
+				dns.Question{Name: "Example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET})
+
+			qType, domain, group := extractCacheKey(cacheKey)
+			Expect(qType).Should(Equal(A))
+			Expect(domain).Should(Equal("example.com"))
+			Expect(group).Should(Equal("family"))
+
+			qType, domain, group = extractCacheKey(util.GenerateCacheKey(A, "example.com"))
+			Expect(qType).Should(Equal(A))
+			Expect(domain).Should(Equal("example.com"))
+			Expect(group).Should(BeEmpty())
+		})
+	})
+
+	Describe("a fast path is configured", func() {
+		var fastPath *mockFastPath
+
+		JustBeforeEach(func() {
+			fastPath = &mockFastPath{}
+			fastPath.On("Put", mock.Anything, mock.Anything)
+			fastPath.On("FlushZone", mock.Anything)
+			fastPath.On("Flush")
+
+			sutConfig = config.Caching{
+				MaxCachingTime:    config.Duration(time.Second * 10),
+				CacheTimeNegative: config.Duration(time.Minute),
+			}
+
+			sut, _ = NewCachingResolver(ctx, sutConfig, nil, fastPath, nil)
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
+			sut.Next(m)
+		})
+
+		When("the response is cached", func() {
+			BeforeEach(func() {
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 1000, A, "1.1.1.1")
+			})
+
+			It("should put it with the cache time", func() {
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				fastPath.AssertCalled(GinkgoT(), "Put", mock.MatchedBy(func(msg *dns.Msg) bool {
+					return len(msg.Answer) == 1 && msg.Answer[0].Header().Ttl == 10
+				}), 10*time.Second)
+			})
+		})
+
+		When("the response is NXDOMAIN", func() {
+			BeforeEach(func() {
+				mockAnswer = new(dns.Msg)
+				mockAnswer.Rcode = dns.RcodeNameError
+			})
+
+			It("should put it with the negative cache time", func() {
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				fastPath.AssertCalled(GinkgoT(), "Put", mock.Anything, time.Minute)
+			})
+		})
+
+		When("the response is truncated", func() {
+			BeforeEach(func() {
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 1000, A, "1.1.1.1")
+				mockAnswer.Truncated = true
+			})
+
+			It("should not put it", func() {
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				fastPath.AssertNotCalled(GinkgoT(), "Put", mock.Anything, mock.Anything)
+			})
+		})
+
+		It("should flush the fast path with the cache", func() {
+			sut.FlushZone(ctx, "lan")
+			sut.FlushCaches(ctx)
+
+			fastPath.AssertCalled(GinkgoT(), "FlushZone", "lan")
+			fastPath.AssertCalled(GinkgoT(), "Flush")
+		})
+	})
 	Context("isRequestCacheable", func() {
 		var request *Request
 		When("request is not cacheable", func() {
@@ -837,7 +1358,7 @@ var _ = Describe("CachingResolver", func() {
 			sutConfig = config.Caching{Exclude: exclude}
 			mockAnswer, _ = util.NewMsgWithAnswer(domain, 1000, A, "10.0.0.1")
 			request = newRequest(domain, A)
-			sut, _ = NewCachingResolver(ctx, sutConfig, nil)
+			sut, _ = NewCachingResolver(ctx, sutConfig, nil, nil, nil)
 			m.On("Resolve", mock.Anything, mock.Anything).Return(&Response{Res: mockAnswer}, nil)
 			cacheMock.On("Get", mock.Anything).Return([]byte{}, config.Duration(time.Second*10))
 			cacheMock.On("Put", mock.Anything, mock.Anything, mock.Anything).Return()
@@ -847,17 +1368,65 @@ var _ = Describe("CachingResolver", func() {
 
 		When("Exclude settings are wrong", func() {
 			It("should fail", func() {
-				_, err := NewCachingResolver(ctx, config.Caching{Exclude: []string{"/[]/"}}, nil)
+				_, err := NewCachingResolver(ctx, config.Caching{Exclude: []string{"/[]/"}}, nil, nil, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("cache exclusion configuration '/[]/' fail because"))
 			})
 		})
 
-		When("Exclude settings are wrong because of missing slashes", func() {
+		When("Exclude settings contain an unsupported wildcard", func() {
 			It("should fail", func() {
-				_, err := NewCachingResolver(ctx, config.Caching{Exclude: []string{"lan"}}, nil)
+				_, err := NewCachingResolver(ctx, config.Caching{Exclude: []string{"a*.lan"}}, nil, nil, nil)
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("cache exclusion configuration 'lan' fail because of missing slashes"))
+				Expect(err.Error()).To(ContainSubstring("cache exclusion configuration 'a*.lan' fail because"))
+			})
+		})
+
+		When("Query name matches an exact domain of the Exclude setting", func() {
+			BeforeEach(func() {
+				domain = "Canary.Example.com."
+				exclude = []string{"canary.example.com."}
+			})
+			It("should not call cache", func() {
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+				Expect(cacheMock.Calls).Should(BeEmpty())
+			})
+		})
+
+		When("Query name is a subdomain of an exact domain of the Exclude setting", func() {
+			BeforeEach(func() {
+				domain = "sub.canary.example.com."
+				exclude = []string{"canary.example.com"}
+			})
+			It("should call cache", func() {
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+				Expect(cacheMock.Calls).Should(HaveLen(2))
+			})
+		})
+
+		When("Query name matches a wildcard of the Exclude setting", func() {
+			BeforeEach(func() {
+				domain = "host.split.example.com."
+				exclude = []string{"*.split.example.com"}
+			})
+			It("should not call cache", func() {
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+				Expect(cacheMock.Calls).Should(BeEmpty())
+			})
+		})
+
+		When("Query name is the domain of a wildcard of the Exclude setting", func() {
+			BeforeEach(func() {
+				domain = "split.example.com."
+				exclude = []string{"*.split.example.com"}
+			})
+			It("should not call cache", func() {
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+				Expect(cacheMock.Calls).Should(BeEmpty())
 			})
 		})
 
@@ -0,0 +1,174 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/cache"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/lists"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	expirationcache "github.com/0xERR0R/expiration-cache"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	categorizationResolverType = "categorization"
+
+	// failed lookups are cached shortly, so an unavailable API isn't queried for each query
+	categorizationErrorTTL = time.Minute
+	// limits the size of the API responses
+	categorizationMaxResponseSize = 64 * 1024
+)
+
+// CategorizationResolver assigns the categories of the queried domain to the request, so blocking, statistics and
+// the query log can use them. It must be placed after the client names resolver and before all resolvers using
+// the categories.
+type CategorizationResolver struct {
+	configurable[*config.Categorization]
+	NextResolver
+	typed
+
+	// categories of the lists in alphabetical order
+	listCategories []string
+	matcher        lists.Matcher
+
+	client   *http.Client
+	apiCache cache.ExpiringCache[[]string]
+}
+
+// NewCategorizationResolver creates new resolver instance and loads the category lists
+func NewCategorizationResolver(ctx context.Context,
+	cfg config.Categorization, bootstrap *Bootstrap,
+) (*CategorizationResolver, error) {
+	r := &CategorizationResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(categorizationResolverType),
+
+		listCategories: slices.Sorted(maps.Keys(cfg.Sources)),
+	}
+
+	if len(cfg.Sources) != 0 {
+		downloader := lists.NewDownloader(cfg.Loading.Downloads, bootstrap.NewHTTPTransport())
+
+		matcher, err := lists.NewListCache(ctx, lists.ListCacheTypeCategory, cfg.Loading, cfg.Sources, downloader)
+		if err != nil {
+			return nil, err
+		}
+
+		r.matcher = matcher
+	}
+
+	if cfg.API.IsEnabled() {
+		r.client = &http.Client{Transport: bootstrap.NewHTTPTransport(), Timeout: cfg.API.Timeout.ToDuration()}
+		r.apiCache = expirationcache.NewCache[[]string](ctx, expirationcache.Options{MaxSize: cfg.API.CacheSize})
+	}
+
+	return r, nil
+}
+
+// Resolve sets the categories of the request and delegates to the next resolver
+func (r *CategorizationResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	domain := util.ExtractDomain(request.Req.Question[0])
+
+	if categories := r.categoriesOf(ctx, domain); len(categories) != 0 {
+		request.Categories = categories
+
+		ctx, _ = log.CtxWithFields(ctx, logrus.Fields{"categories": strings.Join(categories, ",")})
+	}
+
+	return r.next.Resolve(ctx, request)
+}
+
+// categoriesOf returns the sorted categories of the domain from the lists and the API
+func (r *CategorizationResolver) categoriesOf(ctx context.Context, domain string) []string {
+	var categories []string
+
+	if r.matcher != nil {
+		categories = r.matcher.Match(domain, r.listCategories)
+	}
+
+	if r.cfg.API.IsEnabled() {
+		categories = append(categories, r.apiCategories(ctx, domain)...)
+	}
+
+	slices.Sort(categories)
+
+	return slices.Compact(categories)
+}
+
+// apiCategories returns the categories of the domain from the API, the answers are cached
+func (r *CategorizationResolver) apiCategories(ctx context.Context, domain string) []string {
+	if categories, _ := r.apiCache.Get(domain); categories != nil {
+		return *categories
+	}
+
+	categories, err := r.lookup(ctx, domain)
+	if err != nil {
+		_, logger := r.logWithFields(ctx, logrus.Fields{"domain": domain})
+		logger.WithError(err).Warn("can't look up the categories of the domain")
+
+		r.apiCache.Put(domain, &[]string{}, categorizationErrorTTL)
+
+		return nil
+	}
+
+	r.apiCache.Put(domain, &categories, r.cfg.API.CacheTTL.ToDuration())
+
+	return categories
+}
+
+// lookup queries the API for the categories of the domain
+func (r *CategorizationResolver) lookup(ctx context.Context, domain string) ([]string, error) {
+	endpoint := strings.ReplaceAll(r.cfg.API.URL, config.CategorizationDomainPlaceholder, url.PathEscape(domain))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cfg.API.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.cfg.API.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("categorization API returned status %s", resp.Status)
+	}
+
+	var categories []string
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, categorizationMaxResponseSize)).Decode(&categories); err != nil {
+		return nil, fmt.Errorf("invalid categorization API response: %w", err)
+	}
+
+	result := make([]string, 0, len(categories))
+
+	for _, category := range categories {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			result = append(result, category)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/creasty/defaults"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("CategorizationResolver", func() {
+	var (
+		sut       *CategorizationResolver
+		sutConfig config.Categorization
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		tmpDir := NewTmpFolder("CategorizationResolver")
+		DeferCleanup(tmpDir.Clean)
+
+		socialFile := tmpDir.CreateStringFile("social.txt", "facebook.com", "twitter.com")
+		videoFile := tmpDir.CreateStringFile("video.txt", "youtube.com", "twitter.com")
+
+		Expect(defaults.Set(&sutConfig)).Should(Succeed())
+
+		sutConfig.Sources = map[string][]config.BytesSource{
+			"social": config.NewBytesSources(socialFile.Path),
+			"video":  config.NewBytesSources(videoFile.Path),
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		bootstrap := &Bootstrap{
+			configurable: withConfig(newBootstrapConfig(&config.Config{Upstreams: defaultUpstreamsConfig})),
+			typed:        withType("bootstrap"),
+			dialer:       new(net.Dialer),
+		}
+
+		sut, err = NewCategorizationResolver(ctx, sutConfig, bootstrap)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg), RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	categoriesOf := func(domain string) []string {
+		request := newRequestWithClient(domain, A, "192.168.178.2", "laptop")
+
+		_, err := sut.Resolve(ctx, request)
+		Expect(err).Should(Succeed())
+
+		Expect(m.Calls).ShouldNot(BeEmpty())
+
+		return request.Categories
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("there are no sources and no API", func() {
+			BeforeEach(func() {
+				sutConfig.Sources = nil
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not set categories", func() {
+				Expect(categoriesOf("facebook.com.")).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("category lists", func() {
+		It("should set the categories of the domain", func() {
+			Expect(categoriesOf("facebook.com.")).Should(Equal([]string{"social"}))
+			Expect(categoriesOf("twitter.com.")).Should(Equal([]string{"social", "video"}))
+		})
+
+		It("should not set categories for unknown domains", func() {
+			Expect(categoriesOf("example.com.")).Should(BeEmpty())
+		})
+	})
+
+	Describe("API", func() {
+		var (
+			server   *httptest.Server
+			response string
+			status   int
+			calls    atomic.Int32
+			auth     atomic.Value
+		)
+
+		BeforeEach(func() {
+			response = `["Gaming", " social "]`
+			status = http.StatusOK
+			calls.Store(0)
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				auth.Store(r.Header.Get("Authorization"))
+
+				if r.URL.Path != "/categories/facebook.com" {
+					w.WriteHeader(http.StatusNotFound)
+
+					return
+				}
+
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(response))
+			}))
+			DeferCleanup(server.Close)
+
+			sutConfig.API.URL = server.URL + "/categories/{domain}"
+			sutConfig.API.Token = "secret"
+		})
+
+		It("should merge the categories of the API and the lists", func() {
+			Expect(categoriesOf("facebook.com.")).Should(Equal([]string{"gaming", "social"}))
+			Expect(auth.Load()).Should(Equal("Bearer secret"))
+		})
+
+		It("should cache the answers", func() {
+			categoriesOf("facebook.com.")
+			categoriesOf("facebook.com.")
+
+			Expect(calls.Load()).Should(BeEquivalentTo(1))
+		})
+
+		When("the API fails", func() {
+			BeforeEach(func() {
+				status = http.StatusInternalServerError
+			})
+
+			It("should only use the lists and cache the failure", func() {
+				Expect(categoriesOf("facebook.com.")).Should(Equal([]string{"social"}))
+				Expect(categoriesOf("facebook.com.")).Should(Equal([]string{"social"}))
+
+				Expect(calls.Load()).Should(BeEquivalentTo(1))
+			})
+		})
+
+		When("the response is invalid", func() {
+			BeforeEach(func() {
+				response = `{"categories": "social"}`
+				sutConfig.Sources = nil
+			})
+
+			It("should not set categories", func() {
+				Expect(categoriesOf("facebook.com.")).Should(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/log"
+)
+
+// DescribeChain returns the current resolvers of the chain in the order of processing with their effective
+// configuration and their branches
+func DescribeChain(res Resolver) []api.ResolverNode {
+	var result []api.ResolverNode
+
+	ForEach(res, func(r Resolver) {
+		result = append(result, describeResolver(r))
+	})
+
+	return result
+}
+
+func describeResolver(res Resolver) api.ResolverNode {
+	res = Unwrap(res)
+
+	node := api.ResolverNode{
+		Type:     res.Type(),
+		Name:     res.String(),
+		Enabled:  res.IsEnabled(),
+		Branches: resolverBranches(res),
+	}
+
+	if node.Enabled {
+		node.Config = log.Messages(res.LogConfig)
+	}
+
+	return node
+}
+
+// resolverBranches returns the sub-resolvers, which are selected per query
+func resolverBranches(res Resolver) []api.ResolverBranch {
+	switch r := res.(type) {
+	case *UpstreamTreeResolver:
+		return mappedBranches(r.branches)
+	case *ConditionalUpstreamResolver:
+		return mappedBranches(r.mapping)
+	case *ParallelBestResolver:
+		return upstreamBranches(*r.resolvers.Load())
+	case *StrictResolver:
+		return upstreamBranches(*r.resolvers.Load())
+	case *RewriterResolver:
+		return []api.ResolverBranch{{Name: "rewritten", Resolvers: []api.ResolverNode{describeResolver(r.inner)}}}
+	case *MirroringResolver:
+		if r.shadow != nil {
+			return []api.ResolverBranch{{Name: "shadow", Resolvers: []api.ResolverNode{describeResolver(r.shadow)}}}
+		}
+	case *ClientNamesResolver:
+		if r.externalResolver != nil {
+			return []api.ResolverBranch{{
+				Name:      "client lookup",
+				Resolvers: []api.ResolverNode{describeResolver(r.externalResolver)},
+			}}
+		}
+	}
+
+	return nil
+}
+
+// mappedBranches returns a branch per key, e.g. per upstream group or conditional domain, sorted by key
+func mappedBranches(mapping map[string]Resolver) []api.ResolverBranch {
+	result := make([]api.ResolverBranch, 0, len(mapping))
+
+	for _, key := range slices.Sorted(maps.Keys(mapping)) {
+		result = append(result, api.ResolverBranch{
+			Name:      key,
+			Resolvers: []api.ResolverNode{describeResolver(mapping[key])},
+		})
+	}
+
+	return result
+}
+
+// upstreamBranches returns a branch per upstream of a group in configuration order
+func upstreamBranches(statuses []*upstreamResolverStatus) []api.ResolverBranch {
+	result := make([]api.ResolverBranch, 0, len(statuses))
+
+	for i, status := range statuses {
+		result = append(result, api.ResolverBranch{
+			Name:      fmt.Sprintf("#%d", i+1),
+			Resolvers: []api.ResolverNode{describeResolver(status.resolver)},
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DescribeChain", func() {
+	It("should describe the resolvers of the chain with their branches", func() {
+		first := newUpstreamResolverUnchecked(
+			newUpstreamConfig(config.Upstream{Host: "192.0.2.1", Port: 53, Net: config.NetProtocolTcpUdp},
+				defaultUpstreamsConfig), nil)
+		second := newUpstreamResolverUnchecked(
+			newUpstreamConfig(config.Upstream{Host: "192.0.2.2", Port: 53, Net: config.NetProtocolTcpUdp},
+				defaultUpstreamsConfig), nil)
+
+		tree := &UpstreamTreeResolver{
+			configurable: withConfig(&defaultUpstreamsConfig),
+			typed:        withType(upstreamTreeResolverType),
+			branches: map[string]Resolver{
+				config.UpstreamDefaultCfgName: newParallelBestResolver(
+					config.NewUpstreamGroup(config.UpstreamDefaultCfgName, defaultUpstreamsConfig, nil),
+					[]Resolver{first, second}),
+				"iot": newStrictResolver(
+					config.NewUpstreamGroup("iot", defaultUpstreamsConfig, nil), []Resolver{second}),
+			},
+		}
+
+		chain := Chain(
+			NewFilteringResolver(config.Filtering{}),
+			NewSwappableResolver(NewFilteringResolver(config.Filtering{QueryTypes: config.NewQTypeSet(AAAA)})),
+			tree,
+		)
+
+		nodes := DescribeChain(chain)
+
+		Expect(nodes).Should(HaveLen(3))
+
+		By("disabled resolvers have no configuration", func() {
+			Expect(nodes[0]).Should(Equal(api.ResolverNode{Type: "filtering", Name: "filtering"}))
+		})
+
+		By("swapped resolvers are described with their current configuration", func() {
+			Expect(nodes[1].Enabled).Should(BeTrue())
+			Expect(nodes[1].Config).Should(ContainElement(ContainSubstring("AAAA")))
+		})
+
+		By("branches are sorted by name, the upstreams are in configuration order", func() {
+			Expect(nodes[2].Type).Should(Equal(upstreamTreeResolverType))
+			Expect(nodes[2].Branches).Should(HaveExactElements(
+				SatisfyAll(
+					HaveField("Name", config.UpstreamDefaultCfgName),
+					HaveField("Resolvers", HaveExactElements(HaveField("Type", parallelResolverType))),
+				),
+				SatisfyAll(
+					HaveField("Name", "iot"),
+					HaveField("Resolvers", HaveExactElements(HaveField("Type", strictResolverType))),
+				),
+			))
+
+			upstreams := nodes[2].Branches[0].Resolvers[0].Branches
+			Expect(upstreams).Should(HaveExactElements(
+				SatisfyAll(
+					HaveField("Name", "#1"),
+					HaveField("Resolvers", HaveExactElements(HaveField("Name", ContainSubstring("192.0.2.1")))),
+				),
+				SatisfyAll(
+					HaveField("Name", "#2"),
+					HaveField("Resolvers", HaveExactElements(HaveField("Name", ContainSubstring("192.0.2.2")))),
+				),
+			))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"net"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+)
+
+// matchingClientGroups returns the values of all client identifiers, which match the client of the request.
+//
+// Identifiers are client names (with wildcards), tags (`tag:` prefix), tenants (`tenant:` prefix), IPs, CIDRs and,
+// if `fqdnIPs` is not nil, FQDNs, which are resolved with `fqdnIPs`.
+func matchingClientGroups[T any](
+	request *model.Request, byIdentifier map[string]T, fqdnIPs func(identifier string) []net.IP,
+) []T {
+	var result []T
+
+	// try client names
+	for _, cName := range request.ClientNames {
+		for identifier, value := range byIdentifier {
+			if util.ClientNameMatchesGroupName(identifier, cName) {
+				result = append(result, value)
+			}
+		}
+	}
+
+	// try tags
+	for _, tag := range request.ClientTags {
+		if value, found := byIdentifier[clientTagPrefix+tag]; found {
+			result = append(result, value)
+		}
+	}
+
+	// try tenant
+	if request.Tenant != "" {
+		if value, found := byIdentifier[tenantPrefix+request.Tenant]; found {
+			result = append(result, value)
+		}
+	}
+
+	// try IP
+	if value, found := byIdentifier[request.ClientIP.String()]; found {
+		result = append(result, value)
+	}
+
+	for identifier, value := range byIdentifier {
+		// try CIDR
+		if util.CidrContainsIP(identifier, request.ClientIP) {
+			result = append(result, value)
+		} else if isFQDN(identifier) && fqdnIPs != nil {
+			for _, ip := range fqdnIPs(identifier) {
+				if ip.Equal(request.ClientIP) {
+					result = append(result, value)
+				}
+			}
+		}
+	}
+
+	return result
+}
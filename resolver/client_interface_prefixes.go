@@ -0,0 +1,143 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// interfaceAddrs returns the addresses of a network interface, it's replaced in tests
+//
+//nolint:gochecknoglobals
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return iface.Addrs()
+}
+
+// interfacePrefixes tracks the global IPv6 prefixes of the interfaces, which are referenced by client identifiers
+// `if:<interface>`. The prefixes change, if the ISP delegates a new prefix to the router.
+type interfacePrefixes struct {
+	interfaces []string
+	addrs      func(name string) ([]net.Addr, error)
+	prefixes   atomic.Pointer[map[string][]net.IPNet]
+}
+
+func newInterfacePrefixes(cfg *config.ClientLookup) *interfacePrefixes {
+	p := &interfacePrefixes{addrs: interfaceAddrs}
+
+	for _, prefix := range cfg.InterfacePrefixes() {
+		if !slices.Contains(p.interfaces, prefix.Interface) {
+			p.interfaces = append(p.interfaces, prefix.Interface)
+		}
+	}
+
+	return p
+}
+
+func (p *interfacePrefixes) logger() *logrus.Entry {
+	return log.PrefixedLog("client_interface_prefixes")
+}
+
+// start reads the prefixes and refreshes them periodically until the context is done
+func (p *interfacePrefixes) start(ctx context.Context, interval time.Duration) {
+	if len(p.interfaces) == 0 {
+		return
+	}
+
+	p.refresh()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.refresh()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// refresh reads the prefixes of all interfaces, the current prefixes of an interface are kept if it can't be read
+func (p *interfacePrefixes) refresh() {
+	previous := p.prefixes.Load()
+	prefixes := make(map[string][]net.IPNet, len(p.interfaces))
+
+	for _, name := range p.interfaces {
+		addrs, err := p.addrs(name)
+		if err != nil {
+			p.logger().Warnf("can't read the addresses of the interface %s: %v", name, err)
+
+			if previous != nil {
+				prefixes[name] = (*previous)[name]
+			}
+
+			continue
+		}
+
+		current := globalIPv6Prefixes(addrs)
+
+		if previous == nil || prefixesToString((*previous)[name]) != prefixesToString(current) {
+			p.logger().Infof("prefixes of the interface %s: %s", name, prefixesToString(current))
+		}
+
+		prefixes[name] = current
+	}
+
+	p.prefixes.Store(&prefixes)
+}
+
+// contains returns true, if the identifier is `if:<interface>[/<suffix>/<length>]` and contains the IP
+func (p *interfacePrefixes) contains(identifier string, ip net.IP) bool {
+	identity, ok, err := config.ParseInterfacePrefix(identifier)
+	if !ok || err != nil || ip == nil {
+		return false
+	}
+
+	prefixes := p.prefixes.Load()
+	if prefixes == nil {
+		return false
+	}
+
+	for _, prefix := range (*prefixes)[identity.Interface] {
+		if network, ok := identity.Network(prefix); ok && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globalIPv6Prefixes returns the networks of the global IPv6 addresses, the link-local addresses are ignored
+func globalIPv6Prefixes(addrs []net.Addr) []net.IPNet {
+	var result []net.IPNet
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+
+		prefix := net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+
+		if !slices.ContainsFunc(result, func(p net.IPNet) bool { return p.String() == prefix.String() }) {
+			result = append(result, prefix)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,109 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("interfacePrefixes", func() {
+	var (
+		sut       *ClientNamesResolver
+		sutConfig config.ClientLookup
+		addrs     atomic.Pointer[[]string]
+		addrsErr  atomic.Bool
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		addrs.Store(&[]string{"192.168.178.2/24", "fe80::1/64", "2001:db8:1:2::1/64"})
+		addrsErr.Store(false)
+
+		original := interfaceAddrs
+		DeferCleanup(func() { interfaceAddrs = original })
+
+		interfaceAddrs = func(name string) ([]net.Addr, error) {
+			Expect(name).Should(Equal("eth0"))
+
+			if addrsErr.Load() {
+				return nil, errors.New("interface is down")
+			}
+
+			var result []net.Addr
+
+			for _, addr := range *addrs.Load() {
+				ip, ipNet, err := net.ParseCIDR(addr)
+				Expect(err).Should(Succeed())
+
+				result = append(result, &net.IPNet{IP: ip, Mask: ipNet.Mask})
+			}
+
+			return result, nil
+		}
+
+		sutConfig = config.ClientLookup{
+			Tags: map[string][]string{
+				"lan":     {"if:eth0"},
+				"printer": {"if:eth0/::10/128"},
+			},
+			PrefixRefreshPeriod: config.Duration(timeout),
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewClientNamesResolver(ctx, sutConfig, defaultUpstreamsConfig, nil)
+		Expect(err).Should(Succeed())
+
+		m := &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	tagsOf := func(ip string) []string {
+		request := newRequestWithClient("example.com.", dns.Type(dns.TypeA), ip)
+		Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+		return request.ClientTags
+	}
+
+	It("should tag the clients in the prefixes of the interface", func() {
+		Expect(tagsOf("2001:db8:1:2::10")).Should(Equal([]string{"lan", "printer"}))
+		Expect(tagsOf("2001:db8:1:2:aaaa::1")).Should(Equal([]string{"lan"}))
+		Expect(tagsOf("2001:db8:1:3::10")).Should(BeEmpty())
+		Expect(tagsOf("fe80::10")).Should(BeEmpty())
+		Expect(tagsOf("192.168.178.3")).Should(BeEmpty())
+	})
+
+	It("should follow a new prefix of the interface", func() {
+		addrs.Store(&[]string{"2001:db8:9:2::1/64"})
+
+		Eventually(func() []string { return tagsOf("2001:db8:9:2::10") }, "1s").
+			Should(Equal([]string{"lan", "printer"}))
+		Expect(tagsOf("2001:db8:1:2::10")).Should(BeEmpty())
+	})
+
+	It("should keep the prefixes, if the interface can't be read", func() {
+		addrsErr.Store(true)
+
+		Consistently(func() []string { return tagsOf("2001:db8:1:2::10") }, "200ms").
+			Should(Equal([]string{"lan", "printer"}))
+	})
+})
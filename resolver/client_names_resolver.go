@@ -3,6 +3,7 @@ package resolver
 import (
 	"context"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,6 +26,8 @@ type ClientNamesResolver struct {
 
 	cache            cache.ExpiringCache[[]string]
 	externalResolver Resolver
+	tunnels          *tunnelLookup
+	prefixes         *interfacePrefixes
 }
 
 // NewClientNamesResolver creates new resolver instance
@@ -49,6 +52,13 @@ func NewClientNamesResolver(ctx context.Context,
 		externalResolver: r,
 	}
 
+	// the cached names of the tunnel IPs are outdated, if the peers change
+	cr.tunnels = newTunnelLookup(cr.cfg, cr.FlushCache)
+	cr.tunnels.start(ctx)
+
+	cr.prefixes = newInterfacePrefixes(cr.cfg)
+	cr.prefixes.start(ctx, cr.cfg.PrefixRefreshPeriod.ToDuration())
+
 	return
 }
 
@@ -64,6 +74,7 @@ func (r *ClientNamesResolver) Resolve(ctx context.Context, request *model.Reques
 	clientNames := r.getClientNames(ctx, request)
 
 	request.ClientNames = clientNames
+	request.ClientTags = r.getClientTags(request)
 	ctx, _ = log.CtxWithFields(ctx, logrus.Fields{"client_names": strings.Join(clientNames, "; ")})
 
 	return r.next.Resolve(ctx, request)
@@ -121,6 +132,12 @@ func (r *ClientNamesResolver) resolveClientNames(ctx context.Context, ip net.IP)
 		return result
 	}
 
+	if peer, ok := r.tunnels.peer(ip); ok {
+		logger.WithField("client_names", strings.Join(peer.names, "; ")).Debug("resolved client name(s) from tunnel peer")
+
+		return slices.Clone(peer.names)
+	}
+
 	if r.externalResolver == nil {
 		return []string{ip.String()}
 	}
@@ -168,6 +185,35 @@ func (r *ClientNamesResolver) getNameFromIPMapping(ip net.IP, result []string) [
 	return result
 }
 
+// returns the tags of all tag definitions containing the client's IP, a CIDR containing the IP, a matching client name
+// or an interface prefix (`if:<interface>`) containing the IP and the tag of the DoH endpoint
+func (r *ClientNamesResolver) getClientTags(request *model.Request) []string {
+	var tags []string
+
+	for tag, clients := range r.cfg.Tags {
+		if slices.ContainsFunc(clients, func(client string) bool {
+			return clientMatchesGroup(client, request) || r.prefixes.contains(client, request.ClientIP)
+		}) {
+			tags = append(tags, strings.ToLower(tag))
+		}
+	}
+
+	if request.EndpointTag != "" {
+		tags = append(tags, strings.ToLower(request.EndpointTag))
+	}
+
+	// the ACL tags of the Tailscale device
+	if request.ClientIP != nil {
+		if peer, ok := r.tunnels.peer(request.ClientIP); ok {
+			tags = append(tags, peer.tags...)
+		}
+	}
+
+	slices.Sort(tags)
+
+	return slices.Compact(tags)
+}
+
 // FlushCache reset client name cache
 func (r *ClientNamesResolver) FlushCache() {
 	r.cache.Clear()
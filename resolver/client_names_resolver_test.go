@@ -148,6 +148,51 @@ var _ = Describe("ClientResolver", Label("clientNamesResolver"), func() {
 		})
 	})
 
+	Describe("Resolve client tags", func() {
+		BeforeEach(func() {
+			sutConfig = config.ClientLookup{
+				ClientnameIPMapping: map[string][]net.IP{
+					"cam-garden": {net.ParseIP("1.2.3.4")},
+				},
+				Tags: map[string][]string{
+					"IoT":    {"cam-*", "192.168.20.0/24"},
+					"kids":   {"1.2.3.5"},
+					"guests": {"10.0.0.0/8"},
+				},
+			}
+		})
+		AfterEach(func() {
+			// next resolver will be called
+			m.AssertExpectations(GinkgoT())
+		})
+
+		It("should tag the client by name", func() {
+			request := newRequestWithClient("google.de.", dns.Type(dns.TypeA), "1.2.3.4")
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientTags).Should(Equal([]string{"iot"}))
+		})
+
+		It("should tag the client by IP and CIDR", func() {
+			request := newRequestWithClient("google.de.", dns.Type(dns.TypeA), "1.2.3.5")
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientTags).Should(Equal([]string{"kids"}))
+
+			request = newRequestWithClient("google.de.", dns.Type(dns.TypeA), "192.168.20.7")
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientTags).Should(Equal([]string{"iot"}))
+		})
+
+		It("should not tag unknown clients", func() {
+			request := newRequestWithClient("google.de.", dns.Type(dns.TypeA), "172.16.0.1")
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.ClientTags).Should(BeEmpty())
+		})
+	})
+
 	Describe("Resolve client name via rDNS lookup", func() {
 		var testUpstream *MockUDPUpstreamServer
 
@@ -0,0 +1,325 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+)
+
+const (
+	clientStatsResolverType = "client_stats"
+
+	clientStatsDayFormat  = "2006-01-02"
+	clientStatsSavePeriod = time.Minute
+	// limits the memory usage of clients querying many different domains
+	clientStatsMaxDomains = 1000
+)
+
+// ClientStatsResolver aggregates query statistics per client and day, independent of the query log
+type ClientStatsResolver struct {
+	configurable[*config.ClientStats]
+	NextResolver
+	typed
+
+	lock sync.Mutex
+	// statistics per day (formatted with `clientStatsDayFormat`) and client (see `clientStatsKey`)
+	days map[string]map[string]*clientCounters
+	// true if the statistics changed since the last save
+	dirty bool
+}
+
+type clientCounters struct {
+	Tenant        string           `json:"tenant,omitempty"`
+	Total         int64            `json:"total"`
+	Blocked       int64            `json:"blocked"`
+	ResponseTypes map[string]int64 `json:"responseTypes"`
+	Domains       map[string]int64 `json:"domains"`
+	// nil until the client queries a categorized domain
+	Categories map[string]int64 `json:"categories,omitempty"`
+}
+
+// NewClientStatsResolver creates new resolver instance and loads the stored statistics
+func NewClientStatsResolver(ctx context.Context, cfg config.ClientStats) (*ClientStatsResolver, error) {
+	r := &ClientStatsResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(clientStatsResolverType),
+
+		days: make(map[string]map[string]*clientCounters),
+	}
+
+	if !cfg.IsEnabled() || cfg.StoreFile == "" {
+		return r, nil
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.periodicSave(ctx)
+
+	return r, nil
+}
+
+// Resolve counts the query and its response for the client
+func (r *ClientStatsResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	response, err := r.next.Resolve(ctx, request)
+
+	if err == nil && r.IsEnabled() {
+		r.record(request, response, time.Now())
+	}
+
+	return response, err
+}
+
+func (r *ClientStatsResolver) record(request *model.Request, response *model.Response, now time.Time) {
+	client := clientStatsKey(request.Tenant, strings.Join(request.ClientNames, ","))
+	domain := util.ExtractDomain(request.Req.Question[0])
+	day := now.Format(clientStatsDayFormat)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	clients, ok := r.days[day]
+	if !ok {
+		clients = make(map[string]*clientCounters)
+		r.days[day] = clients
+
+		r.pruneDays(now)
+	}
+
+	counters, ok := clients[client]
+	if !ok {
+		counters = &clientCounters{
+			Tenant:        request.Tenant,
+			ResponseTypes: make(map[string]int64),
+			Domains:       make(map[string]int64),
+		}
+		clients[client] = counters
+	}
+
+	counters.Total++
+	counters.ResponseTypes[response.RType.String()]++
+
+	if response.RType == model.ResponseTypeBLOCKED {
+		counters.Blocked++
+	}
+
+	if _, ok := counters.Domains[domain]; ok || len(counters.Domains) < clientStatsMaxDomains {
+		counters.Domains[domain]++
+	}
+
+	counters.addCategories(request.Categories, 1)
+
+	r.dirty = true
+}
+
+// addCategories adds the count to each category
+func (c *clientCounters) addCategories(categories []string, count int64) {
+	if len(categories) == 0 {
+		return
+	}
+
+	if c.Categories == nil {
+		c.Categories = make(map[string]int64, len(categories))
+	}
+
+	for _, category := range categories {
+		c.Categories[category] += count
+	}
+}
+
+// clientStatsKey separates the statistics of clients with the same name in different tenants
+func clientStatsKey(tenant, client string) string {
+	if tenant == "" {
+		return client
+	}
+
+	return tenant + "/" + client
+}
+
+// pruneDays removes the days outside of the retention
+func (r *ClientStatsResolver) pruneDays(now time.Time) {
+	oldest := r.firstDay(now, int(r.cfg.RetentionDays))
+
+	for day := range r.days {
+		if day < oldest {
+			delete(r.days, day)
+		}
+	}
+}
+
+// firstDay returns the first day of the period with the count of days ending today
+func (r *ClientStatsResolver) firstDay(now time.Time, days int) string {
+	return now.AddDate(0, 0, 1-days).Format(clientStatsDayFormat)
+}
+
+// ClientStats implements `api.ClientStatsProvider`
+func (r *ClientStatsResolver) ClientStats(days int) ([]api.ClientStats, error) {
+	if !r.IsEnabled() {
+		return nil, api.ErrClientStatsDisabled
+	}
+
+	if days <= 0 || days > int(r.cfg.RetentionDays) {
+		days = int(r.cfg.RetentionDays)
+	}
+
+	oldest := r.firstDay(time.Now(), days)
+
+	r.lock.Lock()
+
+	aggregated := make(map[string]*clientCounters)
+
+	for day, clients := range r.days {
+		if day < oldest {
+			continue
+		}
+
+		for client, counters := range clients {
+			sum, ok := aggregated[client]
+			if !ok {
+				sum = &clientCounters{
+					Tenant:        counters.Tenant,
+					ResponseTypes: make(map[string]int64),
+					Domains:       make(map[string]int64),
+				}
+				aggregated[client] = sum
+			}
+
+			sum.Total += counters.Total
+			sum.Blocked += counters.Blocked
+
+			for responseType, count := range counters.ResponseTypes {
+				sum.ResponseTypes[responseType] += count
+			}
+
+			for domain, count := range counters.Domains {
+				sum.Domains[domain] += count
+			}
+
+			for category, count := range counters.Categories {
+				sum.addCategories([]string{category}, count)
+			}
+		}
+	}
+
+	r.lock.Unlock()
+
+	result := make([]api.ClientStats, 0, len(aggregated))
+
+	for client, counters := range aggregated {
+		result = append(result, api.ClientStats{
+			Client:        strings.TrimPrefix(client, clientStatsKey(counters.Tenant, "")),
+			Tenant:        counters.Tenant,
+			Total:         counters.Total,
+			Blocked:       counters.Blocked,
+			ResponseTypes: counters.ResponseTypes,
+			TopDomains:    r.topDomains(counters.Domains),
+			Categories:    counters.Categories,
+		})
+	}
+
+	slices.SortFunc(result, func(a, b api.ClientStats) int {
+		return cmp.Or(cmp.Compare(a.Tenant, b.Tenant), cmp.Compare(a.Client, b.Client))
+	})
+
+	return result, nil
+}
+
+// topDomains returns the most queried domains
+func (r *ClientStatsResolver) topDomains(domains map[string]int64) []api.DomainCount {
+	result := make([]api.DomainCount, 0, len(domains))
+
+	for domain, count := range domains {
+		result = append(result, api.DomainCount{Domain: domain, Count: count})
+	}
+
+	slices.SortFunc(result, func(a, b api.DomainCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Domain, b.Domain))
+	})
+
+	return result[:min(len(result), int(r.cfg.TopDomains))]
+}
+
+// saves the changed statistics periodically and on shutdown
+func (r *ClientStatsResolver) periodicSave(ctx context.Context) {
+	ticker := time.NewTicker(clientStatsSavePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.saveLogged()
+		case <-ctx.Done():
+			r.saveLogged()
+
+			return
+		}
+	}
+}
+
+func (r *ClientStatsResolver) saveLogged() {
+	if err := r.save(); err != nil {
+		log.PrefixedLog(clientStatsResolverType).Errorf("can't save client statistics: %s", err)
+	}
+}
+
+// load reads the stored statistics, a missing file starts without statistics
+func (r *ClientStatsResolver) load() error {
+	content, err := os.ReadFile(r.cfg.StoreFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't read client statistics store: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &r.days); err != nil {
+		return fmt.Errorf("invalid client statistics store: %w", err)
+	}
+
+	r.pruneDays(time.Now())
+
+	return nil
+}
+
+// save writes all statistics to the store file, if they changed since the last save
+func (r *ClientStatsResolver) save() error {
+	r.lock.Lock()
+
+	if !r.dirty {
+		r.lock.Unlock()
+
+		return nil
+	}
+
+	content, err := json.Marshal(r.days)
+
+	r.dirty = false
+	r.lock.Unlock()
+
+	if err == nil {
+		err = writeFileAtomic(r.cfg.StoreFile, content)
+	}
+
+	if err != nil {
+		r.lock.Lock()
+		r.dirty = true
+		r.lock.Unlock()
+	}
+
+	return err
+}
@@ -0,0 +1,295 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ClientStatsResolver", func() {
+	var (
+		sut        *ClientStatsResolver
+		sutConfig  config.ClientStats
+		m          *mockResolver
+		mockAnswer *dns.Msg
+		tmpDir     *TmpFolder
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		tmpDir = NewTmpFolder("ClientStatsResolver")
+		DeferCleanup(tmpDir.Clean)
+
+		sutConfig = config.ClientStats{
+			Enable:        true,
+			RetentionDays: 30,
+			TopDomains:    2,
+			StoreFile:     tmpDir.JoinPath("client-stats.json"),
+		}
+
+		mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewClientStatsResolver(ctx, sutConfig)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.MatchedBy(func(req *Request) bool {
+			return req.Req.Question[0].Name == "blocked.com."
+		})).Return(&Response{Res: new(dns.Msg), RType: ResponseTypeBLOCKED}, nil)
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	query := func(domain string, clientNames ...string) {
+		_, err := sut.Resolve(ctx, newRequestWithClient(domain, A, "192.168.178.2", clientNames...))
+		Expect(err).Should(Succeed())
+	}
+
+	// returns the date of the day relative to today
+	day := func(offset int) string {
+		return time.Now().AddDate(0, 0, offset).Format(clientStatsDayFormat)
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not count queries", func() {
+				query("example.com.", "laptop")
+
+				Expect(sut.days).Should(BeEmpty())
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+
+			It("should return an error for the statistics", func() {
+				_, err := sut.ClientStats(0)
+				Expect(err).Should(MatchError(api.ErrClientStatsDisabled))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("ClientStats", func() {
+		It("should aggregate the queries per client", func() {
+			query("example.com.", "laptop")
+			query("www.example.com.", "laptop")
+			query("example.com.", "laptop")
+			query("blocked.com.", "laptop")
+			query("example.com.", "phone", "phone.lan")
+
+			Expect(sut.ClientStats(0)).Should(Equal([]api.ClientStats{
+				{
+					Client:        "laptop",
+					Total:         4,
+					Blocked:       1,
+					ResponseTypes: map[string]int64{"RESOLVED": 3, "BLOCKED": 1},
+					TopDomains: []api.DomainCount{
+						{Domain: "example.com", Count: 2},
+						{Domain: "blocked.com", Count: 1},
+					},
+				},
+				{
+					Client:        "phone,phone.lan",
+					Total:         1,
+					ResponseTypes: map[string]int64{"RESOLVED": 1},
+					TopDomains:    []api.DomainCount{{Domain: "example.com", Count: 1}},
+				},
+			}))
+		})
+
+		It("should separate the clients of the tenants", func() {
+			query("example.com.", "laptop")
+
+			for _, tenant := range []string{"smith", "smith", "miller"} {
+				request := newRequestWithClient("example.com.", A, "10.1.0.2", "laptop")
+				request.Tenant = tenant
+
+				_, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+			}
+
+			stats, err := sut.ClientStats(0)
+			Expect(err).Should(Succeed())
+			Expect(stats).Should(HaveLen(3))
+
+			Expect(stats[0]).Should(SatisfyAll(
+				HaveField("Client", "laptop"), HaveField("Tenant", BeEmpty()), HaveField("Total", BeEquivalentTo(1))))
+			Expect(stats[1]).Should(SatisfyAll(
+				HaveField("Client", "laptop"), HaveField("Tenant", Equal("miller")), HaveField("Total", BeEquivalentTo(1))))
+			Expect(stats[2]).Should(SatisfyAll(
+				HaveField("Client", "laptop"), HaveField("Tenant", Equal("smith")), HaveField("Total", BeEquivalentTo(2))))
+		})
+
+		It("should count the queries per category", func() {
+			query("example.com.", "laptop")
+
+			for _, categories := range [][]string{{"social"}, {"social", "video"}} {
+				request := newRequestWithClient("example.com.", A, "192.168.178.2", "laptop")
+				request.Categories = categories
+
+				_, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+			}
+
+			stats, err := sut.ClientStats(0)
+			Expect(err).Should(Succeed())
+			Expect(stats).Should(HaveLen(1))
+			Expect(stats[0].Categories).Should(Equal(map[string]int64{"social": 2, "video": 1}))
+		})
+
+		It("should return no statistics without queries", func() {
+			Expect(sut.ClientStats(0)).Should(BeEmpty())
+		})
+
+		It("should not count failed queries", func() {
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(nil, errors.New("failed"))
+			sut.Next(m)
+
+			_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"))
+			Expect(err).Should(HaveOccurred())
+
+			Expect(sut.ClientStats(0)).Should(BeEmpty())
+		})
+
+		When("statistics of several days are stored", func() {
+			BeforeEach(func() {
+				tmpDir.CreateStringFile("client-stats.json", `{
+					"`+day(-1)+`": {"laptop": {"total": 2, "blocked": 0,
+						"responseTypes": {"CACHED": 2}, "domains": {"example.com": 2}}},
+					"`+day(-7)+`": {"laptop": {"total": 5, "blocked": 5,
+						"responseTypes": {"BLOCKED": 5}, "domains": {"blocked.com": 5}}},
+					"`+day(-30)+`": {"laptop": {"total": 1, "blocked": 0,
+						"responseTypes": {"RESOLVED": 1}, "domains": {"old.com": 1}}}
+				}`)
+			})
+
+			It("should aggregate all retained days", func() {
+				query("example.com.", "laptop")
+
+				Expect(sut.days).ShouldNot(HaveKey(day(-30)))
+
+				Expect(sut.ClientStats(0)).Should(Equal([]api.ClientStats{
+					{
+						Client:        "laptop",
+						Total:         8,
+						Blocked:       5,
+						ResponseTypes: map[string]int64{"RESOLVED": 1, "CACHED": 2, "BLOCKED": 5},
+						TopDomains: []api.DomainCount{
+							{Domain: "blocked.com", Count: 5},
+							{Domain: "example.com", Count: 3},
+						},
+					},
+				}))
+			})
+
+			It("should aggregate the last days", func() {
+				query("example.com.", "laptop")
+
+				stats, err := sut.ClientStats(2)
+				Expect(err).Should(Succeed())
+				Expect(stats).Should(HaveLen(1))
+				Expect(stats[0].Total).Should(BeNumerically("==", 3))
+				Expect(stats[0].TopDomains).Should(Equal([]api.DomainCount{{Domain: "example.com", Count: 3}}))
+			})
+		})
+
+		It("should limit the tracked domains", func() {
+			counters := &clientCounters{ResponseTypes: map[string]int64{}, Domains: map[string]int64{}}
+			for i := range clientStatsMaxDomains {
+				counters.Domains[fmt.Sprintf("domain%d.com", i)] = 1
+			}
+
+			sut.days[day(0)] = map[string]*clientCounters{"laptop": counters}
+
+			query("new.com.", "laptop")
+			query("new.com.", "laptop")
+
+			Expect(counters.Domains).Should(HaveLen(clientStatsMaxDomains))
+			Expect(counters.Domains).ShouldNot(HaveKey("new.com"))
+			Expect(counters.Total).Should(BeNumerically("==", 2))
+		})
+	})
+
+	Describe("store", func() {
+		It("should persist the statistics", func() {
+			query("example.com.", "laptop")
+
+			Expect(sut.save()).Should(Succeed())
+
+			reloaded, err := NewClientStatsResolver(ctx, sutConfig)
+			Expect(err).Should(Succeed())
+
+			expected, err := sut.ClientStats(0)
+			Expect(err).Should(Succeed())
+
+			Expect(reloaded.ClientStats(0)).Should(Equal(expected))
+		})
+
+		It("should save the statistics on shutdown", func() {
+			query("example.com.", "laptop")
+
+			cancelFn()
+
+			Eventually(func(g Gomega) {
+				content, err := os.ReadFile(sutConfig.StoreFile)
+				g.Expect(err).Should(Succeed())
+				g.Expect(string(content)).Should(ContainSubstring(`"laptop"`))
+			}).Should(Succeed())
+		})
+
+		It("should fail on invalid stores", func() {
+			tmpDir.CreateStringFile("client-stats.json", "invalid")
+
+			_, err := NewClientStatsResolver(ctx, sutConfig)
+			Expect(err).Should(MatchError(ContainSubstring("invalid client statistics store")))
+		})
+	})
+})
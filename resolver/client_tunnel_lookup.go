@@ -0,0 +1,328 @@
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// the local API of tailscaled accepts only requests for this host
+const tailscaleLocalAPIURL = "http://local-tailscaled.sock/localapi/v0/status"
+
+// tunnelPeer is a device of a VPN with its names and its tags (Tailscale ACL tags without the prefix `tag:`)
+type tunnelPeer struct {
+	names []string
+	tags  []string
+}
+
+// tunnelSource loads the peers of a VPN periodically, the peers are mapped by their tunnel IPs
+type tunnelSource struct {
+	name     string
+	interval time.Duration
+	load     func(ctx context.Context) (map[string]tunnelPeer, error)
+
+	peers atomic.Pointer[map[string]tunnelPeer]
+}
+
+// tunnelLookup maps the tunnel IPs of Tailscale and WireGuard devices to their names
+type tunnelLookup struct {
+	sources []*tunnelSource
+	// called after the peers of a source changed
+	onChange func()
+}
+
+func newTunnelLookup(cfg *config.ClientLookup, onChange func()) *tunnelLookup {
+	l := &tunnelLookup{onChange: onChange}
+
+	if cfg.Tailscale.IsEnabled() {
+		l.sources = append(l.sources, &tunnelSource{
+			name:     "tailscale",
+			interval: cfg.Tailscale.RefreshPeriod.ToDuration(),
+			load:     newTailscaleStatusLoader(cfg.Tailscale.Socket),
+		})
+	}
+
+	if cfg.WireGuard.IsEnabled() {
+		l.sources = append(l.sources, &tunnelSource{
+			name:     "wireguard",
+			interval: cfg.WireGuard.RefreshPeriod.ToDuration(),
+			load: func(context.Context) (map[string]tunnelPeer, error) {
+				return loadWireGuardPeers(cfg.WireGuard.Configs)
+			},
+		})
+	}
+
+	return l
+}
+
+func (l *tunnelLookup) logger() *logrus.Entry {
+	return log.PrefixedLog("client_tunnel_lookup")
+}
+
+// start loads the peers of all sources and refreshes them periodically until the context is done
+func (l *tunnelLookup) start(ctx context.Context) {
+	for _, source := range l.sources {
+		l.refresh(ctx, source)
+
+		go func() {
+			ticker := time.NewTicker(source.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					l.refresh(ctx, source)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// refresh loads the peers of the source, the current peers are kept if the source can't be read
+func (l *tunnelLookup) refresh(ctx context.Context, source *tunnelSource) {
+	peers, err := source.load(ctx)
+	if err != nil {
+		l.logger().Warnf("can't load the %s peers: %v", source.name, err)
+
+		return
+	}
+
+	previous := source.peers.Swap(&peers)
+	if previous != nil && fmt.Sprint(*previous) == fmt.Sprint(peers) {
+		return
+	}
+
+	l.logger().Debugf("loaded %d %s peers", len(peers), source.name)
+
+	if l.onChange != nil {
+		l.onChange()
+	}
+}
+
+// peer returns the peer with the tunnel IP from the first source, which contains it
+func (l *tunnelLookup) peer(ip net.IP) (tunnelPeer, bool) {
+	for _, source := range l.sources {
+		if peers := source.peers.Load(); peers != nil {
+			if peer, ok := (*peers)[ip.String()]; ok {
+				return peer, true
+			}
+		}
+	}
+
+	return tunnelPeer{}, false
+}
+
+// tailscaleStatus contains the needed fields of the response of `/localapi/v0/status`
+type tailscaleStatus struct {
+	Self *tailscalePeerStatus           `json:"Self"`
+	Peer map[string]tailscalePeerStatus `json:"Peer"`
+}
+
+type tailscalePeerStatus struct {
+	HostName     string   `json:"HostName"`
+	DNSName      string   `json:"DNSName"`
+	TailscaleIPs []string `json:"TailscaleIPs"`
+	Tags         []string `json:"Tags"`
+}
+
+// newTailscaleStatusLoader returns a loader, which requests the status of the tailnet via the socket of tailscaled
+func newTailscaleStatusLoader(socket string) func(ctx context.Context) (map[string]tunnelPeer, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second, //nolint:mnd
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	return func(ctx context.Context) (map[string]tunnelPeer, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tailscaleLocalAPIURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("local API returned status %s", resp.Status)
+		}
+
+		var status tailscaleStatus
+
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			return nil, fmt.Errorf("can't decode the status: %w", err)
+		}
+
+		peers := make(map[string]tunnelPeer)
+
+		devices := make([]tailscalePeerStatus, 0, len(status.Peer)+1)
+		if status.Self != nil {
+			devices = append(devices, *status.Self)
+		}
+
+		for _, device := range status.Peer {
+			devices = append(devices, device)
+		}
+
+		for _, device := range devices {
+			peer := tailscalePeer(device)
+			if len(peer.names) == 0 {
+				continue
+			}
+
+			for _, ip := range device.TailscaleIPs {
+				if parsed := net.ParseIP(ip); parsed != nil {
+					peers[parsed.String()] = peer
+				}
+			}
+		}
+
+		return peers, nil
+	}
+}
+
+// tailscalePeer uses the MagicDNS name of a device without the tailnet domain or its host name
+func tailscalePeer(device tailscalePeerStatus) tunnelPeer {
+	var peer tunnelPeer
+
+	if name, _, _ := strings.Cut(device.DNSName, "."); name != "" {
+		peer.names = append(peer.names, name)
+	} else if device.HostName != "" {
+		peer.names = append(peer.names, device.HostName)
+	}
+
+	for _, tag := range device.Tags {
+		peer.tags = append(peer.tags, strings.ToLower(strings.TrimPrefix(tag, "tag:")))
+	}
+
+	return peer
+}
+
+// loadWireGuardPeers reads the peers of the configuration files
+func loadWireGuardPeers(files []string) (map[string]tunnelPeer, error) {
+	peers := make(map[string]tunnelPeer)
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		err = parseWireGuardPeers(f, peers)
+
+		f.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s: %w", file, err)
+		}
+	}
+
+	return peers, nil
+}
+
+// parseWireGuardPeers adds the host addresses in `AllowedIPs` of each named peer, routed subnets are ignored
+func parseWireGuardPeers(r io.Reader, peers map[string]tunnelPeer) error {
+	var (
+		inPeer  bool
+		name    string
+		comment string
+		ips     []string
+	)
+
+	addPeer := func() {
+		if inPeer && name != "" {
+			for _, ip := range ips {
+				peers[ip] = tunnelPeer{names: []string{name}}
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "["):
+			addPeer()
+
+			inPeer = strings.EqualFold(line, "[Peer]")
+			name, ips = "", nil
+
+			if inPeer {
+				// the comment above the section
+				name, _ = wireGuardPeerName(comment)
+			}
+		case strings.HasPrefix(line, "#"):
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+
+			// the first name is used, the name comment of the next peer can follow the current section
+			if value, isName := wireGuardPeerName(comment); isName && inPeer && name == "" {
+				name = value
+			}
+
+			continue
+		case inPeer:
+			if key, value, found := strings.Cut(line, "="); found &&
+				strings.EqualFold(strings.TrimSpace(key), "AllowedIPs") {
+				ips = append(ips, wireGuardHostIPs(value)...)
+			}
+		}
+
+		comment = ""
+	}
+
+	addPeer()
+
+	return scanner.Err()
+}
+
+// wireGuardPeerName returns the value of a comment `Name = <name>` and true or the whole comment and false
+func wireGuardPeerName(comment string) (string, bool) {
+	if key, value, found := strings.Cut(comment, "="); found && strings.EqualFold(strings.TrimSpace(key), "name") {
+		return strings.TrimSpace(value), true
+	}
+
+	return comment, false
+}
+
+// wireGuardHostIPs returns the addresses of the /32 and /128 prefixes of the list
+func wireGuardHostIPs(allowedIPs string) []string {
+	var ips []string
+
+	for _, prefix := range strings.Split(allowedIPs, ",") {
+		ip, ipNet, err := net.ParseCIDR(strings.TrimSpace(prefix))
+		if err != nil {
+			continue
+		}
+
+		if ones, bits := ipNet.Mask.Size(); ones == bits && !slices.Contains(ips, ip.String()) {
+			ips = append(ips, ip.String())
+		}
+	}
+
+	return ips
+}
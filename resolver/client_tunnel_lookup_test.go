@@ -0,0 +1,203 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+const wireGuardTestConfig = `
+[Interface]
+Address = 10.8.0.1/24
+ListenPort = 51820
+
+# phone
+[Peer]
+PublicKey = a
+AllowedIPs = 10.8.0.2/32, fd00::2/128
+
+[Peer]
+# Name = laptop
+PublicKey = b
+AllowedIPs = 10.8.0.3/32, 192.168.50.0/24
+
+[Peer]
+PublicKey = c
+AllowedIPs = 10.8.0.4/32
+`
+
+var _ = Describe("tunnelLookup", func() {
+	var (
+		sut       *ClientNamesResolver
+		sutConfig config.ClientLookup
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.ClientLookup{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewClientNamesResolver(ctx, sutConfig, defaultUpstreamsConfig, nil)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	resolve := func(ip string) *Request {
+		request := newRequestWithClient("example.com.", dns.Type(dns.TypeA), ip)
+		Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+		return request
+	}
+
+	Describe("WireGuard", func() {
+		var file string
+
+		BeforeEach(func() {
+			file = TempFile(wireGuardTestConfig).Name()
+			DeferCleanup(os.Remove, file)
+
+			sutConfig.WireGuard = config.WireGuardLookup{Configs: []string{file}, RefreshPeriod: config.Duration(timeout)}
+		})
+
+		It("should use the names of the peers", func() {
+			Expect(resolve("10.8.0.2").ClientNames).Should(Equal([]string{"phone"}))
+			Expect(resolve("fd00::2").ClientNames).Should(Equal([]string{"phone"}))
+			Expect(resolve("10.8.0.3").ClientNames).Should(Equal([]string{"laptop"}))
+		})
+
+		It("should ignore unnamed peers and routed subnets", func() {
+			Expect(resolve("10.8.0.4").ClientNames).Should(Equal([]string{"10.8.0.4"}))
+			Expect(resolve("192.168.50.0").ClientNames).Should(Equal([]string{"192.168.50.0"}))
+		})
+
+		It("should use the changed peers after a refresh", func() {
+			Expect(resolve("10.8.0.2").ClientNames).Should(Equal([]string{"phone"}))
+
+			Expect(os.WriteFile(file, []byte("[Peer]\n# Name = tablet\nAllowedIPs = 10.8.0.2/32\n"), 0o600)).
+				Should(Succeed())
+
+			Eventually(func() []string { return resolve("10.8.0.2").ClientNames }, "1s").
+				Should(Equal([]string{"tablet"}))
+		})
+	})
+
+	Describe("Tailscale", func() {
+		var status atomic.Pointer[map[string]any]
+
+		BeforeEach(func() {
+			dir, err := os.MkdirTemp("", "ts")
+			Expect(err).Should(Succeed())
+			DeferCleanup(os.RemoveAll, dir)
+
+			socket := filepath.Join(dir, "tailscaled.sock")
+
+			listener, err := net.Listen("unix", socket)
+			Expect(err).Should(Succeed())
+
+			status.Store(&map[string]any{
+				"Self": map[string]any{
+					"HostName": "blocky", "DNSName": "blocky.tail1234.ts.net.", "TailscaleIPs": []string{"100.64.0.1"},
+				},
+				"Peer": map[string]any{
+					"key1": map[string]any{
+						"HostName":     "Kid-Laptop",
+						"DNSName":      "kid-laptop.tail1234.ts.net.",
+						"TailscaleIPs": []string{"100.64.0.2", "fd7a:115c:a1e0::2"},
+						"Tags":         []string{"tag:Kids"},
+					},
+				},
+			})
+
+			server := &http.Server{
+				ReadHeaderTimeout: timeout,
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					Expect(r.Host).Should(Equal("local-tailscaled.sock"))
+					Expect(r.URL.Path).Should(Equal("/localapi/v0/status"))
+
+					_ = json.NewEncoder(w).Encode(*status.Load())
+				}),
+			}
+
+			go func() { _ = server.Serve(listener) }()
+			DeferCleanup(server.Close)
+
+			sutConfig.Tailscale = config.TailscaleLookup{Enable: true, Socket: socket, RefreshPeriod: config.Duration(timeout)}
+			sutConfig.Tags = map[string][]string{"iot": {"100.64.0.0/10"}}
+		})
+
+		It("should use the MagicDNS names and the ACL tags of the devices", func() {
+			request := resolve("fd7a:115c:a1e0::2")
+
+			Expect(request.ClientNames).Should(Equal([]string{"kid-laptop"}))
+			Expect(request.ClientTags).Should(Equal([]string{"kids"}))
+
+			request = resolve("100.64.0.2")
+
+			Expect(request.ClientNames).Should(Equal([]string{"kid-laptop"}))
+			Expect(request.ClientTags).Should(Equal([]string{"iot", "kids"}))
+
+			Expect(resolve("100.64.0.1").ClientNames).Should(Equal([]string{"blocky"}))
+		})
+
+		When("tailscaled is not running", func() {
+			BeforeEach(func() {
+				sutConfig.Tailscale.Socket = filepath.Join(os.TempDir(), "missing.sock")
+			})
+
+			It("should use the IP as name", func() {
+				Expect(resolve("100.64.0.2").ClientNames).Should(Equal([]string{"100.64.0.2"}))
+			})
+		})
+	})
+
+	DescribeTable("parseWireGuardPeers",
+		func(cfg string, want map[string]string) {
+			peers := make(map[string]tunnelPeer)
+			Expect(parseWireGuardPeers(strings.NewReader(cfg), peers)).Should(Succeed())
+
+			names := make(map[string]string)
+			for ip, peer := range peers {
+				names[ip] = strings.Join(peer.names, ",")
+			}
+
+			Expect(names).Should(Equal(want))
+		},
+		Entry("comment above the section", "# phone\n[Peer]\nAllowedIPs = 10.8.0.2/32",
+			map[string]string{"10.8.0.2": "phone"}),
+		Entry("name in the section", "[Peer]\n#Name=laptop\nAllowedIPs=10.8.0.3/32,fd00::3/128",
+			map[string]string{"10.8.0.3": "laptop", "fd00::3": "laptop"}),
+		Entry("name of the next peer after the section",
+			"[Peer]\n# Name = a\nAllowedIPs = 10.8.0.2/32\n\n# Name = b\n[Peer]\nAllowedIPs = 10.8.0.3/32",
+			map[string]string{"10.8.0.2": "a", "10.8.0.3": "b"}),
+		Entry("interface address", "# server\n[Interface]\nAddress = 10.8.0.1/32", map[string]string{}),
+	)
+})
@@ -0,0 +1,125 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+//nolint:gochecknoglobals
+var coalescedQueries = promauto.With(metrics.Reg).NewCounter(
+	prometheus.CounterOpts{
+		Name: "blocky_coalesced_queries_total",
+		Help: "Number of queries answered with the response of an identical in-flight query",
+	},
+)
+
+// CoalescingResolver sends concurrent identical queries only once to the next resolver
+// and returns the response to all of them
+type CoalescingResolver struct {
+	configurable[*config.Coalescing]
+	NextResolver
+	typed
+
+	inFlight singleflight.Group
+
+	// upstreamGroup returns the upstream group of the request: queries of clients with different upstreams
+	// are resolved separately
+	upstreamGroup func(request *model.Request) string
+
+	// identifiers of the clients sent to the upstreams: queries of different clients are resolved separately
+	clientIdentifiers map[string]config.UpstreamClientIdentifier
+}
+
+// NewCoalescingResolver creates a new resolver, upstreamGroup can be nil, if all clients use the same upstreams
+func NewCoalescingResolver(
+	cfg config.Coalescing,
+	clientIdentifiers map[string]config.UpstreamClientIdentifier,
+	upstreamGroup func(request *model.Request) string,
+) *CoalescingResolver {
+	return &CoalescingResolver{
+		configurable:      withConfig(&cfg),
+		typed:             withType("coalescing"),
+		upstreamGroup:     upstreamGroup,
+		clientIdentifiers: clientIdentifiers,
+	}
+}
+
+func (r *CoalescingResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.log(ctx)
+
+	executed := false
+
+	key := clientIdentity(r.clientIdentifiers, request) + coalescingKey(request.Req)
+	if r.upstreamGroup != nil {
+		key = fmt.Sprintf("group=%s|%s", r.upstreamGroup(request), key)
+	}
+
+	ch := r.inFlight.DoChan(key, func() (interface{}, error) {
+		executed = true
+
+		// the response is shared: the first request must not cancel the others
+		return r.next.Resolve(context.WithoutCancel(ctx), request)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+
+		if !executed {
+			coalescedQueries.Inc()
+			logger.Debug("response of in-flight query is used")
+		}
+
+		return copyResponseFor(request, res.Val.(*model.Response)), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// coalescingKey contains all properties of the query, which have an influence on the response
+func coalescingKey(msg *dns.Msg) string {
+	var sb strings.Builder
+
+	for _, q := range msg.Question {
+		fmt.Fprintf(&sb, "%s|%d|%d|", strings.ToLower(q.Name), q.Qtype, q.Qclass)
+	}
+
+	fmt.Fprintf(&sb, "rd=%t|cd=%t", msg.RecursionDesired, msg.CheckingDisabled)
+
+	if opt := msg.IsEdns0(); opt != nil {
+		fmt.Fprintf(&sb, "|do=%t", opt.Do())
+	}
+
+	if ecs := util.GetEdns0Option[*dns.EDNS0_SUBNET](msg); ecs != nil {
+		fmt.Fprintf(&sb, "|ecs=%s/%d", ecs.Address, ecs.SourceNetmask)
+	}
+
+	return sb.String()
+}
+
+// copyResponseFor returns a copy of the shared response with the ID and question of the request
+func copyResponseFor(request *model.Request, response *model.Response) *model.Response {
+	res := *response
+	res.Res = response.Res.Copy()
+	res.Res.Id = request.Req.Id
+	res.Res.Question = slices.Clone(request.Req.Question)
+
+	return &res
+}
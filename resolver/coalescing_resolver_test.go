@@ -0,0 +1,273 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("CoalescingResolver", func() {
+	var (
+		sut       *CoalescingResolver
+		sutConfig config.Coalescing
+		m         *mockResolver
+		release   chan struct{}
+		started   chan struct{}
+		resolveFn func(ctx context.Context, req *Request) (*Response, error)
+		groupFn   func(req *Request) string
+		clientIDs map[string]config.UpstreamClientIdentifier
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Coalescing{Enable: true}
+		release = make(chan struct{})
+		started = make(chan struct{}, 10)
+		groupFn = nil
+		clientIDs = nil
+
+		resolveFn = func(_ context.Context, req *Request) (*Response, error) {
+			started <- struct{}{}
+			<-release
+
+			response, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "192.0.2.1")
+			Expect(err).Should(Succeed())
+
+			return &Response{Res: response, RType: ResponseTypeRESOLVED}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewCoalescingResolver(sutConfig, clientIDs, groupFn)
+		m = &mockResolver{ResolveFn: func(ctx context.Context, req *Request) (*Response, error) {
+			return resolveFn(ctx, req)
+		}}
+		m.On("Resolve", mock.Anything)
+		sut.Next(m)
+	})
+
+	// resolveConcurrently resolves the requests concurrently and releases the upstream, when the first query is sent
+	// to the upstream and all requests wait for their response
+	resolveConcurrently := func(requests ...*Request) ([]*Response, []error) {
+		responses := make([]*Response, len(requests))
+		errs := make([]error, len(requests))
+		waiting := make(chan struct{}, len(requests))
+
+		var wg sync.WaitGroup
+
+		for i, req := range requests {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				responses[i], errs[i] = sut.Resolve(waitingContext{ctx, waiting}, req)
+			}()
+		}
+
+		Eventually(started).Should(Receive())
+
+		for range requests {
+			Eventually(waiting).Should(Receive())
+		}
+
+		close(release)
+
+		wg.Wait()
+
+		return responses, errs
+	}
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("coalescing is enabled", func() {
+		It("should resolve identical queries once", func() {
+			before := testutil.ToFloat64(coalescedQueries)
+
+			first := newRequest("example.com.", A)
+			second := newRequest("Example.com.", A)
+			second.Req.Id = first.Req.Id + 1
+
+			responses, errs := resolveConcurrently(first, second, newRequest("example.com.", A))
+			Expect(errs).Should(HaveEach(Succeed()))
+
+			Expect(m.Calls).Should(HaveLen(1))
+			Expect(testutil.ToFloat64(coalescedQueries) - before).Should(BeNumerically("==", 2))
+
+			for _, response := range responses {
+				Expect(response).Should(SatisfyAll(
+					HaveResponseType(ResponseTypeRESOLVED),
+					WithTransform(func(r *Response) []dns.RR { return r.Res.Answer }, HaveLen(1)),
+				))
+			}
+
+			// each client gets its own message with its own ID and question
+			Expect(responses[0].Res).ShouldNot(BeIdenticalTo(responses[1].Res))
+			Expect(responses[0].Res.Id).Should(Equal(first.Req.Id))
+			Expect(responses[1].Res.Id).Should(Equal(second.Req.Id))
+			Expect(responses[1].Res.Question[0].Name).Should(Equal("Example.com."))
+		})
+
+		It("should resolve different queries separately", func() {
+			withDO := newRequest("example.com.", A)
+			withDO.Req.SetEdns0(dns.DefaultMsgSize, true)
+
+			resolveConcurrently(newRequest("example.com.", A), newRequest("example.com.", AAAA), withDO)
+
+			Expect(m.Calls).Should(HaveLen(3))
+		})
+
+		When("the clients use different upstream groups", func() {
+			BeforeEach(func() {
+				groupFn = func(req *Request) string {
+					if req.ClientNames[0] == "kid-tablet" {
+						return "family"
+					}
+
+					return "default"
+				}
+			})
+
+			It("should resolve identical queries of each group once", func() {
+				resolveConcurrently(
+					newRequestWithClient("example.com.", A, "192.168.178.2", "kid-tablet"),
+					newRequestWithClient("example.com.", A, "192.168.178.3", "laptop"),
+					newRequestWithClient("example.com.", A, "192.168.178.4", "tv"),
+				)
+
+				Expect(m.Calls).Should(HaveLen(2))
+				Expect(m.Calls).Should(ContainElement(HaveField("Arguments", ContainElement(
+					HaveField("ClientNames", ConsistOf("kid-tablet"))))))
+			})
+		})
+
+		When("upstreams get client identifiers", func() {
+			BeforeEach(func() {
+				clientIDs = map[string]config.UpstreamClientIdentifier{
+					"1.1.1.1": {Code: 65001, Value: config.ClientIdentifierValueName},
+				}
+			})
+
+			It("should resolve identical queries of each client once", func() {
+				resolveConcurrently(
+					newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"),
+					newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"),
+					newRequestWithClient("example.com.", A, "192.168.178.3", "tv"),
+				)
+
+				Expect(m.Calls).Should(HaveLen(2))
+			})
+
+			When("the identifier is the MAC address", func() {
+				BeforeEach(func() {
+					clientIDs["1.1.1.1"] = config.UpstreamClientIdentifier{
+						Code: 65001, Value: config.ClientIdentifierValueMac,
+					}
+				})
+
+				It("should resolve identical queries of each client IP once", func() {
+					resolveConcurrently(
+						newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"),
+						newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"),
+						newRequestWithClient("example.com.", A, "192.168.178.3", "laptop"),
+					)
+
+					Expect(m.Calls).Should(HaveLen(2))
+				})
+			})
+		})
+
+		It("should return the error to all requests", func() {
+			resolveFn = func(context.Context, *Request) (*Response, error) {
+				started <- struct{}{}
+				<-release
+
+				return nil, errors.New("upstream failed")
+			}
+
+			_, errs := resolveConcurrently(newRequest("example.com.", A), newRequest("example.com.", A))
+
+			Expect(errs).Should(HaveEach(MatchError("upstream failed")))
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		It("should return on cancelled context", func() {
+			DeferCleanup(func() {
+				close(release)
+
+				// wait for the in-flight query
+				Expect(sut.Resolve(context.Background(), newRequest("example.com.", A))).Error().Should(Succeed())
+			})
+
+			cancelFn()
+
+			_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(MatchError(context.Canceled))
+		})
+	})
+
+	When("coalescing is disabled", func() {
+		BeforeEach(func() {
+			sutConfig = config.Coalescing{}
+		})
+
+		It("should delegate all queries", func() {
+			for range 2 {
+				go func() {
+					_, _ = sut.Resolve(ctx, newRequest("example.com.", A))
+				}()
+			}
+
+			// both queries are sent to the upstream before the first one is answered
+			Eventually(started).Should(Receive())
+			Eventually(started).Should(Receive())
+			close(release)
+
+			Expect(m.Calls).Should(HaveLen(2))
+		})
+	})
+})
+
+// waitingContext signals, when a request waits for the response: the resolver selects its done channel after the
+// query is in flight
+type waitingContext struct {
+	context.Context
+
+	waiting chan<- struct{}
+}
+
+func (c waitingContext) Done() <-chan struct{} {
+	c.waiting <- struct{}{}
+
+	return c.Context.Done()
+}
@@ -3,9 +3,13 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"net"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
 
@@ -19,7 +23,14 @@ type ConditionalUpstreamResolver struct {
 	NextResolver
 	typed
 
-	mapping map[string]Resolver
+	mapping      map[string]Resolver
+	clientSubnet map[string]config.ConditionalClientSubnet
+	// the transferred zones by domain
+	zones map[string]*secondaryZone
+	// the IPs of the upstreams by domain, which are allowed to send NOTIFY messages
+	notifiers map[string][]net.IP
+	// the last serial of the forwarded zones by domain, which were notified
+	serials sync.Map
 }
 
 // NewConditionalUpstreamResolver returns new resolver instance
@@ -29,27 +40,152 @@ func NewConditionalUpstreamResolver(
 	m := make(map[string]Resolver, len(cfg.Mapping.Upstreams))
 
 	for domain, upstreams := range cfg.Mapping.Upstreams {
-		name := fmt.Sprintf("<conditional in %s>", domain)
-		cfg := config.NewUpstreamGroup(name, upstreamsCfg, upstreams)
+		var registry *registryWatch
+
+		if config.HasRegistryUpstreams(upstreams) {
+			registry = newRegistryWatch(conditionalGroupName(domain), upstreamsCfg, upstreams, bootstrap)
+			upstreams = registry.initialUpstreams(ctx)
+		}
+
+		cfg := config.NewUpstreamGroup(conditionalGroupName(domain), upstreamsCfg, upstreams)
 
 		r, err := NewParallelBestResolver(ctx, cfg, bootstrap)
 		if err != nil {
 			return nil, err
 		}
 
+		if registry != nil {
+			registry.start(ctx, r)
+		}
+
 		m[strings.ToLower(domain)] = r
 	}
 
+	// the reverse zones of internal subnets share the resolver of their domain
+	for zone, domain := range cfg.ReverseZoneDomains() {
+		if r, ok := m[strings.ToLower(domain)]; ok {
+			m[zone] = r
+		}
+	}
+
+	clientSubnet := make(map[string]config.ConditionalClientSubnet, len(cfg.ClientSubnet))
+	for domain, subnet := range cfg.ClientSubnet {
+		clientSubnet[strings.ToLower(domain)] = subnet
+	}
+
+	notifiers := make(map[string][]net.IP, len(cfg.Mapping.Upstreams))
+
+	for domain, upstreams := range cfg.Mapping.Upstreams {
+		for _, upstream := range upstreams {
+			if ip := net.ParseIP(upstream.Host); ip != nil {
+				notifiers[strings.ToLower(domain)] = append(notifiers[strings.ToLower(domain)], ip)
+			}
+		}
+	}
+
+	for zone, domain := range cfg.ReverseZoneDomains() {
+		notifiers[zone] = notifiers[strings.ToLower(domain)]
+	}
+
+	zones := make(map[string]*secondaryZone, len(cfg.Transfer.Zones))
+
+	for _, zone := range cfg.Transfer.Zones {
+		z := newSecondaryZone(zone, cfg.TransferPrimaries(zone), cfg.Transfer.Refresh.ToDuration(),
+			upstreamsCfg.Timeout.ToDuration())
+		z.start(ctx)
+
+		zones[strings.ToLower(zone)] = z
+	}
+
 	r := ConditionalUpstreamResolver{
 		configurable: withConfig(&cfg),
 		typed:        withType("conditional_upstream"),
 
-		mapping: m,
+		mapping:      m,
+		clientSubnet: clientSubnet,
+		zones:        zones,
+		notifiers:    notifiers,
 	}
 
 	return &r, nil
 }
 
+// Notify handles a NOTIFY message (RFC 1996) of an upstream of the conditional domain: a transferred zone is
+// refreshed, the cached answers of a forwarded zone are flushed, if the serial of its SOA record changed.
+// Returns false, if the NOTIFY isn't accepted.
+func (r *ConditionalUpstreamResolver) Notify(ctx context.Context, zone string, source net.IP) bool {
+	domain := util.ExtractDomainOnly(zone)
+
+	if !r.cfg.Notify || !slices.ContainsFunc(r.notifiers[domain], source.Equal) {
+		return false
+	}
+
+	if z, ok := r.zones[domain]; ok {
+		// the cached answers are flushed after the transfer of a new serial
+		z.notify()
+
+		return true
+	}
+
+	if r.serialChanged(ctx, domain) {
+		evt.Bus().Publish(evt.ConditionalZoneChanged, domain)
+	}
+
+	return true
+}
+
+// serialChanged queries the SOA record of the forwarded zone and returns true, if its serial changed since the last
+// NOTIFY or is unknown
+func (r *ConditionalUpstreamResolver) serialChanged(ctx context.Context, domain string) bool {
+	ctx, logger := r.log(ctx)
+
+	resp, err := r.mapping[domain].Resolve(ctx, newRequest(dns.Fqdn(domain), dns.Type(dns.TypeSOA)))
+	if err != nil {
+		logger.Debugf("can't query the serial of '%s': %v", util.Obfuscate(domain), err)
+		r.serials.Delete(domain)
+
+		return true
+	}
+
+	for _, rr := range resp.Res.Answer {
+		if soa, ok := rr.(*dns.SOA); ok {
+			previous, found := r.serials.Swap(domain, soa.Serial)
+
+			return !found || previous.(uint32) != soa.Serial
+		}
+	}
+
+	r.serials.Delete(domain)
+
+	return true
+}
+
+// NotifyZone passes a NOTIFY message to the conditional upstream resolver of the chain, returns false if it isn't
+// accepted
+func NotifyZone(ctx context.Context, chain Resolver, zone string, source net.IP) bool {
+	accepted := false
+
+	ForEach(chain, func(res Resolver) {
+		res = Unwrap(res)
+
+		// the conditional upstream resolver is wrapped by the rewriter of the conditional mapping
+		if rewriter, ok := res.(*RewriterResolver); ok {
+			res = Unwrap(rewriter.inner)
+		}
+
+		if conditional, ok := res.(*ConditionalUpstreamResolver); ok {
+			accepted = conditional.Notify(ctx, zone, source) || accepted
+		}
+	})
+
+	return accepted
+}
+
+// conditionalGroupName returns the upstream group name of the conditional mapping entry
+func conditionalGroupName(domain string) string {
+	return fmt.Sprintf("<conditional in %s>", domain)
+}
+
 func (r *ConditionalUpstreamResolver) processRequest(
 	ctx context.Context, request *model.Request,
 ) (bool, *model.Response, error) {
@@ -103,12 +239,33 @@ func (r *ConditionalUpstreamResolver) internalResolve(ctx context.Context, reso
 	ctx, logger := r.log(ctx)
 
 	req.Req.Question[0].Name = dns.Fqdn(doFQ)
+
+	if zone, ok := r.zones[do]; ok {
+		if response := zone.resolve(req); response != nil {
+			logger.WithField("domain", util.Obfuscate(do)).Debug("answered from transferred zone")
+
+			return response, nil
+		}
+	}
+
+	if subnet, ok := r.clientSubnet[do]; ok {
+		// the internal DNS server can apply its own per-client logic
+		if edsOption := subnetOption(req.ClientIP, subnet.IPv4Mask, subnet.IPv6Mask); edsOption != nil {
+			logger.Debugf("set edns0 subnet option address: %s", edsOption.Address)
+			util.SetEdns0Option(req.Req, edsOption)
+		}
+	}
+
 	response, err := reso.Resolve(ctx, req)
 
 	if err == nil {
-		response.Reason = "CONDITIONAL"
+		response.Reason = model.NewReason(model.ReasonCodeCONDITIONAL)
 		response.RType = model.ResponseTypeCONDITIONAL
 
+		if response.Upstream != nil {
+			response.Upstream.Group = conditionalGroupName(do)
+		}
+
 		if len(response.Res.Question) > 0 {
 			response.Res.Question[0].Name = req.Req.Question[0].Name
 		}
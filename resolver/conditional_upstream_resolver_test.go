@@ -2,8 +2,12 @@ package resolver
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -63,9 +67,22 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 			return response
 		})
 
+		// answers with the address of the received client subnet
+		ecsTestUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+			address := "0.0.0.0"
+			if so := util.GetEdns0Option[*dns.EDNS0_SUBNET](request); so != nil {
+				address = so.Address.String()
+			}
+
+			response, _ = util.NewMsgWithAnswer(request.Question[0].Name, 123, A, address)
+
+			return response
+		})
+
 		sutConfig = config.ConditionalUpstream{
 			Mapping: config.ConditionalUpstreamMapping{
 				Upstreams: map[string][]config.Upstream{
+					"ecs.box":        {ecsTestUpstream.Start()},
 					"fritz.box":      {fbTestUpstream.Start()},
 					"other.box":      {otherTestUpstream.Start()},
 					"refused.domain": {refuseTestUpstream.Start()},
@@ -178,6 +195,124 @@ var _ = Describe("ConditionalUpstreamResolver", Label("conditionalResolver"), fu
 			})
 		})
 	})
+	Describe("Reverse zones", func() {
+		BeforeEach(func() {
+			_, subnet, err := net.ParseCIDR("192.168.178.0/23")
+			Expect(err).Should(Succeed())
+
+			sutConfig.ReverseZones = config.ConditionalReverseZones{"Fritz.box": {subnet}}
+		})
+		It("should forward reverse lookups of the subnet to the DNS of the domain", func() {
+			Expect(sut.Resolve(ctx, newRequest("5.179.168.192.in-addr.arpa.", PTR))).
+				Should(
+					SatisfyAll(
+						BeDNSRecord("5.179.168.192.in-addr.arpa.", A, "123.124.122.122"),
+						HaveResponseType(ResponseTypeCONDITIONAL),
+						HaveReason("CONDITIONAL"),
+					))
+
+			// no call to next resolver
+			Expect(m.Calls).Should(BeEmpty())
+		})
+		It("should delegate reverse lookups outside the subnet to next resolver", func() {
+			Expect(sut.Resolve(ctx, newRequest("5.180.168.192.in-addr.arpa.", PTR))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			m.AssertExpectations(GinkgoT())
+		})
+	})
+	Describe("NOTIFY", func() {
+		var (
+			serial   atomic.Uint32
+			upstream config.Upstream
+			changes  chan string
+		)
+
+		BeforeEach(func() {
+			serial.Store(1)
+
+			upstream = NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) (response *dns.Msg) {
+				rr, _ := dns.NewRR(fmt.Sprintf(
+					"notify.box. 300 IN SOA ns.notify.box. admin.notify.box. %d 3600 600 86400 300", serial.Load()))
+
+				response = new(dns.Msg)
+				response.Answer = []dns.RR{rr}
+
+				return response
+			}).Start()
+
+			sutConfig.Mapping.Upstreams["notify.box"] = []config.Upstream{upstream}
+			sutConfig.Notify = true
+
+			changes = make(chan string, 10)
+			handler := func(zone string) {
+				changes <- zone
+			}
+
+			Expect(evt.Bus().Subscribe(evt.ConditionalZoneChanged, handler)).Should(Succeed())
+			DeferCleanup(evt.Bus().Unsubscribe, evt.ConditionalZoneChanged, handler)
+		})
+
+		It("should flush the cached answers of a forwarded zone, if its serial changed", func() {
+			Expect(sut.Notify(ctx, "notify.box.", net.ParseIP(upstream.Host))).Should(BeTrue())
+			Expect(changes).Should(Receive(Equal("notify.box")))
+
+			By("the serial didn't change", func() {
+				Expect(sut.Notify(ctx, "Notify.box.", net.ParseIP(upstream.Host))).Should(BeTrue())
+				Expect(changes).ShouldNot(Receive())
+			})
+
+			serial.Store(2)
+
+			Expect(sut.Notify(ctx, "notify.box.", net.ParseIP(upstream.Host))).Should(BeTrue())
+			Expect(changes).Should(Receive(Equal("notify.box")))
+		})
+
+		It("should refuse NOTIFY of other hosts and for other zones", func() {
+			Expect(sut.Notify(ctx, "notify.box.", net.ParseIP("192.0.2.1"))).Should(BeFalse())
+			Expect(sut.Notify(ctx, "unknown.box.", net.ParseIP(upstream.Host))).Should(BeFalse())
+			Expect(changes).ShouldNot(Receive())
+		})
+
+		When("NOTIFY is disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Notify = false
+			})
+
+			It("should refuse NOTIFY", func() {
+				Expect(sut.Notify(ctx, "notify.box.", net.ParseIP(upstream.Host))).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("Client subnet", func() {
+		When("client subnet is defined for the conditional domain", func() {
+			BeforeEach(func() {
+				sutConfig.ClientSubnet = map[string]config.ConditionalClientSubnet{
+					"ECS.box": {IPv4Mask: 24},
+				}
+			})
+			It("should send the masked client IP to the conditional DNS", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("host.ecs.box.", A, "192.168.178.5"))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("host.ecs.box.", A, "192.168.178.0"),
+							HaveResponseType(ResponseTypeCONDITIONAL),
+						))
+			})
+			It("should not send a client subnet for clients of the other IP version", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("host.ecs.box.", A, "fd00::5"))).
+					Should(BeDNSRecord("host.ecs.box.", A, "0.0.0.0"))
+			})
+		})
+		When("client subnet is not defined for the conditional domain", func() {
+			It("should not send a client subnet to the conditional DNS", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("host.ecs.box.", A, "192.168.178.5"))).
+					Should(BeDNSRecord("host.ecs.box.", A, "0.0.0.0"))
+			})
+		})
+	})
+
 	Describe("Delegation to next resolver", func() {
 		When("Query doesn't match defined mapping", func() {
 			It("should delegate to next resolver", func() {
@@ -97,13 +97,45 @@ func (r *CustomDNSResolver) handleReverseDNS(request *model.Request) *model.Resp
 				response.Answer = append(response.Answer, ptr)
 			}
 
-			return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}
+			return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: model.NewReason(model.ReasonCodeCUSTOMDNS)}
 		}
+
+		return r.synthesizeReverseDNS(request)
 	}
 
 	return nil
 }
 
+// synthesizeReverseDNS answers PTR queries for IPs without mapping in a configured subnet with the host name
+// built from the subnet's template
+func (r *CustomDNSResolver) synthesizeReverseDNS(request *model.Request) *model.Response {
+	if len(r.cfg.ReverseSynthesis) == 0 {
+		return nil
+	}
+
+	question := request.Req.Question[0]
+
+	ip, err := util.ParseIPFromArpaAddr(strings.ToLower(question.Name))
+	if err != nil {
+		return nil
+	}
+
+	hostName, found := r.cfg.ReverseSynthesis.HostName(ip)
+	if !found {
+		return nil
+	}
+
+	response := new(dns.Msg)
+	response.SetReply(request.Req)
+
+	ptr := new(dns.PTR)
+	ptr.Hdr = util.CreateHeader(question, r.cfg.CustomTTL.SecondsU32())
+	ptr.Ptr = dns.Fqdn(hostName)
+	response.Answer = append(response.Answer, ptr)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: model.NewReason(model.ReasonCodeCUSTOMDNSSYNTHESIZED)}
+}
+
 func (r *CustomDNSResolver) processRequest(
 	ctx context.Context,
 	logger *logrus.Entry,
@@ -139,7 +171,7 @@ func (r *CustomDNSResolver) processRequest(
 					"domain": domain,
 				}).Debugf("returning custom dns entry")
 
-				return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
+				return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: model.NewReason(model.ReasonCodeCUSTOMDNS)}, nil
 			}
 
 			// Mapping exists for this domain, but for another type
@@ -149,7 +181,7 @@ func (r *CustomDNSResolver) processRequest(
 			}
 
 			// return NOERROR with empty result
-			return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}, nil
+			return &model.Response{Res: response, RType: model.ResponseTypeCUSTOMDNS, Reason: model.NewReason(model.ReasonCodeCUSTOMDNS)}, nil
 		}
 
 		if i := strings.IndexRune(domain, '.'); i >= 0 {
@@ -181,11 +213,15 @@ func (r *CustomDNSResolver) processDNSEntry(
 		return r.processTXT(v.Txt, question, v.Header().Ttl)
 	case *dns.SRV:
 		return r.processSRV(*v, question, v.Header().Ttl)
+	case *dns.SVCB:
+		return r.processSVCB(*v, dns.TypeSVCB, question, v.Header().Ttl)
+	case *dns.HTTPS:
+		return r.processSVCB(v.SVCB, dns.TypeHTTPS, question, v.Header().Ttl)
 	case *dns.CNAME:
 		return r.processCNAME(ctx, logger, request, *v, resolvedCnames, question, v.Header().Ttl)
 	}
 
-	return nil, fmt.Errorf("unsupported customDNS RR type %T", entry)
+	return nil, fmt.Errorf("%w: unsupported customDNS RR type %T", ErrConfig, entry)
 }
 
 // Resolve uses internal mapping to resolve the query
@@ -244,6 +280,29 @@ func (r *CustomDNSResolver) processSRV(
 	return result, nil
 }
 
+func (r *CustomDNSResolver) processSVCB(
+	targetSVCB dns.SVCB,
+	rrType uint16,
+	question dns.Question,
+	ttl uint32,
+) (result []dns.RR, err error) {
+	if question.Qtype != rrType {
+		return result, nil
+	}
+
+	svcb := new(dns.SVCB)
+	svcb.Hdr = dns.RR_Header{Class: dns.ClassINET, Ttl: ttl, Rrtype: rrType, Name: question.Name}
+	svcb.Priority = targetSVCB.Priority
+	svcb.Target = targetSVCB.Target
+	svcb.Value = slices.Clone(targetSVCB.Value)
+
+	if rrType == dns.TypeHTTPS {
+		return append(result, &dns.HTTPS{SVCB: *svcb}), nil
+	}
+
+	return append(result, svcb), nil
+}
+
 func (r *CustomDNSResolver) processCNAME(
 	ctx context.Context,
 	logger *logrus.Entry,
@@ -265,12 +324,16 @@ func (r *CustomDNSResolver) processCNAME(
 	targetWithoutDot := strings.TrimSuffix(targetCname.Target, ".")
 
 	if slices.Contains(resolvedCnames, targetWithoutDot) {
-		return nil, fmt.Errorf("CNAME loop detected: %v", append(resolvedCnames, targetWithoutDot))
+		return nil, fmt.Errorf("%w: CNAME loop detected: %v", ErrConfig, append(resolvedCnames, targetWithoutDot))
 	}
 
 	cnames := resolvedCnames
 	cnames = append(cnames, targetWithoutDot)
 
+	if err := checkCustomDNSDepth(ctx, len(cnames)); err != nil {
+		return nil, err
+	}
+
 	clientIP := request.ClientIP.String()
 	clientID := request.RequestClientID
 	targetRequest := newRequestWithClientID(targetWithoutDot, dns.Type(question.Qtype), clientIP, clientID)
@@ -61,6 +61,9 @@ var _ = Describe("CustomDNSResolver", func() {
 					"srv.":             {&dns.SRV{Priority: 0, Weight: 5, Port: 12345, Target: "service", Hdr: zoneHdr}},
 					"txt.":             {&dns.TXT{Txt: []string{"space", "separated", "value"}, Hdr: zoneHdr}},
 					"mx.domain.":       {&dns.MX{Mx: "mx.domain", Hdr: zoneHdr}},
+					"https.domain.": {&dns.HTTPS{SVCB: dns.SVCB{
+						Priority: 1, Target: ".", Value: []dns.SVCBKeyValue{&dns.SVCBAlpn{Alpn: []string{"h2"}}}, Hdr: zoneHdr,
+					}}},
 				},
 			},
 			CustomTTL:           config.Duration(time.Duration(TTL) * time.Second),
@@ -404,6 +407,26 @@ var _ = Describe("CustomDNSResolver", func() {
 							HaveReturnCode(dns.RcodeSuccess),
 						))
 			})
+			It("Returns a HTTPS response", func() {
+				Expect(sut.Resolve(ctx, newRequest("https.domain", HTTPS))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("https.domain.", HTTPS, `1 . alpn="h2"`),
+							HaveTTL(BeNumerically("==", zoneTTL)),
+							HaveResponseType(ResponseTypeCUSTOMDNS),
+							HaveReason("CUSTOM DNS"),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+			})
+			It("Returns an empty response for other types of a HTTPS entry", func() {
+				Expect(sut.Resolve(ctx, newRequest("https.domain", A))).
+					Should(
+						SatisfyAll(
+							HaveNoAnswer(),
+							HaveResponseType(ResponseTypeCUSTOMDNS),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+			})
 		})
 		When("An unsupported DNS query type is queried from the resolver but found in the config mapping ", func() {
 			It("an error should be returned", func() {
@@ -458,6 +481,51 @@ var _ = Describe("CustomDNSResolver", func() {
 				})
 			})
 		})
+		When("Reverse DNS synthesis is configured", func() {
+			BeforeEach(func() {
+				_, subnet, err := net.ParseCIDR("192.168.0.0/16")
+				Expect(err).Should(Succeed())
+
+				cfg.ReverseSynthesis = config.ReverseSynthesis{
+					{Subnet: subnet, Template: "{ip}.dhcp.lan"},
+				}
+			})
+
+			It("should synthesize PTR answers for IPs without mapping", func() {
+				Expect(sut.Resolve(ctx, newRequest("77.1.168.192.in-addr.arpa.", PTR))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("77.1.168.192.in-addr.arpa.", PTR, "192-168-1-77.dhcp.lan."),
+							HaveTTL(BeNumerically("==", TTL)),
+							HaveResponseType(ResponseTypeCUSTOMDNS),
+							HaveReason("CUSTOM DNS (SYNTHESIZED)"),
+							HaveReturnCode(dns.RcodeSuccess),
+						))
+
+				// will not delegate to next resolver
+				m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+			})
+
+			It("should prefer the defined mapping", func() {
+				Expect(sut.Resolve(ctx, newRequest("125.143.168.192.in-addr.arpa.", PTR))).
+					Should(
+						SatisfyAll(
+							BeDNSRecord("125.143.168.192.in-addr.arpa.", PTR, "multiple.ips."),
+							HaveReason("CUSTOM DNS"),
+						))
+			})
+
+			It("should delegate IPs outside the subnets to next resolver", func() {
+				Expect(sut.Resolve(ctx, newRequest("1.0.0.10.in-addr.arpa.", PTR))).
+					Should(
+						SatisfyAll(
+							HaveNoAnswer(),
+							HaveResponseType(ResponseTypeRESOLVED),
+						))
+
+				m.AssertExpectations(GinkgoT())
+			})
+		})
 		When("Domain mapping is defined", func() {
 			It("subdomain must also match", func() {
 				Expect(sut.Resolve(ctx, newRequest("ABC.CUSTOM.DOMAIN.", A))).
@@ -0,0 +1,80 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/0xERR0R/blocky/cache/stringcache"
+
+	"github.com/miekg/dns"
+)
+
+// domainPatternGroup is the only group of the pattern cache
+const domainPatternGroup = "patterns"
+
+// domainPatterns matches domains against exact domains, wildcards like `*.example.com` (the domain and its
+// subdomains) and regexes like `/lan$/`
+type domainPatterns struct {
+	cache stringcache.GroupedStringCache
+}
+
+func newDomainPatterns(patterns []string) (*domainPatterns, error) {
+	cache := stringcache.NewChainedGroupedCache(
+		stringcache.NewInMemoryGroupedRegexCache(),
+		stringcache.NewInMemoryGroupedWildcardCache(), // must be after regex which can contain '*'
+		stringcache.NewInMemoryGroupedStringCache(),   // accepts all values, must be last
+	)
+
+	factory := cache.Refresh(domainPatternGroup)
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+
+		if err := validateDomainPattern(pattern); err != nil {
+			return nil, fmt.Errorf("'%s' fail because %w", pattern, err)
+		}
+
+		factory.AddEntry(strings.TrimSuffix(pattern, "."))
+	}
+
+	factory.Finish()
+
+	return &domainPatterns{cache: cache}, nil
+}
+
+func validateDomainPattern(pattern string) error {
+	switch {
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+		_, err := regexp.Compile(strings.TrimSpace(pattern[1 : len(pattern)-1]))
+
+		return err
+	case strings.Contains(pattern, "*"):
+		if !strings.HasPrefix(pattern, "*.") || strings.Count(pattern, "*") > 1 {
+			return errors.New("a wildcard must start with '*.' and contain no other '*'")
+		}
+	case pattern == "" || strings.Contains(pattern, "/"):
+		return errors.New("it's neither a domain, a wildcard nor a regex")
+	}
+
+	return nil
+}
+
+// matches returns true if the domain matches a pattern
+func (p *domainPatterns) matches(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	return len(p.cache.Contains(domain, []string{domainPatternGroup})) != 0
+}
+
+// matchesAny returns true if the name of a question matches a pattern
+func (p *domainPatterns) matchesAny(questions []dns.Question) bool {
+	for _, q := range questions {
+		if p.matches(q.Name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -51,7 +51,6 @@ func NewECSResolver(cfg config.ECS) ChainedResolver {
 func (r *ECSResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
 	if r.cfg.IsEnabled() {
 		ctx, logger := r.log(ctx)
-		_ = ctx
 
 		so := util.GetEdns0Option[*dns.EDNS0_SUBNET](request.Req)
 		// Set the client IP from the Edns0 subnet option if the option is enabled and the correct subnet mask is set
@@ -71,6 +70,8 @@ func (r *ECSResolver) Resolve(ctx context.Context, request *model.Request) (*mod
 		if r.cfg.IPv4Mask == 0 && r.cfg.IPv6Mask == 0 && so != nil && !r.cfg.Forward {
 			logger.Debug("remove edns0 subnet option")
 			util.RemoveEdns0Option[*dns.EDNS0_SUBNET](request.Req)
+
+			recordManipulation(ctx, r.Type(), manipulationECSRemoved, "client subnet option removed from the query")
 		}
 	}
 
@@ -87,22 +88,26 @@ func (r *ECSResolver) setSubnet(so *dns.EDNS0_SUBNET, request *model.Request, lo
 		subIP = request.ClientIP
 	}
 
-	var edsOption *dns.EDNS0_SUBNET
+	if edsOption := subnetOption(subIP, r.cfg.IPv4Mask, r.cfg.IPv6Mask); edsOption != nil {
+		logger.Debugf("set edns0 subnet option address: %s", edsOption.Address)
+		util.SetEdns0Option(request.Req, edsOption)
+	}
+}
 
-	if ip := subIP.To4(); ip != nil && r.cfg.IPv4Mask > 0 {
-		if mip, err := maskIP(ip, r.cfg.IPv4Mask); err == nil {
-			edsOption = newEdnsSubnetOption(mip, ecsFamilyIPv4, r.cfg.IPv4Mask)
+// subnetOption creates the EDNS0 subnet option for the IP masked with the mask of its IP version
+// or returns nil if the corresponding mask is not set
+func subnetOption(subIP net.IP, ipv4Mask config.ECSv4Mask, ipv6Mask config.ECSv6Mask) *dns.EDNS0_SUBNET {
+	if ip := subIP.To4(); ip != nil && ipv4Mask > 0 {
+		if mip, err := maskIP(ip, ipv4Mask); err == nil {
+			return newEdnsSubnetOption(mip, ecsFamilyIPv4, ipv4Mask)
 		}
-	} else if ip := subIP.To16(); ip != nil && r.cfg.IPv6Mask > 0 {
-		if mip, err := maskIP(ip, r.cfg.IPv6Mask); err == nil {
-			edsOption = newEdnsSubnetOption(mip, ecsFamilyIPv6, r.cfg.IPv6Mask)
+	} else if ip := subIP.To16(); ip != nil && ipv6Mask > 0 {
+		if mip, err := maskIP(ip, ipv6Mask); err == nil {
+			return newEdnsSubnetOption(mip, ecsFamilyIPv6, ipv6Mask)
 		}
 	}
 
-	if edsOption != nil {
-		logger.Debugf("set edns0 subnet option address: %s", edsOption.Address)
-		util.SetEdns0Option(request.Req, edsOption)
-	}
+	return nil
 }
 
 // maskIP masks the IP with the given mask and return an error if the mask is invalid
@@ -49,7 +49,7 @@ var _ = Describe("EcsResolver", func() {
 			m.On("Resolve", mock.Anything).Return(&Response{
 				Res:    mockAnswer,
 				RType:  ResponseTypeCUSTOMDNS,
-				Reason: "Test",
+				Reason: NewReason(ReasonCodeRESOLVED, "Test"),
 			}, nil)
 		}
 
@@ -99,7 +99,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 
 			It("shouldn't change ClientIP with subnet 24", func(ctx context.Context) {
@@ -120,7 +120,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 		})
 
@@ -147,7 +147,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 
 			It("should add ECS information with subnet 128", func(ctx context.Context) {
@@ -166,7 +166,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 		})
 
@@ -199,7 +199,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 
 			When("subnet mask is 24", func() {
@@ -229,7 +229,7 @@ var _ = Describe("EcsResolver", func() {
 								HaveNoAnswer(),
 								HaveResponseType(ResponseTypeRESOLVED),
 								HaveReturnCode(dns.RcodeSuccess),
-								HaveReason("Test")))
+								HaveReason("RESOLVED (Test)")))
 				})
 			})
 
@@ -254,7 +254,7 @@ var _ = Describe("EcsResolver", func() {
 							HaveNoAnswer(),
 							HaveResponseType(ResponseTypeRESOLVED),
 							HaveReturnCode(dns.RcodeSuccess),
-							HaveReason("Test")))
+							HaveReason("RESOLVED (Test)")))
 			})
 		})
 	})
@@ -284,5 +284,5 @@ func addEcsOption(req *dns.Msg, ip net.IP, netmask uint8) {
 
 // respondWith creates a new Response with the given request and message
 func respondWith(res *dns.Msg) *Response {
-	return &Response{Res: res, RType: ResponseTypeRESOLVED, Reason: "Test"}
+	return &Response{Res: res, RType: ResponseTypeRESOLVED, Reason: NewReason(ReasonCodeRESOLVED, "Test")}
 }
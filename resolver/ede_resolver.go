@@ -51,9 +51,30 @@ func (r *EDEResolver) addExtraReasoning(res *model.Response) {
 		return
 	}
 
+	if res.RType == model.ResponseTypeBLOCKED && r.cfg.BlockedCode == config.EDEBlockedCodeFiltered {
+		infocode = dns.ExtendedErrorCodeFiltered
+	}
+
+	if r.cfg.BlockedOnly && !isBlockingInfoCode(infocode) {
+		return
+	}
+
 	edeOption := new(dns.EDNS0_EDE)
 	edeOption.InfoCode = infocode
-	edeOption.ExtraText = res.Reason
+
+	if !r.cfg.HideReason {
+		edeOption.ExtraText = res.Reason.String()
+	}
 
 	util.SetEdns0Option(res.Res, edeOption)
 }
+
+// isBlockingInfoCode returns true if the info code is used for responses, which don't contain the real answer
+func isBlockingInfoCode(infocode uint16) bool {
+	switch infocode {
+	case dns.ExtendedErrorCodeBlocked, dns.ExtendedErrorCodeFiltered, dns.ExtendedErrorCodeProhibited:
+		return true
+	}
+
+	return false
+}
@@ -41,6 +41,7 @@ var _ = Describe("EdeResolver", func() {
 		DeferCleanup(cancelFn)
 
 		mockAnswer = new(dns.Msg)
+		m = nil
 	})
 
 	JustBeforeEach(func() {
@@ -49,7 +50,7 @@ var _ = Describe("EdeResolver", func() {
 			m.On("Resolve", mock.Anything).Return(&Response{
 				Res:    mockAnswer,
 				RType:  ResponseTypeCUSTOMDNS,
-				Reason: "Test",
+				Reason: NewReason(ReasonCodeRESOLVED, "Test"),
 			}, nil)
 		}
 
@@ -106,7 +107,7 @@ var _ = Describe("EdeResolver", func() {
 						WithTransform(extractEdeOption,
 							SatisfyAll(
 								HaveField("InfoCode", Equal(dns.ExtendedErrorCodeForgedAnswer)),
-								HaveField("ExtraText", Equal("Test")),
+								HaveField("ExtraText", Equal("RESOLVED (Test)")),
 							)),
 					))
 		})
@@ -117,7 +118,7 @@ var _ = Describe("EdeResolver", func() {
 				m.On("Resolve", mock.Anything).Return(&Response{
 					Res:    mockAnswer,
 					RType:  ResponseType(math.MaxInt),
-					Reason: "Test",
+					Reason: NewReason(ReasonCodeRESOLVED, "Test"),
 				}, nil)
 			})
 
@@ -150,6 +151,81 @@ var _ = Describe("EdeResolver", func() {
 			})
 		})
 
+		When("the response is blocked", func() {
+			BeforeEach(func() {
+				m = &mockResolver{}
+				m.On("Resolve", mock.Anything).Return(&Response{
+					Res:    mockAnswer,
+					RType:  ResponseTypeBLOCKED,
+					Reason: NewReason(ReasonCodeBLOCKED, "ads"),
+				}, nil)
+			})
+
+			It("should add the blocked code and the reason", func() {
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(WithTransform(extractEdeOption, SatisfyAll(
+						HaveField("InfoCode", Equal(dns.ExtendedErrorCodeBlocked)),
+						HaveField("ExtraText", Equal("BLOCKED (ads)")),
+					)))
+			})
+
+			When("the filtered code is configured", func() {
+				BeforeEach(func() {
+					sutConfig.BlockedCode = config.EDEBlockedCodeFiltered
+				})
+
+				It("should add the filtered code", func() {
+					Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+						Should(WithTransform(extractEdeOption,
+							HaveField("InfoCode", Equal(dns.ExtendedErrorCodeFiltered)),
+						))
+				})
+			})
+
+			When("the reason is hidden", func() {
+				BeforeEach(func() {
+					sutConfig.HideReason = true
+				})
+
+				It("should add the code without extra text", func() {
+					Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+						Should(WithTransform(extractEdeOption, SatisfyAll(
+							HaveField("InfoCode", Equal(dns.ExtendedErrorCodeBlocked)),
+							HaveField("ExtraText", BeEmpty()),
+						)))
+				})
+			})
+		})
+
+		When("only blocked responses should get EDE information", func() {
+			BeforeEach(func() {
+				sutConfig.BlockedOnly = true
+			})
+
+			It("shouldn't add EDE information to other responses", func() {
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(Not(HaveEdnsOption(dns.EDNS0EDE)))
+			})
+
+			When("the response is filtered", func() {
+				BeforeEach(func() {
+					m = &mockResolver{}
+					m.On("Resolve", mock.Anything).Return(&Response{
+						Res:    mockAnswer,
+						RType:  ResponseTypeFILTERED,
+						Reason: NewReason(ReasonCodeFILTERED),
+					}, nil)
+				})
+
+				It("should add EDE information", func() {
+					Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+						Should(WithTransform(extractEdeOption,
+							HaveField("InfoCode", Equal(dns.ExtendedErrorCodeFiltered)),
+						))
+				})
+			})
+		})
+
 		Describe("LogConfig", func() {
 			It("should log something", func() {
 				logger, hook := log.NewMockEntry()
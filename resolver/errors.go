@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+)
+
+// Errors returned by the resolver chain, the original error is wrapped, e.g. `upstream timeout: ...`
+var (
+	// ErrUpstreamTimeout is returned, if an upstream didn't answer within the upstream timeout
+	ErrUpstreamTimeout = errors.New("upstream timeout")
+	// ErrUpstreamRefused is returned, if an upstream refused the connection
+	ErrUpstreamRefused = errors.New("upstream refused")
+	// ErrConfig is returned, if the query can't be answered because of the configuration, e.g. a CNAME loop
+	ErrConfig = errors.New("configuration error")
+)
+
+// Classes of the errors, used as label of metrics and in the query log
+const (
+	ErrorClassUpstreamTimeout = "upstreamTimeout"
+	ErrorClassUpstreamRefused = "upstreamRefused"
+	ErrorClassUpstreamFailure = "upstreamServerFailure"
+	ErrorClassConfig          = "config"
+	ErrorClassCanceled        = "canceled"
+	ErrorClassTimeout         = "timeout"
+	ErrorClassOther           = "other"
+)
+
+// ErrorClass returns the class of an error of the resolver chain
+func ErrorClass(err error) string {
+	var serverErr *UpstreamServerError
+
+	switch {
+	case errors.Is(err, ErrUpstreamTimeout):
+		return ErrorClassUpstreamTimeout
+	case errors.Is(err, ErrUpstreamRefused):
+		return ErrorClassUpstreamRefused
+	case errors.As(err, &serverErr):
+		return ErrorClassUpstreamFailure
+	case errors.Is(err, ErrConfig):
+		return ErrorClassConfig
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	default:
+		return ErrorClassOther
+	}
+}
+
+// wrapUpstreamError wraps timeouts and refused connections of an upstream with the matching error
+func wrapUpstreamError(err error) error {
+	switch {
+	case isTimeout(err):
+		return fmt.Errorf("%w: %w", ErrUpstreamTimeout, err)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("%w: %w", ErrUpstreamRefused, err)
+	default:
+		return err
+	}
+}
+
+// errorResponse describes a failed query in the query log, the client receives SERVFAIL
+func errorResponse(request *model.Request, err error) *model.Response {
+	msg := new(dns.Msg)
+	msg.SetRcode(request.Req, dns.RcodeServerFailure)
+
+	return &model.Response{
+		Res:    msg,
+		RType:  model.ResponseTypeERROR,
+		Reason: model.NewReason(model.ReasonCodeERROR, ErrorClass(err)),
+	}
+}
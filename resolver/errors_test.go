@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("ErrorClass", func() {
+		DescribeTable("should return the class of the error",
+			func(err error, class string) {
+				Expect(ErrorClass(err)).Should(Equal(class))
+			},
+			Entry("upstream timeout", fmt.Errorf("%w: i/o timeout", ErrUpstreamTimeout), ErrorClassUpstreamTimeout),
+			Entry("upstream refused", fmt.Errorf("%w: refused", ErrUpstreamRefused), ErrorClassUpstreamRefused),
+			Entry("upstream server failure",
+				fmt.Errorf("resolve: %w", &UpstreamServerError{new(dns.Msg)}), ErrorClassUpstreamFailure),
+			Entry("configuration", fmt.Errorf("%w: CNAME loop detected", ErrConfig), ErrorClassConfig),
+			Entry("canceled", fmt.Errorf("query: %w", context.Canceled), ErrorClassCanceled),
+			Entry("timeout", context.DeadlineExceeded, ErrorClassTimeout),
+			Entry("other", errors.New("boom"), ErrorClassOther),
+		)
+	})
+
+	Describe("wrapUpstreamError", func() {
+		It("should wrap timeouts", func() {
+			err := wrapUpstreamError(&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded})
+
+			Expect(err).Should(MatchError(ErrUpstreamTimeout))
+			Expect(err).Should(MatchError(os.ErrDeadlineExceeded))
+		})
+
+		It("should wrap refused connections", func() {
+			err := wrapUpstreamError(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+
+			Expect(err).Should(MatchError(ErrUpstreamRefused))
+			Expect(err).Should(MatchError(syscall.ECONNREFUSED))
+		})
+
+		It("should keep other errors", func() {
+			err := errors.New("boom")
+
+			Expect(wrapUpstreamError(err)).Should(BeIdenticalTo(err))
+		})
+	})
+
+	Describe("errorResponse", func() {
+		It("should describe the failed query", func() {
+			resp := errorResponse(newRequest("example.com.", dns.Type(dns.TypeA)), fmt.Errorf("%w: loop", ErrConfig))
+
+			Expect(resp.RType).Should(Equal(ResponseTypeERROR))
+			Expect(resp.Reason.Code).Should(Equal(ReasonCodeERROR))
+			Expect(resp.Reason.String()).Should(Equal("ERROR (config)"))
+			Expect(resp.Res.Rcode).Should(Equal(dns.RcodeServerFailure))
+			Expect(util.ExtractDomain(resp.Res.Question[0])).Should(Equal("example.com"))
+		})
+	})
+})
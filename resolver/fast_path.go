@@ -0,0 +1,18 @@
+package resolver
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// FastPath answers cached queries without the resolver chain, e.g. with an XDP program. It gets the entries of the
+// caching resolver.
+type FastPath interface {
+	// Put adds the cached response, which expires after the TTL
+	Put(response *dns.Msg, ttl time.Duration)
+	// FlushZone removes the entries of the zone and its sub domains
+	FlushZone(zone string)
+	// Flush removes all entries
+	Flush()
+}
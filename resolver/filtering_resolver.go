@@ -2,12 +2,21 @@ package resolver
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
 	"github.com/miekg/dns"
 )
 
+// prefixes of client group names referencing a tag of the client lookup or a tenant instead of a client
+const (
+	clientTagPrefix = "tag:"
+	tenantPrefix    = "tenant:"
+)
+
 // FilteringResolver filters DNS queries (for example can drop all AAAA query)
 // returns empty ANSWER with NOERROR
 type FilteringResolver struct {
@@ -24,13 +33,181 @@ func NewFilteringResolver(cfg config.Filtering) *FilteringResolver {
 }
 
 func (r *FilteringResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
-	qType := request.Req.Question[0].Qtype
-	if r.cfg.QueryTypes.Contains(dns.Type(qType)) {
+	qType := dns.Type(request.Req.Question[0].Qtype)
+	if r.queryTypesForClient(request).Contains(qType) {
 		response := new(dns.Msg)
 		response.SetRcode(request.Req, dns.RcodeSuccess)
 
 		return &model.Response{Res: response, RType: model.ResponseTypeFILTERED}, nil
 	}
 
-	return r.next.Resolve(ctx, request)
+	response, err := r.next.Resolve(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cfg.StripECH {
+		stripECH(response.Res)
+	}
+
+	if qType == dns.Type(dns.TypeAAAA) && r.filtersAAAA(request) {
+		return r.filterAAAA(ctx, request, response), nil
+	}
+
+	return response, nil
+}
+
+// filtersAAAA checks if the AAAA answers are removed for the client and the queried domain
+func (r *FilteringResolver) filtersAAAA(request *model.Request) bool {
+	cfg := r.cfg.FilterAAAA
+	mode := cfg.Mode
+
+	matched := false
+
+	for group, groupMode := range cfg.ClientGroups {
+		if !clientMatchesGroup(group, request) {
+			continue
+		}
+
+		// the strictest mode of all matching groups wins
+		if !matched || groupMode > mode {
+			mode = groupMode
+		}
+
+		matched = true
+	}
+
+	switch mode {
+	case config.FilterAAAAModeOff:
+		return false
+	case config.FilterAAAAModeOnV4:
+		if request.ClientIP.To4() == nil {
+			return false
+		}
+	}
+
+	if len(cfg.Domains) == 0 {
+		return true
+	}
+
+	domain := strings.ToLower(request.Req.Question[0].Name)
+
+	return slices.ContainsFunc(cfg.Domains, func(d string) bool {
+		return dns.IsSubDomain(dns.Fqdn(strings.ToLower(d)), domain)
+	})
+}
+
+// filterAAAA removes the AAAA records of the response, if the domain has an A record.
+// Like BIND, the AAAA records of IPv6-only domains are kept.
+func (r *FilteringResolver) filterAAAA(
+	ctx context.Context, request *model.Request, response *model.Response,
+) *model.Response {
+	if !slices.ContainsFunc(response.Res.Answer, isAAAA) {
+		return response
+	}
+
+	aResponse, err := r.next.Resolve(ctx, &model.Request{
+		ClientIP:    request.ClientIP,
+		ClientNames: request.ClientNames,
+		ClientTags:  request.ClientTags,
+		Protocol:    request.Protocol,
+		Listener:    request.Listener,
+		Req:         util.NewMsgWithQuestion(request.Req.Question[0].Name, dns.Type(dns.TypeA)),
+		RequestTS:   request.RequestTS,
+		Unrecorded:  request.Unrecorded,
+		Tenant:      request.Tenant,
+		Categories:  request.Categories,
+	})
+	if err != nil || !slices.ContainsFunc(aResponse.Res.Answer, isA) {
+		return response
+	}
+
+	filtered := *response
+	filtered.Res = response.Res.Copy()
+	filtered.Res.Answer = slices.DeleteFunc(filtered.Res.Answer, isAAAA)
+	filtered.RType = model.ResponseTypeFILTERED
+	filtered.Reason = model.NewReason(model.ReasonCodeFILTERED, "AAAA")
+
+	recordManipulation(ctx, r.Type(), manipulationAAAAFiltered, "AAAA records removed, domain has an A record")
+
+	return &filtered
+}
+
+func isA(rr dns.RR) bool {
+	return rr.Header().Rrtype == dns.TypeA
+}
+
+func isAAAA(rr dns.RR) bool {
+	return rr.Header().Rrtype == dns.TypeAAAA
+}
+
+// stripECH removes the encrypted client hello configuration from SVCB and HTTPS records
+func stripECH(msg *dns.Msg) {
+	for _, rr := range msg.Answer {
+		var svcb *dns.SVCB
+
+		switch v := rr.(type) {
+		case *dns.SVCB:
+			svcb = v
+		case *dns.HTTPS:
+			svcb = &v.SVCB
+		default:
+			continue
+		}
+
+		svcb.Value = slices.DeleteFunc(svcb.Value, func(kv dns.SVCBKeyValue) bool {
+			return kv.Key() == dns.SVCB_ECHCONFIG
+		})
+	}
+}
+
+// returns the query types to filter for the client: the query types of all matching client groups or
+// the global query types, if no group matches
+func (r *FilteringResolver) queryTypesForClient(request *model.Request) config.QTypeSet {
+	var (
+		result  config.QTypeSet
+		matched bool
+	)
+
+	for group, qTypes := range r.cfg.ClientGroups {
+		if !clientMatchesGroup(group, request) {
+			continue
+		}
+
+		matched = true
+
+		for qType := range qTypes {
+			result.Insert(dns.Type(qType))
+		}
+	}
+
+	if !matched {
+		return r.cfg.QueryTypes
+	}
+
+	return result
+}
+
+// checks if the group name is the client's IP, a CIDR containing the IP, matches one of the client names
+// or references one of the client's tags (e.g. "tag:iot") or its tenant (e.g. "tenant:smith")
+func clientMatchesGroup(group string, request *model.Request) bool {
+	if tag, ok := strings.CutPrefix(group, clientTagPrefix); ok {
+		return slices.Contains(request.ClientTags, strings.ToLower(tag))
+	}
+
+	if tenant, ok := strings.CutPrefix(group, tenantPrefix); ok {
+		return request.Tenant != "" && strings.ToLower(tenant) == request.Tenant
+	}
+
+	if group == request.ClientIP.String() || util.CidrContainsIP(group, request.ClientIP) {
+		return true
+	}
+
+	for _, name := range request.ClientNames {
+		if util.ClientNameMatchesGroupName(group, name) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -7,6 +7,7 @@ import (
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
 
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
@@ -93,6 +94,160 @@ var _ = Describe("FilteringResolver", func() {
 		})
 	})
 
+	When("Filtering query types are defined per client group", func() {
+		BeforeEach(func() {
+			sutConfig = config.Filtering{
+				QueryTypes: config.NewQTypeSet(MX),
+				ClientGroups: map[string]config.QTypeSet{
+					"192.168.20.0/24": config.NewQTypeSet(AAAA),
+					"laptop*":         config.NewQTypeSet(HTTPS),
+					"tag:IoT":         config.NewQTypeSet(TXT),
+					"10.0.0.1":        {},
+				},
+			}
+		})
+		It("Should filter query types of the client's CIDR group", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "192.168.20.5"))).
+				Should(HaveResponseType(ResponseTypeFILTERED))
+
+			// global query types don't apply to clients with group
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", MX, "192.168.20.5"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+		It("Should filter query types of all groups matching the client's name", func() {
+			request := newRequestWithClient("example.com.", HTTPS, "192.168.20.5", "laptop-1")
+
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeFILTERED))
+
+			request = newRequestWithClient("example.com.", AAAA, "192.168.20.5", "laptop-1")
+
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeFILTERED))
+		})
+		It("Should filter query types of the group referencing the client's tag", func() {
+			request := newRequestWithClient("example.com.", TXT, "192.168.30.5")
+			request.ClientTags = []string{"iot"}
+
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeFILTERED))
+
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", TXT, "192.168.30.5", "tag:iot"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+		It("Should not filter clients with an empty group", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", MX, "10.0.0.1"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+		It("Should use global query types for other clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "192.168.30.5"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", MX, "192.168.30.5"))).
+				Should(HaveResponseType(ResponseTypeFILTERED))
+		})
+	})
+
+	When("ECH stripping is enabled", func() {
+		BeforeEach(func() {
+			sutConfig = config.Filtering{StripECH: true}
+
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, HTTPS, "1 . alpn=h2 ech=AEX+DQBB")
+		})
+		It("Should remove the ech parameter from HTTPS records", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", HTTPS))).
+				Should(
+					SatisfyAll(
+						BeDNSRecord("example.com.", HTTPS, `1 . alpn="h2"`),
+						HaveResponseType(ResponseTypeRESOLVED),
+					))
+		})
+	})
+
+	When("AAAA filtering is enabled", func() {
+		BeforeEach(func() {
+			sutConfig = config.Filtering{
+				FilterAAAA: config.FilterAAAA{
+					Mode: config.FilterAAAAModeOnV4,
+					ClientGroups: map[string]config.FilterAAAAMode{
+						"tv*":        config.FilterAAAAModeAlways,
+						"10.0.0.0/8": config.FilterAAAAModeOff,
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			// v6only.com has no A record
+			m.AnswerFn = func(qType dns.Type, qName string) (*dns.Msg, error) {
+				switch {
+				case qType == AAAA:
+					return util.NewMsgWithAnswer(qName, 300, AAAA, "2001:db8::1")
+				case qName != "v6only.com.":
+					return util.NewMsgWithAnswer(qName, 300, A, "192.0.2.1")
+				}
+
+				return new(dns.Msg), nil
+			}
+		})
+
+		It("Should remove the AAAA answers for IPv4 clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "192.168.178.2"))).
+				Should(
+					SatisfyAll(
+						HaveNoAnswer(),
+						HaveResponseType(ResponseTypeFILTERED),
+						HaveReason("FILTERED (AAAA)"),
+						HaveReturnCode(dns.RcodeSuccess),
+					))
+		})
+
+		It("Should keep the AAAA answers for IPv6 clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "fd00::2"))).
+				Should(
+					SatisfyAll(
+						BeDNSRecord("example.com.", AAAA, "2001:db8::1"),
+						HaveResponseType(ResponseTypeRESOLVED),
+					))
+		})
+
+		It("Should keep the AAAA answers of domains without A record", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("v6only.com.", AAAA, "192.168.178.2"))).
+				Should(BeDNSRecord("v6only.com.", AAAA, "2001:db8::1"))
+		})
+
+		It("Should not filter other query types", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2"))).
+				Should(BeDNSRecord("example.com.", A, "192.0.2.1"))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		It("Should use the mode of the client's group", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "fd00::2", "tv-livingroom"))).
+				Should(HaveResponseType(ResponseTypeFILTERED))
+
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "10.0.0.2"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("Should use the strictest mode of all matching groups", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "10.0.0.2", "tv-kitchen"))).
+				Should(HaveResponseType(ResponseTypeFILTERED))
+		})
+
+		When("domains are defined", func() {
+			BeforeEach(func() {
+				sutConfig.FilterAAAA.Domains = []string{"Netflix.com"}
+			})
+
+			It("Should only remove the AAAA answers of the domains and their subdomains", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("www.netflix.com.", AAAA, "192.168.178.2"))).
+					Should(HaveResponseType(ResponseTypeFILTERED))
+
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "192.168.178.2"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+	})
+
 	When("No filtering query types are defined", func() {
 		BeforeEach(func() {
 			sutConfig = config.Filtering{}
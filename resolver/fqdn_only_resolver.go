@@ -24,15 +24,33 @@ func NewFQDNOnlyResolver(cfg config.FQDNOnly) *FQDNOnlyResolver {
 }
 
 func (r *FQDNOnlyResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
-	if r.IsEnabled() {
+	if r.enabledForClient(request) {
 		domainFromQuestion := util.ExtractDomain(request.Req.Question[0])
 		if !strings.Contains(domainFromQuestion, ".") {
 			response := new(dns.Msg)
 			response.Rcode = dns.RcodeNameError
 
-			return &model.Response{Res: response, RType: model.ResponseTypeNOTFQDN, Reason: "NOTFQDN"}, nil
+			return &model.Response{Res: response, RType: model.ResponseTypeNOTFQDN, Reason: model.NewReason(model.ReasonCodeNOTFQDN)}, nil
 		}
 	}
 
 	return r.next.Resolve(ctx, request)
 }
+
+// returns true, if one of the matching client groups is enabled or the global setting, if no group matches
+func (r *FQDNOnlyResolver) enabledForClient(request *model.Request) bool {
+	var enabled, matched bool
+
+	for group, enable := range r.cfg.ClientGroups {
+		if clientMatchesGroup(group, request) {
+			matched = true
+			enabled = enabled || enable
+		}
+	}
+
+	if !matched {
+		return r.cfg.Enable
+	}
+
+	return enabled
+}
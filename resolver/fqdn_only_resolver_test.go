@@ -141,4 +141,46 @@ var _ = Describe("FqdnOnlyResolver", func() {
 			})
 		})
 	})
+
+	When("Fqdn only is configured per client group", func() {
+		BeforeEach(func() {
+			sutConfig = config.FQDNOnly{
+				Enable: false,
+				ClientGroups: map[string]bool{
+					"guest":          true,
+					"192.168.1.0/24": false,
+				},
+			}
+		})
+		It("Should return NXDOMAIN for clients of an enabled group", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example", A, "10.0.0.1", "guest"))).
+				Should(
+					SatisfyAll(
+						HaveResponseType(ResponseTypeNOTFQDN),
+						HaveReturnCode(dns.RcodeNameError),
+					))
+
+			Expect(m.Calls).Should(BeZero())
+		})
+		It("Should delegate to next resolver for clients of a disabled group", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example", A, "192.168.1.5", "lab"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+		It("Should use the global setting for other clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example", A, "10.0.0.1", "other"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+		It("Should be strict, if an enabled and a disabled group match", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example", A, "192.168.1.5", "guest"))).
+				Should(HaveResponseType(ResponseTypeNOTFQDN))
+		})
+
+		Describe("IsEnabled", func() {
+			It("is true", func() {
+				Expect(sut.IsEnabled()).Should(BeTrue())
+			})
+		})
+	})
 })
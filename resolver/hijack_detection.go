@@ -0,0 +1,171 @@
+package resolver
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const canaryLabelLength = 20
+
+// HijackDetector periodically queries random, nonexistent domains via each upstream. An upstream, which answers
+// these queries with records instead of NXDOMAIN, hijacks the responses (e.g. ISP search pages or captive portals).
+type HijackDetector struct {
+	cfg       config.HijackDetection
+	upstreams Resolver
+
+	hijacked *metrics.GaugeVec
+}
+
+// NewHijackDetector creates a detector for all upstreams of the passed upstream tree
+func NewHijackDetector(cfg config.HijackDetection, upstreams Resolver) *HijackDetector {
+	d := &HijackDetector{
+		cfg:       cfg,
+		upstreams: upstreams,
+
+		hijacked: hijackedGauge(),
+	}
+
+	metrics.RegisterMetric(d.hijacked)
+
+	return d
+}
+
+func hijackedGauge() *metrics.GaugeVec {
+	return metrics.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_upstream_hijacked",
+			Help: "Upstream answers queries for nonexistent domains (1) or not (0)",
+		}, []string{"group", "upstream"},
+	)
+}
+
+func (d *HijackDetector) logger() *logrus.Entry {
+	return log.PrefixedLog("hijack_detection")
+}
+
+// Start checks all upstreams periodically until the context is done
+func (d *HijackDetector) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.cfg.Interval.ToDuration())
+		defer ticker.Stop()
+
+		for {
+			d.Check(ctx)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Check queries the canary domains via all upstreams
+func (d *HijackDetector) Check(ctx context.Context) {
+	forEachUpstream(d.upstreams, func(group string, upstream *UpstreamResolver) {
+		hijacked, answer, ok := d.checkUpstream(ctx, upstream)
+		if !ok {
+			return
+		}
+
+		logger := d.logger().WithFields(logrus.Fields{"group": group, "upstream": upstream.cfg.String()})
+
+		if hijacked {
+			d.hijacked.WithLabelValues(group, upstream.cfg.String()).Set(1)
+
+			if !upstream.hijacked.Swap(true) {
+				logger.Warnf("upstream answers queries for nonexistent domains: %s", answer)
+				evt.Bus().Publish(evt.UpstreamHijackDetected, upstream.cfg.String(), answer)
+			}
+
+			if d.cfg.Disable && !upstream.disabled.Swap(true) {
+				logger.Warn("upstream disabled")
+			}
+
+			return
+		}
+
+		d.hijacked.WithLabelValues(group, upstream.cfg.String()).Set(0)
+
+		if upstream.hijacked.Swap(false) {
+			logger.Info("upstream does not answer queries for nonexistent domains anymore")
+		}
+
+		if upstream.disabled.Swap(false) {
+			logger.Info("upstream enabled")
+		}
+	})
+}
+
+// checkUpstream returns true, if the upstream hijacks responses. ok is false, if the check was inconclusive.
+func (d *HijackDetector) checkUpstream(
+	ctx context.Context, upstream *UpstreamResolver,
+) (hijacked bool, answer string, ok bool) {
+	for range d.cfg.Canaries {
+		request := newRequest(canaryDomain(), dns.Type(dns.TypeA))
+
+		response, err := upstream.resolve(ctx, request)
+		if err != nil {
+			d.logger().WithField("upstream", upstream.cfg.String()).Debugf("canary query failed: %v", err)
+
+			continue
+		}
+
+		ok = true
+
+		if response.Res.Rcode == dns.RcodeSuccess && len(response.Res.Answer) > 0 {
+			return true, util.AnswerToString(response.Res.Answer), true
+		}
+	}
+
+	return false, "", ok
+}
+
+// canaryDomain returns a random domain, which doesn't exist
+func canaryDomain() string {
+	return strings.ToLower(rand.Text()[:canaryLabelLength]) + ".com."
+}
+
+// forEachUpstream calls the callback for each upstream resolver in the upstream tree
+func forEachUpstream(res Resolver, callback func(group string, upstream *UpstreamResolver)) {
+	var statuses []*upstreamResolverStatus
+
+	group := config.UpstreamDefaultCfgName
+
+	switch r := res.(type) {
+	case *UpstreamTreeResolver:
+		for _, branch := range r.branches {
+			forEachUpstream(branch, callback)
+		}
+
+		return
+	case *ParallelBestResolver:
+		statuses, group = *r.resolvers.Load(), r.cfg.Name
+	case *StrictResolver:
+		statuses, group = *r.resolvers.Load(), r.cfg.Name
+	case *UpstreamResolver:
+		callback(group, r)
+
+		return
+	}
+
+	for _, status := range statuses {
+		// the bootstrap resolver is used until the upstreams are initialized
+		if upstream, ok := status.resolver.(*UpstreamResolver); ok {
+			callback(group, upstream)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("HijackDetector", Label("hijackDetector"), func() {
+	var (
+		sut       *HijackDetector
+		sutConfig config.HijackDetection
+
+		upstream *UpstreamResolver
+		rcode    atomic.Int32
+		hijacks  atomic.Bool
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.HijackDetection]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Enable = true
+
+		rcode.Store(dns.RcodeNameError)
+		hijacks.Store(false)
+
+		mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+			response := new(dns.Msg)
+			response.SetReply(request)
+
+			if hijacks.Load() {
+				rr, err := dns.NewRR(request.Question[0].Name + " 300 IN A 192.0.2.1")
+				Expect(err).Should(Succeed())
+
+				response.Answer = append(response.Answer, rr)
+
+				return response
+			}
+
+			response.Rcode = int(rcode.Load())
+
+			return response
+		})
+
+		upstream = newUpstreamResolverUnchecked(newUpstreamConfig(mockUpstream.Start(), defaultUpstreamsConfig), nil)
+	})
+
+	JustBeforeEach(func() {
+		group := config.NewUpstreamGroup("iot", defaultUpstreamsConfig, []config.Upstream{upstream.cfg.Upstream})
+
+		sut = NewHijackDetector(sutConfig, newParallelBestResolver(group, []Resolver{upstream}))
+	})
+
+	gauge := func() float64 {
+		return testutil.ToFloat64(sut.hijacked.WithLabelValues("iot", upstream.cfg.String()))
+	}
+
+	Describe("Check", func() {
+		When("upstream answers with NXDOMAIN", func() {
+			It("should not report the upstream", func() {
+				sut.Check(ctx)
+
+				Expect(gauge()).Should(BeZero())
+				Expect(upstream.hijacked.Load()).Should(BeFalse())
+			})
+		})
+
+		When("upstream answers queries for nonexistent domains", func() {
+			BeforeEach(func() {
+				hijacks.Store(true)
+			})
+
+			It("should report the upstream once", func() {
+				var answers []string
+
+				handler := func(name, answer string) {
+					Expect(name).Should(Equal(upstream.cfg.String()))
+
+					answers = append(answers, answer)
+				}
+				Expect(Bus().Subscribe(UpstreamHijackDetected, handler)).Should(Succeed())
+				DeferCleanup(Bus().Unsubscribe, UpstreamHijackDetected, handler)
+
+				sut.Check(ctx)
+				sut.Check(ctx)
+
+				Expect(gauge()).Should(BeNumerically("==", 1))
+				Expect(answers).Should(HaveLen(1))
+				Expect(answers[0]).Should(Equal("A (192.0.2.1)"))
+			})
+
+			It("should keep the upstream enabled", func() {
+				sut.Check(ctx)
+
+				_, err := upstream.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+			})
+
+			It("should reset the state if the upstream is fixed", func() {
+				sut.Check(ctx)
+				Expect(gauge()).Should(BeNumerically("==", 1))
+
+				hijacks.Store(false)
+				sut.Check(ctx)
+
+				Expect(gauge()).Should(BeZero())
+				Expect(upstream.hijacked.Load()).Should(BeFalse())
+			})
+
+			When("disable is enabled", func() {
+				BeforeEach(func() {
+					sutConfig.Disable = true
+				})
+
+				It("should disable the upstream until it is fixed", func() {
+					sut.Check(ctx)
+
+					_, err := upstream.Resolve(ctx, newRequest("example.com.", A))
+					Expect(errors.Is(err, errUpstreamDisabled)).Should(BeTrue())
+
+					hijacks.Store(false)
+					sut.Check(ctx)
+
+					_, err = upstream.Resolve(ctx, newRequest("example.com.", A))
+					Expect(err).Should(Succeed())
+				})
+			})
+		})
+
+		When("canary queries fail", func() {
+			BeforeEach(func() {
+				rcode.Store(dns.RcodeServerFailure)
+			})
+
+			It("should keep the previous state", func() {
+				hijacks.Store(true)
+				sut.Check(ctx)
+				Expect(gauge()).Should(BeNumerically("==", 1))
+
+				hijacks.Store(false)
+				sut.Check(ctx)
+
+				Expect(gauge()).Should(BeNumerically("==", 1))
+				Expect(upstream.hijacked.Load()).Should(BeTrue())
+			})
+		})
+	})
+
+	Describe("Start", func() {
+		BeforeEach(func() {
+			hijacks.Store(true)
+		})
+
+		It("should check the upstreams immediately", func() {
+			sut.Start(ctx)
+
+			Eventually(upstream.hijacked.Load).Should(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("forEachUpstream", func() {
+	It("should visit the upstreams of all groups", func() {
+		first := newUpstreamResolverUnchecked(
+			newUpstreamConfig(config.Upstream{Host: "192.0.2.1", Port: 53, Net: config.NetProtocolTcpUdp},
+				defaultUpstreamsConfig), nil)
+		second := newUpstreamResolverUnchecked(
+			newUpstreamConfig(config.Upstream{Host: "192.0.2.2", Port: 53, Net: config.NetProtocolTcpUdp},
+				defaultUpstreamsConfig), nil)
+
+		tree := &UpstreamTreeResolver{
+			branches: map[string]Resolver{
+				config.UpstreamDefaultCfgName: newParallelBestResolver(
+					config.NewUpstreamGroup(config.UpstreamDefaultCfgName, defaultUpstreamsConfig, nil),
+					[]Resolver{first}),
+				"iot": newStrictResolver(
+					config.NewUpstreamGroup("iot", defaultUpstreamsConfig, nil), []Resolver{second}),
+			},
+		}
+
+		visited := map[string]string{}
+
+		forEachUpstream(tree, func(group string, upstream *UpstreamResolver) {
+			visited[upstream.cfg.Host] = group
+		})
+
+		Expect(visited).Should(Equal(map[string]string{
+			"192.0.2.1": config.UpstreamDefaultCfgName,
+			"192.0.2.2": "iot",
+		}))
+	})
+})
@@ -103,7 +103,7 @@ func (r *HostsFileResolver) handleReverseDNS(request *model.Request) *model.Resp
 				response.Answer = append(response.Answer, ptrAlias)
 			}
 
-			return &model.Response{Res: response, RType: model.ResponseTypeHOSTSFILE, Reason: "HOSTS FILE"}
+			return &model.Response{Res: response, RType: model.ResponseTypeHOSTSFILE, Reason: model.NewReason(model.ReasonCodeHOSTSFILE)}
 		}
 	}
 
@@ -132,7 +132,7 @@ func (r *HostsFileResolver) Resolve(ctx context.Context, request *model.Request)
 			"domain": util.Obfuscate(domain),
 		}).Debugf("returning hosts file entry")
 
-		return &model.Response{Res: response, RType: model.ResponseTypeHOSTSFILE, Reason: "HOSTS FILE"}, nil
+		return &model.Response{Res: response, RType: model.ResponseTypeHOSTSFILE, Reason: model.NewReason(model.ReasonCodeHOSTSFILE)}, nil
 	}
 
 	logger.WithField("next_resolver", Name(r.next)).Trace("go to next resolver")
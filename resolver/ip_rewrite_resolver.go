@@ -0,0 +1,63 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// IPRewriteResolver replaces resolved IPs in the A and AAAA records of the responses with the mapped IPs.
+// This allows to reach internal hosts via their public names in networks without hairpin NAT.
+type IPRewriteResolver struct {
+	configurable[*config.IPRewrite]
+	NextResolver
+	typed
+}
+
+// NewIPRewriteResolver creates new resolver instance
+func NewIPRewriteResolver(cfg config.IPRewrite) *IPRewriteResolver {
+	return &IPRewriteResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("ip_rewrite"),
+	}
+}
+
+// Resolve rewrites the IPs in the response of the next resolver
+func (r *IPRewriteResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.log(ctx)
+
+	resp, err := r.next.Resolve(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rr := range resp.Res.Answer {
+		switch v := rr.(type) {
+		case *dns.A:
+			v.A = r.rewrite(logger, v.A)
+		case *dns.AAAA:
+			v.AAAA = r.rewrite(logger, v.AAAA)
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *IPRewriteResolver) rewrite(logger *logrus.Entry, ip net.IP) net.IP {
+	to, ok := r.cfg.Mapping[ip.String()]
+	if !ok {
+		return ip
+	}
+
+	logger.WithFields(logrus.Fields{"from": ip, "to": to}).Debug("rewriting IP")
+
+	return to
+}
@@ -0,0 +1,146 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("IPRewriteResolver", func() {
+	var (
+		sut        *IPRewriteResolver
+		sutConfig  config.IPRewrite
+		m          *mockResolver
+		mockAnswer *dns.Msg
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.IPRewrite{
+			Mapping: map[string]net.IP{
+				"203.0.113.10": net.ParseIP("192.168.1.10"),
+				"2001:db8::10": net.ParseIP("fd00::10"),
+			},
+		}
+
+		mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "203.0.113.10")
+	})
+
+	JustBeforeEach(func() {
+		sut = NewIPRewriteResolver(sutConfig)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("no mapping is configured", func() {
+			BeforeEach(func() {
+				sutConfig = config.IPRewrite{}
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not modify the response", func() {
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(BeDNSRecord("example.com.", A, "203.0.113.10"))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("the response contains a mapped IPv4 address", func() {
+		It("should rewrite the address", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(
+					SatisfyAll(
+						BeDNSRecord("example.com.", A, "192.168.1.10"),
+						HaveTTL(BeNumerically("==", 300)),
+						HaveResponseType(ResponseTypeRESOLVED),
+					))
+		})
+	})
+
+	When("the response contains a mapped IPv6 address", func() {
+		BeforeEach(func() {
+			mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, AAAA, "2001:0db8:0::10")
+		})
+
+		It("should rewrite the address", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", AAAA))).
+				Should(BeDNSRecord("example.com.", AAAA, "fd00::10"))
+		})
+	})
+
+	When("the response contains other addresses", func() {
+		BeforeEach(func() {
+			rr1, _ := dns.NewRR("example.com. 300 IN CNAME www.example.com.")
+			rr2, _ := dns.NewRR("www.example.com. 300 IN A 203.0.113.11")
+			rr3, _ := dns.NewRR("www.example.com. 300 IN A 203.0.113.10")
+			mockAnswer = new(dns.Msg)
+			mockAnswer.Answer = []dns.RR{rr1, rr2, rr3}
+		})
+
+		It("should only rewrite the mapped addresses", func() {
+			resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(resp.Res.Answer).Should(HaveLen(3))
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("example.com.", CNAME, "www.example.com."),
+				BeDNSRecord("www.example.com.", A, "203.0.113.11"),
+				BeDNSRecord("www.example.com.", A, "192.168.1.10"),
+			))
+		})
+	})
+
+	When("the next resolver returns an error", func() {
+		JustBeforeEach(func() {
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(nil, errors.New("error"))
+			sut.Next(m)
+		})
+
+		It("should return the error", func() {
+			_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+)
+
+// IPv6OnlyResolver answers the A queries of the configured clients in networks without IPv4 connectivity with an
+// empty answer, so the clients don't try to connect to unreachable IPv4 addresses
+type IPv6OnlyResolver struct {
+	configurable[*config.IPv6Only]
+	NextResolver
+	typed
+}
+
+func NewIPv6OnlyResolver(cfg config.IPv6Only) *IPv6OnlyResolver {
+	return &IPv6OnlyResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("ipv6_only"),
+	}
+}
+
+// Resolve returns an empty answer for A queries of the configured clients
+func (r *IPv6OnlyResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() || request.Req.Question[0].Qtype != dns.TypeA || !r.filtersA(request) {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.log(ctx)
+
+	logger.Debug("filtering A query of IPv6 only client")
+
+	recordManipulation(ctx, r.Type(), manipulationAFiltered, "A query answered without records")
+
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, dns.RcodeSuccess)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeFILTERED, Reason: model.NewReason(model.ReasonCodeFILTERED, "IPv6 ONLY")}, nil
+}
+
+// filtersA checks if the client belongs to one of the groups, which get no A answers
+func (r *IPv6OnlyResolver) filtersA(request *model.Request) bool {
+	return slices.ContainsFunc(r.cfg.FilterA, func(group string) bool {
+		return clientMatchesGroup(group, request)
+	})
+}
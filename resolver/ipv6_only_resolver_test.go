@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("IPv6OnlyResolver", func() {
+	var (
+		sut       *IPv6OnlyResolver
+		sutConfig config.IPv6Only
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.IPv6Only{
+			Enable:  true,
+			FilterA: []string{"laptop", "10.0.0.0/8"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewIPv6OnlyResolver(sutConfig)
+
+		mockAnswer, err := util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("should filter A queries of the configured clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"))).
+				Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveResponseType(ResponseTypeFILTERED),
+					HaveReason("FILTERED (IPv6 ONLY)"),
+					HaveReturnCode(dns.RcodeSuccess),
+				))
+
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.1.2.3"))).
+				Should(HaveResponseType(ResponseTypeFILTERED))
+
+			Expect(m.Calls).Should(BeEmpty())
+		})
+
+		It("should record the filtered query as manipulation", func() {
+			ctx := WithResponseManipulations(ctx)
+
+			_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.1.2.3"))
+			Expect(err).Should(Succeed())
+
+			Expect(responseManipulationsFromCtx(ctx).list()).Should(ConsistOf(
+				HaveField("Action", manipulationAFiltered),
+			))
+		})
+
+		It("should delegate the queries of other clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "tv"))).
+				Should(SatisfyAll(
+					BeDNSRecord("example.com.", A, "192.0.2.1"),
+					HaveResponseType(ResponseTypeRESOLVED),
+				))
+		})
+
+		It("should delegate other query types", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", AAAA, "10.1.2.3"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("should delegate all queries", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.1.2.3"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+	})
+})
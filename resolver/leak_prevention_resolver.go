@@ -0,0 +1,71 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+//nolint:gochecknoglobals
+var preventedLeaks = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_leak_prevention_blocked_total",
+		Help: "Number of queries for internal zones, which were not forwarded to the upstreams",
+	}, []string{"zone"},
+))
+
+// LeakPreventionResolver answers queries for internal zones with NXDOMAIN. It is the last resolver before the
+// upstreams, so queries, which could not be resolved locally (e.g. by the fallback of custom DNS or a rewrite),
+// don't leak the internal names to the public upstreams.
+type LeakPreventionResolver struct {
+	configurable[*config.LeakPrevention]
+	NextResolver
+	typed
+
+	zones internalZones
+}
+
+// NewLeakPreventionResolver creates a resolver for the additional zones of the configuration
+// and the domains of custom DNS and conditional mapping
+func NewLeakPreventionResolver(
+	cfg config.LeakPrevention, customDNS config.CustomDNS, conditional config.ConditionalUpstream,
+) *LeakPreventionResolver {
+	return &LeakPreventionResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("leak_prevention"),
+
+		zones: newInternalZones(cfg.Zones, customDNS, conditional),
+	}
+}
+
+// Resolve answers the request with NXDOMAIN, if the query is for an internal zone
+func (r *LeakPreventionResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	zone, internal := r.zones.find(util.ExtractDomain(request.Req.Question[0]))
+	if !internal {
+		return r.next.Resolve(ctx, request)
+	}
+
+	_, logger := r.logWithFields(ctx, logrus.Fields{
+		"zone":     zone,
+		"question": util.QuestionToString(request.Req.Question),
+	})
+
+	logger.Debug("preventing leak of query for internal zone")
+
+	preventedLeaks.WithLabelValues(zone).Inc()
+
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, dns.RcodeNameError)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeBLOCKED, Reason: model.NewReason(model.ReasonCodeLEAKPREVENTION)}, nil
+}
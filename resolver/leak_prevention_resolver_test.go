@@ -0,0 +1,151 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("LeakPreventionResolver", func() {
+	var (
+		sut            *LeakPreventionResolver
+		sutConfig      config.LeakPrevention
+		customDNSCfg   config.CustomDNS
+		conditionalCfg config.ConditionalUpstream
+		m              *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.LeakPrevention{
+			Enable: true,
+			Zones:  []string{"corp.example"},
+		}
+
+		customDNSCfg = config.CustomDNS{
+			Mapping: config.CustomDNSMapping{
+				"printer.lan": {&dns.A{A: []byte{192, 168, 178, 3}}},
+			},
+		}
+
+		conditionalCfg = config.ConditionalUpstream{
+			Mapping: config.ConditionalUpstreamMapping{
+				Upstreams: map[string][]config.Upstream{
+					"fritz.box": {config.Upstream{Host: "192.168.178.1"}},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewLeakPreventionResolver(sutConfig, customDNSCfg, conditionalCfg)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false and delegates all queries", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+
+				Expect(sut.Resolve(ctx, newRequest("printer.lan.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+				Expect(m.Calls).Should(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElement("additional zones: corp.example"))
+		})
+	})
+
+	When("query is for an internal zone", func() {
+		DescribeTable("should answer with NXDOMAIN",
+			func(question string) {
+				Expect(sut.Resolve(ctx, newRequest(question, A))).
+					Should(SatisfyAll(
+						HaveNoAnswer(),
+						HaveResponseType(ResponseTypeBLOCKED),
+						HaveReason("LEAK PREVENTION"),
+						HaveReturnCode(dns.RcodeNameError),
+					))
+
+				Expect(m.Calls).Should(BeEmpty())
+			},
+			Entry("custom DNS mapping", "printer.lan."),
+			Entry("sub domain of custom DNS mapping", "sub.printer.lan."),
+			Entry("conditional mapping", "nas.fritz.box."),
+			Entry("additional zone", "www.corp.example."),
+			Entry("upper case", "NAS.Fritz.Box."),
+		)
+
+		It("should count the prevented leaks per zone", func() {
+			before := testutil.ToFloat64(preventedLeaks.WithLabelValues("fritz.box"))
+
+			Expect(sut.Resolve(ctx, newRequest("nas.fritz.box.", AAAA))).
+				Should(HaveReturnCode(dns.RcodeNameError))
+
+			Expect(testutil.ToFloat64(preventedLeaks.WithLabelValues("fritz.box")) - before).
+				Should(BeNumerically("==", 1))
+		})
+	})
+
+	When("query is for a public domain", func() {
+		It("should delegate to next resolver", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		It("should not match domains with the same suffix", func() {
+			Expect(sut.Resolve(ctx, newRequest("myprinter.lan.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+	})
+
+	When("conditional mapping for single label names is configured", func() {
+		BeforeEach(func() {
+			conditionalCfg.Mapping.Upstreams["."] = []config.Upstream{{Host: "192.168.178.1"}}
+		})
+
+		It("should answer single label names with NXDOMAIN", func() {
+			Expect(sut.Resolve(ctx, newRequest("nas.", A))).
+				Should(HaveReturnCode(dns.RcodeNameError))
+		})
+	})
+})
@@ -23,17 +23,18 @@ type MetricsResolver struct {
 	NextResolver
 	typed
 
-	totalQueries      *prometheus.CounterVec
-	totalResponse     *prometheus.CounterVec
-	totalErrors       prometheus.Counter
-	durationHistogram *prometheus.HistogramVec
+	totalQueries      *metrics.CounterVec
+	totalResponse     *metrics.CounterVec
+	totalErrors       *metrics.CounterVec
+	durationHistogram *metrics.HistogramVec
+	categoryQueries   *metrics.CounterVec
 }
 
 // Resolve resolves the passed request
 func (r *MetricsResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
 	response, err := r.next.Resolve(ctx, request)
 
-	if r.cfg.Enable {
+	if r.cfg.Enable && !request.Unrecorded {
 		r.totalQueries.With(prometheus.Labels{
 			"client": strings.Join(request.ClientNames, ","),
 			"type":   dns.TypeToString[request.Req.Question[0].Qtype],
@@ -48,11 +49,15 @@ func (r *MetricsResolver) Resolve(ctx context.Context, request *model.Request) (
 
 		r.durationHistogram.WithLabelValues(responseType).Observe(reqDuration.Seconds())
 
+		for _, category := range request.Categories {
+			r.categoryQueries.WithLabelValues(category, responseType).Inc()
+		}
+
 		if err != nil {
-			r.totalErrors.Inc()
+			r.totalErrors.WithLabelValues(ErrorClass(err)).Inc()
 		} else {
 			r.totalResponse.With(prometheus.Labels{
-				"reason":        response.Reason,
+				"reason":        response.Reason.String(),
 				"response_code": dns.RcodeToString[response.Res.Rcode],
 				"response_type": response.RType.String(),
 			}).Inc()
@@ -72,6 +77,7 @@ func NewMetricsResolver(cfg config.Metrics) *MetricsResolver {
 		totalQueries:      totalQueriesMetric(),
 		totalResponse:     totalResponseMetric(),
 		totalErrors:       totalErrorMetric(),
+		categoryQueries:   categoryQueriesMetric(),
 	}
 
 	m.registerMetrics()
@@ -84,10 +90,11 @@ func (r *MetricsResolver) registerMetrics() {
 	metrics.RegisterMetric(r.totalQueries)
 	metrics.RegisterMetric(r.totalResponse)
 	metrics.RegisterMetric(r.totalErrors)
+	metrics.RegisterMetric(r.categoryQueries)
 }
 
-func totalQueriesMetric() *prometheus.CounterVec {
-	return prometheus.NewCounterVec(
+func totalQueriesMetric() *metrics.CounterVec {
+	return metrics.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_query_total",
 			Help: "Number of total queries",
@@ -95,17 +102,17 @@ func totalQueriesMetric() *prometheus.CounterVec {
 	)
 }
 
-func totalErrorMetric() prometheus.Counter {
-	return prometheus.NewCounter(
+func totalErrorMetric() *metrics.CounterVec {
+	return metrics.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_error_total",
 			Help: "Number of total errors",
-		},
+		}, []string{"error_class"},
 	)
 }
 
-func durationHistogram() *prometheus.HistogramVec {
-	return prometheus.NewHistogramVec(
+func durationHistogram() *metrics.HistogramVec {
+	return metrics.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:                        "blocky_request_duration_seconds",
 			Help:                        "Request duration distribution",
@@ -116,11 +123,20 @@ func durationHistogram() *prometheus.HistogramVec {
 	)
 }
 
-func totalResponseMetric() *prometheus.CounterVec {
-	return prometheus.NewCounterVec(
+func totalResponseMetric() *metrics.CounterVec {
+	return metrics.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blocky_response_total",
 			Help: "Number of total responses",
 		}, []string{"reason", "response_code", "response_type"},
 	)
 }
+
+func categoryQueriesMetric() *metrics.CounterVec {
+	return metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_category_query_total",
+			Help: "Number of queries for domains of a category",
+		}, []string{"category", "response_type"},
+	)
+}
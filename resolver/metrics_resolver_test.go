@@ -3,6 +3,7 @@ package resolver
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
@@ -77,6 +78,31 @@ var _ = Describe("MetricResolver", func() {
 					m.AssertExpectations(GinkgoT())
 				})
 			})
+			When("the domain has categories", func() {
+				It("should count the queries per category", func() {
+					request := newRequestWithClient("example.com.", A, "", "client")
+					request.Categories = []string{"social", "video"}
+
+					Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+					Expect(testutil.ToFloat64(sut.categoryQueries.WithLabelValues("social", "RESOLVED"))).
+						Should(BeNumerically("==", 1))
+					Expect(testutil.ToFloat64(sut.categoryQueries.WithLabelValues("video", "RESOLVED"))).
+						Should(BeNumerically("==", 1))
+				})
+			})
+			When("Request is unrecorded", func() {
+				It("Should not record metrics", func() {
+					request := newRequestWithClient("example.com.", A, "", "client")
+					request.Unrecorded = true
+
+					Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+					Expect(testutil.CollectAndCount(sut.totalQueries)).Should(BeZero())
+					Expect(testutil.CollectAndCount(sut.totalResponse)).Should(BeZero())
+					m.AssertExpectations(GinkgoT())
+				})
+			})
 			When("Error occurs while request processing", func() {
 				BeforeEach(func() {
 					m = &mockResolver{}
@@ -89,6 +115,19 @@ var _ = Describe("MetricResolver", func() {
 					Expect(err).Should(HaveOccurred())
 
 					Expect(testutil.ToFloat64(sut.totalErrors)).Should(BeNumerically("==", 1))
+					Expect(testutil.ToFloat64(sut.totalErrors.WithLabelValues(ErrorClassOther))).Should(BeNumerically("==", 1))
+				})
+
+				It("should record the class of the error", func() {
+					m = &mockResolver{}
+					m.On("Resolve", mock.Anything).Return(nil, fmt.Errorf("%w: refused", ErrUpstreamRefused))
+					sut.Next(m)
+
+					_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "", "client"))
+					Expect(err).Should(HaveOccurred())
+
+					Expect(testutil.ToFloat64(sut.totalErrors.WithLabelValues(ErrorClassUpstreamRefused))).
+						Should(BeNumerically("==", 1))
 				})
 			})
 		})
@@ -0,0 +1,176 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// results of the mirrored queries, used in the metrics
+const (
+	mirrorResultMatch   = "match"
+	mirrorResultDiff    = "diff"
+	mirrorResultError   = "error"
+	mirrorResultDropped = "dropped"
+)
+
+//nolint:gochecknoglobals
+var mirroredQueries = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_mirrored_queries_total",
+		Help: "Number of queries mirrored to the shadow upstream",
+	}, []string{"result"},
+))
+
+// MirroringResolver sends a sample of the queries asynchronously to a shadow upstream and logs the differences
+// between the answers of the shadow upstream and the own responses. The responses to the clients are not affected.
+type MirroringResolver struct {
+	configurable[*config.Mirroring]
+	NextResolver
+	typed
+
+	shadow Resolver
+	// limits the number of mirrored queries in flight
+	slots chan struct{}
+}
+
+// NewMirroringResolver creates a new resolver instance
+func NewMirroringResolver(
+	cfg config.Mirroring, upstreamsCfg config.Upstreams, bootstrap *Bootstrap,
+) *MirroringResolver {
+	r := MirroringResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("mirroring"),
+
+		slots: make(chan struct{}, cfg.MaxInFlight),
+	}
+
+	if cfg.IsEnabled() {
+		// the shadow upstream is not checked on start, it must not affect the own resolution
+		r.shadow = newUpstreamResolverUnchecked(newUpstreamConfig(cfg.Upstream, upstreamsCfg), bootstrap)
+	}
+
+	return &r
+}
+
+// Resolve resolves the request with the next resolver and mirrors sampled queries to the shadow upstream
+func (r *MirroringResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() || rand.IntN(100) >= int(r.cfg.SampleRate) { //nolint:gosec,mnd // no security relevance
+		return r.next.Resolve(ctx, request)
+	}
+
+	// the next resolvers may modify the query
+	mirrored := &model.Request{
+		ClientIP:    request.ClientIP,
+		ClientNames: request.ClientNames,
+		Protocol:    request.Protocol,
+		Req:         request.Req.Copy(),
+		RequestTS:   request.RequestTS,
+	}
+
+	response, err := r.next.Resolve(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		mirroredQueries.WithLabelValues(mirrorResultDropped).Inc()
+
+		return response, nil
+	}
+
+	// the previous resolvers may modify the response
+	own := response.Res.Copy()
+
+	go func() {
+		defer func() { <-r.slots }()
+
+		r.mirror(context.WithoutCancel(ctx), mirrored, own)
+	}()
+
+	return response, nil
+}
+
+func (r *MirroringResolver) mirror(ctx context.Context, request *model.Request, own *dns.Msg) {
+	ctx, logger := r.log(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout.ToDuration())
+	defer cancel()
+
+	shadow, err := r.shadow.Resolve(ctx, request)
+	if err != nil {
+		mirroredQueries.WithLabelValues(mirrorResultError).Inc()
+		logger.Debugf("mirrored query failed: %s", err)
+
+		return
+	}
+
+	diff := answerDiff(own, shadow.Res)
+	if diff == "" {
+		mirroredQueries.WithLabelValues(mirrorResultMatch).Inc()
+
+		return
+	}
+
+	mirroredQueries.WithLabelValues(mirrorResultDiff).Inc()
+	logger.WithFields(logrus.Fields{
+		"question": util.QuestionToString(request.Req.Question),
+	}).Infof("answer of shadow upstream differs: %s", diff)
+}
+
+// answerDiff describes the differences of the response codes and answers, the TTLs and the order are ignored.
+// Empty, if the responses match.
+func answerDiff(own, shadow *dns.Msg) string {
+	var diffs []string
+
+	if own.Rcode != shadow.Rcode {
+		diffs = append(diffs, fmt.Sprintf("rcode %s != %s", dns.RcodeToString[own.Rcode], dns.RcodeToString[shadow.Rcode]))
+	}
+
+	if onlyOwn := missingRRs(own.Answer, shadow.Answer); len(onlyOwn) > 0 {
+		diffs = append(diffs, "only own: "+util.AnswerToString(onlyOwn))
+	}
+
+	if onlyShadow := missingRRs(shadow.Answer, own.Answer); len(onlyShadow) > 0 {
+		diffs = append(diffs, "only shadow: "+util.AnswerToString(onlyShadow))
+	}
+
+	return strings.Join(diffs, "; ")
+}
+
+// missingRRs returns the records of rrs, which are not in other
+func missingRRs(rrs, other []dns.RR) []dns.RR {
+	keys := make(map[string]struct{}, len(other))
+	for _, rr := range other {
+		keys[rrKey(rr)] = struct{}{}
+	}
+
+	var result []dns.RR
+
+	for _, rr := range rrs {
+		if _, ok := keys[rrKey(rr)]; !ok {
+			result = append(result, rr)
+		}
+	}
+
+	return result
+}
+
+func rrKey(rr dns.RR) string {
+	rr = dns.Copy(rr)
+	rr.Header().Ttl = 0
+
+	return strings.ToLower(rr.String())
+}
@@ -0,0 +1,229 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("MirroringResolver", func() {
+	var (
+		sut       *MirroringResolver
+		sutConfig config.Mirroring
+		m         *mockResolver
+		shadow    *mockResolver
+		shadowFn  func(ctx context.Context, req *Request) (*Response, error)
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	mirrored := func(result string) func() float64 {
+		return func() float64 {
+			return testutil.ToFloat64(mirroredQueries.WithLabelValues(result))
+		}
+	}
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		var err error
+
+		sutConfig, err = config.WithDefaults[config.Mirroring]()
+		Expect(err).Should(Succeed())
+
+		sutConfig.Upstream = config.Upstream{Net: config.NetProtocolTcpUdp, Host: "192.0.2.53", Port: 53}
+
+		shadowFn = func(_ context.Context, req *Request) (*Response, error) {
+			response, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 60, A, "192.0.2.1")
+			Expect(err).Should(Succeed())
+
+			return &Response{Res: response, RType: ResponseTypeRESOLVED}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewMirroringResolver(sutConfig, defaultUpstreamsConfig, systemResolverBootstrap)
+
+		m = &mockResolver{ResolveFn: func(_ context.Context, req *Request) (*Response, error) {
+			response, err := util.NewMsgWithAnswer(req.Req.Question[0].Name, 300, A, "192.0.2.1")
+			Expect(err).Should(Succeed())
+
+			return &Response{Res: response, RType: ResponseTypeRESOLVED}, nil
+		}}
+		m.On("Resolve", mock.Anything)
+		sut.Next(m)
+
+		shadow = &mockResolver{ResolveFn: func(ctx context.Context, req *Request) (*Response, error) {
+			return shadowFn(ctx, req)
+		}}
+		shadow.On("Resolve", mock.Anything)
+		sut.shadow = shadow
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("mirroring is disabled", func() {
+		BeforeEach(func() {
+			sutConfig.Upstream = config.Upstream{}
+		})
+
+		It("should not mirror queries", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Consistently(func() int { return len(shadow.Calls) }, "50ms").Should(BeZero())
+		})
+	})
+
+	When("the sample rate is 0", func() {
+		BeforeEach(func() {
+			sutConfig.SampleRate = 0
+		})
+
+		It("should not mirror queries", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Consistently(func() int { return len(shadow.Calls) }, "50ms").Should(BeZero())
+		})
+	})
+
+	It("should count matching answers, the TTL is ignored", func() {
+		before := mirrored(mirrorResultMatch)()
+
+		Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+			Should(HaveResponseType(ResponseTypeRESOLVED))
+
+		Eventually(mirrored(mirrorResultMatch)).Should(Equal(before + 1))
+		shadow.AssertNumberOfCalls(GinkgoT(), "Resolve", 1)
+	})
+
+	When("the answers differ", func() {
+		BeforeEach(func() {
+			shadowFn = func(_ context.Context, req *Request) (*Response, error) {
+				response := new(dns.Msg)
+				response.SetRcode(req.Req, dns.RcodeNameError)
+
+				return &Response{Res: response, RType: ResponseTypeRESOLVED}, nil
+			}
+		})
+
+		It("should not affect the response and count the difference", func() {
+			before := mirrored(mirrorResultDiff)()
+
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(SatisfyAll(
+					BeDNSRecord("example.com.", A, "192.0.2.1"),
+					HaveReturnCode(dns.RcodeSuccess),
+				))
+
+			Eventually(mirrored(mirrorResultDiff)).Should(Equal(before + 1))
+		})
+	})
+
+	When("the shadow upstream fails", func() {
+		BeforeEach(func() {
+			shadowFn = func(context.Context, *Request) (*Response, error) {
+				return nil, errors.New("timeout")
+			}
+		})
+
+		It("should count the error", func() {
+			before := mirrored(mirrorResultError)()
+
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Eventually(mirrored(mirrorResultError)).Should(Equal(before + 1))
+		})
+	})
+
+	When("too many mirrored queries are in flight", func() {
+		BeforeEach(func() {
+			sutConfig.MaxInFlight = 1
+
+			release := make(chan struct{})
+			DeferCleanup(func() { close(release) })
+
+			shadowFn = func(context.Context, *Request) (*Response, error) {
+				<-release
+
+				return nil, errors.New("released")
+			}
+		})
+
+		It("should drop the query", func() {
+			before := mirrored(mirrorResultDropped)()
+
+			_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			_, err = sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			Expect(mirrored(mirrorResultDropped)()).Should(Equal(before + 1))
+		})
+	})
+})
+
+var _ = Describe("answerDiff", func() {
+	msg := func(rcode int, rrs ...string) *dns.Msg {
+		res := new(dns.Msg)
+		res.Rcode = rcode
+
+		for _, s := range rrs {
+			rr, err := dns.NewRR(s)
+			Expect(err).Should(Succeed())
+
+			res.Answer = append(res.Answer, rr)
+		}
+
+		return res
+	}
+
+	It("should ignore TTL, order and case", func() {
+		Expect(answerDiff(
+			msg(dns.RcodeSuccess, "example.com. 300 IN A 192.0.2.1", "example.com. 300 IN A 192.0.2.2"),
+			msg(dns.RcodeSuccess, "EXAMPLE.com. 10 IN A 192.0.2.2", "example.com. 10 IN A 192.0.2.1"),
+		)).Should(BeEmpty())
+	})
+
+	It("should describe the differences", func() {
+		Expect(answerDiff(
+			msg(dns.RcodeSuccess, "example.com. 300 IN A 192.0.2.1"),
+			msg(dns.RcodeServerFailure, "example.com. 300 IN A 192.0.2.2"),
+		)).Should(Equal("rcode NOERROR != SERVFAIL; only own: A (192.0.2.1); only shadow: A (192.0.2.2)"))
+	})
+})
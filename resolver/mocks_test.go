@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/redis"
 	"github.com/0xERR0R/blocky/util"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -56,9 +57,8 @@ func (r *mockResolver) Resolve(ctx context.Context, req *model.Request) (*model.
 
 	if r.ResponseFn != nil {
 		return &model.Response{
-			Res:    r.ResponseFn(req.Req),
-			Reason: "",
-			RType:  model.ResponseTypeRESOLVED,
+			Res:   r.ResponseFn(req.Req),
+			RType: model.ResponseTypeRESOLVED,
 		}, nil
 	}
 
@@ -71,9 +71,8 @@ func (r *mockResolver) Resolve(ctx context.Context, req *model.Request) (*model.
 
 			if answer != nil {
 				return &model.Response{
-					Res:    answer,
-					Reason: "",
-					RType:  model.ResponseTypeRESOLVED,
+					Res:   answer,
+					RType: model.ResponseTypeRESOLVED,
 				}, nil
 			}
 		}
@@ -82,9 +81,8 @@ func (r *mockResolver) Resolve(ctx context.Context, req *model.Request) (*model.
 		response.SetRcode(req.Req, dns.RcodeBadName)
 
 		return &model.Response{
-			Res:    response,
-			Reason: "",
-			RType:  model.ResponseTypeRESOLVED,
+			Res:   response,
+			RType: model.ResponseTypeRESOLVED,
 		}, nil
 	}
 
@@ -259,3 +257,52 @@ func (ec *mockExpiringCache) PutTotalCount() int {
 func (ec *mockExpiringCache) PutClear() {
 	panic("not implemented")
 }
+
+// mockSyncBus is a bus, which isn't backed by redis, e.g. NATS
+type mockSyncBus struct {
+	publishedCache   chan string
+	publishedEnabled chan *redis.EnabledMessage
+	cacheMessages    chan *redis.CacheMessage
+	enabledMessages  chan *redis.EnabledMessage
+}
+
+func newMockSyncBus() *mockSyncBus {
+	return &mockSyncBus{
+		publishedCache:   make(chan string, 10),
+		publishedEnabled: make(chan *redis.EnabledMessage, 10),
+		cacheMessages:    make(chan *redis.CacheMessage),
+		enabledMessages:  make(chan *redis.EnabledMessage),
+	}
+}
+
+func (b *mockSyncBus) PublishCache(key string, _ *dns.Msg) {
+	b.publishedCache <- key
+}
+
+func (b *mockSyncBus) PublishEnabled(_ context.Context, state *redis.EnabledMessage) {
+	b.publishedEnabled <- state
+}
+
+func (b *mockSyncBus) CacheMessages() <-chan *redis.CacheMessage {
+	return b.cacheMessages
+}
+
+func (b *mockSyncBus) EnabledMessages() <-chan *redis.EnabledMessage {
+	return b.enabledMessages
+}
+
+type mockFastPath struct {
+	mock.Mock
+}
+
+func (f *mockFastPath) Put(response *dns.Msg, ttl time.Duration) {
+	f.Called(response, ttl)
+}
+
+func (f *mockFastPath) FlushZone(zone string) {
+	f.Called(zone)
+}
+
+func (f *mockFastPath) Flush() {
+	f.Called()
+}
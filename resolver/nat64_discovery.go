@@ -0,0 +1,177 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// the well-known name and IPv4 addresses to discover the NAT64 prefix (RFC 7050)
+const nat64DiscoveryDomain = "ipv4only.arpa."
+
+//nolint:gochecknoglobals
+var nat64WellKnownIPs = []net.IP{net.IPv4(192, 0, 0, 170).To4(), net.IPv4(192, 0, 0, 171).To4()}
+
+// NAT64Discovery periodically resolves `ipv4only.arpa` via the upstreams. The DNS64 server of the network synthesizes
+// AAAA records for it, which contain the NAT64 prefix in front of the well-known IPv4 addresses.
+type NAT64Discovery struct {
+	cfg       config.NAT64Discovery
+	upstreams Resolver
+
+	lock     sync.RWMutex
+	prefixes []net.IPNet
+
+	prefixGauge *metrics.GaugeVec
+}
+
+// NewNAT64Discovery creates a discovery, which resolves the well-known name via the passed upstreams
+func NewNAT64Discovery(cfg config.NAT64Discovery, upstreams Resolver) *NAT64Discovery {
+	d := &NAT64Discovery{
+		cfg:       cfg,
+		upstreams: upstreams,
+
+		prefixGauge: nat64PrefixGauge(),
+	}
+
+	metrics.RegisterMetric(d.prefixGauge)
+
+	return d
+}
+
+func nat64PrefixGauge() *metrics.GaugeVec {
+	return metrics.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blocky_nat64_prefix",
+			Help: "NAT64 prefix discovered via ipv4only.arpa (1) or no longer announced (0)",
+		}, []string{"prefix"},
+	)
+}
+
+func (d *NAT64Discovery) logger() *logrus.Entry {
+	return log.PrefixedLog("nat64_discovery")
+}
+
+// Start discovers the prefixes periodically until the context is done
+func (d *NAT64Discovery) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.cfg.Interval.ToDuration())
+		defer ticker.Stop()
+
+		for {
+			d.Discover(ctx)
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Prefixes returns the NAT64 prefixes of the last successful discovery
+func (d *NAT64Discovery) Prefixes() []net.IPNet {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return slices.Clone(d.prefixes)
+}
+
+// Discover resolves the well-known name and updates the prefixes. The prefixes are kept, if the query fails.
+func (d *NAT64Discovery) Discover(ctx context.Context) {
+	response, err := d.upstreams.Resolve(ctx, newRequest(nat64DiscoveryDomain, dns.Type(dns.TypeAAAA)))
+	if err != nil {
+		d.logger().Warnf("can't resolve %s: %v", nat64DiscoveryDomain, err)
+
+		return
+	}
+
+	var prefixes []net.IPNet
+
+	for _, rr := range response.Res.Answer {
+		aaaa, ok := rr.(*dns.AAAA)
+		if !ok {
+			continue
+		}
+
+		prefix, ok := nat64Prefix(aaaa.AAAA)
+		if ok && !slices.ContainsFunc(prefixes, func(p net.IPNet) bool { return p.String() == prefix.String() }) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	d.lock.Lock()
+	previous := d.prefixes
+	d.prefixes = prefixes
+	d.lock.Unlock()
+
+	for _, prefix := range previous {
+		d.prefixGauge.WithLabelValues(prefix.String()).Set(0)
+	}
+
+	for _, prefix := range prefixes {
+		d.prefixGauge.WithLabelValues(prefix.String()).Set(1)
+	}
+
+	if prefixesToString(previous) == prefixesToString(prefixes) {
+		return
+	}
+
+	if len(prefixes) == 0 {
+		d.logger().Warn("no NAT64 prefix found, the network has no DNS64")
+
+		return
+	}
+
+	d.logger().Infof("discovered NAT64 prefix: %s", prefixesToString(prefixes))
+}
+
+// nat64Prefix extracts the prefix of an IPv4-embedded IPv6 address of the well-known IPv4 addresses.
+// The positions of the IPv4 address for the prefix lengths are defined in RFC 6052, bits 64 to 71 are skipped.
+func nat64Prefix(ip net.IP) (net.IPNet, bool) {
+	ip = ip.To16()
+	if ip == nil || ip.To4() != nil {
+		return net.IPNet{}, false
+	}
+
+	for _, prefixLen := range []int{96, 64, 56, 48, 40, 32} {
+		var embedded net.IP
+
+		for i := prefixLen / 8; len(embedded) < net.IPv4len; i++ {
+			if i != 8 {
+				embedded = append(embedded, ip[i])
+			}
+		}
+
+		if !slices.ContainsFunc(nat64WellKnownIPs, embedded.Equal) {
+			continue
+		}
+
+		mask := net.CIDRMask(prefixLen, 8*net.IPv6len)
+
+		return net.IPNet{IP: ip.Mask(mask), Mask: mask}, true
+	}
+
+	return net.IPNet{}, false
+}
+
+func prefixesToString(prefixes []net.IPNet) string {
+	result := make([]string, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		result = append(result, prefix.String())
+	}
+
+	return strings.Join(result, ", ")
+}
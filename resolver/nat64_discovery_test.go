@@ -0,0 +1,113 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("NAT64Discovery", func() {
+	var (
+		sut *NAT64Discovery
+		m   *mockResolver
+
+		answer *dns.Msg
+		err    error
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		answer, err = util.NewMsgWithAnswer(nat64DiscoveryDomain, 300, AAAA, "64:ff9b::c000:aa")
+		Expect(err).Should(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: answer}, err)
+
+		cfg, cfgErr := config.WithDefaults[config.NAT64Discovery]()
+		Expect(cfgErr).Should(Succeed())
+
+		sut = NewNAT64Discovery(cfg, m)
+	})
+
+	Describe("Discover", func() {
+		It("should extract the prefix of the synthesized address", func() {
+			sut.Discover(ctx)
+
+			Expect(m.Calls).Should(HaveLen(1))
+			Expect(m.Calls[0].Arguments.Get(0).(*Request).Req.Question[0]).Should(SatisfyAll(
+				HaveField("Name", "ipv4only.arpa."),
+				HaveField("Qtype", dns.TypeAAAA),
+			))
+
+			Expect(prefixesToString(sut.Prefixes())).Should(Equal("64:ff9b::/96"))
+			Expect(testutil.ToFloat64(sut.prefixGauge.WithLabelValues("64:ff9b::/96"))).Should(BeNumerically("==", 1))
+		})
+
+		When("the query fails", func() {
+			BeforeEach(func() {
+				err = errors.New("timeout")
+			})
+
+			It("should keep the prefixes", func() {
+				sut.prefixes = []net.IPNet{{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)}}
+
+				sut.Discover(ctx)
+
+				Expect(prefixesToString(sut.Prefixes())).Should(Equal("64:ff9b::/96"))
+			})
+		})
+
+		When("the network has no DNS64", func() {
+			BeforeEach(func() {
+				answer = new(dns.Msg)
+			})
+
+			It("should find no prefix", func() {
+				sut.Discover(ctx)
+
+				Expect(sut.Prefixes()).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("nat64Prefix", func() {
+		DescribeTable("should extract the prefix of all lengths",
+			func(address, prefix string) {
+				result, ok := nat64Prefix(net.ParseIP(address))
+				Expect(ok).Should(BeTrue())
+				Expect(result.String()).Should(Equal(prefix))
+			},
+			Entry("/32", "2001:db8:c000:aa::", "2001:db8::/32"),
+			Entry("/40", "2001:db8:1c0:0:aa::", "2001:db8:100::/40"),
+			Entry("/48", "2001:db8:122:c000:0:aa00::", "2001:db8:122::/48"),
+			Entry("/56", "2001:db8:122:3c0:0:aa:0:0", "2001:db8:122:300::/56"),
+			Entry("/64", "2001:db8:122:344:c0:0:aa00:0", "2001:db8:122:344::/64"),
+			Entry("/96", "2001:db8:122:344::192.0.0.171", "2001:db8:122:344::/96"),
+		)
+
+		It("should ignore other addresses", func() {
+			_, ok := nat64Prefix(net.ParseIP("2001:db8::1"))
+			Expect(ok).Should(BeFalse())
+
+			_, ok = nat64Prefix(net.ParseIP("192.0.0.170"))
+			Expect(ok).Should(BeFalse())
+		})
+	})
+})
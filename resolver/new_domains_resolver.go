@@ -0,0 +1,285 @@
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	newDomainsResolverType = "new_domains"
+
+	firstSeenStoreHeader = "blocky first seen v1"
+	firstSeenSavePeriod  = time.Minute
+)
+
+var errInvalidFirstSeenStore = errors.New("invalid first seen store file")
+
+//nolint:gochecknoglobals
+var (
+	newDomainQueries = metrics.Registered(metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_new_domain_queries_total",
+			Help: "Number of queries for domains first observed less than the minimum age ago",
+		}, []string{"action"},
+	))
+
+	firstSeenDomains = promauto.With(metrics.Reg).NewGauge(
+		prometheus.GaugeOpts{
+			Name: "blocky_new_domain_tracked_domains",
+			Help: "Number of registrable domains with a first-seen timestamp",
+		},
+	)
+)
+
+// NewDomainsResolver tracks when registrable domains are queried first and flags or blocks queries
+// for domains, which were first observed less than the minimum age ago
+type NewDomainsResolver struct {
+	configurable[*config.NewDomains]
+	NextResolver
+	typed
+
+	lock sync.Mutex
+	// start of the learning period: the first start without stored timestamps
+	started time.Time
+	// first-seen timestamps of the registrable domains as unix seconds
+	firstSeen map[string]int64
+	// true if the timestamps changed since the last save
+	dirty bool
+}
+
+// NewNewDomainsResolver creates new resolver instance and loads the stored first-seen timestamps
+func NewNewDomainsResolver(ctx context.Context, cfg config.NewDomains) (*NewDomainsResolver, error) {
+	r := &NewDomainsResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(newDomainsResolverType),
+
+		started:   time.Now(),
+		firstSeen: make(map[string]int64),
+	}
+
+	if !cfg.IsEnabled() || cfg.StoreFile == "" {
+		return r, nil
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	firstSeenDomains.Set(float64(len(r.firstSeen)))
+
+	go r.periodicSave(ctx)
+
+	return r, nil
+}
+
+// Resolve flags or blocks the request, if the registrable domain was first observed less than the minimum age ago
+func (r *NewDomainsResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	domain := util.ExtractDomain(request.Req.Question[0])
+
+	// reverse lookups have no registrable domain
+	if strings.HasSuffix(domain, ".arpa") {
+		return r.next.Resolve(ctx, request)
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return r.next.Resolve(ctx, request)
+	}
+
+	age, learning := r.observe(registrable, time.Now())
+	if learning || age >= r.cfg.MinAge.ToDuration() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.logWithFields(ctx, logrus.Fields{
+		"domain":      domain,
+		"registrable": registrable,
+		"first_seen":  age.Round(time.Second).String() + " ago",
+		"action":      r.cfg.Action,
+	})
+
+	logger.Warn("query for newly observed domain")
+
+	newDomainQueries.WithLabelValues(r.cfg.Action.String()).Inc()
+
+	if r.cfg.Action != config.NewDomainActionBlock {
+		return r.next.Resolve(ctx, request)
+	}
+
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, dns.RcodeNameError)
+
+	return &model.Response{
+		Res:    response,
+		RType:  model.ResponseTypeBLOCKED,
+		Reason: model.NewReason(model.ReasonCodeNEWDOMAIN),
+	}, nil
+}
+
+// observe records the first-seen timestamp of the domain and returns the time since it was first seen and
+// whether the learning period is still running
+func (r *NewDomainsResolver) observe(domain string, now time.Time) (time.Duration, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	firstSeen, ok := r.firstSeen[domain]
+	if !ok {
+		firstSeen = now.Unix()
+		r.firstSeen[domain] = firstSeen
+		r.dirty = true
+
+		firstSeenDomains.Set(float64(len(r.firstSeen)))
+	}
+
+	learning := now.Sub(r.started) < r.cfg.LearningPeriod.ToDuration()
+
+	return now.Sub(time.Unix(firstSeen, 0)), learning
+}
+
+// saves the changed timestamps periodically and on shutdown
+func (r *NewDomainsResolver) periodicSave(ctx context.Context) {
+	ticker := time.NewTicker(firstSeenSavePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.saveLogged()
+		case <-ctx.Done():
+			r.saveLogged()
+
+			return
+		}
+	}
+}
+
+func (r *NewDomainsResolver) saveLogged() {
+	if err := r.save(); err != nil {
+		log.PrefixedLog(newDomainsResolverType).Errorf("can't save first seen timestamps: %s", err)
+	}
+}
+
+// load reads the stored timestamps, a missing file starts a new learning period
+func (r *NewDomainsResolver) load() error {
+	f, err := os.Open(r.cfg.StoreFile)
+	if errors.Is(err, os.ErrNotExist) {
+		// create the file to persist the start of the learning period
+		r.dirty = true
+
+		return r.save()
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't open first seen store: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	if !scanner.Scan() || scanner.Text() != firstSeenStoreHeader || !scanner.Scan() {
+		return errInvalidFirstSeenStore
+	}
+
+	started, err := strconv.ParseInt(scanner.Text(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidFirstSeenStore, err)
+	}
+
+	r.started = time.Unix(started, 0)
+
+	for scanner.Scan() {
+		timestamp, domain, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			return errInvalidFirstSeenStore
+		}
+
+		firstSeen, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidFirstSeenStore, err)
+		}
+
+		r.firstSeen[domain] = firstSeen
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("can't read first seen store: %w", err)
+	}
+
+	return nil
+}
+
+// save writes all timestamps to the store file, if they changed since the last save
+func (r *NewDomainsResolver) save() error {
+	r.lock.Lock()
+
+	if !r.dirty {
+		r.lock.Unlock()
+
+		return nil
+	}
+
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "%s\n%d\n", firstSeenStoreHeader, r.started.Unix())
+
+	for domain, firstSeen := range r.firstSeen {
+		fmt.Fprintf(&content, "%d %s\n", firstSeen, domain)
+	}
+
+	r.dirty = false
+	r.lock.Unlock()
+
+	err := writeFileAtomic(r.cfg.StoreFile, []byte(content.String()))
+	if err != nil {
+		r.lock.Lock()
+		r.dirty = true
+		r.lock.Unlock()
+	}
+
+	return err
+}
+
+// writeFileAtomic replaces the file with a temporary file, so readers never see a partially written file
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,234 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("NewDomainsResolver", func() {
+	var (
+		sut        *NewDomainsResolver
+		sutConfig  config.NewDomains
+		m          *mockResolver
+		mockAnswer *dns.Msg
+		tmpDir     *TmpFolder
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	// writes a store, which finished the learning period and knows the domains with the ages
+	createStore := func(ages map[string]time.Duration) {
+		now := time.Now()
+		lines := []string{firstSeenStoreHeader, fmt.Sprint(now.Add(-30 * 24 * time.Hour).Unix())}
+
+		for domain, age := range ages {
+			lines = append(lines, fmt.Sprintf("%d %s", now.Add(-age).Unix(), domain))
+		}
+
+		tmpDir.CreateStringFile("first-seen", lines...)
+	}
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		tmpDir = NewTmpFolder("NewDomainsResolver")
+		DeferCleanup(tmpDir.Clean)
+
+		sutConfig = config.NewDomains{
+			Enable:         true,
+			MinAge:         config.Duration(24 * time.Hour),
+			LearningPeriod: config.Duration(7 * 24 * time.Hour),
+			Action:         config.NewDomainActionBlock,
+			StoreFile:      tmpDir.JoinPath("first-seen"),
+		}
+
+		createStore(map[string]time.Duration{
+			"example.com":   48 * time.Hour,
+			"example.net":   time.Hour,
+			"example.co.uk": 48 * time.Hour,
+		})
+
+		mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewNewDomainsResolver(ctx, sutConfig)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("disabled", func() {
+			BeforeEach(func() {
+				sutConfig.Enable = false
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should delegate to the next resolver", func() {
+				Expect(sut.Resolve(ctx, newRequest("new.org.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	DescribeTable("should block newly observed domains",
+		func(domain string) {
+			Expect(sut.Resolve(ctx, newRequest(domain, A))).
+				Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveResponseType(ResponseTypeBLOCKED),
+					HaveReturnCode(dns.RcodeNameError),
+					HaveReason("NEW DOMAIN"),
+				))
+			Expect(m.Calls).Should(BeEmpty())
+		},
+		Entry("unknown domain", "new.org."),
+		Entry("subdomain of unknown domain", "www.new.org."),
+		Entry("domain seen recently", "example.net."),
+		Entry("subdomain of domain seen recently", "c2.example.net."),
+		Entry("other registrable domain below public suffix", "other.co.uk."),
+	)
+
+	DescribeTable("should resolve known domains",
+		func(domain string) {
+			Expect(sut.Resolve(ctx, newRequest(domain, A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		},
+		Entry("known domain", "example.com."),
+		Entry("subdomain of known domain", "www.example.com."),
+		Entry("known domain below public suffix", "www.example.co.uk."),
+		Entry("public suffix", "co.uk."),
+		Entry("reverse lookup", "1.2.0.192.in-addr.arpa."),
+	)
+
+	It("should allow domains after the min age", func() {
+		createStore(map[string]time.Duration{"example.net": 25 * time.Hour})
+
+		sut, err := NewNewDomainsResolver(ctx, sutConfig)
+		Expect(err).Should(Succeed())
+		sut.Next(m)
+
+		Expect(sut.Resolve(ctx, newRequest("example.net.", A))).
+			Should(HaveResponseType(ResponseTypeRESOLVED))
+	})
+
+	When("the action is flag", func() {
+		BeforeEach(func() {
+			sutConfig.Action = config.NewDomainActionFlag
+		})
+
+		It("should resolve newly observed domains", func() {
+			Expect(sut.Resolve(ctx, newRequest("new.org.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+	})
+
+	When("the store doesn't exist", func() {
+		BeforeEach(func() {
+			Expect(os.Remove(sutConfig.StoreFile)).Should(Succeed())
+		})
+
+		It("should only record domains during the learning period", func() {
+			Expect(sut.Resolve(ctx, newRequest("new.org.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sutConfig.StoreFile).Should(BeAnExistingFile())
+		})
+
+		When("there is no learning period", func() {
+			BeforeEach(func() {
+				sutConfig.LearningPeriod = 0
+			})
+
+			It("should block all domains", func() {
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(HaveResponseType(ResponseTypeBLOCKED))
+			})
+		})
+	})
+
+	Describe("store", func() {
+		It("should persist the first seen timestamps", func() {
+			Expect(sut.Resolve(ctx, newRequest("www.new.org.", A))).
+				Should(HaveResponseType(ResponseTypeBLOCKED))
+
+			Expect(sut.save()).Should(Succeed())
+
+			reloaded, err := NewNewDomainsResolver(ctx, sutConfig)
+			Expect(err).Should(Succeed())
+
+			Expect(reloaded.started).Should(Equal(sut.started.Truncate(time.Second)))
+			Expect(reloaded.firstSeen).Should(Equal(sut.firstSeen))
+			Expect(reloaded.firstSeen).Should(HaveKey("new.org"))
+		})
+
+		It("should save the timestamps on shutdown", func() {
+			Expect(sut.Resolve(ctx, newRequest("new.org.", A))).
+				Should(HaveResponseType(ResponseTypeBLOCKED))
+
+			cancelFn()
+
+			Eventually(func(g Gomega) {
+				content, err := os.ReadFile(sutConfig.StoreFile)
+				g.Expect(err).Should(Succeed())
+				g.Expect(string(content)).Should(ContainSubstring(" new.org\n"))
+			}).Should(Succeed())
+		})
+
+		DescribeTable("should fail on invalid stores",
+			func(lines ...string) {
+				tmpDir.CreateStringFile("first-seen", lines...)
+
+				_, err := NewNewDomainsResolver(ctx, sutConfig)
+				Expect(err).Should(MatchError(errInvalidFirstSeenStore))
+			},
+			Entry("without header", "1700000000", "1700000000 example.com"),
+			Entry("invalid start", firstSeenStoreHeader, "yesterday"),
+			Entry("invalid timestamp", firstSeenStoreHeader, "1700000000", "now example.com"),
+			Entry("missing domain", firstSeenStoreHeader, "1700000000", "1700000000"),
+		)
+	})
+})
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"math/rand"
 	"strings"
 	"sync/atomic"
@@ -38,41 +37,42 @@ type ParallelBestResolver struct {
 }
 
 type upstreamResolverStatus struct {
-	resolver      Resolver
-	lastErrorTime atomic.Value
+	resolver Resolver
+	score    *upstreamScore
 }
 
-func newUpstreamResolverStatus(resolver Resolver) *upstreamResolverStatus {
-	status := &upstreamResolverStatus{
+func newUpstreamResolverStatus(resolver Resolver, halfLife time.Duration) *upstreamResolverStatus {
+	return &upstreamResolverStatus{
 		resolver: resolver,
+		score:    newUpstreamScore(halfLife),
 	}
-
-	status.lastErrorTime.Store(time.Unix(0, 0))
-
-	return status
 }
 
-func newUpstreamResolverStatuses(resolvers []Resolver) []*upstreamResolverStatus {
+func newUpstreamResolverStatuses(resolvers []Resolver, halfLife time.Duration) []*upstreamResolverStatus {
 	statuses := make([]*upstreamResolverStatus, 0, len(resolvers))
 
 	for _, r := range resolvers {
-		statuses = append(statuses, newUpstreamResolverStatus(r))
+		statuses = append(statuses, newUpstreamResolverStatus(r, halfLife))
 	}
 
 	return statuses
 }
 
 func (r *upstreamResolverStatus) resolve(ctx context.Context, req *model.Request) (*model.Response, error) {
+	start := time.Now()
+
 	resp, err := r.resolver.Resolve(ctx, req)
 	if err != nil {
 		// Ignore `Canceled`: resolver lost the race, not an error
 		if !errors.Is(err, context.Canceled) {
-			r.lastErrorTime.Store(time.Now())
+			r.score.recordError(time.Now())
 		}
 
 		return nil, fmt.Errorf("%s: %w", r.resolver, err)
 	}
 
+	r.score.recordSuccess(time.Now(), time.Since(start))
+
 	return resp, nil
 }
 
@@ -123,7 +123,7 @@ func newParallelBestResolver(cfg config.UpstreamGroup, resolvers []Resolver) *Pa
 		retryWithDifferentResolver: retryWithDifferentResolver,
 	}
 
-	r.setResolvers(newUpstreamResolverStatuses(resolvers))
+	r.setResolvers(newUpstreamResolverStatuses(resolvers, cfg.WeightHalfLife.ToDuration()))
 
 	return &r
 }
@@ -157,7 +157,12 @@ func (r *ParallelBestResolver) Resolve(ctx context.Context, request *model.Reque
 		resolver := allResolvers[0]
 		logger.WithField("resolver", resolver.resolver).Debug("delegating to resolver")
 
-		return resolver.resolve(ctx, request)
+		resp, err := resolver.resolve(ctx, request)
+		if err == nil {
+			markSelected(resolver.resolver)
+		}
+
+		return resp, err
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -202,6 +207,8 @@ func evaluateResponses(
 
 		logger.WithField("answer", util.AnswerToString(result.response.Res.Answer)).Debug("using response from resolver")
 
+		markSelected(*result.resolver)
+
 		return result.response, nil
 	}
 
@@ -225,6 +232,8 @@ func (r *ParallelBestResolver) retryWithDifferent(
 		"answer":   util.AnswerToString(resp.Res.Answer),
 	}).Debug("using response from resolver")
 
+	markSelected(resolver.resolver)
+
 	return resp, nil
 }
 
@@ -239,10 +248,10 @@ func pickRandom(ctx context.Context, resolvers []*upstreamResolverStatus, resolv
 	return chosenResolvers
 }
 
+// weightedRandom picks a random resolver, which is not excluded. The resolvers are weighted with their decayed
+// error rate and latency.
 func weightedRandom(ctx context.Context, in, excludedResolvers []*upstreamResolverStatus) *upstreamResolverStatus {
-	const errorWindowInSec = 60
-
-	choices := make([]weightedrand.Choice[*upstreamResolverStatus, uint], 0, len(in))
+	candidates := make([]*upstreamResolverStatus, 0, len(in))
 
 outer:
 	for _, res := range in {
@@ -252,15 +261,14 @@ outer:
 			}
 		}
 
-		var weight float64 = errorWindowInSec
+		candidates = append(candidates, res)
+	}
 
-		if time.Since(res.lastErrorTime.Load().(time.Time)) < time.Hour {
-			// reduce weight: consider last error time
-			lastErrorTime := res.lastErrorTime.Load().(time.Time)
-			weight = math.Max(1, weight-(errorWindowInSec-time.Since(lastErrorTime).Minutes()))
-		}
+	weights := upstreamWeights(currentScores(candidates))
+	choices := make([]weightedrand.Choice[*upstreamResolverStatus, uint], 0, len(candidates))
 
-		choices = append(choices, weightedrand.NewChoice(res, uint(weight)))
+	for i, res := range candidates {
+		choices = append(choices, weightedrand.NewChoice(res, max(1, uint(weights[i]*upstreamWeightScale))))
 	}
 
 	c, err := weightedrand.NewChooser(choices...)
@@ -274,3 +282,15 @@ outer:
 
 	return c.Pick()
 }
+
+// currentScores returns the decayed scores of the resolvers
+func currentScores(resolvers []*upstreamResolverStatus) []upstreamScoreSnapshot {
+	now := time.Now()
+	scores := make([]upstreamScoreSnapshot, 0, len(resolvers))
+
+	for _, res := range resolvers {
+		scores = append(scores, res.score.snapshot(now))
+	}
+
+	return scores
+}
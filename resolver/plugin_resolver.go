@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/extension"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PluginResolver delegates queries to a custom resolver, which is either loaded as Go plugin
+// or connected via the remote resolver protocol
+type PluginResolver struct {
+	configurable[*config.Plugin]
+	NextResolver
+	typed
+
+	plugin extension.Resolver
+}
+
+// NewPluginResolver creates new resolver instance
+func NewPluginResolver(cfg config.Plugin) (*PluginResolver, error) {
+	var (
+		plugin extension.Resolver
+		err    error
+	)
+
+	switch {
+	case cfg.Path != "":
+		plugin, err = extension.Load(cfg.Path, cfg.Args)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.URL != "":
+		plugin = extension.NewRemote(cfg.URL, &http.Client{Timeout: cfg.Timeout.ToDuration()})
+	default:
+		return nil, errors.New("path or url must be set")
+	}
+
+	return newPluginResolver(cfg, plugin), nil
+}
+
+func newPluginResolver(cfg config.Plugin, plugin extension.Resolver) *PluginResolver {
+	return &PluginResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("plugin"),
+
+		plugin: plugin,
+	}
+}
+
+// Name implements `NamedResolver`
+func (r *PluginResolver) Name() string {
+	return fmt.Sprintf("%s (%s)", r.Type(), r.cfg.Name)
+}
+
+// Resolve passes the query to the plugin, the next resolver is called if the plugin has no answer
+func (r *PluginResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	ctx, logger := r.logWithFields(ctx, logrus.Fields{"plugin": r.cfg.Name})
+
+	msg, err := r.plugin.Resolve(ctx, &extension.Query{
+		ClientIP:    request.ClientIP,
+		ClientNames: request.ClientNames,
+		Protocol:    strings.ToLower(request.Protocol.String()),
+		Msg:         request.Req,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' failed: %w", r.cfg.Name, err)
+	}
+
+	if msg == nil {
+		logger.Trace("no answer from plugin, go to next resolver")
+
+		return r.next.Resolve(ctx, request)
+	}
+
+	msg.Id = request.Req.Id
+
+	return &model.Response{Res: msg, RType: model.ResponseTypePLUGIN, Reason: model.NewReason(model.ReasonCodePLUGIN, r.cfg.Name)}, nil
+}
@@ -0,0 +1,154 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/extension"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("PluginResolver", func() {
+	var (
+		sut       *PluginResolver
+		sutConfig config.Plugin
+		m         *mockResolver
+		received  *extension.Query
+		answer    *dns.Msg
+		pluginErr error
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	plugin := extension.ResolverFunc(func(_ context.Context, query *extension.Query) (*dns.Msg, error) {
+		received = query
+
+		return answer, pluginErr
+	})
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Plugin{Name: "policy", URL: "http://localhost"}
+		received = nil
+		answer = nil
+		pluginErr = nil
+	})
+
+	JustBeforeEach(func() {
+		sut = newPluginResolver(sutConfig, plugin)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	Describe("Name", func() {
+		It("should contain the plugin name", func() {
+			Expect(Name(sut)).Should(Equal("plugin (policy)"))
+		})
+	})
+
+	When("plugin has an answer", func() {
+		BeforeEach(func() {
+			answer = new(dns.Msg)
+			answer.SetRcode(util.NewMsgWithQuestion("example.com", A), dns.RcodeRefused)
+		})
+
+		It("should return the answer of the plugin", func() {
+			request := newRequestWithClient("example.com.", A, "10.0.0.1", "client1")
+
+			Expect(sut.Resolve(ctx, request)).
+				Should(
+					SatisfyAll(
+						HaveResponseType(ResponseTypePLUGIN),
+						HaveReason("PLUGIN (policy)"),
+						HaveReturnCode(dns.RcodeRefused),
+					))
+
+			Expect(received.ClientIP.String()).Should(Equal("10.0.0.1"))
+			Expect(received.ClientNames).Should(Equal([]string{"client1"}))
+			Expect(received.Protocol).Should(Equal("udp"))
+			Expect(m.Calls).Should(BeEmpty())
+		})
+	})
+
+	When("plugin has no answer", func() {
+		It("should delegate to next resolver", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+	})
+
+	When("plugin fails", func() {
+		BeforeEach(func() {
+			pluginErr = errors.New("boom")
+		})
+
+		It("should return error", func() {
+			_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(MatchError("plugin 'policy' failed: boom"))
+			Expect(m.Calls).Should(BeEmpty())
+		})
+	})
+
+	Describe("NewPluginResolver", func() {
+		It("should connect remote resolvers", func() {
+			server := httptest.NewServer(extension.Handler(plugin))
+			DeferCleanup(server.Close)
+
+			res, err := NewPluginResolver(config.Plugin{Name: "remote", URL: server.URL})
+			Expect(err).Should(Succeed())
+
+			answer = new(dns.Msg)
+			answer.SetRcode(util.NewMsgWithQuestion("example.com", A), dns.RcodeRefused)
+
+			Expect(res.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypePLUGIN))
+		})
+
+		It("should fail if Go plugin can't be loaded", func() {
+			_, err := NewPluginResolver(config.Plugin{Name: "local", Path: "/does/not/exist.so"})
+			Expect(err).Should(MatchError(ContainSubstring("can't open plugin")))
+		})
+
+		It("should fail without path and url", func() {
+			_, err := NewPluginResolver(config.Plugin{Name: "empty"})
+			Expect(err).Should(MatchError("path or url must be set"))
+		})
+	})
+})
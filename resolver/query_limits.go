@@ -0,0 +1,146 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// names of the query limits, used in errors and as metric label
+const (
+	queryLimitCNAMEChain     = "cnameChain"
+	queryLimitCustomDNSDepth = "customDNSDepth"
+	queryLimitRewrites       = "rewrites"
+	queryLimitWork           = "work"
+)
+
+//nolint:gochecknoglobals
+var queryLimitsExceeded = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_query_limit_exceeded_total",
+		Help: "Number of queries answered with SERVFAIL, since they exceeded a processing limit",
+	}, []string{"limit"},
+))
+
+type queryLimitsCtxKey struct{}
+
+// QueryLimitError is returned by the resolvers, if the processing of a query exceeds one of the limits
+type QueryLimitError struct {
+	Limit string
+	Max   uint
+}
+
+func (e *QueryLimitError) Error() string {
+	return fmt.Sprintf("query limit exceeded: %s > %d", e.Limit, e.Max)
+}
+
+// queryLimits counts the work spent on a single query
+type queryLimits struct {
+	cfg config.QueryLimits
+
+	lock     sync.Mutex
+	rewrites uint
+	work     uint
+}
+
+// WithQueryLimits returns a context, which limits the processing of the query resolved with it
+func WithQueryLimits(ctx context.Context, cfg config.QueryLimits) context.Context {
+	return context.WithValue(ctx, queryLimitsCtxKey{}, &queryLimits{cfg: cfg})
+}
+
+func queryLimitsFromCtx(ctx context.Context) *queryLimits {
+	limits, _ := ctx.Value(queryLimitsCtxKey{}).(*queryLimits)
+
+	return limits
+}
+
+// newQueryLimitError counts the exceeded limit and returns the error
+func newQueryLimitError(limit string, maxValue uint) error {
+	queryLimitsExceeded.WithLabelValues(limit).Inc()
+
+	return &QueryLimitError{Limit: limit, Max: maxValue}
+}
+
+// exceeds returns true, if the limit is enabled (> 0) and the value exceeds it
+func exceeds(value, limit uint) bool {
+	return limit > 0 && value > limit
+}
+
+// spendQueryWork adds a unit of work to the query and returns an error, if the total work exceeds the limit
+func spendQueryWork(ctx context.Context) error {
+	limits := queryLimitsFromCtx(ctx)
+	if limits == nil {
+		return nil
+	}
+
+	limits.lock.Lock()
+	defer limits.lock.Unlock()
+
+	limits.work++
+
+	if exceeds(limits.work, limits.cfg.Work) {
+		return newQueryLimitError(queryLimitWork, limits.cfg.Work)
+	}
+
+	return nil
+}
+
+// spendQueryRewrite counts an applied rewrite of the query
+func spendQueryRewrite(ctx context.Context) error {
+	limits := queryLimitsFromCtx(ctx)
+	if limits == nil {
+		return nil
+	}
+
+	limits.lock.Lock()
+	limits.rewrites++
+	rewrites := limits.rewrites
+	limits.lock.Unlock()
+
+	if exceeds(rewrites, limits.cfg.Rewrites) {
+		return newQueryLimitError(queryLimitRewrites, limits.cfg.Rewrites)
+	}
+
+	return spendQueryWork(ctx)
+}
+
+// checkCustomDNSDepth returns an error, if the depth of the recursive CNAME resolution exceeds the limit
+func checkCustomDNSDepth(ctx context.Context, depth int) error {
+	limits := queryLimitsFromCtx(ctx)
+	if limits == nil {
+		return nil
+	}
+
+	if exceeds(uint(depth), limits.cfg.CustomDNSDepth) {
+		return newQueryLimitError(queryLimitCustomDNSDepth, limits.cfg.CustomDNSDepth)
+	}
+
+	return spendQueryWork(ctx)
+}
+
+// checkCNAMEChain returns an error, if the answer contains more CNAME records than the limit
+func checkCNAMEChain(ctx context.Context, answer []dns.RR) error {
+	limits := queryLimitsFromCtx(ctx)
+	if limits == nil {
+		return nil
+	}
+
+	var cnames uint
+
+	for _, rr := range answer {
+		if _, ok := rr.(*dns.CNAME); ok {
+			cnames++
+		}
+	}
+
+	if exceeds(cnames, limits.cfg.CNAMEChain) {
+		return newQueryLimitError(queryLimitCNAMEChain, limits.cfg.CNAMEChain)
+	}
+
+	return spendQueryWork(ctx)
+}
@@ -0,0 +1,142 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("QueryLimits", func() {
+	var (
+		ctx context.Context
+		cfg config.QueryLimits
+	)
+
+	BeforeEach(func() {
+		cfg = config.QueryLimits{
+			CNAMEChain:     2,
+			CustomDNSDepth: 2,
+			Rewrites:       1,
+			Work:           3,
+		}
+	})
+
+	JustBeforeEach(func() {
+		ctx = WithQueryLimits(context.Background(), cfg)
+	})
+
+	cnames := func(count int) []dns.RR {
+		result := make([]dns.RR, 0, count+1)
+
+		for range count {
+			result = append(result, new(dns.CNAME))
+		}
+
+		return append(result, new(dns.A))
+	}
+
+	expectLimitError := func(err error, limit string) {
+		var limitErr *QueryLimitError
+
+		Expect(err).Should(BeAssignableToTypeOf(limitErr))
+		Expect(err.(*QueryLimitError).Limit).Should(Equal(limit))
+	}
+
+	Describe("CNAME chain", func() {
+		It("should allow chains within the limit", func() {
+			Expect(checkCNAMEChain(ctx, cnames(2))).Should(Succeed())
+		})
+
+		It("should fail for longer chains and count them", func() {
+			before := testutil.ToFloat64(queryLimitsExceeded.WithLabelValues(queryLimitCNAMEChain))
+
+			expectLimitError(checkCNAMEChain(ctx, cnames(3)), queryLimitCNAMEChain)
+
+			Expect(testutil.ToFloat64(queryLimitsExceeded.WithLabelValues(queryLimitCNAMEChain)) - before).
+				Should(BeNumerically("==", 1))
+		})
+	})
+
+	Describe("custom DNS depth", func() {
+		It("should fail if the depth exceeds the limit", func() {
+			Expect(checkCustomDNSDepth(ctx, 2)).Should(Succeed())
+
+			expectLimitError(checkCustomDNSDepth(ctx, 3), queryLimitCustomDNSDepth)
+		})
+	})
+
+	Describe("rewrites", func() {
+		It("should fail if more rewrites are applied", func() {
+			Expect(spendQueryRewrite(ctx)).Should(Succeed())
+
+			expectLimitError(spendQueryRewrite(ctx), queryLimitRewrites)
+		})
+	})
+
+	Describe("work", func() {
+		It("should sum up all work of the query", func() {
+			Expect(spendQueryRewrite(ctx)).Should(Succeed())
+			Expect(checkCNAMEChain(ctx, cnames(1))).Should(Succeed())
+			Expect(checkCustomDNSDepth(ctx, 1)).Should(Succeed())
+
+			expectLimitError(checkCNAMEChain(ctx, cnames(1)), queryLimitWork)
+		})
+
+		It("should be counted per query", func() {
+			for range 3 {
+				Expect(spendQueryWork(ctx)).Should(Succeed())
+			}
+
+			Expect(spendQueryWork(WithQueryLimits(context.Background(), cfg))).Should(Succeed())
+		})
+	})
+
+	When("limits are disabled", func() {
+		BeforeEach(func() {
+			cfg = config.QueryLimits{}
+		})
+
+		It("should not fail", func() {
+			for range 10 {
+				Expect(spendQueryRewrite(ctx)).Should(Succeed())
+				Expect(checkCNAMEChain(ctx, cnames(10))).Should(Succeed())
+				Expect(checkCustomDNSDepth(ctx, 10)).Should(Succeed())
+			}
+		})
+	})
+
+	When("the context has no limits", func() {
+		It("should not fail", func() {
+			Expect(checkCNAMEChain(context.Background(), cnames(10))).Should(Succeed())
+			Expect(spendQueryRewrite(context.Background())).Should(Succeed())
+		})
+	})
+
+	Describe("QueryLimitError", func() {
+		It("should contain the limit", func() {
+			err := &QueryLimitError{Limit: queryLimitWork, Max: 3}
+
+			Expect(err).Should(MatchError("query limit exceeded: work > 3"))
+		})
+	})
+
+	It("should be applied by the rewriter", func() {
+		inner := &mockResolver{}
+		inner.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+
+		sut := NewRewriterResolver(config.RewriterConfig{Rewrite: map[string]string{"home": "lan"}}, inner)
+		sut.Next(&mockResolver{})
+
+		Expect(spendQueryRewrite(ctx)).Should(Succeed())
+
+		_, err := sut.Resolve(ctx, newRequest("printer.home.", A))
+		expectLimitError(err, queryLimitRewrites)
+	})
+})
@@ -8,10 +8,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/querylog"
+	"github.com/0xERR0R/blocky/redis"
 	"github.com/0xERR0R/blocky/util"
 	"github.com/avast/retry-go/v4"
 	"github.com/miekg/dns"
@@ -23,15 +25,24 @@ const (
 	logChanCap               = 1000
 )
 
+// queryLogDurationBuckets are the upper bounds of the duration buckets in milliseconds
+//
+//nolint:gochecknoglobals
+var queryLogDurationBuckets = []int64{10, 50, 100, 500, 1000}
+
 // QueryLoggingResolver writes query information (question, answer, duration, ...)
 type QueryLoggingResolver struct {
 	configurable[*config.QueryLog]
 	NextResolver
 	typed
 
-	logChan    chan *querylog.LogEntry
-	writer     querylog.Writer
-	instanceID string
+	logChan chan *querylog.LogEntry
+	writer  querylog.Writer
+	// only set, if the entries are sent to Redis
+	redisWriter *querylog.RedisWriter
+	instanceID  string
+	// functions to run in the writer goroutine, e.g. to rotate the files
+	controlChan chan func()
 }
 
 func GetQueryLoggingWriter(ctx context.Context, cfg config.QueryLog) (querylog.Writer, error) {
@@ -41,18 +52,20 @@ func GetQueryLoggingWriter(ctx context.Context, cfg config.QueryLog) (querylog.W
 
 	switch cfg.Type {
 	case config.QueryLogTypeCsv:
-		writer, err = querylog.NewCSVWriter(cfg.Target, false, cfg.LogRetentionDays)
+		writer, err = querylog.NewCSVWriter(cfg.Target, false, cfg.LogRetentionDays, uint64(cfg.MaxFileSize),
+			cfg.Compress)
 	case config.QueryLogTypeCsvClient:
-		writer, err = querylog.NewCSVWriter(cfg.Target, true, cfg.LogRetentionDays)
+		writer, err = querylog.NewCSVWriter(cfg.Target, true, cfg.LogRetentionDays, uint64(cfg.MaxFileSize),
+			cfg.Compress)
 	case config.QueryLogTypeMysql:
 		writer, err = querylog.NewDatabaseWriter(ctx, "mysql", cfg.Target, cfg.LogRetentionDays,
-			cfg.FlushInterval.ToDuration())
+			cfg.FlushInterval.ToDuration(), cfg.Aggregate)
 	case config.QueryLogTypePostgresql:
 		writer, err = querylog.NewDatabaseWriter(ctx, "postgresql", cfg.Target, cfg.LogRetentionDays,
-			cfg.FlushInterval.ToDuration())
+			cfg.FlushInterval.ToDuration(), cfg.Aggregate)
 	case config.QueryLogTypeTimescale:
 		writer, err = querylog.NewDatabaseWriter(ctx, "timescale", cfg.Target, cfg.LogRetentionDays,
-			cfg.FlushInterval.ToDuration())
+			cfg.FlushInterval.ToDuration(), cfg.Aggregate)
 	case config.QueryLogTypeConsole:
 		writer = querylog.NewLoggerWriter()
 	case config.QueryLogTypeNone:
@@ -63,7 +76,8 @@ func GetQueryLoggingWriter(ctx context.Context, cfg config.QueryLog) (querylog.W
 }
 
 // NewQueryLoggingResolver returns a new resolver instance
-func NewQueryLoggingResolver(ctx context.Context, cfg config.QueryLog) (*QueryLoggingResolver, error) {
+func NewQueryLoggingResolver(ctx context.Context, cfg config.QueryLog, redis *redis.Client,
+) (*QueryLoggingResolver, error) {
 	logger := log.PrefixedLog(queryLoggingResolverType)
 
 	var writer querylog.Writer
@@ -102,9 +116,19 @@ func NewQueryLoggingResolver(ctx context.Context, cfg config.QueryLog) (*QueryLo
 		configurable: withConfig(&cfg),
 		typed:        withType(queryLoggingResolverType),
 
-		logChan:    logChan,
-		writer:     writer,
-		instanceID: instanceID,
+		logChan:     logChan,
+		writer:      writer,
+		instanceID:  instanceID,
+		controlChan: make(chan func()),
+	}
+
+	if cfg.Redis.IsEnabled() {
+		if redis != nil {
+			resolver.redisWriter = querylog.NewRedisWriter(ctx, redis, cfg.Redis.Stream, cfg.Redis.Channel,
+				cfg.Redis.MaxLen, cfg.Redis.BufferSize)
+		} else {
+			logger.Warn("redis is not configured, the query log entries are not sent to redis")
+		}
 	}
 
 	go resolver.writeLog(ctx)
@@ -144,16 +168,22 @@ func (r *QueryLoggingResolver) Resolve(ctx context.Context, request *model.Reque
 	resp, err := r.next.Resolve(ctx, request)
 	duration := time.Since(start).Milliseconds()
 
+	logged := resp
 	if err != nil {
-		return nil, err
+		logged = errorResponse(request, err)
 	}
 
-	entry := r.createLogEntry(request, resp, start, duration)
+	entry := r.createLogEntry(request, logged, start, duration)
 
-	if r.ignore(resp) {
+	if request.Unrecorded || r.ignore(logged) {
 		// Log to the console for debugging purposes
 		logger.WithFields(querylog.LogEntryFields(entry)).Debug("ignored querylog entry")
 	} else {
+		// independent of the writer: a slow writer doesn't delay the external consumers
+		if r.redisWriter != nil {
+			r.redisWriter.Write(entry)
+		}
+
 		select {
 		case r.logChan <- entry:
 		default:
@@ -161,6 +191,10 @@ func (r *QueryLoggingResolver) Resolve(ctx context.Context, request *model.Reque
 		}
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
 	return resp, nil
 }
 
@@ -196,7 +230,8 @@ func (r *QueryLoggingResolver) createLogEntry(request *model.Request, response *
 			entry.ClientNames = request.ClientNames
 
 		case config.QueryLogFieldResponseReason:
-			entry.ResponseReason = response.Reason
+			entry.ResponseReason = response.Reason.String()
+			entry.ResponseReasonCode = response.Reason.Code.String()
 			entry.ResponseType = response.RType.String()
 			entry.ResponseCode = dns.RcodeToString[response.Res.Rcode]
 
@@ -209,18 +244,61 @@ func (r *QueryLoggingResolver) createLogEntry(request *model.Request, response *
 
 		case config.QueryLogFieldDuration:
 			entry.DurationMs = durationMs
+
+		case config.QueryLogFieldUpstream:
+			if upstream := response.Upstream; upstream != nil {
+				entry.Upstream = upstream.Name
+				entry.UpstreamGroup = upstream.Group
+				entry.UpstreamProtocol = upstream.Protocol
+				entry.UpstreamRTTMs = upstream.RTT.Milliseconds()
+				entry.UpstreamRetries = upstream.Retries
+			}
+
+		case config.QueryLogFieldEcs:
+			if so := util.GetEdns0Option[*dns.EDNS0_SUBNET](request.Req); so != nil {
+				entry.ECS = fmt.Sprintf("%s/%d", so.Address, so.SourceNetmask)
+			}
+
+		case config.QueryLogFieldDurationBucket:
+			entry.DurationBucket = durationBucket(durationMs)
+
+		case config.QueryLogFieldTenant:
+			entry.Tenant = request.Tenant
+
+		case config.QueryLogFieldCategories:
+			entry.Categories = strings.Join(request.Categories, ",")
 		}
 	}
 
 	return &entry
 }
 
+// durationBucket returns the range of the duration, which is less detailed than the duration itself
+func durationBucket(durationMs int64) string {
+	lower := int64(0)
+
+	for _, upper := range queryLogDurationBuckets {
+		if durationMs < upper {
+			return fmt.Sprintf("%d-%dms", lower, upper)
+		}
+
+		lower = upper
+	}
+
+	return fmt.Sprintf("%dms+", lower)
+}
+
 // write entry: if log directory is configured, write to log file
 func (r *QueryLoggingResolver) writeLog(ctx context.Context) {
 	ctx, logger := r.log(ctx)
 
 	for {
 		select {
+		case fn := <-r.controlChan:
+			r.writePending()
+
+			fn()
+
 		case logEntry := <-r.logChan:
 			start := time.Now()
 
@@ -240,6 +318,64 @@ func (r *QueryLoggingResolver) writeLog(ctx context.Context) {
 	}
 }
 
+// writePending writes the queued entries
+func (r *QueryLoggingResolver) writePending() {
+	for {
+		select {
+		case logEntry := <-r.logChan:
+			r.writer.Write(logEntry)
+
+		default:
+			return
+		}
+	}
+}
+
+// FlushQueryLog implements `api.QueryLogControl`.
+func (r *QueryLoggingResolver) FlushQueryLog(ctx context.Context) error {
+	return r.control(ctx, func() error {
+		if flusher, ok := r.writer.(querylog.Flusher); ok {
+			return flusher.Flush()
+		}
+
+		return nil
+	})
+}
+
+// RotateQueryLog implements `api.QueryLogControl`.
+func (r *QueryLoggingResolver) RotateQueryLog(ctx context.Context, compress *bool) error {
+	rotator, ok := r.writer.(querylog.Rotator)
+	if !ok {
+		return api.ErrQueryLogNotRotatable
+	}
+
+	if compress == nil {
+		compress = &r.cfg.Compress
+	}
+
+	return r.control(ctx, func() error {
+		return rotator.Rotate(*compress)
+	})
+}
+
+// control runs the function in the writer goroutine after the queued entries were written
+func (r *QueryLoggingResolver) control(ctx context.Context, fn func() error) error {
+	errCh := make(chan error, 1)
+
+	select {
+	case r.controlChan <- func() { errCh <- fn() }:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func readInstanceID(file string) (string, error) {
 	// Prefer /etc/hostname over os.Hostname to allow easy differentiation in a Docker Swarm
 	// See details in https://github.com/0xERR0R/blocky/pull/756
@@ -5,13 +5,19 @@ import (
 	"context"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"os"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/querylog"
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/creasty/defaults"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
 
@@ -39,13 +45,15 @@ func (m *SlowMockWriter) CleanUp() {
 
 var _ = Describe("QueryLoggingResolver", func() {
 	var (
-		sut        *QueryLoggingResolver
-		sutConfig  config.QueryLog
-		err        error
-		m          *mockResolver
-		tmpDir     *TmpFolder
-		mockRType  ResponseType
-		mockAnswer *dns.Msg
+		sut          *QueryLoggingResolver
+		sutConfig    config.QueryLog
+		err          error
+		m            *mockResolver
+		tmpDir       *TmpFolder
+		mockRType    ResponseType
+		mockAnswer   *dns.Msg
+		mockUpstream *UpstreamInfo
+		redisClient  *redis.Client
 
 		ctx      context.Context
 		cancelFn context.CancelFunc
@@ -66,6 +74,8 @@ var _ = Describe("QueryLoggingResolver", func() {
 
 		mockRType = ResponseTypeRESOLVED
 		mockAnswer = new(dns.Msg)
+		mockUpstream = nil
+		redisClient = nil
 		tmpDir = NewTmpFolder("queryLoggingResolver")
 	})
 
@@ -74,12 +84,12 @@ var _ = Describe("QueryLoggingResolver", func() {
 			sutConfig.SetDefaults() // not called when using a struct literal
 		}
 
-		sut, err = NewQueryLoggingResolver(ctx, sutConfig)
+		sut, err = NewQueryLoggingResolver(ctx, sutConfig, redisClient)
 		Expect(err).Should(Succeed())
 
 		m = &mockResolver{
 			ResolveFn: func(context.Context, *Request) (*Response, error) {
-				return &Response{RType: mockRType, Res: mockAnswer, Reason: "reason"}, nil
+				return &Response{RType: mockRType, Res: mockAnswer, Reason: NewReason(ReasonCodeRESOLVED, "reason"), Upstream: mockUpstream}, nil
 			},
 		}
 
@@ -124,6 +134,32 @@ var _ = Describe("QueryLoggingResolver", func() {
 			})
 		})
 
+		When("the query fails", func() {
+			JustBeforeEach(func() {
+				// Stop background goroutines, so the entries stay in the channel
+				cancelFn()
+
+				ctx, cancelFn = context.WithCancel(context.Background())
+				DeferCleanup(cancelFn)
+
+				m.ResolveFn = func(context.Context, *Request) (*Response, error) {
+					return nil, fmt.Errorf("%w: can't resolve", ErrUpstreamTimeout)
+				}
+			})
+
+			It("should log the error class and return the error", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.25", "client1"))
+				Expect(err).Should(MatchError(ErrUpstreamTimeout))
+
+				var entry *querylog.LogEntry
+
+				Expect(sut.logChan).Should(Receive(&entry))
+				Expect(entry.ResponseType).Should(Equal("ERROR"))
+				Expect(entry.ResponseReason).Should(Equal("ERROR (upstreamTimeout)"))
+				Expect(entry.ResponseCode).Should(Equal("SERVFAIL"))
+			})
+		})
+
 		Describe("ignore", func() {
 			var ignored *log.MockLoggerHook
 
@@ -169,6 +205,54 @@ var _ = Describe("QueryLoggingResolver", func() {
 					Expect(ignored.Calls).Should(BeEmpty())
 				})
 			})
+
+			It("should not log unrecorded requests", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25", "client1")
+				request.Unrecorded = true
+
+				_, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+
+				Expect(sut.logChan).Should(BeEmpty())
+				Expect(ignored.Messages).Should(ContainElement(ContainSubstring("ignored querylog entry")))
+			})
+		})
+
+		Describe("Redis", func() {
+			var redisServer *miniredis.Miniredis
+
+			BeforeEach(func() {
+				redisServer = miniredis.RunT(GinkgoT())
+
+				var rcfg config.Redis
+				Expect(defaults.Set(&rcfg)).Should(Succeed())
+				rcfg.Address = redisServer.Addr()
+
+				redisClient, err = redis.New(ctx, &rcfg)
+				Expect(err).Should(Succeed())
+
+				sutConfig.Type = config.QueryLogTypeNone
+				sutConfig.Redis = config.QueryLogRedis{Stream: "blocky:querylog", MaxLen: 100, BufferSize: 10}
+			})
+
+			It("should add the entries to the stream", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.25", "client1"))
+				Expect(err).Should(Succeed())
+
+				Eventually(func() ([]miniredis.StreamEntry, error) {
+					return redisServer.Stream("blocky:querylog")
+				}).Should(ContainElement(HaveField("Values", ContainElements("question_name", "example.com."))))
+			})
+
+			It("should not add ignored entries", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25", "client1")
+				request.Unrecorded = true
+
+				_, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+
+				Consistently(func() bool { return redisServer.Exists("blocky:querylog") }).Should(BeFalse())
+			})
 		})
 
 		When("Configuration with logging per client", func() {
@@ -211,12 +295,13 @@ var _ = Describe("QueryLoggingResolver", func() {
 						g.Expect(csvLines).ShouldNot(BeEmpty())
 						g.Expect(csvLines[0][1]).Should(Equal("192.168.178.25"))
 						g.Expect(csvLines[0][2]).Should(Equal("client1"))
-						g.Expect(csvLines[0][4]).Should(Equal("reason"))
+						g.Expect(csvLines[0][4]).Should(Equal("RESOLVED (reason)"))
 						g.Expect(csvLines[0][5]).Should(Equal("example.com."))
 						g.Expect(csvLines[0][6]).Should(Equal("A (123.122.121.120)"))
 						g.Expect(csvLines[0][7]).Should(Equal("NOERROR"))
 						g.Expect(csvLines[0][8]).Should(Equal("RESOLVED"))
 						g.Expect(csvLines[0][9]).Should(Equal("A"))
+						g.Expect(csvLines[0][18]).Should(Equal("RESOLVED"))
 					}).Should(Succeed())
 				})
 
@@ -229,7 +314,7 @@ var _ = Describe("QueryLoggingResolver", func() {
 						g.Expect(csvLines).Should(HaveLen(1))
 						g.Expect(csvLines[0][1]).Should(Equal("192.168.178.26"))
 						g.Expect(csvLines[0][2]).Should(Equal("cl/ient2\\$%&test"))
-						g.Expect(csvLines[0][4]).Should(Equal("reason"))
+						g.Expect(csvLines[0][4]).Should(Equal("RESOLVED (reason)"))
 						g.Expect(csvLines[0][5]).Should(Equal("example.com."))
 						g.Expect(csvLines[0][6]).Should(Equal("A (123.122.121.120)"))
 						g.Expect(csvLines[0][7]).Should(Equal("NOERROR"))
@@ -279,7 +364,7 @@ var _ = Describe("QueryLoggingResolver", func() {
 						// client1 -> first line
 						g.Expect(csvLines[0][1]).Should(Equal("192.168.178.25"))
 						g.Expect(csvLines[0][2]).Should(Equal("client1"))
-						g.Expect(csvLines[0][4]).Should(Equal("reason"))
+						g.Expect(csvLines[0][4]).Should(Equal("RESOLVED (reason)"))
 						g.Expect(csvLines[0][5]).Should(Equal("example.com."))
 						g.Expect(csvLines[0][6]).Should(Equal("A (123.122.121.120)"))
 						g.Expect(csvLines[0][7]).Should(Equal("NOERROR"))
@@ -289,7 +374,7 @@ var _ = Describe("QueryLoggingResolver", func() {
 						// client2 -> second line
 						g.Expect(csvLines[1][1]).Should(Equal("192.168.178.26"))
 						g.Expect(csvLines[1][2]).Should(Equal("client2"))
-						g.Expect(csvLines[1][4]).Should(Equal("reason"))
+						g.Expect(csvLines[1][4]).Should(Equal("RESOLVED (reason)"))
 						g.Expect(csvLines[1][5]).Should(Equal("example.com."))
 						g.Expect(csvLines[1][6]).Should(Equal("A (123.122.121.120)"))
 						g.Expect(csvLines[1][7]).Should(Equal("NOERROR"))
@@ -344,8 +429,197 @@ var _ = Describe("QueryLoggingResolver", func() {
 				})
 			})
 		})
+		When("Configuration with upstream field to log", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLog{
+					Target:           tmpDir.Path,
+					Type:             config.QueryLogTypeCsv,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields:           []config.QueryLogField{config.QueryLogFieldUpstream},
+				}
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.122.121.120")
+				mockUpstream = &UpstreamInfo{
+					Name:     "tcp+udp:1.1.1.1",
+					Group:    "default",
+					Protocol: "tcp+udp",
+					RTT:      42 * time.Millisecond,
+					Retries:  1,
+				}
+			})
+			It("should log the upstream, which answered the query", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.25", "client1"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				By("check log", func() {
+					Eventually(func(g Gomega) {
+						csvLines, err := readCsv(tmpDir.JoinPath(
+							time.Now().Format("2006-01-02") + "_ALL.log"))
+
+						g.Expect(err).Should(Succeed())
+						g.Expect(csvLines).Should(HaveLen(1))
+
+						g.Expect(csvLines[0][2]).Should(Equal("none"))
+						g.Expect(csvLines[0][11:16]).Should(Equal([]string{
+							"tcp+udp:1.1.1.1", "default", "tcp+udp", "42", "1",
+						}))
+					}, "1s").Should(Succeed())
+				})
+			})
+		})
+		When("Configuration with ECS and duration bucket fields to log", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLog{
+					Target:           tmpDir.Path,
+					Type:             config.QueryLogTypeCsv,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields: []config.QueryLogField{
+						config.QueryLogFieldEcs, config.QueryLogFieldDurationBucket,
+					},
+				}
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.122.121.120")
+			})
+			It("should log the client subnet and the duration bucket instead of the duration", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25", "client1")
+				util.SetEdns0Option(request.Req, &dns.EDNS0_SUBNET{
+					Code:          dns.EDNS0SUBNET,
+					Family:        ecsFamilyIPv4,
+					SourceNetmask: 24,
+					Address:       net.ParseIP("192.168.178.0").To4(),
+				})
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				By("check log", func() {
+					Eventually(func(g Gomega) {
+						csvLines, err := readCsv(tmpDir.JoinPath(
+							time.Now().Format("2006-01-02") + "_ALL.log"))
+
+						g.Expect(err).Should(Succeed())
+						g.Expect(csvLines).Should(HaveLen(1))
+
+						g.Expect(csvLines[0][1]).Should(Equal("0.0.0.0"))
+						g.Expect(csvLines[0][3]).Should(Equal("0"))
+						g.Expect(csvLines[0][16:18]).Should(Equal([]string{"192.168.178.0/24", "0-10ms"}))
+					}, "1s").Should(Succeed())
+				})
+			})
+		})
+		When("Configuration with tenant field to log", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLog{
+					Target:           tmpDir.Path,
+					Type:             config.QueryLogTypeCsv,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields:           []config.QueryLogField{config.QueryLogFieldTenant},
+				}
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.122.121.120")
+			})
+			It("should log the tenant in the file of the tenant", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25", "client1")
+				request.Tenant = "smith"
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				By("check log", func() {
+					Eventually(func(g Gomega) {
+						csvLines, err := readCsv(tmpDir.JoinPath(
+							time.Now().Format("2006-01-02") + "_smith_ALL.log"))
+
+						g.Expect(err).Should(Succeed())
+						g.Expect(csvLines).Should(HaveLen(1))
+
+						g.Expect(csvLines[0][19]).Should(Equal("smith"))
+					}, "1s").Should(Succeed())
+				})
+			})
+		})
+		When("Configuration with categories field to log", func() {
+			BeforeEach(func() {
+				sutConfig = config.QueryLog{
+					Target:           tmpDir.Path,
+					Type:             config.QueryLogTypeCsv,
+					CreationAttempts: 1,
+					CreationCooldown: config.Duration(time.Millisecond),
+					Fields:           []config.QueryLogField{config.QueryLogFieldCategories},
+				}
+				mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "123.122.121.120")
+			})
+			It("should log the categories of the domain", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.25", "client1")
+				request.Categories = []string{"social", "video"}
+
+				Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				By("check log", func() {
+					Eventually(func(g Gomega) {
+						csvLines, err := readCsv(tmpDir.JoinPath(
+							time.Now().Format("2006-01-02") + "_ALL.log"))
+
+						g.Expect(err).Should(Succeed())
+						g.Expect(csvLines).Should(HaveLen(1))
+
+						g.Expect(csvLines[0][20]).Should(Equal("social,video"))
+					}, "1s").Should(Succeed())
+				})
+			})
+		})
+	})
+
+	Describe("Query log control", func() {
+		When("the query log is written to CSV files", func() {
+			BeforeEach(func() {
+				sutConfig.Type = config.QueryLogTypeCsv
+				sutConfig.Target = tmpDir.Path
+				sutConfig.Compress = true
+			})
+
+			It("should write the queued entries and rotate the files", func() {
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.25", "client1"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				Expect(sut.FlushQueryLog(ctx)).Should(Succeed())
+
+				fileName := time.Now().Format("2006-01-02") + "_ALL"
+				Expect(readCsv(tmpDir.JoinPath(fileName + ".log"))).Should(HaveLen(1))
+
+				compress := false
+				Expect(sut.RotateQueryLog(ctx, &compress)).Should(Succeed())
+				Expect(tmpDir.JoinPath(fileName + ".1.log")).Should(BeARegularFile())
+
+				Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.25", "client1"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+
+				// the configured compression
+				Expect(sut.RotateQueryLog(ctx, nil)).Should(Succeed())
+				Expect(tmpDir.JoinPath(fileName + ".2.log.gz")).Should(BeARegularFile())
+			})
+		})
+
+		When("the query log has no files", func() {
+			BeforeEach(func() {
+				sutConfig.Type = config.QueryLogTypeConsole
+			})
+
+			It("should flush, but not rotate", func() {
+				Expect(sut.FlushQueryLog(ctx)).Should(Succeed())
+				Expect(sut.RotateQueryLog(ctx, nil)).Should(MatchError(api.ErrQueryLogNotRotatable))
+			})
+		})
 	})
 
+	DescribeTable("durationBucket",
+		func(durationMs int64, expected string) {
+			Expect(durationBucket(durationMs)).Should(Equal(expected))
+		},
+		Entry("fast", int64(3), "0-10ms"),
+		Entry("lower bound", int64(50), "50-100ms"),
+		Entry("slow", int64(999), "500-1000ms"),
+		Entry("very slow", int64(2500), "1000ms+"),
+	)
+
 	Describe("Slow writer", func() {
 		When("writer is too slow", func() {
 			BeforeEach(func() {
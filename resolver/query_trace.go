@@ -0,0 +1,160 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+type queryTraceCtxKey struct{}
+
+// QueryTrace captures the processing of a single query by the resolver chain: the visited stages with their
+// timings and all log messages of the resolvers, independent of the configured log level.
+type QueryTrace struct {
+	start time.Time
+
+	lock    sync.Mutex
+	entries []QueryTraceEntry
+}
+
+// QueryTraceEntry is a single step of a query trace
+type QueryTraceEntry struct {
+	// Elapsed time since the start of the trace
+	Elapsed time.Duration
+	// Stage is the resolver, which created the entry
+	Stage   string
+	Message string
+}
+
+// String returns the entry in the format `+<elapsed> <stage>: <message>`
+func (e QueryTraceEntry) String() string {
+	return fmt.Sprintf("+%s %s: %s", e.Elapsed, e.Stage, e.Message)
+}
+
+// NewQueryTrace starts a trace of the query processed with the returned context.
+// All log messages of the resolvers are captured, the configured log level still applies to the log output.
+func NewQueryTrace(ctx context.Context) (context.Context, *QueryTrace) {
+	trace := &QueryTrace{start: time.Now()}
+
+	ctx = context.WithValue(ctx, queryTraceCtxKey{}, trace)
+
+	ctx, _ = log.CaptureCtx(ctx, trace.addLogEntry)
+
+	return ctx, trace
+}
+
+func queryTraceFromCtx(ctx context.Context) *QueryTrace {
+	trace, _ := ctx.Value(queryTraceCtxKey{}).(*QueryTrace)
+
+	return trace
+}
+
+// Entries returns the captured entries
+func (t *QueryTrace) Entries() []QueryTraceEntry {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return append([]QueryTraceEntry(nil), t.entries...)
+}
+
+// Lines returns the captured entries as strings
+func (t *QueryTrace) Lines() []string {
+	entries := t.Entries()
+	lines := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		lines = append(lines, entry.String())
+	}
+
+	return lines
+}
+
+func (t *QueryTrace) add(at time.Time, stage, message string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.entries = append(t.entries, QueryTraceEntry{
+		Elapsed: at.Sub(t.start),
+		Stage:   stage,
+		Message: message,
+	})
+}
+
+func (t *QueryTrace) addLogEntry(entry *logrus.Entry) {
+	keys := make([]string, 0, len(entry.Data))
+
+	for key := range entry.Data {
+		if key != "prefix" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "[%s] %s", entry.Level, entry.Message)
+
+	for _, key := range keys {
+		fmt.Fprintf(&sb, " %s=%v", key, entry.Data[key])
+	}
+
+	t.add(entry.Time, log.Prefix(entry), sb.String())
+}
+
+// traced wraps a resolver of the chain, so its visits are recorded in the trace of traced queries
+type traced struct {
+	Resolver
+}
+
+// TraceStages returns the resolver, which records its visits in the trace of traced queries
+func TraceStages(res Resolver) Resolver {
+	if _, ok := res.(*traced); ok {
+		return res
+	}
+
+	return &traced{res}
+}
+
+// Resolve implements `Resolver`.
+func (t *traced) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	trace := queryTraceFromCtx(ctx)
+	if trace == nil {
+		return t.Resolver.Resolve(ctx, request)
+	}
+
+	stage := t.Type()
+	start := time.Now()
+
+	trace.add(start, stage, "start")
+
+	response, err := t.Resolver.Resolve(ctx, request)
+
+	end := time.Now()
+
+	switch {
+	case err != nil:
+		trace.add(end, stage, fmt.Sprintf("failed after %s: %s", end.Sub(start), err))
+	case response != nil && response.Res != nil:
+		trace.add(end, stage, fmt.Sprintf("done after %s: %s (%s) %s",
+			end.Sub(start), response.RType, response.Reason, dns.RcodeToString[response.Res.Rcode]))
+	default:
+		trace.add(end, stage, fmt.Sprintf("done after %s", end.Sub(start)))
+	}
+
+	return response, err
+}
+
+// Name implements `NamedResolver`.
+func (t *traced) Name() string {
+	return Name(t.Resolver)
+}
@@ -0,0 +1,88 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QueryTrace", func() {
+	var (
+		ctx   context.Context
+		m     *mockResolver
+		chain ChainedResolver
+	)
+
+	BeforeEach(func() {
+		var cancelFn context.CancelFunc
+
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(ctx context.Context, req *Request) (*Response, error) {
+			_, logger := log.CtxWithFields(ctx, logrus.Fields{"prefix": "mock", "domain": "example.com"})
+			logger.Trace("resolving")
+
+			return newResponse(req, 0, ResponseTypeRESOLVED, NewReason(ReasonCodeRESOLVED)), nil
+		}
+
+		chain = Chain(NewFQDNOnlyResolver(config.FQDNOnly{}), m)
+	})
+
+	It("should not change the next resolver of the chain", func() {
+		Expect(chain.GetNext()).Should(BeIdenticalTo(m))
+		Expect(Name(TraceStages(m))).Should(Equal("mock"))
+	})
+
+	It("should not record queries without trace", func() {
+		resp, err := TraceStages(chain).Resolve(ctx, newRequest("example.com.", A))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+	})
+
+	It("should record the visited resolvers and all log messages", func() {
+		ctx, trace := NewQueryTrace(ctx)
+
+		_, err := TraceStages(chain).Resolve(ctx, newRequest("example.com.", A))
+		Expect(err).Should(Succeed())
+
+		Expect(trace.Lines()).Should(HaveExactElements(
+			MatchRegexp(`^\+.+ fqdn_only: start$`),
+			MatchRegexp(`^\+.+ mock: start$`),
+			MatchRegexp(`^\+.+ mock: \[trace\] resolving domain=example.com$`),
+			MatchRegexp(`^\+.+ mock: done after .+: RESOLVED \(RESOLVED\) NOERROR$`),
+			MatchRegexp(`^\+.+ fqdn_only: done after .+: RESOLVED \(RESOLVED\) NOERROR$`),
+		))
+
+		entries := trace.Entries()
+		Expect(entries[4].Elapsed).Should(BeNumerically(">=", entries[0].Elapsed))
+	})
+
+	It("should record errors", func() {
+		m.ResolveFn = func(context.Context, *Request) (*Response, error) {
+			return nil, errors.New("boom")
+		}
+
+		ctx, trace := NewQueryTrace(ctx)
+
+		_, err := chain.Resolve(ctx, newRequest("example.com.", A))
+		Expect(err).Should(HaveOccurred())
+
+		Expect(trace.Lines()).Should(HaveExactElements(
+			MatchRegexp(`^\+.+ mock: start$`),
+			MatchRegexp(`^\+.+ mock: failed after .+: boom$`),
+		))
+	})
+})
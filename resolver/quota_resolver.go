@@ -0,0 +1,370 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	quotaResolverType = "quotas"
+
+	quotaDayFormat  = "2006-01-02"
+	quotaSavePeriod = time.Minute
+)
+
+//nolint:gochecknoglobals
+var quotaBlockedQueries = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_quota_blocked_queries_total",
+		Help: "Number of queries blocked because the daily budget of the category is exhausted",
+	}, []string{"category"},
+))
+
+// QuotaResolver tracks the daily usage of categories of domains per client and blocks the queries of a category,
+// once the budget of the client is exhausted
+type QuotaResolver struct {
+	configurable[*config.Quotas]
+	NextResolver
+	typed
+
+	// categories in alphabetical order
+	categories []quotaCategory
+
+	lock sync.Mutex
+	// day of the usage, formatted with `quotaDayFormat`
+	day string
+	// usage per category and client
+	usage map[string]map[string]*quotaUsage
+	// true if the usage changed since the last save
+	dirty bool
+}
+
+type quotaCategory struct {
+	name    string
+	cfg     config.QuotaCategory
+	domains *domainPatterns
+}
+
+type quotaUsage struct {
+	Queries int64 `json:"queries"`
+	Seconds int64 `json:"seconds"`
+	// start of the last activity period as unix seconds
+	LastPeriod int64 `json:"lastPeriod"`
+}
+
+// quotaStore is the content of the store file
+type quotaStore struct {
+	Day   string                            `json:"day"`
+	Usage map[string]map[string]*quotaUsage `json:"usage"`
+}
+
+// NewQuotaResolver creates new resolver instance and loads the stored usage of the current day
+func NewQuotaResolver(ctx context.Context, cfg config.Quotas) (*QuotaResolver, error) {
+	r := &QuotaResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType(quotaResolverType),
+
+		day:   time.Now().Format(quotaDayFormat),
+		usage: make(map[string]map[string]*quotaUsage),
+	}
+
+	for _, name := range cfg.Names() {
+		category := cfg.Categories[name]
+
+		domains, err := newDomainPatterns(category.Domains)
+		if err != nil {
+			return nil, fmt.Errorf("quota category %s: %w", name, err)
+		}
+
+		r.categories = append(r.categories, quotaCategory{name: name, cfg: category, domains: domains})
+	}
+
+	if !cfg.IsEnabled() || cfg.StoreFile == "" {
+		return r, nil
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.periodicSave(ctx)
+
+	return r, nil
+}
+
+// Resolve counts the query for the budgets of the matching categories and blocks it, if a budget is exhausted
+func (r *QuotaResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	client := strings.Join(request.ClientNames, ",")
+	now := time.Now()
+
+	for _, category := range r.categories {
+		if !category.matches(request) {
+			continue
+		}
+
+		if !r.consume(category, client, now) {
+			continue
+		}
+
+		_, logger := r.logWithFields(ctx, logrus.Fields{
+			"category": category.name,
+			"client":   client,
+		})
+
+		logger.Debug("daily budget of the category is exhausted")
+
+		quotaBlockedQueries.WithLabelValues(category.name).Inc()
+
+		response := new(dns.Msg)
+		response.SetRcode(request.Req, dns.RcodeNameError)
+
+		return &model.Response{
+			Res:    response,
+			RType:  model.ResponseTypeBLOCKED,
+			Reason: model.NewReason(model.ReasonCodeQUOTA, category.name),
+		}, nil
+	}
+
+	return r.next.Resolve(ctx, request)
+}
+
+// matches returns true if the question and the client of the request belong to the category
+func (c *quotaCategory) matches(request *model.Request) bool {
+	if !c.domains.matchesAny(request.Req.Question) {
+		return false
+	}
+
+	if len(c.cfg.Clients) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(c.cfg.Clients, func(group string) bool {
+		return clientMatchesGroup(group, request)
+	})
+}
+
+// consume adds the query to the usage of the client and returns true, if the budget was already exhausted
+func (r *QuotaResolver) consume(category quotaCategory, client string, now time.Time) bool {
+	period := now.Truncate(r.cfg.ActivityPeriod.ToDuration()).Unix()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.rollover(now)
+
+	clients, ok := r.usage[category.name]
+	if !ok {
+		clients = make(map[string]*quotaUsage)
+		r.usage[category.name] = clients
+	}
+
+	usage, ok := clients[client]
+	if !ok {
+		usage = &quotaUsage{}
+		clients[client] = usage
+	}
+
+	if category.exhausted(usage, period) {
+		return true
+	}
+
+	usage.Queries++
+
+	if usage.LastPeriod != period {
+		usage.Seconds += int64(r.cfg.ActivityPeriod.ToDuration().Seconds())
+		usage.LastPeriod = period
+	}
+
+	r.dirty = true
+
+	return false
+}
+
+// exhausted returns true if the usage reached a budget. A query in an already counted activity period
+// doesn't use more time.
+func (c *quotaCategory) exhausted(usage *quotaUsage, period int64) bool {
+	if c.cfg.Queries > 0 && usage.Queries >= int64(c.cfg.Queries) {
+		return true
+	}
+
+	return c.cfg.Time.IsAboveZero() && usage.LastPeriod != period &&
+		usage.Seconds >= int64(c.cfg.Time.ToDuration().Seconds())
+}
+
+// rollover resets the usage on the first query of a new day, the lock must be held
+func (r *QuotaResolver) rollover(now time.Time) {
+	day := now.Format(quotaDayFormat)
+	if day == r.day {
+		return
+	}
+
+	r.day = day
+	r.usage = make(map[string]map[string]*quotaUsage)
+	r.dirty = true
+}
+
+// Quotas implements `api.QuotaControl`
+func (r *QuotaResolver) Quotas() ([]api.QuotaUsage, error) {
+	if !r.IsEnabled() {
+		return nil, api.ErrQuotasDisabled
+	}
+
+	now := time.Now()
+	period := now.Truncate(r.cfg.ActivityPeriod.ToDuration()).Unix()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.rollover(now)
+
+	var result []api.QuotaUsage
+
+	for _, category := range r.categories {
+		for client, usage := range r.usage[category.name] {
+			result = append(result, api.QuotaUsage{
+				Category:   category.name,
+				Client:     client,
+				Queries:    usage.Queries,
+				QueryLimit: int64(category.cfg.Queries),
+				Time:       time.Duration(usage.Seconds) * time.Second,
+				TimeLimit:  category.cfg.Time.ToDuration(),
+				Exhausted:  category.exhausted(usage, period),
+			})
+		}
+	}
+
+	slices.SortFunc(result, func(a, b api.QuotaUsage) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.Client, b.Client))
+	})
+
+	return result, nil
+}
+
+// ResetQuotas implements `api.QuotaControl`
+func (r *QuotaResolver) ResetQuotas(category, client string) error {
+	if !r.IsEnabled() {
+		return api.ErrQuotasDisabled
+	}
+
+	if category != "" && !slices.ContainsFunc(r.categories, func(c quotaCategory) bool { return c.name == category }) {
+		return fmt.Errorf("%w: %s", api.ErrUnknownQuotaCategory, category)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for name, clients := range r.usage {
+		if category != "" && name != category {
+			continue
+		}
+
+		if client == "" {
+			delete(r.usage, name)
+		} else {
+			delete(clients, client)
+		}
+	}
+
+	r.dirty = true
+
+	log.PrefixedLog(quotaResolverType).Infof("reset usage of category '%s' and client '%s'",
+		log.EscapeInput(category), log.EscapeInput(client))
+
+	return nil
+}
+
+// saves the changed usage periodically and on shutdown
+func (r *QuotaResolver) periodicSave(ctx context.Context) {
+	ticker := time.NewTicker(quotaSavePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.saveLogged()
+		case <-ctx.Done():
+			r.saveLogged()
+
+			return
+		}
+	}
+}
+
+func (r *QuotaResolver) saveLogged() {
+	if err := r.save(); err != nil {
+		log.PrefixedLog(quotaResolverType).Errorf("can't save quota usage: %s", err)
+	}
+}
+
+// load reads the stored usage, a missing file or the usage of another day starts without usage
+func (r *QuotaResolver) load() error {
+	content, err := os.ReadFile(r.cfg.StoreFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't read quota store: %w", err)
+	}
+
+	var store quotaStore
+
+	if err := json.Unmarshal(content, &store); err != nil {
+		return fmt.Errorf("invalid quota store: %w", err)
+	}
+
+	if store.Day == r.day && store.Usage != nil {
+		r.usage = store.Usage
+	}
+
+	return nil
+}
+
+// save writes the usage to the store file, if it changed since the last save
+func (r *QuotaResolver) save() error {
+	r.lock.Lock()
+
+	if !r.dirty {
+		r.lock.Unlock()
+
+		return nil
+	}
+
+	content, err := json.Marshal(quotaStore{Day: r.day, Usage: r.usage})
+
+	r.dirty = false
+	r.lock.Unlock()
+
+	if err == nil {
+		err = writeFileAtomic(r.cfg.StoreFile, content)
+	}
+
+	if err != nil {
+		r.lock.Lock()
+		r.dirty = true
+		r.lock.Unlock()
+	}
+
+	return err
+}
@@ -0,0 +1,307 @@
+package resolver
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("QuotaResolver", func() {
+	var (
+		sut        *QuotaResolver
+		sutConfig  config.Quotas
+		m          *mockResolver
+		mockAnswer *dns.Msg
+		tmpDir     *TmpFolder
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		tmpDir = NewTmpFolder("QuotaResolver")
+		DeferCleanup(tmpDir.Clean)
+
+		sutConfig = config.Quotas{
+			Categories: map[string]config.QuotaCategory{
+				"gaming": {
+					Domains: []string{"*.roblox.com"},
+					Clients: []string{"tag:kids"},
+					Time:    config.Duration(2 * time.Minute),
+				},
+				"video": {
+					Domains: []string{"youtube.com"},
+					Queries: 2,
+				},
+			},
+			ActivityPeriod: config.Duration(time.Minute),
+			StoreFile:      tmpDir.JoinPath("quotas.json"),
+		}
+
+		mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewQuotaResolver(ctx, sutConfig)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	query := func(domain string, clientTags ...string) *Response {
+		request := newRequestWithClient(domain, A, "192.168.178.2", "laptop")
+		request.ClientTags = clientTags
+
+		resp, err := sut.Resolve(ctx, request)
+		Expect(err).Should(Succeed())
+
+		return resp
+	}
+
+	category := func(name string) quotaCategory {
+		for _, c := range sut.categories {
+			if c.name == name {
+				return c
+			}
+		}
+
+		Fail("unknown category " + name)
+
+		return quotaCategory{}
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("there are no categories", func() {
+			BeforeEach(func() {
+				sutConfig.Categories = nil
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should return an error for the usage", func() {
+				_, err := sut.Quotas()
+				Expect(err).Should(MatchError(api.ErrQuotasDisabled))
+
+				Expect(sut.ResetQuotas("", "")).Should(MatchError(api.ErrQuotasDisabled))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	It("should fail on invalid domains", func() {
+		sutConfig.Categories["gaming"] = config.QuotaCategory{Domains: []string{"/invalid[/"}, Queries: 1}
+
+		_, err := NewQuotaResolver(ctx, sutConfig)
+		Expect(err).Should(MatchError(ContainSubstring("quota category gaming")))
+	})
+
+	Describe("Resolve", func() {
+		It("should block the queries of a category after the query budget", func() {
+			Expect(query("youtube.com.")).Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(query("youtube.com.")).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			resp := query("youtube.com.")
+			Expect(resp).Should(And(
+				HaveResponseType(ResponseTypeBLOCKED),
+				HaveReturnCode(dns.RcodeNameError),
+				HaveReason("QUOTA (video)"),
+			))
+
+			Expect(m.Calls).Should(HaveLen(2))
+		})
+
+		It("should not count other domains", func() {
+			for range 3 {
+				Expect(query("example.com.")).Should(HaveResponseType(ResponseTypeRESOLVED))
+			}
+
+			Expect(sut.Quotas()).Should(BeEmpty())
+		})
+
+		It("should only count the clients of the category", func() {
+			for range 3 {
+				Expect(query("www.roblox.com.")).Should(HaveResponseType(ResponseTypeRESOLVED))
+			}
+
+			Expect(sut.Quotas()).Should(BeEmpty())
+
+			query("www.roblox.com.", "kids")
+
+			Expect(sut.Quotas()).Should(ConsistOf(HaveField("Category", "gaming")))
+		})
+	})
+
+	Describe("time budget", func() {
+		It("should count each activity period once", func() {
+			gaming := category("gaming")
+			start := time.Now().Truncate(time.Minute)
+
+			Expect(sut.consume(gaming, "laptop", start)).Should(BeFalse())
+			Expect(sut.consume(gaming, "laptop", start.Add(30*time.Second))).Should(BeFalse())
+			Expect(sut.consume(gaming, "laptop", start.Add(time.Minute))).Should(BeFalse())
+			// the budget is used, but the current period is already counted
+			Expect(sut.consume(gaming, "laptop", start.Add(90*time.Second))).Should(BeFalse())
+			Expect(sut.consume(gaming, "laptop", start.Add(2*time.Minute))).Should(BeTrue())
+
+			Expect(sut.usage["gaming"]["laptop"]).Should(And(
+				HaveField("Queries", BeEquivalentTo(4)),
+				HaveField("Seconds", BeEquivalentTo(120)),
+			))
+		})
+
+		It("should reset the usage on a new day", func() {
+			video := category("video")
+			now := time.Now()
+
+			sut.consume(video, "laptop", now)
+			sut.consume(video, "laptop", now)
+			Expect(sut.consume(video, "laptop", now)).Should(BeTrue())
+
+			Expect(sut.consume(video, "laptop", now.AddDate(0, 0, 1))).Should(BeFalse())
+			Expect(sut.usage["video"]["laptop"].Queries).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("Quotas", func() {
+		It("should return the usage per category and client", func() {
+			query("youtube.com.")
+			query("youtube.com.")
+			query("www.roblox.com.", "kids")
+
+			Expect(sut.Quotas()).Should(Equal([]api.QuotaUsage{
+				{
+					Category:  "gaming",
+					Client:    "laptop",
+					Queries:   1,
+					Time:      time.Minute,
+					TimeLimit: 2 * time.Minute,
+				},
+				{
+					Category:   "video",
+					Client:     "laptop",
+					Queries:    2,
+					QueryLimit: 2,
+					Time:       time.Minute,
+					Exhausted:  true,
+				},
+			}))
+		})
+	})
+
+	Describe("ResetQuotas", func() {
+		JustBeforeEach(func() {
+			query("youtube.com.")
+			query("youtube.com.")
+			query("www.roblox.com.", "kids")
+		})
+
+		It("should reset all usage", func() {
+			Expect(sut.ResetQuotas("", "")).Should(Succeed())
+
+			Expect(sut.Quotas()).Should(BeEmpty())
+			Expect(query("youtube.com.")).Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should reset the usage of a category", func() {
+			Expect(sut.ResetQuotas("video", "")).Should(Succeed())
+
+			Expect(sut.Quotas()).Should(ConsistOf(HaveField("Category", "gaming")))
+		})
+
+		It("should reset the usage of a client", func() {
+			Expect(sut.ResetQuotas("", "phone")).Should(Succeed())
+			Expect(sut.Quotas()).Should(HaveLen(2))
+
+			Expect(sut.ResetQuotas("", "laptop")).Should(Succeed())
+			Expect(sut.Quotas()).Should(BeEmpty())
+		})
+
+		It("should fail for unknown categories", func() {
+			Expect(sut.ResetQuotas("social", "")).Should(MatchError(api.ErrUnknownQuotaCategory))
+		})
+	})
+
+	Describe("store", func() {
+		It("should persist the usage", func() {
+			query("youtube.com.")
+
+			Expect(sut.save()).Should(Succeed())
+
+			reloaded, err := NewQuotaResolver(ctx, sutConfig)
+			Expect(err).Should(Succeed())
+
+			expected, err := sut.Quotas()
+			Expect(err).Should(Succeed())
+
+			Expect(reloaded.Quotas()).Should(Equal(expected))
+		})
+
+		It("should ignore the usage of another day", func() {
+			tmpDir.CreateStringFile("quotas.json",
+				`{"day":"2000-01-01","usage":{"video":{"laptop":{"queries":2,"seconds":60,"lastPeriod":0}}}}`)
+
+			reloaded, err := NewQuotaResolver(ctx, sutConfig)
+			Expect(err).Should(Succeed())
+
+			Expect(reloaded.Quotas()).Should(BeEmpty())
+		})
+
+		It("should save the usage on shutdown", func() {
+			query("youtube.com.")
+
+			cancelFn()
+
+			Eventually(func(g Gomega) {
+				content, err := os.ReadFile(sutConfig.StoreFile)
+				g.Expect(err).Should(Succeed())
+				g.Expect(string(content)).Should(ContainSubstring(`"laptop"`))
+			}).Should(Succeed())
+		})
+
+		It("should fail on invalid stores", func() {
+			tmpDir.CreateStringFile("quotas.json", "invalid")
+
+			_, err := NewQuotaResolver(ctx, sutConfig)
+			Expect(err).Should(MatchError(ContainSubstring("invalid quota store")))
+		})
+	})
+})
@@ -34,7 +34,7 @@ func newRequestWithClient(question string, rType dns.Type, ip string, clientName
 }
 
 // newResponse creates a response to the given request
-func newResponse(request *model.Request, rcode int, rtype model.ResponseType, reason string) *model.Response {
+func newResponse(request *model.Request, rcode int, rtype model.ResponseType, reason model.Reason) *model.Response {
 	response := new(dns.Msg)
 	response.SetReply(request.Req)
 	response.Rcode = rcode
@@ -93,6 +93,10 @@ func (r *NextResolver) Next(n Resolver) {
 
 // GetNext returns the next resolver
 func (r *NextResolver) GetNext() Resolver {
+	if t, ok := r.next.(*traced); ok {
+		return t.Resolver
+	}
+
 	return r.next
 }
 
@@ -102,12 +106,12 @@ type NamedResolver interface {
 	Name() string
 }
 
-// Chain creates a chain of resolvers
+// Chain creates a chain of resolvers, the visits of the linked resolvers are recorded in query traces
 func Chain(resolvers ...Resolver) ChainedResolver {
 	for i, res := range resolvers {
 		if i+1 < len(resolvers) {
 			if cr, ok := res.(ChainedResolver); ok {
-				cr.Next(resolvers[i+1])
+				cr.Next(TraceStages(resolvers[i+1]))
 			}
 		}
 	}
@@ -117,7 +121,7 @@ func Chain(resolvers ...Resolver) ChainedResolver {
 
 func GetFromChainWithType[T any](resolver ChainedResolver) (result T, err error) {
 	for resolver != nil {
-		if result, found := resolver.(T); found {
+		if result, found := Unwrap(resolver).(T); found {
 			return result, nil
 		}
 
@@ -146,7 +150,7 @@ func Name(resolver Resolver) string {
 // the callback is called exactly once.
 func ForEach(resolver Resolver, callback func(Resolver)) {
 	for resolver != nil {
-		callback(resolver)
+		callback(Unwrap(resolver))
 
 		if chained, ok := resolver.(ChainedResolver); ok {
 			resolver = chained.GetNext()
@@ -269,12 +273,15 @@ func createGroupResolvers(
 	resolvers := make([]*upstreamResolverStatus, 0, len(upstreams))
 
 	for _, upstream := range upstreams {
-		resolver, err := NewUpstreamResolver(ctx, newUpstreamConfig(upstream, cfg.Upstreams), bootstrap)
+		upstreamCfg := newUpstreamConfig(upstream, cfg.Upstreams)
+		upstreamCfg.binding = cfg.Binding()
+
+		resolver, err := NewUpstreamResolver(ctx, upstreamCfg, bootstrap)
 		if err != nil {
 			continue // err was already logged
 		}
 
-		resolvers = append(resolvers, newUpstreamResolverStatus(resolver))
+		resolvers = append(resolvers, newUpstreamResolverStatus(resolver, cfg.WeightHalfLife.ToDuration()))
 	}
 
 	if len(resolvers) == 0 {
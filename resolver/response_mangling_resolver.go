@@ -0,0 +1,205 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+const defaultClientGroup = "default"
+
+// ResponseManglingResolver removes EDNS options, rewrites the TTLs and orders the answers of the responses to the
+// clients
+type ResponseManglingResolver struct {
+	configurable[*config.ResponseMangling]
+	NextResolver
+	typed
+
+	domainTTL map[string]uint32
+}
+
+// NewResponseManglingResolver creates new resolver instance
+func NewResponseManglingResolver(cfg config.ResponseMangling) *ResponseManglingResolver {
+	domainTTL := make(map[string]uint32, len(cfg.DomainTTL))
+	for domain, ttl := range cfg.DomainTTL {
+		domainTTL[strings.ToLower(strings.TrimSuffix(domain, "."))] = ttl.SecondsU32()
+	}
+
+	return &ResponseManglingResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("response_mangling"),
+
+		domainTTL: domainTTL,
+	}
+}
+
+// Resolve modifies the response of the next resolver according to the rules of the client's groups
+func (r *ResponseManglingResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	resp, err := r.next.Resolve(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := r.rulesForClient(request)
+
+	removeEdnsOptions(resp.Res, rules.RemoveEDNSOptions)
+
+	if ttl, ok := r.ttlForDomain(request.Req.Question[0].Name); ok {
+		for _, rr := range resp.Res.Answer {
+			rr.Header().Ttl = ttl
+		}
+	} else {
+		limitTTLs(resp.Res.Answer, rules.MinTTL, rules.MaxTTL)
+	}
+
+	if r.cfg.SortAnswers || r.cfg.RotateAnswers {
+		orderAnswers(resp.Res.Answer, r.cfg.SortAnswers, r.cfg.RotateAnswers)
+	}
+
+	return resp, nil
+}
+
+// returns the combined global rules and rules of all groups matching the client or the rules of the default group
+func (r *ResponseManglingResolver) rulesForClient(request *model.Request) config.ResponseManglingRules {
+	result := config.ResponseManglingRules{MinTTL: r.cfg.MinTTL, MaxTTL: r.cfg.MaxTTL}
+	matched := false
+
+	for group, rules := range r.cfg.ClientGroups {
+		if group == defaultClientGroup || !clientMatchesGroup(group, request) {
+			continue
+		}
+
+		matched = true
+		result = mergeManglingRules(result, rules)
+	}
+
+	if !matched {
+		return mergeManglingRules(result, r.cfg.ClientGroups[defaultClientGroup])
+	}
+
+	return result
+}
+
+// all EDNS options of both rules are removed and the strictest TTL limits are used
+func mergeManglingRules(a, b config.ResponseManglingRules) config.ResponseManglingRules {
+	result := config.ResponseManglingRules{
+		RemoveEDNSOptions: slices.Concat(a.RemoveEDNSOptions, b.RemoveEDNSOptions),
+		MinTTL:            max(a.MinTTL, b.MinTTL),
+		MaxTTL:            a.MaxTTL,
+	}
+
+	if b.MaxTTL.IsAboveZero() && (!result.MaxTTL.IsAboveZero() || b.MaxTTL < result.MaxTTL) {
+		result.MaxTTL = b.MaxTTL
+	}
+
+	return result
+}
+
+// returns the fixed TTL of the domain or its nearest parent domain
+func (r *ResponseManglingResolver) ttlForDomain(name string) (uint32, bool) {
+	domain := strings.ToLower(util.ExtractDomainOnly(name))
+
+	for {
+		if ttl, ok := r.domainTTL[domain]; ok {
+			return ttl, true
+		}
+
+		i := strings.IndexRune(domain, '.')
+		if i < 0 {
+			return 0, false
+		}
+
+		domain = domain[i+1:]
+	}
+}
+
+// removes the options from the OPT record, the OPT record itself is kept
+func removeEdnsOptions(msg *dns.Msg, options []config.EDNSOption) {
+	opt := msg.IsEdns0()
+	if opt == nil || len(options) == 0 {
+		return
+	}
+
+	opt.Option = slices.DeleteFunc(opt.Option, func(o dns.EDNS0) bool {
+		return slices.ContainsFunc(options, func(option config.EDNSOption) bool {
+			return option.Code() == o.Option()
+		})
+	})
+}
+
+// sets the TTL of each record to at least minTTL and at most maxTTL, if the limit is defined
+func limitTTLs(records []dns.RR, minTTL, maxTTL config.Duration) {
+	for _, rr := range records {
+		if minTTL.IsAboveZero() {
+			rr.Header().Ttl = max(rr.Header().Ttl, minTTL.SecondsU32())
+		}
+
+		if maxTTL.IsAboveZero() {
+			rr.Header().Ttl = min(rr.Header().Ttl, maxTTL.SecondsU32())
+		}
+	}
+}
+
+// orders the records within each RRset, the order of the RRsets (e.g. of a CNAME chain) is kept
+func orderAnswers(records []dns.RR, sortRecords, rotate bool) {
+	for start := 0; start < len(records); {
+		end := start + 1
+		for end < len(records) && sameRRset(records[start], records[end]) {
+			end++
+		}
+
+		rrset := records[start:end]
+
+		if sortRecords {
+			sortCanonical(rrset)
+		}
+
+		if rotate && len(rrset) > 1 {
+			offset := rand.IntN(len(rrset)) //nolint:gosec // no security relevance
+			copy(rrset, slices.Concat(rrset[offset:], rrset[:offset]))
+		}
+
+		start = end
+	}
+}
+
+func sameRRset(a, b dns.RR) bool {
+	ha, hb := a.Header(), b.Header()
+
+	return ha.Rrtype == hb.Rrtype && ha.Class == hb.Class && strings.EqualFold(ha.Name, hb.Name)
+}
+
+// sorts the records by their RDATA in wire format (canonical order, RFC 4034 section 6.3)
+func sortCanonical(rrset []dns.RR) {
+	if len(rrset) < 2 { //nolint:mnd
+		return
+	}
+
+	rdata := make(map[dns.RR][]byte, len(rrset))
+
+	for _, rr := range rrset {
+		buf := make([]byte, dns.Len(rr))
+
+		off, err := dns.PackRR(rr, buf, 0, nil, false)
+		if err != nil {
+			continue // can't be packed, sorted first
+		}
+
+		rdata[rr] = buf[off-int(rr.Header().Rdlength) : off]
+	}
+
+	slices.SortStableFunc(rrset, func(a, b dns.RR) int {
+		return bytes.Compare(rdata[a], rdata[b])
+	})
+}
@@ -0,0 +1,322 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ResponseManglingResolver", func() {
+	var (
+		sut        *ResponseManglingResolver
+		sutConfig  config.ResponseMangling
+		m          *mockResolver
+		mockAnswer *dns.Msg
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.ResponseMangling{
+			ClientGroups: map[string]config.ResponseManglingRules{
+				"192.168.20.0/24": {
+					RemoveEDNSOptions: []config.EDNSOption{config.EDNSOptionEcs},
+					MaxTTL:            config.Duration(time.Hour),
+				},
+				"iot*": {
+					RemoveEDNSOptions: []config.EDNSOption{config.EDNSOptionPadding},
+					MinTTL:            config.Duration(5 * time.Minute),
+					MaxTTL:            config.Duration(2 * time.Hour),
+				},
+				"default": {
+					RemoveEDNSOptions: []config.EDNSOption{config.EDNSOptionCookie},
+				},
+			},
+		}
+
+		var err error
+
+		mockAnswer, err = util.NewMsgWithAnswer("example.com.", 7200, A, "192.0.2.1")
+		Expect(err).Should(Succeed())
+
+		mockAnswer.SetEdns0(dns.DefaultMsgSize, false)
+		util.SetEdns0Option(mockAnswer, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1})
+		util.SetEdns0Option(mockAnswer, &dns.EDNS0_PADDING{Padding: make([]byte, 8)})
+		util.SetEdns0Option(mockAnswer, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "24a5ac1223344556"})
+	})
+
+	JustBeforeEach(func() {
+		sut = NewResponseManglingResolver(sutConfig)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer}, nil)
+		sut.Next(m)
+	})
+
+	options := func(resp *Response) []uint16 {
+		var codes []uint16
+
+		for _, o := range resp.Res.IsEdns0().Option {
+			codes = append(codes, o.Option())
+		}
+
+		return codes
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("no rules are configured", func() {
+			BeforeEach(func() {
+				sutConfig = config.ResponseMangling{}
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should not modify the response", func() {
+				resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+				Expect(err).Should(Succeed())
+
+				Expect(resp).Should(HaveTTL(BeNumerically("==", 7200)))
+				Expect(options(resp)).Should(HaveLen(3))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("the client matches a group", func() {
+		It("should apply the rules of the group", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(SatisfyAll(
+				BeDNSRecord("example.com.", A, "192.0.2.1"),
+				HaveTTL(BeNumerically("==", 3600)),
+			))
+			Expect(options(resp)).Should(ConsistOf(uint16(dns.EDNS0PADDING), uint16(dns.EDNS0COOKIE)))
+		})
+	})
+
+	When("the client matches several groups", func() {
+		It("should combine the rules of the groups", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5", "iot-cam"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 3600)))
+			Expect(options(resp)).Should(ConsistOf(uint16(dns.EDNS0COOKIE)))
+		})
+
+		It("should raise the TTL to the minimum", func() {
+			mockAnswer.Answer[0].Header().Ttl = 5
+
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5", "iot-cam"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 300)))
+		})
+	})
+
+	When("the client matches no group", func() {
+		It("should apply the rules of the default group", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.0.0.1"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 7200)))
+			Expect(options(resp)).Should(ConsistOf(uint16(dns.EDNS0SUBNET), uint16(dns.EDNS0PADDING)))
+		})
+	})
+
+	When("global TTL limits are defined", func() {
+		BeforeEach(func() {
+			sutConfig.MinTTL = config.Duration(time.Minute)
+			sutConfig.MaxTTL = config.Duration(90 * time.Minute)
+		})
+
+		It("should use the strictest limits of global and group rules", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 3600)))
+		})
+
+		It("should apply the limits to clients without group", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.0.0.1"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 5400)))
+
+			mockAnswer.Answer[0].Header().Ttl = 5
+
+			resp, err = sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.0.0.1"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 60)))
+		})
+	})
+
+	When("a domain TTL is defined", func() {
+		BeforeEach(func() {
+			sutConfig.DomainTTL = map[string]config.Duration{"Example.com.": config.Duration(10 * time.Minute)}
+		})
+
+		It("should use the fixed TTL for the domain and its subdomains", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 600)))
+
+			resp, err = sut.Resolve(ctx, newRequestWithClient("sub.example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 600)))
+		})
+
+		It("should not use the fixed TTL for other domains", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("otherexample.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 3600)))
+		})
+	})
+
+	When("the response has no OPT record", func() {
+		BeforeEach(func() {
+			util.RemoveEdns0Record(mockAnswer)
+		})
+
+		It("should only limit the TTL", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(resp).Should(HaveTTL(BeNumerically("==", 3600)))
+			Expect(resp.Res.IsEdns0()).Should(BeNil())
+		})
+	})
+
+	Describe("Answer order", func() {
+		answers := func(resp *Response) []string {
+			var result []string
+
+			for _, rr := range resp.Res.Answer {
+				result = append(result, strings.TrimPrefix(rr.String(), rr.Header().String()))
+			}
+
+			return result
+		}
+
+		sorted := []string{"example.com.", "10.0.0.1", "192.0.2.2", "192.0.2.10"}
+
+		BeforeEach(func() {
+			sutConfig = config.ResponseMangling{}
+
+			mockAnswer.Answer = nil
+
+			for _, record := range []string{
+				"www.example.com. 300 IN CNAME example.com.",
+				"example.com. 300 IN A 192.0.2.10",
+				"example.com. 300 IN A 10.0.0.1",
+				"example.com. 300 IN A 192.0.2.2",
+			} {
+				rr, err := dns.NewRR(record)
+				Expect(err).Should(Succeed())
+
+				mockAnswer.Answer = append(mockAnswer.Answer, rr)
+			}
+		})
+
+		When("the answers are sorted", func() {
+			BeforeEach(func() {
+				sutConfig.SortAnswers = true
+			})
+
+			It("should sort the records of each RRset and keep the CNAME chain", func() {
+				resp, err := sut.Resolve(ctx, newRequestWithClient("www.example.com.", A, "192.168.20.5"))
+				Expect(err).Should(Succeed())
+
+				Expect(answers(resp)).Should(Equal(sorted))
+			})
+		})
+
+		When("the answers are sorted and rotated", func() {
+			BeforeEach(func() {
+				sutConfig.SortAnswers = true
+				sutConfig.RotateAnswers = true
+			})
+
+			It("should rotate the sorted records", func() {
+				rotations := [][]string{
+					sorted,
+					{"example.com.", "192.0.2.2", "192.0.2.10", "10.0.0.1"},
+					{"example.com.", "192.0.2.10", "10.0.0.1", "192.0.2.2"},
+				}
+
+				seen := make(map[string]bool)
+
+				for range 100 {
+					resp, err := sut.Resolve(ctx, newRequestWithClient("www.example.com.", A, "192.168.20.5"))
+					Expect(err).Should(Succeed())
+
+					Expect(rotations).Should(ContainElement(answers(resp)))
+
+					seen[answers(resp)[1]] = true
+				}
+
+				Expect(seen).Should(HaveLen(3))
+			})
+		})
+
+		It("should keep the order by default", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("www.example.com.", A, "192.168.20.5"))
+			Expect(err).Should(Succeed())
+
+			Expect(answers(resp)).Should(Equal([]string{"example.com.", "192.0.2.10", "10.0.0.1", "192.0.2.2"}))
+		})
+	})
+
+	When("the next resolver returns an error", func() {
+		JustBeforeEach(func() {
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(nil, errors.New("error"))
+			sut.Next(m)
+		})
+
+		It("should return the error", func() {
+			_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.20.5"))
+
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0xERR0R/blocky/api"
+)
+
+// kinds of the recorded changes
+const (
+	manipulationBlocked      = "blocked"
+	manipulationRewritten    = "rewritten"
+	manipulationTTLClamped   = "ttlClamped"
+	manipulationAAAAFiltered = "aaaaFiltered"
+	manipulationECSRemoved   = "ecsRemoved"
+	manipulationAFiltered    = "aFiltered"
+)
+
+type responseManipulationsCtxKey struct{}
+
+// responseManipulations collects the changes of a single query and its response by the resolvers
+type responseManipulations struct {
+	lock    sync.Mutex
+	changes []api.ResponseChange
+}
+
+// WithResponseManipulations returns a context, which collects the changes of the query resolved with it.
+// An existing collector of the context is kept.
+func WithResponseManipulations(ctx context.Context) context.Context {
+	if responseManipulationsFromCtx(ctx) != nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, responseManipulationsCtxKey{}, &responseManipulations{})
+}
+
+func responseManipulationsFromCtx(ctx context.Context) *responseManipulations {
+	manipulations, _ := ctx.Value(responseManipulationsCtxKey{}).(*responseManipulations)
+
+	return manipulations
+}
+
+// recordManipulation adds a change to the collector of the context, if there is one
+func recordManipulation(ctx context.Context, stage, action, detail string) {
+	manipulations := responseManipulationsFromCtx(ctx)
+	if manipulations == nil {
+		return
+	}
+
+	manipulations.lock.Lock()
+	defer manipulations.lock.Unlock()
+
+	manipulations.changes = append(manipulations.changes, api.ResponseChange{
+		Stage:  stage,
+		Action: action,
+		Detail: detail,
+	})
+}
+
+// list returns a copy of the collected changes
+func (m *responseManipulations) list() []api.ResponseChange {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]api.ResponseChange(nil), m.changes...)
+}
@@ -45,6 +45,11 @@ func NewRewriterResolver(cfg config.RewriterConfig, inner ChainedResolver) Chain
 	}
 }
 
+// Inner returns the resolver, which resolves the rewritten queries
+func (r *RewriterResolver) Inner() Resolver {
+	return r.inner
+}
+
 func (r *RewriterResolver) Name() string {
 	return fmt.Sprintf("%s w/ %s", Name(r.inner), r.Type())
 }
@@ -64,7 +69,14 @@ func (r *RewriterResolver) Resolve(ctx context.Context, request *model.Request)
 
 	rewritten, originalNames := r.rewriteRequest(logger, original)
 	if rewritten != nil {
+		if err := spendQueryRewrite(ctx); err != nil {
+			return nil, err
+		}
+
 		request.Req = rewritten
+
+		recordManipulation(ctx, r.Type(), manipulationRewritten, fmt.Sprintf("%q to %q",
+			strings.TrimSuffix(original.Question[0].Name, "."), strings.TrimSuffix(rewritten.Question[0].Name, ".")))
 	}
 
 	logger.WithField("resolver", Name(r.inner)).Trace("go to inner resolver")
@@ -0,0 +1,180 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/scripting"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ScriptingResolver evaluates the hooks of a Lua script for each query and response
+type ScriptingResolver struct {
+	configurable[*config.Scripting]
+	NextResolver
+	typed
+
+	script *scripting.Script
+
+	evaluations *metrics.CounterVec
+	duration    *metrics.HistogramVec
+}
+
+// NewScriptingResolver creates new resolver instance
+func NewScriptingResolver(cfg config.Scripting) (*ScriptingResolver, error) {
+	r := &ScriptingResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("scripting"),
+
+		evaluations: scriptEvaluationsMetric(),
+		duration:    scriptDurationHistogram(),
+	}
+
+	if !cfg.IsEnabled() {
+		return r, nil
+	}
+
+	code, err := loadScript(cfg.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	r.script, err = scripting.Compile(cfg.Script.String(), code, cfg.Timeout.ToDuration())
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.RegisterMetric(r.evaluations)
+	metrics.RegisterMetric(r.duration)
+
+	return r, nil
+}
+
+func loadScript(source config.BytesSource) (string, error) {
+	switch source.Type {
+	case config.BytesSourceTypeText:
+		return source.From, nil
+	case config.BytesSourceTypeFile:
+		content, err := os.ReadFile(source.From)
+		if err != nil {
+			return "", fmt.Errorf("can't read script: %w", err)
+		}
+
+		return string(content), nil
+	default:
+		return "", fmt.Errorf("unsupported script source %s", source)
+	}
+}
+
+// Resolve calls the query hook before and the response hook after the next resolvers.
+// Failing hooks are logged and ignored.
+func (r *ScriptingResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if r.script == nil {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.log(ctx)
+
+	query := &scripting.Query{
+		Name:        util.ExtractDomain(request.Req.Question[0]),
+		Type:        dns.TypeToString[request.Req.Question[0].Qtype],
+		ClientIP:    request.ClientIP,
+		ClientNames: request.ClientNames,
+		Protocol:    strings.ToLower(request.Protocol.String()),
+	}
+
+	if r.script.HasQueryHook() {
+		rcode, matched, err := r.evaluate(ctx, scripting.QueryHook, func() (int, bool, error) {
+			return r.script.OnQuery(ctx, query)
+		})
+		if err != nil {
+			logger.Warnf("%s: %v", scripting.QueryHook, err)
+		} else if matched {
+			return r.scriptResponse(request, rcode, scripting.QueryHook), nil
+		}
+	}
+
+	response, err := r.next.Resolve(ctx, request)
+	if err != nil || !r.script.HasResponseHook() {
+		return response, err
+	}
+
+	rcode, matched, err := r.evaluate(ctx, scripting.ResponseHook, func() (int, bool, error) {
+		return r.script.OnResponse(ctx, query, &scripting.Response{
+			Rcode:      dns.RcodeToString[response.Res.Rcode],
+			Type:       response.RType.String(),
+			Reason:     response.Reason.String(),
+			ReasonCode: response.Reason.Code.String(),
+		})
+	})
+	if err != nil {
+		logger.Warnf("%s: %v", scripting.ResponseHook, err)
+	} else if matched {
+		return r.scriptResponse(request, rcode, scripting.ResponseHook), nil
+	}
+
+	return response, nil
+}
+
+func (r *ScriptingResolver) evaluate(
+	ctx context.Context, hook string, eval func() (int, bool, error),
+) (int, bool, error) {
+	start := time.Now()
+
+	rcode, matched, err := eval()
+
+	r.duration.WithLabelValues(hook).Observe(time.Since(start).Seconds())
+
+	result := "continue"
+
+	switch {
+	case errors.Is(err, scripting.ErrTimeout):
+		result = "timeout"
+	case err != nil:
+		result = "error"
+	case matched:
+		result = dns.RcodeToString[rcode]
+	}
+
+	r.evaluations.WithLabelValues(hook, result).Inc()
+
+	return rcode, matched, err
+}
+
+func (r *ScriptingResolver) scriptResponse(request *model.Request, rcode int, hook string) *model.Response {
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, rcode)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeSCRIPT, Reason: model.NewReason(model.ReasonCodeSCRIPT, hook)}
+}
+
+func scriptEvaluationsMetric() *metrics.CounterVec {
+	return metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blocky_script_evaluations_total",
+			Help: "Number of script hook evaluations",
+		}, []string{"hook", "result"},
+	)
+}
+
+func scriptDurationHistogram() *metrics.HistogramVec {
+	return metrics.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:                        "blocky_script_duration_seconds",
+			Help:                        "Script hook evaluation duration distribution",
+			Buckets:                     []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1},
+			NativeHistogramBucketFactor: nativeHistogramBucketFactor,
+		},
+		[]string{"hook"},
+	)
+}
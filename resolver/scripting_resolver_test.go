@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ScriptingResolver", func() {
+	var (
+		sut       *ScriptingResolver
+		sutConfig config.Scripting
+		m         *mockResolver
+		nextErr   error
+		err       error
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		nextErr = nil
+		sutConfig = config.Scripting{Timeout: config.Duration(50 * time.Millisecond)}
+	})
+
+	JustBeforeEach(func() {
+		sut, err = NewScriptingResolver(sutConfig)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg), Reason: NewReason(ReasonCodeRESOLVED)}, nextErr)
+		sut.Next(m)
+	})
+
+	withScript := func(code string) {
+		BeforeEach(func() {
+			sutConfig.Script = config.BytesSource{Type: config.BytesSourceTypeText, From: code}
+		})
+	}
+
+	When("no script is configured", func() {
+		It("is disabled and delegates to next resolver", func() {
+			Expect(sut.IsEnabled()).Should(BeFalse())
+
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+	})
+
+	Describe("LogConfig", func() {
+		withScript("function on_query(q) end\n")
+
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("query hook matches", func() {
+		withScript(`function on_query(q)
+  if in_cidr(q.client_ip, "10.0.0.0/8") and q.qtype == "ANY" then return "REFUSED" end
+end`)
+
+		It("should answer with the returned rcode", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", dns.Type(dns.TypeANY), "10.0.0.1"))).
+				Should(SatisfyAll(
+					HaveResponseType(ResponseTypeSCRIPT),
+					HaveReason("SCRIPT (on_query)"),
+					HaveReturnCode(dns.RcodeRefused),
+				))
+
+			Expect(m.Calls).Should(BeEmpty())
+			Expect(testutil.ToFloat64(sut.evaluations.WithLabelValues("on_query", "REFUSED"))).Should(Equal(1.0))
+		})
+
+		It("should delegate other queries", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("example.com.", A, "10.0.0.1"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+			Expect(testutil.ToFloat64(sut.evaluations.WithLabelValues("on_query", "continue"))).Should(Equal(1.0))
+		})
+	})
+
+	When("response hook matches", func() {
+		withScript(`function on_response(q, r) if r.reason == "RESOLVED" then return "SERVFAIL" end end`)
+
+		It("should replace the response", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(SatisfyAll(
+					HaveResponseType(ResponseTypeSCRIPT),
+					HaveReason("SCRIPT (on_response)"),
+					HaveReturnCode(dns.RcodeServerFailure),
+				))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		When("next resolver fails", func() {
+			BeforeEach(func() {
+				nextErr = errors.New("boom")
+			})
+
+			It("should return the error", func() {
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(MatchError("boom"))
+			})
+		})
+	})
+
+	When("hook exceeds the timeout", func() {
+		withScript(`function on_query(q) while true do end end`)
+
+		It("should ignore the hook", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(testutil.ToFloat64(sut.evaluations.WithLabelValues("on_query", "timeout"))).Should(Equal(1.0))
+		})
+	})
+
+	When("hook fails", func() {
+		withScript(`function on_response(q, r) error("boom") end`)
+
+		It("should ignore the hook", func() {
+			Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(testutil.ToFloat64(sut.evaluations.WithLabelValues("on_response", "error"))).Should(Equal(1.0))
+		})
+	})
+
+	Describe("script sources", func() {
+		It("should load script from file", func() {
+			file := NewTmpFolder("scripting").CreateStringFile("policy.lua", `function on_query(q) return "REFUSED" end`)
+
+			res, err := NewScriptingResolver(config.Scripting{
+				Script:  config.BytesSource{Type: config.BytesSourceTypeFile, From: file.Path},
+				Timeout: config.Duration(time.Second),
+			})
+			Expect(err).Should(Succeed())
+
+			Expect(res.Resolve(ctx, newRequest("example.com.", A))).
+				Should(HaveReturnCode(dns.RcodeRefused))
+		})
+
+		It("should fail if file does not exist", func() {
+			_, err := NewScriptingResolver(config.Scripting{
+				Script: config.BytesSource{Type: config.BytesSourceTypeFile, From: "/does/not/exist.lua"},
+			})
+			Expect(err).Should(MatchError(ContainSubstring("can't read script")))
+		})
+
+		It("should fail for HTTP sources", func() {
+			_, err := NewScriptingResolver(config.Scripting{
+				Script: config.BytesSource{Type: config.BytesSourceTypeHttp, From: "http://example.com/policy.lua"},
+			})
+			Expect(err).Should(MatchError("unsupported script source http://example.com/policy.lua"))
+		})
+
+		It("should fail on invalid scripts", func() {
+			_, err := NewScriptingResolver(config.Scripting{
+				Script: config.BytesSource{Type: config.BytesSourceTypeText, From: "x = \n"},
+			})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
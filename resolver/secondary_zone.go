@@ -0,0 +1,423 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// zoneTransferRetry is the delay before a failed transfer is retried, if the SOA record of the zone is unknown
+var zoneTransferRetry = time.Minute //nolint:gochecknoglobals
+
+// maxZoneCNAMEChain limits the CNAME records, which are followed within a transferred zone
+const maxZoneCNAMEChain = 8
+
+var errIncompleteTransfer = errors.New("incomplete zone transfer")
+
+// secondaryZone is a zone of the conditional mapping, which is transferred from its primaries via AXFR/IXFR and
+// answered locally. The zone is refreshed periodically and on NOTIFY of a primary.
+type secondaryZone struct {
+	name      string
+	primaries []string
+	refresh   time.Duration
+	timeout   time.Duration
+
+	data    atomic.Pointer[zoneData]
+	notifyC chan struct{}
+}
+
+func newSecondaryZone(zone string, primaries []config.Upstream, refresh, timeout time.Duration) *secondaryZone {
+	z := &secondaryZone{
+		name:    dns.Fqdn(strings.ToLower(zone)),
+		refresh: refresh,
+		timeout: timeout,
+		notifyC: make(chan struct{}, 1),
+	}
+
+	for _, primary := range primaries {
+		z.primaries = append(z.primaries, net.JoinHostPort(primary.Host, strconv.Itoa(int(primary.Port))))
+	}
+
+	return z
+}
+
+func (z *secondaryZone) logger() *logrus.Entry {
+	return log.PrefixedLog("secondary_zone").WithField("zone", z.name)
+}
+
+// start transfers the zone and refreshes it until the context is done
+func (z *secondaryZone) start(ctx context.Context) {
+	go func() {
+		for {
+			if !z.waitForRefresh(ctx, z.update()) {
+				return
+			}
+		}
+	}()
+}
+
+// waitForRefresh waits for the refresh interval or a NOTIFY, returns false if the context is done before
+func (z *secondaryZone) waitForRefresh(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-z.notifyC:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// notify triggers a refresh of the zone
+func (z *secondaryZone) notify() {
+	select {
+	case z.notifyC <- struct{}{}:
+	default:
+		// a refresh is already pending
+	}
+}
+
+// update transfers the zone from the first primary, which answers, and returns the delay until the next refresh
+func (z *secondaryZone) update() time.Duration {
+	current := z.data.Load()
+
+	var errs error
+
+	for _, primary := range z.primaries {
+		data, err := z.transfer(current, primary)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", primary, err))
+
+			continue
+		}
+
+		z.data.Store(data)
+
+		if current == nil || current.soa.Serial != data.soa.Serial {
+			z.logger().Infof("transferred serial %d with %d records from %s", data.soa.Serial, len(data.records), primary)
+		}
+
+		if current != nil && current.soa.Serial != data.soa.Serial {
+			evt.Bus().Publish(evt.ConditionalZoneChanged, strings.TrimSuffix(z.name, "."))
+		}
+
+		if z.refresh > 0 {
+			return z.refresh
+		}
+
+		return max(time.Duration(data.soa.Refresh)*time.Second, time.Second)
+	}
+
+	if current == nil {
+		z.logger().Warnf("can't transfer zone, retrying in %s: %v", zoneTransferRetry, errs)
+
+		return zoneTransferRetry
+	}
+
+	retry := max(time.Duration(current.soa.Retry)*time.Second, time.Second)
+
+	z.logger().Warnf("can't refresh zone, retrying in %s: %v", retry, errs)
+
+	return retry
+}
+
+// transfer requests an IXFR with the serial of the current zone or an AXFR, if there is none
+func (z *secondaryZone) transfer(current *zoneData, primary string) (*zoneData, error) {
+	msg := new(dns.Msg)
+
+	if current == nil {
+		msg.SetAxfr(z.name)
+	} else {
+		msg.SetIxfr(z.name, current.soa.Serial, current.soa.Ns, current.soa.Mbox)
+	}
+
+	t := &dns.Transfer{DialTimeout: z.timeout, ReadTimeout: z.timeout, WriteTimeout: z.timeout}
+
+	envelopes, err := t.In(msg, primary)
+	if err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, envelope.Error
+		}
+
+		rrs = append(rrs, envelope.RR...)
+	}
+
+	records, err := applyTransfer(current, rrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return newZoneData(z.name, rrs[0].(*dns.SOA), records, time.Now()), nil
+}
+
+// applyTransfer returns the records of the zone after the transfer. The answer of an IXFR is either a single SOA
+// record, if the zone is up to date, the differences of the versions (RFC 1995) or the complete zone like an AXFR.
+func applyTransfer(current *zoneData, rrs []dns.RR) (zoneRecords, error) {
+	if len(rrs) == 0 {
+		return nil, errIncompleteTransfer
+	}
+
+	soa, ok := rrs[0].(*dns.SOA)
+	if !ok {
+		return nil, fmt.Errorf("%w: first record is no SOA record", errIncompleteTransfer)
+	}
+
+	if len(rrs) == 1 {
+		if current == nil || current.soa.Serial != soa.Serial {
+			return nil, errIncompleteTransfer
+		}
+
+		return current.records, nil
+	}
+
+	if last, ok := rrs[len(rrs)-1].(*dns.SOA); !ok || last.Serial != soa.Serial {
+		return nil, fmt.Errorf("%w: last record is no SOA record with serial %d", errIncompleteTransfer, soa.Serial)
+	}
+
+	_, incremental := rrs[1].(*dns.SOA)
+
+	if current == nil || !incremental || len(rrs) == 2 {
+		records := make(zoneRecords, len(rrs)-1)
+
+		for _, rr := range rrs[:len(rrs)-1] {
+			records[recordKey(rr)] = rr
+		}
+
+		return records, nil
+	}
+
+	records := maps.Clone(current.records)
+
+	// each version starts with the old SOA record and the deleted records, followed by the new SOA record and the
+	// added records
+	adding := true
+
+	for _, rr := range rrs[1 : len(rrs)-1] {
+		if _, ok := rr.(*dns.SOA); ok {
+			adding = !adding
+		}
+
+		if adding {
+			records[recordKey(rr)] = rr
+		} else {
+			delete(records, recordKey(rr))
+		}
+	}
+
+	return records, nil
+}
+
+// recordKey identifies a record independent of its TTL and the case of its name
+func recordKey(rr dns.RR) string {
+	rr = dns.Copy(rr)
+	rr.Header().Ttl = 0
+	rr.Header().Name = strings.ToLower(rr.Header().Name)
+
+	return rr.String()
+}
+
+// zoneRecords are the records of a zone by their key
+type zoneRecords map[string]dns.RR
+
+// zoneData is a version of a transferred zone, which isn't changed after its creation
+type zoneData struct {
+	apex    string
+	soa     *dns.SOA
+	records zoneRecords
+	// the records by owner name
+	owners map[string][]dns.RR
+	// the owner names and their ancestors within the zone (empty non-terminals)
+	names   map[string]struct{}
+	expires time.Time
+}
+
+func newZoneData(apex string, soa *dns.SOA, records zoneRecords, transferred time.Time) *zoneData {
+	d := &zoneData{
+		apex:    apex,
+		soa:     soa,
+		records: records,
+		owners:  make(map[string][]dns.RR),
+		names:   make(map[string]struct{}),
+		expires: transferred.Add(time.Duration(soa.Expire) * time.Second),
+	}
+
+	for _, rr := range records {
+		name := strings.ToLower(rr.Header().Name)
+
+		d.owners[name] = append(d.owners[name], rr)
+
+		for n := name; n != "" && dns.IsSubDomain(apex, n); n = parentName(n) {
+			d.names[n] = struct{}{}
+		}
+	}
+
+	return d
+}
+
+// parentName returns the name without its first label, "" for the root
+func parentName(name string) string {
+	if i := strings.IndexByte(name, '.'); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+
+	return ""
+}
+
+// answer returns the answer of the zone for the question. It returns false, if the name is delegated to other
+// name servers, the query is forwarded to the primaries then.
+func (d *zoneData) answer(question dns.Question) (*dns.Msg, bool) {
+	msg := new(dns.Msg)
+	name := question.Name
+
+	for range maxZoneCNAMEChain {
+		if d.isDelegated(name) {
+			return msg, len(msg.Answer) != 0
+		}
+
+		rrs, exists := d.lookup(name)
+		if !exists {
+			msg.Rcode = dns.RcodeNameError
+			msg.Ns = []dns.RR{d.negativeSOA()}
+
+			return msg, true
+		}
+
+		if answer := recordsOfType(rrs, question.Qtype); len(answer) != 0 {
+			msg.Answer = append(msg.Answer, answer...)
+
+			return msg, true
+		}
+
+		cnames := recordsOfType(rrs, dns.TypeCNAME)
+		if len(cnames) == 0 {
+			msg.Ns = []dns.RR{d.negativeSOA()}
+
+			return msg, true
+		}
+
+		msg.Answer = append(msg.Answer, cnames[0])
+
+		name = cnames[0].(*dns.CNAME).Target
+		if !dns.IsSubDomain(d.apex, strings.ToLower(name)) {
+			break
+		}
+	}
+
+	return msg, true
+}
+
+// lookup returns the records of the name, synthesized from a wildcard if necessary, and whether the name exists
+func (d *zoneData) lookup(name string) ([]dns.RR, bool) {
+	name = strings.ToLower(name)
+
+	if rrs, ok := d.owners[name]; ok {
+		return rrs, true
+	}
+
+	if _, ok := d.names[name]; ok {
+		return nil, true
+	}
+
+	// the wildcard of the closest existing ancestor (RFC 4592)
+	for n := parentName(name); n != "" && dns.IsSubDomain(d.apex, n); n = parentName(n) {
+		if _, ok := d.names[n]; !ok {
+			continue
+		}
+
+		wildcard, ok := d.owners["*."+n]
+		if !ok {
+			return nil, false
+		}
+
+		result := make([]dns.RR, 0, len(wildcard))
+
+		for _, rr := range wildcard {
+			rr = dns.Copy(rr)
+			rr.Header().Name = name
+			result = append(result, rr)
+		}
+
+		return result, true
+	}
+
+	return nil, false
+}
+
+// isDelegated returns true, if the name or one of its ancestors below the apex has NS records
+func (d *zoneData) isDelegated(name string) bool {
+	for n := strings.ToLower(name); n != d.apex && dns.IsSubDomain(d.apex, n); n = parentName(n) {
+		if len(recordsOfType(d.owners[n], dns.TypeNS)) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// negativeSOA returns the SOA record for negative answers with the negative caching TTL (RFC 2308)
+func (d *zoneData) negativeSOA() dns.RR {
+	soa := dns.Copy(d.soa)
+	soa.Header().Ttl = min(d.soa.Hdr.Ttl, d.soa.Minttl)
+
+	return soa
+}
+
+func recordsOfType(rrs []dns.RR, qType uint16) []dns.RR {
+	var result []dns.RR
+
+	for _, rr := range rrs {
+		if qType == dns.TypeANY || rr.Header().Rrtype == qType {
+			result = append(result, rr)
+		}
+	}
+
+	return result
+}
+
+// resolve answers the request from the zone, returns nil if the zone isn't transferred yet, is expired or the name
+// is delegated
+func (z *secondaryZone) resolve(request *model.Request) *model.Response {
+	data := z.data.Load()
+	if data == nil || time.Now().After(data.expires) {
+		return nil
+	}
+
+	answer, ok := data.answer(request.Req.Question[0])
+	if !ok {
+		return nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetRcode(request.Req, answer.Rcode)
+	msg.Answer = answer.Answer
+	msg.Ns = answer.Ns
+
+	return &model.Response{
+		Res:    msg,
+		RType:  model.ResponseTypeCONDITIONAL,
+		Reason: model.NewReason(model.ReasonCodeSECONDARYZONE, strings.TrimSuffix(z.name, ".")),
+	}
+}
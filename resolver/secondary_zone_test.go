@@ -0,0 +1,400 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+// zonePrimary serves the versions of the zone `lan.` via AXFR and IXFR over TCP
+type zonePrimary struct {
+	lock     sync.Mutex
+	versions map[uint32][]dns.RR
+	serial   uint32
+	// the types of the transfer requests
+	requests []uint16
+}
+
+func newZonePrimary() *zonePrimary {
+	return &zonePrimary{versions: make(map[uint32][]dns.RR)}
+}
+
+// publish adds a version of the zone, the SOA record with the serial is added
+func (p *zonePrimary) publish(serial uint32, records ...string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.versions[serial] = append([]dns.RR{zoneSOA(serial)}, zoneRRs(records...)...)
+	p.serial = serial
+}
+
+func (p *zonePrimary) transferTypes() []uint16 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return append([]uint16(nil), p.requests...)
+}
+
+func (p *zonePrimary) answer(request *dns.Msg) []dns.RR {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	current := p.versions[p.serial]
+	soa := current[0]
+
+	if qType := request.Question[0].Qtype; qType == dns.TypeAXFR || qType == dns.TypeIXFR {
+		p.requests = append(p.requests, qType)
+	}
+
+	if request.Question[0].Qtype == dns.TypeIXFR {
+		serial := request.Ns[0].(*dns.SOA).Serial
+		if serial == p.serial {
+			return []dns.RR{soa}
+		}
+
+		if old, ok := p.versions[serial]; ok {
+			deleted, added := zoneDiff(old, current)
+
+			result := append([]dns.RR{soa, old[0]}, deleted...)
+			result = append(append(append(result, soa), added...), soa)
+
+			return result
+		}
+	}
+
+	return append(append([]dns.RR(nil), current...), soa)
+}
+
+// start serves the zone on a random port
+func (p *zonePrimary) start() config.Upstream {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).Should(Succeed())
+
+	srv := &dns.Server{
+		Listener: ln,
+		Net:      "tcp",
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+			msg := new(dns.Msg)
+			msg.SetReply(request)
+			msg.Answer = p.answer(request)
+
+			_ = w.WriteMsg(msg)
+		}),
+	}
+
+	go func() {
+		defer GinkgoRecover()
+
+		_ = srv.ActivateAndServe()
+	}()
+
+	DeferCleanup(srv.Shutdown)
+
+	addr := ln.Addr().(*net.TCPAddr)
+
+	return config.Upstream{Net: config.NetProtocolTcpUdp, Host: addr.IP.String(), Port: uint16(addr.Port)}
+}
+
+func zoneDiff(old, current []dns.RR) (deleted, added []dns.RR) {
+	keys := func(rrs []dns.RR) map[string]dns.RR {
+		result := make(map[string]dns.RR)
+		for _, rr := range rrs[1:] {
+			result[recordKey(rr)] = rr
+		}
+
+		return result
+	}
+
+	oldKeys, currentKeys := keys(old), keys(current)
+
+	for key, rr := range oldKeys {
+		if _, ok := currentKeys[key]; !ok {
+			deleted = append(deleted, rr)
+		}
+	}
+
+	for key, rr := range currentKeys {
+		if _, ok := oldKeys[key]; !ok {
+			added = append(added, rr)
+		}
+	}
+
+	return deleted, added
+}
+
+func zoneSOA(serial uint32) *dns.SOA {
+	rr, err := dns.NewRR(fmt.Sprintf("lan. 3600 IN SOA ns.lan. admin.lan. %d 3600 600 86400 300", serial))
+	Expect(err).Should(Succeed())
+
+	return rr.(*dns.SOA)
+}
+
+func zoneRRs(records ...string) []dns.RR {
+	result := make([]dns.RR, 0, len(records))
+
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		Expect(err).Should(Succeed())
+
+		result = append(result, rr)
+	}
+
+	return result
+}
+
+var _ = Describe("SecondaryZone", func() {
+	Describe("applyTransfer", func() {
+		var current *zoneData
+
+		BeforeEach(func() {
+			records, err := applyTransfer(nil, append([]dns.RR{zoneSOA(1)},
+				append(zoneRRs("a.lan. 300 IN A 192.168.178.1", "b.lan. 300 IN A 192.168.178.2"), zoneSOA(1))...))
+			Expect(err).Should(Succeed())
+
+			current = newZoneData("lan.", zoneSOA(1), records, time.Now())
+		})
+
+		It("should take the records of a full transfer", func() {
+			Expect(current.records).Should(HaveLen(3))
+			Expect(current.owners).Should(HaveKey("a.lan."))
+		})
+
+		It("should keep the records, if the zone is up to date", func() {
+			records, err := applyTransfer(current, []dns.RR{zoneSOA(1)})
+			Expect(err).Should(Succeed())
+			Expect(records).Should(Equal(current.records))
+		})
+
+		It("should apply the differences of an incremental transfer", func() {
+			rrs := []dns.RR{zoneSOA(3), zoneSOA(1)}
+			rrs = append(rrs, zoneRRs("a.lan. 60 IN A 192.168.178.1")...)
+			rrs = append(rrs, zoneSOA(2))
+			rrs = append(rrs, zoneRRs("c.lan. 300 IN A 192.168.178.3")...)
+			rrs = append(rrs, zoneSOA(2))
+			rrs = append(rrs, zoneRRs("b.lan. 300 IN A 192.168.178.2")...)
+			rrs = append(rrs, zoneSOA(3))
+			rrs = append(rrs, zoneRRs("d.lan. 300 IN A 192.168.178.4")...)
+			rrs = append(rrs, zoneSOA(3))
+
+			records, err := applyTransfer(current, rrs)
+			Expect(err).Should(Succeed())
+
+			data := newZoneData("lan.", zoneSOA(3), records, time.Now())
+			Expect(data.owners).Should(SatisfyAll(
+				HaveKey("c.lan."), HaveKey("d.lan."), Not(HaveKey("a.lan.")), Not(HaveKey("b.lan.")),
+			))
+			Expect(data.owners["lan."]).Should(HaveExactElements(zoneSOA(3)))
+		})
+
+		It("should replace the zone, if an IXFR is answered with the complete zone", func() {
+			records, err := applyTransfer(current,
+				append([]dns.RR{zoneSOA(2)}, append(zoneRRs("c.lan. 300 IN A 192.168.178.3"), zoneSOA(2))...))
+			Expect(err).Should(Succeed())
+			Expect(records).Should(HaveLen(2))
+		})
+
+		It("should fail on incomplete transfers", func() {
+			_, err := applyTransfer(nil, []dns.RR{zoneSOA(1)})
+			Expect(err).Should(MatchError(errIncompleteTransfer))
+
+			_, err = applyTransfer(nil, append([]dns.RR{zoneSOA(1)}, zoneRRs("a.lan. 300 IN A 192.168.178.1")...))
+			Expect(err).Should(MatchError(errIncompleteTransfer))
+
+			_, err = applyTransfer(nil, zoneRRs("a.lan. 300 IN A 192.168.178.1"))
+			Expect(err).Should(MatchError(errIncompleteTransfer))
+		})
+	})
+
+	Describe("answer", func() {
+		var data *zoneData
+
+		BeforeEach(func() {
+			records := make(zoneRecords)
+			for _, rr := range append([]dns.RR{zoneSOA(1)}, zoneRRs(
+				"lan. 300 IN NS ns.lan.",
+				"ns.lan. 300 IN A 192.168.178.1",
+				"nas.lan. 300 IN A 192.168.178.2",
+				"nas.lan. 300 IN TXT \"storage\"",
+				"files.lan. 300 IN CNAME nas.lan.",
+				"www.lan. 300 IN CNAME example.com.",
+				"printer.office.lan. 300 IN A 192.168.178.3",
+				"*.dyn.lan. 60 IN A 192.168.178.4",
+				"dyn.lan. 60 IN MX 10 mail.lan.",
+				"sub.lan. 300 IN NS ns.sub.lan.",
+			)...) {
+				records[recordKey(rr)] = rr
+			}
+
+			data = newZoneData("lan.", zoneSOA(1), records, time.Now())
+		})
+
+		answer := func(name string, qType dns.Type) *dns.Msg {
+			msg, ok := data.answer(dns.Question{Name: name, Qtype: uint16(qType), Qclass: dns.ClassINET})
+			Expect(ok).Should(BeTrue())
+
+			return msg
+		}
+
+		It("should answer all record types", func() {
+			Expect(answer("nas.lan.", A).Answer).Should(HaveExactElements(BeDNSRecord("nas.lan.", A, "192.168.178.2")))
+			Expect(answer("NAS.lan.", TXT).Answer).Should(HaveExactElements(BeDNSRecord("nas.lan.", TXT, "storage")))
+			Expect(answer("dyn.lan.", MX).Answer).Should(HaveLen(1))
+		})
+
+		It("should follow CNAME records within the zone", func() {
+			Expect(answer("files.lan.", A).Answer).Should(HaveExactElements(
+				BeDNSRecord("files.lan.", CNAME, "nas.lan."),
+				BeDNSRecord("nas.lan.", A, "192.168.178.2"),
+			))
+			Expect(answer("www.lan.", A).Answer).Should(HaveExactElements(
+				BeDNSRecord("www.lan.", CNAME, "example.com."),
+			))
+		})
+
+		It("should answer without records and the SOA record, if the type doesn't exist", func() {
+			msg := answer("nas.lan.", AAAA)
+			Expect(msg.Rcode).Should(Equal(dns.RcodeSuccess))
+			Expect(msg.Answer).Should(BeEmpty())
+			Expect(msg.Ns).Should(HaveExactElements(SatisfyAll(
+				BeAssignableToTypeOf(&dns.SOA{}),
+				WithTransform(func(rr dns.RR) uint32 { return rr.Header().Ttl }, BeNumerically("==", 300)),
+			)))
+
+			Expect(answer("office.lan.", A).Rcode).Should(Equal(dns.RcodeSuccess))
+		})
+
+		It("should answer NXDOMAIN for unknown names", func() {
+			msg := answer("unknown.lan.", A)
+			Expect(msg.Rcode).Should(Equal(dns.RcodeNameError))
+			Expect(msg.Ns).Should(HaveLen(1))
+
+			Expect(answer("unknown.office.lan.", A).Rcode).Should(Equal(dns.RcodeNameError))
+		})
+
+		It("should synthesize the answer of wildcards", func() {
+			Expect(answer("host.dyn.lan.", A).Answer).Should(HaveExactElements(
+				BeDNSRecord("host.dyn.lan.", A, "192.168.178.4"),
+			))
+			Expect(answer("a.host.dyn.lan.", A).Answer).Should(HaveExactElements(
+				BeDNSRecord("a.host.dyn.lan.", A, "192.168.178.4"),
+			))
+		})
+
+		It("should not answer delegated names", func() {
+			_, ok := data.answer(dns.Question{Name: "host.sub.lan.", Qtype: dns.TypeA, Qclass: dns.ClassINET})
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	Describe("ConditionalUpstreamResolver with transferred zones", func() {
+		var (
+			primary  *zonePrimary
+			sut      *ConditionalUpstreamResolver
+			m        *mockResolver
+			ctx      context.Context
+			upstream config.Upstream
+			changes  chan string
+		)
+
+		BeforeEach(func() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			primary = newZonePrimary()
+			primary.publish(1, "nas.lan. 300 IN A 192.168.178.2")
+			upstream = primary.start()
+
+			changes = make(chan string, 10)
+			handler := func(zone string) {
+				changes <- zone
+			}
+
+			Expect(evt.Bus().Subscribe(evt.ConditionalZoneChanged, handler)).Should(Succeed())
+			DeferCleanup(evt.Bus().Unsubscribe, evt.ConditionalZoneChanged, handler)
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			sut, err = NewConditionalUpstreamResolver(ctx, config.ConditionalUpstream{
+				Mapping: config.ConditionalUpstreamMapping{
+					Upstreams: map[string][]config.Upstream{"lan": {upstream}},
+				},
+				Transfer: config.ConditionalTransfer{Zones: []string{"lan"}},
+				Notify:   true,
+			}, defaultUpstreamsConfig, systemResolverBootstrap)
+			Expect(err).Should(Succeed())
+
+			m = &mockResolver{}
+			m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+			sut.Next(m)
+		})
+
+		It("should answer from the transferred zone and refresh it incrementally on NOTIFY", func() {
+			Eventually(func(g Gomega) {
+				resp, err := sut.Resolve(ctx, newRequest("nas.lan.", A))
+				g.Expect(err).Should(Succeed())
+				g.Expect(resp).Should(SatisfyAll(
+					HaveResponseType(ResponseTypeCONDITIONAL),
+					HaveReason("SECONDARY ZONE (lan)"),
+					BeDNSRecord("nas.lan.", A, "192.168.178.2"),
+				))
+			}).Should(Succeed())
+
+			primary.publish(2, "nas.lan. 300 IN A 192.168.178.20")
+
+			By("NOTIFY of other hosts is refused", func() {
+				Expect(sut.Notify(ctx, "lan.", net.ParseIP("192.168.178.1"))).Should(BeFalse())
+				Expect(sut.Notify(ctx, "other.", net.ParseIP(upstream.Host))).Should(BeFalse())
+			})
+
+			Expect(sut.Notify(ctx, "lan.", net.ParseIP(upstream.Host))).Should(BeTrue())
+
+			Eventually(func(g Gomega) {
+				resp, err := sut.Resolve(ctx, newRequest("nas.lan.", A))
+				g.Expect(err).Should(Succeed())
+				g.Expect(resp).Should(BeDNSRecord("nas.lan.", A, "192.168.178.20"))
+			}).Should(Succeed())
+
+			Expect(primary.transferTypes()).Should(HaveExactElements(dns.TypeAXFR, dns.TypeIXFR))
+
+			By("the cached answers are flushed after the transfer of a new serial only", func() {
+				Expect(changes).Should(Receive(Equal("lan")))
+				Expect(changes).ShouldNot(Receive())
+			})
+
+			By("NOTIFY is passed from the chain", func() {
+				Expect(NotifyZone(ctx, Chain(NewRewriterResolver(config.RewriterConfig{
+					Rewrite: map[string]string{"home": "lan"},
+				}, sut)), "lan.", net.ParseIP(upstream.Host))).Should(BeTrue())
+			})
+
+			m.AssertNotCalled(GinkgoT(), "Resolve", mock.Anything)
+		})
+
+		When("the zone isn't transferred", func() {
+			BeforeEach(func() {
+				upstream.Port = 1
+			})
+
+			It("should forward the queries to the conditional upstream", func() {
+				resp, err := sut.Resolve(ctx, newRequest("nas.lan.", A))
+				Expect(err).Should(HaveOccurred())
+				Expect(resp).Should(BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+)
+
+// StatisticsResolver excludes the queries of the configured clients and domains from the metrics and the query log.
+// It must be placed after the client names resolver and before the query logging and metrics resolvers.
+type StatisticsResolver struct {
+	configurable[*config.Statistics]
+	NextResolver
+	typed
+
+	domains *domainPatterns
+}
+
+// NewStatisticsResolver creates a new resolver instance
+func NewStatisticsResolver(cfg config.Statistics) (*StatisticsResolver, error) {
+	domains, err := newDomainPatterns(cfg.Exclude.Domains)
+	if err != nil {
+		return nil, fmt.Errorf("excluded domain %w", err)
+	}
+
+	return &StatisticsResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("statistics"),
+
+		domains: domains,
+	}, nil
+}
+
+// Resolve marks excluded queries as unrecorded and delegates to the next resolver
+func (r *StatisticsResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if r.isExcluded(request) {
+		request.Unrecorded = true
+	}
+
+	return r.next.Resolve(ctx, request)
+}
+
+func (r *StatisticsResolver) isExcluded(request *model.Request) bool {
+	for _, client := range r.cfg.Exclude.Clients {
+		if clientMatchesGroup(client, request) {
+			return true
+		}
+	}
+
+	return r.domains.matchesAny(request.Req.Question)
+}
@@ -0,0 +1,101 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("StatisticsResolver", func() {
+	var (
+		sut       *StatisticsResolver
+		sutConfig config.Statistics
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Statistics{Exclude: config.StatisticsExclude{
+			Clients: []string{"10.0.0.5", "lb-*", "tag:monitoring"},
+			Domains: []string{"health.example.com", "*.canary.example.com", "/^probe[0-9]+\\./"},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		sut, err = NewStatisticsResolver(sutConfig)
+		Expect(err).Should(Succeed())
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	When("the excluded domains are invalid", func() {
+		It("should fail", func() {
+			_, err := NewStatisticsResolver(config.Statistics{Exclude: config.StatisticsExclude{
+				Domains: []string{"/[]/"},
+			}})
+
+			Expect(err).Should(MatchError(ContainSubstring("excluded domain '/[]/' fail because")))
+		})
+	})
+
+	DescribeTable("marks excluded queries as unrecorded",
+		func(request *Request, unrecorded bool) {
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.Unrecorded).Should(Equal(unrecorded))
+			m.AssertExpectations(GinkgoT())
+		},
+		Entry("client IP", newRequestWithClient("example.com.", A, "10.0.0.5"), true),
+		Entry("client name", newRequestWithClient("example.com.", A, "10.0.0.6", "lb-1"), true),
+		Entry("client tag", func() *Request {
+			request := newRequestWithClient("example.com.", A, "10.0.0.6")
+			request.ClientTags = []string{"monitoring"}
+
+			return request
+		}(), true),
+		Entry("exact domain", newRequestWithClient("Health.Example.com.", A, "10.0.0.6"), true),
+		Entry("subdomain of exact domain", newRequestWithClient("a.health.example.com.", A, "10.0.0.6"), false),
+		Entry("wildcard", newRequestWithClient("a.canary.example.com.", AAAA, "10.0.0.6"), true),
+		Entry("regex", newRequestWithClient("probe1.example.com.", A, "10.0.0.6"), true),
+		Entry("other query", newRequestWithClient("example.com.", A, "10.0.0.6", "laptop"), false),
+	)
+})
@@ -47,7 +47,7 @@ func newStrictResolver(
 		typed:        withType(strictResolverType),
 	}
 
-	r.setResolvers(newUpstreamResolverStatuses(resolvers))
+	r.setResolvers(newUpstreamResolverStatuses(resolvers, cfg.WeightHalfLife.ToDuration()))
 
 	return &r
 }
@@ -92,6 +92,8 @@ func (r *StrictResolver) Resolve(ctx context.Context, request *model.Request) (*
 			"answer":   util.AnswerToString(resp.Res.Answer),
 		}).Debug("using response from resolver")
 
+		markSelected(resolver.resolver)
+
 		return resp, nil
 	}
 
@@ -101,13 +101,14 @@ func NewSpecialUseDomainNamesResolver(cfg config.SUDN) *SpecialUseDomainNamesRes
 }
 
 func (r *SpecialUseDomainNamesResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
-	if !r.cfg.Enable {
+	cfg := r.configForClient(request)
+	if !cfg.Enable {
 		return r.next.Resolve(ctx, request)
 	}
 
 	handler := r.handler(request)
 	if handler != nil {
-		resp := handler(request, r.cfg)
+		resp := handler(request, cfg)
 		if resp != nil {
 			return resp, nil
 		}
@@ -116,6 +117,47 @@ func (r *SpecialUseDomainNamesResolver) Resolve(ctx context.Context, request *mo
 	return r.next.Resolve(ctx, request)
 }
 
+// returns the configuration with the options of the matching client groups: if several groups set an option,
+// the strictest value is used
+func (r *SpecialUseDomainNamesResolver) configForClient(request *model.Request) *config.SUDN {
+	if len(r.cfg.ClientGroups) == 0 {
+		return r.cfg
+	}
+
+	var enable, appendixG *bool
+
+	merge := func(current, value *bool) *bool {
+		if value == nil || (current != nil && *current) {
+			return current
+		}
+
+		return value
+	}
+
+	for group, rules := range r.cfg.ClientGroups {
+		if clientMatchesGroup(group, request) {
+			enable = merge(enable, rules.Enable)
+			appendixG = merge(appendixG, rules.RFC6762AppendixG)
+		}
+	}
+
+	if enable == nil && appendixG == nil {
+		return r.cfg
+	}
+
+	cfg := *r.cfg
+
+	if enable != nil {
+		cfg.Enable = *enable
+	}
+
+	if appendixG != nil {
+		cfg.RFC6762AppendixG = *appendixG
+	}
+
+	return &cfg
+}
+
 func (r *SpecialUseDomainNamesResolver) handler(request *model.Request) sudnHandler {
 	q := request.Req.Question[0]
 	domain := q.Name
@@ -136,7 +178,7 @@ func (r *SpecialUseDomainNamesResolver) handler(request *model.Request) sudnHand
 }
 
 func newSUDNResponse(response *model.Request, rcode int) *model.Response {
-	return newResponse(response, rcode, model.ResponseTypeSPECIAL, "Special-Use Domain Name")
+	return newResponse(response, rcode, model.ResponseTypeSPECIAL, model.NewReason(model.ReasonCodeSPECIAL))
 }
 
 func sudnNXDomain(request *model.Request, _ *config.SUDN) *model.Response {
@@ -203,4 +203,42 @@ var _ = Describe("SudnResolver", Label("sudnResolver"), func() {
 			Expect(resp).ShouldNot(HaveResponseType(ResponseTypeSPECIAL))
 		})
 	})
+
+	Describe("client groups", func() {
+		BeforeEach(func() {
+			sutConfig.ClientGroups = map[string]config.SUDNRules{
+				"lab":      {RFC6762AppendixG: ptrOf(false)},
+				"10.0.0.2": {RFC6762AppendixG: ptrOf(true)},
+				"off":      {Enable: ptrOf(false)},
+			}
+		})
+
+		It("should use the options of the client's group", func() {
+			resp, err := sut.Resolve(ctx, newRequestWithClient("something.internal.", A, "10.0.0.1", "lab"))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(sut.Resolve(ctx, newRequestWithClient("something.local.", A, "10.0.0.1", "lab"))).
+				Should(HaveResponseType(ResponseTypeSPECIAL))
+		})
+
+		It("should use the global options for other clients", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("something.internal.", A, "10.0.0.3", "other"))).
+				Should(HaveResponseType(ResponseTypeSPECIAL))
+		})
+
+		It("should use the strictest option of several groups", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("something.internal.", A, "10.0.0.2", "lab"))).
+				Should(HaveResponseType(ResponseTypeSPECIAL))
+		})
+
+		It("should forward all queries of disabled groups", func() {
+			Expect(sut.Resolve(ctx, newRequestWithClient("localhost.", A, "10.0.0.1", "off"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+	})
 })
+
+func ptrOf[T any](val T) *T {
+	return &val
+}
@@ -0,0 +1,114 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SwappableResolver is a link of the chain, whose resolver can be replaced at runtime, e.g. after a configuration
+// change. The next resolver of the chain is passed to each new resolver.
+type SwappableResolver struct {
+	current atomic.Pointer[Resolver]
+
+	// serializes the changes of the next resolver and swaps
+	lock sync.Mutex
+	next Resolver
+}
+
+// NewSwappableResolver creates a new link with the given resolver
+func NewSwappableResolver(res Resolver) *SwappableResolver {
+	s := &SwappableResolver{}
+	s.current.Store(&res)
+
+	return s
+}
+
+// Current returns the current resolver
+func (s *SwappableResolver) Current() Resolver {
+	return *s.current.Load()
+}
+
+// Swap replaces the current resolver and returns the previous one
+func (s *SwappableResolver) Swap(res Resolver) Resolver {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if cr, ok := res.(ChainedResolver); ok && s.next != nil {
+		cr.Next(s.next)
+	}
+
+	return *s.current.Swap(&res)
+}
+
+// Next implements `ChainedResolver`.
+func (s *SwappableResolver) Next(n Resolver) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.next = n
+
+	if cr, ok := s.Current().(ChainedResolver); ok {
+		cr.Next(n)
+	}
+}
+
+// GetNext implements `ChainedResolver`.
+func (s *SwappableResolver) GetNext() Resolver {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if t, ok := s.next.(*traced); ok {
+		return t.Resolver
+	}
+
+	return s.next
+}
+
+// Type implements `Resolver`.
+func (s *SwappableResolver) Type() string {
+	return s.Current().Type()
+}
+
+// String implements `fmt.Stringer`.
+func (s *SwappableResolver) String() string {
+	return s.Current().String()
+}
+
+// Name implements `NamedResolver`.
+func (s *SwappableResolver) Name() string {
+	return Name(s.Current())
+}
+
+// IsEnabled implements `config.Configurable`.
+func (s *SwappableResolver) IsEnabled() bool {
+	return s.Current().IsEnabled()
+}
+
+// LogConfig implements `config.Configurable`.
+func (s *SwappableResolver) LogConfig(logger *logrus.Entry) {
+	s.Current().LogConfig(logger)
+}
+
+// Resolve implements `Resolver`.
+func (s *SwappableResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	return s.Current().Resolve(ctx, request)
+}
+
+// Unwrap returns the resolver inside of the links of the chain, which add no own behavior
+func Unwrap(res Resolver) Resolver {
+	for {
+		switch r := res.(type) {
+		case *traced:
+			res = r.Resolver
+		case *SwappableResolver:
+			res = r.Current()
+		default:
+			return res
+		}
+	}
+}
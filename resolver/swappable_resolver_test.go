@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SwappableResolver", func() {
+	var (
+		sut  *SwappableResolver
+		ctx  context.Context
+		next *mockResolver
+	)
+
+	BeforeEach(func() {
+		var cancelFn context.CancelFunc
+
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		next = &mockResolver{}
+		next.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg), RType: ResponseTypeRESOLVED}, nil)
+
+		sut = NewSwappableResolver(NewCustomDNSResolver(config.CustomDNS{
+			Mapping: config.CustomDNSMapping{"first.lan": {&dns.A{A: net.ParseIP("192.168.0.1")}}},
+		}))
+
+		Chain(sut, next)
+	})
+
+	It("should describe the current resolver", func() {
+		Expect(sut.Type()).Should(Equal("custom_dns"))
+		Expect(sut.IsEnabled()).Should(BeTrue())
+		Expect(sut.GetNext()).Should(BeIdenticalTo(next))
+		Expect(Unwrap(sut)).Should(BeAssignableToTypeOf(&CustomDNSResolver{}))
+	})
+
+	It("should pass the next resolver to the new resolver", func() {
+		prev := sut.Swap(NewCustomDNSResolver(config.CustomDNS{
+			Mapping: config.CustomDNSMapping{"second.lan": {&dns.A{A: net.ParseIP("192.168.0.2")}}},
+		}))
+		Expect(prev).Should(BeAssignableToTypeOf(&CustomDNSResolver{}))
+
+		resp, err := sut.Resolve(ctx, newRequest("second.lan.", A))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(SatisfyAll(
+			HaveResponseType(ResponseTypeCUSTOMDNS),
+			WithTransform(ToAnswer, ContainElement(BeDNSRecord("second.lan.", A, "192.168.0.2"))),
+		))
+
+		// not in the new mapping: passed to the next resolver
+		resp, err = sut.Resolve(ctx, newRequest("first.lan.", A))
+		Expect(err).Should(Succeed())
+		Expect(resp).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+		Expect(sut.Current().(ChainedResolver).GetNext()).Should(BeIdenticalTo(next))
+	})
+
+	It("should be found in the chain", func() {
+		found, err := GetFromChainWithType[*CustomDNSResolver](Chain(NewFQDNOnlyResolver(config.FQDNOnly{}), sut))
+		Expect(err).Should(Succeed())
+		Expect(found).Should(BeIdenticalTo(sut.Current()))
+	})
+})
@@ -0,0 +1,20 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/redis"
+	"github.com/miekg/dns"
+)
+
+// SyncBus shares the cache entries and the blocking status with other blocky instances, e.g. via redis or NATS
+type SyncBus interface {
+	// PublishCache sends the cache entry to the other instances
+	PublishCache(key string, message *dns.Msg)
+	// PublishEnabled sends the blocking status to the other instances
+	PublishEnabled(ctx context.Context, state *redis.EnabledMessage)
+	// CacheMessages returns the cache entries of the other instances
+	CacheMessages() <-chan *redis.CacheMessage
+	// EnabledMessages returns the blocking status changes of the other instances
+	EnabledMessages() <-chan *redis.EnabledMessage
+}
@@ -0,0 +1,60 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/sirupsen/logrus"
+)
+
+// TenantResolver assigns the request to the first tenant (sorted by name), whose listeners or clients match.
+// It must be placed after the client names resolver and before all resolvers with client groups.
+type TenantResolver struct {
+	configurable[*config.Tenancy]
+	NextResolver
+	typed
+
+	names []string
+}
+
+// NewTenantResolver creates a new resolver instance
+func NewTenantResolver(cfg config.Tenancy) *TenantResolver {
+	return &TenantResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("tenant"),
+
+		names: cfg.Names(),
+	}
+}
+
+// Resolve sets the tenant of the request and delegates to the next resolver
+func (r *TenantResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if tenant := r.tenantOf(request); tenant != "" {
+		request.Tenant = tenant
+
+		ctx, _ = log.CtxWithFields(ctx, logrus.Fields{"tenant": tenant})
+	}
+
+	return r.next.Resolve(ctx, request)
+}
+
+func (r *TenantResolver) tenantOf(request *model.Request) string {
+	for _, name := range r.names {
+		tenant := r.cfg.Tenants[name]
+
+		if slices.Contains(tenant.Listeners, request.Listener) {
+			return name
+		}
+
+		for _, client := range tenant.Clients {
+			if clientMatchesGroup(client, request) {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,103 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("TenantResolver", func() {
+	var (
+		sut       *TenantResolver
+		sutConfig config.Tenancy
+		m         *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Tenancy{Tenants: map[string]config.Tenant{
+			"smith":  {Clients: []string{"10.1.0.0/16", "smith-*"}},
+			"miller": {Clients: []string{"10.2.0.5", "tag:miller"}},
+			"wilson": {Listeners: []RequestListener{RequestListenerHttps}, Clients: []string{"10.1.0.5"}},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewTenantResolver(sutConfig)
+
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("there are no tenants", func() {
+			BeforeEach(func() {
+				sutConfig = config.Tenancy{}
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	DescribeTable("assigns the request to a tenant",
+		func(request *Request, tenant string) {
+			Expect(sut.Resolve(ctx, request)).Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(request.Tenant).Should(Equal(tenant))
+			m.AssertExpectations(GinkgoT())
+		},
+		Entry("client CIDR", newRequestWithClient("example.com.", A, "10.1.2.3"), "smith"),
+		Entry("client name", newRequestWithClient("example.com.", A, "192.168.0.1", "smith-laptop"), "smith"),
+		Entry("client IP", newRequestWithClient("example.com.", A, "10.2.0.5"), "miller"),
+		Entry("client tag", func() *Request {
+			request := newRequestWithClient("example.com.", A, "192.168.0.1")
+			request.ClientTags = []string{"miller"}
+
+			return request
+		}(), "miller"),
+		Entry("listener", func() *Request {
+			request := newRequestWithClient("example.com.", A, "192.168.0.1")
+			request.Listener = RequestListenerHttps
+
+			return request
+		}(), "wilson"),
+		Entry("first tenant by name", newRequestWithClient("example.com.", A, "10.1.0.5"), "smith"),
+		Entry("no tenant", newRequestWithClient("example.com.", A, "192.168.0.1", "laptop"), ""),
+	)
+})
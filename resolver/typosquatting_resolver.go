@@ -0,0 +1,173 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+//nolint:gochecknoglobals
+var typosquattingQueries = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_typosquatting_queries_total",
+		Help: "Number of queries for domains similar to a protected domain",
+	}, []string{"domain", "action"},
+))
+
+// TyposquattingResolver flags or blocks queries for domains, which are within a small edit distance
+// of a protected domain, but are neither the protected domain nor one of its subdomains
+type TyposquattingResolver struct {
+	configurable[*config.Typosquatting]
+	NextResolver
+	typed
+
+	domains []protectedDomain
+}
+
+type protectedDomain struct {
+	name   string
+	labels []string
+}
+
+// NewTyposquattingResolver creates new resolver instance
+func NewTyposquattingResolver(cfg config.Typosquatting) *TyposquattingResolver {
+	domains := make([]protectedDomain, 0, len(cfg.Domains))
+
+	for _, domain := range cfg.Domains {
+		name := util.ExtractDomainOnly(domain)
+
+		domains = append(domains, protectedDomain{name: name, labels: strings.Split(name, ".")})
+	}
+
+	return &TyposquattingResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("typosquatting"),
+
+		domains: domains,
+	}
+}
+
+// Resolve flags or blocks the request, if the queried domain is similar to a protected domain
+func (r *TyposquattingResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() {
+		return r.next.Resolve(ctx, request)
+	}
+
+	domain := util.ExtractDomain(request.Req.Question[0])
+
+	protected, found := r.similarDomain(domain)
+	if !found {
+		return r.next.Resolve(ctx, request)
+	}
+
+	ctx, logger := r.logWithFields(ctx, logrus.Fields{
+		"domain":    domain,
+		"protected": protected,
+		"action":    r.cfg.Action,
+	})
+
+	logger.Warn("query for domain similar to protected domain")
+
+	typosquattingQueries.WithLabelValues(protected, r.cfg.Action.String()).Inc()
+
+	if r.cfg.Action != config.TyposquattingActionBlock {
+		return r.next.Resolve(ctx, request)
+	}
+
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, dns.RcodeNameError)
+
+	return &model.Response{
+		Res:    response,
+		RType:  model.ResponseTypeBLOCKED,
+		Reason: model.NewReason(model.ReasonCodeTYPOSQUATTING, protected),
+	}, nil
+}
+
+// similarDomain returns the protected domain, which is similar to the domain
+func (r *TyposquattingResolver) similarDomain(domain string) (string, bool) {
+	for _, protected := range r.domains {
+		if domain == protected.name || strings.HasSuffix(domain, "."+protected.name) {
+			return "", false
+		}
+	}
+
+	labels := strings.Split(domain, ".")
+	limit := int(r.cfg.MaxDistance)
+
+	for _, protected := range r.domains {
+		if len(labels) < len(protected.labels) {
+			continue
+		}
+
+		// compare the labels of the protected domain with the last labels of the domain
+		candidate := labels[len(labels)-len(protected.labels):]
+		distance := 0
+
+		for i, label := range protected.labels {
+			distance += editDistance(candidate[i], label, limit-distance)
+			if distance > limit {
+				break
+			}
+		}
+
+		if distance > 0 && distance <= limit {
+			return protected.name, true
+		}
+	}
+
+	return "", false
+}
+
+// editDistance returns the optimal string alignment distance (Levenshtein distance with transpositions)
+// of a and b or limit+1, if the distance is greater than limit
+func editDistance(a, b string, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			distance := min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				distance = min(distance, prev2[j-2]+1)
+			}
+
+			cur[j] = distance
+			rowMin = min(rowMin, distance)
+		}
+
+		// the distance can't get smaller in the following rows
+		if rowMin > limit {
+			return limit + 1
+		}
+
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return min(prev[len(b)], limit+1)
+}
@@ -0,0 +1,150 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("TyposquattingResolver", func() {
+	var (
+		sut        *TyposquattingResolver
+		sutConfig  config.Typosquatting
+		m          *mockResolver
+		mockAnswer *dns.Msg
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.Typosquatting{
+			Domains:     []string{"mybank.com", "Employer.de."},
+			MaxDistance: 1,
+			Action:      config.TyposquattingActionBlock,
+		}
+
+		mockAnswer, _ = util.NewMsgWithAnswer("example.com.", 300, A, "192.0.2.1")
+	})
+
+	JustBeforeEach(func() {
+		sut = NewTyposquattingResolver(sutConfig)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: mockAnswer, RType: ResponseTypeRESOLVED}, nil)
+		sut.Next(m)
+	})
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("no domains are configured", func() {
+			BeforeEach(func() {
+				sutConfig.Domains = nil
+			})
+
+			It("is false", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+			})
+
+			It("should delegate to the next resolver", func() {
+				Expect(sut.Resolve(ctx, newRequest("mybnak.com.", A))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log something", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Calls).ShouldNot(BeEmpty())
+		})
+	})
+
+	DescribeTable("should block similar domains",
+		func(domain, protected string) {
+			Expect(sut.Resolve(ctx, newRequest(domain, A))).
+				Should(SatisfyAll(
+					HaveNoAnswer(),
+					HaveResponseType(ResponseTypeBLOCKED),
+					HaveReturnCode(dns.RcodeNameError),
+					HaveReason("TYPOSQUATTING ("+protected+")"),
+				))
+			Expect(m.Calls).Should(BeEmpty())
+		},
+		Entry("substitution", "mybenk.com.", "mybank.com"),
+		Entry("insertion", "my-bank.com.", "mybank.com"),
+		Entry("deletion", "mybak.com.", "mybank.com"),
+		Entry("transposition", "mybnak.com.", "mybank.com"),
+		Entry("other TLD", "mybank.co.", "mybank.com"),
+		Entry("subdomain", "login.mybnak.com.", "mybank.com"),
+		Entry("upper case", "EMPLOYR.de.", "employer.de"),
+	)
+
+	DescribeTable("should resolve other domains",
+		func(domain string) {
+			Expect(sut.Resolve(ctx, newRequest(domain, A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		},
+		Entry("protected domain", "mybank.com."),
+		Entry("subdomain of protected domain", "www.mybank.com."),
+		Entry("distance too big", "yourbank.com."),
+		Entry("two edits", "mybnak.co."),
+		Entry("unrelated", "example.com."),
+		Entry("single label", "mybank."),
+	)
+
+	When("the max distance is 2", func() {
+		BeforeEach(func() {
+			sutConfig.MaxDistance = 2
+		})
+
+		It("should block domains with two edits", func() {
+			Expect(sut.Resolve(ctx, newRequest("mybnak.co.", A))).
+				Should(HaveResponseType(ResponseTypeBLOCKED))
+		})
+	})
+
+	When("the action is flag", func() {
+		BeforeEach(func() {
+			sutConfig.Action = config.TyposquattingActionFlag
+		})
+
+		It("should resolve similar domains", func() {
+			Expect(sut.Resolve(ctx, newRequest("mybnak.com.", A))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+	})
+
+	Describe("editDistance", func() {
+		It("should compute the distance up to the limit", func() {
+			Expect(editDistance("kitten", "sitting", 5)).Should(Equal(3))
+			Expect(editDistance("kitten", "sitting", 2)).Should(Equal(3))
+			Expect(editDistance("ab", "ba", 1)).Should(Equal(1))
+			Expect(editDistance("same", "same", 0)).Should(Equal(0))
+			Expect(editDistance("", "abc", 1)).Should(Equal(2))
+		})
+	})
+})
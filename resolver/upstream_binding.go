@@ -0,0 +1,43 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/0xERR0R/blocky/config"
+)
+
+var errBindToDeviceUnsupported = errors.New("binding to an interface is only supported on Linux")
+
+// newUpstreamDialer returns a dialer, whose sockets are bound to the source IP and interface of the binding.
+// The local address has to match the network, so there is a dialer per network.
+func newUpstreamDialer(binding config.UpstreamBinding, network string) *net.Dialer {
+	dialer := new(net.Dialer)
+
+	if binding.SourceIP != nil {
+		switch network {
+		case "udp":
+			dialer.LocalAddr = &net.UDPAddr{IP: binding.SourceIP}
+		default:
+			dialer.LocalAddr = &net.TCPAddr{IP: binding.SourceIP}
+		}
+	}
+
+	if binding.Interface != "" {
+		dialer.Control = func(_, _ string, c syscall.RawConn) error {
+			return bindToDevice(c, binding.Interface)
+		}
+	}
+
+	return dialer
+}
+
+// validateUpstreamBinding returns an error, if the binding can't be used on this system
+func validateUpstreamBinding(binding config.UpstreamBinding) error {
+	if binding.Interface != "" && !bindToDeviceSupported {
+		return errBindToDeviceUnsupported
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package resolver
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const bindToDeviceSupported = true
+
+// bindToDevice binds the socket to the network interface (SO_BINDTODEVICE), before Linux 5.7 this requires CAP_NET_RAW
+func bindToDevice(c syscall.RawConn, device string) error {
+	var bindErr error
+
+	err := c.Control(func(fd uintptr) {
+		bindErr = syscall.BindToDevice(int(fd), device)
+	})
+	if err != nil {
+		return err
+	}
+
+	if bindErr != nil {
+		return fmt.Errorf("can't bind to interface %s: %w", device, bindErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package resolver
+
+import "syscall"
+
+const bindToDeviceSupported = false
+
+func bindToDevice(_ syscall.RawConn, _ string) error {
+	return errBindToDeviceUnsupported
+}
@@ -0,0 +1,114 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upstream binding", func() {
+	var (
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+	})
+
+	Describe("newUpstreamDialer", func() {
+		It("should use the source IP as local address of the network", func() {
+			binding := config.UpstreamBinding{SourceIP: net.ParseIP("127.0.0.1")}
+
+			Expect(newUpstreamDialer(binding, "udp").LocalAddr).Should(Equal(&net.UDPAddr{IP: binding.SourceIP}))
+			Expect(newUpstreamDialer(binding, "tcp").LocalAddr).Should(Equal(&net.TCPAddr{IP: binding.SourceIP}))
+			Expect(newUpstreamDialer(binding, "tcp").Control).Should(BeNil())
+		})
+
+		It("should bind to the interface", func() {
+			dialer := newUpstreamDialer(config.UpstreamBinding{Interface: "lo"}, "udp")
+
+			Expect(dialer.LocalAddr).Should(BeNil())
+			Expect(dialer.Control).ShouldNot(BeNil())
+		})
+	})
+
+	Describe("validateUpstreamBinding", func() {
+		It("should accept a source IP", func() {
+			Expect(validateUpstreamBinding(config.UpstreamBinding{SourceIP: net.ParseIP("127.0.0.1")})).Should(Succeed())
+		})
+
+		It("should accept an interface, if supported", func() {
+			err := validateUpstreamBinding(config.UpstreamBinding{Interface: "lo"})
+
+			if bindToDeviceSupported {
+				Expect(err).Should(Succeed())
+			} else {
+				Expect(err).Should(MatchError(errBindToDeviceUnsupported))
+			}
+		})
+	})
+
+	Describe("Resolving", func() {
+		var sutConfig upstreamConfig
+
+		BeforeEach(func() {
+			mockUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+
+			sutConfig = newUpstreamConfig(mockUpstream.Start(), defaultUpstreamsConfig)
+		})
+
+		When("the source IP is local", func() {
+			It("should resolve", func() {
+				sutConfig.binding = config.UpstreamBinding{SourceIP: net.ParseIP("127.0.0.1")}
+
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+			})
+		})
+
+		When("the source IP is not assigned to this host", func() {
+			It("should fail", func() {
+				sutConfig.binding = config.UpstreamBinding{SourceIP: net.ParseIP("192.0.2.1")}
+
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		When("the interface is bound", func() {
+			BeforeEach(func() {
+				if !bindToDeviceSupported {
+					Skip("binding to an interface is not supported")
+				}
+			})
+
+			It("should resolve via the loopback interface", func() {
+				sutConfig.binding = config.UpstreamBinding{Interface: "lo"}
+
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				Expect(sut.Resolve(ctx, newRequest("example.com.", A))).
+					Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+			})
+
+			It("should fail for an unknown interface", func() {
+				sutConfig.binding = config.UpstreamBinding{Interface: "blocky-none0"}
+
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
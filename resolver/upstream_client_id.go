@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+)
+
+// neighborTableRefresh is the maximum age of the neighbor table used for the MAC addresses of the clients
+const neighborTableRefresh = 30 * time.Second
+
+// neighborTablePath is the ARP table of the Linux kernel, a variable for the tests
+var neighborTablePath = "/proc/net/arp"
+
+// upstreamClientID attaches the identifier of the querying client as EDNS0 local option to the queries of an upstream
+type upstreamClientID struct {
+	code  uint16
+	value config.ClientIdentifierValue
+
+	// only used for MAC addresses
+	neighbors *neighborTable
+}
+
+func newUpstreamClientID(cfg config.UpstreamClientIdentifier) *upstreamClientID {
+	c := &upstreamClientID{
+		code:  cfg.Code,
+		value: cfg.Value,
+	}
+
+	if cfg.Value == config.ClientIdentifierValueMac {
+		c.neighbors = &neighborTable{path: neighborTablePath}
+	}
+
+	return c
+}
+
+// withClientID returns a copy of the query with the identifier of the client,
+// the query itself if the identifier is unknown
+func (c *upstreamClientID) withClientID(query *dns.Msg, request *model.Request) *dns.Msg {
+	data := c.identifier(request)
+	if len(data) == 0 {
+		return query
+	}
+
+	result := query.Copy()
+
+	util.SetEdns0Option(result, &dns.EDNS0_LOCAL{Code: c.code, Data: data})
+
+	return result
+}
+
+// clientIdentity returns the properties of the client, which are sent as identifier to one of the upstreams, or an
+// empty string without client identifiers. The MAC address is determined by the IP.
+func clientIdentity(identifiers map[string]config.UpstreamClientIdentifier, request *model.Request) string {
+	var name, ip bool
+
+	for _, identifier := range identifiers {
+		switch identifier.Value {
+		case config.ClientIdentifierValueName:
+			name = true
+		case config.ClientIdentifierValueIp, config.ClientIdentifierValueMac:
+			ip = true
+		}
+	}
+
+	var sb strings.Builder
+
+	if name && len(request.ClientNames) > 0 {
+		fmt.Fprintf(&sb, "name=%s|", request.ClientNames[0])
+	}
+
+	if ip {
+		fmt.Fprintf(&sb, "ip=%s|", request.ClientIP)
+	}
+
+	return sb.String()
+}
+
+func (c *upstreamClientID) identifier(request *model.Request) []byte {
+	switch c.value {
+	case config.ClientIdentifierValueName:
+		if len(request.ClientNames) > 0 {
+			return []byte(request.ClientNames[0])
+		}
+
+	case config.ClientIdentifierValueIp:
+		if ip := request.ClientIP.To4(); ip != nil {
+			return ip
+		}
+
+		return request.ClientIP.To16()
+
+	case config.ClientIdentifierValueMac:
+		if request.ClientIP != nil {
+			return c.neighbors.lookup(request.ClientIP)
+		}
+	}
+
+	return nil
+}
+
+// neighborTable is a cached copy of the ARP table with the MAC address per IP
+type neighborTable struct {
+	path string
+
+	lock    sync.Mutex
+	macs    map[string]net.HardwareAddr
+	updated time.Time
+}
+
+func (t *neighborTable) lookup(ip net.IP) net.HardwareAddr {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if time.Since(t.updated) > neighborTableRefresh {
+		t.macs = readNeighborTable(t.path)
+		t.updated = time.Now()
+	}
+
+	return t.macs[ip.String()]
+}
+
+// readNeighborTable reads the complete entries of the ARP table, an unreadable table has no entries
+func readNeighborTable(path string) map[string]net.HardwareAddr {
+	result := make(map[string]net.HardwareAddr)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return result
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	// header: IP address, HW type, Flags, HW address, Mask, Device
+	scanner.Scan()
+
+	const (
+		colIP    = 0
+		colFlags = 2
+		colMAC   = 3
+		// ATF_COM: the entry is complete
+		flagComplete = 0x2
+	)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) <= colMAC {
+			continue
+		}
+
+		flags, err := strconv.ParseInt(fields[colFlags], 0, 0)
+		if err != nil || flags&flagComplete == 0 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[colIP])
+
+		mac, err := net.ParseMAC(fields[colMAC])
+		if ip == nil || err != nil {
+			continue
+		}
+
+		result[ip.String()] = mac
+	}
+
+	return result
+}
@@ -0,0 +1,125 @@
+package resolver
+
+import (
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// localOption returns the data of the EDNS0 local option with the code, nil if the option is missing
+func localOption(msg *dns.Msg, code uint16) []byte {
+	if opt := msg.IsEdns0(); opt != nil {
+		for _, o := range opt.Option {
+			if local, ok := o.(*dns.EDNS0_LOCAL); ok && local.Code == code {
+				return local.Data
+			}
+		}
+	}
+
+	return nil
+}
+
+var _ = Describe("upstreamClientID", func() {
+	const code = 65001
+
+	var (
+		sut    *upstreamClientID
+		value  config.ClientIdentifierValue
+		tmpDir *TmpFolder
+	)
+
+	BeforeEach(func() {
+		value = config.ClientIdentifierValueName
+		tmpDir = NewTmpFolder("neighbors")
+
+		neighborTablePath = tmpDir.CreateStringFile("arp",
+			"IP address       HW type     Flags       HW address            Mask     Device",
+			"192.168.178.2    0x1         0x2         aa:bb:cc:dd:ee:01     *        eth0",
+			"192.168.178.3    0x1         0x0         00:00:00:00:00:00     *        eth0",
+			"192.168.178.4    0x1         0x6         aa:bb:cc:dd:ee:04     *        eth0",
+		).Path
+		DeferCleanup(func() { neighborTablePath = "/proc/net/arp" })
+	})
+
+	JustBeforeEach(func() {
+		sut = newUpstreamClientID(config.UpstreamClientIdentifier{Code: code, Value: value})
+	})
+
+	sentID := func(clientIP string, clientNames ...string) []byte {
+		request := newRequestWithClient("example.com.", A, clientIP, clientNames...)
+
+		return localOption(sut.withClientID(request.Req, request), code)
+	}
+
+	When("the name is sent", func() {
+		It("should send the first client name", func() {
+			Expect(sentID("192.168.178.2", "laptop", "laptop.fritz.box")).Should(Equal([]byte("laptop")))
+		})
+
+		It("should not modify the query", func() {
+			request := newRequestWithClient("example.com.", A, "192.168.178.2", "laptop")
+
+			query := sut.withClientID(request.Req, request)
+
+			Expect(query).ShouldNot(BeIdenticalTo(request.Req))
+			Expect(request.Req.IsEdns0()).Should(BeNil())
+		})
+
+		It("should keep other options of the query", func() {
+			request := newRequestWithClient("example.com.", A, "192.168.178.2", "laptop")
+			util.SetEdns0Option(request.Req, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"})
+
+			query := sut.withClientID(request.Req, request)
+
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](query)).ShouldNot(BeNil())
+			Expect(localOption(query, code)).Should(Equal([]byte("laptop")))
+		})
+
+		It("should send nothing for a client without name", func() {
+			request := newRequestWithClient("example.com.", A, "192.168.178.2")
+
+			Expect(sut.withClientID(request.Req, request)).Should(BeIdenticalTo(request.Req))
+		})
+	})
+
+	When("the IP is sent", func() {
+		BeforeEach(func() {
+			value = config.ClientIdentifierValueIp
+		})
+
+		It("should send the IPv4 address with 4 bytes", func() {
+			Expect(sentID("192.168.178.2")).Should(Equal([]byte{192, 168, 178, 2}))
+		})
+
+		It("should send the IPv6 address with 16 bytes", func() {
+			Expect(sentID("fd00::1")).Should(Equal([]byte(net.ParseIP("fd00::1"))))
+		})
+	})
+
+	When("the MAC is sent", func() {
+		BeforeEach(func() {
+			value = config.ClientIdentifierValueMac
+		})
+
+		It("should send the MAC address of the neighbor table", func() {
+			Expect(sentID("192.168.178.2")).Should(Equal([]byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x01}))
+			Expect(sentID("192.168.178.4")).Should(Equal([]byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x04}))
+		})
+
+		It("should send nothing for incomplete and unknown entries", func() {
+			Expect(sentID("192.168.178.3")).Should(BeNil())
+			Expect(sentID("192.168.178.9")).Should(BeNil())
+		})
+
+		It("should send nothing without neighbor table", func() {
+			sut.neighbors.path = tmpDir.JoinPath("missing")
+
+			Expect(sentID("192.168.178.2")).Should(BeNil())
+		})
+	})
+})
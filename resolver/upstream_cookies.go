@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/miekg/dns"
+)
+
+// clientCookieLen is the length of a client cookie in bytes (RFC 7873)
+const clientCookieLen = 8
+
+// errBadCookie is returned, if the upstream answered with BADCOOKIE. The query is retried with the new server cookie.
+var errBadCookie = errors.New("upstream rejected the server cookie")
+
+// upstreamCookies are the DNS cookies (RFC 7873) sent to an upstream: a random client cookie
+// and the last server cookie received from each IP of the upstream
+type upstreamCookies struct {
+	// hex encoded, like the cookies of `dns.EDNS0_COOKIE`
+	client string
+
+	lock   sync.Mutex
+	server map[string]string
+}
+
+func newUpstreamCookies() *upstreamCookies {
+	client := make([]byte, clientCookieLen)
+	_, _ = rand.Read(client)
+
+	return &upstreamCookies{
+		client: hex.EncodeToString(client),
+		server: make(map[string]string),
+	}
+}
+
+// withCookie returns a copy of the query with the cookie for the upstream IP, replacing the cookie of the client
+func (c *upstreamCookies) withCookie(query *dns.Msg, ip net.IP) *dns.Msg {
+	c.lock.Lock()
+	cookie := c.client + c.server[ip.String()]
+	c.lock.Unlock()
+
+	result := query.Copy()
+
+	util.SetEdns0Option(result, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+
+	return result
+}
+
+// takeCookie stores the server cookie of the response and removes the cookie from the response,
+// so it isn't passed to the clients
+func (c *upstreamCookies) takeCookie(response *dns.Msg, ip net.IP) {
+	opt := util.GetEdns0Option[*dns.EDNS0_COOKIE](response)
+	if opt == nil {
+		return
+	}
+
+	util.RemoveEdns0Option[*dns.EDNS0_COOKIE](response)
+
+	// a cookie not starting with our client cookie wasn't sent in response to our query
+	if len(opt.Cookie) <= len(c.client) || !strings.EqualFold(opt.Cookie[:len(c.client)], c.client) {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.server[ip.String()] = opt.Cookie[len(c.client):]
+}
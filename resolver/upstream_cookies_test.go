@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"net"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("upstreamCookies", func() {
+	var (
+		sut *upstreamCookies
+		ip  net.IP
+	)
+
+	BeforeEach(func() {
+		sut = newUpstreamCookies()
+		ip = net.ParseIP("192.0.2.1")
+	})
+
+	response := func(cookie string) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetEdns0(1232, false)
+		util.SetEdns0Option(msg, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+
+		return msg
+	}
+
+	sentCookie := func() string {
+		return util.GetEdns0Option[*dns.EDNS0_COOKIE](sut.withCookie(util.NewMsgWithQuestion("example.com.", A), ip)).Cookie
+	}
+
+	It("should create random client cookies", func() {
+		Expect(sut.client).Should(HaveLen(2 * clientCookieLen))
+		Expect(sut.client).ShouldNot(Equal(newUpstreamCookies().client))
+	})
+
+	It("should replace the cookie of the client", func() {
+		query := util.NewMsgWithQuestion("example.com.", A)
+		util.SetEdns0Option(query, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"})
+
+		Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](sut.withCookie(query, ip)).Cookie).Should(Equal(sut.client))
+		Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](query).Cookie).Should(Equal("0102030405060708"))
+	})
+
+	It("should store the server cookie per IP", func() {
+		sut.takeCookie(response(sut.client+"a1a2a3a4a5a6a7a8"), ip)
+
+		Expect(sentCookie()).Should(Equal(sut.client + "a1a2a3a4a5a6a7a8"))
+
+		ip = net.ParseIP("192.0.2.2")
+		Expect(sentCookie()).Should(Equal(sut.client))
+	})
+
+	It("should ignore server cookies for other client cookies", func() {
+		msg := response("0102030405060708a1a2a3a4a5a6a7a8")
+
+		sut.takeCookie(msg, ip)
+
+		Expect(sentCookie()).Should(Equal(sut.client))
+		Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](msg)).Should(BeNil())
+	})
+})
@@ -0,0 +1,159 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+
+	"github.com/miekg/dns"
+)
+
+// ddrResolverName is the special-use name to query the designated resolvers of a resolver (RFC 9462)
+const ddrResolverName = "_dns.resolver.arpa."
+
+// ddrKnownKeys are the SvcParamKeys, which are understood. Records with other mandatory keys are ignored.
+//
+//nolint:gochecknoglobals
+var ddrKnownKeys = []dns.SVCBKey{
+	dns.SVCB_ALPN, dns.SVCB_NO_DEFAULT_ALPN, dns.SVCB_PORT, dns.SVCB_IPV4HINT, dns.SVCB_IPV6HINT, dns.SVCB_DOHPATH,
+}
+
+// discoverDDR queries the SVCB records of the designated resolvers from the unencrypted resolver and returns the
+// encrypted endpoints, whose certificates are valid for the IP of the unencrypted resolver (verified discovery)
+func (d *upstreamDiscovery) discoverDDR(ctx context.Context) ([]config.Upstream, error) {
+	resolver := newUpstreamResolverUnchecked(newUpstreamConfig(d.cfg.DDR, d.upstreams), d.bootstrap)
+
+	response, err := resolver.Resolve(ctx, newRequest(ddrResolverName, dns.Type(dns.TypeSVCB)))
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*dns.SVCB
+
+	for _, rr := range response.Res.Answer {
+		// the alias mode (priority 0) isn't defined for DDR
+		if svcb, ok := rr.(*dns.SVCB); ok && svcb.Priority != 0 {
+			records = append(records, svcb)
+		}
+	}
+
+	slices.SortStableFunc(records, func(a, b *dns.SVCB) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	var members []config.Upstream
+
+	for _, record := range records {
+		for _, upstream := range ddrUpstreams(record) {
+			if err := d.verifyDesignatedResolver(ctx, upstream); err != nil {
+				d.logger().Warnf("ignoring designated resolver %s: %v", upstream, err)
+
+				continue
+			}
+
+			members = append(members, upstream)
+		}
+	}
+
+	return members, nil
+}
+
+// ddrUpstreams returns the DoH and DoT upstreams of a SVCB record (RFC 9461), other protocols are ignored
+func ddrUpstreams(record *dns.SVCB) []config.Upstream {
+	target := strings.TrimSuffix(record.Target, ".")
+	if target == "" {
+		// the owner name `_dns.resolver.arpa` can't be a resolver
+		return nil
+	}
+
+	var (
+		alpn    []string
+		port    uint16
+		dohPath string
+	)
+
+	for _, value := range record.Value {
+		switch v := value.(type) {
+		case *dns.SVCBMandatory:
+			for _, key := range v.Code {
+				if !slices.Contains(ddrKnownKeys, key) {
+					return nil
+				}
+			}
+		case *dns.SVCBAlpn:
+			alpn = v.Alpn
+		case *dns.SVCBPort:
+			port = v.Port
+		case *dns.SVCBDoHPath:
+			dohPath = v.Template
+		}
+	}
+
+	var upstreams []config.Upstream
+
+	addUpstream := func(upstream config.Upstream) {
+		if port != 0 {
+			upstream.Port = port
+		}
+
+		if !slices.Contains(upstreams, upstream) {
+			upstreams = append(upstreams, upstream)
+		}
+	}
+
+	for _, protocol := range alpn {
+		switch protocol {
+		case "h2", "h3":
+			// DoH requires the path of the URI template
+			if dohPath != "" {
+				addUpstream(config.Upstream{Net: config.NetProtocolHttps, Host: target, Port: 443, Path: dohPath})
+			}
+		case "dot":
+			addUpstream(config.Upstream{Net: config.NetProtocolTcpTls, Host: target, Port: 853})
+		}
+	}
+
+	return upstreams
+}
+
+// verifyDesignatedResolver connects to the designated resolver and checks, that its certificate is valid for the name
+// of the resolver and contains the IP of the unencrypted resolver
+func (d *upstreamDiscovery) verifyDesignatedResolver(ctx context.Context, upstream config.Upstream) error {
+	ctx, cancel := context.WithTimeout(ctx, d.upstreams.Timeout.ToDuration())
+	defer cancel()
+
+	conn, err := d.bootstrap.dialContext(ctx, "tcp", net.JoinHostPort(upstream.Host, strconv.Itoa(int(upstream.Port))))
+	if err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName: upstream.Host,
+		RootCAs:    d.rootCAs,
+		MinVersion: tls.VersionTLS12,
+	})
+	defer tlsConn.Close()
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+
+	certificates := tlsConn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return errors.New("no certificate")
+	}
+
+	if err := certificates[0].VerifyHostname(d.cfg.DDR.Host); err != nil {
+		return fmt.Errorf("certificate isn't valid for the unencrypted resolver: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// upstreamDiscovery resolves the members of an upstream group periodically from DNS, e.g. the pods of a Kubernetes
+// headless Service, and replaces the resolvers of the group if the members changed
+type upstreamDiscovery struct {
+	cfg       config.UpstreamDiscovery
+	group     string
+	upstreams config.Upstreams
+	bootstrap *Bootstrap
+
+	// roots to verify the certificates of designated resolvers, nil uses the system roots
+	rootCAs *x509.CertPool
+
+	// the static upstreams of the group are used, if no member is found
+	static  []config.Upstream
+	members []config.Upstream
+}
+
+func newUpstreamDiscovery(group string, cfg config.Upstreams, bootstrap *Bootstrap) *upstreamDiscovery {
+	return &upstreamDiscovery{
+		cfg:       cfg.Discovery[group],
+		group:     group,
+		upstreams: cfg,
+		bootstrap: bootstrap,
+
+		// the instances of a service registry can't be used as fallback without its watch
+		static: slices.DeleteFunc(slices.Clone(cfg.Groups[group]), func(u config.Upstream) bool { return u.IsRegistry() }),
+	}
+}
+
+func (d *upstreamDiscovery) logger() *logrus.Entry {
+	return log.PrefixedLog("upstream_discovery").WithField("group", d.group)
+}
+
+// initialUpstreams discovers the members for the creation of the group resolver
+func (d *upstreamDiscovery) initialUpstreams(ctx context.Context) []config.Upstream {
+	members, err := d.discover(ctx)
+	if err != nil {
+		d.logger().Warnf("can't discover upstreams, using the static upstreams: %v", err)
+
+		return d.static
+	}
+
+	d.members = members
+
+	d.logger().Infof("discovered upstreams: %s", upstreamsToString(members))
+
+	return members
+}
+
+// start refreshes the members periodically until the context is done
+func (d *upstreamDiscovery) start(ctx context.Context, target initializable) {
+	go func() {
+		ticker := time.NewTicker(d.cfg.Interval.ToDuration())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.refresh(ctx, target)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// refresh replaces the resolvers of the group, if the members changed. The current resolvers are kept on errors.
+func (d *upstreamDiscovery) refresh(ctx context.Context, target initializable) {
+	members, err := d.discover(ctx)
+	if err != nil {
+		d.logger().Warnf("can't discover upstreams, keeping the current upstreams: %v", err)
+
+		return
+	}
+
+	if slices.Equal(members, d.members) {
+		return
+	}
+
+	resolvers, err := createGroupResolvers(ctx, config.NewUpstreamGroup(d.group, d.upstreams, members), d.bootstrap)
+	if err != nil {
+		d.logger().Warnf("can't create resolvers for the discovered upstreams: %v", err)
+
+		return
+	}
+
+	target.setResolvers(resolvers)
+	d.members = members
+
+	d.logger().Infof("upstreams changed: %s", upstreamsToString(members))
+}
+
+// discover returns the sorted members of the name, the static upstreams are returned if no member is found
+func (d *upstreamDiscovery) discover(ctx context.Context) ([]config.Upstream, error) {
+	var (
+		members []config.Upstream
+		err     error
+	)
+
+	switch {
+	case d.cfg.IsDDR():
+		members, err = d.discoverDDR(ctx)
+	case d.cfg.SRV:
+		members, err = d.discoverSRV(ctx)
+	default:
+		members, err = d.discoverIPs(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		if len(d.static) == 0 {
+			return nil, fmt.Errorf("no upstreams found for %s", d.cfg.Name)
+		}
+
+		return d.static, nil
+	}
+
+	return slices.Compact(members), nil
+}
+
+func (d *upstreamDiscovery) discoverIPs(ctx context.Context) ([]config.Upstream, error) {
+	ips, err := d.bootstrap.lookupIPs(ctx, d.cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]config.Upstream, 0, len(ips))
+
+	for _, ip := range ips {
+		members = append(members, config.Upstream{Net: config.NetProtocolTcpUdp, Host: ip.String(), Port: d.cfg.Port})
+	}
+
+	slices.SortFunc(members, func(a, b config.Upstream) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return members, nil
+}
+
+func (d *upstreamDiscovery) discoverSRV(ctx context.Context) ([]config.Upstream, error) {
+	srvs, err := d.bootstrap.lookupSRV(ctx, d.cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the order of RFC 2782: the lowest priority first and within a priority the highest weight first,
+	// which is kept by the strict strategy
+	slices.SortStableFunc(srvs, func(a, b *net.SRV) int {
+		if a.Priority != b.Priority {
+			return cmp.Compare(a.Priority, b.Priority)
+		}
+
+		if a.Weight != b.Weight {
+			return cmp.Compare(b.Weight, a.Weight)
+		}
+
+		return strings.Compare(a.Target, b.Target)
+	})
+
+	members := make([]config.Upstream, 0, len(srvs))
+
+	for _, srv := range srvs {
+		if srv.Target == "." {
+			// the service is decidedly not available at the name
+			continue
+		}
+
+		members = append(members, config.Upstream{
+			Net:  config.NetProtocolTcpUdp,
+			Host: strings.TrimSuffix(srv.Target, "."),
+			Port: srv.Port,
+		})
+	}
+
+	return members, nil
+}
+
+func upstreamsToString(upstreams []config.Upstream) string {
+	result := make([]string, 0, len(upstreams))
+
+	for _, upstream := range upstreams {
+		result = append(result, upstream.String())
+	}
+
+	return strings.Join(result, ", ")
+}
@@ -0,0 +1,289 @@
+package resolver
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("upstreamDiscovery", func() {
+	var (
+		sut          *upstreamDiscovery
+		upstreamsCfg config.Upstreams
+		m            *mockResolver
+
+		members atomic.Pointer[[]string]
+		failing atomic.Bool
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		upstreamsCfg = defaultUpstreamsConfig
+		upstreamsCfg.Init.Strategy = config.InitStrategyFast
+		upstreamsCfg.Groups = config.UpstreamGroups{"cluster": {{Net: config.NetProtocolTcpUdp, Host: "9.9.9.9", Port: 53}}}
+		upstreamsCfg.Discovery = map[string]config.UpstreamDiscovery{
+			"cluster": {Name: "dns.infra.svc.cluster.local", Port: 5353, Interval: config.Duration(timeout)},
+		}
+
+		members.Store(&[]string{"10.0.0.2", "10.0.0.1"})
+		failing.Store(false)
+	})
+
+	JustBeforeEach(func() {
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything)
+		m.ResolveFn = func(_ context.Context, req *Request) (*Response, error) {
+			if failing.Load() {
+				return nil, errors.New("timeout")
+			}
+
+			question := req.Req.Question[0]
+			response := new(dns.Msg)
+			response.SetReply(req.Req)
+
+			for _, member := range *members.Load() {
+				switch question.Qtype {
+				case dns.TypeA:
+					rr, err := util.CreateAnswerFromQuestion(question, net.ParseIP(member), 30)
+					Expect(err).Should(Succeed())
+
+					response.Answer = append(response.Answer, rr)
+				case dns.TypeSRV:
+					data := "0 0 53 " + member + "."
+					if strings.Contains(member, " ") {
+						// priority, weight, port and target
+						data = member
+					}
+
+					rr, err := dns.NewRR(question.Name + " 30 IN SRV " + data)
+					Expect(err).Should(Succeed())
+
+					response.Answer = append(response.Answer, rr)
+				}
+			}
+
+			return &Response{Res: response}, nil
+		}
+
+		bootstrap := &Bootstrap{
+			configurable: withConfig(newBootstrapConfig(&config.Config{Upstreams: upstreamsCfg})),
+			typed:        withType("bootstrap"),
+			resolver:     m,
+			dialer:       new(net.Dialer),
+		}
+
+		sut = newUpstreamDiscovery("cluster", upstreamsCfg, bootstrap)
+	})
+
+	Describe("initialUpstreams", func() {
+		It("should return the sorted members of the name", func() {
+			Expect(upstreamsToString(sut.initialUpstreams(ctx))).
+				Should(Equal("tcp+udp:10.0.0.1:5353, tcp+udp:10.0.0.2:5353"))
+		})
+
+		When("the discovery fails", func() {
+			BeforeEach(func() {
+				failing.Store(true)
+			})
+
+			It("should return the static upstreams", func() {
+				Expect(upstreamsToString(sut.initialUpstreams(ctx))).Should(Equal("tcp+udp:9.9.9.9"))
+			})
+		})
+
+		When("SRV records are used", func() {
+			BeforeEach(func() {
+				discovery := upstreamsCfg.Discovery["cluster"]
+				discovery.SRV = true
+				upstreamsCfg.Discovery["cluster"] = discovery
+
+				members.Store(&[]string{"pod-1.dns.infra.svc.cluster.local"})
+			})
+
+			It("should use the targets and ports of the records", func() {
+				Expect(upstreamsToString(sut.initialUpstreams(ctx))).
+					Should(Equal("tcp+udp:pod-1.dns.infra.svc.cluster.local"))
+
+				Expect(m.Calls[0].Arguments.Get(0).(*Request).Req.Question[0].Qtype).Should(Equal(dns.TypeSRV))
+			})
+
+			It("should order the targets by priority and weight", func() {
+				members.Store(&[]string{"20 0 53 backup.", "10 5 53 light.", "10 50 5353 heavy.", "30 0 0 ."})
+
+				Expect(upstreamsToString(sut.initialUpstreams(ctx))).
+					Should(Equal("tcp+udp:heavy:5353, tcp+udp:light, tcp+udp:backup"))
+			})
+		})
+
+		When("designated resolvers are discovered", func() {
+			var (
+				tlsServer *httptest.Server
+				ddrServer *MockUDPUpstreamServer
+				// read by the mock server, the records are changed by the tests
+				records atomic.Pointer[[]string]
+			)
+
+			BeforeEach(func() {
+				tlsServer = httptest.NewTLSServer(http.NotFoundHandler())
+				DeferCleanup(tlsServer.Close)
+
+				_, tlsPort, err := net.SplitHostPort(tlsServer.Listener.Addr().String())
+				Expect(err).Should(Succeed())
+
+				// the certificate of the test server is valid for example.com and 127.0.0.1
+				members.Store(&[]string{"127.0.0.1"})
+
+				records.Store(&[]string{
+					`_dns.resolver.arpa. 300 IN SVCB 2 example.com. alpn=dot port=` + tlsPort,
+					`_dns.resolver.arpa. 300 IN SVCB 1 example.com. alpn=h2,h3 port=` + tlsPort +
+						` dohpath=/dns-query{?dns}`,
+					`_dns.resolver.arpa. 300 IN SVCB 3 wrong-name.test. alpn=dot port=` + tlsPort,
+				})
+
+				ddrServer = NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+					Expect(request.Question[0].Name).Should(Equal("_dns.resolver.arpa."))
+					Expect(request.Question[0].Qtype).Should(Equal(dns.TypeSVCB))
+
+					response := new(dns.Msg)
+
+					for _, record := range *records.Load() {
+						rr, err := dns.NewRR(record)
+						Expect(err).Should(Succeed())
+
+						response.Answer = append(response.Answer, rr)
+					}
+
+					return response
+				})
+
+				// the certificate has to contain the IP of the unencrypted resolver
+				discovery := upstreamsCfg.Discovery["cluster"]
+				discovery.DDR = ddrServer.Start()
+				discovery.DDR.Host = "127.0.0.1"
+				upstreamsCfg.Discovery["cluster"] = discovery
+			})
+
+			JustBeforeEach(func() {
+				sut.rootCAs = x509.NewCertPool()
+				sut.rootCAs.AddCert(tlsServer.Certificate())
+			})
+
+			It("should use the verified encrypted endpoints in the order of their priority", func() {
+				members := sut.initialUpstreams(ctx)
+
+				Expect(members).Should(HaveLen(2))
+				Expect(members[0].Net).Should(Equal(config.NetProtocolHttps))
+				Expect(members[0].Host).Should(Equal("example.com"))
+				Expect(members[0].Path).Should(Equal("/dns-query{?dns}"))
+				Expect(members[1].Net).Should(Equal(config.NetProtocolTcpTls))
+				Expect(members[1].Host).Should(Equal("example.com"))
+			})
+
+			It("should reject a certificate without the IP of the unencrypted resolver", func() {
+				sut.cfg.DDR.Host = "10.0.0.1"
+
+				upstream := config.Upstream{Net: config.NetProtocolTcpTls, Host: "example.com"}
+				_, tlsPort, _ := net.SplitHostPort(tlsServer.Listener.Addr().String())
+				port, _ := strconv.Atoi(tlsPort)
+				upstream.Port = uint16(port)
+
+				Expect(sut.verifyDesignatedResolver(ctx, upstream)).
+					Should(MatchError(ContainSubstring("isn't valid for the unencrypted resolver")))
+			})
+
+			It("should return the static upstreams without verified endpoints", func() {
+				records.Store(&[]string{(*records.Load())[2]})
+
+				Expect(upstreamsToString(sut.initialUpstreams(ctx))).Should(Equal("tcp+udp:9.9.9.9"))
+			})
+		})
+	})
+
+	DescribeTable("ddrUpstreams",
+		func(record string, want []string) {
+			rr, err := dns.NewRR(record)
+			Expect(err).Should(Succeed())
+
+			Expect(upstreamsToString(ddrUpstreams(rr.(*dns.SVCB)))).Should(Equal(strings.Join(want, ", ")))
+		},
+		Entry("DoH and DoT",
+			`_dns.resolver.arpa. 300 IN SVCB 1 dns.example.net. alpn=h2,h3,dot dohpath=/dns-query{?dns}`,
+			[]string{"https://dns.example.net/dns-query{?dns}", "tcp-tls:dns.example.net"}),
+		Entry("custom port",
+			`_dns.resolver.arpa. 300 IN SVCB 1 dns.example.net. alpn=dot port=8853`,
+			[]string{"tcp-tls:dns.example.net:8853"}),
+		Entry("DoH without path", `_dns.resolver.arpa. 300 IN SVCB 1 dns.example.net. alpn=h2`, nil),
+		Entry("unsupported protocol", `_dns.resolver.arpa. 300 IN SVCB 1 dns.example.net. alpn=doq`, nil),
+		Entry("owner name as target", `_dns.resolver.arpa. 300 IN SVCB 1 . alpn=dot`, nil),
+		Entry("unknown mandatory key",
+			`_dns.resolver.arpa. 300 IN SVCB 1 dns.example.net. mandatory=key65000 alpn=dot key65000=abc`, nil),
+	)
+
+	Describe("refresh", func() {
+		var target *ParallelBestResolver
+
+		upstreamsOfTarget := func() []string {
+			result := make([]string, 0)
+
+			for _, status := range *target.resolvers.Load() {
+				result = append(result, status.resolver.(*UpstreamResolver).Upstream().String())
+			}
+
+			return result
+		}
+
+		JustBeforeEach(func() {
+			sut.initialUpstreams(ctx)
+
+			group := config.NewUpstreamGroup("cluster", upstreamsCfg, sut.members)
+			resolvers, err := createGroupResolvers(ctx, group, sut.bootstrap)
+			Expect(err).Should(Succeed())
+
+			target = newParallelBestResolver(group, nil)
+			target.setResolvers(resolvers)
+		})
+
+		It("should replace the resolvers if the members changed", func() {
+			members.Store(&[]string{"10.0.0.3"})
+
+			sut.refresh(ctx, target)
+
+			Expect(upstreamsOfTarget()).Should(ConsistOf("tcp+udp:10.0.0.3:5353"))
+		})
+
+		It("should keep the resolvers if the discovery fails", func() {
+			failing.Store(true)
+
+			sut.refresh(ctx, target)
+
+			Expect(upstreamsOfTarget()).Should(ConsistOf("tcp+udp:10.0.0.1:5353", "tcp+udp:10.0.0.2:5353"))
+		})
+
+		It("should refresh the members periodically", func() {
+			sut.start(ctx, target)
+
+			members.Store(&[]string{"10.0.0.4"})
+
+			Eventually(upstreamsOfTarget, "1s").Should(ConsistOf("tcp+udp:10.0.0.4:5353"))
+		})
+	})
+})
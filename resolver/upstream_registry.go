@@ -0,0 +1,526 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// registryRetryDelay is the delay before a failed lookup or watch of a service registry is retried
+var registryRetryDelay = 5 * time.Second //nolint:gochecknoglobals
+
+// registrySource provides the upstreams of a service registry entry like `consul://dns`
+type registrySource interface {
+	// lookup returns the currently registered upstreams
+	lookup(ctx context.Context) ([]config.Upstream, error)
+	// wait blocks until the registered upstreams changed since the last lookup
+	wait(ctx context.Context) error
+}
+
+// registryWatch watches the service registry entries of an upstream group and replaces the resolvers of the group
+// with the registered upstreams and the static upstreams of the group, if the registered upstreams changed
+type registryWatch struct {
+	group     string
+	upstreams config.Upstreams
+	bootstrap *Bootstrap
+
+	static  []config.Upstream
+	sources map[config.Upstream]registrySource
+
+	lock       sync.Mutex
+	registered map[config.Upstream][]config.Upstream
+	members    []config.Upstream
+}
+
+func newRegistryWatch(
+	group string, cfg config.Upstreams, upstreams []config.Upstream, bootstrap *Bootstrap,
+) *registryWatch {
+	w := &registryWatch{
+		group:     group,
+		upstreams: cfg,
+		bootstrap: bootstrap,
+
+		sources:    make(map[config.Upstream]registrySource),
+		registered: make(map[config.Upstream][]config.Upstream),
+	}
+
+	client := &http.Client{Transport: bootstrap.NewHTTPTransport()}
+
+	for _, upstream := range upstreams {
+		switch upstream.Registry {
+		case config.RegistryConsul:
+			w.sources[upstream] = &consulSource{
+				cfg: cfg.Registry.Consul, service: upstream.RegistryKey(), client: client, timeout: cfg.Timeout.ToDuration(),
+			}
+		case config.RegistryEtcd:
+			w.sources[upstream] = &etcdSource{
+				cfg: cfg.Registry.Etcd, prefix: upstream.RegistryKey(), client: client, timeout: cfg.Timeout.ToDuration(),
+			}
+		default:
+			w.static = append(w.static, upstream)
+		}
+	}
+
+	return w
+}
+
+func (w *registryWatch) logger() *logrus.Entry {
+	return log.PrefixedLog("upstream_registry").WithField("group", w.group)
+}
+
+// initialUpstreams looks up the registered upstreams for the creation of the group resolver
+func (w *registryWatch) initialUpstreams(ctx context.Context) []config.Upstream {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for source, src := range w.sources {
+		registered, err := src.lookup(ctx)
+		if err != nil {
+			w.logger().Warnf("can't look up %s: %v", source, err)
+
+			continue
+		}
+
+		w.registered[source] = registered
+	}
+
+	w.members = w.merge()
+
+	w.logger().Infof("registered upstreams: %s", upstreamsToString(w.members))
+
+	return w.members
+}
+
+// start watches the service registry entries until the context is done
+func (w *registryWatch) start(ctx context.Context, target initializable) {
+	for source, src := range w.sources {
+		go w.watch(ctx, source, src, target)
+	}
+}
+
+func (w *registryWatch) watch(ctx context.Context, source config.Upstream, src registrySource, target initializable) {
+	for {
+		if err := src.wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			w.logger().Warnf("can't watch %s, retrying in %s: %v", source, registryRetryDelay, err)
+
+			if !sleepContext(ctx, registryRetryDelay) {
+				return
+			}
+		}
+
+		registered, err := src.lookup(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			w.logger().Warnf("can't look up %s, retrying in %s: %v", source, registryRetryDelay, err)
+
+			if !sleepContext(ctx, registryRetryDelay) {
+				return
+			}
+
+			continue
+		}
+
+		w.update(ctx, source, registered, target)
+	}
+}
+
+// update replaces the resolvers of the group, if the upstreams changed. The current resolvers are kept on errors.
+func (w *registryWatch) update(
+	ctx context.Context, source config.Upstream, registered []config.Upstream, target initializable,
+) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.registered[source] = registered
+
+	members := w.merge()
+	if slices.Equal(members, w.members) {
+		return
+	}
+
+	resolvers, err := createGroupResolvers(ctx, config.NewUpstreamGroup(w.group, w.upstreams, members), w.bootstrap)
+	if err != nil {
+		w.logger().Warnf("can't create resolvers for the registered upstreams: %v", err)
+
+		return
+	}
+
+	target.setResolvers(resolvers)
+	w.members = members
+
+	w.logger().Infof("upstreams changed: %s", upstreamsToString(members))
+}
+
+// merge returns the static upstreams followed by the sorted registered upstreams
+func (w *registryWatch) merge() []config.Upstream {
+	var registered []config.Upstream
+
+	for _, upstreams := range w.registered {
+		registered = append(registered, upstreams...)
+	}
+
+	slices.SortFunc(registered, func(a, b config.Upstream) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return append(slices.Clone(w.static), slices.Compact(registered)...)
+}
+
+// sleepContext waits for the duration, returns false if the context is done before
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// consulSource provides the healthy instances of a Consul service. The lookup is a blocking query, which returns
+// as soon as the instances changed or the wait time elapsed.
+type consulSource struct {
+	cfg     config.ConsulRegistry
+	service string
+	client  *http.Client
+	timeout time.Duration
+
+	index uint64
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    uint16
+	}
+}
+
+// wait returns immediately, the blocking query of the lookup waits for changes
+func (s *consulSource) wait(context.Context) error {
+	return nil
+}
+
+func (s *consulSource) lookup(ctx context.Context) ([]config.Upstream, error) {
+	wait := s.cfg.Wait.ToDuration()
+
+	query := url.Values{}
+	query.Set("passing", "true")
+	query.Set("index", strconv.FormatUint(s.index, 10))
+	query.Set("wait", fmt.Sprintf("%ds", int(wait.Seconds())))
+
+	if s.cfg.Datacenter != "" {
+		query.Set("dc", s.cfg.Datacenter)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/health/service/%s?%s",
+		strings.TrimSuffix(s.cfg.Address, "/"), url.PathEscape(s.service), query.Encode())
+
+	// Consul adds a jitter of up to 1/16 of the wait time
+	ctx, cancel := context.WithTimeout(ctx, wait+wait/16+s.timeout) //nolint:mnd
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul returned status %s", resp.Status)
+	}
+
+	index, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid X-Consul-Index: %w", err)
+	}
+
+	var entries []consulServiceEntry
+
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("can't decode consul response: %w", err)
+	}
+
+	// the index must be reset, if it goes backwards or is 0
+	if index < s.index || index == 0 {
+		index = 1
+	}
+
+	s.index = index
+
+	upstreams := make([]config.Upstream, 0, len(entries))
+
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+
+		port := entry.Service.Port
+		if port == 0 {
+			port = 53
+		}
+
+		upstreams = append(upstreams, config.Upstream{Net: config.NetProtocolTcpUdp, Host: host, Port: port})
+	}
+
+	return upstreams, nil
+}
+
+// etcdSource provides the upstreams, which are stored as values of the keys with a prefix in etcd.
+// It uses the JSON gateway of the etcd v3 API and watches the prefix for changes.
+type etcdSource struct {
+	cfg     config.EtcdRegistry
+	prefix  string
+	client  *http.Client
+	timeout time.Duration
+
+	revision int64
+	token    string
+}
+
+type etcdRangeResponse struct {
+	Header struct {
+		Revision int64 `json:"revision,string"`
+	} `json:"header"`
+	Kvs []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Events       []json.RawMessage `json:"events"`
+		Canceled     bool              `json:"canceled"`
+		CancelReason string            `json:"cancel_reason"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *etcdSource) lookup(ctx context.Context) ([]config.Upstream, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := s.post(ctx, "/v3/kv/range", map[string]any{"key": []byte(s.prefix), "range_end": s.rangeEnd()})
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var result etcdRangeResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("can't decode etcd response: %w", err)
+	}
+
+	s.revision = result.Header.Revision
+
+	upstreams := make([]config.Upstream, 0, len(result.Kvs))
+
+	for _, kv := range result.Kvs {
+		upstream, err := config.ParseUpstream(strings.TrimSpace(string(kv.Value)))
+		if err == nil && upstream.IsRegistry() {
+			err = errors.New("upstreams of a service registry can't be nested")
+		}
+
+		if err != nil {
+			log.PrefixedLog("upstream_registry").Warnf("ignoring etcd key %s: %v", kv.Key, err)
+
+			continue
+		}
+
+		upstreams = append(upstreams, upstream)
+	}
+
+	return upstreams, nil
+}
+
+// wait watches the prefix from the revision of the last lookup and returns with the first change
+func (s *etcdSource) wait(ctx context.Context) error {
+	if s.revision == 0 {
+		// no successful lookup yet
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resp, err := s.post(ctx, "/v3/watch", map[string]any{
+		"create_request": map[string]any{
+			"key": []byte(s.prefix), "range_end": s.rangeEnd(), "start_revision": s.revision + 1,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		var result etcdWatchResponse
+
+		if err := decoder.Decode(&result); err != nil {
+			return fmt.Errorf("etcd watch closed: %w", err)
+		}
+
+		switch {
+		case result.Error != nil:
+			return fmt.Errorf("etcd watch failed: %s", result.Error.Message)
+		case result.Result.Canceled:
+			return fmt.Errorf("etcd watch canceled: %s", result.Result.CancelReason)
+		case len(result.Result.Events) != 0:
+			return nil
+		}
+	}
+}
+
+// rangeEnd returns the end of the range of all keys with the prefix
+func (s *etcdSource) rangeEnd() []byte {
+	end := []byte(s.prefix)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff { //nolint:mnd
+			end[i]++
+
+			return end[:i+1]
+		}
+	}
+
+	// the prefix consists of 0xff bytes only: all following keys
+	return []byte{0}
+}
+
+// post sends the request to the first endpoint, which answers it
+func (s *etcdSource) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	for _, endpoint := range s.cfg.EndpointList() {
+		resp, err := s.postTo(ctx, strings.TrimSuffix(endpoint, "/"), path, data)
+		if err == nil {
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (s *etcdSource) postTo(ctx context.Context, endpoint, path string, data []byte) (*http.Response, error) {
+	if s.cfg.Username != "" && s.token == "" {
+		if err := s.authenticate(ctx, endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.token != "" {
+		req.Header.Set("Authorization", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			// the token expired, authenticate again with the next request
+			s.token = ""
+		}
+
+		return nil, fmt.Errorf("etcd returned status %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (s *etcdSource) authenticate(ctx context.Context, endpoint string) error {
+	data, err := json.Marshal(map[string]string{"name": s.cfg.Username, "password": s.cfg.Password})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/v3/auth/authenticate", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("etcd authentication failed: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("can't decode etcd authentication response: %w", err)
+	}
+
+	s.token = result.Token
+
+	return nil
+}
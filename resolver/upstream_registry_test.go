@@ -0,0 +1,292 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRegistry stores the registered values and notifies the blocking queries and watches of changes
+type fakeRegistry struct {
+	lock     sync.Mutex
+	values   []string
+	revision int
+	changed  chan struct{}
+	stopped  chan struct{}
+	requests []*http.Request
+}
+
+func newFakeRegistry(values ...string) *fakeRegistry {
+	return &fakeRegistry{values: values, revision: 10, changed: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+// serve starts a server with the handler, which is closed after the test
+func (f *fakeRegistry) serve(handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(handler)
+
+	DeferCleanup(func() {
+		// ends the open blocking queries and watches
+		close(f.stopped)
+		server.Close()
+	})
+
+	return server
+}
+
+func (f *fakeRegistry) set(values ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.values = values
+	f.revision++
+
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeRegistry) state(r *http.Request) ([]string, int, chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.requests = append(f.requests, r)
+
+	return f.values, f.revision, f.changed
+}
+
+func (f *fakeRegistry) lastRequest() *http.Request {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.requests[len(f.requests)-1]
+}
+
+// consulHandler answers blocking queries of the health endpoint, the values are `host:port`
+func (f *fakeRegistry) consulHandler(w http.ResponseWriter, r *http.Request) {
+	values, revision, changed := f.state(r)
+
+	if index, _ := strconv.Atoi(r.URL.Query().Get("index")); index >= revision {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-f.stopped:
+			return
+		}
+
+		values, revision, _ = f.state(r)
+	}
+
+	entries := make([]map[string]any, 0, len(values))
+
+	for _, value := range values {
+		host, port, _ := net.SplitHostPort(value)
+		p, _ := strconv.Atoi(port)
+
+		entries = append(entries, map[string]any{
+			"Node":    map[string]any{"Address": "192.168.0.1"},
+			"Service": map[string]any{"Address": host, "Port": p},
+		})
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.Itoa(revision))
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+// etcdHandler implements the range and watch endpoints of the etcd JSON gateway
+func (f *fakeRegistry) etcdHandler(w http.ResponseWriter, r *http.Request) {
+	values, revision, changed := f.state(r)
+
+	switch r.URL.Path {
+	case "/v3/auth/authenticate":
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "secret-token"})
+	case "/v3/kv/range":
+		kvs := make([]map[string]any, 0, len(values))
+
+		for i, value := range values {
+			kvs = append(kvs, map[string]any{"key": []byte(fmt.Sprintf("/dns/%d", i)), "value": []byte(value)})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"header": map[string]any{"revision": strconv.Itoa(revision)},
+			"kvs":    kvs,
+		})
+	case "/v3/watch":
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"created": true}})
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-f.stopped:
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"events": []any{map[string]any{}}}})
+		w.(http.Flusher).Flush()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("registryWatch", func() {
+	var (
+		sut          *registryWatch
+		upstreamsCfg config.Upstreams
+		upstreams    []config.Upstream
+		bootstrap    *Bootstrap
+		registry     *fakeRegistry
+		server       *httptest.Server
+		target       *ParallelBestResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	upstreamsOfTarget := func() []string {
+		result := make([]string, 0)
+
+		for _, status := range *target.resolvers.Load() {
+			result = append(result, status.resolver.(*UpstreamResolver).Upstream().String())
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		upstreamsCfg = defaultUpstreamsConfig
+		upstreamsCfg.Init.Strategy = config.InitStrategyFast
+		upstreamsCfg.Registry = config.UpstreamRegistry{Consul: config.ConsulRegistry{Wait: config.Duration(timeout)}}
+
+		bootstrap = &Bootstrap{
+			configurable: withConfig(newBootstrapConfig(&config.Config{Upstreams: upstreamsCfg})),
+			typed:        withType("bootstrap"),
+			dialer:       new(net.Dialer),
+		}
+
+		registry = newFakeRegistry("10.0.0.2:53", "10.0.0.1:5353")
+	})
+
+	JustBeforeEach(func() {
+		sut = newRegistryWatch("default", upstreamsCfg, upstreams, bootstrap)
+	})
+
+	startWatch := func() {
+		group := config.NewUpstreamGroup("default", upstreamsCfg, sut.initialUpstreams(ctx))
+		resolvers, err := createGroupResolvers(ctx, group, bootstrap)
+		Expect(err).Should(Succeed())
+
+		target = newParallelBestResolver(group, nil)
+		target.setResolvers(resolvers)
+
+		sut.start(ctx, target)
+	}
+
+	Describe("Consul", func() {
+		BeforeEach(func() {
+			server = registry.serve(registry.consulHandler)
+
+			upstreamsCfg.Registry.Consul.Address = server.URL
+			upstreamsCfg.Registry.Consul.Token = "consul-token"
+			upstreamsCfg.Registry.Consul.Datacenter = "dc2"
+
+			upstreams = []config.Upstream{
+				{Net: config.NetProtocolTcpUdp, Host: "9.9.9.9", Port: 53},
+				{Registry: config.RegistryConsul, Path: "dns"},
+			}
+		})
+
+		It("should return the static and the sorted healthy instances", func() {
+			Expect(upstreamsToString(sut.initialUpstreams(ctx))).
+				Should(Equal("tcp+udp:9.9.9.9, tcp+udp:10.0.0.1:5353, tcp+udp:10.0.0.2"))
+
+			req := registry.lastRequest()
+			Expect(req.URL.Path).Should(Equal("/v1/health/service/dns"))
+			Expect(req.URL.Query().Get("passing")).Should(Equal("true"))
+			Expect(req.URL.Query().Get("dc")).Should(Equal("dc2"))
+			Expect(req.Header.Get("X-Consul-Token")).Should(Equal("consul-token"))
+		})
+
+		It("should replace the resolvers if the instances changed", func() {
+			startWatch()
+
+			Eventually(func() string { return registry.lastRequest().URL.Query().Get("index") }, "1s").
+				Should(Equal("10"))
+
+			registry.set("10.0.0.3:53")
+
+			Eventually(upstreamsOfTarget, "1s").Should(ConsistOf("tcp+udp:9.9.9.9", "tcp+udp:10.0.0.3"))
+		})
+
+		When("Consul is not reachable", func() {
+			BeforeEach(func() {
+				server.Close()
+			})
+
+			It("should return the static upstreams", func() {
+				Expect(upstreamsToString(sut.initialUpstreams(ctx))).Should(Equal("tcp+udp:9.9.9.9"))
+			})
+		})
+	})
+
+	Describe("etcd", func() {
+		BeforeEach(func() {
+			registry = newFakeRegistry("tcp-tls:dns.quad9.net", "invalid upstream", "consul://dns")
+
+			server = registry.serve(registry.etcdHandler)
+
+			upstreamsCfg.Registry.Etcd.Endpoints = []string{"http://127.0.0.1:1", server.URL}
+
+			upstreams = []config.Upstream{{Registry: config.RegistryEtcd, Path: "/dns/"}}
+		})
+
+		It("should return the valid upstreams of the values", func() {
+			Expect(upstreamsToString(sut.initialUpstreams(ctx))).Should(Equal("tcp-tls:dns.quad9.net"))
+		})
+
+		It("should replace the resolvers after a change", func() {
+			startWatch()
+
+			Eventually(func() string { return registry.lastRequest().URL.Path }, "1s").Should(Equal("/v3/watch"))
+
+			registry.set("tcp+udp:10.0.0.3", "https://dns.google/dns-query")
+
+			Eventually(upstreamsOfTarget, "1s").
+				Should(ConsistOf("tcp+udp:10.0.0.3", "https://dns.google/dns-query"))
+		})
+
+		When("credentials are configured", func() {
+			BeforeEach(func() {
+				upstreamsCfg.Registry.Etcd.Username = "blocky"
+				upstreamsCfg.Registry.Etcd.Password = "secret"
+			})
+
+			It("should send the token of the authentication", func() {
+				sut.initialUpstreams(ctx)
+
+				Expect(registry.requests[0].URL.Path).Should(Equal("/v3/auth/authenticate"))
+				Expect(registry.lastRequest().Header.Get("Authorization")).Should(Equal("secret-token"))
+			})
+		})
+	})
+
+	Describe("etcdSource.rangeEnd", func() {
+		It("should return the end of the prefix range", func() {
+			Expect((&etcdSource{prefix: "/dns/"}).rangeEnd()).Should(Equal([]byte("/dns0")))
+			Expect((&etcdSource{prefix: "a\xff"}).rangeEnd()).Should(Equal([]byte("b")))
+			Expect((&etcdSource{prefix: "\xff"}).rangeEnd()).Should(Equal([]byte{0}))
+		})
+	})
+})
@@ -4,22 +4,28 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/avast/retry-go/v4"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
 	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/metrics"
 	"github.com/0xERR0R/blocky/model"
 	"github.com/0xERR0R/blocky/util"
 
 	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -28,6 +34,17 @@ const (
 	retryAttempts  = 3
 )
 
+// errUpstreamDisabled is returned by upstreams, which were disabled by the hijack detection
+var errUpstreamDisabled = errors.New("upstream is disabled")
+
+//nolint:gochecknoglobals
+var truncatedResponses = metrics.Registered(metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "blocky_upstream_truncated_responses_total",
+		Help: "Number of truncated UDP answers of upstreams by their handling",
+	}, []string{"upstream", "handling"},
+))
+
 // UpstreamServerError wraps a response with RCode ServFail so no other resolver tries to use it.
 type UpstreamServerError struct {
 	Msg *dns.Msg
@@ -40,10 +57,13 @@ func (e *UpstreamServerError) Error() string {
 type upstreamConfig struct {
 	config.Upstreams
 	config.Upstream
+
+	// source of the sockets, only set for the upstreams of an upstream group
+	binding config.UpstreamBinding
 }
 
 func newUpstreamConfig(upstream config.Upstream, cfg config.Upstreams) upstreamConfig {
-	return upstreamConfig{cfg, upstream}
+	return upstreamConfig{Upstreams: cfg, Upstream: upstream}
 }
 
 func (c upstreamConfig) String() string {
@@ -67,23 +87,42 @@ type UpstreamResolver struct {
 
 	upstreamClient upstreamClient
 	bootstrap      *Bootstrap
+	// nil, if no cookies are sent to the upstream
+	cookies *upstreamCookies
+	// nil, if no client identifier is sent to the upstream
+	clientID *upstreamClientID
+
+	unreachable atomic.Bool
+	hijacked    atomic.Bool
+	disabled    atomic.Bool
+
+	stats upstreamStats
 }
 
 type upstreamClient interface {
 	fmtURL(ip net.IP, port uint16, path string) string
 	callExternal(
-		ctx context.Context, msg *dns.Msg, upstreamURL string, protocol model.RequestProtocol,
+		ctx context.Context, msg *dns.Msg, upstreamURL string, request *model.Request,
 	) (response *dns.Msg, rtt time.Duration, err error)
 }
 
 type dnsUpstreamClient struct {
 	tcpClient, udpClient *dns.Client
+
+	// name of the upstream for the metrics
+	upstream   string
+	truncation config.UpstreamTruncation
 }
 
 type httpUpstreamClient struct {
 	client    *http.Client
 	host      string
 	userAgent string
+	// GET or POST
+	method  string
+	headers map[string]string
+	// profile provider, which identifies the querying device, if the profile has no fixed device
+	deviceProvider string
 }
 
 func createUpstreamClient(cfg upstreamConfig) upstreamClient {
@@ -96,35 +135,68 @@ func createUpstreamClient(cfg upstreamConfig) upstreamClient {
 		tlsConfig.ServerName = cfg.CommonName
 	}
 
+	// only replace the default dialers, if the sockets are bound
+	dialer := func(network string) *net.Dialer {
+		if !cfg.binding.IsEnabled() {
+			return nil
+		}
+
+		return newUpstreamDialer(cfg.binding, network)
+	}
+
 	switch cfg.Net {
 	case config.NetProtocolHttps:
 		transport := util.DefaultHTTPTransport()
 		transport.TLSClientConfig = &tlsConfig
 
-		return &httpUpstreamClient{
+		if d := dialer("tcp"); d != nil {
+			transport.DialContext = d.DialContext
+		}
+
+		options := cfg.DoHOptions(cfg.Upstream)
+
+		method := http.MethodPost
+		if options.Method == config.DoHMethodGet || (options.Method == config.DoHMethodAuto && cfg.HasURITemplate()) {
+			method = http.MethodGet
+		}
+
+		client := &httpUpstreamClient{
 			userAgent: cfg.UserAgent,
 			client: &http.Client{
 				Transport: transport,
 			},
-			host: cfg.Host,
+			host:    cfg.Host,
+			method:  method,
+			headers: options.Headers,
 		}
 
+		if _, device := cfg.Profile(); cfg.Provider != "" && device == "" {
+			client.deviceProvider = cfg.Provider
+		}
+
+		return client
+
 	case config.NetProtocolTcpTls:
 		return &dnsUpstreamClient{
 			tcpClient: &dns.Client{
 				TLSConfig: &tlsConfig,
 				Net:       cfg.Net.String(),
+				Dialer:    dialer("tcp"),
 			},
 		}
 
 	case config.NetProtocolTcpUdp:
 		return &dnsUpstreamClient{
 			tcpClient: &dns.Client{
-				Net: "tcp",
+				Net:    "tcp",
+				Dialer: dialer("tcp"),
 			},
 			udpClient: &dns.Client{
-				Net: "udp",
+				Net:    "udp",
+				Dialer: dialer("udp"),
 			},
+			upstream:   cfg.String(),
+			truncation: cfg.Truncation,
 		}
 
 	default:
@@ -138,24 +210,15 @@ func (r *httpUpstreamClient) fmtURL(ip net.IP, port uint16, path string) string
 }
 
 func (r *httpUpstreamClient) callExternal(
-	ctx context.Context, msg *dns.Msg, upstreamURL string, _ model.RequestProtocol,
+	ctx context.Context, msg *dns.Msg, upstreamURL string, request *model.Request,
 ) (*dns.Msg, time.Duration, error) {
 	start := time.Now()
 
-	rawDNSMessage, err := msg.Pack()
-	if err != nil {
-		return nil, 0, fmt.Errorf("can't pack message: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(rawDNSMessage))
+	req, err := r.newRequest(ctx, msg, upstreamURL, request)
 	if err != nil {
-		return nil, 0, fmt.Errorf("can't create the new request %w", err)
+		return nil, 0, err
 	}
 
-	req.Header.Set("User-Agent", r.userAgent)
-	req.Header.Set("Content-Type", dnsContentType)
-	req.Host = r.host
-
 	httpResponse, err := r.client.Do(req)
 	if err != nil {
 		return nil, 0, fmt.Errorf("can't perform https request: %w", err)
@@ -186,21 +249,94 @@ func (r *httpUpstreamClient) callExternal(
 		return nil, 0, fmt.Errorf("can't unpack message: %w", err)
 	}
 
+	// GET requests are sent with ID 0
+	response.Id = msg.Id
+
 	return &response, time.Since(start), nil
 }
 
+// newRequest creates the request with the query as body (POST) or as parameter `dns` (GET, RFC 8484)
+func (r *httpUpstreamClient) newRequest(
+	ctx context.Context, msg *dns.Msg, upstreamURL string, request *model.Request,
+) (*http.Request, error) {
+	var body io.Reader
+
+	device := profileDeviceName(request)
+
+	// ControlD identifies the device by the path
+	if r.deviceProvider == config.ProfileProviderControlD && device != "" {
+		upstreamURL += "/" + device
+	}
+
+	if r.method == http.MethodGet {
+		// the ID 0 makes the URL of the same query cacheable
+		query := msg.Copy()
+		query.Id = 0
+
+		rawDNSMessage, err := query.Pack()
+		if err != nil {
+			return nil, fmt.Errorf("can't pack message: %w", err)
+		}
+
+		separator := "?"
+		if strings.Contains(upstreamURL, "?") {
+			separator = "&"
+		}
+
+		upstreamURL += separator + "dns=" + base64.RawURLEncoding.EncodeToString(rawDNSMessage)
+	} else {
+		rawDNSMessage, err := msg.Pack()
+		if err != nil {
+			return nil, fmt.Errorf("can't pack message: %w", err)
+		}
+
+		body = bytes.NewReader(rawDNSMessage)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, upstreamURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the new request %w", err)
+	}
+
+	req.Header.Set("User-Agent", r.userAgent)
+	req.Header.Set("Accept", dnsContentType)
+
+	if body != nil {
+		req.Header.Set("Content-Type", dnsContentType)
+	}
+
+	// NextDNS identifies the device by the headers of its CLI client
+	if r.deviceProvider == config.ProfileProviderNextDNS {
+		if device != "" {
+			req.Header.Set("X-Device-Name", device)
+		}
+
+		if request.ClientIP != nil {
+			req.Header.Set("X-Device-Ip", request.ClientIP.String())
+		}
+	}
+
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+
+	req.Host = r.host
+
+	return req, nil
+}
+
 func (r *dnsUpstreamClient) fmtURL(ip net.IP, port uint16, _ string) string {
 	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
 }
 
 func (r *dnsUpstreamClient) callExternal(
-	ctx context.Context, msg *dns.Msg, upstreamURL string, protocol model.RequestProtocol,
+	ctx context.Context, msg *dns.Msg, upstreamURL string, request *model.Request,
 ) (response *dns.Msg, rtt time.Duration, err error) {
 	if r.udpClient == nil {
 		return r.tcpClient.ExchangeContext(ctx, msg, upstreamURL)
 	}
 
-	return r.raceClients(ctx, msg, upstreamURL, protocol)
+	return r.raceClients(ctx, msg, upstreamURL, request.Protocol)
 }
 
 type exchangeResult struct {
@@ -241,8 +377,18 @@ func (r *dnsUpstreamClient) raceClients(
 		return res1.msg, res1.rtt, nil
 	}
 
+	if r.trustsTruncated(msg, &res1) {
+		return res1.msg, res1.rtt, nil
+	}
+
 	res2 := <-ch
 	if res2.err == nil && !res2.msg.Truncated {
+		r.countTruncated(&res1, "tcp")
+
+		return res2.msg, res2.rtt, nil
+	}
+
+	if r.trustsTruncated(msg, &res2) {
 		return res2.msg, res2.rtt, nil
 	}
 
@@ -264,9 +410,39 @@ func (r *dnsUpstreamClient) raceClients(
 	// Only a single one failed, use the one that succeeded
 	successful := resWhere(func(r *exchangeResult) bool { return r.err == nil })
 
+	if successful.msg.Truncated && r.truncation.RetryTCP {
+		r.countTruncated(successful, "retried")
+
+		return r.tcpClient.ExchangeContext(ctx, msg, upstreamURL)
+	}
+
+	r.countTruncated(successful, "returned")
+
 	return successful.msg, successful.rtt, nil
 }
 
+// trustsTruncated returns true, if the result is a truncated answer of a trusted query type. The TC bit is removed,
+// so the answer is cached and used by the client.
+func (r *dnsUpstreamClient) trustsTruncated(msg *dns.Msg, res *exchangeResult) bool {
+	if res.err != nil || !res.msg.Truncated || len(msg.Question) == 0 ||
+		!r.truncation.TrustTypes.Contains(dns.Type(msg.Question[0].Qtype)) {
+		return false
+	}
+
+	r.countTruncated(res, "trusted")
+
+	res.msg.Truncated = false
+
+	return true
+}
+
+// countTruncated counts the result with its handling, if it is a truncated answer
+func (r *dnsUpstreamClient) countTruncated(res *exchangeResult, handling string) {
+	if res.err == nil && res.msg.Truncated {
+		truncatedResponses.WithLabelValues(r.upstream, handling).Inc()
+	}
+}
+
 // NewUpstreamResolver creates new resolver instance
 func NewUpstreamResolver(
 	ctx context.Context, cfg upstreamConfig, bootstrap *Bootstrap,
@@ -291,20 +467,35 @@ func NewUpstreamResolver(
 func newUpstreamResolverUnchecked(cfg upstreamConfig, bootstrap *Bootstrap) *UpstreamResolver {
 	upstreamClient := createUpstreamClient(cfg)
 
+	var cookies *upstreamCookies
+
+	// DoH has no use for cookies, the responses can't be spoofed
+	if cfg.Cookies && cfg.Net != config.NetProtocolHttps {
+		cookies = newUpstreamCookies()
+	}
+
+	var clientID *upstreamClientID
+
+	if id := cfg.ClientIdentifierOf(cfg.Upstream); id != nil {
+		clientID = newUpstreamClientID(*id)
+	}
+
 	return &UpstreamResolver{
 		typed:        withType("upstream"),
 		configurable: withConfig(cfg),
 
 		upstreamClient: upstreamClient,
 		bootstrap:      bootstrap,
+		cookies:        cookies,
+		clientID:       clientID,
 	}
 }
 
-func (r UpstreamResolver) String() string {
+func (r *UpstreamResolver) String() string {
 	return fmt.Sprintf("%s '%s'", r.Type(), r.cfg)
 }
 
-func (r UpstreamResolver) Upstream() config.Upstream {
+func (r *UpstreamResolver) Upstream() config.Upstream {
 	return r.cfg.Upstream
 }
 
@@ -325,7 +516,15 @@ func (r *UpstreamResolver) testResolve(ctx context.Context) error {
 }
 
 // Resolve calls external resolver
-func (r *UpstreamResolver) Resolve(ctx context.Context, request *model.Request) (response *model.Response, err error) {
+func (r *UpstreamResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if r.disabled.Load() {
+		return nil, fmt.Errorf("upstream %s: %w", r.cfg, errUpstreamDisabled)
+	}
+
+	return r.resolve(ctx, request)
+}
+
+func (r *UpstreamResolver) resolve(ctx context.Context, request *model.Request) (response *model.Response, err error) {
 	ctx, logger := r.log(ctx)
 
 	ips, err := r.bootstrap.UpstreamIPs(ctx, r)
@@ -334,24 +533,60 @@ func (r *UpstreamResolver) Resolve(ctx context.Context, request *model.Request)
 	}
 
 	var (
-		resp *dns.Msg
-		ip   net.IP
+		resp    *dns.Msg
+		ip      net.IP
+		rtt     time.Duration
+		retries uint
 	)
 
 	err = retry.Do(
 		func() error {
 			ip = ips.Current()
-			upstreamURL := r.upstreamClient.fmtURL(ip, r.cfg.Port, r.cfg.Path)
+			// the query of GET requests is added by the client
+			upstreamURL := r.upstreamClient.fmtURL(ip, r.cfg.Port, r.cfg.PathWithoutTemplate())
 
 			ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout.ToDuration())
 			defer cancel()
 
-			response, rtt, err := r.upstreamClient.callExternal(ctx, request.Req, upstreamURL, request.Protocol)
+			query := request.Req
+			if r.cfg.RandomizeCase {
+				query = withRandomizedCase(request.Req)
+			}
+
+			if r.cookies != nil {
+				query = r.cookies.withCookie(query, ip)
+			}
+
+			if r.clientID != nil {
+				query = r.clientID.withClientID(query, request)
+			}
+
+			response, responseRTT, err := r.upstreamClient.callExternal(ctx, query, upstreamURL, request)
+			if errors.Is(err, dns.ErrId) {
+				err = &responseMismatchError{mismatchID, err.Error()}
+			} else if err == nil {
+				err = validateResponse(query, response, r.cfg.RandomizeCase)
+			}
+
+			if err == nil && r.cookies != nil {
+				r.cookies.takeCookie(response, ip)
+
+				if response.Rcode == dns.RcodeBadCookie {
+					err = errBadCookie
+				}
+			}
+
 			if err != nil {
+				r.publishIfMismatch(err)
+
 				return fmt.Errorf("can't resolve request via upstream server %s (%s): %w", r.cfg, upstreamURL, err)
 			}
 
-			resp = response
+			if r.cfg.RandomizeCase {
+				restoreCase(request.Req, query, response)
+			}
+
+			resp, rtt = response, responseRTT
 			r.logResponse(logger, request, response, ip, rtt)
 
 			return nil
@@ -361,7 +596,9 @@ func (r *UpstreamResolver) Resolve(ctx context.Context, request *model.Request)
 		retry.DelayType(retry.FixedDelay),
 		retry.Delay(1*time.Millisecond),
 		retry.LastErrorOnly(true),
-		retry.RetryIf(isTimeout),
+		retry.RetryIf(func(err error) bool {
+			return isTimeout(err) || isResponseMismatch(err) || errors.Is(err, errBadCookie)
+		}),
 		retry.OnRetry(func(n uint, err error) {
 			logger.WithFields(logrus.Fields{
 				"upstream":    r.cfg.String(),
@@ -370,13 +607,53 @@ func (r *UpstreamResolver) Resolve(ctx context.Context, request *model.Request)
 				"attempt":     fmt.Sprintf("%d/%d", n+1, retryAttempts),
 			}).Debugf("%s, retrying...", err)
 
+			retries = n + 1
+
 			ips.Next()
 		}))
 	if err != nil {
+		// Ignore `Canceled`: resolver lost the race, not an error
+		if !errors.Is(err, context.Canceled) {
+			r.setReachable(false, err)
+			r.stats.recordError()
+		}
+
+		return nil, wrapUpstreamError(err)
+	}
+
+	r.setReachable(true, nil)
+	r.stats.recordResponse(rtt)
+
+	if err := checkCNAMEChain(ctx, resp.Answer); err != nil {
 		return nil, err
 	}
 
-	return &model.Response{Res: resp, Reason: fmt.Sprintf("RESOLVED (%s)", r.cfg)}, nil
+	return &model.Response{
+		Res:    resp,
+		Reason: model.NewReason(model.ReasonCodeRESOLVED, r.cfg.String()),
+		Upstream: &model.UpstreamInfo{
+			Name:     r.cfg.String(),
+			Protocol: r.cfg.Net.String(),
+			RTT:      rtt,
+			Retries:  retries,
+		},
+	}, nil
+}
+
+// publishIfMismatch publishes an event if the response was rejected, since it doesn't match the query
+func (r *UpstreamResolver) publishIfMismatch(err error) {
+	var mismatchErr *responseMismatchError
+
+	if errors.As(err, &mismatchErr) {
+		evt.Bus().Publish(evt.UpstreamResponseRejected, r.cfg.String(), mismatchErr.reason)
+	}
+}
+
+// setReachable publishes an event if the reachability of the upstream changed
+func (r *UpstreamResolver) setReachable(reachable bool, err error) {
+	if r.unreachable.CompareAndSwap(reachable, !reachable) {
+		evt.Bus().Publish(evt.UpstreamStatusChanged, r.cfg.String(), reachable, err)
+	}
 }
 
 func (r *UpstreamResolver) logResponse(
@@ -398,3 +675,21 @@ func isTimeout(err error) bool {
 
 	return errors.As(err, &netErr) && netErr.Timeout()
 }
+
+// profileDeviceName returns the first client name of the request as device name of a profile provider:
+// spaces are replaced by "--", other characters than letters, digits and hyphens by "-"
+func profileDeviceName(request *model.Request) string {
+	if len(request.ClientNames) == 0 {
+		return ""
+	}
+
+	name := strings.ReplaceAll(strings.TrimSpace(request.ClientNames[0]), " ", "--")
+
+	return strings.Map(func(r rune) rune {
+		if r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+
+		return '-'
+	}, name)
+}
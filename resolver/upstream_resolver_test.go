@@ -2,15 +2,20 @@ package resolver
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/evt"
 	. "github.com/0xERR0R/blocky/helpertest"
 	"github.com/0xERR0R/blocky/log"
 	. "github.com/0xERR0R/blocky/model"
@@ -18,6 +23,7 @@ import (
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
@@ -80,6 +86,21 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 							HaveReason(fmt.Sprintf("RESOLVED (%s)", sutConfig.Upstream))),
 					)
 			})
+			It("should return the upstream, which answered the query", func() {
+				mockUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+
+				sutConfig.Upstream = mockUpstream.Start()
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Upstream).ShouldNot(BeNil())
+				Expect(resp.Upstream.Name).Should(Equal(sutConfig.String()))
+				Expect(resp.Upstream.Protocol).Should(Equal("tcp+udp"))
+				Expect(resp.Upstream.RTT).Should(BeNumerically(">", 0))
+				Expect(resp.Upstream.Retries).Should(BeZero())
+			})
 		})
 		When("Configured DNS resolver can't resolve query", func() {
 			It("should return response code from DNS upstream", func() {
@@ -125,6 +146,53 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 				Expect(errors.As(err, &servErr)).Should(BeTrue())
 			})
 		})
+		When("Configured DNS resolver becomes unreachable", func() {
+			It("should publish status changes", func() {
+				var failing atomic.Bool
+
+				mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+					response := new(dns.Msg)
+					response.SetReply(request)
+
+					if failing.Load() {
+						response.Rcode = dns.RcodeServerFailure
+					}
+
+					return response
+				})
+
+				sutConfig.Upstream = mockUpstream.Start()
+				sut := newUpstreamResolverUnchecked(sutConfig, nil)
+
+				var statuses []bool
+
+				handler := func(upstream string, reachable bool, err error) {
+					Expect(upstream).Should(Equal(sutConfig.Upstream.String()))
+					Expect(err != nil).Should(Equal(!reachable))
+
+					statuses = append(statuses, reachable)
+				}
+				Expect(Bus().Subscribe(UpstreamStatusChanged, handler)).Should(Succeed())
+				DeferCleanup(Bus().Unsubscribe, UpstreamStatusChanged, handler)
+
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				failing.Store(true)
+
+				for range 2 {
+					_, err = sut.Resolve(ctx, newRequest("example.com.", A))
+					Expect(err).Should(HaveOccurred())
+				}
+
+				failing.Store(false)
+
+				_, err = sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(statuses).Should(Equal([]bool{false, true}))
+			})
+		})
 		When("Timeout occurs", func() {
 			var counter int32
 			var attemptsWithTimeout int32
@@ -158,6 +226,7 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 								HaveResponseType(ResponseTypeRESOLVED),
 								HaveReturnCode(dns.RcodeSuccess),
 								HaveTTL(BeNumerically("==", 123)),
+								WithTransform(func(resp *Response) uint { return resp.Upstream.Retries }, Equal(uint(2))),
 							))
 				})
 
@@ -194,6 +263,277 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 				})
 			})
 		})
+
+		Describe("truncated UDP answers", func() {
+			// the mock upstream doesn't listen on TCP, so the TCP queries fail
+			BeforeEach(func() {
+				mockUpstream := NewMockUDPUpstreamServer().WithAnswerFn(func(request *dns.Msg) *dns.Msg {
+					response, err := util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
+					Expect(err).Should(Succeed())
+
+					response.Truncated = true
+
+					return response
+				})
+
+				sutConfig.Upstream = mockUpstream.Start()
+			})
+
+			truncated := func(handling string) float64 {
+				return testutil.ToFloat64(truncatedResponses.WithLabelValues(sutConfig.String(), handling))
+			}
+
+			It("should return the truncated answer, if the TCP query fails", func() {
+				resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Res.Truncated).Should(BeTrue())
+				Expect(truncated("returned")).Should(BeNumerically("==", 1))
+			})
+
+			When("retry over TCP is enabled", func() {
+				BeforeEach(func() {
+					sutConfig.Truncation.RetryTCP = true
+				})
+
+				It("should not return the truncated answer", func() {
+					_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+					Expect(err).Should(HaveOccurred())
+
+					Expect(truncated("retried")).Should(BeNumerically("==", 1))
+				})
+			})
+
+			When("the query type is trusted", func() {
+				BeforeEach(func() {
+					sutConfig.Truncation = config.UpstreamTruncation{
+						RetryTCP:   true,
+						TrustTypes: config.NewQTypeSet(A),
+					}
+				})
+
+				It("should return the answer without TC bit", func() {
+					resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+					Expect(err).Should(Succeed())
+
+					Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+					Expect(resp.Res.Truncated).Should(BeFalse())
+					Expect(truncated("trusted")).Should(BeNumerically("==", 1))
+				})
+			})
+		})
+	})
+
+	Describe("Response validation", func() {
+		var client *fakeUpstreamClient
+
+		JustBeforeEach(func() {
+			client = &fakeUpstreamClient{}
+
+			sutConfig.Upstream = config.Upstream{Net: config.NetProtocolTcpUdp, Host: "127.0.0.1", Port: 53}
+			sut = newUpstreamResolverUnchecked(sutConfig, nil)
+			sut.upstreamClient = client
+		})
+
+		wrongQuestion := func(request *dns.Msg) *dns.Msg {
+			response := new(dns.Msg)
+			response.SetReply(util.NewMsgWithQuestion("spoofed.com.", A))
+			response.Id = request.Id
+
+			return response
+		}
+
+		matching := func(request *dns.Msg) *dns.Msg {
+			response, err := util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
+			Expect(err).Should(Succeed())
+			response.SetReply(request)
+
+			return response
+		}
+
+		When("the upstream answers with a mismatching response", func() {
+			It("should discard it, retry and publish the rejection", func() {
+				client.answers = []func(*dns.Msg) *dns.Msg{wrongQuestion, matching}
+
+				var reasons []string
+
+				handler := func(upstream, reason string) {
+					Expect(upstream).Should(Equal(sutConfig.Upstream.String()))
+
+					reasons = append(reasons, reason)
+				}
+				Expect(Bus().Subscribe(UpstreamResponseRejected, handler)).Should(Succeed())
+				DeferCleanup(Bus().Unsubscribe, UpstreamResponseRejected, handler)
+
+				resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+				Expect(resp.Upstream.Retries).Should(Equal(uint(1)))
+
+				Expect(reasons).Should(Equal([]string{"question"}))
+			})
+
+			It("should fail, if all attempts are mismatching", func() {
+				client.answers = []func(*dns.Msg) *dns.Msg{wrongQuestion, wrongQuestion, wrongQuestion}
+
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(MatchError(ContainSubstring("response doesn't match the query (question)")))
+				Expect(client.calls).Should(Equal(retryAttempts))
+			})
+		})
+
+		When("the case of the query is randomized", func() {
+			BeforeEach(func() {
+				sutConfig.RandomizeCase = true
+			})
+
+			It("should send a randomized query and return the original name", func() {
+				client.answers = []func(*dns.Msg) *dns.Msg{matching}
+
+				resp, err := sut.Resolve(ctx, newRequest("some-long.example.com.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp).Should(BeDNSRecord("some-long.example.com.", A, "123.124.122.122"))
+				Expect(resp.Res.Question[0].Name).Should(Equal("some-long.example.com."))
+
+				Expect(strings.ToLower(client.queries[0].Question[0].Name)).Should(Equal("some-long.example.com."))
+			})
+
+			It("should reject a response with a different case", func() {
+				lowered := func(request *dns.Msg) *dns.Msg {
+					response := matching(request)
+					response.Question[0].Name = strings.ToLower(response.Question[0].Name)
+
+					return response
+				}
+
+				client.answers = []func(*dns.Msg) *dns.Msg{lowered, lowered, lowered}
+
+				_, err := sut.Resolve(ctx, newRequest("some-long.example.com.", A))
+				Expect(err).Should(MatchError(ContainSubstring("(case)")))
+			})
+		})
+	})
+
+	Describe("Cookies", func() {
+		var client *fakeUpstreamClient
+
+		BeforeEach(func() {
+			sutConfig.Cookies = true
+		})
+
+		JustBeforeEach(func() {
+			client = &fakeUpstreamClient{}
+
+			sutConfig.Upstream = config.Upstream{Net: config.NetProtocolTcpUdp, Host: "127.0.0.1", Port: 53}
+			sut = newUpstreamResolverUnchecked(sutConfig, nil)
+			sut.upstreamClient = client
+		})
+
+		// answers with the client cookie of the query and the server cookie
+		withServerCookie := func(rcode int) func(*dns.Msg) *dns.Msg {
+			return func(request *dns.Msg) *dns.Msg {
+				response, err := util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
+				Expect(err).Should(Succeed())
+				response.SetReply(request)
+				response.Rcode = rcode
+
+				cookie := util.GetEdns0Option[*dns.EDNS0_COOKIE](request)
+				Expect(cookie).ShouldNot(BeNil())
+
+				response.SetEdns0(1232, false)
+				util.SetEdns0Option(response, &dns.EDNS0_COOKIE{
+					Code: dns.EDNS0COOKIE, Cookie: cookie.Cookie[:16] + "a1a2a3a4a5a6a7a8",
+				})
+
+				return response
+			}
+		}
+
+		It("should send the server cookie of the last response and remove it from the response", func() {
+			client.answers = []func(*dns.Msg) *dns.Msg{withServerCookie(dns.RcodeSuccess), withServerCookie(dns.RcodeSuccess)}
+
+			resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](resp.Res)).Should(BeNil())
+
+			_, err = sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+
+			first := util.GetEdns0Option[*dns.EDNS0_COOKIE](client.queries[0])
+			second := util.GetEdns0Option[*dns.EDNS0_COOKIE](client.queries[1])
+			Expect(first.Cookie).Should(HaveLen(16))
+			Expect(second.Cookie).Should(Equal(first.Cookie + "a1a2a3a4a5a6a7a8"))
+		})
+
+		It("should retry with the new server cookie on BADCOOKIE", func() {
+			client.answers = []func(*dns.Msg) *dns.Msg{withServerCookie(dns.RcodeBadCookie), withServerCookie(dns.RcodeSuccess)}
+
+			resp, err := sut.Resolve(ctx, newRequest("example.com.", A))
+			Expect(err).Should(Succeed())
+			Expect(resp).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+			Expect(resp.Upstream.Retries).Should(Equal(uint(1)))
+
+			Expect(util.GetEdns0Option[*dns.EDNS0_COOKIE](client.queries[1]).Cookie).Should(HaveSuffix("a1a2a3a4a5a6a7a8"))
+		})
+
+		It("should not modify the request", func() {
+			client.answers = []func(*dns.Msg) *dns.Msg{withServerCookie(dns.RcodeSuccess)}
+
+			req := newRequest("example.com.", A)
+
+			_, err := sut.Resolve(ctx, req)
+			Expect(err).Should(Succeed())
+			Expect(req.Req.IsEdns0()).Should(BeNil())
+		})
+	})
+
+	Describe("Client identifier", func() {
+		var client *fakeUpstreamClient
+
+		JustBeforeEach(func() {
+			client = &fakeUpstreamClient{}
+			client.answers = []func(*dns.Msg) *dns.Msg{func(request *dns.Msg) *dns.Msg {
+				response, err := util.NewMsgWithAnswer(request.Question[0].Name, 123, A, "123.124.122.122")
+				Expect(err).Should(Succeed())
+				response.SetReply(request)
+
+				return response
+			}}
+
+			sutConfig.Upstream = config.Upstream{Net: config.NetProtocolTcpUdp, Host: "127.0.0.1", Port: 53}
+			sut = newUpstreamResolverUnchecked(sutConfig, nil)
+			sut.upstreamClient = client
+		})
+
+		When("configured for the upstream", func() {
+			BeforeEach(func() {
+				sutConfig.ClientIdentifier = map[string]config.UpstreamClientIdentifier{
+					"127.0.0.1": {Code: 65001, Value: config.ClientIdentifierValueName},
+				}
+			})
+
+			It("should send the client name", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"))
+				Expect(err).Should(Succeed())
+
+				Expect(localOption(client.queries[0], 65001)).Should(Equal([]byte("laptop")))
+			})
+		})
+
+		When("configured for another upstream", func() {
+			BeforeEach(func() {
+				sutConfig.ClientIdentifier = map[string]config.UpstreamClientIdentifier{
+					"127.0.0.2": {Code: 65001, Value: config.ClientIdentifierValueName},
+				}
+			})
+
+			It("should not send the client name", func() {
+				_, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "laptop"))
+				Expect(err).Should(Succeed())
+
+				Expect(client.queries[0].IsEdns0()).Should(BeNil())
+			})
+		})
 	})
 
 	Describe("Using DNS over HTTPS (DoH) upstream", func() {
@@ -289,6 +629,188 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 				Expect(err.Error()).Should(ContainSubstring("can't unpack message"))
 			})
 		})
+		Describe("request method and headers", func() {
+			var (
+				requests chan *http.Request
+				path     string
+				provider string
+				options  config.DoHUpstream
+			)
+
+			BeforeEach(func() {
+				requests = make(chan *http.Request, 1)
+				path = "/dns-query"
+				provider = ""
+				options = config.DoHUpstream{}
+			})
+
+			JustBeforeEach(func() {
+				server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					var (
+						raw []byte
+						err error
+					)
+
+					if r.Method == http.MethodGet {
+						raw, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+					} else {
+						raw, err = io.ReadAll(r.Body)
+					}
+
+					Expect(err).Should(Succeed())
+
+					query := new(dns.Msg)
+					Expect(query.Unpack(raw)).Should(Succeed())
+
+					response := respFn(query)
+					response.SetReply(query)
+
+					b, err := response.Pack()
+					Expect(err).Should(Succeed())
+
+					requests <- r
+
+					w.Header().Set("Content-Type", dnsContentType)
+					_, _ = w.Write(b)
+				}))
+				DeferCleanup(server.Close)
+
+				upstream, err := config.ParseUpstream(server.URL + path)
+				Expect(err).Should(Succeed())
+
+				upstream.Provider = provider
+
+				sutConfig.Upstream = upstream
+				sutConfig.DoH = map[string]config.DoHUpstream{upstream.String(): options}
+				sut = newUpstreamResolverUnchecked(sutConfig, nil)
+
+				transport().TLSClientConfig.InsecureSkipVerify = true
+			})
+
+			resolve := func() *http.Request {
+				request := newRequestWithClient("example.com.", A, "192.168.178.2", "My Laptop.fritz.box")
+
+				response, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+				Expect(response.Res.Id).Should(Equal(request.Req.Id))
+				Expect(response).Should(BeDNSRecord("example.com.", A, "123.124.122.122"))
+
+				return <-requests
+			}
+
+			It("should use POST by default", func() {
+				req := resolve()
+
+				Expect(req.Method).Should(Equal(http.MethodPost))
+				Expect(req.URL.Path).Should(Equal("/dns-query"))
+				Expect(req.URL.RawQuery).Should(BeEmpty())
+				Expect(req.Header.Get("Content-Type")).Should(Equal(dnsContentType))
+			})
+
+			When("the path is a URI template", func() {
+				BeforeEach(func() {
+					path = "/dns-query?profile=abc{&dns}"
+				})
+
+				It("should use GET with the query as parameter", func() {
+					req := resolve()
+
+					Expect(req.Method).Should(Equal(http.MethodGet))
+					Expect(req.URL.Path).Should(Equal("/dns-query"))
+					Expect(req.URL.Query().Get("profile")).Should(Equal("abc"))
+					Expect(req.URL.Query().Get("dns")).ShouldNot(BeEmpty())
+					Expect(req.Header.Get("Accept")).Should(Equal(dnsContentType))
+				})
+
+				When("POST is configured", func() {
+					BeforeEach(func() {
+						options.Method = config.DoHMethodPost
+					})
+
+					It("should use POST without the template", func() {
+						req := resolve()
+
+						Expect(req.Method).Should(Equal(http.MethodPost))
+						Expect(req.URL.RawQuery).Should(Equal("profile=abc"))
+					})
+				})
+			})
+
+			When("GET is configured", func() {
+				BeforeEach(func() {
+					options.Method = config.DoHMethodGet
+				})
+
+				It("should send the query with ID 0", func() {
+					req := resolve()
+
+					Expect(req.Method).Should(Equal(http.MethodGet))
+
+					raw, err := base64.RawURLEncoding.DecodeString(req.URL.Query().Get("dns"))
+					Expect(err).Should(Succeed())
+
+					query := new(dns.Msg)
+					Expect(query.Unpack(raw)).Should(Succeed())
+					Expect(query.Id).Should(BeZero())
+				})
+			})
+
+			When("headers are configured", func() {
+				BeforeEach(func() {
+					options.Headers = map[string]string{"Authorization": "Bearer token"}
+				})
+
+				It("should send them", func() {
+					Expect(resolve().Header.Get("Authorization")).Should(Equal("Bearer token"))
+				})
+			})
+
+			When("the upstream is a NextDNS profile", func() {
+				BeforeEach(func() {
+					provider = config.ProfileProviderNextDNS
+					path = "/abc123"
+				})
+
+				It("should send the device headers", func() {
+					req := resolve()
+
+					Expect(req.URL.Path).Should(Equal("/abc123"))
+					Expect(req.Header.Get("X-Device-Name")).Should(Equal("My--Laptop-fritz-box"))
+					Expect(req.Header.Get("X-Device-Ip")).Should(Equal("192.168.178.2"))
+				})
+
+				When("the profile has a fixed device", func() {
+					BeforeEach(func() {
+						path = "/abc123/blocky"
+					})
+
+					It("should not send the device headers", func() {
+						req := resolve()
+
+						Expect(req.URL.Path).Should(Equal("/abc123/blocky"))
+						Expect(req.Header).ShouldNot(HaveKey("X-Device-Name"))
+						Expect(req.Header).ShouldNot(HaveKey("X-Device-Ip"))
+					})
+				})
+			})
+
+			When("the upstream is a ControlD profile", func() {
+				BeforeEach(func() {
+					provider = config.ProfileProviderControlD
+					path = "/abc123"
+				})
+
+				It("should add the device to the path", func() {
+					req := resolve()
+
+					Expect(req.URL.Path).Should(Equal("/abc123/My--Laptop-fritz-box"))
+					Expect(req.Header).ShouldNot(HaveKey("X-Device-Name"))
+				})
+			})
+		})
+
 		When("Configured DoH resolver does not respond", func() {
 			JustBeforeEach(func() {
 				sutConfig.Upstream = config.Upstream{
@@ -309,3 +831,26 @@ var _ = Describe("UpstreamResolver", Label("upstreamResolver"), func() {
 		})
 	})
 })
+
+// fakeUpstreamClient answers the queries with the functions in order
+type fakeUpstreamClient struct {
+	answers []func(*dns.Msg) *dns.Msg
+
+	calls   int
+	queries []*dns.Msg
+}
+
+func (c *fakeUpstreamClient) fmtURL(ip net.IP, port uint16, _ string) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+}
+
+func (c *fakeUpstreamClient) callExternal(
+	_ context.Context, msg *dns.Msg, _ string, _ *Request,
+) (*dns.Msg, time.Duration, error) {
+	answer := c.answers[c.calls]
+
+	c.calls++
+	c.queries = append(c.queries, msg)
+
+	return answer(msg), time.Millisecond, nil
+}
@@ -0,0 +1,176 @@
+package resolver
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+)
+
+// number of the latest response times, which are used for the latency percentiles
+const upstreamStatsLatencySamples = 1000
+
+const (
+	upstreamStateHealthy     = "healthy"
+	upstreamStateUnreachable = "unreachable"
+	upstreamStateHijacked    = "hijacked"
+	upstreamStateDisabled    = "disabled"
+)
+
+// upstreamStats collects the statistics of an upstream since start
+type upstreamStats struct {
+	queries  atomic.Int64
+	errors   atomic.Int64
+	selected atomic.Int64
+
+	// ring buffer of the latest response times
+	lock      sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+func (s *upstreamStats) recordResponse(rtt time.Duration) {
+	s.queries.Add(1)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.latencies) < upstreamStatsLatencySamples {
+		s.latencies = append(s.latencies, rtt)
+
+		return
+	}
+
+	s.latencies[s.next] = rtt
+	s.next = (s.next + 1) % upstreamStatsLatencySamples
+}
+
+func (s *upstreamStats) recordError() {
+	s.queries.Add(1)
+	s.errors.Add(1)
+}
+
+// percentiles returns the latency percentiles of the latest responses, zero if there are none
+func (s *upstreamStats) percentiles(ps ...float64) []time.Duration {
+	s.lock.Lock()
+	sorted := slices.Clone(s.latencies)
+	s.lock.Unlock()
+
+	slices.Sort(sorted)
+
+	result := make([]time.Duration, len(ps))
+
+	if len(sorted) == 0 {
+		return result
+	}
+
+	for i, p := range ps {
+		idx := int(p*float64(len(sorted)) + 0.5) //nolint:mnd // round to the nearest rank
+		idx = min(max(idx-1, 0), len(sorted)-1)
+
+		result[i] = sorted[idx]
+	}
+
+	return result
+}
+
+// markSelected counts the response of the resolver as used to answer the query
+func markSelected(res Resolver) {
+	if upstream, ok := res.(*UpstreamResolver); ok {
+		upstream.stats.selected.Add(1)
+	}
+}
+
+// state returns the current health state of the upstream
+func (r *UpstreamResolver) state() string {
+	switch {
+	case r.disabled.Load():
+		return upstreamStateDisabled
+	case r.hijacked.Load():
+		return upstreamStateHijacked
+	case r.unreachable.Load():
+		return upstreamStateUnreachable
+	default:
+		return upstreamStateHealthy
+	}
+}
+
+// CollectUpstreamStats returns the statistics of all upstreams of the upstream tree
+func CollectUpstreamStats(upstreams Resolver) []api.UpstreamStats {
+	var result []api.UpstreamStats
+
+	selectedPerGroup := make(map[string]int64)
+	weightings := make(map[*UpstreamResolver]*api.UpstreamWeighting)
+
+	collectWeightings(Unwrap(upstreams), weightings)
+
+	forEachUpstream(Unwrap(upstreams), func(group string, upstream *UpstreamResolver) {
+		//nolint:mnd // median, 90th and 99th percentile
+		latencies := upstream.stats.percentiles(0.5, 0.9, 0.99)
+		selected := upstream.stats.selected.Load()
+
+		selectedPerGroup[group] += selected
+
+		result = append(result, api.UpstreamStats{
+			Group:      group,
+			Upstream:   upstream.cfg.String(),
+			State:      upstream.state(),
+			Queries:    upstream.stats.queries.Load(),
+			Errors:     upstream.stats.errors.Load(),
+			Selected:   selected,
+			LatencyP50: latencies[0],
+			LatencyP90: latencies[1],
+			LatencyP99: latencies[2],
+			Weighting:  weightings[upstream],
+		})
+	})
+
+	for i, stats := range result {
+		if total := selectedPerGroup[stats.Group]; total > 0 {
+			result[i].SelectionShare = float64(stats.Selected) / float64(total)
+		}
+	}
+
+	// the groups are iterated in random order, the upstreams of a group in configuration order
+	slices.SortStableFunc(result, func(a, b api.UpstreamStats) int {
+		return cmp.Compare(a.Group, b.Group)
+	})
+
+	return result
+}
+
+// collectWeightings adds the current weights of all upstreams picked by weighted random
+func collectWeightings(res Resolver, weightings map[*UpstreamResolver]*api.UpstreamWeighting) {
+	switch r := res.(type) {
+	case *UpstreamTreeResolver:
+		for _, branch := range r.branches {
+			collectWeightings(branch, weightings)
+		}
+	case *ParallelBestResolver:
+		statuses := *r.resolvers.Load()
+		scores := currentScores(statuses)
+		weights := upstreamWeights(scores)
+
+		var total float64
+		for _, weight := range weights {
+			total += weight
+		}
+
+		for i, status := range statuses {
+			upstream, ok := status.resolver.(*UpstreamResolver)
+			if !ok {
+				continue
+			}
+
+			weighting := &api.UpstreamWeighting{Latency: scores[i].latency, ErrorRate: scores[i].errorRate}
+			if total > 0 {
+				weighting.Weight = weights[i] / total
+			}
+
+			weightings[upstream] = weighting
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpstreamStats", func() {
+	Describe("upstreamStats", func() {
+		var sut *upstreamStats
+
+		BeforeEach(func() {
+			sut = &upstreamStats{}
+		})
+
+		It("should return zero percentiles without responses", func() {
+			Expect(sut.percentiles(0.5, 0.99)).Should(Equal([]time.Duration{0, 0}))
+		})
+
+		It("should return the percentiles of the response times", func() {
+			for i := 100; i > 0; i-- {
+				sut.recordResponse(time.Duration(i) * time.Millisecond)
+			}
+
+			Expect(sut.percentiles(0.5, 0.9, 0.99)).Should(Equal([]time.Duration{
+				50 * time.Millisecond, 90 * time.Millisecond, 99 * time.Millisecond,
+			}))
+			Expect(sut.queries.Load()).Should(BeEquivalentTo(100))
+		})
+
+		It("should only keep the latest response times", func() {
+			for range upstreamStatsLatencySamples {
+				sut.recordResponse(time.Second)
+			}
+
+			for range upstreamStatsLatencySamples {
+				sut.recordResponse(time.Millisecond)
+			}
+
+			Expect(sut.latencies).Should(HaveLen(upstreamStatsLatencySamples))
+			Expect(sut.percentiles(0.99)).Should(Equal([]time.Duration{time.Millisecond}))
+		})
+
+		It("should count errors as queries", func() {
+			sut.recordError()
+
+			Expect(sut.queries.Load()).Should(BeEquivalentTo(1))
+			Expect(sut.errors.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("CollectUpstreamStats", func() {
+		var (
+			ctx       context.Context
+			sut       *ParallelBestResolver
+			upstreams []config.Upstream
+		)
+
+		BeforeEach(func() {
+			var cancelFn context.CancelFunc
+			ctx, cancelFn = context.WithCancel(context.Background())
+			DeferCleanup(cancelFn)
+
+			fastUpstream := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A 123.124.122.122")
+			slowUpstream := NewMockUDPUpstreamServer().
+				WithAnswerRR("example.com 123 IN A 123.124.122.123").
+				WithDelay(timeout / 2)
+
+			upstreams = []config.Upstream{fastUpstream.Start(), slowUpstream.Start()}
+
+			upstreamsCfg := config.Upstreams{Timeout: config.Duration(timeout)}
+
+			var err error
+
+			sut, err = NewParallelBestResolver(ctx, config.NewUpstreamGroup("test", upstreamsCfg, upstreams),
+				systemResolverBootstrap)
+			Expect(err).Should(Succeed())
+		})
+
+		It("should count the selected responses of each upstream", func() {
+			for range 3 {
+				_, err := sut.Resolve(ctx, newRequest("example.com.", A))
+				Expect(err).Should(Succeed())
+			}
+
+			stats := CollectUpstreamStats(sut)
+			Expect(stats).Should(HaveLen(2))
+
+			fast := stats[0]
+			Expect(fast.Group).Should(Equal("test"))
+			Expect(fast.Upstream).Should(Equal(upstreams[0].String()))
+			Expect(fast.State).Should(Equal("healthy"))
+			Expect(fast.Queries).Should(BeEquivalentTo(4)) // incl. the initial test query
+			Expect(fast.Errors).Should(BeZero())
+			Expect(fast.Selected).Should(BeEquivalentTo(3))
+			Expect(fast.SelectionShare).Should(BeNumerically("==", 1))
+			Expect(fast.LatencyP50).Should(BeNumerically(">", 0))
+
+			slow := stats[1]
+			Expect(slow.Selected).Should(BeZero())
+			Expect(slow.SelectionShare).Should(BeZero())
+		})
+
+		It("should return the current weights", func() {
+			stats := CollectUpstreamStats(sut)
+
+			Expect(stats[0].Weighting).ShouldNot(BeNil())
+			Expect(stats[1].Weighting).ShouldNot(BeNil())
+			Expect(stats[0].Weighting.Weight + stats[1].Weighting.Weight).Should(BeNumerically("~", 1, 0.001))
+		})
+
+		It("should return the health state", func() {
+			upstream := (*sut.resolvers.Load())[1].resolver.(*UpstreamResolver)
+			upstream.disabled.Store(true)
+
+			Expect(CollectUpstreamStats(sut)[1].State).Should(Equal("disabled"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/0xERR0R/blocky/config"
@@ -20,11 +21,13 @@ type UpstreamTreeResolver struct {
 	configurable[*config.Upstreams]
 	typed
 
-	branches map[string]Resolver
+	branches             map[string]Resolver
+	clientGroupsUpstream map[string]string
 }
 
 func NewUpstreamTreeResolver(ctx context.Context, cfg config.Upstreams, bootstrap *Bootstrap) (Resolver, error) {
-	if len(cfg.Groups[upstreamDefaultCfgName]) == 0 {
+	_, discovered := cfg.Discovery[upstreamDefaultCfgName]
+	if len(cfg.Groups[upstreamDefaultCfgName]) == 0 && !discovered {
 		return nil, fmt.Errorf("no external DNS resolvers configured as default upstream resolvers. "+
 			"Please configure at least one under '%s' configuration name", upstreamDefaultCfgName)
 	}
@@ -45,26 +48,63 @@ func NewUpstreamTreeResolver(ctx context.Context, cfg config.Upstreams, bootstra
 		configurable: withConfig(&cfg),
 		typed:        withType(upstreamTreeResolverType),
 
-		branches: branches,
+		branches:             branches,
+		clientGroupsUpstream: clientGroupsUpstream(cfg),
 	}
 
 	return &r, nil
 }
 
+// clientGroupsUpstream splits the comma separated client identifiers of `cfg.ClientGroupsUpstream`
+func clientGroupsUpstream(cfg config.Upstreams) map[string]string {
+	cgu := make(map[string]string, len(cfg.ClientGroupsUpstream))
+
+	for identifier, group := range cfg.ClientGroupsUpstream {
+		if !cfg.HasGroup(group) {
+			continue
+		}
+
+		for _, ipart := range strings.Split(strings.ToLower(identifier), ",") {
+			cgu[strings.TrimSpace(ipart)] = group
+		}
+	}
+
+	return cgu
+}
+
 func createUpstreamBranches(
 	ctx context.Context, cfg config.Upstreams, bootstrap *Bootstrap,
 ) (map[string]Resolver, error) {
-	branches := make(map[string]Resolver, len(cfg.Groups))
-	errs := make([]error, 0, len(cfg.Groups))
+	groups := cfg.GroupNames()
+	branches := make(map[string]Resolver, len(groups))
+	errs := make([]error, 0, len(groups))
 
-	for group, upstreams := range cfg.Groups {
+	for _, group := range groups {
 		var (
-			upstream Resolver
-			err      error
+			upstream  Resolver
+			discovery *upstreamDiscovery
+			registry  *registryWatch
+			err       error
 		)
 
+		upstreams := cfg.Groups[group]
+
+		if _, ok := cfg.Discovery[group]; ok {
+			discovery = newUpstreamDiscovery(group, cfg, bootstrap)
+			upstreams = discovery.initialUpstreams(ctx)
+		} else if config.HasRegistryUpstreams(upstreams) {
+			registry = newRegistryWatch(group, cfg, upstreams, bootstrap)
+			upstreams = registry.initialUpstreams(ctx)
+		}
+
 		groupConfig := config.NewUpstreamGroup(group, cfg, upstreams)
 
+		if err := validateUpstreamBinding(groupConfig.Binding()); err != nil {
+			errs = append(errs, fmt.Errorf("group %s: %w", group, err))
+
+			continue
+		}
+
 		switch cfg.Strategy {
 		case config.UpstreamStrategyParallelBest:
 			fallthrough
@@ -80,6 +120,16 @@ func createUpstreamBranches(
 			continue
 		}
 
+		if target, ok := upstream.(initializable); ok {
+			if discovery != nil {
+				discovery.start(ctx, target)
+			}
+
+			if registry != nil {
+				registry.start(ctx, target)
+			}
+		}
+
 		branches[group] = upstream
 	}
 
@@ -107,21 +157,55 @@ func (r *UpstreamTreeResolver) String() string {
 func (r *UpstreamTreeResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
 	ctx, logger := r.log(ctx)
 
-	group := r.upstreamGroupByClient(logger, request)
+	group, matches := r.upstreamGroupByClient(request)
+	if len(matches) > 1 {
+		logger.WithFields(logrus.Fields{
+			"clientNames": request.ClientNames,
+			"clientIP":    request.ClientIP.String(),
+			"groups":      matches,
+		}).Warn("client matches multiple groups")
+	}
 
 	// delegate request to group resolver
 	logger.WithField("resolver", fmt.Sprintf("%s (%s)", group, r.branches[group].Type())).Debug("delegating to resolver")
 
-	return r.branches[group].Resolve(ctx, request)
+	resp, err := r.branches[group].Resolve(ctx, request)
+	if err == nil && resp.Upstream != nil {
+		resp.Upstream.Group = group
+	}
+
+	return resp, err
 }
 
-func (r *UpstreamTreeResolver) upstreamGroupByClient(logger *logrus.Entry, request *model.Request) string {
-	groups := make([]string, 0, len(r.branches))
+// UpstreamGroup returns the name of the upstream group, which resolves the request
+func (r *UpstreamTreeResolver) UpstreamGroup(request *model.Request) string {
+	group, _ := r.upstreamGroupByClient(request)
+
+	return group
+}
+
+// upstreamGroupByClient returns the upstream group of the client and all groups matching the client
+func (r *UpstreamTreeResolver) upstreamGroupByClient(request *model.Request) (string, []string) {
+	// the group of the request, e.g. of a refreshed cache entry, replaces the group of the client
+	if _, exists := r.branches[request.UpstreamGroup]; exists {
+		return request.UpstreamGroup, nil
+	}
+
 	clientIP := request.ClientIP.String()
 
+	// try the client groups mapping
+	if groups := matchingClientGroups(request, r.clientGroupsUpstream, nil); len(groups) > 0 {
+		slices.Sort(groups)
+		groups = slices.Compact(groups)
+
+		return groups[0], groups
+	}
+
+	groups := make([]string, 0, len(r.branches))
+
 	// try IP
 	if _, exists := r.branches[clientIP]; exists {
-		return clientIP
+		return clientIP, nil
 	}
 
 	// try client names
@@ -143,16 +227,12 @@ func (r *UpstreamTreeResolver) upstreamGroupByClient(logger *logrus.Entry, reque
 	}
 
 	if len(groups) > 0 {
-		if len(groups) > 1 {
-			logger.WithFields(logrus.Fields{
-				"clientNames": request.ClientNames,
-				"clientIP":    clientIP,
-				"groups":      groups,
-			}).Warn("client matches multiple groups")
-		}
+		return groups[0], groups
+	}
 
-		return groups[0]
+	if group, ok := r.clientGroupsUpstream[upstreamDefaultCfgName]; ok {
+		return group, nil
 	}
 
-	return upstreamDefaultCfgName
+	return upstreamDefaultCfgName, nil
 }
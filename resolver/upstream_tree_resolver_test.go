@@ -177,6 +177,16 @@ var _ = Describe("UpstreamTreeResolver", Label("upstreamTreeResolver"), func() {
 							HaveReturnCode(dns.RcodeSuccess),
 						))
 			})
+			It("Should return the group of the upstream, which answered the query", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.55", "laptop")
+
+				resp, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+
+				Expect(resp.Upstream).ShouldNot(BeNil())
+				Expect(resp.Upstream.Group).Should(Equal("laptop"))
+				Expect(resp.Upstream.Name).Should(Equal(sutConfig.Groups["laptop"][0].String()))
+			})
 			It("Should use client specific resolver if client name matches exact", func() {
 				request := newRequestWithClient("example.com.", A, "192.168.178.55", "laptop")
 
@@ -284,5 +294,81 @@ var _ = Describe("UpstreamTreeResolver", Label("upstreamTreeResolver"), func() {
 				Expect(hook.Messages).Should(ContainElement(ContainSubstring("client matches multiple groups")))
 			})
 		})
+
+		When("client groups are mapped to upstream groups", func() {
+			groups := map[string]string{
+				upstreamDefaultCfgName: "127.0.0.1",
+				"family":               "127.0.0.2",
+				"unfiltered":           "127.0.0.3",
+				"laptop":               "127.0.0.4",
+			}
+
+			BeforeEach(func() {
+				sutConfig.Groups = make(config.UpstreamGroups, len(groups))
+
+				for group, ip := range groups {
+					server := NewMockUDPUpstreamServer().WithAnswerRR("example.com 123 IN A " + ip)
+					sutConfig.Groups[group] = []config.Upstream{server.Start()}
+				}
+
+				sutConfig.ClientGroupsUpstream = map[string]string{
+					"kid-*":                 "family",
+					"tag:kids, 10.0.0.0/24": "family",
+					"adult-laptop":          "unfiltered",
+					"laptop":                "family",
+					"unknown-group-client":  "unknown",
+				}
+			})
+
+			DescribeTable("should use the upstream group of the matching client group",
+				func(clientIP string, clientName string, tags []string, expectedGroup string) {
+					request := newRequestWithClient("example.com.", A, clientIP, clientName)
+					request.ClientTags = tags
+
+					resp, err := sut.Resolve(ctx, request)
+					Expect(err).Should(Succeed())
+					Expect(resp.Res.Answer).Should(HaveLen(1))
+					Expect(resp.Res.Answer[0].(*dns.A).A.String()).Should(Equal(groups[expectedGroup]))
+					Expect(resp.Upstream.Group).Should(Equal(expectedGroup))
+					Expect(sut.(*UpstreamTreeResolver).UpstreamGroup(request)).Should(Equal(expectedGroup))
+				},
+				Entry("client name with wildcard", "192.168.178.2", "kid-tablet", nil, "family"),
+				Entry("client name", "192.168.178.2", "adult-laptop", nil, "unfiltered"),
+				Entry("tag", "192.168.178.2", "tv", []string{"kids"}, "family"),
+				Entry("CIDR", "10.0.0.5", "tv", nil, "family"),
+				Entry("mapping before group name", "192.168.178.2", "laptop", nil, "family"),
+				Entry("unknown upstream group", "192.168.178.2", "unknown-group-client", nil, "default"),
+				Entry("no match", "192.168.178.2", "tv", nil, "default"),
+			)
+
+			It("should use the upstream group of the request instead of the client", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.2", "adult-laptop")
+				request.UpstreamGroup = "family"
+
+				resp, err := sut.Resolve(ctx, request)
+				Expect(err).Should(Succeed())
+				Expect(resp.Upstream.Group).Should(Equal("family"))
+				Expect(sut.(*UpstreamTreeResolver).UpstreamGroup(request)).Should(Equal("family"))
+			})
+
+			It("should ignore an unknown upstream group of the request", func() {
+				request := newRequestWithClient("example.com.", A, "192.168.178.2", "adult-laptop")
+				request.UpstreamGroup = "unknown"
+
+				Expect(sut.(*UpstreamTreeResolver).UpstreamGroup(request)).Should(Equal("unfiltered"))
+			})
+
+			When("a default client group is mapped", func() {
+				BeforeEach(func() {
+					sutConfig.ClientGroupsUpstream[upstreamDefaultCfgName] = "unfiltered"
+				})
+
+				It("should use its upstream group, if no client group matches", func() {
+					resp, err := sut.Resolve(ctx, newRequestWithClient("example.com.", A, "192.168.178.2", "tv"))
+					Expect(err).Should(Succeed())
+					Expect(resp.Upstream.Group).Should(Equal("unfiltered"))
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,134 @@
+package resolver
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	mismatchID       = "id"
+	mismatchQuestion = "question"
+	mismatchCase     = "case"
+)
+
+// responseMismatchError is returned, if the response of an upstream doesn't belong to the query,
+// e.g. a spoofed or late response
+type responseMismatchError struct {
+	// reason is one of `mismatchID`, `mismatchQuestion` or `mismatchCase`
+	reason string
+	detail string
+}
+
+func (e *responseMismatchError) Error() string {
+	return fmt.Sprintf("response doesn't match the query (%s): %s", e.reason, e.detail)
+}
+
+func isResponseMismatch(err error) bool {
+	var mismatchErr *responseMismatchError
+
+	return errors.As(err, &mismatchErr)
+}
+
+// validateResponse checks that the response has the ID and echoes the question of the query.
+// With `exactCase` the name must be echoed with the same case (0x20 encoding).
+func validateResponse(query, response *dns.Msg, exactCase bool) error {
+	if response.Id != query.Id {
+		return &responseMismatchError{mismatchID, fmt.Sprintf("expected ID %d, got %d", query.Id, response.Id)}
+	}
+
+	// some servers omit the question of errors like REFUSED or FORMERR
+	if len(response.Question) == 0 && response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil
+	}
+
+	if len(response.Question) != len(query.Question) {
+		return &responseMismatchError{
+			mismatchQuestion,
+			fmt.Sprintf("expected %d questions, got %d", len(query.Question), len(response.Question)),
+		}
+	}
+
+	for i, q := range query.Question {
+		r := response.Question[i]
+
+		if r.Qtype != q.Qtype || r.Qclass != q.Qclass || !strings.EqualFold(r.Name, q.Name) {
+			return &responseMismatchError{mismatchQuestion, fmt.Sprintf("expected %s, got %s", &q, &r)}
+		}
+
+		if exactCase && r.Name != q.Name {
+			return &responseMismatchError{mismatchCase, fmt.Sprintf("expected %s, got %s", q.Name, r.Name)}
+		}
+	}
+
+	return nil
+}
+
+// withRandomizedCase returns a copy of the query with randomly changed case of the question names (0x20 encoding),
+// which makes spoofed responses less likely to be accepted
+func withRandomizedCase(query *dns.Msg) *dns.Msg {
+	result := query.Copy()
+
+	for i := range result.Question {
+		result.Question[i].Name = randomizeCase(result.Question[i].Name)
+	}
+
+	return result
+}
+
+func randomizeCase(name string) string {
+	const bitsPerByte = 8
+
+	bits := make([]byte, (len(name)+bitsPerByte-1)/bitsPerByte)
+	_, _ = rand.Read(bits)
+
+	result := []byte(name)
+
+	for i, c := range result {
+		if bits[i/bitsPerByte]&(1<<(i%bitsPerByte)) == 0 {
+			continue
+		}
+
+		switch {
+		case c >= 'a' && c <= 'z':
+			result[i] = c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z':
+			result[i] = c - 'A' + 'a'
+		}
+	}
+
+	return string(result)
+}
+
+// restoreCase replaces the randomized names of the response with the names of the original query.
+// The names are compared case-insensitively, since some servers don't echo the case in the records.
+func restoreCase(query, sent, response *dns.Msg) {
+	for i, q := range sent.Question {
+		if i >= len(query.Question) {
+			break
+		}
+
+		name := query.Question[i].Name
+
+		for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+			for _, rr := range section {
+				if strings.EqualFold(rr.Header().Name, q.Name) {
+					rr.Header().Name = name
+				}
+
+				if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Target, q.Name) {
+					cname.Target = name
+				}
+			}
+		}
+	}
+
+	for i := range response.Question {
+		if i < len(query.Question) {
+			response.Question[i].Name = query.Question[i].Name
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package resolver
+
+import (
+	"strings"
+
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Upstream response validation", func() {
+	var query, response *dns.Msg
+
+	BeforeEach(func() {
+		query = util.NewMsgWithQuestion("example.com.", A)
+
+		response = new(dns.Msg)
+		response.SetReply(query)
+	})
+
+	Describe("validateResponse", func() {
+		It("should accept a matching response", func() {
+			Expect(validateResponse(query, response, true)).Should(Succeed())
+		})
+
+		It("should reject a response with a different ID", func() {
+			response.Id = query.Id + 1
+
+			err := validateResponse(query, response, false)
+			Expect(isResponseMismatch(err)).Should(BeTrue())
+			Expect(err).Should(MatchError(ContainSubstring("(id)")))
+		})
+
+		It("should reject a response to a different question", func() {
+			response.Question[0].Name = "other.com."
+
+			Expect(validateResponse(query, response, false)).Should(MatchError(ContainSubstring("(question)")))
+		})
+
+		It("should reject a response with a different query type", func() {
+			response.Question[0].Qtype = dns.TypeAAAA
+
+			Expect(validateResponse(query, response, false)).Should(MatchError(ContainSubstring("(question)")))
+		})
+
+		It("should reject a response without question", func() {
+			response.Question = nil
+
+			Expect(validateResponse(query, response, false)).Should(MatchError(ContainSubstring("(question)")))
+		})
+
+		It("should accept an error response without question", func() {
+			response.Question = nil
+			response.Rcode = dns.RcodeRefused
+
+			Expect(validateResponse(query, response, false)).Should(Succeed())
+		})
+
+		When("the case is not checked", func() {
+			It("should accept a name with a different case", func() {
+				response.Question[0].Name = "EXAMPLE.com."
+
+				Expect(validateResponse(query, response, false)).Should(Succeed())
+			})
+		})
+
+		When("the case is checked", func() {
+			It("should reject a name with a different case", func() {
+				response.Question[0].Name = "EXAMPLE.com."
+
+				Expect(validateResponse(query, response, true)).Should(MatchError(ContainSubstring("(case)")))
+			})
+		})
+	})
+
+	Describe("withRandomizedCase", func() {
+		It("should only change the case of the name", func() {
+			query = util.NewMsgWithQuestion("some-long.example.com.", A)
+
+			randomized := withRandomizedCase(query)
+
+			Expect(randomized.Id).Should(Equal(query.Id))
+			Expect(query.Question[0].Name).Should(Equal("some-long.example.com."))
+			Expect(strings.ToLower(randomized.Question[0].Name)).Should(Equal("some-long.example.com."))
+		})
+
+		It("should change the case randomly", func() {
+			query = util.NewMsgWithQuestion("some-long.example.com.", A)
+
+			Eventually(func() string {
+				return withRandomizedCase(query).Question[0].Name
+			}).ShouldNot(Equal("some-long.example.com."))
+		})
+	})
+
+	Describe("restoreCase", func() {
+		It("should restore the names of the question and answer", func() {
+			sent := query.Copy()
+			sent.Question[0].Name = "eXaMple.CoM."
+
+			response, err := util.NewMsgWithAnswer("eXaMple.CoM.", 300, A, "1.2.3.4")
+			Expect(err).Should(Succeed())
+			response.Question = sent.Question
+
+			restoreCase(query, sent, response)
+
+			Expect(response.Question[0].Name).Should(Equal("example.com."))
+			Expect(response.Answer[0].Header().Name).Should(Equal("example.com."))
+		})
+
+		It("should restore the names of all sections case-insensitively", func() {
+			sent := query.Copy()
+			sent.Question[0].Name = "eXaMple.CoM."
+
+			response.SetReply(sent)
+
+			for _, record := range []string{
+				"EXAMPLE.com. 300 IN CNAME cdn.net.",
+				"alias.net. 300 IN CNAME example.COM.",
+				"eXaMple.CoM. 300 IN A 1.2.3.4",
+			} {
+				rr, err := dns.NewRR(record)
+				Expect(err).Should(Succeed())
+
+				response.Answer = append(response.Answer, rr)
+			}
+
+			response.Extra = response.Answer[2:]
+			response.Answer = response.Answer[:2]
+
+			restoreCase(query, sent, response)
+
+			Expect(response.Question[0].Name).Should(Equal("example.com."))
+			Expect(response.Answer[0].Header().Name).Should(Equal("example.com."))
+			Expect(response.Answer[1].(*dns.CNAME).Target).Should(Equal("example.com."))
+			Expect(response.Extra[0].Header().Name).Should(Equal("example.com."))
+		})
+
+		It("should restore the names of a NXDOMAIN response with SOA", func() {
+			sent := query.Copy()
+			sent.Question[0].Name = "eXaMple.CoM."
+
+			soa, err := dns.NewRR("eXaMple.CoM. 300 IN SOA ns.example.com. admin.example.com. 1 7200 900 1209600 300")
+			Expect(err).Should(Succeed())
+
+			response.SetRcode(sent, dns.RcodeNameError)
+			response.Ns = []dns.RR{soa}
+
+			restoreCase(query, sent, response)
+
+			Expect(response.Question[0].Name).Should(Equal("example.com."))
+			Expect(response.Ns[0].Header().Name).Should(Equal("example.com."))
+			Expect(validateResponse(query, response, true)).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,130 @@
+package resolver
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// scale of the weights for the weighted random choice, the minimum weight is 1
+	upstreamWeightScale = 1000
+
+	// added to the response times, so small differences (e.g. between local upstreams) don't change the weight
+	upstreamLatencySmoothing = 10 * time.Millisecond
+)
+
+// upstreamScore tracks the response times and errors of an upstream. The samples decay exponentially with the
+// half-life: an error counts half after one half-life, so the weight of an upstream recovers over time.
+type upstreamScore struct {
+	halfLife time.Duration
+
+	lock    sync.Mutex
+	updated time.Time
+
+	// decayed sums of all samples, errors and the response times of successful queries
+	samples    float64
+	errors     float64
+	successes  float64
+	latencySum time.Duration
+}
+
+// upstreamScoreSnapshot is the decayed score at a point in time
+type upstreamScoreSnapshot struct {
+	// probability of an error, 0 without samples
+	errorRate float64
+	// average response time, 0 without successful samples
+	latency time.Duration
+	// weight of the successful samples of the latency
+	successes float64
+}
+
+func newUpstreamScore(halfLife time.Duration) *upstreamScore {
+	return &upstreamScore{halfLife: halfLife}
+}
+
+func (s *upstreamScore) recordSuccess(now time.Time, rtt time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(now)
+
+	s.samples++
+	s.successes++
+	s.latencySum += rtt
+}
+
+func (s *upstreamScore) recordError(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(now)
+
+	s.samples++
+	s.errors++
+}
+
+func (s *upstreamScore) snapshot(now time.Time) upstreamScoreSnapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.decay(now)
+
+	// one neutral prior sample: an upstream with a few errors is not excluded and recovers without new samples
+	result := upstreamScoreSnapshot{
+		errorRate: s.errors / (s.samples + 1),
+		successes: s.successes,
+	}
+
+	if s.successes > 0 {
+		result.latency = time.Duration(float64(s.latencySum) / s.successes)
+	}
+
+	return result
+}
+
+// decay reduces all sums according to the time since the last update, the lock must be held
+func (s *upstreamScore) decay(now time.Time) {
+	elapsed := now.Sub(s.updated)
+	s.updated = now
+
+	if elapsed <= 0 || s.halfLife <= 0 {
+		return
+	}
+
+	factor := math.Exp2(-float64(elapsed) / float64(s.halfLife))
+
+	s.samples *= factor
+	s.errors *= factor
+	s.successes *= factor
+	s.latencySum = time.Duration(float64(s.latencySum) * factor)
+}
+
+// upstreamWeights returns the weight of each score: the success rate (squared, to avoid failing upstreams early)
+// multiplied with the ratio of the best latency to the own latency. Upstreams without known latency are assumed
+// to be as fast as the best one, so they are tried.
+func upstreamWeights(scores []upstreamScoreSnapshot) []float64 {
+	best := time.Duration(math.MaxInt64)
+
+	for _, score := range scores {
+		if score.successes > 0 && score.latency < best {
+			best = score.latency
+		}
+	}
+
+	weights := make([]float64, len(scores))
+
+	for i, score := range scores {
+		successRate := 1 - score.errorRate
+		weights[i] = successRate * successRate
+
+		if score.successes > 0 {
+			// mean of the own latency and the best latency, weighted with the successful samples
+			latency := (float64(score.latency)*score.successes + float64(best)) / (score.successes + 1)
+
+			weights[i] *= float64(best+upstreamLatencySmoothing) / (latency + float64(upstreamLatencySmoothing))
+		}
+	}
+
+	return weights
+}
@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpstreamWeighting", func() {
+	const halfLife = time.Minute
+
+	var (
+		sut *upstreamScore
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		sut = newUpstreamScore(halfLife)
+		now = time.Now()
+	})
+
+	Describe("upstreamScore", func() {
+		It("should have no errors and latency without samples", func() {
+			Expect(sut.snapshot(now)).Should(Equal(upstreamScoreSnapshot{}))
+		})
+
+		It("should return the average latency", func() {
+			sut.recordSuccess(now, 10*time.Millisecond)
+			sut.recordSuccess(now, 30*time.Millisecond)
+
+			score := sut.snapshot(now)
+			Expect(score.latency).Should(Equal(20 * time.Millisecond))
+			Expect(score.errorRate).Should(BeZero())
+		})
+
+		It("should decay the errors with the half-life", func() {
+			sut.recordError(now)
+
+			Expect(sut.snapshot(now).errorRate).Should(BeNumerically("~", 1.0/2, 0.001))
+			Expect(sut.snapshot(now.Add(halfLife)).errorRate).Should(BeNumerically("~", 0.5/1.5, 0.001))
+			Expect(sut.snapshot(now.Add(10 * halfLife)).errorRate).Should(BeNumerically("<", 0.001))
+		})
+
+		It("should weight newer samples more", func() {
+			sut.recordSuccess(now, 100*time.Millisecond)
+			sut.recordSuccess(now.Add(halfLife), 10*time.Millisecond)
+
+			// (0.5 * 100ms + 10ms) / 1.5
+			Expect(sut.snapshot(now.Add(halfLife)).latency).Should(BeNumerically("~", 40*time.Millisecond, time.Millisecond))
+		})
+
+		When("the half-life is not set", func() {
+			BeforeEach(func() {
+				sut = newUpstreamScore(0)
+			})
+
+			It("should not decay", func() {
+				sut.recordError(now)
+
+				Expect(sut.snapshot(now.Add(time.Hour)).errorRate).Should(BeNumerically("~", 1.0/2, 0.001))
+			})
+		})
+	})
+
+	Describe("upstreamWeights", func() {
+		It("should return the same weight without samples", func() {
+			Expect(upstreamWeights(make([]upstreamScoreSnapshot, 3))).Should(Equal([]float64{1, 1, 1}))
+		})
+
+		It("should prefer faster upstreams", func() {
+			weights := upstreamWeights([]upstreamScoreSnapshot{
+				{latency: 10 * time.Millisecond, successes: 100},
+				{latency: 100 * time.Millisecond, successes: 100},
+			})
+
+			Expect(weights[0]).Should(BeNumerically("==", 1))
+			// (10ms + 10ms) / (~99ms + 10ms)
+			Expect(weights[1]).Should(BeNumerically("~", 0.18, 0.01))
+		})
+
+		It("should ignore small latency differences", func() {
+			weights := upstreamWeights([]upstreamScoreSnapshot{
+				{latency: 100 * time.Microsecond, successes: 100},
+				{latency: 500 * time.Microsecond, successes: 100},
+			})
+
+			Expect(weights[1]).Should(BeNumerically(">", 0.95))
+		})
+
+		It("should reduce the weight of failing upstreams", func() {
+			weights := upstreamWeights([]upstreamScoreSnapshot{
+				{errorRate: 0},
+				{errorRate: 0.5},
+				{errorRate: 0.9},
+			})
+
+			Expect(weights[1]).Should(BeNumerically("~", 0.25, 0.001))
+			Expect(weights[2]).Should(BeNumerically("~", 0.01, 0.001))
+		})
+
+		It("should treat upstreams without latency as the fastest", func() {
+			weights := upstreamWeights([]upstreamScoreSnapshot{
+				{latency: 10 * time.Millisecond, successes: 100},
+				{},
+			})
+
+			Expect(weights).Should(Equal([]float64{1, 1}))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+)
+
+// ZoneVisibilityResolver refuses queries for internal zones, if the request was not received on one of the
+// configured listeners or from one of the configured clients
+type ZoneVisibilityResolver struct {
+	configurable[*config.ZoneVisibility]
+	NextResolver
+	typed
+
+	zones internalZones
+}
+
+// NewZoneVisibilityResolver creates a resolver for the additional zones of the configuration
+// and the domains of custom DNS and conditional mapping
+func NewZoneVisibilityResolver(
+	cfg config.ZoneVisibility, customDNS config.CustomDNS, conditional config.ConditionalUpstream,
+) *ZoneVisibilityResolver {
+	return &ZoneVisibilityResolver{
+		configurable: withConfig(&cfg),
+		typed:        withType("zone_visibility"),
+
+		zones: newInternalZones(cfg.Zones, customDNS, conditional),
+	}
+}
+
+// Resolve refuses the request, if the query is for an internal zone and the client is not allowed to see it
+func (r *ZoneVisibilityResolver) Resolve(ctx context.Context, request *model.Request) (*model.Response, error) {
+	if !r.IsEnabled() || r.isVisible(request) {
+		return r.next.Resolve(ctx, request)
+	}
+
+	zone, internal := r.zones.find(util.ExtractDomain(request.Req.Question[0]))
+	if !internal {
+		return r.next.Resolve(ctx, request)
+	}
+
+	_, logger := r.logWithFields(ctx, logrus.Fields{
+		"zone":     zone,
+		"listener": request.Listener,
+	})
+
+	logger.Debug("refusing query for internal zone")
+
+	response := new(dns.Msg)
+	response.SetRcode(request.Req, dns.RcodeRefused)
+
+	return &model.Response{Res: response, RType: model.ResponseTypeREFUSED, Reason: model.NewReason(model.ReasonCodeINTERNALZONE)}, nil
+}
+
+func (r *ZoneVisibilityResolver) isVisible(request *model.Request) bool {
+	if slices.Contains(r.cfg.Listeners, request.Listener) {
+		return true
+	}
+
+	for _, client := range r.cfg.Clients {
+		if clientMatchesGroup(client, request) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// internalZones is the set of the zones, which are resolved locally
+type internalZones map[string]struct{}
+
+// newInternalZones collects the additional zones and the domains of custom DNS and conditional mapping
+func newInternalZones(
+	additional []string, customDNS config.CustomDNS, conditional config.ConditionalUpstream,
+) internalZones {
+	zones := make(internalZones)
+
+	addZone := func(zone string) {
+		if zone != "." {
+			zone = util.ExtractDomainOnly(zone)
+		}
+
+		zones[zone] = struct{}{}
+	}
+
+	for _, zone := range additional {
+		addZone(zone)
+	}
+
+	for domain := range customDNS.Mapping {
+		addZone(domain)
+	}
+
+	for domain := range customDNS.Zone.RRs {
+		addZone(domain)
+	}
+
+	for domain := range conditional.Mapping.Upstreams {
+		addZone(domain)
+	}
+
+	for zone := range conditional.ReverseZoneDomains() {
+		addZone(zone)
+	}
+
+	return zones
+}
+
+// find returns the internal zone containing the domain
+func (z internalZones) find(domain string) (string, bool) {
+	if !strings.Contains(domain, ".") {
+		// single label names are internal, if conditional mapping "." is configured
+		_, found := z["."]
+
+		return ".", found
+	}
+
+	for zone := domain; len(zone) > 0; {
+		if _, found := z[zone]; found {
+			return zone, true
+		}
+
+		i := strings.Index(zone, ".")
+		if i < 0 {
+			break
+		}
+
+		zone = zone[i+1:]
+	}
+
+	return "", false
+}
@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/0xERR0R/blocky/model"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("ZoneVisibilityResolver", func() {
+	var (
+		sut            *ZoneVisibilityResolver
+		sutConfig      config.ZoneVisibility
+		customDNSCfg   config.CustomDNS
+		conditionalCfg config.ConditionalUpstream
+		m              *mockResolver
+
+		ctx      context.Context
+		cancelFn context.CancelFunc
+	)
+
+	Describe("Type", func() {
+		It("follows conventions", func() {
+			expectValidResolverType(sut)
+		})
+	})
+
+	BeforeEach(func() {
+		ctx, cancelFn = context.WithCancel(context.Background())
+		DeferCleanup(cancelFn)
+
+		sutConfig = config.ZoneVisibility{
+			Zones:     []string{"corp.example"},
+			Listeners: []RequestListener{RequestListenerDns},
+			Clients:   []string{"192.168.178.0/24", "laptop*"},
+		}
+
+		customDNSCfg = config.CustomDNS{
+			Mapping: config.CustomDNSMapping{
+				"printer.lan": {&dns.A{A: []byte{192, 168, 178, 3}}},
+			},
+		}
+
+		conditionalCfg = config.ConditionalUpstream{
+			Mapping: config.ConditionalUpstreamMapping{
+				Upstreams: map[string][]config.Upstream{
+					"fritz.box": {config.Upstream{Host: "192.168.178.1"}},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		sut = NewZoneVisibilityResolver(sutConfig, customDNSCfg, conditionalCfg)
+		m = &mockResolver{}
+		m.On("Resolve", mock.Anything).Return(&Response{Res: new(dns.Msg)}, nil)
+		sut.Next(m)
+	})
+
+	request := func(question string, listener RequestListener, ip string, clientNames ...string) *Request {
+		req := newRequestWithClient(question, A, ip, clientNames...)
+		req.Listener = listener
+
+		return req
+	}
+
+	Describe("IsEnabled", func() {
+		It("is true", func() {
+			Expect(sut.IsEnabled()).Should(BeTrue())
+		})
+
+		When("no listeners and clients are configured", func() {
+			BeforeEach(func() {
+				sutConfig = config.ZoneVisibility{Zones: []string{"corp.example"}}
+			})
+
+			It("is false and delegates all queries", func() {
+				Expect(sut.IsEnabled()).Should(BeFalse())
+
+				Expect(sut.Resolve(ctx, request("corp.example.", RequestListenerHttps, "1.2.3.4"))).
+					Should(HaveResponseType(ResponseTypeRESOLVED))
+			})
+		})
+	})
+
+	Describe("LogConfig", func() {
+		It("should log configuration", func() {
+			logger, hook := log.NewMockEntry()
+
+			sut.LogConfig(logger)
+
+			Expect(hook.Messages).Should(ContainElements(
+				"listeners: dns",
+				"clients: 192.168.178.0/24, laptop*",
+				"additional zones: corp.example",
+			))
+		})
+	})
+
+	When("query is for an internal zone", func() {
+		DescribeTable("should refuse on other listeners",
+			func(question string) {
+				Expect(sut.Resolve(ctx, request(question, RequestListenerHttps, "1.2.3.4"))).
+					Should(SatisfyAll(
+						HaveNoAnswer(),
+						HaveResponseType(ResponseTypeREFUSED),
+						HaveReason("INTERNAL ZONE"),
+						HaveReturnCode(dns.RcodeRefused),
+					))
+
+				Expect(m.Calls).Should(BeEmpty())
+			},
+			Entry("custom DNS mapping", "printer.lan."),
+			Entry("sub domain of custom DNS mapping", "sub.printer.lan."),
+			Entry("conditional mapping", "nas.fritz.box."),
+			Entry("additional zone", "www.corp.example."),
+			Entry("upper case", "NAS.Fritz.Box."),
+		)
+
+		It("should answer on the configured listeners", func() {
+			Expect(sut.Resolve(ctx, request("printer.lan.", RequestListenerDns, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should answer for clients by IP", func() {
+			Expect(sut.Resolve(ctx, request("printer.lan.", RequestListenerHttps, "192.168.178.20"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+
+		It("should answer for clients by name", func() {
+			Expect(sut.Resolve(ctx, request("printer.lan.", RequestListenerHttps, "1.2.3.4", "laptop-1"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+	})
+
+	When("query is for a public domain", func() {
+		It("should delegate to next resolver", func() {
+			Expect(sut.Resolve(ctx, request("example.com.", RequestListenerHttps, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+
+			Expect(m.Calls).Should(HaveLen(1))
+		})
+
+		It("should not match domains with the same suffix", func() {
+			Expect(sut.Resolve(ctx, request("myprinter.lan.", RequestListenerHttps, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+	})
+
+	When("conditional mapping for single label names is configured", func() {
+		BeforeEach(func() {
+			conditionalCfg.Mapping.Upstreams["."] = []config.Upstream{{Host: "192.168.178.1"}}
+		})
+
+		It("should refuse single label names", func() {
+			Expect(sut.Resolve(ctx, request("nas.", RequestListenerHttps, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeREFUSED))
+		})
+
+		It("should not treat all domains as internal", func() {
+			Expect(sut.Resolve(ctx, request("example.com.", RequestListenerHttps, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeRESOLVED))
+		})
+	})
+
+	When("conditional reverse zones are configured", func() {
+		BeforeEach(func() {
+			_, subnet, err := net.ParseCIDR("192.168.178.0/24")
+			Expect(err).Should(Succeed())
+
+			conditionalCfg.ReverseZones = config.ConditionalReverseZones{"fritz.box": {subnet}}
+		})
+
+		It("should refuse reverse lookups of the subnet", func() {
+			Expect(sut.Resolve(ctx, request("5.178.168.192.in-addr.arpa.", RequestListenerHttps, "1.2.3.4"))).
+				Should(HaveResponseType(ResponseTypeREFUSED))
+		})
+	})
+})
@@ -0,0 +1,274 @@
+// Package scripting evaluates policies written in Lua for each query and response.
+//
+// A script defines the global functions `on_query(query)` and/or `on_response(query, response)`. A hook returns
+// nothing to continue the normal processing or the name of a DNS return code (e.g. "REFUSED") to answer the query
+// with this code. Scripts run in a sandbox without access to the file system, the network or the OS, each
+// evaluation is aborted after the timeout.
+//
+// Each evaluation runs the script in a new Lua state, nothing is shared between evaluations. The call stack and the
+// registry (stack of values) of a state are limited, the memory of tables and strings isn't: it is only bounded by
+// the allocations possible within the timeout.
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/log"
+
+	"github.com/miekg/dns"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	// QueryHook is the name of the function, which is called for each query
+	QueryHook = "on_query"
+	// ResponseHook is the name of the function, which is called for each response
+	ResponseHook = "on_response"
+
+	// maximum depth of nested function calls
+	callStackSize = 64
+	// initial and maximum number of values on the stack of a state
+	registrySize    = 1024
+	registryMaxSize = 64 * 1024
+)
+
+// ErrTimeout is returned if the evaluation of a hook exceeds the timeout
+var ErrTimeout = errors.New("script timeout exceeded")
+
+// Query contains the information about a query, which is passed to the hooks
+type Query struct {
+	Name        string
+	Type        string
+	ClientIP    net.IP
+	ClientNames []string
+	Protocol    string
+}
+
+// Response contains the information about a response, which is passed to the response hook
+type Response struct {
+	Rcode      string
+	Type       string
+	Reason     string
+	ReasonCode string
+}
+
+// Script is a compiled Lua script. It is safe for concurrent use: each evaluation uses a new Lua state,
+// therefore global variables are not shared between evaluations.
+type Script struct {
+	proto   *lua.FunctionProto
+	timeout time.Duration
+
+	hasQueryHook    bool
+	hasResponseHook bool
+}
+
+// Compile compiles the Lua code and checks, that it defines at least one hook
+func Compile(name, code string, timeout time.Duration) (*Script, error) {
+	chunk, err := parse.Parse(strings.NewReader(code), name)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse script: %w", err)
+	}
+
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, fmt.Errorf("can't compile script: %w", err)
+	}
+
+	s := &Script{proto: proto, timeout: timeout}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state, err := s.newState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer state.Close()
+
+	s.hasQueryHook = state.GetGlobal(QueryHook).Type() == lua.LTFunction
+	s.hasResponseHook = state.GetGlobal(ResponseHook).Type() == lua.LTFunction
+
+	if !s.hasQueryHook && !s.hasResponseHook {
+		return nil, fmt.Errorf("script defines neither '%s' nor '%s'", QueryHook, ResponseHook)
+	}
+
+	return s, nil
+}
+
+// HasQueryHook returns true if the script defines the query hook
+func (s *Script) HasQueryHook() bool {
+	return s.hasQueryHook
+}
+
+// HasResponseHook returns true if the script defines the response hook
+func (s *Script) HasResponseHook() bool {
+	return s.hasResponseHook
+}
+
+// OnQuery calls the query hook. It returns the return code and true, if the query should be answered with it.
+func (s *Script) OnQuery(ctx context.Context, query *Query) (int, bool, error) {
+	if !s.hasQueryHook {
+		return 0, false, nil
+	}
+
+	return s.call(ctx, QueryHook, func(state *lua.LState) []lua.LValue {
+		return []lua.LValue{queryTable(state, query)}
+	})
+}
+
+// OnResponse calls the response hook. It returns the return code and true, if the response should be replaced.
+func (s *Script) OnResponse(ctx context.Context, query *Query, response *Response) (int, bool, error) {
+	if !s.hasResponseHook {
+		return 0, false, nil
+	}
+
+	return s.call(ctx, ResponseHook, func(state *lua.LState) []lua.LValue {
+		return []lua.LValue{queryTable(state, query), responseTable(state, response)}
+	})
+}
+
+// call evaluates the hook in a new state, the timeout includes the execution of the script
+func (s *Script) call(ctx context.Context, hook string, args func(*lua.LState) []lua.LValue) (int, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	state, err := s.newState(ctx)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, false, ErrTimeout
+		}
+
+		return 0, false, err
+	}
+	defer state.Close()
+
+	err = state.CallByParam(lua.P{Fn: state.GetGlobal(hook), NRet: 1, Protect: true}, args(state)...)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, false, ErrTimeout
+		}
+
+		return 0, false, fmt.Errorf("'%s' failed: %w", hook, err)
+	}
+
+	return toRcode(hook, state.Get(-1))
+}
+
+func toRcode(hook string, ret lua.LValue) (int, bool, error) {
+	switch v := ret.(type) {
+	case *lua.LNilType:
+		return 0, false, nil
+	case lua.LString:
+		rcode, ok := dns.StringToRcode[strings.ToUpper(string(v))]
+		if !ok {
+			return 0, false, fmt.Errorf("'%s' returned unknown return code '%s'", hook, v)
+		}
+
+		return rcode, true, nil
+	default:
+		return 0, false, fmt.Errorf("'%s' returned unsupported type %s", hook, ret.Type())
+	}
+}
+
+// newState creates a sandboxed Lua state and executes the script in it, the state is bound to the context
+func (s *Script) newState(ctx context.Context) (*lua.LState, error) {
+	state := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   callStackSize,
+		RegistrySize:    registrySize,
+		RegistryMaxSize: registryMaxSize,
+	})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+
+	// remove functions, which give access to the file system or allow loading code
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "print"} {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	state.SetGlobal("in_cidr", state.NewFunction(luaInCIDR))
+	state.SetGlobal("log", state.NewFunction(luaLog))
+
+	state.SetContext(ctx)
+
+	state.Push(state.NewFunctionFromProto(s.proto))
+
+	if err := state.PCall(0, lua.MultRet, nil); err != nil {
+		state.Close()
+
+		return nil, fmt.Errorf("can't execute script: %w", err)
+	}
+
+	return state, nil
+}
+
+func queryTable(state *lua.LState, query *Query) *lua.LTable {
+	t := state.NewTable()
+	t.RawSetString("name", lua.LString(query.Name))
+	t.RawSetString("qtype", lua.LString(query.Type))
+	t.RawSetString("protocol", lua.LString(query.Protocol))
+
+	if query.ClientIP != nil {
+		t.RawSetString("client_ip", lua.LString(query.ClientIP.String()))
+	}
+
+	names := state.NewTable()
+	for _, name := range query.ClientNames {
+		names.Append(lua.LString(name))
+	}
+
+	t.RawSetString("client_names", names)
+
+	return t
+}
+
+func responseTable(state *lua.LState, response *Response) *lua.LTable {
+	t := state.NewTable()
+	t.RawSetString("rcode", lua.LString(response.Rcode))
+	t.RawSetString("response_type", lua.LString(response.Type))
+	t.RawSetString("reason", lua.LString(response.Reason))
+	t.RawSetString("reason_code", lua.LString(response.ReasonCode))
+
+	return t
+}
+
+// luaInCIDR implements `in_cidr(ip, cidr)`
+func luaInCIDR(state *lua.LState) int {
+	ip := net.ParseIP(state.OptString(1, ""))
+
+	_, cidr, err := net.ParseCIDR(state.CheckString(2))
+	if err != nil {
+		state.ArgError(2, err.Error())
+
+		return 0
+	}
+
+	state.Push(lua.LBool(ip != nil && cidr.Contains(ip)))
+
+	return 1
+}
+
+// luaLog implements `log(message)`
+func luaLog(state *lua.LState) int {
+	log.PrefixedLog("script").Info(state.CheckString(1))
+
+	return 0
+}
@@ -0,0 +1,286 @@
+package scripting
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Script", func() {
+	var (
+		ctx   context.Context
+		query *Query
+	)
+
+	compile := func(code string) *Script {
+		s, err := Compile("test", code, 50*time.Millisecond)
+		Expect(err).Should(Succeed())
+
+		return s
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		query = &Query{
+			Name:        "example.com",
+			Type:        "ANY",
+			ClientIP:    net.ParseIP("10.1.2.3"),
+			ClientNames: []string{"laptop"},
+			Protocol:    "udp",
+		}
+	})
+
+	Describe("Compile", func() {
+		It("should fail on syntax errors", func() {
+			_, err := Compile("test", "function on_query(", time.Second)
+			Expect(err).Should(MatchError(ContainSubstring("can't parse script")))
+		})
+
+		It("should fail if no hook is defined", func() {
+			_, err := Compile("test", "x = 1", time.Second)
+			Expect(err).Should(MatchError("script defines neither 'on_query' nor 'on_response'"))
+		})
+
+		It("should fail on runtime errors in the main chunk", func() {
+			_, err := Compile("test", "error('boom')", time.Second)
+			Expect(err).Should(MatchError(ContainSubstring("can't execute script")))
+		})
+
+		It("should abort endless loops in the main chunk", func() {
+			_, err := Compile("test", "while true do end", 10*time.Millisecond)
+			Expect(err).Should(MatchError(ContainSubstring("can't execute script")))
+		})
+
+		It("should detect the defined hooks", func() {
+			s := compile("function on_response(q, r) end")
+
+			Expect(s.HasQueryHook()).Should(BeFalse())
+			Expect(s.HasResponseHook()).Should(BeTrue())
+		})
+	})
+
+	Describe("OnQuery", func() {
+		It("should return the rcode for matching queries", func() {
+			s := compile(`
+function on_query(q)
+  if in_cidr(q.client_ip, "10.0.0.0/8") and q.qtype == "ANY" then
+    return "REFUSED"
+  end
+end`)
+
+			rcode, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeTrue())
+			Expect(rcode).Should(Equal(dns.RcodeRefused))
+
+			query.ClientIP = net.ParseIP("192.168.178.2")
+
+			_, matched, err = s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeFalse())
+		})
+
+		It("should pass all query fields", func() {
+			s := compile(`
+function on_query(q)
+  if q.name == "example.com" and q.protocol == "udp" and q.client_names[1] == "laptop" then
+    return "nxdomain"
+  end
+end`)
+
+			rcode, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeTrue())
+			Expect(rcode).Should(Equal(dns.RcodeNameError))
+		})
+
+		It("should handle queries without client IP", func() {
+			s := compile(`function on_query(q) if in_cidr(q.client_ip, "10.0.0.0/8") then return "REFUSED" end end`)
+			query.ClientIP = nil
+
+			_, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeFalse())
+		})
+
+		It("should fail on unknown return codes", func() {
+			s := compile(`function on_query(q) return "FOO" end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError("'on_query' returned unknown return code 'FOO'"))
+		})
+
+		It("should fail on unsupported return types", func() {
+			s := compile(`function on_query(q) return 1 end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError("'on_query' returned unsupported type number"))
+		})
+
+		It("should fail on runtime errors", func() {
+			s := compile(`function on_query(q) error("boom") end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError(ContainSubstring("'on_query' failed")))
+
+			// a new state is created for the next evaluation
+			_, _, err = s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError(ContainSubstring("'on_query' failed")))
+		})
+
+		It("should abort on timeout", func() {
+			s := compile(`function on_query(q) while true do end end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError(ErrTimeout))
+		})
+
+		It("should return nothing without hook", func() {
+			s := compile(`function on_response(q, r) return "REFUSED" end`)
+
+			_, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeFalse())
+		})
+
+		It("should be safe for concurrent use", func() {
+			s := compile(`function on_query(q) if q.qtype == "ANY" then return "REFUSED" end end`)
+
+			var wg sync.WaitGroup
+
+			for range 20 {
+				wg.Add(1)
+
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					_, matched, err := s.OnQuery(ctx, query)
+					Expect(err).Should(Succeed())
+					Expect(matched).Should(BeTrue())
+				}()
+			}
+
+			wg.Wait()
+		})
+	})
+
+	Describe("Isolation", func() {
+		It("should not share global variables between evaluations", func() {
+			s := compile(`
+seen = {}
+function on_query(q)
+  counter = (counter or 0) + 1
+  if counter > 1 or seen[q.name] then
+    return "REFUSED"
+  end
+  seen[q.name] = true
+end`)
+
+			for range 3 {
+				_, matched, err := s.OnQuery(ctx, query)
+				Expect(err).Should(Succeed())
+				Expect(matched).Should(BeFalse())
+			}
+		})
+
+		It("should not share changes of the libraries between evaluations", func() {
+			s := compile(`
+function on_query(q)
+  if string.hijacked then
+    return "REFUSED"
+  end
+  string.hijacked = true
+  in_cidr = nil
+end`)
+
+			for range 3 {
+				_, matched, err := s.OnQuery(ctx, query)
+				Expect(err).Should(Succeed())
+				Expect(matched).Should(BeFalse())
+			}
+		})
+
+		It("should limit the call stack", func() {
+			s := compile(`
+local function deep(n) return 1 + deep(n + 1) end
+function on_query(q) return deep(1) end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(MatchError(ContainSubstring("stack overflow")))
+		})
+
+		It("should not share state after a failed evaluation", func() {
+			s := compile(`
+function on_query(q)
+  if failed then
+    return "REFUSED"
+  end
+  failed = true
+  error("boom")
+end`)
+
+			_, _, err := s.OnQuery(ctx, query)
+			Expect(err).Should(HaveOccurred())
+
+			_, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(HaveOccurred())
+			Expect(matched).Should(BeFalse())
+		})
+	})
+
+	Describe("OnResponse", func() {
+		It("should pass the response", func() {
+			s := compile(`
+function on_response(q, r)
+  if r.rcode == "NOERROR" and r.response_type == "RESOLVED" and r.reason == "RESOLVED (udp)" and
+    r.reason_code == "RESOLVED" then
+    return "SERVFAIL"
+  end
+end`)
+
+			rcode, matched, err := s.OnResponse(ctx, query, &Response{
+				Rcode:      "NOERROR",
+				Type:       "RESOLVED",
+				Reason:     "RESOLVED (udp)",
+				ReasonCode: "RESOLVED",
+			})
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeTrue())
+			Expect(rcode).Should(Equal(dns.RcodeServerFailure))
+		})
+	})
+
+	Describe("Sandbox", func() {
+		DescribeTable("should not provide unsafe functions",
+			func(name string) {
+				s := compile(`function on_query(q) if ` + name + ` == nil then return "REFUSED" end end`)
+
+				_, matched, err := s.OnQuery(ctx, query)
+				Expect(err).Should(Succeed())
+				Expect(matched).Should(BeTrue())
+			},
+			Entry("io", "io"),
+			Entry("os", "os"),
+			Entry("debug", "debug"),
+			Entry("dofile", "dofile"),
+			Entry("loadfile", "loadfile"),
+			Entry("load", "load"),
+			Entry("require", "require"),
+		)
+
+		It("should provide string functions", func() {
+			s := compile(`function on_query(q) if string.find(q.name, "example") then return "REFUSED" end end`)
+
+			_, matched, err := s.OnQuery(ctx, query)
+			Expect(err).Should(Succeed())
+			Expect(matched).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/engine"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/model"
+	"github.com/0xERR0R/blocky/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DryRunConfig parses the configuration, creates its resolver chain in a sandbox and resolves the queries with it.
+// The running resolver chain is not changed.
+func (s *Server) DryRunConfig(ctx context.Context, data []byte, queries []api.DryRunQuery) api.DryRunResult {
+	var (
+		result api.DryRunResult
+		cfg    *config.Config
+	)
+
+	result.Messages = log.Messages(func(logger *logrus.Entry) {
+		cfg, result.Err = config.ParseConfig(logger, data)
+	})
+
+	if result.Err != nil {
+		return result
+	}
+
+	// the resolvers of the sandbox are stopped with the context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sandbox, err := engine.NewSandbox(ctx, cfg)
+	if err != nil {
+		result.Err = fmt.Errorf("can't create resolver chain: %w", err)
+
+		return result
+	}
+
+	result.Chain = sandbox.ResolverChain()
+
+	for _, query := range queries {
+		reqCtx, req := newRequest(ctx, query.ClientIP, "", model.RequestProtocolTCP,
+			util.NewMsgWithQuestion(query.Question, query.Type))
+		req.Listener = model.RequestListenerHttp
+
+		resp, err := sandbox.ResolveRequest(reqCtx, req)
+
+		result.Responses = append(result.Responses, api.DryRunResponse{Response: resp, Err: err})
+	}
+
+	return result
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/engine"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadedSections are the configuration sections of the subsystems, which are reloaded on a change
+//
+//nolint:gochecknoglobals
+var reloadedSections = []string{"upstreams", "customDNS", "blocking", "queryLog"}
+
+// WatchConfig compares the fingerprint of the configuration file(s) periodically and reloads all subsystems,
+// if it changed. This detects the update of a Kubernetes ConfigMap, which is mounted into the pod.
+func (s *Server) WatchConfig(ctx context.Context, cfg config.ConfigWatch, fingerprint func() (string, error)) {
+	logger := log.PrefixedLog("config_watch")
+
+	last, err := fingerprint()
+	if err != nil {
+		logger.Warnf("can't read configuration: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval.ToDuration())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			current, err := fingerprint()
+			if err != nil {
+				logger.Warnf("can't read configuration: %v", err)
+
+				continue
+			}
+
+			if current == last {
+				continue
+			}
+
+			logger.Info("configuration changed, reloading")
+
+			if err := s.reloadAll(ctx, logger); err != nil {
+				// the reload is retried on the next check, the current configuration stays active
+				logger.Errorf("can't reload configuration: %v", err)
+
+				continue
+			}
+
+			last = current
+		}
+	}()
+}
+
+// reloadAll rebuilds all subsystems from the current configuration file
+func (s *Server) reloadAll(ctx context.Context, logger *logrus.Entry) error {
+	if s.loadConfig == nil {
+		return errors.New("reload is not supported, the configuration file is unknown")
+	}
+
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return fmt.Errorf("can't load configuration: %w", err)
+	}
+
+	if sections := restartSections(s.cfg, cfg); len(sections) != 0 {
+		logger.Warnf("changes of %s are only applied on restart", strings.Join(sections, ", "))
+	}
+
+	var errs []error
+
+	for _, name := range engine.SubsystemNames() {
+		sub, _ := engine.ParseSubsystem(name)
+
+		errs = append(errs, s.engine.Reload(ctx, cfg, sub))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	evt.Bus().Publish(evt.ConfigReloaded)
+
+	return nil
+}
+
+// restartSections returns the names of the changed configuration sections, which aren't reloaded
+func restartSections(running, loaded *config.Config) []string {
+	var result []string
+
+	runningVal := reflect.ValueOf(running).Elem()
+	loadedVal := reflect.ValueOf(loaded).Elem()
+
+	for i := range runningVal.NumField() {
+		// the deprecated options are inlined and migrated to their sections
+		name, _, _ := strings.Cut(runningVal.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || slices.Contains(reloadedSections, name) {
+			continue
+		}
+
+		if !reflect.DeepEqual(runningVal.Field(i).Interface(), loadedVal.Field(i).Interface()) {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
@@ -29,7 +29,7 @@ func newHTTPServer(name string, handler http.Handler, cfg *config.Config) *httpS
 			ReadTimeout:       time.Duration(readTimeout),
 			ReadHeaderTimeout: time.Duration(readHeaderTimeout),
 			WriteTimeout:      time.Duration(writeTimeout),
-			Handler:           withCommonMiddleware(handler),
+			Handler:           withCommonMiddleware(handler, &cfg.ReverseProxy),
 		},
 
 		name: name,
@@ -50,12 +50,13 @@ func (s *httpServer) Serve(ctx context.Context, l net.Listener) error {
 	return s.inner.Serve(l)
 }
 
-func withCommonMiddleware(inner http.Handler) *chi.Mux {
+func withCommonMiddleware(inner http.Handler, proxyCfg *config.ReverseProxy) *chi.Mux {
 	// Middleware must be defined before routes, so
 	// create a new router and mount the inner handler
 	mux := chi.NewMux()
 
 	mux.Use(
+		newReverseProxyMiddleware(proxyCfg),
 		secureHeadersMiddleware,
 		newCORSMiddleware(),
 	)
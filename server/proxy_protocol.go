@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+)
+
+const (
+	// maximum duration to receive the PROXY protocol header
+	proxyHeaderTimeout = 5 * time.Second
+	// maximum length of a v1 header including CRLF
+	proxyV1MaxLength = 107
+
+	proxyV2HeaderLength = 16
+	proxyV2CmdLocal     = 0x0
+	proxyV2CmdProxy     = 0x1
+	proxyV2FamilyInet   = 0x1
+	proxyV2FamilyInet6  = 0x2
+)
+
+//nolint:gochecknoglobals
+var (
+	proxyV1Signature = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// proxyProtocolListener reads the PROXY protocol header of the connections of trusted proxies, the connections of
+// other sources are passed unchanged
+type proxyProtocolListener struct {
+	net.Listener
+
+	cfg *config.ReverseProxy
+}
+
+func newProxyProtocolListener(inner net.Listener, cfg *config.ReverseProxy) net.Listener {
+	return &proxyProtocolListener{Listener: inner, cfg: cfg}
+}
+
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); !ok || !l.cfg.Trusts(addr.IP) {
+		return conn, nil
+	}
+
+	return &proxyProtocolConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyProtocolConn reads the header before the first read or the first access to the remote address.
+// Connections of the proxy without header (e.g. health checks) keep their address.
+type proxyProtocolConn struct {
+	net.Conn
+
+	reader *bufio.Reader
+
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+
+	lock         sync.Mutex
+	readDeadline time.Time
+}
+
+func (c *proxyProtocolConn) init() {
+	c.once.Do(func() {
+		// the deadline of the header replaces the deadline of the first read temporarily
+		_ = c.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+
+		c.remoteAddr, c.err = readProxyHeader(c.reader)
+		if c.err == nil && c.remoteAddr == nil {
+			c.remoteAddr = c.Conn.RemoteAddr()
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		_ = c.Conn.SetReadDeadline(c.readDeadline)
+	})
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	c.init()
+
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.reader.Read(b)
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	c.init()
+
+	if c.remoteAddr == nil {
+		return c.Conn.RemoteAddr()
+	}
+
+	return c.remoteAddr
+}
+
+func (c *proxyProtocolConn) SetDeadline(t time.Time) error {
+	c.lock.Lock()
+	c.readDeadline = t
+	c.lock.Unlock()
+
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *proxyProtocolConn) SetReadDeadline(t time.Time) error {
+	c.lock.Lock()
+	c.readDeadline = t
+	c.lock.Unlock()
+
+	return c.Conn.SetReadDeadline(t)
+}
+
+// readProxyHeader reads a v1 or v2 header. Returns a nil address without error, if there is no header or the proxy
+// sends its own address (v1 `UNKNOWN`, v2 `LOCAL` or an unsupported address family).
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		// the error is returned by the next read
+		return nil, nil //nolint:nilerr
+	}
+
+	signature := proxyV1Signature
+	if first[0] == proxyV2Signature[0] {
+		signature = proxyV2Signature
+	}
+
+	if prefix, err := r.Peek(len(signature)); err != nil || !bytes.Equal(prefix, signature) {
+		return nil, nil //nolint:nilerr
+	}
+
+	if first[0] == proxyV2Signature[0] {
+		return readProxyV2Header(r)
+	}
+
+	return readProxyV1Header(r)
+}
+
+// readProxyV1Header parses a header like `PROXY TCP4 192.0.2.1 192.0.2.2 56324 53\r\n`
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return nil, errors.New("PROXY v1 header is too long")
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("can't read PROXY v1 header: %w", err)
+		}
+
+		line = append(line, b)
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	const v1Fields = 6
+	if len(fields) != v1Fields || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("invalid PROXY v1 header '%s'", strings.TrimSpace(string(line)))
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("invalid PROXY v1 source '%s %s'", fields[2], fields[4])
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header parses the binary header, the TLVs are skipped
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("can't read PROXY v2 header: %w", err)
+	}
+
+	version, command := header[12]>>4, header[12]&0x0f //nolint:mnd
+	if version != 2 || (command != proxyV2CmdLocal && command != proxyV2CmdProxy) {
+		return nil, fmt.Errorf("unsupported PROXY v2 version %d or command %d", version, command)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("can't read PROXY v2 addresses: %w", err)
+	}
+
+	if command == proxyV2CmdLocal {
+		return nil, nil
+	}
+
+	var ipLen int
+
+	switch header[13] >> 4 { //nolint:mnd
+	case proxyV2FamilyInet:
+		ipLen = net.IPv4len
+	case proxyV2FamilyInet6:
+		ipLen = net.IPv6len
+	default:
+		return nil, nil
+	}
+
+	// source and destination address followed by source and destination port
+	if len(payload) < 2*ipLen+4 {
+		return nil, errors.New("PROXY v2 addresses are too short")
+	}
+
+	ip := make(net.IP, ipLen)
+	copy(ip, payload[:ipLen])
+
+	return &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(payload[2*ipLen:]))}, nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PROXY protocol listener", func() {
+	var (
+		cfg      config.ReverseProxy
+		listener net.Listener
+	)
+
+	BeforeEach(func() {
+		cfg = config.ReverseProxy{TrustedProxies: []string{"127.0.0.1"}, ProxyProtocol: true}
+	})
+
+	JustBeforeEach(func() {
+		inner, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).Should(Succeed())
+
+		listener = newProxyProtocolListener(inner, &cfg)
+		DeferCleanup(listener.Close)
+	})
+
+	// accept sends the data from a client and returns the accepted connection
+	accept := func(data []byte) net.Conn {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).Should(Succeed())
+		DeferCleanup(client.Close)
+
+		_, err = client.Write(data)
+		Expect(err).Should(Succeed())
+
+		conn, err := listener.Accept()
+		Expect(err).Should(Succeed())
+		DeferCleanup(conn.Close)
+
+		return conn
+	}
+
+	readPayload := func(conn net.Conn) string {
+		buf := make([]byte, len("payload"))
+		_, err := io.ReadFull(conn, buf)
+		Expect(err).Should(Succeed())
+
+		return string(buf)
+	}
+
+	v2Header := func(command, family byte, addrs []byte) []byte {
+		header := append([]byte{}, proxyV2Signature...)
+		header = append(header, 0x20|command, family<<4|0x1)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(addrs)))
+
+		return append(header, addrs...)
+	}
+
+	It("should use the source of a v1 header", func() {
+		conn := accept([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 53\r\npayload"))
+
+		Expect(conn.RemoteAddr().String()).Should(Equal("192.0.2.1:56324"))
+		Expect(readPayload(conn)).Should(Equal("payload"))
+	})
+
+	It("should use the source of a v1 IPv6 header", func() {
+		conn := accept([]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 53\r\npayload"))
+
+		Expect(readPayload(conn)).Should(Equal("payload"))
+		Expect(conn.RemoteAddr().String()).Should(Equal("[2001:db8::1]:56324"))
+	})
+
+	It("should use the source of a v2 header and skip the TLVs", func() {
+		addrs := []byte{192, 0, 2, 1, 192, 0, 2, 2, 0xdc, 0x04, 0x00, 0x35}
+		addrs = append(addrs, 0x04, 0x00, 0x01, 0xff) // TLV
+
+		conn := accept(append(v2Header(proxyV2CmdProxy, proxyV2FamilyInet, addrs), []byte("payload")...))
+
+		Expect(conn.RemoteAddr().String()).Should(Equal("192.0.2.1:56324"))
+		Expect(readPayload(conn)).Should(Equal("payload"))
+	})
+
+	It("should keep the address of the proxy for v1 UNKNOWN and v2 LOCAL", func() {
+		conn := accept([]byte("PROXY UNKNOWN\r\npayload"))
+
+		Expect(conn.RemoteAddr().(*net.TCPAddr).IP.String()).Should(Equal("127.0.0.1"))
+		Expect(readPayload(conn)).Should(Equal("payload"))
+
+		conn = accept(append(v2Header(proxyV2CmdLocal, 0, nil), []byte("payload")...))
+
+		Expect(conn.RemoteAddr().(*net.TCPAddr).IP.String()).Should(Equal("127.0.0.1"))
+		Expect(readPayload(conn)).Should(Equal("payload"))
+	})
+
+	It("should accept connections of the proxy without header", func() {
+		conn := accept([]byte("payload"))
+
+		Expect(readPayload(conn)).Should(Equal("payload"))
+		Expect(conn.RemoteAddr().(*net.TCPAddr).IP.String()).Should(Equal("127.0.0.1"))
+	})
+
+	It("should fail on an invalid header", func() {
+		conn := accept([]byte("PROXY TCP4 192.0.2.1\r\npayload"))
+
+		_, err := conn.Read(make([]byte, 10))
+		Expect(err).Should(MatchError(ContainSubstring("invalid PROXY v1 header")))
+	})
+
+	When("the source is no trusted proxy", func() {
+		BeforeEach(func() {
+			cfg.TrustedProxies = []string{"192.0.2.10"}
+		})
+
+		It("should not parse the header", func() {
+			conn := accept([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 53\r\n"))
+
+			Expect(conn.RemoteAddr().(*net.TCPAddr).IP.String()).Should(Equal("127.0.0.1"))
+
+			buf := make([]byte, len("PROXY "))
+			_, err := io.ReadFull(conn, buf)
+			Expect(err).Should(Succeed())
+			Expect(string(buf)).Should(Equal("PROXY "))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xERR0R/blocky/api"
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/engine"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/resolver"
+)
+
+// SetConfigLoader sets the function to load the current configuration, which enables the reload of subsystems
+func (s *Server) SetConfigLoader(load func() (*config.Config, error)) {
+	s.loadConfig = load
+}
+
+// Reload rebuilds the subsystem from the current configuration file
+func (s *Server) Reload(ctx context.Context, subsystem string) error {
+	sub, err := engine.ParseSubsystem(subsystem)
+	if err != nil {
+		return fmt.Errorf("%w '%s'", api.ErrUnknownSubsystem, subsystem)
+	}
+
+	if s.loadConfig == nil {
+		return errors.New("reload is not supported, the configuration file is unknown")
+	}
+
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return fmt.Errorf("can't load configuration: %w", err)
+	}
+
+	if err := s.engine.Reload(ctx, cfg, sub); err != nil {
+		return err
+	}
+
+	evt.Bus().Publish(evt.ConfigReloaded)
+
+	return nil
+}
+
+// chainBlockingControl passes all calls to the blocking resolver of the chain. It is looked up for each call,
+// since the resolver is replaced, if the lists are reloaded.
+type chainBlockingControl struct {
+	chain resolver.ChainedResolver
+}
+
+func (c chainBlockingControl) blocking() *resolver.BlockingResolver {
+	blocking, err := resolver.GetFromChainWithType[*resolver.BlockingResolver](c.chain)
+	if err != nil {
+		// the chain is checked on start
+		panic(err)
+	}
+
+	return blocking
+}
+
+// EnableBlocking implements `api.BlockingControl`.
+func (c chainBlockingControl) EnableBlocking(ctx context.Context) {
+	c.blocking().EnableBlocking(ctx)
+}
+
+// DisableBlocking implements `api.BlockingControl`.
+func (c chainBlockingControl) DisableBlocking(ctx context.Context, duration time.Duration, groups []string) error {
+	return c.blocking().DisableBlocking(ctx, duration, groups)
+}
+
+// BlockingStatus implements `api.BlockingControl`.
+func (c chainBlockingControl) BlockingStatus() api.BlockingStatus {
+	return c.blocking().BlockingStatus()
+}
+
+// RefreshLists implements `api.ListRefresher`.
+func (c chainBlockingControl) RefreshLists(groups []string) error {
+	return c.blocking().RefreshLists(groups)
+}
+
+// chainQueryLogControl passes all calls to the query logging resolver of the chain. It is looked up for each call,
+// since the resolver is replaced, if the query log is reloaded.
+type chainQueryLogControl struct {
+	chain resolver.ChainedResolver
+}
+
+func (c chainQueryLogControl) queryLogging() (*resolver.QueryLoggingResolver, error) {
+	return resolver.GetFromChainWithType[*resolver.QueryLoggingResolver](c.chain)
+}
+
+// FlushQueryLog implements `api.QueryLogControl`.
+func (c chainQueryLogControl) FlushQueryLog(ctx context.Context) error {
+	queryLogging, err := c.queryLogging()
+	if err != nil {
+		return err
+	}
+
+	return queryLogging.FlushQueryLog(ctx)
+}
+
+// RotateQueryLog implements `api.QueryLogControl`.
+func (c chainQueryLogControl) RotateQueryLog(ctx context.Context, compress *bool) error {
+	queryLogging, err := c.queryLogging()
+	if err != nil {
+		return err
+	}
+
+	return queryLogging.RotateQueryLog(ctx, compress)
+}
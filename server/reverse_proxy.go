@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/0xERR0R/blocky/config"
+)
+
+// newReverseProxyMiddleware replaces the remote address of requests of trusted proxies with the client IP from the
+// header `Forwarded` or `X-Forwarded-For`. The headers of other sources are removed, so they can't spoof their IP.
+func newReverseProxyMiddleware(cfg *config.ReverseProxy) httpMiddleware {
+	return func(next http.Handler) http.Handler {
+		if !cfg.IsEnabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := forwardedClientIP(cfg, r); ip != nil {
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			}
+
+			r.Header.Del("Forwarded")
+			r.Header.Del("X-Forwarded-For")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP walks the forwarded chain from the right, as long as the hops are trusted proxies.
+// Returns nil, if the request doesn't come from a trusted proxy.
+func forwardedClientIP(cfg *config.ReverseProxy, r *http.Request) net.IP {
+	ip := parseForwardedIP(r.RemoteAddr)
+	if !cfg.Trusts(ip) {
+		return nil
+	}
+
+	hops := forwardedHops(r.Header)
+
+	for _, hop := range slices.Backward(hops) {
+		if !cfg.Trusts(ip) {
+			break
+		}
+
+		hopIP := parseForwardedIP(hop)
+		if hopIP == nil {
+			// unknown or obfuscated identifier: the proxy is the client
+			break
+		}
+
+		ip = hopIP
+	}
+
+	return ip
+}
+
+// forwardedHops returns the `for` identifiers of the header `Forwarded` (RFC 7239) or the addresses of the header
+// `X-Forwarded-For`, if the request has no header `Forwarded`
+func forwardedHops(header http.Header) []string {
+	var hops []string
+
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, identifier, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(key, "for") {
+					hops = append(hops, identifier)
+				}
+			}
+		}
+	}
+
+	if len(hops) != 0 {
+		return hops
+	}
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, addr := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(addr))
+		}
+	}
+
+	return hops
+}
+
+// parseForwardedIP parses an IP with optional port, brackets and quotes (e.g. `"[2001:db8::1]:4711"`)
+func parseForwardedIP(addr string) net.IP {
+	addr = strings.Trim(strings.TrimSpace(addr), `"`)
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/0xERR0R/blocky/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reverse proxy middleware", func() {
+	var cfg config.ReverseProxy
+
+	BeforeEach(func() {
+		cfg = config.ReverseProxy{TrustedProxies: []string{"192.0.2.10", "10.0.0.0/8"}}
+	})
+
+	// serve returns the remote address and the forwarding headers, which reach the handler
+	serve := func(remoteAddr string, header http.Header) (string, http.Header) {
+		var (
+			gotAddr   string
+			gotHeader http.Header
+		)
+
+		handler := newReverseProxyMiddleware(&cfg)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			gotAddr, gotHeader = r.RemoteAddr, r.Header
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/dns-query", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header = header
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		return gotAddr, gotHeader
+	}
+
+	DescribeTable("client address",
+		func(remoteAddr string, header http.Header, want string) {
+			addr, gotHeader := serve(remoteAddr, header)
+
+			Expect(addr).Should(Equal(want))
+			Expect(gotHeader).ShouldNot(HaveKey("Forwarded"))
+			Expect(gotHeader).ShouldNot(HaveKey("X-Forwarded-For"))
+		},
+		Entry("X-Forwarded-For of a trusted proxy",
+			"192.0.2.10:4711", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1:0"),
+		Entry("chain of trusted proxies",
+			"192.0.2.10:4711", http.Header{"X-Forwarded-For": {"203.0.113.1, 198.51.100.1", "10.1.1.1"}},
+			"198.51.100.1:0"),
+		Entry("Forwarded header with quoted IPv6",
+			"192.0.2.10:4711", http.Header{"Forwarded": {`for=192.0.2.60;proto=https, For="[2001:db8::1]:4711"`}},
+			"[2001:db8::1]:0"),
+		Entry("Forwarded header takes precedence",
+			"192.0.2.10:4711", http.Header{
+				"Forwarded":       {"for=198.51.100.2"},
+				"X-Forwarded-For": {"198.51.100.1"},
+			}, "198.51.100.2:0"),
+		Entry("obfuscated identifier",
+			"192.0.2.10:4711", http.Header{"Forwarded": {"for=_hidden"}}, "192.0.2.10:0"),
+		Entry("untrusted source",
+			"198.51.100.5:4711", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.5:4711"),
+	)
+
+	When("no proxy is trusted", func() {
+		BeforeEach(func() {
+			cfg = config.ReverseProxy{}
+		})
+
+		It("should keep the request", func() {
+			addr, header := serve("198.51.100.5:4711", http.Header{"X-Forwarded-For": {"198.51.100.1"}})
+
+			Expect(addr).Should(Equal("198.51.100.5:4711"))
+			Expect(header.Get("X-Forwarded-For")).Should(Equal("198.51.100.1"))
+		})
+	})
+})
@@ -3,20 +3,24 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/engine"
 	"github.com/0xERR0R/blocky/log"
 	"github.com/0xERR0R/blocky/metrics"
 	"github.com/0xERR0R/blocky/model"
-	"github.com/0xERR0R/blocky/redis"
+	"github.com/0xERR0R/blocky/notification"
+	"github.com/0xERR0R/blocky/peersync"
+	"github.com/0xERR0R/blocky/profiling"
 	"github.com/0xERR0R/blocky/resolver"
 
 	"github.com/0xERR0R/blocky/util"
@@ -37,10 +41,21 @@ const (
 // Server controls the endpoints for DNS and HTTP
 type Server struct {
 	dnsServers    []*dns.Server
+	engine        *engine.Engine
 	queryResolver resolver.ChainedResolver
 	cfg           *config.Config
 
 	servers map[net.Listener]*httpServer
+
+	ready chan struct{}
+
+	peerSync *peersync.Peers
+
+	// captures profiles on high latency or memory usage, nil if disabled
+	profiles *profiling.Capture
+
+	// loads the current configuration for reloads, nil if reloads are not supported
+	loadConfig func() (*config.Config, error)
 }
 
 func logger() *logrus.Entry {
@@ -117,43 +132,56 @@ func NewServer(ctx context.Context, cfg *config.Config) (server *Server, err err
 	}
 
 	httpListeners, httpsListeners, err := createHTTPListeners(cfg, tlsCfg)
-	if err != nil {
-		return nil, err
-	}
 
-	metrics.RegisterEventListeners()
+	defer func() {
+		if err != nil {
+			// the ports must be free for a retry
+			closeListeners(dnsServers, httpListeners, httpsListeners)
+		}
+	}()
 
-	bootstrap, err := resolver.NewBootstrap(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	var redisClient *redis.Client
-	if cfg.Redis.IsEnabled() {
-		redisClient, err = redis.New(ctx, &cfg.Redis)
-		if err != nil && cfg.Redis.Required {
+	metrics.RegisterEventListeners()
+
+	if cfg.Notifications.IsEnabled() {
+		notifier, err := notification.New(cfg.Notifications)
+		if err != nil {
 			return nil, err
 		}
+
+		notifier.Subscribe(ctx)
 	}
 
-	queryResolver, queryError := createQueryResolver(ctx, cfg, bootstrap, redisClient)
-	if queryError != nil {
-		return nil, queryError
+	eng, err := engine.New(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	server = &Server{
 		dnsServers:    dnsServers,
-		queryResolver: queryResolver,
+		engine:        eng,
+		queryResolver: eng.Chain(),
 		cfg:           cfg,
 
 		servers: make(map[net.Listener]*httpServer),
+		ready:   make(chan struct{}),
+	}
+
+	if cfg.Profiling.Capture.IsEnabled() {
+		server.profiles, err = profiling.NewCapture(cfg.Profiling.Capture)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	server.printConfiguration()
 
 	server.registerDNSHandlers(ctx)
 
-	openAPIImpl, err := server.createOpenAPIInterfaceImpl()
+	openAPIImpl, err := server.createOpenAPIInterfaceImpl(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -161,6 +189,10 @@ func NewServer(ctx context.Context, cfg *config.Config) (server *Server, err err
 	httpRouter := createHTTPRouter(cfg, openAPIImpl)
 	server.registerDoHEndpoints(httpRouter, cfg)
 
+	if server.peerSync != nil {
+		server.peerSync.RegisterEndpoints(httpRouter)
+	}
+
 	if len(cfg.Ports.HTTP) != 0 {
 		srv := newHTTPServer("http", httpRouter, cfg)
 
@@ -200,12 +232,37 @@ func createServers(cfg *config.Config, tlsCfg *tls.Config) ([]*dns.Server, error
 
 	err = multierror.Append(err,
 		addServers(createUDPServer, cfg.Ports.DNS),
-		addServers(createTCPServer, cfg.Ports.DNS),
 		addServers(func(address string) (*dns.Server, error) {
-			return createTLSServer(address, tlsCfg)
+			return createTCPServer(address, &cfg.ReverseProxy)
+		}, cfg.Ports.DNS),
+		addServers(func(address string) (*dns.Server, error) {
+			return createTLSServer(address, tlsCfg, &cfg.ReverseProxy)
 		}, cfg.Ports.TLS))
 
-	return dnsServers, err.ErrorOrNil()
+	if err.ErrorOrNil() != nil {
+		closeListeners(dnsServers, nil, nil)
+
+		return nil, err
+	}
+
+	return dnsServers, nil
+}
+
+// closeListeners closes the bound sockets of a server, which isn't started
+func closeListeners(dnsServers []*dns.Server, httpListeners, httpsListeners []net.Listener) {
+	for _, srv := range dnsServers {
+		if srv.PacketConn != nil {
+			_ = srv.PacketConn.Close()
+		}
+
+		if srv.Listener != nil {
+			_ = srv.Listener.Close()
+		}
+	}
+
+	for _, l := range slices.Concat(httpListeners, httpsListeners) {
+		_ = l.Close()
+	}
 }
 
 func createHTTPListeners(
@@ -252,10 +309,16 @@ func newTLSListeners(proto string, addresses config.ListenConfig, tlsCfg *tls.Co
 	return listeners, nil
 }
 
-func createTLSServer(address string, tlsCfg *tls.Config) (*dns.Server, error) {
+func createTLSServer(address string, tlsCfg *tls.Config, proxy *config.ReverseProxy) (*dns.Server, error) {
+	listener, err := newTCPListener(address, proxy)
+	if err != nil {
+		return nil, err
+	}
+
 	return &dns.Server{
 		Addr:      address,
 		Net:       "tcp-tls",
+		Listener:  tls.NewListener(listener, tlsCfg),
 		TLSConfig: tlsCfg,
 		Handler:   dns.NewServeMux(),
 		NotifyStartedFunc: func() {
@@ -264,22 +327,48 @@ func createTLSServer(address string, tlsCfg *tls.Config) (*dns.Server, error) {
 	}, nil
 }
 
-func createTCPServer(address string) (*dns.Server, error) {
+func createTCPServer(address string, proxy *config.ReverseProxy) (*dns.Server, error) {
+	listener, err := newTCPListener(address, proxy)
+	if err != nil {
+		return nil, err
+	}
+
 	return &dns.Server{
-		Addr:    address,
-		Net:     "tcp",
-		Handler: dns.NewServeMux(),
+		Addr:     address,
+		Net:      "tcp",
+		Listener: listener,
+		Handler:  dns.NewServeMux(),
 		NotifyStartedFunc: func() {
 			logger().Infof("TCP server is up and running on address %s", address)
 		},
 	}, nil
 }
 
+// newTCPListener binds the address, with support of the PROXY protocol if it is enabled
+func newTCPListener(address string, proxy *config.ReverseProxy) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("start TCP listener on %s failed: %w", address, err)
+	}
+
+	if !proxy.ProxyProtocol || !proxy.IsEnabled() {
+		return listener, nil
+	}
+
+	return newProxyProtocolListener(listener, proxy), nil
+}
+
 func createUDPServer(address string) (*dns.Server, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("start UDP listener on %s failed: %w", address, err)
+	}
+
 	return &dns.Server{
-		Addr:    address,
-		Net:     "udp",
-		Handler: dns.NewServeMux(),
+		Addr:       address,
+		Net:        "udp",
+		PacketConn: conn,
+		Handler:    dns.NewServeMux(),
 		NotifyStartedFunc: func() {
 			logger().Infof("UDP server is up and running on address %s", address)
 		},
@@ -287,53 +376,6 @@ func createUDPServer(address string) (*dns.Server, error) {
 	}, nil
 }
 
-func createQueryResolver(
-	ctx context.Context,
-	cfg *config.Config,
-	bootstrap *resolver.Bootstrap,
-	redisClient *redis.Client,
-) (resolver.ChainedResolver, error) {
-	upstreamTree, utErr := resolver.NewUpstreamTreeResolver(ctx, cfg.Upstreams, bootstrap)
-	blocking, blErr := resolver.NewBlockingResolver(ctx, cfg.Blocking, redisClient, bootstrap)
-	clientNames, cnErr := resolver.NewClientNamesResolver(ctx, cfg.ClientLookup, cfg.Upstreams, bootstrap)
-	queryLogging, qlErr := resolver.NewQueryLoggingResolver(ctx, cfg.QueryLog)
-	condUpstream, cuErr := resolver.NewConditionalUpstreamResolver(ctx, cfg.Conditional, cfg.Upstreams, bootstrap)
-	hostsFile, hfErr := resolver.NewHostsFileResolver(ctx, cfg.HostsFile, bootstrap)
-	cachingResolver, crErr := resolver.NewCachingResolver(ctx, cfg.Caching, redisClient)
-
-	err := multierror.Append(
-		multierror.Prefix(utErr, "upstream tree resolver: "),
-		multierror.Prefix(blErr, "blocking resolver: "),
-		multierror.Prefix(qlErr, "query logging resolver: "),
-		multierror.Prefix(cnErr, "client names resolver: "),
-		multierror.Prefix(cuErr, "conditional upstream resolver: "),
-		multierror.Prefix(hfErr, "hosts file resolver: "),
-		multierror.Prefix(crErr, "caching resolver: "),
-	).ErrorOrNil()
-	if err != nil {
-		return nil, err
-	}
-
-	r := resolver.Chain(
-		resolver.NewFilteringResolver(cfg.Filtering),
-		resolver.NewFQDNOnlyResolver(cfg.FQDNOnly),
-		resolver.NewECSResolver(cfg.ECS),
-		clientNames,
-		resolver.NewEDEResolver(cfg.EDE),
-		queryLogging,
-		resolver.NewMetricsResolver(cfg.Prometheus),
-		resolver.NewRewriterResolver(cfg.CustomDNS.RewriterConfig, resolver.NewCustomDNSResolver(cfg.CustomDNS)),
-		hostsFile,
-		blocking,
-		cachingResolver,
-		resolver.NewRewriterResolver(cfg.Conditional.RewriterConfig, condUpstream),
-		resolver.NewSpecialUseDomainNamesResolver(cfg.SUDN),
-		upstreamTree,
-	)
-
-	return r, nil
-}
-
 func (s *Server) registerDNSHandlers(ctx context.Context) {
 	for _, server := range s.dnsServers {
 		handler := server.Handler.(*dns.ServeMux)
@@ -354,6 +396,61 @@ func (s *Server) printConfiguration() {
 		log.WithIndent(logger(), "  ", s.cfg.Redis.LogConfig)
 	}
 
+	if s.cfg.Sync.IsEnabled() {
+		logger().Info("sync:")
+		log.WithIndent(logger(), "  ", s.cfg.Sync.LogConfig)
+	}
+
+	if s.cfg.XDP.IsEnabled() {
+		logger().Info("xdp:")
+		log.WithIndent(logger(), "  ", s.cfg.XDP.LogConfig)
+	}
+
+	if s.cfg.PeerSync.IsEnabled() {
+		logger().Info("peer sync:")
+		log.WithIndent(logger(), "  ", s.cfg.PeerSync.LogConfig)
+	}
+
+	if s.cfg.Notifications.IsEnabled() {
+		logger().Info("notifications:")
+		log.WithIndent(logger(), "  ", s.cfg.Notifications.LogConfig)
+	}
+
+	if s.cfg.QueryProcessing.IsEnabled() {
+		logger().Info("query processing:")
+		log.WithIndent(logger(), "  ", s.cfg.QueryProcessing.LogConfig)
+	}
+
+	if s.cfg.Responses.IsEnabled() {
+		logger().Info("responses:")
+		log.WithIndent(logger(), "  ", s.cfg.Responses.LogConfig)
+	}
+
+	if s.cfg.Cookies.IsEnabled() {
+		logger().Info("cookies:")
+		log.WithIndent(logger(), "  ", s.cfg.Cookies.LogConfig)
+	}
+
+	if s.cfg.Profiling.IsEnabled() {
+		logger().Info("profiling:")
+		log.WithIndent(logger(), "  ", s.cfg.Profiling.LogConfig)
+	}
+
+	if s.cfg.SelfCheck.IsEnabled() {
+		logger().Info("self-check:")
+		log.WithIndent(logger(), "  ", s.cfg.SelfCheck.LogConfig)
+	}
+
+	if s.cfg.ReverseProxy.IsEnabled() {
+		logger().Info("reverse proxy:")
+		log.WithIndent(logger(), "  ", s.cfg.ReverseProxy.LogConfig)
+	}
+
+	if s.cfg.RunAs.IsEnabled() {
+		logger().Info("run as:")
+		log.WithIndent(logger(), "  ", s.cfg.RunAs.LogConfig)
+	}
+
 	resolver.ForEach(s.queryResolver, func(res resolver.Resolver) {
 		resolver.LogResolverConfig(res, logger())
 	})
@@ -391,9 +488,23 @@ func toMB(b uint64) uint64 {
 func (s *Server) Start(ctx context.Context, errCh chan<- error) {
 	logger().Info("Starting server")
 
+	var wg sync.WaitGroup
+
+	wg.Add(len(s.dnsServers))
+
 	for _, srv := range s.dnsServers {
+		onStarted := srv.NotifyStartedFunc
+		srv.NotifyStartedFunc = func() {
+			if onStarted != nil {
+				onStarted()
+			}
+
+			wg.Done()
+		}
+
 		go func() {
-			if err := srv.ListenAndServe(); err != nil {
+			// the listeners are already bound in NewServer
+			if err := srv.ActivateAndServe(); err != nil {
 				errCh <- fmt.Errorf("start %s listener failed: %w", srv.Net, err)
 			}
 		}()
@@ -410,9 +521,83 @@ func (s *Server) Start(ctx context.Context, errCh chan<- error) {
 		}()
 	}
 
+	// the HTTP servers accept connections on the bound listeners immediately, only the DNS servers must be awaited
+	go func() {
+		wg.Wait()
+		close(s.ready)
+	}()
+
+	if s.profiles != nil {
+		s.profiles.Start(ctx)
+	}
+
 	registerPrintConfigurationTrigger(ctx, s)
 }
 
+// Ready returns a channel, which is closed as soon as all DNS listeners are bound and serving
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func (s *Server) isReady() bool {
+	select {
+	case <-s.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckHealth sends a health check query to each DNS listener. Must be called after the server is ready.
+func (s *Server) CheckHealth(ctx context.Context) error {
+	for _, srv := range s.dnsServers {
+		var addr net.Addr
+
+		if srv.PacketConn != nil {
+			addr = srv.PacketConn.LocalAddr()
+		} else if srv.Listener != nil {
+			addr = srv.Listener.Addr()
+		}
+
+		if addr == nil || !s.isReady() {
+			// the listeners are bound before the start
+			return fmt.Errorf("%s listener on %s is not running", srv.Net, srv.Addr)
+		}
+
+		client := &dns.Client{Net: srv.Net}
+		if srv.Net == "tcp-tls" {
+			// #nosec G402 // only the local listener is checked, the certificate doesn't matter
+			client.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+		}
+
+		_, _, err := client.ExchangeContext(ctx, util.NewMsgWithQuestion("healthcheck.blocky.", dns.Type(dns.TypeA)),
+			loopbackAddress(addr))
+		if err != nil {
+			return fmt.Errorf("%s listener on %s is not healthy: %w", srv.Net, srv.Addr, err)
+		}
+	}
+
+	return nil
+}
+
+// loopbackAddress replaces an unspecified listen IP with the loopback address
+func loopbackAddress(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		if ip != nil && ip.To4() == nil {
+			host = net.IPv6loopback.String()
+		} else {
+			host = "127.0.0.1"
+		}
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
 // Stop stops the server
 func (s *Server) Stop(ctx context.Context) error {
 	logger().Info("Stopping server")
@@ -423,6 +608,10 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
+	if caching, err := resolver.GetFromChainWithType[*resolver.CachingResolver](s.queryResolver); err == nil {
+		caching.SavePrefetchState(ctx)
+	}
+
 	return nil
 }
 
@@ -474,11 +663,18 @@ func newRequestFromDNS(ctx context.Context, rw dns.ResponseWriter, msg *dns.Msg)
 	}
 
 	var clientID string
+
+	listener := model.RequestListenerDns
+
 	if con, ok := rw.(dns.ConnectionStater); ok && con.ConnectionState() != nil {
 		clientID = extractClientIDFromHost(con.ConnectionState().ServerName)
+		listener = model.RequestListenerTls
 	}
 
-	return newRequest(ctx, clientIP, clientID, protocol, msg)
+	ctx, request := newRequest(ctx, clientIP, clientID, protocol, msg)
+	request.Listener = listener
+
+	return ctx, request
 }
 
 func newRequestFromHTTP(ctx context.Context, req *http.Request, msg *dns.Msg) (context.Context, *model.Request) {
@@ -490,7 +686,14 @@ func newRequestFromHTTP(ctx context.Context, req *http.Request, msg *dns.Msg) (c
 		clientID = extractClientIDFromHost(req.Host)
 	}
 
-	return newRequest(ctx, clientIP, clientID, protocol, msg)
+	ctx, request := newRequest(ctx, clientIP, clientID, protocol, msg)
+
+	request.Listener = model.RequestListenerHttp
+	if req.TLS != nil {
+		request.Listener = model.RequestListenerHttps
+	}
+
+	return ctx, request
 }
 
 // OnRequest will be executed if a new DNS request is received
@@ -505,7 +708,7 @@ type msgWriter interface {
 }
 
 func (s *Server) handleReq(ctx context.Context, request *model.Request, w msgWriter) {
-	response, err := s.resolve(ctx, request)
+	response, err := s.engine.ResolveRequest(ctx, request)
 	if err != nil {
 		log.FromCtx(ctx).Error("error on processing request:", err)
 
@@ -517,68 +720,10 @@ func (s *Server) handleReq(ctx context.Context, request *model.Request, w msgWri
 		err := w.WriteMsg(response.Res)
 		util.LogOnError(ctx, "can't write message: ", err)
 	}
-}
-
-func (s *Server) resolve(ctx context.Context, request *model.Request) (response *model.Response, rerr error) {
-	defer func() {
-		if val := recover(); val != nil {
-			rerr = fmt.Errorf("panic occurred: %v", val)
-		}
-	}()
-
-	contextUpstreamTimeoutMultiplier := 100
-	timeoutDuration := time.Duration(contextUpstreamTimeoutMultiplier) * s.cfg.Upstreams.Timeout.ToDuration()
-
-	ctx, cancel := context.WithTimeout(ctx, timeoutDuration)
-
-	defer cancel()
-
-	switch {
-	case len(request.Req.Question) == 0:
-		m := new(dns.Msg)
-		m.SetRcode(request.Req, dns.RcodeFormatError)
-
-		log.FromCtx(ctx).Error("query has no questions")
-
-		response = &model.Response{Res: m, RType: model.ResponseTypeCUSTOMDNS, Reason: "CUSTOM DNS"}
-	default:
-		var err error
-
-		response, err = s.queryResolver.Resolve(ctx, request)
-		if err != nil {
-			var upstreamErr *resolver.UpstreamServerError
-
-			if errors.As(err, &upstreamErr) {
-				response = &model.Response{Res: upstreamErr.Msg, RType: model.ResponseTypeRESOLVED, Reason: upstreamErr.Error()}
-			} else {
-				return nil, err
-			}
-		}
-	}
-
-	response.Res.RecursionAvailable = request.Req.RecursionDesired
-
-	// truncate if necessary
-	response.Res.Truncate(getMaxResponseSize(request))
-
-	// enable compression
-	response.Res.Compress = true
-
-	return response, nil
-}
-
-// returns EDNS UDP size or if not present, 512 for UDP and 64K for TCP
-func getMaxResponseSize(req *model.Request) int {
-	edns := req.Req.IsEdns0()
-	if edns != nil && edns.UDPSize() > 0 {
-		return int(edns.UDPSize())
-	}
 
-	if req.Protocol == model.RequestProtocolTCP {
-		return dns.MaxMsgSize
+	if s.profiles != nil {
+		s.profiles.ObserveLatency(ctx, time.Since(request.RequestTS))
 	}
-
-	return dns.MinMsgSize
 }
 
 // OnHealthCheck Handler for docker health check. Just returns OK code without delegating to resolver chain
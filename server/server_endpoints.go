@@ -8,8 +8,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/0xERR0R/blocky/metrics"
+	"github.com/0xERR0R/blocky/peersync"
 	"github.com/0xERR0R/blocky/resolver"
 
 	"github.com/0xERR0R/blocky/api"
@@ -34,15 +36,22 @@ const (
 	yamlContentType    = "text/yaml"
 )
 
-func (s *Server) createOpenAPIInterfaceImpl() (impl api.StrictServerInterface, err error) {
-	bControl, err := resolver.GetFromChainWithType[api.BlockingControl](s.queryResolver)
-	if err != nil {
+func (s *Server) createOpenAPIInterfaceImpl(ctx context.Context) (impl api.StrictServerInterface, err error) {
+	if _, err := resolver.GetFromChainWithType[*resolver.BlockingResolver](s.queryResolver); err != nil {
 		return nil, fmt.Errorf("no blocking API implementation found %w", err)
 	}
 
-	refresher, err := resolver.GetFromChainWithType[api.ListRefresher](s.queryResolver)
-	if err != nil {
-		return nil, fmt.Errorf("no refresh API implementation found %w", err)
+	// the blocking resolver is replaced, if the lists are reloaded
+	var bControl api.BlockingControl = chainBlockingControl{chain: s.queryResolver}
+
+	if s.cfg.PeerSync.IsEnabled() {
+		s.peerSync, err = peersync.New(ctx, s.cfg.PeerSync, bControl)
+		if err != nil {
+			return nil, fmt.Errorf("can't create peer sync: %w", err)
+		}
+
+		// state changes via API are published to the peers
+		bControl = s.peerSync
 	}
 
 	cacheControl, err := resolver.GetFromChainWithType[api.CacheControl](s.queryResolver)
@@ -50,18 +59,56 @@ func (s *Server) createOpenAPIInterfaceImpl() (impl api.StrictServerInterface, e
 		return nil, fmt.Errorf("no cache API implementation found %w", err)
 	}
 
-	return api.NewOpenAPIInterfaceImpl(bControl, s, refresher, cacheControl), nil
+	clientStats, err := resolver.GetFromChainWithType[api.ClientStatsProvider](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no client stats API implementation found %w", err)
+	}
+
+	auditLog, err := resolver.GetFromChainWithType[api.AuditLogProvider](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no audit API implementation found %w", err)
+	}
+
+	quotas, err := resolver.GetFromChainWithType[api.QuotaControl](s.queryResolver)
+	if err != nil {
+		return nil, fmt.Errorf("no quota API implementation found %w", err)
+	}
+
+	return api.NewOpenAPIInterfaceImpl(bControl, s, chainBlockingControl{chain: s.queryResolver}, cacheControl,
+		clientStats, s, s.engine, auditLog, chainQueryLogControl{chain: s.queryResolver}, s.engine, s, quotas), nil
 }
 
 func (s *Server) registerDoHEndpoints(router *chi.Mux, cfg *config.Config) {
-	pathDohQuery := cfg.Ports.DOHPath
-
-	router.Get(pathDohQuery, s.dohGetRequestHandler)
-	router.Get(pathDohQuery+"/", s.dohGetRequestHandler)
-	router.Get(pathDohQuery+"/{clientID}", s.dohGetRequestHandler)
-	router.Post(pathDohQuery, s.dohPostRequestHandler)
-	router.Post(pathDohQuery+"/", s.dohPostRequestHandler)
-	router.Post(pathDohQuery+"/{clientID}", s.dohPostRequestHandler)
+	for _, pathDohQuery := range cfg.Ports.DoHPaths() {
+		router.Get(pathDohQuery, s.dohGetRequestHandler)
+		router.Get(pathDohQuery+"/", s.dohGetRequestHandler)
+		router.Get(pathDohQuery+"/{clientID}", s.dohGetRequestHandler)
+		router.Post(pathDohQuery, s.dohPostRequestHandler)
+		router.Post(pathDohQuery+"/", s.dohPostRequestHandler)
+		router.Post(pathDohQuery+"/{clientID}", s.dohPostRequestHandler)
+	}
+}
+
+// dohEndpointTag returns the tag of the additional DoH endpoint, which matches the path and the host of the request
+func (s *Server) dohEndpointTag(req *http.Request) string {
+	path := req.URL.Path
+	if clientID := chi.URLParam(req, "clientID"); clientID != "" {
+		path = strings.TrimSuffix(path, "/"+clientID)
+	}
+
+	path = strings.TrimSuffix(path, "/")
+
+	// the SNI is the host name of the client, the Host header can differ with HTTP/1.1
+	host := req.Host
+	if req.TLS != nil && req.TLS.ServerName != "" {
+		host = req.TLS.ServerName
+	} else if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	tag, _ := s.cfg.Ports.DoHEndpointTag(path, host)
+
+	return tag
 }
 
 func (s *Server) dohGetRequestHandler(rw http.ResponseWriter, req *http.Request) {
@@ -128,6 +175,7 @@ func (s *Server) processDohMessage(rawMsg []byte, rw http.ResponseWriter, httpRe
 	}
 
 	ctx, dnsReq := newRequestFromHTTP(httpReq.Context(), httpReq, msg)
+	dnsReq.EndpointTag = s.dohEndpointTag(httpReq)
 
 	s.handleReq(ctx, dnsReq, httpMsgWriter{rw})
 }
@@ -168,22 +216,26 @@ func getSmallestTTLFromAnswer(msg *dns.Msg) uint32 {
 }
 
 func (s *Server) Query(
-	ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type,
+	ctx context.Context, serverHost string, clientIP net.IP, question string, qType dns.Type, debug bool,
 ) (*model.Response, error) {
 	msg := util.NewMsgWithQuestion(question, qType)
 	clientID := extractClientIDFromHost(serverHost)
 
 	ctx, req := newRequest(ctx, clientIP, clientID, model.RequestProtocolTCP, msg)
+	req.Listener = model.RequestListenerHttp
+	req.Debug = debug
 
-	return s.resolve(ctx, req)
+	return s.engine.ResolveRequest(ctx, req)
 }
 
 func createHTTPRouter(cfg *config.Config, openAPIImpl api.StrictServerInterface) *chi.Mux {
 	router := chi.NewRouter()
 
-	api.RegisterOpenAPIEndpoints(router, openAPIImpl)
+	api.RegisterOpenAPIEndpoints(router, openAPIImpl, apiTokens(&cfg.Tenancy))
 
-	configureDebugHandler(router)
+	if cfg.Profiling.Endpoints {
+		configureDebugHandler(router)
+	}
 
 	configureDocsHandler(router)
 
@@ -196,6 +248,20 @@ func createHTTPRouter(cfg *config.Config, openAPIImpl api.StrictServerInterface)
 	return router
 }
 
+// apiTokens returns the tokens of the administrator and the tenants, which authorize the API requests
+func apiTokens(cfg *config.Tenancy) api.APITokens {
+	tokens := api.APITokens{
+		Admin:   cfg.APIToken,
+		Tenants: make(map[string]string, len(cfg.Tenants)),
+	}
+
+	for name, tenant := range cfg.Tenants {
+		tokens.Tenants[name] = tenant.APIToken
+	}
+
+	return tokens
+}
+
 func configureDocsHandler(router *chi.Mux) {
 	router.Get("/docs/openapi.yaml", func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set(contentTypeHeader, yamlContentType)
@@ -246,10 +312,13 @@ func configureRootHandler(cfg *config.Config, router *chi.Mux) {
 				URL:   "/static/rapidoc.html",
 				Title: "Interactive Rest API Documentation (RapiDoc)",
 			},
-			{
+		}
+
+		if cfg.Profiling.Endpoints {
+			pd.Links = append(pd.Links, HandlerLink{
 				URL:   "/debug/",
 				Title: "Go Profiler",
-			},
+			})
 		}
 
 		if cfg.Prometheus.Enable {
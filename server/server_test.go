@@ -3,7 +3,9 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -12,6 +14,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/0xERR0R/blocky/api"
 	"github.com/0xERR0R/blocky/config"
 	"github.com/0xERR0R/blocky/docs"
 	. "github.com/0xERR0R/blocky/helpertest"
@@ -137,6 +140,7 @@ var _ = BeforeSuite(func() {
 				"clAllowlistOnly": {"allowlist"},
 				"clAdsAndYoutube": {"ads", "youtube"},
 				"clYoutubeOnly":   {"youtube"},
+				"tag:kids":        {"youtube"},
 			},
 			BlockType: "zeroIp",
 			BlockTTL:  config.Duration(6 * time.Hour),
@@ -155,6 +159,10 @@ var _ = BeforeSuite(func() {
 			HTTP:    config.ListenConfig{GetHostPort("", httpBasePort)},
 			HTTPS:   config.ListenConfig{GetHostPort("", httpsBasePort)},
 			DOHPath: "/dns-query",
+			DoHEndpoints: []config.DoHEndpoint{
+				{Path: "/dns-query-kids", Tag: "kids"},
+				{Host: "kids.blocky.test", Tag: "kids"},
+			},
 		},
 		CertFile: certPem.Path,
 		KeyFile:  keyPem.Path,
@@ -357,6 +365,11 @@ var _ = Describe("Running DNS server", func() {
 
 				Expect(resp.Answer).Should(BeEmpty())
 			})
+
+			It("should be ready and healthy", func() {
+				Eventually(sut.Ready()).Should(BeClosed())
+				Expect(sut.CheckHealth(ctx)).Should(Succeed())
+			})
 		})
 	})
 
@@ -583,6 +596,50 @@ var _ = Describe("Running DNS server", func() {
 				})
 			})
 		})
+
+		Context("additional DoH endpoints", func() {
+			post := func(url, host string) *dns.Msg {
+				rawDNSMessage, err := util.NewMsgWithQuestion("youtube.com.", A).Pack()
+				Expect(err).Should(Succeed())
+
+				req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(rawDNSMessage))
+				Expect(err).Should(Succeed())
+
+				req.Header.Set("Content-Type", "application/dns-message")
+				req.Host = host
+
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).Should(Succeed())
+				DeferCleanup(resp.Body.Close)
+
+				Expect(resp).Should(HaveHTTPStatus(http.StatusOK))
+
+				rawMsg, err := io.ReadAll(resp.Body)
+				Expect(err).Should(Succeed())
+
+				msg := new(dns.Msg)
+				Expect(msg.Unpack(rawMsg)).Should(Succeed())
+
+				return msg
+			}
+
+			It("should apply the client groups of the tag of the path", func() {
+				Expect(post(baseURL+"dns-query-kids", "").Answer).
+					Should(BeDNSRecord("youtube.com.", A, "0.0.0.0"))
+				Expect(post(baseURL+"dns-query-kids/client123", "").Answer).
+					Should(BeDNSRecord("youtube.com.", A, "0.0.0.0"))
+			})
+
+			It("should apply the client groups of the tag of the host", func() {
+				Expect(post(queryURL, "kids.blocky.test").Answer).
+					Should(BeDNSRecord("youtube.com.", A, "0.0.0.0"))
+			})
+
+			It("should not tag the queries of the default endpoint", func() {
+				Expect(post(queryURL, "").Answer).
+					Should(BeDNSRecord("youtube.com.", A, "123.124.122.122"))
+			})
+		})
 	})
 
 	Describe("Server create", func() {
@@ -603,9 +660,11 @@ var _ = Describe("Running DNS server", func() {
 		})
 		When("Server is created", func() {
 			It("is created without redis connection", func() {
-				_, err = NewServer(ctx, &cfg)
+				server, err := NewServer(ctx, &cfg)
 
 				Expect(err).Should(Succeed())
+
+				closeListeners(server.dnsServers, nil, nil)
 			})
 			It("can't be created if redis server is unavailable", func() {
 				cfg.Redis.Required = true
@@ -617,6 +676,197 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("Server reload", func() {
+		var (
+			server *Server
+			cfg    config.Config
+		)
+		BeforeEach(func() {
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			cfg.Upstreams.Groups = map[string][]config.Upstream{
+				"default": {config.Upstream{Net: config.NetProtocolTcpUdp, Host: "1.1.1.1", Port: 53}},
+			}
+
+			server, err = NewServer(ctx, &cfg)
+			Expect(err).Should(Succeed())
+
+			// the server isn't started, the bound listeners are closed for the next test
+			DeferCleanup(func() { closeListeners(server.dnsServers, nil, nil) })
+		})
+		When("the subsystem is unknown", func() {
+			It("should fail", func() {
+				server.SetConfigLoader(func() (*config.Config, error) { return &cfg, nil })
+
+				Expect(server.Reload(ctx, "unknown")).Should(MatchError(api.ErrUnknownSubsystem))
+			})
+		})
+		When("no config loader is set", func() {
+			It("should fail", func() {
+				Expect(server.Reload(ctx, "customdns")).Should(MatchError(ContainSubstring("not supported")))
+			})
+		})
+		When("the configuration can't be loaded", func() {
+			It("should fail", func() {
+				server.SetConfigLoader(func() (*config.Config, error) { return nil, errors.New("invalid") })
+
+				Expect(server.Reload(ctx, "customdns")).Should(MatchError(ContainSubstring("invalid")))
+			})
+		})
+		When("the configuration is loaded", func() {
+			It("should reload the subsystem", func() {
+				server.SetConfigLoader(func() (*config.Config, error) {
+					reloaded := cfg
+					reloaded.CustomDNS.Mapping = config.CustomDNSMapping{
+						"reloaded.lan": {&dns.A{A: net.ParseIP("192.168.178.57")}},
+					}
+
+					return &reloaded, nil
+				})
+
+				Expect(server.Reload(ctx, "customdns")).Should(Succeed())
+
+				resp, err := server.engine.Resolve(ctx, util.NewMsgWithQuestion("reloaded.lan.", A))
+				Expect(err).Should(Succeed())
+				Expect(resp.Answer).Should(ContainElement(BeDNSRecord("reloaded.lan.", A, "192.168.178.57")))
+			})
+		})
+		When("the configuration watch detects a change", func() {
+			It("should reload all subsystems", func() {
+				server.SetConfigLoader(func() (*config.Config, error) {
+					reloaded := cfg
+					reloaded.CustomDNS.Mapping = config.CustomDNSMapping{
+						"watched.lan": {&dns.A{A: net.ParseIP("192.168.178.58")}},
+					}
+
+					return &reloaded, nil
+				})
+
+				var fingerprint atomic.Value
+				fingerprint.Store("before")
+
+				server.WatchConfig(ctx, config.ConfigWatch{Enable: true, Interval: config.Duration(10 * time.Millisecond)},
+					func() (string, error) { return fingerprint.Load().(string), nil })
+
+				fingerprint.Store("after")
+
+				Eventually(func(g Gomega) {
+					resp, err := server.engine.Resolve(ctx, util.NewMsgWithQuestion("watched.lan.", A))
+					g.Expect(err).Should(Succeed())
+					g.Expect(resp.Answer).Should(ContainElement(BeDNSRecord("watched.lan.", A, "192.168.178.58")))
+				}, "1s").Should(Succeed())
+			})
+		})
+		When("the reload after a change fails", func() {
+			It("should retry the reload", func() {
+				var loads atomic.Int32
+
+				server.SetConfigLoader(func() (*config.Config, error) {
+					if loads.Add(1) == 1 {
+						return nil, errors.New("invalid")
+					}
+
+					reloaded := cfg
+					reloaded.CustomDNS.Mapping = config.CustomDNSMapping{
+						"retried.lan": {&dns.A{A: net.ParseIP("192.168.178.60")}},
+					}
+
+					return &reloaded, nil
+				})
+
+				var fingerprint atomic.Value
+				fingerprint.Store("before")
+
+				server.WatchConfig(ctx, config.ConfigWatch{Enable: true, Interval: config.Duration(10 * time.Millisecond)},
+					func() (string, error) { return fingerprint.Load().(string), nil })
+
+				fingerprint.Store("after")
+
+				Eventually(func(g Gomega) {
+					resp, err := server.engine.Resolve(ctx, util.NewMsgWithQuestion("retried.lan.", A))
+					g.Expect(err).Should(Succeed())
+					g.Expect(resp.Answer).Should(ContainElement(BeDNSRecord("retried.lan.", A, "192.168.178.60")))
+				}, "1s").Should(Succeed())
+
+				Expect(loads.Load()).Should(BeNumerically(">=", 2))
+			})
+		})
+		Describe("restartSections", func() {
+			It("should return no sections for an unchanged configuration", func() {
+				running, err := config.LoadConfig("../docs/config.yml", true)
+				Expect(err).Should(Succeed())
+
+				loaded, err := config.LoadConfig("../docs/config.yml", true)
+				Expect(err).Should(Succeed())
+
+				Expect(restartSections(running, loaded)).Should(BeEmpty())
+			})
+
+			It("should return the changed sections, which aren't reloaded", func() {
+				loaded := cfg
+				loaded.CustomDNS.Mapping = config.CustomDNSMapping{
+					"changed.lan": {&dns.A{A: net.ParseIP("192.168.178.61")}},
+				}
+				loaded.Caching.MaxCachingTime = config.Duration(time.Hour)
+				loaded.Ports.DNS = config.ListenConfig{"5353"}
+
+				Expect(restartSections(&cfg, &loaded)).Should(Equal([]string{"caching", "ports"}))
+			})
+		})
+	})
+
+	Describe("Config dry run", func() {
+		var server *Server
+
+		BeforeEach(func() {
+			var cfg config.Config
+
+			Expect(defaults.Set(&cfg)).Should(Succeed())
+
+			cfg.Upstreams.Groups = map[string][]config.Upstream{
+				"default": {config.Upstream{Net: config.NetProtocolTcpUdp, Host: "1.1.1.1", Port: 53}},
+			}
+
+			server, err = NewServer(ctx, &cfg)
+			Expect(err).Should(Succeed())
+
+			// the server isn't started, the bound listeners are closed for the next test
+			DeferCleanup(func() { closeListeners(server.dnsServers, nil, nil) })
+		})
+		When("the configuration is invalid", func() {
+			It("should return the error", func() {
+				result := server.DryRunConfig(ctx, []byte("unknownOption: 1"), nil)
+
+				Expect(result.Err).Should(HaveOccurred())
+				Expect(result.Chain).Should(BeEmpty())
+			})
+		})
+		When("the configuration is valid", func() {
+			It("should resolve the queries with the candidate, but not change the running chain", func() {
+				result := server.DryRunConfig(ctx, []byte(`
+upstreams:
+  groups:
+    default:
+      - 1.1.1.1
+customDNS:
+  mapping:
+    candidate.lan: 192.168.178.59
+`), []api.DryRunQuery{{Question: "candidate.lan.", Type: A}})
+
+				Expect(result.Err).Should(Succeed())
+				Expect(result.Chain).Should(ContainElement(
+					HaveField("Config", ContainElement(ContainSubstring("candidate.lan")))))
+				Expect(result.Responses).Should(HaveLen(1))
+				Expect(result.Responses[0].Err).Should(Succeed())
+				Expect(result.Responses[0].Response.Res.Answer).
+					Should(ContainElement(BeDNSRecord("candidate.lan.", A, "192.168.178.59")))
+
+				Expect(server.engine.ResolverChain()).ShouldNot(ContainElement(
+					HaveField("Config", ContainElement(ContainSubstring("candidate.lan")))))
+			})
+		})
+	})
+
 	Describe("Server start", Label("XX"), func() {
 		When("Server start is called", func() {
 			var (
@@ -652,6 +902,10 @@ var _ = Describe("Running DNS server", func() {
 
 				DeferCleanup(server.Stop)
 			})
+			It("should become ready", func() {
+				Eventually(server.Ready()).Should(BeClosed())
+				Expect(server.CheckHealth(ctx)).Should(Succeed())
+			})
 			It("start was called 2 times, start should fail", func() {
 				Consistently(errChan, "1s").ShouldNot(Receive())
 
@@ -691,8 +945,14 @@ var _ = Describe("Running DNS server", func() {
 
 				Expect(err).Should(Succeed())
 
+				DeferCleanup(func() { closeListeners(server.dnsServers, nil, nil) })
+
 				errChan = make(chan error, 10)
 			})
+			It("should not be ready and healthy before start", func() {
+				Expect(server.Ready()).ShouldNot(BeClosed())
+				Expect(server.CheckHealth(ctx)).Should(MatchError(ContainSubstring("is not running")))
+			})
 			It("stop was called 2 times, start should fail", func() {
 				// start server
 				go server.Start(ctx, errChan)
@@ -729,6 +989,25 @@ var _ = Describe("Running DNS server", func() {
 		})
 	})
 
+	Describe("request listener", func() {
+		It("should be http for DoH requests without TLS", func() {
+			httpReq, err := http.NewRequest(http.MethodGet, "http://blocky/dns-query", nil)
+			Expect(err).Should(Succeed())
+
+			_, req := newRequestFromHTTP(context.Background(), httpReq, util.NewMsgWithQuestion("example.com.", A))
+			Expect(req.Listener).Should(Equal(model.RequestListenerHttp))
+		})
+		It("should be https for DoH requests with TLS", func() {
+			httpReq, err := http.NewRequest(http.MethodGet, "https://blocky/dns-query", nil)
+			Expect(err).Should(Succeed())
+
+			httpReq.TLS = &tls.ConnectionState{}
+
+			_, req := newRequestFromHTTP(context.Background(), httpReq, util.NewMsgWithQuestion("example.com.", A))
+			Expect(req.Listener).Should(Equal(model.RequestListenerHttps))
+		})
+	})
+
 	Describe("self-signed certificate creation", func() {
 		var (
 			cfg  config.Config
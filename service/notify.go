@@ -0,0 +1,112 @@
+//go:build !windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	notifySocketEnv = "NOTIFY_SOCKET"
+	watchdogUsecEnv = "WATCHDOG_USEC"
+	watchdogPIDEnv  = "WATCHDOG_PID"
+)
+
+// Notify sends the state (e.g. "READY=1") to the service manager (systemd sd_notify protocol).
+// Returns false if the process was not started with notification support (NOTIFY_SOCKET is not set).
+func Notify(state string) (bool, error) {
+	socketAddr := os.Getenv(notifySocketEnv)
+	if socketAddr == "" {
+		return false, nil
+	}
+
+	// abstract namespace socket
+	if socketAddr[0] == '@' {
+		socketAddr = "\x00" + socketAddr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketAddr, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("can't connect to notify socket: %w", err)
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("can't write to notify socket: %w", err)
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured by the service manager (WatchdogSec).
+// Returns 0 if the watchdog is not enabled for this process.
+func WatchdogInterval() (time.Duration, error) {
+	usecStr := os.Getenv(watchdogUsecEnv)
+	if usecStr == "" {
+		return 0, nil
+	}
+
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s'", watchdogUsecEnv, usecStr)
+	}
+
+	if pidStr := os.Getenv(watchdogPIDEnv); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s value '%s'", watchdogPIDEnv, pidStr)
+		}
+
+		// watchdog is meant for another process
+		if pid != os.Getpid() {
+			return 0, nil
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond, nil
+}
+
+// RunWatchdog periodically sends "WATCHDOG=1" to the service manager until the context is done.
+// The ping interval is half of the configured watchdog timeout. A ping is only sent if the liveness check succeeds,
+// so the service manager restarts the process if it is not healthy anymore.
+// Does nothing if the watchdog is not enabled.
+func RunWatchdog(ctx context.Context, check func(ctx context.Context) error, onError func(error)) error {
+	interval, err := WatchdogInterval()
+	if err != nil || interval == 0 {
+		return err
+	}
+
+	pingInterval := interval / 2 //nolint:mnd
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, pingInterval)
+			err := check(checkCtx)
+
+			cancel()
+
+			if err != nil {
+				onError(fmt.Errorf("liveness check failed: %w", err))
+
+				continue
+			}
+
+			if _, err := Notify("WATCHDOG=1"); err != nil {
+				onError(err)
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
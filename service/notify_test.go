@@ -0,0 +1,150 @@
+//go:build !windows
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notify", func() {
+	var conn *net.UnixConn
+
+	healthy := func(context.Context) error { return nil }
+
+	readMessage := func() string {
+		buf := make([]byte, 1024)
+
+		Expect(conn.SetReadDeadline(time.Now().Add(time.Second))).Should(Succeed())
+
+		n, err := conn.Read(buf)
+		Expect(err).Should(Succeed())
+
+		return string(buf[:n])
+	}
+
+	BeforeEach(func() {
+		socketPath := filepath.Join(GinkgoT().TempDir(), "notify.sock")
+
+		var err error
+		conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+		Expect(err).Should(Succeed())
+		DeferCleanup(conn.Close)
+
+		GinkgoT().Setenv(notifySocketEnv, socketPath)
+	})
+
+	Describe("Notify", func() {
+		It("should send the state to the socket", func() {
+			Expect(Notify("READY=1")).Should(BeTrue())
+			Expect(readMessage()).Should(Equal("READY=1"))
+		})
+
+		When("NOTIFY_SOCKET is not set", func() {
+			BeforeEach(func() {
+				GinkgoT().Setenv(notifySocketEnv, "")
+			})
+
+			It("should do nothing", func() {
+				Expect(Notify("READY=1")).Should(BeFalse())
+			})
+		})
+
+		When("socket does not exist", func() {
+			BeforeEach(func() {
+				GinkgoT().Setenv(notifySocketEnv, filepath.Join(GinkgoT().TempDir(), "missing.sock"))
+			})
+
+			It("should return error", func() {
+				_, err := Notify("READY=1")
+				Expect(err).Should(MatchError(ContainSubstring("can't connect to notify socket")))
+			})
+		})
+	})
+
+	Describe("WatchdogInterval", func() {
+		It("should be disabled without env", func() {
+			GinkgoT().Setenv(watchdogUsecEnv, "")
+
+			Expect(WatchdogInterval()).Should(BeZero())
+		})
+
+		It("should parse the interval", func() {
+			GinkgoT().Setenv(watchdogUsecEnv, "30000000")
+			GinkgoT().Setenv(watchdogPIDEnv, strconv.Itoa(os.Getpid()))
+
+			Expect(WatchdogInterval()).Should(Equal(30 * time.Second))
+		})
+
+		It("should be disabled for other processes", func() {
+			GinkgoT().Setenv(watchdogUsecEnv, "30000000")
+			GinkgoT().Setenv(watchdogPIDEnv, strconv.Itoa(os.Getpid()+1))
+
+			Expect(WatchdogInterval()).Should(BeZero())
+		})
+
+		It("should return error on invalid value", func() {
+			GinkgoT().Setenv(watchdogUsecEnv, "abc")
+
+			_, err := WatchdogInterval()
+			Expect(err).Should(MatchError(ContainSubstring("invalid WATCHDOG_USEC")))
+		})
+	})
+
+	Describe("RunWatchdog", func() {
+		It("should ping periodically", func(ctx context.Context) {
+			GinkgoT().Setenv(watchdogUsecEnv, "100000")
+			GinkgoT().Setenv(watchdogPIDEnv, "")
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			go func() {
+				defer GinkgoRecover()
+
+				Expect(RunWatchdog(ctx, healthy, func(err error) { Fail(err.Error()) })).Should(Succeed())
+			}()
+
+			Expect(readMessage()).Should(Equal("WATCHDOG=1"))
+			Expect(readMessage()).Should(Equal("WATCHDOG=1"))
+		})
+
+		It("should not ping if liveness check fails", func(ctx context.Context) {
+			GinkgoT().Setenv(watchdogUsecEnv, "100000")
+			GinkgoT().Setenv(watchdogPIDEnv, "")
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			errs := make(chan error, 10)
+
+			go func() {
+				defer GinkgoRecover()
+
+				Expect(RunWatchdog(ctx, func(context.Context) error { return errors.New("boom") },
+					func(err error) { errs <- err })).Should(Succeed())
+			}()
+
+			Eventually(errs).Should(Receive(MatchError("liveness check failed: boom")))
+
+			Expect(conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))).Should(Succeed())
+
+			_, err := conn.Read(make([]byte, 1024))
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("should return immediately if watchdog is disabled", func(ctx context.Context) {
+			GinkgoT().Setenv(watchdogUsecEnv, "")
+
+			Expect(RunWatchdog(ctx, healthy, func(error) {})).Should(Succeed())
+		})
+	})
+})
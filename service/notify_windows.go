@@ -0,0 +1,23 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Notify is a no-op on Windows, there is no sd_notify equivalent.
+func Notify(string) (bool, error) {
+	return false, nil
+}
+
+// WatchdogInterval always returns 0 on Windows.
+func WatchdogInterval() (time.Duration, error) {
+	return 0, nil
+}
+
+// RunWatchdog is a no-op on Windows.
+func RunWatchdog(context.Context, func(context.Context) error, func(error)) error {
+	return nil
+}
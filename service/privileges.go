@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// ErrPrivilegeDropNotSupported is returned if the privileges can't be dropped on the current operating system
+var ErrPrivilegeDropNotSupported = errors.New("dropping privileges is only supported on Linux")
+
+// lookupIDs returns the IDs of the user and group, names and numeric IDs are accepted. Without group, the primary
+// group of the user is used, a numeric user ID without entry in the user database requires a group. Without user,
+// the current user is kept.
+func lookupIDs(userName, groupName string, currentUID int) (uid, gid int, err error) {
+	uid = currentUID
+	gid = -1
+
+	if userName != "" {
+		u, err := lookupUser(userName)
+		if err != nil {
+			return 0, 0, fmt.Errorf("can't find user %s: %w", userName, err)
+		}
+
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return 0, 0, fmt.Errorf("invalid ID of user %s: %w", userName, err)
+		}
+
+		switch {
+		case u.Gid != "":
+			if gid, err = strconv.Atoi(u.Gid); err != nil {
+				return 0, 0, fmt.Errorf("invalid primary group of user %s: %w", userName, err)
+			}
+		case groupName == "":
+			// keeping the current group would keep the group of root
+			return 0, 0, fmt.Errorf("user %s has no primary group, runAs.group must be set", userName)
+		}
+	}
+
+	if groupName != "" {
+		g, err := lookupGroup(groupName)
+		if err != nil {
+			return 0, 0, fmt.Errorf("can't find group %s: %w", groupName, err)
+		}
+
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return 0, 0, fmt.Errorf("invalid ID of group %s: %w", groupName, err)
+		}
+	}
+
+	return uid, gid, nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+
+		// a numeric ID without entry in the user database has no primary group
+		return &user.User{Uid: name}, nil
+	}
+
+	return user.Lookup(name)
+}
+
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return &user.Group{Gid: name}, nil
+	}
+
+	return user.LookupGroup(name)
+}
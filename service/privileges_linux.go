@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// DropPrivileges switches the process to the user and group (setuid/setgid) and drops all capabilities, already
+// bound listeners are kept. Must be called after all privileged ports are bound.
+func DropPrivileges(userName, groupName string) error {
+	currentUID := os.Getuid()
+
+	uid, gid, err := lookupIDs(userName, groupName, currentUID)
+	if err != nil {
+		return err
+	}
+
+	if gid < 0 {
+		gid = os.Getgid()
+	}
+
+	if uid != currentUID || gid != os.Getgid() {
+		// the supplementary groups of the current user must not be kept
+		if err := syscall.Setgroups([]int{gid}); err != nil {
+			return fmt.Errorf("can't set groups: %w", err)
+		}
+
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("can't set group ID %d: %w", gid, err)
+		}
+	}
+
+	if uid != currentUID {
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("can't set user ID %d: %w", uid, err)
+		}
+	}
+
+	err = dropCapabilities()
+	if errors.Is(err, syscall.ENOTSUP) {
+		// with cgo, the syscalls can't be applied to all threads. setuid from root already cleared the capabilities
+		// of all threads, only the additional protection is missing
+		if currentUID == 0 && uid != 0 {
+			return nil
+		}
+
+		return fmt.Errorf("%w (blocky has to be built without cgo)", err)
+	}
+
+	return err
+}
+
+// dropCapabilities clears the effective, permitted, inheritable and ambient capabilities of all threads and
+// prevents gaining new privileges on execve (e.g. by file capabilities or setuid binaries)
+func dropCapabilities() error {
+	if err := allThreadsPrctl(unix.PR_SET_NO_NEW_PRIVS, 1); err != nil {
+		return fmt.Errorf("can't set no new privileges: %w", err)
+	}
+
+	// EINVAL: ambient capabilities are not supported by the kernel (before Linux 4.3)
+	err := allThreadsPrctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL)
+	if err != nil && !errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("can't clear ambient capabilities: %w", err)
+	}
+
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	data := [2]unix.CapUserData{} // all capabilities cleared
+
+	_, _, errno := syscall.AllThreadsSyscall(
+		unix.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0,
+	)
+	if errno != 0 {
+		return fmt.Errorf("can't clear capabilities: %w", errno)
+	}
+
+	return nil
+}
+
+// allThreadsPrctl calls prctl on all threads, capabilities and the no new privileges flag are per thread
+func allThreadsPrctl(option int, arg uintptr) error {
+	_, _, errno := syscall.AllThreadsSyscall(unix.SYS_PRCTL, uintptr(option), arg, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package service
+
+// DropPrivileges is only supported on Linux
+func DropPrivileges(_, _ string) error {
+	return ErrPrivilegeDropNotSupported
+}
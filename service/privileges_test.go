@@ -0,0 +1,79 @@
+//go:build !windows
+
+package service
+
+import (
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Privileges", func() {
+	Describe("lookupIDs", func() {
+		It("should return the IDs and the primary group of the user", func() {
+			uid, gid, err := lookupIDs("root", "", 1000)
+			Expect(err).Should(Succeed())
+
+			Expect(uid).Should(BeZero())
+			Expect(gid).Should(BeZero())
+		})
+
+		It("should use the configured group", func() {
+			uid, gid, err := lookupIDs("root", "1234", 1000)
+			Expect(err).Should(Succeed())
+
+			Expect(uid).Should(BeZero())
+			Expect(gid).Should(Equal(1234))
+		})
+
+		It("should accept numeric IDs without entry", func() {
+			uid, gid, err := lookupIDs("54321", "54321", 1000)
+			Expect(err).Should(Succeed())
+
+			Expect(uid).Should(Equal(54321))
+			Expect(gid).Should(Equal(54321))
+		})
+
+		It("should fail for numeric IDs without entry and without group", func() {
+			_, _, err := lookupIDs("54321", "", 0)
+			Expect(err).Should(MatchError("user 54321 has no primary group, runAs.group must be set"))
+		})
+
+		It("should use the primary group of numeric IDs with entry", func() {
+			uid, gid, err := lookupIDs("0", "", 1000)
+			Expect(err).Should(Succeed())
+
+			Expect(uid).Should(BeZero())
+			Expect(gid).Should(BeZero())
+		})
+
+		It("should keep the current user without user", func() {
+			uid, gid, err := lookupIDs("", "1234", 1000)
+			Expect(err).Should(Succeed())
+
+			Expect(uid).Should(Equal(1000))
+			Expect(gid).Should(Equal(1234))
+		})
+
+		It("should fail for an unknown user", func() {
+			_, _, err := lookupIDs("blocky-unknown-user", "", 1000)
+			Expect(err).Should(MatchError(ContainSubstring("can't find user blocky-unknown-user")))
+		})
+
+		It("should fail for an unknown group", func() {
+			_, _, err := lookupIDs("", "blocky-unknown-group", 1000)
+			Expect(err).Should(MatchError(ContainSubstring("can't find group blocky-unknown-group")))
+		})
+	})
+
+	Describe("DropPrivileges", func() {
+		It("should not be supported on other systems than Linux", func() {
+			if runtime.GOOS == "linux" {
+				Skip("supported on Linux")
+			}
+
+			Expect(DropPrivileges("nobody", "")).Should(MatchError(ErrPrivilegeDropNotSupported))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package service
+
+// IsWindowsService returns true if the process is started by the Windows Service Control Manager
+func IsWindowsService() bool {
+	return false
+}
+
+// Install registers the executable as Windows service
+func Install(_ []string) error {
+	return ErrNotSupported
+}
+
+// Uninstall removes the Windows service registration
+func Uninstall() error {
+	return ErrNotSupported
+}
+
+// Run runs the function as Windows service
+func Run(_ func(ready func()) error, _ func()) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Windows service", func() {
+	It("should not be supported", func() {
+		Expect(IsWindowsService()).Should(BeFalse())
+		Expect(Install(nil)).Should(MatchError(ErrNotSupported))
+		Expect(Uninstall()).Should(MatchError(ErrNotSupported))
+		Expect(Run(func(func()) error { return nil }, func() {})).Should(MatchError(ErrNotSupported))
+	})
+})
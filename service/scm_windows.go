@@ -0,0 +1,148 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/0xERR0R/blocky/log"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// IsWindowsService returns true if the process is started by the Windows Service Control Manager
+func IsWindowsService() bool {
+	isService, err := svc.IsWindowsService()
+
+	return err == nil && isService
+}
+
+// Install registers the executable as Windows service, args are passed to the "service run" command
+func Install(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("can't determine executable path: %w", err)
+	}
+
+	exe, err = filepath.Abs(exe)
+	if err != nil {
+		return fmt.Errorf("can't determine executable path: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("can't connect to service manager: %w", err)
+	}
+
+	defer m.Disconnect() //nolint:errcheck
+
+	if s, err := m.OpenService(Name); err == nil {
+		s.Close()
+
+		return fmt.Errorf("service '%s' already exists", Name)
+	}
+
+	s, err := m.CreateService(Name, exe, mgr.Config{
+		DisplayName: displayName,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, append([]string{"service", "run"}, args...)...)
+	if err != nil {
+		return fmt.Errorf("can't create service: %w", err)
+	}
+
+	defer s.Close()
+
+	return nil
+}
+
+// Uninstall removes the Windows service registration
+func Uninstall() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("can't connect to service manager: %w", err)
+	}
+
+	defer m.Disconnect() //nolint:errcheck
+
+	s, err := m.OpenService(Name)
+	if err != nil {
+		return fmt.Errorf("service '%s' is not installed: %w", Name, err)
+	}
+
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("can't delete service: %w", err)
+	}
+
+	return nil
+}
+
+// Run runs the function as Windows service. The run function must call ready as soon as the server is started.
+// The stop function is called, if the service manager requests the service to stop, run must return afterwards.
+func Run(run func(ready func()) error, stop func()) error {
+	if !IsWindowsService() {
+		return errors.New("not started by the Windows service manager")
+	}
+
+	return svc.Run(Name, &handler{run: run, stop: stop})
+}
+
+type handler struct {
+	run  func(ready func()) error
+	stop func()
+}
+
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+
+	status <- svc.Status{State: svc.StartPending}
+
+	readyCh := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	go func() {
+		var once sync.Once
+
+		errCh <- h.run(func() {
+			once.Do(func() { close(readyCh) })
+		})
+	}()
+
+	for {
+		select {
+		case <-readyCh:
+			readyCh = nil // nil channel is never selected again
+
+			status <- svc.Status{State: svc.Running, Accepts: accepted}
+
+		case err := <-errCh:
+			status <- svc.Status{State: svc.StopPending}
+
+			if err != nil {
+				log.Log().Error("service terminated with error: ", err)
+
+				return true, 1
+			}
+
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+
+				h.stop()
+			}
+		}
+	}
+}
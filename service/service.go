@@ -0,0 +1,16 @@
+// Package service contains the integration with the service managers of the operating systems:
+// systemd (sd_notify READY/STOPPING/WATCHDOG) on Linux and the Service Control Manager on Windows.
+package service
+
+import "errors"
+
+const (
+	// Name is the name of the registered Windows service
+	Name = "blocky"
+
+	displayName = "Blocky DNS"
+	description = "Fast and lightweight DNS proxy as ad-blocker for local network with many features"
+)
+
+// ErrNotSupported is returned if the service manager is not available on the current operating system
+var ErrNotSupported = errors.New("windows service is only supported on Windows")
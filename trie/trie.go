@@ -1,6 +1,10 @@
 package trie
 
 import (
+	"encoding/binary"
+	"errors"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/0xERR0R/blocky/log"
@@ -190,3 +194,145 @@ func (t terminal) hasParentOf(searchKey string, split SplitFunc) bool {
 		tKey = tRest
 	}
 }
+
+const (
+	terminalTag byte = iota
+	parentTag
+)
+
+var errInvalidEncoding = errors.New("invalid trie encoding")
+
+// MarshalBinary encodes the nodes of the trie, so it can be restored by `UnmarshalBinary` without inserting the keys
+// again. The split function is not encoded.
+func (t *Trie) MarshalBinary() ([]byte, error) {
+	return t.root.appendBinary(nil), nil
+}
+
+// UnmarshalBinary replaces the nodes of the trie with nodes encoded by `MarshalBinary`.
+// The trie must use the same split function as the encoded one.
+func (t *Trie) UnmarshalBinary(data []byte) error {
+	var root parent
+
+	rest, err := root.decodeChildren(data)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) != 0 {
+		return errInvalidEncoding
+	}
+
+	t.root = root
+
+	return nil
+}
+
+// appendBinary appends the children in key order, so equal tries have equal encodings
+func (n *parent) appendBinary(buf []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(n.children)))
+
+	for _, label := range slices.Sorted(maps.Keys(n.children)) {
+		buf = appendString(buf, label)
+
+		switch child := n.children[label].(type) {
+		case *parent:
+			buf = append(buf, parentTag)
+			buf = child.appendBinary(buf)
+
+		case terminal:
+			buf = append(buf, terminalTag)
+			buf = appendString(buf, child.String())
+		}
+	}
+
+	return buf
+}
+
+func (n *parent) decodeChildren(data []byte) ([]byte, error) {
+	count, data, err := decodeUvarint(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return data, nil
+	}
+
+	// each child needs at least a byte for the label length and one for the tag
+	if count > uint64(len(data)/2) { //nolint:mnd
+		return nil, errInvalidEncoding
+	}
+
+	n.children = make(map[string]node, count)
+
+	for range count {
+		var label string
+
+		label, data, err = decodeString(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) == 0 {
+			return nil, errInvalidEncoding
+		}
+
+		tag := data[0]
+		data = data[1:]
+
+		switch tag {
+		case parentTag:
+			child := &parent{}
+
+			data, err = child.decodeChildren(data)
+			if err != nil {
+				return nil, err
+			}
+
+			n.children[label] = child
+
+		case terminalTag:
+			var key string
+
+			key, data, err = decodeString(data)
+			if err != nil {
+				return nil, err
+			}
+
+			n.children[label] = terminal(key)
+
+		default:
+			return nil, errInvalidEncoding
+		}
+	}
+
+	return data, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+
+	return append(buf, s...)
+}
+
+func decodeUvarint(data []byte) (uint64, []byte, error) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, errInvalidEncoding
+	}
+
+	return value, data[n:], nil
+}
+
+func decodeString(data []byte) (string, []byte, error) {
+	length, data, err := decodeUvarint(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if length > uint64(len(data)) {
+		return "", nil, errInvalidEncoding
+	}
+
+	return string(data[:length]), data[length:], nil
+}
@@ -105,4 +105,62 @@ var _ = Describe("Trie", func() {
 			})
 		})
 	})
+
+	Describe("Binary encoding", func() {
+		decode := func(data []byte) (*Trie, error) {
+			decoded := NewTrie(SplitTLD)
+
+			return decoded, decoded.UnmarshalBinary(data)
+		}
+
+		It("should restore the trie", func() {
+			for _, domain := range []string{"example.com", "abc.other.com", "xyz.other.com", "org"} {
+				sut.Insert(domain)
+			}
+
+			data, err := sut.MarshalBinary()
+			Expect(err).Should(Succeed())
+
+			decoded, err := decode(data)
+			Expect(err).Should(Succeed())
+
+			for _, domain := range []string{"example.com", "www.example.com", "abc.other.com", "xyz.other.com", "a.org"} {
+				Expect(decoded.HasParentOf(domain)).Should(BeTrue(), domain)
+			}
+
+			for _, domain := range []string{"com", "other.com", "def.other.com", "example.net"} {
+				Expect(decoded.HasParentOf(domain)).Should(BeFalse(), domain)
+			}
+
+			Expect(decoded.MarshalBinary()).Should(Equal(data))
+		})
+
+		It("should restore an empty trie", func() {
+			data, err := sut.MarshalBinary()
+			Expect(err).Should(Succeed())
+
+			decoded, err := decode(data)
+			Expect(err).Should(Succeed())
+			Expect(decoded.IsEmpty()).Should(BeTrue())
+		})
+
+		It("should fail on invalid data", func() {
+			sut.Insert("example.com")
+			sut.Insert("www.other.com")
+
+			data, err := sut.MarshalBinary()
+			Expect(err).Should(Succeed())
+
+			for i := range data {
+				_, err := decode(data[:i])
+				Expect(err).Should(MatchError(errInvalidEncoding), "length %d", i)
+			}
+
+			_, err = decode(append(data, 0))
+			Expect(err).Should(MatchError(errInvalidEncoding))
+
+			_, err = decode([]byte{1, 1, 'a', 7})
+			Expect(err).Should(MatchError(errInvalidEncoding))
+		})
+	})
 })
@@ -89,3 +89,54 @@ func parseIPv6FromArpaAddr(arpa string) (net.IP, error) {
 
 	return net.IP(buf), nil
 }
+
+// ReverseZones returns the reverse zones (without trailing dot) covering the subnet.
+// Prefixes not on an octet (IPv4) or nibble (IPv6) boundary are expanded to all zones of the next boundary.
+func ReverseZones(subnet *net.IPNet) []string {
+	const (
+		base10     = 10
+		base16     = 16
+		nibbleBits = byteBits / 2
+		nibbleMask = 1<<nibbleBits - 1
+	)
+
+	ones, bits := subnet.Mask.Size()
+
+	if ip4 := subnet.IP.To4(); ip4 != nil && bits == net.IPv4len*byteBits {
+		return reverseZones(ip4, ones, byteBits, base10, IPv4PtrSuffix)
+	}
+
+	nibbles := make([]byte, 0, 2*net.IPv6len)
+	for _, b := range subnet.IP.To16() {
+		nibbles = append(nibbles, b>>nibbleBits, b&nibbleMask)
+	}
+
+	return reverseZones(nibbles, ones, nibbleBits, base16, IPv6PtrSuffix)
+}
+
+// reverseZones builds the zone names from the labels (octets or nibbles) of `labelBits` bits each,
+// which are covered by the first `ones` bits
+func reverseZones(labels []byte, ones, labelBits, base int, suffix string) []string {
+	count := (ones + labelBits - 1) / labelBits
+	expand := 1 << (count*labelBits - ones)
+	suffix = strings.Trim(suffix, ".")
+
+	zones := make([]string, 0, expand)
+
+	for i := range expand {
+		parts := make([]string, 0, count+1)
+
+		for j := count - 1; j >= 0; j-- {
+			label := labels[j]
+			if j == count-1 {
+				label += byte(i)
+			}
+
+			parts = append(parts, strconv.FormatUint(uint64(label), base))
+		}
+
+		zones = append(zones, strings.Join(append(parts, suffix), "."))
+	}
+
+	return zones
+}
@@ -96,3 +96,38 @@ var _ = Describe("ParseIPFromArpaAddr", func() {
 		})
 	})
 })
+
+var _ = Describe("ReverseZones", func() {
+	zones := func(cidr string) []string {
+		_, subnet, err := net.ParseCIDR(cidr)
+		Expect(err).Should(Succeed())
+
+		return ReverseZones(subnet)
+	}
+
+	Describe("IPv4", func() {
+		It("returns the zone for octet boundaries", func() {
+			Expect(zones("10.0.0.0/8")).Should(Equal([]string{"10.in-addr.arpa"}))
+			Expect(zones("192.168.1.0/24")).Should(Equal([]string{"1.168.192.in-addr.arpa"}))
+			Expect(zones("192.168.1.7/32")).Should(Equal([]string{"7.1.168.192.in-addr.arpa"}))
+			Expect(zones("0.0.0.0/0")).Should(Equal([]string{"in-addr.arpa"}))
+		})
+
+		It("expands other prefixes to the next octet boundary", func() {
+			Expect(zones("172.16.0.0/12")).Should(HaveLen(16))
+			Expect(zones("172.16.0.0/12")).Should(ContainElements("16.172.in-addr.arpa", "31.172.in-addr.arpa"))
+			Expect(zones("192.168.4.0/23")).Should(Equal([]string{"4.168.192.in-addr.arpa", "5.168.192.in-addr.arpa"}))
+		})
+	})
+
+	Describe("IPv6", func() {
+		It("returns the zone for nibble boundaries", func() {
+			Expect(zones("fd00::/8")).Should(Equal([]string{"d.f.ip6.arpa"}))
+			Expect(zones("2001:db8::/32")).Should(Equal([]string{"8.b.d.0.1.0.0.2.ip6.arpa"}))
+		})
+
+		It("expands other prefixes to the next nibble boundary", func() {
+			Expect(zones("fc00::/7")).Should(Equal([]string{"c.f.ip6.arpa", "d.f.ip6.arpa"}))
+		})
+	})
+})
@@ -0,0 +1,196 @@
+package xdp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// registers of the eBPF instruction set: r0 is the return value, r1-r5 are the arguments of calls and are clobbered
+// by them, r6-r9 are preserved by calls and r10 is the read-only frame pointer
+const (
+	r0 uint8 = iota
+	r1
+	r2
+	r3
+	r4
+	r5
+	r6
+	r7
+	r8
+	r9
+	r10
+)
+
+// sizes of memory accesses
+const (
+	sizeB  = unix.BPF_B
+	sizeH  = unix.BPF_H
+	sizeW  = unix.BPF_W
+	sizeDW = unix.BPF_DW
+)
+
+const (
+	insnSize = 8
+	// class, mode and size of the instruction, which loads a 64 bit immediate and occupies two instructions
+	ldImm64 = unix.BPF_LD | unix.BPF_IMM | unix.BPF_DW
+)
+
+// instruction is an eBPF instruction, a jump refers to the label of its target
+type instruction struct {
+	code  uint8
+	dst   uint8
+	src   uint8
+	off   int16
+	imm   int64
+	label string
+}
+
+// slots returns the count of instructions in the encoded program
+func (i *instruction) slots() int {
+	if i.code == ldImm64 {
+		return 2 //nolint:mnd
+	}
+
+	return 1
+}
+
+// registers returns the byte with the registers, the order of the bit fields depends on the byte order
+func (i *instruction) registers() uint8 {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+		return i.src<<4 | i.dst //nolint:mnd
+	}
+
+	return i.dst<<4 | i.src //nolint:mnd
+}
+
+// assembler collects the instructions of a program and resolves the labels of the jumps
+type assembler struct {
+	insns []instruction
+	// labels and the index of the instruction, they refer to
+	labels map[string]int
+}
+
+func newAssembler() *assembler {
+	return &assembler{labels: make(map[string]int)}
+}
+
+// label marks the position of the next instruction
+func (a *assembler) label(name string) {
+	a.labels[name] = len(a.insns)
+}
+
+func (a *assembler) emit(insn instruction) {
+	a.insns = append(a.insns, insn)
+}
+
+// mov: dst = imm
+func (a *assembler) mov(dst uint8, imm int32) {
+	a.alu(unix.BPF_MOV, dst, imm)
+}
+
+// movReg: dst = src
+func (a *assembler) movReg(dst, src uint8) {
+	a.aluReg(unix.BPF_MOV, dst, src)
+}
+
+// alu: dst = dst op imm (64 bit)
+func (a *assembler) alu(op uint8, dst uint8, imm int32) {
+	a.emit(instruction{code: unix.BPF_ALU64 | op | unix.BPF_K, dst: dst, imm: int64(imm)})
+}
+
+// aluReg: dst = dst op src (64 bit)
+func (a *assembler) aluReg(op uint8, dst, src uint8) {
+	a.emit(instruction{code: unix.BPF_ALU64 | op | unix.BPF_X, dst: dst, src: src})
+}
+
+// toBigEndian converts the lower `bits` of dst between host and network byte order and clears the other bits
+func (a *assembler) toBigEndian(dst uint8, bits int32) {
+	a.emit(instruction{code: unix.BPF_ALU | unix.BPF_END | unix.BPF_TO_BE, dst: dst, imm: int64(bits)})
+}
+
+// load: dst = *(size *)(src + off)
+func (a *assembler) load(size uint8, dst, src uint8, off int16) {
+	a.emit(instruction{code: unix.BPF_LDX | unix.BPF_MEM | size, dst: dst, src: src, off: off})
+}
+
+// store: *(size *)(dst + off) = src
+func (a *assembler) store(size uint8, dst uint8, off int16, src uint8) {
+	a.emit(instruction{code: unix.BPF_STX | unix.BPF_MEM | size, dst: dst, src: src, off: off})
+}
+
+// storeImm: *(size *)(dst + off) = imm
+func (a *assembler) storeImm(size uint8, dst uint8, off int16, imm int32) {
+	a.emit(instruction{code: unix.BPF_ST | unix.BPF_MEM | size, dst: dst, off: off, imm: int64(imm)})
+}
+
+// loadMap: dst = map with the file descriptor
+func (a *assembler) loadMap(dst uint8, fd int) {
+	a.emit(instruction{code: ldImm64, dst: dst, src: unix.BPF_PSEUDO_MAP_FD, imm: int64(fd)})
+}
+
+// jump: if dst op imm goto label (64 bit compare)
+func (a *assembler) jump(op uint8, dst uint8, imm int32, label string) {
+	a.emit(instruction{code: unix.BPF_JMP | op | unix.BPF_K, dst: dst, imm: int64(imm), label: label})
+}
+
+// jumpReg: if dst op src goto label (64 bit compare)
+func (a *assembler) jumpReg(op uint8, dst, src uint8, label string) {
+	a.emit(instruction{code: unix.BPF_JMP | op | unix.BPF_X, dst: dst, src: src, label: label})
+}
+
+// call calls the helper function
+func (a *assembler) call(helper int32) {
+	a.emit(instruction{code: unix.BPF_JMP | unix.BPF_CALL, imm: int64(helper)})
+}
+
+// exit returns r0
+func (a *assembler) exit() {
+	a.emit(instruction{code: unix.BPF_JMP | unix.BPF_EXIT})
+}
+
+// assemble encodes the instructions in host byte order
+func (a *assembler) assemble() ([]byte, error) {
+	// position of each instruction in the encoded program
+	positions := make([]int, len(a.insns)+1)
+	for i := range a.insns {
+		positions[i+1] = positions[i] + a.insns[i].slots()
+	}
+
+	code := make([]byte, 0, positions[len(a.insns)]*insnSize)
+
+	for i, insn := range a.insns {
+		off := insn.off
+
+		if insn.label != "" {
+			target, ok := a.labels[insn.label]
+			if !ok {
+				return nil, fmt.Errorf("unknown label '%s'", insn.label)
+			}
+
+			off = int16(positions[target] - positions[i] - 1)
+		}
+
+		code = append(code, insn.code, insn.registers())
+		code = binary.NativeEndian.AppendUint16(code, uint16(off))
+		code = binary.NativeEndian.AppendUint32(code, uint32(insn.imm))
+
+		if insn.code == ldImm64 {
+			code = append(code, 0, 0, 0, 0)
+			code = binary.NativeEndian.AppendUint32(code, uint32(insn.imm>>32)) //nolint:mnd
+		}
+	}
+
+	return code, nil
+}
+
+// networkOrder16 returns the value, which is loaded from memory with the bytes of v in network byte order
+func networkOrder16(v uint16) int32 {
+	return int32(binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, v)))
+}
+
+// networkOrder32 is networkOrder16 for 32 bit values
+func networkOrder32(v uint32) int32 {
+	return int32(binary.NativeEndian.Uint32(binary.BigEndian.AppendUint32(nil, v)))
+}
@@ -0,0 +1,294 @@
+package xdp
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// the size of the verifier log, which is requested if the program is rejected
+	verifierLogSize = 1 << 20
+	// the end of the verifier log, which is returned in the error
+	verifierLogTail = 2048
+	// license of the program, it doesn't use helpers which require the GPL
+	license = "Apache-2.0"
+)
+
+// attributes of the bpf syscall: the layout of the structs matches `union bpf_attr` of the kernel
+
+type mapCreateAttr struct {
+	mapType    uint32
+	keySize    uint32
+	valueSize  uint32
+	maxEntries uint32
+	flags      uint32
+	innerMapFd uint32
+	numaNode   uint32
+	name       [unix.BPF_OBJ_NAME_LEN]byte
+}
+
+type mapElemAttr struct {
+	mapFd uint32
+	_     uint32
+	key   uint64
+	value uint64 // or the next key
+	flags uint64
+}
+
+type progLoadAttr struct {
+	progType           uint32
+	insnCount          uint32
+	insns              uint64
+	license            uint64
+	logLevel           uint32
+	logSize            uint32
+	logBuf             uint64
+	kernVersion        uint32
+	flags              uint32
+	name               [unix.BPF_OBJ_NAME_LEN]byte
+	ifIndex            uint32
+	expectedAttachType uint32
+}
+
+type testRunAttr struct {
+	progFd      uint32
+	retval      uint32
+	dataSizeIn  uint32
+	dataSizeOut uint32
+	dataIn      uint64
+	dataOut     uint64
+	repeat      uint32
+	duration    uint32
+	ctxSizeIn   uint32
+	ctxSizeOut  uint32
+	ctxIn       uint64
+	ctxOut      uint64
+	flags       uint32
+	cpu         uint32
+}
+
+type linkCreateAttr struct {
+	progFd      uint32
+	targetIfIdx uint32
+	attachType  uint32
+	flags       uint32
+	targetBTFID uint32
+	_           uint32
+	_           [2]uint64
+}
+
+func bpf[T any](cmd uintptr, attr *T) (int, error) {
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, cmd, uintptr(unsafe.Pointer(attr)), unsafe.Sizeof(*attr))
+	runtime.KeepAlive(attr)
+
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return int(fd), nil
+}
+
+func pointer[T any](p *T) uint64 {
+	return uint64(uintptr(unsafe.Pointer(p)))
+}
+
+func objectName(name string) (result [unix.BPF_OBJ_NAME_LEN]byte) {
+	copy(result[:unix.BPF_OBJ_NAME_LEN-1], name)
+
+	return result
+}
+
+// bpfMap is a hash map of the kernel
+type bpfMap struct {
+	fd        int
+	keySize   int
+	valueSize int
+}
+
+func newMap(name string, keySize, valueSize, maxEntries uint32) (*bpfMap, error) {
+	fd, err := bpf(unix.BPF_MAP_CREATE, &mapCreateAttr{
+		mapType:    unix.BPF_MAP_TYPE_HASH,
+		keySize:    keySize,
+		valueSize:  valueSize,
+		maxEntries: maxEntries,
+		name:       objectName(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create BPF map %s: %w", name, err)
+	}
+
+	return &bpfMap{fd: fd, keySize: int(keySize), valueSize: int(valueSize)}, nil
+}
+
+func (m *bpfMap) update(key, value []byte) error {
+	_, err := bpf(unix.BPF_MAP_UPDATE_ELEM, &mapElemAttr{
+		mapFd: uint32(m.fd),
+		key:   pointer(&key[0]),
+		value: pointer(&value[0]),
+		flags: unix.BPF_ANY,
+	})
+
+	runtime.KeepAlive(key)
+	runtime.KeepAlive(value)
+
+	return err
+}
+
+func (m *bpfMap) lookup(key, value []byte) error {
+	_, err := bpf(unix.BPF_MAP_LOOKUP_ELEM, &mapElemAttr{
+		mapFd: uint32(m.fd),
+		key:   pointer(&key[0]),
+		value: pointer(&value[0]),
+	})
+
+	runtime.KeepAlive(key)
+	runtime.KeepAlive(value)
+
+	return err
+}
+
+func (m *bpfMap) delete(key []byte) error {
+	_, err := bpf(unix.BPF_MAP_DELETE_ELEM, &mapElemAttr{
+		mapFd: uint32(m.fd),
+		key:   pointer(&key[0]),
+	})
+
+	runtime.KeepAlive(key)
+
+	return err
+}
+
+// keys returns all keys of the map
+func (m *bpfMap) keys() ([][]byte, error) {
+	var (
+		keys [][]byte
+		// without key, the first key is returned
+		key []byte
+	)
+
+	for {
+		next := make([]byte, m.keySize)
+		attr := mapElemAttr{mapFd: uint32(m.fd), value: pointer(&next[0])}
+
+		if key != nil {
+			attr.key = pointer(&key[0])
+		}
+
+		_, err := bpf(unix.BPF_MAP_GET_NEXT_KEY, &attr)
+
+		runtime.KeepAlive(key)
+		runtime.KeepAlive(next)
+
+		if errors.Is(err, unix.ENOENT) {
+			return keys, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, next)
+		key = next
+	}
+}
+
+func (m *bpfMap) close() error {
+	return unix.Close(m.fd)
+}
+
+// loadProgram loads the XDP program and returns its file descriptor. If the verifier rejects the program, the end of
+// its log is returned in the error.
+func loadProgram(code []byte) (int, error) {
+	licenseBytes := append([]byte(license), 0)
+
+	attr := progLoadAttr{
+		progType:  unix.BPF_PROG_TYPE_XDP,
+		insnCount: uint32(len(code) / insnSize),
+		insns:     pointer(&code[0]),
+		license:   pointer(&licenseBytes[0]),
+		name:      objectName("blocky_xdp"),
+	}
+
+	fd, err := bpf(unix.BPF_PROG_LOAD, &attr)
+
+	runtime.KeepAlive(code)
+	runtime.KeepAlive(licenseBytes)
+
+	if err == nil {
+		return fd, nil
+	}
+
+	if !errors.Is(err, unix.EACCES) && !errors.Is(err, unix.EINVAL) {
+		return 0, fmt.Errorf("can't load XDP program: %w", err)
+	}
+
+	logBuf := make([]byte, verifierLogSize)
+	attr.logLevel = 1
+	attr.logSize = verifierLogSize
+	attr.logBuf = pointer(&logBuf[0])
+
+	_, err = bpf(unix.BPF_PROG_LOAD, &attr)
+
+	runtime.KeepAlive(code)
+	runtime.KeepAlive(licenseBytes)
+	runtime.KeepAlive(logBuf)
+
+	verifierLog := strings.TrimRight(unix.ByteSliceToString(logBuf), "\n")
+	if len(verifierLog) > verifierLogTail {
+		verifierLog = verifierLog[len(verifierLog)-verifierLogTail:]
+	}
+
+	return 0, fmt.Errorf("XDP program rejected by the verifier: %w: %s", err, verifierLog)
+}
+
+// testRun runs the program with the frame and returns the result and the modified frame
+func testRun(progFd int, frame []byte) (uint32, []byte, error) {
+	out := make([]byte, 2*maxResponseSize+questionFrom)
+
+	attr := testRunAttr{
+		progFd:      uint32(progFd),
+		dataSizeIn:  uint32(len(frame)),
+		dataSizeOut: uint32(len(out)),
+		dataIn:      pointer(&frame[0]),
+		dataOut:     pointer(&out[0]),
+		repeat:      1,
+	}
+
+	_, err := bpf(unix.BPF_PROG_TEST_RUN, &attr)
+
+	runtime.KeepAlive(frame)
+	runtime.KeepAlive(out)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return attr.retval, out[:attr.dataSizeOut], nil
+}
+
+// attach attaches the program to the network interface and returns the file descriptor of the link, which detaches
+// the program when it is closed. The native mode of the driver is preferred, the generic mode is the fallback.
+func attach(progFd, ifIndex int) (int, error) {
+	var err error
+
+	for _, flags := range []uint32{unix.XDP_FLAGS_DRV_MODE, unix.XDP_FLAGS_SKB_MODE} {
+		var fd int
+
+		fd, err = bpf(unix.BPF_LINK_CREATE, &linkCreateAttr{
+			progFd:      uint32(progFd),
+			targetIfIdx: uint32(ifIndex),
+			attachType:  unix.BPF_XDP,
+			flags:       flags,
+		})
+		if err == nil {
+			return fd, nil
+		}
+	}
+
+	return 0, err
+}
@@ -0,0 +1,151 @@
+package xdp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// size of the key: the question section of the query, padded with zeros
+	keySize = 128
+	// maximum size of a DNS message over UDP without EDNS
+	maxResponseSize = 512
+	// maximum count of answers, the TTLs are adjusted to the remaining cache time
+	maxTTLOffsets = 16
+
+	// layout of the value: expiry (monotonic clock in ns), length of the response, count and offsets of the answer
+	// TTLs and the response
+	valueExpiry     = 0
+	valueLength     = 8
+	valueTTLCount   = 10
+	valueTTLOffsets = 12
+	valueResponse   = 48
+	valueSize       = valueResponse + maxResponseSize
+
+	dnsHeaderSize = 12
+	// type and class of a question
+	questionFixedSize = 4
+	// type, class, TTL and data length of a resource record
+	rrFixedSize = 10
+	rrTTLOffset = 4
+)
+
+// entry is a cached answer in the format of the XDP program
+type entry struct {
+	key   [keySize]byte
+	value [valueSize]byte
+}
+
+// newEntry converts the cached response, which expires at `expiry` (monotonic clock in ns), into an entry.
+// The name of the question is lowercase: queries with other cases are answered by the resolvers.
+func newEntry(response *dns.Msg, expiry uint64) (*entry, error) {
+	if len(response.Question) != 1 {
+		return nil, errors.New("only responses with one question are supported")
+	}
+
+	if len(response.Answer) > maxTTLOffsets {
+		return nil, fmt.Errorf("response has more than %d answers", maxTTLOffsets)
+	}
+
+	msg := response.Copy()
+	msg.Id = 0
+	msg.Compress = true
+
+	qName := strings.ToLower(msg.Question[0].Name)
+	msg.Question[0].Name = qName
+
+	minTTL := uint32(math.MaxInt32)
+	for _, rr := range msg.Answer {
+		minTTL = min(minTTL, rr.Header().Ttl)
+	}
+
+	for _, rr := range msg.Answer {
+		header := rr.Header()
+
+		if strings.EqualFold(header.Name, qName) {
+			header.Name = qName
+		}
+
+		// the XDP program adds the remaining cache time, like the caching resolver
+		header.Ttl -= minTTL
+	}
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(packed) > maxResponseSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxResponseSize)
+	}
+
+	_, questionEnd, err := dns.UnpackDomainName(packed, dnsHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+
+	questionEnd += questionFixedSize
+
+	if questionEnd-dnsHeaderSize > keySize {
+		return nil, fmt.Errorf("question exceeds %d bytes", keySize)
+	}
+
+	offsets, err := answerTTLOffsets(packed, questionEnd, len(msg.Answer))
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(entry)
+	copy(e.key[:], packed[dnsHeaderSize:questionEnd])
+
+	binary.NativeEndian.PutUint64(e.value[valueExpiry:], expiry)
+	binary.NativeEndian.PutUint16(e.value[valueLength:], uint16(len(packed)))
+	e.value[valueTTLCount] = byte(len(offsets))
+
+	for i, offset := range offsets {
+		binary.NativeEndian.PutUint16(e.value[valueTTLOffsets+2*i:], offset)
+	}
+
+	copy(e.value[valueResponse:], packed)
+
+	return e, nil
+}
+
+// answerTTLOffsets returns the offsets of the TTLs of the answers, which start at `offset` in the packed message
+func answerTTLOffsets(packed []byte, offset, count int) ([]uint16, error) {
+	offsets := make([]uint16, 0, count)
+
+	for range count {
+		_, end, err := dns.UnpackDomainName(packed, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if end+rrFixedSize > len(packed) {
+			return nil, errors.New("truncated resource record")
+		}
+
+		offsets = append(offsets, uint16(end+rrTTLOffset))
+
+		offset = end + rrFixedSize + int(binary.BigEndian.Uint16(packed[end+rrFixedSize-2:]))
+	}
+
+	return offsets, nil
+}
+
+// keyName returns the name of the question of the key
+func keyName(key []byte) (string, error) {
+	name, _, err := dns.UnpackDomainName(key, 0)
+
+	return name, err
+}
+
+// entryExpiry returns the expiry of the value (monotonic clock in ns)
+func entryExpiry(value []byte) uint64 {
+	return binary.NativeEndian.Uint64(value[valueExpiry:])
+}
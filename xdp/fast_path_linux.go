@@ -0,0 +1,345 @@
+package xdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	"github.com/0xERR0R/blocky/log"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// interval of the removal of expired entries, they aren't answered after their expiry
+	cleanUpInterval = time.Minute
+	// maximum count of addresses, queries to other addresses are not answered
+	maxAddresses = 256
+)
+
+// FastPath answers cached UDP queries with an XDP program, which is attached to network interfaces
+type FastPath struct {
+	l *logrus.Entry
+
+	// protects the file descriptors, which are closed with the context
+	lock      sync.RWMutex
+	closed    bool
+	entries   *bpfMap
+	addresses *bpfMap
+	progFd    int
+	links     []int
+}
+
+// New loads the XDP program and attaches it to the configured interfaces. It answers queries to the port of the DNS
+// listeners and to their addresses, or the IPv4 addresses of the interfaces for listeners without address.
+// The program is detached when the context is done.
+func New(ctx context.Context, cfg *config.XDP, listen config.ListenConfig) (*FastPath, error) {
+	if cfg == nil || !cfg.IsEnabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	interfaces := make([]*net.Interface, 0, len(cfg.Interfaces))
+
+	for _, name := range cfg.Interfaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown network interface '%s': %w", name, err)
+		}
+
+		interfaces = append(interfaces, iface)
+	}
+
+	port, addresses, err := listenAddresses(listen, interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFastPath(cfg.MaxEntries, port, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		link, err := attach(f.progFd, iface.Index)
+		if err != nil {
+			f.close()
+
+			return nil, fmt.Errorf("can't attach XDP program to '%s': %w", iface.Name, err)
+		}
+
+		f.links = append(f.links, link)
+	}
+
+	f.subscribeChanges(ctx)
+
+	go f.run(ctx)
+
+	return f, nil
+}
+
+// listenAddresses returns the port of the listeners and the IPv4 addresses, which are answered
+func listenAddresses(listen config.ListenConfig, interfaces []*net.Interface) (uint16, []net.IP, error) {
+	var (
+		port        string
+		addresses   []net.IP
+		unspecified bool
+	)
+
+	for _, address := range listen {
+		host, p, err := net.SplitHostPort(address)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if port != "" && port != p {
+			return 0, nil, errors.New("the DNS listeners use different ports")
+		}
+
+		port = p
+
+		ip := net.ParseIP(host)
+
+		switch {
+		case host == "" || (ip != nil && ip.IsUnspecified()):
+			unspecified = true
+		case ip != nil && ip.To4() != nil:
+			addresses = append(addresses, ip.To4())
+		}
+	}
+
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid DNS port '%s': %w", port, err)
+	}
+
+	if unspecified {
+		for _, iface := range interfaces {
+			ifaceAddresses, err := iface.Addrs()
+			if err != nil {
+				return 0, nil, err
+			}
+
+			for _, address := range ifaceAddresses {
+				if ipNet, ok := address.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+					addresses = append(addresses, ipNet.IP.To4())
+				}
+			}
+		}
+	}
+
+	if len(addresses) == 0 {
+		return 0, nil, errors.New("no IPv4 address to answer queries")
+	}
+
+	return uint16(portNumber), addresses, nil
+}
+
+// newFastPath creates the maps and loads the program, without attaching it
+func newFastPath(maxEntries uint32, port uint16, addresses []net.IP) (*FastPath, error) {
+	f := &FastPath{l: log.PrefixedLog("xdp")}
+
+	var err error
+
+	f.entries, err = newMap("blocky_entries", keySize, valueSize, maxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	f.addresses, err = newMap("blocky_addresses", net.IPv4len, 1, maxAddresses)
+	if err != nil {
+		f.close()
+
+		return nil, err
+	}
+
+	for _, address := range addresses {
+		if err := f.addresses.update(address.To4(), []byte{1}); err != nil {
+			f.close()
+
+			return nil, fmt.Errorf("can't add address %s: %w", address, err)
+		}
+	}
+
+	code, err := program(port, f.entries.fd, f.addresses.fd)
+	if err == nil {
+		f.progFd, err = loadProgram(code)
+	}
+
+	if err != nil {
+		f.close()
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// subscribeChanges flushes the entries, if the blocking status, the lists or the configuration change:
+// the program doesn't check, if the domains are blocked
+func (f *FastPath) subscribeChanges(ctx context.Context) {
+	flush := func(_ ...any) {
+		f.Flush()
+	}
+
+	topics := []string{evt.BlockingEnabledEvent, evt.BlockingCacheGroupChanged, evt.ConfigReloaded}
+
+	for _, topic := range topics {
+		if err := evt.Bus().Subscribe(topic, flush); err != nil {
+			f.l.Errorf("can't subscribe to %s: %v", topic, err)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		for _, topic := range topics {
+			_ = evt.Bus().Unsubscribe(topic, flush)
+		}
+	}()
+}
+
+// run removes the expired entries until the context is done, then the program is detached
+func (f *FastPath) run(ctx context.Context) {
+	ticker := time.NewTicker(cleanUpInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.removeExpired()
+		case <-ctx.Done():
+			f.close()
+
+			return
+		}
+	}
+}
+
+// Put adds the cached response, which expires after the TTL. Responses, which the program can't answer, e.g.
+// with more than 512 bytes, are skipped.
+func (f *FastPath) Put(response *dns.Msg, ttl time.Duration) {
+	e, err := newEntry(response, monotonicNow()+uint64(ttl.Nanoseconds()))
+	if err != nil {
+		f.l.Tracef("skipping response: %v", err)
+
+		return
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.closed {
+		return
+	}
+
+	// fails if the map is full
+	if err := f.entries.update(e.key[:], e.value[:]); err != nil {
+		f.l.Debugf("can't add response: %v", err)
+	}
+}
+
+// FlushZone removes the entries of the zone and its sub domains
+func (f *FastPath) FlushZone(zone string) {
+	zone = dns.Fqdn(zone)
+
+	f.deleteFunc(func(key, _ []byte) bool {
+		name, err := keyName(key)
+
+		return err != nil || dns.IsSubDomain(zone, name)
+	}, false)
+
+	f.l.Debugf("flushed zone '%s'", util.Obfuscate(zone))
+}
+
+// Flush removes all entries
+func (f *FastPath) Flush() {
+	f.deleteFunc(func(_, _ []byte) bool {
+		return true
+	}, false)
+}
+
+func (f *FastPath) removeExpired() {
+	now := monotonicNow()
+
+	f.deleteFunc(func(_, value []byte) bool {
+		return entryExpiry(value) <= now
+	}, true)
+}
+
+// deleteFunc deletes the entries, for which the function returns true. The value is only passed, if withValue is set.
+func (f *FastPath) deleteFunc(fn func(key, value []byte) bool, withValue bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.closed {
+		return
+	}
+
+	keys, err := f.entries.keys()
+	if err != nil {
+		f.l.Warnf("can't read keys: %v", err)
+
+		return
+	}
+
+	value := make([]byte, valueSize)
+
+	for _, key := range keys {
+		if withValue {
+			if err := f.entries.lookup(key, value); err != nil {
+				// deleted in the meantime
+				continue
+			}
+		}
+
+		if fn(key, value) {
+			// the entry may have been deleted in the meantime
+			_ = f.entries.delete(key)
+		}
+	}
+}
+
+// close detaches the program and releases the maps
+func (f *FastPath) close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	f.closed = true
+
+	for _, link := range f.links {
+		_ = unix.Close(link)
+	}
+
+	if f.progFd != 0 {
+		_ = unix.Close(f.progFd)
+	}
+
+	if f.addresses != nil {
+		_ = f.addresses.close()
+	}
+
+	if f.entries != nil {
+		_ = f.entries.close()
+	}
+}
+
+// monotonicNow returns the time of the monotonic clock in ns, which is used by `bpf_ktime_get_ns`
+func monotonicNow() uint64 {
+	var ts unix.Timespec
+
+	_ = unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts)
+
+	return uint64(ts.Nano())
+}
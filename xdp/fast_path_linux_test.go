@@ -0,0 +1,562 @@
+package xdp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/0xERR0R/blocky/evt"
+	. "github.com/0xERR0R/blocky/helpertest"
+	"github.com/0xERR0R/blocky/util"
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	dnsPort    = 53
+	clientPort = 40000
+)
+
+//nolint:gochecknoglobals
+var (
+	serverIP  = net.IPv4(10, 0, 0, 1).To4()
+	clientIP  = net.IPv4(10, 0, 0, 2).To4()
+	serverMAC = []byte{2, 0, 0, 0, 0, 1}
+	clientMAC = []byte{2, 0, 0, 0, 0, 2}
+)
+
+// newFrame returns an Ethernet frame with the UDP packet of the client
+func newFrame(dstIP net.IP, dstPort uint16, payload []byte) []byte {
+	frame := append(append([]byte{}, serverMAC...), clientMAC...)
+	frame = binary.BigEndian.AppendUint16(frame, ethTypeIPv4)
+
+	ip := make([]byte, ipHeaderLen)
+	ip[0] = ipVersionIHL >> 8
+	binary.BigEndian.PutUint16(ip[2:], uint16(ipHeaderLen+udpHeaderLen+len(payload)))
+	ip[8] = 64
+	ip[9] = unix.IPPROTO_UDP
+	copy(ip[12:], clientIP)
+	copy(ip[16:], dstIP)
+	binary.BigEndian.PutUint16(ip[10:], ^checksum(ip))
+
+	frame = append(frame, ip...)
+	frame = binary.BigEndian.AppendUint16(frame, clientPort)
+	frame = binary.BigEndian.AppendUint16(frame, dstPort)
+	frame = binary.BigEndian.AppendUint16(frame, uint16(udpHeaderLen+len(payload)))
+	frame = binary.BigEndian.AppendUint16(frame, 0)
+
+	return append(frame, payload...)
+}
+
+// checksum returns the one's complement sum of the header
+func checksum(header []byte) uint16 {
+	var sum uint32
+
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+
+	return uint16(sum)
+}
+
+func newQuery(name string, qType dns.Type) *dns.Msg {
+	query := util.NewMsgWithQuestion(name, qType)
+	query.Id = 0x1234
+
+	return query
+}
+
+func pack(msg *dns.Msg) []byte {
+	packed, err := msg.Pack()
+	Expect(err).Should(Succeed())
+
+	return packed
+}
+
+func newResponse(query *dns.Msg, answers ...string) *dns.Msg {
+	response := new(dns.Msg)
+	response.SetReply(query)
+	response.RecursionAvailable = true
+
+	for _, answer := range answers {
+		rr, err := dns.NewRR(answer)
+		Expect(err).Should(Succeed())
+
+		response.Answer = append(response.Answer, rr)
+	}
+
+	return response
+}
+
+var _ = Describe("FastPath", func() {
+	var sut *FastPath
+
+	BeforeEach(func() {
+		var err error
+
+		sut, err = newFastPath(100, dnsPort, []net.IP{serverIP})
+		if errors.Is(err, unix.EPERM) {
+			Skip("loading BPF programs is not permitted")
+		}
+
+		Expect(err).Should(Succeed())
+		DeferCleanup(sut.close)
+	})
+
+	run := func(frame []byte) (uint32, []byte) {
+		result, out, err := testRun(sut.progFd, frame)
+		Expect(err).Should(Succeed())
+
+		return result, out
+	}
+
+	answer := func(query *dns.Msg) *dns.Msg {
+		result, out := run(newFrame(serverIP, dnsPort, pack(query)))
+		Expect(result).Should(BeEquivalentTo(xdpTX))
+
+		response := new(dns.Msg)
+		Expect(response.Unpack(out[dnsStart:])).Should(Succeed())
+
+		return response
+	}
+
+	expectPass := func(frame []byte) {
+		result, out := run(frame)
+		Expect(result).Should(BeEquivalentTo(xdpPass))
+		Expect(out).Should(Equal(frame))
+	}
+
+	Describe("answers", func() {
+		It("should answer a cached query", func() {
+			query := newQuery("example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 300*time.Second)
+
+			result, out := run(newFrame(serverIP, dnsPort, pack(query)))
+			Expect(result).Should(BeEquivalentTo(xdpTX))
+
+			By("swapping the addresses", func() {
+				Expect(out[ethDst:ethSrc]).Should(Equal(clientMAC))
+				Expect(out[ethSrc:ethType]).Should(Equal(serverMAC))
+				Expect(net.IP(out[ipSrc:ipDst])).Should(Equal(serverIP))
+				Expect(net.IP(out[ipDst:udpStart])).Should(Equal(clientIP))
+				Expect(binary.BigEndian.Uint16(out[udpSrcPort:])).Should(BeEquivalentTo(dnsPort))
+				Expect(binary.BigEndian.Uint16(out[udpDstPort:])).Should(BeEquivalentTo(clientPort))
+			})
+
+			By("setting the lengths and the IP checksum", func() {
+				Expect(binary.BigEndian.Uint16(out[ipTotalLen:])).Should(BeEquivalentTo(len(out) - ipStart))
+				Expect(binary.BigEndian.Uint16(out[udpLen:])).Should(BeEquivalentTo(len(out) - udpStart))
+				Expect(checksum(out[ipStart:udpStart])).Should(BeEquivalentTo(0xffff))
+			})
+
+			response := new(dns.Msg)
+			Expect(response.Unpack(out[dnsStart:])).Should(Succeed())
+			Expect(response.Id).Should(Equal(query.Id))
+			Expect(response.Response).Should(BeTrue())
+			Expect(response.RecursionDesired).Should(BeTrue())
+			Expect(response.Question).Should(Equal(query.Question))
+			Expect(response.Answer).Should(HaveExactElements(BeDNSRecord("example.com.", dns.Type(dns.TypeA), "1.2.3.4")))
+			Expect(response).Should(HaveTTL(BeNumerically("~", 300, 1)))
+		})
+
+		It("should use the remaining cache time as TTL, like the caching resolver", func() {
+			query := newQuery("example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query,
+				"example.com. 600 IN CNAME www.example.com.",
+				"www.example.com. 300 IN A 1.2.3.4",
+			), 100*time.Second)
+
+			response := answer(query)
+			Expect(response.Answer).Should(HaveLen(2))
+			Expect(response.Answer[0].Header().Ttl).Should(BeNumerically("~", 400, 1))
+			Expect(response.Answer[1].Header().Ttl).Should(BeNumerically("~", 100, 1))
+		})
+
+		It("should copy the RD and CD flags of the query", func() {
+			query := newQuery("example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 300*time.Second)
+
+			query.RecursionDesired = false
+			query.CheckingDisabled = true
+
+			response := answer(query)
+			Expect(response.RecursionDesired).Should(BeFalse())
+			Expect(response.CheckingDisabled).Should(BeTrue())
+			Expect(response.RecursionAvailable).Should(BeTrue())
+		})
+
+		It("should answer cached NXDOMAIN responses", func() {
+			query := newQuery("missing.example.com.", dns.Type(dns.TypeA))
+			cached := newResponse(query)
+			cached.Rcode = dns.RcodeNameError
+			cached.Ns = []dns.RR{&dns.SOA{
+				Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 60},
+				Ns:  "ns.example.com.", Mbox: "admin.example.com.", Serial: 1, Minttl: 60,
+			}}
+			sut.Put(cached, 30*time.Minute)
+
+			response := answer(query)
+			Expect(response.Rcode).Should(Equal(dns.RcodeNameError))
+			Expect(response.Ns).Should(HaveLen(1))
+		})
+
+		It("should only answer queries with lowercase names", func() {
+			query := newQuery("Example.COM.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "Example.COM. 300 IN A 1.2.3.4"), 300*time.Second)
+
+			expectPass(newFrame(serverIP, dnsPort, pack(query)))
+
+			response := answer(newQuery("example.com.", dns.Type(dns.TypeA)))
+			Expect(response.Answer).Should(HaveExactElements(BeDNSRecord("example.com.", dns.Type(dns.TypeA), "1.2.3.4")))
+		})
+	})
+
+	Describe("passes", func() {
+		var query *dns.Msg
+
+		BeforeEach(func() {
+			query = newQuery("example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 300*time.Second)
+		})
+
+		It("should pass queries, which are not cached", func() {
+			expectPass(newFrame(serverIP, dnsPort, pack(newQuery("example.com.", dns.Type(dns.TypeAAAA)))))
+			expectPass(newFrame(serverIP, dnsPort, pack(newQuery("other.com.", dns.Type(dns.TypeA)))))
+		})
+
+		It("should pass queries with EDNS", func() {
+			query.SetEdns0(dns.DefaultMsgSize, false)
+
+			expectPass(newFrame(serverIP, dnsPort, pack(query)))
+		})
+
+		It("should pass queries to other ports and addresses", func() {
+			expectPass(newFrame(serverIP, 5353, pack(query)))
+			expectPass(newFrame(net.IPv4(10, 0, 0, 3).To4(), dnsPort, pack(query)))
+		})
+
+		It("should pass responses", func() {
+			expectPass(newFrame(serverIP, dnsPort, pack(newResponse(query, "example.com. 300 IN A 1.2.3.4"))))
+		})
+
+		It("should pass fragments", func() {
+			frame := newFrame(serverIP, dnsPort, pack(query))
+			frame[ipFragment] = 0x20
+
+			expectPass(frame)
+		})
+
+		It("should pass frames with trailing data", func() {
+			expectPass(append(newFrame(serverIP, dnsPort, pack(query)), 0, 0))
+		})
+
+		It("should pass other protocols", func() {
+			frame := newFrame(serverIP, dnsPort, pack(query))
+			frame[ipTTLProto+1] = unix.IPPROTO_TCP
+
+			expectPass(frame)
+
+			frame = newFrame(serverIP, dnsPort, pack(query))
+			binary.BigEndian.PutUint16(frame[ethType:], 0x86dd)
+
+			expectPass(frame)
+		})
+
+		It("should pass short frames", func() {
+			expectPass(newFrame(serverIP, dnsPort, []byte{0x12, 0x34}))
+		})
+
+		It("should pass expired entries", func() {
+			sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 0)
+
+			expectPass(newFrame(serverIP, dnsPort, pack(query)))
+		})
+	})
+
+	Describe("bounds checks", func() {
+		var (
+			query *dns.Msg
+			frame []byte
+		)
+
+		// setLengths sets the IP and UDP length, so that the headers are consistent with the frame
+		setLengths := func(frame []byte) []byte {
+			binary.BigEndian.PutUint16(frame[ipTotalLen:], uint16(len(frame)-ipStart))
+			binary.BigEndian.PutUint16(frame[udpLen:], uint16(len(frame)-udpStart))
+			binary.BigEndian.PutUint16(frame[ipChecksum:], 0)
+			binary.BigEndian.PutUint16(frame[ipChecksum:], ^checksum(frame[ipStart:udpStart]))
+
+			return frame
+		}
+
+		BeforeEach(func() {
+			query = newQuery("example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 300*time.Second)
+
+			frame = newFrame(serverIP, dnsPort, pack(query))
+
+			// the frame is answered without changes
+			result, _ := run(slices.Clone(frame))
+			Expect(result).Should(BeEquivalentTo(xdpTX))
+		})
+
+		It("should pass truncated frames", func() {
+			// the kernel runs the program only with an Ethernet header
+			for size := ipStart; size < len(frame); size++ {
+				expectPass(slices.Clone(frame[:size]))
+			}
+		})
+
+		It("should pass frames with truncated questions", func() {
+			for size := questionFrom; size < len(frame); size++ {
+				expectPass(setLengths(slices.Clone(frame[:size])))
+			}
+		})
+
+		It("should pass frames with inconsistent lengths", func() {
+			for _, length := range []int{0, udpHeaderLen, len(frame) - udpStart - 1, len(frame) - udpStart + 1, 0xffff} {
+				changed := slices.Clone(frame)
+				binary.BigEndian.PutUint16(changed[udpLen:], uint16(length))
+
+				expectPass(changed)
+			}
+
+			for _, length := range []int{0, ipHeaderLen, len(frame) - ipStart - 1, len(frame) - ipStart + 1, 0xffff} {
+				changed := slices.Clone(frame)
+				binary.BigEndian.PutUint16(changed[ipTotalLen:], uint16(length))
+
+				expectPass(changed)
+			}
+		})
+
+		It("should pass frames with IP options", func() {
+			// the options shift the UDP header, the program only supports the IP header without options
+			options := slices.Concat(frame[:udpStart], []byte{1, 1, 1, 0}, frame[udpStart:])
+			options[ipStart] = 0x46
+
+			expectPass(setLengths(options))
+		})
+
+		It("should pass fragments with an offset", func() {
+			changed := slices.Clone(frame)
+			binary.BigEndian.PutUint16(changed[ipFragment:], 1)
+
+			expectPass(changed)
+		})
+
+		It("should pass messages with other counts of records", func() {
+			// a query has one question and no answer, authority or additional records
+			counts := []struct {
+				offset int
+				value  uint16
+			}{
+				{dnsQDCount, 0}, {dnsQDCount, 2}, {dnsQDCount + 2, 1}, {dnsNSCount, 1}, {dnsNSCount + 2, 1},
+			}
+
+			for _, count := range counts {
+				changed := slices.Clone(frame)
+				binary.BigEndian.PutUint16(changed[count.offset:], count.value)
+
+				expectPass(changed)
+			}
+		})
+
+		It("should pass other opcodes", func() {
+			notify := newQuery("example.com.", dns.Type(dns.TypeA))
+			notify.Opcode = dns.OpcodeNotify
+
+			expectPass(newFrame(serverIP, dnsPort, pack(notify)))
+		})
+
+		It("should pass names, which exceed the key", func() {
+			label := strings.Repeat("a", 63)
+			long := newQuery(strings.Join([]string{label, label, label}, ".")+".com.", dns.Type(dns.TypeA))
+			Expect(len(pack(long)) - dnsHeaderSize).Should(BeNumerically(">", keySize))
+
+			expectPass(newFrame(serverIP, dnsPort, pack(long)))
+		})
+
+		It("should pass questions without name", func() {
+			expectPass(setLengths(append(slices.Clone(frame[:questionFrom]), 0, 0, 1)))
+		})
+	})
+
+	Describe("entries", func() {
+		var query *dns.Msg
+
+		BeforeEach(func() {
+			query = newQuery("www.example.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(query, "www.example.com. 300 IN A 1.2.3.4"), 300*time.Second)
+		})
+
+		It("should be removed on flush", func() {
+			sut.Flush()
+
+			expectPass(newFrame(serverIP, dnsPort, pack(query)))
+		})
+
+		It("should be removed by zone", func() {
+			sut.FlushZone("other.com")
+			answer(query)
+
+			sut.FlushZone("example.com")
+			expectPass(newFrame(serverIP, dnsPort, pack(query)))
+		})
+
+		It("should be removed after expiry", func() {
+			expired := newQuery("expired.com.", dns.Type(dns.TypeA))
+			sut.Put(newResponse(expired, "expired.com. 300 IN A 1.2.3.4"), 0)
+
+			Expect(sut.entries.keys()).Should(HaveLen(2))
+
+			sut.removeExpired()
+
+			Expect(sut.entries.keys()).Should(HaveLen(1))
+		})
+
+		It("should skip responses, which the program can't answer", func() {
+			large := newQuery("large.com.", dns.Type(dns.TypeTXT))
+			sut.Put(newResponse(large, `large.com. 300 IN TXT "`+strings.Repeat("a", 250)+`" "`+
+				strings.Repeat("b", 250)+`"`), 300*time.Second)
+
+			Expect(sut.entries.keys()).Should(HaveLen(1))
+		})
+
+		It("should be flushed if the blocking status changes", func(ctx context.Context) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sut.subscribeChanges(ctx)
+			evt.Bus().Publish(evt.BlockingEnabledEvent, true)
+
+			Expect(sut.entries.keys()).Should(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("New", func() {
+	It("should return nil if disabled", func(ctx context.Context) {
+		Expect(New(ctx, &config.XDP{}, config.ListenConfig{":53"})).Should(BeNil())
+	})
+
+	It("should fail for unknown interfaces", func(ctx context.Context) {
+		_, err := New(ctx, &config.XDP{Interfaces: []string{"unknown0"}, MaxEntries: 10}, config.ListenConfig{":53"})
+		Expect(err).Should(MatchError(ContainSubstring("unknown network interface 'unknown0'")))
+	})
+
+	It("should answer queries on the interface until the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		// no server listens on the address: only the XDP program answers
+		address := GetHostPort("127.0.0.1", 53530)
+
+		sut, err := New(ctx, &config.XDP{Interfaces: []string{"lo"}, MaxEntries: 10}, config.ListenConfig{address})
+		if errors.Is(err, unix.EPERM) {
+			Skip("attaching XDP programs is not permitted")
+		}
+
+		Expect(err).Should(Succeed())
+
+		query := newQuery("example.com.", dns.Type(dns.TypeA))
+		sut.Put(newResponse(query, "example.com. 300 IN A 1.2.3.4"), time.Minute)
+
+		client := &dns.Client{Timeout: time.Second}
+
+		response, _, err := client.Exchange(query, address)
+		Expect(err).Should(Succeed())
+		Expect(response.Answer).Should(HaveExactElements(BeDNSRecord("example.com.", dns.Type(dns.TypeA), "1.2.3.4")))
+
+		cancel()
+
+		Eventually(func() error {
+			_, _, err := client.Exchange(query, address)
+
+			return err
+		}).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("newEntry", func() {
+	It("should use the question as key", func() {
+		query := newQuery("example.com.", dns.Type(dns.TypeA))
+
+		e, err := newEntry(newResponse(query, "example.com. 300 IN A 1.2.3.4"), 1)
+		Expect(err).Should(Succeed())
+
+		packed := pack(query)
+		Expect(e.key[:len(packed)-dnsHeaderSize]).Should(Equal(packed[dnsHeaderSize:]))
+		Expect(e.key[len(packed)-dnsHeaderSize:]).Should(HaveEach(BeZero()))
+		Expect(keyName(e.key[:])).Should(Equal("example.com."))
+		Expect(entryExpiry(e.value[:])).Should(BeEquivalentTo(1))
+	})
+
+	It("should fail for responses with too many answers", func() {
+		query := newQuery("example.com.", dns.Type(dns.TypeA))
+		response := newResponse(query)
+
+		for i := range maxTTLOffsets + 1 {
+			rr, err := dns.NewRR(fmt.Sprintf("example.com. 300 IN A 10.0.0.%d", i))
+			Expect(err).Should(Succeed())
+
+			response.Answer = append(response.Answer, rr)
+		}
+
+		_, err := newEntry(response, 1)
+		Expect(err).Should(MatchError(ContainSubstring("more than 16 answers")))
+	})
+
+	It("should fail for responses without question", func() {
+		_, err := newEntry(new(dns.Msg), 1)
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("listenAddresses", func() {
+	var loopback *net.Interface
+
+	BeforeEach(func() {
+		var err error
+
+		loopback, err = net.InterfaceByName("lo")
+		if err != nil {
+			Skip("no loopback interface")
+		}
+	})
+
+	It("should use the addresses of the interfaces for listeners without address", func() {
+		port, addresses, err := listenAddresses(config.ListenConfig{":53", "[::]:53"}, []*net.Interface{loopback})
+		Expect(err).Should(Succeed())
+		Expect(port).Should(BeEquivalentTo(53))
+		Expect(addresses).Should(ContainElement(net.IPv4(127, 0, 0, 1).To4()))
+	})
+
+	It("should use the IPv4 addresses of the listeners", func() {
+		port, addresses, err := listenAddresses(config.ListenConfig{"10.0.0.1:5353", "[fd00::1]:5353"}, nil)
+		Expect(err).Should(Succeed())
+		Expect(port).Should(BeEquivalentTo(5353))
+		Expect(addresses).Should(Equal([]net.IP{serverIP}))
+	})
+
+	It("should fail for listeners with different ports", func() {
+		_, _, err := listenAddresses(config.ListenConfig{"10.0.0.1:53", "10.0.0.2:5353"}, nil)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should fail without IPv4 address", func() {
+		_, _, err := listenAddresses(config.ListenConfig{"[fd00::1]:53"}, nil)
+		Expect(err).Should(HaveOccurred())
+	})
+})
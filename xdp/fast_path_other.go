@@ -0,0 +1,32 @@
+//go:build !linux
+
+package xdp
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xERR0R/blocky/config"
+	"github.com/miekg/dns"
+)
+
+// FastPath is not supported on this platform, the queries are answered by the resolvers
+type FastPath struct{}
+
+// New fails if the fast path is enabled: XDP is only supported on Linux
+func New(_ context.Context, cfg *config.XDP, _ config.ListenConfig) (*FastPath, error) {
+	if cfg == nil || !cfg.IsEnabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	return nil, ErrNotSupported
+}
+
+// Put does nothing
+func (f *FastPath) Put(*dns.Msg, time.Duration) {}
+
+// FlushZone does nothing
+func (f *FastPath) FlushZone(string) {}
+
+// Flush does nothing
+func (f *FastPath) Flush() {}
@@ -0,0 +1,329 @@
+package xdp
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// return values of XDP programs
+const (
+	xdpAborted = 0
+	xdpPass    = 2
+	xdpTX      = 3
+)
+
+// helper functions of the kernel
+const (
+	helperMapLookupElem = 1
+	helperKtimeGetNs    = 5
+	helperXDPAdjustTail = 65
+)
+
+// offsets in the frame: Ethernet, IPv4 without options, UDP and DNS header
+const (
+	ethDst       = 0
+	ethSrc       = 6
+	ethType      = 12
+	ipStart      = 14
+	ipTotalLen   = 16
+	ipID         = 18
+	ipFragment   = 20
+	ipTTLProto   = 22
+	ipChecksum   = 24
+	ipSrc        = 26
+	ipDst        = 30
+	udpStart     = 34
+	udpSrcPort   = 34
+	udpDstPort   = 36
+	udpLen       = 38
+	udpChecksum  = 40
+	dnsStart     = 42
+	dnsID        = 42
+	dnsFlags1    = 44
+	dnsFlags2    = 45
+	dnsQDCount   = 46
+	dnsNSCount   = 50
+	questionFrom = dnsStart + dnsHeaderSize
+
+	ethAddrLen    = 6
+	ipAddrLen     = 4
+	ipHeaderLen   = 20
+	udpHeaderLen  = 8
+	ethTypeIPv4   = 0x0800
+	ipVersionIHL  = 0x4500 // version 4 without options, with type of service 0
+	ipFragMask    = 0x3fff // more fragments flag and fragment offset
+	ipTTLUDP      = 64<<8 | unix.IPPROTO_UDP
+	dnsQROpcode   = 0xf8
+	dnsRD         = 0x01
+	dnsCD         = 0x10
+	minQuestion   = 5 // root name, type and class
+	nsPerSecond   = 1_000_000_000
+	checksumMask  = 0xffff
+	checksumShift = 16
+)
+
+// offsets of the variables on the stack
+const (
+	stackKey       = -keySize
+	stackAddress   = stackKey - 8
+	stackQLen      = stackAddress - 8
+	stackRemaining = stackQLen - 8
+	stackID        = stackRemaining - 8
+	stackRD        = stackID - 8
+	stackCD        = stackRD - 8
+)
+
+// labels
+const (
+	labelPass    = "pass"
+	labelAbort   = "abort"
+	labelKeyDone = "keyDone"
+	labelCopied  = "copied"
+	labelTTLDone = "ttlDone"
+)
+
+// program returns the XDP program, which answers the queries to the port with the responses of the entries map.
+// The destination address of the queries must be in the addresses map.
+//
+// r6 holds the context, r7 and r8 the start and end of the frame and r9 the length of the question, later the entry.
+// The pointers into the frame are reloaded after the copy loops, so that the verifier can prune their branches.
+//
+//nolint:funlen,maintidx
+func program(port uint16, entriesFd, addressesFd int) ([]byte, error) {
+	a := newAssembler()
+
+	a.movReg(r6, r1)
+	loadFrame := func(minLen int32, label string) {
+		a.load(sizeW, r7, r6, 0)
+		a.load(sizeW, r8, r6, 4) //nolint:mnd
+		a.movReg(r1, r7)
+		a.alu(unix.BPF_ADD, r1, minLen)
+		a.jumpReg(unix.BPF_JGT, r1, r8, label)
+	}
+
+	// IPv4 UDP query to the port without fragments and IP options
+	loadFrame(questionFrom, labelPass)
+	a.load(sizeH, r1, r7, ethType)
+	a.jump(unix.BPF_JNE, r1, networkOrder16(ethTypeIPv4), labelPass)
+	a.load(sizeB, r1, r7, ipStart)
+	a.jump(unix.BPF_JNE, r1, ipVersionIHL>>8, labelPass)
+	a.load(sizeB, r1, r7, ipTTLProto+1)
+	a.jump(unix.BPF_JNE, r1, unix.IPPROTO_UDP, labelPass)
+	a.load(sizeH, r1, r7, ipFragment)
+	a.alu(unix.BPF_AND, r1, networkOrder16(ipFragMask))
+	a.jump(unix.BPF_JNE, r1, 0, labelPass)
+	a.load(sizeH, r1, r7, udpDstPort)
+	a.jump(unix.BPF_JNE, r1, networkOrder16(port), labelPass)
+
+	// standard query with one question and without other records, e.g. EDNS
+	a.load(sizeB, r1, r7, dnsFlags1)
+	a.alu(unix.BPF_AND, r1, dnsQROpcode)
+	a.jump(unix.BPF_JNE, r1, 0, labelPass)
+	a.load(sizeW, r1, r7, dnsQDCount)
+	a.jump(unix.BPF_JNE, r1, networkOrder32(1<<16), labelPass) //nolint:mnd
+	a.load(sizeW, r1, r7, dnsNSCount)
+	a.jump(unix.BPF_JNE, r1, 0, labelPass)
+
+	// the destination address is an address of blocky: forwarded queries are not answered
+	a.load(sizeW, r1, r7, ipDst)
+	a.store(sizeW, r10, stackAddress, r1)
+	a.loadMap(r1, addressesFd)
+	a.movReg(r2, r10)
+	a.alu(unix.BPF_ADD, r2, stackAddress)
+	a.call(helperMapLookupElem)
+	a.jump(unix.BPF_JEQ, r0, 0, labelPass)
+
+	// the question ends with the UDP packet and the IP packet: frames with padding or trailing data are not answered
+	a.load(sizeH, r9, r7, udpLen)
+	a.toBigEndian(r9, 16) //nolint:mnd
+	a.load(sizeH, r1, r7, ipTotalLen)
+	a.toBigEndian(r1, 16) //nolint:mnd
+	a.alu(unix.BPF_SUB, r1, ipHeaderLen)
+	a.jumpReg(unix.BPF_JNE, r1, r9, labelPass)
+	a.alu(unix.BPF_SUB, r9, udpHeaderLen+dnsHeaderSize)
+	a.jump(unix.BPF_JGT, r9, keySize, labelPass)
+	a.jump(unix.BPF_JLT, r9, minQuestion, labelPass)
+	a.movReg(r1, r7)
+	a.aluReg(unix.BPF_ADD, r1, r9)
+	a.alu(unix.BPF_ADD, r1, questionFrom)
+	a.jumpReg(unix.BPF_JGT, r1, r8, labelPass)
+	a.jumpReg(unix.BPF_JLT, r1, r8, labelPass)
+
+	// the key is the question, padded with zeros
+	for i := int16(0); i < keySize; i += 8 {
+		a.storeImm(sizeDW, r10, stackKey+i, 0)
+	}
+
+	for i := range int16(keySize) {
+		a.jump(unix.BPF_JLE, r9, int32(i), labelKeyDone)
+		a.movReg(r1, r7)
+		a.alu(unix.BPF_ADD, r1, int32(questionFrom+i+1))
+		a.jumpReg(unix.BPF_JGT, r1, r8, labelPass)
+		a.load(sizeB, r1, r7, questionFrom+i)
+		a.store(sizeB, r10, stackKey+i, r1)
+	}
+
+	a.label(labelKeyDone)
+	a.store(sizeDW, r10, stackQLen, r9)
+
+	// ID and flags of the query, which are copied into the response
+	loadFrame(questionFrom, labelPass)
+	a.load(sizeH, r1, r7, dnsID)
+	a.store(sizeDW, r10, stackID, r1)
+	a.load(sizeB, r1, r7, dnsFlags1)
+	a.alu(unix.BPF_AND, r1, dnsRD)
+	a.store(sizeDW, r10, stackRD, r1)
+	a.load(sizeB, r1, r7, dnsFlags2)
+	a.alu(unix.BPF_AND, r1, dnsCD)
+	a.store(sizeDW, r10, stackCD, r1)
+
+	a.loadMap(r1, entriesFd)
+	a.movReg(r2, r10)
+	a.alu(unix.BPF_ADD, r2, stackKey)
+	a.call(helperMapLookupElem)
+	a.jump(unix.BPF_JEQ, r0, 0, labelPass)
+	a.movReg(r9, r0)
+
+	// remaining cache time in seconds, expired entries are removed by blocky
+	a.call(helperKtimeGetNs)
+	a.load(sizeDW, r1, r9, valueExpiry)
+	a.jumpReg(unix.BPF_JGE, r0, r1, labelPass)
+	a.aluReg(unix.BPF_SUB, r1, r0)
+	a.alu(unix.BPF_DIV, r1, nsPerSecond)
+	a.store(sizeDW, r10, stackRemaining, r1)
+
+	// resize the frame: the response replaces the query
+	a.load(sizeH, r2, r9, valueLength)
+	a.load(sizeDW, r1, r10, stackQLen)
+	a.aluReg(unix.BPF_SUB, r2, r1)
+	a.alu(unix.BPF_SUB, r2, dnsHeaderSize)
+	a.movReg(r1, r6)
+	a.call(helperXDPAdjustTail)
+	a.jump(unix.BPF_JNE, r0, 0, labelPass)
+
+	// from here on, the frame is modified: errors abort
+	loadFrame(questionFrom, labelAbort)
+	a.load(sizeH, r3, r9, valueLength)
+	a.jump(unix.BPF_JGT, r3, maxResponseSize, labelAbort)
+
+	swap := func(off1, off2 int16) {
+		a.load(sizeH, r1, r7, off1)
+		a.load(sizeH, r2, r7, off2)
+		a.store(sizeH, r7, off1, r2)
+		a.store(sizeH, r7, off2, r1)
+	}
+
+	for i := int16(0); i < ethAddrLen; i += 2 {
+		swap(ethDst+i, ethSrc+i)
+	}
+
+	a.storeImm(sizeH, r7, ipStart, networkOrder16(ipVersionIHL))
+	a.movReg(r1, r3)
+	a.alu(unix.BPF_ADD, r1, ipHeaderLen+udpHeaderLen)
+	a.toBigEndian(r1, 16) //nolint:mnd
+	a.store(sizeH, r7, ipTotalLen, r1)
+	a.storeImm(sizeH, r7, ipID, 0)
+	a.storeImm(sizeH, r7, ipFragment, 0)
+	a.storeImm(sizeH, r7, ipTTLProto, networkOrder16(ipTTLUDP))
+	a.storeImm(sizeH, r7, ipChecksum, 0)
+
+	for i := int16(0); i < ipAddrLen; i += 2 {
+		swap(ipSrc+i, ipDst+i)
+	}
+
+	// the one's complement sum doesn't depend on the byte order
+	a.mov(r1, 0)
+
+	for i := int16(ipStart); i < udpStart; i += 2 {
+		a.load(sizeH, r2, r7, i)
+		a.aluReg(unix.BPF_ADD, r1, r2)
+	}
+
+	for range 2 {
+		a.movReg(r2, r1)
+		a.alu(unix.BPF_RSH, r2, checksumShift)
+		a.alu(unix.BPF_AND, r1, checksumMask)
+		a.aluReg(unix.BPF_ADD, r1, r2)
+	}
+
+	a.alu(unix.BPF_XOR, r1, checksumMask)
+	a.store(sizeH, r7, ipChecksum, r1)
+
+	swap(udpSrcPort, udpDstPort)
+	a.movReg(r1, r3)
+	a.alu(unix.BPF_ADD, r1, udpHeaderLen)
+	a.toBigEndian(r1, 16) //nolint:mnd
+	a.store(sizeH, r7, udpLen, r1)
+	// the UDP checksum is optional for IPv4
+	a.storeImm(sizeH, r7, udpChecksum, 0)
+
+	for i := range int16(maxResponseSize) {
+		a.jump(unix.BPF_JLE, r3, int32(i), labelCopied)
+		a.movReg(r1, r7)
+		a.alu(unix.BPF_ADD, r1, int32(dnsStart+i+1))
+		a.jumpReg(unix.BPF_JGT, r1, r8, labelAbort)
+		a.load(sizeB, r1, r9, valueResponse+i)
+		a.store(sizeB, r7, dnsStart+i, r1)
+	}
+
+	a.label(labelCopied)
+	loadFrame(questionFrom, labelAbort)
+
+	a.load(sizeDW, r1, r10, stackID)
+	a.store(sizeH, r7, dnsID, r1)
+
+	copyFlag := func(off int16, flag int32, stackOff int16) {
+		a.load(sizeB, r1, r7, off)
+		a.alu(unix.BPF_AND, r1, ^flag&0xff) //nolint:mnd
+		a.load(sizeDW, r2, r10, stackOff)
+		a.aluReg(unix.BPF_OR, r1, r2)
+		a.store(sizeB, r7, off, r1)
+	}
+
+	copyFlag(dnsFlags1, dnsRD, stackRD)
+	copyFlag(dnsFlags2, dnsCD, stackCD)
+
+	// TTL of the answers: the TTL relative to the minimum TTL plus the remaining cache time
+	a.load(sizeDW, r4, r10, stackRemaining)
+	a.load(sizeB, r5, r9, valueTTLCount)
+
+	for i := range int16(maxTTLOffsets) {
+		a.jump(unix.BPF_JLE, r5, int32(i), labelTTLDone)
+		a.load(sizeH, r1, r9, valueTTLOffsets+2*i)
+		a.jump(unix.BPF_JGT, r1, maxResponseSize-4, labelAbort) //nolint:mnd
+		a.movReg(r3, r7)
+		a.aluReg(unix.BPF_ADD, r3, r1)
+		a.alu(unix.BPF_ADD, r3, dnsStart)
+		a.movReg(r2, r3)
+		a.alu(unix.BPF_ADD, r2, 4) //nolint:mnd
+		a.jumpReg(unix.BPF_JGT, r2, r8, labelAbort)
+
+		a.load(sizeB, r2, r3, 0)
+
+		for j := int16(1); j < 4; j++ {
+			a.alu(unix.BPF_LSH, r2, 8) //nolint:mnd
+			a.load(sizeB, r1, r3, j)
+			a.aluReg(unix.BPF_OR, r2, r1)
+		}
+
+		a.aluReg(unix.BPF_ADD, r2, r4)
+
+		for j := int16(3); j >= 0; j-- {
+			a.store(sizeB, r3, j, r2)
+			a.alu(unix.BPF_RSH, r2, 8) //nolint:mnd
+		}
+	}
+
+	a.label(labelTTLDone)
+	a.mov(r0, xdpTX)
+	a.exit()
+
+	a.label(labelPass)
+	a.mov(r0, xdpPass)
+	a.exit()
+
+	a.label(labelAbort)
+	a.mov(r0, xdpAborted)
+	a.exit()
+
+	return a.assemble()
+}
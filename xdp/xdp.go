@@ -0,0 +1,12 @@
+// Package xdp answers cached UDP queries in the kernel with an XDP program (Linux only).
+//
+// The program is assembled at runtime and loaded via the bpf syscall, no BPF toolchain is needed. It answers IPv4
+// UDP queries with one question and without EDNS, if the question matches a cached answer exactly. All other packets
+// are passed to the network stack and answered by the resolvers. The cache entries are added by the caching resolver
+// and expire in the kernel with their TTL.
+package xdp
+
+import "errors"
+
+// ErrNotSupported is returned on platforms without XDP
+var ErrNotSupported = errors.New("XDP is only supported on Linux")
@@ -0,0 +1,18 @@
+package xdp
+
+import (
+	"testing"
+
+	"github.com/0xERR0R/blocky/log"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func init() {
+	log.Silence()
+}
+
+func TestXDP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "XDP Suite")
+}